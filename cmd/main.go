@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,10 +11,15 @@ import (
 	"syscall"
 
 	genapi "github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/api"
 	"github.com/USSTM/cv-backend/internal/config"
 	"github.com/USSTM/cv-backend/internal/container"
+	"github.com/USSTM/cv-backend/internal/database"
 	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/metrics"
 	appmiddleware "github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/storage"
 	"github.com/USSTM/cv-backend/internal/swagger"
 	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/go-chi/chi/v5"
@@ -21,7 +28,25 @@ import (
 )
 
 func main() {
+	validateOnly := flag.Bool("validate-config", false, "check the configuration from the environment, then exit; non-zero when it is invalid")
+	migrateOnly := flag.Bool("migrate", false, "apply pending database migrations, then exit")
+	flag.Parse()
+
+	// refuse to start on a bad configuration rather than failing on first use.
+	// Secrets come first so Load sees them.
+	if err := config.LoadSecrets(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *validateOnly {
+		fmt.Println("Configuration is valid")
+		return
+	}
 
 	// Initialize structured logging before anything else (so we can log errors)
 	if err := logging.Init(&cfg.Logging); err != nil {
@@ -30,8 +55,16 @@ func main() {
 	logging.Info("Logger initialized successfully",
 		"level", cfg.Logging.Level,
 		"format", cfg.Logging.Format,
+		"output", cfg.Logging.Output,
 		"filename", cfg.Logging.Filename)
 
+	if *migrateOnly {
+		if err := migrate(&cfg.Database); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+		return
+	}
+
 	// create container after (so we can log errors with structured logger)
 	c, err := container.New(*cfg)
 	if err != nil {
@@ -53,6 +86,22 @@ func main() {
 	// Add request context and logging middlewares AFTER CORS
 	r.Use(appmiddleware.RequestContext)
 	r.Use(appmiddleware.LoggingMiddleware)
+	r.Use(appmiddleware.Metrics)
+
+	// outside the API group: scrapes are never shed or timed out
+	r.Method(http.MethodGet, "/metrics", metrics.Handler(c.Config.Metrics.Token))
+
+	// presigned links of the filesystem storage backend; each carries its own
+	// signature, so they sit outside authentication too
+	if files, ok := c.Storage.(*storage.Filesystem); ok {
+		r.Handle(files.BasePath()+"*", files.Handler())
+	}
+
+	// SES bounce and complaint notifications, posted by SNS as text/plain
+	// and carrying a shared token in place of a user's credentials
+	if c.Config.Email.NotificationToken != "" {
+		r.Method(http.MethodPost, "/webhooks/ses", notifications.NewBounceHandler(c.Database.Queries(), c.Config.Email))
+	}
 
 	// group swagger ui routes away from actual API
 	r.Group(func(r chi.Router) {
@@ -65,15 +114,33 @@ func main() {
 
 	// authentication middleware and API
 	r.Group(func(r chi.Router) {
+		// Accept: text/csv becomes ?format=csv ahead of the timeout, which
+		// lets exports stream, and the validator
+		r.Use(appmiddleware.NegotiateExport)
+		// shed before anything else runs so refused requests cost nothing
+		r.Use(c.LoadShedder.Handler)
+		// outermost after shedding so authentication lookups share the request deadline
+		r.Use(appmiddleware.NewTimeoutHandler(&c.Config.Server))
 		r.Use(middleware.OapiRequestValidatorWithOptions(spec, &middleware.Options{
 			Options: openapi3filter.Options{
 				AuthenticationFunc: c.Authenticator.Authenticate,
 			},
+			ErrorHandler: api.ValidatorErrorHandler,
 		}))
 
-		// strict handler
-		strictHandler := genapi.NewStrictHandler(c.Server, nil)
-		genapi.HandlerFromMux(strictHandler, r)
+		// strict handler; every operation passes its authorization policy
+		// first, and the changes it lets through are audited. Errors from any
+		// layer share the one envelope, carrying the request ID
+		strictHandler := genapi.NewStrictHandlerWithOptions(c.Server,
+			[]genapi.StrictMiddlewareFunc{api.StampRequestID, api.Audit(c.Database), api.Authorize(c.Authenticator), api.ScopeLogger},
+			genapi.StrictHTTPServerOptions{
+				RequestErrorHandlerFunc:  api.RequestErrorHandler,
+				ResponseErrorHandlerFunc: api.ResponseErrorHandler,
+			})
+		genapi.HandlerWithOptions(strictHandler, genapi.ChiServerOptions{
+			BaseRouter:       r,
+			ErrorHandlerFunc: api.RequestErrorHandler,
+		})
 	})
 
 	addr := fmt.Sprintf("0.0.0.0:%s", cfg.Server.Port)
@@ -92,6 +159,23 @@ func main() {
 		os.Exit(0)
 	}()
 
+	// SIGHUP re-reads CONFIG_RELOAD_FILE; a bad file is logged and the
+	// running settings kept
+	go func() {
+		hupChan := make(chan os.Signal, 1)
+		signal.Notify(hupChan, syscall.SIGHUP)
+		for range hupChan {
+			if err := c.Reload(); err != nil {
+				logging.Error("Configuration reload failed", "error", err)
+			}
+		}
+	}()
+
+	if err := c.Events.Start(context.Background()); err != nil {
+		logging.Error("Event broker failed to start", "error", err)
+		log.Fatal(err)
+	}
+
 	logging.Info("Starting queue worker...")
 	if err := c.Worker.Start(); err != nil {
 		logging.Error("Worker failed to start", "error", err)
@@ -105,3 +189,20 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// applies the migrations embedded in the binary, for a release step run
+// before the new version starts
+func migrate(cfg *config.DatabaseConfig) error {
+	db, err := database.New(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	applied, err := db.Migrate(context.Background())
+	if err != nil {
+		return err
+	}
+	logging.Info("Database migrated", "applied", applied)
+	return nil
+}
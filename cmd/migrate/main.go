@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/database"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+)
+
+// where create writes new migrations, relative to the repository root
+const migrationsDir = "db/migrations"
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	if len(os.Args) < 2 {
+		printUsage()
+		return errors.New("command required")
+	}
+
+	var command func(context.Context, *goose.Provider, []string) error
+	switch os.Args[1] {
+	case "up":
+		command = upCommand
+	case "down":
+		command = downCommand
+	case "status":
+		command = statusCommand
+	case "redo":
+		command = redoCommand
+	case "create":
+		// only writes a file, so needs no database
+		return createCommand(os.Args[2:])
+	case "help", "--help", "-h":
+		printUsage()
+		return nil
+	default:
+		printUsage()
+		return fmt.Errorf("unknown command: %s", os.Args[1])
+	}
+
+	if err := config.LoadSecrets(context.Background()); err != nil {
+		return err
+	}
+	cfg := config.Load()
+	migrateDB, err := database.New(&cfg.Database)
+	if err != nil {
+		return fmt.Errorf("database connection failed: %w", err)
+	}
+	defer migrateDB.Close()
+
+	sqlDB := stdlib.OpenDBFromPool(migrateDB.Pool())
+	defer sqlDB.Close()
+	provider, err := database.NewMigrator(sqlDB)
+	if err != nil {
+		return err
+	}
+
+	return command(context.Background(), provider, os.Args[2:])
+}
+
+func upCommand(ctx context.Context, provider *goose.Provider, args []string) error {
+	results, err := provider.Up(ctx)
+	printResults(results)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Println("no pending migrations")
+	}
+	return nil
+}
+
+// rolls back the latest n migrations, one by default
+func downCommand(ctx context.Context, provider *goose.Provider, args []string) error {
+	n := 1
+	if len(args) > 0 {
+		var err error
+		n, err = strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid count %q: must be a positive number", args[0])
+		}
+	}
+	for i := 0; i < n; i++ {
+		result, err := provider.Down(ctx)
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			fmt.Println("no migrations left to roll back")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		printResults([]*goose.MigrationResult{result})
+	}
+	return nil
+}
+
+func statusCommand(ctx context.Context, provider *goose.Provider, args []string) error {
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.State == goose.StateApplied {
+			appliedAt = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%-19s  %s\n", appliedAt, status.Source.Path)
+	}
+	return nil
+}
+
+// rolls back the latest migration and applies it again, for iterating on a
+// migration that is still being written
+func redoCommand(ctx context.Context, provider *goose.Provider, args []string) error {
+	down, err := provider.Down(ctx)
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		return errors.New("no migration to redo")
+	}
+	if err != nil {
+		return err
+	}
+	printResults([]*goose.MigrationResult{down})
+
+	up, err := provider.UpByOne(ctx)
+	if err != nil {
+		return err
+	}
+	printResults([]*goose.MigrationResult{up})
+	return nil
+}
+
+func createCommand(args []string) error {
+	if len(args) != 1 || args[0] == "" {
+		return errors.New("usage: migrate create <name>")
+	}
+	return goose.Create(nil, migrationsDir, args[0], "sql")
+}
+
+func printResults(results []*goose.MigrationResult) {
+	for _, result := range results {
+		fmt.Println(result)
+	}
+}
+
+func printUsage() {
+	fmt.Println("Migrate - Manage the Campus Vault database schema")
+	fmt.Println()
+	fmt.Println("USAGE:")
+	fmt.Println("  migrate <command> [args]")
+	fmt.Println()
+	fmt.Println("COMMANDS:")
+	fmt.Println("  up            Apply every pending migration")
+	fmt.Println("  down [N]      Roll back the latest N migrations, 1 by default")
+	fmt.Println("  status        List migrations and when each was applied")
+	fmt.Println("  redo          Roll back the latest migration and apply it again")
+	fmt.Println("  create NAME   Add an empty SQL migration to " + migrationsDir)
+	fmt.Println("  help          Show this help message")
+	fmt.Println()
+	fmt.Println("The migrations are the ones built into this binary, and the database comes")
+	fmt.Println("from the usual POSTGRES_* environment variables. Run create from the")
+	fmt.Println("repository root, then rebuild before applying the new migration.")
+	fmt.Println()
+	fmt.Println("EXAMPLES:")
+	fmt.Println("  migrate up")
+	fmt.Println("  migrate down 2")
+	fmt.Println("  migrate create add_item_barcodes")
+}
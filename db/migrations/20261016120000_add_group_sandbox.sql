@@ -0,0 +1,11 @@
+-- +goose Up
+-- Sandbox groups behave normally but suppress outbound email and flag their bookings as test data
+ALTER TABLE groups ADD COLUMN sandbox BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE booking ADD COLUMN is_test BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_booking_test ON booking(group_id) WHERE is_test;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_booking_test;
+ALTER TABLE booking DROP COLUMN is_test;
+ALTER TABLE groups DROP COLUMN sandbox;
@@ -0,0 +1,10 @@
+-- +goose Up
+INSERT INTO permissions (name, description) VALUES
+    ('manage_workers', 'Pause, drain, and cancel background task queues');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('global_admin', 'manage_workers');
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_name = 'manage_workers';
+DELETE FROM permissions WHERE name = 'manage_workers';
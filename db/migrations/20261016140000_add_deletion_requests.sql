@@ -0,0 +1,32 @@
+-- +goose Up
+-- Dual-control deletion: a delete request must be approved by a second admin,
+-- after which the entity sits in the recycle bin until purge_after.
+CREATE TYPE deletion_status AS ENUM ('pending', 'binned', 'rejected', 'restored', 'purged');
+CREATE TYPE deletion_entity AS ENUM ('group', 'item');
+
+CREATE TABLE deletion_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entity_type deletion_entity NOT NULL,
+    entity_id UUID NOT NULL,
+    entity_name VARCHAR(255) NOT NULL,
+    status deletion_status NOT NULL DEFAULT 'pending',
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    requested_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    approved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    approved_at TIMESTAMP,
+    purge_after TIMESTAMP,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP
+);
+
+-- at most one open (pending or binned) request per entity
+CREATE UNIQUE INDEX idx_deletion_requests_open
+    ON deletion_requests(entity_type, entity_id)
+    WHERE status IN ('pending', 'binned');
+
+CREATE INDEX idx_deletion_requests_status ON deletion_requests(status, requested_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS deletion_requests;
+DROP TYPE IF EXISTS deletion_entity;
+DROP TYPE IF EXISTS deletion_status;
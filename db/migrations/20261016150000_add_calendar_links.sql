@@ -0,0 +1,14 @@
+-- +goose Up
+-- External calendar (ICS) feeds linked by managers; busy blocks imported from
+-- the feed remove conflicting availability.
+CREATE TABLE calendar_links (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    ics_url TEXT NOT NULL,
+    last_synced_at TIMESTAMP,
+    last_error TEXT,
+    removed_slots INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS calendar_links;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- CSV exports generated by the worker and stored in S3.
+CREATE TYPE report_type AS ENUM ('borrowings', 'bookings', 'takings');
+CREATE TYPE report_status AS ENUM ('pending', 'ready', 'failed');
+
+CREATE TABLE reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    report_type report_type NOT NULL,
+    status report_status NOT NULL DEFAULT 'pending',
+    requested_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    group_id UUID REFERENCES groups(id) ON DELETE CASCADE,
+    from_date DATE,
+    to_date DATE,
+    s3_key TEXT,
+    row_count INT,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+CREATE INDEX idx_reports_requested_by ON reports(requested_by, created_at DESC);
+
+-- +goose StatementBegin
+INSERT INTO notification_entity_types (name, description) VALUES
+    ('report', 'Generated report downloads');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM notification_entity_types WHERE name = 'report';
+-- +goose StatementEnd
+DROP TABLE IF EXISTS reports;
+DROP TYPE IF EXISTS report_status;
+DROP TYPE IF EXISTS report_type;
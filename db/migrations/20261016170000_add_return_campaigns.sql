@@ -0,0 +1,44 @@
+-- +goose Up
+-- End-of-term return campaigns: staged reminders for borrowings due before
+-- term end, escalation to group admins once overdue, and a final
+-- outstanding-items report.
+CREATE TYPE campaign_status AS ENUM ('active', 'completed', 'cancelled');
+CREATE TYPE campaign_stage AS ENUM ('t14', 't7', 't1', 'escalated');
+
+ALTER TYPE report_type ADD VALUE 'outstanding';
+
+CREATE TABLE return_campaigns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    term_end DATE NOT NULL,
+    status campaign_status NOT NULL DEFAULT 'active',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_run_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    report_id UUID REFERENCES reports(id) ON DELETE SET NULL
+);
+
+-- one row per reminder sent, so each stage goes out at most once per borrowing
+CREATE TABLE return_campaign_notices (
+    campaign_id UUID NOT NULL REFERENCES return_campaigns(id) ON DELETE CASCADE,
+    borrowing_id UUID NOT NULL REFERENCES borrowings(id) ON DELETE CASCADE,
+    stage campaign_stage NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (campaign_id, borrowing_id, stage)
+);
+
+-- +goose StatementBegin
+INSERT INTO notification_entity_types (name, description) VALUES
+    ('return_campaign', 'End-of-term return reminders');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM notification_entity_types WHERE name = 'return_campaign';
+-- +goose StatementEnd
+DROP TABLE IF EXISTS return_campaign_notices;
+DROP TABLE IF EXISTS return_campaigns;
+DROP TYPE IF EXISTS campaign_stage;
+DROP TYPE IF EXISTS campaign_status;
+-- report_type keeps the 'outstanding' value; enum values cannot be dropped
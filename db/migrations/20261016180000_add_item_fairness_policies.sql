@@ -0,0 +1,16 @@
+-- +goose Up
+-- Opt-in rotation for high-demand items: pending requests for a policy item
+-- are ranked by how long the requesting group has gone without it.
+CREATE TABLE item_fairness_policies (
+    item_id UUID PRIMARY KEY REFERENCES items(id) ON DELETE CASCADE,
+    window_days INT NOT NULL DEFAULT 90 CHECK (window_days > 0),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- why an approver skipped a higher-priority group
+ALTER TABLE requests ADD COLUMN override_justification TEXT;
+
+-- +goose Down
+ALTER TABLE requests DROP COLUMN IF EXISTS override_justification;
+DROP TABLE IF EXISTS item_fairness_policies;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- Student ID numbers are kept only as an HMAC-SHA256 keyed with the server's
+-- STUDENT_ID_HASH_KEY; desk staff enter the number at pickup and it is
+-- compared against the requester's hash.
+ALTER TABLE users ADD COLUMN student_id_hash TEXT;
+
+-- who set or replaced a user's student ID; the ID itself is not recorded
+CREATE TABLE student_id_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    replaced BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_student_id_changes_user ON student_id_changes(user_id, created_at DESC);
+
+-- every verification attempt at the desk, successful or not
+CREATE TABLE booking_verifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES booking(id) ON DELETE CASCADE,
+    verified_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    matched BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_booking_verifications_booking ON booking_verifications(booking_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS booking_verifications;
+DROP TABLE IF EXISTS student_id_changes;
+ALTER TABLE users DROP COLUMN IF EXISTS student_id_hash;
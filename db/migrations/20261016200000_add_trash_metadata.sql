@@ -0,0 +1,16 @@
+-- +goose Up
+-- who removed an entity and why, for the admin trash view
+ALTER TABLE deletion_requests ADD COLUMN reason TEXT;
+
+ALTER TABLE booking
+    ADD COLUMN cancelled_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN cancelled_at TIMESTAMP,
+    ADD COLUMN cancellation_reason TEXT;
+
+-- +goose Down
+ALTER TABLE booking
+    DROP COLUMN IF EXISTS cancellation_reason,
+    DROP COLUMN IF EXISTS cancelled_at,
+    DROP COLUMN IF EXISTS cancelled_by;
+
+ALTER TABLE deletion_requests DROP COLUMN IF EXISTS reason;
@@ -0,0 +1,45 @@
+-- +goose Up
+-- Append-only log of booking status transitions, written in the same
+-- transaction as the change itself. Replaying a booking's events must
+-- reproduce booking.status.
+CREATE TABLE booking_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES booking(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    from_status request_status,
+    to_status request_status NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    -- clock_timestamp so events written in one transaction keep their order
+    created_at TIMESTAMP NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX idx_booking_events_booking ON booking_events(booking_id, created_at);
+
+-- +goose StatementBegin
+CREATE FUNCTION booking_events_immutable() RETURNS trigger AS $$
+BEGIN
+    -- the log only goes away together with its booking (the ON DELETE
+    -- CASCADE from sandbox resets and purges), never row by row
+    IF TG_OP = 'DELETE' AND NOT EXISTS (SELECT 1 FROM booking WHERE id = OLD.booking_id) THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'booking_events rows are immutable';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER booking_events_no_update
+    BEFORE UPDATE ON booking_events
+    FOR EACH ROW EXECUTE FUNCTION booking_events_immutable();
+
+CREATE TRIGGER booking_events_no_delete
+    BEFORE DELETE ON booking_events
+    FOR EACH ROW EXECUTE FUNCTION booking_events_immutable();
+
+-- bookings that predate the log start from their current status
+INSERT INTO booking_events (booking_id, to_status, payload, created_at)
+SELECT id, status, '{"backfilled": true}', created_at FROM booking;
+
+-- +goose Down
+DROP TABLE IF EXISTS booking_events;
+DROP FUNCTION IF EXISTS booking_events_immutable();
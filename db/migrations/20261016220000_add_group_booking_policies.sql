@@ -0,0 +1,15 @@
+-- +goose Up
+-- Per-group overrides for booking confirmation rules. NULL columns fall back
+-- to the server defaults (BOOKING_CONFIRMATION_WINDOW, BOOKING_PICKUP_CUTOFF).
+CREATE TABLE group_booking_policies (
+    group_id UUID PRIMARY KEY REFERENCES groups(id) ON DELETE CASCADE,
+    -- how long after a booking is created the requester has to confirm it
+    confirmation_window_hours INT CHECK (confirmation_window_hours > 0),
+    -- confirmation closes this long before pickup
+    pickup_cutoff_hours INT CHECK (pickup_cutoff_hours >= 0),
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS group_booking_policies;
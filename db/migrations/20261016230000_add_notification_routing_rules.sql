@@ -0,0 +1,34 @@
+-- +goose Up
+-- Admin-configured recipients for notifications. A rule applies to one email
+-- template; its NULL conditions match anything. The recipient is either a
+-- user (in-app and email) or a bare address such as a team mailbox (email only).
+CREATE TABLE notification_routing_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    template TEXT NOT NULL,
+    item_id UUID REFERENCES items(id) ON DELETE CASCADE,
+    item_type item_type,
+    group_id UUID REFERENCES groups(id) ON DELETE CASCADE,
+    min_days_overdue INT CHECK (min_days_overdue >= 0),
+    recipient_user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    recipient_email TEXT,
+    -- send to the rule's recipient instead of the notification's usual ones
+    replace_default BOOLEAN NOT NULL DEFAULT FALSE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK ((recipient_user_id IS NULL) <> (recipient_email IS NULL))
+);
+
+CREATE INDEX idx_notification_routing_rules_template ON notification_routing_rules(template) WHERE enabled;
+
+INSERT INTO permissions (name, description) VALUES
+    ('manage_notifications', 'Configure notification routing rules');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('global_admin', 'manage_notifications');
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_name = 'manage_notifications';
+DELETE FROM permissions WHERE name = 'manage_notifications';
+DROP TABLE IF EXISTS notification_routing_rules;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Filled in from the registrar export by the bulk user import.
+ALTER TABLE users ADD COLUMN name TEXT;
+ALTER TABLE users ADD COLUMN program TEXT;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS program;
+ALTER TABLE users DROP COLUMN IF EXISTS name;
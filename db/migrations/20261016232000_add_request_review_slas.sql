@@ -0,0 +1,19 @@
+-- +goose Up
+-- How long a group's item requests may wait for review. Groups without a row
+-- have no SLA and are not tracked.
+CREATE TABLE group_request_slas (
+    group_id UUID PRIMARY KEY REFERENCES groups(id) ON DELETE CASCADE,
+    review_hours INT NOT NULL CHECK (review_hours > 0),
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- One row per request whose breach has been alerted, so the worker alerts once.
+CREATE TABLE request_sla_alerts (
+    request_id UUID PRIMARY KEY REFERENCES requests(id) ON DELETE CASCADE,
+    alerted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS request_sla_alerts;
+DROP TABLE IF EXISTS group_request_slas;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- Members waiting for an out-of-stock item, served in the order they joined.
+-- An entry leaves the queue once its member has been told the item is back.
+CREATE TABLE item_waitlist (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    notified_at TIMESTAMP
+);
+
+-- a member waits at most once per item
+CREATE UNIQUE INDEX idx_item_waitlist_waiting ON item_waitlist(item_id, user_id) WHERE notified_at IS NULL;
+
+-- +goose StatementBegin
+INSERT INTO notification_entity_types (name, description) VALUES
+    ('item_waitlist', 'Waitlisted items back in stock');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM notification_entity_types WHERE name = 'item_waitlist';
+-- +goose StatementEnd
+DROP TABLE IF EXISTS item_waitlist;
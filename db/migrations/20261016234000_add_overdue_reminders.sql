@@ -0,0 +1,29 @@
+-- +goose Up
+-- Unreturned borrowings the worker has found past their due date. Rows stay
+-- after the item comes back as a record that it was late.
+CREATE TABLE overdue_borrowings (
+    borrowing_id UUID PRIMARY KEY REFERENCES borrowings(id) ON DELETE CASCADE,
+    overdue_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- One row per reminder sent, keyed by the day relative to the due date it
+-- was for (-1 the day before, 0 on the day, 3, 6, ... after), so each is
+-- sent once.
+CREATE TABLE borrowing_reminders (
+    borrowing_id UUID NOT NULL REFERENCES borrowings(id) ON DELETE CASCADE,
+    days_from_due INT NOT NULL,
+    sent_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (borrowing_id, days_from_due)
+);
+
+-- +goose StatementBegin
+INSERT INTO notification_entity_types (name, description) VALUES
+    ('borrowing', 'Return reminders for borrowed items');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM notification_entity_types WHERE name = 'borrowing';
+-- +goose StatementEnd
+DROP TABLE IF EXISTS borrowing_reminders;
+DROP TABLE IF EXISTS overdue_borrowings;
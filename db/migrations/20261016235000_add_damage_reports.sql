@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TYPE damage_severity AS ENUM ('minor', 'moderate', 'severe');
+CREATE TYPE damage_report_status AS ENUM ('open', 'resolved', 'dismissed');
+
+-- Opened when an item comes back in worse condition than it went out in.
+-- photo_keys are the S3 keys of the borrowing's after-images at return.
+CREATE TABLE damage_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    borrowing_id UUID NOT NULL UNIQUE REFERENCES borrowings(id) ON DELETE CASCADE,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    group_id UUID REFERENCES groups(id) ON DELETE SET NULL,
+    reported_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    before_condition condition NOT NULL,
+    after_condition condition NOT NULL,
+    severity damage_severity NOT NULL,
+    notes TEXT,
+    photo_keys TEXT[] NOT NULL DEFAULT '{}',
+    status damage_report_status NOT NULL DEFAULT 'open',
+    resolution_notes TEXT,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_damage_reports_status ON damage_reports(status, created_at);
+
+-- +goose StatementBegin
+INSERT INTO notification_entity_types (name, description) VALUES
+    ('damage_report', 'Items returned damaged');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM notification_entity_types WHERE name = 'damage_report';
+-- +goose StatementEnd
+DROP TABLE IF EXISTS damage_reports;
+DROP TYPE IF EXISTS damage_report_status;
+DROP TYPE IF EXISTS damage_severity;
@@ -0,0 +1,36 @@
+-- +goose Up
+-- Catalogue categories, addressed by slug in filters (e.g. ?category=av).
+CREATE TABLE categories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug VARCHAR(64) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- an item sits in at most one category
+CREATE TABLE item_categories (
+    item_id UUID PRIMARY KEY REFERENCES items(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_item_categories_category ON item_categories(category_id);
+
+-- Free-form tags, stored lowercased so filters match regardless of case.
+CREATE TABLE tags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE TABLE item_tags (
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (item_id, tag_id)
+);
+
+CREATE INDEX idx_item_tags_tag ON item_tags(tag_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS item_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS item_categories;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Trigram matching lets catalogue search still find "projector" when
+-- someone types "projecter".
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- must match the expression used by the search queries to be picked up
+CREATE INDEX idx_items_search_document ON items
+    USING GIN (to_tsvector('english', name || ' ' || COALESCE(description, '')));
+
+CREATE INDEX idx_items_name_trgm ON items USING GIN (name gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_items_name_trgm;
+DROP INDEX IF EXISTS idx_items_search_document;
+-- pg_trgm is left installed in case anything else has come to rely on it
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Manager check-ins for a booking. Pickup opens the borrowing that tracks
+-- the loan; return closes it. One row per booking, returned_at is set once
+-- the item is back.
+CREATE TABLE booking_handoffs (
+    booking_id UUID PRIMARY KEY REFERENCES booking(id) ON DELETE CASCADE,
+    borrowing_id UUID NOT NULL UNIQUE REFERENCES borrowings(id) ON DELETE CASCADE,
+    picked_up_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    picked_up_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    returned_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    returned_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS booking_handoffs;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Per-type email switches. A missing row means the type is on; the global
+-- email_notifications preference still turns every email off.
+CREATE TABLE notification_preferences (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    notification_type TEXT NOT NULL,
+    email_enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, notification_type)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS notification_preferences;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- One bookings calendar feed token per user. Only its SHA-256 is stored; the
+-- token itself is shown to the user once.
+CREATE TABLE calendar_feed_tokens (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS calendar_feed_tokens;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Images uploaded straight to S3 are confirmed by their object key; a key can
+-- back only one image, so confirming twice can't create duplicates.
+CREATE UNIQUE INDEX idx_item_images_original_key ON item_images(original_s3_key);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_item_images_original_key;
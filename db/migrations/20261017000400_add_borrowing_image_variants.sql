@@ -0,0 +1,14 @@
+-- +goose Up
+-- Condition photos are stored as uploaded and then processed by the worker,
+-- which strips their EXIF data in place and adds web-sized and thumbnail
+-- variants. The variant keys stay NULL until then.
+ALTER TABLE borrowing_images
+    ADD COLUMN web_s3_key TEXT,
+    ADD COLUMN thumbnail_s3_key TEXT,
+    ADD COLUMN processed_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE borrowing_images
+    DROP COLUMN processed_at,
+    DROP COLUMN thumbnail_s3_key,
+    DROP COLUMN web_s3_key;
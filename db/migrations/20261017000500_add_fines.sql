@@ -0,0 +1,41 @@
+-- +goose Up
+-- What borrowers are charged for an item returned late or damaged. Items
+-- without a row are free of fees.
+CREATE TABLE item_fees (
+    item_id UUID PRIMARY KEY REFERENCES items(id) ON DELETE CASCADE,
+    late_fee_cents INT NOT NULL DEFAULT 0 CHECK (late_fee_cents >= 0),
+    damage_fee_cents INT NOT NULL DEFAULT 0 CHECK (damage_fee_cents >= 0),
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TYPE fine_reason AS ENUM ('late', 'damage');
+CREATE TYPE fine_status AS ENUM ('unpaid', 'paid', 'waived');
+
+-- Charged when a borrowing is returned; at most one of each reason per borrowing.
+CREATE TABLE fines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    borrowing_id UUID NOT NULL REFERENCES borrowings(id) ON DELETE CASCADE,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    damage_report_id UUID REFERENCES damage_reports(id) ON DELETE SET NULL,
+    reason fine_reason NOT NULL,
+    amount_cents INT NOT NULL CHECK (amount_cents > 0),
+    -- whole or part days past the due date, for late fines
+    days_late INT,
+    status fine_status NOT NULL DEFAULT 'unpaid',
+    resolution_notes TEXT,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (borrowing_id, reason)
+);
+
+CREATE INDEX idx_fines_user_status ON fines(user_id, status);
+CREATE INDEX idx_fines_status ON fines(status, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS fines;
+DROP TYPE IF EXISTS fine_status;
+DROP TYPE IF EXISTS fine_reason;
+DROP TABLE IF EXISTS item_fees;
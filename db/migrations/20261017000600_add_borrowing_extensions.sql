@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TYPE extension_status AS ENUM ('pending', 'approved', 'denied');
+
+-- A borrower asking to keep an item past its due date. Requests that would
+-- hold up a waitlist or an upcoming booking are denied as they are made.
+CREATE TABLE borrowing_extensions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    borrowing_id UUID NOT NULL REFERENCES borrowings(id) ON DELETE CASCADE,
+    requested_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    current_due_date TIMESTAMP NOT NULL,
+    requested_due_date TIMESTAMP NOT NULL,
+    reason TEXT,
+    status extension_status NOT NULL DEFAULT 'pending',
+    decision_notes TEXT,
+    decided_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT later_due_date CHECK (requested_due_date > current_due_date)
+);
+
+-- one open request per borrowing
+CREATE UNIQUE INDEX idx_borrowing_extensions_pending ON borrowing_extensions(borrowing_id) WHERE status = 'pending';
+CREATE INDEX idx_borrowing_extensions_status ON borrowing_extensions(status, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS borrowing_extensions;
+DROP TYPE IF EXISTS extension_status;
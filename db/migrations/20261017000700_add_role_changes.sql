@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TYPE role_change_action AS ENUM (
+    'role_created',
+    'permissions_set',
+    'user_role_assigned',
+    'user_role_revoked'
+);
+
+-- who changed which role, and what it was changed to. Not tied to roles or
+-- groups by foreign key so the record outlives them.
+CREATE TABLE role_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    action role_change_action NOT NULL,
+    role_name VARCHAR(255) NOT NULL,
+    -- the role's permissions after the change, for role_created and permissions_set
+    permissions TEXT[],
+    -- the user given or stripped of the role, for user_role_assigned and user_role_revoked
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    scope scope_type,
+    scope_id UUID,
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_role_changes_created ON role_changes(created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS role_changes;
+DROP TYPE IF EXISTS role_change_action;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- accounts at an OpenID Connect provider linked to users. The provider's
+-- subject is the stable key; the address is kept for support and may change.
+CREATE TABLE user_identities (
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX idx_user_identities_user ON user_identities(user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_identities;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- TOTP two-factor authentication. The secret has to be readable to check
+-- codes, so unlike OTPs and refresh tokens it is not hashed.
+CREATE TABLE user_mfa (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    -- NULL until the user proves their authenticator app with a first code;
+    -- until then enrolling again replaces the secret
+    confirmed_at TIMESTAMP,
+    -- the last 30-second time step a code was accepted for, so each code
+    -- works once
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- single-use codes for when the authenticator app is lost, stored hashed
+CREATE TABLE user_mfa_backup_codes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_mfa_backup_codes;
+DROP TABLE IF EXISTS user_mfa;
@@ -0,0 +1,30 @@
+-- +goose Up
+-- Keys for kiosks and scripts that call the API without signing in. A key
+-- acts as its service account, a regular user, but only with the
+-- permissions listed on the key. Only a hash of the key is stored;
+-- key_prefix is its first few characters, to tell keys apart in listings.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key_hash TEXT NOT NULL UNIQUE,
+    key_prefix TEXT NOT NULL,
+    permissions TEXT[] NOT NULL,
+    -- requests per minute; NULL uses API_KEY_RATE_LIMIT
+    rate_limit INT CHECK (rate_limit > 0),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('manage_api_keys', 'Create and revoke API keys for service accounts');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('global_admin', 'manage_api_keys');
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_name = 'manage_api_keys';
+DELETE FROM permissions WHERE name = 'manage_api_keys';
+DROP TABLE IF EXISTS api_keys;
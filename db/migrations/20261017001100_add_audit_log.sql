@@ -0,0 +1,49 @@
+-- +goose Up
+-- every change made through the API: who made it, which operation, what it
+-- changed and the fields that changed, as they were before and after. Not tied
+-- to users or the changed rows by foreign key so entries outlive both.
+CREATE TABLE audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID,
+    -- the operation, e.g. UpdateItem
+    action VARCHAR(100) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    -- a UUID for most entities; role and queue names for those
+    entity_id TEXT,
+    before JSONB,
+    after JSONB,
+    request_id TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT clock_timestamp()
+);
+
+CREATE INDEX idx_audit_log_created ON audit_log(created_at DESC);
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id, created_at DESC);
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_id, created_at DESC);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log rows are immutable';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_no_update
+    BEFORE UPDATE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
+
+CREATE TRIGGER audit_log_no_delete
+    BEFORE DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
+
+INSERT INTO permissions (name, description) VALUES
+    ('view_audit_log', 'View the audit log of changes made through the API');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('global_admin', 'view_audit_log');
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_name = 'view_audit_log';
+DELETE FROM permissions WHERE name = 'view_audit_log';
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_immutable();
@@ -0,0 +1,47 @@
+-- +goose Up
+-- Endpoints told about domain events, e.g. the union's Discord bot. The
+-- secret signs each delivery, so unlike API keys it is stored as given.
+CREATE TABLE webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    url TEXT NOT NULL,
+    description TEXT,
+    secret TEXT NOT NULL,
+    -- event types sent to the endpoint, e.g. request.approved
+    events TEXT[] NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TYPE webhook_delivery_status AS ENUM ('pending', 'succeeded', 'failed');
+
+-- one row per event sent to a webhook, updated after every attempt
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    -- the exact body posted, so retries send the same bytes
+    payload JSONB NOT NULL,
+    status webhook_delivery_status NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    -- from the last attempt; response_status is NULL when no response came
+    response_status INT,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMP,
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at DESC);
+
+INSERT INTO permissions (name, description) VALUES
+    ('manage_webhooks', 'Register webhooks and view their deliveries');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('global_admin', 'manage_webhooks');
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_name = 'manage_webhooks';
+DELETE FROM permissions WHERE name = 'manage_webhooks';
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TYPE IF EXISTS webhook_delivery_status;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Items leaving the recycle bin are archived rather than deleted: borrowings,
+-- fines and damage reports cascade from items, so a DELETE would take the
+-- item's history with it. Archived items stay out of the catalogue.
+ALTER TABLE items ADD COLUMN archived_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE items DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Units of an item out for repair or servicing. While a record is open its
+-- units are taken out of the item's stock, as a borrowing's are, so the
+-- borrow and approval checks never count them as available.
+CREATE TABLE item_maintenance (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    reason TEXT NOT NULL,
+    expected_return_date DATE,
+    started_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completion_notes TEXT,
+    completed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX idx_item_maintenance_item ON item_maintenance(item_id, started_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS item_maintenance;
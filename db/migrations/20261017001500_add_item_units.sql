@@ -0,0 +1,78 @@
+-- +goose Up
+-- Individually tracked units of an item, for gear where it matters which
+-- physical unit someone has. Once an item has units its stock is derived from
+-- them: the triggers below keep items.stock at the count of available units,
+-- so the borrow and approval checks read it as they always have.
+CREATE TYPE unit_status AS ENUM ('available', 'borrowed', 'maintenance', 'retired');
+
+CREATE TABLE item_units (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    serial_number TEXT UNIQUE,
+    asset_tag TEXT UNIQUE,
+    condition condition NOT NULL DEFAULT 'good',
+    status unit_status NOT NULL DEFAULT 'available',
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_item_units_item ON item_units(item_id, status);
+
+ALTER TABLE borrowings ADD COLUMN unit_id UUID REFERENCES item_units(id) ON DELETE SET NULL;
+ALTER TABLE booking ADD COLUMN unit_id UUID REFERENCES item_units(id) ON DELETE SET NULL;
+ALTER TABLE item_maintenance ADD COLUMN unit_id UUID REFERENCES item_units(id) ON DELETE SET NULL;
+
+-- +goose StatementBegin
+CREATE FUNCTION available_unit_count(item UUID) RETURNS INT AS $$
+    SELECT COUNT(*)::INT FROM item_units WHERE item_id = item AND status = 'available';
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION item_units_recount_stock() RETURNS trigger AS $$
+DECLARE
+    affected UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        affected := OLD.item_id;
+    ELSE
+        affected := NEW.item_id;
+    END IF;
+    UPDATE items SET stock = available_unit_count(affected) WHERE id = affected;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER item_units_stock
+    AFTER INSERT OR UPDATE OR DELETE ON item_units
+    FOR EACH ROW EXECUTE FUNCTION item_units_recount_stock();
+
+-- stock written any other way, by an item edit or the borrow and return
+-- arithmetic, is put back to the count for items that have units
+-- +goose StatementBegin
+CREATE FUNCTION items_stock_follows_units() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM item_units WHERE item_id = NEW.id) THEN
+        NEW.stock := available_unit_count(NEW.id);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER items_stock_follows_units
+    BEFORE UPDATE OF stock ON items
+    FOR EACH ROW EXECUTE FUNCTION items_stock_follows_units();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS items_stock_follows_units ON items;
+DROP FUNCTION IF EXISTS items_stock_follows_units();
+ALTER TABLE item_maintenance DROP COLUMN IF EXISTS unit_id;
+ALTER TABLE booking DROP COLUMN IF EXISTS unit_id;
+ALTER TABLE borrowings DROP COLUMN IF EXISTS unit_id;
+DROP TABLE IF EXISTS item_units;
+DROP FUNCTION IF EXISTS item_units_recount_stock();
+DROP FUNCTION IF EXISTS available_unit_count(UUID);
+DROP TYPE IF EXISTS unit_status;
@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TYPE join_request_status AS ENUM ('pending', 'approved', 'denied');
+
+-- A user asking to become a member of a group. The group's admins decide;
+-- approving grants the member role scoped to the group.
+CREATE TABLE group_join_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message TEXT,
+    status join_request_status NOT NULL DEFAULT 'pending',
+    decision_notes TEXT,
+    decided_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- one open request per user and group
+CREATE UNIQUE INDEX idx_group_join_requests_pending ON group_join_requests(group_id, user_id) WHERE status = 'pending';
+CREATE INDEX idx_group_join_requests_group ON group_join_requests(group_id, status, created_at);
+
+-- +goose StatementBegin
+INSERT INTO notification_entity_types (name, description) VALUES
+    ('group_join_request', 'Requests to join a group');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM notification_entity_types WHERE name = 'group_join_request';
+-- +goose StatementEnd
+DROP TABLE IF EXISTS group_join_requests;
+DROP TYPE IF EXISTS join_request_status;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Items may belong to a group. An owned item is only seen and borrowed by
+-- members of the owning group and of the groups it is shared with; items
+-- without an owner stay open to everyone, as all items were before.
+ALTER TABLE items ADD COLUMN owner_group_id UUID REFERENCES groups(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_items_owner_group ON items(owner_group_id) WHERE owner_group_id IS NOT NULL;
+
+CREATE TABLE item_group_shares (
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (item_id, group_id)
+);
+
+CREATE INDEX idx_item_group_shares_group ON item_group_shares(group_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS item_group_shares;
+DROP INDEX IF EXISTS idx_items_owner_group;
+ALTER TABLE items DROP COLUMN IF EXISTS owner_group_id;
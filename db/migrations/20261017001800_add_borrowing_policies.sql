@@ -0,0 +1,49 @@
+-- +goose Up
+-- Limits on what users may borrow. A policy applies to holders of role_name
+-- (everyone when NULL) borrowing items of item_type (every type when NULL);
+-- when several apply, the strictest value of each limit wins. NULL limits
+-- are not enforced.
+CREATE TABLE borrowing_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    role_name VARCHAR(255) REFERENCES roles(name) ON DELETE CASCADE,
+    item_type item_type,
+    -- borrowings of item_type the user may have out at once
+    max_active_borrowings INT CHECK (max_active_borrowings > 0),
+    -- units of one item in a single borrowing or request
+    max_quantity INT CHECK (max_quantity > 0),
+    -- the longest loan, and the length given to approved bookings
+    loan_days INT CHECK (loan_days > 0),
+    -- whether a high-value pickup may take fewer units than were approved
+    allow_partial_pickup BOOLEAN,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- periods, e.g. exam weeks, when items of item_type (every type when NULL)
+-- can be neither picked up nor returned
+CREATE TABLE borrowing_blackouts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reason TEXT NOT NULL,
+    item_type item_type,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_borrowing_blackouts_ends_at ON borrowing_blackouts(ends_at);
+
+INSERT INTO permissions (name, description) VALUES
+    ('manage_borrowing_policies', 'Configure borrowing limits and blackout periods');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('global_admin', 'manage_borrowing_policies');
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission_name = 'manage_borrowing_policies';
+DELETE FROM permissions WHERE name = 'manage_borrowing_policies';
+DROP TABLE IF EXISTS borrowing_blackouts;
+DROP TABLE IF EXISTS borrowing_policies;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- What an in-app notification says, so the inbox can show it without the
+-- email it came with. Set from the email's subject; NULL for notifications
+-- that have no email.
+ALTER TABLE notifications ADD COLUMN title TEXT;
+
+-- +goose Down
+ALTER TABLE notifications DROP COLUMN IF EXISTS title;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- A counter bumped by every write to an item row, whether an admin's edit or
+-- the stock arithmetic of a borrow or return. Item updates compare it against
+-- the client's If-Match so two admins editing at once cannot clobber each
+-- other.
+ALTER TABLE items ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- +goose StatementBegin
+CREATE FUNCTION items_bump_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER items_bump_version
+    BEFORE UPDATE ON items
+    FOR EACH ROW EXECUTE FUNCTION items_bump_version();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS items_bump_version ON items;
+DROP FUNCTION IF EXISTS items_bump_version();
+ALTER TABLE items DROP COLUMN IF EXISTS version;
@@ -0,0 +1,72 @@
+-- +goose Up
+-- A ledger of every change to an item's stock. items.stock stays the number
+-- the borrow and approval checks read, but nothing can move it without a
+-- row landing here: the trigger below records each change as it happens,
+-- whatever made it. The code making a change says what it was by setting
+-- cv.stock_movement for its transaction (see TagStockMovement); changes
+-- nobody described are recorded as adjustments, or purchases for a new
+-- item's opening stock.
+CREATE TYPE stock_movement_kind AS ENUM ('borrow', 'return', 'take', 'adjust', 'purchase', 'write_off', 'maintenance');
+
+CREATE TABLE stock_movements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    -- positive into stock, negative out of it
+    delta INT NOT NULL CHECK (delta <> 0),
+    -- the item's stock once the movement was made
+    balance INT NOT NULL,
+    kind stock_movement_kind NOT NULL,
+    reason TEXT,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    -- the borrowing, taking or maintenance record behind the movement
+    reference_id UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_stock_movements_item ON stock_movements(item_id, created_at DESC);
+
+-- +goose StatementBegin
+CREATE FUNCTION items_record_stock_movement() RETURNS trigger AS $$
+DECLARE
+    moved INT;
+    tag JSONB := NULLIF(current_setting('cv.stock_movement', true), '')::JSONB;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        moved := NEW.stock;
+    ELSE
+        moved := NEW.stock - OLD.stock;
+    END IF;
+    IF moved = 0 THEN
+        RETURN NULL;
+    END IF;
+
+    INSERT INTO stock_movements (item_id, delta, balance, kind, reason, actor_id, reference_id)
+    VALUES (
+        NEW.id,
+        moved,
+        NEW.stock,
+        COALESCE((tag->>'kind')::stock_movement_kind,
+            CASE WHEN TG_OP = 'INSERT' THEN 'purchase' ELSE 'adjust' END::stock_movement_kind),
+        tag->>'reason',
+        (tag->>'actor_id')::UUID,
+        (tag->>'reference_id')::UUID
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER items_stock_ledger
+    AFTER INSERT OR UPDATE OF stock ON items
+    FOR EACH ROW EXECUTE FUNCTION items_record_stock_movement();
+
+-- the stock items already hold, so each item's ledger adds up to it
+INSERT INTO stock_movements (item_id, delta, balance, kind, reason)
+SELECT id, stock, stock, 'adjust', 'opening balance'
+FROM items WHERE stock > 0;
+
+-- +goose Down
+DROP TRIGGER IF EXISTS items_stock_ledger ON items;
+DROP FUNCTION IF EXISTS items_record_stock_movement();
+DROP TABLE IF EXISTS stock_movements;
+DROP TYPE IF EXISTS stock_movement_kind;
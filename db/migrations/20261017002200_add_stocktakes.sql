@@ -0,0 +1,43 @@
+-- +goose Up
+-- Stocktakes: the physical counts done each semester. Staff count items
+-- against a session while it is open, each count keeping the stock the item
+-- had when it was counted, so borrows and returns made during the stocktake
+-- do not show up as discrepancies. Closing the session corrects the stock of
+-- every item counted wrong through the stock ledger.
+CREATE TYPE stocktake_status AS ENUM ('counting', 'closed');
+
+CREATE TABLE stocktakes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    status stocktake_status NOT NULL DEFAULT 'counting',
+    notes TEXT,
+    opened_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    opened_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    closed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    closed_at TIMESTAMP
+);
+
+-- one stocktake at a time
+CREATE UNIQUE INDEX idx_stocktakes_counting ON stocktakes(status) WHERE status = 'counting';
+
+CREATE TABLE stocktake_counts (
+    stocktake_id UUID NOT NULL REFERENCES stocktakes(id) ON DELETE CASCADE,
+    item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    -- the item's stock when it was counted
+    expected INT NOT NULL,
+    counted INT NOT NULL CHECK (counted >= 0),
+    -- for items tracked by unit, the units found, and the available ones
+    -- that were not
+    unit_ids UUID[] NOT NULL DEFAULT '{}',
+    missing_unit_ids UUID[] NOT NULL DEFAULT '{}',
+    notes TEXT,
+    counted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    counted_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    -- the correction closing the stocktake made
+    movement_id UUID REFERENCES stock_movements(id) ON DELETE SET NULL,
+    PRIMARY KEY (stocktake_id, item_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS stocktake_counts;
+DROP TABLE IF EXISTS stocktakes;
+DROP TYPE IF EXISTS stocktake_status;
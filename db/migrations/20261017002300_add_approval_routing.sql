@@ -0,0 +1,39 @@
+-- +goose Up
+-- Approval routing: a request for an item a group owns goes to that group's
+-- approvers first, and on to the global approvers once it has waited past its
+-- group's review SLA. Requests for other items go to the global approvers.
+-- An approver who is away hands what is routed to them to a delegate for the
+-- length of a delegation. Every routing decision is kept against the request.
+CREATE TABLE approval_delegations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delegator_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    delegate_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMP NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (delegator_id <> delegate_id),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_approval_delegations_delegator ON approval_delegations(delegator_id, ends_at);
+
+CREATE TYPE request_route_reason AS ENUM ('owner_group', 'global', 'escalated');
+
+CREATE TABLE request_routes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    request_id UUID NOT NULL REFERENCES requests(id) ON DELETE CASCADE,
+    approver_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason request_route_reason NOT NULL,
+    -- set when the approver stands in for one who was away
+    delegated_from UUID REFERENCES users(id) ON DELETE SET NULL,
+    routed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (request_id, approver_id)
+);
+
+CREATE INDEX idx_request_routes_approver ON request_routes(approver_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS request_routes;
+DROP TYPE IF EXISTS request_route_reason;
+DROP TABLE IF EXISTS approval_delegations;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Comments on a request, for approvers asking what a request is for and
+-- requesters answering. A reply points at the comment it answers.
+CREATE TABLE request_comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    request_id UUID NOT NULL REFERENCES requests(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES request_comments(id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL CHECK (length(trim(body)) > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_request_comments_request ON request_comments(request_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS request_comments;
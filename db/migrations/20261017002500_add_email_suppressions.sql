@@ -0,0 +1,14 @@
+-- +goose Up
+-- Addresses SES reported as bouncing permanently, or whose owners marked our
+-- mail as spam. Nothing more is sent to them until an admin lifts the
+-- suppression. Kept by address rather than user: the address is what bounced,
+-- and it may belong to nobody yet, e.g. a mistyped invite.
+CREATE TABLE email_suppressions (
+    email TEXT PRIMARY KEY CHECK (email = lower(email)),
+    reason TEXT NOT NULL CHECK (reason IN ('bounce', 'complaint')),
+    detail TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS email_suppressions;
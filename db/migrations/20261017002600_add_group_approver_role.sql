@@ -0,0 +1,39 @@
+-- +goose Up
+-- Group approvers held approve_all_requests within their group, but a check
+-- made without a group counts group-scoped roles too, which let them approve
+-- anything. They get a permission of their own, and roles holding
+-- approve_all_requests are now only granted globally.
+INSERT INTO permissions (name, description) VALUES
+    ('approve_group_requests', 'Approve requests for items owned by the group');
+
+INSERT INTO roles (name, description) VALUES
+    ('group_approver', 'Approves requests for items the group owns');
+
+INSERT INTO role_permissions (role_name, permission_name)
+SELECT 'group_approver', name FROM permissions
+WHERE name IN (
+    'approve_group_requests',
+    'view_group_data',
+    'view_items',
+    'manage_cart',
+    'request_items',
+    'view_own_data'
+);
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('global_admin', 'approve_group_requests');
+
+UPDATE user_roles SET role_name = 'group_approver'
+WHERE role_name = 'approver' AND scope = 'group';
+UPDATE signup_codes SET role_name = 'group_approver'
+WHERE role_name = 'approver' AND scope = 'group';
+
+-- +goose Down
+UPDATE user_roles SET role_name = 'approver'
+WHERE role_name = 'group_approver' AND scope = 'group';
+UPDATE signup_codes SET role_name = 'approver'
+WHERE role_name = 'group_approver';
+
+DELETE FROM user_roles WHERE role_name = 'group_approver';
+DELETE FROM roles WHERE name = 'group_approver';
+DELETE FROM permissions WHERE name = 'approve_group_requests';
@@ -0,0 +1,16 @@
+// Package migrations embeds the goose migrations so the binaries can apply
+// them without db/migrations on disk.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// every migration, at the root of the returned FS as goose expects
+func FS() fs.FS {
+	return files
+}
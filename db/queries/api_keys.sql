@@ -0,0 +1,21 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (name, user_id, key_hash, key_prefix, permissions, rate_limit, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ListAPIKeys :many
+SELECT * FROM api_keys
+ORDER BY created_at DESC;
+
+-- name: GetActiveAPIKeyByHash :one
+SELECT * FROM api_keys
+WHERE key_hash = $1 AND revoked_at IS NULL;
+
+-- Writes at most once a minute per key, rather than on every request.
+-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = NOW()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute');
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL;
@@ -0,0 +1,80 @@
+-- name: CreateApprovalDelegation :one
+INSERT INTO approval_delegations (delegator_id, delegate_id, starts_at, ends_at, reason)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListApprovalDelegations :many
+-- The delegator's current and upcoming delegations
+SELECT * FROM approval_delegations
+WHERE delegator_id = $1 AND ends_at > NOW()
+ORDER BY starts_at;
+
+-- name: ListActiveApprovalDelegations :many
+SELECT * FROM approval_delegations
+WHERE starts_at <= NOW() AND ends_at > NOW()
+ORDER BY created_at;
+
+-- name: DeleteApprovalDelegation :execrows
+DELETE FROM approval_delegations
+WHERE id = $1 AND delegator_id = $2;
+
+-- name: GetGroupApproverIDs :many
+-- Users who approve requests within the group's scope
+SELECT DISTINCT ur.user_id FROM user_roles ur
+JOIN role_permissions rp ON rp.role_name = ur.role_name
+WHERE rp.permission_name = 'approve_group_requests' AND ur.scope = 'group' AND ur.scope_id = $1;
+
+-- name: AddRequestRoute :execrows
+-- Returns 0 when the request was already routed to the approver
+INSERT INTO request_routes (request_id, approver_id, reason, delegated_from)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (request_id, approver_id) DO NOTHING;
+
+-- name: ListRequestRoutes :many
+SELECT * FROM request_routes
+WHERE request_id = $1
+ORDER BY routed_at, approver_id;
+
+-- name: IsRequestRoutedTo :one
+SELECT EXISTS (
+    SELECT 1 FROM request_routes
+    WHERE request_id = $1 AND approver_id = $2
+);
+
+-- name: RouteDelegatorPendingRequests :execrows
+-- Hands the delegator's pending requests to the delegate
+INSERT INTO request_routes (request_id, approver_id, reason, delegated_from)
+SELECT rr.request_id, sqlc.arg('delegate_id')::UUID, rr.reason, rr.approver_id
+FROM request_routes rr
+JOIN requests r ON r.id = rr.request_id
+WHERE rr.approver_id = sqlc.arg('delegator_id') AND r.status = 'pending'
+ON CONFLICT (request_id, approver_id) DO NOTHING;
+
+-- name: ListRoutedPendingRequests :many
+SELECT * FROM requests
+WHERE status = 'pending'
+  AND id IN (SELECT request_id FROM request_routes WHERE approver_id = $1)
+ORDER BY requested_at
+LIMIT $2 OFFSET $3;
+
+-- name: CountRoutedPendingRequests :one
+SELECT COUNT(*) FROM requests
+WHERE status = 'pending'
+  AND id IN (SELECT request_id FROM request_routes WHERE approver_id = $1);
+
+-- name: ListRequestsDueForEscalation :many
+-- Pending requests still with the owning group's approvers after waiting past
+-- their group's review SLA, or default_hours for groups without one (0 leaves
+-- those with the owning group)
+SELECT r.id, r.user_id, r.group_id, r.quantity, r.requested_at,
+    i.name AS item_name, u.email AS requester_email
+FROM requests r
+JOIN items i ON i.id = r.item_id
+LEFT JOIN group_request_slas s ON s.group_id = r.group_id
+LEFT JOIN users u ON u.id = r.user_id
+WHERE r.status = 'pending'
+  AND EXISTS (SELECT 1 FROM request_routes rr WHERE rr.request_id = r.id AND rr.reason = 'owner_group')
+  AND NOT EXISTS (SELECT 1 FROM request_routes rr WHERE rr.request_id = r.id AND rr.reason = 'escalated')
+  AND (s.review_hours IS NOT NULL OR sqlc.arg('default_hours')::INT > 0)
+  AND r.requested_at + COALESCE(s.review_hours, sqlc.arg('default_hours')::INT) * INTERVAL '1 hour' < NOW()
+ORDER BY r.requested_at;
@@ -0,0 +1,56 @@
+-- name: ListApproverDigestRecipients :many
+-- users who opted in to the approver digest
+SELECT user_id FROM notification_preferences
+WHERE notification_type = 'approver_digest' AND email_enabled
+ORDER BY user_id;
+
+-- name: ListDigestPendingRequests :many
+-- pending requests routed to the user, oldest first
+SELECT r.id, r.quantity, r.requested_at,
+    i.name AS item_name, u.email AS requester_email, g.name AS group_name
+FROM requests r
+JOIN request_routes rr ON rr.request_id = r.id
+JOIN items i ON i.id = r.item_id
+LEFT JOIN users u ON u.id = r.user_id
+LEFT JOIN groups g ON g.id = r.group_id
+WHERE rr.approver_id = $1 AND r.status = 'pending'
+ORDER BY r.requested_at, r.id;
+
+-- name: ListDigestPendingConfirmations :many
+-- bookings waiting on their requester's confirmation in groups whose bookings
+-- the user manages, soonest pickup first
+SELECT b.id, b.pick_up_date, b.created_at,
+    i.name AS item_name, u.email AS requester_email, g.name AS group_name
+FROM booking b
+JOIN items i ON i.id = b.item_id
+LEFT JOIN users u ON u.id = b.requester_id
+LEFT JOIN groups g ON g.id = b.group_id
+WHERE b.status = 'pending_confirmation'
+  AND EXISTS (
+    SELECT 1 FROM user_roles ur
+    JOIN role_permissions rp ON rp.role_name = ur.role_name
+    WHERE ur.user_id = sqlc.arg('user_id')::UUID
+      AND ((ur.scope = 'global' AND rp.permission_name = 'manage_all_bookings')
+        OR (ur.scope = 'group' AND ur.scope_id = b.group_id AND rp.permission_name = 'manage_group_bookings'))
+  )
+ORDER BY b.pick_up_date, b.id;
+
+-- name: ListDigestOverdueBorrowings :many
+-- unreturned overdue borrowings in groups whose data the user can see, longest
+-- overdue first
+SELECT b.id, b.quantity, b.due_date,
+    i.name AS item_name, u.email AS user_email, g.name AS group_name
+FROM borrowings b
+JOIN overdue_borrowings o ON o.borrowing_id = b.id
+JOIN items i ON i.id = b.item_id
+LEFT JOIN users u ON u.id = b.user_id
+LEFT JOIN groups g ON g.id = b.group_id
+WHERE b.returned_at IS NULL
+  AND EXISTS (
+    SELECT 1 FROM user_roles ur
+    JOIN role_permissions rp ON rp.role_name = ur.role_name
+    WHERE ur.user_id = sqlc.arg('user_id')::UUID
+      AND ((ur.scope = 'global' AND rp.permission_name = 'view_all_data')
+        OR (ur.scope = 'group' AND ur.scope_id = b.group_id AND rp.permission_name = 'view_group_data'))
+  )
+ORDER BY b.due_date, b.id;
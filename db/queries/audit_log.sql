@@ -0,0 +1,25 @@
+-- name: CreateAuditLogEntry :exec
+INSERT INTO audit_log (actor_id, action, entity_type, entity_id, before, after, request_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListAuditLog :many
+-- Newest first, optionally narrowed to an actor, an action, an entity and a
+-- time range
+SELECT * FROM audit_log
+WHERE (sqlc.narg('actor_id')::UUID IS NULL OR actor_id = sqlc.narg('actor_id'))
+  AND (sqlc.narg('action')::TEXT IS NULL OR action = sqlc.narg('action'))
+  AND (sqlc.narg('entity_type')::TEXT IS NULL OR entity_type = sqlc.narg('entity_type'))
+  AND (sqlc.narg('entity_id')::TEXT IS NULL OR entity_id = sqlc.narg('entity_id'))
+  AND (sqlc.narg('since')::TIMESTAMP IS NULL OR created_at >= sqlc.narg('since'))
+  AND (sqlc.narg('until')::TIMESTAMP IS NULL OR created_at < sqlc.narg('until'))
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountAuditLog :one
+SELECT COUNT(*) FROM audit_log
+WHERE (sqlc.narg('actor_id')::UUID IS NULL OR actor_id = sqlc.narg('actor_id'))
+  AND (sqlc.narg('action')::TEXT IS NULL OR action = sqlc.narg('action'))
+  AND (sqlc.narg('entity_type')::TEXT IS NULL OR entity_type = sqlc.narg('entity_type'))
+  AND (sqlc.narg('entity_id')::TEXT IS NULL OR entity_id = sqlc.narg('entity_id'))
+  AND (sqlc.narg('since')::TIMESTAMP IS NULL OR created_at >= sqlc.narg('since'))
+  AND (sqlc.narg('until')::TIMESTAMP IS NULL OR created_at < sqlc.narg('until'));
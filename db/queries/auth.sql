@@ -42,3 +42,11 @@ INSERT INTO role_permissions (role_name, permission_name) VALUES ($1, $2);
 
 -- name: CreateUserRole :exec
 INSERT INTO user_roles (user_id, role_name, scope, scope_id) VALUES ($1, $2, $3, $4);
+
+-- global roles have no scope_id, so $4 only matters for group roles
+-- name: DeleteUserRole :execrows
+DELETE FROM user_roles
+WHERE user_id = $1
+  AND role_name = $2
+  AND scope = $3
+  AND (scope = 'global' OR scope_id = $4);
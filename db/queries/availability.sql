@@ -25,6 +25,8 @@ JOIN users u ON ua.user_id = u.id
 JOIN time_slots ts ON ua.time_slot_id = ts.id
 WHERE (sqlc.narg('filter_date')::DATE IS NULL OR ua.date = sqlc.narg('filter_date'))
   AND (sqlc.narg('filter_user_id')::UUID IS NULL OR ua.user_id = sqlc.narg('filter_user_id'))
+  AND (sqlc.narg('from_date')::DATE IS NULL OR ua.date >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR ua.date <= sqlc.narg('to_date'))
 ORDER BY ua.date, ts.start_time;
 
 -- name: GetAvailabilityByDate :many
@@ -62,6 +64,17 @@ SELECT EXISTS(
     AND date = $3
 ) AS has_conflict;
 
+-- name: CheckAvailabilityBookingConflict :one
+-- Check if the user already manages an active booking picked up during this slot/date
+SELECT EXISTS(
+  SELECT 1 FROM booking b
+  JOIN time_slots ts ON ts.id = sqlc.arg('time_slot_id')
+  WHERE b.manager_id = sqlc.arg('user_id')
+    AND b.status NOT IN ('cancelled', 'expired', 'no_show', 'fulfilled')
+    AND b.pick_up_date >= sqlc.arg('date')::DATE + ts.start_time
+    AND b.pick_up_date < sqlc.arg('date')::DATE + ts.end_time
+) AS has_conflict;
+
 -- name: DeleteAvailability :exec
 DELETE FROM user_availability WHERE id = $1;
 
@@ -82,6 +95,26 @@ JOIN users u ON ua.user_id = u.id
 WHERE ua.date = $1
   AND ua.time_slot_id = $2;
 
+-- name: ListOpenAvailability :many
+-- Upcoming availability in a date range that no active booking has claimed
+SELECT
+  ua.id,
+  ua.time_slot_id,
+  ua.date,
+  ts.start_time,
+  ts.end_time
+FROM user_availability ua
+JOIN time_slots ts ON ua.time_slot_id = ts.id
+WHERE ua.date >= sqlc.arg('from_date')::DATE
+  AND ua.date <= sqlc.arg('to_date')::DATE
+  AND ua.date + ts.start_time > LOCALTIMESTAMP
+  AND NOT EXISTS (
+    SELECT 1 FROM booking b
+    WHERE b.availability_id = ua.id
+      AND b.status NOT IN ('cancelled', 'expired', 'no_show', 'fulfilled')
+  )
+ORDER BY ua.date, ts.start_time, ua.id;
+
 -- name: GetAvailabilityCountByUser :one
 -- Get count of availability entries for a user in a date range
 SELECT COUNT(*) as count
@@ -89,3 +122,9 @@ FROM user_availability
 WHERE user_id = $1
   AND date >= $2
   AND date <= $3;
+
+-- name: DeletePastUnusedAvailability :execrows
+-- Slots more than retention_days in the past that no booking ever used
+DELETE FROM user_availability ua
+WHERE ua.date < CURRENT_DATE - sqlc.arg('retention_days')::INT
+  AND NOT EXISTS (SELECT 1 FROM booking b WHERE b.availability_id = ua.id);
@@ -0,0 +1,16 @@
+-- name: CreateBookingHandoff :one
+INSERT INTO booking_handoffs (booking_id, borrowing_id, picked_up_by)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetBookingHandoffForUpdate :one
+SELECT * FROM booking_handoffs
+WHERE booking_id = $1
+FOR UPDATE;
+
+-- name: RecordBookingHandoffReturn :one
+UPDATE booking_handoffs
+SET returned_by = $2,
+    returned_at = NOW()
+WHERE booking_id = $1 AND returned_at IS NULL
+RETURNING *;
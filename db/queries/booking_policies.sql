@@ -0,0 +1,20 @@
+-- name: UpsertGroupBookingPolicy :one
+INSERT INTO group_booking_policies (group_id, confirmation_window_hours, pickup_cutoff_hours, updated_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (group_id) DO UPDATE
+SET confirmation_window_hours = EXCLUDED.confirmation_window_hours,
+    pickup_cutoff_hours = EXCLUDED.pickup_cutoff_hours,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetGroupBookingPolicy :one
+SELECT * FROM group_booking_policies
+WHERE group_id = $1;
+
+-- name: ListGroupBookingPolicies :many
+SELECT * FROM group_booking_policies;
+
+-- name: DeleteGroupBookingPolicy :execrows
+DELETE FROM group_booking_policies
+WHERE group_id = $1;
@@ -1,9 +1,12 @@
 -- name: CreateBooking :one
 INSERT INTO booking (
     id, requester_id, manager_id, item_id, group_id, availability_id,
-    pick_up_date, pick_up_location, return_date, return_location, status
+    pick_up_date, pick_up_location, return_date, return_location, status, is_test
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+    COALESCE((SELECT g.sandbox FROM groups g WHERE g.id = $5), FALSE)
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING *;
 
 -- name: GetBookingByID :one
@@ -47,8 +50,13 @@ WHERE (sqlc.narg('status')::request_status IS NULL OR b.status = sqlc.narg('stat
   AND (sqlc.narg('group_id')::UUID IS NULL OR b.group_id = sqlc.narg('group_id'))
   AND (sqlc.narg('from_date')::DATE IS NULL OR ua.date >= sqlc.narg('from_date'))
   AND (sqlc.narg('to_date')::DATE IS NULL OR ua.date <= sqlc.narg('to_date'))
-ORDER BY ua.date, b.pick_up_date
-LIMIT $1 OFFSET $2;
+ORDER BY
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'created_at' AND NOT sqlc.arg('sort_desc')::BOOLEAN THEN b.created_at END ASC,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'created_at' AND sqlc.arg('sort_desc')::BOOLEAN THEN b.created_at END DESC,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'date' AND sqlc.arg('sort_desc')::BOOLEAN THEN ua.date END DESC,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'date' AND sqlc.arg('sort_desc')::BOOLEAN THEN b.pick_up_date END DESC,
+  ua.date, b.pick_up_date, b.id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: ListBookingsByUser :many
 SELECT
@@ -63,10 +71,16 @@ LEFT JOIN users manager ON b.manager_id = manager.id
 JOIN items i ON b.item_id = i.id
 JOIN user_availability ua ON b.availability_id = ua.id
 JOIN time_slots ts ON ua.time_slot_id = ts.id
-WHERE b.requester_id = $1
+WHERE b.requester_id = sqlc.arg('requester_id')
   AND (sqlc.narg('status')::request_status IS NULL OR b.status = sqlc.narg('status'))
-ORDER BY ua.date DESC
-LIMIT $2 OFFSET $3;
+  AND (sqlc.narg('from_date')::DATE IS NULL OR ua.date >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR ua.date <= sqlc.narg('to_date'))
+ORDER BY
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'created_at' AND NOT sqlc.arg('sort_desc')::BOOLEAN THEN b.created_at END ASC,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'created_at' AND sqlc.arg('sort_desc')::BOOLEAN THEN b.created_at END DESC,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'date' AND NOT sqlc.arg('sort_desc')::BOOLEAN THEN ua.date END ASC,
+  ua.date DESC, b.id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: ListPendingConfirmation :many
 SELECT
@@ -96,14 +110,45 @@ RETURNING *;
 
 -- name: CancelBooking :one
 UPDATE booking
-SET status = 'cancelled'
+SET status = 'cancelled',
+    cancelled_by = $2,
+    cancelled_at = NOW(),
+    cancellation_reason = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: FulfillBooking :one
+-- the item was handed over at pickup, with the unit for items tracked by unit
+UPDATE booking
+SET status = 'fulfilled', unit_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- like ConfirmBooking and CancelBooking but with the time given; only the
+-- seeder uses these to write historical bookings
+-- name: SeedConfirmBooking :one
+UPDATE booking
+SET status = 'confirmed',
+    confirmed_at = $2,
+    confirmed_by = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: SeedCancelBooking :one
+UPDATE booking
+SET status = 'cancelled',
+    cancelled_at = $2,
+    cancelled_by = $3,
+    cancellation_reason = $4
 WHERE id = $1
 RETURNING *;
 
 -- name: GetExpiredBookings :many
-SELECT id FROM booking
-WHERE status = 'pending_confirmation'
-  AND created_at < NOW() - INTERVAL '48 hours';
+-- Pending bookings past their group's confirmation window, or the default one
+SELECT b.id FROM booking b
+LEFT JOIN group_booking_policies p ON p.group_id = b.group_id
+WHERE b.status = 'pending_confirmation'
+  AND b.created_at < NOW() - make_interval(hours => COALESCE(p.confirmation_window_hours, sqlc.arg('default_window_hours')::INT));
 
 -- name: CountBookings :one
 SELECT COUNT(*) as count
@@ -117,5 +162,91 @@ WHERE (sqlc.narg('status')::request_status IS NULL OR b.status = sqlc.narg('stat
 -- name: CountBookingsByUser :one
 SELECT COUNT(*) as count
 FROM booking b
+JOIN user_availability ua ON b.availability_id = ua.id
+WHERE b.requester_id = sqlc.arg('requester_id')
+  AND (sqlc.narg('status')::request_status IS NULL OR b.status = sqlc.narg('status'))
+  AND (sqlc.narg('from_date')::DATE IS NULL OR ua.date >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR ua.date <= sqlc.narg('to_date'));
+
+-- name: RecordBookingVerification :one
+INSERT INTO booking_verifications (booking_id, verified_by, matched)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: HasVerifiedBooking :one
+SELECT EXISTS (
+    SELECT 1 FROM booking_verifications
+    WHERE booking_id = $1 AND matched
+) AS verified;
+
+-- name: RescheduleBooking :one
+-- moves a booking to another availability slot; the status is left alone
+UPDATE booking
+SET availability_id = $2,
+    manager_id = $3,
+    pick_up_date = $4,
+    pick_up_location = $5,
+    return_date = $6,
+    return_location = $7
+WHERE id = $1
+RETURNING *;
+
+-- name: RestoreCancelledBooking :one
+UPDATE booking
+SET status = 'confirmed',
+    cancelled_by = NULL,
+    cancelled_at = NULL,
+    cancellation_reason = NULL
+WHERE id = $1 AND status = 'cancelled'
+RETURNING *;
+
+-- name: AppendBookingEvent :one
+INSERT INTO booking_events (booking_id, actor_id, from_status, to_status, payload)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListBookingEvents :many
+SELECT * FROM booking_events
+WHERE booking_id = $1
+ORDER BY created_at, id;
+
+-- name: ListBookingStatuses :many
+SELECT id, status FROM booking ORDER BY id;
+
+-- name: ListAllBookingEvents :many
+SELECT * FROM booking_events
+ORDER BY booking_id, created_at, id;
+
+-- name: ExpireBooking :execrows
+-- Only a booking still waiting for confirmation is expired
+UPDATE booking
+SET status = 'expired'
+WHERE id = $1 AND status = 'pending_confirmation';
+
+-- name: CountUpcomingItemBookings :one
+-- Live bookings of the item picked up between now and the cutoff
+SELECT COUNT(*) FROM booking
+WHERE item_id = $1
+  AND status IN ('pending_confirmation', 'confirmed')
+  AND pick_up_date >= NOW()
+  AND pick_up_date < sqlc.arg('cutoff')::TIMESTAMP;
+
+-- name: ListUpcomingBookingsByUser :many
+-- The user's live bookings still to be picked up, soonest first
+SELECT
+    b.*,
+    manager.email as manager_email,
+    i.name as item_name,
+    ua.date as availability_date,
+    ts.start_time,
+    ts.end_time
+FROM booking b
+LEFT JOIN users manager ON b.manager_id = manager.id
+JOIN items i ON b.item_id = i.id
+JOIN user_availability ua ON b.availability_id = ua.id
+JOIN time_slots ts ON ua.time_slot_id = ts.id
 WHERE b.requester_id = $1
-  AND (sqlc.narg('status')::request_status IS NULL OR b.status = sqlc.narg('status'));
+  AND b.status IN ('pending_confirmation', 'confirmed')
+  AND b.pick_up_date >= NOW()
+ORDER BY b.pick_up_date, b.id
+LIMIT $2;
@@ -2,16 +2,16 @@
 SELECT id, user_id, group_id, item_id, quantity,
     borrowed_at, due_date, returned_at,
     before_condition, before_condition_url,
-    after_condition, after_condition_url
+    after_condition, after_condition_url, unit_id
 FROM borrowings WHERE id = $1;
 
 -- this function creates a new borrowing record for a user borrowing an item
 -- name: BorrowItem :one
 INSERT INTO borrowings (
     user_id, group_id, item_id, quantity,
-    due_date, before_condition, before_condition_url
+    due_date, before_condition, before_condition_url, unit_id
 )
-SELECT $1, $2, i.id, $4, $5, $6, $7
+SELECT $1, $2, i.id, $4, $5, $6, $7, $8
 FROM items i
 WHERE i.id = $3
   AND i.type IN ('medium', 'high')
@@ -19,7 +19,7 @@ WHERE i.id = $3
 RETURNING id, user_id, group_id, item_id, quantity,
     borrowed_at, due_date, returned_at,
     before_condition, before_condition_url,
-    after_condition, after_condition_url;
+    after_condition, after_condition_url, unit_id;
 
 -- this function records the return of a borrowed item, updating the after condition and return timestamp (basically closing the borrowing record)
 -- it only works if the item is currently borrowed (i.e., has no return timestamp yet)
@@ -33,7 +33,29 @@ WHERE item_id = $1 AND returned_at IS NULL
 RETURNING id, user_id, group_id, item_id, quantity,
     borrowed_at, due_date, returned_at,
     before_condition, before_condition_url,
-    after_condition, after_condition_url;
+    after_condition, after_condition_url, unit_id;
+
+-- closes one specific borrowing, for returns checked in against a booking
+-- name: ReturnBorrowingByID :one
+UPDATE borrowings
+SET returned_at = NOW(),
+    after_condition = $2,
+    after_condition_url = $3
+WHERE id = $1 AND returned_at IS NULL
+RETURNING id, user_id, group_id, item_id, quantity,
+    borrowed_at, due_date, returned_at,
+    before_condition, before_condition_url,
+    after_condition, after_condition_url, unit_id;
+
+-- moves an unreturned borrowing's due date, for granted extensions
+-- name: ExtendBorrowingDueDate :one
+UPDATE borrowings
+SET due_date = $2
+WHERE id = $1 AND returned_at IS NULL
+RETURNING id, user_id, group_id, item_id, quantity,
+    borrowed_at, due_date, returned_at,
+    before_condition, before_condition_url,
+    after_condition, after_condition_url, unit_id;
 
 -- this function checks if an item is currently borrowed (i.e., not available) by looking for active borrowings without a return timestamp and returns true if the item is available
 -- name: CheckBorrowingItemStatus :one
@@ -47,7 +69,7 @@ SELECT NOT EXISTS (
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE item_id = $1 AND user_id = $2 AND returned_at IS NULL
 FOR UPDATE;
@@ -56,7 +78,7 @@ FOR UPDATE;
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE user_id = $1
 ORDER BY borrowed_at DESC LIMIT $2 OFFSET $3;
@@ -65,7 +87,7 @@ ORDER BY borrowed_at DESC LIMIT $2 OFFSET $3;
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE user_id = $1 AND returned_at IS NULL
 ORDER BY borrowed_at DESC LIMIT $2 OFFSET $3;
@@ -74,7 +96,7 @@ ORDER BY borrowed_at DESC LIMIT $2 OFFSET $3;
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE user_id = $1 AND returned_at IS NOT NULL
 ORDER BY returned_at DESC LIMIT $2 OFFSET $3;
@@ -83,16 +105,24 @@ ORDER BY returned_at DESC LIMIT $2 OFFSET $3;
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE returned_at IS NULL
-ORDER BY borrowed_at DESC LIMIT $1 OFFSET $2;
+  AND (sqlc.narg('group_id')::UUID IS NULL OR group_id = sqlc.narg('group_id'))
+  AND (sqlc.narg('from_date')::DATE IS NULL OR due_date::DATE >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR due_date::DATE <= sqlc.narg('to_date'))
+ORDER BY
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'due_date' AND NOT sqlc.arg('sort_desc')::BOOLEAN THEN due_date END ASC,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'due_date' AND sqlc.arg('sort_desc')::BOOLEAN THEN due_date END DESC,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'borrowed_at' AND NOT sqlc.arg('sort_desc')::BOOLEAN THEN borrowed_at END ASC,
+  borrowed_at DESC, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: GetAllReturnedItems :many
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE returned_at IS NOT NULL
 ORDER BY returned_at DESC LIMIT $1 OFFSET $2;
@@ -101,12 +131,16 @@ ORDER BY returned_at DESC LIMIT $1 OFFSET $2;
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE returned_at IS NULL AND due_date <= $1;
 
 -- name: CountAllActiveBorrowedItems :one
-SELECT COUNT(*) as count FROM borrowings WHERE returned_at IS NULL;
+SELECT COUNT(*) as count FROM borrowings
+WHERE returned_at IS NULL
+  AND (sqlc.narg('group_id')::UUID IS NULL OR group_id = sqlc.narg('group_id'))
+  AND (sqlc.narg('from_date')::DATE IS NULL OR due_date::DATE >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR due_date::DATE <= sqlc.narg('to_date'));
 
 -- name: CountAllReturnedItems :one
 SELECT COUNT(*) as count FROM borrowings WHERE returned_at IS NOT NULL;
@@ -118,4 +152,12 @@ SELECT COUNT(*) as count FROM borrowings WHERE user_id = $1;
 SELECT COUNT(*) as count FROM borrowings WHERE user_id = $1 AND returned_at IS NULL;
 
 -- name: CountReturnedItemsByUserId :one
-SELECT COUNT(*) as count FROM borrowings WHERE user_id = $1 AND returned_at IS NOT NULL;
\ No newline at end of file
+SELECT COUNT(*) as count FROM borrowings WHERE user_id = $1 AND returned_at IS NOT NULL;
+-- writes a borrowing with its whole history in one go; only the seeder uses it
+-- name: SeedBorrowing :one
+INSERT INTO borrowings (
+    user_id, group_id, item_id, quantity, borrowed_at, due_date, returned_at,
+    before_condition, before_condition_url, after_condition, after_condition_url
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *;
@@ -11,3 +11,8 @@ SELECT * FROM borrowing_images WHERE borrowing_id = $1 ORDER BY image_type ASC,
 
 -- name: DeleteBorrowingImage :exec
 DELETE FROM borrowing_images WHERE id = $1;
+
+-- name: SetBorrowingImageVariants :exec
+UPDATE borrowing_images
+SET web_s3_key = $2, thumbnail_s3_key = $3, processed_at = NOW()
+WHERE id = $1;
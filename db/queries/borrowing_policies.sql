@@ -0,0 +1,72 @@
+-- name: CreateBorrowingPolicy :one
+INSERT INTO borrowing_policies (
+    name, role_name, item_type, max_active_borrowings, max_quantity,
+    loan_days, allow_partial_pickup, created_by
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: UpdateBorrowingPolicy :one
+UPDATE borrowing_policies
+SET name = $2,
+    role_name = $3,
+    item_type = $4,
+    max_active_borrowings = $5,
+    max_quantity = $6,
+    loan_days = $7,
+    allow_partial_pickup = $8,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetBorrowingPolicy :one
+SELECT * FROM borrowing_policies
+WHERE id = $1;
+
+-- name: ListBorrowingPolicies :many
+SELECT * FROM borrowing_policies
+ORDER BY created_at;
+
+-- name: DeleteBorrowingPolicy :execrows
+DELETE FROM borrowing_policies
+WHERE id = $1;
+
+-- policies that apply to a user borrowing an item of the given type
+-- name: ListBorrowingPoliciesForUser :many
+SELECT * FROM borrowing_policies
+WHERE (item_type IS NULL OR item_type = sqlc.arg('item_type')::item_type)
+  AND (role_name IS NULL OR role_name IN (
+    SELECT ur.role_name FROM user_roles ur WHERE ur.user_id = sqlc.arg('user_id')
+  ))
+ORDER BY created_at;
+
+-- name: CreateBorrowingBlackout :one
+INSERT INTO borrowing_blackouts (reason, item_type, starts_at, ends_at, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetBorrowingBlackout :one
+SELECT * FROM borrowing_blackouts
+WHERE id = $1;
+
+-- name: ListBorrowingBlackouts :many
+SELECT * FROM borrowing_blackouts
+ORDER BY starts_at;
+
+-- name: DeleteBorrowingBlackout :execrows
+DELETE FROM borrowing_blackouts
+WHERE id = $1;
+
+-- blackouts not yet over that cover items of the given type
+-- name: ListBorrowingBlackoutsForItemType :many
+SELECT * FROM borrowing_blackouts
+WHERE (item_type IS NULL OR item_type = sqlc.arg('item_type')::item_type)
+  AND ends_at > NOW()
+ORDER BY starts_at;
+
+-- name: CountActiveBorrowingsByItemType :many
+SELECT i.type, COUNT(*) AS count
+FROM borrowings b
+JOIN items i ON i.id = b.item_id
+WHERE b.user_id = $1 AND b.returned_at IS NULL
+GROUP BY i.type;
@@ -0,0 +1,57 @@
+-- name: UpsertCalendarLink :one
+INSERT INTO calendar_links (user_id, ics_url)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET ics_url = EXCLUDED.ics_url, last_synced_at = NULL, last_error = NULL, removed_slots = 0
+RETURNING user_id, ics_url, last_synced_at, last_error, removed_slots, created_at;
+
+-- name: GetCalendarLink :one
+SELECT user_id, ics_url, last_synced_at, last_error, removed_slots, created_at
+FROM calendar_links WHERE user_id = $1;
+
+-- name: ListCalendarLinks :many
+SELECT user_id, ics_url, last_synced_at, last_error, removed_slots, created_at
+FROM calendar_links ORDER BY user_id;
+
+-- name: DeleteCalendarLink :execrows
+DELETE FROM calendar_links WHERE user_id = $1;
+
+-- name: RecordCalendarSync :exec
+UPDATE calendar_links
+SET last_synced_at = NOW(), last_error = $2, removed_slots = removed_slots + sqlc.arg('removed')::INT
+WHERE user_id = $1;
+
+-- name: UpsertCalendarFeedToken :exec
+INSERT INTO calendar_feed_tokens (user_id, token_hash)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET token_hash = EXCLUDED.token_hash, created_at = NOW();
+
+-- name: GetUserIDByCalendarFeedToken :one
+SELECT user_id FROM calendar_feed_tokens WHERE token_hash = $1;
+
+-- name: DeleteCalendarFeedToken :execrows
+DELETE FROM calendar_feed_tokens WHERE user_id = $1;
+
+-- name: ListCalendarBookings :many
+-- Bookings a user requested or manages that are still worth showing in their
+-- calendar: not cancelled, and returned at most 30 days ago
+SELECT
+    b.id,
+    b.requester_id,
+    b.status,
+    b.pick_up_date,
+    b.pick_up_location,
+    b.return_date,
+    b.return_location,
+    i.name AS item_name,
+    ts.start_time,
+    ts.end_time
+FROM booking b
+JOIN items i ON b.item_id = i.id
+LEFT JOIN user_availability ua ON b.availability_id = ua.id
+LEFT JOIN time_slots ts ON ua.time_slot_id = ts.id
+WHERE (b.requester_id = sqlc.arg('user_id')::UUID OR b.manager_id = sqlc.arg('user_id')::UUID)
+  AND b.status IN ('pending_confirmation', 'confirmed', 'fulfilled')
+  AND b.return_date >= NOW() - INTERVAL '30 days'
+ORDER BY b.pick_up_date, b.id;
@@ -0,0 +1,59 @@
+-- name: CreateReturnCampaign :one
+INSERT INTO return_campaigns (name, term_end, created_by)
+VALUES ($1, $2, $3)
+RETURNING id, name, term_end, status, created_by, created_at, last_run_at, completed_at, report_id;
+
+-- name: GetReturnCampaignByID :one
+SELECT id, name, term_end, status, created_by, created_at, last_run_at, completed_at, report_id
+FROM return_campaigns WHERE id = $1;
+
+-- name: ListReturnCampaigns :many
+SELECT id, name, term_end, status, created_by, created_at, last_run_at, completed_at, report_id
+FROM return_campaigns
+ORDER BY created_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CountReturnCampaigns :one
+SELECT COUNT(*) FROM return_campaigns;
+
+-- name: ListActiveReturnCampaigns :many
+SELECT id, name, term_end, status, created_by, created_at, last_run_at, completed_at, report_id
+FROM return_campaigns WHERE status = 'active'
+ORDER BY term_end;
+
+-- name: RecordReturnCampaignRun :exec
+UPDATE return_campaigns SET last_run_at = NOW() WHERE id = $1;
+
+-- name: CompleteReturnCampaign :one
+UPDATE return_campaigns
+SET status = 'completed', completed_at = NOW(), report_id = $2
+WHERE id = $1 AND status = 'active'
+RETURNING id, name, term_end, status, created_by, created_at, last_run_at, completed_at, report_id;
+
+-- name: CancelReturnCampaign :one
+UPDATE return_campaigns
+SET status = 'cancelled', completed_at = NOW()
+WHERE id = $1 AND status = 'active'
+RETURNING id, name, term_end, status, created_by, created_at, last_run_at, completed_at, report_id;
+
+-- name: ListCampaignBorrowings :many
+-- Active borrowings due on or before the campaign's term end
+SELECT b.id, b.user_id, b.group_id, b.item_id, b.quantity, b.due_date,
+    i.name AS item_name, i.type AS item_type, g.name AS group_name, u.email AS user_email
+FROM borrowings b
+JOIN items i ON b.item_id = i.id
+LEFT JOIN groups g ON b.group_id = g.id
+LEFT JOIN users u ON b.user_id = u.id
+WHERE b.returned_at IS NULL
+  AND b.due_date < sqlc.arg('term_end')::DATE + 1
+ORDER BY b.due_date;
+
+-- name: RecordCampaignNotice :execrows
+-- Returns 0 when this stage was already sent for the borrowing
+INSERT INTO return_campaign_notices (campaign_id, borrowing_id, stage)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: GetGroupAdminIDs :many
+SELECT user_id FROM user_roles
+WHERE role_name = 'group_admin' AND scope = 'group' AND scope_id = $1;
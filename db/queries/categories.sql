@@ -0,0 +1,48 @@
+-- name: CreateCategory :one
+INSERT INTO categories (slug, name)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: ListCategories :many
+SELECT * FROM categories
+ORDER BY name ASC;
+
+-- name: GetCategoryBySlug :one
+SELECT * FROM categories WHERE slug = $1;
+
+-- name: SetItemCategory :exec
+INSERT INTO item_categories (item_id, category_id)
+VALUES ($1, $2)
+ON CONFLICT (item_id) DO UPDATE
+SET category_id = EXCLUDED.category_id;
+
+-- name: ClearItemCategory :exec
+DELETE FROM item_categories WHERE item_id = $1;
+
+-- name: ListCategoriesForItems :many
+SELECT ic.item_id, c.slug
+FROM item_categories ic
+JOIN categories c ON c.id = ic.category_id
+WHERE ic.item_id = ANY(@item_ids::uuid[]);
+
+-- name: UpsertTag :one
+INSERT INTO tags (name)
+VALUES ($1)
+ON CONFLICT (name) DO UPDATE
+SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: AddItemTag :exec
+INSERT INTO item_tags (item_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: ClearItemTags :exec
+DELETE FROM item_tags WHERE item_id = $1;
+
+-- name: ListTagsForItems :many
+SELECT it.item_id, t.name
+FROM item_tags it
+JOIN tags t ON t.id = it.tag_id
+WHERE it.item_id = ANY(@item_ids::uuid[])
+ORDER BY t.name ASC;
@@ -1,9 +1,13 @@
 -- name: GetCartItemsForCheckout :many
 SELECT c.group_id, c.user_id, c.item_id, c.quantity,
-       i.type, i.stock, i.name
+       i.type, i.stock, i.name,
+       -- owned items go only to the owner and the groups it is shared with
+       (i.owner_group_id IS NULL OR i.owner_group_id = c.group_id OR EXISTS (
+         SELECT 1 FROM item_group_shares sh WHERE sh.item_id = i.id AND sh.group_id = c.group_id))::BOOLEAN AS available_to_group
 FROM cart c
 JOIN items i ON c.item_id = i.id
 WHERE c.group_id = $1 AND c.user_id = $2
+  AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = i.id AND d.status = 'binned')
 FOR UPDATE OF i;
 
 -- name: DecrementStockForLowItem :exec
@@ -0,0 +1,29 @@
+-- name: CreateDamageReport :one
+INSERT INTO damage_reports (
+    borrowing_id, item_id, group_id, reported_by,
+    before_condition, after_condition, severity, notes, photo_keys
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetDamageReportByID :one
+SELECT * FROM damage_reports WHERE id = $1;
+
+-- name: ListDamageReports :many
+-- Newest first, optionally narrowed to a status and a group
+SELECT * FROM damage_reports
+WHERE (sqlc.narg('status')::damage_report_status IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('group_id')::UUID IS NULL OR group_id = sqlc.narg('group_id'))
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountDamageReports :one
+SELECT COUNT(*) FROM damage_reports
+WHERE (sqlc.narg('status')::damage_report_status IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('group_id')::UUID IS NULL OR group_id = sqlc.narg('group_id'));
+
+-- name: UpdateDamageReport :one
+UPDATE damage_reports
+SET status = $2, severity = $3, resolution_notes = $4, resolved_by = $5, resolved_at = $6
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,63 @@
+-- name: CreateDeletionRequest :one
+INSERT INTO deletion_requests (entity_type, entity_id, entity_name, requested_by, reason)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, entity_type, entity_id, entity_name, status, requested_by, requested_at,
+    approved_by, approved_at, purge_after, resolved_by, resolved_at, reason;
+
+-- name: GetDeletionRequestByID :one
+SELECT id, entity_type, entity_id, entity_name, status, requested_by, requested_at,
+    approved_by, approved_at, purge_after, resolved_by, resolved_at, reason
+FROM deletion_requests WHERE id = $1;
+
+-- name: GetDeletionRequestForUpdate :one
+SELECT id, entity_type, entity_id, entity_name, status, requested_by, requested_at,
+    approved_by, approved_at, purge_after, resolved_by, resolved_at, reason
+FROM deletion_requests WHERE id = $1 FOR UPDATE;
+
+-- name: GetOpenDeletionRequest :one
+SELECT id, entity_type, entity_id, entity_name, status, requested_by, requested_at,
+    approved_by, approved_at, purge_after, resolved_by, resolved_at, reason
+FROM deletion_requests
+WHERE entity_type = $1 AND entity_id = $2 AND status IN ('pending', 'binned');
+
+-- name: ListDeletionRequests :many
+SELECT id, entity_type, entity_id, entity_name, status, requested_by, requested_at,
+    approved_by, approved_at, purge_after, resolved_by, resolved_at, reason
+FROM deletion_requests
+WHERE (sqlc.narg('status')::deletion_status IS NULL OR status = sqlc.narg('status'))
+ORDER BY requested_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountDeletionRequests :one
+SELECT COUNT(*) as count
+FROM deletion_requests
+WHERE (sqlc.narg('status')::deletion_status IS NULL OR status = sqlc.narg('status'));
+
+-- name: ApproveDeletionRequest :one
+UPDATE deletion_requests
+SET status = 'binned', approved_by = $2, approved_at = NOW(), purge_after = $3
+WHERE id = $1
+RETURNING id, entity_type, entity_id, entity_name, status, requested_by, requested_at,
+    approved_by, approved_at, purge_after, resolved_by, resolved_at, reason;
+
+-- name: ResolveDeletionRequest :one
+UPDATE deletion_requests
+SET status = $2, resolved_by = $3, resolved_at = NOW()
+WHERE id = $1
+RETURNING id, entity_type, entity_id, entity_name, status, requested_by, requested_at,
+    approved_by, approved_at, purge_after, resolved_by, resolved_at, reason;
+
+-- name: ListExpiredDeletionRequests :many
+-- binned requests past their retention window, for the purge sweep
+SELECT id, entity_type, entity_id, entity_name, status, requested_by, requested_at,
+    approved_by, approved_at, purge_after, resolved_by, resolved_at, reason
+FROM deletion_requests
+WHERE status = 'binned' AND purge_after <= NOW()
+ORDER BY purge_after;
+
+-- name: GetBinnedDeletionRequestForUpdate :one
+SELECT id, entity_type, entity_id, entity_name, status, requested_by, requested_at,
+    approved_by, approved_at, purge_after, resolved_by, resolved_at, reason
+FROM deletion_requests
+WHERE entity_type = $1 AND entity_id = $2 AND status = 'binned'
+FOR UPDATE;
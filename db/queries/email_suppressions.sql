@@ -0,0 +1,18 @@
+-- name: SuppressEmail :exec
+-- a later notification replaces the reason, but the address stays suppressed
+-- from when it first was
+INSERT INTO email_suppressions (email, reason, detail)
+VALUES (lower(@email::TEXT), @reason, @detail)
+ON CONFLICT (email) DO UPDATE SET reason = EXCLUDED.reason, detail = EXCLUDED.detail;
+
+-- name: GetEmailSuppression :one
+SELECT * FROM email_suppressions
+WHERE email = lower(@email::TEXT);
+
+-- name: ListEmailSuppressions :many
+SELECT * FROM email_suppressions
+ORDER BY created_at DESC;
+
+-- name: DeleteEmailSuppression :execrows
+DELETE FROM email_suppressions
+WHERE email = lower(@email::TEXT);
@@ -0,0 +1,27 @@
+-- name: CreateBorrowingExtension :one
+INSERT INTO borrowing_extensions (
+    borrowing_id, requested_by, current_due_date, requested_due_date, reason, status, decision_notes, decided_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, CASE WHEN $6 = 'pending' THEN NULL ELSE NOW() END)
+RETURNING *;
+
+-- name: GetBorrowingExtensionByID :one
+SELECT * FROM borrowing_extensions WHERE id = $1;
+
+-- name: ListBorrowingExtensions :many
+-- Oldest first, so approvers work through the queue in order
+SELECT * FROM borrowing_extensions
+WHERE (sqlc.narg('status')::extension_status IS NULL OR status = sqlc.narg('status'))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountBorrowingExtensions :one
+SELECT COUNT(*) FROM borrowing_extensions
+WHERE (sqlc.narg('status')::extension_status IS NULL OR status = sqlc.narg('status'));
+
+-- name: DecideBorrowingExtension :one
+-- No rows when the request was already decided
+UPDATE borrowing_extensions
+SET status = $2, decision_notes = $3, decided_by = $4, decided_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
@@ -0,0 +1,23 @@
+-- name: UpsertFairnessPolicy :one
+INSERT INTO item_fairness_policies (item_id, window_days, created_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (item_id) DO UPDATE
+SET window_days = EXCLUDED.window_days
+RETURNING *;
+
+-- name: GetFairnessPolicy :one
+SELECT * FROM item_fairness_policies
+WHERE item_id = $1;
+
+-- name: DeleteFairnessPolicy :execrows
+DELETE FROM item_fairness_policies
+WHERE item_id = $1;
+
+-- name: ListPendingRequestCandidates :many
+-- Every pending request for an item with when the requesting group last borrowed it
+SELECT r.id, r.group_id, r.requested_at,
+    (SELECT MAX(b.borrowed_at) FROM borrowings b
+     WHERE b.item_id = r.item_id AND b.group_id = r.group_id)::TIMESTAMP AS group_last_had_at
+FROM requests r
+WHERE r.item_id = $1 AND r.status = 'pending'
+ORDER BY r.requested_at ASC;
@@ -0,0 +1,47 @@
+-- name: UpsertItemFees :one
+INSERT INTO item_fees (item_id, late_fee_cents, damage_fee_cents, updated_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (item_id) DO UPDATE
+SET late_fee_cents = EXCLUDED.late_fee_cents,
+    damage_fee_cents = EXCLUDED.damage_fee_cents,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetItemFees :one
+SELECT * FROM item_fees WHERE item_id = $1;
+
+-- name: CreateFine :one
+INSERT INTO fines (user_id, borrowing_id, item_id, damage_report_id, reason, amount_cents, days_late)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetFineByID :one
+SELECT * FROM fines WHERE id = $1;
+
+-- name: ListFinesByBorrowing :many
+SELECT * FROM fines WHERE borrowing_id = $1 ORDER BY reason;
+
+-- name: ListFines :many
+-- Newest first, optionally narrowed to a status and a user
+SELECT * FROM fines
+WHERE (sqlc.narg('status')::fine_status IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('user_id')::UUID IS NULL OR user_id = sqlc.narg('user_id'))
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountFines :one
+SELECT COUNT(*) FROM fines
+WHERE (sqlc.narg('status')::fine_status IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('user_id')::UUID IS NULL OR user_id = sqlc.narg('user_id'));
+
+-- name: GetUnpaidFineTotal :one
+SELECT COALESCE(SUM(amount_cents), 0)::BIGINT FROM fines
+WHERE user_id = $1 AND status = 'unpaid';
+
+-- name: ResolveFine :one
+-- Marks an unpaid fine paid or waived; no rows when it was already resolved
+UPDATE fines
+SET status = $2, resolution_notes = $3, resolved_by = $4, resolved_at = NOW()
+WHERE id = $1 AND status = 'unpaid'
+RETURNING *;
@@ -0,0 +1,25 @@
+-- name: CreateGroupJoinRequest :one
+INSERT INTO group_join_requests (group_id, user_id, message)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetGroupJoinRequestByID :one
+SELECT * FROM group_join_requests WHERE id = $1;
+
+-- name: ListGroupJoinRequests :many
+-- Oldest first, so admins work through the queue in order
+SELECT * FROM group_join_requests
+WHERE group_id = sqlc.arg('group_id') AND status = sqlc.arg('status')
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountGroupJoinRequests :one
+SELECT COUNT(*) FROM group_join_requests
+WHERE group_id = $1 AND status = $2;
+
+-- name: DecideGroupJoinRequest :one
+-- No rows when the request was already decided
+UPDATE group_join_requests
+SET status = $2, decision_notes = $3, decided_by = $4, decided_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
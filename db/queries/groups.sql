@@ -1,24 +1,38 @@
 -- name: GetGroupByID :one
-SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key FROM groups WHERE id = $1;
+SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox FROM groups
+WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'group' AND d.entity_id = groups.id AND d.status = 'binned');
+
+-- name: GetGroupByIDIncludingBinned :one
+-- also finds groups in the recycle bin; only for admin, trash and purge paths
+SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox FROM groups WHERE id = $1;
 
 -- name: GetAllGroups :many
-SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key FROM groups ORDER BY name;
+SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox FROM groups
+WHERE NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'group' AND d.entity_id = groups.id AND d.status = 'binned')
+ORDER BY name;
 
 -- name: CreateGroup :one
-INSERT INTO groups (name, description) VALUES ($1, $2)
-RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key;
+INSERT INTO groups (name, description, sandbox) VALUES ($1, $2, $3)
+RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox;
 
 -- name: UpdateGroup :one
 UPDATE groups SET name = $2, description = $3 WHERE id = $1
-RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key;
+RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox;
 
 -- name: DeleteGroup :exec
 DELETE FROM groups WHERE id = $1;
 
 -- name: GetGroupByName :one
-SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key
+SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox
 FROM groups WHERE name = $1;
 
 -- name: UpdateGroupLogo :one
 UPDATE groups SET logo_s3_key = $2, logo_thumbnail_s3_key = $3 WHERE id = $1
-RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key;
+RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox;
+
+-- name: SetGroupSandbox :one
+UPDATE groups SET sandbox = $2 WHERE id = $1
+RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox;
+
+-- name: IsGroupSandbox :one
+SELECT sandbox FROM groups WHERE id = $1;
@@ -0,0 +1,22 @@
+-- name: GetInventoryDigest :one
+-- Catalogue-wide counts for the weekly digest; since is the start of the
+-- week it covers
+SELECT
+    (SELECT COUNT(*) FROM items i
+        WHERE NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = i.id AND d.status = 'binned'))::BIGINT AS items,
+    (SELECT COUNT(*) FROM items i
+        WHERE i.stock = 0
+          AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = i.id AND d.status = 'binned'))::BIGINT AS out_of_stock,
+    (SELECT COUNT(*) FROM borrowings WHERE returned_at IS NULL)::BIGINT AS active_borrowings,
+    (SELECT COUNT(*) FROM borrowings WHERE returned_at IS NULL AND due_date < NOW())::BIGINT AS overdue_borrowings,
+    (SELECT COUNT(*) FROM borrowings WHERE borrowed_at >= sqlc.arg('since'))::BIGINT AS borrowed_since,
+    (SELECT COUNT(*) FROM borrowings WHERE returned_at >= sqlc.arg('since'))::BIGINT AS returned_since,
+    (SELECT COUNT(*) FROM requests WHERE status = 'pending')::BIGINT AS pending_requests,
+    (SELECT COUNT(*) FROM damage_reports WHERE status = 'open')::BIGINT AS open_damage_reports;
+
+-- name: ListLowStockItems :many
+SELECT i.id, i.name, i.type, i.stock FROM items i
+WHERE i.stock <= sqlc.arg('threshold')::INT
+  AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = i.id AND d.status = 'binned')
+ORDER BY i.stock ASC, i.name ASC
+LIMIT 25;
@@ -6,9 +6,16 @@ RETURNING *;
 -- name: GetItemImageByID :one
 SELECT * FROM item_images WHERE id = $1;
 
+-- name: GetItemImageByOriginalKey :one
+SELECT * FROM item_images WHERE original_s3_key = $1;
+
 -- name: ListItemImagesByItem :many
 SELECT * FROM item_images WHERE item_id = $1 ORDER BY display_order ASC, created_at ASC;
 
+-- name: ListPrimaryItemImagesForItems :many
+SELECT * FROM item_images
+WHERE is_primary AND item_id = ANY(@item_ids::uuid[]);
+
 -- name: UnsetPrimaryItemImages :exec
 UPDATE item_images SET is_primary = FALSE WHERE item_id = $1;
 
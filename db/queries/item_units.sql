@@ -0,0 +1,49 @@
+-- name: CreateItemUnit :one
+INSERT INTO item_units (item_id, serial_number, asset_tag, condition, notes)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetItemUnitByID :one
+SELECT * FROM item_units WHERE id = $1;
+
+-- name: GetItemUnitByTag :one
+-- A unit by the asset tag or serial number on it, asset tags first
+SELECT * FROM item_units
+WHERE asset_tag = sqlc.arg('code')::text OR serial_number = sqlc.arg('code')::text
+ORDER BY asset_tag = sqlc.arg('code')::text DESC
+LIMIT 1;
+
+-- name: GetItemUnitForUpdate :one
+SELECT * FROM item_units WHERE id = $1 FOR UPDATE;
+
+-- name: ListItemUnits :many
+SELECT * FROM item_units
+WHERE item_id = $1
+ORDER BY created_at, id;
+
+-- name: ItemHasUnits :one
+-- Items with units are tracked by unit, retired ones included
+SELECT EXISTS (SELECT 1 FROM item_units WHERE item_id = $1);
+
+-- name: GetAvailableItemUnit :one
+-- The longest-registered available unit, for handing out when none was picked
+SELECT * FROM item_units
+WHERE item_id = $1 AND status = 'available'
+ORDER BY created_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: UpdateItemUnit :one
+UPDATE item_units
+SET serial_number = $2, asset_tag = $3, condition = $4, status = $5, notes = $6, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetItemUnitStatus :exec
+UPDATE item_units SET status = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: ReturnItemUnit :exec
+-- Puts a unit back on the shelf, recording the condition it came back in
+UPDATE item_units
+SET status = 'available', condition = COALESCE(sqlc.narg('condition'), condition), updated_at = NOW()
+WHERE id = sqlc.arg('id');
@@ -1,29 +1,78 @@
 -- name: GetAllItems :many
-SELECT id, name, description, type, stock, urls from items ORDER BY name ASC LIMIT $1 OFFSET $2;
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version from items
+WHERE archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  -- owned items only for members of the owner or a group it is shared with
+  AND (sqlc.narg('viewer_id')::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = sqlc.narg('viewer_id') AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
+ORDER BY name ASC LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListAllItems :many
+-- the whole inventory, for exports
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version FROM items
+WHERE archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+ORDER BY name ASC, id ASC;
 
 -- name: CreateItem :one
 INSERT INTO items (name, description, type, stock, urls)
 VALUES ($1, $2, $3, $4, sqlc.narg('urls'))
-RETURNING id, name, description, type, stock, urls;
+RETURNING id, name, description, type, stock, urls, archived_at, owner_group_id, version;
+
+-- name: CreateItems :copyfrom
+-- bulk insert for seeding, one COPY instead of an INSERT per item
+INSERT INTO items (name, description, type, stock, urls)
+VALUES ($1, $2, $3, $4, $5);
 
 -- name: GetItemsByType :many
-SELECT id, name, description, type, stock, urls FROM items WHERE type = $1 ORDER BY name ASC LIMIT $2 OFFSET $3;
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version FROM items
+WHERE type = sqlc.arg('type') AND archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  -- owned items only for members of the owner or a group it is shared with
+  AND (sqlc.narg('viewer_id')::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = sqlc.narg('viewer_id') AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
+ORDER BY name ASC LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: GetItemByID :one
-SELECT id, name, description, type, stock, urls FROM items WHERE id = $1;
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version FROM items
+WHERE id = $1 AND archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned');
 
 -- name: GetItemByIDForUpdate :one
-SELECT id, name, description, type, stock, urls FROM items WHERE id = $1 FOR UPDATE;
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version FROM items
+WHERE id = $1 AND archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+FOR UPDATE;
+
+-- name: LockItemVersion :one
+-- the item's version, locking the row until the transaction ends so an
+-- If-Match check still holds when the update that follows it runs
+SELECT version FROM items WHERE id = $1 FOR UPDATE;
+
+-- name: GetItemByIDIncludingBinned :one
+-- also finds items in the recycle bin or archived; only for admin, trash,
+-- history and purge paths
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version FROM items WHERE id = $1;
 
 -- name: UpdateItem :one
 UPDATE items
 SET name = $2, description = $3, type = $4, stock = $5, urls = $6
 WHERE id = $1
-RETURNING id, name, description, type, stock, urls;
+RETURNING id, name, description, type, stock, urls, archived_at, owner_group_id, version;
 
 -- name: DeleteItem :exec
 DELETE FROM items WHERE id = $1;
 
+-- name: ArchiveItem :exec
+UPDATE items SET archived_at = NOW() WHERE id = $1 AND archived_at IS NULL;
+
+-- name: RestoreItem :one
+-- brings an archived item back into the catalogue
+UPDATE items SET archived_at = NULL
+WHERE id = $1 AND archived_at IS NOT NULL
+RETURNING id, name, description, type, stock, urls, archived_at, owner_group_id, version;
+
 -- name: PatchItem :one
 UPDATE items
 SET name = COALESCE(sqlc.narg('name'), name),
@@ -32,7 +81,7 @@ SET name = COALESCE(sqlc.narg('name'), name),
     stock = COALESCE(sqlc.narg('stock'), stock),
     urls = COALESCE(sqlc.narg('urls'), urls)
 WHERE id = sqlc.arg('id')
-RETURNING id, name, description, type, stock, urls;
+RETURNING id, name, description, type, stock, urls, archived_at, owner_group_id, version;
 
 -- name: DecrementItemStock :exec
 UPDATE items
@@ -45,40 +94,77 @@ SET stock = stock + $2
 WHERE id = $1;
 
 -- name: GetItemByName :one
-SELECT id, name, description, type, stock, urls
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version
 FROM items WHERE name = $1;
 
+-- name: GetItemsByNames :many
+-- the items with any of the names, for resolving many names in one round trip
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version
+FROM items WHERE name = ANY(@names::TEXT[]);
+
 -- name: CountAllItems :one
-SELECT COUNT(*) as count FROM items;
+SELECT COUNT(*) as count FROM items
+WHERE archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  -- owned items only for members of the owner or a group it is shared with
+  AND (sqlc.narg('viewer_id')::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = sqlc.narg('viewer_id') AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))));
 
 -- name: CountItemsByType :one
-SELECT COUNT(*) as count FROM items WHERE type = $1;
+SELECT COUNT(*) as count FROM items
+WHERE type = sqlc.arg('type') AND archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  -- owned items only for members of the owner or a group it is shared with
+  AND (sqlc.narg('viewer_id')::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = sqlc.narg('viewer_id') AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))));
 
 -- name: SearchItems :many
-WITH ranked_items AS (
-    -- get rankings (each row turned to rank, from vector/query relationship)
-    SELECT *,
-    CASE
-      WHEN sqlc.narg('query')::TEXT IS NOT NULL THEN
-        ts_rank(
-          to_tsvector('english', name || ' ' || COALESCE(description, '')),
-          plainto_tsquery('english', sqlc.narg('query'))
-        )
-        -- 0 if null query
-        ELSE 0.0
-    END as rank
-  FROM items
-  WHERE (sqlc.narg('query')::TEXT IS NULL OR
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id,
+  (CASE
+    WHEN sqlc.narg('query')::TEXT IS NOT NULL THEN
+      ts_rank(
+        to_tsvector('english', name || ' ' || COALESCE(description, '')),
+        plainto_tsquery('english', sqlc.narg('query'))
+      )
+    -- 0 if null query
+    ELSE 0.0
+  END)::REAL AS rank
+FROM items
+WHERE (sqlc.narg('query')::TEXT IS NULL OR
   -- if nulls, no ranking shenanigans
-    to_tsvector('english', name || ' ' || COALESCE(description, '')) @@ plainto_tsquery('english', sqlc.narg('query')))
-    AND (sqlc.narg('item_type')::item_type IS NULL OR type = sqlc.narg('item_type'))
-    AND (sqlc.narg('in_stock')::BOOLEAN IS NULL OR (stock > 0) = sqlc.narg('in_stock'))
-)
-SELECT id, name, description, type, stock, urls, rank
-FROM ranked_items
+  to_tsvector('english', name || ' ' || COALESCE(description, '')) @@ plainto_tsquery('english', sqlc.narg('query')))
+  AND (sqlc.narg('item_type')::item_type IS NULL OR type = sqlc.narg('item_type'))
+  AND (sqlc.narg('in_stock')::BOOLEAN IS NULL OR (stock > 0) = sqlc.narg('in_stock'))
+  AND (sqlc.narg('category')::TEXT IS NULL OR EXISTS (
+    SELECT 1 FROM item_categories ic JOIN categories c ON c.id = ic.category_id
+    WHERE ic.item_id = items.id AND c.slug = sqlc.narg('category')))
+  -- tagged with every requested tag
+  AND (sqlc.narg('tags')::TEXT[] IS NULL OR (
+    SELECT COUNT(*) FROM item_tags it JOIN tags t ON t.id = it.tag_id
+    WHERE it.item_id = items.id AND t.name = ANY(sqlc.narg('tags')::TEXT[])) = cardinality(sqlc.narg('tags')::TEXT[]))
+  AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  AND (sqlc.arg('include_archived')::BOOLEAN OR archived_at IS NULL)
+  -- owned items only for members of the owner or a group it is shared with
+  AND (sqlc.narg('viewer_id')::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = sqlc.narg('viewer_id') AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
 ORDER BY
--- if query null then alphabetical, else sort by rank
-  CASE WHEN sqlc.narg('query')::TEXT IS NOT NULL THEN rank END DESC NULLS LAST,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'stock' AND NOT sqlc.arg('sort_desc')::BOOLEAN THEN stock END ASC,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'stock' AND sqlc.arg('sort_desc')::BOOLEAN THEN stock END DESC,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'name' AND sqlc.arg('sort_desc')::BOOLEAN THEN name END DESC,
+  -- without an explicit sort a query orders by rank, otherwise alphabetical
+  CASE WHEN sqlc.arg('sort_by')::TEXT = '' AND sqlc.narg('query')::TEXT IS NOT NULL THEN
+    ts_rank(
+      to_tsvector('english', name || ' ' || COALESCE(description, '')),
+      plainto_tsquery('english', sqlc.narg('query'))
+    )
+  END DESC NULLS LAST,
   name ASC
 LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
@@ -88,4 +174,88 @@ FROM items
 WHERE (sqlc.narg('query')::TEXT IS NULL OR
   to_tsvector('english', name || ' ' || COALESCE(description, '')) @@ plainto_tsquery('english', sqlc.narg('query')))
   AND (sqlc.narg('item_type')::item_type IS NULL OR type = sqlc.narg('item_type'))
-  AND (sqlc.narg('in_stock')::BOOLEAN IS NULL OR (stock > 0) = sqlc.narg('in_stock'));
+  AND (sqlc.narg('in_stock')::BOOLEAN IS NULL OR (stock > 0) = sqlc.narg('in_stock'))
+  AND (sqlc.narg('category')::TEXT IS NULL OR EXISTS (
+    SELECT 1 FROM item_categories ic JOIN categories c ON c.id = ic.category_id
+    WHERE ic.item_id = items.id AND c.slug = sqlc.narg('category')))
+  AND (sqlc.narg('tags')::TEXT[] IS NULL OR (
+    SELECT COUNT(*) FROM item_tags it JOIN tags t ON t.id = it.tag_id
+    WHERE it.item_id = items.id AND t.name = ANY(sqlc.narg('tags')::TEXT[])) = cardinality(sqlc.narg('tags')::TEXT[]))
+  AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  AND (sqlc.arg('include_archived')::BOOLEAN OR archived_at IS NULL)
+  -- owned items only for members of the owner or a group it is shared with
+  AND (sqlc.narg('viewer_id')::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = sqlc.narg('viewer_id') AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))));
+
+-- name: FullTextSearchItems :many
+-- Full-text matches come first, ordered by ts_rank; near misses on the name
+-- follow by trigram word similarity, so a typo still finds the item just
+-- further down the list.
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id,
+  GREATEST(
+    ts_rank(to_tsvector('english', name || ' ' || COALESCE(description, '')), plainto_tsquery('english', sqlc.arg('query')::TEXT)),
+    word_similarity(sqlc.arg('query')::TEXT, name)
+  )::REAL AS rank
+FROM items
+WHERE (to_tsvector('english', name || ' ' || COALESCE(description, '')) @@ plainto_tsquery('english', sqlc.arg('query')::TEXT)
+    OR sqlc.arg('query')::TEXT <% name)
+  AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  AND (sqlc.arg('include_archived')::BOOLEAN OR archived_at IS NULL)
+  -- owned items only for members of the owner or a group it is shared with
+  AND (sqlc.narg('viewer_id')::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = sqlc.narg('viewer_id') AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
+ORDER BY to_tsvector('english', name || ' ' || COALESCE(description, '')) @@ plainto_tsquery('english', sqlc.arg('query')::TEXT) DESC,
+  ts_rank(to_tsvector('english', name || ' ' || COALESCE(description, '')), plainto_tsquery('english', sqlc.arg('query')::TEXT)) DESC,
+  word_similarity(sqlc.arg('query')::TEXT, name) DESC,
+  name ASC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountFullTextSearchItems :one
+SELECT COUNT(*) as count
+FROM items
+WHERE (to_tsvector('english', name || ' ' || COALESCE(description, '')) @@ plainto_tsquery('english', sqlc.arg('query')::TEXT)
+    OR sqlc.arg('query')::TEXT <% name)
+  AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  AND (sqlc.arg('include_archived')::BOOLEAN OR archived_at IS NULL)
+  -- owned items only for members of the owner or a group it is shared with
+  AND (sqlc.narg('viewer_id')::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = sqlc.narg('viewer_id') AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))));
+
+-- name: IsItemVisibleToUser :one
+-- whether a user may see an item: unowned items are open to all
+SELECT EXISTS(
+  SELECT 1 FROM items
+  WHERE id = sqlc.arg('item_id') AND (owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = sqlc.arg('user_id') AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
+) AS visible;
+
+-- name: IsItemSharedWithGroup :one
+SELECT EXISTS(
+  SELECT 1 FROM item_group_shares WHERE item_id = $1 AND group_id = $2
+) AS shared;
+
+-- name: SetItemOwner :exec
+UPDATE items SET owner_group_id = sqlc.narg('owner_group_id') WHERE id = sqlc.arg('id');
+
+-- name: ListItemShares :many
+SELECT group_id FROM item_group_shares WHERE item_id = $1 ORDER BY created_at ASC, group_id ASC;
+
+-- name: ClearItemShares :exec
+DELETE FROM item_group_shares WHERE item_id = $1;
+
+-- name: AddItemShares :exec
+INSERT INTO item_group_shares (item_id, group_id)
+SELECT sqlc.arg('item_id'), unnest(sqlc.arg('group_ids')::UUID[])
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,24 @@
+-- name: CreateItemMaintenance :one
+INSERT INTO item_maintenance (item_id, quantity, reason, expected_return_date, started_by, unit_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetItemMaintenanceByID :one
+SELECT * FROM item_maintenance WHERE id = $1;
+
+-- name: ListItemMaintenance :many
+-- An item's maintenance history, newest first
+SELECT * FROM item_maintenance
+WHERE item_id = $1
+ORDER BY started_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountItemMaintenance :one
+SELECT COUNT(*) FROM item_maintenance WHERE item_id = $1;
+
+-- name: CompleteItemMaintenance :one
+-- Closes an open record; no rows when it was already completed
+UPDATE item_maintenance
+SET completion_notes = $2, completed_by = $3, completed_at = NOW()
+WHERE id = $1 AND completed_at IS NULL
+RETURNING *;
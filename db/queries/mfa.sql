@@ -0,0 +1,38 @@
+-- name: GetUserMFA :one
+SELECT * FROM user_mfa WHERE user_id = $1;
+
+-- name: IsMFAEnrolled :one
+SELECT EXISTS (
+    SELECT 1 FROM user_mfa WHERE user_id = $1 AND confirmed_at IS NOT NULL
+);
+
+-- affects no row when the user already has confirmed two-factor authentication
+-- name: UpsertPendingUserMFA :execrows
+INSERT INTO user_mfa (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+WHERE user_mfa.confirmed_at IS NULL;
+
+-- name: ConfirmUserMFA :execrows
+UPDATE user_mfa
+SET confirmed_at = NOW(), last_used_step = $2
+WHERE user_id = $1 AND confirmed_at IS NULL;
+
+-- affects no row when a code for this or a later step was already accepted
+-- name: UseUserMFAStep :execrows
+UPDATE user_mfa
+SET last_used_step = $2
+WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2;
+
+-- name: ReplaceMFABackupCodes :exec
+WITH cleared AS (
+    DELETE FROM user_mfa_backup_codes WHERE user_id = @user_id
+)
+INSERT INTO user_mfa_backup_codes (user_id, code_hash)
+SELECT @user_id, UNNEST(@code_hashes::TEXT[]);
+
+-- name: UseMFABackupCode :execrows
+UPDATE user_mfa_backup_codes
+SET used_at = NOW()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
@@ -20,16 +20,35 @@ RETURNING *;
 
 -- name: CreateNotification :one
 INSERT INTO notifications (
-    notification_object_id, notifier_id, is_read
+    notification_object_id, notifier_id, title, is_read
 ) VALUES (
-    $1, $2, false
+    $1, $2, $3, false
 )
 RETURNING *;
 
+-- name: GetUserNotification :one
+SELECT 
+    n.id AS notification_id,
+    n.is_read,
+    n.title,
+    n.created_at AS notification_created_at,
+    no.id AS notification_object_id,
+    no.entity_id,
+    net.name AS entity_type_name,
+    nc.actor_id,
+    u.email AS actor_email
+FROM notifications n
+JOIN notification_objects no ON n.notification_object_id = no.id
+JOIN notification_entity_types net ON no.entity_type_id = net.id
+JOIN notification_changes nc ON no.id = nc.notification_object_id
+JOIN users u ON nc.actor_id = u.id
+WHERE n.id = $1 AND n.notifier_id = $2;
+
 -- name: GetUserNotifications :many
 SELECT 
     n.id AS notification_id,
     n.is_read,
+    n.title,
     n.created_at AS notification_created_at,
     no.id AS notification_object_id,
     no.entity_id,
@@ -45,6 +64,33 @@ WHERE n.notifier_id = $1
 ORDER BY n.created_at DESC
 LIMIT $2 OFFSET $3;
 
+-- name: ListNotifiedUserIDs :many
+-- Users sent an in-app notification about an entity
+SELECT DISTINCT n.notifier_id FROM notifications n
+JOIN notification_objects o ON o.id = n.notification_object_id
+JOIN notification_entity_types t ON t.id = o.entity_type_id
+WHERE t.name = $1 AND o.entity_id = $2;
+
+-- name: ListUnreadUserNotifications :many
+SELECT 
+    n.id AS notification_id,
+    n.is_read,
+    n.title,
+    n.created_at AS notification_created_at,
+    no.id AS notification_object_id,
+    no.entity_id,
+    net.name AS entity_type_name,
+    nc.actor_id,
+    u.email AS actor_email
+FROM notifications n
+JOIN notification_objects no ON n.notification_object_id = no.id
+JOIN notification_entity_types net ON no.entity_type_id = net.id
+JOIN notification_changes nc ON no.id = nc.notification_object_id
+JOIN users u ON nc.actor_id = u.id
+WHERE n.notifier_id = $1 AND n.is_read = false
+ORDER BY n.created_at DESC
+LIMIT $2;
+
 -- name: CountUserNotifications :one
 SELECT COUNT(*) FROM notifications
 WHERE notifier_id = $1 AND is_read = false;
@@ -63,3 +109,15 @@ RETURNING *;
 UPDATE notifications
 SET is_read = true
 WHERE notifier_id = $1 AND is_read = false;
+
+-- name: ListNotificationPreferences :many
+SELECT * FROM notification_preferences
+WHERE user_id = $1
+ORDER BY notification_type;
+
+-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, notification_type, email_enabled)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, notification_type) DO UPDATE
+SET email_enabled = EXCLUDED.email_enabled,
+    updated_at = NOW();
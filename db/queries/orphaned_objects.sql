@@ -0,0 +1,12 @@
+-- name: ListReferencedObjectKeys :many
+-- every S3 key a row still points at; objects under the swept prefixes that
+-- are missing from this list are orphans
+SELECT original_s3_key AS key FROM item_images
+UNION SELECT thumbnail_s3_key FROM item_images
+UNION SELECT s3_key FROM borrowing_images
+UNION SELECT web_s3_key FROM borrowing_images WHERE web_s3_key IS NOT NULL
+UNION SELECT thumbnail_s3_key FROM borrowing_images WHERE thumbnail_s3_key IS NOT NULL
+UNION SELECT unnest(photo_keys) FROM damage_reports
+UNION SELECT logo_s3_key FROM groups WHERE logo_s3_key IS NOT NULL
+UNION SELECT logo_thumbnail_s3_key FROM groups WHERE logo_thumbnail_s3_key IS NOT NULL
+UNION SELECT s3_key FROM reports WHERE s3_key IS NOT NULL;
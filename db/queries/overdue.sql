@@ -0,0 +1,61 @@
+-- name: MarkOverdueBorrowings :many
+-- Returns the borrowings that newly became overdue
+WITH marked AS (
+    INSERT INTO overdue_borrowings (borrowing_id)
+    SELECT id FROM borrowings
+    WHERE returned_at IS NULL AND due_date < NOW()
+    ON CONFLICT DO NOTHING
+    RETURNING borrowing_id
+)
+SELECT b.id, b.group_id, b.item_id, b.quantity, b.due_date,
+    i.name AS item_name, u.email AS user_email, g.name AS group_name
+FROM borrowings b
+JOIN marked m ON m.borrowing_id = b.id
+JOIN items i ON b.item_id = i.id
+LEFT JOIN users u ON b.user_id = u.id
+LEFT JOIN groups g ON b.group_id = g.id;
+
+-- name: ListBorrowingsDueBy :many
+-- Unreturned borrowings due before the cutoff, with what the reminder needs
+SELECT b.id, b.user_id, b.group_id, b.item_id, b.quantity, b.due_date,
+    i.name AS item_name, i.type AS item_type
+FROM borrowings b
+JOIN items i ON b.item_id = i.id
+WHERE b.returned_at IS NULL
+  AND b.due_date < sqlc.arg('cutoff')::TIMESTAMP
+ORDER BY b.due_date;
+
+-- name: RecordBorrowingReminder :execrows
+-- Returns 0 when this reminder was already sent
+INSERT INTO borrowing_reminders (borrowing_id, days_from_due)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: ListOverdueBorrowings :many
+SELECT b.id, b.user_id, b.group_id, b.item_id, b.quantity,
+    b.borrowed_at, b.due_date, o.overdue_at,
+    i.name AS item_name, u.email AS user_email, g.name AS group_name,
+    COUNT(r.borrowing_id) AS reminders_sent
+FROM borrowings b
+JOIN overdue_borrowings o ON o.borrowing_id = b.id
+JOIN items i ON b.item_id = i.id
+LEFT JOIN users u ON b.user_id = u.id
+LEFT JOIN groups g ON b.group_id = g.id
+LEFT JOIN borrowing_reminders r ON r.borrowing_id = b.id
+WHERE b.returned_at IS NULL
+GROUP BY b.id, o.overdue_at, i.name, u.email, g.name
+ORDER BY b.due_date
+LIMIT $1 OFFSET $2;
+
+-- name: CountOverdueBorrowings :one
+SELECT COUNT(*) FROM borrowings b
+JOIN overdue_borrowings o ON o.borrowing_id = b.id
+WHERE b.returned_at IS NULL;
+
+-- name: ResetBorrowingOverdue :exec
+-- Forgets a borrowing's reminders and overdue mark once its due date moves,
+-- so both start over from the new date
+WITH cleared AS (
+    DELETE FROM borrowing_reminders WHERE borrowing_id = $1
+)
+DELETE FROM overdue_borrowings WHERE borrowing_id = $1;
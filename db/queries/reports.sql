@@ -0,0 +1,190 @@
+-- name: CreateReport :one
+INSERT INTO reports (report_type, requested_by, group_id, from_date, to_date)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, report_type, status, requested_by, group_id, from_date, to_date,
+    s3_key, row_count, error, created_at, completed_at, expires_at;
+
+-- name: GetReportByID :one
+SELECT id, report_type, status, requested_by, group_id, from_date, to_date,
+    s3_key, row_count, error, created_at, completed_at, expires_at
+FROM reports WHERE id = $1;
+
+-- name: ListReportsByUser :many
+SELECT id, report_type, status, requested_by, group_id, from_date, to_date,
+    s3_key, row_count, error, created_at, completed_at, expires_at
+FROM reports
+WHERE requested_by = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountReportsByUser :one
+SELECT COUNT(*) FROM reports WHERE requested_by = $1;
+
+-- name: MarkReportReady :one
+UPDATE reports
+SET status = 'ready', s3_key = $2, row_count = $3, expires_at = $4, completed_at = NOW(), error = NULL
+WHERE id = $1
+RETURNING id, report_type, status, requested_by, group_id, from_date, to_date,
+    s3_key, row_count, error, created_at, completed_at, expires_at;
+
+-- name: MarkReportFailed :exec
+UPDATE reports
+SET status = 'failed', error = $2, completed_at = NOW()
+WHERE id = $1;
+
+-- name: ExportBorrowings :many
+SELECT b.id, u.email AS user_email, g.name AS group_name, i.name AS item_name,
+    b.quantity, b.borrowed_at, b.due_date, b.returned_at, b.before_condition, b.after_condition
+FROM borrowings b
+LEFT JOIN users u ON b.user_id = u.id
+LEFT JOIN groups g ON b.group_id = g.id
+LEFT JOIN items i ON b.item_id = i.id
+WHERE (sqlc.narg('group_id')::UUID IS NULL OR b.group_id = sqlc.narg('group_id'))
+  AND (sqlc.narg('from_date')::DATE IS NULL OR b.borrowed_at >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR b.borrowed_at < sqlc.narg('to_date')::DATE + 1)
+ORDER BY b.borrowed_at;
+
+-- name: ExportBookings :many
+SELECT bk.id, r.email AS requester_email, m.email AS manager_email, g.name AS group_name,
+    i.name AS item_name, bk.status, bk.pick_up_date, bk.pick_up_location,
+    bk.return_date, bk.return_location, bk.created_at
+FROM booking bk
+LEFT JOIN users r ON bk.requester_id = r.id
+LEFT JOIN users m ON bk.manager_id = m.id
+LEFT JOIN groups g ON bk.group_id = g.id
+LEFT JOIN items i ON bk.item_id = i.id
+WHERE bk.is_test = false
+  AND (sqlc.narg('group_id')::UUID IS NULL OR bk.group_id = sqlc.narg('group_id'))
+  AND (sqlc.narg('from_date')::DATE IS NULL OR bk.pick_up_date >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR bk.pick_up_date < sqlc.narg('to_date')::DATE + 1)
+ORDER BY bk.pick_up_date;
+
+-- name: ExportTakings :many
+SELECT t.id, u.email AS user_email, g.name AS group_name, i.name AS item_name,
+    t.quantity, t.taken_at
+FROM item_takings t
+JOIN users u ON t.user_id = u.id
+JOIN groups g ON t.group_id = g.id
+JOIN items i ON t.item_id = i.id
+WHERE (sqlc.narg('group_id')::UUID IS NULL OR t.group_id = sqlc.narg('group_id'))
+  AND (sqlc.narg('from_date')::DATE IS NULL OR t.taken_at >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR t.taken_at < sqlc.narg('to_date')::DATE + 1)
+ORDER BY t.taken_at;
+
+-- name: ExportOutstanding :many
+-- Unreturned borrowings due on or before to_date
+SELECT b.id, u.email AS user_email, g.name AS group_name, i.name AS item_name,
+    b.quantity, b.borrowed_at, b.due_date
+FROM borrowings b
+LEFT JOIN users u ON b.user_id = u.id
+LEFT JOIN groups g ON b.group_id = g.id
+LEFT JOIN items i ON b.item_id = i.id
+WHERE b.returned_at IS NULL
+  AND (sqlc.narg('group_id')::UUID IS NULL OR b.group_id = sqlc.narg('group_id'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR b.due_date < sqlc.narg('to_date')::DATE + 1)
+ORDER BY b.due_date;
+
+-- name: GetItemUtilization :many
+-- Per borrowable item and week or month from from_date to to_date
+-- (inclusive): borrowings started, and days on loan summed over every unit.
+-- The first and last periods are clipped to the window; loans still out count
+-- up to now.
+WITH periods AS (
+    SELECT GREATEST(p, sqlc.arg('from_date')::DATE)::TIMESTAMP AS period_start,
+        LEAST(p + ('1 ' || sqlc.arg('period')::TEXT)::INTERVAL, sqlc.arg('to_date')::DATE + 1)::TIMESTAMP AS period_end
+    FROM generate_series(
+        date_trunc(sqlc.arg('period')::TEXT, sqlc.arg('from_date')::DATE::TIMESTAMP),
+        sqlc.arg('to_date')::DATE::TIMESTAMP,
+        ('1 ' || sqlc.arg('period')::TEXT)::INTERVAL
+    ) AS p
+)
+SELECT i.id AS item_id, i.name AS item_name, i.stock, pr.period_start, pr.period_end,
+    COUNT(b.id) FILTER (WHERE b.borrowed_at >= pr.period_start) AS borrowings,
+    COALESCE(SUM(b.quantity * EXTRACT(EPOCH FROM
+        LEAST(COALESCE(b.returned_at, NOW()::TIMESTAMP), pr.period_end) - GREATEST(b.borrowed_at, pr.period_start)
+    ) / 86400), 0)::FLOAT8 AS unit_days_borrowed
+FROM items i
+CROSS JOIN periods pr
+LEFT JOIN borrowings b ON b.item_id = i.id
+    AND b.borrowed_at < pr.period_end
+    AND COALESCE(b.returned_at, NOW()::TIMESTAMP) > pr.period_start
+WHERE i.type <> 'low'
+  AND (sqlc.narg('item_id')::UUID IS NULL OR i.id = sqlc.narg('item_id'))
+GROUP BY i.id, i.name, i.stock, pr.period_start, pr.period_end
+ORDER BY i.name, i.id, pr.period_start;
+
+-- name: GetTopItems :many
+-- Items ranked by how often they were borrowed or taken in the window
+WITH uses AS (
+    SELECT b.item_id, b.quantity, TRUE AS borrowed
+    FROM borrowings b
+    WHERE (sqlc.narg('from_date')::DATE IS NULL OR b.borrowed_at >= sqlc.narg('from_date'))
+      AND (sqlc.narg('to_date')::DATE IS NULL OR b.borrowed_at < sqlc.narg('to_date')::DATE + 1)
+    UNION ALL
+    SELECT t.item_id, t.quantity, FALSE
+    FROM item_takings t
+    WHERE (sqlc.narg('from_date')::DATE IS NULL OR t.taken_at >= sqlc.narg('from_date'))
+      AND (sqlc.narg('to_date')::DATE IS NULL OR t.taken_at < sqlc.narg('to_date')::DATE + 1)
+)
+SELECT i.id AS item_id, i.name AS item_name, i.type AS item_type,
+    COUNT(*) FILTER (WHERE u.borrowed) AS borrowings,
+    COUNT(*) FILTER (WHERE NOT u.borrowed) AS takings,
+    SUM(u.quantity)::BIGINT AS units
+FROM uses u
+JOIN items i ON i.id = u.item_id
+GROUP BY i.id, i.name, i.type
+ORDER BY COUNT(*) DESC, units DESC, i.name
+LIMIT sqlc.arg('limit');
+
+-- name: GetGroupActivity :many
+-- Per group: requests, bookings, borrowings and takings in the window, and
+-- how many members borrowed or took anything
+WITH borrowed AS (
+    SELECT b.group_id, b.user_id
+    FROM borrowings b
+    WHERE (sqlc.narg('from_date')::DATE IS NULL OR b.borrowed_at >= sqlc.narg('from_date'))
+      AND (sqlc.narg('to_date')::DATE IS NULL OR b.borrowed_at < sqlc.narg('to_date')::DATE + 1)
+), taken AS (
+    SELECT t.group_id, t.user_id
+    FROM item_takings t
+    WHERE (sqlc.narg('from_date')::DATE IS NULL OR t.taken_at >= sqlc.narg('from_date'))
+      AND (sqlc.narg('to_date')::DATE IS NULL OR t.taken_at < sqlc.narg('to_date')::DATE + 1)
+)
+SELECT g.id AS group_id, g.name AS group_name,
+    (SELECT COUNT(*) FROM requests r
+     WHERE r.group_id = g.id
+       AND (sqlc.narg('from_date')::DATE IS NULL OR r.requested_at >= sqlc.narg('from_date'))
+       AND (sqlc.narg('to_date')::DATE IS NULL OR r.requested_at < sqlc.narg('to_date')::DATE + 1)) AS requests,
+    (SELECT COUNT(*) FROM booking bk
+     WHERE bk.group_id = g.id AND bk.is_test = false
+       AND (sqlc.narg('from_date')::DATE IS NULL OR bk.pick_up_date >= sqlc.narg('from_date'))
+       AND (sqlc.narg('to_date')::DATE IS NULL OR bk.pick_up_date < sqlc.narg('to_date')::DATE + 1)) AS bookings,
+    (SELECT COUNT(*) FROM borrowed b WHERE b.group_id = g.id) AS borrowings,
+    (SELECT COUNT(*) FROM taken t WHERE t.group_id = g.id) AS takings,
+    (SELECT COUNT(active.user_id) FROM (
+        SELECT b.user_id FROM borrowed b WHERE b.group_id = g.id
+        UNION
+        SELECT t.user_id FROM taken t WHERE t.group_id = g.id
+    ) active) AS active_users
+FROM groups g
+ORDER BY g.name;
+
+-- name: GetOverdueSummary :many
+-- Per group, borrowings due in the window: how many came back on time or
+-- late, how many are still out past their due date, and the days late summed
+-- over the late ones. Borrowings made outside any group come last, with no
+-- group.
+SELECT b.group_id, g.name AS group_name,
+    COUNT(*) AS due,
+    COUNT(*) FILTER (WHERE b.returned_at <= b.due_date) AS returned_on_time,
+    COUNT(*) FILTER (WHERE b.returned_at > b.due_date) AS returned_late,
+    COUNT(*) FILTER (WHERE b.returned_at IS NULL AND b.due_date < NOW()) AS still_overdue,
+    COALESCE(SUM(EXTRACT(EPOCH FROM COALESCE(b.returned_at, NOW()::TIMESTAMP) - b.due_date) / 86400)
+        FILTER (WHERE COALESCE(b.returned_at, NOW()::TIMESTAMP) > b.due_date), 0)::FLOAT8 AS days_late
+FROM borrowings b
+LEFT JOIN groups g ON g.id = b.group_id
+WHERE b.due_date IS NOT NULL
+  AND (sqlc.narg('from_date')::DATE IS NULL OR b.due_date >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR b.due_date < sqlc.narg('to_date')::DATE + 1)
+GROUP BY b.group_id, g.name
+ORDER BY g.name;
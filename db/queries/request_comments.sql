@@ -0,0 +1,19 @@
+-- name: CreateRequestComment :one
+INSERT INTO request_comments (request_id, parent_id, author_id, body)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetRequestComment :one
+SELECT * FROM request_comments WHERE id = $1;
+
+-- name: ListRequestComments :many
+SELECT c.id, c.request_id, c.parent_id, c.author_id, c.body, c.created_at,
+    u.email AS author_email
+FROM request_comments c
+LEFT JOIN users u ON u.id = c.author_id
+WHERE c.request_id = $1
+ORDER BY c.created_at, c.id;
+
+-- name: ListRequestCommenterIDs :many
+SELECT DISTINCT author_id FROM request_comments
+WHERE request_id = $1 AND author_id IS NOT NULL;
@@ -0,0 +1,63 @@
+-- name: UpsertGroupRequestSLA :one
+INSERT INTO group_request_slas (group_id, review_hours, updated_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (group_id) DO UPDATE
+SET review_hours = EXCLUDED.review_hours,
+    updated_by = EXCLUDED.updated_by,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetGroupRequestSLA :one
+SELECT * FROM group_request_slas
+WHERE group_id = $1;
+
+-- name: ListGroupRequestSLAs :many
+SELECT * FROM group_request_slas;
+
+-- name: DeleteGroupRequestSLA :execrows
+DELETE FROM group_request_slas
+WHERE group_id = $1;
+
+-- name: ListUnalertedSLABreaches :many
+-- Pending requests past their group's review SLA that haven't been alerted yet
+SELECT r.id, r.user_id, r.group_id, r.quantity, r.requested_at, s.review_hours,
+    i.name AS item_name, g.name AS group_name, u.email AS requester_email
+FROM requests r
+JOIN group_request_slas s ON s.group_id = r.group_id
+JOIN items i ON i.id = r.item_id
+JOIN groups g ON g.id = r.group_id
+LEFT JOIN users u ON u.id = r.user_id
+WHERE r.status = 'pending'
+  AND r.requested_at + s.review_hours * INTERVAL '1 hour' < NOW()
+  AND NOT EXISTS (SELECT 1 FROM request_sla_alerts a WHERE a.request_id = r.id)
+ORDER BY r.requested_at;
+
+-- name: RecordSLAAlert :execrows
+-- Returns 0 when the breach was already alerted
+INSERT INTO request_sla_alerts (request_id)
+VALUES ($1)
+ON CONFLICT DO NOTHING;
+
+-- name: GetRequestApproverIDs :many
+SELECT DISTINCT ur.user_id FROM user_roles ur
+JOIN role_permissions rp ON rp.role_name = ur.role_name
+WHERE rp.permission_name = 'approve_all_requests' AND ur.scope = 'global';
+
+-- name: GetRequestSLACompliance :many
+-- Per group with an SLA: requests made in the window, how many were reviewed
+-- within the SLA, how many breached it (reviewed late or still pending past
+-- it) and the average hours to review
+SELECT g.id AS group_id, g.name AS group_name, s.review_hours,
+    COUNT(r.id) AS total,
+    COUNT(CASE WHEN r.reviewed_at <= r.requested_at + s.review_hours * INTERVAL '1 hour' THEN 1 END) AS within_sla,
+    COUNT(CASE WHEN COALESCE(r.reviewed_at, NOW()) > r.requested_at + s.review_hours * INTERVAL '1 hour' THEN 1 END) AS breached,
+    COUNT(CASE WHEN r.id IS NOT NULL AND r.reviewed_at IS NULL THEN 1 END) AS pending,
+    COALESCE(AVG(EXTRACT(EPOCH FROM r.reviewed_at - r.requested_at) / 3600), 0)::FLOAT8 AS avg_review_hours
+FROM group_request_slas s
+JOIN groups g ON g.id = s.group_id
+LEFT JOIN requests r ON r.group_id = s.group_id
+    AND (sqlc.narg('from_date')::DATE IS NULL OR r.requested_at >= sqlc.narg('from_date'))
+    AND (sqlc.narg('to_date')::DATE IS NULL OR r.requested_at < sqlc.narg('to_date')::DATE + 1)
+WHERE sqlc.narg('group_id')::UUID IS NULL OR s.group_id = sqlc.narg('group_id')
+GROUP BY g.id, g.name, s.review_hours
+ORDER BY g.name;
@@ -14,7 +14,8 @@ RETURNING id, user_id, group_id, item_id, quantity,
 UPDATE requests r
 SET status = $2,
     reviewed_by = $3,
-    reviewed_at = NOW()
+    reviewed_at = NOW(),
+    override_justification = $4
 FROM items i
 WHERE r.id = $1
   AND r.status = 'pending'
@@ -24,7 +25,14 @@ WHERE r.id = $1
     OR ($2 = 'approved'::request_status AND i.stock >= r.quantity)
   )
 RETURNING r.id, r.user_id, r.group_id, r.item_id, r.quantity,
-    r.status, r.reviewed_at, r.reviewed_by;
+    r.status, r.reviewed_at, r.reviewed_by, r.override_justification;
+
+-- name: CancelRequest :one
+-- The requester retracting a request; no rows once it has been reviewed
+UPDATE requests
+SET status = 'cancelled'
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
 
 -- name: GetApprovedRequestForUserAndItem :one
 SELECT * FROM requests
@@ -47,12 +55,29 @@ ORDER BY requested_at ASC LIMIT $1 OFFSET $2;
 
 -- name: GetAllRequests :many
 SELECT * FROM requests
-ORDER BY requested_at DESC LIMIT $1 OFFSET $2;
+WHERE (sqlc.narg('status')::request_status IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('group_id')::UUID IS NULL OR group_id = sqlc.narg('group_id'))
+  AND (sqlc.narg('from_date')::DATE IS NULL OR requested_at::DATE >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR requested_at::DATE <= sqlc.narg('to_date'))
+ORDER BY
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'quantity' AND NOT sqlc.arg('sort_desc')::BOOLEAN THEN quantity END ASC,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'quantity' AND sqlc.arg('sort_desc')::BOOLEAN THEN quantity END DESC,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'requested_at' AND NOT sqlc.arg('sort_desc')::BOOLEAN THEN requested_at END ASC,
+  requested_at DESC, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: GetRequestsByUserId :many
 SELECT * FROM requests
-WHERE user_id = $1
-ORDER BY requested_at DESC;
+WHERE user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('status')::request_status IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('from_date')::DATE IS NULL OR requested_at::DATE >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR requested_at::DATE <= sqlc.narg('to_date'))
+ORDER BY
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'quantity' AND NOT sqlc.arg('sort_desc')::BOOLEAN THEN quantity END ASC,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'quantity' AND sqlc.arg('sort_desc')::BOOLEAN THEN quantity END DESC,
+  CASE WHEN sqlc.arg('sort_by')::TEXT = 'requested_at' AND NOT sqlc.arg('sort_desc')::BOOLEAN THEN requested_at END ASC,
+  requested_at DESC, id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
 
 -- name: GetRequestById :one
 SELECT * FROM requests
@@ -69,12 +94,42 @@ SET booking_id = $2
 WHERE id = $1
 RETURNING *;
 
+-- name: ReopenRequestForBooking :one
+-- An approved request whose booking lapsed goes back to pending for a fresh review
+UPDATE requests
+SET status = 'pending',
+    booking_id = NULL,
+    reviewed_by = NULL,
+    reviewed_at = NULL,
+    override_justification = NULL
+WHERE booking_id = $1 AND status = 'approved'
+RETURNING *;
+
 -- name: GetRequestByBookingID :one
 SELECT * FROM requests
 WHERE booking_id = $1;
 
 -- name: CountAllRequests :one
-SELECT COUNT(*) as count FROM requests;
+SELECT COUNT(*) as count FROM requests
+WHERE (sqlc.narg('status')::request_status IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('group_id')::UUID IS NULL OR group_id = sqlc.narg('group_id'))
+  AND (sqlc.narg('from_date')::DATE IS NULL OR requested_at::DATE >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR requested_at::DATE <= sqlc.narg('to_date'));
+
+-- name: CountRequestsByUserId :one
+SELECT COUNT(*) as count FROM requests
+WHERE user_id = sqlc.arg('user_id')
+  AND (sqlc.narg('status')::request_status IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('from_date')::DATE IS NULL OR requested_at::DATE >= sqlc.narg('from_date'))
+  AND (sqlc.narg('to_date')::DATE IS NULL OR requested_at::DATE <= sqlc.narg('to_date'));
 
 -- name: CountPendingRequests :one
-SELECT COUNT(*) as count FROM requests WHERE status = 'pending';
\ No newline at end of file
+SELECT COUNT(*) as count FROM requests WHERE status = 'pending';
+-- writes a request with its whole history in one go; only the seeder uses it
+-- name: SeedRequest :one
+INSERT INTO requests (
+    user_id, group_id, item_id, quantity, status,
+    requested_at, reviewed_by, reviewed_at, fulfilled_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
@@ -0,0 +1,37 @@
+-- name: ListRoles :many
+SELECT * FROM roles ORDER BY name;
+
+-- name: GetRole :one
+SELECT * FROM roles WHERE name = $1;
+
+-- name: ListPermissions :many
+SELECT * FROM permissions ORDER BY name;
+
+-- name: ListRolePermissions :many
+SELECT * FROM role_permissions ORDER BY role_name, permission_name;
+
+-- name: ListPermissionsForRole :many
+SELECT permission_name FROM role_permissions
+WHERE role_name = $1
+ORDER BY permission_name;
+
+-- name: CountPermissionsByName :one
+SELECT COUNT(*) FROM permissions WHERE name = ANY(@names::TEXT[]);
+
+-- name: ClearRolePermissions :exec
+DELETE FROM role_permissions WHERE role_name = $1;
+
+-- name: AddRolePermissions :exec
+INSERT INTO role_permissions (role_name, permission_name)
+SELECT @role_name::VARCHAR, UNNEST(@permission_names::TEXT[])
+ON CONFLICT DO NOTHING;
+
+-- name: CountGlobalRoleHolders :one
+SELECT COUNT(*) FROM user_roles WHERE role_name = $1 AND scope = 'global';
+
+-- name: CountGroupRoleHolders :one
+SELECT COUNT(*) FROM user_roles WHERE role_name = $1 AND scope = 'group';
+
+-- name: CreateRoleChange :exec
+INSERT INTO role_changes (action, role_name, permissions, user_id, scope, scope_id, changed_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
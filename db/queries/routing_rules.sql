@@ -0,0 +1,20 @@
+-- name: CreateRoutingRule :one
+INSERT INTO notification_routing_rules (
+    name, template, item_id, item_type, group_id, min_days_overdue,
+    recipient_user_id, recipient_email, replace_default, enabled, created_by
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *;
+
+-- name: ListRoutingRules :many
+SELECT * FROM notification_routing_rules
+ORDER BY template, created_at;
+
+-- name: ListEnabledRoutingRules :many
+SELECT * FROM notification_routing_rules
+WHERE template = $1 AND enabled
+ORDER BY created_at;
+
+-- name: DeleteRoutingRule :execrows
+DELETE FROM notification_routing_rules
+WHERE id = $1;
@@ -0,0 +1,47 @@
+-- name: RestoreSandboxTakenStock :exec
+-- put consumed stock back before item takings are purged
+UPDATE items i
+SET stock = i.stock + t.quantity
+FROM (
+    SELECT item_id, SUM(quantity)::INT AS quantity
+    FROM item_takings
+    WHERE group_id = $1
+    GROUP BY item_id
+) t
+WHERE i.id = t.item_id;
+
+-- name: RestoreSandboxBorrowedStock :exec
+-- put stock held by unreturned borrowings back before they are purged
+UPDATE items i
+SET stock = i.stock + b.quantity
+FROM (
+    SELECT item_id, SUM(quantity)::INT AS quantity
+    FROM borrowings
+    WHERE group_id = $1 AND returned_at IS NULL
+    GROUP BY item_id
+) b
+WHERE i.id = b.item_id;
+
+-- name: RestoreSandboxBorrowedUnits :exec
+-- and the units those borrowings hold
+UPDATE item_units
+SET status = 'available', updated_at = NOW()
+WHERE id IN (
+    SELECT unit_id FROM borrowings
+    WHERE group_id = $1 AND returned_at IS NULL AND unit_id IS NOT NULL
+);
+
+-- name: PurgeGroupItemTakings :execrows
+DELETE FROM item_takings WHERE group_id = $1;
+
+-- name: PurgeGroupBorrowings :execrows
+DELETE FROM borrowings WHERE group_id = $1;
+
+-- name: PurgeGroupRequests :execrows
+DELETE FROM requests WHERE group_id = $1;
+
+-- name: PurgeGroupBookings :execrows
+DELETE FROM booking WHERE group_id = $1;
+
+-- name: PurgeGroupCarts :execrows
+DELETE FROM cart WHERE group_id = $1;
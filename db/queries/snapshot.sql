@@ -0,0 +1,84 @@
+-- the Snapshot queries read rows back in the shape of the seed YAML, with IDs
+-- resolved to the names and emails the seeder looks rows up by
+-- name: SnapshotGroups :many
+SELECT name, description FROM groups
+ORDER BY name;
+
+-- name: SnapshotItems :many
+SELECT name, type, stock, description, urls FROM items
+ORDER BY name;
+
+-- name: SnapshotUsers :many
+SELECT email FROM users
+ORDER BY email;
+
+-- name: SnapshotUserRoles :many
+SELECT u.email AS user_email, ur.role_name, ur.scope, g.name AS group_name
+FROM user_roles ur
+JOIN users u ON u.id = ur.user_id
+LEFT JOIN groups g ON g.id = ur.scope_id
+ORDER BY u.email, ur.role_name, g.name;
+
+-- name: SnapshotAvailability :many
+SELECT u.email AS user_email, ua.date, ts.start_time
+FROM user_availability ua
+JOIN users u ON u.id = ua.user_id
+JOIN time_slots ts ON ts.id = ua.time_slot_id
+ORDER BY u.email, ua.date, ts.start_time;
+
+-- name: SnapshotBorrowings :many
+SELECT u.email AS user_email, g.name AS group_name, i.name AS item_name,
+    b.quantity, b.borrowed_at, b.due_date, b.returned_at,
+    b.before_condition, b.before_condition_url,
+    b.after_condition, b.after_condition_url
+FROM borrowings b
+JOIN users u ON u.id = b.user_id
+JOIN groups g ON g.id = b.group_id
+JOIN items i ON i.id = b.item_id
+ORDER BY b.borrowed_at, b.id;
+
+-- name: SnapshotRequests :many
+SELECT u.email AS user_email, g.name AS group_name, i.name AS item_name,
+    r.quantity, r.status, r.requested_at,
+    rev.email AS reviewed_by_email, r.reviewed_at, r.fulfilled_at
+FROM requests r
+JOIN users u ON u.id = r.user_id
+JOIN groups g ON g.id = r.group_id
+JOIN items i ON i.id = r.item_id
+LEFT JOIN users rev ON rev.id = r.reviewed_by
+ORDER BY r.requested_at, r.id;
+
+-- name: SnapshotBookings :many
+SELECT req.email AS requester_email, mgr.email AS manager_email,
+    i.name AS item_name, g.name AS group_name,
+    ua.date AS availability_date, ts.start_time AS availability_time_slot,
+    b.pick_up_date, b.pick_up_location, b.return_date, b.return_location,
+    b.status, b.confirmed_at, conf.email AS confirmed_by_email,
+    b.cancelled_at, canc.email AS cancelled_by_email, b.cancellation_reason
+FROM booking b
+JOIN users req ON req.id = b.requester_id
+JOIN users mgr ON mgr.id = b.manager_id
+JOIN items i ON i.id = b.item_id
+JOIN groups g ON g.id = b.group_id
+JOIN user_availability ua ON ua.id = b.availability_id
+JOIN time_slots ts ON ts.id = ua.time_slot_id
+LEFT JOIN users conf ON conf.id = b.confirmed_by
+LEFT JOIN users canc ON canc.id = b.cancelled_by
+ORDER BY b.created_at, b.id;
+
+-- name: SnapshotCartItems :many
+SELECT u.email AS user_email, g.name AS group_name, i.name AS item_name, c.quantity
+FROM cart c
+JOIN users u ON u.id = c.user_id
+JOIN groups g ON g.id = c.group_id
+JOIN items i ON i.id = c.item_id
+ORDER BY c.created_at;
+
+-- name: SnapshotItemTakings :many
+SELECT u.email AS user_email, g.name AS group_name, i.name AS item_name,
+    t.quantity, t.taken_at
+FROM item_takings t
+JOIN users u ON u.id = t.user_id
+JOIN groups g ON g.id = t.group_id
+JOIN items i ON i.id = t.item_id
+ORDER BY t.taken_at, t.id;
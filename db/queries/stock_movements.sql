@@ -0,0 +1,27 @@
+-- name: TagStockMovement :exec
+-- Describes the stock changes the rest of the transaction makes to the
+-- items_stock_ledger trigger, as JSON with kind, reason, actor_id and reference_id
+SELECT set_config('cv.stock_movement', @movement::TEXT, true);
+
+-- name: AdjustItemStock :one
+-- Moves an item's stock by delta; no rows when it would go below zero
+UPDATE items
+SET stock = stock + @delta::INT
+WHERE id = @id AND stock + @delta::INT >= 0
+RETURNING stock;
+
+-- name: GetLatestStockMovement :one
+SELECT * FROM stock_movements
+WHERE item_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: ListStockMovements :many
+-- An item's stock ledger, newest first
+SELECT * FROM stock_movements
+WHERE item_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountStockMovements :one
+SELECT COUNT(*) FROM stock_movements WHERE item_id = $1;
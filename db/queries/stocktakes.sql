@@ -0,0 +1,59 @@
+-- name: OpenStocktake :one
+INSERT INTO stocktakes (notes, opened_by)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetStocktakeByID :one
+SELECT * FROM stocktakes WHERE id = $1;
+
+-- name: GetStocktakeForUpdate :one
+SELECT * FROM stocktakes WHERE id = $1 FOR UPDATE;
+
+-- name: ListStocktakes :many
+SELECT * FROM stocktakes
+ORDER BY opened_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CountStocktakes :one
+SELECT COUNT(*) FROM stocktakes;
+
+-- name: CloseStocktake :one
+-- No rows when the stocktake was already closed
+UPDATE stocktakes
+SET status = 'closed', closed_by = $2, closed_at = NOW()
+WHERE id = $1 AND status = 'counting'
+RETURNING *;
+
+-- name: RecordStocktakeCount :one
+-- A recount replaces the item's earlier count
+INSERT INTO stocktake_counts (stocktake_id, item_id, expected, counted, unit_ids, missing_unit_ids, notes, counted_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (stocktake_id, item_id) DO UPDATE
+SET expected = EXCLUDED.expected,
+    counted = EXCLUDED.counted,
+    unit_ids = EXCLUDED.unit_ids,
+    missing_unit_ids = EXCLUDED.missing_unit_ids,
+    notes = EXCLUDED.notes,
+    counted_by = EXCLUDED.counted_by,
+    counted_at = NOW()
+RETURNING *;
+
+-- name: ListStocktakeCounts :many
+SELECT c.stocktake_id, c.item_id, i.name AS item_name, c.expected, c.counted, c.unit_ids,
+       c.missing_unit_ids, c.notes, c.counted_by, c.counted_at, c.movement_id
+FROM stocktake_counts c
+JOIN items i ON i.id = c.item_id
+WHERE c.stocktake_id = $1
+ORDER BY i.name, c.item_id;
+
+-- name: SetStocktakeCountMovement :exec
+UPDATE stocktake_counts
+SET movement_id = $3
+WHERE stocktake_id = $1 AND item_id = $2;
+
+-- name: CountUncountedItems :one
+-- Items in the catalogue the stocktake has no count for
+SELECT COUNT(*) FROM items i
+WHERE i.archived_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = i.id AND d.status = 'binned')
+  AND NOT EXISTS (SELECT 1 FROM stocktake_counts c WHERE c.stocktake_id = $1 AND c.item_id = i.id);
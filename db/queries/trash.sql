@@ -0,0 +1,29 @@
+-- name: ListTrash :many
+-- binned groups/items and cancelled bookings, newest first. Only bookings that
+-- were confirmed and have not reached pickup yet can be put back.
+SELECT dr.entity_type::TEXT AS entity_type, dr.entity_id, dr.entity_name::TEXT AS entity_name,
+    dr.requested_by AS removed_by, dr.approved_at AS removed_at, dr.reason,
+    dr.purge_after, TRUE AS restorable
+FROM deletion_requests dr
+WHERE dr.status = 'binned'
+  AND (sqlc.narg('entity_type')::TEXT IS NULL OR dr.entity_type::TEXT = sqlc.narg('entity_type'))
+UNION ALL
+SELECT 'booking'::TEXT, b.id, i.name::TEXT,
+    b.cancelled_by, b.cancelled_at, b.cancellation_reason,
+    NULL::TIMESTAMP, (b.confirmed_at IS NOT NULL AND b.pick_up_date > NOW())
+FROM booking b
+JOIN items i ON b.item_id = i.id
+WHERE b.status = 'cancelled'
+  AND (sqlc.narg('entity_type')::TEXT IS NULL OR sqlc.narg('entity_type')::TEXT = 'booking')
+ORDER BY removed_at DESC NULLS LAST
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountTrash :one
+SELECT (
+    (SELECT COUNT(*) FROM deletion_requests dr
+     WHERE dr.status = 'binned'
+       AND (sqlc.narg('entity_type')::TEXT IS NULL OR dr.entity_type::TEXT = sqlc.narg('entity_type')))
+  + (SELECT COUNT(*) FROM booking b
+     WHERE b.status = 'cancelled'
+       AND (sqlc.narg('entity_type')::TEXT IS NULL OR sqlc.narg('entity_type')::TEXT = 'booking'))
+)::BIGINT AS count;
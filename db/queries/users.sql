@@ -30,11 +30,51 @@ SELECT preferences FROM users WHERE id = $1;
 UPDATE users SET preferences = $1 WHERE id = $2 RETURNING preferences;
 
 -- name: GetUsersByIDsEmailOptIn :many
-SELECT id, email FROM users
-WHERE id = ANY(@ids::uuid[])
-AND (preferences->>'email_notifications') IS DISTINCT FROM 'false';
+-- users who take email, and with a notification_type, haven't turned that
+-- type off
+SELECT id, email FROM users u
+WHERE u.id = ANY(@ids::uuid[])
+AND (u.preferences->>'email_notifications') IS DISTINCT FROM 'false'
+AND (sqlc.narg('notification_type')::TEXT IS NULL OR NOT EXISTS (
+    SELECT 1 FROM notification_preferences np
+    WHERE np.user_id = u.id
+      AND np.notification_type = sqlc.narg('notification_type')
+      AND NOT np.email_enabled
+));
 
 -- name: CreateSignUpCode :one
 INSERT INTO signup_codes (id, code, email, role_name, scope, scope_id, created_at, used_at, expires_at, created_by)
 VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, NOW(), NULL, NOW() + INTERVAL '7 days', $6)
-    RETURNING *;
\ No newline at end of file
+    RETURNING *;
+-- name: SetUserStudentIDHash :exec
+UPDATE users SET student_id_hash = $2 WHERE id = $1;
+
+-- name: SetUserStudentIDHashIfUnset :execrows
+-- Returns 0 when the user already has a student ID on file
+UPDATE users SET student_id_hash = $2 WHERE id = $1 AND student_id_hash IS NULL;
+
+-- name: RecordStudentIDChange :exec
+INSERT INTO student_id_changes (user_id, changed_by, replaced)
+VALUES ($1, $2, $3);
+
+-- name: GetUserStudentIDHash :one
+SELECT student_id_hash FROM users WHERE id = $1;
+
+-- name: CreateImportedUser :one
+INSERT INTO users (email, name, program)
+VALUES ($1, $2, $3)
+RETURNING id, email;
+
+-- every user with each of their roles, one row per role; users without a
+-- role get one row with the role columns NULL
+-- name: ListUsersWithRoles :many
+SELECT u.id, u.email, u.name, ur.role_name, ur.scope, g.name AS group_name
+FROM users u
+LEFT JOIN user_roles ur ON ur.user_id = u.id
+LEFT JOIN groups g ON g.id = ur.scope_id
+ORDER BY u.email, ur.role_name, g.name;
+
+-- name: GetGlobalPermissionHolderIDs :many
+SELECT DISTINCT ur.user_id FROM user_roles ur
+JOIN role_permissions rp ON rp.role_name = ur.role_name
+WHERE rp.permission_name = $1 AND ur.scope = 'global';
@@ -0,0 +1,24 @@
+-- name: JoinItemWaitlist :one
+INSERT INTO item_waitlist (item_id, user_id, group_id, quantity)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetWaitingEntry :one
+SELECT * FROM item_waitlist
+WHERE item_id = $1 AND user_id = $2 AND notified_at IS NULL;
+
+-- name: ListItemWaitlist :many
+-- Entries still waiting, first in line first
+SELECT * FROM item_waitlist
+WHERE item_id = $1 AND notified_at IS NULL
+ORDER BY created_at, id;
+
+-- name: LeaveItemWaitlist :execrows
+DELETE FROM item_waitlist
+WHERE item_id = $1 AND user_id = $2 AND notified_at IS NULL;
+
+-- name: MarkWaitlistNotified :execrows
+-- Returns 0 when the entry was already notified or has left
+UPDATE item_waitlist
+SET notified_at = NOW()
+WHERE id = $1 AND notified_at IS NULL;
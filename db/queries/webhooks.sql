@@ -0,0 +1,54 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (url, description, secret, events, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhooks
+ORDER BY created_at DESC;
+
+-- name: GetWebhookByID :one
+SELECT * FROM webhooks
+WHERE id = $1;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1;
+
+-- name: ListWebhooksForEvent :many
+-- Webhooks subscribed to an event type
+SELECT * FROM webhooks
+WHERE sqlc.arg('event_type')::text = ANY(events)
+ORDER BY created_at;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetWebhookDeliveryForSend :one
+-- A delivery with the endpoint and secret it is sent with
+SELECT d.id, d.event_type, d.payload, d.status, w.url, w.secret
+FROM webhook_deliveries d
+JOIN webhooks w ON d.webhook_id = w.id
+WHERE d.id = $1;
+
+-- name: RecordWebhookAttempt :exec
+UPDATE webhook_deliveries
+SET attempts = attempts + 1,
+    status = $2,
+    response_status = $3,
+    last_error = $4,
+    last_attempt_at = NOW(),
+    delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() END
+WHERE id = $1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountWebhookDeliveries :one
+SELECT COUNT(*) FROM webhook_deliveries
+WHERE webhook_id = $1;
@@ -115,6 +115,35 @@ func (q *Queries) CreateUserRole(ctx context.Context, arg CreateUserRoleParams)
 	return err
 }
 
+const deleteUserRole = `-- name: DeleteUserRole :execrows
+DELETE FROM user_roles
+WHERE user_id = $1
+  AND role_name = $2
+  AND scope = $3
+  AND (scope = 'global' OR scope_id = $4)
+`
+
+type DeleteUserRoleParams struct {
+	UserID   *uuid.UUID  `json:"user_id"`
+	RoleName pgtype.Text `json:"role_name"`
+	Scope    ScopeType   `json:"scope"`
+	ScopeID  *uuid.UUID  `json:"scope_id"`
+}
+
+// global roles have no scope_id, so $4 only matters for group roles
+func (q *Queries) DeleteUserRole(ctx context.Context, arg DeleteUserRoleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserRole,
+		arg.UserID,
+		arg.RoleName,
+		arg.Scope,
+		arg.ScopeID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
 SELECT id, email FROM users WHERE email = $1
 `
@@ -12,6 +12,31 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const checkAvailabilityBookingConflict = `-- name: CheckAvailabilityBookingConflict :one
+SELECT EXISTS(
+  SELECT 1 FROM booking b
+  JOIN time_slots ts ON ts.id = $1
+  WHERE b.manager_id = $2
+    AND b.status NOT IN ('cancelled', 'expired', 'no_show', 'fulfilled')
+    AND b.pick_up_date >= $3::DATE + ts.start_time
+    AND b.pick_up_date < $3::DATE + ts.end_time
+) AS has_conflict
+`
+
+type CheckAvailabilityBookingConflictParams struct {
+	TimeSlotID uuid.UUID   `json:"time_slot_id"`
+	UserID     *uuid.UUID  `json:"user_id"`
+	Date       pgtype.Date `json:"date"`
+}
+
+// Check if the user already manages an active booking picked up during this slot/date
+func (q *Queries) CheckAvailabilityBookingConflict(ctx context.Context, arg CheckAvailabilityBookingConflictParams) (bool, error) {
+	row := q.db.QueryRow(ctx, checkAvailabilityBookingConflict, arg.TimeSlotID, arg.UserID, arg.Date)
+	var has_conflict bool
+	err := row.Scan(&has_conflict)
+	return has_conflict, err
+}
+
 const checkAvailabilityConflict = `-- name: CheckAvailabilityConflict :one
 SELECT EXISTS(
   SELECT 1 FROM user_availability
@@ -91,6 +116,21 @@ func (q *Queries) DeleteAvailability(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deletePastUnusedAvailability = `-- name: DeletePastUnusedAvailability :execrows
+DELETE FROM user_availability ua
+WHERE ua.date < CURRENT_DATE - $1::INT
+  AND NOT EXISTS (SELECT 1 FROM booking b WHERE b.availability_id = ua.id)
+`
+
+// Slots more than retention_days in the past that no booking ever used
+func (q *Queries) DeletePastUnusedAvailability(ctx context.Context, retentionDays int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePastUnusedAvailability, retentionDays)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getAvailabilityByDate = `-- name: GetAvailabilityByDate :many
 SELECT
   ua.id, ua.user_id, ua.time_slot_id, ua.date,
@@ -308,12 +348,16 @@ JOIN users u ON ua.user_id = u.id
 JOIN time_slots ts ON ua.time_slot_id = ts.id
 WHERE ($1::DATE IS NULL OR ua.date = $1)
   AND ($2::UUID IS NULL OR ua.user_id = $2)
+  AND ($3::DATE IS NULL OR ua.date >= $3)
+  AND ($4::DATE IS NULL OR ua.date <= $4)
 ORDER BY ua.date, ts.start_time
 `
 
 type ListAvailabilityParams struct {
 	FilterDate   pgtype.Date `json:"filter_date"`
 	FilterUserID *uuid.UUID  `json:"filter_user_id"`
+	FromDate     pgtype.Date `json:"from_date"`
+	ToDate       pgtype.Date `json:"to_date"`
 }
 
 type ListAvailabilityRow struct {
@@ -327,7 +371,12 @@ type ListAvailabilityRow struct {
 }
 
 func (q *Queries) ListAvailability(ctx context.Context, arg ListAvailabilityParams) ([]ListAvailabilityRow, error) {
-	rows, err := q.db.Query(ctx, listAvailability, arg.FilterDate, arg.FilterUserID)
+	rows, err := q.db.Query(ctx, listAvailability,
+		arg.FilterDate,
+		arg.FilterUserID,
+		arg.FromDate,
+		arg.ToDate,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -353,3 +402,63 @@ func (q *Queries) ListAvailability(ctx context.Context, arg ListAvailabilityPara
 	}
 	return items, nil
 }
+
+const listOpenAvailability = `-- name: ListOpenAvailability :many
+SELECT
+  ua.id,
+  ua.time_slot_id,
+  ua.date,
+  ts.start_time,
+  ts.end_time
+FROM user_availability ua
+JOIN time_slots ts ON ua.time_slot_id = ts.id
+WHERE ua.date >= $1::DATE
+  AND ua.date <= $2::DATE
+  AND ua.date + ts.start_time > LOCALTIMESTAMP
+  AND NOT EXISTS (
+    SELECT 1 FROM booking b
+    WHERE b.availability_id = ua.id
+      AND b.status NOT IN ('cancelled', 'expired', 'no_show', 'fulfilled')
+  )
+ORDER BY ua.date, ts.start_time, ua.id
+`
+
+type ListOpenAvailabilityParams struct {
+	FromDate pgtype.Date `json:"from_date"`
+	ToDate   pgtype.Date `json:"to_date"`
+}
+
+type ListOpenAvailabilityRow struct {
+	ID         uuid.UUID   `json:"id"`
+	TimeSlotID *uuid.UUID  `json:"time_slot_id"`
+	Date       pgtype.Date `json:"date"`
+	StartTime  pgtype.Time `json:"start_time"`
+	EndTime    pgtype.Time `json:"end_time"`
+}
+
+// Upcoming availability in a date range that no active booking has claimed
+func (q *Queries) ListOpenAvailability(ctx context.Context, arg ListOpenAvailabilityParams) ([]ListOpenAvailabilityRow, error) {
+	rows, err := q.db.Query(ctx, listOpenAvailability, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOpenAvailabilityRow{}
+	for rows.Next() {
+		var i ListOpenAvailabilityRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TimeSlotID,
+			&i.Date,
+			&i.StartTime,
+			&i.EndTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
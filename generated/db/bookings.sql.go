@@ -12,15 +12,59 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const appendBookingEvent = `-- name: AppendBookingEvent :one
+INSERT INTO booking_events (booking_id, actor_id, from_status, to_status, payload)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, booking_id, actor_id, from_status, to_status, payload, created_at
+`
+
+type AppendBookingEventParams struct {
+	BookingID  uuid.UUID         `json:"booking_id"`
+	ActorID    *uuid.UUID        `json:"actor_id"`
+	FromStatus NullRequestStatus `json:"from_status"`
+	ToStatus   RequestStatus     `json:"to_status"`
+	Payload    []byte            `json:"payload"`
+}
+
+func (q *Queries) AppendBookingEvent(ctx context.Context, arg AppendBookingEventParams) (BookingEvent, error) {
+	row := q.db.QueryRow(ctx, appendBookingEvent,
+		arg.BookingID,
+		arg.ActorID,
+		arg.FromStatus,
+		arg.ToStatus,
+		arg.Payload,
+	)
+	var i BookingEvent
+	err := row.Scan(
+		&i.ID,
+		&i.BookingID,
+		&i.ActorID,
+		&i.FromStatus,
+		&i.ToStatus,
+		&i.Payload,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const cancelBooking = `-- name: CancelBooking :one
 UPDATE booking
-SET status = 'cancelled'
+SET status = 'cancelled',
+    cancelled_by = $2,
+    cancelled_at = NOW(),
+    cancellation_reason = $3
 WHERE id = $1
-RETURNING id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, confirmed_at, confirmed_by, created_at
+RETURNING id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, confirmed_at, confirmed_by, created_at, is_test, cancelled_by, cancelled_at, cancellation_reason, unit_id
 `
 
-func (q *Queries) CancelBooking(ctx context.Context, id uuid.UUID) (Booking, error) {
-	row := q.db.QueryRow(ctx, cancelBooking, id)
+type CancelBookingParams struct {
+	ID                 uuid.UUID   `json:"id"`
+	CancelledBy        *uuid.UUID  `json:"cancelled_by"`
+	CancellationReason pgtype.Text `json:"cancellation_reason"`
+}
+
+func (q *Queries) CancelBooking(ctx context.Context, arg CancelBookingParams) (Booking, error) {
+	row := q.db.QueryRow(ctx, cancelBooking, arg.ID, arg.CancelledBy, arg.CancellationReason)
 	var i Booking
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +81,11 @@ func (q *Queries) CancelBooking(ctx context.Context, id uuid.UUID) (Booking, err
 		&i.ConfirmedAt,
 		&i.ConfirmedBy,
 		&i.CreatedAt,
+		&i.IsTest,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.UnitID,
 	)
 	return i, err
 }
@@ -47,7 +96,7 @@ SET status = 'confirmed',
     confirmed_at = NOW(),
     confirmed_by = $2
 WHERE id = $1
-RETURNING id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, confirmed_at, confirmed_by, created_at
+RETURNING id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, confirmed_at, confirmed_by, created_at, is_test, cancelled_by, cancelled_at, cancellation_reason, unit_id
 `
 
 type ConfirmBookingParams struct {
@@ -73,6 +122,11 @@ func (q *Queries) ConfirmBooking(ctx context.Context, arg ConfirmBookingParams)
 		&i.ConfirmedAt,
 		&i.ConfirmedBy,
 		&i.CreatedAt,
+		&i.IsTest,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.UnitID,
 	)
 	return i, err
 }
@@ -109,17 +163,48 @@ func (q *Queries) CountBookings(ctx context.Context, arg CountBookingsParams) (i
 const countBookingsByUser = `-- name: CountBookingsByUser :one
 SELECT COUNT(*) as count
 FROM booking b
+JOIN user_availability ua ON b.availability_id = ua.id
 WHERE b.requester_id = $1
   AND ($2::request_status IS NULL OR b.status = $2)
+  AND ($3::DATE IS NULL OR ua.date >= $3)
+  AND ($4::DATE IS NULL OR ua.date <= $4)
 `
 
 type CountBookingsByUserParams struct {
 	RequesterID *uuid.UUID        `json:"requester_id"`
 	Status      NullRequestStatus `json:"status"`
+	FromDate    pgtype.Date       `json:"from_date"`
+	ToDate      pgtype.Date       `json:"to_date"`
 }
 
 func (q *Queries) CountBookingsByUser(ctx context.Context, arg CountBookingsByUserParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countBookingsByUser, arg.RequesterID, arg.Status)
+	row := q.db.QueryRow(ctx, countBookingsByUser,
+		arg.RequesterID,
+		arg.Status,
+		arg.FromDate,
+		arg.ToDate,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUpcomingItemBookings = `-- name: CountUpcomingItemBookings :one
+SELECT COUNT(*) FROM booking
+WHERE item_id = $1
+  AND status IN ('pending_confirmation', 'confirmed')
+  AND pick_up_date >= NOW()
+  AND pick_up_date < $2::TIMESTAMP
+`
+
+type CountUpcomingItemBookingsParams struct {
+	ItemID *uuid.UUID       `json:"item_id"`
+	Cutoff pgtype.Timestamp `json:"cutoff"`
+}
+
+// Live bookings of the item picked up between now and the cutoff
+func (q *Queries) CountUpcomingItemBookings(ctx context.Context, arg CountUpcomingItemBookingsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUpcomingItemBookings, arg.ItemID, arg.Cutoff)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -128,10 +213,13 @@ func (q *Queries) CountBookingsByUser(ctx context.Context, arg CountBookingsByUs
 const createBooking = `-- name: CreateBooking :one
 INSERT INTO booking (
     id, requester_id, manager_id, item_id, group_id, availability_id,
-    pick_up_date, pick_up_location, return_date, return_location, status
+    pick_up_date, pick_up_location, return_date, return_location, status, is_test
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-RETURNING id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, confirmed_at, confirmed_by, created_at
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+    COALESCE((SELECT g.sandbox FROM groups g WHERE g.id = $5), FALSE)
+)
+RETURNING id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, confirmed_at, confirmed_by, created_at, is_test, cancelled_by, cancelled_at, cancellation_reason, unit_id
 `
 
 type CreateBookingParams struct {
@@ -178,13 +266,73 @@ func (q *Queries) CreateBooking(ctx context.Context, arg CreateBookingParams) (B
 		&i.ConfirmedAt,
 		&i.ConfirmedBy,
 		&i.CreatedAt,
+		&i.IsTest,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.UnitID,
+	)
+	return i, err
+}
+
+const expireBooking = `-- name: ExpireBooking :execrows
+UPDATE booking
+SET status = 'expired'
+WHERE id = $1 AND status = 'pending_confirmation'
+`
+
+// Only a booking still waiting for confirmation is expired
+func (q *Queries) ExpireBooking(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, expireBooking, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const fulfillBooking = `-- name: FulfillBooking :one
+UPDATE booking
+SET status = 'fulfilled', unit_id = $2
+WHERE id = $1
+RETURNING id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, confirmed_at, confirmed_by, created_at, is_test, cancelled_by, cancelled_at, cancellation_reason, unit_id
+`
+
+type FulfillBookingParams struct {
+	ID     uuid.UUID  `json:"id"`
+	UnitID *uuid.UUID `json:"unit_id"`
+}
+
+// the item was handed over at pickup, with the unit for items tracked by unit
+func (q *Queries) FulfillBooking(ctx context.Context, arg FulfillBookingParams) (Booking, error) {
+	row := q.db.QueryRow(ctx, fulfillBooking, arg.ID, arg.UnitID)
+	var i Booking
+	err := row.Scan(
+		&i.ID,
+		&i.RequesterID,
+		&i.ManagerID,
+		&i.ItemID,
+		&i.GroupID,
+		&i.AvailabilityID,
+		&i.PickUpDate,
+		&i.PickUpLocation,
+		&i.ReturnDate,
+		&i.ReturnLocation,
+		&i.Status,
+		&i.ConfirmedAt,
+		&i.ConfirmedBy,
+		&i.CreatedAt,
+		&i.IsTest,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.UnitID,
 	)
 	return i, err
 }
 
 const getBookingByID = `-- name: GetBookingByID :one
 SELECT
-    b.id, b.requester_id, b.manager_id, b.item_id, b.group_id, b.availability_id, b.pick_up_date, b.pick_up_location, b.return_date, b.return_location, b.status, b.confirmed_at, b.confirmed_by, b.created_at,
+    b.id, b.requester_id, b.manager_id, b.item_id, b.group_id, b.availability_id, b.pick_up_date, b.pick_up_location, b.return_date, b.return_location, b.status, b.confirmed_at, b.confirmed_by, b.created_at, b.is_test, b.cancelled_by, b.cancelled_at, b.cancellation_reason, b.unit_id,
     requester.email as requester_email,
     manager.email as manager_email,
     i.name as item_name,
@@ -204,28 +352,33 @@ WHERE b.id = $1
 `
 
 type GetBookingByIDRow struct {
-	ID               uuid.UUID        `json:"id"`
-	RequesterID      *uuid.UUID       `json:"requester_id"`
-	ManagerID        *uuid.UUID       `json:"manager_id"`
-	ItemID           *uuid.UUID       `json:"item_id"`
-	GroupID          *uuid.UUID       `json:"group_id"`
-	AvailabilityID   *uuid.UUID       `json:"availability_id"`
-	PickUpDate       pgtype.Timestamp `json:"pick_up_date"`
-	PickUpLocation   string           `json:"pick_up_location"`
-	ReturnDate       pgtype.Timestamp `json:"return_date"`
-	ReturnLocation   string           `json:"return_location"`
-	Status           RequestStatus    `json:"status"`
-	ConfirmedAt      pgtype.Timestamp `json:"confirmed_at"`
-	ConfirmedBy      *uuid.UUID       `json:"confirmed_by"`
-	CreatedAt        pgtype.Timestamp `json:"created_at"`
-	RequesterEmail   string           `json:"requester_email"`
-	ManagerEmail     pgtype.Text      `json:"manager_email"`
-	ItemName         string           `json:"item_name"`
-	ItemType         ItemType         `json:"item_type"`
-	AvailabilityDate pgtype.Date      `json:"availability_date"`
-	GroupName        string           `json:"group_name"`
-	StartTime        pgtype.Time      `json:"start_time"`
-	EndTime          pgtype.Time      `json:"end_time"`
+	ID                 uuid.UUID        `json:"id"`
+	RequesterID        *uuid.UUID       `json:"requester_id"`
+	ManagerID          *uuid.UUID       `json:"manager_id"`
+	ItemID             *uuid.UUID       `json:"item_id"`
+	GroupID            *uuid.UUID       `json:"group_id"`
+	AvailabilityID     *uuid.UUID       `json:"availability_id"`
+	PickUpDate         pgtype.Timestamp `json:"pick_up_date"`
+	PickUpLocation     string           `json:"pick_up_location"`
+	ReturnDate         pgtype.Timestamp `json:"return_date"`
+	ReturnLocation     string           `json:"return_location"`
+	Status             RequestStatus    `json:"status"`
+	ConfirmedAt        pgtype.Timestamp `json:"confirmed_at"`
+	ConfirmedBy        *uuid.UUID       `json:"confirmed_by"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+	IsTest             bool             `json:"is_test"`
+	CancelledBy        *uuid.UUID       `json:"cancelled_by"`
+	CancelledAt        pgtype.Timestamp `json:"cancelled_at"`
+	CancellationReason pgtype.Text      `json:"cancellation_reason"`
+	UnitID             *uuid.UUID       `json:"unit_id"`
+	RequesterEmail     string           `json:"requester_email"`
+	ManagerEmail       pgtype.Text      `json:"manager_email"`
+	ItemName           string           `json:"item_name"`
+	ItemType           ItemType         `json:"item_type"`
+	AvailabilityDate   pgtype.Date      `json:"availability_date"`
+	GroupName          string           `json:"group_name"`
+	StartTime          pgtype.Time      `json:"start_time"`
+	EndTime            pgtype.Time      `json:"end_time"`
 }
 
 func (q *Queries) GetBookingByID(ctx context.Context, id uuid.UUID) (GetBookingByIDRow, error) {
@@ -246,6 +399,11 @@ func (q *Queries) GetBookingByID(ctx context.Context, id uuid.UUID) (GetBookingB
 		&i.ConfirmedAt,
 		&i.ConfirmedBy,
 		&i.CreatedAt,
+		&i.IsTest,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.UnitID,
 		&i.RequesterEmail,
 		&i.ManagerEmail,
 		&i.ItemName,
@@ -259,7 +417,7 @@ func (q *Queries) GetBookingByID(ctx context.Context, id uuid.UUID) (GetBookingB
 }
 
 const getBookingByIDForUpdate = `-- name: GetBookingByIDForUpdate :one
-SELECT id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, confirmed_at, confirmed_by, created_at FROM booking WHERE id = $1 FOR UPDATE
+SELECT id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, confirmed_at, confirmed_by, created_at, is_test, cancelled_by, cancelled_at, cancellation_reason, unit_id FROM booking WHERE id = $1 FOR UPDATE
 `
 
 func (q *Queries) GetBookingByIDForUpdate(ctx context.Context, id uuid.UUID) (Booking, error) {
@@ -280,18 +438,25 @@ func (q *Queries) GetBookingByIDForUpdate(ctx context.Context, id uuid.UUID) (Bo
 		&i.ConfirmedAt,
 		&i.ConfirmedBy,
 		&i.CreatedAt,
+		&i.IsTest,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.UnitID,
 	)
 	return i, err
 }
 
 const getExpiredBookings = `-- name: GetExpiredBookings :many
-SELECT id FROM booking
-WHERE status = 'pending_confirmation'
-  AND created_at < NOW() - INTERVAL '48 hours'
+SELECT b.id FROM booking b
+LEFT JOIN group_booking_policies p ON p.group_id = b.group_id
+WHERE b.status = 'pending_confirmation'
+  AND b.created_at < NOW() - make_interval(hours => COALESCE(p.confirmation_window_hours, $1::INT))
 `
 
-func (q *Queries) GetExpiredBookings(ctx context.Context) ([]uuid.UUID, error) {
-	rows, err := q.db.Query(ctx, getExpiredBookings)
+// Pending bookings past their group's confirmation window, or the default one
+func (q *Queries) GetExpiredBookings(ctx context.Context, defaultWindowHours int32) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, getExpiredBookings, defaultWindowHours)
 	if err != nil {
 		return nil, err
 	}
@@ -310,9 +475,119 @@ func (q *Queries) GetExpiredBookings(ctx context.Context) ([]uuid.UUID, error) {
 	return items, nil
 }
 
+const hasVerifiedBooking = `-- name: HasVerifiedBooking :one
+SELECT EXISTS (
+    SELECT 1 FROM booking_verifications
+    WHERE booking_id = $1 AND matched
+) AS verified
+`
+
+func (q *Queries) HasVerifiedBooking(ctx context.Context, bookingID uuid.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, hasVerifiedBooking, bookingID)
+	var verified bool
+	err := row.Scan(&verified)
+	return verified, err
+}
+
+const listAllBookingEvents = `-- name: ListAllBookingEvents :many
+SELECT id, booking_id, actor_id, from_status, to_status, payload, created_at FROM booking_events
+ORDER BY booking_id, created_at, id
+`
+
+func (q *Queries) ListAllBookingEvents(ctx context.Context) ([]BookingEvent, error) {
+	rows, err := q.db.Query(ctx, listAllBookingEvents)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []BookingEvent{}
+	for rows.Next() {
+		var i BookingEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookingID,
+			&i.ActorID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.Payload,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookingEvents = `-- name: ListBookingEvents :many
+SELECT id, booking_id, actor_id, from_status, to_status, payload, created_at FROM booking_events
+WHERE booking_id = $1
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListBookingEvents(ctx context.Context, bookingID uuid.UUID) ([]BookingEvent, error) {
+	rows, err := q.db.Query(ctx, listBookingEvents, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []BookingEvent{}
+	for rows.Next() {
+		var i BookingEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.BookingID,
+			&i.ActorID,
+			&i.FromStatus,
+			&i.ToStatus,
+			&i.Payload,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookingStatuses = `-- name: ListBookingStatuses :many
+SELECT id, status FROM booking ORDER BY id
+`
+
+type ListBookingStatusesRow struct {
+	ID     uuid.UUID     `json:"id"`
+	Status RequestStatus `json:"status"`
+}
+
+func (q *Queries) ListBookingStatuses(ctx context.Context) ([]ListBookingStatusesRow, error) {
+	rows, err := q.db.Query(ctx, listBookingStatuses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBookingStatusesRow{}
+	for rows.Next() {
+		var i ListBookingStatusesRow
+		if err := rows.Scan(&i.ID, &i.Status); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listBookings = `-- name: ListBookings :many
 SELECT
-    b.id, b.requester_id, b.manager_id, b.item_id, b.group_id, b.availability_id, b.pick_up_date, b.pick_up_location, b.return_date, b.return_location, b.status, b.confirmed_at, b.confirmed_by, b.created_at,
+    b.id, b.requester_id, b.manager_id, b.item_id, b.group_id, b.availability_id, b.pick_up_date, b.pick_up_location, b.return_date, b.return_location, b.status, b.confirmed_at, b.confirmed_by, b.created_at, b.is_test, b.cancelled_by, b.cancelled_at, b.cancellation_reason, b.unit_id,
     requester.email as requester_email,
     manager.email as manager_email,
     i.name as item_name,
@@ -324,53 +599,67 @@ LEFT JOIN users manager ON b.manager_id = manager.id
 JOIN items i ON b.item_id = i.id
 JOIN groups g ON b.group_id = g.id
 JOIN user_availability ua ON b.availability_id = ua.id
-WHERE ($3::request_status IS NULL OR b.status = $3)
-  AND ($4::UUID IS NULL OR b.group_id = $4)
-  AND ($5::DATE IS NULL OR ua.date >= $5)
-  AND ($6::DATE IS NULL OR ua.date <= $6)
-ORDER BY ua.date, b.pick_up_date
-LIMIT $1 OFFSET $2
+WHERE ($1::request_status IS NULL OR b.status = $1)
+  AND ($2::UUID IS NULL OR b.group_id = $2)
+  AND ($3::DATE IS NULL OR ua.date >= $3)
+  AND ($4::DATE IS NULL OR ua.date <= $4)
+ORDER BY
+  CASE WHEN $5::TEXT = 'created_at' AND NOT $6::BOOLEAN THEN b.created_at END ASC,
+  CASE WHEN $5::TEXT = 'created_at' AND $6::BOOLEAN THEN b.created_at END DESC,
+  CASE WHEN $5::TEXT = 'date' AND $6::BOOLEAN THEN ua.date END DESC,
+  CASE WHEN $5::TEXT = 'date' AND $6::BOOLEAN THEN b.pick_up_date END DESC,
+  ua.date, b.pick_up_date, b.id
+LIMIT $7 OFFSET $8
 `
 
 type ListBookingsParams struct {
-	Limit    int64             `json:"limit"`
-	Offset   int64             `json:"offset"`
 	Status   NullRequestStatus `json:"status"`
 	GroupID  *uuid.UUID        `json:"group_id"`
 	FromDate pgtype.Date       `json:"from_date"`
 	ToDate   pgtype.Date       `json:"to_date"`
+	SortBy   string            `json:"sort_by"`
+	SortDesc bool              `json:"sort_desc"`
+	Limit    int64             `json:"limit"`
+	Offset   int64             `json:"offset"`
 }
 
 type ListBookingsRow struct {
-	ID               uuid.UUID        `json:"id"`
-	RequesterID      *uuid.UUID       `json:"requester_id"`
-	ManagerID        *uuid.UUID       `json:"manager_id"`
-	ItemID           *uuid.UUID       `json:"item_id"`
-	GroupID          *uuid.UUID       `json:"group_id"`
-	AvailabilityID   *uuid.UUID       `json:"availability_id"`
-	PickUpDate       pgtype.Timestamp `json:"pick_up_date"`
-	PickUpLocation   string           `json:"pick_up_location"`
-	ReturnDate       pgtype.Timestamp `json:"return_date"`
-	ReturnLocation   string           `json:"return_location"`
-	Status           RequestStatus    `json:"status"`
-	ConfirmedAt      pgtype.Timestamp `json:"confirmed_at"`
-	ConfirmedBy      *uuid.UUID       `json:"confirmed_by"`
-	CreatedAt        pgtype.Timestamp `json:"created_at"`
-	RequesterEmail   string           `json:"requester_email"`
-	ManagerEmail     pgtype.Text      `json:"manager_email"`
-	ItemName         string           `json:"item_name"`
-	AvailabilityDate pgtype.Date      `json:"availability_date"`
-	GroupName        string           `json:"group_name"`
+	ID                 uuid.UUID        `json:"id"`
+	RequesterID        *uuid.UUID       `json:"requester_id"`
+	ManagerID          *uuid.UUID       `json:"manager_id"`
+	ItemID             *uuid.UUID       `json:"item_id"`
+	GroupID            *uuid.UUID       `json:"group_id"`
+	AvailabilityID     *uuid.UUID       `json:"availability_id"`
+	PickUpDate         pgtype.Timestamp `json:"pick_up_date"`
+	PickUpLocation     string           `json:"pick_up_location"`
+	ReturnDate         pgtype.Timestamp `json:"return_date"`
+	ReturnLocation     string           `json:"return_location"`
+	Status             RequestStatus    `json:"status"`
+	ConfirmedAt        pgtype.Timestamp `json:"confirmed_at"`
+	ConfirmedBy        *uuid.UUID       `json:"confirmed_by"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+	IsTest             bool             `json:"is_test"`
+	CancelledBy        *uuid.UUID       `json:"cancelled_by"`
+	CancelledAt        pgtype.Timestamp `json:"cancelled_at"`
+	CancellationReason pgtype.Text      `json:"cancellation_reason"`
+	UnitID             *uuid.UUID       `json:"unit_id"`
+	RequesterEmail     string           `json:"requester_email"`
+	ManagerEmail       pgtype.Text      `json:"manager_email"`
+	ItemName           string           `json:"item_name"`
+	AvailabilityDate   pgtype.Date      `json:"availability_date"`
+	GroupName          string           `json:"group_name"`
 }
 
 func (q *Queries) ListBookings(ctx context.Context, arg ListBookingsParams) ([]ListBookingsRow, error) {
 	rows, err := q.db.Query(ctx, listBookings,
-		arg.Limit,
-		arg.Offset,
 		arg.Status,
 		arg.GroupID,
 		arg.FromDate,
 		arg.ToDate,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.Limit,
+		arg.Offset,
 	)
 	if err != nil {
 		return nil, err
@@ -394,6 +683,11 @@ func (q *Queries) ListBookings(ctx context.Context, arg ListBookingsParams) ([]L
 			&i.ConfirmedAt,
 			&i.ConfirmedBy,
 			&i.CreatedAt,
+			&i.IsTest,
+			&i.CancelledBy,
+			&i.CancelledAt,
+			&i.CancellationReason,
+			&i.UnitID,
 			&i.RequesterEmail,
 			&i.ManagerEmail,
 			&i.ItemName,
@@ -412,7 +706,7 @@ func (q *Queries) ListBookings(ctx context.Context, arg ListBookingsParams) ([]L
 
 const listBookingsByUser = `-- name: ListBookingsByUser :many
 SELECT
-    b.id, b.requester_id, b.manager_id, b.item_id, b.group_id, b.availability_id, b.pick_up_date, b.pick_up_location, b.return_date, b.return_location, b.status, b.confirmed_at, b.confirmed_by, b.created_at,
+    b.id, b.requester_id, b.manager_id, b.item_id, b.group_id, b.availability_id, b.pick_up_date, b.pick_up_location, b.return_date, b.return_location, b.status, b.confirmed_at, b.confirmed_by, b.created_at, b.is_test, b.cancelled_by, b.cancelled_at, b.cancellation_reason, b.unit_id,
     manager.email as manager_email,
     i.name as item_name,
     ua.date as availability_date,
@@ -424,46 +718,65 @@ JOIN items i ON b.item_id = i.id
 JOIN user_availability ua ON b.availability_id = ua.id
 JOIN time_slots ts ON ua.time_slot_id = ts.id
 WHERE b.requester_id = $1
-  AND ($4::request_status IS NULL OR b.status = $4)
-ORDER BY ua.date DESC
-LIMIT $2 OFFSET $3
+  AND ($2::request_status IS NULL OR b.status = $2)
+  AND ($3::DATE IS NULL OR ua.date >= $3)
+  AND ($4::DATE IS NULL OR ua.date <= $4)
+ORDER BY
+  CASE WHEN $5::TEXT = 'created_at' AND NOT $6::BOOLEAN THEN b.created_at END ASC,
+  CASE WHEN $5::TEXT = 'created_at' AND $6::BOOLEAN THEN b.created_at END DESC,
+  CASE WHEN $5::TEXT = 'date' AND NOT $6::BOOLEAN THEN ua.date END ASC,
+  ua.date DESC, b.id
+LIMIT $7 OFFSET $8
 `
 
 type ListBookingsByUserParams struct {
 	RequesterID *uuid.UUID        `json:"requester_id"`
+	Status      NullRequestStatus `json:"status"`
+	FromDate    pgtype.Date       `json:"from_date"`
+	ToDate      pgtype.Date       `json:"to_date"`
+	SortBy      string            `json:"sort_by"`
+	SortDesc    bool              `json:"sort_desc"`
 	Limit       int64             `json:"limit"`
 	Offset      int64             `json:"offset"`
-	Status      NullRequestStatus `json:"status"`
 }
 
 type ListBookingsByUserRow struct {
-	ID               uuid.UUID        `json:"id"`
-	RequesterID      *uuid.UUID       `json:"requester_id"`
-	ManagerID        *uuid.UUID       `json:"manager_id"`
-	ItemID           *uuid.UUID       `json:"item_id"`
-	GroupID          *uuid.UUID       `json:"group_id"`
-	AvailabilityID   *uuid.UUID       `json:"availability_id"`
-	PickUpDate       pgtype.Timestamp `json:"pick_up_date"`
-	PickUpLocation   string           `json:"pick_up_location"`
-	ReturnDate       pgtype.Timestamp `json:"return_date"`
-	ReturnLocation   string           `json:"return_location"`
-	Status           RequestStatus    `json:"status"`
-	ConfirmedAt      pgtype.Timestamp `json:"confirmed_at"`
-	ConfirmedBy      *uuid.UUID       `json:"confirmed_by"`
-	CreatedAt        pgtype.Timestamp `json:"created_at"`
-	ManagerEmail     pgtype.Text      `json:"manager_email"`
-	ItemName         string           `json:"item_name"`
-	AvailabilityDate pgtype.Date      `json:"availability_date"`
-	StartTime        pgtype.Time      `json:"start_time"`
-	EndTime          pgtype.Time      `json:"end_time"`
+	ID                 uuid.UUID        `json:"id"`
+	RequesterID        *uuid.UUID       `json:"requester_id"`
+	ManagerID          *uuid.UUID       `json:"manager_id"`
+	ItemID             *uuid.UUID       `json:"item_id"`
+	GroupID            *uuid.UUID       `json:"group_id"`
+	AvailabilityID     *uuid.UUID       `json:"availability_id"`
+	PickUpDate         pgtype.Timestamp `json:"pick_up_date"`
+	PickUpLocation     string           `json:"pick_up_location"`
+	ReturnDate         pgtype.Timestamp `json:"return_date"`
+	ReturnLocation     string           `json:"return_location"`
+	Status             RequestStatus    `json:"status"`
+	ConfirmedAt        pgtype.Timestamp `json:"confirmed_at"`
+	ConfirmedBy        *uuid.UUID       `json:"confirmed_by"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+	IsTest             bool             `json:"is_test"`
+	CancelledBy        *uuid.UUID       `json:"cancelled_by"`
+	CancelledAt        pgtype.Timestamp `json:"cancelled_at"`
+	CancellationReason pgtype.Text      `json:"cancellation_reason"`
+	UnitID             *uuid.UUID       `json:"unit_id"`
+	ManagerEmail       pgtype.Text      `json:"manager_email"`
+	ItemName           string           `json:"item_name"`
+	AvailabilityDate   pgtype.Date      `json:"availability_date"`
+	StartTime          pgtype.Time      `json:"start_time"`
+	EndTime            pgtype.Time      `json:"end_time"`
 }
 
 func (q *Queries) ListBookingsByUser(ctx context.Context, arg ListBookingsByUserParams) ([]ListBookingsByUserRow, error) {
 	rows, err := q.db.Query(ctx, listBookingsByUser,
 		arg.RequesterID,
+		arg.Status,
+		arg.FromDate,
+		arg.ToDate,
+		arg.SortBy,
+		arg.SortDesc,
 		arg.Limit,
 		arg.Offset,
-		arg.Status,
 	)
 	if err != nil {
 		return nil, err
@@ -487,6 +800,11 @@ func (q *Queries) ListBookingsByUser(ctx context.Context, arg ListBookingsByUser
 			&i.ConfirmedAt,
 			&i.ConfirmedBy,
 			&i.CreatedAt,
+			&i.IsTest,
+			&i.CancelledBy,
+			&i.CancelledAt,
+			&i.CancellationReason,
+			&i.UnitID,
 			&i.ManagerEmail,
 			&i.ItemName,
 			&i.AvailabilityDate,
@@ -505,7 +823,7 @@ func (q *Queries) ListBookingsByUser(ctx context.Context, arg ListBookingsByUser
 
 const listPendingConfirmation = `-- name: ListPendingConfirmation :many
 SELECT
-    b.id, b.requester_id, b.manager_id, b.item_id, b.group_id, b.availability_id, b.pick_up_date, b.pick_up_location, b.return_date, b.return_location, b.status, b.confirmed_at, b.confirmed_by, b.created_at,
+    b.id, b.requester_id, b.manager_id, b.item_id, b.group_id, b.availability_id, b.pick_up_date, b.pick_up_location, b.return_date, b.return_location, b.status, b.confirmed_at, b.confirmed_by, b.created_at, b.is_test, b.cancelled_by, b.cancelled_at, b.cancellation_reason, b.unit_id,
     requester.email as requester_email,
     i.name as item_name,
     ua.date as availability_date,
@@ -523,25 +841,30 @@ ORDER BY ua.date, ts.start_time
 `
 
 type ListPendingConfirmationRow struct {
-	ID               uuid.UUID        `json:"id"`
-	RequesterID      *uuid.UUID       `json:"requester_id"`
-	ManagerID        *uuid.UUID       `json:"manager_id"`
-	ItemID           *uuid.UUID       `json:"item_id"`
-	GroupID          *uuid.UUID       `json:"group_id"`
-	AvailabilityID   *uuid.UUID       `json:"availability_id"`
-	PickUpDate       pgtype.Timestamp `json:"pick_up_date"`
-	PickUpLocation   string           `json:"pick_up_location"`
-	ReturnDate       pgtype.Timestamp `json:"return_date"`
-	ReturnLocation   string           `json:"return_location"`
-	Status           RequestStatus    `json:"status"`
-	ConfirmedAt      pgtype.Timestamp `json:"confirmed_at"`
-	ConfirmedBy      *uuid.UUID       `json:"confirmed_by"`
-	CreatedAt        pgtype.Timestamp `json:"created_at"`
-	RequesterEmail   string           `json:"requester_email"`
-	ItemName         string           `json:"item_name"`
-	AvailabilityDate pgtype.Date      `json:"availability_date"`
-	GroupName        string           `json:"group_name"`
-	StartTime        pgtype.Time      `json:"start_time"`
+	ID                 uuid.UUID        `json:"id"`
+	RequesterID        *uuid.UUID       `json:"requester_id"`
+	ManagerID          *uuid.UUID       `json:"manager_id"`
+	ItemID             *uuid.UUID       `json:"item_id"`
+	GroupID            *uuid.UUID       `json:"group_id"`
+	AvailabilityID     *uuid.UUID       `json:"availability_id"`
+	PickUpDate         pgtype.Timestamp `json:"pick_up_date"`
+	PickUpLocation     string           `json:"pick_up_location"`
+	ReturnDate         pgtype.Timestamp `json:"return_date"`
+	ReturnLocation     string           `json:"return_location"`
+	Status             RequestStatus    `json:"status"`
+	ConfirmedAt        pgtype.Timestamp `json:"confirmed_at"`
+	ConfirmedBy        *uuid.UUID       `json:"confirmed_by"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+	IsTest             bool             `json:"is_test"`
+	CancelledBy        *uuid.UUID       `json:"cancelled_by"`
+	CancelledAt        pgtype.Timestamp `json:"cancelled_at"`
+	CancellationReason pgtype.Text      `json:"cancellation_reason"`
+	UnitID             *uuid.UUID       `json:"unit_id"`
+	RequesterEmail     string           `json:"requester_email"`
+	ItemName           string           `json:"item_name"`
+	AvailabilityDate   pgtype.Date      `json:"availability_date"`
+	GroupName          string           `json:"group_name"`
+	StartTime          pgtype.Time      `json:"start_time"`
 }
 
 func (q *Queries) ListPendingConfirmation(ctx context.Context, groupID *uuid.UUID) ([]ListPendingConfirmationRow, error) {
@@ -568,6 +891,11 @@ func (q *Queries) ListPendingConfirmation(ctx context.Context, groupID *uuid.UUI
 			&i.ConfirmedAt,
 			&i.ConfirmedBy,
 			&i.CreatedAt,
+			&i.IsTest,
+			&i.CancelledBy,
+			&i.CancelledAt,
+			&i.CancellationReason,
+			&i.UnitID,
 			&i.RequesterEmail,
 			&i.ItemName,
 			&i.AvailabilityDate,
@@ -583,3 +911,314 @@ func (q *Queries) ListPendingConfirmation(ctx context.Context, groupID *uuid.UUI
 	}
 	return items, nil
 }
+
+const listUpcomingBookingsByUser = `-- name: ListUpcomingBookingsByUser :many
+SELECT
+    b.id, b.requester_id, b.manager_id, b.item_id, b.group_id, b.availability_id, b.pick_up_date, b.pick_up_location, b.return_date, b.return_location, b.status, b.confirmed_at, b.confirmed_by, b.created_at, b.is_test, b.cancelled_by, b.cancelled_at, b.cancellation_reason, b.unit_id,
+    manager.email as manager_email,
+    i.name as item_name,
+    ua.date as availability_date,
+    ts.start_time,
+    ts.end_time
+FROM booking b
+LEFT JOIN users manager ON b.manager_id = manager.id
+JOIN items i ON b.item_id = i.id
+JOIN user_availability ua ON b.availability_id = ua.id
+JOIN time_slots ts ON ua.time_slot_id = ts.id
+WHERE b.requester_id = $1
+  AND b.status IN ('pending_confirmation', 'confirmed')
+  AND b.pick_up_date >= NOW()
+ORDER BY b.pick_up_date, b.id
+LIMIT $2
+`
+
+type ListUpcomingBookingsByUserParams struct {
+	RequesterID *uuid.UUID `json:"requester_id"`
+	Limit       int64      `json:"limit"`
+}
+
+type ListUpcomingBookingsByUserRow struct {
+	ID                 uuid.UUID        `json:"id"`
+	RequesterID        *uuid.UUID       `json:"requester_id"`
+	ManagerID          *uuid.UUID       `json:"manager_id"`
+	ItemID             *uuid.UUID       `json:"item_id"`
+	GroupID            *uuid.UUID       `json:"group_id"`
+	AvailabilityID     *uuid.UUID       `json:"availability_id"`
+	PickUpDate         pgtype.Timestamp `json:"pick_up_date"`
+	PickUpLocation     string           `json:"pick_up_location"`
+	ReturnDate         pgtype.Timestamp `json:"return_date"`
+	ReturnLocation     string           `json:"return_location"`
+	Status             RequestStatus    `json:"status"`
+	ConfirmedAt        pgtype.Timestamp `json:"confirmed_at"`
+	ConfirmedBy        *uuid.UUID       `json:"confirmed_by"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+	IsTest             bool             `json:"is_test"`
+	CancelledBy        *uuid.UUID       `json:"cancelled_by"`
+	CancelledAt        pgtype.Timestamp `json:"cancelled_at"`
+	CancellationReason pgtype.Text      `json:"cancellation_reason"`
+	UnitID             *uuid.UUID       `json:"unit_id"`
+	ManagerEmail       pgtype.Text      `json:"manager_email"`
+	ItemName           string           `json:"item_name"`
+	AvailabilityDate   pgtype.Date      `json:"availability_date"`
+	StartTime          pgtype.Time      `json:"start_time"`
+	EndTime            pgtype.Time      `json:"end_time"`
+}
+
+// The user's live bookings still to be picked up, soonest first
+func (q *Queries) ListUpcomingBookingsByUser(ctx context.Context, arg ListUpcomingBookingsByUserParams) ([]ListUpcomingBookingsByUserRow, error) {
+	rows, err := q.db.Query(ctx, listUpcomingBookingsByUser, arg.RequesterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUpcomingBookingsByUserRow{}
+	for rows.Next() {
+		var i ListUpcomingBookingsByUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequesterID,
+			&i.ManagerID,
+			&i.ItemID,
+			&i.GroupID,
+			&i.AvailabilityID,
+			&i.PickUpDate,
+			&i.PickUpLocation,
+			&i.ReturnDate,
+			&i.ReturnLocation,
+			&i.Status,
+			&i.ConfirmedAt,
+			&i.ConfirmedBy,
+			&i.CreatedAt,
+			&i.IsTest,
+			&i.CancelledBy,
+			&i.CancelledAt,
+			&i.CancellationReason,
+			&i.UnitID,
+			&i.ManagerEmail,
+			&i.ItemName,
+			&i.AvailabilityDate,
+			&i.StartTime,
+			&i.EndTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordBookingVerification = `-- name: RecordBookingVerification :one
+INSERT INTO booking_verifications (booking_id, verified_by, matched)
+VALUES ($1, $2, $3)
+RETURNING id, booking_id, verified_by, matched, created_at
+`
+
+type RecordBookingVerificationParams struct {
+	BookingID  uuid.UUID  `json:"booking_id"`
+	VerifiedBy *uuid.UUID `json:"verified_by"`
+	Matched    bool       `json:"matched"`
+}
+
+func (q *Queries) RecordBookingVerification(ctx context.Context, arg RecordBookingVerificationParams) (BookingVerification, error) {
+	row := q.db.QueryRow(ctx, recordBookingVerification, arg.BookingID, arg.VerifiedBy, arg.Matched)
+	var i BookingVerification
+	err := row.Scan(
+		&i.ID,
+		&i.BookingID,
+		&i.VerifiedBy,
+		&i.Matched,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const rescheduleBooking = `-- name: RescheduleBooking :one
+UPDATE booking
+SET availability_id = $2,
+    manager_id = $3,
+    pick_up_date = $4,
+    pick_up_location = $5,
+    return_date = $6,
+    return_location = $7
+WHERE id = $1
+RETURNING id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, confirmed_at, confirmed_by, created_at, is_test, cancelled_by, cancelled_at, cancellation_reason, unit_id
+`
+
+type RescheduleBookingParams struct {
+	ID             uuid.UUID        `json:"id"`
+	AvailabilityID *uuid.UUID       `json:"availability_id"`
+	ManagerID      *uuid.UUID       `json:"manager_id"`
+	PickUpDate     pgtype.Timestamp `json:"pick_up_date"`
+	PickUpLocation string           `json:"pick_up_location"`
+	ReturnDate     pgtype.Timestamp `json:"return_date"`
+	ReturnLocation string           `json:"return_location"`
+}
+
+// moves a booking to another availability slot; the status is left alone
+func (q *Queries) RescheduleBooking(ctx context.Context, arg RescheduleBookingParams) (Booking, error) {
+	row := q.db.QueryRow(ctx, rescheduleBooking,
+		arg.ID,
+		arg.AvailabilityID,
+		arg.ManagerID,
+		arg.PickUpDate,
+		arg.PickUpLocation,
+		arg.ReturnDate,
+		arg.ReturnLocation,
+	)
+	var i Booking
+	err := row.Scan(
+		&i.ID,
+		&i.RequesterID,
+		&i.ManagerID,
+		&i.ItemID,
+		&i.GroupID,
+		&i.AvailabilityID,
+		&i.PickUpDate,
+		&i.PickUpLocation,
+		&i.ReturnDate,
+		&i.ReturnLocation,
+		&i.Status,
+		&i.ConfirmedAt,
+		&i.ConfirmedBy,
+		&i.CreatedAt,
+		&i.IsTest,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.UnitID,
+	)
+	return i, err
+}
+
+const restoreCancelledBooking = `-- name: RestoreCancelledBooking :one
+UPDATE booking
+SET status = 'confirmed',
+    cancelled_by = NULL,
+    cancelled_at = NULL,
+    cancellation_reason = NULL
+WHERE id = $1 AND status = 'cancelled'
+RETURNING id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, confirmed_at, confirmed_by, created_at, is_test, cancelled_by, cancelled_at, cancellation_reason, unit_id
+`
+
+func (q *Queries) RestoreCancelledBooking(ctx context.Context, id uuid.UUID) (Booking, error) {
+	row := q.db.QueryRow(ctx, restoreCancelledBooking, id)
+	var i Booking
+	err := row.Scan(
+		&i.ID,
+		&i.RequesterID,
+		&i.ManagerID,
+		&i.ItemID,
+		&i.GroupID,
+		&i.AvailabilityID,
+		&i.PickUpDate,
+		&i.PickUpLocation,
+		&i.ReturnDate,
+		&i.ReturnLocation,
+		&i.Status,
+		&i.ConfirmedAt,
+		&i.ConfirmedBy,
+		&i.CreatedAt,
+		&i.IsTest,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.UnitID,
+	)
+	return i, err
+}
+
+const seedCancelBooking = `-- name: SeedCancelBooking :one
+UPDATE booking
+SET status = 'cancelled',
+    cancelled_at = $2,
+    cancelled_by = $3,
+    cancellation_reason = $4
+WHERE id = $1
+RETURNING id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, confirmed_at, confirmed_by, created_at, is_test, cancelled_by, cancelled_at, cancellation_reason, unit_id
+`
+
+type SeedCancelBookingParams struct {
+	ID                 uuid.UUID        `json:"id"`
+	CancelledAt        pgtype.Timestamp `json:"cancelled_at"`
+	CancelledBy        *uuid.UUID       `json:"cancelled_by"`
+	CancellationReason pgtype.Text      `json:"cancellation_reason"`
+}
+
+func (q *Queries) SeedCancelBooking(ctx context.Context, arg SeedCancelBookingParams) (Booking, error) {
+	row := q.db.QueryRow(ctx, seedCancelBooking,
+		arg.ID,
+		arg.CancelledAt,
+		arg.CancelledBy,
+		arg.CancellationReason,
+	)
+	var i Booking
+	err := row.Scan(
+		&i.ID,
+		&i.RequesterID,
+		&i.ManagerID,
+		&i.ItemID,
+		&i.GroupID,
+		&i.AvailabilityID,
+		&i.PickUpDate,
+		&i.PickUpLocation,
+		&i.ReturnDate,
+		&i.ReturnLocation,
+		&i.Status,
+		&i.ConfirmedAt,
+		&i.ConfirmedBy,
+		&i.CreatedAt,
+		&i.IsTest,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.UnitID,
+	)
+	return i, err
+}
+
+const seedConfirmBooking = `-- name: SeedConfirmBooking :one
+UPDATE booking
+SET status = 'confirmed',
+    confirmed_at = $2,
+    confirmed_by = $3
+WHERE id = $1
+RETURNING id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, confirmed_at, confirmed_by, created_at, is_test, cancelled_by, cancelled_at, cancellation_reason, unit_id
+`
+
+type SeedConfirmBookingParams struct {
+	ID          uuid.UUID        `json:"id"`
+	ConfirmedAt pgtype.Timestamp `json:"confirmed_at"`
+	ConfirmedBy *uuid.UUID       `json:"confirmed_by"`
+}
+
+// like ConfirmBooking and CancelBooking but with the time given; only the
+// seeder uses these to write historical bookings
+func (q *Queries) SeedConfirmBooking(ctx context.Context, arg SeedConfirmBookingParams) (Booking, error) {
+	row := q.db.QueryRow(ctx, seedConfirmBooking, arg.ID, arg.ConfirmedAt, arg.ConfirmedBy)
+	var i Booking
+	err := row.Scan(
+		&i.ID,
+		&i.RequesterID,
+		&i.ManagerID,
+		&i.ItemID,
+		&i.GroupID,
+		&i.AvailabilityID,
+		&i.PickUpDate,
+		&i.PickUpLocation,
+		&i.ReturnDate,
+		&i.ReturnLocation,
+		&i.Status,
+		&i.ConfirmedAt,
+		&i.ConfirmedBy,
+		&i.CreatedAt,
+		&i.IsTest,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.CancellationReason,
+		&i.UnitID,
+	)
+	return i, err
+}
@@ -15,9 +15,9 @@ import (
 const borrowItem = `-- name: BorrowItem :one
 INSERT INTO borrowings (
     user_id, group_id, item_id, quantity,
-    due_date, before_condition, before_condition_url
+    due_date, before_condition, before_condition_url, unit_id
 )
-SELECT $1, $2, i.id, $4, $5, $6, $7
+SELECT $1, $2, i.id, $4, $5, $6, $7, $8
 FROM items i
 WHERE i.id = $3
   AND i.type IN ('medium', 'high')
@@ -25,7 +25,7 @@ WHERE i.id = $3
 RETURNING id, user_id, group_id, item_id, quantity,
     borrowed_at, due_date, returned_at,
     before_condition, before_condition_url,
-    after_condition, after_condition_url
+    after_condition, after_condition_url, unit_id
 `
 
 type BorrowItemParams struct {
@@ -36,6 +36,7 @@ type BorrowItemParams struct {
 	DueDate            pgtype.Timestamp `json:"due_date"`
 	BeforeCondition    Condition        `json:"before_condition"`
 	BeforeConditionUrl string           `json:"before_condition_url"`
+	UnitID             *uuid.UUID       `json:"unit_id"`
 }
 
 // this function creates a new borrowing record for a user borrowing an item
@@ -48,6 +49,7 @@ func (q *Queries) BorrowItem(ctx context.Context, arg BorrowItemParams) (Borrowi
 		arg.DueDate,
 		arg.BeforeCondition,
 		arg.BeforeConditionUrl,
+		arg.UnitID,
 	)
 	var i Borrowing
 	err := row.Scan(
@@ -63,6 +65,7 @@ func (q *Queries) BorrowItem(ctx context.Context, arg BorrowItemParams) (Borrowi
 		&i.BeforeConditionUrl,
 		&i.AfterCondition,
 		&i.AfterConditionUrl,
+		&i.UnitID,
 	)
 	return i, err
 }
@@ -94,11 +97,21 @@ func (q *Queries) CountActiveBorrowedItemsByUserId(ctx context.Context, userID *
 }
 
 const countAllActiveBorrowedItems = `-- name: CountAllActiveBorrowedItems :one
-SELECT COUNT(*) as count FROM borrowings WHERE returned_at IS NULL
+SELECT COUNT(*) as count FROM borrowings
+WHERE returned_at IS NULL
+  AND ($1::UUID IS NULL OR group_id = $1)
+  AND ($2::DATE IS NULL OR due_date::DATE >= $2)
+  AND ($3::DATE IS NULL OR due_date::DATE <= $3)
 `
 
-func (q *Queries) CountAllActiveBorrowedItems(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, countAllActiveBorrowedItems)
+type CountAllActiveBorrowedItemsParams struct {
+	GroupID  *uuid.UUID  `json:"group_id"`
+	FromDate pgtype.Date `json:"from_date"`
+	ToDate   pgtype.Date `json:"to_date"`
+}
+
+func (q *Queries) CountAllActiveBorrowedItems(ctx context.Context, arg CountAllActiveBorrowedItemsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countAllActiveBorrowedItems, arg.GroupID, arg.FromDate, arg.ToDate)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -137,11 +150,48 @@ func (q *Queries) CountReturnedItemsByUserId(ctx context.Context, userID *uuid.U
 	return count, err
 }
 
+const extendBorrowingDueDate = `-- name: ExtendBorrowingDueDate :one
+UPDATE borrowings
+SET due_date = $2
+WHERE id = $1 AND returned_at IS NULL
+RETURNING id, user_id, group_id, item_id, quantity,
+    borrowed_at, due_date, returned_at,
+    before_condition, before_condition_url,
+    after_condition, after_condition_url, unit_id
+`
+
+type ExtendBorrowingDueDateParams struct {
+	ID      uuid.UUID        `json:"id"`
+	DueDate pgtype.Timestamp `json:"due_date"`
+}
+
+// moves an unreturned borrowing's due date, for granted extensions
+func (q *Queries) ExtendBorrowingDueDate(ctx context.Context, arg ExtendBorrowingDueDateParams) (Borrowing, error) {
+	row := q.db.QueryRow(ctx, extendBorrowingDueDate, arg.ID, arg.DueDate)
+	var i Borrowing
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.GroupID,
+		&i.ItemID,
+		&i.Quantity,
+		&i.BorrowedAt,
+		&i.DueDate,
+		&i.ReturnedAt,
+		&i.BeforeCondition,
+		&i.BeforeConditionUrl,
+		&i.AfterCondition,
+		&i.AfterConditionUrl,
+		&i.UnitID,
+	)
+	return i, err
+}
+
 const getActiveBorrowedItemsByUserId = `-- name: GetActiveBorrowedItemsByUserId :many
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE user_id = $1 AND returned_at IS NULL
 ORDER BY borrowed_at DESC LIMIT $2 OFFSET $3
@@ -175,6 +225,7 @@ func (q *Queries) GetActiveBorrowedItemsByUserId(ctx context.Context, arg GetAct
 			&i.BeforeConditionUrl,
 			&i.AfterCondition,
 			&i.AfterConditionUrl,
+			&i.UnitID,
 		); err != nil {
 			return nil, err
 		}
@@ -190,7 +241,7 @@ const getActiveBorrowedItemsToBeReturnedByDate = `-- name: GetActiveBorrowedItem
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE returned_at IS NULL AND due_date <= $1
 `
@@ -217,6 +268,7 @@ func (q *Queries) GetActiveBorrowedItemsToBeReturnedByDate(ctx context.Context,
 			&i.BeforeConditionUrl,
 			&i.AfterCondition,
 			&i.AfterConditionUrl,
+			&i.UnitID,
 		); err != nil {
 			return nil, err
 		}
@@ -232,7 +284,7 @@ const getActiveBorrowingByItemAndUser = `-- name: GetActiveBorrowingByItemAndUse
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE item_id = $1 AND user_id = $2 AND returned_at IS NULL
 FOR UPDATE
@@ -260,6 +312,7 @@ func (q *Queries) GetActiveBorrowingByItemAndUser(ctx context.Context, arg GetAc
 		&i.BeforeConditionUrl,
 		&i.AfterCondition,
 		&i.AfterConditionUrl,
+		&i.UnitID,
 	)
 	return i, err
 }
@@ -268,19 +321,40 @@ const getAllActiveBorrowedItems = `-- name: GetAllActiveBorrowedItems :many
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE returned_at IS NULL
-ORDER BY borrowed_at DESC LIMIT $1 OFFSET $2
+  AND ($1::UUID IS NULL OR group_id = $1)
+  AND ($2::DATE IS NULL OR due_date::DATE >= $2)
+  AND ($3::DATE IS NULL OR due_date::DATE <= $3)
+ORDER BY
+  CASE WHEN $4::TEXT = 'due_date' AND NOT $5::BOOLEAN THEN due_date END ASC,
+  CASE WHEN $4::TEXT = 'due_date' AND $5::BOOLEAN THEN due_date END DESC,
+  CASE WHEN $4::TEXT = 'borrowed_at' AND NOT $5::BOOLEAN THEN borrowed_at END ASC,
+  borrowed_at DESC, id
+LIMIT $6 OFFSET $7
 `
 
 type GetAllActiveBorrowedItemsParams struct {
-	Limit  int64 `json:"limit"`
-	Offset int64 `json:"offset"`
+	GroupID  *uuid.UUID  `json:"group_id"`
+	FromDate pgtype.Date `json:"from_date"`
+	ToDate   pgtype.Date `json:"to_date"`
+	SortBy   string      `json:"sort_by"`
+	SortDesc bool        `json:"sort_desc"`
+	Limit    int64       `json:"limit"`
+	Offset   int64       `json:"offset"`
 }
 
 func (q *Queries) GetAllActiveBorrowedItems(ctx context.Context, arg GetAllActiveBorrowedItemsParams) ([]Borrowing, error) {
-	rows, err := q.db.Query(ctx, getAllActiveBorrowedItems, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getAllActiveBorrowedItems,
+		arg.GroupID,
+		arg.FromDate,
+		arg.ToDate,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -301,6 +375,7 @@ func (q *Queries) GetAllActiveBorrowedItems(ctx context.Context, arg GetAllActiv
 			&i.BeforeConditionUrl,
 			&i.AfterCondition,
 			&i.AfterConditionUrl,
+			&i.UnitID,
 		); err != nil {
 			return nil, err
 		}
@@ -316,7 +391,7 @@ const getAllReturnedItems = `-- name: GetAllReturnedItems :many
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE returned_at IS NOT NULL
 ORDER BY returned_at DESC LIMIT $1 OFFSET $2
@@ -349,6 +424,7 @@ func (q *Queries) GetAllReturnedItems(ctx context.Context, arg GetAllReturnedIte
 			&i.BeforeConditionUrl,
 			&i.AfterCondition,
 			&i.AfterConditionUrl,
+			&i.UnitID,
 		); err != nil {
 			return nil, err
 		}
@@ -364,7 +440,7 @@ const getBorrowedItemHistoryByUserId = `-- name: GetBorrowedItemHistoryByUserId
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE user_id = $1
 ORDER BY borrowed_at DESC LIMIT $2 OFFSET $3
@@ -398,6 +474,7 @@ func (q *Queries) GetBorrowedItemHistoryByUserId(ctx context.Context, arg GetBor
 			&i.BeforeConditionUrl,
 			&i.AfterCondition,
 			&i.AfterConditionUrl,
+			&i.UnitID,
 		); err != nil {
 			return nil, err
 		}
@@ -413,7 +490,7 @@ const getBorrowingByID = `-- name: GetBorrowingByID :one
 SELECT id, user_id, group_id, item_id, quantity,
     borrowed_at, due_date, returned_at,
     before_condition, before_condition_url,
-    after_condition, after_condition_url
+    after_condition, after_condition_url, unit_id
 FROM borrowings WHERE id = $1
 `
 
@@ -433,6 +510,7 @@ func (q *Queries) GetBorrowingByID(ctx context.Context, id uuid.UUID) (Borrowing
 		&i.BeforeConditionUrl,
 		&i.AfterCondition,
 		&i.AfterConditionUrl,
+		&i.UnitID,
 	)
 	return i, err
 }
@@ -441,7 +519,7 @@ const getReturnedItemsByUserId = `-- name: GetReturnedItemsByUserId :many
 SELECT id, user_id, group_id, item_id, quantity,
        borrowed_at, due_date, returned_at,
        before_condition, before_condition_url,
-       after_condition, after_condition_url
+       after_condition, after_condition_url, unit_id
 FROM borrowings
 WHERE user_id = $1 AND returned_at IS NOT NULL
 ORDER BY returned_at DESC LIMIT $2 OFFSET $3
@@ -475,6 +553,7 @@ func (q *Queries) GetReturnedItemsByUserId(ctx context.Context, arg GetReturnedI
 			&i.BeforeConditionUrl,
 			&i.AfterCondition,
 			&i.AfterConditionUrl,
+			&i.UnitID,
 		); err != nil {
 			return nil, err
 		}
@@ -486,6 +565,46 @@ func (q *Queries) GetReturnedItemsByUserId(ctx context.Context, arg GetReturnedI
 	return items, nil
 }
 
+const returnBorrowingByID = `-- name: ReturnBorrowingByID :one
+UPDATE borrowings
+SET returned_at = NOW(),
+    after_condition = $2,
+    after_condition_url = $3
+WHERE id = $1 AND returned_at IS NULL
+RETURNING id, user_id, group_id, item_id, quantity,
+    borrowed_at, due_date, returned_at,
+    before_condition, before_condition_url,
+    after_condition, after_condition_url, unit_id
+`
+
+type ReturnBorrowingByIDParams struct {
+	ID                uuid.UUID     `json:"id"`
+	AfterCondition    NullCondition `json:"after_condition"`
+	AfterConditionUrl pgtype.Text   `json:"after_condition_url"`
+}
+
+// closes one specific borrowing, for returns checked in against a booking
+func (q *Queries) ReturnBorrowingByID(ctx context.Context, arg ReturnBorrowingByIDParams) (Borrowing, error) {
+	row := q.db.QueryRow(ctx, returnBorrowingByID, arg.ID, arg.AfterCondition, arg.AfterConditionUrl)
+	var i Borrowing
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.GroupID,
+		&i.ItemID,
+		&i.Quantity,
+		&i.BorrowedAt,
+		&i.DueDate,
+		&i.ReturnedAt,
+		&i.BeforeCondition,
+		&i.BeforeConditionUrl,
+		&i.AfterCondition,
+		&i.AfterConditionUrl,
+		&i.UnitID,
+	)
+	return i, err
+}
+
 const returnItem = `-- name: ReturnItem :one
 UPDATE borrowings
 SET returned_at = NOW(),
@@ -495,7 +614,7 @@ WHERE item_id = $1 AND returned_at IS NULL
 RETURNING id, user_id, group_id, item_id, quantity,
     borrowed_at, due_date, returned_at,
     before_condition, before_condition_url,
-    after_condition, after_condition_url
+    after_condition, after_condition_url, unit_id
 `
 
 type ReturnItemParams struct {
@@ -523,6 +642,64 @@ func (q *Queries) ReturnItem(ctx context.Context, arg ReturnItemParams) (Borrowi
 		&i.BeforeConditionUrl,
 		&i.AfterCondition,
 		&i.AfterConditionUrl,
+		&i.UnitID,
+	)
+	return i, err
+}
+
+const seedBorrowing = `-- name: SeedBorrowing :one
+INSERT INTO borrowings (
+    user_id, group_id, item_id, quantity, borrowed_at, due_date, returned_at,
+    before_condition, before_condition_url, after_condition, after_condition_url
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id, user_id, group_id, item_id, quantity, borrowed_at, due_date, returned_at, before_condition, before_condition_url, after_condition, after_condition_url, unit_id
+`
+
+type SeedBorrowingParams struct {
+	UserID             *uuid.UUID       `json:"user_id"`
+	GroupID            *uuid.UUID       `json:"group_id"`
+	ItemID             *uuid.UUID       `json:"item_id"`
+	Quantity           int32            `json:"quantity"`
+	BorrowedAt         pgtype.Timestamp `json:"borrowed_at"`
+	DueDate            pgtype.Timestamp `json:"due_date"`
+	ReturnedAt         pgtype.Timestamp `json:"returned_at"`
+	BeforeCondition    Condition        `json:"before_condition"`
+	BeforeConditionUrl string           `json:"before_condition_url"`
+	AfterCondition     NullCondition    `json:"after_condition"`
+	AfterConditionUrl  pgtype.Text      `json:"after_condition_url"`
+}
+
+// writes a borrowing with its whole history in one go; only the seeder uses it
+func (q *Queries) SeedBorrowing(ctx context.Context, arg SeedBorrowingParams) (Borrowing, error) {
+	row := q.db.QueryRow(ctx, seedBorrowing,
+		arg.UserID,
+		arg.GroupID,
+		arg.ItemID,
+		arg.Quantity,
+		arg.BorrowedAt,
+		arg.DueDate,
+		arg.ReturnedAt,
+		arg.BeforeCondition,
+		arg.BeforeConditionUrl,
+		arg.AfterCondition,
+		arg.AfterConditionUrl,
+	)
+	var i Borrowing
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.GroupID,
+		&i.ItemID,
+		&i.Quantity,
+		&i.BorrowedAt,
+		&i.DueDate,
+		&i.ReturnedAt,
+		&i.BeforeCondition,
+		&i.BeforeConditionUrl,
+		&i.AfterCondition,
+		&i.AfterConditionUrl,
+		&i.UnitID,
 	)
 	return i, err
 }
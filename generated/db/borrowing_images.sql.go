@@ -9,12 +9,13 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createBorrowingImage = `-- name: CreateBorrowingImage :one
 INSERT INTO borrowing_images (id, borrowing_id, s3_key, image_type, uploaded_by)
 VALUES ($1, $2, $3, $4, $5)
-RETURNING id, borrowing_id, s3_key, image_type, uploaded_by, created_at
+RETURNING id, borrowing_id, s3_key, image_type, uploaded_by, created_at, web_s3_key, thumbnail_s3_key, processed_at
 `
 
 type CreateBorrowingImageParams struct {
@@ -41,6 +42,9 @@ func (q *Queries) CreateBorrowingImage(ctx context.Context, arg CreateBorrowingI
 		&i.ImageType,
 		&i.UploadedBy,
 		&i.CreatedAt,
+		&i.WebS3Key,
+		&i.ThumbnailS3Key,
+		&i.ProcessedAt,
 	)
 	return i, err
 }
@@ -55,7 +59,7 @@ func (q *Queries) DeleteBorrowingImage(ctx context.Context, id uuid.UUID) error
 }
 
 const getBorrowingImageByID = `-- name: GetBorrowingImageByID :one
-SELECT id, borrowing_id, s3_key, image_type, uploaded_by, created_at FROM borrowing_images WHERE id = $1
+SELECT id, borrowing_id, s3_key, image_type, uploaded_by, created_at, web_s3_key, thumbnail_s3_key, processed_at FROM borrowing_images WHERE id = $1
 `
 
 func (q *Queries) GetBorrowingImageByID(ctx context.Context, id uuid.UUID) (BorrowingImage, error) {
@@ -68,12 +72,15 @@ func (q *Queries) GetBorrowingImageByID(ctx context.Context, id uuid.UUID) (Borr
 		&i.ImageType,
 		&i.UploadedBy,
 		&i.CreatedAt,
+		&i.WebS3Key,
+		&i.ThumbnailS3Key,
+		&i.ProcessedAt,
 	)
 	return i, err
 }
 
 const listBorrowingImagesByBorrowing = `-- name: ListBorrowingImagesByBorrowing :many
-SELECT id, borrowing_id, s3_key, image_type, uploaded_by, created_at FROM borrowing_images WHERE borrowing_id = $1 ORDER BY image_type ASC, created_at ASC
+SELECT id, borrowing_id, s3_key, image_type, uploaded_by, created_at, web_s3_key, thumbnail_s3_key, processed_at FROM borrowing_images WHERE borrowing_id = $1 ORDER BY image_type ASC, created_at ASC
 `
 
 func (q *Queries) ListBorrowingImagesByBorrowing(ctx context.Context, borrowingID uuid.UUID) ([]BorrowingImage, error) {
@@ -92,6 +99,9 @@ func (q *Queries) ListBorrowingImagesByBorrowing(ctx context.Context, borrowingI
 			&i.ImageType,
 			&i.UploadedBy,
 			&i.CreatedAt,
+			&i.WebS3Key,
+			&i.ThumbnailS3Key,
+			&i.ProcessedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -102,3 +112,20 @@ func (q *Queries) ListBorrowingImagesByBorrowing(ctx context.Context, borrowingI
 	}
 	return items, nil
 }
+
+const setBorrowingImageVariants = `-- name: SetBorrowingImageVariants :exec
+UPDATE borrowing_images
+SET web_s3_key = $2, thumbnail_s3_key = $3, processed_at = NOW()
+WHERE id = $1
+`
+
+type SetBorrowingImageVariantsParams struct {
+	ID             uuid.UUID   `json:"id"`
+	WebS3Key       pgtype.Text `json:"web_s3_key"`
+	ThumbnailS3Key pgtype.Text `json:"thumbnail_s3_key"`
+}
+
+func (q *Queries) SetBorrowingImageVariants(ctx context.Context, arg SetBorrowingImageVariantsParams) error {
+	_, err := q.db.Exec(ctx, setBorrowingImageVariants, arg.ID, arg.WebS3Key, arg.ThumbnailS3Key)
+	return err
+}
@@ -13,17 +13,18 @@ import (
 )
 
 const createGroup = `-- name: CreateGroup :one
-INSERT INTO groups (name, description) VALUES ($1, $2)
-RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key
+INSERT INTO groups (name, description, sandbox) VALUES ($1, $2, $3)
+RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox
 `
 
 type CreateGroupParams struct {
 	Name        string      `json:"name"`
 	Description pgtype.Text `json:"description"`
+	Sandbox     bool        `json:"sandbox"`
 }
 
 func (q *Queries) CreateGroup(ctx context.Context, arg CreateGroupParams) (Group, error) {
-	row := q.db.QueryRow(ctx, createGroup, arg.Name, arg.Description)
+	row := q.db.QueryRow(ctx, createGroup, arg.Name, arg.Description, arg.Sandbox)
 	var i Group
 	err := row.Scan(
 		&i.ID,
@@ -31,6 +32,7 @@ func (q *Queries) CreateGroup(ctx context.Context, arg CreateGroupParams) (Group
 		&i.Description,
 		&i.LogoS3Key,
 		&i.LogoThumbnailS3Key,
+		&i.Sandbox,
 	)
 	return i, err
 }
@@ -45,7 +47,9 @@ func (q *Queries) DeleteGroup(ctx context.Context, id uuid.UUID) error {
 }
 
 const getAllGroups = `-- name: GetAllGroups :many
-SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key FROM groups ORDER BY name
+SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox FROM groups
+WHERE NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'group' AND d.entity_id = groups.id AND d.status = 'binned')
+ORDER BY name
 `
 
 func (q *Queries) GetAllGroups(ctx context.Context) ([]Group, error) {
@@ -63,6 +67,7 @@ func (q *Queries) GetAllGroups(ctx context.Context) ([]Group, error) {
 			&i.Description,
 			&i.LogoS3Key,
 			&i.LogoThumbnailS3Key,
+			&i.Sandbox,
 		); err != nil {
 			return nil, err
 		}
@@ -75,7 +80,8 @@ func (q *Queries) GetAllGroups(ctx context.Context) ([]Group, error) {
 }
 
 const getGroupByID = `-- name: GetGroupByID :one
-SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key FROM groups WHERE id = $1
+SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox FROM groups
+WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'group' AND d.entity_id = groups.id AND d.status = 'binned')
 `
 
 func (q *Queries) GetGroupByID(ctx context.Context, id uuid.UUID) (Group, error) {
@@ -87,12 +93,32 @@ func (q *Queries) GetGroupByID(ctx context.Context, id uuid.UUID) (Group, error)
 		&i.Description,
 		&i.LogoS3Key,
 		&i.LogoThumbnailS3Key,
+		&i.Sandbox,
+	)
+	return i, err
+}
+
+const getGroupByIDIncludingBinned = `-- name: GetGroupByIDIncludingBinned :one
+SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox FROM groups WHERE id = $1
+`
+
+// also finds groups in the recycle bin; only for admin, trash and purge paths
+func (q *Queries) GetGroupByIDIncludingBinned(ctx context.Context, id uuid.UUID) (Group, error) {
+	row := q.db.QueryRow(ctx, getGroupByIDIncludingBinned, id)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.LogoS3Key,
+		&i.LogoThumbnailS3Key,
+		&i.Sandbox,
 	)
 	return i, err
 }
 
 const getGroupByName = `-- name: GetGroupByName :one
-SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key
+SELECT id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox
 FROM groups WHERE name = $1
 `
 
@@ -105,13 +131,49 @@ func (q *Queries) GetGroupByName(ctx context.Context, name string) (Group, error
 		&i.Description,
 		&i.LogoS3Key,
 		&i.LogoThumbnailS3Key,
+		&i.Sandbox,
+	)
+	return i, err
+}
+
+const isGroupSandbox = `-- name: IsGroupSandbox :one
+SELECT sandbox FROM groups WHERE id = $1
+`
+
+func (q *Queries) IsGroupSandbox(ctx context.Context, id uuid.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, isGroupSandbox, id)
+	var sandbox bool
+	err := row.Scan(&sandbox)
+	return sandbox, err
+}
+
+const setGroupSandbox = `-- name: SetGroupSandbox :one
+UPDATE groups SET sandbox = $2 WHERE id = $1
+RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox
+`
+
+type SetGroupSandboxParams struct {
+	ID      uuid.UUID `json:"id"`
+	Sandbox bool      `json:"sandbox"`
+}
+
+func (q *Queries) SetGroupSandbox(ctx context.Context, arg SetGroupSandboxParams) (Group, error) {
+	row := q.db.QueryRow(ctx, setGroupSandbox, arg.ID, arg.Sandbox)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.LogoS3Key,
+		&i.LogoThumbnailS3Key,
+		&i.Sandbox,
 	)
 	return i, err
 }
 
 const updateGroup = `-- name: UpdateGroup :one
 UPDATE groups SET name = $2, description = $3 WHERE id = $1
-RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key
+RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox
 `
 
 type UpdateGroupParams struct {
@@ -129,13 +191,14 @@ func (q *Queries) UpdateGroup(ctx context.Context, arg UpdateGroupParams) (Group
 		&i.Description,
 		&i.LogoS3Key,
 		&i.LogoThumbnailS3Key,
+		&i.Sandbox,
 	)
 	return i, err
 }
 
 const updateGroupLogo = `-- name: UpdateGroupLogo :one
 UPDATE groups SET logo_s3_key = $2, logo_thumbnail_s3_key = $3 WHERE id = $1
-RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key
+RETURNING id, name, description, logo_s3_key, logo_thumbnail_s3_key, sandbox
 `
 
 type UpdateGroupLogoParams struct {
@@ -153,6 +216,7 @@ func (q *Queries) UpdateGroupLogo(ctx context.Context, arg UpdateGroupLogoParams
 		&i.Description,
 		&i.LogoS3Key,
 		&i.LogoThumbnailS3Key,
+		&i.Sandbox,
 	)
 	return i, err
 }
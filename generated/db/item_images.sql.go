@@ -88,6 +88,28 @@ func (q *Queries) GetItemImageByID(ctx context.Context, id uuid.UUID) (ItemImage
 	return i, err
 }
 
+const getItemImageByOriginalKey = `-- name: GetItemImageByOriginalKey :one
+SELECT id, item_id, original_s3_key, thumbnail_s3_key, display_order, is_primary, width, height, uploaded_by, created_at FROM item_images WHERE original_s3_key = $1
+`
+
+func (q *Queries) GetItemImageByOriginalKey(ctx context.Context, originalS3Key string) (ItemImage, error) {
+	row := q.db.QueryRow(ctx, getItemImageByOriginalKey, originalS3Key)
+	var i ItemImage
+	err := row.Scan(
+		&i.ID,
+		&i.ItemID,
+		&i.OriginalS3Key,
+		&i.ThumbnailS3Key,
+		&i.DisplayOrder,
+		&i.IsPrimary,
+		&i.Width,
+		&i.Height,
+		&i.UploadedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const listItemImagesByItem = `-- name: ListItemImagesByItem :many
 SELECT id, item_id, original_s3_key, thumbnail_s3_key, display_order, is_primary, width, height, uploaded_by, created_at FROM item_images WHERE item_id = $1 ORDER BY display_order ASC, created_at ASC
 `
@@ -123,6 +145,42 @@ func (q *Queries) ListItemImagesByItem(ctx context.Context, itemID uuid.UUID) ([
 	return items, nil
 }
 
+const listPrimaryItemImagesForItems = `-- name: ListPrimaryItemImagesForItems :many
+SELECT id, item_id, original_s3_key, thumbnail_s3_key, display_order, is_primary, width, height, uploaded_by, created_at FROM item_images
+WHERE is_primary AND item_id = ANY($1::uuid[])
+`
+
+func (q *Queries) ListPrimaryItemImagesForItems(ctx context.Context, itemIds []uuid.UUID) ([]ItemImage, error) {
+	rows, err := q.db.Query(ctx, listPrimaryItemImagesForItems, itemIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ItemImage{}
+	for rows.Next() {
+		var i ItemImage
+		if err := rows.Scan(
+			&i.ID,
+			&i.ItemID,
+			&i.OriginalS3Key,
+			&i.ThumbnailS3Key,
+			&i.DisplayOrder,
+			&i.IsPrimary,
+			&i.Width,
+			&i.Height,
+			&i.UploadedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const setItemImageAsPrimary = `-- name: SetItemImageAsPrimary :exec
 UPDATE item_images SET is_primary = TRUE WHERE id = $1
 `
@@ -12,23 +12,104 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addItemShares = `-- name: AddItemShares :exec
+INSERT INTO item_group_shares (item_id, group_id)
+SELECT $1, unnest($2::UUID[])
+ON CONFLICT DO NOTHING
+`
+
+type AddItemSharesParams struct {
+	ItemID   uuid.UUID   `json:"item_id"`
+	GroupIds []uuid.UUID `json:"group_ids"`
+}
+
+func (q *Queries) AddItemShares(ctx context.Context, arg AddItemSharesParams) error {
+	_, err := q.db.Exec(ctx, addItemShares, arg.ItemID, arg.GroupIds)
+	return err
+}
+
+const archiveItem = `-- name: ArchiveItem :exec
+UPDATE items SET archived_at = NOW() WHERE id = $1 AND archived_at IS NULL
+`
+
+func (q *Queries) ArchiveItem(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, archiveItem, id)
+	return err
+}
+
+const clearItemShares = `-- name: ClearItemShares :exec
+DELETE FROM item_group_shares WHERE item_id = $1
+`
+
+func (q *Queries) ClearItemShares(ctx context.Context, itemID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, clearItemShares, itemID)
+	return err
+}
+
 const countAllItems = `-- name: CountAllItems :one
 SELECT COUNT(*) as count FROM items
+WHERE archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  -- owned items only for members of the owner or a group it is shared with
+  AND ($1::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = $1 AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
+`
+
+func (q *Queries) CountAllItems(ctx context.Context, viewerID *uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countAllItems, viewerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countFullTextSearchItems = `-- name: CountFullTextSearchItems :one
+SELECT COUNT(*) as count
+FROM items
+WHERE (to_tsvector('english', name || ' ' || COALESCE(description, '')) @@ plainto_tsquery('english', $1::TEXT)
+    OR $1::TEXT <% name)
+  AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  AND ($2::BOOLEAN OR archived_at IS NULL)
+  -- owned items only for members of the owner or a group it is shared with
+  AND ($3::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = $3 AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
 `
 
-func (q *Queries) CountAllItems(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, countAllItems)
+type CountFullTextSearchItemsParams struct {
+	Query           string     `json:"query"`
+	IncludeArchived bool       `json:"include_archived"`
+	ViewerID        *uuid.UUID `json:"viewer_id"`
+}
+
+func (q *Queries) CountFullTextSearchItems(ctx context.Context, arg CountFullTextSearchItemsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countFullTextSearchItems, arg.Query, arg.IncludeArchived, arg.ViewerID)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
 const countItemsByType = `-- name: CountItemsByType :one
-SELECT COUNT(*) as count FROM items WHERE type = $1
+SELECT COUNT(*) as count FROM items
+WHERE type = $1 AND archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  -- owned items only for members of the owner or a group it is shared with
+  AND ($2::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = $2 AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
 `
 
-func (q *Queries) CountItemsByType(ctx context.Context, type_ ItemType) (int64, error) {
-	row := q.db.QueryRow(ctx, countItemsByType, type_)
+type CountItemsByTypeParams struct {
+	Type     ItemType   `json:"type"`
+	ViewerID *uuid.UUID `json:"viewer_id"`
+}
+
+func (q *Queries) CountItemsByType(ctx context.Context, arg CountItemsByTypeParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countItemsByType, arg.Type, arg.ViewerID)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -41,16 +122,42 @@ WHERE ($1::TEXT IS NULL OR
   to_tsvector('english', name || ' ' || COALESCE(description, '')) @@ plainto_tsquery('english', $1))
   AND ($2::item_type IS NULL OR type = $2)
   AND ($3::BOOLEAN IS NULL OR (stock > 0) = $3)
+  AND ($4::TEXT IS NULL OR EXISTS (
+    SELECT 1 FROM item_categories ic JOIN categories c ON c.id = ic.category_id
+    WHERE ic.item_id = items.id AND c.slug = $4))
+  AND ($5::TEXT[] IS NULL OR (
+    SELECT COUNT(*) FROM item_tags it JOIN tags t ON t.id = it.tag_id
+    WHERE it.item_id = items.id AND t.name = ANY($5::TEXT[])) = cardinality($5::TEXT[]))
+  AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  AND ($6::BOOLEAN OR archived_at IS NULL)
+  -- owned items only for members of the owner or a group it is shared with
+  AND ($7::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = $7 AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
 `
 
 type CountSearchItemsParams struct {
-	Query    pgtype.Text  `json:"query"`
-	ItemType NullItemType `json:"item_type"`
-	InStock  pgtype.Bool  `json:"in_stock"`
+	Query           pgtype.Text  `json:"query"`
+	ItemType        NullItemType `json:"item_type"`
+	InStock         pgtype.Bool  `json:"in_stock"`
+	Category        pgtype.Text  `json:"category"`
+	Tags            []string     `json:"tags"`
+	IncludeArchived bool         `json:"include_archived"`
+	ViewerID        *uuid.UUID   `json:"viewer_id"`
 }
 
 func (q *Queries) CountSearchItems(ctx context.Context, arg CountSearchItemsParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countSearchItems, arg.Query, arg.ItemType, arg.InStock)
+	row := q.db.QueryRow(ctx, countSearchItems,
+		arg.Query,
+		arg.ItemType,
+		arg.InStock,
+		arg.Category,
+		arg.Tags,
+		arg.IncludeArchived,
+		arg.ViewerID,
+	)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -59,7 +166,7 @@ func (q *Queries) CountSearchItems(ctx context.Context, arg CountSearchItemsPara
 const createItem = `-- name: CreateItem :one
 INSERT INTO items (name, description, type, stock, urls)
 VALUES ($1, $2, $3, $4, $5)
-RETURNING id, name, description, type, stock, urls
+RETURNING id, name, description, type, stock, urls, archived_at, owner_group_id, version
 `
 
 type CreateItemParams struct {
@@ -86,10 +193,21 @@ func (q *Queries) CreateItem(ctx context.Context, arg CreateItemParams) (Item, e
 		&i.Type,
 		&i.Stock,
 		&i.Urls,
+		&i.ArchivedAt,
+		&i.OwnerGroupID,
+		&i.Version,
 	)
 	return i, err
 }
 
+type CreateItemsParams struct {
+	Name        string      `json:"name"`
+	Description pgtype.Text `json:"description"`
+	Type        ItemType    `json:"type"`
+	Stock       int32       `json:"stock"`
+	Urls        []string    `json:"urls"`
+}
+
 const decrementItemStock = `-- name: DecrementItemStock :exec
 UPDATE items
 SET stock = stock - $2
@@ -115,17 +233,108 @@ func (q *Queries) DeleteItem(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const fullTextSearchItems = `-- name: FullTextSearchItems :many
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id,
+  GREATEST(
+    ts_rank(to_tsvector('english', name || ' ' || COALESCE(description, '')), plainto_tsquery('english', $1::TEXT)),
+    word_similarity($1::TEXT, name)
+  )::REAL AS rank
+FROM items
+WHERE (to_tsvector('english', name || ' ' || COALESCE(description, '')) @@ plainto_tsquery('english', $1::TEXT)
+    OR $1::TEXT <% name)
+  AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  AND ($2::BOOLEAN OR archived_at IS NULL)
+  -- owned items only for members of the owner or a group it is shared with
+  AND ($3::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = $3 AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
+ORDER BY to_tsvector('english', name || ' ' || COALESCE(description, '')) @@ plainto_tsquery('english', $1::TEXT) DESC,
+  ts_rank(to_tsvector('english', name || ' ' || COALESCE(description, '')), plainto_tsquery('english', $1::TEXT)) DESC,
+  word_similarity($1::TEXT, name) DESC,
+  name ASC
+LIMIT $4 OFFSET $5
+`
+
+type FullTextSearchItemsParams struct {
+	Query           string     `json:"query"`
+	IncludeArchived bool       `json:"include_archived"`
+	ViewerID        *uuid.UUID `json:"viewer_id"`
+	Limit           int64      `json:"limit"`
+	Offset          int64      `json:"offset"`
+}
+
+type FullTextSearchItemsRow struct {
+	ID           uuid.UUID        `json:"id"`
+	Name         string           `json:"name"`
+	Description  pgtype.Text      `json:"description"`
+	Type         ItemType         `json:"type"`
+	Stock        int32            `json:"stock"`
+	Urls         []string         `json:"urls"`
+	ArchivedAt   pgtype.Timestamp `json:"archived_at"`
+	OwnerGroupID *uuid.UUID       `json:"owner_group_id"`
+	Rank         float32          `json:"rank"`
+}
+
+// Full-text matches rank by ts_rank, near misses on the name by trigram word
+// similarity, so a typo still finds the item just further down the list.
+func (q *Queries) FullTextSearchItems(ctx context.Context, arg FullTextSearchItemsParams) ([]FullTextSearchItemsRow, error) {
+	rows, err := q.db.Query(ctx, fullTextSearchItems,
+		arg.Query,
+		arg.IncludeArchived,
+		arg.ViewerID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FullTextSearchItemsRow{}
+	for rows.Next() {
+		var i FullTextSearchItemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Type,
+			&i.Stock,
+			&i.Urls,
+			&i.ArchivedAt,
+			&i.OwnerGroupID,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAllItems = `-- name: GetAllItems :many
-SELECT id, name, description, type, stock, urls from items ORDER BY name ASC LIMIT $1 OFFSET $2
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version from items
+WHERE archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  -- owned items only for members of the owner or a group it is shared with
+  AND ($1::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = $1 AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
+ORDER BY name ASC LIMIT $2 OFFSET $3
 `
 
 type GetAllItemsParams struct {
-	Limit  int64 `json:"limit"`
-	Offset int64 `json:"offset"`
+	ViewerID *uuid.UUID `json:"viewer_id"`
+	Limit    int64      `json:"limit"`
+	Offset   int64      `json:"offset"`
 }
 
 func (q *Queries) GetAllItems(ctx context.Context, arg GetAllItemsParams) ([]Item, error) {
-	rows, err := q.db.Query(ctx, getAllItems, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getAllItems, arg.ViewerID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -140,6 +349,9 @@ func (q *Queries) GetAllItems(ctx context.Context, arg GetAllItemsParams) ([]Ite
 			&i.Type,
 			&i.Stock,
 			&i.Urls,
+			&i.ArchivedAt,
+			&i.OwnerGroupID,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -152,7 +364,8 @@ func (q *Queries) GetAllItems(ctx context.Context, arg GetAllItemsParams) ([]Ite
 }
 
 const getItemByID = `-- name: GetItemByID :one
-SELECT id, name, description, type, stock, urls FROM items WHERE id = $1
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version FROM items
+WHERE id = $1 AND archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
 `
 
 func (q *Queries) GetItemByID(ctx context.Context, id uuid.UUID) (Item, error) {
@@ -165,12 +378,17 @@ func (q *Queries) GetItemByID(ctx context.Context, id uuid.UUID) (Item, error) {
 		&i.Type,
 		&i.Stock,
 		&i.Urls,
+		&i.ArchivedAt,
+		&i.OwnerGroupID,
+		&i.Version,
 	)
 	return i, err
 }
 
 const getItemByIDForUpdate = `-- name: GetItemByIDForUpdate :one
-SELECT id, name, description, type, stock, urls FROM items WHERE id = $1 FOR UPDATE
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version FROM items
+WHERE id = $1 AND archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+FOR UPDATE
 `
 
 func (q *Queries) GetItemByIDForUpdate(ctx context.Context, id uuid.UUID) (Item, error) {
@@ -183,12 +401,38 @@ func (q *Queries) GetItemByIDForUpdate(ctx context.Context, id uuid.UUID) (Item,
 		&i.Type,
 		&i.Stock,
 		&i.Urls,
+		&i.ArchivedAt,
+		&i.OwnerGroupID,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getItemByIDIncludingBinned = `-- name: GetItemByIDIncludingBinned :one
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version FROM items WHERE id = $1
+`
+
+// also finds items in the recycle bin or archived; only for admin, trash,
+// history and purge paths
+func (q *Queries) GetItemByIDIncludingBinned(ctx context.Context, id uuid.UUID) (Item, error) {
+	row := q.db.QueryRow(ctx, getItemByIDIncludingBinned, id)
+	var i Item
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Type,
+		&i.Stock,
+		&i.Urls,
+		&i.ArchivedAt,
+		&i.OwnerGroupID,
+		&i.Version,
 	)
 	return i, err
 }
 
 const getItemByName = `-- name: GetItemByName :one
-SELECT id, name, description, type, stock, urls
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version
 FROM items WHERE name = $1
 `
 
@@ -202,22 +446,75 @@ func (q *Queries) GetItemByName(ctx context.Context, name string) (Item, error)
 		&i.Type,
 		&i.Stock,
 		&i.Urls,
+		&i.ArchivedAt,
+		&i.OwnerGroupID,
+		&i.Version,
 	)
 	return i, err
 }
 
+const getItemsByNames = `-- name: GetItemsByNames :many
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version
+FROM items WHERE name = ANY($1::TEXT[])
+`
+
+// the items with any of the names, for resolving many names in one round trip
+func (q *Queries) GetItemsByNames(ctx context.Context, names []string) ([]Item, error) {
+	rows, err := q.db.Query(ctx, getItemsByNames, names)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Item{}
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Type,
+			&i.Stock,
+			&i.Urls,
+			&i.ArchivedAt,
+			&i.OwnerGroupID,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getItemsByType = `-- name: GetItemsByType :many
-SELECT id, name, description, type, stock, urls FROM items WHERE type = $1 ORDER BY name ASC LIMIT $2 OFFSET $3
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version FROM items
+WHERE type = $1 AND archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  -- owned items only for members of the owner or a group it is shared with
+  AND ($2::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = $2 AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
+ORDER BY name ASC LIMIT $3 OFFSET $4
 `
 
 type GetItemsByTypeParams struct {
-	Type   ItemType `json:"type"`
-	Limit  int64    `json:"limit"`
-	Offset int64    `json:"offset"`
+	Type     ItemType   `json:"type"`
+	ViewerID *uuid.UUID `json:"viewer_id"`
+	Limit    int64      `json:"limit"`
+	Offset   int64      `json:"offset"`
 }
 
 func (q *Queries) GetItemsByType(ctx context.Context, arg GetItemsByTypeParams) ([]Item, error) {
-	rows, err := q.db.Query(ctx, getItemsByType, arg.Type, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getItemsByType,
+		arg.Type,
+		arg.ViewerID,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -232,6 +529,9 @@ func (q *Queries) GetItemsByType(ctx context.Context, arg GetItemsByTypeParams)
 			&i.Type,
 			&i.Stock,
 			&i.Urls,
+			&i.ArchivedAt,
+			&i.OwnerGroupID,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -259,6 +559,122 @@ func (q *Queries) IncrementItemStock(ctx context.Context, arg IncrementItemStock
 	return err
 }
 
+const isItemSharedWithGroup = `-- name: IsItemSharedWithGroup :one
+SELECT EXISTS(
+  SELECT 1 FROM item_group_shares WHERE item_id = $1 AND group_id = $2
+) AS shared
+`
+
+type IsItemSharedWithGroupParams struct {
+	ItemID  uuid.UUID `json:"item_id"`
+	GroupID uuid.UUID `json:"group_id"`
+}
+
+func (q *Queries) IsItemSharedWithGroup(ctx context.Context, arg IsItemSharedWithGroupParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isItemSharedWithGroup, arg.ItemID, arg.GroupID)
+	var shared bool
+	err := row.Scan(&shared)
+	return shared, err
+}
+
+const isItemVisibleToUser = `-- name: IsItemVisibleToUser :one
+SELECT EXISTS(
+  SELECT 1 FROM items
+  WHERE id = $1 AND (owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = $2 AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
+) AS visible
+`
+
+type IsItemVisibleToUserParams struct {
+	ItemID uuid.UUID `json:"item_id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// whether a user may see an item: unowned items are open to all
+func (q *Queries) IsItemVisibleToUser(ctx context.Context, arg IsItemVisibleToUserParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isItemVisibleToUser, arg.ItemID, arg.UserID)
+	var visible bool
+	err := row.Scan(&visible)
+	return visible, err
+}
+
+const listAllItems = `-- name: ListAllItems :many
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id, version FROM items
+WHERE archived_at IS NULL AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+ORDER BY name ASC, id ASC
+`
+
+// the whole inventory, for exports
+func (q *Queries) ListAllItems(ctx context.Context) ([]Item, error) {
+	rows, err := q.db.Query(ctx, listAllItems)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Item{}
+	for rows.Next() {
+		var i Item
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Type,
+			&i.Stock,
+			&i.Urls,
+			&i.ArchivedAt,
+			&i.OwnerGroupID,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listItemShares = `-- name: ListItemShares :many
+SELECT group_id FROM item_group_shares WHERE item_id = $1 ORDER BY created_at ASC, group_id ASC
+`
+
+func (q *Queries) ListItemShares(ctx context.Context, itemID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, listItemShares, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var group_id uuid.UUID
+		if err := rows.Scan(&group_id); err != nil {
+			return nil, err
+		}
+		items = append(items, group_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockItemVersion = `-- name: LockItemVersion :one
+SELECT version FROM items WHERE id = $1 FOR UPDATE
+`
+
+// the item's version, locking the row until the transaction ends so an
+// If-Match check still holds when the update that follows it runs
+func (q *Queries) LockItemVersion(ctx context.Context, id uuid.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, lockItemVersion, id)
+	var version int32
+	err := row.Scan(&version)
+	return version, err
+}
+
 const patchItem = `-- name: PatchItem :one
 UPDATE items
 SET name = COALESCE($1, name),
@@ -267,7 +683,7 @@ SET name = COALESCE($1, name),
     stock = COALESCE($4, stock),
     urls = COALESCE($5, urls)
 WHERE id = $6
-RETURNING id, name, description, type, stock, urls
+RETURNING id, name, description, type, stock, urls, archived_at, owner_group_id, version
 `
 
 type PatchItemParams struct {
@@ -296,64 +712,123 @@ func (q *Queries) PatchItem(ctx context.Context, arg PatchItemParams) (Item, err
 		&i.Type,
 		&i.Stock,
 		&i.Urls,
+		&i.ArchivedAt,
+		&i.OwnerGroupID,
+		&i.Version,
+	)
+	return i, err
+}
+
+const restoreItem = `-- name: RestoreItem :one
+UPDATE items SET archived_at = NULL
+WHERE id = $1 AND archived_at IS NOT NULL
+RETURNING id, name, description, type, stock, urls, archived_at, owner_group_id, version
+`
+
+// brings an archived item back into the catalogue
+func (q *Queries) RestoreItem(ctx context.Context, id uuid.UUID) (Item, error) {
+	row := q.db.QueryRow(ctx, restoreItem, id)
+	var i Item
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Type,
+		&i.Stock,
+		&i.Urls,
+		&i.ArchivedAt,
+		&i.OwnerGroupID,
+		&i.Version,
 	)
 	return i, err
 }
 
 const searchItems = `-- name: SearchItems :many
-WITH ranked_items AS (
-    -- get rankings (each row turned to rank, from vector/query relationship)
-    SELECT id, name, description, type, stock, urls,
-    CASE
-      WHEN $1::TEXT IS NOT NULL THEN
-        ts_rank(
-          to_tsvector('english', name || ' ' || COALESCE(description, '')),
-          plainto_tsquery('english', $1)
-        )
-        -- 0 if null query
-        ELSE 0.0
-    END as rank
-  FROM items
-  WHERE ($1::TEXT IS NULL OR
+SELECT id, name, description, type, stock, urls, archived_at, owner_group_id,
+  (CASE
+    WHEN $1::TEXT IS NOT NULL THEN
+      ts_rank(
+        to_tsvector('english', name || ' ' || COALESCE(description, '')),
+        plainto_tsquery('english', $1)
+      )
+    -- 0 if null query
+    ELSE 0.0
+  END)::REAL AS rank
+FROM items
+WHERE ($1::TEXT IS NULL OR
   -- if nulls, no ranking shenanigans
-    to_tsvector('english', name || ' ' || COALESCE(description, '')) @@ plainto_tsquery('english', $1))
-    AND ($4::item_type IS NULL OR type = $4)
-    AND ($5::BOOLEAN IS NULL OR (stock > 0) = $5)
-)
-SELECT id, name, description, type, stock, urls, rank
-FROM ranked_items
+  to_tsvector('english', name || ' ' || COALESCE(description, '')) @@ plainto_tsquery('english', $1))
+  AND ($2::item_type IS NULL OR type = $2)
+  AND ($3::BOOLEAN IS NULL OR (stock > 0) = $3)
+  AND ($4::TEXT IS NULL OR EXISTS (
+    SELECT 1 FROM item_categories ic JOIN categories c ON c.id = ic.category_id
+    WHERE ic.item_id = items.id AND c.slug = $4))
+  -- tagged with every requested tag
+  AND ($5::TEXT[] IS NULL OR (
+    SELECT COUNT(*) FROM item_tags it JOIN tags t ON t.id = it.tag_id
+    WHERE it.item_id = items.id AND t.name = ANY($5::TEXT[])) = cardinality($5::TEXT[]))
+  AND NOT EXISTS (SELECT 1 FROM deletion_requests d WHERE d.entity_type = 'item' AND d.entity_id = items.id AND d.status = 'binned')
+  AND ($6::BOOLEAN OR archived_at IS NULL)
+  -- owned items only for members of the owner or a group it is shared with
+  AND ($7::UUID IS NULL OR owner_group_id IS NULL OR EXISTS (
+    SELECT 1 FROM user_roles ur
+    WHERE ur.user_id = $7 AND ur.scope = 'group'
+      AND (ur.scope_id = items.owner_group_id OR ur.scope_id IN (
+        SELECT sh.group_id FROM item_group_shares sh WHERE sh.item_id = items.id))))
 ORDER BY
-  CASE WHEN $1::TEXT IS NOT NULL THEN rank END DESC NULLS LAST,
+  CASE WHEN $8::TEXT = 'stock' AND NOT $9::BOOLEAN THEN stock END ASC,
+  CASE WHEN $8::TEXT = 'stock' AND $9::BOOLEAN THEN stock END DESC,
+  CASE WHEN $8::TEXT = 'name' AND $9::BOOLEAN THEN name END DESC,
+  -- without an explicit sort a query orders by rank, otherwise alphabetical
+  CASE WHEN $8::TEXT = '' AND $1::TEXT IS NOT NULL THEN
+    ts_rank(
+      to_tsvector('english', name || ' ' || COALESCE(description, '')),
+      plainto_tsquery('english', $1)
+    )
+  END DESC NULLS LAST,
   name ASC
-LIMIT $3 OFFSET $2
+LIMIT $10 OFFSET $11
 `
 
 type SearchItemsParams struct {
-	Query    pgtype.Text  `json:"query"`
-	Offset   int64        `json:"offset"`
-	Limit    int64        `json:"limit"`
-	ItemType NullItemType `json:"item_type"`
-	InStock  pgtype.Bool  `json:"in_stock"`
+	Query           pgtype.Text  `json:"query"`
+	ItemType        NullItemType `json:"item_type"`
+	InStock         pgtype.Bool  `json:"in_stock"`
+	Category        pgtype.Text  `json:"category"`
+	Tags            []string     `json:"tags"`
+	IncludeArchived bool         `json:"include_archived"`
+	ViewerID        *uuid.UUID   `json:"viewer_id"`
+	SortBy          string       `json:"sort_by"`
+	SortDesc        bool         `json:"sort_desc"`
+	Limit           int64        `json:"limit"`
+	Offset          int64        `json:"offset"`
 }
 
 type SearchItemsRow struct {
-	ID          uuid.UUID   `json:"id"`
-	Name        string      `json:"name"`
-	Description pgtype.Text `json:"description"`
-	Type        ItemType    `json:"type"`
-	Stock       int32       `json:"stock"`
-	Urls        []string    `json:"urls"`
-	Rank        float32     `json:"rank"`
+	ID           uuid.UUID        `json:"id"`
+	Name         string           `json:"name"`
+	Description  pgtype.Text      `json:"description"`
+	Type         ItemType         `json:"type"`
+	Stock        int32            `json:"stock"`
+	Urls         []string         `json:"urls"`
+	ArchivedAt   pgtype.Timestamp `json:"archived_at"`
+	OwnerGroupID *uuid.UUID       `json:"owner_group_id"`
+	Rank         float32          `json:"rank"`
 }
 
-// if query null then alphabetical, else sort by rank
 func (q *Queries) SearchItems(ctx context.Context, arg SearchItemsParams) ([]SearchItemsRow, error) {
 	rows, err := q.db.Query(ctx, searchItems,
 		arg.Query,
-		arg.Offset,
-		arg.Limit,
 		arg.ItemType,
 		arg.InStock,
+		arg.Category,
+		arg.Tags,
+		arg.IncludeArchived,
+		arg.ViewerID,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.Limit,
+		arg.Offset,
 	)
 	if err != nil {
 		return nil, err
@@ -369,6 +844,8 @@ func (q *Queries) SearchItems(ctx context.Context, arg SearchItemsParams) ([]Sea
 			&i.Type,
 			&i.Stock,
 			&i.Urls,
+			&i.ArchivedAt,
+			&i.OwnerGroupID,
 			&i.Rank,
 		); err != nil {
 			return nil, err
@@ -381,11 +858,25 @@ func (q *Queries) SearchItems(ctx context.Context, arg SearchItemsParams) ([]Sea
 	return items, nil
 }
 
+const setItemOwner = `-- name: SetItemOwner :exec
+UPDATE items SET owner_group_id = $1 WHERE id = $2
+`
+
+type SetItemOwnerParams struct {
+	OwnerGroupID *uuid.UUID `json:"owner_group_id"`
+	ID           uuid.UUID  `json:"id"`
+}
+
+func (q *Queries) SetItemOwner(ctx context.Context, arg SetItemOwnerParams) error {
+	_, err := q.db.Exec(ctx, setItemOwner, arg.OwnerGroupID, arg.ID)
+	return err
+}
+
 const updateItem = `-- name: UpdateItem :one
 UPDATE items
 SET name = $2, description = $3, type = $4, stock = $5, urls = $6
 WHERE id = $1
-RETURNING id, name, description, type, stock, urls
+RETURNING id, name, description, type, stock, urls, archived_at, owner_group_id, version
 `
 
 type UpdateItemParams struct {
@@ -414,6 +905,9 @@ func (q *Queries) UpdateItem(ctx context.Context, arg UpdateItemParams) (Item, e
 		&i.Type,
 		&i.Stock,
 		&i.Urls,
+		&i.ArchivedAt,
+		&i.OwnerGroupID,
+		&i.Version,
 	)
 	return i, err
 }
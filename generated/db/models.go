@@ -12,200 +12,1049 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type CampaignStage string
+
+const (
+	CampaignStageT14       CampaignStage = "t14"
+	CampaignStageT7        CampaignStage = "t7"
+	CampaignStageT1        CampaignStage = "t1"
+	CampaignStageEscalated CampaignStage = "escalated"
+)
+
+func (e *CampaignStage) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = CampaignStage(s)
+	case string:
+		*e = CampaignStage(s)
+	default:
+		return fmt.Errorf("unsupported scan type for CampaignStage: %T", src)
+	}
+	return nil
+}
+
+type NullCampaignStage struct {
+	CampaignStage CampaignStage `json:"campaign_stage"`
+	Valid         bool          `json:"valid"` // Valid is true if CampaignStage is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullCampaignStage) Scan(value interface{}) error {
+	if value == nil {
+		ns.CampaignStage, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.CampaignStage.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullCampaignStage) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.CampaignStage), nil
+}
+
+type CampaignStatus string
+
+const (
+	CampaignStatusActive    CampaignStatus = "active"
+	CampaignStatusCompleted CampaignStatus = "completed"
+	CampaignStatusCancelled CampaignStatus = "cancelled"
+)
+
+func (e *CampaignStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = CampaignStatus(s)
+	case string:
+		*e = CampaignStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for CampaignStatus: %T", src)
+	}
+	return nil
+}
+
+type NullCampaignStatus struct {
+	CampaignStatus CampaignStatus `json:"campaign_status"`
+	Valid          bool           `json:"valid"` // Valid is true if CampaignStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullCampaignStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.CampaignStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.CampaignStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullCampaignStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.CampaignStatus), nil
+}
+
 type Condition string
 
 const (
-	ConditionUnusable Condition = "unusable"
-	ConditionDamaged  Condition = "damaged"
-	ConditionDecent   Condition = "decent"
-	ConditionGood     Condition = "good"
-	ConditionPristine Condition = "pristine"
+	ConditionUnusable Condition = "unusable"
+	ConditionDamaged  Condition = "damaged"
+	ConditionDecent   Condition = "decent"
+	ConditionGood     Condition = "good"
+	ConditionPristine Condition = "pristine"
+)
+
+func (e *Condition) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = Condition(s)
+	case string:
+		*e = Condition(s)
+	default:
+		return fmt.Errorf("unsupported scan type for Condition: %T", src)
+	}
+	return nil
+}
+
+type NullCondition struct {
+	Condition Condition `json:"condition"`
+	Valid     bool      `json:"valid"` // Valid is true if Condition is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullCondition) Scan(value interface{}) error {
+	if value == nil {
+		ns.Condition, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.Condition.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullCondition) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.Condition), nil
+}
+
+type DamageReportStatus string
+
+const (
+	DamageReportStatusOpen      DamageReportStatus = "open"
+	DamageReportStatusResolved  DamageReportStatus = "resolved"
+	DamageReportStatusDismissed DamageReportStatus = "dismissed"
+)
+
+func (e *DamageReportStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = DamageReportStatus(s)
+	case string:
+		*e = DamageReportStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for DamageReportStatus: %T", src)
+	}
+	return nil
+}
+
+type NullDamageReportStatus struct {
+	DamageReportStatus DamageReportStatus `json:"damage_report_status"`
+	Valid              bool               `json:"valid"` // Valid is true if DamageReportStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullDamageReportStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.DamageReportStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.DamageReportStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullDamageReportStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.DamageReportStatus), nil
+}
+
+type DamageSeverity string
+
+const (
+	DamageSeverityMinor    DamageSeverity = "minor"
+	DamageSeverityModerate DamageSeverity = "moderate"
+	DamageSeveritySevere   DamageSeverity = "severe"
+)
+
+func (e *DamageSeverity) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = DamageSeverity(s)
+	case string:
+		*e = DamageSeverity(s)
+	default:
+		return fmt.Errorf("unsupported scan type for DamageSeverity: %T", src)
+	}
+	return nil
+}
+
+type NullDamageSeverity struct {
+	DamageSeverity DamageSeverity `json:"damage_severity"`
+	Valid          bool           `json:"valid"` // Valid is true if DamageSeverity is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullDamageSeverity) Scan(value interface{}) error {
+	if value == nil {
+		ns.DamageSeverity, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.DamageSeverity.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullDamageSeverity) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.DamageSeverity), nil
+}
+
+type DeletionEntity string
+
+const (
+	DeletionEntityGroup DeletionEntity = "group"
+	DeletionEntityItem  DeletionEntity = "item"
+)
+
+func (e *DeletionEntity) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = DeletionEntity(s)
+	case string:
+		*e = DeletionEntity(s)
+	default:
+		return fmt.Errorf("unsupported scan type for DeletionEntity: %T", src)
+	}
+	return nil
+}
+
+type NullDeletionEntity struct {
+	DeletionEntity DeletionEntity `json:"deletion_entity"`
+	Valid          bool           `json:"valid"` // Valid is true if DeletionEntity is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullDeletionEntity) Scan(value interface{}) error {
+	if value == nil {
+		ns.DeletionEntity, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.DeletionEntity.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullDeletionEntity) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.DeletionEntity), nil
+}
+
+type DeletionStatus string
+
+const (
+	DeletionStatusPending  DeletionStatus = "pending"
+	DeletionStatusBinned   DeletionStatus = "binned"
+	DeletionStatusRejected DeletionStatus = "rejected"
+	DeletionStatusRestored DeletionStatus = "restored"
+	DeletionStatusPurged   DeletionStatus = "purged"
+)
+
+func (e *DeletionStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = DeletionStatus(s)
+	case string:
+		*e = DeletionStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for DeletionStatus: %T", src)
+	}
+	return nil
+}
+
+type NullDeletionStatus struct {
+	DeletionStatus DeletionStatus `json:"deletion_status"`
+	Valid          bool           `json:"valid"` // Valid is true if DeletionStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullDeletionStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.DeletionStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.DeletionStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullDeletionStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.DeletionStatus), nil
+}
+
+type ExtensionStatus string
+
+const (
+	ExtensionStatusPending  ExtensionStatus = "pending"
+	ExtensionStatusApproved ExtensionStatus = "approved"
+	ExtensionStatusDenied   ExtensionStatus = "denied"
+)
+
+func (e *ExtensionStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ExtensionStatus(s)
+	case string:
+		*e = ExtensionStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ExtensionStatus: %T", src)
+	}
+	return nil
+}
+
+type NullExtensionStatus struct {
+	ExtensionStatus ExtensionStatus `json:"extension_status"`
+	Valid           bool            `json:"valid"` // Valid is true if ExtensionStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullExtensionStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.ExtensionStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ExtensionStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullExtensionStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ExtensionStatus), nil
+}
+
+type FineReason string
+
+const (
+	FineReasonLate   FineReason = "late"
+	FineReasonDamage FineReason = "damage"
+)
+
+func (e *FineReason) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = FineReason(s)
+	case string:
+		*e = FineReason(s)
+	default:
+		return fmt.Errorf("unsupported scan type for FineReason: %T", src)
+	}
+	return nil
+}
+
+type NullFineReason struct {
+	FineReason FineReason `json:"fine_reason"`
+	Valid      bool       `json:"valid"` // Valid is true if FineReason is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullFineReason) Scan(value interface{}) error {
+	if value == nil {
+		ns.FineReason, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.FineReason.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullFineReason) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.FineReason), nil
+}
+
+type FineStatus string
+
+const (
+	FineStatusUnpaid FineStatus = "unpaid"
+	FineStatusPaid   FineStatus = "paid"
+	FineStatusWaived FineStatus = "waived"
+)
+
+func (e *FineStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = FineStatus(s)
+	case string:
+		*e = FineStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for FineStatus: %T", src)
+	}
+	return nil
+}
+
+type NullFineStatus struct {
+	FineStatus FineStatus `json:"fine_status"`
+	Valid      bool       `json:"valid"` // Valid is true if FineStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullFineStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.FineStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.FineStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullFineStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.FineStatus), nil
+}
+
+type ItemType string
+
+const (
+	ItemTypeLow    ItemType = "low"
+	ItemTypeMedium ItemType = "medium"
+	ItemTypeHigh   ItemType = "high"
+)
+
+func (e *ItemType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ItemType(s)
+	case string:
+		*e = ItemType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ItemType: %T", src)
+	}
+	return nil
+}
+
+type NullItemType struct {
+	ItemType ItemType `json:"item_type"`
+	Valid    bool     `json:"valid"` // Valid is true if ItemType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullItemType) Scan(value interface{}) error {
+	if value == nil {
+		ns.ItemType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ItemType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullItemType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ItemType), nil
+}
+
+type JoinRequestStatus string
+
+const (
+	JoinRequestStatusPending  JoinRequestStatus = "pending"
+	JoinRequestStatusApproved JoinRequestStatus = "approved"
+	JoinRequestStatusDenied   JoinRequestStatus = "denied"
+)
+
+func (e *JoinRequestStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = JoinRequestStatus(s)
+	case string:
+		*e = JoinRequestStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for JoinRequestStatus: %T", src)
+	}
+	return nil
+}
+
+type NullJoinRequestStatus struct {
+	JoinRequestStatus JoinRequestStatus `json:"join_request_status"`
+	Valid             bool              `json:"valid"` // Valid is true if JoinRequestStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullJoinRequestStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.JoinRequestStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.JoinRequestStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullJoinRequestStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.JoinRequestStatus), nil
+}
+
+type ReportStatus string
+
+const (
+	ReportStatusPending ReportStatus = "pending"
+	ReportStatusReady   ReportStatus = "ready"
+	ReportStatusFailed  ReportStatus = "failed"
+)
+
+func (e *ReportStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ReportStatus(s)
+	case string:
+		*e = ReportStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ReportStatus: %T", src)
+	}
+	return nil
+}
+
+type NullReportStatus struct {
+	ReportStatus ReportStatus `json:"report_status"`
+	Valid        bool         `json:"valid"` // Valid is true if ReportStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullReportStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.ReportStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ReportStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullReportStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ReportStatus), nil
+}
+
+type ReportType string
+
+const (
+	ReportTypeBorrowings  ReportType = "borrowings"
+	ReportTypeBookings    ReportType = "bookings"
+	ReportTypeTakings     ReportType = "takings"
+	ReportTypeOutstanding ReportType = "outstanding"
+)
+
+func (e *ReportType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ReportType(s)
+	case string:
+		*e = ReportType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ReportType: %T", src)
+	}
+	return nil
+}
+
+type NullReportType struct {
+	ReportType ReportType `json:"report_type"`
+	Valid      bool       `json:"valid"` // Valid is true if ReportType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullReportType) Scan(value interface{}) error {
+	if value == nil {
+		ns.ReportType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ReportType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullReportType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ReportType), nil
+}
+
+type RequestRouteReason string
+
+const (
+	RequestRouteReasonOwnerGroup RequestRouteReason = "owner_group"
+	RequestRouteReasonGlobal     RequestRouteReason = "global"
+	RequestRouteReasonEscalated  RequestRouteReason = "escalated"
+)
+
+func (e *RequestRouteReason) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RequestRouteReason(s)
+	case string:
+		*e = RequestRouteReason(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RequestRouteReason: %T", src)
+	}
+	return nil
+}
+
+type NullRequestRouteReason struct {
+	RequestRouteReason RequestRouteReason `json:"request_route_reason"`
+	Valid              bool               `json:"valid"` // Valid is true if RequestRouteReason is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullRequestRouteReason) Scan(value interface{}) error {
+	if value == nil {
+		ns.RequestRouteReason, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.RequestRouteReason.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullRequestRouteReason) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.RequestRouteReason), nil
+}
+
+type RequestStatus string
+
+const (
+	RequestStatusPending             RequestStatus = "pending"
+	RequestStatusApproved            RequestStatus = "approved"
+	RequestStatusDenied              RequestStatus = "denied"
+	RequestStatusFulfilled           RequestStatus = "fulfilled"
+	RequestStatusPendingConfirmation RequestStatus = "pending_confirmation"
+	RequestStatusConfirmed           RequestStatus = "confirmed"
+	RequestStatusExpired             RequestStatus = "expired"
+	RequestStatusNoShow              RequestStatus = "no_show"
+	RequestStatusCancelled           RequestStatus = "cancelled"
+)
+
+func (e *RequestStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RequestStatus(s)
+	case string:
+		*e = RequestStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RequestStatus: %T", src)
+	}
+	return nil
+}
+
+type NullRequestStatus struct {
+	RequestStatus RequestStatus `json:"request_status"`
+	Valid         bool          `json:"valid"` // Valid is true if RequestStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullRequestStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.RequestStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.RequestStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullRequestStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.RequestStatus), nil
+}
+
+type RoleChangeAction string
+
+const (
+	RoleChangeActionRoleCreated      RoleChangeAction = "role_created"
+	RoleChangeActionPermissionsSet   RoleChangeAction = "permissions_set"
+	RoleChangeActionUserRoleAssigned RoleChangeAction = "user_role_assigned"
+	RoleChangeActionUserRoleRevoked  RoleChangeAction = "user_role_revoked"
+)
+
+func (e *RoleChangeAction) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RoleChangeAction(s)
+	case string:
+		*e = RoleChangeAction(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RoleChangeAction: %T", src)
+	}
+	return nil
+}
+
+type NullRoleChangeAction struct {
+	RoleChangeAction RoleChangeAction `json:"role_change_action"`
+	Valid            bool             `json:"valid"` // Valid is true if RoleChangeAction is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullRoleChangeAction) Scan(value interface{}) error {
+	if value == nil {
+		ns.RoleChangeAction, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.RoleChangeAction.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullRoleChangeAction) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.RoleChangeAction), nil
+}
+
+type ScopeType string
+
+const (
+	ScopeTypeGlobal ScopeType = "global"
+	ScopeTypeGroup  ScopeType = "group"
+)
+
+func (e *ScopeType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ScopeType(s)
+	case string:
+		*e = ScopeType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ScopeType: %T", src)
+	}
+	return nil
+}
+
+type NullScopeType struct {
+	ScopeType ScopeType `json:"scope_type"`
+	Valid     bool      `json:"valid"` // Valid is true if ScopeType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullScopeType) Scan(value interface{}) error {
+	if value == nil {
+		ns.ScopeType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ScopeType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullScopeType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ScopeType), nil
+}
+
+type StockMovementKind string
+
+const (
+	StockMovementKindBorrow      StockMovementKind = "borrow"
+	StockMovementKindReturn      StockMovementKind = "return"
+	StockMovementKindTake        StockMovementKind = "take"
+	StockMovementKindAdjust      StockMovementKind = "adjust"
+	StockMovementKindPurchase    StockMovementKind = "purchase"
+	StockMovementKindWriteOff    StockMovementKind = "write_off"
+	StockMovementKindMaintenance StockMovementKind = "maintenance"
 )
 
-func (e *Condition) Scan(src interface{}) error {
+func (e *StockMovementKind) Scan(src interface{}) error {
 	switch s := src.(type) {
 	case []byte:
-		*e = Condition(s)
+		*e = StockMovementKind(s)
 	case string:
-		*e = Condition(s)
+		*e = StockMovementKind(s)
 	default:
-		return fmt.Errorf("unsupported scan type for Condition: %T", src)
+		return fmt.Errorf("unsupported scan type for StockMovementKind: %T", src)
 	}
 	return nil
 }
 
-type NullCondition struct {
-	Condition Condition `json:"condition"`
-	Valid     bool      `json:"valid"` // Valid is true if Condition is not NULL
+type NullStockMovementKind struct {
+	StockMovementKind StockMovementKind `json:"stock_movement_kind"`
+	Valid             bool              `json:"valid"` // Valid is true if StockMovementKind is not NULL
 }
 
 // Scan implements the Scanner interface.
-func (ns *NullCondition) Scan(value interface{}) error {
+func (ns *NullStockMovementKind) Scan(value interface{}) error {
 	if value == nil {
-		ns.Condition, ns.Valid = "", false
+		ns.StockMovementKind, ns.Valid = "", false
 		return nil
 	}
 	ns.Valid = true
-	return ns.Condition.Scan(value)
+	return ns.StockMovementKind.Scan(value)
 }
 
 // Value implements the driver Valuer interface.
-func (ns NullCondition) Value() (driver.Value, error) {
+func (ns NullStockMovementKind) Value() (driver.Value, error) {
 	if !ns.Valid {
 		return nil, nil
 	}
-	return string(ns.Condition), nil
+	return string(ns.StockMovementKind), nil
 }
 
-type ItemType string
+type StocktakeStatus string
 
 const (
-	ItemTypeLow    ItemType = "low"
-	ItemTypeMedium ItemType = "medium"
-	ItemTypeHigh   ItemType = "high"
+	StocktakeStatusCounting StocktakeStatus = "counting"
+	StocktakeStatusClosed   StocktakeStatus = "closed"
 )
 
-func (e *ItemType) Scan(src interface{}) error {
+func (e *StocktakeStatus) Scan(src interface{}) error {
 	switch s := src.(type) {
 	case []byte:
-		*e = ItemType(s)
+		*e = StocktakeStatus(s)
 	case string:
-		*e = ItemType(s)
+		*e = StocktakeStatus(s)
 	default:
-		return fmt.Errorf("unsupported scan type for ItemType: %T", src)
+		return fmt.Errorf("unsupported scan type for StocktakeStatus: %T", src)
 	}
 	return nil
 }
 
-type NullItemType struct {
-	ItemType ItemType `json:"item_type"`
-	Valid    bool     `json:"valid"` // Valid is true if ItemType is not NULL
+type NullStocktakeStatus struct {
+	StocktakeStatus StocktakeStatus `json:"stocktake_status"`
+	Valid           bool            `json:"valid"` // Valid is true if StocktakeStatus is not NULL
 }
 
 // Scan implements the Scanner interface.
-func (ns *NullItemType) Scan(value interface{}) error {
+func (ns *NullStocktakeStatus) Scan(value interface{}) error {
 	if value == nil {
-		ns.ItemType, ns.Valid = "", false
+		ns.StocktakeStatus, ns.Valid = "", false
 		return nil
 	}
 	ns.Valid = true
-	return ns.ItemType.Scan(value)
+	return ns.StocktakeStatus.Scan(value)
 }
 
 // Value implements the driver Valuer interface.
-func (ns NullItemType) Value() (driver.Value, error) {
+func (ns NullStocktakeStatus) Value() (driver.Value, error) {
 	if !ns.Valid {
 		return nil, nil
 	}
-	return string(ns.ItemType), nil
+	return string(ns.StocktakeStatus), nil
 }
 
-type RequestStatus string
+type UnitStatus string
 
 const (
-	RequestStatusPending             RequestStatus = "pending"
-	RequestStatusApproved            RequestStatus = "approved"
-	RequestStatusDenied              RequestStatus = "denied"
-	RequestStatusFulfilled           RequestStatus = "fulfilled"
-	RequestStatusPendingConfirmation RequestStatus = "pending_confirmation"
-	RequestStatusConfirmed           RequestStatus = "confirmed"
-	RequestStatusExpired             RequestStatus = "expired"
-	RequestStatusNoShow              RequestStatus = "no_show"
-	RequestStatusCancelled           RequestStatus = "cancelled"
+	UnitStatusAvailable   UnitStatus = "available"
+	UnitStatusBorrowed    UnitStatus = "borrowed"
+	UnitStatusMaintenance UnitStatus = "maintenance"
+	UnitStatusRetired     UnitStatus = "retired"
 )
 
-func (e *RequestStatus) Scan(src interface{}) error {
+func (e *UnitStatus) Scan(src interface{}) error {
 	switch s := src.(type) {
 	case []byte:
-		*e = RequestStatus(s)
+		*e = UnitStatus(s)
 	case string:
-		*e = RequestStatus(s)
+		*e = UnitStatus(s)
 	default:
-		return fmt.Errorf("unsupported scan type for RequestStatus: %T", src)
+		return fmt.Errorf("unsupported scan type for UnitStatus: %T", src)
 	}
 	return nil
 }
 
-type NullRequestStatus struct {
-	RequestStatus RequestStatus `json:"request_status"`
-	Valid         bool          `json:"valid"` // Valid is true if RequestStatus is not NULL
+type NullUnitStatus struct {
+	UnitStatus UnitStatus `json:"unit_status"`
+	Valid      bool       `json:"valid"` // Valid is true if UnitStatus is not NULL
 }
 
 // Scan implements the Scanner interface.
-func (ns *NullRequestStatus) Scan(value interface{}) error {
+func (ns *NullUnitStatus) Scan(value interface{}) error {
 	if value == nil {
-		ns.RequestStatus, ns.Valid = "", false
+		ns.UnitStatus, ns.Valid = "", false
 		return nil
 	}
 	ns.Valid = true
-	return ns.RequestStatus.Scan(value)
+	return ns.UnitStatus.Scan(value)
 }
 
 // Value implements the driver Valuer interface.
-func (ns NullRequestStatus) Value() (driver.Value, error) {
+func (ns NullUnitStatus) Value() (driver.Value, error) {
 	if !ns.Valid {
 		return nil, nil
 	}
-	return string(ns.RequestStatus), nil
+	return string(ns.UnitStatus), nil
 }
 
-type ScopeType string
+type WebhookDeliveryStatus string
 
 const (
-	ScopeTypeGlobal ScopeType = "global"
-	ScopeTypeGroup  ScopeType = "group"
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
 )
 
-func (e *ScopeType) Scan(src interface{}) error {
+func (e *WebhookDeliveryStatus) Scan(src interface{}) error {
 	switch s := src.(type) {
 	case []byte:
-		*e = ScopeType(s)
+		*e = WebhookDeliveryStatus(s)
 	case string:
-		*e = ScopeType(s)
+		*e = WebhookDeliveryStatus(s)
 	default:
-		return fmt.Errorf("unsupported scan type for ScopeType: %T", src)
+		return fmt.Errorf("unsupported scan type for WebhookDeliveryStatus: %T", src)
 	}
 	return nil
 }
 
-type NullScopeType struct {
-	ScopeType ScopeType `json:"scope_type"`
-	Valid     bool      `json:"valid"` // Valid is true if ScopeType is not NULL
+type NullWebhookDeliveryStatus struct {
+	WebhookDeliveryStatus WebhookDeliveryStatus `json:"webhook_delivery_status"`
+	Valid                 bool                  `json:"valid"` // Valid is true if WebhookDeliveryStatus is not NULL
 }
 
 // Scan implements the Scanner interface.
-func (ns *NullScopeType) Scan(value interface{}) error {
+func (ns *NullWebhookDeliveryStatus) Scan(value interface{}) error {
 	if value == nil {
-		ns.ScopeType, ns.Valid = "", false
+		ns.WebhookDeliveryStatus, ns.Valid = "", false
 		return nil
 	}
 	ns.Valid = true
-	return ns.ScopeType.Scan(value)
+	return ns.WebhookDeliveryStatus.Scan(value)
 }
 
 // Value implements the driver Valuer interface.
-func (ns NullScopeType) Value() (driver.Value, error) {
+func (ns NullWebhookDeliveryStatus) Value() (driver.Value, error) {
 	if !ns.Valid {
 		return nil, nil
 	}
-	return string(ns.ScopeType), nil
+	return string(ns.WebhookDeliveryStatus), nil
+}
+
+type ApiKey struct {
+	ID          uuid.UUID        `json:"id"`
+	Name        string           `json:"name"`
+	UserID      uuid.UUID        `json:"user_id"`
+	KeyHash     string           `json:"key_hash"`
+	KeyPrefix   string           `json:"key_prefix"`
+	Permissions []string         `json:"permissions"`
+	RateLimit   pgtype.Int4      `json:"rate_limit"`
+	CreatedBy   *uuid.UUID       `json:"created_by"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	LastUsedAt  pgtype.Timestamp `json:"last_used_at"`
+	RevokedAt   pgtype.Timestamp `json:"revoked_at"`
+}
+
+type ApprovalDelegation struct {
+	ID          uuid.UUID        `json:"id"`
+	DelegatorID uuid.UUID        `json:"delegator_id"`
+	DelegateID  uuid.UUID        `json:"delegate_id"`
+	StartsAt    pgtype.Timestamp `json:"starts_at"`
+	EndsAt      pgtype.Timestamp `json:"ends_at"`
+	Reason      pgtype.Text      `json:"reason"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+type AuditLog struct {
+	ID         uuid.UUID        `json:"id"`
+	ActorID    *uuid.UUID       `json:"actor_id"`
+	Action     string           `json:"action"`
+	EntityType string           `json:"entity_type"`
+	EntityID   pgtype.Text      `json:"entity_id"`
+	Before     []byte           `json:"before"`
+	After      []byte           `json:"after"`
+	RequestID  pgtype.Text      `json:"request_id"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
 }
 
 type Booking struct {
-	ID             uuid.UUID        `json:"id"`
-	RequesterID    *uuid.UUID       `json:"requester_id"`
-	ManagerID      *uuid.UUID       `json:"manager_id"`
-	ItemID         *uuid.UUID       `json:"item_id"`
-	GroupID        *uuid.UUID       `json:"group_id"`
-	AvailabilityID *uuid.UUID       `json:"availability_id"`
-	PickUpDate     pgtype.Timestamp `json:"pick_up_date"`
-	PickUpLocation string           `json:"pick_up_location"`
-	ReturnDate     pgtype.Timestamp `json:"return_date"`
-	ReturnLocation string           `json:"return_location"`
-	Status         RequestStatus    `json:"status"`
-	ConfirmedAt    pgtype.Timestamp `json:"confirmed_at"`
-	ConfirmedBy    *uuid.UUID       `json:"confirmed_by"`
-	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	ID                 uuid.UUID        `json:"id"`
+	RequesterID        *uuid.UUID       `json:"requester_id"`
+	ManagerID          *uuid.UUID       `json:"manager_id"`
+	ItemID             *uuid.UUID       `json:"item_id"`
+	GroupID            *uuid.UUID       `json:"group_id"`
+	AvailabilityID     *uuid.UUID       `json:"availability_id"`
+	PickUpDate         pgtype.Timestamp `json:"pick_up_date"`
+	PickUpLocation     string           `json:"pick_up_location"`
+	ReturnDate         pgtype.Timestamp `json:"return_date"`
+	ReturnLocation     string           `json:"return_location"`
+	Status             RequestStatus    `json:"status"`
+	ConfirmedAt        pgtype.Timestamp `json:"confirmed_at"`
+	ConfirmedBy        *uuid.UUID       `json:"confirmed_by"`
+	CreatedAt          pgtype.Timestamp `json:"created_at"`
+	IsTest             bool             `json:"is_test"`
+	CancelledBy        *uuid.UUID       `json:"cancelled_by"`
+	CancelledAt        pgtype.Timestamp `json:"cancelled_at"`
+	CancellationReason pgtype.Text      `json:"cancellation_reason"`
+	UnitID             *uuid.UUID       `json:"unit_id"`
+}
+
+type BookingEvent struct {
+	ID         uuid.UUID         `json:"id"`
+	BookingID  uuid.UUID         `json:"booking_id"`
+	ActorID    *uuid.UUID        `json:"actor_id"`
+	FromStatus NullRequestStatus `json:"from_status"`
+	ToStatus   RequestStatus     `json:"to_status"`
+	Payload    []byte            `json:"payload"`
+	CreatedAt  pgtype.Timestamp  `json:"created_at"`
+}
+
+type BookingHandoff struct {
+	BookingID   uuid.UUID        `json:"booking_id"`
+	BorrowingID uuid.UUID        `json:"borrowing_id"`
+	PickedUpBy  *uuid.UUID       `json:"picked_up_by"`
+	PickedUpAt  pgtype.Timestamp `json:"picked_up_at"`
+	ReturnedBy  *uuid.UUID       `json:"returned_by"`
+	ReturnedAt  pgtype.Timestamp `json:"returned_at"`
+}
+
+type BookingVerification struct {
+	ID         uuid.UUID        `json:"id"`
+	BookingID  uuid.UUID        `json:"booking_id"`
+	VerifiedBy *uuid.UUID       `json:"verified_by"`
+	Matched    bool             `json:"matched"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
 }
 
 type Borrowing struct {
@@ -221,15 +1070,78 @@ type Borrowing struct {
 	BeforeConditionUrl string           `json:"before_condition_url"`
 	AfterCondition     NullCondition    `json:"after_condition"`
 	AfterConditionUrl  pgtype.Text      `json:"after_condition_url"`
+	UnitID             *uuid.UUID       `json:"unit_id"`
+}
+
+type BorrowingBlackout struct {
+	ID        uuid.UUID        `json:"id"`
+	Reason    string           `json:"reason"`
+	ItemType  NullItemType     `json:"item_type"`
+	StartsAt  pgtype.Timestamp `json:"starts_at"`
+	EndsAt    pgtype.Timestamp `json:"ends_at"`
+	CreatedBy *uuid.UUID       `json:"created_by"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type BorrowingExtension struct {
+	ID               uuid.UUID        `json:"id"`
+	BorrowingID      uuid.UUID        `json:"borrowing_id"`
+	RequestedBy      *uuid.UUID       `json:"requested_by"`
+	CurrentDueDate   pgtype.Timestamp `json:"current_due_date"`
+	RequestedDueDate pgtype.Timestamp `json:"requested_due_date"`
+	Reason           pgtype.Text      `json:"reason"`
+	Status           ExtensionStatus  `json:"status"`
+	DecisionNotes    pgtype.Text      `json:"decision_notes"`
+	DecidedBy        *uuid.UUID       `json:"decided_by"`
+	DecidedAt        pgtype.Timestamp `json:"decided_at"`
+	CreatedAt        pgtype.Timestamp `json:"created_at"`
 }
 
 type BorrowingImage struct {
-	ID          uuid.UUID        `json:"id"`
+	ID             uuid.UUID        `json:"id"`
+	BorrowingID    uuid.UUID        `json:"borrowing_id"`
+	S3Key          string           `json:"s3_key"`
+	ImageType      string           `json:"image_type"`
+	UploadedBy     *uuid.UUID       `json:"uploaded_by"`
+	CreatedAt      pgtype.Timestamp `json:"created_at"`
+	WebS3Key       pgtype.Text      `json:"web_s3_key"`
+	ThumbnailS3Key pgtype.Text      `json:"thumbnail_s3_key"`
+	ProcessedAt    pgtype.Timestamp `json:"processed_at"`
+}
+
+type BorrowingPolicy struct {
+	ID                  uuid.UUID        `json:"id"`
+	Name                string           `json:"name"`
+	RoleName            pgtype.Text      `json:"role_name"`
+	ItemType            NullItemType     `json:"item_type"`
+	MaxActiveBorrowings pgtype.Int4      `json:"max_active_borrowings"`
+	MaxQuantity         pgtype.Int4      `json:"max_quantity"`
+	LoanDays            pgtype.Int4      `json:"loan_days"`
+	AllowPartialPickup  pgtype.Bool      `json:"allow_partial_pickup"`
+	CreatedBy           *uuid.UUID       `json:"created_by"`
+	CreatedAt           pgtype.Timestamp `json:"created_at"`
+	UpdatedAt           pgtype.Timestamp `json:"updated_at"`
+}
+
+type BorrowingReminder struct {
 	BorrowingID uuid.UUID        `json:"borrowing_id"`
-	S3Key       string           `json:"s3_key"`
-	ImageType   string           `json:"image_type"`
-	UploadedBy  *uuid.UUID       `json:"uploaded_by"`
-	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	DaysFromDue int32            `json:"days_from_due"`
+	SentAt      pgtype.Timestamp `json:"sent_at"`
+}
+
+type CalendarFeedToken struct {
+	UserID    uuid.UUID        `json:"user_id"`
+	TokenHash string           `json:"token_hash"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type CalendarLink struct {
+	UserID       uuid.UUID        `json:"user_id"`
+	IcsUrl       string           `json:"ics_url"`
+	LastSyncedAt pgtype.Timestamp `json:"last_synced_at"`
+	LastError    pgtype.Text      `json:"last_error"`
+	RemovedSlots int32            `json:"removed_slots"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
 }
 
 type Cart struct {
@@ -240,21 +1152,142 @@ type Cart struct {
 	Quantity  int32            `json:"quantity"`
 }
 
+type Category struct {
+	ID        uuid.UUID        `json:"id"`
+	Slug      string           `json:"slug"`
+	Name      string           `json:"name"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type DamageReport struct {
+	ID              uuid.UUID          `json:"id"`
+	BorrowingID     uuid.UUID          `json:"borrowing_id"`
+	ItemID          uuid.UUID          `json:"item_id"`
+	GroupID         *uuid.UUID         `json:"group_id"`
+	ReportedBy      *uuid.UUID         `json:"reported_by"`
+	BeforeCondition Condition          `json:"before_condition"`
+	AfterCondition  Condition          `json:"after_condition"`
+	Severity        DamageSeverity     `json:"severity"`
+	Notes           pgtype.Text        `json:"notes"`
+	PhotoKeys       []string           `json:"photo_keys"`
+	Status          DamageReportStatus `json:"status"`
+	ResolutionNotes pgtype.Text        `json:"resolution_notes"`
+	ResolvedBy      *uuid.UUID         `json:"resolved_by"`
+	ResolvedAt      pgtype.Timestamp   `json:"resolved_at"`
+	CreatedAt       pgtype.Timestamp   `json:"created_at"`
+}
+
+type DeletionRequest struct {
+	ID          uuid.UUID        `json:"id"`
+	EntityType  DeletionEntity   `json:"entity_type"`
+	EntityID    uuid.UUID        `json:"entity_id"`
+	EntityName  string           `json:"entity_name"`
+	Status      DeletionStatus   `json:"status"`
+	RequestedBy *uuid.UUID       `json:"requested_by"`
+	RequestedAt pgtype.Timestamp `json:"requested_at"`
+	ApprovedBy  *uuid.UUID       `json:"approved_by"`
+	ApprovedAt  pgtype.Timestamp `json:"approved_at"`
+	PurgeAfter  pgtype.Timestamp `json:"purge_after"`
+	ResolvedBy  *uuid.UUID       `json:"resolved_by"`
+	ResolvedAt  pgtype.Timestamp `json:"resolved_at"`
+	Reason      pgtype.Text      `json:"reason"`
+}
+
+type EmailSuppression struct {
+	Email     string           `json:"email"`
+	Reason    string           `json:"reason"`
+	Detail    pgtype.Text      `json:"detail"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type Fine struct {
+	ID              uuid.UUID        `json:"id"`
+	UserID          uuid.UUID        `json:"user_id"`
+	BorrowingID     uuid.UUID        `json:"borrowing_id"`
+	ItemID          uuid.UUID        `json:"item_id"`
+	DamageReportID  *uuid.UUID       `json:"damage_report_id"`
+	Reason          FineReason       `json:"reason"`
+	AmountCents     int32            `json:"amount_cents"`
+	DaysLate        pgtype.Int4      `json:"days_late"`
+	Status          FineStatus       `json:"status"`
+	ResolutionNotes pgtype.Text      `json:"resolution_notes"`
+	ResolvedBy      *uuid.UUID       `json:"resolved_by"`
+	ResolvedAt      pgtype.Timestamp `json:"resolved_at"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+}
+
 type Group struct {
 	ID                 uuid.UUID   `json:"id"`
 	Name               string      `json:"name"`
 	Description        pgtype.Text `json:"description"`
 	LogoS3Key          pgtype.Text `json:"logo_s3_key"`
 	LogoThumbnailS3Key pgtype.Text `json:"logo_thumbnail_s3_key"`
+	Sandbox            bool        `json:"sandbox"`
+}
+
+type GroupBookingPolicy struct {
+	GroupID                 uuid.UUID        `json:"group_id"`
+	ConfirmationWindowHours pgtype.Int4      `json:"confirmation_window_hours"`
+	PickupCutoffHours       pgtype.Int4      `json:"pickup_cutoff_hours"`
+	UpdatedBy               *uuid.UUID       `json:"updated_by"`
+	UpdatedAt               pgtype.Timestamp `json:"updated_at"`
+}
+
+type GroupJoinRequest struct {
+	ID            uuid.UUID         `json:"id"`
+	GroupID       uuid.UUID         `json:"group_id"`
+	UserID        uuid.UUID         `json:"user_id"`
+	Message       pgtype.Text       `json:"message"`
+	Status        JoinRequestStatus `json:"status"`
+	DecisionNotes pgtype.Text       `json:"decision_notes"`
+	DecidedBy     *uuid.UUID        `json:"decided_by"`
+	DecidedAt     pgtype.Timestamp  `json:"decided_at"`
+	CreatedAt     pgtype.Timestamp  `json:"created_at"`
+}
+
+type GroupRequestSla struct {
+	GroupID     uuid.UUID        `json:"group_id"`
+	ReviewHours int32            `json:"review_hours"`
+	UpdatedBy   *uuid.UUID       `json:"updated_by"`
+	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
 }
 
 type Item struct {
-	ID          uuid.UUID   `json:"id"`
-	Name        string      `json:"name"`
-	Description pgtype.Text `json:"description"`
-	Type        ItemType    `json:"type"`
-	Stock       int32       `json:"stock"`
-	Urls        []string    `json:"urls"`
+	ID           uuid.UUID        `json:"id"`
+	Name         string           `json:"name"`
+	Description  pgtype.Text      `json:"description"`
+	Type         ItemType         `json:"type"`
+	Stock        int32            `json:"stock"`
+	Urls         []string         `json:"urls"`
+	ArchivedAt   pgtype.Timestamp `json:"archived_at"`
+	OwnerGroupID *uuid.UUID       `json:"owner_group_id"`
+	Version      int32            `json:"version"`
+}
+
+type ItemCategory struct {
+	ItemID     uuid.UUID `json:"item_id"`
+	CategoryID uuid.UUID `json:"category_id"`
+}
+
+type ItemFairnessPolicy struct {
+	ItemID     uuid.UUID        `json:"item_id"`
+	WindowDays int32            `json:"window_days"`
+	CreatedBy  *uuid.UUID       `json:"created_by"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+type ItemFee struct {
+	ItemID         uuid.UUID        `json:"item_id"`
+	LateFeeCents   int32            `json:"late_fee_cents"`
+	DamageFeeCents int32            `json:"damage_fee_cents"`
+	UpdatedBy      *uuid.UUID       `json:"updated_by"`
+	UpdatedAt      pgtype.Timestamp `json:"updated_at"`
+}
+
+type ItemGroupShare struct {
+	ItemID    uuid.UUID        `json:"item_id"`
+	GroupID   uuid.UUID        `json:"group_id"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
 }
 
 type ItemImage struct {
@@ -270,6 +1303,25 @@ type ItemImage struct {
 	CreatedAt      pgtype.Timestamp `json:"created_at"`
 }
 
+type ItemMaintenance struct {
+	ID                 uuid.UUID        `json:"id"`
+	ItemID             uuid.UUID        `json:"item_id"`
+	Quantity           int32            `json:"quantity"`
+	Reason             string           `json:"reason"`
+	ExpectedReturnDate pgtype.Date      `json:"expected_return_date"`
+	StartedBy          *uuid.UUID       `json:"started_by"`
+	StartedAt          pgtype.Timestamp `json:"started_at"`
+	CompletionNotes    pgtype.Text      `json:"completion_notes"`
+	CompletedBy        *uuid.UUID       `json:"completed_by"`
+	CompletedAt        pgtype.Timestamp `json:"completed_at"`
+	UnitID             *uuid.UUID       `json:"unit_id"`
+}
+
+type ItemTag struct {
+	ItemID uuid.UUID `json:"item_id"`
+	TagID  uuid.UUID `json:"tag_id"`
+}
+
 type ItemTaking struct {
 	ID       uuid.UUID        `json:"id"`
 	UserID   uuid.UUID        `json:"user_id"`
@@ -279,12 +1331,35 @@ type ItemTaking struct {
 	TakenAt  pgtype.Timestamp `json:"taken_at"`
 }
 
+type ItemUnit struct {
+	ID           uuid.UUID        `json:"id"`
+	ItemID       uuid.UUID        `json:"item_id"`
+	SerialNumber pgtype.Text      `json:"serial_number"`
+	AssetTag     pgtype.Text      `json:"asset_tag"`
+	Condition    Condition        `json:"condition"`
+	Status       UnitStatus       `json:"status"`
+	Notes        pgtype.Text      `json:"notes"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
+}
+
+type ItemWaitlist struct {
+	ID         uuid.UUID        `json:"id"`
+	ItemID     uuid.UUID        `json:"item_id"`
+	UserID     uuid.UUID        `json:"user_id"`
+	GroupID    uuid.UUID        `json:"group_id"`
+	Quantity   int32            `json:"quantity"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	NotifiedAt pgtype.Timestamp `json:"notified_at"`
+}
+
 type Notification struct {
 	ID                   uuid.UUID        `json:"id"`
 	NotificationObjectID uuid.UUID        `json:"notification_object_id"`
 	NotifierID           uuid.UUID        `json:"notifier_id"`
 	IsRead               bool             `json:"is_read"`
 	CreatedAt            pgtype.Timestamp `json:"created_at"`
+	Title                pgtype.Text      `json:"title"`
 }
 
 type NotificationChange struct {
@@ -307,11 +1382,55 @@ type NotificationObject struct {
 	CreatedAt    pgtype.Timestamp `json:"created_at"`
 }
 
+type NotificationPreference struct {
+	UserID           uuid.UUID        `json:"user_id"`
+	NotificationType string           `json:"notification_type"`
+	EmailEnabled     bool             `json:"email_enabled"`
+	UpdatedAt        pgtype.Timestamp `json:"updated_at"`
+}
+
+type NotificationRoutingRule struct {
+	ID              uuid.UUID        `json:"id"`
+	Name            string           `json:"name"`
+	Template        string           `json:"template"`
+	ItemID          *uuid.UUID       `json:"item_id"`
+	ItemType        NullItemType     `json:"item_type"`
+	GroupID         *uuid.UUID       `json:"group_id"`
+	MinDaysOverdue  pgtype.Int4      `json:"min_days_overdue"`
+	RecipientUserID *uuid.UUID       `json:"recipient_user_id"`
+	RecipientEmail  pgtype.Text      `json:"recipient_email"`
+	ReplaceDefault  bool             `json:"replace_default"`
+	Enabled         bool             `json:"enabled"`
+	CreatedBy       *uuid.UUID       `json:"created_by"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+}
+
+type OverdueBorrowing struct {
+	BorrowingID uuid.UUID        `json:"borrowing_id"`
+	OverdueAt   pgtype.Timestamp `json:"overdue_at"`
+}
+
 type Permission struct {
 	Name        string      `json:"name"`
 	Description pgtype.Text `json:"description"`
 }
 
+type Report struct {
+	ID          uuid.UUID        `json:"id"`
+	ReportType  ReportType       `json:"report_type"`
+	Status      ReportStatus     `json:"status"`
+	RequestedBy uuid.UUID        `json:"requested_by"`
+	GroupID     *uuid.UUID       `json:"group_id"`
+	FromDate    pgtype.Date      `json:"from_date"`
+	ToDate      pgtype.Date      `json:"to_date"`
+	S3Key       pgtype.Text      `json:"s3_key"`
+	RowCount    pgtype.Int4      `json:"row_count"`
+	Error       pgtype.Text      `json:"error"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	CompletedAt pgtype.Timestamp `json:"completed_at"`
+	ExpiresAt   pgtype.Timestamp `json:"expires_at"`
+}
+
 type Request struct {
 	ID                      uuid.UUID         `json:"id"`
 	UserID                  *uuid.UUID        `json:"user_id"`
@@ -325,6 +1444,49 @@ type Request struct {
 	FulfilledAt             pgtype.Timestamp  `json:"fulfilled_at"`
 	BookingID               *uuid.UUID        `json:"booking_id"`
 	PreferredAvailabilityID *uuid.UUID        `json:"preferred_availability_id"`
+	OverrideJustification   pgtype.Text       `json:"override_justification"`
+}
+
+type RequestComment struct {
+	ID        uuid.UUID        `json:"id"`
+	RequestID uuid.UUID        `json:"request_id"`
+	ParentID  *uuid.UUID       `json:"parent_id"`
+	AuthorID  *uuid.UUID       `json:"author_id"`
+	Body      string           `json:"body"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type RequestRoute struct {
+	ID            uuid.UUID          `json:"id"`
+	RequestID     uuid.UUID          `json:"request_id"`
+	ApproverID    uuid.UUID          `json:"approver_id"`
+	Reason        RequestRouteReason `json:"reason"`
+	DelegatedFrom *uuid.UUID         `json:"delegated_from"`
+	RoutedAt      pgtype.Timestamp   `json:"routed_at"`
+}
+
+type RequestSlaAlert struct {
+	RequestID uuid.UUID        `json:"request_id"`
+	AlertedAt pgtype.Timestamp `json:"alerted_at"`
+}
+
+type ReturnCampaign struct {
+	ID          uuid.UUID        `json:"id"`
+	Name        string           `json:"name"`
+	TermEnd     pgtype.Date      `json:"term_end"`
+	Status      CampaignStatus   `json:"status"`
+	CreatedBy   *uuid.UUID       `json:"created_by"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	LastRunAt   pgtype.Timestamp `json:"last_run_at"`
+	CompletedAt pgtype.Timestamp `json:"completed_at"`
+	ReportID    *uuid.UUID       `json:"report_id"`
+}
+
+type ReturnCampaignNotice struct {
+	CampaignID  uuid.UUID        `json:"campaign_id"`
+	BorrowingID uuid.UUID        `json:"borrowing_id"`
+	Stage       CampaignStage    `json:"stage"`
+	SentAt      pgtype.Timestamp `json:"sent_at"`
 }
 
 type Role struct {
@@ -332,6 +1494,18 @@ type Role struct {
 	Description pgtype.Text `json:"description"`
 }
 
+type RoleChange struct {
+	ID          uuid.UUID        `json:"id"`
+	Action      RoleChangeAction `json:"action"`
+	RoleName    string           `json:"role_name"`
+	Permissions []string         `json:"permissions"`
+	UserID      *uuid.UUID       `json:"user_id"`
+	Scope       NullScopeType    `json:"scope"`
+	ScopeID     *uuid.UUID       `json:"scope_id"`
+	ChangedBy   *uuid.UUID       `json:"changed_by"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
 type RolePermission struct {
 	RoleName       string `json:"role_name"`
 	PermissionName string `json:"permission_name"`
@@ -350,6 +1524,54 @@ type SignupCode struct {
 	CreatedBy uuid.UUID        `json:"created_by"`
 }
 
+type StockMovement struct {
+	ID          uuid.UUID         `json:"id"`
+	ItemID      uuid.UUID         `json:"item_id"`
+	Delta       int32             `json:"delta"`
+	Balance     int32             `json:"balance"`
+	Kind        StockMovementKind `json:"kind"`
+	Reason      pgtype.Text       `json:"reason"`
+	ActorID     *uuid.UUID        `json:"actor_id"`
+	ReferenceID *uuid.UUID        `json:"reference_id"`
+	CreatedAt   pgtype.Timestamp  `json:"created_at"`
+}
+
+type Stocktake struct {
+	ID       uuid.UUID        `json:"id"`
+	Status   StocktakeStatus  `json:"status"`
+	Notes    pgtype.Text      `json:"notes"`
+	OpenedBy *uuid.UUID       `json:"opened_by"`
+	OpenedAt pgtype.Timestamp `json:"opened_at"`
+	ClosedBy *uuid.UUID       `json:"closed_by"`
+	ClosedAt pgtype.Timestamp `json:"closed_at"`
+}
+
+type StocktakeCount struct {
+	StocktakeID    uuid.UUID        `json:"stocktake_id"`
+	ItemID         uuid.UUID        `json:"item_id"`
+	Expected       int32            `json:"expected"`
+	Counted        int32            `json:"counted"`
+	UnitIds        []uuid.UUID      `json:"unit_ids"`
+	MissingUnitIds []uuid.UUID      `json:"missing_unit_ids"`
+	Notes          pgtype.Text      `json:"notes"`
+	CountedBy      *uuid.UUID       `json:"counted_by"`
+	CountedAt      pgtype.Timestamp `json:"counted_at"`
+	MovementID     *uuid.UUID       `json:"movement_id"`
+}
+
+type StudentIDChange struct {
+	ID        uuid.UUID        `json:"id"`
+	UserID    uuid.UUID        `json:"user_id"`
+	ChangedBy *uuid.UUID       `json:"changed_by"`
+	Replaced  bool             `json:"replaced"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type Tag struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
 type TimeSlot struct {
 	ID        uuid.UUID   `json:"id"`
 	StartTime pgtype.Time `json:"start_time"`
@@ -357,9 +1579,12 @@ type TimeSlot struct {
 }
 
 type User struct {
-	ID          uuid.UUID `json:"id"`
-	Email       string    `json:"email"`
-	Preferences []byte    `json:"preferences"`
+	ID            uuid.UUID   `json:"id"`
+	Email         string      `json:"email"`
+	Preferences   []byte      `json:"preferences"`
+	StudentIDHash pgtype.Text `json:"student_id_hash"`
+	Name          pgtype.Text `json:"name"`
+	Program       pgtype.Text `json:"program"`
 }
 
 type UserAvailability struct {
@@ -369,9 +1594,56 @@ type UserAvailability struct {
 	Date       pgtype.Date `json:"date"`
 }
 
+type UserIdentity struct {
+	Issuer      string           `json:"issuer"`
+	Subject     string           `json:"subject"`
+	UserID      uuid.UUID        `json:"user_id"`
+	Email       string           `json:"email"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	LastLoginAt pgtype.Timestamp `json:"last_login_at"`
+}
+
+type UserMfa struct {
+	UserID       uuid.UUID        `json:"user_id"`
+	Secret       string           `json:"secret"`
+	ConfirmedAt  pgtype.Timestamp `json:"confirmed_at"`
+	LastUsedStep int64            `json:"last_used_step"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+}
+
+type UserMfaBackupCode struct {
+	UserID   uuid.UUID        `json:"user_id"`
+	CodeHash string           `json:"code_hash"`
+	UsedAt   pgtype.Timestamp `json:"used_at"`
+}
+
 type UserRole struct {
 	UserID   *uuid.UUID  `json:"user_id"`
 	RoleName pgtype.Text `json:"role_name"`
 	Scope    ScopeType   `json:"scope"`
 	ScopeID  *uuid.UUID  `json:"scope_id"`
 }
+
+type Webhook struct {
+	ID          uuid.UUID        `json:"id"`
+	Url         string           `json:"url"`
+	Description pgtype.Text      `json:"description"`
+	Secret      string           `json:"secret"`
+	Events      []string         `json:"events"`
+	CreatedBy   *uuid.UUID       `json:"created_by"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID             `json:"id"`
+	WebhookID      uuid.UUID             `json:"webhook_id"`
+	EventType      string                `json:"event_type"`
+	Payload        []byte                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int32                 `json:"attempts"`
+	ResponseStatus pgtype.Int4           `json:"response_status"`
+	LastError      pgtype.Text           `json:"last_error"`
+	CreatedAt      pgtype.Timestamp      `json:"created_at"`
+	LastAttemptAt  pgtype.Timestamp      `json:"last_attempt_at"`
+	DeliveredAt    pgtype.Timestamp      `json:"delivered_at"`
+}
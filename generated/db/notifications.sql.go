@@ -38,20 +38,21 @@ func (q *Queries) CountUserNotifications(ctx context.Context, notifierID uuid.UU
 
 const createNotification = `-- name: CreateNotification :one
 INSERT INTO notifications (
-    notification_object_id, notifier_id, is_read
+    notification_object_id, notifier_id, title, is_read
 ) VALUES (
-    $1, $2, false
+    $1, $2, $3, false
 )
-RETURNING id, notification_object_id, notifier_id, is_read, created_at
+RETURNING id, notification_object_id, notifier_id, is_read, created_at, title
 `
 
 type CreateNotificationParams struct {
-	NotificationObjectID uuid.UUID `json:"notification_object_id"`
-	NotifierID           uuid.UUID `json:"notifier_id"`
+	NotificationObjectID uuid.UUID   `json:"notification_object_id"`
+	NotifierID           uuid.UUID   `json:"notifier_id"`
+	Title                pgtype.Text `json:"title"`
 }
 
 func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error) {
-	row := q.db.QueryRow(ctx, createNotification, arg.NotificationObjectID, arg.NotifierID)
+	row := q.db.QueryRow(ctx, createNotification, arg.NotificationObjectID, arg.NotifierID, arg.Title)
 	var i Notification
 	err := row.Scan(
 		&i.ID,
@@ -59,6 +60,7 @@ func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotification
 		&i.NotifierID,
 		&i.IsRead,
 		&i.CreatedAt,
+		&i.Title,
 	)
 	return i, err
 }
@@ -127,10 +129,64 @@ func (q *Queries) GetNotificationEntityTypeByName(ctx context.Context, name stri
 	return i, err
 }
 
+const getUserNotification = `-- name: GetUserNotification :one
+SELECT 
+    n.id AS notification_id,
+    n.is_read,
+    n.title,
+    n.created_at AS notification_created_at,
+    no.id AS notification_object_id,
+    no.entity_id,
+    net.name AS entity_type_name,
+    nc.actor_id,
+    u.email AS actor_email
+FROM notifications n
+JOIN notification_objects no ON n.notification_object_id = no.id
+JOIN notification_entity_types net ON no.entity_type_id = net.id
+JOIN notification_changes nc ON no.id = nc.notification_object_id
+JOIN users u ON nc.actor_id = u.id
+WHERE n.id = $1 AND n.notifier_id = $2
+`
+
+type GetUserNotificationParams struct {
+	ID         uuid.UUID `json:"id"`
+	NotifierID uuid.UUID `json:"notifier_id"`
+}
+
+type GetUserNotificationRow struct {
+	NotificationID        uuid.UUID        `json:"notification_id"`
+	IsRead                bool             `json:"is_read"`
+	Title                 pgtype.Text      `json:"title"`
+	NotificationCreatedAt pgtype.Timestamp `json:"notification_created_at"`
+	NotificationObjectID  uuid.UUID        `json:"notification_object_id"`
+	EntityID              uuid.UUID        `json:"entity_id"`
+	EntityTypeName        string           `json:"entity_type_name"`
+	ActorID               uuid.UUID        `json:"actor_id"`
+	ActorEmail            string           `json:"actor_email"`
+}
+
+func (q *Queries) GetUserNotification(ctx context.Context, arg GetUserNotificationParams) (GetUserNotificationRow, error) {
+	row := q.db.QueryRow(ctx, getUserNotification, arg.ID, arg.NotifierID)
+	var i GetUserNotificationRow
+	err := row.Scan(
+		&i.NotificationID,
+		&i.IsRead,
+		&i.Title,
+		&i.NotificationCreatedAt,
+		&i.NotificationObjectID,
+		&i.EntityID,
+		&i.EntityTypeName,
+		&i.ActorID,
+		&i.ActorEmail,
+	)
+	return i, err
+}
+
 const getUserNotifications = `-- name: GetUserNotifications :many
 SELECT 
     n.id AS notification_id,
     n.is_read,
+    n.title,
     n.created_at AS notification_created_at,
     no.id AS notification_object_id,
     no.entity_id,
@@ -156,6 +212,7 @@ type GetUserNotificationsParams struct {
 type GetUserNotificationsRow struct {
 	NotificationID        uuid.UUID        `json:"notification_id"`
 	IsRead                bool             `json:"is_read"`
+	Title                 pgtype.Text      `json:"title"`
 	NotificationCreatedAt pgtype.Timestamp `json:"notification_created_at"`
 	NotificationObjectID  uuid.UUID        `json:"notification_object_id"`
 	EntityID              uuid.UUID        `json:"entity_id"`
@@ -176,6 +233,139 @@ func (q *Queries) GetUserNotifications(ctx context.Context, arg GetUserNotificat
 		if err := rows.Scan(
 			&i.NotificationID,
 			&i.IsRead,
+			&i.Title,
+			&i.NotificationCreatedAt,
+			&i.NotificationObjectID,
+			&i.EntityID,
+			&i.EntityTypeName,
+			&i.ActorID,
+			&i.ActorEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotificationPreferences = `-- name: ListNotificationPreferences :many
+SELECT user_id, notification_type, email_enabled, updated_at FROM notification_preferences
+WHERE user_id = $1
+ORDER BY notification_type
+`
+
+func (q *Queries) ListNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]NotificationPreference, error) {
+	rows, err := q.db.Query(ctx, listNotificationPreferences, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []NotificationPreference{}
+	for rows.Next() {
+		var i NotificationPreference
+		if err := rows.Scan(
+			&i.UserID,
+			&i.NotificationType,
+			&i.EmailEnabled,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotifiedUserIDs = `-- name: ListNotifiedUserIDs :many
+SELECT DISTINCT n.notifier_id FROM notifications n
+JOIN notification_objects o ON o.id = n.notification_object_id
+JOIN notification_entity_types t ON t.id = o.entity_type_id
+WHERE t.name = $1 AND o.entity_id = $2
+`
+
+type ListNotifiedUserIDsParams struct {
+	Name     string    `json:"name"`
+	EntityID uuid.UUID `json:"entity_id"`
+}
+
+// Users sent an in-app notification about an entity
+func (q *Queries) ListNotifiedUserIDs(ctx context.Context, arg ListNotifiedUserIDsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, listNotifiedUserIDs, arg.Name, arg.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var notifier_id uuid.UUID
+		if err := rows.Scan(&notifier_id); err != nil {
+			return nil, err
+		}
+		items = append(items, notifier_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnreadUserNotifications = `-- name: ListUnreadUserNotifications :many
+SELECT 
+    n.id AS notification_id,
+    n.is_read,
+    n.title,
+    n.created_at AS notification_created_at,
+    no.id AS notification_object_id,
+    no.entity_id,
+    net.name AS entity_type_name,
+    nc.actor_id,
+    u.email AS actor_email
+FROM notifications n
+JOIN notification_objects no ON n.notification_object_id = no.id
+JOIN notification_entity_types net ON no.entity_type_id = net.id
+JOIN notification_changes nc ON no.id = nc.notification_object_id
+JOIN users u ON nc.actor_id = u.id
+WHERE n.notifier_id = $1 AND n.is_read = false
+ORDER BY n.created_at DESC
+LIMIT $2
+`
+
+type ListUnreadUserNotificationsParams struct {
+	NotifierID uuid.UUID `json:"notifier_id"`
+	Limit      int64     `json:"limit"`
+}
+
+type ListUnreadUserNotificationsRow struct {
+	NotificationID        uuid.UUID        `json:"notification_id"`
+	IsRead                bool             `json:"is_read"`
+	Title                 pgtype.Text      `json:"title"`
+	NotificationCreatedAt pgtype.Timestamp `json:"notification_created_at"`
+	NotificationObjectID  uuid.UUID        `json:"notification_object_id"`
+	EntityID              uuid.UUID        `json:"entity_id"`
+	EntityTypeName        string           `json:"entity_type_name"`
+	ActorID               uuid.UUID        `json:"actor_id"`
+	ActorEmail            string           `json:"actor_email"`
+}
+
+func (q *Queries) ListUnreadUserNotifications(ctx context.Context, arg ListUnreadUserNotificationsParams) ([]ListUnreadUserNotificationsRow, error) {
+	rows, err := q.db.Query(ctx, listUnreadUserNotifications, arg.NotifierID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUnreadUserNotificationsRow{}
+	for rows.Next() {
+		var i ListUnreadUserNotificationsRow
+		if err := rows.Scan(
+			&i.NotificationID,
+			&i.IsRead,
+			&i.Title,
 			&i.NotificationCreatedAt,
 			&i.NotificationObjectID,
 			&i.EntityID,
@@ -208,7 +398,7 @@ const markNotificationAsRead = `-- name: MarkNotificationAsRead :one
 UPDATE notifications
 SET is_read = true
 WHERE id = $1 AND notifier_id = $2
-RETURNING id, notification_object_id, notifier_id, is_read, created_at
+RETURNING id, notification_object_id, notifier_id, is_read, created_at, title
 `
 
 type MarkNotificationAsReadParams struct {
@@ -225,6 +415,26 @@ func (q *Queries) MarkNotificationAsRead(ctx context.Context, arg MarkNotificati
 		&i.NotifierID,
 		&i.IsRead,
 		&i.CreatedAt,
+		&i.Title,
 	)
 	return i, err
 }
+
+const upsertNotificationPreference = `-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, notification_type, email_enabled)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, notification_type) DO UPDATE
+SET email_enabled = EXCLUDED.email_enabled,
+    updated_at = NOW()
+`
+
+type UpsertNotificationPreferenceParams struct {
+	UserID           uuid.UUID `json:"user_id"`
+	NotificationType string    `json:"notification_type"`
+	EmailEnabled     bool      `json:"email_enabled"`
+}
+
+func (q *Queries) UpsertNotificationPreference(ctx context.Context, arg UpsertNotificationPreferenceParams) error {
+	_, err := q.db.Exec(ctx, upsertNotificationPreference, arg.UserID, arg.NotificationType, arg.EmailEnabled)
+	return err
+}
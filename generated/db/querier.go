@@ -12,10 +12,25 @@ import (
 )
 
 type Querier interface {
+	AddItemShares(ctx context.Context, arg AddItemSharesParams) error
+	AddItemTag(ctx context.Context, arg AddItemTagParams) error
+	// Returns 0 when the request was already routed to the approver
+	AddRequestRoute(ctx context.Context, arg AddRequestRouteParams) (int64, error)
+	AddRolePermissions(ctx context.Context, arg AddRolePermissionsParams) error
 	AddToCart(ctx context.Context, arg AddToCartParams) (AddToCartRow, error)
+	// Moves an item's stock by delta; no rows when it would go below zero
+	AdjustItemStock(ctx context.Context, arg AdjustItemStockParams) (int32, error)
+	AppendBookingEvent(ctx context.Context, arg AppendBookingEventParams) (BookingEvent, error)
+	ApproveDeletionRequest(ctx context.Context, arg ApproveDeletionRequestParams) (DeletionRequest, error)
+	ArchiveItem(ctx context.Context, id uuid.UUID) error
 	// this function creates a new borrowing record for a user borrowing an item
 	BorrowItem(ctx context.Context, arg BorrowItemParams) (Borrowing, error)
-	CancelBooking(ctx context.Context, id uuid.UUID) (Booking, error)
+	CancelBooking(ctx context.Context, arg CancelBookingParams) (Booking, error)
+	// The requester retracting a request; no rows once it has been reviewed
+	CancelRequest(ctx context.Context, id uuid.UUID) (Request, error)
+	CancelReturnCampaign(ctx context.Context, id uuid.UUID) (ReturnCampaign, error)
+	// Check if the user already manages an active booking picked up during this slot/date
+	CheckAvailabilityBookingConflict(ctx context.Context, arg CheckAvailabilityBookingConflictParams) (bool, error)
 	// Check if user already has availability for this slot/date
 	CheckAvailabilityConflict(ctx context.Context, arg CheckAvailabilityConflictParams) (bool, error)
 	// Check if availability is referenced by active bookings
@@ -25,46 +40,140 @@ type Querier interface {
 	CheckBorrowingItemStatus(ctx context.Context, itemID *uuid.UUID) (bool, error)
 	CheckUserPermission(ctx context.Context, arg CheckUserPermissionParams) (bool, error)
 	ClearCart(ctx context.Context, arg ClearCartParams) error
+	ClearItemCategory(ctx context.Context, itemID uuid.UUID) error
+	ClearItemShares(ctx context.Context, itemID uuid.UUID) error
+	ClearItemTags(ctx context.Context, itemID uuid.UUID) error
+	ClearRolePermissions(ctx context.Context, roleName string) error
+	// No rows when the stocktake was already closed
+	CloseStocktake(ctx context.Context, arg CloseStocktakeParams) (Stocktake, error)
+	// Closes an open record; no rows when it was already completed
+	CompleteItemMaintenance(ctx context.Context, arg CompleteItemMaintenanceParams) (ItemMaintenance, error)
+	CompleteReturnCampaign(ctx context.Context, arg CompleteReturnCampaignParams) (ReturnCampaign, error)
 	ConfirmBooking(ctx context.Context, arg ConfirmBookingParams) (Booking, error)
+	ConfirmUserMFA(ctx context.Context, arg ConfirmUserMFAParams) (int64, error)
 	CountActiveBorrowedItemsByUserId(ctx context.Context, userID *uuid.UUID) (int64, error)
-	CountAllActiveBorrowedItems(ctx context.Context) (int64, error)
-	CountAllItems(ctx context.Context) (int64, error)
-	CountAllRequests(ctx context.Context) (int64, error)
+	CountActiveBorrowingsByItemType(ctx context.Context, userID *uuid.UUID) ([]CountActiveBorrowingsByItemTypeRow, error)
+	CountAllActiveBorrowedItems(ctx context.Context, arg CountAllActiveBorrowedItemsParams) (int64, error)
+	CountAllItems(ctx context.Context, viewerID *uuid.UUID) (int64, error)
+	CountAllRequests(ctx context.Context, arg CountAllRequestsParams) (int64, error)
 	CountAllReturnedItems(ctx context.Context) (int64, error)
 	CountAllUserNotifications(ctx context.Context, notifierID uuid.UUID) (int64, error)
+	CountAuditLog(ctx context.Context, arg CountAuditLogParams) (int64, error)
 	CountBookings(ctx context.Context, arg CountBookingsParams) (int64, error)
 	CountBookingsByUser(ctx context.Context, arg CountBookingsByUserParams) (int64, error)
 	CountBorrowedItemHistoryByUserId(ctx context.Context, userID *uuid.UUID) (int64, error)
-	CountItemsByType(ctx context.Context, type_ ItemType) (int64, error)
+	CountBorrowingExtensions(ctx context.Context, status NullExtensionStatus) (int64, error)
+	CountDamageReports(ctx context.Context, arg CountDamageReportsParams) (int64, error)
+	CountDeletionRequests(ctx context.Context, status NullDeletionStatus) (int64, error)
+	CountFines(ctx context.Context, arg CountFinesParams) (int64, error)
+	CountFullTextSearchItems(ctx context.Context, arg CountFullTextSearchItemsParams) (int64, error)
+	CountGlobalRoleHolders(ctx context.Context, roleName pgtype.Text) (int64, error)
+	CountGroupJoinRequests(ctx context.Context, arg CountGroupJoinRequestsParams) (int64, error)
+	CountGroupRoleHolders(ctx context.Context, roleName pgtype.Text) (int64, error)
+	CountItemMaintenance(ctx context.Context, itemID uuid.UUID) (int64, error)
+	CountItemsByType(ctx context.Context, arg CountItemsByTypeParams) (int64, error)
+	CountOverdueBorrowings(ctx context.Context) (int64, error)
 	CountPendingRequests(ctx context.Context) (int64, error)
+	CountPermissionsByName(ctx context.Context, names []string) (int64, error)
+	CountReportsByUser(ctx context.Context, requestedBy uuid.UUID) (int64, error)
+	CountRequestsByUserId(ctx context.Context, arg CountRequestsByUserIdParams) (int64, error)
+	CountReturnCampaigns(ctx context.Context) (int64, error)
 	CountReturnedItemsByUserId(ctx context.Context, userID *uuid.UUID) (int64, error)
+	CountRoutedPendingRequests(ctx context.Context, approverID uuid.UUID) (int64, error)
 	CountSearchItems(ctx context.Context, arg CountSearchItemsParams) (int64, error)
+	CountStockMovements(ctx context.Context, itemID uuid.UUID) (int64, error)
+	CountStocktakes(ctx context.Context) (int64, error)
 	CountTakingHistoryByItemId(ctx context.Context, itemID uuid.UUID) (int64, error)
 	CountTakingHistoryByUserId(ctx context.Context, userID uuid.UUID) (int64, error)
 	CountTakingHistoryByUserIdWithGroupFilter(ctx context.Context, arg CountTakingHistoryByUserIdWithGroupFilterParams) (int64, error)
+	CountTrash(ctx context.Context, entityType pgtype.Text) (int64, error)
+	// Items in the catalogue the stocktake has no count for
+	CountUncountedItems(ctx context.Context, stocktakeID uuid.UUID) (int64, error)
+	// Live bookings of the item picked up between now and the cutoff
+	CountUpcomingItemBookings(ctx context.Context, arg CountUpcomingItemBookingsParams) (int64, error)
 	CountUserNotifications(ctx context.Context, notifierID uuid.UUID) (int64, error)
+	CountWebhookDeliveries(ctx context.Context, webhookID uuid.UUID) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
+	CreateApprovalDelegation(ctx context.Context, arg CreateApprovalDelegationParams) (ApprovalDelegation, error)
+	CreateAuditLogEntry(ctx context.Context, arg CreateAuditLogEntryParams) error
 	CreateAvailability(ctx context.Context, arg CreateAvailabilityParams) (UserAvailability, error)
 	CreateBooking(ctx context.Context, arg CreateBookingParams) (Booking, error)
+	CreateBookingHandoff(ctx context.Context, arg CreateBookingHandoffParams) (BookingHandoff, error)
+	CreateBorrowingBlackout(ctx context.Context, arg CreateBorrowingBlackoutParams) (BorrowingBlackout, error)
+	CreateBorrowingExtension(ctx context.Context, arg CreateBorrowingExtensionParams) (BorrowingExtension, error)
 	CreateBorrowingImage(ctx context.Context, arg CreateBorrowingImageParams) (BorrowingImage, error)
+	CreateBorrowingPolicy(ctx context.Context, arg CreateBorrowingPolicyParams) (BorrowingPolicy, error)
+	CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error)
+	CreateDamageReport(ctx context.Context, arg CreateDamageReportParams) (DamageReport, error)
+	CreateDeletionRequest(ctx context.Context, arg CreateDeletionRequestParams) (DeletionRequest, error)
+	CreateFine(ctx context.Context, arg CreateFineParams) (Fine, error)
 	CreateGroup(ctx context.Context, arg CreateGroupParams) (Group, error)
+	CreateGroupJoinRequest(ctx context.Context, arg CreateGroupJoinRequestParams) (GroupJoinRequest, error)
+	CreateImportedUser(ctx context.Context, arg CreateImportedUserParams) (CreateImportedUserRow, error)
 	CreateItem(ctx context.Context, arg CreateItemParams) (Item, error)
 	CreateItemImage(ctx context.Context, arg CreateItemImageParams) (ItemImage, error)
+	CreateItemMaintenance(ctx context.Context, arg CreateItemMaintenanceParams) (ItemMaintenance, error)
+	// bulk insert for seeding, one COPY instead of an INSERT per item
+	CreateItems(ctx context.Context, arg []CreateItemsParams) (int64, error)
+	CreateItemUnit(ctx context.Context, arg CreateItemUnitParams) (ItemUnit, error)
 	CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error)
 	CreateNotificationChange(ctx context.Context, arg CreateNotificationChangeParams) (NotificationChange, error)
 	CreateNotificationObject(ctx context.Context, arg CreateNotificationObjectParams) (NotificationObject, error)
 	CreatePermission(ctx context.Context, arg CreatePermissionParams) error
+	CreateReport(ctx context.Context, arg CreateReportParams) (Report, error)
+	CreateRequestComment(ctx context.Context, arg CreateRequestCommentParams) (RequestComment, error)
+	CreateReturnCampaign(ctx context.Context, arg CreateReturnCampaignParams) (ReturnCampaign, error)
 	CreateRole(ctx context.Context, arg CreateRoleParams) error
+	CreateRoleChange(ctx context.Context, arg CreateRoleChangeParams) error
 	CreateRolePermission(ctx context.Context, arg CreateRolePermissionParams) error
+	CreateRoutingRule(ctx context.Context, arg CreateRoutingRuleParams) (NotificationRoutingRule, error)
 	CreateSignUpCode(ctx context.Context, arg CreateSignUpCodeParams) (SignupCode, error)
 	CreateUser(ctx context.Context, email string) (CreateUserRow, error)
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) error
 	CreateUserRole(ctx context.Context, arg CreateUserRoleParams) error
+	CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	// No rows when the request was already decided
+	DecideBorrowingExtension(ctx context.Context, arg DecideBorrowingExtensionParams) (BorrowingExtension, error)
+	// No rows when the request was already decided
+	DecideGroupJoinRequest(ctx context.Context, arg DecideGroupJoinRequestParams) (GroupJoinRequest, error)
 	DecrementItemStock(ctx context.Context, arg DecrementItemStockParams) error
 	DecrementStockForLowItem(ctx context.Context, arg DecrementStockForLowItemParams) error
+	DeleteApprovalDelegation(ctx context.Context, arg DeleteApprovalDelegationParams) (int64, error)
 	DeleteAvailability(ctx context.Context, id uuid.UUID) error
+	DeleteBorrowingBlackout(ctx context.Context, id uuid.UUID) (int64, error)
 	DeleteBorrowingImage(ctx context.Context, id uuid.UUID) error
+	DeleteBorrowingPolicy(ctx context.Context, id uuid.UUID) (int64, error)
+	DeleteCalendarFeedToken(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteCalendarLink(ctx context.Context, userID uuid.UUID) (int64, error)
+	DeleteEmailSuppression(ctx context.Context, email string) (int64, error)
+	DeleteFairnessPolicy(ctx context.Context, itemID uuid.UUID) (int64, error)
 	DeleteGroup(ctx context.Context, id uuid.UUID) error
+	DeleteGroupBookingPolicy(ctx context.Context, groupID uuid.UUID) (int64, error)
+	DeleteGroupRequestSLA(ctx context.Context, groupID uuid.UUID) (int64, error)
 	DeleteItem(ctx context.Context, id uuid.UUID) error
 	DeleteItemImage(ctx context.Context, id uuid.UUID) error
+	// Slots more than retention_days in the past that no booking ever used
+	DeletePastUnusedAvailability(ctx context.Context, retentionDays int32) (int64, error)
+	DeleteRoutingRule(ctx context.Context, id uuid.UUID) (int64, error)
+	// global roles have no scope_id, so $4 only matters for group roles
+	DeleteUserRole(ctx context.Context, arg DeleteUserRoleParams) (int64, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) (int64, error)
+	// Only a booking still waiting for confirmation is expired
+	ExpireBooking(ctx context.Context, id uuid.UUID) (int64, error)
+	ExportBookings(ctx context.Context, arg ExportBookingsParams) ([]ExportBookingsRow, error)
+	ExportBorrowings(ctx context.Context, arg ExportBorrowingsParams) ([]ExportBorrowingsRow, error)
+	// Unreturned borrowings due on or before to_date
+	ExportOutstanding(ctx context.Context, arg ExportOutstandingParams) ([]ExportOutstandingRow, error)
+	ExportTakings(ctx context.Context, arg ExportTakingsParams) ([]ExportTakingsRow, error)
+	// moves an unreturned borrowing's due date, for granted extensions
+	ExtendBorrowingDueDate(ctx context.Context, arg ExtendBorrowingDueDateParams) (Borrowing, error)
+	// the item was handed over at pickup, with the unit for items tracked by unit
+	FulfillBooking(ctx context.Context, arg FulfillBookingParams) (Booking, error)
+	// Full-text matches rank by ts_rank, near misses on the name by trigram word
+	// similarity, so a typo still finds the item just further down the list.
+	FullTextSearchItems(ctx context.Context, arg FullTextSearchItemsParams) ([]FullTextSearchItemsRow, error)
+	GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error)
 	GetActiveBorrowedItemsByUserId(ctx context.Context, arg GetActiveBorrowedItemsByUserIdParams) ([]Borrowing, error)
 	GetActiveBorrowedItemsToBeReturnedByDate(ctx context.Context, dueDate pgtype.Timestamp) ([]Borrowing, error)
 	// this function gets an active borrowing by item_id and user_id, used to validate ownership before return
@@ -83,80 +192,389 @@ type Querier interface {
 	GetAvailabilityCountByUser(ctx context.Context, arg GetAvailabilityCountByUserParams) (int64, error)
 	// Find all approvers available for a specific date/time slot
 	GetAvailableApproversForSlot(ctx context.Context, arg GetAvailableApproversForSlotParams) ([]GetAvailableApproversForSlotRow, error)
+	// The longest-registered available unit, for handing out when none was picked
+	GetAvailableItemUnit(ctx context.Context, itemID uuid.UUID) (ItemUnit, error)
+	GetBinnedDeletionRequestForUpdate(ctx context.Context, arg GetBinnedDeletionRequestForUpdateParams) (DeletionRequest, error)
 	GetBookingByID(ctx context.Context, id uuid.UUID) (GetBookingByIDRow, error)
 	GetBookingByIDForUpdate(ctx context.Context, id uuid.UUID) (Booking, error)
+	GetBookingHandoffForUpdate(ctx context.Context, bookingID uuid.UUID) (BookingHandoff, error)
 	GetBorrowedItemHistoryByUserId(ctx context.Context, arg GetBorrowedItemHistoryByUserIdParams) ([]Borrowing, error)
+	GetBorrowingBlackout(ctx context.Context, id uuid.UUID) (BorrowingBlackout, error)
 	GetBorrowingByID(ctx context.Context, id uuid.UUID) (Borrowing, error)
+	GetBorrowingExtensionByID(ctx context.Context, id uuid.UUID) (BorrowingExtension, error)
 	GetBorrowingImageByID(ctx context.Context, id uuid.UUID) (BorrowingImage, error)
+	GetBorrowingPolicy(ctx context.Context, id uuid.UUID) (BorrowingPolicy, error)
+	GetCalendarLink(ctx context.Context, userID uuid.UUID) (CalendarLink, error)
 	GetCartByUser(ctx context.Context, arg GetCartByUserParams) ([]GetCartByUserRow, error)
 	GetCartItemCount(ctx context.Context, arg GetCartItemCountParams) (GetCartItemCountRow, error)
 	GetCartItemsForCheckout(ctx context.Context, arg GetCartItemsForCheckoutParams) ([]GetCartItemsForCheckoutRow, error)
-	GetExpiredBookings(ctx context.Context) ([]uuid.UUID, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (Category, error)
+	GetDamageReportByID(ctx context.Context, id uuid.UUID) (DamageReport, error)
+	GetDeletionRequestByID(ctx context.Context, id uuid.UUID) (DeletionRequest, error)
+	GetDeletionRequestForUpdate(ctx context.Context, id uuid.UUID) (DeletionRequest, error)
+	GetEmailSuppression(ctx context.Context, email string) (EmailSuppression, error)
+	// Pending bookings past their group's confirmation window, or the default one
+	GetExpiredBookings(ctx context.Context, defaultWindowHours int32) ([]uuid.UUID, error)
+	GetFairnessPolicy(ctx context.Context, itemID uuid.UUID) (ItemFairnessPolicy, error)
+	GetFineByID(ctx context.Context, id uuid.UUID) (Fine, error)
+	GetGlobalPermissionHolderIDs(ctx context.Context, permissionName string) ([]*uuid.UUID, error)
+	// Per group: requests, bookings, borrowings and takings in the window, and
+	// how many members borrowed or took anything
+	GetGroupActivity(ctx context.Context, arg GetGroupActivityParams) ([]GetGroupActivityRow, error)
+	GetGroupAdminIDs(ctx context.Context, scopeID *uuid.UUID) ([]*uuid.UUID, error)
+	// Users who approve requests within the group's scope
+	GetGroupApproverIDs(ctx context.Context, scopeID *uuid.UUID) ([]*uuid.UUID, error)
+	GetGroupBookingPolicy(ctx context.Context, groupID uuid.UUID) (GroupBookingPolicy, error)
 	GetGroupByID(ctx context.Context, id uuid.UUID) (Group, error)
+	// also finds groups in the recycle bin; only for admin, trash and purge paths
+	GetGroupByIDIncludingBinned(ctx context.Context, id uuid.UUID) (Group, error)
 	GetGroupByName(ctx context.Context, name string) (Group, error)
+	GetGroupJoinRequestByID(ctx context.Context, id uuid.UUID) (GroupJoinRequest, error)
+	GetGroupRequestSLA(ctx context.Context, groupID uuid.UUID) (GroupRequestSla, error)
+	// Catalogue-wide counts for the weekly digest; since is the start of the
+	// week it covers
+	GetInventoryDigest(ctx context.Context, since pgtype.Timestamp) (GetInventoryDigestRow, error)
 	GetItemByID(ctx context.Context, id uuid.UUID) (Item, error)
 	GetItemByIDForUpdate(ctx context.Context, id uuid.UUID) (Item, error)
+	// also finds items in the recycle bin or archived; only for admin, trash,
+	// history and purge paths
+	GetItemByIDIncludingBinned(ctx context.Context, id uuid.UUID) (Item, error)
 	GetItemByName(ctx context.Context, name string) (Item, error)
+	GetItemFees(ctx context.Context, itemID uuid.UUID) (ItemFee, error)
 	GetItemImageByID(ctx context.Context, id uuid.UUID) (ItemImage, error)
+	GetItemImageByOriginalKey(ctx context.Context, originalS3Key string) (ItemImage, error)
+	GetItemMaintenanceByID(ctx context.Context, id uuid.UUID) (ItemMaintenance, error)
+	// the items with any of the names, for resolving many names in one round trip
+	GetItemsByNames(ctx context.Context, names []string) ([]Item, error)
 	GetItemsByType(ctx context.Context, arg GetItemsByTypeParams) ([]Item, error)
+	GetItemUnitByID(ctx context.Context, id uuid.UUID) (ItemUnit, error)
+	// A unit by the asset tag or serial number on it, asset tags first
+	GetItemUnitByTag(ctx context.Context, code string) (ItemUnit, error)
+	GetItemUnitForUpdate(ctx context.Context, id uuid.UUID) (ItemUnit, error)
+	// Per borrowable item and week or month from from_date to to_date
+	// (inclusive): borrowings started, and days on loan summed over every unit.
+	// The first and last periods are clipped to the window; loans still out count
+	// up to now.
+	GetItemUtilization(ctx context.Context, arg GetItemUtilizationParams) ([]GetItemUtilizationRow, error)
+	GetLatestStockMovement(ctx context.Context, itemID uuid.UUID) (StockMovement, error)
 	GetNotificationEntityTypeByName(ctx context.Context, name string) (NotificationEntityType, error)
+	GetOpenDeletionRequest(ctx context.Context, arg GetOpenDeletionRequestParams) (DeletionRequest, error)
+	// Per group, borrowings due in the window: how many came back on time or
+	// late, how many are still out past their due date, and the days late summed
+	// over the late ones. Borrowings made outside any group come last, with no
+	// group.
+	GetOverdueSummary(ctx context.Context, arg GetOverdueSummaryParams) ([]GetOverdueSummaryRow, error)
 	GetPendingRequests(ctx context.Context, arg GetPendingRequestsParams) ([]Request, error)
+	GetReportByID(ctx context.Context, id uuid.UUID) (Report, error)
+	GetRequestApproverIDs(ctx context.Context) ([]*uuid.UUID, error)
 	GetRequestByBookingID(ctx context.Context, bookingID *uuid.UUID) (Request, error)
 	GetRequestById(ctx context.Context, id uuid.UUID) (Request, error)
 	GetRequestByIdForUpdate(ctx context.Context, id uuid.UUID) (Request, error)
-	GetRequestsByUserId(ctx context.Context, userID *uuid.UUID) ([]Request, error)
+	GetRequestComment(ctx context.Context, id uuid.UUID) (RequestComment, error)
+	// Per group with an SLA: requests made in the window, how many were reviewed
+	// within the SLA, how many breached it (reviewed late or still pending past
+	// it) and the average hours to review
+	GetRequestSLACompliance(ctx context.Context, arg GetRequestSLAComplianceParams) ([]GetRequestSLAComplianceRow, error)
+	GetRequestsByUserId(ctx context.Context, arg GetRequestsByUserIdParams) ([]Request, error)
+	GetReturnCampaignByID(ctx context.Context, id uuid.UUID) (ReturnCampaign, error)
 	GetReturnedItemsByUserId(ctx context.Context, arg GetReturnedItemsByUserIdParams) ([]Borrowing, error)
+	GetRole(ctx context.Context, name string) (Role, error)
+	GetStocktakeByID(ctx context.Context, id uuid.UUID) (Stocktake, error)
+	GetStocktakeForUpdate(ctx context.Context, id uuid.UUID) (Stocktake, error)
 	GetTakingHistoryByItemId(ctx context.Context, arg GetTakingHistoryByItemIdParams) ([]GetTakingHistoryByItemIdRow, error)
 	GetTakingHistoryByUserId(ctx context.Context, arg GetTakingHistoryByUserIdParams) ([]GetTakingHistoryByUserIdRow, error)
 	GetTakingHistoryByUserIdWithGroupFilter(ctx context.Context, arg GetTakingHistoryByUserIdWithGroupFilterParams) ([]GetTakingHistoryByUserIdWithGroupFilterRow, error)
 	GetTakingStats(ctx context.Context, arg GetTakingStatsParams) (GetTakingStatsRow, error)
 	GetTimeSlotByID(ctx context.Context, id uuid.UUID) (TimeSlot, error)
 	GetTimeSlotByStartTime(ctx context.Context, startTime pgtype.Time) (TimeSlot, error)
+	// Items ranked by how often they were borrowed or taken in the window
+	GetTopItems(ctx context.Context, arg GetTopItemsParams) ([]GetTopItemsRow, error)
+	GetUnpaidFineTotal(ctx context.Context, userID uuid.UUID) (int64, error)
 	// Get a specific user's availability schedule
 	GetUserAvailability(ctx context.Context, arg GetUserAvailabilityParams) ([]GetUserAvailabilityRow, error)
 	GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (GetUserByIDRow, error)
 	GetUserGroupsByUserId(ctx context.Context, userID *uuid.UUID) ([]*uuid.UUID, error)
+	GetUserIDByCalendarFeedToken(ctx context.Context, tokenHash string) (uuid.UUID, error)
+	GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error)
+	GetUserMFA(ctx context.Context, userID uuid.UUID) (UserMfa, error)
+	GetUserNotification(ctx context.Context, arg GetUserNotificationParams) (GetUserNotificationRow, error)
 	GetUserNotifications(ctx context.Context, arg GetUserNotificationsParams) ([]GetUserNotificationsRow, error)
 	GetUserPermissions(ctx context.Context, userID *uuid.UUID) ([]GetUserPermissionsRow, error)
 	GetUserPreferences(ctx context.Context, id uuid.UUID) ([]byte, error)
 	GetUserRoles(ctx context.Context, userID *uuid.UUID) ([]GetUserRolesRow, error)
+	GetUserStudentIDHash(ctx context.Context, id uuid.UUID) (pgtype.Text, error)
 	GetUsersByGroup(ctx context.Context, scopeID *uuid.UUID) ([]GetUsersByGroupRow, error)
 	GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]GetUsersByIDsRow, error)
-	GetUsersByIDsEmailOptIn(ctx context.Context, ids []uuid.UUID) ([]GetUsersByIDsEmailOptInRow, error)
+	// users who take email, and with a notification_type, haven't turned that
+	// type off
+	GetUsersByIDsEmailOptIn(ctx context.Context, arg GetUsersByIDsEmailOptInParams) ([]GetUsersByIDsEmailOptInRow, error)
+	GetWaitingEntry(ctx context.Context, arg GetWaitingEntryParams) (ItemWaitlist, error)
+	GetWebhookByID(ctx context.Context, id uuid.UUID) (Webhook, error)
+	// A delivery with the endpoint and secret it is sent with
+	GetWebhookDeliveryForSend(ctx context.Context, id uuid.UUID) (GetWebhookDeliveryForSendRow, error)
+	HasVerifiedBooking(ctx context.Context, bookingID uuid.UUID) (bool, error)
 	IncrementItemStock(ctx context.Context, arg IncrementItemStockParams) error
+	IsGroupSandbox(ctx context.Context, id uuid.UUID) (bool, error)
+	IsItemSharedWithGroup(ctx context.Context, arg IsItemSharedWithGroupParams) (bool, error)
+	// whether a user may see an item: unowned items are open to all
+	IsItemVisibleToUser(ctx context.Context, arg IsItemVisibleToUserParams) (bool, error)
+	IsMFAEnrolled(ctx context.Context, userID uuid.UUID) (bool, error)
+	IsRequestRoutedTo(ctx context.Context, arg IsRequestRoutedToParams) (bool, error)
 	IsUserMemberOfGroup(ctx context.Context, arg IsUserMemberOfGroupParams) (bool, error)
+	// Items with units are tracked by unit, retired ones included
+	ItemHasUnits(ctx context.Context, itemID uuid.UUID) (bool, error)
+	JoinItemWaitlist(ctx context.Context, arg JoinItemWaitlistParams) (ItemWaitlist, error)
+	LeaveItemWaitlist(ctx context.Context, arg LeaveItemWaitlistParams) (int64, error)
+	ListActiveApprovalDelegations(ctx context.Context) ([]ApprovalDelegation, error)
+	ListActiveReturnCampaigns(ctx context.Context) ([]ReturnCampaign, error)
+	ListAllBookingEvents(ctx context.Context) ([]BookingEvent, error)
+	// the whole inventory, for exports
+	ListAllItems(ctx context.Context) ([]Item, error)
+	ListAPIKeys(ctx context.Context) ([]ApiKey, error)
+	// The delegator's current and upcoming delegations
+	ListApprovalDelegations(ctx context.Context, delegatorID uuid.UUID) ([]ApprovalDelegation, error)
+	// users who opted in to the approver digest
+	ListApproverDigestRecipients(ctx context.Context) ([]uuid.UUID, error)
+	// Newest first, optionally narrowed to an actor, an action, an entity and a
+	// time range
+	ListAuditLog(ctx context.Context, arg ListAuditLogParams) ([]AuditLog, error)
 	ListAvailability(ctx context.Context, arg ListAvailabilityParams) ([]ListAvailabilityRow, error)
+	ListBookingEvents(ctx context.Context, bookingID uuid.UUID) ([]BookingEvent, error)
+	ListBookingStatuses(ctx context.Context) ([]ListBookingStatusesRow, error)
 	ListBookings(ctx context.Context, arg ListBookingsParams) ([]ListBookingsRow, error)
 	ListBookingsByUser(ctx context.Context, arg ListBookingsByUserParams) ([]ListBookingsByUserRow, error)
+	ListBorrowingBlackouts(ctx context.Context) ([]BorrowingBlackout, error)
+	// blackouts not yet over that cover items of the given type
+	ListBorrowingBlackoutsForItemType(ctx context.Context, itemType ItemType) ([]BorrowingBlackout, error)
+	// Oldest first, so approvers work through the queue in order
+	ListBorrowingExtensions(ctx context.Context, arg ListBorrowingExtensionsParams) ([]BorrowingExtension, error)
 	ListBorrowingImagesByBorrowing(ctx context.Context, borrowingID uuid.UUID) ([]BorrowingImage, error)
+	ListBorrowingPolicies(ctx context.Context) ([]BorrowingPolicy, error)
+	// policies that apply to a user borrowing an item of the given type
+	ListBorrowingPoliciesForUser(ctx context.Context, arg ListBorrowingPoliciesForUserParams) ([]BorrowingPolicy, error)
+	// Unreturned borrowings due before the cutoff, with what the reminder needs
+	ListBorrowingsDueBy(ctx context.Context, cutoff pgtype.Timestamp) ([]ListBorrowingsDueByRow, error)
+	// Bookings a user requested or manages that are still worth showing in their
+	// calendar: not cancelled, and returned at most 30 days ago
+	ListCalendarBookings(ctx context.Context, userID uuid.UUID) ([]ListCalendarBookingsRow, error)
+	ListCalendarLinks(ctx context.Context) ([]CalendarLink, error)
+	// Active borrowings due on or before the campaign's term end
+	ListCampaignBorrowings(ctx context.Context, termEnd pgtype.Date) ([]ListCampaignBorrowingsRow, error)
+	ListCategories(ctx context.Context) ([]Category, error)
+	ListCategoriesForItems(ctx context.Context, itemIds []uuid.UUID) ([]ListCategoriesForItemsRow, error)
+	// Newest first, optionally narrowed to a status and a group
+	ListDamageReports(ctx context.Context, arg ListDamageReportsParams) ([]DamageReport, error)
+	ListDeletionRequests(ctx context.Context, arg ListDeletionRequestsParams) ([]DeletionRequest, error)
+	// unreturned overdue borrowings in groups whose data the user can see, longest
+	// overdue first
+	ListDigestOverdueBorrowings(ctx context.Context, userID uuid.UUID) ([]ListDigestOverdueBorrowingsRow, error)
+	// bookings waiting on their requester's confirmation in groups whose bookings
+	// the user manages, soonest pickup first
+	ListDigestPendingConfirmations(ctx context.Context, userID uuid.UUID) ([]ListDigestPendingConfirmationsRow, error)
+	// pending requests routed to the user, oldest first
+	ListDigestPendingRequests(ctx context.Context, approverID uuid.UUID) ([]ListDigestPendingRequestsRow, error)
+	ListEmailSuppressions(ctx context.Context) ([]EmailSuppression, error)
+	ListEnabledRoutingRules(ctx context.Context, template string) ([]NotificationRoutingRule, error)
+	// binned requests past their retention window, for the purge sweep
+	ListExpiredDeletionRequests(ctx context.Context) ([]DeletionRequest, error)
+	// Newest first, optionally narrowed to a status and a user
+	ListFines(ctx context.Context, arg ListFinesParams) ([]Fine, error)
+	ListFinesByBorrowing(ctx context.Context, borrowingID uuid.UUID) ([]Fine, error)
+	ListGroupBookingPolicies(ctx context.Context) ([]GroupBookingPolicy, error)
+	// Oldest first, so admins work through the queue in order
+	ListGroupJoinRequests(ctx context.Context, arg ListGroupJoinRequestsParams) ([]GroupJoinRequest, error)
+	ListGroupRequestSLAs(ctx context.Context) ([]GroupRequestSla, error)
 	ListItemImagesByItem(ctx context.Context, itemID uuid.UUID) ([]ItemImage, error)
+	// An item's maintenance history, newest first
+	ListItemMaintenance(ctx context.Context, arg ListItemMaintenanceParams) ([]ItemMaintenance, error)
+	ListItemShares(ctx context.Context, itemID uuid.UUID) ([]uuid.UUID, error)
+	ListItemUnits(ctx context.Context, itemID uuid.UUID) ([]ItemUnit, error)
+	// Entries still waiting, first in line first
+	ListItemWaitlist(ctx context.Context, itemID uuid.UUID) ([]ItemWaitlist, error)
+	ListLowStockItems(ctx context.Context, threshold int32) ([]ListLowStockItemsRow, error)
+	ListNotificationPreferences(ctx context.Context, userID uuid.UUID) ([]NotificationPreference, error)
+	// Users sent an in-app notification about an entity
+	ListNotifiedUserIDs(ctx context.Context, arg ListNotifiedUserIDsParams) ([]uuid.UUID, error)
+	// Upcoming availability in a date range that no active booking has claimed
+	ListOpenAvailability(ctx context.Context, arg ListOpenAvailabilityParams) ([]ListOpenAvailabilityRow, error)
+	ListOverdueBorrowings(ctx context.Context, arg ListOverdueBorrowingsParams) ([]ListOverdueBorrowingsRow, error)
 	ListPendingConfirmation(ctx context.Context, groupID *uuid.UUID) ([]ListPendingConfirmationRow, error)
+	// Every pending request for an item with when the requesting group last borrowed it
+	ListPendingRequestCandidates(ctx context.Context, itemID *uuid.UUID) ([]ListPendingRequestCandidatesRow, error)
+	ListPermissions(ctx context.Context) ([]Permission, error)
+	ListPermissionsForRole(ctx context.Context, roleName string) ([]string, error)
+	ListPrimaryItemImagesForItems(ctx context.Context, itemIds []uuid.UUID) ([]ItemImage, error)
+	// every S3 key a row still points at; objects under the swept prefixes that
+	// are missing from this list are orphans
+	ListReferencedObjectKeys(ctx context.Context) ([]string, error)
+	ListReportsByUser(ctx context.Context, arg ListReportsByUserParams) ([]Report, error)
+	ListRequestCommenterIDs(ctx context.Context, requestID uuid.UUID) ([]*uuid.UUID, error)
+	ListRequestComments(ctx context.Context, requestID uuid.UUID) ([]ListRequestCommentsRow, error)
+	ListRequestRoutes(ctx context.Context, requestID uuid.UUID) ([]RequestRoute, error)
+	// Pending requests still with the owning group's approvers after waiting past
+	// their group's review SLA, or default_hours for groups without one (0 leaves
+	// those with the owning group)
+	ListRequestsDueForEscalation(ctx context.Context, defaultHours int32) ([]ListRequestsDueForEscalationRow, error)
+	ListReturnCampaigns(ctx context.Context, arg ListReturnCampaignsParams) ([]ReturnCampaign, error)
+	ListRolePermissions(ctx context.Context) ([]RolePermission, error)
+	ListRoles(ctx context.Context) ([]Role, error)
+	ListRoutedPendingRequests(ctx context.Context, arg ListRoutedPendingRequestsParams) ([]Request, error)
+	ListRoutingRules(ctx context.Context) ([]NotificationRoutingRule, error)
+	// An item's stock ledger, newest first
+	ListStockMovements(ctx context.Context, arg ListStockMovementsParams) ([]StockMovement, error)
+	ListStocktakeCounts(ctx context.Context, stocktakeID uuid.UUID) ([]ListStocktakeCountsRow, error)
+	ListStocktakes(ctx context.Context, arg ListStocktakesParams) ([]Stocktake, error)
+	ListTagsForItems(ctx context.Context, itemIds []uuid.UUID) ([]ListTagsForItemsRow, error)
 	ListTimeSlots(ctx context.Context) ([]TimeSlot, error)
+	// binned groups/items and cancelled bookings, newest first. Only bookings that
+	// were confirmed and have not reached pickup yet can be put back.
+	ListTrash(ctx context.Context, arg ListTrashParams) ([]ListTrashRow, error)
+	// Pending requests past their group's review SLA that haven't been alerted yet
+	ListUnalertedSLABreaches(ctx context.Context) ([]ListUnalertedSLABreachesRow, error)
+	ListUnreadUserNotifications(ctx context.Context, arg ListUnreadUserNotificationsParams) ([]ListUnreadUserNotificationsRow, error)
+	// The user's live bookings still to be picked up, soonest first
+	ListUpcomingBookingsByUser(ctx context.Context, arg ListUpcomingBookingsByUserParams) ([]ListUpcomingBookingsByUserRow, error)
+	// every user with each of their roles, one row per role; users without a
+	// role get one row with the role columns NULL
+	ListUsersWithRoles(ctx context.Context) ([]ListUsersWithRolesRow, error)
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	// Webhooks subscribed to an event type
+	ListWebhooksForEvent(ctx context.Context, eventType string) ([]Webhook, error)
+	// the item's version, locking the row until the transaction ends so an
+	// If-Match check still holds when the update that follows it runs
+	LockItemVersion(ctx context.Context, id uuid.UUID) (int32, error)
 	MarkAllNotificationsAsRead(ctx context.Context, notifierID uuid.UUID) error
 	MarkNotificationAsRead(ctx context.Context, arg MarkNotificationAsReadParams) (Notification, error)
+	// Returns the borrowings that newly became overdue
+	MarkOverdueBorrowings(ctx context.Context) ([]MarkOverdueBorrowingsRow, error)
+	MarkReportFailed(ctx context.Context, arg MarkReportFailedParams) error
+	MarkReportReady(ctx context.Context, arg MarkReportReadyParams) (Report, error)
 	MarkRequestAsFulfilled(ctx context.Context, id uuid.UUID) error
+	// Returns 0 when the entry was already notified or has left
+	MarkWaitlistNotified(ctx context.Context, id uuid.UUID) (int64, error)
+	OpenStocktake(ctx context.Context, arg OpenStocktakeParams) (Stocktake, error)
 	PatchItem(ctx context.Context, arg PatchItemParams) (Item, error)
+	PurgeGroupBookings(ctx context.Context, groupID *uuid.UUID) (int64, error)
+	PurgeGroupBorrowings(ctx context.Context, groupID *uuid.UUID) (int64, error)
+	PurgeGroupCarts(ctx context.Context, groupID uuid.UUID) (int64, error)
+	PurgeGroupItemTakings(ctx context.Context, groupID uuid.UUID) (int64, error)
+	PurgeGroupRequests(ctx context.Context, groupID *uuid.UUID) (int64, error)
+	RecordBookingHandoffReturn(ctx context.Context, arg RecordBookingHandoffReturnParams) (BookingHandoff, error)
+	RecordBookingVerification(ctx context.Context, arg RecordBookingVerificationParams) (BookingVerification, error)
+	// Returns 0 when this reminder was already sent
+	RecordBorrowingReminder(ctx context.Context, arg RecordBorrowingReminderParams) (int64, error)
+	RecordCalendarSync(ctx context.Context, arg RecordCalendarSyncParams) error
+	// Returns 0 when this stage was already sent for the borrowing
+	RecordCampaignNotice(ctx context.Context, arg RecordCampaignNoticeParams) (int64, error)
 	RecordItemTaking(ctx context.Context, arg RecordItemTakingParams) (ItemTaking, error)
+	RecordReturnCampaignRun(ctx context.Context, id uuid.UUID) error
+	// Returns 0 when the breach was already alerted
+	RecordSLAAlert(ctx context.Context, requestID uuid.UUID) (int64, error)
+	// A recount replaces the item's earlier count
+	RecordStocktakeCount(ctx context.Context, arg RecordStocktakeCountParams) (StocktakeCount, error)
+	RecordStudentIDChange(ctx context.Context, arg RecordStudentIDChangeParams) error
+	RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) error
 	RemoveFromCart(ctx context.Context, arg RemoveFromCartParams) error
+	// An approved request whose booking lapsed goes back to pending for a fresh review
+	ReopenRequestForBooking(ctx context.Context, bookingID *uuid.UUID) (Request, error)
+	ReplaceMFABackupCodes(ctx context.Context, arg ReplaceMFABackupCodesParams) error
 	// this function creates a new request in the requests table for a user requesting an item
 	RequestItem(ctx context.Context, arg RequestItemParams) (RequestItemRow, error)
+	// moves a booking to another availability slot; the status is left alone
+	RescheduleBooking(ctx context.Context, arg RescheduleBookingParams) (Booking, error)
+	// Forgets a borrowing's reminders and overdue mark once its due date moves,
+	// so both start over from the new date
+	ResetBorrowingOverdue(ctx context.Context, borrowingID uuid.UUID) error
+	ResolveDeletionRequest(ctx context.Context, arg ResolveDeletionRequestParams) (DeletionRequest, error)
+	// Marks an unpaid fine paid or waived; no rows when it was already resolved
+	ResolveFine(ctx context.Context, arg ResolveFineParams) (Fine, error)
+	RestoreCancelledBooking(ctx context.Context, id uuid.UUID) (Booking, error)
+	// brings an archived item back into the catalogue
+	RestoreItem(ctx context.Context, id uuid.UUID) (Item, error)
+	// put stock held by unreturned borrowings back before they are purged
+	RestoreSandboxBorrowedStock(ctx context.Context, groupID *uuid.UUID) error
+	// and the units those borrowings hold
+	RestoreSandboxBorrowedUnits(ctx context.Context, groupID *uuid.UUID) error
+	// put consumed stock back before item takings are purged
+	RestoreSandboxTakenStock(ctx context.Context, groupID uuid.UUID) error
+	// closes one specific borrowing, for returns checked in against a booking
+	ReturnBorrowingByID(ctx context.Context, arg ReturnBorrowingByIDParams) (Borrowing, error)
 	// this function records the return of a borrowed item, updating the after condition and return timestamp (basically closing the borrowing record)
 	// it only works if the item is currently borrowed (i.e., has no return timestamp yet)
 	// the request is identified by the item_id
 	ReturnItem(ctx context.Context, arg ReturnItemParams) (Borrowing, error)
+	// Puts a unit back on the shelf, recording the condition it came back in
+	ReturnItemUnit(ctx context.Context, arg ReturnItemUnitParams) error
 	// this function updates the status of a request (approve or deny) and records who reviewed it and when
 	ReviewRequest(ctx context.Context, arg ReviewRequestParams) (ReviewRequestRow, error)
-	// if query null then alphabetical, else sort by rank
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (int64, error)
+	// Hands the delegator's pending requests to the delegate
+	RouteDelegatorPendingRequests(ctx context.Context, arg RouteDelegatorPendingRequestsParams) (int64, error)
 	SearchItems(ctx context.Context, arg SearchItemsParams) ([]SearchItemsRow, error)
+	// writes a borrowing with its whole history in one go; only the seeder uses it
+	SeedBorrowing(ctx context.Context, arg SeedBorrowingParams) (Borrowing, error)
+	SeedCancelBooking(ctx context.Context, arg SeedCancelBookingParams) (Booking, error)
+	// like ConfirmBooking and CancelBooking but with the time given; only the
+	// seeder uses these to write historical bookings
+	SeedConfirmBooking(ctx context.Context, arg SeedConfirmBookingParams) (Booking, error)
+	// writes a request with its whole history in one go; only the seeder uses it
+	SeedRequest(ctx context.Context, arg SeedRequestParams) (Request, error)
+	SetBorrowingImageVariants(ctx context.Context, arg SetBorrowingImageVariantsParams) error
+	SetGroupSandbox(ctx context.Context, arg SetGroupSandboxParams) (Group, error)
+	SetItemCategory(ctx context.Context, arg SetItemCategoryParams) error
 	SetItemImageAsPrimary(ctx context.Context, id uuid.UUID) error
+	SetItemOwner(ctx context.Context, arg SetItemOwnerParams) error
+	SetItemUnitStatus(ctx context.Context, arg SetItemUnitStatusParams) error
+	SetStocktakeCountMovement(ctx context.Context, arg SetStocktakeCountMovementParams) error
+	SetUserStudentIDHash(ctx context.Context, arg SetUserStudentIDHashParams) error
+	// Returns 0 when the user already has a student ID on file
+	SetUserStudentIDHashIfUnset(ctx context.Context, arg SetUserStudentIDHashIfUnsetParams) (int64, error)
+	SnapshotAvailability(ctx context.Context) ([]SnapshotAvailabilityRow, error)
+	SnapshotBookings(ctx context.Context) ([]SnapshotBookingsRow, error)
+	SnapshotBorrowings(ctx context.Context) ([]SnapshotBorrowingsRow, error)
+	SnapshotCartItems(ctx context.Context) ([]SnapshotCartItemsRow, error)
+	// the Snapshot queries read rows back in the shape of the seed YAML, with IDs
+	// resolved to the names and emails the seeder looks rows up by
+	SnapshotGroups(ctx context.Context) ([]SnapshotGroupsRow, error)
+	SnapshotItemTakings(ctx context.Context) ([]SnapshotItemTakingsRow, error)
+	SnapshotItems(ctx context.Context) ([]SnapshotItemsRow, error)
+	SnapshotRequests(ctx context.Context) ([]SnapshotRequestsRow, error)
+	SnapshotUserRoles(ctx context.Context) ([]SnapshotUserRolesRow, error)
+	SnapshotUsers(ctx context.Context) ([]string, error)
+	// a later notification replaces the reason, but the address stays suppressed
+	// from when it first was
+	SuppressEmail(ctx context.Context, arg SuppressEmailParams) error
+	// Describes the stock changes the rest of the transaction makes to the
+	// items_stock_ledger trigger, as JSON with kind, reason, actor_id and reference_id
+	TagStockMovement(ctx context.Context, movement string) error
+	// Writes at most once a minute per key, rather than on every request.
+	TouchAPIKey(ctx context.Context, id uuid.UUID) error
+	TouchUserIdentity(ctx context.Context, arg TouchUserIdentityParams) error
 	UnsetPrimaryItemImages(ctx context.Context, itemID uuid.UUID) error
+	UpdateBorrowingPolicy(ctx context.Context, arg UpdateBorrowingPolicyParams) (BorrowingPolicy, error)
 	UpdateCartItemQuantity(ctx context.Context, arg UpdateCartItemQuantityParams) (UpdateCartItemQuantityRow, error)
+	UpdateDamageReport(ctx context.Context, arg UpdateDamageReportParams) (DamageReport, error)
 	UpdateGroup(ctx context.Context, arg UpdateGroupParams) (Group, error)
 	UpdateGroupLogo(ctx context.Context, arg UpdateGroupLogoParams) (Group, error)
 	UpdateItem(ctx context.Context, arg UpdateItemParams) (Item, error)
+	UpdateItemUnit(ctx context.Context, arg UpdateItemUnitParams) (ItemUnit, error)
 	UpdateRequestWithBooking(ctx context.Context, arg UpdateRequestWithBookingParams) (Request, error)
 	UpdateUserPreferences(ctx context.Context, arg UpdateUserPreferencesParams) ([]byte, error)
+	UpsertCalendarFeedToken(ctx context.Context, arg UpsertCalendarFeedTokenParams) error
+	UpsertCalendarLink(ctx context.Context, arg UpsertCalendarLinkParams) (CalendarLink, error)
+	UpsertFairnessPolicy(ctx context.Context, arg UpsertFairnessPolicyParams) (ItemFairnessPolicy, error)
+	UpsertGroupBookingPolicy(ctx context.Context, arg UpsertGroupBookingPolicyParams) (GroupBookingPolicy, error)
+	UpsertGroupRequestSLA(ctx context.Context, arg UpsertGroupRequestSLAParams) (GroupRequestSla, error)
+	UpsertItemFees(ctx context.Context, arg UpsertItemFeesParams) (ItemFee, error)
+	UpsertNotificationPreference(ctx context.Context, arg UpsertNotificationPreferenceParams) error
+	// affects no row when the user already has confirmed two-factor authentication
+	UpsertPendingUserMFA(ctx context.Context, arg UpsertPendingUserMFAParams) (int64, error)
+	UpsertTag(ctx context.Context, name string) (Tag, error)
+	UseMFABackupCode(ctx context.Context, arg UseMFABackupCodeParams) (int64, error)
+	// affects no row when a code for this or a later step was already accepted
+	UseUserMFAStep(ctx context.Context, arg UseUserMFAStepParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
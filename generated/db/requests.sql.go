@@ -12,12 +12,57 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const cancelRequest = `-- name: CancelRequest :one
+UPDATE requests
+SET status = 'cancelled'
+WHERE id = $1 AND status = 'pending'
+RETURNING id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id, override_justification
+`
+
+// The requester retracting a request; no rows once it has been reviewed
+func (q *Queries) CancelRequest(ctx context.Context, id uuid.UUID) (Request, error) {
+	row := q.db.QueryRow(ctx, cancelRequest, id)
+	var i Request
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.GroupID,
+		&i.ItemID,
+		&i.Quantity,
+		&i.Status,
+		&i.RequestedAt,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.FulfilledAt,
+		&i.BookingID,
+		&i.PreferredAvailabilityID,
+		&i.OverrideJustification,
+	)
+	return i, err
+}
+
 const countAllRequests = `-- name: CountAllRequests :one
 SELECT COUNT(*) as count FROM requests
+WHERE ($1::request_status IS NULL OR status = $1)
+  AND ($2::UUID IS NULL OR group_id = $2)
+  AND ($3::DATE IS NULL OR requested_at::DATE >= $3)
+  AND ($4::DATE IS NULL OR requested_at::DATE <= $4)
 `
 
-func (q *Queries) CountAllRequests(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, countAllRequests)
+type CountAllRequestsParams struct {
+	Status   NullRequestStatus `json:"status"`
+	GroupID  *uuid.UUID        `json:"group_id"`
+	FromDate pgtype.Date       `json:"from_date"`
+	ToDate   pgtype.Date       `json:"to_date"`
+}
+
+func (q *Queries) CountAllRequests(ctx context.Context, arg CountAllRequestsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countAllRequests,
+		arg.Status,
+		arg.GroupID,
+		arg.FromDate,
+		arg.ToDate,
+	)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -34,18 +79,69 @@ func (q *Queries) CountPendingRequests(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const countRequestsByUserId = `-- name: CountRequestsByUserId :one
+SELECT COUNT(*) as count FROM requests
+WHERE user_id = $1
+  AND ($2::request_status IS NULL OR status = $2)
+  AND ($3::DATE IS NULL OR requested_at::DATE >= $3)
+  AND ($4::DATE IS NULL OR requested_at::DATE <= $4)
+`
+
+type CountRequestsByUserIdParams struct {
+	UserID   *uuid.UUID        `json:"user_id"`
+	Status   NullRequestStatus `json:"status"`
+	FromDate pgtype.Date       `json:"from_date"`
+	ToDate   pgtype.Date       `json:"to_date"`
+}
+
+func (q *Queries) CountRequestsByUserId(ctx context.Context, arg CountRequestsByUserIdParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countRequestsByUserId,
+		arg.UserID,
+		arg.Status,
+		arg.FromDate,
+		arg.ToDate,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const getAllRequests = `-- name: GetAllRequests :many
-SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id FROM requests
-ORDER BY requested_at DESC LIMIT $1 OFFSET $2
+SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id, override_justification FROM requests
+WHERE ($1::request_status IS NULL OR status = $1)
+  AND ($2::UUID IS NULL OR group_id = $2)
+  AND ($3::DATE IS NULL OR requested_at::DATE >= $3)
+  AND ($4::DATE IS NULL OR requested_at::DATE <= $4)
+ORDER BY
+  CASE WHEN $5::TEXT = 'quantity' AND NOT $6::BOOLEAN THEN quantity END ASC,
+  CASE WHEN $5::TEXT = 'quantity' AND $6::BOOLEAN THEN quantity END DESC,
+  CASE WHEN $5::TEXT = 'requested_at' AND NOT $6::BOOLEAN THEN requested_at END ASC,
+  requested_at DESC, id
+LIMIT $7 OFFSET $8
 `
 
 type GetAllRequestsParams struct {
-	Limit  int64 `json:"limit"`
-	Offset int64 `json:"offset"`
+	Status   NullRequestStatus `json:"status"`
+	GroupID  *uuid.UUID        `json:"group_id"`
+	FromDate pgtype.Date       `json:"from_date"`
+	ToDate   pgtype.Date       `json:"to_date"`
+	SortBy   string            `json:"sort_by"`
+	SortDesc bool              `json:"sort_desc"`
+	Limit    int64             `json:"limit"`
+	Offset   int64             `json:"offset"`
 }
 
 func (q *Queries) GetAllRequests(ctx context.Context, arg GetAllRequestsParams) ([]Request, error) {
-	rows, err := q.db.Query(ctx, getAllRequests, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getAllRequests,
+		arg.Status,
+		arg.GroupID,
+		arg.FromDate,
+		arg.ToDate,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -66,6 +162,7 @@ func (q *Queries) GetAllRequests(ctx context.Context, arg GetAllRequestsParams)
 			&i.FulfilledAt,
 			&i.BookingID,
 			&i.PreferredAvailabilityID,
+			&i.OverrideJustification,
 		); err != nil {
 			return nil, err
 		}
@@ -78,7 +175,7 @@ func (q *Queries) GetAllRequests(ctx context.Context, arg GetAllRequestsParams)
 }
 
 const getApprovedRequestForUserAndItem = `-- name: GetApprovedRequestForUserAndItem :one
-SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id FROM requests
+SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id, override_justification FROM requests
 WHERE user_id = $1
   AND item_id = $2
   AND status = 'approved'
@@ -108,12 +205,13 @@ func (q *Queries) GetApprovedRequestForUserAndItem(ctx context.Context, arg GetA
 		&i.FulfilledAt,
 		&i.BookingID,
 		&i.PreferredAvailabilityID,
+		&i.OverrideJustification,
 	)
 	return i, err
 }
 
 const getPendingRequests = `-- name: GetPendingRequests :many
-SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id FROM requests
+SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id, override_justification FROM requests
 WHERE status = 'pending'
 ORDER BY requested_at ASC LIMIT $1 OFFSET $2
 `
@@ -145,6 +243,7 @@ func (q *Queries) GetPendingRequests(ctx context.Context, arg GetPendingRequests
 			&i.FulfilledAt,
 			&i.BookingID,
 			&i.PreferredAvailabilityID,
+			&i.OverrideJustification,
 		); err != nil {
 			return nil, err
 		}
@@ -157,7 +256,7 @@ func (q *Queries) GetPendingRequests(ctx context.Context, arg GetPendingRequests
 }
 
 const getRequestByBookingID = `-- name: GetRequestByBookingID :one
-SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id FROM requests
+SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id, override_justification FROM requests
 WHERE booking_id = $1
 `
 
@@ -177,12 +276,13 @@ func (q *Queries) GetRequestByBookingID(ctx context.Context, bookingID *uuid.UUI
 		&i.FulfilledAt,
 		&i.BookingID,
 		&i.PreferredAvailabilityID,
+		&i.OverrideJustification,
 	)
 	return i, err
 }
 
 const getRequestById = `-- name: GetRequestById :one
-SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id FROM requests
+SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id, override_justification FROM requests
 WHERE id = $1
 `
 
@@ -202,12 +302,13 @@ func (q *Queries) GetRequestById(ctx context.Context, id uuid.UUID) (Request, er
 		&i.FulfilledAt,
 		&i.BookingID,
 		&i.PreferredAvailabilityID,
+		&i.OverrideJustification,
 	)
 	return i, err
 }
 
 const getRequestByIdForUpdate = `-- name: GetRequestByIdForUpdate :one
-SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id FROM requests
+SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id, override_justification FROM requests
 WHERE id = $1
 FOR UPDATE
 `
@@ -228,18 +329,47 @@ func (q *Queries) GetRequestByIdForUpdate(ctx context.Context, id uuid.UUID) (Re
 		&i.FulfilledAt,
 		&i.BookingID,
 		&i.PreferredAvailabilityID,
+		&i.OverrideJustification,
 	)
 	return i, err
 }
 
 const getRequestsByUserId = `-- name: GetRequestsByUserId :many
-SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id FROM requests
+SELECT id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id, override_justification FROM requests
 WHERE user_id = $1
-ORDER BY requested_at DESC
+  AND ($2::request_status IS NULL OR status = $2)
+  AND ($3::DATE IS NULL OR requested_at::DATE >= $3)
+  AND ($4::DATE IS NULL OR requested_at::DATE <= $4)
+ORDER BY
+  CASE WHEN $5::TEXT = 'quantity' AND NOT $6::BOOLEAN THEN quantity END ASC,
+  CASE WHEN $5::TEXT = 'quantity' AND $6::BOOLEAN THEN quantity END DESC,
+  CASE WHEN $5::TEXT = 'requested_at' AND NOT $6::BOOLEAN THEN requested_at END ASC,
+  requested_at DESC, id
+LIMIT $7 OFFSET $8
 `
 
-func (q *Queries) GetRequestsByUserId(ctx context.Context, userID *uuid.UUID) ([]Request, error) {
-	rows, err := q.db.Query(ctx, getRequestsByUserId, userID)
+type GetRequestsByUserIdParams struct {
+	UserID   *uuid.UUID        `json:"user_id"`
+	Status   NullRequestStatus `json:"status"`
+	FromDate pgtype.Date       `json:"from_date"`
+	ToDate   pgtype.Date       `json:"to_date"`
+	SortBy   string            `json:"sort_by"`
+	SortDesc bool              `json:"sort_desc"`
+	Limit    int64             `json:"limit"`
+	Offset   int64             `json:"offset"`
+}
+
+func (q *Queries) GetRequestsByUserId(ctx context.Context, arg GetRequestsByUserIdParams) ([]Request, error) {
+	rows, err := q.db.Query(ctx, getRequestsByUserId,
+		arg.UserID,
+		arg.Status,
+		arg.FromDate,
+		arg.ToDate,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -260,6 +390,7 @@ func (q *Queries) GetRequestsByUserId(ctx context.Context, userID *uuid.UUID) ([
 			&i.FulfilledAt,
 			&i.BookingID,
 			&i.PreferredAvailabilityID,
+			&i.OverrideJustification,
 		); err != nil {
 			return nil, err
 		}
@@ -282,6 +413,39 @@ func (q *Queries) MarkRequestAsFulfilled(ctx context.Context, id uuid.UUID) erro
 	return err
 }
 
+const reopenRequestForBooking = `-- name: ReopenRequestForBooking :one
+UPDATE requests
+SET status = 'pending',
+    booking_id = NULL,
+    reviewed_by = NULL,
+    reviewed_at = NULL,
+    override_justification = NULL
+WHERE booking_id = $1 AND status = 'approved'
+RETURNING id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id, override_justification
+`
+
+// An approved request whose booking lapsed goes back to pending for a fresh review
+func (q *Queries) ReopenRequestForBooking(ctx context.Context, bookingID *uuid.UUID) (Request, error) {
+	row := q.db.QueryRow(ctx, reopenRequestForBooking, bookingID)
+	var i Request
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.GroupID,
+		&i.ItemID,
+		&i.Quantity,
+		&i.Status,
+		&i.RequestedAt,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.FulfilledAt,
+		&i.BookingID,
+		&i.PreferredAvailabilityID,
+		&i.OverrideJustification,
+	)
+	return i, err
+}
+
 const requestItem = `-- name: RequestItem :one
 INSERT INTO requests (
     user_id, group_id, item_id, quantity, status
@@ -337,7 +501,8 @@ const reviewRequest = `-- name: ReviewRequest :one
 UPDATE requests r
 SET status = $2,
     reviewed_by = $3,
-    reviewed_at = NOW()
+    reviewed_at = NOW(),
+    override_justification = $4
 FROM items i
 WHERE r.id = $1
   AND r.status = 'pending'
@@ -347,29 +512,36 @@ WHERE r.id = $1
     OR ($2 = 'approved'::request_status AND i.stock >= r.quantity)
   )
 RETURNING r.id, r.user_id, r.group_id, r.item_id, r.quantity,
-    r.status, r.reviewed_at, r.reviewed_by
+    r.status, r.reviewed_at, r.reviewed_by, r.override_justification
 `
 
 type ReviewRequestParams struct {
-	ID         uuid.UUID         `json:"id"`
-	Status     NullRequestStatus `json:"status"`
-	ReviewedBy *uuid.UUID        `json:"reviewed_by"`
+	ID                    uuid.UUID         `json:"id"`
+	Status                NullRequestStatus `json:"status"`
+	ReviewedBy            *uuid.UUID        `json:"reviewed_by"`
+	OverrideJustification pgtype.Text       `json:"override_justification"`
 }
 
 type ReviewRequestRow struct {
-	ID         uuid.UUID         `json:"id"`
-	UserID     *uuid.UUID        `json:"user_id"`
-	GroupID    *uuid.UUID        `json:"group_id"`
-	ItemID     *uuid.UUID        `json:"item_id"`
-	Quantity   int32             `json:"quantity"`
-	Status     NullRequestStatus `json:"status"`
-	ReviewedAt pgtype.Timestamp  `json:"reviewed_at"`
-	ReviewedBy *uuid.UUID        `json:"reviewed_by"`
+	ID                    uuid.UUID         `json:"id"`
+	UserID                *uuid.UUID        `json:"user_id"`
+	GroupID               *uuid.UUID        `json:"group_id"`
+	ItemID                *uuid.UUID        `json:"item_id"`
+	Quantity              int32             `json:"quantity"`
+	Status                NullRequestStatus `json:"status"`
+	ReviewedAt            pgtype.Timestamp  `json:"reviewed_at"`
+	ReviewedBy            *uuid.UUID        `json:"reviewed_by"`
+	OverrideJustification pgtype.Text       `json:"override_justification"`
 }
 
 // this function updates the status of a request (approve or deny) and records who reviewed it and when
 func (q *Queries) ReviewRequest(ctx context.Context, arg ReviewRequestParams) (ReviewRequestRow, error) {
-	row := q.db.QueryRow(ctx, reviewRequest, arg.ID, arg.Status, arg.ReviewedBy)
+	row := q.db.QueryRow(ctx, reviewRequest,
+		arg.ID,
+		arg.Status,
+		arg.ReviewedBy,
+		arg.OverrideJustification,
+	)
 	var i ReviewRequestRow
 	err := row.Scan(
 		&i.ID,
@@ -380,6 +552,60 @@ func (q *Queries) ReviewRequest(ctx context.Context, arg ReviewRequestParams) (R
 		&i.Status,
 		&i.ReviewedAt,
 		&i.ReviewedBy,
+		&i.OverrideJustification,
+	)
+	return i, err
+}
+
+const seedRequest = `-- name: SeedRequest :one
+INSERT INTO requests (
+    user_id, group_id, item_id, quantity, status,
+    requested_at, reviewed_by, reviewed_at, fulfilled_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id, override_justification
+`
+
+type SeedRequestParams struct {
+	UserID      *uuid.UUID        `json:"user_id"`
+	GroupID     *uuid.UUID        `json:"group_id"`
+	ItemID      *uuid.UUID        `json:"item_id"`
+	Quantity    int32             `json:"quantity"`
+	Status      NullRequestStatus `json:"status"`
+	RequestedAt pgtype.Timestamp  `json:"requested_at"`
+	ReviewedBy  *uuid.UUID        `json:"reviewed_by"`
+	ReviewedAt  pgtype.Timestamp  `json:"reviewed_at"`
+	FulfilledAt pgtype.Timestamp  `json:"fulfilled_at"`
+}
+
+// writes a request with its whole history in one go; only the seeder uses it
+func (q *Queries) SeedRequest(ctx context.Context, arg SeedRequestParams) (Request, error) {
+	row := q.db.QueryRow(ctx, seedRequest,
+		arg.UserID,
+		arg.GroupID,
+		arg.ItemID,
+		arg.Quantity,
+		arg.Status,
+		arg.RequestedAt,
+		arg.ReviewedBy,
+		arg.ReviewedAt,
+		arg.FulfilledAt,
+	)
+	var i Request
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.GroupID,
+		&i.ItemID,
+		&i.Quantity,
+		&i.Status,
+		&i.RequestedAt,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.FulfilledAt,
+		&i.BookingID,
+		&i.PreferredAvailabilityID,
+		&i.OverrideJustification,
 	)
 	return i, err
 }
@@ -388,7 +614,7 @@ const updateRequestWithBooking = `-- name: UpdateRequestWithBooking :one
 UPDATE requests
 SET booking_id = $2
 WHERE id = $1
-RETURNING id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id
+RETURNING id, user_id, group_id, item_id, quantity, status, requested_at, reviewed_by, reviewed_at, fulfilled_at, booking_id, preferred_availability_id, override_justification
 `
 
 type UpdateRequestWithBookingParams struct {
@@ -412,6 +638,7 @@ func (q *Queries) UpdateRequestWithBooking(ctx context.Context, arg UpdateReques
 		&i.FulfilledAt,
 		&i.BookingID,
 		&i.PreferredAvailabilityID,
+		&i.OverrideJustification,
 	)
 	return i, err
 }
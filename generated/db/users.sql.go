@@ -12,6 +12,30 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const createImportedUser = `-- name: CreateImportedUser :one
+INSERT INTO users (email, name, program)
+VALUES ($1, $2, $3)
+RETURNING id, email
+`
+
+type CreateImportedUserParams struct {
+	Email   string      `json:"email"`
+	Name    pgtype.Text `json:"name"`
+	Program pgtype.Text `json:"program"`
+}
+
+type CreateImportedUserRow struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+}
+
+func (q *Queries) CreateImportedUser(ctx context.Context, arg CreateImportedUserParams) (CreateImportedUserRow, error) {
+	row := q.db.QueryRow(ctx, createImportedUser, arg.Email, arg.Name, arg.Program)
+	var i CreateImportedUserRow
+	err := row.Scan(&i.ID, &i.Email)
+	return i, err
+}
+
 const createSignUpCode = `-- name: CreateSignUpCode :one
 INSERT INTO signup_codes (id, code, email, role_name, scope, scope_id, created_at, used_at, expires_at, created_by)
 VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, NOW(), NULL, NOW() + INTERVAL '7 days', $6)
@@ -81,6 +105,32 @@ func (q *Queries) GetAllUsers(ctx context.Context) ([]GetAllUsersRow, error) {
 	return items, nil
 }
 
+const getGlobalPermissionHolderIDs = `-- name: GetGlobalPermissionHolderIDs :many
+SELECT DISTINCT ur.user_id FROM user_roles ur
+JOIN role_permissions rp ON rp.role_name = ur.role_name
+WHERE rp.permission_name = $1 AND ur.scope = 'global'
+`
+
+func (q *Queries) GetGlobalPermissionHolderIDs(ctx context.Context, permissionName string) ([]*uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, getGlobalPermissionHolderIDs, permissionName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []*uuid.UUID{}
+	for rows.Next() {
+		var user_id *uuid.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserGroupsByUserId = `-- name: GetUserGroupsByUserId :many
 SELECT scope_id
 FROM user_roles
@@ -118,6 +168,17 @@ func (q *Queries) GetUserPreferences(ctx context.Context, id uuid.UUID) ([]byte,
 	return preferences, err
 }
 
+const getUserStudentIDHash = `-- name: GetUserStudentIDHash :one
+SELECT student_id_hash FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUserStudentIDHash(ctx context.Context, id uuid.UUID) (pgtype.Text, error) {
+	row := q.db.QueryRow(ctx, getUserStudentIDHash, id)
+	var student_id_hash pgtype.Text
+	err := row.Scan(&student_id_hash)
+	return student_id_hash, err
+}
+
 const getUsersByGroup = `-- name: GetUsersByGroup :many
 SELECT u.id, u.email, ur.role_name, ur.scope, ur.scope_id
 FROM users u
@@ -189,18 +250,31 @@ func (q *Queries) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]GetUser
 }
 
 const getUsersByIDsEmailOptIn = `-- name: GetUsersByIDsEmailOptIn :many
-SELECT id, email FROM users
-WHERE id = ANY($1::uuid[])
-AND (preferences->>'email_notifications') IS DISTINCT FROM 'false'
+SELECT id, email FROM users u
+WHERE u.id = ANY($1::uuid[])
+AND (u.preferences->>'email_notifications') IS DISTINCT FROM 'false'
+AND ($2::TEXT IS NULL OR NOT EXISTS (
+    SELECT 1 FROM notification_preferences np
+    WHERE np.user_id = u.id
+      AND np.notification_type = $2
+      AND NOT np.email_enabled
+))
 `
 
+type GetUsersByIDsEmailOptInParams struct {
+	Ids              []uuid.UUID `json:"ids"`
+	NotificationType pgtype.Text `json:"notification_type"`
+}
+
 type GetUsersByIDsEmailOptInRow struct {
 	ID    uuid.UUID `json:"id"`
 	Email string    `json:"email"`
 }
 
-func (q *Queries) GetUsersByIDsEmailOptIn(ctx context.Context, ids []uuid.UUID) ([]GetUsersByIDsEmailOptInRow, error) {
-	rows, err := q.db.Query(ctx, getUsersByIDsEmailOptIn, ids)
+// users who take email, and with a notification_type, haven't turned that
+// type off
+func (q *Queries) GetUsersByIDsEmailOptIn(ctx context.Context, arg GetUsersByIDsEmailOptInParams) ([]GetUsersByIDsEmailOptInRow, error) {
+	rows, err := q.db.Query(ctx, getUsersByIDsEmailOptIn, arg.Ids, arg.NotificationType)
 	if err != nil {
 		return nil, err
 	}
@@ -240,6 +314,100 @@ func (q *Queries) IsUserMemberOfGroup(ctx context.Context, arg IsUserMemberOfGro
 	return is_member, err
 }
 
+const listUsersWithRoles = `-- name: ListUsersWithRoles :many
+SELECT u.id, u.email, u.name, ur.role_name, ur.scope, g.name AS group_name
+FROM users u
+LEFT JOIN user_roles ur ON ur.user_id = u.id
+LEFT JOIN groups g ON g.id = ur.scope_id
+ORDER BY u.email, ur.role_name, g.name
+`
+
+type ListUsersWithRolesRow struct {
+	ID        uuid.UUID     `json:"id"`
+	Email     string        `json:"email"`
+	Name      pgtype.Text   `json:"name"`
+	RoleName  pgtype.Text   `json:"role_name"`
+	Scope     NullScopeType `json:"scope"`
+	GroupName pgtype.Text   `json:"group_name"`
+}
+
+// every user with each of their roles, one row per role; users without a
+// role get one row with the role columns NULL
+func (q *Queries) ListUsersWithRoles(ctx context.Context) ([]ListUsersWithRolesRow, error) {
+	rows, err := q.db.Query(ctx, listUsersWithRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUsersWithRolesRow{}
+	for rows.Next() {
+		var i ListUsersWithRolesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Name,
+			&i.RoleName,
+			&i.Scope,
+			&i.GroupName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordStudentIDChange = `-- name: RecordStudentIDChange :exec
+INSERT INTO student_id_changes (user_id, changed_by, replaced)
+VALUES ($1, $2, $3)
+`
+
+type RecordStudentIDChangeParams struct {
+	UserID    uuid.UUID  `json:"user_id"`
+	ChangedBy *uuid.UUID `json:"changed_by"`
+	Replaced  bool       `json:"replaced"`
+}
+
+func (q *Queries) RecordStudentIDChange(ctx context.Context, arg RecordStudentIDChangeParams) error {
+	_, err := q.db.Exec(ctx, recordStudentIDChange, arg.UserID, arg.ChangedBy, arg.Replaced)
+	return err
+}
+
+const setUserStudentIDHash = `-- name: SetUserStudentIDHash :exec
+UPDATE users SET student_id_hash = $2 WHERE id = $1
+`
+
+type SetUserStudentIDHashParams struct {
+	ID            uuid.UUID   `json:"id"`
+	StudentIDHash pgtype.Text `json:"student_id_hash"`
+}
+
+func (q *Queries) SetUserStudentIDHash(ctx context.Context, arg SetUserStudentIDHashParams) error {
+	_, err := q.db.Exec(ctx, setUserStudentIDHash, arg.ID, arg.StudentIDHash)
+	return err
+}
+
+const setUserStudentIDHashIfUnset = `-- name: SetUserStudentIDHashIfUnset :execrows
+UPDATE users SET student_id_hash = $2 WHERE id = $1 AND student_id_hash IS NULL
+`
+
+type SetUserStudentIDHashIfUnsetParams struct {
+	ID            uuid.UUID   `json:"id"`
+	StudentIDHash pgtype.Text `json:"student_id_hash"`
+}
+
+// Returns 0 when the user already has a student ID on file
+func (q *Queries) SetUserStudentIDHashIfUnset(ctx context.Context, arg SetUserStudentIDHashIfUnsetParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setUserStudentIDHashIfUnset, arg.ID, arg.StudentIDHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateUserPreferences = `-- name: UpdateUserPreferences :one
 UPDATE users SET preferences = $1 WHERE id = $2 RETURNING preferences
 `
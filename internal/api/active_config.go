@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+)
+
+func (s Server) GetActiveConfig(ctx context.Context, request api.GetActiveConfigRequestObject) (api.GetActiveConfigResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetActiveConfig401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageWorkers, nil)
+	if err != nil {
+		logger.Error("Error checking manage_workers permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageWorkers,
+			"error", err)
+		return api.GetActiveConfig500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetActiveConfig403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	return api.GetActiveConfig200JSONResponse(s.activeConfig.Current().Redacted()), nil
+}
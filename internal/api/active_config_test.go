@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_GetActiveConfig(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("secrets are redacted", func(t *testing.T) {
+		admin := testDB.NewUser(t).WithEmail("admin@config.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageWorkers, nil, true, nil)
+		response, err := server.GetActiveConfig(ctx, api.GetActiveConfigRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetActiveConfig200JSONResponse{}, response)
+
+		jwt := response.(api.GetActiveConfig200JSONResponse)["jwt"].(map[string]any)
+		assert.Equal(t, "[redacted]", jwt["signing_key"])
+	})
+
+	t.Run("insufficient permissions", func(t *testing.T) {
+		member := testDB.NewUser(t).WithEmail("member@config.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageWorkers, nil, false, nil)
+		response, err := server.GetActiveConfig(ctx, api.GetActiveConfigRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetActiveConfig403JSONResponse{}, response)
+	})
+}
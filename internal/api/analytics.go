@@ -0,0 +1,321 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/jackc/pgx/v5/pgtype"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+const (
+	// weeks covered by a utilization report when from_date is left open
+	defaultUtilizationWeeks = 12
+	// longest utilization window, which bounds the number of periods per item
+	maxUtilizationYears = 2
+
+	defaultTopItemsLimit = 10
+	maxTopItemsLimit     = 100
+)
+
+// the inclusive window between from_date and to_date, either of which may be
+// open; false when it ends before it starts
+func reportWindow(from, to *openapi_types.Date) (pgtype.Date, pgtype.Date, bool) {
+	var fromDate, toDate pgtype.Date
+	if from != nil {
+		fromDate = pgtype.Date{Time: from.Time, Valid: true}
+	}
+	if to != nil {
+		toDate = pgtype.Date{Time: to.Time, Valid: true}
+	}
+	if fromDate.Valid && toDate.Valid && toDate.Time.Before(fromDate.Time) {
+		return fromDate, toDate, false
+	}
+	return fromDate, toDate, true
+}
+
+func (s Server) GetUtilizationReport(ctx context.Context, request api.GetUtilizationReportRequestObject) (api.GetUtilizationReportResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetUtilizationReport401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewAllData, nil)
+	if err != nil {
+		logger.Error("Error checking view_all_data permission", "error", err)
+		return api.GetUtilizationReport500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetUtilizationReport403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	params := request.Params
+	period := api.GetUtilizationReportParamsPeriodWeek
+	if params.Period != nil {
+		period = *params.Period
+	}
+	if period != api.GetUtilizationReportParamsPeriodWeek && period != api.GetUtilizationReportParamsPeriodMonth {
+		return api.GetUtilizationReport400JSONResponse(ValidationErr("period must be one of week, month", nil).Create()), nil
+	}
+
+	fromDate, toDate, valid := reportWindow(params.FromDate, params.ToDate)
+	if !valid {
+		return api.GetUtilizationReport400JSONResponse(ValidationErr("to_date cannot be before from_date", nil).Create()), nil
+	}
+	if !toDate.Valid {
+		toDate = pgtype.Date{Time: time.Now(), Valid: true}
+	}
+	if !fromDate.Valid {
+		fromDate = pgtype.Date{Time: toDate.Time.AddDate(0, 0, -7*defaultUtilizationWeeks), Valid: true}
+	}
+	if fromDate.Time.Before(toDate.Time.AddDate(-maxUtilizationYears, 0, 0)) {
+		return api.GetUtilizationReport400JSONResponse(ValidationErr("the window may span at most two years", nil).Create()), nil
+	}
+
+	rows, err := s.db.Queries().GetItemUtilization(ctx, db.GetItemUtilizationParams{
+		FromDate: fromDate,
+		Period:   string(period),
+		ToDate:   toDate,
+		ItemID:   params.ItemId,
+	})
+	if err != nil {
+		logger.Error("Failed to get item utilization", "error", err)
+		return api.GetUtilizationReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := make(api.GetUtilizationReport200JSONResponse, 0, len(rows))
+	for _, row := range rows {
+		days := row.PeriodEnd.Time.Sub(row.PeriodStart.Time).Hours() / 24
+		utilization := 0.0
+		if capacity := float64(row.Stock) * days; capacity > 0 {
+			utilization = row.UnitDaysBorrowed / capacity
+		}
+		response = append(response, api.ItemUtilization{
+			ItemId:      row.ItemID,
+			ItemName:    row.ItemName,
+			PeriodStart: openapi_types.Date{Time: row.PeriodStart.Time},
+			// periods end at midnight after their last day
+			PeriodEnd:        openapi_types.Date{Time: row.PeriodEnd.Time.AddDate(0, 0, -1)},
+			Borrowings:       int(row.Borrowings),
+			UnitDaysBorrowed: row.UnitDaysBorrowed,
+			Utilization:      utilization,
+		})
+	}
+	if wantsCSV(params.Format) {
+		records := make([][]string, 0, len(response))
+		for _, row := range response {
+			records = append(records, utilizationRecord(row))
+		}
+		body, err := writeCSV(utilizationColumns, records)
+		if err != nil {
+			logger.Error("Failed to write utilization report as CSV", "error", err)
+			return api.GetUtilizationReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		return api.GetUtilizationReport200TextcsvResponse{Body: body, ContentLength: int64(body.Len())}, nil
+	}
+	return response, nil
+}
+
+func (s Server) GetTopItemsReport(ctx context.Context, request api.GetTopItemsReportRequestObject) (api.GetTopItemsReportResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetTopItemsReport401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewAllData, nil)
+	if err != nil {
+		logger.Error("Error checking view_all_data permission", "error", err)
+		return api.GetTopItemsReport500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetTopItemsReport403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	fromDate, toDate, valid := reportWindow(request.Params.FromDate, request.Params.ToDate)
+	if !valid {
+		return api.GetTopItemsReport400JSONResponse(ValidationErr("to_date cannot be before from_date", nil).Create()), nil
+	}
+	limit := defaultTopItemsLimit
+	if request.Params.Limit != nil {
+		limit = *request.Params.Limit
+	}
+	if limit < 1 || limit > maxTopItemsLimit {
+		return api.GetTopItemsReport400JSONResponse(ValidationErr("limit must be between 1 and 100", nil).Create()), nil
+	}
+
+	rows, err := s.db.Queries().GetTopItems(ctx, db.GetTopItemsParams{
+		FromDate: fromDate,
+		ToDate:   toDate,
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		logger.Error("Failed to get top items", "error", err)
+		return api.GetTopItemsReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := make(api.GetTopItemsReport200JSONResponse, 0, len(rows))
+	for _, row := range rows {
+		response = append(response, api.TopItem{
+			ItemId:     row.ItemID,
+			ItemName:   row.ItemName,
+			ItemType:   api.ItemType(row.ItemType),
+			Borrowings: int(row.Borrowings),
+			Takings:    int(row.Takings),
+			Units:      int(row.Units),
+		})
+	}
+	if wantsCSV(request.Params.Format) {
+		records := make([][]string, 0, len(response))
+		for _, row := range response {
+			records = append(records, topItemRecord(row))
+		}
+		body, err := writeCSV(topItemColumns, records)
+		if err != nil {
+			logger.Error("Failed to write top items report as CSV", "error", err)
+			return api.GetTopItemsReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		return api.GetTopItemsReport200TextcsvResponse{Body: body, ContentLength: int64(body.Len())}, nil
+	}
+	return response, nil
+}
+
+func (s Server) GetGroupActivityReport(ctx context.Context, request api.GetGroupActivityReportRequestObject) (api.GetGroupActivityReportResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetGroupActivityReport401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewAllData, nil)
+	if err != nil {
+		logger.Error("Error checking view_all_data permission", "error", err)
+		return api.GetGroupActivityReport500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetGroupActivityReport403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	fromDate, toDate, valid := reportWindow(request.Params.FromDate, request.Params.ToDate)
+	if !valid {
+		return api.GetGroupActivityReport400JSONResponse(ValidationErr("to_date cannot be before from_date", nil).Create()), nil
+	}
+
+	rows, err := s.db.Queries().GetGroupActivity(ctx, db.GetGroupActivityParams{
+		FromDate: fromDate,
+		ToDate:   toDate,
+	})
+	if err != nil {
+		logger.Error("Failed to get group activity", "error", err)
+		return api.GetGroupActivityReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := make(api.GetGroupActivityReport200JSONResponse, 0, len(rows))
+	for _, row := range rows {
+		response = append(response, api.GroupActivity{
+			GroupId:     row.GroupID,
+			GroupName:   row.GroupName,
+			Requests:    int(row.Requests),
+			Bookings:    int(row.Bookings),
+			Borrowings:  int(row.Borrowings),
+			Takings:     int(row.Takings),
+			ActiveUsers: int(row.ActiveUsers),
+		})
+	}
+	if wantsCSV(request.Params.Format) {
+		records := make([][]string, 0, len(response))
+		for _, row := range response {
+			records = append(records, groupActivityRecord(row))
+		}
+		body, err := writeCSV(groupActivityColumns, records)
+		if err != nil {
+			logger.Error("Failed to write group activity report as CSV", "error", err)
+			return api.GetGroupActivityReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		return api.GetGroupActivityReport200TextcsvResponse{Body: body, ContentLength: int64(body.Len())}, nil
+	}
+	return response, nil
+}
+
+func (s Server) GetOverdueSummaryReport(ctx context.Context, request api.GetOverdueSummaryReportRequestObject) (api.GetOverdueSummaryReportResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetOverdueSummaryReport401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewAllData, nil)
+	if err != nil {
+		logger.Error("Error checking view_all_data permission", "error", err)
+		return api.GetOverdueSummaryReport500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetOverdueSummaryReport403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	fromDate, toDate, valid := reportWindow(request.Params.FromDate, request.Params.ToDate)
+	if !valid {
+		return api.GetOverdueSummaryReport400JSONResponse(ValidationErr("to_date cannot be before from_date", nil).Create()), nil
+	}
+
+	rows, err := s.db.Queries().GetOverdueSummary(ctx, db.GetOverdueSummaryParams{
+		FromDate: fromDate,
+		ToDate:   toDate,
+	})
+	if err != nil {
+		logger.Error("Failed to get overdue summary", "error", err)
+		return api.GetOverdueSummaryReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := api.GetOverdueSummaryReport200JSONResponse{Groups: []api.OverdueGroupSummary{}}
+	var daysLate float64
+	for _, row := range rows {
+		response.Due += int(row.Due)
+		response.ReturnedOnTime += int(row.ReturnedOnTime)
+		response.ReturnedLate += int(row.ReturnedLate)
+		response.StillOverdue += int(row.StillOverdue)
+		daysLate += row.DaysLate
+
+		// borrowings made outside any group count towards the totals only
+		if row.GroupID == nil {
+			continue
+		}
+		response.Groups = append(response.Groups, api.OverdueGroupSummary{
+			GroupId:         *row.GroupID,
+			GroupName:       row.GroupName.String,
+			Due:             int(row.Due),
+			ReturnedOnTime:  int(row.ReturnedOnTime),
+			ReturnedLate:    int(row.ReturnedLate),
+			StillOverdue:    int(row.StillOverdue),
+			AverageDaysLate: averageDaysLate(row.DaysLate, row.ReturnedLate+row.StillOverdue),
+		})
+	}
+	response.AverageDaysLate = averageDaysLate(daysLate, int64(response.ReturnedLate+response.StillOverdue))
+	if wantsCSV(request.Params.Format) {
+		records := overdueRecords(api.OverdueSummary(response))
+		body, err := writeCSV(overdueColumns, records)
+		if err != nil {
+			logger.Error("Failed to write overdue summary as CSV", "error", err)
+			return api.GetOverdueSummaryReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		return api.GetOverdueSummaryReport200TextcsvResponse{Body: body, ContentLength: int64(body.Len())}, nil
+	}
+	return response, nil
+}
+
+func averageDaysLate(total float64, late int64) float64 {
+	if late == 0 {
+		return 0
+	}
+	return total / float64(late)
+}
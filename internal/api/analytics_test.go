@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_GetUtilizationReport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	ctx := context.Background()
+
+	admin := testDB.NewUser(t).WithEmail("utilization@reports.test").AsGlobalAdmin().Create()
+	userCtx := testutil.ContextWithUser(ctx, admin, testDB.Queries())
+
+	t.Run("counts the days each unit spent on loan", func(t *testing.T) {
+		borrowing := createBorrowing(t, testDB, admin.ID)
+		_, err := testDB.Pool().Exec(ctx, `UPDATE borrowings SET borrowed_at = NOW() - INTERVAL '3 days' WHERE id = $1`, borrowing.ID)
+		require.NoError(t, err)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewAllData, nil, true, nil)
+		from := toOpenAPIDate(time.Now().AddDate(0, 0, -6))
+		response, err := server.GetUtilizationReport(userCtx, api.GetUtilizationReportRequestObject{
+			Params: api.GetUtilizationReportParams{FromDate: &from, ItemId: borrowing.ItemID},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetUtilizationReport200JSONResponse{}, response)
+
+		rows := response.(api.GetUtilizationReport200JSONResponse)
+		require.NotEmpty(t, rows)
+		var unitDays float64
+		borrowings := 0
+		for _, row := range rows {
+			assert.Equal(t, *borrowing.ItemID, row.ItemId)
+			unitDays += row.UnitDaysBorrowed
+			borrowings += row.Borrowings
+		}
+		assert.InDelta(t, 3.0, unitDays, 0.01)
+		assert.Equal(t, 1, borrowings)
+	})
+
+	t.Run("rejects a window longer than two years", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewAllData, nil, true, nil)
+		from := toOpenAPIDate(time.Now().AddDate(-3, 0, 0))
+		response, err := server.GetUtilizationReport(userCtx, api.GetUtilizationReportRequestObject{
+			Params: api.GetUtilizationReportParams{FromDate: &from},
+		})
+		require.NoError(t, err)
+		assert.IsType(t, api.GetUtilizationReport400JSONResponse{}, response)
+	})
+
+	t.Run("requires view_all_data", func(t *testing.T) {
+		member := testDB.NewUser(t).WithEmail("utilization-member@reports.test").AsMember().Create()
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewAllData, nil, false, nil)
+
+		response, err := server.GetUtilizationReport(testutil.ContextWithUser(ctx, member, testDB.Queries()), api.GetUtilizationReportRequestObject{})
+		require.NoError(t, err)
+		assert.IsType(t, api.GetUtilizationReport403JSONResponse{}, response)
+	})
+}
+
+func TestServer_GetTopItemsReport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	ctx := context.Background()
+
+	admin := testDB.NewUser(t).WithEmail("top-items@reports.test").AsGlobalAdmin().Create()
+	userCtx := testutil.ContextWithUser(ctx, admin, testDB.Queries())
+
+	group := testDB.NewGroup(t).WithName("Top Items Group").Create()
+	cables := testDB.NewItem(t).WithName("HDMI Cable").WithType("low").WithStock(50).Create()
+	createTaking(t, testDB, admin.ID, group.ID, cables.ID, 2)
+	createTaking(t, testDB, admin.ID, group.ID, cables.ID, 3)
+	createBorrowing(t, testDB, admin.ID)
+
+	t.Run("ranks items by use", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewAllData, nil, true, nil)
+		response, err := server.GetTopItemsReport(userCtx, api.GetTopItemsReportRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetTopItemsReport200JSONResponse{}, response)
+
+		items := response.(api.GetTopItemsReport200JSONResponse)
+		require.Len(t, items, 2)
+		assert.Equal(t, cables.ID, items[0].ItemId)
+		assert.Equal(t, 2, items[0].Takings)
+		assert.Equal(t, 5, items[0].Units)
+		assert.Equal(t, 1, items[1].Borrowings)
+	})
+
+	t.Run("honours the limit", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewAllData, nil, true, nil)
+		limit := 1
+		response, err := server.GetTopItemsReport(userCtx, api.GetTopItemsReportRequestObject{
+			Params: api.GetTopItemsReportParams{Limit: &limit},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetTopItemsReport200JSONResponse{}, response)
+		assert.Len(t, response.(api.GetTopItemsReport200JSONResponse), 1)
+	})
+
+	t.Run("rejects a window that ends before it starts", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewAllData, nil, true, nil)
+		from := toOpenAPIDate(time.Now())
+		to := toOpenAPIDate(time.Now().AddDate(0, 0, -1))
+		response, err := server.GetTopItemsReport(userCtx, api.GetTopItemsReportRequestObject{
+			Params: api.GetTopItemsReportParams{FromDate: &from, ToDate: &to},
+		})
+		require.NoError(t, err)
+		assert.IsType(t, api.GetTopItemsReport400JSONResponse{}, response)
+	})
+}
+
+func TestServer_GetGroupActivityReport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	ctx := context.Background()
+
+	admin := testDB.NewUser(t).WithEmail("group-activity@reports.test").AsGlobalAdmin().Create()
+	borrowing := createBorrowing(t, testDB, admin.ID)
+
+	mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewAllData, nil, true, nil)
+	response, err := server.GetGroupActivityReport(testutil.ContextWithUser(ctx, admin, testDB.Queries()), api.GetGroupActivityReportRequestObject{})
+	require.NoError(t, err)
+	require.IsType(t, api.GetGroupActivityReport200JSONResponse{}, response)
+
+	var found bool
+	for _, row := range response.(api.GetGroupActivityReport200JSONResponse) {
+		if row.GroupId != *borrowing.GroupID {
+			continue
+		}
+		found = true
+		assert.Equal(t, 1, row.Borrowings)
+		assert.Equal(t, 0, row.Takings)
+		assert.Equal(t, 1, row.ActiveUsers)
+	}
+	assert.True(t, found, "group missing from the report")
+}
+
+func TestServer_GetOverdueSummaryReport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	ctx := context.Background()
+
+	admin := testDB.NewUser(t).WithEmail("overdue@reports.test").AsGlobalAdmin().Create()
+	overdue := createBorrowing(t, testDB, admin.ID)
+	_, err := testDB.Pool().Exec(ctx, `UPDATE borrowings SET due_date = NOW() - INTERVAL '2 days' WHERE id = $1`, overdue.ID)
+	require.NoError(t, err)
+	createBorrowing(t, testDB, admin.ID)
+
+	mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewAllData, nil, true, nil)
+	response, err := server.GetOverdueSummaryReport(testutil.ContextWithUser(ctx, admin, testDB.Queries()), api.GetOverdueSummaryReportRequestObject{})
+	require.NoError(t, err)
+	require.IsType(t, api.GetOverdueSummaryReport200JSONResponse{}, response)
+
+	summary := response.(api.GetOverdueSummaryReport200JSONResponse)
+	assert.Equal(t, 2, summary.Due)
+	assert.Equal(t, 1, summary.StillOverdue)
+	assert.Equal(t, 0, summary.ReturnedLate)
+	assert.InDelta(t, 2.0, summary.AverageDaysLate, 0.01)
+	assert.Len(t, summary.Groups, 2)
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s Server) ListApiKeys(ctx context.Context, request api.ListApiKeysRequestObject) (api.ListApiKeysResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListApiKeys401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageAPIKeys, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageAPIKeys permission", "error", err)
+		return api.ListApiKeys500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListApiKeys403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	keys, err := s.db.Queries().ListAPIKeys(ctx)
+	if err != nil {
+		logger.Error("Failed to list API keys", "error", err)
+		return api.ListApiKeys500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := make(api.ListApiKeys200JSONResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, toAPIKeyResponse(key))
+	}
+	return response, nil
+}
+
+func (s Server) CreateApiKey(ctx context.Context, request api.CreateApiKeyRequestObject) (api.CreateApiKeyResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateApiKey401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageAPIKeys, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageAPIKeys permission", "error", err)
+		return api.CreateApiKey500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CreateApiKey403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.CreateApiKey400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	body := request.Body
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		return api.CreateApiKey400JSONResponse(ValidationErr("name is required", nil).Create()), nil
+	}
+	permissions := uniquePermissions(body.Permissions)
+	if len(permissions) == 0 {
+		return api.CreateApiKey400JSONResponse(ValidationErr("At least one permission is required", nil).Create()), nil
+	}
+	known, err := s.knownPermissions(ctx, permissions)
+	if err != nil {
+		logger.Error("Failed to check permissions", "error", err)
+		return api.CreateApiKey500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if !known {
+		return api.CreateApiKey400JSONResponse(ValidationErr("Unknown permission", nil).Create()), nil
+	}
+
+	params := db.CreateAPIKeyParams{
+		Name:        name,
+		UserID:      body.UserId,
+		Permissions: permissions,
+		CreatedBy:   &user.ID,
+	}
+	if body.RateLimit != nil {
+		if *body.RateLimit < 1 {
+			return api.CreateApiKey400JSONResponse(ValidationErr("rate_limit must be at least 1", nil).Create()), nil
+		}
+		params.RateLimit = pgtype.Int4{Int32: int32(*body.RateLimit), Valid: true}
+	}
+
+	if _, err := s.db.Queries().GetUserByID(ctx, body.UserId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.CreateApiKey404JSONResponse(NotFound("User").Create()), nil
+		}
+		logger.Error("Failed to get user", "user_id", body.UserId, "error", err)
+		return api.CreateApiKey500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	generated, err := auth.GenerateAPIKey()
+	if err != nil {
+		logger.Error("Failed to generate API key", "error", err)
+		return api.CreateApiKey500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	params.KeyHash = generated.Hash
+	params.KeyPrefix = generated.Prefix
+
+	key, err := s.db.Queries().CreateAPIKey(ctx, params)
+	if err != nil {
+		logger.Error("Failed to create API key", "user_id", body.UserId, "error", err)
+		return api.CreateApiKey500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("API key created", "api_key_id", key.ID, "service_account_id", key.UserID, "user_id", user.ID)
+	return api.CreateApiKey201JSONResponse{
+		ApiKey: toAPIKeyResponse(key),
+		Key:    generated.Key,
+	}, nil
+}
+
+func (s Server) RevokeApiKey(ctx context.Context, request api.RevokeApiKeyRequestObject) (api.RevokeApiKeyResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RevokeApiKey401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageAPIKeys, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageAPIKeys permission", "error", err)
+		return api.RevokeApiKey500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.RevokeApiKey403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	revoked, err := s.db.Queries().RevokeAPIKey(ctx, request.Id)
+	if err != nil {
+		logger.Error("Failed to revoke API key", "api_key_id", request.Id, "error", err)
+		return api.RevokeApiKey500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if revoked == 0 {
+		return api.RevokeApiKey404JSONResponse(NotFound("API key").Create()), nil
+	}
+
+	logger.Info("API key revoked", "api_key_id", request.Id, "user_id", user.ID)
+	return api.RevokeApiKey204Response{}, nil
+}
+
+func toAPIKeyResponse(key db.ApiKey) api.ApiKey {
+	response := api.ApiKey{
+		Id:          key.ID,
+		Name:        key.Name,
+		KeyPrefix:   key.KeyPrefix,
+		UserId:      key.UserID,
+		Permissions: key.Permissions,
+		CreatedBy:   key.CreatedBy,
+		CreatedAt:   key.CreatedAt.Time,
+	}
+	if key.RateLimit.Valid {
+		limit := int(key.RateLimit.Int32)
+		response.RateLimit = &limit
+	}
+	if key.LastUsedAt.Valid {
+		response.LastUsedAt = &key.LastUsedAt.Time
+	}
+	if key.RevokedAt.Valid {
+		response.RevokedAt = &key.RevokedAt.Time
+	}
+	return response
+}
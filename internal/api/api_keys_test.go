@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ApiKeys(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@keys.test").AsGlobalAdmin().Create()
+	kiosk := testDB.NewUser(t).WithEmail("kiosk@keys.test").AsMember().Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	create := func(body api.CreateApiKeyRequest) api.CreateApiKeyResponseObject {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAPIKeys, nil, true, nil)
+		response, err := server.CreateApiKey(ctx, api.CreateApiKeyRequestObject{Body: &body})
+		require.NoError(t, err)
+		return response
+	}
+
+	var keyID uuid.UUID
+
+	t.Run("create", func(t *testing.T) {
+		limit := 30
+		response := create(api.CreateApiKeyRequest{
+			Name:        "Front desk kiosk",
+			UserId:      kiosk.ID,
+			Permissions: []string{rbac.ViewItems, rbac.ManageCart, rbac.ViewItems},
+			RateLimit:   &limit,
+		})
+		require.IsType(t, api.CreateApiKey201JSONResponse{}, response)
+		created := response.(api.CreateApiKey201JSONResponse)
+		assert.True(t, strings.HasPrefix(created.Key, created.ApiKey.KeyPrefix))
+		assert.Equal(t, []string{rbac.ManageCart, rbac.ViewItems}, created.ApiKey.Permissions)
+		assert.Equal(t, &limit, created.ApiKey.RateLimit)
+		assert.Equal(t, kiosk.ID, created.ApiKey.UserId)
+		keyID = created.ApiKey.Id
+
+		stored, err := testDB.Queries().ListAPIKeys(context.Background())
+		require.NoError(t, err)
+		require.Len(t, stored, 1)
+		assert.NotEqual(t, created.Key, stored[0].KeyHash, "only a hash is stored")
+	})
+
+	t.Run("unknown permission", func(t *testing.T) {
+		response := create(api.CreateApiKeyRequest{Name: "Bad", UserId: kiosk.ID, Permissions: []string{"launch_rockets"}})
+		require.IsType(t, api.CreateApiKey400JSONResponse{}, response)
+	})
+
+	t.Run("needs a permission", func(t *testing.T) {
+		response := create(api.CreateApiKeyRequest{Name: "Empty", UserId: kiosk.ID, Permissions: []string{}})
+		require.IsType(t, api.CreateApiKey400JSONResponse{}, response)
+	})
+
+	t.Run("unknown service account", func(t *testing.T) {
+		response := create(api.CreateApiKeyRequest{Name: "Ghost", UserId: uuid.New(), Permissions: []string{rbac.ViewItems}})
+		require.IsType(t, api.CreateApiKey404JSONResponse{}, response)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAPIKeys, nil, true, nil)
+		response, err := server.ListApiKeys(ctx, api.ListApiKeysRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.ListApiKeys200JSONResponse{}, response)
+		keys := response.(api.ListApiKeys200JSONResponse)
+		require.Len(t, keys, 1)
+		assert.Equal(t, keyID, keys[0].Id)
+		assert.Nil(t, keys[0].RevokedAt)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAPIKeys, nil, true, nil)
+		response, err := server.RevokeApiKey(ctx, api.RevokeApiKeyRequestObject{Id: keyID})
+		require.NoError(t, err)
+		require.IsType(t, api.RevokeApiKey204Response{}, response)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAPIKeys, nil, true, nil)
+		response, err = server.RevokeApiKey(ctx, api.RevokeApiKeyRequestObject{Id: keyID})
+		require.NoError(t, err)
+		require.IsType(t, api.RevokeApiKey404JSONResponse{}, response)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAPIKeys, nil, true, nil)
+		listed, err := server.ListApiKeys(ctx, api.ListApiKeysRequestObject{})
+		require.NoError(t, err)
+		keys := listed.(api.ListApiKeys200JSONResponse)
+		require.Len(t, keys, 1)
+		assert.NotNil(t, keys[0].RevokedAt, "revoked keys stay listed")
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAPIKeys, nil, false, nil)
+		response, err := server.ListApiKeys(ctx, api.ListApiKeysRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.ListApiKeys403JSONResponse{}, response)
+	})
+}
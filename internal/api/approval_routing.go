@@ -0,0 +1,254 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/approvals"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func toApprovalDelegationResponse(d db.ApprovalDelegation) api.ApprovalDelegation {
+	resp := api.ApprovalDelegation{
+		Id:          d.ID,
+		DelegatorId: d.DelegatorID,
+		DelegateId:  d.DelegateID,
+		StartsAt:    d.StartsAt.Time,
+		EndsAt:      d.EndsAt.Time,
+		CreatedAt:   d.CreatedAt.Time,
+	}
+	if d.Reason.Valid {
+		resp.Reason = &d.Reason.String
+	}
+	return resp
+}
+
+func toRequestRouteResponse(r db.RequestRoute) api.RequestRoute {
+	return api.RequestRoute{
+		ApproverId:    r.ApproverID,
+		Reason:        api.RequestRouteReason(r.Reason),
+		DelegatedFrom: r.DelegatedFrom,
+		RoutedAt:      r.RoutedAt.Time,
+	}
+}
+
+// records who reviews a new request. A request left unrouted can still be
+// reviewed by the global approvers, so a failure is only logged.
+func (s Server) routeRequest(ctx context.Context, requestID, itemID uuid.UUID) []approvals.Route {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	item, err := s.db.Queries().GetItemByID(ctx, itemID)
+	if err != nil {
+		logger.Error("Failed to get item to route request", "request_id", requestID, "item_id", itemID, "error", err)
+		return nil
+	}
+	routes, err := approvals.RouteRequest(ctx, s.db.Queries(), requestID, item.OwnerGroupID)
+	if err != nil {
+		logger.Error("Failed to route request", "request_id", requestID, "error", err)
+	}
+	return routes
+}
+
+// whether someone who is not a global approver may review the request: it
+// was routed to them, or they approve for the group owning its item.
+func (s Server) mayReviewRequest(ctx context.Context, q *db.Queries, userID, requestID uuid.UUID, item db.Item) (bool, error) {
+	routed, err := q.IsRequestRoutedTo(ctx, db.IsRequestRoutedToParams{RequestID: requestID, ApproverID: userID})
+	if err != nil || routed {
+		return routed, err
+	}
+	if item.OwnerGroupID == nil {
+		return false, nil
+	}
+	return s.authenticator.CheckPermission(ctx, userID, rbac.ApproveGroupRequests, item.OwnerGroupID)
+}
+
+func (s Server) ListApprovalDelegations(ctx context.Context, request api.ListApprovalDelegationsRequestObject) (api.ListApprovalDelegationsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListApprovalDelegations401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	delegations, err := s.db.Queries().ListApprovalDelegations(ctx, user.ID)
+	if err != nil {
+		logger.Error("Failed to list approval delegations", "user_id", user.ID, "error", err)
+		return api.ListApprovalDelegations500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	resp := make(api.ListApprovalDelegations200JSONResponse, 0, len(delegations))
+	for _, d := range delegations {
+		resp = append(resp, toApprovalDelegationResponse(d))
+	}
+	return resp, nil
+}
+
+func (s Server) CreateApprovalDelegation(ctx context.Context, request api.CreateApprovalDelegationRequestObject) (api.CreateApprovalDelegationResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateApprovalDelegation401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.CreateApprovalDelegation400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	if request.Body.DelegateId == user.ID {
+		return api.CreateApprovalDelegation400JSONResponse(ValidationErr("You cannot delegate to yourself", nil).Create()), nil
+	}
+	now := time.Now()
+	startsAt := now
+	if request.Body.StartsAt != nil {
+		startsAt = *request.Body.StartsAt
+	}
+	endsAt := request.Body.EndsAt
+	if !endsAt.After(startsAt) || !endsAt.After(now) {
+		return api.CreateApprovalDelegation400JSONResponse(ValidationErr("ends_at must be after starts_at and in the future", nil).Create()), nil
+	}
+	var reason pgtype.Text
+	if request.Body.Reason != nil && strings.TrimSpace(*request.Body.Reason) != "" {
+		reason = pgtype.Text{String: strings.TrimSpace(*request.Body.Reason), Valid: true}
+	}
+
+	if _, err := s.db.Queries().GetUserByID(ctx, request.Body.DelegateId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.CreateApprovalDelegation404JSONResponse(NotFound("User").Create()), nil
+		}
+		logger.Error("Failed to get delegate", "delegate_id", request.Body.DelegateId, "error", err)
+		return api.CreateApprovalDelegation500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.CreateApprovalDelegation500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	delegation, err := qtx.CreateApprovalDelegation(ctx, db.CreateApprovalDelegationParams{
+		DelegatorID: user.ID,
+		DelegateID:  request.Body.DelegateId,
+		StartsAt:    pgtype.Timestamp{Time: startsAt.UTC(), Valid: true},
+		EndsAt:      pgtype.Timestamp{Time: endsAt.UTC(), Valid: true},
+		Reason:      reason,
+	})
+	if err != nil {
+		logger.Error("Failed to create approval delegation", "user_id", user.ID, "error", err)
+		return api.CreateApprovalDelegation500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	// requests already waiting on the delegator go to the delegate too
+	var handedOn int64
+	if !startsAt.After(now) {
+		handedOn, err = qtx.RouteDelegatorPendingRequests(ctx, db.RouteDelegatorPendingRequestsParams{
+			DelegateID:  delegation.DelegateID,
+			DelegatorID: user.ID,
+		})
+		if err != nil {
+			logger.Error("Failed to hand on pending requests", "delegation_id", delegation.ID, "error", err)
+			return api.CreateApprovalDelegation500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit approval delegation", "user_id", user.ID, "error", err)
+		return api.CreateApprovalDelegation500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Approval delegation created",
+		"delegation_id", delegation.ID,
+		"user_id", user.ID,
+		"delegate_id", delegation.DelegateID,
+		"pending_handed_on", handedOn)
+
+	return api.CreateApprovalDelegation201JSONResponse(toApprovalDelegationResponse(delegation)), nil
+}
+
+func (s Server) DeleteApprovalDelegation(ctx context.Context, request api.DeleteApprovalDelegationRequestObject) (api.DeleteApprovalDelegationResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.DeleteApprovalDelegation401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	deleted, err := s.db.Queries().DeleteApprovalDelegation(ctx, db.DeleteApprovalDelegationParams{
+		ID:          request.DelegationId,
+		DelegatorID: user.ID,
+	})
+	if err != nil {
+		logger.Error("Failed to delete approval delegation", "delegation_id", request.DelegationId, "error", err)
+		return api.DeleteApprovalDelegation500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if deleted == 0 {
+		return api.DeleteApprovalDelegation404JSONResponse(NotFound("Delegation").Create()), nil
+	}
+
+	logger.Info("Approval delegation deleted", "delegation_id", request.DelegationId, "user_id", user.ID)
+	return api.DeleteApprovalDelegation204Response{}, nil
+}
+
+func (s Server) GetMyApprovalQueue(ctx context.Context, request api.GetMyApprovalQueueRequestObject) (api.GetMyApprovalQueueResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetMyApprovalQueue401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	requests, err := s.db.Queries().ListRoutedPendingRequests(ctx, db.ListRoutedPendingRequestsParams{
+		ApproverID: user.ID,
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		logger.Error("Failed to list routed requests", "user_id", user.ID, "error", err)
+		return api.GetMyApprovalQueue500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountRoutedPendingRequests(ctx, user.ID)
+	if err != nil {
+		logger.Error("Failed to count routed requests", "user_id", user.ID, "error", err)
+		return api.GetMyApprovalQueue500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	priorities, err := s.requestFairness(ctx, requests)
+	if err != nil {
+		logger.Error("Failed to rank routed requests", "user_id", user.ID, "error", err)
+		return api.GetMyApprovalQueue500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	deadlines, err := s.requestSLAStatus(ctx, requests)
+	if err != nil {
+		logger.Error("Failed to get SLA status of routed requests", "user_id", user.ID, "error", err)
+		return api.GetMyApprovalQueue500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := createRequestItemResponse(requests)
+	for i := range response {
+		if f, ok := priorities[response[i].Id]; ok {
+			response[i].Fairness = &f
+		}
+		if d, ok := deadlines[response[i].Id]; ok {
+			response[i].Sla = &d
+		}
+	}
+	return api.GetMyApprovalQueue200JSONResponse{
+		Data: response,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/sla"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ApprovalRouting(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	ctx := context.Background()
+
+	group := testDB.NewGroup(t).WithName("Film Society").Create()
+	requester := testDB.NewUser(t).WithEmail("requester@routing.test").AsMember().Create()
+	testDB.AssignUserToGroup(t, requester.ID, group.ID, "member")
+	groupApprover := testDB.NewUser(t).WithEmail("treasurer@routing.test").AsMember().Create()
+	testDB.AssignUserToGroup(t, groupApprover.ID, group.ID, "group_approver")
+	globalApprover := testDB.NewUser(t).WithEmail("vp@routing.test").AsApprover().Create()
+
+	owned := testDB.NewItem(t).WithName("Society Camera").WithType("high").WithStock(2).Create()
+	require.NoError(t, testDB.Queries().SetItemOwner(ctx, db.SetItemOwnerParams{ID: owned.ID, OwnerGroupID: &group.ID}))
+	shared := testDB.NewItem(t).WithName("Union Projector").WithType("high").WithStock(2).Create()
+
+	requesterCtx := testutil.ContextWithUser(ctx, requester, testDB.Queries())
+	groupApproverCtx := testutil.ContextWithUser(ctx, groupApprover, testDB.Queries())
+
+	newRequest := func(t *testing.T, itemID uuid.UUID) uuid.UUID {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(requester.ID, rbac.RequestItems, &group.ID, true, nil)
+		resp, err := server.RequestItem(requesterCtx, api.RequestItemRequestObject{
+			Body: &api.RequestItemJSONRequestBody{UserId: requester.ID, GroupId: group.ID, ItemId: itemID, Quantity: 1},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RequestItem201JSONResponse{}, resp)
+		return resp.(api.RequestItem201JSONResponse).Id
+	}
+	routesOf := func(t *testing.T, requestID uuid.UUID) []db.RequestRoute {
+		t.Helper()
+		routes, err := testDB.Queries().ListRequestRoutes(ctx, requestID)
+		require.NoError(t, err)
+		return routes
+	}
+	notificationsOf := func(t *testing.T, userID uuid.UUID) int {
+		t.Helper()
+		notifs, err := testDB.Queries().GetUserNotifications(ctx, db.GetUserNotificationsParams{NotifierID: userID, Limit: 50})
+		require.NoError(t, err)
+		return len(notifs)
+	}
+
+	t.Run("requests for a group's item go to the group's approvers", func(t *testing.T) {
+		before := notificationsOf(t, groupApprover.ID)
+		requestID := newRequest(t, owned.ID)
+
+		routes := routesOf(t, requestID)
+		require.Len(t, routes, 1)
+		assert.Equal(t, groupApprover.ID, routes[0].ApproverID)
+		assert.Equal(t, db.RequestRouteReasonOwnerGroup, routes[0].Reason)
+		assert.Equal(t, before+1, notificationsOf(t, groupApprover.ID), "the group's approver hears about it")
+
+		// visible to the approver it was routed to, with the routing
+		mockAuth.ExpectCheckPermission(groupApprover.ID, rbac.ViewOwnData, nil, true, nil)
+		mockAuth.ExpectCheckPermission(groupApprover.ID, rbac.ViewAllData, nil, false, nil)
+		got, err := server.GetRequestById(groupApproverCtx, api.GetRequestByIdRequestObject{RequestId: requestID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetRequestById200JSONResponse{}, got)
+		routing := got.(api.GetRequestById200JSONResponse).Routing
+		require.NotNil(t, routing)
+		require.Len(t, *routing, 1)
+		assert.Equal(t, api.RequestRouteReasonOwnerGroup, (*routing)[0].Reason)
+
+		mockAuth.ExpectCheckPermission(groupApprover.ID, rbac.ApproveAllRequests, nil, false, nil)
+		reviewed, err := server.ReviewRequest(groupApproverCtx, api.ReviewRequestRequestObject{
+			RequestId: requestID,
+			Body:      &api.ReviewRequestJSONRequestBody{Status: api.RequestStatusDenied},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ReviewRequest200JSONResponse{}, reviewed)
+	})
+
+	t.Run("requests for other items go to the global approvers", func(t *testing.T) {
+		requestID := newRequest(t, shared.ID)
+
+		routes := routesOf(t, requestID)
+		require.Len(t, routes, 1)
+		assert.Equal(t, globalApprover.ID, routes[0].ApproverID)
+		assert.Equal(t, db.RequestRouteReasonGlobal, routes[0].Reason)
+
+		// the group's approver has no say over it
+		mockAuth.ExpectCheckPermission(groupApprover.ID, rbac.ApproveAllRequests, nil, false, nil)
+		reviewed, err := server.ReviewRequest(groupApproverCtx, api.ReviewRequestRequestObject{
+			RequestId: requestID,
+			Body:      &api.ReviewRequestJSONRequestBody{Status: api.RequestStatusDenied},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ReviewRequest403JSONResponse{}, reviewed)
+	})
+
+	t.Run("an approver who is away hands their requests to a delegate", func(t *testing.T) {
+		delegate := testDB.NewUser(t).WithEmail("deputy@routing.test").AsMember().Create()
+		delegateCtx := testutil.ContextWithUser(ctx, delegate, testDB.Queries())
+
+		waiting := newRequest(t, owned.ID)
+
+		reason := "Away at a conference"
+		created, err := server.CreateApprovalDelegation(groupApproverCtx, api.CreateApprovalDelegationRequestObject{
+			Body: &api.CreateApprovalDelegationRequest{DelegateId: delegate.ID, EndsAt: time.Now().Add(7 * 24 * time.Hour), Reason: &reason},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateApprovalDelegation201JSONResponse{}, created)
+		delegation := created.(api.CreateApprovalDelegation201JSONResponse)
+
+		later := newRequest(t, owned.ID)
+		routes := routesOf(t, later)
+		require.Len(t, routes, 1)
+		assert.Equal(t, delegate.ID, routes[0].ApproverID)
+		assert.Equal(t, db.RequestRouteReasonOwnerGroup, routes[0].Reason)
+		require.NotNil(t, routes[0].DelegatedFrom)
+		assert.Equal(t, groupApprover.ID, *routes[0].DelegatedFrom)
+
+		queue, err := server.GetMyApprovalQueue(delegateCtx, api.GetMyApprovalQueueRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetMyApprovalQueue200JSONResponse{}, queue)
+		assert.Equal(t, 2, queue.(api.GetMyApprovalQueue200JSONResponse).Meta.Total, "the request already waiting is handed on too")
+
+		mockAuth.ExpectCheckPermission(delegate.ID, rbac.ApproveAllRequests, nil, false, nil)
+		reviewed, err := server.ReviewRequest(delegateCtx, api.ReviewRequestRequestObject{
+			RequestId: waiting,
+			Body:      &api.ReviewRequestJSONRequestBody{Status: api.RequestStatusDenied},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ReviewRequest200JSONResponse{}, reviewed)
+
+		listed, err := server.ListApprovalDelegations(groupApproverCtx, api.ListApprovalDelegationsRequestObject{})
+		require.NoError(t, err)
+		require.Len(t, listed.(api.ListApprovalDelegations200JSONResponse), 1)
+
+		deleted, err := server.DeleteApprovalDelegation(groupApproverCtx, api.DeleteApprovalDelegationRequestObject{DelegationId: delegation.Id})
+		require.NoError(t, err)
+		require.IsType(t, api.DeleteApprovalDelegation204Response{}, deleted)
+		deleted, err = server.DeleteApprovalDelegation(groupApproverCtx, api.DeleteApprovalDelegationRequestObject{DelegationId: delegation.Id})
+		require.NoError(t, err)
+		require.IsType(t, api.DeleteApprovalDelegation404JSONResponse{}, deleted)
+
+		back := newRequest(t, owned.ID)
+		assert.Equal(t, groupApprover.ID, routesOf(t, back)[0].ApproverID, "requests go back to the approver once the delegation ends")
+	})
+
+	t.Run("delegations are validated", func(t *testing.T) {
+		create := func(body api.CreateApprovalDelegationRequest) api.CreateApprovalDelegationResponseObject {
+			resp, err := server.CreateApprovalDelegation(groupApproverCtx, api.CreateApprovalDelegationRequestObject{Body: &body})
+			require.NoError(t, err)
+			return resp
+		}
+		nextWeek := time.Now().Add(7 * 24 * time.Hour)
+
+		assert.IsType(t, api.CreateApprovalDelegation400JSONResponse{}, create(api.CreateApprovalDelegationRequest{DelegateId: groupApprover.ID, EndsAt: nextWeek}))
+		assert.IsType(t, api.CreateApprovalDelegation400JSONResponse{}, create(api.CreateApprovalDelegationRequest{DelegateId: globalApprover.ID, EndsAt: time.Now().Add(-time.Hour)}))
+		assert.IsType(t, api.CreateApprovalDelegation404JSONResponse{}, create(api.CreateApprovalDelegationRequest{DelegateId: uuid.New(), EndsAt: nextWeek}))
+	})
+
+	t.Run("requests left with the owning group escalate to the global approvers", func(t *testing.T) {
+		requestID := newRequest(t, owned.ID)
+		_, err := testDB.Pool().Exec(ctx, `UPDATE requests SET requested_at = NOW() - INTERVAL '3 days' WHERE id = $1`, requestID)
+		require.NoError(t, err)
+
+		checker := sla.NewChecker(testDB.Queries(), server.dispatcher, 48*time.Hour)
+		escalated, err := checker.Escalate(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, escalated)
+
+		routes := routesOf(t, requestID)
+		require.Len(t, routes, 2)
+		assert.Equal(t, globalApprover.ID, routes[1].ApproverID)
+		assert.Equal(t, db.RequestRouteReasonEscalated, routes[1].Reason)
+
+		escalated, err = checker.Escalate(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, escalated, "a request escalates once")
+	})
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/audit"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// auditSpec says how an operation's changes are recorded in the audit log.
+// Every operation that changes anything must have one; see audits.
+type auditSpec struct {
+	// Skip leaves the operation out: session bookkeeping and the caller's own
+	// scratch state, such as their cart
+	Skip bool
+	// the kind of entity changed: item, booking, role...
+	Entity string
+	// picks the changed entity out of the request; nil when the request does
+	// not name it
+	ID func(request any) string
+	// picks the entity a create made out of its response
+	CreatedID func(response any) string
+	// reads the entity's current state for the before and after snapshots;
+	// nil records which entity changed but not how
+	Load auditLoader
+}
+
+type auditLoader func(ctx context.Context, q *db.Queries, id string) (any, error)
+
+func notAudited() auditSpec {
+	return auditSpec{Skip: true}
+}
+
+// records who did what to which kind of entity, for operations that change
+// many at once or nothing that can be read back
+func auditAction(entity string) auditSpec {
+	return auditSpec{Entity: entity}
+}
+
+func auditChange[R any](entity string, id func(request R) string, load auditLoader) auditSpec {
+	return auditSpec{Entity: entity, Load: load, ID: func(request any) string {
+		return id(request.(R))
+	}}
+}
+
+func auditCreate[Resp any](entity string, id func(response Resp) uuid.UUID, load auditLoader) auditSpec {
+	return auditSpec{Entity: entity, Load: load, CreatedID: func(response any) string {
+		created, ok := response.(Resp)
+		if !ok {
+			return ""
+		}
+		return id(created).String()
+	}}
+}
+
+// loads an entity by ID with a generated query, e.g. (*db.Queries).GetItemByID
+func loadByID[T any](get func(q *db.Queries, ctx context.Context, id uuid.UUID) (T, error)) auditLoader {
+	return func(ctx context.Context, q *db.Queries, id string) (any, error) {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+		state, err := get(q, ctx, parsed)
+		if err != nil {
+			return nil, err
+		}
+		return state, nil
+	}
+}
+
+func loadRole(ctx context.Context, q *db.Queries, name string) (any, error) {
+	role, err := q.GetRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := q.ListPermissionsForRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"name": role.Name, "description": role.Description, "permissions": permissions}, nil
+}
+
+func loadUserRoles(ctx context.Context, q *db.Queries, id string) (any, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := q.GetUserRoles(ctx, &userID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"roles": roles}, nil
+}
+
+func loadItemVisibility(ctx context.Context, q *db.Queries, id string) (any, error) {
+	itemID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	item, err := q.GetItemByIDIncludingBinned(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := q.ListItemShares(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"owner_group_id": item.OwnerGroupID, "shared_group_ids": shares}, nil
+}
+
+// the entity's state, or nil when it does not exist (yet, or any more) or
+// cannot be read
+func (spec auditSpec) snapshot(ctx context.Context, q *db.Queries, id string, logger *slog.Logger) any {
+	if spec.Load == nil || id == "" {
+		return nil
+	}
+	state, err := spec.Load(ctx, q, id)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("Failed to read entity for the audit log", "entity_type", spec.Entity, "entity_id", id, "error", err)
+		}
+		return nil
+	}
+	return state
+}
+
+// Audit returns strict middleware that records each successful change made
+// through the API in the audit log. It goes inside Authorize so refused
+// requests never reach it.
+func Audit(database DatabaseService) api.StrictMiddlewareFunc {
+	return func(next api.StrictHandlerFunc, operationID string) api.StrictHandlerFunc {
+		spec, audited := audits[operationID]
+		if !audited || spec.Skip {
+			return next
+		}
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+			logger := middleware.GetLoggerFromContext(ctx)
+			q := database.Queries()
+
+			var id string
+			if spec.ID != nil {
+				id = spec.ID(request)
+			}
+			before := spec.snapshot(ctx, q, id, logger)
+
+			response, err := next(ctx, w, r, request)
+			if err != nil || !succeeded(operationID, response) {
+				return response, err
+			}
+
+			if id == "" && spec.CreatedID != nil {
+				id = spec.CreatedID(response)
+			}
+			entry := audit.Entry{
+				Action:     operationID,
+				EntityType: spec.Entity,
+				EntityID:   id,
+				Before:     before,
+				After:      spec.snapshot(ctx, q, id, logger),
+				RequestID:  middleware.GetRequestID(ctx),
+			}
+			if user, ok := auth.GetAuthenticatedUser(ctx); ok {
+				entry.ActorID = &user.ID
+			}
+			// the change is made by now, so a failure to record it is
+			// logged rather than failing the request
+			if err := audit.Record(ctx, q, entry); err != nil {
+				logger.Error("Failed to record audit log entry", "operation", operationID, "error", err)
+			}
+			return response, nil
+		}
+	}
+}
+
+// the strict server names response types for their operation and status, as
+// in CreateItem201JSONResponse
+func succeeded(operationID string, response any) bool {
+	if response == nil {
+		return false
+	}
+	status, ok := strings.CutPrefix(reflect.TypeOf(response).Name(), operationID)
+	if !ok || len(status) < 3 {
+		return false
+	}
+	code, err := strconv.Atoi(status[:3])
+	return err == nil && code >= 200 && code < 300
+}
+
+func (s Server) GetAuditLog(ctx context.Context, request api.GetAuditLogRequestObject) (api.GetAuditLogResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetAuditLog401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewAuditLog, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ViewAuditLog permission", "error", err)
+		return api.GetAuditLog500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetAuditLog403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+	params := request.Params
+	filter := db.CountAuditLogParams{
+		ActorID:    params.ActorId,
+		Action:     optionalText(params.Action),
+		EntityType: optionalText(params.EntityType),
+		EntityID:   optionalText(params.EntityId),
+	}
+	if params.Since != nil {
+		filter.Since = pgtype.Timestamp{Time: params.Since.UTC(), Valid: true}
+	}
+	if params.Until != nil {
+		filter.Until = pgtype.Timestamp{Time: params.Until.UTC(), Valid: true}
+	}
+
+	entries, err := s.db.Queries().ListAuditLog(ctx, db.ListAuditLogParams{
+		ActorID:    filter.ActorID,
+		Action:     filter.Action,
+		EntityType: filter.EntityType,
+		EntityID:   filter.EntityID,
+		Since:      filter.Since,
+		Until:      filter.Until,
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		logger.Error("Failed to list audit log", "error", err)
+		return api.GetAuditLog500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountAuditLog(ctx, filter)
+	if err != nil {
+		logger.Error("Failed to count audit log", "error", err)
+		return api.GetAuditLog500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	data := make([]api.AuditLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		data = append(data, toAuditLogEntryResponse(entry))
+	}
+
+	return api.GetAuditLog200JSONResponse{
+		Data: data,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
+
+func toAuditLogEntryResponse(entry db.AuditLog) api.AuditLogEntry {
+	response := api.AuditLogEntry{
+		Id:         entry.ID,
+		ActorId:    entry.ActorID,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		CreatedAt:  entry.CreatedAt.Time,
+	}
+	if entry.EntityID.Valid {
+		response.EntityId = &entry.EntityID.String
+	}
+	if entry.RequestID.Valid {
+		response.RequestId = &entry.RequestID.String
+	}
+	// written by audit.Record from JSON objects
+	if entry.Before != nil {
+		var before map[string]interface{}
+		if json.Unmarshal(entry.Before, &before) == nil {
+			response.Before = &before
+		}
+	}
+	if entry.After != nil {
+		var after map[string]interface{}
+		if json.Unmarshal(entry.After, &after) == nil {
+			response.After = &after
+		}
+	}
+	return response
+}
+
+func optionalText(s *string) pgtype.Text {
+	if s == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *s, Valid: true}
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudits_CoverEveryChange(t *testing.T) {
+	swagger, err := api.GetSwagger()
+	require.NoError(t, err)
+
+	changes := make(map[string]bool)
+	for _, item := range swagger.Paths.Map() {
+		for method, operation := range item.Operations() {
+			if method == http.MethodGet {
+				continue
+			}
+			// the generated Go names capitalise the operation ID
+			name := strings.ToUpper(operation.OperationID[:1]) + operation.OperationID[1:]
+			changes[name] = true
+			assert.Contains(t, audits, name, "operation has no audit spec")
+		}
+	}
+	for name := range audits {
+		assert.True(t, changes[name], "audit spec %q names no operation that changes anything", name)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	// runs the operation through the middleware the way the strict handler
+	// would, with the server's own handler inside
+	run := func(t *testing.T, ctx context.Context, operationID string, handle func(ctx context.Context, request interface{}) (interface{}, error), request interface{}) interface{} {
+		next := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+			return handle(ctx, request)
+		}
+		handler := Audit(testDB)(next, operationID)
+
+		r := httptest.NewRequest(http.MethodPost, "/", nil).WithContext(ctx)
+		response, err := handler(ctx, httptest.NewRecorder(), r, request)
+		require.NoError(t, err)
+		return response
+	}
+	updateItem := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return server.UpdateItem(ctx, request.(api.UpdateItemRequestObject))
+	}
+	auditLog := func(t *testing.T) []db.AuditLog {
+		entries, err := testDB.Queries().ListAuditLog(context.Background(), db.ListAuditLogParams{Limit: 100})
+		require.NoError(t, err)
+		return entries
+	}
+
+	t.Run("records the fields a change touched", func(t *testing.T) {
+		admin := testDB.NewUser(t).WithEmail("audit-update@example.ca").AsGlobalAdmin().Create()
+		item := testDB.NewItem(t).WithName("Projector").WithDescription("Ceiling mounted").WithType("medium").WithStock(5).Create()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		description := "Ceiling mounted"
+		response := run(t, ctx, "UpdateItem", updateItem, api.UpdateItemRequestObject{
+			Id:   item.ID,
+			Body: &api.UpdateItemJSONRequestBody{Name: "Projector", Description: &description, Type: api.ItemTypeMedium, Stock: 8},
+		})
+		require.IsType(t, api.UpdateItem200JSONResponse{}, response)
+
+		entries := auditLog(t)
+		require.Len(t, entries, 1)
+		entry := entries[0]
+		assert.Equal(t, &admin.ID, entry.ActorID)
+		assert.Equal(t, "UpdateItem", entry.Action)
+		assert.Equal(t, "item", entry.EntityType)
+		assert.Equal(t, item.ID.String(), entry.EntityID.String)
+		assert.JSONEq(t, `{"stock": 5}`, string(entry.Before))
+		assert.JSONEq(t, `{"stock": 8}`, string(entry.After))
+	})
+
+	t.Run("failed changes are not recorded", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("audit-failed@example.ca").AsGlobalAdmin().Create()
+		item := testDB.NewItem(t).WithName("Laptop").WithType("high").WithStock(2).Create()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		response := run(t, ctx, "UpdateItem", updateItem, api.UpdateItemRequestObject{Id: item.ID})
+		require.IsType(t, api.UpdateItem400JSONResponse{}, response)
+
+		assert.Empty(t, auditLog(t))
+	})
+}
+
+func TestServer_GetAuditLog(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	ctx := context.Background()
+
+	for _, action := range []string{"CreateItem", "UpdateItem", "DeleteItem"} {
+		require.NoError(t, testDB.Queries().CreateAuditLogEntry(ctx, db.CreateAuditLogEntryParams{
+			Action:     action,
+			EntityType: "item",
+			After:      []byte(`{"stock": 3}`),
+		}))
+	}
+
+	t.Run("lists entries matching the filter", func(t *testing.T) {
+		admin := testDB.NewUser(t).WithEmail("audit-reader@example.ca").AsGlobalAdmin().Create()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewAuditLog, nil, true, nil)
+		userCtx := testutil.ContextWithUser(ctx, admin, testDB.Queries())
+
+		action := "UpdateItem"
+		response, err := server.GetAuditLog(userCtx, api.GetAuditLogRequestObject{
+			Params: api.GetAuditLogParams{Action: &action},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetAuditLog200JSONResponse{}, response)
+
+		body := response.(api.GetAuditLog200JSONResponse)
+		require.Len(t, body.Data, 1)
+		assert.Equal(t, "UpdateItem", body.Data[0].Action)
+		require.NotNil(t, body.Data[0].After)
+		stock, _ := json.Marshal(*body.Data[0].After)
+		assert.JSONEq(t, `{"stock": 3}`, string(stock))
+		assert.Equal(t, 1, body.Meta.Total)
+	})
+
+	t.Run("requires the view_audit_log permission", func(t *testing.T) {
+		member := testDB.NewUser(t).WithEmail("audit-member@example.ca").AsMember().Create()
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewAuditLog, nil, false, nil)
+		userCtx := testutil.ContextWithUser(ctx, member, testDB.Queries())
+
+		response, err := server.GetAuditLog(userCtx, api.GetAuditLogRequestObject{})
+		require.NoError(t, err)
+		assert.IsType(t, api.GetAuditLog403JSONResponse{}, response)
+	})
+}
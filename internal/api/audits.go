@@ -0,0 +1,131 @@
+package api
+
+import (
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/google/uuid"
+)
+
+// audits is keyed by operation ID and covers every operation that is not a
+// GET. Operations missing from it are not recorded.
+var audits = map[string]auditSpec{
+	"AddToCart":                 notAudited(),
+	"AdjustItemStock":           auditChange("item", func(r api.AdjustItemStockRequestObject) string { return r.ItemId.String() }, loadByID((*db.Queries).GetItemByID)),
+	"ApproveDeletionRequest":    auditChange("deletion_request", func(r api.ApproveDeletionRequestRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetDeletionRequestByID)),
+	"AssignUserRole":            auditChange("user_roles", func(r api.AssignUserRoleRequestObject) string { return r.UserId.String() }, loadUserRoles),
+	"BorrowItem":                auditCreate("borrowing", func(r api.BorrowItem201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetBorrowingByID)),
+	"CancelBooking":             auditChange("booking", func(r api.CancelBookingRequestObject) string { return r.BookingId.String() }, loadByID((*db.Queries).GetBookingByID)),
+	"CancelQueueTask":           auditChange("queue_task", func(r api.CancelQueueTaskRequestObject) string { return r.TaskId }, nil),
+	"CancelRequest":             auditChange("request", func(r api.CancelRequestRequestObject) string { return r.RequestId.String() }, loadByID((*db.Queries).GetRequestById)),
+	"CancelReturnCampaign":      auditChange("return_campaign", func(r api.CancelReturnCampaignRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetReturnCampaignByID)),
+	"CheckoutCart":              auditAction("checkout"),
+	"CheckoutGroupCart":         auditAction("checkout"),
+	"ClearCart":                 notAudited(),
+	"CloseStocktake":            auditChange("stocktake", func(r api.CloseStocktakeRequestObject) string { return r.StocktakeId.String() }, loadByID((*db.Queries).GetStocktakeByID)),
+	"CompleteItemMaintenance":   auditChange("item_maintenance", func(r api.CompleteItemMaintenanceRequestObject) string { return r.MaintenanceId.String() }, loadByID((*db.Queries).GetItemMaintenanceByID)),
+	"ConfirmBooking":            auditChange("booking", func(r api.ConfirmBookingRequestObject) string { return r.BookingId.String() }, loadByID((*db.Queries).GetBookingByID)),
+	"ConfirmMFA":                auditAction("mfa"),
+	"CreateApiKey":              auditCreate("api_key", func(r api.CreateApiKey201JSONResponse) uuid.UUID { return r.ApiKey.Id }, nil),
+	"CreateApprovalDelegation":  auditCreate("approval_delegation", func(r api.CreateApprovalDelegation201JSONResponse) uuid.UUID { return r.Id }, nil),
+	"CreateAvailability":        auditCreate("availability", func(r api.CreateAvailability201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetAvailabilityByID)),
+	"CreateBorrowingBlackout":   auditCreate("borrowing_blackout", func(r api.CreateBorrowingBlackout201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetBorrowingBlackout)),
+	"CreateBorrowingPolicy":     auditCreate("borrowing_policy", func(r api.CreateBorrowingPolicy201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetBorrowingPolicy)),
+	"CreateCategory":            auditCreate("category", func(r api.CreateCategory201JSONResponse) uuid.UUID { return r.Id }, nil),
+	"CreateGroup":               auditCreate("group", func(r api.CreateGroup201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetGroupByID)),
+	"CreateItem":                auditCreate("item", func(r api.CreateItem201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetItemByID)),
+	"CreateItemUnit":            auditCreate("item_unit", func(r api.CreateItemUnit201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetItemUnitByID)),
+	"CreateMyCalendarFeedToken": notAudited(),
+	"CreateReport":              auditCreate("report", func(r api.CreateReport202JSONResponse) uuid.UUID { return r.Id }, nil),
+	"CreateRequestComment":      auditCreate("request_comment", func(r api.CreateRequestComment201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetRequestComment)),
+	"CreateReturnCampaign":      auditCreate("return_campaign", func(r api.CreateReturnCampaign201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetReturnCampaignByID)),
+	"CreateRole": auditChange("role", func(r api.CreateRoleRequestObject) string {
+		if r.Body == nil {
+			return ""
+		}
+		return r.Body.Name
+	}, loadRole),
+	"CreateRoutingRule":               auditCreate("routing_rule", func(r api.CreateRoutingRule201JSONResponse) uuid.UUID { return r.Id }, nil),
+	"CreateWebhook":                   auditCreate("webhook", func(r api.CreateWebhook201JSONResponse) uuid.UUID { return r.Webhook.Id }, nil),
+	"CreateWeeklyAvailability":        auditAction("availability"),
+	"DecideBorrowingExtension":        auditChange("borrowing_extension", func(r api.DecideBorrowingExtensionRequestObject) string { return r.ExtensionId.String() }, loadByID((*db.Queries).GetBorrowingExtensionByID)),
+	"DecideGroupJoinRequest":          auditChange("group_join_request", func(r api.DecideGroupJoinRequestRequestObject) string { return r.RequestId.String() }, loadByID((*db.Queries).GetGroupJoinRequestByID)),
+	"DeleteApprovalDelegation":        auditChange("approval_delegation", func(r api.DeleteApprovalDelegationRequestObject) string { return r.DelegationId.String() }, nil),
+	"DeleteAvailability":              auditChange("availability", func(r api.DeleteAvailabilityRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetAvailabilityByID)),
+	"DeleteBorrowingBlackout":         auditChange("borrowing_blackout", func(r api.DeleteBorrowingBlackoutRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetBorrowingBlackout)),
+	"DeleteBorrowingImage":            auditChange("borrowing_image", func(r api.DeleteBorrowingImageRequestObject) string { return r.ImageId.String() }, loadByID((*db.Queries).GetBorrowingImageByID)),
+	"DeleteBorrowingPolicy":           auditChange("borrowing_policy", func(r api.DeleteBorrowingPolicyRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetBorrowingPolicy)),
+	"DeleteGroup":                     auditChange("group", func(r api.DeleteGroupRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetGroupByID)),
+	"DeleteItem":                      auditChange("item", func(r api.DeleteItemRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetItemByID)),
+	"DeleteItemImage":                 auditChange("item_image", func(r api.DeleteItemImageRequestObject) string { return r.ImageId.String() }, loadByID((*db.Queries).GetItemImageByID)),
+	"DeleteMyCalendarLink":            notAudited(),
+	"DeleteRoutingRule":               auditChange("routing_rule", func(r api.DeleteRoutingRuleRequestObject) string { return r.Id.String() }, nil),
+	"DeleteWebhook":                   auditChange("webhook", func(r api.DeleteWebhookRequestObject) string { return r.Id.String() }, nil),
+	"DrainQueue":                      auditChange("queue", func(r api.DrainQueueRequestObject) string { return r.Queue }, nil),
+	"EnrollMFA":                       auditAction("mfa"),
+	"ImportItems":                     auditAction("item"),
+	"ImportUsers":                     auditAction("user"),
+	"InviteUser":                      auditAction("user"),
+	"JoinItemWaitlist":                auditChange("item_waitlist", func(r api.JoinItemWaitlistRequestObject) string { return r.ItemId.String() }, nil),
+	"LeaveItemWaitlist":               auditChange("item_waitlist", func(r api.LeaveItemWaitlistRequestObject) string { return r.ItemId.String() }, nil),
+	"LiftEmailSuppression":            auditChange("email_suppression", func(r api.LiftEmailSuppressionRequestObject) string { return r.UserId.String() }, nil),
+	"Logout":                          notAudited(),
+	"MarkAllNotificationsAsRead":      notAudited(),
+	"MarkMyNotificationRead":          notAudited(),
+	"MarkNotificationAsRead":          notAudited(),
+	"OpenStocktake":                   auditCreate("stocktake", func(r api.OpenStocktake201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetStocktakeByID)),
+	"PatchItem":                       auditChange("item", func(r api.PatchItemRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetItemByID)),
+	"PauseQueue":                      auditChange("queue", func(r api.PauseQueueRequestObject) string { return r.Queue }, nil),
+	"PayFine":                         auditChange("fine", func(r api.PayFineRequestObject) string { return r.FineId.String() }, loadByID((*db.Queries).GetFineByID)),
+	"PresignItemImageUpload":          notAudited(), // changes nothing until the upload is confirmed
+	"PromoteGroup":                    auditChange("group", func(r api.PromoteGroupRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetGroupByID)),
+	"RecordBookingPickup":             auditChange("booking", func(r api.RecordBookingPickupRequestObject) string { return r.BookingId.String() }, loadByID((*db.Queries).GetBookingByID)),
+	"RecordBookingReturn":             auditChange("booking", func(r api.RecordBookingReturnRequestObject) string { return r.BookingId.String() }, loadByID((*db.Queries).GetBookingByID)),
+	"RecordStocktakeCount":            auditChange("stocktake", func(r api.RecordStocktakeCountRequestObject) string { return r.StocktakeId.String() }, nil),
+	"RefreshToken":                    notAudited(),
+	"RejectDeletionRequest":           auditChange("deletion_request", func(r api.RejectDeletionRequestRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetDeletionRequestByID)),
+	"RemoveFromCart":                  notAudited(),
+	"RemoveGroupBookingPolicy":        auditChange("group_booking_policy", func(r api.RemoveGroupBookingPolicyRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetGroupBookingPolicy)),
+	"RemoveGroupRequestSLA":           auditChange("group_request_sla", func(r api.RemoveGroupRequestSLARequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetGroupRequestSLA)),
+	"RemoveItemFairnessPolicy":        auditChange("item_fairness_policy", func(r api.RemoveItemFairnessPolicyRequestObject) string { return r.ItemId.String() }, loadByID((*db.Queries).GetFairnessPolicy)),
+	"RequestBorrowingExtension":       auditCreate("borrowing_extension", func(r api.RequestBorrowingExtension201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetBorrowingExtensionByID)),
+	"RequestGroupJoin":                auditCreate("group_join_request", func(r api.RequestGroupJoin201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetGroupJoinRequestByID)),
+	"RequestItem":                     auditCreate("request", func(r api.RequestItem201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetRequestById)),
+	"RequestOTP":                      notAudited(),
+	"RescheduleBooking":               auditChange("booking", func(r api.RescheduleBookingRequestObject) string { return r.BookingId.String() }, loadByID((*db.Queries).GetBookingByID)),
+	"RestoreDeletionRequest":          auditChange("deletion_request", func(r api.RestoreDeletionRequestRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetDeletionRequestByID)),
+	"RestoreItem":                     auditChange("item", func(r api.RestoreItemRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetItemByIDIncludingBinned)),
+	"RestoreTrashEntry":               auditChange("trash", func(r api.RestoreTrashEntryRequestObject) string { return r.EntityId.String() }, nil),
+	"ResumeQueue":                     auditChange("queue", func(r api.ResumeQueueRequestObject) string { return r.Queue }, nil),
+	"ReturnItem":                      auditChange("item", func(r api.ReturnItemRequestObject) string { return r.ItemId.String() }, nil),
+	"RevokeApiKey":                    auditChange("api_key", func(r api.RevokeApiKeyRequestObject) string { return r.Id.String() }, nil),
+	"RevokeMyCalendarFeedToken":       notAudited(),
+	"RevokeUserRole":                  auditChange("user_roles", func(r api.RevokeUserRoleRequestObject) string { return r.UserId.String() }, loadUserRoles),
+	"ReviewRequest":                   auditChange("request", func(r api.ReviewRequestRequestObject) string { return r.RequestId.String() }, loadByID((*db.Queries).GetRequestById)),
+	"RunReturnCampaign":               auditChange("return_campaign", func(r api.RunReturnCampaignRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetReturnCampaignByID)),
+	"SetGroupBookingPolicy":           auditChange("group_booking_policy", func(r api.SetGroupBookingPolicyRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetGroupBookingPolicy)),
+	"SetGroupRequestSLA":              auditChange("group_request_sla", func(r api.SetGroupRequestSLARequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetGroupRequestSLA)),
+	"SetItemFairnessPolicy":           auditChange("item_fairness_policy", func(r api.SetItemFairnessPolicyRequestObject) string { return r.ItemId.String() }, loadByID((*db.Queries).GetFairnessPolicy)),
+	"SetItemFees":                     auditChange("item_fees", func(r api.SetItemFeesRequestObject) string { return r.ItemId.String() }, loadByID((*db.Queries).GetItemFees)),
+	"SetItemPrimaryImage":             auditChange("item_image", func(r api.SetItemPrimaryImageRequestObject) string { return r.ImageId.String() }, loadByID((*db.Queries).GetItemImageByID)),
+	"SetItemVisibility":               auditChange("item_visibility", func(r api.SetItemVisibilityRequestObject) string { return r.ItemId.String() }, loadItemVisibility),
+	"SetMyCalendarLink":               notAudited(),
+	"SetMyStudentId":                  auditAction("student_id"),
+	"SetRolePermissions":              auditChange("role", func(r api.SetRolePermissionsRequestObject) string { return r.RoleName }, loadRole),
+	"SetUserStudentId":                auditChange("student_id", func(r api.SetUserStudentIdRequestObject) string { return r.UserId.String() }, nil),
+	"StartItemMaintenance":            auditCreate("item_maintenance", func(r api.StartItemMaintenance201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetItemMaintenanceByID)),
+	"UpdateBorrowingPolicy":           auditChange("borrowing_policy", func(r api.UpdateBorrowingPolicyRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetBorrowingPolicy)),
+	"UpdateCartItemQuantity":          notAudited(),
+	"UpdateDamageReport":              auditChange("damage_report", func(r api.UpdateDamageReportRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetDamageReportByID)),
+	"UpdateGroup":                     auditChange("group", func(r api.UpdateGroupRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetGroupByID)),
+	"UpdateItem":                      auditChange("item", func(r api.UpdateItemRequestObject) string { return r.Id.String() }, loadByID((*db.Queries).GetItemByID)),
+	"UpdateItemUnit":                  auditChange("item_unit", func(r api.UpdateItemUnitRequestObject) string { return r.UnitId.String() }, loadByID((*db.Queries).GetItemUnitByID)),
+	"UpdateMyNotificationPreferences": notAudited(),
+	"UpdateMyPreferences":             notAudited(),
+	"UploadBorrowingImage":            auditCreate("borrowing_image", func(r api.UploadBorrowingImage201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetBorrowingImageByID)),
+	"UploadGroupLogo":                 auditChange("group", func(r api.UploadGroupLogoRequestObject) string { return r.GroupId.String() }, loadByID((*db.Queries).GetGroupByID)),
+	"UploadItemImage":                 auditCreate("item_image", func(r api.UploadItemImage201JSONResponse) uuid.UUID { return r.Id }, loadByID((*db.Queries).GetItemImageByID)),
+	"VerifyBookingIdentity":           auditChange("booking", func(r api.VerifyBookingIdentityRequestObject) string { return r.BookingId.String() }, nil),
+	"VerifyMFA":                       notAudited(),
+	"WaiveFine":                       auditChange("fine", func(r api.WaiveFineRequestObject) string { return r.FineId.String() }, loadByID((*db.Queries).GetFineByID)),
+	"VerifyOTP":                       notAudited(),
+}
@@ -26,15 +26,17 @@ func (s Server) RequestOTP(ctx context.Context, request api.RequestOTPRequestObj
 		if errors.Is(err, internalauth.ErrUserNotFound) {
 			return api.RequestOTP200JSONResponse{Message: "A login code has been sent if your email is registered."}, nil
 		}
-		if errors.Is(err, internalauth.ErrOTPCooldown) {
-			logger.Warn("OTP request blocked by cooldown", "email", email)
-			return api.RequestOTP429JSONResponse(ValidationErr("Please wait before requesting another code.", nil).Create()), nil
+		if apiErr := errorFor(err); apiErr.Code == CodeRateLimited {
+			logger.Warn("OTP request rate limited", "email", email, "reason", err)
+			return api.RequestOTP429JSONResponse(apiErr.Create()), nil
 		}
 		logger.Error("Failed to generate OTP", "email", email, "error", err)
 		return api.RequestOTP500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
 	}
 
-	_, err = s.queue.Enqueue(queue.TypeEmailDelivery, queue.EmailDeliveryPayload{
+	// sent directly rather than through the dispatcher: a login code has to
+	// arrive whatever the user's email preferences say
+	_, err = s.queue.Enqueue(ctx, queue.TypeEmailDelivery, queue.EmailDeliveryPayload{
 		To:      email,
 		Subject: "Your Campus Vault login code",
 		Body:    fmt.Sprintf("Your one-time login code is: %s\n\nThis code expires in %d minutes.", code, int(s.authService.OTPExpiry().Minutes())),
@@ -59,17 +61,16 @@ func (s Server) VerifyOTP(ctx context.Context, request api.VerifyOTPRequestObjec
 
 	accessToken, refreshToken, err := s.authService.VerifyOTP(ctx, email, code)
 	if err != nil {
-		if errors.Is(err, internalauth.ErrOTPInvalid) {
-			logger.Warn("OTP verification failed: invalid code", "email", email)
-			return api.VerifyOTP400JSONResponse(ValidationErr("Invalid or expired code.", nil).Create()), nil
-		}
-		if errors.Is(err, internalauth.ErrOTPMaxAttempts) {
-			logger.Warn("OTP verification failed: max attempts exceeded", "email", email)
-			return api.VerifyOTP400JSONResponse(ValidationErr("Invalid or expired code.", nil).Create()), nil
-		}
 		if errors.Is(err, internalauth.ErrUserNotFound) {
 			logger.Warn("OTP verification failed: user deleted between OTP request and verify", "email", email)
-			return api.VerifyOTP400JSONResponse(ValidationErr("Invalid or expired code.", nil).Create()), nil
+			return api.VerifyOTP400JSONResponse(errorFor(internalauth.ErrOTPInvalid).Create()), nil
+		}
+		switch apiErr := errorFor(err); apiErr.Code {
+		case CodeValidationError:
+			logger.Warn("OTP verification failed", "email", email, "reason", err)
+			return api.VerifyOTP400JSONResponse(apiErr.Create()), nil
+		case CodeRateLimited:
+			return api.VerifyOTP429JSONResponse(apiErr.Create()), nil
 		}
 		logger.Error("Failed to verify OTP", "email", email, "error", err)
 		return api.VerifyOTP500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
@@ -91,9 +92,9 @@ func (s Server) RefreshToken(ctx context.Context, request api.RefreshTokenReques
 
 	accessToken, refreshToken, err := s.authService.Refresh(ctx, request.Body.RefreshToken)
 	if err != nil {
-		if errors.Is(err, internalauth.ErrRefreshInvalid) {
+		if apiErr := errorFor(err); apiErr.Code == CodeAuthRequired {
 			logger.Warn("Refresh token rejected: invalid or expired")
-			return api.RefreshToken401JSONResponse(Unauthorized("Invalid or expired refresh token.").Create()), nil
+			return api.RefreshToken401JSONResponse(apiErr.Create()), nil
 		}
 		logger.Error("Failed to refresh token", "error", err)
 		return api.RefreshToken500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
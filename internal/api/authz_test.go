@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicies_CoverEveryOperation(t *testing.T) {
+	operations := reflect.TypeOf((*api.StrictServerInterface)(nil)).Elem()
+	names := make(map[string]bool, operations.NumMethod())
+	for i := 0; i < operations.NumMethod(); i++ {
+		name := operations.Method(i).Name
+		names[name] = true
+		assert.Contains(t, policies, name, "operation has no authorization policy")
+	}
+	for name := range policies {
+		assert.True(t, names[name], "policy %q names no operation", name)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	userCtx := func(userID uuid.UUID) context.Context {
+		return context.WithValue(context.Background(), auth.UserClaimsKey, &auth.AuthenticatedUser{ID: userID})
+	}
+
+	// runs operationID through the middleware, reporting the status written
+	// and whether the handler was reached
+	run := func(t *testing.T, mockAuth *testutil.MockAuthenticator, ctx context.Context, operationID string, request interface{}) (int, bool) {
+		reached := false
+		next := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+			reached = true
+			return nil, nil
+		}
+		handler := Authorize(mockAuth)(next, operationID)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		_, err := handler(ctx, w, r, request)
+		require.NoError(t, err)
+		return w.Code, reached
+	}
+
+	t.Run("public operations need no user", func(t *testing.T) {
+		mockAuth := testutil.NewMockAuthenticator(t)
+		_, reached := run(t, mockAuth, context.Background(), "HealthCheck", api.HealthCheckRequestObject{})
+		assert.True(t, reached)
+	})
+
+	t.Run("everything else needs a user", func(t *testing.T) {
+		mockAuth := testutil.NewMockAuthenticator(t)
+		status, reached := run(t, mockAuth, context.Background(), "GetNotifications", api.GetNotificationsRequestObject{})
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.False(t, reached)
+	})
+
+	t.Run("authenticated operations leave the rest to the handler", func(t *testing.T) {
+		mockAuth := testutil.NewMockAuthenticator(t)
+		_, reached := run(t, mockAuth, userCtx(uuid.New()), "GetNotifications", api.GetNotificationsRequestObject{})
+		assert.True(t, reached)
+		mockAuth.AssertNotCalled(t, "CheckPermission")
+	})
+
+	t.Run("missing permission is refused", func(t *testing.T) {
+		mockAuth := testutil.NewMockAuthenticator(t)
+		userID := uuid.New()
+		mockAuth.ExpectCheckPermission(userID, rbac.ManageUsers, nil, false, nil)
+
+		status, reached := run(t, mockAuth, userCtx(userID), "CreateRole", api.CreateRoleRequestObject{})
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.False(t, reached)
+	})
+
+	t.Run("held permission reaches the handler", func(t *testing.T) {
+		mockAuth := testutil.NewMockAuthenticator(t)
+		userID := uuid.New()
+		mockAuth.ExpectCheckPermission(userID, rbac.ManageUsers, nil, true, nil)
+
+		_, reached := run(t, mockAuth, userCtx(userID), "AssignUserRole", api.AssignUserRoleRequestObject{})
+		assert.True(t, reached)
+	})
+
+	t.Run("scoped permission is checked in the request's group", func(t *testing.T) {
+		mockAuth := testutil.NewMockAuthenticator(t)
+		userID := uuid.New()
+		groupID := uuid.New()
+		mockAuth.ExpectCheckPermission(userID, rbac.ManageCart, &groupID, true, nil)
+
+		_, reached := run(t, mockAuth, userCtx(userID), "GetCart", api.GetCartRequestObject{GroupId: groupID})
+		assert.True(t, reached)
+		mockAuth.AssertExpectations(t)
+	})
+
+	t.Run("pending two-factor authentication is refused before any check", func(t *testing.T) {
+		mockAuth := testutil.NewMockAuthenticator(t)
+		ctx := context.WithValue(context.Background(), auth.UserClaimsKey, &auth.AuthenticatedUser{ID: uuid.New(), MFAPending: true})
+
+		status, reached := run(t, mockAuth, ctx, "CreateRole", api.CreateRoleRequestObject{})
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.False(t, reached)
+		mockAuth.AssertNotCalled(t, "CheckPermission")
+
+		_, reached = run(t, mockAuth, ctx, "VerifyMFA", api.VerifyMFARequestObject{})
+		assert.True(t, reached)
+	})
+
+	t.Run("rate limited API keys are refused", func(t *testing.T) {
+		mockAuth := testutil.NewMockAuthenticator(t)
+		ctx := context.WithValue(context.Background(), auth.UserClaimsKey, &auth.AuthenticatedUser{ID: uuid.New(), RateLimited: true})
+
+		status, reached := run(t, mockAuth, ctx, "GetNotifications", api.GetNotificationsRequestObject{})
+		assert.Equal(t, http.StatusTooManyRequests, status)
+		assert.False(t, reached)
+	})
+
+	t.Run("check failure is an internal error", func(t *testing.T) {
+		mockAuth := testutil.NewMockAuthenticator(t)
+		userID := uuid.New()
+		mockAuth.ExpectCheckPermission(userID, rbac.ManageUsers, nil, false, errors.New("connection reset"))
+
+		status, reached := run(t, mockAuth, userCtx(userID), "ListRoles", api.ListRolesRequestObject{})
+		assert.Equal(t, http.StatusInternalServerError, status)
+		assert.False(t, reached)
+	})
+
+	t.Run("unknown operations are refused", func(t *testing.T) {
+		mockAuth := testutil.NewMockAuthenticator(t)
+		status, reached := run(t, mockAuth, userCtx(uuid.New()), "LaunchRockets", nil)
+		assert.Equal(t, http.StatusInternalServerError, status)
+		assert.False(t, reached)
+	})
+}
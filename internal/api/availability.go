@@ -3,6 +3,8 @@ package api
 import (
 	"github.com/USSTM/cv-backend/internal/rbac"
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/USSTM/cv-backend/generated/api"
@@ -10,10 +12,20 @@ import (
 	"github.com/USSTM/cv-backend/internal/auth"
 	"github.com/USSTM/cv-backend/internal/middleware"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
+const (
+	// how far ahead weekly availability can be published in one request
+	maxAvailabilityWeeks = 26
+
+	// the open slots window when none is given, and the widest allowed
+	defaultSlotWindowDays = 30
+	maxSlotWindowDays     = 90
+)
+
 // approvers set availability schedule
 func (s Server) CreateAvailability(ctx context.Context, request api.CreateAvailabilityRequestObject) (api.CreateAvailabilityResponseObject, error) {
 	logger := middleware.GetLoggerFromContext(ctx)
@@ -58,6 +70,24 @@ func (s Server) CreateAvailability(ctx context.Context, request api.CreateAvaila
 		return api.CreateAvailability409JSONResponse(ConflictErr("You already have availability set for this time slot on this date").Create()), nil
 	}
 
+	// a booking already picked up from you during this slot?
+	hasBooking, err := s.db.Queries().CheckAvailabilityBookingConflict(ctx, db.CheckAvailabilityBookingConflictParams{
+		TimeSlotID: request.Body.TimeSlotId,
+		UserID:     &user.ID,
+		Date: pgtype.Date{
+			Time:  date,
+			Valid: true,
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to check booking conflict", "error", err)
+		return api.CreateAvailability500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if hasBooking {
+		return api.CreateAvailability409JSONResponse(ConflictErr("You already have a booking during this time slot on this date").Create()), nil
+	}
+
 	// create
 	availability, err := s.db.Queries().CreateAvailability(ctx, db.CreateAvailabilityParams{
 		ID:         uuid.New(),
@@ -90,6 +120,147 @@ func (s Server) CreateAvailability(ctx context.Context, request api.CreateAvaila
 	}, nil
 }
 
+// publishes the same slots on the same weekday for several weeks. Occurrences
+// that clash with existing availability or bookings are skipped, not fatal.
+func (s Server) CreateWeeklyAvailability(ctx context.Context, request api.CreateWeeklyAvailabilityRequestObject) (api.CreateWeeklyAvailabilityResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateWeeklyAvailability401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageTimeSlots, nil)
+	if err != nil {
+		logger.Error("Failed to check permission", "error", err)
+		return api.CreateWeeklyAvailability500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if !hasPermission {
+		return api.CreateWeeklyAvailability403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body.Weeks < 1 || request.Body.Weeks > maxAvailabilityWeeks {
+		return api.CreateWeeklyAvailability400JSONResponse(ValidationErr(fmt.Sprintf("weeks must be between 1 and %d", maxAvailabilityWeeks), nil).Create()), nil
+	}
+	if len(request.Body.TimeSlotIds) == 0 {
+		return api.CreateWeeklyAvailability400JSONResponse(ValidationErr("At least one time slot is required", nil).Create()), nil
+	}
+
+	start := request.Body.StartDate.Time
+	if start.Before(time.Now().Truncate(24 * time.Hour)) {
+		return api.CreateWeeklyAvailability400JSONResponse(ValidationErr("Date must be in the future", nil).Create()), nil
+	}
+
+	// each slot once, in the order given
+	timeSlots := make([]db.TimeSlot, 0, len(request.Body.TimeSlotIds))
+	seen := make(map[uuid.UUID]bool, len(request.Body.TimeSlotIds))
+	for _, id := range request.Body.TimeSlotIds {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		timeSlot, err := s.db.Queries().GetTimeSlotByID(ctx, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.CreateWeeklyAvailability400JSONResponse(ValidationErr("Unknown time slot", []ErrorDetail{{Field: "time_slot_ids", Message: id.String() + " does not exist"}}).Create()), nil
+		}
+		if err != nil {
+			logger.Error("Failed to fetch time slot", "time_slot_id", id, "error", err)
+			return api.CreateWeeklyAvailability500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+		}
+		timeSlots = append(timeSlots, timeSlot)
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.CreateWeeklyAvailability500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	response := api.CreateWeeklyAvailability201JSONResponse{
+		Created: []api.AvailabilityResponse{},
+		Skipped: []api.SkippedAvailability{},
+	}
+	for week := 0; week < request.Body.Weeks; week++ {
+		date := pgtype.Date{Time: start.AddDate(0, 0, 7*week), Valid: true}
+
+		for _, timeSlot := range timeSlots {
+			hasConflict, err := qtx.CheckAvailabilityConflict(ctx, db.CheckAvailabilityConflictParams{
+				UserID:     &user.ID,
+				TimeSlotID: &timeSlot.ID,
+				Date:       date,
+			})
+			if err != nil {
+				logger.Error("Failed to check availability conflict", "error", err)
+				return api.CreateWeeklyAvailability500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+			}
+			if hasConflict {
+				response.Skipped = append(response.Skipped, api.SkippedAvailability{
+					TimeSlotId: timeSlot.ID,
+					Date:       openapi_types.Date{Time: date.Time},
+					Reason:     api.AlreadyAvailable,
+				})
+				continue
+			}
+
+			hasBooking, err := qtx.CheckAvailabilityBookingConflict(ctx, db.CheckAvailabilityBookingConflictParams{
+				TimeSlotID: timeSlot.ID,
+				UserID:     &user.ID,
+				Date:       date,
+			})
+			if err != nil {
+				logger.Error("Failed to check booking conflict", "error", err)
+				return api.CreateWeeklyAvailability500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+			}
+			if hasBooking {
+				response.Skipped = append(response.Skipped, api.SkippedAvailability{
+					TimeSlotId: timeSlot.ID,
+					Date:       openapi_types.Date{Time: date.Time},
+					Reason:     api.BookingConflict,
+				})
+				continue
+			}
+
+			availability, err := qtx.CreateAvailability(ctx, db.CreateAvailabilityParams{
+				ID:         uuid.New(),
+				UserID:     &user.ID,
+				TimeSlotID: &timeSlot.ID,
+				Date:       date,
+			})
+			if err != nil {
+				logger.Error("Failed to create availability", "error", err)
+				return api.CreateWeeklyAvailability500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+			}
+
+			response.Created = append(response.Created, api.AvailabilityResponse{
+				Id:         availability.ID,
+				UserId:     *availability.UserID,
+				TimeSlotId: *availability.TimeSlotID,
+				Date:       openapi_types.Date{Time: availability.Date.Time},
+				UserEmail:  openapi_types.Email(user.Email),
+				StartTime:  formatPgTime(timeSlot.StartTime),
+				EndTime:    formatPgTime(timeSlot.EndTime),
+			})
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit weekly availability", "error", err)
+		return api.CreateWeeklyAvailability500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	logger.Info("Weekly availability created",
+		"user_id", user.ID,
+		"created", len(response.Created),
+		"skipped", len(response.Skipped))
+
+	return response, nil
+}
+
 // filter availability
 func (s Server) ListAvailability(ctx context.Context, request api.ListAvailabilityRequestObject) (api.ListAvailabilityResponseObject, error) {
 	logger := middleware.GetLoggerFromContext(ctx)
@@ -112,9 +283,23 @@ func (s Server) ListAvailability(ctx context.Context, request api.ListAvailabili
 		userIDParam = &uid
 	}
 
+	// date range filter
+	var fromDate, toDate pgtype.Date
+	if request.Params.FromDate != nil {
+		fromDate = pgtype.Date{Time: request.Params.FromDate.Time, Valid: true}
+	}
+	if request.Params.ToDate != nil {
+		toDate = pgtype.Date{Time: request.Params.ToDate.Time, Valid: true}
+	}
+	if fromDate.Valid && toDate.Valid && toDate.Time.Before(fromDate.Time) {
+		return api.ListAvailability400JSONResponse(ValidationErr("to_date must not be before from_date", nil).Create()), nil
+	}
+
 	availabilities, err := s.db.Queries().ListAvailability(ctx, db.ListAvailabilityParams{
 		FilterDate:   dateParam,
 		FilterUserID: userIDParam,
+		FromDate:     fromDate,
+		ToDate:       toDate,
 	})
 	if err != nil {
 		logger.Error("Failed to list availability", "error", err)
@@ -138,6 +323,71 @@ func (s Server) ListAvailability(ctx context.Context, request api.ListAvailabili
 	return response, nil
 }
 
+// open pickup slots a requester can pick from while requesting an item
+func (s Server) GetItemAvailableSlots(ctx context.Context, request api.GetItemAvailableSlotsRequestObject) (api.GetItemAvailableSlotsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetItemAvailableSlots401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewItems, nil)
+	if err != nil {
+		logger.Error("Failed to check permission", "error", err)
+		return api.GetItemAvailableSlots500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if !hasPermission {
+		return api.GetItemAvailableSlots403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	from := time.Now().Truncate(24 * time.Hour)
+	if request.Params.FromDate != nil {
+		from = request.Params.FromDate.Time
+	}
+	to := from.AddDate(0, 0, defaultSlotWindowDays)
+	if request.Params.ToDate != nil {
+		to = request.Params.ToDate.Time
+	}
+	if to.Before(from) {
+		return api.GetItemAvailableSlots400JSONResponse(ValidationErr("to_date must not be before from_date", nil).Create()), nil
+	}
+	if to.After(from.AddDate(0, 0, maxSlotWindowDays)) {
+		return api.GetItemAvailableSlots400JSONResponse(ValidationErr(fmt.Sprintf("Date range cannot exceed %d days", maxSlotWindowDays), nil).Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetItemByID(ctx, request.ItemId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.GetItemAvailableSlots404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.GetItemAvailableSlots500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	slots, err := s.db.Queries().ListOpenAvailability(ctx, db.ListOpenAvailabilityParams{
+		FromDate: pgtype.Date{Time: from, Valid: true},
+		ToDate:   pgtype.Date{Time: to, Valid: true},
+	})
+	if err != nil {
+		logger.Error("Failed to list open availability", "item_id", request.ItemId, "error", err)
+		return api.GetItemAvailableSlots500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	response := make(api.GetItemAvailableSlots200JSONResponse, 0, len(slots))
+	for _, slot := range slots {
+		response = append(response, api.AvailableSlot{
+			AvailabilityId: slot.ID,
+			TimeSlotId:     *slot.TimeSlotID,
+			Date:           openapi_types.Date{Time: slot.Date.Time},
+			StartTime:      formatPgTime(slot.StartTime),
+			EndTime:        formatPgTime(slot.EndTime),
+		})
+	}
+
+	return response, nil
+}
+
 // returns approvers available on date
 func (s Server) GetAvailabilityByDate(ctx context.Context, request api.GetAvailabilityByDateRequestObject) (api.GetAvailabilityByDateResponseObject, error) {
 	logger := middleware.GetLoggerFromContext(ctx)
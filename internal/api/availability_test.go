@@ -221,6 +221,36 @@ func TestServer_ListAvailability(t *testing.T) {
 		assert.Equal(t, approver1.ID, resp[0].UserId)
 	})
 
+	t.Run("filter by from_date and to_date", func(t *testing.T) {
+		from := toOpenAPIDate(date1.AddDate(0, 0, 1))
+		to := toOpenAPIDate(date2)
+		response, err := server.ListAvailability(ctx, api.ListAvailabilityRequestObject{
+			Params: api.ListAvailabilityParams{
+				FromDate: &from,
+				ToDate:   &to,
+			},
+		})
+
+		require.NoError(t, err)
+		resp := response.(api.ListAvailability200JSONResponse)
+		assert.Len(t, resp, 1)
+		assert.Equal(t, approver2.ID, resp[0].UserId)
+	})
+
+	t.Run("to_date before from_date", func(t *testing.T) {
+		from := toOpenAPIDate(date2)
+		to := toOpenAPIDate(date1)
+		response, err := server.ListAvailability(ctx, api.ListAvailabilityRequestObject{
+			Params: api.ListAvailabilityParams{
+				FromDate: &from,
+				ToDate:   &to,
+			},
+		})
+
+		require.NoError(t, err)
+		require.IsType(t, api.ListAvailability400JSONResponse{}, response)
+	})
+
 	t.Run("fail - unauthorized", func(t *testing.T) {
 		ctx := context.Background()
 		response, err := server.ListAvailability(ctx, api.ListAvailabilityRequestObject{})
@@ -517,3 +547,216 @@ func TestServer_DeleteAvailability(t *testing.T) {
 		require.IsType(t, api.DeleteAvailability404JSONResponse{}, response)
 	})
 }
+
+func TestServer_CreateWeeklyAvailability(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("creates each slot every week", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		approver := testDB.NewUser(t).WithEmail("approver@weekly.test").AsApprover().Create()
+		ctx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+
+		timeSlots, _ := testDB.Queries().ListTimeSlots(ctx)
+		require.GreaterOrEqual(t, len(timeSlots), 2)
+
+		start := time.Now().AddDate(0, 0, 7)
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageTimeSlots, nil, true, nil)
+
+		response, err := server.CreateWeeklyAvailability(ctx, api.CreateWeeklyAvailabilityRequestObject{
+			Body: &api.CreateWeeklyAvailabilityRequest{
+				TimeSlotIds: []uuid.UUID{timeSlots[0].ID, timeSlots[1].ID, timeSlots[0].ID},
+				StartDate:   toOpenAPIDate(start),
+				Weeks:       3,
+			},
+		})
+
+		require.NoError(t, err)
+		require.IsType(t, api.CreateWeeklyAvailability201JSONResponse{}, response)
+
+		resp := response.(api.CreateWeeklyAvailability201JSONResponse)
+		assert.Len(t, resp.Created, 6)
+		assert.Empty(t, resp.Skipped)
+		assert.Equal(t, start.AddDate(0, 0, 14).Format("2006-01-02"), resp.Created[5].Date.Time.Format("2006-01-02"))
+	})
+
+	t.Run("skips slots that are already available", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		approver := testDB.NewUser(t).WithEmail("approver@weekly.test").AsApprover().Create()
+		ctx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+
+		timeSlots, _ := testDB.Queries().ListTimeSlots(ctx)
+		start := time.Now().AddDate(0, 0, 7)
+
+		_, err := testDB.Queries().CreateAvailability(ctx, db.CreateAvailabilityParams{
+			ID:         uuid.New(),
+			UserID:     &approver.ID,
+			TimeSlotID: &timeSlots[0].ID,
+			Date:       pgtype.Date{Time: start.AddDate(0, 0, 7), Valid: true},
+		})
+		require.NoError(t, err)
+
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageTimeSlots, nil, true, nil)
+
+		response, err := server.CreateWeeklyAvailability(ctx, api.CreateWeeklyAvailabilityRequestObject{
+			Body: &api.CreateWeeklyAvailabilityRequest{
+				TimeSlotIds: []uuid.UUID{timeSlots[0].ID},
+				StartDate:   toOpenAPIDate(start),
+				Weeks:       2,
+			},
+		})
+
+		require.NoError(t, err)
+		resp := response.(api.CreateWeeklyAvailability201JSONResponse)
+		assert.Len(t, resp.Created, 1)
+		require.Len(t, resp.Skipped, 1)
+		assert.Equal(t, api.AlreadyAvailable, resp.Skipped[0].Reason)
+		assert.Equal(t, start.AddDate(0, 0, 7).Format("2006-01-02"), resp.Skipped[0].Date.Time.Format("2006-01-02"))
+	})
+
+	t.Run("unknown time slot", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		approver := testDB.NewUser(t).WithEmail("approver@weekly.test").AsApprover().Create()
+		ctx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageTimeSlots, nil, true, nil)
+
+		response, err := server.CreateWeeklyAvailability(ctx, api.CreateWeeklyAvailabilityRequestObject{
+			Body: &api.CreateWeeklyAvailabilityRequest{
+				TimeSlotIds: []uuid.UUID{uuid.New()},
+				StartDate:   toOpenAPIDate(time.Now().AddDate(0, 0, 7)),
+				Weeks:       1,
+			},
+		})
+
+		require.NoError(t, err)
+		require.IsType(t, api.CreateWeeklyAvailability400JSONResponse{}, response)
+	})
+
+	t.Run("member cannot create weekly availability", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@weekly.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageTimeSlots, nil, false, nil)
+
+		response, err := server.CreateWeeklyAvailability(ctx, api.CreateWeeklyAvailabilityRequestObject{
+			Body: &api.CreateWeeklyAvailabilityRequest{
+				TimeSlotIds: []uuid.UUID{uuid.New()},
+				StartDate:   toOpenAPIDate(time.Now().AddDate(0, 0, 7)),
+				Weeks:       1,
+			},
+		})
+
+		require.NoError(t, err)
+		require.IsType(t, api.CreateWeeklyAvailability403JSONResponse{}, response)
+	})
+}
+
+func TestServer_GetItemAvailableSlots(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	member := testDB.NewUser(t).WithEmail("member@slots.test").AsMember().Create()
+	approver := testDB.NewUser(t).WithEmail("approver@slots.test").AsApprover().Create()
+	group := testDB.NewGroup(t).WithName("Slots Group").Create()
+	item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+
+	ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+	timeSlots, _ := testDB.Queries().ListTimeSlots(ctx)
+	require.GreaterOrEqual(t, len(timeSlots), 2)
+
+	date := time.Now().AddDate(0, 0, 7)
+	unclaimed, err := testDB.Queries().CreateAvailability(ctx, db.CreateAvailabilityParams{
+		ID:         uuid.New(),
+		UserID:     &approver.ID,
+		TimeSlotID: &timeSlots[0].ID,
+		Date:       pgtype.Date{Time: date, Valid: true},
+	})
+	require.NoError(t, err)
+
+	claimed, err := testDB.Queries().CreateAvailability(ctx, db.CreateAvailabilityParams{
+		ID:         uuid.New(),
+		UserID:     &approver.ID,
+		TimeSlotID: &timeSlots[1].ID,
+		Date:       pgtype.Date{Time: date, Valid: true},
+	})
+	require.NoError(t, err)
+
+	pickup := date.Truncate(24 * time.Hour).Add(time.Duration(timeSlots[1].StartTime.Microseconds) * time.Microsecond)
+	_, err = testDB.Queries().CreateBooking(ctx, db.CreateBookingParams{
+		ID:             uuid.New(),
+		RequesterID:    &member.ID,
+		ManagerID:      &approver.ID,
+		ItemID:         &item.ID,
+		GroupID:        &group.ID,
+		AvailabilityID: &claimed.ID,
+		PickUpDate:     pgtype.Timestamp{Time: pickup, Valid: true},
+		PickUpLocation: "Main Office",
+		ReturnDate:     pgtype.Timestamp{Time: pickup.Add(24 * time.Hour), Valid: true},
+		ReturnLocation: "Main Office",
+		Status:         db.RequestStatusPendingConfirmation,
+	})
+	require.NoError(t, err)
+
+	t.Run("lists slots no booking has claimed", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewItems, nil, true, nil)
+
+		response, err := server.GetItemAvailableSlots(ctx, api.GetItemAvailableSlotsRequestObject{
+			ItemId: item.ID,
+		})
+
+		require.NoError(t, err)
+		require.IsType(t, api.GetItemAvailableSlots200JSONResponse{}, response)
+
+		resp := response.(api.GetItemAvailableSlots200JSONResponse)
+		require.Len(t, resp, 1)
+		assert.Equal(t, unclaimed.ID, resp[0].AvailabilityId)
+		assert.Equal(t, timeSlots[0].ID, resp[0].TimeSlotId)
+		assert.NotEmpty(t, resp[0].StartTime)
+	})
+
+	t.Run("date range excludes later slots", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewItems, nil, true, nil)
+
+		to := toOpenAPIDate(date.AddDate(0, 0, -1))
+		response, err := server.GetItemAvailableSlots(ctx, api.GetItemAvailableSlotsRequestObject{
+			ItemId: item.ID,
+			Params: api.GetItemAvailableSlotsParams{ToDate: &to},
+		})
+
+		require.NoError(t, err)
+		assert.Empty(t, response.(api.GetItemAvailableSlots200JSONResponse))
+	})
+
+	t.Run("range too wide", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewItems, nil, true, nil)
+
+		to := toOpenAPIDate(time.Now().AddDate(1, 0, 0))
+		response, err := server.GetItemAvailableSlots(ctx, api.GetItemAvailableSlotsRequestObject{
+			ItemId: item.ID,
+			Params: api.GetItemAvailableSlotsParams{ToDate: &to},
+		})
+
+		require.NoError(t, err)
+		require.IsType(t, api.GetItemAvailableSlots400JSONResponse{}, response)
+	})
+
+	t.Run("unknown item", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewItems, nil, true, nil)
+
+		response, err := server.GetItemAvailableSlots(ctx, api.GetItemAvailableSlotsRequestObject{
+			ItemId: uuid.New(),
+		})
+
+		require.NoError(t, err)
+		require.IsType(t, api.GetItemAvailableSlots404JSONResponse{}, response)
+	})
+}
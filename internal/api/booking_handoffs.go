@@ -0,0 +1,395 @@
+package api
+
+import (
+	"context"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/bookingevents"
+	"github.com/USSTM/cv-backend/internal/damage"
+	"github.com/USSTM/cv-backend/internal/events"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// managers of every booking, or of the booking's group
+func (s Server) canManageBooking(ctx context.Context, userID uuid.UUID, groupID *uuid.UUID) (bool, error) {
+	canManage, err := s.authenticator.CheckPermission(ctx, userID, rbac.ManageAllBookings, nil)
+	if err != nil || canManage || groupID == nil {
+		return canManage, err
+	}
+	return s.authenticator.CheckPermission(ctx, userID, rbac.ManageGroupBookings, groupID)
+}
+
+func toBorrowingResponse(b db.Borrowing) api.BorrowingResponse {
+	resp := api.BorrowingResponse{
+		Id:                 b.ID,
+		ItemId:             *b.ItemID,
+		UserId:             *b.UserID,
+		GroupId:            b.GroupID,
+		Quantity:           int(b.Quantity),
+		DueDate:            b.DueDate.Time,
+		BorrowedAt:         b.BorrowedAt.Time,
+		BeforeCondition:    string(b.BeforeCondition),
+		BeforeConditionUrl: b.BeforeConditionUrl,
+	}
+	if b.ReturnedAt.Valid {
+		resp.ReturnedAt = &b.ReturnedAt.Time
+	}
+	if b.AfterCondition.Valid {
+		condition := string(b.AfterCondition.Condition)
+		resp.AfterCondition = &condition
+	}
+	if b.AfterConditionUrl.Valid {
+		resp.AfterConditionUrl = &b.AfterConditionUrl.String
+	}
+	resp.UnitId = b.UnitID
+	return resp
+}
+
+// Opens the borrowing for a confirmed booking once the requester has been
+// identified at the desk, and moves the booking to fulfilled. The loan is due
+// back at the booking's return date.
+func (s Server) RecordBookingPickup(ctx context.Context, request api.RecordBookingPickupRequestObject) (api.RecordBookingPickupResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RecordBookingPickup401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+	if request.Body == nil {
+		return api.RecordBookingPickup400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	if !damage.ValidCondition(db.Condition(request.Body.BeforeCondition)) {
+		return api.RecordBookingPickup400JSONResponse(ValidationErr("before_condition must be one of pristine, good, decent, damaged or unusable", nil).Create()), nil
+	}
+	if request.Body.BeforeConditionUrl == "" {
+		return api.RecordBookingPickup400JSONResponse(ValidationErr("before_condition_url is required", nil).Create()), nil
+	}
+
+	booking, err := s.db.Queries().GetBookingByID(ctx, request.BookingId)
+	if err == pgx.ErrNoRows {
+		return api.RecordBookingPickup404JSONResponse(NotFound("Booking").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get booking for pickup", "booking_id", request.BookingId, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	canManage, err := s.canManageBooking(ctx, user.ID, booking.GroupID)
+	if err != nil {
+		logger.Error("Failed to check booking management permission", "user_id", user.ID, "group_id", booking.GroupID, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if !canManage {
+		return api.RecordBookingPickup403JSONResponse(PermissionDenied("Insufficient permissions to check in this booking").Create()), nil
+	}
+	if booking.RequesterID == nil || booking.ItemID == nil {
+		return api.RecordBookingPickup400JSONResponse(ValidationErr("Booking no longer has a requester or item", nil).Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	// re-check under lock so two desks can't both hand the item over
+	locked, err := qtx.GetBookingByIDForUpdate(ctx, request.BookingId)
+	if err != nil {
+		logger.Error("Failed to lock booking", "booking_id", request.BookingId, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if locked.Status == db.RequestStatusFulfilled {
+		return api.RecordBookingPickup409JSONResponse(ConflictErr("Pickup has already been recorded for this booking").Create()), nil
+	}
+	if locked.Status != db.RequestStatusConfirmed {
+		return api.RecordBookingPickup400JSONResponse(ValidationErr("Only confirmed bookings can be picked up", nil).Create()), nil
+	}
+
+	verified, err := qtx.HasVerifiedBooking(ctx, locked.ID)
+	if err != nil {
+		logger.Error("Failed to check booking verification", "booking_id", locked.ID, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if !verified {
+		return api.RecordBookingPickup403JSONResponse(PermissionDenied("Identity must be verified at the desk before the item is handed over").Create()), nil
+	}
+
+	// the request behind the booking carries the quantity; bookings made
+	// without one are for a single item
+	quantity := int32(1)
+	var requestID *uuid.UUID
+	itemRequest, err := qtx.GetRequestByBookingID(ctx, &locked.ID)
+	if err != nil && err != pgx.ErrNoRows {
+		logger.Error("Failed to get request for booking", "booking_id", locked.ID, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if err == nil {
+		quantity = itemRequest.Quantity
+		requestID = &itemRequest.ID
+	}
+
+	item, err := qtx.GetItemByIDForUpdate(ctx, *locked.ItemID)
+	if err != nil {
+		logger.Error("Failed to lock item", "item_id", locked.ItemID, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if item.Stock < quantity {
+		return api.RecordBookingPickup400JSONResponse(ValidationErr("Insufficient stock available", nil).Create()), nil
+	}
+	if item.Type == db.ItemTypeHigh {
+		borrowable, err := qtx.CheckBorrowingItemStatus(ctx, &item.ID)
+		if err != nil {
+			logger.Error("Failed to check item borrowing status", "item_id", item.ID, "error", err)
+			return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+		}
+		if !borrowable {
+			return api.RecordBookingPickup400JSONResponse(ValidationErr("High-value item is currently borrowed", nil).Create()), nil
+		}
+	}
+
+	unitID, err := pickItemUnit(ctx, qtx, item.ID, request.Body.UnitId, quantity)
+	if err != nil {
+		if apiErr := errorFor(err); apiErr.Code == CodeValidationError {
+			return api.RecordBookingPickup400JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to pick unit", "item_id", item.ID, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	borrowing, err := qtx.BorrowItem(ctx, db.BorrowItemParams{
+		UserID:             locked.RequesterID,
+		GroupID:            locked.GroupID,
+		ID:                 item.ID,
+		Quantity:           quantity,
+		DueDate:            locked.ReturnDate,
+		BeforeCondition:    db.Condition(request.Body.BeforeCondition),
+		BeforeConditionUrl: request.Body.BeforeConditionUrl,
+		UnitID:             unitID,
+	})
+	if err == pgx.ErrNoRows {
+		return api.RecordBookingPickup400JSONResponse(ValidationErr("Low-value items cannot be borrowed", nil).Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to open borrowing for booking", "booking_id", locked.ID, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindBorrow, ActorID: &user.ID, ReferenceID: &borrowing.ID}); err != nil {
+		logger.Error("Failed to update stock", "item_id", item.ID, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("Failed to update stock").Create()), nil
+	}
+	if err := qtx.DecrementItemStock(ctx, db.DecrementItemStockParams{ID: item.ID, Stock: quantity}); err != nil {
+		logger.Error("Failed to update stock", "item_id", item.ID, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("Failed to update stock").Create()), nil
+	}
+	if err := holdItemUnit(ctx, qtx, unitID, db.UnitStatusBorrowed); err != nil {
+		logger.Error("Failed to hold unit", "unit_id", unitID, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("Failed to update stock").Create()), nil
+	}
+
+	if requestID != nil {
+		if err := qtx.MarkRequestAsFulfilled(ctx, *requestID); err != nil {
+			logger.Error("Failed to mark request as fulfilled", "request_id", *requestID, "error", err)
+			return api.RecordBookingPickup500JSONResponse(InternalError("Failed to mark request as fulfilled").Create()), nil
+		}
+	}
+
+	fulfilled, err := qtx.FulfillBooking(ctx, db.FulfillBookingParams{ID: locked.ID, UnitID: unitID})
+	if err != nil {
+		logger.Error("Failed to fulfill booking", "booking_id", locked.ID, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if _, err := qtx.CreateBookingHandoff(ctx, db.CreateBookingHandoffParams{
+		BookingID:   fulfilled.ID,
+		BorrowingID: borrowing.ID,
+		PickedUpBy:  &user.ID,
+	}); err != nil {
+		logger.Error("Failed to record booking pickup", "booking_id", fulfilled.ID, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if err := bookingevents.Record(ctx, qtx, fulfilled.ID, &user.ID,
+		db.NullRequestStatus{RequestStatus: locked.Status, Valid: true}, fulfilled.Status, map[string]any{
+			"borrowing_id":     borrowing.ID,
+			"before_condition": borrowing.BeforeCondition,
+		}); err != nil {
+		logger.Error("Failed to record booking pickup event", "booking_id", fulfilled.ID, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit booking pickup", "booking_id", fulfilled.ID, "error", err)
+		return api.RecordBookingPickup500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	s.publishStockChanged(ctx, item.ID)
+
+	logger.Info("Booking picked up",
+		"booking_id", fulfilled.ID,
+		"borrowing_id", borrowing.ID,
+		"user_id", user.ID)
+
+	return api.RecordBookingPickup201JSONResponse(toBorrowingResponse(borrowing)), nil
+}
+
+// Closes the borrowing opened at pickup. As with a regular return the stock
+// goes back, an item in worse condition opens a damage report, and the
+// waitlist hears the item is available again.
+func (s Server) RecordBookingReturn(ctx context.Context, request api.RecordBookingReturnRequestObject) (api.RecordBookingReturnResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RecordBookingReturn401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+	if request.Body == nil {
+		return api.RecordBookingReturn400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	if !damage.ValidCondition(db.Condition(request.Body.AfterCondition)) {
+		return api.RecordBookingReturn400JSONResponse(ValidationErr("after_condition must be one of pristine, good, decent, damaged or unusable", nil).Create()), nil
+	}
+	if request.Body.DamageSeverity != nil && !validDamageSeverity(*request.Body.DamageSeverity) {
+		return api.RecordBookingReturn400JSONResponse(ValidationErr("damage_severity must be minor, moderate or severe", nil).Create()), nil
+	}
+
+	booking, err := s.db.Queries().GetBookingByID(ctx, request.BookingId)
+	if err == pgx.ErrNoRows {
+		return api.RecordBookingReturn404JSONResponse(NotFound("Booking").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get booking for return", "booking_id", request.BookingId, "error", err)
+		return api.RecordBookingReturn500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	canManage, err := s.canManageBooking(ctx, user.ID, booking.GroupID)
+	if err != nil {
+		logger.Error("Failed to check booking management permission", "user_id", user.ID, "group_id", booking.GroupID, "error", err)
+		return api.RecordBookingReturn500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if !canManage {
+		return api.RecordBookingReturn403JSONResponse(PermissionDenied("Insufficient permissions to check in this booking").Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.RecordBookingReturn500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	handoff, err := qtx.GetBookingHandoffForUpdate(ctx, request.BookingId)
+	if err == pgx.ErrNoRows {
+		return api.RecordBookingReturn400JSONResponse(ValidationErr("Pickup has not been recorded for this booking", nil).Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to lock booking handoff", "booking_id", request.BookingId, "error", err)
+		return api.RecordBookingReturn500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if handoff.ReturnedAt.Valid {
+		return api.RecordBookingReturn409JSONResponse(ConflictErr("Return has already been recorded for this booking").Create()), nil
+	}
+
+	afterConditionUrl := pgtype.Text{}
+	if request.Body.AfterConditionUrl != nil {
+		afterConditionUrl = pgtype.Text{String: *request.Body.AfterConditionUrl, Valid: true}
+	}
+
+	borrowing, err := qtx.ReturnBorrowingByID(ctx, db.ReturnBorrowingByIDParams{
+		ID:                handoff.BorrowingID,
+		AfterCondition:    db.NullCondition{Condition: db.Condition(request.Body.AfterCondition), Valid: true},
+		AfterConditionUrl: afterConditionUrl,
+	})
+	if err == pgx.ErrNoRows {
+		// returned through /borrowings/item/return before the desk checked it in
+		return api.RecordBookingReturn409JSONResponse(ConflictErr("The booking's borrowing has already been returned").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to close borrowing for booking", "booking_id", request.BookingId, "borrowing_id", handoff.BorrowingID, "error", err)
+		return api.RecordBookingReturn500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindReturn, ActorID: &user.ID, ReferenceID: &borrowing.ID}); err != nil {
+		logger.Error("Failed to update stock", "item_id", borrowing.ItemID, "error", err)
+		return api.RecordBookingReturn500JSONResponse(InternalError("Failed to update stock").Create()), nil
+	}
+	if err := qtx.IncrementItemStock(ctx, db.IncrementItemStockParams{ID: *borrowing.ItemID, Stock: borrowing.Quantity}); err != nil {
+		logger.Error("Failed to update stock", "item_id", borrowing.ItemID, "error", err)
+		return api.RecordBookingReturn500JSONResponse(InternalError("Failed to update stock").Create()), nil
+	}
+	if err := releaseItemUnit(ctx, qtx, borrowing.UnitID, borrowing.AfterCondition); err != nil {
+		logger.Error("Failed to return unit", "unit_id", borrowing.UnitID, "error", err)
+		return api.RecordBookingReturn500JSONResponse(InternalError("Failed to update stock").Create()), nil
+	}
+
+	var report *db.DamageReport
+	if damage.Worse(borrowing.BeforeCondition, db.Condition(request.Body.AfterCondition)) {
+		created, err := openDamageReport(ctx, qtx, user.ID, borrowing, request.Body)
+		if err != nil {
+			logger.Error("Failed to open damage report", "borrowing_id", borrowing.ID, "error", err)
+			return api.RecordBookingReturn500JSONResponse(InternalError("Failed to record damage").Create()), nil
+		}
+		report = &created
+	}
+
+	if _, err := assessFines(ctx, qtx, borrowing, report); err != nil {
+		logger.Error("Failed to assess fines", "borrowing_id", borrowing.ID, "error", err)
+		return api.RecordBookingReturn500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if _, err := qtx.RecordBookingHandoffReturn(ctx, db.RecordBookingHandoffReturnParams{
+		BookingID:  handoff.BookingID,
+		ReturnedBy: &user.ID,
+	}); err != nil {
+		logger.Error("Failed to record booking return", "booking_id", handoff.BookingID, "error", err)
+		return api.RecordBookingReturn500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	// the booking stays fulfilled; the event marks when the loan ended
+	if err := bookingevents.Record(ctx, qtx, handoff.BookingID, &user.ID,
+		db.NullRequestStatus{RequestStatus: booking.Status, Valid: true}, booking.Status, map[string]any{
+			"borrowing_id":    borrowing.ID,
+			"after_condition": request.Body.AfterCondition,
+			"returned":        true,
+		}); err != nil {
+		logger.Error("Failed to record booking return event", "booking_id", handoff.BookingID, "error", err)
+		return api.RecordBookingReturn500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit booking return", "booking_id", handoff.BookingID, "error", err)
+		return api.RecordBookingReturn500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	s.publishEvent(ctx, events.Event{Type: events.ItemReturned, EntityID: borrowing.ID, GroupID: borrowing.GroupID, ItemID: borrowing.ItemID, UserID: borrowing.UserID})
+	s.publishStockChanged(ctx, *borrowing.ItemID)
+
+	if _, err := s.queue.Enqueue(ctx, queue.TypeWaitlistNotify, queue.WaitlistNotifyPayload{ItemID: *borrowing.ItemID}); err != nil {
+		logger.Warn("Failed to enqueue waitlist notification", "item_id", *borrowing.ItemID, "error", err)
+	}
+
+	resp := toBorrowingResponse(borrowing)
+	if report != nil {
+		s.notifyDamageReported(ctx, user, *report)
+		resp.DamageReportId = &report.ID
+	}
+
+	logger.Info("Booking returned",
+		"booking_id", handoff.BookingID,
+		"borrowing_id", borrowing.ID,
+		"user_id", user.ID)
+
+	return api.RecordBookingReturn200JSONResponse(resp), nil
+}
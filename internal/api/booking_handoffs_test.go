@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_BookingHandoffs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	pickupBody := &api.RecordBookingPickupJSONRequestBody{
+		BeforeCondition:    "good",
+		BeforeConditionUrl: "https://example.com/before.jpg",
+	}
+
+	t.Run("pickup opens the borrowing and return closes it", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		sharedQueue.Cleanup(t)
+
+		user := testDB.NewUser(t).WithEmail("user@handoff.test").AsMember().Create()
+		manager := testDB.NewUser(t).WithEmail("manager@handoff.test").AsApprover().Create()
+		item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+		group := testDB.NewGroup(t).WithName("Handoff Group").Create()
+
+		availability := createTestAvailability(t, testDB, manager.ID)
+		booking := createTestBooking(t, testDB,
+			availability.ID, user.ID, manager.ID, item.ID, group.ID,
+			db.RequestStatusConfirmed, 0)
+		_, err := testDB.Queries().RecordBookingVerification(context.Background(), db.RecordBookingVerificationParams{
+			BookingID:  booking.ID,
+			VerifiedBy: &manager.ID,
+			Matched:    true,
+		})
+		require.NoError(t, err)
+
+		mockAuth.ExpectCheckPermission(manager.ID, rbac.ManageAllBookings, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), manager, testDB.Queries())
+		response, err := server.RecordBookingPickup(ctx, api.RecordBookingPickupRequestObject{
+			BookingId: booking.ID,
+			Body:      pickupBody,
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RecordBookingPickup201JSONResponse{}, response)
+
+		borrowing := response.(api.RecordBookingPickup201JSONResponse)
+		assert.Equal(t, user.ID, borrowing.UserId)
+		assert.Equal(t, item.ID, borrowing.ItemId)
+		assert.Equal(t, 1, borrowing.Quantity)
+		assert.Equal(t, booking.ReturnDate.Unix(), borrowing.DueDate.Unix())
+		assert.Nil(t, borrowing.ReturnedAt)
+
+		updated, err := testDB.Queries().GetBookingByID(ctx, booking.ID)
+		require.NoError(t, err)
+		assert.Equal(t, db.RequestStatusFulfilled, updated.Status)
+
+		stocked, err := testDB.Queries().GetItemByID(ctx, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(0), stocked.Stock)
+
+		// a second desk can't hand the item over again
+		mockAuth.ExpectCheckPermission(manager.ID, rbac.ManageAllBookings, nil, true, nil)
+		response, err = server.RecordBookingPickup(ctx, api.RecordBookingPickupRequestObject{
+			BookingId: booking.ID,
+			Body:      pickupBody,
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RecordBookingPickup409JSONResponse{}, response)
+
+		mockAuth.ExpectCheckPermission(manager.ID, rbac.ManageAllBookings, nil, true, nil)
+		returned, err := server.RecordBookingReturn(ctx, api.RecordBookingReturnRequestObject{
+			BookingId: booking.ID,
+			Body:      &api.RecordBookingReturnJSONRequestBody{AfterCondition: "damaged"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RecordBookingReturn200JSONResponse{}, returned)
+
+		closed := returned.(api.RecordBookingReturn200JSONResponse)
+		assert.Equal(t, borrowing.Id, closed.Id)
+		require.NotNil(t, closed.ReturnedAt)
+		require.NotNil(t, closed.AfterCondition)
+		assert.Equal(t, "damaged", *closed.AfterCondition)
+		assert.NotNil(t, closed.DamageReportId, "an item back in worse condition opens a damage report")
+
+		stocked, err = testDB.Queries().GetItemByID(ctx, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), stocked.Stock)
+
+		events, err := testDB.Queries().ListBookingEvents(ctx, booking.ID)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, db.RequestStatusConfirmed, events[0].FromStatus.RequestStatus)
+		assert.Equal(t, db.RequestStatusFulfilled, events[0].ToStatus)
+		assert.Equal(t, db.RequestStatusFulfilled, events[1].FromStatus.RequestStatus)
+		assert.Equal(t, db.RequestStatusFulfilled, events[1].ToStatus)
+
+		tasks, err := sharedQueue.Inspector.ListPendingTasks("default")
+		require.NoError(t, err)
+		var waitlistTasks int
+		for _, task := range tasks {
+			if task.Type == queue.TypeWaitlistNotify {
+				waitlistTasks++
+			}
+		}
+		assert.Equal(t, 1, waitlistTasks)
+
+		mockAuth.ExpectCheckPermission(manager.ID, rbac.ManageAllBookings, nil, true, nil)
+		returned, err = server.RecordBookingReturn(ctx, api.RecordBookingReturnRequestObject{
+			BookingId: booking.ID,
+			Body:      &api.RecordBookingReturnJSONRequestBody{AfterCondition: "good"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RecordBookingReturn409JSONResponse{}, returned)
+	})
+
+	t.Run("pickup requires a verified identity", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		user := testDB.NewUser(t).WithEmail("user@handoff.test").AsMember().Create()
+		manager := testDB.NewUser(t).WithEmail("manager@handoff.test").AsApprover().Create()
+		item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+		group := testDB.NewGroup(t).WithName("Handoff Group").Create()
+
+		availability := createTestAvailability(t, testDB, manager.ID)
+		booking := createTestBooking(t, testDB,
+			availability.ID, user.ID, manager.ID, item.ID, group.ID,
+			db.RequestStatusConfirmed, 0)
+
+		mockAuth.ExpectCheckPermission(manager.ID, rbac.ManageAllBookings, nil, false, nil)
+		mockAuth.ExpectCheckPermission(manager.ID, rbac.ManageGroupBookings, &group.ID, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), manager, testDB.Queries())
+		response, err := server.RecordBookingPickup(ctx, api.RecordBookingPickupRequestObject{
+			BookingId: booking.ID,
+			Body:      pickupBody,
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RecordBookingPickup403JSONResponse{}, response)
+
+		stocked, err := testDB.Queries().GetItemByID(ctx, item.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), stocked.Stock)
+	})
+
+	t.Run("pickup rejects a booking that is not confirmed", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		user := testDB.NewUser(t).WithEmail("user@handoff.test").AsMember().Create()
+		manager := testDB.NewUser(t).WithEmail("manager@handoff.test").AsApprover().Create()
+		item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+		group := testDB.NewGroup(t).WithName("Handoff Group").Create()
+
+		availability := createTestAvailability(t, testDB, manager.ID)
+		booking := createTestBooking(t, testDB,
+			availability.ID, user.ID, manager.ID, item.ID, group.ID,
+			db.RequestStatusPendingConfirmation, 0)
+
+		mockAuth.ExpectCheckPermission(manager.ID, rbac.ManageAllBookings, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), manager, testDB.Queries())
+		response, err := server.RecordBookingPickup(ctx, api.RecordBookingPickupRequestObject{
+			BookingId: booking.ID,
+			Body:      pickupBody,
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RecordBookingPickup400JSONResponse{}, response)
+	})
+
+	t.Run("requester cannot check in their own booking", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		user := testDB.NewUser(t).WithEmail("user@handoff.test").AsMember().Create()
+		manager := testDB.NewUser(t).WithEmail("manager@handoff.test").AsApprover().Create()
+		item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+		group := testDB.NewGroup(t).WithName("Handoff Group").Create()
+
+		availability := createTestAvailability(t, testDB, manager.ID)
+		booking := createTestBooking(t, testDB,
+			availability.ID, user.ID, manager.ID, item.ID, group.ID,
+			db.RequestStatusConfirmed, 0)
+
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ManageAllBookings, nil, false, nil)
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ManageGroupBookings, &group.ID, false, nil)
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+		response, err := server.RecordBookingPickup(ctx, api.RecordBookingPickupRequestObject{
+			BookingId: booking.ID,
+			Body:      pickupBody,
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RecordBookingPickup403JSONResponse{}, response)
+	})
+
+	t.Run("return before pickup is rejected", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		user := testDB.NewUser(t).WithEmail("user@handoff.test").AsMember().Create()
+		manager := testDB.NewUser(t).WithEmail("manager@handoff.test").AsApprover().Create()
+		item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+		group := testDB.NewGroup(t).WithName("Handoff Group").Create()
+
+		availability := createTestAvailability(t, testDB, manager.ID)
+		booking := createTestBooking(t, testDB,
+			availability.ID, user.ID, manager.ID, item.ID, group.ID,
+			db.RequestStatusConfirmed, 0)
+
+		mockAuth.ExpectCheckPermission(manager.ID, rbac.ManageAllBookings, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), manager, testDB.Queries())
+		response, err := server.RecordBookingReturn(ctx, api.RecordBookingReturnRequestObject{
+			BookingId: booking.ID,
+			Body:      &api.RecordBookingReturnJSONRequestBody{AfterCondition: "good"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RecordBookingReturn400JSONResponse{}, response)
+	})
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/bookingpolicy"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s Server) GetGroupBookingPolicy(ctx context.Context, request api.GetGroupBookingPolicyRequestObject) (api.GetGroupBookingPolicyResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetGroupBookingPolicy401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewGroupData, nil)
+	if err != nil {
+		logger.Error("Error checking view_group_data permission",
+			"user_id", user.ID,
+			"permission", rbac.ViewGroupData,
+			"error", err)
+		return api.GetGroupBookingPolicy500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetGroupBookingPolicy403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetGroupByID(ctx, request.Id); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.GetGroupBookingPolicy404JSONResponse(NotFound("Group").Create()), nil
+		}
+		logger.Error("Failed to get group", "group_id", request.Id, "error", err)
+		return api.GetGroupBookingPolicy500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	row, err := s.db.Queries().GetGroupBookingPolicy(ctx, request.Id)
+	if err == pgx.ErrNoRows {
+		return api.GetGroupBookingPolicy200JSONResponse(toBookingPolicyResponse(request.Id, s.policies.Defaults(), nil)), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get booking policy", "group_id", request.Id, "error", err)
+		return api.GetGroupBookingPolicy500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	return api.GetGroupBookingPolicy200JSONResponse(toBookingPolicyResponse(request.Id, s.policies.Defaults().With(row), &row)), nil
+}
+
+func (s Server) SetGroupBookingPolicy(ctx context.Context, request api.SetGroupBookingPolicyRequestObject) (api.SetGroupBookingPolicyResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.SetGroupBookingPolicy401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageGroups, nil)
+	if err != nil {
+		logger.Error("Error checking manage_groups permission", "error", err)
+		return api.SetGroupBookingPolicy500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.SetGroupBookingPolicy403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.SetGroupBookingPolicy400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	var windowHours, cutoffHours pgtype.Int4
+	if request.Body.ConfirmationWindowHours != nil {
+		if *request.Body.ConfirmationWindowHours < 1 {
+			return api.SetGroupBookingPolicy400JSONResponse(ValidationErr("confirmation_window_hours must be at least 1", nil).Create()), nil
+		}
+		windowHours = pgtype.Int4{Int32: int32(*request.Body.ConfirmationWindowHours), Valid: true}
+	}
+	if request.Body.PickupCutoffHours != nil {
+		if *request.Body.PickupCutoffHours < 0 {
+			return api.SetGroupBookingPolicy400JSONResponse(ValidationErr("pickup_cutoff_hours cannot be negative", nil).Create()), nil
+		}
+		cutoffHours = pgtype.Int4{Int32: int32(*request.Body.PickupCutoffHours), Valid: true}
+	}
+
+	if _, err := s.db.Queries().GetGroupByID(ctx, request.Id); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.SetGroupBookingPolicy404JSONResponse(NotFound("Group").Create()), nil
+		}
+		logger.Error("Failed to get group", "group_id", request.Id, "error", err)
+		return api.SetGroupBookingPolicy500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	row, err := s.db.Queries().UpsertGroupBookingPolicy(ctx, db.UpsertGroupBookingPolicyParams{
+		GroupID:                 request.Id,
+		ConfirmationWindowHours: windowHours,
+		PickupCutoffHours:       cutoffHours,
+		UpdatedBy:               &user.ID,
+	})
+	if err != nil {
+		logger.Error("Failed to set booking policy", "group_id", request.Id, "error", err)
+		return api.SetGroupBookingPolicy500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	policy := s.policies.Defaults().With(row)
+	logger.Info("Booking policy set",
+		"group_id", row.GroupID,
+		"confirmation_window", policy.ConfirmationWindow,
+		"pickup_cutoff", policy.PickupCutoff,
+		"user_id", user.ID)
+	return api.SetGroupBookingPolicy200JSONResponse(toBookingPolicyResponse(request.Id, policy, &row)), nil
+}
+
+func (s Server) RemoveGroupBookingPolicy(ctx context.Context, request api.RemoveGroupBookingPolicyRequestObject) (api.RemoveGroupBookingPolicyResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RemoveGroupBookingPolicy401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageGroups, nil)
+	if err != nil {
+		logger.Error("Error checking manage_groups permission", "error", err)
+		return api.RemoveGroupBookingPolicy500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.RemoveGroupBookingPolicy403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	deleted, err := s.db.Queries().DeleteGroupBookingPolicy(ctx, request.Id)
+	if err != nil {
+		logger.Error("Failed to remove booking policy", "group_id", request.Id, "error", err)
+		return api.RemoveGroupBookingPolicy500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if deleted == 0 {
+		return api.RemoveGroupBookingPolicy404JSONResponse(NotFound("Booking policy").Create()), nil
+	}
+
+	logger.Info("Booking policy removed", "group_id", request.Id, "user_id", user.ID)
+	return api.RemoveGroupBookingPolicy204Response{}, nil
+}
+
+// row is nil when the group uses the defaults.
+func toBookingPolicyResponse(groupID uuid.UUID, policy bookingpolicy.Policy, row *db.GroupBookingPolicy) api.BookingPolicy {
+	response := api.BookingPolicy{
+		GroupId:                 groupID,
+		ConfirmationWindowHours: int(policy.ConfirmationWindow / time.Hour),
+		PickupCutoffHours:       int(policy.PickupCutoff / time.Hour),
+		IsDefault:               policy.IsDefault,
+	}
+	if row != nil {
+		response.UpdatedBy = row.UpdatedBy
+		response.UpdatedAt = &row.UpdatedAt.Time
+	}
+	return response
+}
+
+// fills in the confirmation deadline while a booking is waiting on the
+// requester.
+func setConfirmBy(response *api.BookingResponse, policy bookingpolicy.Policy) {
+	if response.Status != api.RequestStatusPendingConfirmation {
+		return
+	}
+	deadline := policy.ConfirmationDeadline(response.CreatedAt, response.PickUpDate)
+	response.ConfirmBy = &deadline
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_GroupBookingPolicy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@policy.test").AsGlobalAdmin().Create()
+	group := testDB.NewGroup(t).WithName("Policy Group").Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	get := func() api.BookingPolicy {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewGroupData, nil, true, nil)
+		response, err := server.GetGroupBookingPolicy(ctx, api.GetGroupBookingPolicyRequestObject{Id: group.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetGroupBookingPolicy200JSONResponse{}, response)
+		return api.BookingPolicy(response.(api.GetGroupBookingPolicy200JSONResponse))
+	}
+
+	t.Run("defaults until the group sets its own", func(t *testing.T) {
+		policy := get()
+		assert.True(t, policy.IsDefault)
+		assert.Equal(t, 48, policy.ConfirmationWindowHours)
+		assert.Equal(t, 0, policy.PickupCutoffHours)
+	})
+
+	t.Run("set overrides only the given fields", func(t *testing.T) {
+		window := 72
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+		response, err := server.SetGroupBookingPolicy(ctx, api.SetGroupBookingPolicyRequestObject{
+			Id:   group.ID,
+			Body: &api.SetBookingPolicyRequest{ConfirmationWindowHours: &window},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetGroupBookingPolicy200JSONResponse{}, response)
+
+		policy := get()
+		assert.False(t, policy.IsDefault)
+		assert.Equal(t, 72, policy.ConfirmationWindowHours)
+		assert.Equal(t, 0, policy.PickupCutoffHours)
+	})
+
+	t.Run("rejects an empty window", func(t *testing.T) {
+		window := 0
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+		response, err := server.SetGroupBookingPolicy(ctx, api.SetGroupBookingPolicyRequestObject{
+			Id:   group.ID,
+			Body: &api.SetBookingPolicyRequest{ConfirmationWindowHours: &window},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetGroupBookingPolicy400JSONResponse{}, response)
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+		response, err := server.SetGroupBookingPolicy(ctx, api.SetGroupBookingPolicyRequestObject{
+			Id:   uuid.New(),
+			Body: &api.SetBookingPolicyRequest{},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetGroupBookingPolicy404JSONResponse{}, response)
+	})
+
+	t.Run("remove returns the group to the defaults", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+		response, err := server.RemoveGroupBookingPolicy(ctx, api.RemoveGroupBookingPolicyRequestObject{Id: group.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.RemoveGroupBookingPolicy204Response{}, response)
+		assert.True(t, get().IsDefault)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+		response, err = server.RemoveGroupBookingPolicy(ctx, api.RemoveGroupBookingPolicyRequestObject{Id: group.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.RemoveGroupBookingPolicy404JSONResponse{}, response)
+	})
+}
+
+func TestServer_ConfirmBooking_GroupPolicy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	type fixture struct {
+		ctx                                         context.Context
+		user                                        *testutil.TestUser
+		approverID, itemID, groupID, availabilityID uuid.UUID
+	}
+
+	setup := func(t *testing.T, window, cutoff pgtype.Int4) fixture {
+		testDB.CleanupDatabase(t)
+
+		user := testDB.NewUser(t).WithEmail("user@policy.test").AsMember().Create()
+		item := testDB.NewItem(t).WithName("Laptop").WithType("high").WithStock(5).Create()
+		approver := testDB.NewUser(t).WithEmail("approver@policy.test").AsApprover().Create()
+		group := testDB.NewGroup(t).WithName("Policy Group").Create()
+
+		_, err := testDB.Queries().UpsertGroupBookingPolicy(context.Background(), db.UpsertGroupBookingPolicyParams{
+			GroupID:                 group.ID,
+			ConfirmationWindowHours: window,
+			PickupCutoffHours:       cutoff,
+		})
+		require.NoError(t, err)
+
+		return fixture{
+			ctx:            testutil.ContextWithUser(context.Background(), user, testDB.Queries()),
+			user:           user,
+			approverID:     approver.ID,
+			itemID:         item.ID,
+			groupID:        group.ID,
+			availabilityID: createTestAvailability(t, testDB, approver.ID).ID,
+		}
+	}
+
+	t.Run("longer window accepts a booking past the default", func(t *testing.T) {
+		f := setup(t, pgtype.Int4{Int32: 72, Valid: true}, pgtype.Int4{})
+		ctx := f.ctx
+
+		bookingID := uuid.New()
+		pickupDate := time.Now().AddDate(0, 0, 14).Add(9 * time.Hour)
+		_, err := testDB.Pool().Exec(ctx, `
+			INSERT INTO booking (id, requester_id, manager_id, item_id, group_id, availability_id, pick_up_date, pick_up_location, return_date, return_location, status, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		`, bookingID, f.user.ID, f.approverID, f.itemID, f.groupID, f.availabilityID, pickupDate, "Main Office", pickupDate.Add(24*time.Hour), "Main Office",
+			db.RequestStatusPendingConfirmation, time.Now().Add(-49*time.Hour))
+		require.NoError(t, err)
+
+		mockAuth.ExpectCheckPermission(f.user.ID, rbac.ViewAllData, nil, false, nil)
+		viewed, err := server.GetBookingByID(ctx, api.GetBookingByIDRequestObject{BookingId: bookingID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetBookingByID200JSONResponse{}, viewed)
+		confirmBy := viewed.(api.GetBookingByID200JSONResponse).ConfirmBy
+		require.NotNil(t, confirmBy)
+		assert.WithinDuration(t, time.Now().Add(23*time.Hour), *confirmBy, time.Minute)
+
+		response, err := server.ConfirmBooking(ctx, api.ConfirmBookingRequestObject{BookingId: bookingID})
+		require.NoError(t, err)
+		require.IsType(t, api.ConfirmBooking200JSONResponse{}, response)
+		assert.Nil(t, response.(api.ConfirmBooking200JSONResponse).ConfirmBy)
+	})
+
+	t.Run("pickup cutoff closes confirmation early", func(t *testing.T) {
+		f := setup(t, pgtype.Int4{}, pgtype.Int4{Int32: 24, Valid: true})
+
+		// pickup in about 9 hours
+		booking := createTestBooking(t, testDB, f.availabilityID, f.user.ID, f.approverID, f.itemID, f.groupID,
+			db.RequestStatusPendingConfirmation, -7*24*time.Hour)
+
+		response, err := server.ConfirmBooking(f.ctx, api.ConfirmBookingRequestObject{BookingId: booking.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.ConfirmBooking400JSONResponse{}, response)
+		assert.Equal(t, "Confirmation closed (must confirm at least 24 hours before pickup)",
+			response.(api.ConfirmBooking400JSONResponse).Error.Message)
+	})
+}
@@ -2,15 +2,22 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/USSTM/cv-backend/generated/api"
 	"github.com/USSTM/cv-backend/generated/db"
 	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/bookingevents"
+	"github.com/USSTM/cv-backend/internal/events"
 	"github.com/USSTM/cv-backend/internal/middleware"
 	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/queue"
 	"github.com/USSTM/cv-backend/internal/rbac"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
@@ -47,11 +54,78 @@ func (s Server) GetBookingByID(ctx context.Context, request api.GetBookingByIDRe
 		return api.GetBookingByID403JSONResponse(PermissionDenied("Insufficient permissions to view this booking").Create()), nil
 	}
 
+	policy, err := s.policies.ForGroup(ctx, s.db.Queries(), booking.GroupID)
+	if err != nil {
+		logger.Error("Failed to load booking policy", "group_id", booking.GroupID, "error", err)
+		return api.GetBookingByID500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
 	response := convertToBookingResponse(booking)
+	setConfirmBy(&response, policy)
 
 	return api.GetBookingByID200JSONResponse(response), nil
 }
 
+func (s Server) GetBookingEvents(ctx context.Context, request api.GetBookingEventsRequestObject) (api.GetBookingEventsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetBookingEvents401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	booking, err := s.db.Queries().GetBookingByID(ctx, request.BookingId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return api.GetBookingEvents404JSONResponse(NotFound("Booking").Create()), nil
+		}
+		logger.Error("Failed to get booking", "booking_id", request.BookingId, "error", err)
+		return api.GetBookingEvents500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	// same visibility as the booking itself
+	isOwner := booking.RequesterID != nil && *booking.RequesterID == user.ID
+	hasViewAll, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewAllData, nil)
+	if err != nil {
+		logger.Error("Failed to check permission",
+			"user_id", user.ID,
+			"permission", rbac.ViewAllData,
+			"error", err)
+		return api.GetBookingEvents500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if !isOwner && !hasViewAll {
+		return api.GetBookingEvents403JSONResponse(PermissionDenied("Insufficient permissions to view this booking").Create()), nil
+	}
+
+	events, err := s.db.Queries().ListBookingEvents(ctx, booking.ID)
+	if err != nil {
+		logger.Error("Failed to list booking events", "booking_id", booking.ID, "error", err)
+		return api.GetBookingEvents500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	response := make(api.GetBookingEvents200JSONResponse, 0, len(events))
+	for _, e := range events {
+		payload := map[string]interface{}{}
+		if err := json.Unmarshal(e.Payload, &payload); err != nil {
+			logger.Warn("Unreadable booking event payload", "event_id", e.ID, "error", err)
+		}
+		event := api.BookingEvent{
+			Id:        e.ID,
+			BookingId: e.BookingID,
+			ActorId:   e.ActorID,
+			ToStatus:  api.RequestStatus(e.ToStatus),
+			Payload:   payload,
+			CreatedAt: e.CreatedAt.Time,
+		}
+		if e.FromStatus.Valid {
+			from := api.RequestStatus(e.FromStatus.RequestStatus)
+			event.FromStatus = &from
+		}
+		response = append(response, event)
+	}
+	return response, nil
+}
+
 // database booking to API response
 func convertToBookingResponse(booking db.GetBookingByIDRow) api.BookingResponse {
 	response := api.BookingResponse{
@@ -65,7 +139,9 @@ func convertToBookingResponse(booking db.GetBookingByIDRow) api.BookingResponse
 		ReturnDate:     booking.ReturnDate.Time,
 		ReturnLocation: booking.ReturnLocation,
 		Status:         api.RequestStatus(booking.Status),
+		UnitId:         booking.UnitID,
 		CreatedAt:      booking.CreatedAt.Time,
+		IsTest:         booking.IsTest,
 		RequesterEmail: &booking.RequesterEmail,
 		ItemName:       &booking.ItemName,
 		ItemType:       (*api.ItemType)(&booking.ItemType),
@@ -117,7 +193,9 @@ func convertToBookingResponseFromListRow(booking db.ListBookingsRow) api.Booking
 		ReturnDate:     booking.ReturnDate.Time,
 		ReturnLocation: booking.ReturnLocation,
 		Status:         api.RequestStatus(booking.Status),
+		UnitId:         booking.UnitID,
 		CreatedAt:      booking.CreatedAt.Time,
+		IsTest:         booking.IsTest,
 		RequesterEmail: &booking.RequesterEmail,
 		ItemName:       &booking.ItemName,
 	}
@@ -156,7 +234,9 @@ func convertToBookingResponseFromUserRow(booking db.ListBookingsByUserRow) api.B
 		ReturnDate:     booking.ReturnDate.Time,
 		ReturnLocation: booking.ReturnLocation,
 		Status:         api.RequestStatus(booking.Status),
+		UnitId:         booking.UnitID,
 		CreatedAt:      booking.CreatedAt.Time,
+		IsTest:         booking.IsTest,
 		ItemName:       &booking.ItemName,
 	}
 
@@ -204,7 +284,9 @@ func convertToBookingResponseFromPendingRow(booking db.ListPendingConfirmationRo
 		ReturnDate:     booking.ReturnDate.Time,
 		ReturnLocation: booking.ReturnLocation,
 		Status:         api.RequestStatus(booking.Status),
+		UnitId:         booking.UnitID,
 		CreatedAt:      booking.CreatedAt.Time,
+		IsTest:         booking.IsTest,
 		RequesterEmail: &booking.RequesterEmail,
 		ItemName:       &booking.ItemName,
 	}
@@ -259,32 +341,48 @@ func (s Server) ListBookings(ctx context.Context, request api.ListBookingsReques
 		}
 	}
 
-	var fromDate, toDate pgtype.Date
-	if request.Params.FromDate != nil {
-		fromDate = pgtype.Date{Time: request.Params.FromDate.Time, Valid: true}
-	}
-	if request.Params.ToDate != nil {
-		toDate = pgtype.Date{Time: request.Params.ToDate.Time, Valid: true}
+	fromDate, toDate := parseDateRange(request.Params.FromDate, request.Params.ToDate)
+
+	// staff see the upcoming schedule first, requesters their latest bookings
+	sortDefault := listSort{By: "date", Desc: !hasViewAll}
+	sort, err := parseSort(request.Params.SortBy, request.Params.Order, sortDefault, "date", "created_at")
+	if err != nil {
+		return api.ListBookings400JSONResponse(ValidationErr(err.Error(), nil).Create()), nil
 	}
 
 	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
-	var total int64
 
-	// view_all_data, show all bookings
+	policies, err := s.policies.All(ctx, s.db.Queries())
+	if err != nil {
+		logger.Error("Failed to load booking policies", "error", err)
+		return api.ListBookings500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	// view_all_data sees all bookings, everyone else only their own
+	var listBookings func(ctx context.Context, limit, offset int64) ([]api.BookingResponse, error)
+	var total int64
 	if hasViewAll {
-		bookings, err := s.db.Queries().ListBookings(ctx, db.ListBookingsParams{
-			Status:   status,
-			GroupID:  request.Params.GroupId,
-			FromDate: fromDate,
-			ToDate:   toDate,
-			Limit:    limit,
-			Offset:   offset,
-		})
-		if err != nil {
-			logger.Error("Failed to list bookings",
-				"status", status,
-				"error", err)
-			return api.ListBookings500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+		listBookings = func(ctx context.Context, limit, offset int64) ([]api.BookingResponse, error) {
+			bookings, err := s.db.Queries().ListBookings(ctx, db.ListBookingsParams{
+				Status:   status,
+				GroupID:  request.Params.GroupId,
+				FromDate: fromDate,
+				ToDate:   toDate,
+				SortBy:   sort.By,
+				SortDesc: sort.Desc,
+				Limit:    limit,
+				Offset:   offset,
+			})
+			if err != nil {
+				return nil, err
+			}
+			response := make([]api.BookingResponse, 0, len(bookings))
+			for _, booking := range bookings {
+				r := convertToBookingResponseFromListRow(booking)
+				setConfirmBy(&r, policies.ForGroup(booking.GroupID))
+				response = append(response, r)
+			}
+			return response, nil
 		}
 
 		total, err = s.db.Queries().CountBookings(ctx, db.CountBookingsParams{
@@ -297,48 +395,74 @@ func (s Server) ListBookings(ctx context.Context, request api.ListBookingsReques
 			logger.Error("Failed to count bookings", "error", err)
 			return api.ListBookings500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
 		}
+	} else {
+		listBookings = func(ctx context.Context, limit, offset int64) ([]api.BookingResponse, error) {
+			bookings, err := s.db.Queries().ListBookingsByUser(ctx, db.ListBookingsByUserParams{
+				RequesterID: &user.ID,
+				Status:      status,
+				FromDate:    fromDate,
+				ToDate:      toDate,
+				SortBy:      sort.By,
+				SortDesc:    sort.Desc,
+				Limit:       limit,
+				Offset:      offset,
+			})
+			if err != nil {
+				return nil, err
+			}
+			response := make([]api.BookingResponse, 0, len(bookings))
+			for _, booking := range bookings {
+				r := convertToBookingResponseFromUserRow(booking)
+				setConfirmBy(&r, policies.ForGroup(booking.GroupID))
+				response = append(response, r)
+			}
+			return response, nil
+		}
 
-		response := make([]api.BookingResponse, 0, len(bookings))
-		for _, booking := range bookings {
-			response = append(response, convertToBookingResponseFromListRow(booking))
+		total, err = s.db.Queries().CountBookingsByUser(ctx, db.CountBookingsByUserParams{
+			RequesterID: &user.ID,
+			Status:      status,
+			FromDate:    fromDate,
+			ToDate:      toDate,
+		})
+		if err != nil {
+			logger.Error("Failed to count bookings for user", "error", err)
+			return api.ListBookings500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
 		}
-		return api.ListBookings200JSONResponse{
-			Data: response,
-			Meta: buildPaginationMeta(total, limit, offset),
+	}
+
+	if wantsCSV(request.Params.Format) {
+		body := streamCSV(ctx, bookingColumns, func(ctx context.Context, limit, offset int64) ([][]string, error) {
+			bookings, err := listBookings(ctx, limit, offset)
+			if err != nil {
+				return nil, err
+			}
+			records := make([][]string, 0, len(bookings))
+			for _, b := range bookings {
+				records = append(records, bookingRecord(b))
+			}
+			return records, nil
+		})
+		return api.ListBookings200TextcsvResponse{
+			Body:    body,
+			Headers: api.ListBookings200ResponseHeaders{XTotalCount: int(total)},
 		}, nil
 	}
 
-	// only show user's own bookings
-	bookings, err := s.db.Queries().ListBookingsByUser(ctx, db.ListBookingsByUserParams{
-		RequesterID: &user.ID,
-		Status:      status,
-		Limit:       limit,
-		Offset:      offset,
-	})
+	response, err := listBookings(ctx, limit, offset)
 	if err != nil {
-		logger.Error("Failed to list bookings for user",
+		logger.Error("Failed to list bookings",
 			"user_id", user.ID,
 			"status", status,
 			"error", err)
 		return api.ListBookings500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
 	}
-
-	total, err = s.db.Queries().CountBookingsByUser(ctx, db.CountBookingsByUserParams{
-		RequesterID: &user.ID,
-		Status:      status,
-	})
-	if err != nil {
-		logger.Error("Failed to count bookings for user", "error", err)
-		return api.ListBookings500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
-	}
-
-	response := make([]api.BookingResponse, 0, len(bookings))
-	for _, booking := range bookings {
-		response = append(response, convertToBookingResponseFromUserRow(booking))
-	}
 	return api.ListBookings200JSONResponse{
-		Data: response,
-		Meta: buildPaginationMeta(total, limit, offset),
+		Body: api.PaginatedBookingResponse{
+			Data: response,
+			Meta: buildPaginationMeta(total, limit, offset),
+		},
+		Headers: api.ListBookings200ResponseHeaders{XTotalCount: int(total)},
 	}, nil
 }
 
@@ -359,12 +483,23 @@ func (s Server) GetMyBookings(ctx context.Context, request api.GetMyBookingsRequ
 		}
 	}
 
+	fromDate, toDate := parseDateRange(request.Params.FromDate, request.Params.ToDate)
+
+	sort, err := parseSort(request.Params.SortBy, request.Params.Order, listSort{By: "date", Desc: true}, "date", "created_at")
+	if err != nil {
+		return api.GetMyBookings400JSONResponse(ValidationErr(err.Error(), nil).Create()), nil
+	}
+
 	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
 
 	// Fetch user bookings
 	bookings, err := s.db.Queries().ListBookingsByUser(ctx, db.ListBookingsByUserParams{
 		RequesterID: &user.ID,
 		Status:      status,
+		FromDate:    fromDate,
+		ToDate:      toDate,
+		SortBy:      sort.By,
+		SortDesc:    sort.Desc,
 		Limit:       limit,
 		Offset:      offset,
 	})
@@ -379,20 +514,33 @@ func (s Server) GetMyBookings(ctx context.Context, request api.GetMyBookingsRequ
 	total, err := s.db.Queries().CountBookingsByUser(ctx, db.CountBookingsByUserParams{
 		RequesterID: &user.ID,
 		Status:      status,
+		FromDate:    fromDate,
+		ToDate:      toDate,
 	})
 	if err != nil {
 		logger.Error("Failed to count bookings for user", "error", err)
 		return api.GetMyBookings500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
 	}
 
+	policies, err := s.policies.All(ctx, s.db.Queries())
+	if err != nil {
+		logger.Error("Failed to load booking policies", "error", err)
+		return api.GetMyBookings500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
 	response := make([]api.BookingResponse, 0, len(bookings))
 	for _, booking := range bookings {
-		response = append(response, convertToBookingResponseFromUserRow(booking))
+		r := convertToBookingResponseFromUserRow(booking)
+		setConfirmBy(&r, policies.ForGroup(booking.GroupID))
+		response = append(response, r)
 	}
 
 	return api.GetMyBookings200JSONResponse{
-		Data: response,
-		Meta: buildPaginationMeta(total, limit, offset),
+		Body: api.PaginatedBookingResponse{
+			Data: response,
+			Meta: buildPaginationMeta(total, limit, offset),
+		},
+		Headers: api.GetMyBookings200ResponseHeaders{XTotalCount: int(total)},
 	}, nil
 }
 
@@ -454,15 +602,24 @@ func (s Server) ListPendingConfirmation(ctx context.Context, request api.ListPen
 		return api.ListPendingConfirmation500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
 	}
 
+	policies, err := s.policies.All(ctx, s.db.Queries())
+	if err != nil {
+		logger.Error("Failed to load booking policies", "error", err)
+		return api.ListPendingConfirmation500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
 	response := make([]api.BookingResponse, 0, len(bookings))
 	for _, booking := range bookings {
-		response = append(response, convertToBookingResponseFromPendingRow(booking))
+		r := convertToBookingResponseFromPendingRow(booking)
+		setConfirmBy(&r, policies.ForGroup(booking.GroupID))
+		response = append(response, r)
 	}
 
 	return api.ListPendingConfirmation200JSONResponse(response), nil
 }
 
-// Validates: requester ownership, pending status, 48h window, before pickup
+// Validates: requester ownership, pending status, and the group's booking
+// policy (confirmation window and pickup cutoff)
 func (s Server) ConfirmBooking(ctx context.Context, request api.ConfirmBookingRequestObject) (api.ConfirmBookingResponseObject, error) {
 	logger := middleware.GetLoggerFromContext(ctx)
 
@@ -490,18 +647,48 @@ func (s Server) ConfirmBooking(ctx context.Context, request api.ConfirmBookingRe
 		return api.ConfirmBooking400JSONResponse(ValidationErr("Booking is not in pending_confirmation status", nil).Create()), nil
 	}
 
-	// Validate within 48h
-	fortyEightHoursAgo := time.Now().Add(-48 * time.Hour)
-	if booking.CreatedAt.Time.Before(fortyEightHoursAgo) {
-		return api.ConfirmBooking400JSONResponse(ValidationErr("Confirmation window expired (must confirm within 48 hours)", nil).Create()), nil
+	policy, err := s.policies.ForGroup(ctx, s.db.Queries(), booking.GroupID)
+	if err != nil {
+		logger.Error("Failed to load booking policy", "group_id", booking.GroupID, "error", err)
+		return api.ConfirmBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
 	}
 
-	// Validate before pickup
-	if time.Now().After(booking.PickUpDate.Time) {
+	// Validate within the confirmation window
+	now := time.Now()
+	if now.After(booking.CreatedAt.Time.Add(policy.ConfirmationWindow)) {
+		msg := fmt.Sprintf("Confirmation window expired (must confirm within %d hours)", int(policy.ConfirmationWindow/time.Hour))
+		return api.ConfirmBooking400JSONResponse(ValidationErr(msg, nil).Create()), nil
+	}
+
+	// Validate before pickup, less the group's cutoff
+	if now.After(booking.PickUpDate.Time) {
 		return api.ConfirmBooking400JSONResponse(ValidationErr("Cannot confirm booking after pickup date has passed", nil).Create()), nil
 	}
+	if now.After(booking.PickUpDate.Time.Add(-policy.PickupCutoff)) {
+		msg := fmt.Sprintf("Confirmation closed (must confirm at least %d hours before pickup)", int(policy.PickupCutoff/time.Hour))
+		return api.ConfirmBooking400JSONResponse(ValidationErr(msg, nil).Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.ConfirmBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	// re-check under lock so the event's from-status is the one we replace
+	locked, err := qtx.GetBookingByIDForUpdate(ctx, request.BookingId)
+	if err != nil {
+		logger.Error("Failed to lock booking", "booking_id", request.BookingId, "error", err)
+		return api.ConfirmBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if locked.Status != db.RequestStatusPendingConfirmation {
+		return api.ConfirmBooking400JSONResponse(ValidationErr("Booking is not in pending_confirmation status", nil).Create()), nil
+	}
 
-	confirmedBooking, err := s.db.Queries().ConfirmBooking(ctx, db.ConfirmBookingParams{
+	confirmedBooking, err := qtx.ConfirmBooking(ctx, db.ConfirmBookingParams{
 		ID:          request.BookingId,
 		ConfirmedBy: &user.ID,
 	})
@@ -513,6 +700,57 @@ func (s Server) ConfirmBooking(ctx context.Context, request api.ConfirmBookingRe
 		return api.ConfirmBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
 	}
 
+	if err := bookingevents.Record(ctx, qtx, confirmedBooking.ID, &user.ID,
+		db.NullRequestStatus{RequestStatus: locked.Status, Valid: true}, confirmedBooking.Status, nil); err != nil {
+		logger.Error("Failed to record booking confirmation", "booking_id", confirmedBooking.ID, "error", err)
+		return api.ConfirmBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit booking confirmation", "booking_id", confirmedBooking.ID, "error", err)
+		return api.ConfirmBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	s.publishEvent(ctx, events.Event{Type: events.BookingConfirmed, EntityID: confirmedBooking.ID,
+		GroupID: confirmedBooking.GroupID, ItemID: confirmedBooking.ItemID, UserID: confirmedBooking.RequesterID})
+
+	// the invite is a convenience; the email goes out without it
+	var attachments []queue.EmailAttachment
+	invite, err := s.bookingICS(calendarBooking{
+		ID:             confirmedBooking.ID,
+		ItemName:       booking.ItemName,
+		Status:         confirmedBooking.Status,
+		PickUpDate:     confirmedBooking.PickUpDate,
+		PickUpLocation: confirmedBooking.PickUpLocation,
+		ReturnDate:     confirmedBooking.ReturnDate,
+		ReturnLocation: confirmedBooking.ReturnLocation,
+		SlotStart:      booking.StartTime,
+		SlotEnd:        booking.EndTime,
+	})
+	if err != nil {
+		logger.Error("Failed to build calendar invite", "booking_id", confirmedBooking.ID, "error", err)
+	} else {
+		attachments = append(attachments, invite)
+	}
+
+	ctx = notifications.WithSandbox(ctx, booking.IsTest)
+	if notifyErr := s.dispatcher.Notify(ctx, user.ID, "booking", confirmedBooking.ID, []notifications.NotifierGroup{
+		{
+			IDs:      []uuid.UUID{user.ID},
+			Template: "booking_confirmed_requester",
+			TemplateData: map[string]interface{}{
+				"ItemName":       booking.ItemName,
+				"PickupDate":     confirmedBooking.PickUpDate.Time.Format("2006-01-02 15:04"),
+				"PickupLocation": confirmedBooking.PickUpLocation,
+				"ReturnDate":     confirmedBooking.ReturnDate.Time.Format("2006-01-02 15:04"),
+				"ReturnLocation": confirmedBooking.ReturnLocation,
+			},
+			Attachments: attachments,
+		},
+	}); notifyErr != nil {
+		logger.Error("failed to notify booking confirmation", "booking_id", confirmedBooking.ID, "error", notifyErr)
+	}
+
 	// complete response
 	updatedBooking, err := s.db.Queries().GetBookingByID(ctx, confirmedBooking.ID)
 	if err != nil {
@@ -574,8 +812,32 @@ func (s Server) CancelBooking(ctx context.Context, request api.CancelBookingRequ
 		return api.CancelBooking403JSONResponse(PermissionDenied("Insufficient permissions to cancel this booking").Create()), nil
 	}
 
+	var reason pgtype.Text
+	if request.Body != nil && request.Body.Reason != nil && *request.Body.Reason != "" {
+		reason = pgtype.Text{String: *request.Body.Reason, Valid: true}
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.CancelBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	locked, err := qtx.GetBookingByIDForUpdate(ctx, request.BookingId)
+	if err != nil {
+		logger.Error("Failed to lock booking", "booking_id", request.BookingId, "error", err)
+		return api.CancelBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
 	// Cancel the booking
-	_, err = s.db.Queries().CancelBooking(ctx, request.BookingId)
+	cancelled, err := qtx.CancelBooking(ctx, db.CancelBookingParams{
+		ID:                 request.BookingId,
+		CancelledBy:        &user.ID,
+		CancellationReason: reason,
+	})
 	if err != nil {
 		logger.Error("Failed to cancel booking",
 			"booking_id", request.BookingId,
@@ -584,6 +846,24 @@ func (s Server) CancelBooking(ctx context.Context, request api.CancelBookingRequ
 		return api.CancelBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
 	}
 
+	payload := map[string]any{}
+	if reason.Valid {
+		payload["reason"] = reason.String
+	}
+	if err := bookingevents.Record(ctx, qtx, cancelled.ID, &user.ID,
+		db.NullRequestStatus{RequestStatus: locked.Status, Valid: true}, cancelled.Status, payload); err != nil {
+		logger.Error("Failed to record booking cancellation", "booking_id", cancelled.ID, "error", err)
+		return api.CancelBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit booking cancellation", "booking_id", cancelled.ID, "error", err)
+		return api.CancelBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	s.publishEvent(ctx, events.Event{Type: events.BookingCancelled, EntityID: cancelled.ID,
+		GroupID: cancelled.GroupID, ItemID: cancelled.ItemID, UserID: cancelled.RequesterID})
+
 	// complete response
 	updatedBooking, err := s.db.Queries().GetBookingByID(ctx, request.BookingId)
 	if err != nil {
@@ -593,6 +873,8 @@ func (s Server) CancelBooking(ctx context.Context, request api.CancelBookingRequ
 		return api.CancelBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
 	}
 
+	// test bookings from sandbox groups still notify in-app, but never email
+	ctx = notifications.WithSandbox(ctx, booking.IsTest)
 	pickupDate := booking.PickUpDate.Time.Format("2006-01-02")
 	if hasManageAll && !isRequester {
 		// approver cancelled: notify requester
@@ -632,3 +914,185 @@ func (s Server) CancelBooking(ctx context.Context, request api.CancelBookingRequ
 	response := convertToBookingResponse(updatedBooking)
 	return api.CancelBooking200JSONResponse(response), nil
 }
+
+// pickup time for a slot: the availability date plus the time slot's start
+func availabilityStart(availability db.GetAvailabilityByIDRow) time.Time {
+	start := availability.Date.Time
+	if availability.StartTime.Valid {
+		// Convert interval microseconds directly to duration (1 microsecond = 1000 nanoseconds)
+		start = start.Add(time.Duration(availability.StartTime.Microseconds) * time.Microsecond)
+	}
+	return start
+}
+
+// Requesters can reschedule before pickup, managers/admins anytime. The loan
+// keeps its length, and a pending booking must still be confirmable under the
+// group's booking policy once moved.
+func (s Server) RescheduleBooking(ctx context.Context, request api.RescheduleBookingRequestObject) (api.RescheduleBookingResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RescheduleBooking401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+	if request.Body == nil {
+		return api.RescheduleBooking400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+
+	booking, err := s.db.Queries().GetBookingByID(ctx, request.BookingId)
+	if err == pgx.ErrNoRows {
+		return api.RescheduleBooking404JSONResponse(NotFound("Booking").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get booking for rescheduling", "booking_id", request.BookingId, "error", err)
+		return api.RescheduleBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	now := time.Now()
+	isRequester := booking.RequesterID != nil && *booking.RequesterID == user.ID
+
+	canManage, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageAllBookings, nil)
+	if err != nil {
+		logger.Error("Failed to check manage_all_bookings permission", "user_id", user.ID, "error", err)
+		return api.RescheduleBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if !canManage && booking.GroupID != nil {
+		canManage, err = s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageGroupBookings, booking.GroupID)
+		if err != nil {
+			logger.Error("Failed to check manage_group_bookings permission", "user_id", user.ID, "group_id", booking.GroupID, "error", err)
+			return api.RescheduleBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+		}
+	}
+	if !canManage && !(isRequester && now.Before(booking.PickUpDate.Time)) {
+		return api.RescheduleBooking403JSONResponse(PermissionDenied("Insufficient permissions to reschedule this booking").Create()), nil
+	}
+
+	if booking.Status != db.RequestStatusPendingConfirmation && booking.Status != db.RequestStatusConfirmed {
+		return api.RescheduleBooking400JSONResponse(ValidationErr("Only pending or confirmed bookings can be rescheduled", nil).Create()), nil
+	}
+
+	availability, err := s.db.Queries().GetAvailabilityByID(ctx, request.Body.AvailabilityId)
+	if err == pgx.ErrNoRows {
+		return api.RescheduleBooking400JSONResponse(ValidationErr("Invalid availability_id", nil).Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get availability", "availability_id", request.Body.AvailabilityId, "error", err)
+		return api.RescheduleBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	pickupDate := availabilityStart(availability)
+	if !pickupDate.After(now) {
+		return api.RescheduleBooking400JSONResponse(ValidationErr("Cannot reschedule to a slot in the past", nil).Create()), nil
+	}
+	returnDate := pickupDate.Add(booking.ReturnDate.Time.Sub(booking.PickUpDate.Time))
+
+	if booking.Status == db.RequestStatusPendingConfirmation {
+		policy, err := s.policies.ForGroup(ctx, s.db.Queries(), booking.GroupID)
+		if err != nil {
+			logger.Error("Failed to load booking policy", "group_id", booking.GroupID, "error", err)
+			return api.RescheduleBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+		}
+		if !now.Before(policy.ConfirmationDeadline(booking.CreatedAt.Time, pickupDate)) {
+			msg := fmt.Sprintf("The requester could no longer confirm this booking (confirmation closes %d hours before pickup)", int(policy.PickupCutoff/time.Hour))
+			return api.RescheduleBooking400JSONResponse(ValidationErr(msg, nil).Create()), nil
+		}
+	}
+
+	pickupLocation := booking.PickUpLocation
+	if request.Body.PickupLocation != nil && *request.Body.PickupLocation != "" {
+		pickupLocation = *request.Body.PickupLocation
+	}
+	returnLocation := booking.ReturnLocation
+	if request.Body.ReturnLocation != nil && *request.Body.ReturnLocation != "" {
+		returnLocation = *request.Body.ReturnLocation
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.RescheduleBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	// re-check under lock so a confirm or cancel in between isn't overwritten
+	locked, err := qtx.GetBookingByIDForUpdate(ctx, request.BookingId)
+	if err != nil {
+		logger.Error("Failed to lock booking", "booking_id", request.BookingId, "error", err)
+		return api.RescheduleBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if locked.Status != booking.Status {
+		return api.RescheduleBooking400JSONResponse(ValidationErr("Booking changed status while rescheduling; try again", nil).Create()), nil
+	}
+
+	rescheduled, err := qtx.RescheduleBooking(ctx, db.RescheduleBookingParams{
+		ID:             request.BookingId,
+		AvailabilityID: &availability.ID,
+		ManagerID:      availability.UserID,
+		PickUpDate:     pgtype.Timestamp{Time: pickupDate, Valid: true},
+		PickUpLocation: pickupLocation,
+		ReturnDate:     pgtype.Timestamp{Time: returnDate, Valid: true},
+		ReturnLocation: returnLocation,
+	})
+	if err != nil {
+		logger.Error("Failed to reschedule booking", "booking_id", request.BookingId, "user_id", user.ID, "error", err)
+		return api.RescheduleBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if err := bookingevents.Record(ctx, qtx, rescheduled.ID, &user.ID,
+		db.NullRequestStatus{RequestStatus: locked.Status, Valid: true}, rescheduled.Status, map[string]any{
+			"rescheduled_from": locked.AvailabilityID,
+			"availability_id":  rescheduled.AvailabilityID,
+			"pick_up_date":     rescheduled.PickUpDate.Time,
+			"return_date":      rescheduled.ReturnDate.Time,
+		}); err != nil {
+		logger.Error("Failed to record booking reschedule", "booking_id", rescheduled.ID, "error", err)
+		return api.RescheduleBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit booking reschedule", "booking_id", rescheduled.ID, "error", err)
+		return api.RescheduleBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	updatedBooking, err := s.db.Queries().GetBookingByID(ctx, rescheduled.ID)
+	if err != nil {
+		logger.Error("Failed to fetch rescheduled booking", "booking_id", rescheduled.ID, "error", err)
+		return api.RescheduleBooking500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	// requester and manager both hear about it, plus the old manager when the
+	// new slot belongs to someone else
+	var recipients []uuid.UUID
+	for _, id := range []*uuid.UUID{booking.RequesterID, rescheduled.ManagerID, booking.ManagerID} {
+		if id != nil && !slices.Contains(recipients, *id) {
+			recipients = append(recipients, *id)
+		}
+	}
+	ctx = notifications.WithSandbox(ctx, booking.IsTest)
+	if notifyErr := s.dispatcher.Notify(ctx, user.ID, "booking", rescheduled.ID, []notifications.NotifierGroup{
+		{
+			IDs:      recipients,
+			Template: "booking_rescheduled",
+			TemplateData: map[string]interface{}{
+				"ItemName":       booking.ItemName,
+				"RescheduledBy":  user.Email,
+				"OldPickupDate":  booking.PickUpDate.Time.Format("2006-01-02 15:04"),
+				"PickupDate":     pickupDate.Format("2006-01-02 15:04"),
+				"PickupLocation": pickupLocation,
+				"ReturnDate":     returnDate.Format("2006-01-02 15:04"),
+				"ReturnLocation": returnLocation,
+			},
+		},
+	}); notifyErr != nil {
+		logger.Error("failed to notify booking reschedule", "booking_id", rescheduled.ID, "error", notifyErr)
+	}
+
+	logger.Info("Booking rescheduled",
+		"booking_id", rescheduled.ID,
+		"user_id", user.ID,
+		"availability_id", availability.ID)
+
+	return api.RescheduleBooking200JSONResponse(convertToBookingResponse(updatedBooking)), nil
+}
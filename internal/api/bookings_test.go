@@ -186,7 +186,7 @@ func TestServer_GetMyBookings(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetMyBookings200JSONResponse{}, response)
 
-		resp := response.(api.GetMyBookings200JSONResponse)
+		resp := response.(api.GetMyBookings200JSONResponse).Body
 		assert.Len(t, resp.Data, 2) // Returned only user's bookings
 
 		// Verify IDs
@@ -228,7 +228,7 @@ func TestServer_GetMyBookings(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetMyBookings200JSONResponse{}, response)
 
-		resp := response.(api.GetMyBookings200JSONResponse)
+		resp := response.(api.GetMyBookings200JSONResponse).Body
 		assert.Len(t, resp.Data, 1)
 		assert.Equal(t, confirmed.ID, resp.Data[0].Id)
 		assert.Equal(t, api.RequestStatus("confirmed"), resp.Data[0].Status)
@@ -245,7 +245,7 @@ func TestServer_GetMyBookings(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetMyBookings200JSONResponse{}, response)
 
-		resp := response.(api.GetMyBookings200JSONResponse)
+		resp := response.(api.GetMyBookings200JSONResponse).Body
 		assert.Len(t, resp.Data, 0)
 	})
 
@@ -426,7 +426,7 @@ func TestServer_ListBookings(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.ListBookings200JSONResponse{}, response)
 
-		resp := response.(api.ListBookings200JSONResponse)
+		resp := response.(api.ListBookings200JSONResponse).Body
 		assert.Len(t, resp.Data, 2) // Both bookings visible
 	})
 
@@ -461,7 +461,7 @@ func TestServer_ListBookings(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.ListBookings200JSONResponse{}, response)
 
-		resp := response.(api.ListBookings200JSONResponse)
+		resp := response.(api.ListBookings200JSONResponse).Body
 		assert.Len(t, resp.Data, 1) // Only user's booking visible
 		assert.Equal(t, user.ID, resp.Data[0].RequesterId)
 	})
@@ -1106,3 +1106,304 @@ func TestServer_CancelBooking(t *testing.T) {
 		assert.Equal(t, "PERMISSION_DENIED", string(resp.Error.Code))
 	})
 }
+
+func TestServer_GetBookingEvents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+	sharedQueue.Cleanup(t)
+
+	user := testDB.NewUser(t).WithEmail("user@events.test").AsMember().Create()
+	other := testDB.NewUser(t).WithEmail("other@events.test").AsMember().Create()
+	item := testDB.NewItem(t).WithName("Laptop").WithType("high").WithStock(5).Create()
+	approver := testDB.NewUser(t).WithEmail("approver@events.test").AsApprover().Create()
+	group := testDB.NewGroup(t).WithName("Test Group").Create()
+
+	ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+	otherCtx := testutil.ContextWithUser(context.Background(), other, testDB.Queries())
+
+	availability := createTestAvailability(t, testDB, approver.ID)
+	booking := createTestBooking(t, testDB,
+		availability.ID, user.ID, approver.ID, item.ID, group.ID,
+		db.RequestStatusPendingConfirmation, 0)
+
+	_, err := server.ConfirmBooking(ctx, api.ConfirmBookingRequestObject{BookingId: booking.ID})
+	require.NoError(t, err)
+
+	reason := "no longer needed"
+	mockAuth.ExpectCheckPermission(user.ID, rbac.ManageAllBookings, nil, false, nil)
+	_, err = server.CancelBooking(ctx, api.CancelBookingRequestObject{
+		BookingId: booking.ID,
+		Body:      &api.CancelBookingJSONRequestBody{Reason: &reason},
+	})
+	require.NoError(t, err)
+
+	t.Run("owner sees transitions in order", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ViewAllData, nil, false, nil)
+
+		response, err := server.GetBookingEvents(ctx, api.GetBookingEventsRequestObject{BookingId: booking.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetBookingEvents200JSONResponse{}, response)
+
+		events := response.(api.GetBookingEvents200JSONResponse)
+		require.Len(t, events, 2)
+
+		require.NotNil(t, events[0].FromStatus)
+		assert.Equal(t, api.RequestStatus("pending_confirmation"), *events[0].FromStatus)
+		assert.Equal(t, api.RequestStatus("confirmed"), events[0].ToStatus)
+		require.NotNil(t, events[0].ActorId)
+		assert.Equal(t, user.ID, *events[0].ActorId)
+
+		require.NotNil(t, events[1].FromStatus)
+		assert.Equal(t, api.RequestStatus("confirmed"), *events[1].FromStatus)
+		assert.Equal(t, api.RequestStatus("cancelled"), events[1].ToStatus)
+		assert.Equal(t, reason, events[1].Payload["reason"])
+	})
+
+	t.Run("other members cannot see the log", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(other.ID, rbac.ViewAllData, nil, false, nil)
+
+		response, err := server.GetBookingEvents(otherCtx, api.GetBookingEventsRequestObject{BookingId: booking.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetBookingEvents403JSONResponse{}, response)
+	})
+
+	t.Run("unknown booking", func(t *testing.T) {
+		response, err := server.GetBookingEvents(ctx, api.GetBookingEventsRequestObject{BookingId: uuid.New()})
+		require.NoError(t, err)
+		require.IsType(t, api.GetBookingEvents404JSONResponse{}, response)
+	})
+
+	t.Run("log cannot be edited or deleted", func(t *testing.T) {
+		_, err := testDB.Pool().Exec(ctx, `UPDATE booking_events SET payload = '{}' WHERE booking_id = $1`, booking.ID)
+		assert.ErrorContains(t, err, "immutable")
+
+		_, err = testDB.Pool().Exec(ctx, `DELETE FROM booking_events WHERE booking_id = $1`, booking.ID)
+		assert.ErrorContains(t, err, "immutable")
+
+		// deleting the booking itself takes its log with it
+		_, err = testDB.Pool().Exec(ctx, `DELETE FROM booking WHERE id = $1`, booking.ID)
+		require.NoError(t, err)
+
+		var remaining int
+		require.NoError(t, testDB.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM booking_events WHERE booking_id = $1`, booking.ID).Scan(&remaining))
+		assert.Zero(t, remaining)
+	})
+}
+
+func TestServer_RescheduleBooking(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	// another manager's slot daysAhead days out, on the first time slot
+	createSlot := func(t *testing.T, managerID uuid.UUID, daysAhead int) (db.UserAvailability, time.Time) {
+		t.Helper()
+		ctx := context.Background()
+		timeSlots, err := testDB.Queries().ListTimeSlots(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, timeSlots)
+
+		date := time.Now().AddDate(0, 0, daysAhead)
+		availability, err := testDB.Queries().CreateAvailability(ctx, db.CreateAvailabilityParams{
+			ID:         uuid.New(),
+			UserID:     &managerID,
+			TimeSlotID: &timeSlots[0].ID,
+			Date:       pgtype.Date{Time: date, Valid: true},
+		})
+		require.NoError(t, err)
+
+		slot, err := testDB.Queries().GetAvailabilityByID(ctx, availability.ID)
+		require.NoError(t, err)
+		return availability, availabilityStart(slot)
+	}
+
+	t.Run("requester moves a pending booking to another manager's slot", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		sharedQueue.Cleanup(t)
+
+		user := testDB.NewUser(t).WithEmail("user@reschedule.test").AsMember().Create()
+		approver := testDB.NewUser(t).WithEmail("approver@reschedule.test").AsApprover().Create()
+		other := testDB.NewUser(t).WithEmail("other@reschedule.test").AsApprover().Create()
+		item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+		group := testDB.NewGroup(t).WithName("Reschedule Group").Create()
+
+		availability := createTestAvailability(t, testDB, approver.ID)
+		booking := createTestBooking(t, testDB,
+			availability.ID, user.ID, approver.ID, item.ID, group.ID,
+			db.RequestStatusPendingConfirmation, 0)
+		slot, pickup := createSlot(t, other.ID, 10)
+
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ManageAllBookings, nil, false, nil)
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ManageGroupBookings, &group.ID, false, nil)
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+		location := "Room 204"
+		response, err := server.RescheduleBooking(ctx, api.RescheduleBookingRequestObject{
+			BookingId: booking.ID,
+			Body:      &api.RescheduleBookingJSONRequestBody{AvailabilityId: slot.ID, PickupLocation: &location},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RescheduleBooking200JSONResponse{}, response)
+
+		resp := response.(api.RescheduleBooking200JSONResponse)
+		assert.Equal(t, api.RequestStatus("pending_confirmation"), resp.Status)
+
+		updated, err := testDB.Queries().GetBookingByID(ctx, booking.ID)
+		require.NoError(t, err)
+		assert.Equal(t, slot.ID, *updated.AvailabilityID)
+		assert.Equal(t, other.ID, *updated.ManagerID)
+		assert.WithinDuration(t, pickup, updated.PickUpDate.Time, time.Second)
+		assert.WithinDuration(t, pickup.Add(booking.ReturnDate.Sub(booking.PickupDate)), updated.ReturnDate.Time, time.Second)
+		assert.Equal(t, "Room 204", updated.PickUpLocation)
+		assert.Equal(t, "Main Office", updated.ReturnLocation)
+
+		events, err := testDB.Queries().ListBookingEvents(ctx, booking.ID)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, db.RequestStatusPendingConfirmation, events[0].ToStatus)
+
+		// requester, new manager and old manager
+		tasks, err := sharedQueue.Inspector.ListPendingTasks("default")
+		require.NoError(t, err)
+		assert.Len(t, tasks, 3)
+	})
+
+	t.Run("group manager moves a confirmed booking", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		user := testDB.NewUser(t).WithEmail("user@reschedule.test").AsMember().Create()
+		manager := testDB.NewUser(t).WithEmail("manager@reschedule.test").AsApprover().Create()
+		item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+		group := testDB.NewGroup(t).WithName("Reschedule Group").Create()
+
+		availability := createTestAvailability(t, testDB, manager.ID)
+		booking := createTestBooking(t, testDB,
+			availability.ID, user.ID, manager.ID, item.ID, group.ID,
+			db.RequestStatusConfirmed, 0)
+		slot, _ := createSlot(t, manager.ID, 12)
+
+		mockAuth.ExpectCheckPermission(manager.ID, rbac.ManageAllBookings, nil, false, nil)
+		mockAuth.ExpectCheckPermission(manager.ID, rbac.ManageGroupBookings, &group.ID, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), manager, testDB.Queries())
+		response, err := server.RescheduleBooking(ctx, api.RescheduleBookingRequestObject{
+			BookingId: booking.ID,
+			Body:      &api.RescheduleBookingJSONRequestBody{AvailabilityId: slot.ID},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RescheduleBooking200JSONResponse{}, response)
+		assert.Equal(t, api.RequestStatus("confirmed"), response.(api.RescheduleBooking200JSONResponse).Status)
+	})
+
+	t.Run("pending booking moved past its confirmation window", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		user := testDB.NewUser(t).WithEmail("user@reschedule.test").AsMember().Create()
+		approver := testDB.NewUser(t).WithEmail("approver@reschedule.test").AsApprover().Create()
+		item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+		group := testDB.NewGroup(t).WithName("Reschedule Group").Create()
+
+		// confirmation closes 30 days before pickup, so a slot 10 days out can't be confirmed
+		_, err := testDB.Queries().UpsertGroupBookingPolicy(context.Background(), db.UpsertGroupBookingPolicyParams{
+			GroupID:           group.ID,
+			PickupCutoffHours: pgtype.Int4{Int32: 30 * 24, Valid: true},
+		})
+		require.NoError(t, err)
+
+		availability := createTestAvailability(t, testDB, approver.ID)
+		booking := createTestBooking(t, testDB,
+			availability.ID, user.ID, approver.ID, item.ID, group.ID,
+			db.RequestStatusPendingConfirmation, 0)
+		slot, _ := createSlot(t, approver.ID, 10)
+
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ManageAllBookings, nil, false, nil)
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ManageGroupBookings, &group.ID, false, nil)
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+		response, err := server.RescheduleBooking(ctx, api.RescheduleBookingRequestObject{
+			BookingId: booking.ID,
+			Body:      &api.RescheduleBookingJSONRequestBody{AvailabilityId: slot.ID},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RescheduleBooking400JSONResponse{}, response)
+	})
+
+	t.Run("slot in the past", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		user := testDB.NewUser(t).WithEmail("user@reschedule.test").AsMember().Create()
+		approver := testDB.NewUser(t).WithEmail("approver@reschedule.test").AsApprover().Create()
+		item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+		group := testDB.NewGroup(t).WithName("Reschedule Group").Create()
+
+		availability := createTestAvailability(t, testDB, approver.ID)
+		booking := createTestBooking(t, testDB,
+			availability.ID, user.ID, approver.ID, item.ID, group.ID,
+			db.RequestStatusConfirmed, 0)
+		slot, _ := createSlot(t, approver.ID, -3)
+
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ManageAllBookings, nil, false, nil)
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ManageGroupBookings, &group.ID, false, nil)
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+		response, err := server.RescheduleBooking(ctx, api.RescheduleBookingRequestObject{
+			BookingId: booking.ID,
+			Body:      &api.RescheduleBookingJSONRequestBody{AvailabilityId: slot.ID},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RescheduleBooking400JSONResponse{}, response)
+	})
+
+	t.Run("cancelled bookings stay put", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		user := testDB.NewUser(t).WithEmail("user@reschedule.test").AsMember().Create()
+		approver := testDB.NewUser(t).WithEmail("approver@reschedule.test").AsApprover().Create()
+		item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+		group := testDB.NewGroup(t).WithName("Reschedule Group").Create()
+
+		availability := createTestAvailability(t, testDB, approver.ID)
+		booking := createTestBooking(t, testDB,
+			availability.ID, user.ID, approver.ID, item.ID, group.ID,
+			db.RequestStatusCancelled, 0)
+		slot, _ := createSlot(t, approver.ID, 10)
+
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ManageAllBookings, nil, false, nil)
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ManageGroupBookings, &group.ID, false, nil)
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+		response, err := server.RescheduleBooking(ctx, api.RescheduleBookingRequestObject{
+			BookingId: booking.ID,
+			Body:      &api.RescheduleBookingJSONRequestBody{AvailabilityId: slot.ID},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RescheduleBooking400JSONResponse{}, response)
+	})
+
+	t.Run("someone else's booking", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		user := testDB.NewUser(t).WithEmail("user@reschedule.test").AsMember().Create()
+		stranger := testDB.NewUser(t).WithEmail("stranger@reschedule.test").AsMember().Create()
+		approver := testDB.NewUser(t).WithEmail("approver@reschedule.test").AsApprover().Create()
+		item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+		group := testDB.NewGroup(t).WithName("Reschedule Group").Create()
+
+		availability := createTestAvailability(t, testDB, approver.ID)
+		booking := createTestBooking(t, testDB,
+			availability.ID, user.ID, approver.ID, item.ID, group.ID,
+			db.RequestStatusPendingConfirmation, 0)
+		slot, _ := createSlot(t, approver.ID, 10)
+
+		mockAuth.ExpectCheckPermission(stranger.ID, rbac.ManageAllBookings, nil, false, nil)
+		mockAuth.ExpectCheckPermission(stranger.ID, rbac.ManageGroupBookings, &group.ID, false, nil)
+		ctx := testutil.ContextWithUser(context.Background(), stranger, testDB.Queries())
+		response, err := server.RescheduleBooking(ctx, api.RescheduleBookingRequestObject{
+			BookingId: booking.ID,
+			Body:      &api.RescheduleBookingJSONRequestBody{AvailabilityId: slot.ID},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RescheduleBooking403JSONResponse{}, response)
+	})
+}
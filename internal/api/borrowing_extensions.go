@@ -0,0 +1,353 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func toBorrowingExtensionResponse(ext db.BorrowingExtension) api.BorrowingExtension {
+	resp := api.BorrowingExtension{
+		Id:               ext.ID,
+		BorrowingId:      ext.BorrowingID,
+		RequestedBy:      ext.RequestedBy,
+		CurrentDueDate:   ext.CurrentDueDate.Time,
+		RequestedDueDate: ext.RequestedDueDate.Time,
+		Status:           api.BorrowingExtensionStatus(ext.Status),
+		DecidedBy:        ext.DecidedBy,
+		CreatedAt:        ext.CreatedAt.Time,
+	}
+	if ext.Reason.Valid {
+		resp.Reason = &ext.Reason.String
+	}
+	if ext.DecisionNotes.Valid {
+		resp.DecisionNotes = &ext.DecisionNotes.String
+	}
+	if ext.DecidedAt.Valid {
+		resp.DecidedAt = &ext.DecidedAt.Time
+	}
+	return resp
+}
+
+// why someone else needs the item before the requested due date, or "" when
+// nobody does: anyone still on its waitlist, or a booking picking it up first.
+func extensionBlocker(ctx context.Context, q *db.Queries, itemID uuid.UUID, dueDate time.Time) (string, error) {
+	waiting, err := q.ListItemWaitlist(ctx, itemID)
+	if err != nil {
+		return "", err
+	}
+	if len(waiting) > 0 {
+		return "Other members are on the waitlist for this item.", nil
+	}
+
+	booked, err := q.CountUpcomingItemBookings(ctx, db.CountUpcomingItemBookingsParams{
+		ItemID: &itemID,
+		Cutoff: pgtype.Timestamp{Time: dueDate, Valid: true},
+	})
+	if err != nil {
+		return "", err
+	}
+	if booked > 0 {
+		return "This item is booked by someone else before the requested date.", nil
+	}
+	return "", nil
+}
+
+func (s Server) RequestBorrowingExtension(ctx context.Context, request api.RequestBorrowingExtensionRequestObject) (api.RequestBorrowingExtensionResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RequestBorrowingExtension401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.RequestItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.RequestItems permission", "error", err)
+		return api.RequestBorrowingExtension500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.RequestBorrowingExtension403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.RequestBorrowingExtension400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+
+	borrowing, err := s.db.Queries().GetBorrowingByID(ctx, request.BorrowingId)
+	if err == pgx.ErrNoRows {
+		return api.RequestBorrowingExtension404JSONResponse(NotFound("Borrowing").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get borrowing", "borrowing_id", request.BorrowingId, "error", err)
+		return api.RequestBorrowingExtension500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if borrowing.UserID == nil || *borrowing.UserID != user.ID {
+		return api.RequestBorrowingExtension403JSONResponse(PermissionDenied("Only the borrower can ask for an extension").Create()), nil
+	}
+	if borrowing.ReturnedAt.Valid {
+		return api.RequestBorrowingExtension409JSONResponse(ConflictErr("Borrowing has already been returned").Create()), nil
+	}
+	if !request.Body.DueDate.After(borrowing.DueDate.Time) {
+		return api.RequestBorrowingExtension400JSONResponse(ValidationErr("New due date must be after the current one", nil).
+			WithContext(ErrorContext{"due_date": borrowing.DueDate.Time}).Create()), nil
+	}
+
+	params := db.CreateBorrowingExtensionParams{
+		BorrowingID:      borrowing.ID,
+		RequestedBy:      &user.ID,
+		CurrentDueDate:   borrowing.DueDate,
+		RequestedDueDate: pgtype.Timestamp{Time: request.Body.DueDate, Valid: true},
+		Status:           db.ExtensionStatusPending,
+	}
+	if request.Body.Reason != nil && *request.Body.Reason != "" {
+		params.Reason = pgtype.Text{String: *request.Body.Reason, Valid: true}
+	}
+
+	// nobody needs to look at a request that could never be granted
+	if borrowing.ItemID != nil {
+		blocker, err := extensionBlocker(ctx, s.db.Queries(), *borrowing.ItemID, request.Body.DueDate)
+		if err != nil {
+			logger.Error("Failed to check item demand", "borrowing_id", borrowing.ID, "error", err)
+			return api.RequestBorrowingExtension500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		if blocker != "" {
+			params.Status = db.ExtensionStatusDenied
+			params.DecisionNotes = pgtype.Text{String: blocker, Valid: true}
+		}
+	}
+
+	ext, err := s.db.Queries().CreateBorrowingExtension(ctx, params)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return api.RequestBorrowingExtension409JSONResponse(ConflictErr("An extension for this borrowing is already awaiting a decision").Create()), nil
+		}
+		logger.Error("Failed to create borrowing extension", "borrowing_id", borrowing.ID, "error", err)
+		return api.RequestBorrowingExtension500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if ext.Status == db.ExtensionStatusDenied {
+		s.notifyExtensionDecided(ctx, user.ID, ext, borrowing)
+	}
+
+	logger.Info("Borrowing extension requested",
+		"extension_id", ext.ID,
+		"borrowing_id", borrowing.ID,
+		"status", ext.Status,
+		"user_id", user.ID)
+	return api.RequestBorrowingExtension201JSONResponse(toBorrowingExtensionResponse(ext)), nil
+}
+
+func (s Server) ListBorrowingExtensions(ctx context.Context, request api.ListBorrowingExtensionsRequestObject) (api.ListBorrowingExtensionsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListBorrowingExtensions401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ApproveAllRequests, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ApproveAllRequests permission", "error", err)
+		return api.ListBorrowingExtensions500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListBorrowingExtensions403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	var status db.NullExtensionStatus
+	if request.Params.Status != nil {
+		status = db.NullExtensionStatus{ExtensionStatus: db.ExtensionStatus(*request.Params.Status), Valid: true}
+	}
+
+	list, err := s.db.Queries().ListBorrowingExtensions(ctx, db.ListBorrowingExtensionsParams{
+		Status: status,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		logger.Error("Failed to list borrowing extensions", "error", err)
+		return api.ListBorrowingExtensions500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountBorrowingExtensions(ctx, status)
+	if err != nil {
+		logger.Error("Failed to count borrowing extensions", "error", err)
+		return api.ListBorrowingExtensions500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	data := make([]api.BorrowingExtension, 0, len(list))
+	for _, ext := range list {
+		data = append(data, toBorrowingExtensionResponse(ext))
+	}
+
+	return api.ListBorrowingExtensions200JSONResponse{
+		Data: data,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
+
+func (s Server) DecideBorrowingExtension(ctx context.Context, request api.DecideBorrowingExtensionRequestObject) (api.DecideBorrowingExtensionResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.DecideBorrowingExtension401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ApproveAllRequests, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ApproveAllRequests permission", "error", err)
+		return api.DecideBorrowingExtension500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.DecideBorrowingExtension403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.DecideBorrowingExtension400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	approve := request.Body.Status == api.BorrowingExtensionStatusApproved
+	if !approve && request.Body.Status != api.BorrowingExtensionStatusDenied {
+		return api.DecideBorrowingExtension400JSONResponse(ValidationErr("status must be 'approved' or 'denied'", nil).Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to start transaction", "error", err)
+		return api.DecideBorrowingExtension500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.db.Queries().WithTx(tx)
+
+	if _, err := qtx.GetBorrowingExtensionByID(ctx, request.ExtensionId); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.DecideBorrowingExtension404JSONResponse(NotFound("Borrowing extension").Create()), nil
+		}
+		logger.Error("Failed to get borrowing extension", "extension_id", request.ExtensionId, "error", err)
+		return api.DecideBorrowingExtension500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	var notes pgtype.Text
+	if request.Body.Notes != nil && *request.Body.Notes != "" {
+		notes = pgtype.Text{String: *request.Body.Notes, Valid: true}
+	}
+
+	ext, err := qtx.DecideBorrowingExtension(ctx, db.DecideBorrowingExtensionParams{
+		ID:            request.ExtensionId,
+		Status:        db.ExtensionStatus(request.Body.Status),
+		DecisionNotes: notes,
+		DecidedBy:     &user.ID,
+	})
+	if err == pgx.ErrNoRows {
+		return api.DecideBorrowingExtension409JSONResponse(ConflictErr("Extension request has already been decided").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to decide borrowing extension", "extension_id", request.ExtensionId, "error", err)
+		return api.DecideBorrowingExtension500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	borrowing, err := qtx.GetBorrowingByID(ctx, ext.BorrowingID)
+	if err != nil {
+		logger.Error("Failed to get borrowing", "borrowing_id", ext.BorrowingID, "error", err)
+		return api.DecideBorrowingExtension500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if approve {
+		// the waitlist or a booking may have appeared while the request waited
+		if borrowing.ItemID != nil {
+			blocker, err := extensionBlocker(ctx, qtx, *borrowing.ItemID, ext.RequestedDueDate.Time)
+			if err != nil {
+				logger.Error("Failed to check item demand", "borrowing_id", borrowing.ID, "error", err)
+				return api.DecideBorrowingExtension500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+			}
+			if blocker != "" {
+				return api.DecideBorrowingExtension409JSONResponse(ConflictErr(blocker).Create()), nil
+			}
+		}
+
+		borrowing, err = qtx.ExtendBorrowingDueDate(ctx, db.ExtendBorrowingDueDateParams{
+			ID:      borrowing.ID,
+			DueDate: ext.RequestedDueDate,
+		})
+		if err == pgx.ErrNoRows {
+			return api.DecideBorrowingExtension409JSONResponse(ConflictErr("Borrowing has already been returned").Create()), nil
+		}
+		if err != nil {
+			logger.Error("Failed to extend borrowing", "borrowing_id", ext.BorrowingID, "error", err)
+			return api.DecideBorrowingExtension500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+
+		// reminders sent against the old due date no longer apply
+		if err := qtx.ResetBorrowingOverdue(ctx, borrowing.ID); err != nil {
+			logger.Error("Failed to reset overdue state", "borrowing_id", borrowing.ID, "error", err)
+			return api.DecideBorrowingExtension500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit extension decision", "extension_id", ext.ID, "error", err)
+		return api.DecideBorrowingExtension500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	s.notifyExtensionDecided(ctx, user.ID, ext, borrowing)
+
+	logger.Info("Borrowing extension decided",
+		"extension_id", ext.ID,
+		"borrowing_id", ext.BorrowingID,
+		"status", ext.Status,
+		"user_id", user.ID)
+	return api.DecideBorrowingExtension200JSONResponse(toBorrowingExtensionResponse(ext)), nil
+}
+
+// emails the borrower whether they may keep the item longer. borrowing
+// carries the due date as it stands after the decision.
+func (s Server) notifyExtensionDecided(ctx context.Context, actorID uuid.UUID, ext db.BorrowingExtension, borrowing db.Borrowing) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	if borrowing.UserID == nil || borrowing.ItemID == nil {
+		return
+	}
+	ctx = s.sandboxContext(ctx, borrowing.GroupID)
+
+	item, err := s.db.Queries().GetItemByIDIncludingBinned(ctx, *borrowing.ItemID)
+	if err != nil {
+		logger.Error("Failed to get item for extension decision", "extension_id", ext.ID, "error", err)
+		return
+	}
+
+	template := "borrowing_extension_denied"
+	if ext.Status == db.ExtensionStatusApproved {
+		template = "borrowing_extension_approved"
+	}
+
+	if err := s.dispatcher.Notify(ctx, actorID, "borrowing", borrowing.ID, []notifications.NotifierGroup{
+		{
+			IDs:      []uuid.UUID{*borrowing.UserID},
+			Template: template,
+			TemplateData: map[string]interface{}{
+				"ItemName":         item.Name,
+				"Quantity":         borrowing.Quantity,
+				"DueDate":          borrowing.DueDate.Time.Format("2006-01-02"),
+				"RequestedDueDate": ext.RequestedDueDate.Time.Format("2006-01-02"),
+				"Notes":            ext.DecisionNotes.String,
+			},
+		},
+	}); err != nil {
+		logger.Error("Failed to send extension decision notification", "extension_id", ext.ID, "error", err)
+	}
+}
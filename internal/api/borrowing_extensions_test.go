@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RequestBorrowingExtension(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("waits for an approver", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@extend.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		borrowing := createBorrowing(t, testDB, member.ID)
+
+		reason := "Still filming"
+		mockAuth.ExpectCheckPermission(member.ID, rbac.RequestItems, nil, true, nil)
+		response, err := server.RequestBorrowingExtension(ctx, api.RequestBorrowingExtensionRequestObject{
+			BorrowingId: borrowing.ID,
+			Body: &api.BorrowingExtensionRequest{
+				DueDate: borrowing.DueDate.Time.Add(3 * 24 * time.Hour),
+				Reason:  &reason,
+			},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RequestBorrowingExtension201JSONResponse{}, response)
+
+		ext := response.(api.RequestBorrowingExtension201JSONResponse)
+		assert.Equal(t, api.BorrowingExtensionStatusPending, ext.Status)
+		assert.Equal(t, reason, *ext.Reason)
+		assert.Nil(t, ext.DecidedAt)
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.RequestItems, nil, true, nil)
+		response, err = server.RequestBorrowingExtension(ctx, api.RequestBorrowingExtensionRequestObject{
+			BorrowingId: borrowing.ID,
+			Body:        &api.BorrowingExtensionRequest{DueDate: borrowing.DueDate.Time.Add(24 * time.Hour)},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RequestBorrowingExtension409JSONResponse{}, response, "one pending request per borrowing")
+	})
+
+	t.Run("denied straight away when someone is waiting", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@extend.test").AsMember().Create()
+		waiter := testDB.NewUser(t).WithEmail("waiter@extend.test").AsMember().Create()
+		group := testDB.NewGroup(t).WithName("Waiters").Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		borrowing := createBorrowing(t, testDB, member.ID)
+
+		_, err := testDB.Queries().JoinItemWaitlist(ctx, db.JoinItemWaitlistParams{
+			ItemID:   *borrowing.ItemID,
+			UserID:   waiter.ID,
+			GroupID:  group.ID,
+			Quantity: 1,
+		})
+		require.NoError(t, err)
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.RequestItems, nil, true, nil)
+		response, err := server.RequestBorrowingExtension(ctx, api.RequestBorrowingExtensionRequestObject{
+			BorrowingId: borrowing.ID,
+			Body:        &api.BorrowingExtensionRequest{DueDate: borrowing.DueDate.Time.Add(24 * time.Hour)},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RequestBorrowingExtension201JSONResponse{}, response)
+
+		ext := response.(api.RequestBorrowingExtension201JSONResponse)
+		assert.Equal(t, api.BorrowingExtensionStatusDenied, ext.Status)
+		assert.NotNil(t, ext.DecisionNotes)
+		assert.NotNil(t, ext.DecidedAt)
+		assert.Nil(t, ext.DecidedBy)
+	})
+
+	t.Run("due date must move later", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@extend.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		borrowing := createBorrowing(t, testDB, member.ID)
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.RequestItems, nil, true, nil)
+		response, err := server.RequestBorrowingExtension(ctx, api.RequestBorrowingExtensionRequestObject{
+			BorrowingId: borrowing.ID,
+			Body:        &api.BorrowingExtensionRequest{DueDate: borrowing.DueDate.Time.Add(-time.Hour)},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RequestBorrowingExtension400JSONResponse{}, response)
+	})
+
+	t.Run("only the borrower may ask", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@extend.test").AsMember().Create()
+		other := testDB.NewUser(t).WithEmail("other@extend.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), other, testDB.Queries())
+		borrowing := createBorrowing(t, testDB, member.ID)
+
+		mockAuth.ExpectCheckPermission(other.ID, rbac.RequestItems, nil, true, nil)
+		response, err := server.RequestBorrowingExtension(ctx, api.RequestBorrowingExtensionRequestObject{
+			BorrowingId: borrowing.ID,
+			Body:        &api.BorrowingExtensionRequest{DueDate: borrowing.DueDate.Time.Add(24 * time.Hour)},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RequestBorrowingExtension403JSONResponse{}, response)
+	})
+}
+
+func TestServer_DecideBorrowingExtension(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	requestExtension := func(t *testing.T, member *testutil.TestUser, borrowing db.Borrowing, dueDate time.Time) api.BorrowingExtension {
+		t.Helper()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		mockAuth.ExpectCheckPermission(member.ID, rbac.RequestItems, nil, true, nil)
+		response, err := server.RequestBorrowingExtension(ctx, api.RequestBorrowingExtensionRequestObject{
+			BorrowingId: borrowing.ID,
+			Body:        &api.BorrowingExtensionRequest{DueDate: dueDate},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RequestBorrowingExtension201JSONResponse{}, response)
+		return api.BorrowingExtension(response.(api.RequestBorrowingExtension201JSONResponse))
+	}
+
+	t.Run("approval moves the due date", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@extend.test").AsMember().Create()
+		approver := testDB.NewUser(t).WithEmail("approver@extend.test").AsGlobalAdmin().Create()
+		borrowing := createBorrowing(t, testDB, member.ID)
+		newDue := borrowing.DueDate.Time.Add(5 * 24 * time.Hour)
+		ext := requestExtension(t, member, borrowing, newDue)
+
+		ctx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ApproveAllRequests, nil, true, nil)
+		response, err := server.DecideBorrowingExtension(ctx, api.DecideBorrowingExtensionRequestObject{
+			ExtensionId: ext.Id,
+			Body:        &api.BorrowingExtensionDecision{Status: api.BorrowingExtensionStatusApproved},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.DecideBorrowingExtension200JSONResponse{}, response)
+		decided := response.(api.DecideBorrowingExtension200JSONResponse)
+		assert.Equal(t, api.BorrowingExtensionStatusApproved, decided.Status)
+		assert.Equal(t, approver.ID, *decided.DecidedBy)
+
+		updated, err := testDB.Queries().GetBorrowingByID(ctx, borrowing.ID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, newDue, updated.DueDate.Time, time.Millisecond)
+
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ApproveAllRequests, nil, true, nil)
+		response, err = server.DecideBorrowingExtension(ctx, api.DecideBorrowingExtensionRequestObject{
+			ExtensionId: ext.Id,
+			Body:        &api.BorrowingExtensionDecision{Status: api.BorrowingExtensionStatusDenied},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.DecideBorrowingExtension409JSONResponse{}, response, "already decided")
+	})
+
+	t.Run("denial leaves the due date alone", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@extend.test").AsMember().Create()
+		approver := testDB.NewUser(t).WithEmail("approver@extend.test").AsGlobalAdmin().Create()
+		borrowing := createBorrowing(t, testDB, member.ID)
+		ext := requestExtension(t, member, borrowing, borrowing.DueDate.Time.Add(24*time.Hour))
+
+		notes := "Needed for orientation week"
+		ctx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ApproveAllRequests, nil, true, nil)
+		response, err := server.DecideBorrowingExtension(ctx, api.DecideBorrowingExtensionRequestObject{
+			ExtensionId: ext.Id,
+			Body:        &api.BorrowingExtensionDecision{Status: api.BorrowingExtensionStatusDenied, Notes: &notes},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.DecideBorrowingExtension200JSONResponse{}, response)
+		assert.Equal(t, notes, *response.(api.DecideBorrowingExtension200JSONResponse).DecisionNotes)
+
+		updated, err := testDB.Queries().GetBorrowingByID(ctx, borrowing.ID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, borrowing.DueDate.Time, updated.DueDate.Time, time.Millisecond)
+	})
+
+	t.Run("pending is not a decision", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		approver := testDB.NewUser(t).WithEmail("approver@extend.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ApproveAllRequests, nil, true, nil)
+		response, err := server.DecideBorrowingExtension(ctx, api.DecideBorrowingExtensionRequestObject{
+			ExtensionId: uuid.New(),
+			Body:        &api.BorrowingExtensionDecision{Status: api.BorrowingExtensionStatusPending},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.DecideBorrowingExtension400JSONResponse{}, response)
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@extend.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ApproveAllRequests, nil, false, nil)
+		response, err := server.DecideBorrowingExtension(ctx, api.DecideBorrowingExtensionRequestObject{
+			ExtensionId: uuid.New(),
+			Body:        &api.BorrowingExtensionDecision{Status: api.BorrowingExtensionStatusApproved},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.DecideBorrowingExtension403JSONResponse{}, response)
+	})
+}
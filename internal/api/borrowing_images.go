@@ -11,24 +11,42 @@ import (
 	"github.com/USSTM/cv-backend/internal/auth"
 	cvimage "github.com/USSTM/cv-backend/internal/image"
 	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/queue"
 	"github.com/USSTM/cv-backend/internal/rbac"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func (s Server) buildBorrowingImageResponse(ctx context.Context, img db.BorrowingImage) genapi.BorrowingImage {
 	logger := middleware.GetLoggerFromContext(ctx)
-	url, err := s.s3Service.GeneratePresignedURL(ctx, "GET", img.S3Key, time.Hour)
+	url, err := s.storage.GeneratePresignedURL(ctx, "GET", img.S3Key, time.Hour)
 	if err != nil {
 		logger.Warn("failed to generate presigned URL", "key", img.S3Key, "error", err)
 	}
-	return genapi.BorrowingImage{
+	response := genapi.BorrowingImage{
 		Id:          img.ID,
 		BorrowingId: img.BorrowingID,
 		Url:         url,
 		ImageType:   genapi.BorrowingImageImageType(img.ImageType),
 		CreatedAt:   img.CreatedAt.Time,
 	}
+	// variants appear once the worker has processed the photo
+	if img.WebS3Key.Valid {
+		if webURL, err := s.storage.GeneratePresignedURL(ctx, "GET", img.WebS3Key.String, time.Hour); err != nil {
+			logger.Warn("failed to generate presigned URL", "key", img.WebS3Key.String, "error", err)
+		} else {
+			response.WebUrl = &webURL
+		}
+	}
+	if img.ThumbnailS3Key.Valid {
+		if thumbURL, err := s.storage.GeneratePresignedURL(ctx, "GET", img.ThumbnailS3Key.String, time.Hour); err != nil {
+			logger.Warn("failed to generate presigned URL", "key", img.ThumbnailS3Key.String, "error", err)
+		} else {
+			response.ThumbnailUrl = &thumbURL
+		}
+	}
+	return response
 }
 
 // returns borrowing and whether the user may manage images for it.
@@ -121,13 +139,13 @@ func (s Server) UploadBorrowingImage(ctx context.Context, request genapi.UploadB
 
 	logger := middleware.GetLoggerFromContext(ctx)
 
-	if err := s.s3Service.PutObject(ctx, s3Key, bytes.NewReader(processed.Original), processed.ContentType); err != nil {
+	if err := s.storage.PutObject(ctx, s3Key, bytes.NewReader(processed.Original), processed.ContentType); err != nil {
 		return genapi.UploadBorrowingImage500JSONResponse(InternalError("Failed to upload image").Create()), nil
 	}
 
 	tx, err := s.db.Pool().Begin(ctx)
 	if err != nil {
-		if err := s.s3Service.DeleteObject(ctx, s3Key); err != nil {
+		if err := s.storage.DeleteObject(ctx, s3Key); err != nil {
 			logger.Warn("failed to delete S3 object", "key", s3Key, "error", err)
 		}
 		return genapi.UploadBorrowingImage500JSONResponse(InternalError("Failed to start transaction").Create()), nil
@@ -143,19 +161,24 @@ func (s Server) UploadBorrowingImage(ctx context.Context, request genapi.UploadB
 		UploadedBy:  &user.ID,
 	})
 	if err != nil {
-		if err := s.s3Service.DeleteObject(ctx, s3Key); err != nil {
+		if err := s.storage.DeleteObject(ctx, s3Key); err != nil {
 			logger.Warn("failed to delete S3 object", "key", s3Key, "error", err)
 		}
 		return genapi.UploadBorrowingImage500JSONResponse(InternalError("Failed to save image record").Create()), nil
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		if err := s.s3Service.DeleteObject(ctx, s3Key); err != nil {
+		if err := s.storage.DeleteObject(ctx, s3Key); err != nil {
 			logger.Warn("failed to delete S3 object", "key", s3Key, "error", err)
 		}
 		return genapi.UploadBorrowingImage500JSONResponse(InternalError("Failed to commit transaction").Create()), nil
 	}
 
+	// the photo is served as uploaded until the worker has made its variants
+	if _, err := s.queue.Enqueue(ctx, queue.TypePhotoProcess, queue.PhotoProcessPayload{ImageID: img.ID}); err != nil {
+		logger.Warn("Failed to enqueue photo processing", "image_id", img.ID, "error", err)
+	}
+
 	return genapi.UploadBorrowingImage201JSONResponse(s.buildBorrowingImageResponse(ctx, img)), nil
 }
 
@@ -216,8 +239,14 @@ func (s Server) DeleteBorrowingImage(ctx context.Context, request genapi.DeleteB
 	if err := s.db.Queries().DeleteBorrowingImage(ctx, img.ID); err != nil {
 		return genapi.DeleteBorrowingImage500JSONResponse(InternalError("Failed to delete image record").Create()), nil
 	}
-	if err := s.s3Service.DeleteObject(ctx, img.S3Key); err != nil {
-		middleware.GetLoggerFromContext(ctx).Warn("failed to delete S3 object", "key", img.S3Key, "error", err)
+	logger := middleware.GetLoggerFromContext(ctx)
+	for _, key := range []pgtype.Text{{String: img.S3Key, Valid: true}, img.WebS3Key, img.ThumbnailS3Key} {
+		if !key.Valid {
+			continue
+		}
+		if err := s.storage.DeleteObject(ctx, key.String); err != nil {
+			logger.Warn("failed to delete S3 object", "key", key.String, "error", err)
+		}
 	}
 
 	return genapi.DeleteBorrowingImage204Response{}, nil
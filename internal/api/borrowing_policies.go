@@ -0,0 +1,366 @@
+package api
+
+import (
+	"context"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s Server) ListBorrowingPolicies(ctx context.Context, request api.ListBorrowingPoliciesRequestObject) (api.ListBorrowingPoliciesResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListBorrowingPolicies401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageBorrowingPolicies, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageBorrowingPolicies permission", "error", err)
+		return api.ListBorrowingPolicies500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListBorrowingPolicies403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	policies, err := s.db.Queries().ListBorrowingPolicies(ctx)
+	if err != nil {
+		logger.Error("Failed to list borrowing policies", "error", err)
+		return api.ListBorrowingPolicies500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := make(api.ListBorrowingPolicies200JSONResponse, 0, len(policies))
+	for _, policy := range policies {
+		response = append(response, toBorrowingPolicyResponse(policy))
+	}
+	return response, nil
+}
+
+func (s Server) CreateBorrowingPolicy(ctx context.Context, request api.CreateBorrowingPolicyRequestObject) (api.CreateBorrowingPolicyResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateBorrowingPolicy401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageBorrowingPolicies, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageBorrowingPolicies permission", "error", err)
+		return api.CreateBorrowingPolicy500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CreateBorrowingPolicy403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	params, apiErr := s.borrowingPolicyParams(ctx, request.Body)
+	if apiErr != nil {
+		if apiErr.Code == CodeInternalError {
+			logger.Error("Failed to validate borrowing policy", "error", apiErr.Cause)
+			return api.CreateBorrowingPolicy500JSONResponse(apiErr.Create()), nil
+		}
+		return api.CreateBorrowingPolicy400JSONResponse(apiErr.Create()), nil
+	}
+	params.CreatedBy = &user.ID
+
+	policy, err := s.db.Queries().CreateBorrowingPolicy(ctx, params)
+	if err != nil {
+		logger.Error("Failed to create borrowing policy", "error", err)
+		return api.CreateBorrowingPolicy500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Borrowing policy created", "policy_id", policy.ID, "user_id", user.ID)
+	return api.CreateBorrowingPolicy201JSONResponse(toBorrowingPolicyResponse(policy)), nil
+}
+
+func (s Server) UpdateBorrowingPolicy(ctx context.Context, request api.UpdateBorrowingPolicyRequestObject) (api.UpdateBorrowingPolicyResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.UpdateBorrowingPolicy401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageBorrowingPolicies, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageBorrowingPolicies permission", "error", err)
+		return api.UpdateBorrowingPolicy500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.UpdateBorrowingPolicy403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	params, apiErr := s.borrowingPolicyParams(ctx, request.Body)
+	if apiErr != nil {
+		if apiErr.Code == CodeInternalError {
+			logger.Error("Failed to validate borrowing policy", "error", apiErr.Cause)
+			return api.UpdateBorrowingPolicy500JSONResponse(apiErr.Create()), nil
+		}
+		return api.UpdateBorrowingPolicy400JSONResponse(apiErr.Create()), nil
+	}
+
+	policy, err := s.db.Queries().UpdateBorrowingPolicy(ctx, db.UpdateBorrowingPolicyParams{
+		ID:                  request.Id,
+		Name:                params.Name,
+		RoleName:            params.RoleName,
+		ItemType:            params.ItemType,
+		MaxActiveBorrowings: params.MaxActiveBorrowings,
+		MaxQuantity:         params.MaxQuantity,
+		LoanDays:            params.LoanDays,
+		AllowPartialPickup:  params.AllowPartialPickup,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return api.UpdateBorrowingPolicy404JSONResponse(NotFound("Borrowing policy").Create()), nil
+		}
+		logger.Error("Failed to update borrowing policy", "policy_id", request.Id, "error", err)
+		return api.UpdateBorrowingPolicy500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Borrowing policy updated", "policy_id", policy.ID, "user_id", user.ID)
+	return api.UpdateBorrowingPolicy200JSONResponse(toBorrowingPolicyResponse(policy)), nil
+}
+
+func (s Server) DeleteBorrowingPolicy(ctx context.Context, request api.DeleteBorrowingPolicyRequestObject) (api.DeleteBorrowingPolicyResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.DeleteBorrowingPolicy401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageBorrowingPolicies, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageBorrowingPolicies permission", "error", err)
+		return api.DeleteBorrowingPolicy500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.DeleteBorrowingPolicy403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	deleted, err := s.db.Queries().DeleteBorrowingPolicy(ctx, request.Id)
+	if err != nil {
+		logger.Error("Failed to delete borrowing policy", "policy_id", request.Id, "error", err)
+		return api.DeleteBorrowingPolicy500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if deleted == 0 {
+		return api.DeleteBorrowingPolicy404JSONResponse(NotFound("Borrowing policy").Create()), nil
+	}
+
+	logger.Info("Borrowing policy deleted", "policy_id", request.Id, "user_id", user.ID)
+	return api.DeleteBorrowingPolicy204Response{}, nil
+}
+
+func (s Server) ListBorrowingBlackouts(ctx context.Context, request api.ListBorrowingBlackoutsRequestObject) (api.ListBorrowingBlackoutsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListBorrowingBlackouts401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageBorrowingPolicies, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageBorrowingPolicies permission", "error", err)
+		return api.ListBorrowingBlackouts500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListBorrowingBlackouts403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	blackouts, err := s.db.Queries().ListBorrowingBlackouts(ctx)
+	if err != nil {
+		logger.Error("Failed to list borrowing blackouts", "error", err)
+		return api.ListBorrowingBlackouts500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := make(api.ListBorrowingBlackouts200JSONResponse, 0, len(blackouts))
+	for _, blackout := range blackouts {
+		response = append(response, toBorrowingBlackoutResponse(blackout))
+	}
+	return response, nil
+}
+
+func (s Server) CreateBorrowingBlackout(ctx context.Context, request api.CreateBorrowingBlackoutRequestObject) (api.CreateBorrowingBlackoutResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateBorrowingBlackout401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageBorrowingPolicies, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageBorrowingPolicies permission", "error", err)
+		return api.CreateBorrowingBlackout500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CreateBorrowingBlackout403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.CreateBorrowingBlackout400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	body := request.Body
+	reason := strings.TrimSpace(body.Reason)
+	if reason == "" {
+		return api.CreateBorrowingBlackout400JSONResponse(ValidationErr("reason is required", nil).Create()), nil
+	}
+	if !body.EndsAt.After(body.StartsAt) {
+		return api.CreateBorrowingBlackout400JSONResponse(ValidationErr("ends_at must be after starts_at", nil).Create()), nil
+	}
+
+	params := db.CreateBorrowingBlackoutParams{
+		Reason:    reason,
+		StartsAt:  pgtype.Timestamp{Time: body.StartsAt, Valid: true},
+		EndsAt:    pgtype.Timestamp{Time: body.EndsAt, Valid: true},
+		CreatedBy: &user.ID,
+	}
+	if body.ItemType != nil {
+		params.ItemType = db.NullItemType{ItemType: db.ItemType(*body.ItemType), Valid: true}
+	}
+
+	blackout, err := s.db.Queries().CreateBorrowingBlackout(ctx, params)
+	if err != nil {
+		logger.Error("Failed to create borrowing blackout", "error", err)
+		return api.CreateBorrowingBlackout500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Borrowing blackout created", "blackout_id", blackout.ID, "user_id", user.ID)
+	return api.CreateBorrowingBlackout201JSONResponse(toBorrowingBlackoutResponse(blackout)), nil
+}
+
+func (s Server) DeleteBorrowingBlackout(ctx context.Context, request api.DeleteBorrowingBlackoutRequestObject) (api.DeleteBorrowingBlackoutResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.DeleteBorrowingBlackout401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageBorrowingPolicies, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageBorrowingPolicies permission", "error", err)
+		return api.DeleteBorrowingBlackout500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.DeleteBorrowingBlackout403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	deleted, err := s.db.Queries().DeleteBorrowingBlackout(ctx, request.Id)
+	if err != nil {
+		logger.Error("Failed to delete borrowing blackout", "blackout_id", request.Id, "error", err)
+		return api.DeleteBorrowingBlackout500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if deleted == 0 {
+		return api.DeleteBorrowingBlackout404JSONResponse(NotFound("Borrowing blackout").Create()), nil
+	}
+
+	logger.Info("Borrowing blackout deleted", "blackout_id", request.Id, "user_id", user.ID)
+	return api.DeleteBorrowingBlackout204Response{}, nil
+}
+
+// the row a create or update request describes, or the error to answer with
+func (s Server) borrowingPolicyParams(ctx context.Context, body *api.BorrowingPolicyRequest) (db.CreateBorrowingPolicyParams, *ErrorBuilder) {
+	if body == nil {
+		return db.CreateBorrowingPolicyParams{}, ValidationErr("Request body is required", nil)
+	}
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		return db.CreateBorrowingPolicyParams{}, ValidationErr("name is required", nil)
+	}
+	if body.MaxActiveBorrowings == nil && body.MaxQuantity == nil && body.LoanDays == nil && body.AllowPartialPickup == nil {
+		return db.CreateBorrowingPolicyParams{}, ValidationErr("A policy must set at least one limit", nil)
+	}
+
+	params := db.CreateBorrowingPolicyParams{Name: name}
+	if body.RoleName != nil {
+		if _, err := s.db.Queries().GetRole(ctx, *body.RoleName); err != nil {
+			if err == pgx.ErrNoRows {
+				return db.CreateBorrowingPolicyParams{}, ValidationErr("Unknown role: "+*body.RoleName, nil)
+			}
+			return db.CreateBorrowingPolicyParams{}, InternalError("An unexpected error occurred.").WithCause(err)
+		}
+		params.RoleName = pgtype.Text{String: *body.RoleName, Valid: true}
+	}
+	if body.ItemType != nil {
+		params.ItemType = db.NullItemType{ItemType: db.ItemType(*body.ItemType), Valid: true}
+	}
+	for _, limit := range []struct {
+		field string
+		value *int
+		dest  *pgtype.Int4
+	}{
+		{"max_active_borrowings", body.MaxActiveBorrowings, &params.MaxActiveBorrowings},
+		{"max_quantity", body.MaxQuantity, &params.MaxQuantity},
+		{"loan_days", body.LoanDays, &params.LoanDays},
+	} {
+		if limit.value == nil {
+			continue
+		}
+		if *limit.value < 1 {
+			return db.CreateBorrowingPolicyParams{}, ValidationErr(limit.field+" must be at least 1", nil)
+		}
+		*limit.dest = pgtype.Int4{Int32: int32(*limit.value), Valid: true}
+	}
+	if body.AllowPartialPickup != nil {
+		params.AllowPartialPickup = pgtype.Bool{Bool: *body.AllowPartialPickup, Valid: true}
+	}
+	return params, nil
+}
+
+func toBorrowingPolicyResponse(policy db.BorrowingPolicy) api.BorrowingPolicy {
+	response := api.BorrowingPolicy{
+		Id:        policy.ID,
+		Name:      policy.Name,
+		CreatedBy: policy.CreatedBy,
+		CreatedAt: policy.CreatedAt.Time,
+		UpdatedAt: policy.UpdatedAt.Time,
+	}
+	if policy.RoleName.Valid {
+		response.RoleName = &policy.RoleName.String
+	}
+	if policy.ItemType.Valid {
+		itemType := api.ItemType(policy.ItemType.ItemType)
+		response.ItemType = &itemType
+	}
+	if policy.MaxActiveBorrowings.Valid {
+		limit := int(policy.MaxActiveBorrowings.Int32)
+		response.MaxActiveBorrowings = &limit
+	}
+	if policy.MaxQuantity.Valid {
+		limit := int(policy.MaxQuantity.Int32)
+		response.MaxQuantity = &limit
+	}
+	if policy.LoanDays.Valid {
+		days := int(policy.LoanDays.Int32)
+		response.LoanDays = &days
+	}
+	if policy.AllowPartialPickup.Valid {
+		response.AllowPartialPickup = &policy.AllowPartialPickup.Bool
+	}
+	return response
+}
+
+func toBorrowingBlackoutResponse(blackout db.BorrowingBlackout) api.BorrowingBlackout {
+	response := api.BorrowingBlackout{
+		Id:        blackout.ID,
+		Reason:    blackout.Reason,
+		StartsAt:  blackout.StartsAt.Time,
+		EndsAt:    blackout.EndsAt.Time,
+		CreatedBy: blackout.CreatedBy,
+		CreatedAt: blackout.CreatedAt.Time,
+	}
+	if blackout.ItemType.Valid {
+		itemType := api.ItemType(blackout.ItemType.ItemType)
+		response.ItemType = &itemType
+	}
+	return response
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_BorrowingPolicies(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	type fixture struct {
+		admin, member *testutil.TestUser
+		group         *testutil.TestGroup
+	}
+
+	setup := func(t *testing.T) fixture {
+		testDB.CleanupDatabase(t)
+		f := fixture{group: testDB.NewGroup(t).WithName("Robotics").Create()}
+		f.admin = testDB.NewUser(t).WithEmail("admin@policies.test").AsGlobalAdmin().Create()
+		f.member = testDB.NewUser(t).WithEmail("member@policies.test").AsMemberOf(f.group).Create()
+		return f
+	}
+
+	createPolicy := func(t *testing.T, f fixture, body api.BorrowingPolicyRequest) api.CreateBorrowingPolicyResponseObject {
+		t.Helper()
+		ctx := testutil.ContextWithUser(context.Background(), f.admin, testDB.Queries())
+		mockAuth.ExpectCheckPermission(f.admin.ID, rbac.ManageBorrowingPolicies, nil, true, nil)
+		resp, err := server.CreateBorrowingPolicy(ctx, api.CreateBorrowingPolicyRequestObject{Body: &body})
+		require.NoError(t, err)
+		return resp
+	}
+
+	borrow := func(t *testing.T, f fixture, itemID uuid.UUID, quantity int, due time.Time) api.BorrowItemResponseObject {
+		t.Helper()
+		ctx := testutil.ContextWithUser(context.Background(), f.member, testDB.Queries())
+		mockAuth.ExpectCheckPermission(f.member.ID, rbac.RequestItems, &f.group.ID, true, nil)
+		resp, err := server.BorrowItem(ctx, api.BorrowItemRequestObject{
+			Body: &api.BorrowItemJSONRequestBody{
+				UserId:             f.member.ID,
+				GroupId:            f.group.ID,
+				ItemId:             itemID,
+				Quantity:           quantity,
+				DueDate:            due,
+				BeforeCondition:    "good",
+				BeforeConditionUrl: "http://example.com/before.jpg",
+			},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	violated := func(t *testing.T, resp api.BorrowItemResponseObject) string {
+		t.Helper()
+		require.IsType(t, api.BorrowItem400JSONResponse{}, resp)
+		body := resp.(api.BorrowItem400JSONResponse)
+		assert.Equal(t, api.POLICYVIOLATION, body.Error.Code)
+		require.NotNil(t, body.Error.Context)
+		return (*body.Error.Context)["rule"].(string)
+	}
+
+	ptr := func(n int) *int { return &n }
+	medium := api.ItemType("medium")
+	member := rbac.RoleMember
+
+	t.Run("limits apply to matching borrowings", func(t *testing.T) {
+		f := setup(t)
+		resp := createPolicy(t, f, api.BorrowingPolicyRequest{
+			Name:                "Members, medium items",
+			RoleName:            &member,
+			ItemType:            &medium,
+			MaxActiveBorrowings: ptr(1),
+			MaxQuantity:         ptr(1),
+			LoanDays:            ptr(3),
+		})
+		require.IsType(t, api.CreateBorrowingPolicy201JSONResponse{}, resp)
+
+		drill := testDB.NewItem(t).WithName("Drill").WithType("medium").WithStock(5).Create()
+		saw := testDB.NewItem(t).WithName("Saw").WithType("medium").WithStock(5).Create()
+		soon := time.Now().AddDate(0, 0, 2)
+
+		assert.Equal(t, "max_quantity", violated(t, borrow(t, f, drill.ID, 2, soon)))
+		assert.Equal(t, "loan_duration", violated(t, borrow(t, f, drill.ID, 1, time.Now().AddDate(0, 0, 10))))
+
+		require.IsType(t, api.BorrowItem201JSONResponse{}, borrow(t, f, drill.ID, 1, soon))
+		assert.Equal(t, "max_active_borrowings", violated(t, borrow(t, f, saw.ID, 1, soon)))
+	})
+
+	t.Run("policies for other roles do not apply", func(t *testing.T) {
+		f := setup(t)
+		admin := rbac.RoleGlobalAdmin
+		require.IsType(t, api.CreateBorrowingPolicy201JSONResponse{}, createPolicy(t, f, api.BorrowingPolicyRequest{
+			Name:        "Admins",
+			RoleName:    &admin,
+			MaxQuantity: ptr(1),
+		}))
+
+		item := testDB.NewItem(t).WithName("Tripod").WithType("medium").WithStock(5).Create()
+		assert.IsType(t, api.BorrowItem201JSONResponse{}, borrow(t, f, item.ID, 2, time.Now().AddDate(0, 0, 2)))
+	})
+
+	t.Run("no borrowing during a blackout", func(t *testing.T) {
+		f := setup(t)
+		ctx := testutil.ContextWithUser(context.Background(), f.admin, testDB.Queries())
+		mockAuth.ExpectCheckPermission(f.admin.ID, rbac.ManageBorrowingPolicies, nil, true, nil)
+		resp, err := server.CreateBorrowingBlackout(ctx, api.CreateBorrowingBlackoutRequestObject{
+			Body: &api.CreateBorrowingBlackoutRequest{
+				Reason:   "Stocktake",
+				StartsAt: time.Now().Add(-time.Hour),
+				EndsAt:   time.Now().Add(time.Hour),
+			},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateBorrowingBlackout201JSONResponse{}, resp)
+
+		item := testDB.NewItem(t).WithName("Projector").WithType("medium").WithStock(1).Create()
+		assert.Equal(t, "blackout", violated(t, borrow(t, f, item.ID, 1, time.Now().AddDate(0, 0, 2))))
+
+		mockAuth.ExpectCheckPermission(f.admin.ID, rbac.ManageBorrowingPolicies, nil, true, nil)
+		deleted, err := server.DeleteBorrowingBlackout(ctx, api.DeleteBorrowingBlackoutRequestObject{Id: resp.(api.CreateBorrowingBlackout201JSONResponse).Id})
+		require.NoError(t, err)
+		require.IsType(t, api.DeleteBorrowingBlackout204Response{}, deleted)
+
+		assert.IsType(t, api.BorrowItem201JSONResponse{}, borrow(t, f, item.ID, 1, time.Now().AddDate(0, 0, 2)))
+	})
+
+	t.Run("invalid policies are rejected", func(t *testing.T) {
+		f := setup(t)
+		unknown := "treasurer"
+		assert.IsType(t, api.CreateBorrowingPolicy400JSONResponse{}, createPolicy(t, f, api.BorrowingPolicyRequest{Name: "Unknown role", RoleName: &unknown, MaxQuantity: ptr(1)}))
+		assert.IsType(t, api.CreateBorrowingPolicy400JSONResponse{}, createPolicy(t, f, api.BorrowingPolicyRequest{Name: "No limits"}))
+		assert.IsType(t, api.CreateBorrowingPolicy400JSONResponse{}, createPolicy(t, f, api.BorrowingPolicyRequest{Name: "Zero", LoanDays: ptr(0)}))
+	})
+
+	t.Run("update, list and delete", func(t *testing.T) {
+		f := setup(t)
+		ctx := testutil.ContextWithUser(context.Background(), f.admin, testDB.Queries())
+		created := createPolicy(t, f, api.BorrowingPolicyRequest{Name: "Everyone", LoanDays: ptr(14)})
+		require.IsType(t, api.CreateBorrowingPolicy201JSONResponse{}, created)
+		id := created.(api.CreateBorrowingPolicy201JSONResponse).Id
+
+		mockAuth.ExpectCheckPermission(f.admin.ID, rbac.ManageBorrowingPolicies, nil, true, nil)
+		updated, err := server.UpdateBorrowingPolicy(ctx, api.UpdateBorrowingPolicyRequestObject{
+			Id:   id,
+			Body: &api.BorrowingPolicyRequest{Name: "Everyone", LoanDays: ptr(10)},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.UpdateBorrowingPolicy200JSONResponse{}, updated)
+		assert.Equal(t, ptr(10), updated.(api.UpdateBorrowingPolicy200JSONResponse).LoanDays)
+
+		mockAuth.ExpectCheckPermission(f.admin.ID, rbac.ManageBorrowingPolicies, nil, true, nil)
+		listed, err := server.ListBorrowingPolicies(ctx, api.ListBorrowingPoliciesRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.ListBorrowingPolicies200JSONResponse{}, listed)
+		require.Len(t, listed.(api.ListBorrowingPolicies200JSONResponse), 1)
+
+		mockAuth.ExpectCheckPermission(f.admin.ID, rbac.ManageBorrowingPolicies, nil, true, nil)
+		deleted, err := server.DeleteBorrowingPolicy(ctx, api.DeleteBorrowingPolicyRequestObject{Id: id})
+		require.NoError(t, err)
+		assert.IsType(t, api.DeleteBorrowingPolicy204Response{}, deleted)
+
+		mockAuth.ExpectCheckPermission(f.admin.ID, rbac.ManageBorrowingPolicies, nil, true, nil)
+		deleted, err = server.DeleteBorrowingPolicy(ctx, api.DeleteBorrowingPolicyRequestObject{Id: id})
+		require.NoError(t, err)
+		assert.IsType(t, api.DeleteBorrowingPolicy404JSONResponse{}, deleted)
+	})
+}
@@ -2,13 +2,20 @@ package api
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/USSTM/cv-backend/generated/api"
 	"github.com/USSTM/cv-backend/generated/db"
 	"github.com/USSTM/cv-backend/internal/auth"
-	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/bookingevents"
+	"github.com/USSTM/cv-backend/internal/borrowingpolicy"
+	"github.com/USSTM/cv-backend/internal/damage"
+	"github.com/USSTM/cv-backend/internal/events"
+	"github.com/USSTM/cv-backend/internal/fairness"
+	"github.com/USSTM/cv-backend/internal/middleware"
 	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/queue"
 	"github.com/USSTM/cv-backend/internal/rbac"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -43,6 +50,14 @@ func (s Server) BorrowItem(ctx context.Context, request api.BorrowItemRequestObj
 		return api.BorrowItem403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
 	}
 
+	blocked, unpaid, err := s.blockedByFines(ctx, user.ID)
+	if err != nil {
+		return api.BorrowItem500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if blocked {
+		return api.BorrowItem403JSONResponse(finesBlockedErr(unpaid).Create()), nil
+	}
+
 	// transaction
 	tx, err := s.db.Pool().Begin(ctx)
 	if err != nil {
@@ -57,6 +72,13 @@ func (s Server) BorrowItem(ctx context.Context, request api.BorrowItemRequestObj
 	if err != nil {
 		return api.BorrowItem500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
+	available, err := itemAvailableToGroup(ctx, qtx, item, request.Body.GroupId)
+	if err != nil {
+		return api.BorrowItem500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !available {
+		return api.BorrowItem403JSONResponse(itemNotForGroupErr().Create()), nil
+	}
 
 	// Reject LOW
 	if item.Type == db.ItemTypeLow {
@@ -70,6 +92,7 @@ func (s Server) BorrowItem(ctx context.Context, request api.BorrowItemRequestObj
 
 	// High items checks
 	var approvedRequestID *uuid.UUID
+	var approvedQuantity int
 	if item.Type == db.ItemTypeHigh {
 		// is currently borrowed?
 		borrowable, err := qtx.CheckBorrowingItemStatus(ctx, &request.Body.ItemId)
@@ -91,12 +114,41 @@ func (s Server) BorrowItem(ctx context.Context, request api.BorrowItemRequestObj
 			return api.BorrowItem500JSONResponse(InternalError("Internal server error").Create()), nil
 		}
 
-		// Verify request quantity matches borrow quantity
-		if approvedRequest.Quantity != int32(request.Body.Quantity) {
-			return api.BorrowItem400JSONResponse(ValidationErr("Borrow quantity must match approved request quantity", nil).Create()), nil
+		// desk staff must have matched the requester's student ID for the booking
+		if approvedRequest.BookingID != nil {
+			verified, err := qtx.HasVerifiedBooking(ctx, *approvedRequest.BookingID)
+			if err != nil {
+				return api.BorrowItem500JSONResponse(InternalError("Internal server error").Create()), nil
+			}
+			if !verified {
+				return api.BorrowItem403JSONResponse(PermissionDenied("Identity must be verified at the desk before picking up high-value items").Create()), nil
+			}
 		}
 
 		approvedRequestID = &approvedRequest.ID
+		approvedQuantity = int(approvedRequest.Quantity)
+	}
+
+	// limits from the borrowing policies, and the approved quantity for high items
+	limits, err := borrowingpolicy.Load(ctx, qtx, &user.ID, item.Type)
+	if err != nil {
+		return api.BorrowItem500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if violation := limits.Check(borrowingpolicy.Loan{
+		Quantity: request.Body.Quantity,
+		Start:    time.Now(),
+		Due:      request.Body.DueDate,
+		Approved: approvedQuantity,
+	}); violation != nil {
+		return api.BorrowItem400JSONResponse(PolicyViolationErr(violation).Create()), nil
+	}
+
+	unitID, err := pickItemUnit(ctx, qtx, item.ID, request.Body.UnitId, int32(request.Body.Quantity))
+	if err != nil {
+		if apiErr := errorFor(err); apiErr.Code == CodeValidationError {
+			return api.BorrowItem400JSONResponse(apiErr.Create()), nil
+		}
+		return api.BorrowItem500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 
 	params := db.BorrowItemParams{
@@ -107,6 +159,7 @@ func (s Server) BorrowItem(ctx context.Context, request api.BorrowItemRequestObj
 		DueDate:            pgtype.Timestamp{Time: request.Body.DueDate, Valid: true},
 		BeforeCondition:    db.Condition(request.Body.BeforeCondition),
 		BeforeConditionUrl: request.Body.BeforeConditionUrl,
+		UnitID:             unitID,
 	}
 
 	// Create borrowing
@@ -116,6 +169,9 @@ func (s Server) BorrowItem(ctx context.Context, request api.BorrowItemRequestObj
 	}
 
 	// Decrement stock
+	if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindBorrow, ActorID: &user.ID, ReferenceID: &resp.ID}); err != nil {
+		return api.BorrowItem500JSONResponse(InternalError("Failed to update stock").Create()), nil
+	}
 	err = qtx.DecrementItemStock(ctx, db.DecrementItemStockParams{
 		ID:    item.ID,
 		Stock: int32(request.Body.Quantity),
@@ -123,6 +179,9 @@ func (s Server) BorrowItem(ctx context.Context, request api.BorrowItemRequestObj
 	if err != nil {
 		return api.BorrowItem500JSONResponse(InternalError("Failed to update stock").Create()), nil
 	}
+	if err := holdItemUnit(ctx, qtx, unitID, db.UnitStatusBorrowed); err != nil {
+		return api.BorrowItem500JSONResponse(InternalError("Failed to update stock").Create()), nil
+	}
 
 	// If high, mark request as fulfilled
 	if item.Type == db.ItemTypeHigh && approvedRequestID != nil {
@@ -137,6 +196,8 @@ func (s Server) BorrowItem(ctx context.Context, request api.BorrowItemRequestObj
 		return api.BorrowItem500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 
+	s.publishStockChanged(ctx, *resp.ItemID)
+
 	return api.BorrowItem201JSONResponse{
 		Id:                 resp.ID,
 		ItemId:             *resp.ItemID,
@@ -150,10 +211,13 @@ func (s Server) BorrowItem(ctx context.Context, request api.BorrowItemRequestObj
 		BeforeConditionUrl: resp.BeforeConditionUrl,
 		AfterCondition:     nil,
 		AfterConditionUrl:  nil,
+		UnitId:             resp.UnitID,
 	}, nil
 }
 
 func (s Server) ReturnItem(ctx context.Context, request api.ReturnItemRequestObject) (api.ReturnItemResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
 	user, ok := auth.GetAuthenticatedUser(ctx)
 	if !ok {
 		return api.ReturnItem401JSONResponse(Unauthorized("Authentication required").Create()), nil
@@ -167,6 +231,13 @@ func (s Server) ReturnItem(ctx context.Context, request api.ReturnItemRequestObj
 		return api.ReturnItem403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
 	}
 
+	if !damage.ValidCondition(db.Condition(request.Body.AfterCondition)) {
+		return api.ReturnItem400JSONResponse(ValidationErr("after_condition must be one of pristine, good, decent, damaged or unusable", nil).Create()), nil
+	}
+	if request.Body.DamageSeverity != nil && !validDamageSeverity(*request.Body.DamageSeverity) {
+		return api.ReturnItem400JSONResponse(ValidationErr("damage_severity must be minor, moderate or severe", nil).Create()), nil
+	}
+
 	// transaction
 	tx, err := s.db.Pool().Begin(ctx)
 	if err != nil {
@@ -177,7 +248,7 @@ func (s Server) ReturnItem(ctx context.Context, request api.ReturnItemRequestObj
 	qtx := s.db.Queries().WithTx(tx)
 
 	// Get active borrowing and verify ownership (locks the row)
-	_, err = qtx.GetActiveBorrowingByItemAndUser(ctx, db.GetActiveBorrowingByItemAndUserParams{
+	active, err := qtx.GetActiveBorrowingByItemAndUser(ctx, db.GetActiveBorrowingByItemAndUserParams{
 		ItemID: &request.ItemId,
 		UserID: &user.ID,
 	})
@@ -188,19 +259,23 @@ func (s Server) ReturnItem(ctx context.Context, request api.ReturnItemRequestObj
 		return api.ReturnItem500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 
-	// Update with return information
-	params := db.ReturnItemParams{
-		ItemID:            &request.ItemId,
+	// Update with return information; by ID, as others may have the same
+	// item out, each with their own unit
+	params := db.ReturnBorrowingByIDParams{
+		ID:                active.ID,
 		AfterCondition:    db.NullCondition{Condition: db.Condition(request.Body.AfterCondition), Valid: request.Body.AfterCondition != ""},
 		AfterConditionUrl: pgtype.Text{String: *request.Body.AfterConditionUrl, Valid: request.Body.AfterConditionUrl != nil},
 	}
 
-	resp, err := qtx.ReturnItem(ctx, params)
+	resp, err := qtx.ReturnBorrowingByID(ctx, params)
 	if err != nil {
 		return api.ReturnItem500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 
 	// Increment stock
+	if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindReturn, ActorID: &user.ID, ReferenceID: &resp.ID}); err != nil {
+		return api.ReturnItem500JSONResponse(InternalError("Failed to update stock").Create()), nil
+	}
 	err = qtx.IncrementItemStock(ctx, db.IncrementItemStockParams{
 		ID:    *resp.ItemID,
 		Stock: resp.Quantity,
@@ -208,12 +283,38 @@ func (s Server) ReturnItem(ctx context.Context, request api.ReturnItemRequestObj
 	if err != nil {
 		return api.ReturnItem500JSONResponse(InternalError("Failed to update stock").Create()), nil
 	}
+	if err := releaseItemUnit(ctx, qtx, resp.UnitID, resp.AfterCondition); err != nil {
+		return api.ReturnItem500JSONResponse(InternalError("Failed to update stock").Create()), nil
+	}
+
+	var report *db.DamageReport
+	if damage.Worse(resp.BeforeCondition, db.Condition(request.Body.AfterCondition)) {
+		created, err := openDamageReport(ctx, qtx, user.ID, resp, request.Body)
+		if err != nil {
+			logger.Error("Failed to open damage report", "borrowing_id", resp.ID, "error", err)
+			return api.ReturnItem500JSONResponse(InternalError("Failed to record damage").Create()), nil
+		}
+		report = &created
+	}
+
+	if _, err := assessFines(ctx, qtx, resp, report); err != nil {
+		logger.Error("Failed to assess fines", "borrowing_id", resp.ID, "error", err)
+		return api.ReturnItem500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
 
 	// end transaction
 	if err := tx.Commit(ctx); err != nil {
 		return api.ReturnItem500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 
+	s.publishEvent(ctx, events.Event{Type: events.ItemReturned, EntityID: resp.ID, GroupID: resp.GroupID, ItemID: resp.ItemID, UserID: resp.UserID})
+	s.publishStockChanged(ctx, *resp.ItemID)
+
+	// tell whoever is next on the item's waitlist that it is back
+	if _, err := s.queue.Enqueue(ctx, queue.TypeWaitlistNotify, queue.WaitlistNotifyPayload{ItemID: *resp.ItemID}); err != nil {
+		logger.Warn("Failed to enqueue waitlist notification", "item_id", *resp.ItemID, "error", err)
+	}
+
 	var afterCondition *string
 	if resp.AfterCondition.Valid {
 		conditionStr := string(resp.AfterCondition.Condition)
@@ -225,6 +326,12 @@ func (s Server) ReturnItem(ctx context.Context, request api.ReturnItemRequestObj
 		afterConditionUrl = &resp.AfterConditionUrl.String
 	}
 
+	var damageReportID *uuid.UUID
+	if report != nil {
+		s.notifyDamageReported(ctx, user, *report)
+		damageReportID = &report.ID
+	}
+
 	return api.ReturnItem200JSONResponse{
 		Id:                 resp.ID,
 		ItemId:             *resp.ItemID,
@@ -238,6 +345,8 @@ func (s Server) ReturnItem(ctx context.Context, request api.ReturnItemRequestObj
 		BeforeConditionUrl: resp.BeforeConditionUrl,
 		AfterCondition:     afterCondition,
 		AfterConditionUrl:  afterConditionUrl,
+		DamageReportId:     damageReportID,
+		UnitId:             resp.UnitID,
 	}, nil
 }
 
@@ -286,16 +395,42 @@ func (s Server) GetBorrowedItemHistoryByUserId(ctx context.Context, request api.
 
 	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
 
-	items, err := s.db.Queries().GetBorrowedItemHistoryByUserId(ctx, db.GetBorrowedItemHistoryByUserIdParams{
-		UserID: &request.UserId,
-		Limit:  limit,
-		Offset: offset,
-	})
+	listHistory := func(ctx context.Context, limit, offset int64) ([]db.Borrowing, error) {
+		return s.db.Queries().GetBorrowedItemHistoryByUserId(ctx, db.GetBorrowedItemHistoryByUserIdParams{
+			UserID: &request.UserId,
+			Limit:  limit,
+			Offset: offset,
+		})
+	}
+
+	total, err := s.db.Queries().CountBorrowedItemHistoryByUserId(ctx, &request.UserId)
 	if err != nil {
 		return api.GetBorrowedItemHistoryByUserId500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 
-	total, err := s.db.Queries().CountBorrowedItemHistoryByUserId(ctx, &request.UserId)
+	if wantsCSV(request.Params.Format) {
+		body := streamCSV(ctx, borrowingColumns, func(ctx context.Context, limit, offset int64) ([][]string, error) {
+			items, err := listHistory(ctx, limit, offset)
+			if err != nil {
+				return nil, err
+			}
+			borrowings, err := createBorrowedItemResponse(items, false)
+			if err != nil {
+				return nil, err
+			}
+			records := make([][]string, 0, len(borrowings))
+			for _, b := range borrowings {
+				records = append(records, borrowingRecord(b))
+			}
+			return records, nil
+		})
+		return api.GetBorrowedItemHistoryByUserId200TextcsvResponse{
+			Body:    body,
+			Headers: api.GetBorrowedItemHistoryByUserId200ResponseHeaders{XTotalCount: int(total)},
+		}, nil
+	}
+
+	items, err := listHistory(ctx, limit, offset)
 	if err != nil {
 		return api.GetBorrowedItemHistoryByUserId500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
@@ -306,8 +441,11 @@ func (s Server) GetBorrowedItemHistoryByUserId(ctx context.Context, request api.
 	}
 
 	return api.GetBorrowedItemHistoryByUserId200JSONResponse{
-		Data: borrowedItemsByUserResponse,
-		Meta: buildPaginationMeta(total, limit, offset),
+		Body: api.PaginatedBorrowingResponse{
+			Data: borrowedItemsByUserResponse,
+			Meta: buildPaginationMeta(total, limit, offset),
+		},
+		Headers: api.GetBorrowedItemHistoryByUserId200ResponseHeaders{XTotalCount: int(total)},
 	}, nil
 }
 
@@ -352,8 +490,11 @@ func (s Server) GetActiveBorrowedItemsByUserId(ctx context.Context, request api.
 	}
 
 	return api.GetActiveBorrowedItemsByUserId200JSONResponse{
-		Data: activeBorrowedItemsByUserResponse,
-		Meta: buildPaginationMeta(total, limit, offset),
+		Body: api.PaginatedBorrowingResponse{
+			Data: activeBorrowedItemsByUserResponse,
+			Meta: buildPaginationMeta(total, limit, offset),
+		},
+		Headers: api.GetActiveBorrowedItemsByUserId200ResponseHeaders{XTotalCount: int(total)},
 	}, nil
 }
 
@@ -398,8 +539,11 @@ func (s Server) GetReturnedItemsByUserId(ctx context.Context, request api.GetRet
 	}
 
 	return api.GetReturnedItemsByUserId200JSONResponse{
-		Data: returnedItemsByUserResponse,
-		Meta: buildPaginationMeta(total, limit, offset),
+		Body: api.PaginatedBorrowingResponse{
+			Data: returnedItemsByUserResponse,
+			Meta: buildPaginationMeta(total, limit, offset),
+		},
+		Headers: api.GetReturnedItemsByUserId200ResponseHeaders{XTotalCount: int(total)},
 	}, nil
 }
 
@@ -419,12 +563,31 @@ func (s Server) GetAllActiveBorrowedItems(ctx context.Context, request api.GetAl
 
 	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
 
-	items, err := s.db.Queries().GetAllActiveBorrowedItems(ctx, db.GetAllActiveBorrowedItemsParams{Limit: limit, Offset: offset})
+	sort, err := parseSort(request.Params.SortBy, request.Params.Order, listSort{By: "borrowed_at", Desc: true}, "borrowed_at", "due_date")
+	if err != nil {
+		return api.GetAllActiveBorrowedItems400JSONResponse(ValidationErr(err.Error(), nil).Create()), nil
+	}
+
+	fromDate, toDate := parseDateRange(request.Params.FromDate, request.Params.ToDate)
+
+	items, err := s.db.Queries().GetAllActiveBorrowedItems(ctx, db.GetAllActiveBorrowedItemsParams{
+		GroupID:  request.Params.GroupId,
+		FromDate: fromDate,
+		ToDate:   toDate,
+		SortBy:   sort.By,
+		SortDesc: sort.Desc,
+		Limit:    limit,
+		Offset:   offset,
+	})
 	if err != nil {
 		return api.GetAllActiveBorrowedItems500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 
-	total, err := s.db.Queries().CountAllActiveBorrowedItems(ctx)
+	total, err := s.db.Queries().CountAllActiveBorrowedItems(ctx, db.CountAllActiveBorrowedItemsParams{
+		GroupID:  request.Params.GroupId,
+		FromDate: fromDate,
+		ToDate:   toDate,
+	})
 	if err != nil {
 		return api.GetAllActiveBorrowedItems500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
@@ -435,8 +598,73 @@ func (s Server) GetAllActiveBorrowedItems(ctx context.Context, request api.GetAl
 	}
 
 	return api.GetAllActiveBorrowedItems200JSONResponse{
-		Data: activeBorrowedItemsResponse,
-		Meta: buildPaginationMeta(total, limit, offset),
+		Body: api.PaginatedBorrowingResponse{
+			Data: activeBorrowedItemsResponse,
+			Meta: buildPaginationMeta(total, limit, offset),
+		},
+		Headers: api.GetAllActiveBorrowedItems200ResponseHeaders{XTotalCount: int(total)},
+	}, nil
+}
+
+func (s Server) GetOverdueBorrowings(ctx context.Context, request api.GetOverdueBorrowingsRequestObject) (api.GetOverdueBorrowingsResponseObject, error) {
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetOverdueBorrowings401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewAllData, nil)
+	if err != nil {
+		return api.GetOverdueBorrowings500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetOverdueBorrowings403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	rows, err := s.db.Queries().ListOverdueBorrowings(ctx, db.ListOverdueBorrowingsParams{Limit: limit, Offset: offset})
+	if err != nil {
+		return api.GetOverdueBorrowings500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountOverdueBorrowings(ctx)
+	if err != nil {
+		return api.GetOverdueBorrowings500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+
+	now := time.Now()
+	data := make([]api.OverdueBorrowing, 0, len(rows))
+	for _, row := range rows {
+		overdue := api.OverdueBorrowing{
+			Id:            row.ID,
+			ItemName:      row.ItemName,
+			UserId:        row.UserID,
+			GroupId:       row.GroupID,
+			Quantity:      int(row.Quantity),
+			BorrowedAt:    row.BorrowedAt.Time,
+			DueDate:       row.DueDate.Time,
+			OverdueAt:     row.OverdueAt.Time,
+			DaysOverdue:   int(now.Sub(row.DueDate.Time).Hours() / 24),
+			RemindersSent: int(row.RemindersSent),
+		}
+		if row.ItemID != nil {
+			overdue.ItemId = *row.ItemID
+		}
+		if row.UserEmail.Valid {
+			overdue.UserEmail = &row.UserEmail.String
+		}
+		if row.GroupName.Valid {
+			overdue.GroupName = &row.GroupName.String
+		}
+		data = append(data, overdue)
+	}
+
+	return api.GetOverdueBorrowings200JSONResponse{
+		Body: api.PaginatedOverdueBorrowingResponse{
+			Data: data,
+			Meta: buildPaginationMeta(total, limit, offset),
+		},
+		Headers: api.GetOverdueBorrowings200ResponseHeaders{XTotalCount: int(total)},
 	}, nil
 }
 
@@ -472,8 +700,11 @@ func (s Server) GetAllReturnedItems(ctx context.Context, request api.GetAllRetur
 	}
 
 	return api.GetAllReturnedItems200JSONResponse{
-		Data: returnedItemsResponse,
-		Meta: buildPaginationMeta(total, limit, offset),
+		Body: api.PaginatedBorrowingResponse{
+			Data: returnedItemsResponse,
+			Meta: buildPaginationMeta(total, limit, offset),
+		},
+		Headers: api.GetAllReturnedItems200ResponseHeaders{XTotalCount: int(total)},
 	}, nil
 }
 
@@ -541,6 +772,7 @@ func createBorrowedItemResponse(items []db.Borrowing, active bool) ([]api.Borrow
 			BeforeConditionUrl: item.BeforeConditionUrl,
 			AfterCondition:     afterCondition,
 			AfterConditionUrl:  afterConditionUrl,
+			UnitId:             item.UnitID,
 		}
 
 		responseItems = append(responseItems, responseItem)
@@ -569,6 +801,14 @@ func (s Server) RequestItem(ctx context.Context, request api.RequestItemRequestO
 		return api.RequestItem403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
 	}
 
+	blocked, unpaid, err := s.blockedByFines(ctx, user.ID)
+	if err != nil {
+		return api.RequestItem500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if blocked {
+		return api.RequestItem403JSONResponse(finesBlockedErr(unpaid).Create()), nil
+	}
+
 	// Validate item is high
 	item, err := s.db.Queries().GetItemByID(ctx, request.Body.ItemId)
 	if err == pgx.ErrNoRows {
@@ -577,6 +817,13 @@ func (s Server) RequestItem(ctx context.Context, request api.RequestItemRequestO
 	if err != nil {
 		return api.RequestItem500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
+	available, err := itemAvailableToGroup(ctx, s.db.Queries(), item, request.Body.GroupId)
+	if err != nil {
+		return api.RequestItem500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !available {
+		return api.RequestItem403JSONResponse(itemNotForGroupErr().Create()), nil
+	}
 
 	if item.Type != db.ItemTypeHigh {
 		return api.RequestItem400JSONResponse(ValidationErr("Only high-value items require approval requests. Low/medium items can be borrowed directly.", nil).Create()), nil
@@ -594,6 +841,9 @@ func (s Server) RequestItem(ctx context.Context, request api.RequestItemRequestO
 		return api.RequestItem500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 
+	s.notifyRequestSubmitted(ctx, user, resp.ID, item.ID, item.Name, item.Type, request.Body.GroupId, request.Body.Quantity)
+	s.publishEvent(ctx, events.Event{Type: events.RequestPending, EntityID: resp.ID, GroupID: resp.GroupID, ItemID: resp.ItemID, UserID: resp.UserID})
+
 	var reviewedAt *time.Time
 	if resp.ReviewedAt.Valid {
 		reviewedAt = &resp.ReviewedAt.Time
@@ -611,19 +861,74 @@ func (s Server) RequestItem(ctx context.Context, request api.RequestItemRequestO
 	}, nil
 }
 
+// routes the new request to its approvers. Those approving for the group that
+// owns the item are told about it; requests for the global approvers wait in
+// the pending queue. Routing rules can add recipients either way, e.g. a team
+// mailbox for one kind of equipment.
+func (s Server) notifyRequestSubmitted(ctx context.Context, requester *auth.AuthenticatedUser, requestID, itemID uuid.UUID, itemName string, itemType db.ItemType, groupID uuid.UUID, quantity int) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	var approverIDs []uuid.UUID
+	for _, route := range s.routeRequest(ctx, requestID, itemID) {
+		if route.Reason == db.RequestRouteReasonOwnerGroup {
+			approverIDs = append(approverIDs, route.ApproverID)
+		}
+	}
+
+	ctx = s.sandboxContext(ctx, &groupID)
+	if err := s.dispatcher.Notify(ctx, requester.ID, "request", requestID, []notifications.NotifierGroup{
+		{
+			IDs:      approverIDs,
+			Template: "request_submitted_approver",
+			TemplateData: map[string]interface{}{
+				"RequesterName": requester.Email,
+				"ItemName":      itemName,
+				"Quantity":      quantity,
+				"RequestID":     requestID,
+			},
+			Facts: notifications.RoutingFacts{
+				ItemID:   &itemID,
+				ItemType: itemType,
+				GroupID:  &groupID,
+			},
+		},
+	}); err != nil {
+		logger.Error("Failed to send request notifications", "request_id", requestID, "error", err)
+	}
+	s.announce(ctx, notifications.ChatRequestSubmitted, &groupID, map[string]interface{}{
+		"RequesterName": requester.Email,
+		"ItemName":      itemName,
+		"Quantity":      quantity,
+		"RequestID":     requestID,
+	})
+}
+
+// posts e to the team's chat channel with the group's name added to data as
+// GroupName.
+func (s Server) announce(ctx context.Context, e notifications.ChatEvent, groupID *uuid.UUID, data map[string]interface{}) {
+	data["GroupName"] = "no group"
+	if groupID != nil {
+		if group, err := s.db.Queries().GetGroupByID(ctx, *groupID); err == nil {
+			data["GroupName"] = group.Name
+		}
+	}
+	s.dispatcher.Announce(ctx, e, groupID, data)
+}
+
 func (s Server) ReviewRequest(ctx context.Context, request api.ReviewRequestRequestObject) (api.ReviewRequestResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
 	user, ok := auth.GetAuthenticatedUser(ctx)
 	if !ok {
 		return api.ReviewRequest401JSONResponse(Unauthorized("Authentication required").Create()), nil
 	}
 
-	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ApproveAllRequests, nil)
+	// global approvers review any request; anyone else is checked against the
+	// request's routing and the item's owner once both are loaded
+	globalApprover, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ApproveAllRequests, nil)
 	if err != nil {
 		return api.ReviewRequest500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
-	if !hasPermission {
-		return api.ReviewRequest403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
-	}
 
 	// transaction
 	tx, err := s.db.Pool().Begin(ctx)
@@ -648,14 +953,64 @@ func (s Server) ReviewRequest(ctx context.Context, request api.ReviewRequestRequ
 		return api.ReviewRequest500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 
+	if !globalApprover {
+		allowed, err := s.mayReviewRequest(ctx, qtx, user.ID, req.ID, item)
+		if err != nil {
+			logger.Error("Failed to check request routing", "request_id", req.ID, "error", err)
+			return api.ReviewRequest500JSONResponse(InternalError("Internal server error").Create()), nil
+		}
+		if !allowed {
+			return api.ReviewRequest403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+		}
+	}
+
 	// verify stock availability (if approved)
-	if request.Body.Status == api.Approved && item.Stock < req.Quantity {
+	if request.Body.Status == api.RequestStatusApproved && item.Stock < req.Quantity {
 		return api.ReviewRequest400JSONResponse(ValidationErr("Insufficient stock to approve this request", nil).Create()), nil
 	}
 
+	// under the fairness policy, skipping a group with higher priority needs a reason
+	var overrideJustification pgtype.Text
+	if request.Body.OverrideJustification != nil && strings.TrimSpace(*request.Body.OverrideJustification) != "" {
+		overrideJustification = pgtype.Text{String: strings.TrimSpace(*request.Body.OverrideJustification), Valid: true}
+	}
+	if request.Body.Status == api.RequestStatusApproved {
+		ranked, windowDays, err := rankPendingRequests(ctx, qtx, item.ID)
+		if err != nil {
+			logger.Error("Failed to rank pending requests", "item_id", item.ID, "error", err)
+			return api.ReviewRequest500JSONResponse(InternalError("Internal server error").Create()), nil
+		}
+		if len(ranked) > 0 && !overrideJustification.Valid {
+			now := time.Now()
+			top := fairness.Priority(ranked[0].LastHadAt, now, windowDays)
+			for _, c := range ranked {
+				if c.RequestID == req.ID && fairness.Priority(c.LastHadAt, now, windowDays) < top {
+					return api.ReviewRequest409JSONResponse(ConflictErr("Another group has fairness priority for this item; provide override_justification to approve this request").Create()), nil
+				}
+			}
+		}
+	}
+
+	// the requester's borrowing policies; a HIGH item's booking dates are
+	// checked against them once known
+	var limits borrowingpolicy.Limits
+	if request.Body.Status == api.RequestStatusApproved {
+		limits, err = borrowingpolicy.Load(ctx, qtx, req.UserID, item.Type)
+		if err != nil {
+			logger.Error("Failed to load borrowing policies", "request_id", req.ID, "error", err)
+			return api.ReviewRequest500JSONResponse(InternalError("Internal server error").Create()), nil
+		}
+		if item.Type != db.ItemTypeHigh {
+			if violation := limits.Check(borrowingpolicy.Loan{Quantity: int(req.Quantity)}); violation != nil {
+				return api.ReviewRequest400JSONResponse(PolicyViolationErr(violation).Create()), nil
+			}
+		}
+	}
+
 	// If approving HIGH item, create booking
 	var bookingID *uuid.UUID
-	if request.Body.Status == api.Approved && item.Type == db.ItemTypeHigh {
+	var confirmBy string
+	if request.Body.Status == api.RequestStatusApproved && item.Type == db.ItemTypeHigh {
 		// Validate booking fields are provided
 		if request.Body.AvailabilityId == nil || request.Body.PickupLocation == nil || request.Body.ReturnLocation == nil {
 			return api.ReviewRequest400JSONResponse(ValidationErr("Booking fields (availability_id, pickup_location, return_location) required when approving HIGH items", nil).Create()), nil
@@ -667,15 +1022,17 @@ func (s Server) ReviewRequest(ctx context.Context, request api.ReviewRequestRequ
 			return api.ReviewRequest400JSONResponse(ValidationErr("Invalid availability_id", nil).Create()), nil
 		}
 
-		// Calculate pickup date: availability date + time slot start time
-		pickupDate := availability.Date.Time
-		if availability.StartTime.Valid {
-			// Convert interval microseconds directly to duration (1 microsecond = 1000 nanoseconds)
-			pickupDate = pickupDate.Add(time.Duration(availability.StartTime.Microseconds) * time.Microsecond)
-		}
+		pickupDate := availabilityStart(availability)
 
-		// Calculate return date: pickup + 7 days (default borrowing period)
-		returnDate := pickupDate.Add(7 * 24 * time.Hour)
+		// the loan length comes from the requester's borrowing policies
+		returnDate := pickupDate.AddDate(0, 0, limits.LoanDays())
+		if violation := limits.Check(borrowingpolicy.Loan{
+			Quantity: int(req.Quantity),
+			Start:    pickupDate,
+			Due:      returnDate,
+		}); violation != nil {
+			return api.ReviewRequest400JSONResponse(PolicyViolationErr(violation).Create()), nil
+		}
 
 		// Create booking
 		newBookingID := uuid.New()
@@ -698,6 +1055,23 @@ func (s Server) ReviewRequest(ctx context.Context, request api.ReviewRequestRequ
 
 		bookingID = &booking.ID
 
+		policy, err := s.policies.ForGroup(ctx, qtx, req.GroupID)
+		if err != nil {
+			logger.Error("Failed to load booking policy", "group_id", req.GroupID, "error", err)
+			return api.ReviewRequest500JSONResponse(InternalError("Failed to create booking").Create()), nil
+		}
+		confirmBy = policy.ConfirmationDeadline(booking.CreatedAt.Time, booking.PickUpDate.Time).Format("2006-01-02 15:04")
+
+		if err := bookingevents.Record(ctx, qtx, booking.ID, &user.ID, db.NullRequestStatus{}, booking.Status, map[string]any{
+			"request_id":      request.RequestId,
+			"availability_id": request.Body.AvailabilityId,
+			"pick_up_date":    booking.PickUpDate.Time,
+			"return_date":     booking.ReturnDate.Time,
+		}); err != nil {
+			logger.Error("Failed to record booking creation", "booking_id", booking.ID, "error", err)
+			return api.ReviewRequest500JSONResponse(InternalError("Failed to create booking").Create()), nil
+		}
+
 		// Link request to booking
 		_, err = qtx.UpdateRequestWithBooking(ctx, db.UpdateRequestWithBookingParams{
 			ID:        request.RequestId,
@@ -709,9 +1083,10 @@ func (s Server) ReviewRequest(ctx context.Context, request api.ReviewRequestRequ
 	}
 
 	params := db.ReviewRequestParams{
-		ID:         request.RequestId,
-		Status:     toDBRequestStatus(request.Body.Status),
-		ReviewedBy: &user.ID,
+		ID:                    request.RequestId,
+		Status:                toDBRequestStatus(request.Body.Status),
+		ReviewedBy:            &user.ID,
+		OverrideJustification: overrideJustification,
 	}
 
 	resp, err := qtx.ReviewRequest(ctx, params)
@@ -726,13 +1101,24 @@ func (s Server) ReviewRequest(ctx context.Context, request api.ReviewRequestRequ
 	if err := tx.Commit(ctx); err != nil {
 		return api.ReviewRequest500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
+	if request.Body.Status == api.RequestStatusApproved && overrideJustification.Valid {
+		logger.Info("Fairness priority overridden", "request_id", req.ID, "item_id", item.ID, "user_id", user.ID)
+	}
 
+	switch request.Body.Status {
+	case api.RequestStatusApproved:
+		s.publishEvent(ctx, events.Event{Type: events.RequestApproved, EntityID: resp.ID, GroupID: resp.GroupID, ItemID: resp.ItemID, UserID: resp.UserID})
+	case api.RequestStatusDenied:
+		s.publishEvent(ctx, events.Event{Type: events.RequestDenied, EntityID: resp.ID, GroupID: resp.GroupID, ItemID: resp.ItemID, UserID: resp.UserID})
+	}
+
+	ctx = s.sandboxContext(ctx, req.GroupID)
 	if req.UserID != nil {
 		var requesterEmail string
 		if users, err := s.db.Queries().GetUsersByIDs(ctx, []uuid.UUID{*req.UserID}); err == nil && len(users) > 0 {
 			requesterEmail = users[0].Email
 		}
-		if request.Body.Status == api.Approved {
+		if request.Body.Status == api.RequestStatusApproved {
 			if notifyErr := s.dispatcher.Notify(ctx, user.ID, "request", request.RequestId, []notifications.NotifierGroup{
 				{
 					IDs:      []uuid.UUID{*req.UserID},
@@ -741,6 +1127,7 @@ func (s Server) ReviewRequest(ctx context.Context, request api.ReviewRequestRequ
 						"UserName":  requesterEmail,
 						"ItemName":  item.Name,
 						"RequestID": request.RequestId,
+						"ConfirmBy": confirmBy,
 					},
 				},
 				{
@@ -753,8 +1140,14 @@ func (s Server) ReviewRequest(ctx context.Context, request api.ReviewRequestRequ
 					},
 				},
 			}); notifyErr != nil {
-				logging.Error("failed to send approval notifications", "request_id", request.RequestId, "error", notifyErr)
+				logger.Error("Failed to send approval notifications", "request_id", request.RequestId, "error", notifyErr)
 			}
+			s.announce(ctx, notifications.ChatRequestApproved, req.GroupID, map[string]interface{}{
+				"ApproverName":  user.Email,
+				"RequesterName": requesterEmail,
+				"ItemName":      item.Name,
+				"Quantity":      req.Quantity,
+			})
 		} else {
 			if notifyErr := s.dispatcher.Notify(ctx, user.ID, "request", request.RequestId, []notifications.NotifierGroup{
 				{
@@ -767,25 +1160,129 @@ func (s Server) ReviewRequest(ctx context.Context, request api.ReviewRequestRequ
 					},
 				},
 			}); notifyErr != nil {
-				logging.Error("failed to send denial notifications", "request_id", request.RequestId, "error", notifyErr)
+				logger.Error("Failed to send denial notifications", "request_id", request.RequestId, "error", notifyErr)
 			}
 		}
 	}
 
 	reviewedAt := resp.ReviewedAt.Time
 
+	var justification *string
+	if resp.OverrideJustification.Valid {
+		justification = &resp.OverrideJustification.String
+	}
+
 	return api.ReviewRequest200JSONResponse{
-		Id:         resp.ID,
-		UserId:     *resp.UserID,
-		GroupId:    *resp.GroupID,
-		ItemId:     *resp.ItemID,
-		Quantity:   int(resp.Quantity),
-		Status:     toAPIRequestStatus(resp.Status),
-		ReviewedBy: resp.ReviewedBy,
-		ReviewedAt: &reviewedAt,
+		Id:                    resp.ID,
+		UserId:                *resp.UserID,
+		GroupId:               *resp.GroupID,
+		ItemId:                *resp.ItemID,
+		Quantity:              int(resp.Quantity),
+		Status:                toAPIRequestStatus(resp.Status),
+		ReviewedBy:            resp.ReviewedBy,
+		ReviewedAt:            &reviewedAt,
+		OverrideJustification: justification,
 	}, nil
 }
 
+func (s Server) CancelRequest(ctx context.Context, request api.CancelRequestRequestObject) (api.CancelRequestResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CancelRequest401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		return api.CancelRequest500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	req, err := qtx.GetRequestByIdForUpdate(ctx, request.RequestId)
+	if err == pgx.ErrNoRows {
+		return api.CancelRequest404JSONResponse(NotFound("Request").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get request", "request_id", request.RequestId, "error", err)
+		return api.CancelRequest500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+
+	// only the requester may withdraw; approvers deny instead
+	if req.UserID == nil || *req.UserID != user.ID {
+		return api.CancelRequest403JSONResponse(PermissionDenied("Only the requester can cancel this request").Create()), nil
+	}
+
+	resp, err := qtx.CancelRequest(ctx, req.ID)
+	if err == pgx.ErrNoRows {
+		return api.CancelRequest409JSONResponse(ConflictErr("Only pending requests can be cancelled").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to cancel request", "request_id", req.ID, "error", err)
+		return api.CancelRequest500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return api.CancelRequest500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+
+	// drops it from the dashboards' pending queues
+	s.publishEvent(ctx, events.Event{Type: events.RequestCancelled, EntityID: resp.ID, GroupID: resp.GroupID, ItemID: resp.ItemID, UserID: resp.UserID})
+	s.notifyRequestCancelled(ctx, user, resp)
+
+	logger.Info("Request cancelled", "request_id", resp.ID, "user_id", user.ID)
+
+	return api.CancelRequest200JSONResponse(createRequestItemResponse([]db.Request{resp})[0]), nil
+}
+
+// tells the approvers notified about the request that it no longer needs a
+// review. Routing rules for request_cancelled_approver can add recipients,
+// e.g. the team mailbox request_submitted_approver goes to.
+func (s Server) notifyRequestCancelled(ctx context.Context, requester *auth.AuthenticatedUser, req db.Request) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	notified, err := s.db.Queries().ListNotifiedUserIDs(ctx, db.ListNotifiedUserIDsParams{Name: "request", EntityID: req.ID})
+	if err != nil {
+		logger.Error("Failed to list users notified about request", "request_id", req.ID, "error", err)
+		return
+	}
+	approvers := make([]uuid.UUID, 0, len(notified))
+	for _, id := range notified {
+		if id != requester.ID {
+			approvers = append(approvers, id)
+		}
+	}
+
+	item, err := s.db.Queries().GetItemByIDIncludingBinned(ctx, *req.ItemID)
+	if err != nil {
+		logger.Error("Failed to get item for request cancellation", "request_id", req.ID, "error", err)
+		return
+	}
+
+	ctx = s.sandboxContext(ctx, req.GroupID)
+	if err := s.dispatcher.Notify(ctx, requester.ID, "request", req.ID, []notifications.NotifierGroup{
+		{
+			IDs:      approvers,
+			Template: "request_cancelled_approver",
+			TemplateData: map[string]interface{}{
+				"RequesterName": requester.Email,
+				"ItemName":      item.Name,
+				"Quantity":      req.Quantity,
+				"RequestID":     req.ID,
+			},
+			Facts: notifications.RoutingFacts{
+				ItemID:   &item.ID,
+				ItemType: item.Type,
+				GroupID:  req.GroupID,
+			},
+		},
+	}); err != nil {
+		logger.Error("Failed to send request cancellation notifications", "request_id", req.ID, "error", err)
+	}
+}
+
 func (s Server) GetAllRequests(ctx context.Context, request api.GetAllRequestsRequestObject) (api.GetAllRequestsResponseObject, error) {
 	user, ok := auth.GetAuthenticatedUser(ctx)
 	if !ok {
@@ -802,20 +1299,71 @@ func (s Server) GetAllRequests(ctx context.Context, request api.GetAllRequestsRe
 
 	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
 
-	requests, err := s.db.Queries().GetAllRequests(ctx, db.GetAllRequestsParams{Limit: limit, Offset: offset})
+	sort, err := parseSort(request.Params.SortBy, request.Params.Order, listSort{By: "requested_at", Desc: true}, "requested_at", "quantity")
+	if err != nil {
+		return api.GetAllRequests400JSONResponse(ValidationErr(err.Error(), nil).Create()), nil
+	}
+
+	var status db.NullRequestStatus
+	if request.Params.Status != nil {
+		status = db.NullRequestStatus{RequestStatus: db.RequestStatus(*request.Params.Status), Valid: true}
+	}
+
+	fromDate, toDate := parseDateRange(request.Params.FromDate, request.Params.ToDate)
+
+	listRequests := func(ctx context.Context, limit, offset int64) ([]db.Request, error) {
+		return s.db.Queries().GetAllRequests(ctx, db.GetAllRequestsParams{
+			Status:   status,
+			GroupID:  request.Params.GroupId,
+			FromDate: fromDate,
+			ToDate:   toDate,
+			SortBy:   sort.By,
+			SortDesc: sort.Desc,
+			Limit:    limit,
+			Offset:   offset,
+		})
+	}
+
+	total, err := s.db.Queries().CountAllRequests(ctx, db.CountAllRequestsParams{
+		Status:   status,
+		GroupID:  request.Params.GroupId,
+		FromDate: fromDate,
+		ToDate:   toDate,
+	})
 	if err != nil {
 		return api.GetAllRequests500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 
-	total, err := s.db.Queries().CountAllRequests(ctx)
+	if wantsCSV(request.Params.Format) {
+		body := streamCSV(ctx, requestColumns, func(ctx context.Context, limit, offset int64) ([][]string, error) {
+			requests, err := listRequests(ctx, limit, offset)
+			if err != nil {
+				return nil, err
+			}
+			records := make([][]string, 0, len(requests))
+			for _, r := range createRequestItemResponse(requests) {
+				records = append(records, requestRecord(r))
+			}
+			return records, nil
+		})
+		return api.GetAllRequests200TextcsvResponse{
+			Body:    body,
+			Headers: api.GetAllRequests200ResponseHeaders{XTotalCount: int(total)},
+		}, nil
+	}
+
+	requests, err := listRequests(ctx, limit, offset)
 	if err != nil {
 		return api.GetAllRequests500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 
 	response := createRequestItemResponse(requests)
 	return api.GetAllRequests200JSONResponse{
-		Data: response,
-		Meta: buildPaginationMeta(total, limit, offset),
+		Body: api.PaginatedRequestResponse{
+			Data: response,
+			Meta: buildPaginationMeta(total, limit, offset),
+		},
+		Headers: api.GetAllRequests200ResponseHeaders{XTotalCount: int(total)},
 	}, nil
 }
 
@@ -845,10 +1393,31 @@ func (s Server) GetPendingRequests(ctx context.Context, request api.GetPendingRe
 		return api.GetPendingRequests500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 
+	priorities, err := s.requestFairness(ctx, requests)
+	if err != nil {
+		return api.GetPendingRequests500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+
+	deadlines, err := s.requestSLAStatus(ctx, requests)
+	if err != nil {
+		return api.GetPendingRequests500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+
 	response := createRequestItemResponse(requests)
+	for i := range response {
+		if f, ok := priorities[response[i].Id]; ok {
+			response[i].Fairness = &f
+		}
+		if d, ok := deadlines[response[i].Id]; ok {
+			response[i].Sla = &d
+		}
+	}
 	return api.GetPendingRequests200JSONResponse{
-		Data: response,
-		Meta: buildPaginationMeta(total, limit, offset),
+		Body: api.PaginatedRequestResponse{
+			Data: response,
+			Meta: buildPaginationMeta(total, limit, offset),
+		},
+		Headers: api.GetPendingRequests200ResponseHeaders{XTotalCount: int(total)},
 	}, nil
 }
 
@@ -871,13 +1440,52 @@ func (s Server) GetRequestsByUserId(ctx context.Context, request api.GetRequests
 		return api.GetRequestsByUserId403JSONResponse(PermissionDenied("Insufficient permissions to view other users' requests").Create()), nil
 	}
 
-	requests, err := s.db.Queries().GetRequestsByUserId(ctx, &request.UserId)
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	sort, err := parseSort(request.Params.SortBy, request.Params.Order, listSort{By: "requested_at", Desc: true}, "requested_at", "quantity")
+	if err != nil {
+		return api.GetRequestsByUserId400JSONResponse(ValidationErr(err.Error(), nil).Create()), nil
+	}
+
+	var status db.NullRequestStatus
+	if request.Params.Status != nil {
+		status = db.NullRequestStatus{RequestStatus: db.RequestStatus(*request.Params.Status), Valid: true}
+	}
+
+	fromDate, toDate := parseDateRange(request.Params.FromDate, request.Params.ToDate)
+
+	requests, err := s.db.Queries().GetRequestsByUserId(ctx, db.GetRequestsByUserIdParams{
+		UserID:   &request.UserId,
+		Status:   status,
+		FromDate: fromDate,
+		ToDate:   toDate,
+		SortBy:   sort.By,
+		SortDesc: sort.Desc,
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		return api.GetRequestsByUserId500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountRequestsByUserId(ctx, db.CountRequestsByUserIdParams{
+		UserID:   &request.UserId,
+		Status:   status,
+		FromDate: fromDate,
+		ToDate:   toDate,
+	})
 	if err != nil {
 		return api.GetRequestsByUserId500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 
 	response := createRequestItemResponse(requests)
-	return api.GetRequestsByUserId200JSONResponse(response), nil
+	return api.GetRequestsByUserId200JSONResponse{
+		Body: api.PaginatedRequestResponse{
+			Data: response,
+			Meta: buildPaginationMeta(total, limit, offset),
+		},
+		Headers: api.GetRequestsByUserId200ResponseHeaders{XTotalCount: int(total)},
+	}, nil
 }
 
 func (s Server) GetRequestById(ctx context.Context, request api.GetRequestByIdRequestObject) (api.GetRequestByIdResponseObject, error) {
@@ -908,7 +1516,23 @@ func (s Server) GetRequestById(ctx context.Context, request api.GetRequestByIdRe
 		return api.GetRequestById500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
 	if !hasViewAllPermission && *req.UserID != user.ID {
-		return api.GetRequestById403JSONResponse(PermissionDenied("Insufficient permissions to view this request").Create()), nil
+		// approvers may view what was routed to them
+		routed, err := s.db.Queries().IsRequestRoutedTo(ctx, db.IsRequestRoutedToParams{RequestID: req.ID, ApproverID: user.ID})
+		if err != nil {
+			return api.GetRequestById500JSONResponse(InternalError("Internal server error").Create()), nil
+		}
+		if !routed {
+			return api.GetRequestById403JSONResponse(PermissionDenied("Insufficient permissions to view this request").Create()), nil
+		}
+	}
+
+	routes, err := s.db.Queries().ListRequestRoutes(ctx, req.ID)
+	if err != nil {
+		return api.GetRequestById500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	routing := make([]api.RequestRoute, 0, len(routes))
+	for _, r := range routes {
+		routing = append(routing, toRequestRouteResponse(r))
 	}
 
 	var reviewedAt *time.Time
@@ -925,6 +1549,7 @@ func (s Server) GetRequestById(ctx context.Context, request api.GetRequestByIdRe
 		Status:     api.RequestStatus(string(req.Status.RequestStatus)),
 		ReviewedBy: req.ReviewedBy,
 		ReviewedAt: reviewedAt,
+		Routing:    &routing,
 	}, nil
 }
 
@@ -937,16 +1562,21 @@ func createRequestItemResponse(requests []db.Request) []api.RequestItemResponse
 		if req.ReviewedAt.Valid {
 			reviewedAt = &req.ReviewedAt.Time
 		}
+		var justification *string
+		if req.OverrideJustification.Valid {
+			justification = &req.OverrideJustification.String
+		}
 
 		response = append(response, api.RequestItemResponse{
-			Id:         req.ID,
-			UserId:     *req.UserID,
-			GroupId:    *req.GroupID,
-			ItemId:     *req.ItemID,
-			Quantity:   int(req.Quantity),
-			Status:     toAPIRequestStatus(req.Status),
-			ReviewedBy: req.ReviewedBy,
-			ReviewedAt: reviewedAt,
+			Id:                    req.ID,
+			UserId:                *req.UserID,
+			GroupId:               *req.GroupID,
+			ItemId:                *req.ItemID,
+			Quantity:              int(req.Quantity),
+			Status:                toAPIRequestStatus(req.Status),
+			ReviewedBy:            req.ReviewedBy,
+			ReviewedAt:            reviewedAt,
+			OverrideJustification: justification,
 		})
 	}
 
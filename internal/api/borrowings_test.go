@@ -755,7 +755,7 @@ func TestServer_UserBorrowingHistory(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetBorrowedItemHistoryByUserId200JSONResponse{}, response)
 
-		historyResp := response.(api.GetBorrowedItemHistoryByUserId200JSONResponse)
+		historyResp := response.(api.GetBorrowedItemHistoryByUserId200JSONResponse).Body
 		assert.Len(t, historyResp.Data, 2) // Should have 2 borrowings (1 returned, 1 active)
 	})
 
@@ -835,7 +835,7 @@ func TestServer_UserBorrowingHistory(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetActiveBorrowedItemsByUserId200JSONResponse{}, response)
 
-		activeResp := response.(api.GetActiveBorrowedItemsByUserId200JSONResponse)
+		activeResp := response.(api.GetActiveBorrowedItemsByUserId200JSONResponse).Body
 		assert.Len(t, activeResp.Data, 1)
 		assert.Nil(t, activeResp.Data[0].ReturnedAt)
 	})
@@ -903,7 +903,7 @@ func TestServer_UserBorrowingHistory(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetReturnedItemsByUserId200JSONResponse{}, response)
 
-		returnedResp := response.(api.GetReturnedItemsByUserId200JSONResponse)
+		returnedResp := response.(api.GetReturnedItemsByUserId200JSONResponse).Body
 		assert.Len(t, returnedResp.Data, 1)
 		assert.NotNil(t, returnedResp.Data[0].ReturnedAt)
 	})
@@ -970,7 +970,7 @@ func TestServer_AdminBorrowingViews(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetAllActiveBorrowedItems200JSONResponse{}, response)
 
-		activeResp := response.(api.GetAllActiveBorrowedItems200JSONResponse)
+		activeResp := response.(api.GetAllActiveBorrowedItems200JSONResponse).Body
 		assert.GreaterOrEqual(t, len(activeResp.Data), 1)
 	})
 
@@ -1121,7 +1121,7 @@ func TestServer_RequestItem(t *testing.T) {
 		assert.Equal(t, group.ID, requestResp.GroupId)
 		assert.Equal(t, highItem.ID, requestResp.ItemId)
 		assert.Equal(t, 1, requestResp.Quantity)
-		assert.Equal(t, api.Pending, requestResp.Status)
+		assert.Equal(t, api.RequestStatusPending, requestResp.Status)
 		assert.Nil(t, requestResp.ReviewedBy)
 		assert.Nil(t, requestResp.ReviewedAt)
 	})
@@ -1349,7 +1349,7 @@ func TestServer_ReviewRequest(t *testing.T) {
 		response, err := server.ReviewRequest(approverCtx, api.ReviewRequestRequestObject{
 			RequestId: createdRequest.Id,
 			Body: &api.ReviewRequestJSONRequestBody{
-				Status:         api.Approved,
+				Status:         api.RequestStatusApproved,
 				AvailabilityId: &availability.ID,
 				PickupLocation: &pickupLocation,
 				ReturnLocation: &returnLocation,
@@ -1361,7 +1361,7 @@ func TestServer_ReviewRequest(t *testing.T) {
 
 		reviewResp := response.(api.ReviewRequest200JSONResponse)
 		assert.Equal(t, createdRequest.Id, reviewResp.Id)
-		assert.Equal(t, api.Approved, reviewResp.Status)
+		assert.Equal(t, api.RequestStatusApproved, reviewResp.Status)
 		assert.Equal(t, approverUser.ID, *reviewResp.ReviewedBy)
 		assert.NotNil(t, reviewResp.ReviewedAt)
 
@@ -1427,7 +1427,7 @@ func TestServer_ReviewRequest(t *testing.T) {
 		response, err := server.ReviewRequest(approverCtx, api.ReviewRequestRequestObject{
 			RequestId: createdRequest.Id,
 			Body: &api.ReviewRequestJSONRequestBody{
-				Status: api.Denied,
+				Status: api.RequestStatusDenied,
 			},
 		})
 
@@ -1435,7 +1435,7 @@ func TestServer_ReviewRequest(t *testing.T) {
 		require.IsType(t, api.ReviewRequest200JSONResponse{}, response)
 
 		reviewResp := response.(api.ReviewRequest200JSONResponse)
-		assert.Equal(t, api.Denied, reviewResp.Status)
+		assert.Equal(t, api.RequestStatusDenied, reviewResp.Status)
 		assert.Equal(t, approverUser.ID, *reviewResp.ReviewedBy)
 
 		// only requester notified
@@ -1499,7 +1499,7 @@ func TestServer_ReviewRequest(t *testing.T) {
 		response, err := server.ReviewRequest(approverCtx, api.ReviewRequestRequestObject{
 			RequestId: createdRequest.Id,
 			Body: &api.ReviewRequestJSONRequestBody{
-				Status: api.Approved,
+				Status: api.RequestStatusApproved,
 			},
 		})
 
@@ -1557,7 +1557,7 @@ func TestServer_ReviewRequest(t *testing.T) {
 		response, err := server.ReviewRequest(memberCtx, api.ReviewRequestRequestObject{
 			RequestId: createdRequest.Id,
 			Body: &api.ReviewRequestJSONRequestBody{
-				Status: api.Approved,
+				Status: api.RequestStatusApproved,
 			},
 		})
 
@@ -1635,7 +1635,7 @@ func TestServer_ReviewRequest(t *testing.T) {
 		_, err = server.ReviewRequest(approverCtx, api.ReviewRequestRequestObject{
 			RequestId: createdRequest.Id,
 			Body: &api.ReviewRequestJSONRequestBody{
-				Status:         api.Approved,
+				Status:         api.RequestStatusApproved,
 				AvailabilityId: &availability.ID,
 				PickupLocation: &pickupLocation,
 				ReturnLocation: &returnLocation,
@@ -1649,7 +1649,7 @@ func TestServer_ReviewRequest(t *testing.T) {
 		response, err := server.ReviewRequest(approverCtx, api.ReviewRequestRequestObject{
 			RequestId: createdRequest.Id,
 			Body: &api.ReviewRequestJSONRequestBody{
-				Status: api.Denied,
+				Status: api.RequestStatusDenied,
 			},
 		})
 
@@ -1716,10 +1716,71 @@ func TestServer_GetAllRequests(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetAllRequests200JSONResponse{}, response)
 
-		requestsResp := response.(api.GetAllRequests200JSONResponse)
+		requestsResp := response.(api.GetAllRequests200JSONResponse).Body
 		assert.GreaterOrEqual(t, len(requestsResp.Data), 1)
 	})
 
+	t.Run("filters by group and sorts by quantity", func(t *testing.T) {
+		adminUser := testDB.NewUser(t).
+			WithEmail("admin@filteredrequests.ca").
+			AsGlobalAdmin().
+			Create()
+		requestUser := testDB.NewUser(t).
+			WithEmail("requester@filteredrequests.ca").
+			AsMember().
+			Create()
+		group := testDB.NewGroup(t).WithName("Filtered Requests Group").Create()
+		otherGroup := testDB.NewGroup(t).WithName("Other Requests Group").Create()
+		item := testDB.NewItem(t).WithName("Projector").WithType("high").WithStock(5).Create()
+
+		for _, r := range []struct {
+			groupID  uuid.UUID
+			quantity int32
+		}{{group.ID, 1}, {group.ID, 3}, {otherGroup.ID, 2}} {
+			_, err := testDB.Queries().RequestItem(context.Background(), db.RequestItemParams{
+				UserID:   &requestUser.ID,
+				GroupID:  &r.groupID,
+				ID:       item.ID,
+				Quantity: r.quantity,
+			})
+			require.NoError(t, err)
+		}
+
+		mockAuth.ExpectCheckPermission(adminUser.ID, rbac.ViewAllData, nil, true, nil)
+		adminCtx := testutil.ContextWithUser(context.Background(), adminUser, testDB.Queries())
+
+		sortBy := "quantity"
+		order := api.Desc
+		response, err := server.GetAllRequests(adminCtx, api.GetAllRequestsRequestObject{
+			Params: api.GetAllRequestsParams{GroupId: &group.ID, SortBy: &sortBy, Order: &order},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetAllRequests200JSONResponse{}, response)
+
+		requestsResp := response.(api.GetAllRequests200JSONResponse)
+		require.Len(t, requestsResp.Body.Data, 2)
+		assert.Equal(t, 3, requestsResp.Body.Data[0].Quantity)
+		assert.Equal(t, 1, requestsResp.Body.Data[1].Quantity)
+		assert.Equal(t, 2, requestsResp.Headers.XTotalCount)
+	})
+
+	t.Run("rejects unknown sort field", func(t *testing.T) {
+		adminUser := testDB.NewUser(t).
+			WithEmail("admin@badsortrequests.ca").
+			AsGlobalAdmin().
+			Create()
+
+		mockAuth.ExpectCheckPermission(adminUser.ID, rbac.ViewAllData, nil, true, nil)
+		adminCtx := testutil.ContextWithUser(context.Background(), adminUser, testDB.Queries())
+
+		sortBy := "user_id"
+		response, err := server.GetAllRequests(adminCtx, api.GetAllRequestsRequestObject{
+			Params: api.GetAllRequestsParams{SortBy: &sortBy},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetAllRequests400JSONResponse{}, response)
+	})
+
 	t.Run("member cannot view all requests", func(t *testing.T) {
 		memberUser := testDB.NewUser(t).
 			WithEmail("member@noviewall.ca").
@@ -1793,12 +1854,12 @@ func TestServer_GetPendingRequests(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetPendingRequests200JSONResponse{}, response)
 
-		pendingResp := response.(api.GetPendingRequests200JSONResponse)
+		pendingResp := response.(api.GetPendingRequests200JSONResponse).Body
 		assert.GreaterOrEqual(t, len(pendingResp.Data), 1)
 
 		// Verify all returned requests are pending
 		for _, req := range pendingResp.Data {
-			assert.Equal(t, api.Pending, req.Status)
+			assert.Equal(t, api.RequestStatusPending, req.Status)
 		}
 	})
 
@@ -1872,10 +1933,11 @@ func TestServer_GetRequestsByUserId(t *testing.T) {
 		require.IsType(t, api.GetRequestsByUserId200JSONResponse{}, response)
 
 		requestsResp := response.(api.GetRequestsByUserId200JSONResponse)
-		assert.GreaterOrEqual(t, len(requestsResp), 1)
+		assert.GreaterOrEqual(t, len(requestsResp.Body.Data), 1)
+		assert.Equal(t, requestsResp.Body.Meta.Total, requestsResp.Headers.XTotalCount)
 
 		// Verify all returned requests belong to this user
-		for _, req := range requestsResp {
+		for _, req := range requestsResp.Body.Data {
 			assert.Equal(t, testUser.ID, req.UserId)
 		}
 	})
@@ -2098,6 +2160,114 @@ func TestServer_GetRequestById(t *testing.T) {
 	})
 }
 
+func TestServer_CancelRequest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	requestUser := testDB.NewUser(t).
+		WithEmail("requester@cancel.ca").
+		AsMember().
+		Create()
+
+	group := testDB.NewGroup(t).
+		WithName("Cancel Group").
+		Create()
+
+	testDB.AssignUserToGroup(t, requestUser.ID, group.ID, "member")
+
+	highItem := testDB.NewItem(t).
+		WithName("Cinema Lens").
+		WithType("high").
+		WithStock(1).
+		Create()
+
+	requestCtx := testutil.ContextWithUser(context.Background(), requestUser, testDB.Queries())
+	newRequest := func(t *testing.T) api.RequestItem201JSONResponse {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(requestUser.ID, rbac.RequestItems, &group.ID, true, nil)
+		requestResp, err := server.RequestItem(requestCtx, api.RequestItemRequestObject{
+			Body: &api.RequestItemJSONRequestBody{
+				UserId:   requestUser.ID,
+				GroupId:  group.ID,
+				ItemId:   highItem.ID,
+				Quantity: 1,
+			},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RequestItem201JSONResponse{}, requestResp)
+		return requestResp.(api.RequestItem201JSONResponse)
+	}
+
+	t.Run("requester cancels a pending request", func(t *testing.T) {
+		createdRequest := newRequest(t)
+
+		response, err := server.CancelRequest(requestCtx, api.CancelRequestRequestObject{RequestId: createdRequest.Id})
+		require.NoError(t, err)
+		require.IsType(t, api.CancelRequest200JSONResponse{}, response)
+		assert.Equal(t, api.RequestStatusCancelled, response.(api.CancelRequest200JSONResponse).Status)
+
+		pending, err := testDB.Queries().GetPendingRequests(context.Background(), db.GetPendingRequestsParams{Limit: 100})
+		require.NoError(t, err)
+		for _, req := range pending {
+			assert.NotEqual(t, createdRequest.Id, req.ID, "a cancelled request leaves the pending queue")
+		}
+
+		again, err := server.CancelRequest(requestCtx, api.CancelRequestRequestObject{RequestId: createdRequest.Id})
+		require.NoError(t, err)
+		require.IsType(t, api.CancelRequest409JSONResponse{}, again)
+	})
+
+	t.Run("approvers already notified hear about the cancellation", func(t *testing.T) {
+		approverUser := testDB.NewUser(t).
+			WithEmail("approver@cancel.ca").
+			AsApprover().
+			Create()
+
+		// routes new requests for the item to the approver
+		_, err := testDB.Queries().CreateRoutingRule(context.Background(), db.CreateRoutingRuleParams{
+			Name:            "Lens requests",
+			Template:        "request_submitted_approver",
+			ItemID:          &highItem.ID,
+			RecipientUserID: &approverUser.ID,
+			Enabled:         true,
+		})
+		require.NoError(t, err)
+
+		createdRequest := newRequest(t)
+
+		response, err := server.CancelRequest(requestCtx, api.CancelRequestRequestObject{RequestId: createdRequest.Id})
+		require.NoError(t, err)
+		require.IsType(t, api.CancelRequest200JSONResponse{}, response)
+
+		approverNotifs, err := testDB.Queries().GetUserNotifications(context.Background(), db.GetUserNotificationsParams{NotifierID: approverUser.ID, Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, approverNotifs, 2, "the approver is told the request was withdrawn")
+	})
+
+	t.Run("only the requester can cancel", func(t *testing.T) {
+		otherUser := testDB.NewUser(t).
+			WithEmail("other@cancel.ca").
+			AsMember().
+			Create()
+
+		createdRequest := newRequest(t)
+
+		otherCtx := testutil.ContextWithUser(context.Background(), otherUser, testDB.Queries())
+		response, err := server.CancelRequest(otherCtx, api.CancelRequestRequestObject{RequestId: createdRequest.Id})
+		require.NoError(t, err)
+		require.IsType(t, api.CancelRequest403JSONResponse{}, response)
+	})
+
+	t.Run("request not found returns 404", func(t *testing.T) {
+		response, err := server.CancelRequest(requestCtx, api.CancelRequestRequestObject{RequestId: uuid.New()})
+		require.NoError(t, err)
+		require.IsType(t, api.CancelRequest404JSONResponse{}, response)
+	})
+}
+
 func TestServer_ReviewRequest_BookingIntegration(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -2158,7 +2328,7 @@ func TestServer_ReviewRequest_BookingIntegration(t *testing.T) {
 		response, err := server.ReviewRequest(approverCtx, api.ReviewRequestRequestObject{
 			RequestId: createdRequest.Id,
 			Body: &api.ReviewRequestJSONRequestBody{
-				Status:         api.Approved,
+				Status:         api.RequestStatusApproved,
 				AvailabilityId: &availability.ID,
 				PickupLocation: &pickupLoc,
 				ReturnLocation: &returnLoc,
@@ -2169,7 +2339,7 @@ func TestServer_ReviewRequest_BookingIntegration(t *testing.T) {
 		require.IsType(t, api.ReviewRequest200JSONResponse{}, response)
 
 		resp := response.(api.ReviewRequest200JSONResponse)
-		assert.Equal(t, api.Approved, resp.Status)
+		assert.Equal(t, api.RequestStatusApproved, resp.Status)
 
 		// Verify booking was created by checking the request has a booking_id
 		request, err := testDB.Queries().GetRequestById(approverCtx, createdRequest.Id)
@@ -2237,7 +2407,7 @@ func TestServer_ReviewRequest_BookingIntegration(t *testing.T) {
 		response, err := server.ReviewRequest(approverCtx, api.ReviewRequestRequestObject{
 			RequestId: createdRequest.Id,
 			Body: &api.ReviewRequestJSONRequestBody{
-				Status:         api.Approved,
+				Status:         api.RequestStatusApproved,
 				PickupLocation: &pickupLoc,
 				ReturnLocation: &returnLoc,
 				// Missing AvailabilityId
@@ -2253,3 +2423,64 @@ func TestServer_ReviewRequest_BookingIntegration(t *testing.T) {
 	})
 
 }
+
+func TestServer_GetOverdueBorrowings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	ctx := context.Background()
+
+	admin := testDB.NewUser(t).WithEmail("admin@overdue.test").AsGlobalAdmin().Create()
+	member := testDB.NewUser(t).WithEmail("member@overdue.test").AsMember().Create()
+	group := testDB.NewGroup(t).WithName("Overdue Group").Create()
+	item := testDB.NewItem(t).WithName("Overdue Projector").WithType("medium").WithStock(5).Create()
+
+	borrow := func(due time.Time) db.Borrowing {
+		borrowing, err := testDB.Queries().BorrowItem(ctx, db.BorrowItemParams{
+			UserID:             &member.ID,
+			GroupID:            &group.ID,
+			ID:                 item.ID,
+			Quantity:           1,
+			DueDate:            pgtype.Timestamp{Time: due, Valid: true},
+			BeforeCondition:    "good",
+			BeforeConditionUrl: "http://example.com/before.jpg",
+		})
+		require.NoError(t, err)
+		return borrowing
+	}
+	late := borrow(time.Now().Add(-4 * 24 * time.Hour))
+	borrow(time.Now().Add(3 * 24 * time.Hour))
+
+	marked, err := testDB.Queries().MarkOverdueBorrowings(ctx)
+	require.NoError(t, err)
+	require.Len(t, marked, 1)
+	assert.Equal(t, late.ID, marked[0].ID)
+
+	t.Run("admin sees overdue borrowings", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewAllData, nil, true, nil)
+		adminCtx := testutil.ContextWithUser(ctx, admin, testDB.Queries())
+
+		response, err := server.GetOverdueBorrowings(adminCtx, api.GetOverdueBorrowingsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetOverdueBorrowings200JSONResponse{}, response)
+
+		overdue := response.(api.GetOverdueBorrowings200JSONResponse).Body
+		require.Len(t, overdue.Data, 1)
+		assert.Equal(t, late.ID, overdue.Data[0].Id)
+		assert.Equal(t, "Overdue Projector", overdue.Data[0].ItemName)
+		assert.Equal(t, 4, overdue.Data[0].DaysOverdue)
+		assert.Equal(t, 0, overdue.Data[0].RemindersSent)
+		assert.Equal(t, 1, overdue.Meta.Total)
+	})
+
+	t.Run("member cannot list overdue borrowings", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewAllData, nil, false, nil)
+		memberCtx := testutil.ContextWithUser(ctx, member, testDB.Queries())
+
+		response, err := server.GetOverdueBorrowings(memberCtx, api.GetOverdueBorrowingsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetOverdueBorrowings403JSONResponse{}, response)
+	})
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/calendar"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/jackc/pgx/v5"
+)
+
+func convertToCalendarLinkResponse(link db.CalendarLink) api.CalendarLink {
+	response := api.CalendarLink{
+		IcsUrl:       link.IcsUrl,
+		RemovedSlots: int(link.RemovedSlots),
+	}
+	if link.LastSyncedAt.Valid {
+		response.LastSyncedAt = &link.LastSyncedAt.Time
+	}
+	if link.LastError.Valid {
+		response.LastError = &link.LastError.String
+	}
+	return response
+}
+
+// accepts absolute http(s) and webcal feed URLs.
+func validCalendarURL(raw string) bool {
+	u, err := url.Parse(calendar.FeedURL(raw))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+func (s Server) GetMyCalendarLink(ctx context.Context, request api.GetMyCalendarLinkRequestObject) (api.GetMyCalendarLinkResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetMyCalendarLink401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageTimeSlots, nil)
+	if err != nil {
+		logger.Error("Failed to check permission", "error", err)
+		return api.GetMyCalendarLink500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetMyCalendarLink403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	link, err := s.db.Queries().GetCalendarLink(ctx, user.ID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return api.GetMyCalendarLink404JSONResponse(NotFound("Calendar link").Create()), nil
+		}
+		logger.Error("Failed to get calendar link", "user_id", user.ID, "error", err)
+		return api.GetMyCalendarLink500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	return api.GetMyCalendarLink200JSONResponse(convertToCalendarLinkResponse(link)), nil
+}
+
+// links an ICS feed and queues an immediate import so conflicting
+// availability is removed without waiting for the next scheduled sync.
+func (s Server) SetMyCalendarLink(ctx context.Context, request api.SetMyCalendarLinkRequestObject) (api.SetMyCalendarLinkResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.SetMyCalendarLink401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageTimeSlots, nil)
+	if err != nil {
+		logger.Error("Failed to check permission", "error", err)
+		return api.SetMyCalendarLink500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if !hasPermission {
+		return api.SetMyCalendarLink403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil || !validCalendarURL(request.Body.IcsUrl) {
+		return api.SetMyCalendarLink400JSONResponse(ValidationErr("ics_url must be an http, https or webcal URL", nil).Create()), nil
+	}
+
+	link, err := s.db.Queries().UpsertCalendarLink(ctx, db.UpsertCalendarLinkParams{
+		UserID: user.ID,
+		IcsUrl: request.Body.IcsUrl,
+	})
+	if err != nil {
+		logger.Error("Failed to save calendar link", "user_id", user.ID, "error", err)
+		return api.SetMyCalendarLink500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	if _, err := s.queue.Enqueue(ctx, queue.TypeCalendarSync, queue.CalendarSyncPayload{UserID: &user.ID}); err != nil {
+		// the scheduled sync will still pick the link up
+		logger.Warn("Failed to enqueue calendar sync", "user_id", user.ID, "error", err)
+	}
+
+	logger.Info("Calendar linked", "user_id", user.ID)
+	return api.SetMyCalendarLink200JSONResponse(convertToCalendarLinkResponse(link)), nil
+}
+
+func (s Server) DeleteMyCalendarLink(ctx context.Context, request api.DeleteMyCalendarLinkRequestObject) (api.DeleteMyCalendarLinkResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.DeleteMyCalendarLink401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageTimeSlots, nil)
+	if err != nil {
+		logger.Error("Failed to check permission", "error", err)
+		return api.DeleteMyCalendarLink500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if !hasPermission {
+		return api.DeleteMyCalendarLink403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	rows, err := s.db.Queries().DeleteCalendarLink(ctx, user.ID)
+	if err != nil {
+		logger.Error("Failed to delete calendar link", "user_id", user.ID, "error", err)
+		return api.DeleteMyCalendarLink500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if rows == 0 {
+		return api.DeleteMyCalendarLink404JSONResponse(NotFound("Calendar link").Create()), nil
+	}
+
+	logger.Info("Calendar unlinked", "user_id", user.ID)
+	return api.DeleteMyCalendarLink204Response{}, nil
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/calendar"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// how long a pickup or return appears in calendars when the booking's
+// availability slot doesn't say
+const defaultHandoffLength = 15 * time.Minute
+
+// the parts of a booking that make up its calendar events
+type calendarBooking struct {
+	ID             uuid.UUID
+	ItemName       string
+	Status         db.RequestStatus
+	PickUpDate     pgtype.Timestamp
+	PickUpLocation string
+	ReturnDate     pgtype.Timestamp
+	ReturnLocation string
+	// the availability slot the pickup was booked in
+	SlotStart pgtype.Time
+	SlotEnd   pgtype.Time
+}
+
+// booking times are stored as wall-clock times in the server's calendar zone
+func (s Server) wallClock(t time.Time) time.Time {
+	loc := s.location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+}
+
+// a pickup and a return event per booking
+func (s Server) bookingEvents(b calendarBooking) []calendar.Event {
+	length := defaultHandoffLength
+	if b.SlotStart.Valid && b.SlotEnd.Valid && b.SlotEnd.Microseconds > b.SlotStart.Microseconds {
+		length = time.Duration(b.SlotEnd.Microseconds-b.SlotStart.Microseconds) * time.Microsecond
+	}
+
+	status := "CONFIRMED"
+	if b.Status == db.RequestStatusPendingConfirmation {
+		status = "TENTATIVE"
+	}
+
+	pickUp := s.wallClock(b.PickUpDate.Time)
+	returnAt := s.wallClock(b.ReturnDate.Time)
+	description := fmt.Sprintf("Booking %s", b.ID)
+
+	return []calendar.Event{
+		{
+			UID:         fmt.Sprintf("booking-%s-pickup@cv-backend", b.ID),
+			Summary:     "Pick up " + b.ItemName,
+			Description: description,
+			Location:    b.PickUpLocation,
+			Start:       pickUp,
+			End:         pickUp.Add(length),
+			Status:      status,
+		},
+		{
+			UID:         fmt.Sprintf("booking-%s-return@cv-backend", b.ID),
+			Summary:     "Return " + b.ItemName,
+			Description: description,
+			Location:    b.ReturnLocation,
+			Start:       returnAt,
+			End:         returnAt.Add(defaultHandoffLength),
+			Status:      status,
+		},
+	}
+}
+
+// the booking's events as an .ics file for emails
+func (s Server) bookingICS(b calendarBooking) (queue.EmailAttachment, error) {
+	var buf bytes.Buffer
+	if err := calendar.WriteICS(&buf, "", s.bookingEvents(b)); err != nil {
+		return queue.EmailAttachment{}, err
+	}
+	return queue.EmailAttachment{
+		Filename:    "booking.ics",
+		ContentType: "text/calendar; charset=UTF-8; method=PUBLISH",
+		Data:        buf.Bytes(),
+	}, nil
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// replaces the user's feed token; the old feed URL stops working.
+func (s Server) CreateMyCalendarFeedToken(ctx context.Context, request api.CreateMyCalendarFeedTokenRequestObject) (api.CreateMyCalendarFeedTokenResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateMyCalendarFeedToken401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewOwnData, nil)
+	if err != nil {
+		logger.Error("Failed to check permission", "error", err)
+		return api.CreateMyCalendarFeedToken500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if !hasPermission {
+		return api.CreateMyCalendarFeedToken403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		logger.Error("Failed to generate calendar feed token", "error", err)
+		return api.CreateMyCalendarFeedToken500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	token := hex.EncodeToString(raw)
+
+	if err := s.db.Queries().UpsertCalendarFeedToken(ctx, db.UpsertCalendarFeedTokenParams{
+		UserID:    user.ID,
+		TokenHash: hashFeedToken(token),
+	}); err != nil {
+		logger.Error("Failed to save calendar feed token", "user_id", user.ID, "error", err)
+		return api.CreateMyCalendarFeedToken500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	return api.CreateMyCalendarFeedToken201JSONResponse{
+		Token:    token,
+		FeedPath: "/me/bookings.ics?" + url.Values{"token": {token}}.Encode(),
+	}, nil
+}
+
+func (s Server) RevokeMyCalendarFeedToken(ctx context.Context, request api.RevokeMyCalendarFeedTokenRequestObject) (api.RevokeMyCalendarFeedTokenResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RevokeMyCalendarFeedToken401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewOwnData, nil)
+	if err != nil {
+		logger.Error("Failed to check permission", "error", err)
+		return api.RevokeMyCalendarFeedToken500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if !hasPermission {
+		return api.RevokeMyCalendarFeedToken403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	deleted, err := s.db.Queries().DeleteCalendarFeedToken(ctx, user.ID)
+	if err != nil {
+		logger.Error("Failed to revoke calendar feed token", "user_id", user.ID, "error", err)
+		return api.RevokeMyCalendarFeedToken500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+	if deleted == 0 {
+		return api.RevokeMyCalendarFeedToken404JSONResponse(NotFound("Calendar feed token").Create()), nil
+	}
+
+	return api.RevokeMyCalendarFeedToken204Response{}, nil
+}
+
+// serves the feed to calendar apps, which authenticate with the feed token
+// instead of a bearer token.
+func (s Server) GetMyBookingsCalendar(ctx context.Context, request api.GetMyBookingsCalendarRequestObject) (api.GetMyBookingsCalendarResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	userID, err := s.db.Queries().GetUserIDByCalendarFeedToken(ctx, hashFeedToken(request.Params.Token))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return api.GetMyBookingsCalendar401JSONResponse(Unauthorized("Unknown or revoked calendar feed token").Create()), nil
+		}
+		logger.Error("Failed to look up calendar feed token", "error", err)
+		return api.GetMyBookingsCalendar500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	bookings, err := s.db.Queries().ListCalendarBookings(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to list calendar bookings", "user_id", userID, "error", err)
+		return api.GetMyBookingsCalendar500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	var events []calendar.Event
+	for _, b := range bookings {
+		events = append(events, s.bookingEvents(calendarBooking{
+			ID:             b.ID,
+			ItemName:       b.ItemName,
+			Status:         b.Status,
+			PickUpDate:     b.PickUpDate,
+			PickUpLocation: b.PickUpLocation,
+			ReturnDate:     b.ReturnDate,
+			ReturnLocation: b.ReturnLocation,
+			SlotStart:      b.StartTime,
+			SlotEnd:        b.EndTime,
+		})...)
+	}
+
+	var buf bytes.Buffer
+	if err := calendar.WriteICS(&buf, "Equipment bookings", events); err != nil {
+		logger.Error("Failed to write calendar feed", "user_id", userID, "error", err)
+		return api.GetMyBookingsCalendar500JSONResponse(InternalError("An unexpected error occurred").Create()), nil
+	}
+
+	return api.GetMyBookingsCalendar200TextcalendarResponse{
+		Body:          &buf,
+		ContentLength: int64(buf.Len()),
+	}, nil
+}
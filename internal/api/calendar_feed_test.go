@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_CalendarFeedToken(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("create, rotate and revoke", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@feed.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewOwnData, nil, true, nil)
+		response, err := server.CreateMyCalendarFeedToken(ctx, api.CreateMyCalendarFeedTokenRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateMyCalendarFeedToken201JSONResponse{}, response)
+		first := response.(api.CreateMyCalendarFeedToken201JSONResponse)
+		assert.NotEmpty(t, first.Token)
+		assert.Equal(t, "/me/bookings.ics?token="+first.Token, first.FeedPath)
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewOwnData, nil, true, nil)
+		response, err = server.CreateMyCalendarFeedToken(ctx, api.CreateMyCalendarFeedTokenRequestObject{})
+		require.NoError(t, err)
+		second := response.(api.CreateMyCalendarFeedToken201JSONResponse)
+		assert.NotEqual(t, first.Token, second.Token)
+
+		// the old token stops working
+		feedResp, err := server.GetMyBookingsCalendar(context.Background(), api.GetMyBookingsCalendarRequestObject{
+			Params: api.GetMyBookingsCalendarParams{Token: first.Token},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetMyBookingsCalendar401JSONResponse{}, feedResp)
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewOwnData, nil, true, nil)
+		revokeResp, err := server.RevokeMyCalendarFeedToken(ctx, api.RevokeMyCalendarFeedTokenRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.RevokeMyCalendarFeedToken204Response{}, revokeResp)
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewOwnData, nil, true, nil)
+		revokeResp, err = server.RevokeMyCalendarFeedToken(ctx, api.RevokeMyCalendarFeedTokenRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.RevokeMyCalendarFeedToken404JSONResponse{}, revokeResp)
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@feed.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewOwnData, nil, false, nil)
+		response, err := server.CreateMyCalendarFeedToken(ctx, api.CreateMyCalendarFeedTokenRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateMyCalendarFeedToken403JSONResponse{}, response)
+	})
+}
+
+func TestServer_GetMyBookingsCalendar(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("feed lists pickups and returns", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		group := testDB.NewGroup(t).WithName("Feed Group").Create()
+		approver := testDB.NewUser(t).WithEmail("approver@feed.test").AsApprover().Create()
+		member := testDB.NewUser(t).WithEmail("member@feed.test").AsMember().Create()
+		item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+
+		availability := createTestAvailability(t, testDB, approver.ID)
+		confirmed := createTestBooking(t, testDB, availability.ID, member.ID, approver.ID, item.ID, group.ID,
+			db.RequestStatusConfirmed, 0)
+		createTestBooking(t, testDB, availability.ID, member.ID, approver.ID, item.ID, group.ID,
+			db.RequestStatusCancelled, 0)
+
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewOwnData, nil, true, nil)
+		tokenResp, err := server.CreateMyCalendarFeedToken(ctx, api.CreateMyCalendarFeedTokenRequestObject{})
+		require.NoError(t, err)
+		token := tokenResp.(api.CreateMyCalendarFeedToken201JSONResponse).Token
+
+		response, err := server.GetMyBookingsCalendar(context.Background(), api.GetMyBookingsCalendarRequestObject{
+			Params: api.GetMyBookingsCalendarParams{Token: token},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetMyBookingsCalendar200TextcalendarResponse{}, response)
+
+		body, err := io.ReadAll(response.(api.GetMyBookingsCalendar200TextcalendarResponse).Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "UID:booking-"+confirmed.ID.String()+"-pickup@cv-backend")
+		assert.Contains(t, string(body), "SUMMARY:Pick up Camera")
+		assert.Contains(t, string(body), "SUMMARY:Return Camera")
+		assert.Equal(t, 2, strings.Count(string(body), "BEGIN:VEVENT"), "cancelled bookings are left out")
+
+		// the test server's zone is UTC, so wall-clock times are written as is
+		assert.Contains(t, string(body), "DTSTART:"+confirmed.PickupDate.Format("20060102T150405")+"Z")
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		response, err := server.GetMyBookingsCalendar(context.Background(), api.GetMyBookingsCalendarRequestObject{
+			Params: api.GetMyBookingsCalendarParams{Token: "not-a-token"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetMyBookingsCalendar401JSONResponse{}, response)
+	})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_SetMyCalendarLink(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("link calendar queues a sync", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		approver := testDB.NewUser(t).WithEmail("approver@calendar.test").AsApprover().Create()
+		ctx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageTimeSlots, nil, true, nil)
+		response, err := server.SetMyCalendarLink(ctx, api.SetMyCalendarLinkRequestObject{
+			Body: &api.CalendarLinkRequest{IcsUrl: "webcal://calendar.example.com/classes.ics"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetMyCalendarLink200JSONResponse{}, response)
+		assert.Equal(t, "webcal://calendar.example.com/classes.ics", response.(api.SetMyCalendarLink200JSONResponse).IcsUrl)
+
+		pending, err := sharedQueue.Inspector.ListPendingTasks("default")
+		require.NoError(t, err)
+		found := false
+		for _, task := range pending {
+			if task.Type == queue.TypeCalendarSync {
+				found = true
+			}
+		}
+		assert.True(t, found, "expected a calendar sync task to be queued")
+
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageTimeSlots, nil, true, nil)
+		getResp, err := server.GetMyCalendarLink(ctx, api.GetMyCalendarLinkRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetMyCalendarLink200JSONResponse{}, getResp)
+	})
+
+	t.Run("invalid calendar URL", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		approver := testDB.NewUser(t).WithEmail("approver@calendar.test").AsApprover().Create()
+		ctx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageTimeSlots, nil, true, nil)
+		response, err := server.SetMyCalendarLink(ctx, api.SetMyCalendarLinkRequestObject{
+			Body: &api.CalendarLinkRequest{IcsUrl: "ftp://calendar.example.com/classes.ics"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetMyCalendarLink400JSONResponse{}, response)
+	})
+
+	t.Run("member cannot link calendar", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@calendar.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageTimeSlots, nil, false, nil)
+		response, err := server.SetMyCalendarLink(ctx, api.SetMyCalendarLinkRequestObject{
+			Body: &api.CalendarLinkRequest{IcsUrl: "https://calendar.example.com/classes.ics"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetMyCalendarLink403JSONResponse{}, response)
+	})
+}
+
+func TestServer_DeleteMyCalendarLink(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("no calendar linked", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		approver := testDB.NewUser(t).WithEmail("approver@calendar.test").AsApprover().Create()
+		ctx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageTimeSlots, nil, true, nil)
+		response, err := server.DeleteMyCalendarLink(ctx, api.DeleteMyCalendarLinkRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.DeleteMyCalendarLink404JSONResponse{}, response)
+	})
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+func convertToReturnCampaignResponse(c db.ReturnCampaign) api.ReturnCampaign {
+	response := api.ReturnCampaign{
+		Id:        c.ID,
+		Name:      c.Name,
+		TermEnd:   openapi_types.Date{Time: c.TermEnd.Time},
+		Status:    api.ReturnCampaignStatus(c.Status),
+		CreatedBy: c.CreatedBy,
+		CreatedAt: c.CreatedAt.Time,
+		ReportId:  c.ReportID,
+	}
+	if c.LastRunAt.Valid {
+		response.LastRunAt = &c.LastRunAt.Time
+	}
+	if c.CompletedAt.Valid {
+		response.CompletedAt = &c.CompletedAt.Time
+	}
+	return response
+}
+
+func (s Server) ListReturnCampaigns(ctx context.Context, request api.ListReturnCampaignsRequestObject) (api.ListReturnCampaignsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListReturnCampaigns401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageAllBookings, nil)
+	if err != nil {
+		logger.Error("Error checking manage_all_bookings permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageAllBookings,
+			"error", err)
+		return api.ListReturnCampaigns500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListReturnCampaigns403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	rows, err := s.db.Queries().ListReturnCampaigns(ctx, db.ListReturnCampaignsParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		logger.Error("Failed to list return campaigns", "error", err)
+		return api.ListReturnCampaigns500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountReturnCampaigns(ctx)
+	if err != nil {
+		logger.Error("Failed to count return campaigns", "error", err)
+		return api.ListReturnCampaigns500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	data := make([]api.ReturnCampaign, 0, len(rows))
+	for _, row := range rows {
+		data = append(data, convertToReturnCampaignResponse(row))
+	}
+
+	return api.ListReturnCampaigns200JSONResponse{
+		Data: data,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
+
+func (s Server) CreateReturnCampaign(ctx context.Context, request api.CreateReturnCampaignRequestObject) (api.CreateReturnCampaignResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateReturnCampaign401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageAllBookings, nil)
+	if err != nil {
+		logger.Error("Error checking manage_all_bookings permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageAllBookings,
+			"error", err)
+		return api.CreateReturnCampaign500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CreateReturnCampaign403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.CreateReturnCampaign400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	name := strings.TrimSpace(request.Body.Name)
+	if name == "" {
+		return api.CreateReturnCampaign400JSONResponse(ValidationErr("name is required", nil).Create()), nil
+	}
+	if request.Body.TermEnd.Time.Before(time.Now().Truncate(24 * time.Hour)) {
+		return api.CreateReturnCampaign400JSONResponse(ValidationErr("term_end must not be in the past", nil).Create()), nil
+	}
+
+	campaign, err := s.db.Queries().CreateReturnCampaign(ctx, db.CreateReturnCampaignParams{
+		Name:      name,
+		TermEnd:   pgtype.Date{Time: request.Body.TermEnd.Time, Valid: true},
+		CreatedBy: &user.ID,
+	})
+	if err != nil {
+		logger.Error("Failed to create return campaign", "error", err)
+		return api.CreateReturnCampaign500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if _, err := s.queue.Enqueue(ctx, queue.TypeCampaignRun, queue.CampaignRunPayload{CampaignID: &campaign.ID}); err != nil {
+		// the daily scheduled run still picks the campaign up
+		logger.Warn("Failed to enqueue return campaign run", "campaign_id", campaign.ID, "error", err)
+	}
+
+	logger.Info("Return campaign created", "campaign_id", campaign.ID, "term_end", request.Body.TermEnd.String(), "user_id", user.ID)
+	return api.CreateReturnCampaign201JSONResponse(convertToReturnCampaignResponse(campaign)), nil
+}
+
+func (s Server) RunReturnCampaign(ctx context.Context, request api.RunReturnCampaignRequestObject) (api.RunReturnCampaignResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RunReturnCampaign401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageAllBookings, nil)
+	if err != nil {
+		logger.Error("Error checking manage_all_bookings permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageAllBookings,
+			"error", err)
+		return api.RunReturnCampaign500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.RunReturnCampaign403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	campaign, err := s.db.Queries().GetReturnCampaignByID(ctx, request.Id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return api.RunReturnCampaign404JSONResponse(NotFound("Return campaign").Create()), nil
+		}
+		logger.Error("Failed to get return campaign", "campaign_id", request.Id, "error", err)
+		return api.RunReturnCampaign500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if campaign.Status != db.CampaignStatusActive {
+		return api.RunReturnCampaign409JSONResponse(ConflictErr("Return campaign is not active").Create()), nil
+	}
+
+	if _, err := s.queue.Enqueue(ctx, queue.TypeCampaignRun, queue.CampaignRunPayload{CampaignID: &campaign.ID}); err != nil {
+		logger.Error("Failed to enqueue return campaign run", "campaign_id", campaign.ID, "error", err)
+		return api.RunReturnCampaign500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Return campaign run requested", "campaign_id", campaign.ID, "user_id", user.ID)
+	return api.RunReturnCampaign202JSONResponse{Message: "Campaign run queued"}, nil
+}
+
+func (s Server) CancelReturnCampaign(ctx context.Context, request api.CancelReturnCampaignRequestObject) (api.CancelReturnCampaignResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CancelReturnCampaign401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageAllBookings, nil)
+	if err != nil {
+		logger.Error("Error checking manage_all_bookings permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageAllBookings,
+			"error", err)
+		return api.CancelReturnCampaign500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CancelReturnCampaign403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetReturnCampaignByID(ctx, request.Id); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.CancelReturnCampaign404JSONResponse(NotFound("Return campaign").Create()), nil
+		}
+		logger.Error("Failed to get return campaign", "campaign_id", request.Id, "error", err)
+		return api.CancelReturnCampaign500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	// only active campaigns match the update, so no rows means it already ended
+	campaign, err := s.db.Queries().CancelReturnCampaign(ctx, request.Id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return api.CancelReturnCampaign409JSONResponse(ConflictErr("Return campaign is not active").Create()), nil
+		}
+		logger.Error("Failed to cancel return campaign", "campaign_id", request.Id, "error", err)
+		return api.CancelReturnCampaign500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Return campaign cancelled", "campaign_id", campaign.ID, "user_id", user.ID)
+	return api.CancelReturnCampaign200JSONResponse(convertToReturnCampaignResponse(campaign)), nil
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_CreateReturnCampaign(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("campaign is created and queued", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@campaigns.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAllBookings, nil, true, nil)
+		response, err := server.CreateReturnCampaign(ctx, api.CreateReturnCampaignRequestObject{
+			Body: &api.CreateReturnCampaignRequest{
+				Name:    "Fall term",
+				TermEnd: openapi_types.Date{Time: time.Now().AddDate(0, 0, 30)},
+			},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateReturnCampaign201JSONResponse{}, response)
+
+		campaign := response.(api.CreateReturnCampaign201JSONResponse)
+		assert.Equal(t, api.ReturnCampaignStatus("active"), campaign.Status)
+
+		pending, err := sharedQueue.Inspector.ListPendingTasks("default")
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, queue.TypeCampaignRun, pending[0].Type)
+
+		var payload queue.CampaignRunPayload
+		require.NoError(t, json.Unmarshal(pending[0].Payload, &payload))
+		require.NotNil(t, payload.CampaignID)
+		assert.Equal(t, campaign.Id, *payload.CampaignID)
+	})
+
+	t.Run("term end in the past is rejected", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@campaigns.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAllBookings, nil, true, nil)
+		response, err := server.CreateReturnCampaign(ctx, api.CreateReturnCampaignRequestObject{
+			Body: &api.CreateReturnCampaignRequest{
+				Name:    "Last term",
+				TermEnd: openapi_types.Date{Time: time.Now().AddDate(0, 0, -3)},
+			},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateReturnCampaign400JSONResponse{}, response)
+	})
+
+	t.Run("member cannot create campaigns", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@campaigns.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageAllBookings, nil, false, nil)
+		response, err := server.CreateReturnCampaign(ctx, api.CreateReturnCampaignRequestObject{
+			Body: &api.CreateReturnCampaignRequest{
+				Name:    "Fall term",
+				TermEnd: openapi_types.Date{Time: time.Now().AddDate(0, 0, 30)},
+			},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateReturnCampaign403JSONResponse{}, response)
+	})
+}
+
+func TestServer_CancelReturnCampaign(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@campaigns.test").AsGlobalAdmin().Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	campaign, err := testDB.Queries().CreateReturnCampaign(context.Background(), db.CreateReturnCampaignParams{
+		Name:      "Winter term",
+		TermEnd:   pgtype.Date{Time: time.Now().AddDate(0, 0, 30), Valid: true},
+		CreatedBy: &admin.ID,
+	})
+	require.NoError(t, err)
+
+	mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAllBookings, nil, true, nil)
+	response, err := server.CancelReturnCampaign(ctx, api.CancelReturnCampaignRequestObject{Id: campaign.ID})
+	require.NoError(t, err)
+	require.IsType(t, api.CancelReturnCampaign200JSONResponse{}, response)
+	assert.Equal(t, api.ReturnCampaignStatus("cancelled"), response.(api.CancelReturnCampaign200JSONResponse).Status)
+
+	mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAllBookings, nil, true, nil)
+	response, err = server.CancelReturnCampaign(ctx, api.CancelReturnCampaignRequestObject{Id: campaign.ID})
+	require.NoError(t, err)
+	require.IsType(t, api.CancelReturnCampaign409JSONResponse{}, response)
+
+	mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAllBookings, nil, true, nil)
+	runResp, err := server.RunReturnCampaign(ctx, api.RunReturnCampaignRequestObject{Id: campaign.ID})
+	require.NoError(t, err)
+	require.IsType(t, api.RunReturnCampaign409JSONResponse{}, runResp)
+
+	mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAllBookings, nil, true, nil)
+	response, err = server.CancelReturnCampaign(ctx, api.CancelReturnCampaignRequestObject{Id: uuid.New()})
+	require.NoError(t, err)
+	require.IsType(t, api.CancelReturnCampaign404JSONResponse{}, response)
+}
@@ -33,6 +33,13 @@ func (s Server) AddToCart(ctx context.Context, request api.AddToCartRequestObjec
 	if err != nil {
 		return api.AddToCart500JSONResponse(InternalError("Internal server error").Create()), nil
 	}
+	available, err := itemAvailableToGroup(ctx, s.db.Queries(), item, request.Body.GroupId)
+	if err != nil {
+		return api.AddToCart500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !available {
+		return api.AddToCart403JSONResponse(itemNotForGroupErr().Create()), nil
+	}
 
 	// Check if quantity is valid
 	if request.Body.Quantity <= 0 {
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+
+	"github.com/USSTM/cv-backend/internal/catalog"
+	"github.com/USSTM/cv-backend/internal/middleware"
+)
+
+// fills dest with an item listing, through the catalogue cache when there is
+// one. params must hold everything that picks the listing's results, the
+// viewer included, since it becomes the cache key.
+func (s Server) cachedListing(ctx context.Context, name string, params any, dest any, load func() error) error {
+	if s.catalogCache == nil {
+		return load()
+	}
+	key, err := catalog.Key(name, params)
+	if err != nil {
+		return err
+	}
+	return s.catalogCache.Load(ctx, key, dest, load)
+}
+
+// drops the cached item listings once an item change is committed. Failing
+// only leaves listings stale until their entries expire, so it is logged
+// rather than failing the change.
+func (s Server) invalidateCatalog(ctx context.Context) {
+	if s.catalogCache == nil {
+		return
+	}
+	if err := s.catalogCache.Invalidate(ctx); err != nil {
+		middleware.GetLoggerFromContext(ctx).Warn("Failed to invalidate catalogue cache", "error", err)
+	}
+}
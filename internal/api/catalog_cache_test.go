@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/catalog"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_CatalogCache(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	server.catalogCache = catalog.NewCache(sharedQueue.Redis, time.Minute)
+
+	admin := testDB.NewUser(t).WithEmail("admin@cache.test").AsGlobalAdmin().Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+	tripod := testDB.NewItem(t).WithName("Tripod").WithType("medium").WithStock(4).Create()
+
+	list := func(t *testing.T) api.PaginatedItemResponse {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewItems, nil, true, nil)
+		resp, err := server.GetItems(ctx, api.GetItemsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItems200JSONResponse{}, resp)
+		listed := resp.(api.GetItems200JSONResponse)
+		assert.Equal(t, listed.Body.Meta.Total, listed.Headers.XTotalCount)
+		return listed.Body
+	}
+
+	t.Run("repeat listings come from the cache", func(t *testing.T) {
+		require.Len(t, list(t).Data, 1)
+
+		// written straight to the database, so nothing drops the cache
+		testDB.NewItem(t).WithName("Light Stand").WithType("medium").WithStock(2).Create()
+
+		cached := list(t)
+		require.Len(t, cached.Data, 1)
+		assert.Equal(t, "Tripod", cached.Data[0].Name)
+	})
+
+	t.Run("an item change drops the cache", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.PatchItem(ctx, api.PatchItemRequestObject{
+			Id:   tripod.ID,
+			Body: &api.PatchItemJSONRequestBody{Stock: 9},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.PatchItem200JSONResponse{}, resp)
+
+		listed := list(t)
+		require.Len(t, listed.Data, 2)
+		for _, item := range listed.Data {
+			if item.Id == tripod.ID {
+				assert.Equal(t, 9, item.Stock)
+			}
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/catalog"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s Server) ListCategories(ctx context.Context, request api.ListCategoriesRequestObject) (api.ListCategoriesResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListCategories401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ViewItems permission", "error", err)
+		return api.ListCategories500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListCategories403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	categories, err := s.db.Queries().ListCategories(ctx)
+	if err != nil {
+		logger.Error("Failed to list categories", "error", err)
+		return api.ListCategories500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := make(api.ListCategories200JSONResponse, 0, len(categories))
+	for _, category := range categories {
+		response = append(response, toCategoryResponse(category))
+	}
+	return response, nil
+}
+
+func (s Server) CreateCategory(ctx context.Context, request api.CreateCategoryRequestObject) (api.CreateCategoryResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateCategory401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.CreateCategory500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CreateCategory403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.CreateCategory400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	slug := request.Body.Slug
+	name := strings.TrimSpace(request.Body.Name)
+	if !catalog.ValidSlug(slug) {
+		return api.CreateCategory400JSONResponse(ValidationErr("slug must be lowercase letters, digits and hyphens", nil).Create()), nil
+	}
+	if name == "" {
+		return api.CreateCategory400JSONResponse(ValidationErr("name is required", nil).Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetCategoryBySlug(ctx, slug); err == nil {
+		return api.CreateCategory409JSONResponse(ConflictErr("A category with this slug already exists").Create()), nil
+	} else if err != pgx.ErrNoRows {
+		logger.Error("Failed to get category", "slug", slug, "error", err)
+		return api.CreateCategory500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	category, err := s.db.Queries().CreateCategory(ctx, db.CreateCategoryParams{Slug: slug, Name: name})
+	if err != nil {
+		logger.Error("Failed to create category", "slug", slug, "error", err)
+		return api.CreateCategory500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Category created", "category_id", category.ID, "slug", category.Slug, "user_id", user.ID)
+	return api.CreateCategory201JSONResponse(toCategoryResponse(category)), nil
+}
+
+// the category and tags to give an item, checked before anything is written.
+// A nil field leaves that label as it is.
+type itemLabels struct {
+	category      *db.Category
+	clearCategory bool
+	tags          *[]string
+}
+
+// validates the category slug and tags from an item request. When replace is
+// set (a full update) a missing category or tag list clears the item's labels.
+func (s Server) resolveItemLabels(ctx context.Context, category *string, tags *[]string, replace bool) (itemLabels, string, error) {
+	var labels itemLabels
+
+	if category != nil && *category != "" {
+		found, err := s.db.Queries().GetCategoryBySlug(ctx, *category)
+		if err == pgx.ErrNoRows {
+			return labels, "unknown category: " + *category, nil
+		}
+		if err != nil {
+			return labels, "", err
+		}
+		labels.category = &found
+	} else if category != nil || replace {
+		labels.clearCategory = true
+	}
+
+	if tags != nil {
+		normalized, err := catalog.NormalizeTags(*tags)
+		if err != nil {
+			return labels, err.Error(), nil
+		}
+		labels.tags = &normalized
+	} else if replace {
+		labels.tags = &[]string{}
+	}
+
+	return labels, "", nil
+}
+
+func applyItemLabels(ctx context.Context, qtx *db.Queries, itemID uuid.UUID, labels itemLabels) error {
+	if labels.category != nil {
+		if err := qtx.SetItemCategory(ctx, db.SetItemCategoryParams{ItemID: itemID, CategoryID: labels.category.ID}); err != nil {
+			return err
+		}
+	} else if labels.clearCategory {
+		if err := qtx.ClearItemCategory(ctx, itemID); err != nil {
+			return err
+		}
+	}
+
+	if labels.tags != nil {
+		if err := qtx.ClearItemTags(ctx, itemID); err != nil {
+			return err
+		}
+		for _, name := range *labels.tags {
+			tag, err := qtx.UpsertTag(ctx, name)
+			if err != nil {
+				return err
+			}
+			if err := qtx.AddItemTag(ctx, db.AddItemTagParams{ItemID: itemID, TagID: tag.ID}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fills in the category and tags of each item with one query for each.
+func (s Server) attachItemLabels(ctx context.Context, items []api.ItemResponse) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.Id)
+	}
+
+	categories, err := s.db.Queries().ListCategoriesForItems(ctx, ids)
+	if err != nil {
+		return err
+	}
+	categoryByItem := make(map[uuid.UUID]string, len(categories))
+	for _, c := range categories {
+		categoryByItem[c.ItemID] = c.Slug
+	}
+
+	tags, err := s.db.Queries().ListTagsForItems(ctx, ids)
+	if err != nil {
+		return err
+	}
+	tagsByItem := make(map[uuid.UUID][]string, len(items))
+	for _, t := range tags {
+		tagsByItem[t.ItemID] = append(tagsByItem[t.ItemID], t.Name)
+	}
+
+	for i := range items {
+		if slug, ok := categoryByItem[items[i].Id]; ok {
+			items[i].Category = &slug
+		}
+		itemTags := tagsByItem[items[i].Id]
+		if itemTags == nil {
+			itemTags = []string{}
+		}
+		items[i].Tags = &itemTags
+	}
+	return nil
+}
+
+func toCategoryResponse(category db.Category) api.Category {
+	return api.Category{
+		Id:   category.ID,
+		Slug: category.Slug,
+		Name: category.Name,
+	}
+}
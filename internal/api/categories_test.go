@@ -0,0 +1,202 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Categories(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@categories.ca").AsGlobalAdmin().Create()
+	member := testDB.NewUser(t).WithEmail("member@categories.ca").AsMember().Create()
+	adminCtx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+	memberCtx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+	t.Run("admin creates a category", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.CreateCategory(adminCtx, api.CreateCategoryRequestObject{
+			Body: &api.CreateCategoryRequest{Slug: "av", Name: "Audio & Visual"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateCategory201JSONResponse{}, response)
+
+		category := response.(api.CreateCategory201JSONResponse)
+		assert.Equal(t, "av", category.Slug)
+		assert.Equal(t, "Audio & Visual", category.Name)
+	})
+
+	t.Run("duplicate slug", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.CreateCategory(adminCtx, api.CreateCategoryRequestObject{
+			Body: &api.CreateCategoryRequest{Slug: "av", Name: "AV again"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateCategory409JSONResponse{}, response)
+	})
+
+	t.Run("invalid slug", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.CreateCategory(adminCtx, api.CreateCategoryRequestObject{
+			Body: &api.CreateCategoryRequest{Slug: "Lab Kit", Name: "Lab kit"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateCategory400JSONResponse{}, response)
+	})
+
+	t.Run("member cannot create categories", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageItems, nil, false, nil)
+		response, err := server.CreateCategory(memberCtx, api.CreateCategoryRequestObject{
+			Body: &api.CreateCategoryRequest{Slug: "tools", Name: "Tools"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateCategory403JSONResponse{}, response)
+	})
+
+	t.Run("member lists categories", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewItems, nil, true, nil)
+		response, err := server.ListCategories(memberCtx, api.ListCategoriesRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.ListCategories200JSONResponse{}, response)
+
+		categories := response.(api.ListCategories200JSONResponse)
+		require.Len(t, categories, 1)
+		assert.Equal(t, "av", categories[0].Slug)
+	})
+}
+
+func TestServer_ItemCategoriesAndTags(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@labels.ca").AsGlobalAdmin().Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+	_, err := server.CreateCategory(ctx, api.CreateCategoryRequestObject{
+		Body: &api.CreateCategoryRequest{Slug: "av", Name: "Audio & Visual"},
+	})
+	require.NoError(t, err)
+
+	createItem := func(t *testing.T, name string, category *string, tags []string) api.CreateItem201JSONResponse {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.CreateItem(ctx, api.CreateItemRequestObject{
+			Body: &api.CreateItemJSONRequestBody{
+				Name:     name,
+				Type:     "medium",
+				Stock:    2,
+				Category: category,
+				Tags:     &tags,
+			},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateItem201JSONResponse{}, response)
+		return response.(api.CreateItem201JSONResponse)
+	}
+
+	av := "av"
+	camera := createItem(t, "Camera", &av, []string{"Camera", "tripod", "camera"})
+	createItem(t, "Microphone", &av, []string{"audio"})
+	createItem(t, "Tripod", nil, []string{"tripod"})
+
+	t.Run("create normalizes tags", func(t *testing.T) {
+		assert.Equal(t, &av, camera.Category)
+		assert.Equal(t, []string{"camera", "tripod"}, *camera.Tags)
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		unknown := "furniture"
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.CreateItem(ctx, api.CreateItemRequestObject{
+			Body: &api.CreateItemJSONRequestBody{Name: "Desk", Type: "high", Stock: 1, Category: &unknown},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateItem400JSONResponse{}, response)
+	})
+
+	t.Run("filter by category", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewItems, nil, true, nil)
+		response, err := server.GetItems(ctx, api.GetItemsRequestObject{
+			Params: api.GetItemsParams{Category: &av},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItems200JSONResponse{}, response)
+
+		items := response.(api.GetItems200JSONResponse).Body
+		require.Len(t, items.Data, 2)
+		assert.Equal(t, "Camera", items.Data[0].Name)
+		assert.Equal(t, "Microphone", items.Data[1].Name)
+		assert.Equal(t, &av, items.Data[0].Category)
+		assert.Equal(t, 2, items.Meta.Total)
+	})
+
+	t.Run("filter by every tag", func(t *testing.T) {
+		tags := "camera,Tripod"
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewItems, nil, true, nil)
+		response, err := server.GetItems(ctx, api.GetItemsRequestObject{
+			Params: api.GetItemsParams{Tags: &tags},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItems200JSONResponse{}, response)
+
+		items := response.(api.GetItems200JSONResponse).Body
+		require.Len(t, items.Data, 1)
+		assert.Equal(t, camera.Id, items.Data[0].Id)
+	})
+
+	t.Run("filter by category and tag", func(t *testing.T) {
+		tags := "tripod"
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewItems, nil, true, nil)
+		response, err := server.GetItems(ctx, api.GetItemsRequestObject{
+			Params: api.GetItemsParams{Category: &av, Tags: &tags},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItems200JSONResponse{}, response)
+
+		items := response.(api.GetItems200JSONResponse).Body
+		require.Len(t, items.Data, 1)
+		assert.Equal(t, "Camera", items.Data[0].Name)
+	})
+
+	t.Run("full update without labels clears them", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.UpdateItem(ctx, api.UpdateItemRequestObject{
+			Id:   camera.Id,
+			Body: &api.UpdateItemJSONRequestBody{Name: "Camera", Type: "medium", Stock: 2},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.UpdateItem200JSONResponse{}, response)
+
+		item := response.(api.UpdateItem200JSONResponse).Body
+		assert.Nil(t, item.Category)
+		assert.Empty(t, *item.Tags)
+	})
+
+	t.Run("patch only touches the labels it is given", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.PatchItem(ctx, api.PatchItemRequestObject{
+			Id:   camera.Id,
+			Body: &api.PatchItemJSONRequestBody{Category: &av},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.PatchItem200JSONResponse{}, response)
+
+		item := response.(api.PatchItem200JSONResponse).Body
+		assert.Equal(t, &av, item.Category)
+		assert.Empty(t, *item.Tags)
+	})
+}
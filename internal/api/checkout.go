@@ -7,6 +7,7 @@ import (
 	"github.com/USSTM/cv-backend/generated/api"
 	"github.com/USSTM/cv-backend/generated/db"
 	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/events"
 	"github.com/USSTM/cv-backend/internal/middleware"
 	"github.com/USSTM/cv-backend/internal/rbac"
 	"github.com/google/uuid"
@@ -42,6 +43,15 @@ func (s Server) CheckoutCart(ctx context.Context, request api.CheckoutCartReques
 		return api.CheckoutCart403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
 	}
 
+	blocked, unpaid, err := s.blockedByFines(ctx, user.ID)
+	if err != nil {
+		logger.Error("Failed to check unpaid fines", "user_id", user.ID, "error", err)
+		return api.CheckoutCart500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if blocked {
+		return api.CheckoutCart403JSONResponse(finesBlockedErr(unpaid).Create()), nil
+	}
+
 	// transaction
 	tx, err := s.db.Pool().Begin(ctx)
 	if err != nil {
@@ -81,6 +91,15 @@ func (s Server) CheckoutCart(ctx context.Context, request api.CheckoutCartReques
 
 	// Process each cart item based on type
 	for _, cartItem := range cartItems {
+		if !cartItem.AvailableToGroup {
+			itemName := cartItem.Name
+			result.Errors = append(result.Errors, api.CheckoutError{
+				ItemId:   cartItem.ItemID,
+				ItemName: &itemName,
+				Message:  "item belongs to another group",
+			})
+			continue
+		}
 		switch cartItem.Type {
 		case db.ItemTypeLow:
 			err := s.processLowItem(ctx, qtx, cartItem, request.Body.GroupId, user.ID, &result)
@@ -146,6 +165,15 @@ func (s Server) CheckoutCart(ctx context.Context, request api.CheckoutCartReques
 		return api.CheckoutCart500JSONResponse(InternalError("Failed to commit transaction").Create()), nil
 	}
 
+	s.publishCheckoutStock(ctx, result)
+
+	for _, requested := range result.HighItemsRequested {
+		s.notifyRequestSubmitted(ctx, user, *requested.RequestId, requested.ItemId, requested.ItemName,
+			db.ItemTypeHigh, request.Body.GroupId, requested.Quantity)
+		s.publishEvent(ctx, events.Event{Type: events.RequestPending, EntityID: *requested.RequestId,
+			GroupID: &request.Body.GroupId, ItemID: &requested.ItemId, UserID: &user.ID})
+	}
+
 	return api.CheckoutCart200JSONResponse{
 		LowItemsProcessed:   result.LowItemsProcessed,
 		MediumItemsBorrowed: result.MediumItemsBorrowed,
@@ -164,15 +192,6 @@ func (s Server) processLowItem(ctx context.Context, qtx *db.Queries, cartItem db
 			cartItem.Quantity, cartItem.Stock)
 	}
 
-	// Decrement
-	err := qtx.DecrementStockForLowItem(ctx, db.DecrementStockForLowItemParams{
-		ID:    cartItem.ItemID,
-		Stock: cartItem.Quantity,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to decrement stock: %w", err)
-	}
-
 	// audit trail
 	taking, err := qtx.RecordItemTaking(ctx, db.RecordItemTakingParams{
 		UserID:   userID,
@@ -184,6 +203,18 @@ func (s Server) processLowItem(ctx context.Context, qtx *db.Queries, cartItem db
 		return fmt.Errorf("failed to record taking: %w", err)
 	}
 
+	// Decrement
+	if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindTake, ActorID: &userID, ReferenceID: &taking.ID}); err != nil {
+		return err
+	}
+	err = qtx.DecrementStockForLowItem(ctx, db.DecrementStockForLowItemParams{
+		ID:    cartItem.ItemID,
+		Stock: cartItem.Quantity,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to decrement stock: %w", err)
+	}
+
 	result.LowItemsProcessed = append(result.LowItemsProcessed, api.CheckoutItemResult{
 		ItemId:   cartItem.ItemID,
 		ItemName: cartItem.Name,
@@ -195,6 +226,16 @@ func (s Server) processLowItem(ctx context.Context, qtx *db.Queries, cartItem db
 	return nil
 }
 
+// the items a checkout took from the shelf
+func (s Server) publishCheckoutStock(ctx context.Context, result CheckoutResult) {
+	for _, taken := range result.LowItemsProcessed {
+		s.publishStockChanged(ctx, taken.ItemId)
+	}
+	for _, borrowed := range result.MediumItemsBorrowed {
+		s.publishStockChanged(ctx, borrowed.ItemId)
+	}
+}
+
 // borrowing record + decrement stock
 func (s Server) processMediumItem(ctx context.Context, qtx *db.Queries, cartItem db.GetCartItemsForCheckoutRow,
 	requestBody *api.CheckoutCartJSONRequestBody, userID uuid.UUID, result *CheckoutResult) error {
@@ -205,6 +246,12 @@ func (s Server) processMediumItem(ctx context.Context, qtx *db.Queries, cartItem
 			cartItem.Quantity, cartItem.Stock)
 	}
 
+	// items tracked by unit hand out the first one on the shelf
+	unitID, err := pickItemUnit(ctx, qtx, cartItem.ItemID, nil, cartItem.Quantity)
+	if err != nil {
+		return err
+	}
+
 	// Create record
 	borrowing, err := qtx.BorrowItem(ctx, db.BorrowItemParams{
 		UserID:             &userID,
@@ -214,12 +261,16 @@ func (s Server) processMediumItem(ctx context.Context, qtx *db.Queries, cartItem
 		DueDate:            pgtype.Timestamp{Time: requestBody.DueDate, Valid: true},
 		BeforeCondition:    db.Condition(requestBody.BeforeCondition),
 		BeforeConditionUrl: requestBody.BeforeConditionUrl,
+		UnitID:             unitID,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create borrowing: %w", err)
 	}
 
 	// Decrement
+	if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindBorrow, ActorID: &userID, ReferenceID: &borrowing.ID}); err != nil {
+		return err
+	}
 	err = qtx.DecrementItemStock(ctx, db.DecrementItemStockParams{
 		ID:    cartItem.ItemID,
 		Stock: cartItem.Quantity,
@@ -227,6 +278,9 @@ func (s Server) processMediumItem(ctx context.Context, qtx *db.Queries, cartItem
 	if err != nil {
 		return fmt.Errorf("failed to decrement stock: %w", err)
 	}
+	if err := holdItemUnit(ctx, qtx, unitID, db.UnitStatusBorrowed); err != nil {
+		return fmt.Errorf("failed to hold unit: %w", err)
+	}
 
 	result.MediumItemsBorrowed = append(result.MediumItemsBorrowed, api.CheckoutItemResult{
 		ItemId:      cartItem.ItemID,
@@ -264,3 +318,168 @@ func (s Server) processHighItem(ctx context.Context, qtx *db.Queries, cartItem d
 
 	return nil
 }
+
+// all-or-nothing checkout of the group's cart: every line's stock is checked
+// under the row locks before anything is written, and any failure rolls the
+// whole checkout back.
+func (s Server) CheckoutGroupCart(ctx context.Context, request api.CheckoutGroupCartRequestObject) (api.CheckoutGroupCartResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CheckoutGroupCart401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+	if request.Body == nil {
+		return api.CheckoutGroupCart400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.RequestItems, &request.GroupId)
+	if err != nil {
+		logger.Error("Failed to check request_items permission",
+			"user_id", user.ID,
+			"group_id", request.GroupId,
+			"permission", rbac.RequestItems,
+			"error", err)
+		return api.CheckoutGroupCart500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CheckoutGroupCart403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	blocked, unpaid, err := s.blockedByFines(ctx, user.ID)
+	if err != nil {
+		logger.Error("Failed to check unpaid fines", "user_id", user.ID, "error", err)
+		return api.CheckoutGroupCart500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if blocked {
+		return api.CheckoutGroupCart403JSONResponse(finesBlockedErr(unpaid).Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin checkout transaction",
+			"user_id", user.ID,
+			"group_id", request.GroupId,
+			"error", err)
+		return api.CheckoutGroupCart500JSONResponse(InternalError("Failed to start transaction").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	cartItems, err := qtx.GetCartItemsForCheckout(ctx, db.GetCartItemsForCheckoutParams{
+		GroupID: request.GroupId,
+		UserID:  user.ID,
+	})
+	if err != nil {
+		logger.Error("Failed to get cart items for checkout",
+			"user_id", user.ID,
+			"group_id", request.GroupId,
+			"error", err)
+		return api.CheckoutGroupCart500JSONResponse(InternalError("Failed to get cart items").Create()), nil
+	}
+	if len(cartItems) == 0 {
+		return api.CheckoutGroupCart400JSONResponse(ValidationErr("Cart is empty", nil).Create()), nil
+	}
+
+	// validate every line before touching anything
+	var short, notForGroup []ErrorDetail
+	hasMedium := false
+	for _, cartItem := range cartItems {
+		if !cartItem.AvailableToGroup {
+			notForGroup = append(notForGroup, ErrorDetail{
+				Field:   cartItem.ItemID.String(),
+				Message: cartItem.Name + ": belongs to another group",
+			})
+		}
+		if cartItem.Stock < cartItem.Quantity {
+			short = append(short, ErrorDetail{
+				Field: cartItem.ItemID.String(),
+				Message: fmt.Sprintf("%s: insufficient stock (requested: %d, available: %d)",
+					cartItem.Name, cartItem.Quantity, cartItem.Stock),
+			})
+		}
+		if cartItem.Type == db.ItemTypeMedium {
+			hasMedium = true
+		}
+	}
+	if len(notForGroup) > 0 {
+		return api.CheckoutGroupCart403JSONResponse(
+			itemNotForGroupErr().WithDetails(notForGroup).Create()), nil
+	}
+	if len(short) > 0 {
+		return api.CheckoutGroupCart409JSONResponse(
+			NewError(CodeInsufficientStock, "Insufficient stock for some cart items").WithDetails(short).Create()), nil
+	}
+
+	borrowing := &api.CheckoutCartJSONRequestBody{GroupId: request.GroupId}
+	if hasMedium {
+		if request.Body.DueDate == nil || request.Body.BeforeCondition == nil || request.Body.BeforeConditionUrl == nil {
+			return api.CheckoutGroupCart400JSONResponse(ValidationErr("dueDate, beforeCondition and beforeConditionUrl are required to borrow medium items", nil).Create()), nil
+		}
+		borrowing.DueDate = *request.Body.DueDate
+		borrowing.BeforeCondition = api.CheckoutCartRequestBeforeCondition(*request.Body.BeforeCondition)
+		borrowing.BeforeConditionUrl = *request.Body.BeforeConditionUrl
+	}
+
+	result := CheckoutResult{
+		LowItemsProcessed:   []api.CheckoutItemResult{},
+		MediumItemsBorrowed: []api.CheckoutItemResult{},
+		HighItemsRequested:  []api.CheckoutItemResult{},
+		Errors:              []api.CheckoutError{},
+	}
+
+	for _, cartItem := range cartItems {
+		var err error
+		switch cartItem.Type {
+		case db.ItemTypeLow:
+			err = s.processLowItem(ctx, qtx, cartItem, request.GroupId, user.ID, &result)
+		case db.ItemTypeMedium:
+			err = s.processMediumItem(ctx, qtx, cartItem, borrowing, user.ID, &result)
+		case db.ItemTypeHigh:
+			err = s.processHighItem(ctx, qtx, cartItem, request.GroupId, user.ID, &result)
+		}
+		if err != nil {
+			logger.Error("Failed to check out cart item",
+				"item_id", cartItem.ItemID,
+				"item_type", cartItem.Type,
+				"user_id", user.ID,
+				"error", err)
+			return api.CheckoutGroupCart500JSONResponse(InternalError("Failed to check out cart").Create()), nil
+		}
+	}
+
+	if err := qtx.ClearCart(ctx, db.ClearCartParams{
+		GroupID: request.GroupId,
+		UserID:  user.ID,
+	}); err != nil {
+		logger.Error("Failed to clear cart", "user_id", user.ID, "group_id", request.GroupId, "error", err)
+		return api.CheckoutGroupCart500JSONResponse(InternalError("Failed to clear cart").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit checkout", "user_id", user.ID, "group_id", request.GroupId, "error", err)
+		return api.CheckoutGroupCart500JSONResponse(InternalError("Failed to commit transaction").Create()), nil
+	}
+
+	s.publishCheckoutStock(ctx, result)
+
+	for _, requested := range result.HighItemsRequested {
+		s.notifyRequestSubmitted(ctx, user, *requested.RequestId, requested.ItemId, requested.ItemName,
+			db.ItemTypeHigh, request.GroupId, requested.Quantity)
+		s.publishEvent(ctx, events.Event{Type: events.RequestPending, EntityID: *requested.RequestId,
+			GroupID: &request.GroupId, ItemID: &requested.ItemId, UserID: &user.ID})
+	}
+
+	logger.Info("Cart checked out",
+		"user_id", user.ID,
+		"group_id", request.GroupId,
+		"lines", len(cartItems))
+
+	return api.CheckoutGroupCart200JSONResponse{
+		LowItemsProcessed:   result.LowItemsProcessed,
+		MediumItemsBorrowed: result.MediumItemsBorrowed,
+		HighItemsRequested:  result.HighItemsRequested,
+		Errors:              result.Errors,
+	}, nil
+}
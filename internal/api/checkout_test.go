@@ -595,3 +595,142 @@ func TestServer_CheckoutCart(t *testing.T) {
 		assert.Len(t, cart, 0)
 	})
 }
+
+func TestServer_CheckoutGroupCart(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	// fills a fresh member's cart with one low, one medium and one high item
+	setup := func(t *testing.T, email string) (*testutil.TestUser, *testutil.TestGroup, []*testutil.TestItem, context.Context) {
+		t.Helper()
+		user := testDB.NewUser(t).WithEmail(email).AsMember().Create()
+		group := testDB.NewGroup(t).WithName("Checkout " + email).Create()
+		testDB.AssignUserToGroup(t, user.ID, group.ID, "member")
+
+		items := []*testutil.TestItem{
+			testDB.NewItem(t).WithName("Batteries").WithType("low").WithStock(20).Create(),
+			testDB.NewItem(t).WithName("Tripod").WithType("medium").WithStock(4).Create(),
+			testDB.NewItem(t).WithName("Drone").WithType("high").WithStock(2).Create(),
+		}
+
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+		for _, item := range items {
+			mockAuth.ExpectCheckPermission(user.ID, rbac.ManageCart, &group.ID, true, nil)
+			_, err := server.AddToCart(ctx, api.AddToCartRequestObject{
+				GroupId: group.ID,
+				Body: &api.AddToCartJSONRequestBody{
+					GroupId:  group.ID,
+					ItemId:   item.ID,
+					Quantity: 2,
+				},
+			})
+			require.NoError(t, err)
+		}
+		return user, group, items, ctx
+	}
+
+	dueDate := time.Now().Add(7 * 24 * time.Hour)
+	condition := api.CartCheckoutRequestBeforeConditionGood
+	conditionURL := "http://example.com/before.jpg"
+	borrowing := &api.CheckoutGroupCartJSONRequestBody{
+		DueDate:            &dueDate,
+		BeforeCondition:    &condition,
+		BeforeConditionUrl: &conditionURL,
+	}
+
+	t.Run("checks out every line", func(t *testing.T) {
+		user, group, items, ctx := setup(t, "all@checkout.ca")
+
+		mockAuth.ExpectCheckPermission(user.ID, rbac.RequestItems, &group.ID, true, nil)
+		response, err := server.CheckoutGroupCart(ctx, api.CheckoutGroupCartRequestObject{GroupId: group.ID, Body: borrowing})
+		require.NoError(t, err)
+		require.IsType(t, api.CheckoutGroupCart200JSONResponse{}, response)
+
+		checkoutResp := response.(api.CheckoutGroupCart200JSONResponse)
+		require.Len(t, checkoutResp.LowItemsProcessed, 1)
+		assert.NotNil(t, checkoutResp.LowItemsProcessed[0].TakingId)
+		require.Len(t, checkoutResp.MediumItemsBorrowed, 1)
+		assert.NotNil(t, checkoutResp.MediumItemsBorrowed[0].BorrowingId)
+		require.Len(t, checkoutResp.HighItemsRequested, 1)
+		assert.NotNil(t, checkoutResp.HighItemsRequested[0].RequestId)
+		assert.Empty(t, checkoutResp.Errors)
+
+		low, err := testDB.Queries().GetItemByID(ctx, items[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(18), low.Stock)
+		medium, err := testDB.Queries().GetItemByID(ctx, items[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), medium.Stock)
+		high, err := testDB.Queries().GetItemByID(ctx, items[2].ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), high.Stock, "high items are decremented on approval")
+
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ManageCart, &group.ID, true, nil)
+		cartResp, err := server.GetCart(ctx, api.GetCartRequestObject{GroupId: group.ID})
+		require.NoError(t, err)
+		assert.Empty(t, cartResp.(api.GetCart200JSONResponse))
+	})
+
+	t.Run("one short line checks out nothing", func(t *testing.T) {
+		user, group, items, ctx := setup(t, "short@checkout.ca")
+
+		_, err := testDB.Pool().Exec(ctx, `UPDATE items SET stock = 1 WHERE id = $1`, items[1].ID)
+		require.NoError(t, err)
+
+		mockAuth.ExpectCheckPermission(user.ID, rbac.RequestItems, &group.ID, true, nil)
+		response, err := server.CheckoutGroupCart(ctx, api.CheckoutGroupCartRequestObject{GroupId: group.ID, Body: borrowing})
+		require.NoError(t, err)
+		require.IsType(t, api.CheckoutGroupCart409JSONResponse{}, response)
+
+		errResp := response.(api.CheckoutGroupCart409JSONResponse)
+		assert.Equal(t, api.INSUFFICIENTSTOCK, errResp.Error.Code)
+		require.NotNil(t, errResp.Error.Details)
+		require.Len(t, *errResp.Error.Details, 1)
+
+		low, err := testDB.Queries().GetItemByID(ctx, items[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(20), low.Stock)
+
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ManageCart, &group.ID, true, nil)
+		cartResp, err := server.GetCart(ctx, api.GetCartRequestObject{GroupId: group.ID})
+		require.NoError(t, err)
+		assert.Len(t, cartResp.(api.GetCart200JSONResponse), 3)
+	})
+
+	t.Run("medium items need borrowing details", func(t *testing.T) {
+		user, group, _, ctx := setup(t, "details@checkout.ca")
+
+		mockAuth.ExpectCheckPermission(user.ID, rbac.RequestItems, &group.ID, true, nil)
+		response, err := server.CheckoutGroupCart(ctx, api.CheckoutGroupCartRequestObject{
+			GroupId: group.ID,
+			Body:    &api.CheckoutGroupCartJSONRequestBody{},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CheckoutGroupCart400JSONResponse{}, response)
+	})
+
+	t.Run("empty cart", func(t *testing.T) {
+		user := testDB.NewUser(t).WithEmail("empty@checkout.ca").AsMember().Create()
+		group := testDB.NewGroup(t).WithName("Empty Checkout").Create()
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(user.ID, rbac.RequestItems, &group.ID, true, nil)
+		response, err := server.CheckoutGroupCart(ctx, api.CheckoutGroupCartRequestObject{GroupId: group.ID, Body: borrowing})
+		require.NoError(t, err)
+		require.IsType(t, api.CheckoutGroupCart400JSONResponse{}, response)
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		user := testDB.NewUser(t).WithEmail("denied@checkout.ca").AsMember().Create()
+		group := testDB.NewGroup(t).WithName("Denied Checkout").Create()
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(user.ID, rbac.RequestItems, &group.ID, false, nil)
+		response, err := server.CheckoutGroupCart(ctx, api.CheckoutGroupCartRequestObject{GroupId: group.ID, Body: borrowing})
+		require.NoError(t, err)
+		require.IsType(t, api.CheckoutGroupCart403JSONResponse{}, response)
+	})
+}
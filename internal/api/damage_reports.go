@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/damage"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s Server) ListDamageReports(ctx context.Context, request api.ListDamageReportsRequestObject) (api.ListDamageReportsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListDamageReports401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	// group admins can follow up on their own group's reports
+	permission := rbac.ViewAllData
+	if request.Params.GroupId != nil {
+		permission = rbac.ViewGroupData
+	}
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, permission, request.Params.GroupId)
+	if err != nil {
+		logger.Error("Error checking permission", "permission", permission, "error", err)
+		return api.ListDamageReports500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListDamageReports403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	var status db.NullDamageReportStatus
+	if request.Params.Status != nil {
+		status = db.NullDamageReportStatus{DamageReportStatus: db.DamageReportStatus(*request.Params.Status), Valid: true}
+	}
+
+	reports, err := s.db.Queries().ListDamageReports(ctx, db.ListDamageReportsParams{
+		Status:  status,
+		GroupID: request.Params.GroupId,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		logger.Error("Failed to list damage reports", "error", err)
+		return api.ListDamageReports500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountDamageReports(ctx, db.CountDamageReportsParams{
+		Status:  status,
+		GroupID: request.Params.GroupId,
+	})
+	if err != nil {
+		logger.Error("Failed to count damage reports", "error", err)
+		return api.ListDamageReports500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	data := make([]api.DamageReport, 0, len(reports))
+	for _, report := range reports {
+		data = append(data, toDamageReportResponse(report))
+	}
+
+	return api.ListDamageReports200JSONResponse{
+		Data: data,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
+
+func (s Server) UpdateDamageReport(ctx context.Context, request api.UpdateDamageReportRequestObject) (api.UpdateDamageReportResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.UpdateDamageReport401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.UpdateDamageReport500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.UpdateDamageReport403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.UpdateDamageReport400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	body := request.Body
+	if body.Status != nil && !validDamageReportStatus(*body.Status) {
+		return api.UpdateDamageReport400JSONResponse(ValidationErr("status must be open, resolved or dismissed", nil).Create()), nil
+	}
+	if body.Severity != nil && !validDamageSeverity(*body.Severity) {
+		return api.UpdateDamageReport400JSONResponse(ValidationErr("severity must be minor, moderate or severe", nil).Create()), nil
+	}
+
+	report, err := s.db.Queries().GetDamageReportByID(ctx, request.Id)
+	if err == pgx.ErrNoRows {
+		return api.UpdateDamageReport404JSONResponse(NotFound("Damage report").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get damage report", "damage_report_id", request.Id, "error", err)
+		return api.UpdateDamageReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	params := db.UpdateDamageReportParams{
+		ID:              report.ID,
+		Status:          report.Status,
+		Severity:        report.Severity,
+		ResolutionNotes: report.ResolutionNotes,
+		ResolvedBy:      report.ResolvedBy,
+		ResolvedAt:      report.ResolvedAt,
+	}
+	if body.Severity != nil {
+		params.Severity = db.DamageSeverity(*body.Severity)
+	}
+	if body.ResolutionNotes != nil {
+		params.ResolutionNotes = pgtype.Text{String: *body.ResolutionNotes, Valid: true}
+	}
+	if body.Status != nil && db.DamageReportStatus(*body.Status) != report.Status {
+		params.Status = db.DamageReportStatus(*body.Status)
+		if params.Status == db.DamageReportStatusOpen {
+			params.ResolvedBy = nil
+			params.ResolvedAt = pgtype.Timestamp{}
+		} else {
+			params.ResolvedBy = &user.ID
+			params.ResolvedAt = pgtype.Timestamp{Time: time.Now(), Valid: true}
+		}
+	}
+
+	updated, err := s.db.Queries().UpdateDamageReport(ctx, params)
+	if err != nil {
+		logger.Error("Failed to update damage report", "damage_report_id", report.ID, "error", err)
+		return api.UpdateDamageReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Damage report updated",
+		"damage_report_id", updated.ID,
+		"status", updated.Status,
+		"severity", updated.Severity,
+		"user_id", user.ID)
+	return api.UpdateDamageReport200JSONResponse(toDamageReportResponse(updated)), nil
+}
+
+// records a borrowing that came back worse than it went out, along with the
+// after-images uploaded for it so far.
+func openDamageReport(ctx context.Context, qtx *db.Queries, reporterID uuid.UUID, borrowing db.Borrowing, body *api.ReturnBorrowingRequest) (db.DamageReport, error) {
+	images, err := qtx.ListBorrowingImagesByBorrowing(ctx, borrowing.ID)
+	if err != nil {
+		return db.DamageReport{}, err
+	}
+	photoKeys := make([]string, 0, len(images))
+	for _, image := range images {
+		if image.ImageType == "after" {
+			photoKeys = append(photoKeys, image.S3Key)
+		}
+	}
+
+	after := db.Condition(body.AfterCondition)
+	severity := damage.SeverityFor(borrowing.BeforeCondition, after)
+	if body.DamageSeverity != nil {
+		severity = db.DamageSeverity(*body.DamageSeverity)
+	}
+
+	var notes pgtype.Text
+	if body.DamageNotes != nil && *body.DamageNotes != "" {
+		notes = pgtype.Text{String: *body.DamageNotes, Valid: true}
+	}
+
+	return qtx.CreateDamageReport(ctx, db.CreateDamageReportParams{
+		BorrowingID:     borrowing.ID,
+		ItemID:          *borrowing.ItemID,
+		GroupID:         borrowing.GroupID,
+		ReportedBy:      &reporterID,
+		BeforeCondition: borrowing.BeforeCondition,
+		AfterCondition:  after,
+		Severity:        severity,
+		Notes:           notes,
+		PhotoKeys:       photoKeys,
+	})
+}
+
+// emails the borrowing group's admins; routing rules can add to or replace them.
+func (s Server) notifyDamageReported(ctx context.Context, returner *auth.AuthenticatedUser, report db.DamageReport) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	ctx = s.sandboxContext(ctx, report.GroupID)
+
+	var adminIDs []uuid.UUID
+	if report.GroupID != nil {
+		admins, err := s.db.Queries().GetGroupAdminIDs(ctx, report.GroupID)
+		if err != nil {
+			logger.Error("Failed to get group admins for damage report", "damage_report_id", report.ID, "error", err)
+			return
+		}
+		for _, id := range admins {
+			if id != nil {
+				adminIDs = append(adminIDs, *id)
+			}
+		}
+	}
+
+	// the item may have been binned since it was borrowed; still name it
+	item, err := s.db.Queries().GetItemByIDIncludingBinned(ctx, report.ItemID)
+	if err != nil {
+		logger.Error("Failed to get item for damage report", "damage_report_id", report.ID, "error", err)
+		return
+	}
+
+	if err := s.dispatcher.Notify(ctx, returner.ID, "damage_report", report.ID, []notifications.NotifierGroup{
+		{
+			IDs:      adminIDs,
+			Template: "damage_reported_group_admin",
+			TemplateData: map[string]interface{}{
+				"ItemName":        item.Name,
+				"BorrowerEmail":   returner.Email,
+				"BeforeCondition": string(report.BeforeCondition),
+				"AfterCondition":  string(report.AfterCondition),
+				"Severity":        string(report.Severity),
+				"Notes":           report.Notes.String,
+				"Photos":          len(report.PhotoKeys),
+				"DamageReportID":  report.ID.String(),
+			},
+			Facts: notifications.RoutingFacts{
+				ItemID:   &item.ID,
+				ItemType: item.Type,
+				GroupID:  report.GroupID,
+			},
+		},
+	}); err != nil {
+		logger.Error("Failed to send damage report notifications", "damage_report_id", report.ID, "error", err)
+	}
+}
+
+func validDamageSeverity(severity api.DamageSeverity) bool {
+	switch severity {
+	case api.Minor, api.Moderate, api.Severe:
+		return true
+	}
+	return false
+}
+
+func validDamageReportStatus(status api.DamageReportStatus) bool {
+	switch status {
+	case api.Open, api.Resolved, api.Dismissed:
+		return true
+	}
+	return false
+}
+
+func toDamageReportResponse(report db.DamageReport) api.DamageReport {
+	response := api.DamageReport{
+		Id:              report.ID,
+		BorrowingId:     report.BorrowingID,
+		ItemId:          report.ItemID,
+		GroupId:         report.GroupID,
+		ReportedBy:      report.ReportedBy,
+		BeforeCondition: string(report.BeforeCondition),
+		AfterCondition:  string(report.AfterCondition),
+		Severity:        api.DamageSeverity(report.Severity),
+		PhotoKeys:       report.PhotoKeys,
+		Status:          api.DamageReportStatus(report.Status),
+		ResolvedBy:      report.ResolvedBy,
+		CreatedAt:       report.CreatedAt.Time,
+	}
+	if response.PhotoKeys == nil {
+		response.PhotoKeys = []string{}
+	}
+	if report.Notes.Valid {
+		response.Notes = &report.Notes.String
+	}
+	if report.ResolutionNotes.Valid {
+		response.ResolutionNotes = &report.ResolutionNotes.String
+	}
+	if report.ResolvedAt.Valid {
+		response.ResolvedAt = &report.ResolvedAt.Time
+	}
+	return response
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// returns the borrowing through the API in the given condition.
+func returnBorrowing(t *testing.T, server *Server, mockAuth *testutil.MockAuthenticator, ctx context.Context, userID uuid.UUID, borrowing db.Borrowing, body api.ReturnBorrowingRequest) api.ReturnItem200JSONResponse {
+	t.Helper()
+	afterURL := "http://example.com/after.jpg"
+	body.AfterConditionUrl = &afterURL
+
+	mockAuth.ExpectCheckPermission(userID, rbac.ViewOwnData, nil, true, nil)
+	response, err := server.ReturnItem(ctx, api.ReturnItemRequestObject{ItemId: *borrowing.ItemID, Body: &body})
+	require.NoError(t, err)
+	require.IsType(t, api.ReturnItem200JSONResponse{}, response)
+	return response.(api.ReturnItem200JSONResponse)
+}
+
+func TestServer_ReturnItem_DamageReport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("worse condition opens a report with the after-images", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@damage.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		borrowing := createBorrowing(t, testDB, member.ID)
+
+		_, err := testDB.Queries().CreateBorrowingImage(ctx, db.CreateBorrowingImageParams{
+			ID:          uuid.New(),
+			BorrowingID: borrowing.ID,
+			S3Key:       "borrowings/after-1.jpg",
+			ImageType:   "after",
+			UploadedBy:  &member.ID,
+		})
+		require.NoError(t, err)
+
+		notes := "Lens cap lost and a scratch on the body"
+		returned := returnBorrowing(t, server, mockAuth, ctx, member.ID, borrowing, api.ReturnBorrowingRequest{
+			AfterCondition: "damaged",
+			DamageNotes:    &notes,
+		})
+		require.NotNil(t, returned.DamageReportId)
+
+		report, err := testDB.Queries().GetDamageReportByID(ctx, *returned.DamageReportId)
+		require.NoError(t, err)
+		assert.Equal(t, borrowing.ID, report.BorrowingID)
+		assert.Equal(t, db.ConditionGood, report.BeforeCondition)
+		assert.Equal(t, db.ConditionDamaged, report.AfterCondition)
+		assert.Equal(t, db.DamageSeverityModerate, report.Severity)
+		assert.Equal(t, notes, report.Notes.String)
+		assert.Equal(t, []string{"borrowings/after-1.jpg"}, report.PhotoKeys)
+		assert.Equal(t, db.DamageReportStatusOpen, report.Status)
+	})
+
+	t.Run("returner can set the severity", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@damage.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		borrowing := createBorrowing(t, testDB, member.ID)
+
+		severe := api.Severe
+		returned := returnBorrowing(t, server, mockAuth, ctx, member.ID, borrowing, api.ReturnBorrowingRequest{
+			AfterCondition: "decent",
+			DamageSeverity: &severe,
+		})
+		require.NotNil(t, returned.DamageReportId)
+
+		report, err := testDB.Queries().GetDamageReportByID(ctx, *returned.DamageReportId)
+		require.NoError(t, err)
+		assert.Equal(t, db.DamageSeveritySevere, report.Severity)
+	})
+
+	t.Run("same condition opens no report", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@damage.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		borrowing := createBorrowing(t, testDB, member.ID)
+
+		returned := returnBorrowing(t, server, mockAuth, ctx, member.ID, borrowing, api.ReturnBorrowingRequest{
+			AfterCondition: "good",
+		})
+		assert.Nil(t, returned.DamageReportId)
+	})
+
+	t.Run("unknown condition", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@damage.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		borrowing := createBorrowing(t, testDB, member.ID)
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewOwnData, nil, true, nil)
+		response, err := server.ReturnItem(ctx, api.ReturnItemRequestObject{
+			ItemId: *borrowing.ItemID,
+			Body:   &api.ReturnBorrowingRequest{AfterCondition: "scuffed"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ReturnItem400JSONResponse{}, response)
+	})
+}
+
+func TestServer_DamageReports(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@damage.test").AsGlobalAdmin().Create()
+	member := testDB.NewUser(t).WithEmail("member@damage.test").AsMember().Create()
+	adminCtx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+	memberCtx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+	borrowing := createBorrowing(t, testDB, member.ID)
+	returned := returnBorrowing(t, server, mockAuth, memberCtx, member.ID, borrowing, api.ReturnBorrowingRequest{
+		AfterCondition: "unusable",
+	})
+	require.NotNil(t, returned.DamageReportId)
+	reportID := *returned.DamageReportId
+
+	t.Run("admin lists open reports", func(t *testing.T) {
+		open := api.Open
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewAllData, nil, true, nil)
+		response, err := server.ListDamageReports(adminCtx, api.ListDamageReportsRequestObject{
+			Params: api.ListDamageReportsParams{Status: &open},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ListDamageReports200JSONResponse{}, response)
+
+		list := response.(api.ListDamageReports200JSONResponse)
+		require.Len(t, list.Data, 1)
+		assert.Equal(t, reportID, list.Data[0].Id)
+		assert.Equal(t, api.Severe, list.Data[0].Severity)
+		assert.Equal(t, 1, list.Meta.Total)
+	})
+
+	t.Run("member cannot list every report", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewAllData, nil, false, nil)
+		response, err := server.ListDamageReports(memberCtx, api.ListDamageReportsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.ListDamageReports403JSONResponse{}, response)
+	})
+
+	t.Run("resolving records who closed it", func(t *testing.T) {
+		resolved := api.Resolved
+		notes := "Replaced under warranty"
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.UpdateDamageReport(adminCtx, api.UpdateDamageReportRequestObject{
+			Id:   reportID,
+			Body: &api.UpdateDamageReportRequest{Status: &resolved, ResolutionNotes: &notes},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.UpdateDamageReport200JSONResponse{}, response)
+
+		report := response.(api.UpdateDamageReport200JSONResponse)
+		assert.Equal(t, api.Resolved, report.Status)
+		assert.Equal(t, &notes, report.ResolutionNotes)
+		assert.Equal(t, &admin.ID, report.ResolvedBy)
+		assert.NotNil(t, report.ResolvedAt)
+	})
+
+	t.Run("reopening clears the resolution", func(t *testing.T) {
+		open := api.Open
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.UpdateDamageReport(adminCtx, api.UpdateDamageReportRequestObject{
+			Id:   reportID,
+			Body: &api.UpdateDamageReportRequest{Status: &open},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.UpdateDamageReport200JSONResponse{}, response)
+
+		report := response.(api.UpdateDamageReport200JSONResponse)
+		assert.Equal(t, api.Open, report.Status)
+		assert.Nil(t, report.ResolvedBy)
+		assert.Nil(t, report.ResolvedAt)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		status := api.DamageReportStatus("fixed")
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.UpdateDamageReport(adminCtx, api.UpdateDamageReportRequestObject{
+			Id:   reportID,
+			Body: &api.UpdateDamageReportRequest{Status: &status},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.UpdateDamageReport400JSONResponse{}, response)
+	})
+
+	t.Run("unknown report", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.UpdateDamageReport(adminCtx, api.UpdateDamageReportRequestObject{
+			Id:   uuid.New(),
+			Body: &api.UpdateDamageReportRequest{},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.UpdateDamageReport404JSONResponse{}, response)
+	})
+
+	t.Run("member cannot update reports", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageItems, nil, false, nil)
+		response, err := server.UpdateDamageReport(memberCtx, api.UpdateDamageReportRequestObject{
+			Id:   reportID,
+			Body: &api.UpdateDamageReportRequest{},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.UpdateDamageReport403JSONResponse{}, response)
+	})
+}
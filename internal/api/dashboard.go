@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/jackc/pgx/v5"
+)
+
+// the sections GET /me/dashboard can return, as named by its include parameter
+var dashboardSections = []string{"active_borrowings", "pending_requests", "upcoming_bookings", "unread_notifications", "fines"}
+
+// most entries each dashboard list holds; the full lists have their own endpoints
+const dashboardListLimit = 20
+
+// the sections named by include, or all of them when it is empty.
+func parseDashboardInclude(include *string) (map[string]bool, error) {
+	selected := make(map[string]bool, len(dashboardSections))
+	if include == nil || strings.TrimSpace(*include) == "" {
+		for _, section := range dashboardSections {
+			selected[section] = true
+		}
+		return selected, nil
+	}
+	for _, name := range strings.Split(*include, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(dashboardSections, name) {
+			return nil, fmt.Errorf("unknown dashboard section: %s", name)
+		}
+		selected[name] = true
+	}
+	return selected, nil
+}
+
+func (s Server) GetMyDashboard(ctx context.Context, request api.GetMyDashboardRequestObject) (api.GetMyDashboardResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetMyDashboard401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewOwnData, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ViewOwnData permission", "error", err)
+		return api.GetMyDashboard500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetMyDashboard403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	include, err := parseDashboardInclude(request.Params.Include)
+	if err != nil {
+		return api.GetMyDashboard400JSONResponse(ValidationErr(err.Error(), nil).Create()), nil
+	}
+
+	// one read-only snapshot on one connection, so the sections agree with
+	// each other (a borrowing returned mid-request shows up in neither list
+	// nor both) and the home screen costs a single pool checkout
+	tx, err := s.db.Pool().BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.GetMyDashboard500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.db.Queries().WithTx(tx)
+
+	dashboard, err := s.loadDashboard(ctx, qtx, user, include)
+	if err != nil {
+		logger.Error("Failed to load dashboard", "user_id", user.ID, "error", err)
+		return api.GetMyDashboard500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	return api.GetMyDashboard200JSONResponse(dashboard), nil
+}
+
+func (s Server) loadDashboard(ctx context.Context, q *db.Queries, user *auth.AuthenticatedUser, include map[string]bool) (api.MyDashboard, error) {
+	var dashboard api.MyDashboard
+
+	if include["active_borrowings"] {
+		borrowings, err := q.GetActiveBorrowedItemsByUserId(ctx, db.GetActiveBorrowedItemsByUserIdParams{
+			UserID: &user.ID,
+			Limit:  dashboardListLimit,
+			Offset: 0,
+		})
+		if err != nil {
+			return dashboard, fmt.Errorf("active borrowings: %w", err)
+		}
+		response, err := createBorrowedItemResponse(borrowings, true)
+		if err != nil {
+			return dashboard, fmt.Errorf("active borrowings: %w", err)
+		}
+		if response == nil {
+			response = []api.BorrowingResponse{}
+		}
+		dashboard.ActiveBorrowings = &response
+	}
+
+	if include["pending_requests"] {
+		requests, err := q.GetRequestsByUserId(ctx, db.GetRequestsByUserIdParams{
+			UserID:   &user.ID,
+			Status:   db.NullRequestStatus{RequestStatus: db.RequestStatusPending, Valid: true},
+			SortBy:   "requested_at",
+			SortDesc: true,
+			Limit:    dashboardListLimit,
+			Offset:   0,
+		})
+		if err != nil {
+			return dashboard, fmt.Errorf("pending requests: %w", err)
+		}
+		response := createRequestItemResponse(requests)
+		dashboard.PendingRequests = &response
+	}
+
+	if include["upcoming_bookings"] {
+		bookings, err := q.ListUpcomingBookingsByUser(ctx, db.ListUpcomingBookingsByUserParams{
+			RequesterID: &user.ID,
+			Limit:       dashboardListLimit,
+		})
+		if err != nil {
+			return dashboard, fmt.Errorf("upcoming bookings: %w", err)
+		}
+		policies, err := s.policies.All(ctx, q)
+		if err != nil {
+			return dashboard, fmt.Errorf("booking policies: %w", err)
+		}
+		response := make([]api.BookingResponse, 0, len(bookings))
+		for _, booking := range bookings {
+			// same columns as ListBookingsByUser, only filtered for the dashboard
+			r := convertToBookingResponseFromUserRow(db.ListBookingsByUserRow(booking))
+			setConfirmBy(&r, policies.ForGroup(booking.GroupID))
+			response = append(response, r)
+		}
+		dashboard.UpcomingBookings = &response
+	}
+
+	if include["unread_notifications"] {
+		notifs, err := q.ListUnreadUserNotifications(ctx, db.ListUnreadUserNotificationsParams{
+			NotifierID: user.ID,
+			Limit:      dashboardListLimit,
+		})
+		if err != nil {
+			return dashboard, fmt.Errorf("unread notifications: %w", err)
+		}
+		unread, err := q.CountUserNotifications(ctx, user.ID)
+		if err != nil {
+			return dashboard, fmt.Errorf("unread notification count: %w", err)
+		}
+		data := make([]api.NotificationResponse, 0, len(notifs))
+		for _, n := range notifs {
+			data = append(data, toNotificationResponse(db.GetUserNotificationsRow(n)))
+		}
+		dashboard.UnreadNotifications = &api.DashboardNotifications{Data: data, UnreadCount: unread}
+	}
+
+	if include["fines"] {
+		list, err := q.ListFines(ctx, db.ListFinesParams{
+			Status: db.NullFineStatus{FineStatus: db.FineStatusUnpaid, Valid: true},
+			UserID: &user.ID,
+			Limit:  dashboardListLimit,
+			Offset: 0,
+		})
+		if err != nil {
+			return dashboard, fmt.Errorf("fines: %w", err)
+		}
+		unpaid, err := q.GetUnpaidFineTotal(ctx, user.ID)
+		if err != nil {
+			return dashboard, fmt.Errorf("unpaid fine total: %w", err)
+		}
+		data := make([]api.Fine, 0, len(list))
+		for _, fine := range list {
+			data = append(data, toFineResponse(fine))
+		}
+		dashboard.Fines = &api.MyFinesResponse{Fines: data, UnpaidTotalCents: unpaid}
+	}
+
+	return dashboard, nil
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_GetMyDashboard(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	setup := func(t *testing.T) *testutil.TestUser {
+		testDB.CleanupDatabase(t)
+		group := testDB.NewGroup(t).WithName("Dashboard Group").Create()
+		member := testDB.NewUser(t).WithEmail("member@dashboard.test").AsMemberOf(group).Create()
+		other := testDB.NewUser(t).WithEmail("other@dashboard.test").AsMemberOf(group).Create()
+
+		createBorrowing(t, testDB, member.ID)
+		createUnpaidFine(t, testDB, member.ID, 750)
+		createBorrowing(t, testDB, other.ID)
+
+		item := testDB.NewItem(t).WithName("Camera").WithType("medium").WithStock(3).Create()
+		_, err := testDB.Queries().RequestItem(context.Background(), db.RequestItemParams{
+			UserID:   &member.ID,
+			GroupID:  &group.ID,
+			ID:       item.ID,
+			Quantity: 1,
+		})
+		require.NoError(t, err)
+		return member
+	}
+
+	get := func(t *testing.T, user *testutil.TestUser, include *string) api.GetMyDashboardResponseObject {
+		t.Helper()
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ViewOwnData, nil, true, nil)
+		resp, err := server.GetMyDashboard(ctx, api.GetMyDashboardRequestObject{
+			Params: api.GetMyDashboardParams{Include: include},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("every section by default", func(t *testing.T) {
+		member := setup(t)
+
+		resp := get(t, member, nil)
+		require.IsType(t, api.GetMyDashboard200JSONResponse{}, resp)
+		dashboard := resp.(api.GetMyDashboard200JSONResponse)
+
+		require.NotNil(t, dashboard.ActiveBorrowings)
+		assert.Len(t, *dashboard.ActiveBorrowings, 2, "the fined borrowing is still out")
+		for _, b := range *dashboard.ActiveBorrowings {
+			assert.False(t, b.DueDate.IsZero())
+		}
+
+		require.NotNil(t, dashboard.PendingRequests)
+		require.Len(t, *dashboard.PendingRequests, 1)
+		assert.Equal(t, api.RequestStatusPending, (*dashboard.PendingRequests)[0].Status)
+
+		require.NotNil(t, dashboard.UpcomingBookings)
+		assert.Empty(t, *dashboard.UpcomingBookings)
+
+		require.NotNil(t, dashboard.UnreadNotifications)
+		assert.Zero(t, dashboard.UnreadNotifications.UnreadCount)
+		assert.Empty(t, dashboard.UnreadNotifications.Data)
+
+		require.NotNil(t, dashboard.Fines)
+		assert.Len(t, dashboard.Fines.Fines, 1)
+		assert.Equal(t, int64(750), dashboard.Fines.UnpaidTotalCents)
+	})
+
+	t.Run("only the included sections", func(t *testing.T) {
+		member := setup(t)
+
+		include := "fines, pending_requests"
+		resp := get(t, member, &include)
+		require.IsType(t, api.GetMyDashboard200JSONResponse{}, resp)
+		dashboard := resp.(api.GetMyDashboard200JSONResponse)
+
+		assert.NotNil(t, dashboard.Fines)
+		assert.NotNil(t, dashboard.PendingRequests)
+		assert.Nil(t, dashboard.ActiveBorrowings)
+		assert.Nil(t, dashboard.UpcomingBookings)
+		assert.Nil(t, dashboard.UnreadNotifications)
+	})
+
+	t.Run("unknown section", func(t *testing.T) {
+		member := setup(t)
+
+		include := "active_borrowings,overdue"
+		resp := get(t, member, &include)
+		require.IsType(t, api.GetMyDashboard400JSONResponse{}, resp)
+		assert.Contains(t, resp.(api.GetMyDashboard400JSONResponse).Error.Message, "overdue")
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		resp, err := server.GetMyDashboard(context.Background(), api.GetMyDashboardRequestObject{})
+		require.NoError(t, err)
+		assert.IsType(t, api.GetMyDashboard401JSONResponse{}, resp)
+	})
+}
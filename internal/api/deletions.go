@@ -0,0 +1,352 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/recyclebin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// database deletion request to API response
+func convertToDeletionRequestResponse(d db.DeletionRequest) api.DeletionRequest {
+	response := api.DeletionRequest{
+		Id:          d.ID,
+		EntityType:  api.DeletionRequestEntityType(d.EntityType),
+		EntityId:    d.EntityID,
+		EntityName:  d.EntityName,
+		Status:      api.DeletionRequestStatus(d.Status),
+		RequestedBy: d.RequestedBy,
+		RequestedAt: d.RequestedAt.Time,
+		ApprovedBy:  d.ApprovedBy,
+		ResolvedBy:  d.ResolvedBy,
+	}
+	if d.ApprovedAt.Valid {
+		response.ApprovedAt = &d.ApprovedAt.Time
+	}
+	if d.PurgeAfter.Valid {
+		response.PurgeAfter = &d.PurgeAfter.Time
+	}
+	if d.ResolvedAt.Valid {
+		response.ResolvedAt = &d.ResolvedAt.Time
+	}
+	if d.Reason.Valid {
+		response.Reason = &d.Reason.String
+	}
+	return response
+}
+
+// groups are guarded by manage_groups, items by manage_items.
+func deletionPermission(entityType db.DeletionEntity) string {
+	if entityType == db.DeletionEntityGroup {
+		return rbac.ManageGroups
+	}
+	return rbac.ManageItems
+}
+
+// opens a pending deletion request for the entity. Returns ok=false when one
+// is already pending or binned.
+func (s Server) requestDeletion(ctx context.Context, entityType db.DeletionEntity, entityID, requestedBy uuid.UUID, entityName string, reason *string) (db.DeletionRequest, bool, error) {
+	_, err := s.db.Queries().GetOpenDeletionRequest(ctx, db.GetOpenDeletionRequestParams{
+		EntityType: entityType,
+		EntityID:   entityID,
+	})
+	if err == nil {
+		return db.DeletionRequest{}, false, nil
+	}
+	if err != pgx.ErrNoRows {
+		return db.DeletionRequest{}, false, err
+	}
+
+	var reasonText pgtype.Text
+	if reason != nil && *reason != "" {
+		reasonText = pgtype.Text{String: *reason, Valid: true}
+	}
+
+	deletion, err := s.db.Queries().CreateDeletionRequest(ctx, db.CreateDeletionRequestParams{
+		EntityType:  entityType,
+		EntityID:    entityID,
+		EntityName:  entityName,
+		RequestedBy: &requestedBy,
+		Reason:      reasonText,
+	})
+	if err != nil {
+		return db.DeletionRequest{}, false, err
+	}
+	return deletion, true, nil
+}
+
+func (s Server) ListDeletionRequests(ctx context.Context, request api.ListDeletionRequestsRequestObject) (api.ListDeletionRequestsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListDeletionRequests401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewAllData, nil)
+	if err != nil {
+		logger.Error("Error checking view_all_data permission",
+			"user_id", user.ID,
+			"permission", rbac.ViewAllData,
+			"error", err)
+		return api.ListDeletionRequests500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListDeletionRequests403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	var status db.NullDeletionStatus
+	if request.Params.Status != nil {
+		status = db.NullDeletionStatus{DeletionStatus: db.DeletionStatus(*request.Params.Status), Valid: true}
+	}
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	rows, err := s.db.Queries().ListDeletionRequests(ctx, db.ListDeletionRequestsParams{
+		Status: status,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		logger.Error("Failed to list deletion requests", "error", err)
+		return api.ListDeletionRequests500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountDeletionRequests(ctx, status)
+	if err != nil {
+		logger.Error("Failed to count deletion requests", "error", err)
+		return api.ListDeletionRequests500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	data := make([]api.DeletionRequest, 0, len(rows))
+	for _, row := range rows {
+		data = append(data, convertToDeletionRequestResponse(row))
+	}
+
+	return api.ListDeletionRequests200JSONResponse{
+		Data: data,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
+
+func (s Server) ApproveDeletionRequest(ctx context.Context, request api.ApproveDeletionRequestRequestObject) (api.ApproveDeletionRequestResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ApproveDeletionRequest401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.ApproveDeletionRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	deletion, err := qtx.GetDeletionRequestForUpdate(ctx, request.Id)
+	if err == pgx.ErrNoRows {
+		return api.ApproveDeletionRequest404JSONResponse(NotFound("Deletion request").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get deletion request", "deletion_id", request.Id, "error", err)
+		return api.ApproveDeletionRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	permission := deletionPermission(deletion.EntityType)
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, permission, nil)
+	if err != nil {
+		logger.Error("Error checking deletion permission",
+			"user_id", user.ID,
+			"permission", permission,
+			"error", err)
+		return api.ApproveDeletionRequest500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ApproveDeletionRequest403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if deletion.Status != db.DeletionStatusPending {
+		return api.ApproveDeletionRequest409JSONResponse(ConflictErr("Deletion request is not pending").Create()), nil
+	}
+	if deletion.RequestedBy != nil && *deletion.RequestedBy == user.ID {
+		return api.ApproveDeletionRequest400JSONResponse(ValidationErr("A second administrator must approve this deletion", nil).Create()), nil
+	}
+
+	purgeAfter := time.Now().Add(recyclebin.Retention)
+	approved, err := qtx.ApproveDeletionRequest(ctx, db.ApproveDeletionRequestParams{
+		ID:         deletion.ID,
+		ApprovedBy: &user.ID,
+		PurgeAfter: pgtype.Timestamp{Time: purgeAfter, Valid: true},
+	})
+	if err != nil {
+		logger.Error("Failed to approve deletion request", "deletion_id", deletion.ID, "error", err)
+		return api.ApproveDeletionRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit deletion approval", "deletion_id", deletion.ID, "error", err)
+		return api.ApproveDeletionRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	// a binned item drops out of the listings
+	if deletion.EntityType == db.DeletionEntityItem {
+		s.invalidateCatalog(ctx)
+	}
+
+	// the purge sweep is idempotent, so a lost task only delays the purge until the next one runs
+	if _, err := s.queue.EnqueueAt(ctx, queue.TypeDeletionPurge, struct{}{}, purgeAfter); err != nil {
+		logger.Error("Failed to schedule recycle bin purge", "deletion_id", deletion.ID, "error", err)
+	}
+
+	logger.Info("Deletion request approved",
+		"deletion_id", approved.ID,
+		"entity_type", approved.EntityType,
+		"entity_id", approved.EntityID,
+		"approved_by", user.ID,
+		"purge_after", purgeAfter)
+
+	return api.ApproveDeletionRequest200JSONResponse(convertToDeletionRequestResponse(approved)), nil
+}
+
+func (s Server) RejectDeletionRequest(ctx context.Context, request api.RejectDeletionRequestRequestObject) (api.RejectDeletionRequestResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RejectDeletionRequest401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.RejectDeletionRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	deletion, err := qtx.GetDeletionRequestForUpdate(ctx, request.Id)
+	if err == pgx.ErrNoRows {
+		return api.RejectDeletionRequest404JSONResponse(NotFound("Deletion request").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get deletion request", "deletion_id", request.Id, "error", err)
+		return api.RejectDeletionRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	permission := deletionPermission(deletion.EntityType)
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, permission, nil)
+	if err != nil {
+		logger.Error("Error checking deletion permission",
+			"user_id", user.ID,
+			"permission", permission,
+			"error", err)
+		return api.RejectDeletionRequest500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.RejectDeletionRequest403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if deletion.Status != db.DeletionStatusPending {
+		return api.RejectDeletionRequest409JSONResponse(ConflictErr("Deletion request is not pending").Create()), nil
+	}
+
+	rejected, err := qtx.ResolveDeletionRequest(ctx, db.ResolveDeletionRequestParams{
+		ID:         deletion.ID,
+		Status:     db.DeletionStatusRejected,
+		ResolvedBy: &user.ID,
+	})
+	if err != nil {
+		logger.Error("Failed to reject deletion request", "deletion_id", deletion.ID, "error", err)
+		return api.RejectDeletionRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit deletion rejection", "deletion_id", deletion.ID, "error", err)
+		return api.RejectDeletionRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	return api.RejectDeletionRequest200JSONResponse(convertToDeletionRequestResponse(rejected)), nil
+}
+
+func (s Server) RestoreDeletionRequest(ctx context.Context, request api.RestoreDeletionRequestRequestObject) (api.RestoreDeletionRequestResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RestoreDeletionRequest401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.RestoreDeletionRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	deletion, err := qtx.GetDeletionRequestForUpdate(ctx, request.Id)
+	if err == pgx.ErrNoRows {
+		return api.RestoreDeletionRequest404JSONResponse(NotFound("Deletion request").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get deletion request", "deletion_id", request.Id, "error", err)
+		return api.RestoreDeletionRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	permission := deletionPermission(deletion.EntityType)
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, permission, nil)
+	if err != nil {
+		logger.Error("Error checking deletion permission",
+			"user_id", user.ID,
+			"permission", permission,
+			"error", err)
+		return api.RestoreDeletionRequest500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.RestoreDeletionRequest403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if deletion.Status != db.DeletionStatusBinned {
+		return api.RestoreDeletionRequest409JSONResponse(ConflictErr("Only entities in the recycle bin can be restored").Create()), nil
+	}
+
+	restored, err := qtx.ResolveDeletionRequest(ctx, db.ResolveDeletionRequestParams{
+		ID:         deletion.ID,
+		Status:     db.DeletionStatusRestored,
+		ResolvedBy: &user.ID,
+	})
+	if err != nil {
+		logger.Error("Failed to restore deletion request", "deletion_id", deletion.ID, "error", err)
+		return api.RestoreDeletionRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit restore", "deletion_id", deletion.ID, "error", err)
+		return api.RestoreDeletionRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if deletion.EntityType == db.DeletionEntityItem {
+		s.invalidateCatalog(ctx)
+	}
+
+	logger.Info("Entity restored from recycle bin",
+		"deletion_id", restored.ID,
+		"entity_type", restored.EntityType,
+		"entity_id", restored.EntityID,
+		"restored_by", user.ID)
+
+	return api.RestoreDeletionRequest200JSONResponse(convertToDeletionRequestResponse(restored)), nil
+}
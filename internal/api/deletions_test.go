@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/recyclebin"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requestGroupDeletion(t *testing.T, server *Server, mockAuth *testutil.MockAuthenticator, ctx context.Context, userID, groupID uuid.UUID) api.DeletionRequest {
+	t.Helper()
+	mockAuth.ExpectCheckPermission(userID, rbac.ManageGroups, nil, true, nil)
+	response, err := server.DeleteGroup(ctx, api.DeleteGroupRequestObject{Id: groupID})
+	require.NoError(t, err)
+	require.IsType(t, api.DeleteGroup202JSONResponse{}, response)
+	return api.DeletionRequest(response.(api.DeleteGroup202JSONResponse))
+}
+
+func TestServer_ApproveDeletionRequest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("second admin approval moves group to recycle bin", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		requester := testDB.NewUser(t).WithEmail("requester@deletions.test").AsGlobalAdmin().Create()
+		approver := testDB.NewUser(t).WithEmail("approver@deletions.test").AsGlobalAdmin().Create()
+		group := testDB.NewGroup(t).WithName("Binned Group").Create()
+
+		requesterCtx := testutil.ContextWithUser(context.Background(), requester, testDB.Queries())
+		approverCtx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+
+		deletion := requestGroupDeletion(t, server, mockAuth, requesterCtx, requester.ID, group.ID)
+
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageGroups, nil, true, nil)
+		response, err := server.ApproveDeletionRequest(approverCtx, api.ApproveDeletionRequestRequestObject{Id: deletion.Id})
+		require.NoError(t, err)
+		require.IsType(t, api.ApproveDeletionRequest200JSONResponse{}, response)
+
+		approved := response.(api.ApproveDeletionRequest200JSONResponse)
+		assert.Equal(t, api.DeletionRequestStatus("binned"), approved.Status)
+		require.NotNil(t, approved.PurgeAfter)
+		assert.WithinDuration(t, time.Now().Add(recyclebin.Retention), *approved.PurgeAfter, time.Minute)
+
+		// binned groups drop out of listings
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ViewGroupData, nil, true, nil)
+		listResp, err := server.GetAllGroups(approverCtx, api.GetAllGroupsRequestObject{})
+		require.NoError(t, err)
+		for _, g := range listResp.(api.GetAllGroups200JSONResponse) {
+			assert.NotEqual(t, group.ID, g.Id)
+		}
+
+		// a purge task is scheduled for the end of the retention window
+		scheduled, err := sharedQueue.Inspector.ListScheduledTasks("default")
+		require.NoError(t, err)
+		assert.Len(t, scheduled, 1)
+	})
+
+	t.Run("requester cannot approve their own deletion", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		requester := testDB.NewUser(t).WithEmail("requester@deletions.test").AsGlobalAdmin().Create()
+		group := testDB.NewGroup(t).WithName("Self Approved").Create()
+		ctx := testutil.ContextWithUser(context.Background(), requester, testDB.Queries())
+
+		deletion := requestGroupDeletion(t, server, mockAuth, ctx, requester.ID, group.ID)
+
+		mockAuth.ExpectCheckPermission(requester.ID, rbac.ManageGroups, nil, true, nil)
+		response, err := server.ApproveDeletionRequest(ctx, api.ApproveDeletionRequestRequestObject{Id: deletion.Id})
+		require.NoError(t, err)
+		require.IsType(t, api.ApproveDeletionRequest400JSONResponse{}, response)
+	})
+
+	t.Run("deletion request not found", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		admin := testDB.NewUser(t).WithEmail("admin@deletions.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		response, err := server.ApproveDeletionRequest(ctx, api.ApproveDeletionRequestRequestObject{Id: uuid.New()})
+		require.NoError(t, err)
+		require.IsType(t, api.ApproveDeletionRequest404JSONResponse{}, response)
+	})
+}
+
+func TestServer_RestoreDeletionRequest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("restore binned item", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		requester := testDB.NewUser(t).WithEmail("requester@restore.test").AsGlobalAdmin().Create()
+		approver := testDB.NewUser(t).WithEmail("approver@restore.test").AsGlobalAdmin().Create()
+		item := testDB.NewItem(t).WithName("Projector").WithType("high").WithStock(1).Create()
+
+		requesterCtx := testutil.ContextWithUser(context.Background(), requester, testDB.Queries())
+		approverCtx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(requester.ID, rbac.ManageItems, nil, true, nil)
+		delResp, err := server.DeleteItem(requesterCtx, api.DeleteItemRequestObject{Id: item.ID})
+		require.NoError(t, err)
+		deletion := delResp.(api.DeleteItem202JSONResponse)
+
+		// restoring a still-pending request is not allowed
+		mockAuth.ExpectCheckPermission(requester.ID, rbac.ManageItems, nil, true, nil)
+		early, err := server.RestoreDeletionRequest(requesterCtx, api.RestoreDeletionRequestRequestObject{Id: deletion.Id})
+		require.NoError(t, err)
+		require.IsType(t, api.RestoreDeletionRequest409JSONResponse{}, early)
+
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageItems, nil, true, nil)
+		_, err = server.ApproveDeletionRequest(approverCtx, api.ApproveDeletionRequestRequestObject{Id: deletion.Id})
+		require.NoError(t, err)
+
+		count, err := testDB.Queries().CountAllItems(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+
+		// by-ID lookups no longer find the binned item
+		mockAuth.ExpectCheckPermission(requester.ID, rbac.ViewItems, nil, true, nil)
+		hidden, err := server.GetItemById(requesterCtx, api.GetItemByIdRequestObject{Id: item.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItemById404JSONResponse{}, hidden)
+
+		mockAuth.ExpectCheckPermission(requester.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.RestoreDeletionRequest(requesterCtx, api.RestoreDeletionRequestRequestObject{Id: deletion.Id})
+		require.NoError(t, err)
+		require.IsType(t, api.RestoreDeletionRequest200JSONResponse{}, response)
+		assert.Equal(t, api.DeletionRequestStatus("restored"), response.(api.RestoreDeletionRequest200JSONResponse).Status)
+
+		count, err = testDB.Queries().CountAllItems(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+}
+
+func TestServer_RejectDeletionRequest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("reject pending request", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		requester := testDB.NewUser(t).WithEmail("requester@reject.test").AsGlobalAdmin().Create()
+		reviewer := testDB.NewUser(t).WithEmail("reviewer@reject.test").AsGlobalAdmin().Create()
+		group := testDB.NewGroup(t).WithName("Kept Group").Create()
+
+		requesterCtx := testutil.ContextWithUser(context.Background(), requester, testDB.Queries())
+		reviewerCtx := testutil.ContextWithUser(context.Background(), reviewer, testDB.Queries())
+
+		deletion := requestGroupDeletion(t, server, mockAuth, requesterCtx, requester.ID, group.ID)
+
+		mockAuth.ExpectCheckPermission(reviewer.ID, rbac.ManageGroups, nil, true, nil)
+		response, err := server.RejectDeletionRequest(reviewerCtx, api.RejectDeletionRequestRequestObject{Id: deletion.Id})
+		require.NoError(t, err)
+		require.IsType(t, api.RejectDeletionRequest200JSONResponse{}, response)
+		assert.Equal(t, api.DeletionRequestStatus("rejected"), response.(api.RejectDeletionRequest200JSONResponse).Status)
+
+		// a rejected request no longer blocks a new one
+		requestGroupDeletion(t, server, mockAuth, requesterCtx, requester.ID, group.ID)
+	})
+}
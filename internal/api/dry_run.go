@@ -0,0 +1,24 @@
+package api
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Destructive admin endpoints accept ?dry_run=true. The handler does all of
+// its work inside a transaction as usual, builds its effect report, and then
+// rolls back instead of committing. Side effects outside the database, such
+// as emails, are skipped on a dry run.
+
+func isDryRun(param *bool) bool {
+	return param != nil && *param
+}
+
+// commits tx, or rolls it back on a dry run.
+func finishTx(ctx context.Context, tx pgx.Tx, dryRun bool) error {
+	if dryRun {
+		return tx.Rollback(ctx)
+	}
+	return tx.Commit(ctx)
+}
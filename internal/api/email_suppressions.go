@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s Server) LiftEmailSuppression(ctx context.Context, request api.LiftEmailSuppressionRequestObject) (api.LiftEmailSuppressionResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.LiftEmailSuppression401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageUsers, nil)
+	if err != nil {
+		logger.Error("Error checking manage_users permission", "error", err)
+		return api.LiftEmailSuppression500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.LiftEmailSuppression403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	target, err := s.db.Queries().GetUserByID(ctx, request.UserId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.LiftEmailSuppression404JSONResponse(NotFound("User").Create()), nil
+		}
+		logger.Error("Failed to get user", "user_id", request.UserId, "error", err)
+		return api.LiftEmailSuppression500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	lifted, err := s.db.Queries().DeleteEmailSuppression(ctx, target.Email)
+	if err != nil {
+		logger.Error("Failed to lift email suppression", "user_id", target.ID, "error", err)
+		return api.LiftEmailSuppression500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if lifted == 0 {
+		return api.LiftEmailSuppression404JSONResponse(NotFound("Email suppression").Create()), nil
+	}
+
+	logger.Info("Email suppression lifted", "user_id", target.ID, "email", target.Email, "lifted_by", user.ID)
+	return api.LiftEmailSuppression204Response{}, nil
+}
+
+// the suppression on email, or nil while mail to it is delivered
+func (s Server) emailSuppression(ctx context.Context, email string) (*api.EmailSuppression, error) {
+	suppression, err := s.db.Queries().GetEmailSuppression(ctx, email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toEmailSuppression(suppression), nil
+}
+
+// every suppression, by address, for listing many users at once
+func (s Server) emailSuppressions(ctx context.Context) (map[string]*api.EmailSuppression, error) {
+	suppressions, err := s.db.Queries().ListEmailSuppressions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byEmail := make(map[string]*api.EmailSuppression, len(suppressions))
+	for _, suppression := range suppressions {
+		byEmail[suppression.Email] = toEmailSuppression(suppression)
+	}
+	return byEmail, nil
+}
+
+// suppressed addresses are kept lowercased
+func suppressionFor(suppressions map[string]*api.EmailSuppression, email string) *api.EmailSuppression {
+	return suppressions[strings.ToLower(email)]
+}
+
+func toEmailSuppression(suppression db.EmailSuppression) *api.EmailSuppression {
+	response := &api.EmailSuppression{
+		Reason:       api.EmailSuppressionReason(suppression.Reason),
+		SuppressedAt: suppression.CreatedAt.Time,
+	}
+	if suppression.Detail.Valid {
+		response.Detail = &suppression.Detail.String
+	}
+	return response
+}
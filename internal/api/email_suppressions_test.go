@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_EmailSuppressions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@suppress.test").AsGlobalAdmin().Create()
+	member := testDB.NewUser(t).WithEmail("Bouncy@Suppress.test").AsMember().Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	// SES reports addresses as they were sent to, in whatever case
+	require.NoError(t, testDB.Queries().SuppressEmail(context.Background(), db.SuppressEmailParams{
+		Email:  "bouncy@suppress.TEST",
+		Reason: "bounce",
+		Detail: pgtype.Text{String: "General: 550 user unknown", Valid: true},
+	}))
+
+	getUser := func(t *testing.T) api.User {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageUsers, nil, true, nil)
+		response, err := server.GetUserById(ctx, api.GetUserByIdRequestObject{UserId: member.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetUserById200JSONResponse{}, response)
+		return api.User(response.(api.GetUserById200JSONResponse))
+	}
+
+	t.Run("admins see the suppression", func(t *testing.T) {
+		user := getUser(t)
+		require.NotNil(t, user.EmailSuppression)
+		assert.Equal(t, api.Bounce, user.EmailSuppression.Reason)
+		assert.Equal(t, "General: 550 user unknown", *user.EmailSuppression.Detail)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageUsers, nil, true, nil)
+		response, err := server.GetUsers(ctx, api.GetUsersRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetUsers200JSONResponse{}, response)
+		for _, u := range response.(api.GetUsers200JSONResponse) {
+			if u.Id == member.ID {
+				assert.NotNil(t, u.EmailSuppression)
+			} else {
+				assert.Nil(t, u.EmailSuppression)
+			}
+		}
+	})
+
+	t.Run("lift", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageUsers, nil, true, nil)
+		response, err := server.LiftEmailSuppression(ctx, api.LiftEmailSuppressionRequestObject{UserId: member.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.LiftEmailSuppression204Response{}, response)
+		assert.Nil(t, getUser(t).EmailSuppression)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageUsers, nil, true, nil)
+		response, err = server.LiftEmailSuppression(ctx, api.LiftEmailSuppressionRequestObject{UserId: member.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.LiftEmailSuppression404JSONResponse{}, response, "nothing left to lift")
+	})
+
+	t.Run("needs manage_users", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageUsers, nil, false, nil)
+		response, err := server.LiftEmailSuppression(ctx, api.LiftEmailSuppressionRequestObject{UserId: member.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.LiftEmailSuppression403JSONResponse{}, response)
+	})
+}
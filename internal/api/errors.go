@@ -1,93 +1,43 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+
 	genapi "github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/apierror"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/borrowingpolicy"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/queue"
 )
 
 const (
-	CodeValidationError   = "VALIDATION_ERROR"
-	CodeAuthRequired      = "AUTHENTICATION_REQUIRED"
-	CodePermissionDenied  = "PERMISSION_DENIED"
-	CodeResourceNotFound  = "RESOURCE_NOT_FOUND"
-	CodeInsufficientStock = "INSUFFICIENT_STOCK"
-	CodeConflict          = "CONFLICT"
-	CodeInternalError     = "INTERNAL_ERROR"
+	CodeValidationError   = apierror.CodeValidation
+	CodeAuthRequired      = apierror.CodeAuthRequired
+	CodePermissionDenied  = apierror.CodePermissionDenied
+	CodeResourceNotFound  = apierror.CodeNotFound
+	CodeInsufficientStock = apierror.CodeInsufficientStock
+	CodePolicyViolation   = apierror.CodePolicyViolation
+	CodeConflict          = apierror.CodeConflict
+	CodeRateLimited       = apierror.CodeRateLimited
+	CodeInternalError     = apierror.CodeInternal
+	CodeTimeout           = apierror.CodeTimeout
+	CodeUnavailable       = apierror.CodeUnavailable
 )
 
-type ErrorDetail struct {
-	Field   string
-	Message string
-}
+type ErrorDetail = apierror.Detail
 
 // additional error context
 type ErrorContext map[string]interface{}
 
 // builder pattern
-type ErrorBuilder struct {
-	Code    string
-	Message string
-	Details []ErrorDetail
-	Context ErrorContext
-}
-
-func NewError(code, message string) *ErrorBuilder {
-	return &ErrorBuilder{Code: code, Message: message}
-}
-
-func (e *ErrorBuilder) WithDetails(details []ErrorDetail) *ErrorBuilder {
-	e.Details = details
-	return e
-}
+type ErrorBuilder = apierror.Error
 
-func (e *ErrorBuilder) WithContext(context ErrorContext) *ErrorBuilder {
-	e.Context = context
-	return e
-}
-
-func (e *ErrorBuilder) Create() genapi.Error {
-	var details *[]struct {
-		Field   string `json:"field"`
-		Message string `json:"message"`
-	}
-	if len(e.Details) > 0 {
-		apiDetails := make([]struct {
-			Field   string `json:"field"`
-			Message string `json:"message"`
-		}, len(e.Details))
-		for i, d := range e.Details {
-			apiDetails[i] = struct {
-				Field   string `json:"field"`
-				Message string `json:"message"`
-			}{
-				Field:   d.Field,
-				Message: d.Message,
-			}
-		}
-		details = &apiDetails
-	}
-
-	var context *map[string]interface{}
-	if len(e.Context) > 0 {
-		ctx := map[string]interface{}(e.Context)
-		context = &ctx
-	}
-
-	return genapi.Error{
-		Error: struct {
-			Code    genapi.ErrorErrorCode   `json:"code"`
-			Context *map[string]interface{} `json:"context,omitempty"`
-			Details *[]struct {
-				Field   string `json:"field"`
-				Message string `json:"message"`
-			} `json:"details,omitempty"`
-			Message string `json:"message"`
-		}{
-			Code:    genapi.ErrorErrorCode(e.Code),
-			Message: e.Message,
-			Details: details,
-			Context: context,
-		},
-	}
+func NewError(code apierror.Code, message string) *ErrorBuilder {
+	return apierror.New(code, message)
 }
 
 // builder pattern extensions
@@ -117,6 +67,14 @@ func InsufficientStockErr(itemName string, requested, available int) *ErrorBuild
 		})
 }
 
+func PolicyViolationErr(v *borrowingpolicy.Violation) *ErrorBuilder {
+	errorContext := ErrorContext{"rule": string(v.Rule)}
+	if v.Limit > 0 {
+		errorContext["limit"] = v.Limit
+	}
+	return NewError(CodePolicyViolation, v.Message).WithContext(errorContext)
+}
+
 func InternalError(msg string) *ErrorBuilder {
 	return NewError(CodeInternalError, msg)
 }
@@ -124,3 +82,97 @@ func InternalError(msg string) *ErrorBuilder {
 func ConflictErr(msg string) *ErrorBuilder {
 	return NewError(CodeConflict, msg)
 }
+
+func RateLimited(msg string) *ErrorBuilder {
+	return NewError(CodeRateLimited, msg)
+}
+
+func Unavailable(msg string) *ErrorBuilder {
+	return NewError(CodeUnavailable, msg)
+}
+
+// how errors from the domain packages read to clients. Handlers go through
+// errorFor rather than writing their own message, so the same failure gets
+// the same code and wording from every endpoint.
+var domainErrors = []struct {
+	target  error
+	code    apierror.Code
+	message string
+}{
+	{auth.ErrOTPCooldown, CodeRateLimited, "Please wait before requesting another code."},
+	{auth.ErrLoginThrottled, CodeRateLimited, "Too many attempts. Please try again later."},
+	{auth.ErrAccountLocked, CodeRateLimited, "Too many attempts. Please try again later."},
+	{auth.ErrOTPInvalid, CodeValidationError, "Invalid or expired code."},
+	{auth.ErrOTPMaxAttempts, CodeValidationError, "Invalid or expired code."},
+	{auth.ErrRefreshInvalid, CodeAuthRequired, "Invalid or expired refresh token."},
+	{auth.ErrMFACodeInvalid, CodeValidationError, "Invalid code."},
+	{auth.ErrMFAMaxAttempts, CodeRateLimited, "Too many attempts. Please wait before trying again."},
+	{auth.ErrMFAAlreadyEnrolled, CodeConflict, "Two-factor authentication is already enabled."},
+	{auth.ErrMFANotPending, CodeConflict, "Start two-factor enrollment first."},
+	{auth.ErrMFANotEnrolled, CodeConflict, "Two-factor authentication is not enabled."},
+	{queue.ErrQueueNotFound, CodeResourceNotFound, "Queue not found"},
+	{context.DeadlineExceeded, CodeTimeout, "Request timed out"},
+}
+
+// the client-facing error for err: err itself when it is (or wraps) one,
+// its domainErrors entry, or otherwise an internal error caused by err.
+func errorFor(err error) *ErrorBuilder {
+	var apiErr *ErrorBuilder
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	for _, d := range domainErrors {
+		if errors.Is(err, d.target) {
+			return NewError(d.code, d.message).WithCause(err)
+		}
+	}
+	return InternalError("An unexpected error occurred.").WithCause(err)
+}
+
+// RequestErrorHandler answers requests the generated code could not decode,
+// such as a malformed JSON body or a path parameter of the wrong type.
+func RequestErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	apierror.Write(w, ValidationErr(err.Error(), nil), middleware.GetRequestID(r.Context()))
+}
+
+// ResponseErrorHandler answers handlers that returned an error instead of a
+// response, in the catalogue's terms via errorFor.
+func ResponseErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := errorFor(err)
+	if apiErr.Code == CodeInternalError {
+		middleware.GetLoggerFromContext(r.Context()).Error("Handler failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+	apierror.Write(w, apiErr, middleware.GetRequestID(r.Context()))
+}
+
+// ValidatorErrorHandler answers requests the OpenAPI validator rejected. The
+// validator does not pass the request along, so the request ID is read back
+// from the response header RequestContext set.
+func ValidatorErrorHandler(w http.ResponseWriter, message string, statusCode int) {
+	apierror.WriteStatus(w, statusCode, NewError(apierror.CodeFor(statusCode), message), w.Header().Get(middleware.RequestIDHeader))
+}
+
+var errorType = reflect.TypeFor[genapi.Error]()
+
+// StampRequestID is strict middleware that fills in the request ID of every
+// error response, so handlers never have to.
+func StampRequestID(next genapi.StrictHandlerFunc, operationID string) genapi.StrictHandlerFunc {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		response, err := next(ctx, w, r, request)
+		if err != nil {
+			return response, err
+		}
+		return stampResponse(response, middleware.GetRequestID(ctx)), nil
+	}
+}
+
+// error responses are each operation's own named copy of genapi.Error, as
+// in GetItem404JSONResponse, so they are found by their shape
+func stampResponse(response any, requestID string) any {
+	v := reflect.ValueOf(response)
+	if !v.IsValid() || v.Kind() != reflect.Struct || !v.Type().ConvertibleTo(errorType) {
+		return response
+	}
+	body := apierror.Stamp(v.Convert(errorType).Interface().(genapi.Error), requestID)
+	return reflect.ValueOf(body).Convert(v.Type()).Interface()
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorFor(t *testing.T) {
+	t.Run("domain errors map to their catalogue entry", func(t *testing.T) {
+		err := errorFor(fmt.Errorf("pausing: %w", queue.ErrQueueNotFound))
+		assert.Equal(t, CodeResourceNotFound, err.Code)
+		assert.Equal(t, "Queue not found", err.Message)
+		assert.ErrorIs(t, err, queue.ErrQueueNotFound)
+
+		assert.Equal(t, CodeRateLimited, errorFor(auth.ErrOTPCooldown).Code)
+		assert.Equal(t, CodeConflict, errorFor(auth.ErrMFANotEnrolled).Code)
+	})
+
+	t.Run("client-facing errors pass through", func(t *testing.T) {
+		err := errorFor(fmt.Errorf("resolving: %w", errFineResolved))
+		assert.Same(t, errFineResolved, err)
+	})
+
+	t.Run("anything else is internal", func(t *testing.T) {
+		cause := errors.New("connection reset")
+		err := errorFor(cause)
+		assert.Equal(t, CodeInternalError, err.Code)
+		assert.Equal(t, "An unexpected error occurred.", err.Message)
+		assert.ErrorIs(t, err, cause)
+	})
+}
+
+func TestStampRequestID(t *testing.T) {
+	// RequestContext puts the ID on the request context
+	var ctx context.Context
+	middleware.RequestContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	requestID := middleware.GetRequestID(ctx)
+	require.NotEmpty(t, requestID)
+
+	run := func(response interface{}) interface{} {
+		next := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+			return response, nil
+		}
+		got, err := StampRequestID(next, "GetItemById")(ctx, httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), nil)
+		require.NoError(t, err)
+		return got
+	}
+
+	stamped, ok := run(api.GetItemById404JSONResponse(NotFound("Item").Create())).(api.GetItemById404JSONResponse)
+	require.True(t, ok, "response keeps its type")
+	require.NotNil(t, stamped.Error.RequestId)
+	assert.Equal(t, requestID, *stamped.Error.RequestId)
+
+	success := api.HealthCheck200JSONResponse{Status: "ok"}
+	assert.Equal(t, success, run(success))
+	assert.Nil(t, run(nil))
+}
+
+func TestResponseErrorHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	ResponseErrorHandler(w, httptest.NewRequest(http.MethodPost, "/queues/x/pause", nil), fmt.Errorf("pausing: %w", queue.ErrQueueNotFound))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var body api.Error
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, api.RESOURCENOTFOUND, body.Error.Code)
+
+	w = httptest.NewRecorder()
+	ResponseErrorHandler(w, httptest.NewRequest(http.MethodGet, "/items", nil), errors.New("pq: relation does not exist"))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "relation")
+}
+
+func TestValidatorErrorHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set(middleware.RequestIDHeader, "req-1")
+	ValidatorErrorHandler(w, "request body has an error: doesn't match schema", http.StatusBadRequest)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var body api.Error
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, api.VALIDATIONERROR, body.Error.Code)
+	require.NotNil(t, body.Error.RequestId)
+	assert.Equal(t, "req-1", *body.Error.RequestId)
+}
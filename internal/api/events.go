@@ -0,0 +1,308 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/events"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/webhooks"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// how often an idle stream sends a comment so proxies keep it open.
+const eventStreamHeartbeat = 30 * time.Second
+
+func (s Server) StreamEvents(ctx context.Context, request api.StreamEventsRequestObject) (api.StreamEventsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.StreamEvents401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	viewAll, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewAllData, nil)
+	if err != nil {
+		logger.Error("Error checking view_all_data permission", "user_id", user.ID, "error", err)
+		return api.StreamEvents500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !viewAll {
+		viewGroup, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewGroupData, nil)
+		if err != nil {
+			logger.Error("Error checking view_group_data permission", "user_id", user.ID, "error", err)
+			return api.StreamEvents500JSONResponse(InternalError("Internal server error").Create()), nil
+		}
+		if !viewGroup {
+			return api.StreamEvents403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+		}
+	}
+
+	types, err := parseEventTypes(request.Params.Types)
+	if err != nil {
+		return api.StreamEvents400JSONResponse(ValidationErr(err.Error(), nil).Create()), nil
+	}
+
+	if request.Params.GroupId != nil && !viewAll {
+		allowed, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewGroupData, request.Params.GroupId)
+		if err != nil {
+			logger.Error("Error checking view_group_data permission", "user_id", user.ID, "group_id", request.Params.GroupId, "error", err)
+			return api.StreamEvents500JSONResponse(InternalError("Internal server error").Create()), nil
+		}
+		if !allowed {
+			return api.StreamEvents403JSONResponse(PermissionDenied("Insufficient permissions for this group").Create()), nil
+		}
+	}
+
+	stream, unsubscribe, err := s.events.Subscribe()
+	if err != nil {
+		logger.Error("Event broker unavailable", "user_id", user.ID, "error", err)
+		return api.StreamEvents503JSONResponse(Unavailable("Event stream is unavailable").Create()), nil
+	}
+	logger.Info("Event stream opened", "user_id", user.ID, "view_all", viewAll)
+	return eventStreamResponse{
+		ctx:         ctx,
+		events:      stream,
+		unsubscribe: unsubscribe,
+		userID:      user.ID,
+		filter: &eventFilter{
+			server:  s,
+			userID:  user.ID,
+			viewAll: viewAll,
+			types:   types,
+			groupID: request.Params.GroupId,
+			groups:  make(map[uuid.UUID]bool),
+		},
+	}, nil
+}
+
+func (s Server) StreamMyEvents(ctx context.Context, request api.StreamMyEventsRequestObject) (api.StreamMyEventsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.StreamMyEvents401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	types, err := parseEventTypes(request.Params.Types)
+	if err != nil {
+		return api.StreamMyEvents400JSONResponse(ValidationErr(err.Error(), nil).Create()), nil
+	}
+
+	stream, unsubscribe, err := s.events.Subscribe()
+	if err != nil {
+		logger.Error("Event broker unavailable", "user_id", user.ID, "error", err)
+		return api.StreamMyEvents503JSONResponse(Unavailable("Event stream is unavailable").Create()), nil
+	}
+	logger.Info("Personal event stream opened", "user_id", user.ID)
+	return eventStreamResponse{
+		ctx:         ctx,
+		events:      stream,
+		unsubscribe: unsubscribe,
+		userID:      user.ID,
+		filter: &myEventFilter{
+			server: s,
+			user:   user,
+			types:  types,
+			items:  make(map[uuid.UUID]bool),
+		},
+	}, nil
+}
+
+// the types named by a comma-separated types parameter, or nil for all of them
+func parseEventTypes(param *string) (map[events.Type]bool, error) {
+	if param == nil || *param == "" {
+		return nil, nil
+	}
+	types := make(map[events.Type]bool)
+	for _, name := range strings.Split(*param, ",") {
+		name = strings.TrimSpace(name)
+		if !events.Valid(name) {
+			return nil, fmt.Errorf("unknown event type: %s", name)
+		}
+		types[events.Type(name)] = true
+	}
+	return types, nil
+}
+
+// decides whether a stream's user receives an event
+type streamFilter interface {
+	allows(ctx context.Context, e events.Event) (bool, error)
+}
+
+// decides which events a stream's user may see and asked for. Group
+// permissions are looked up once per group and kept for the stream's life.
+type eventFilter struct {
+	server  Server
+	userID  uuid.UUID
+	viewAll bool
+	types   map[events.Type]bool
+	groupID *uuid.UUID
+	groups  map[uuid.UUID]bool
+}
+
+func (f *eventFilter) allows(ctx context.Context, e events.Event) (bool, error) {
+	if f.types != nil && !f.types[e.Type] {
+		return false, nil
+	}
+	if f.groupID != nil && (e.GroupID == nil || *e.GroupID != *f.groupID) {
+		return false, nil
+	}
+	if f.viewAll {
+		return true, nil
+	}
+	if e.GroupID == nil {
+		return false, nil
+	}
+	allowed, seen := f.groups[*e.GroupID]
+	if !seen {
+		var err error
+		allowed, err = f.server.authenticator.CheckPermission(ctx, f.userID, rbac.ViewGroupData, e.GroupID)
+		if err != nil {
+			return false, err
+		}
+		f.groups[*e.GroupID] = allowed
+	}
+	return allowed, nil
+}
+
+// passes the events about the user themselves, and stock changes to items
+// they can see. Visibility is looked up once per item and kept for the
+// stream's life.
+type myEventFilter struct {
+	server Server
+	user   *auth.AuthenticatedUser
+	types  map[events.Type]bool
+	items  map[uuid.UUID]bool
+}
+
+func (f *myEventFilter) allows(ctx context.Context, e events.Event) (bool, error) {
+	if f.types != nil && !f.types[e.Type] {
+		return false, nil
+	}
+	if e.Type != events.StockChanged {
+		return e.UserID != nil && *e.UserID == f.user.ID, nil
+	}
+	if e.ItemID == nil {
+		return false, nil
+	}
+	visible, seen := f.items[*e.ItemID]
+	if !seen {
+		item, err := f.server.db.Queries().GetItemByID(ctx, *e.ItemID)
+		if err == pgx.ErrNoRows {
+			f.items[*e.ItemID] = false
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		visible, err = f.server.canSeeItem(ctx, f.user, item)
+		if err != nil {
+			return false, err
+		}
+		f.items[*e.ItemID] = visible
+	}
+	return visible, nil
+}
+
+// writes events as they arrive until the client goes away. Implements
+// StreamEventsResponseObject and StreamMyEventsResponseObject so the strict
+// handler hands it the writer.
+type eventStreamResponse struct {
+	ctx         context.Context
+	events      <-chan events.Event
+	unsubscribe func()
+	userID      uuid.UUID
+	filter      streamFilter
+}
+
+func (r eventStreamResponse) VisitStreamEventsResponse(w http.ResponseWriter) error {
+	return r.stream(w)
+}
+
+func (r eventStreamResponse) VisitStreamMyEventsResponse(w http.ResponseWriter) error {
+	return r.stream(w)
+}
+
+func (r eventStreamResponse) stream(w http.ResponseWriter) error {
+	logger := middleware.GetLoggerFromContext(r.ctx)
+
+	defer r.unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// nginx buffers responses unless told otherwise
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		return err
+	}
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return nil
+			}
+		case e, ok := <-r.events:
+			if !ok {
+				return nil
+			}
+			allowed, err := r.filter.allows(r.ctx, e)
+			if err != nil {
+				logger.Error("Failed to check event permission", "user_id", r.userID, "error", err)
+				continue
+			}
+			if !allowed {
+				continue
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return nil
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return nil
+		}
+	}
+}
+
+// announces a change to connected dashboards and subscribed webhooks. The
+// change has already been committed, so a failure is logged rather than
+// returned.
+func (s Server) publishEvent(ctx context.Context, e events.Event) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+	if err := s.events.Publish(ctx, e); err != nil {
+		logger.Error("Failed to publish event", "type", e.Type, "entity_id", e.EntityID, "error", err)
+	}
+	if err := webhooks.NewPublisher(s.db.Queries(), s.queue).Publish(ctx, e); err != nil {
+		logger.Error("Failed to queue webhook deliveries", "type", e.Type, "entity_id", e.EntityID, "error", err)
+	}
+}
+
+// announces that an item's stock moved, once the change is committed, and
+// drops the cached listings showing the old stock.
+func (s Server) publishStockChanged(ctx context.Context, itemID uuid.UUID) {
+	s.invalidateCatalog(ctx)
+	s.publishEvent(ctx, events.Event{Type: events.StockChanged, EntityID: itemID, ItemID: &itemID})
+}
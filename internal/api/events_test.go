@@ -0,0 +1,215 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/events"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// a ResponseWriter whose body can be read while the stream is still writing.
+type streamRecorder struct {
+	mu     sync.Mutex
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (r *streamRecorder) Header() http.Header { return r.header }
+func (r *streamRecorder) WriteHeader(int)     {}
+func (r *streamRecorder) Flush()              {}
+
+func (r *streamRecorder) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.body.Write(b)
+}
+
+func (r *streamRecorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.body.String()
+}
+
+func TestServer_StreamEvents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	broker := events.NewBroker(sharedQueue.Redis)
+	require.NoError(t, broker.Start(context.Background()))
+	defer broker.Close()
+	server.events = broker
+
+	groupAdmin := testDB.NewUser(t).WithEmail("admin@events.test").AsMember().Create()
+	member := testDB.NewUser(t).WithEmail("member@events.test").AsMember().Create()
+	ownGroup := testDB.NewGroup(t).WithName("Own Club").Create()
+	otherGroup := testDB.NewGroup(t).WithName("Other Club").Create()
+
+	mockAuth.ExpectCheckPermission(groupAdmin.ID, rbac.ViewAllData, nil, false, nil)
+	mockAuth.ExpectCheckPermission(groupAdmin.ID, rbac.ViewGroupData, nil, true, nil)
+	mockAuth.ExpectCheckPermission(groupAdmin.ID, rbac.ViewGroupData, &ownGroup.ID, true, nil)
+	mockAuth.ExpectCheckPermission(groupAdmin.ID, rbac.ViewGroupData, &otherGroup.ID, false, nil)
+
+	t.Run("group admins see their groups' events", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(testutil.ContextWithUser(context.Background(), groupAdmin, testDB.Queries()))
+		defer cancel()
+
+		response, err := server.StreamEvents(ctx, api.StreamEventsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, eventStreamResponse{}, response)
+
+		w := &streamRecorder{header: make(http.Header)}
+		done := make(chan error, 1)
+		go func() {
+			done <- response.VisitStreamEventsResponse(w)
+		}()
+
+		hidden := uuid.New()
+		shown := uuid.New()
+		server.publishEvent(ctx, events.Event{Type: events.ItemReturned, EntityID: hidden, GroupID: &otherGroup.ID})
+		server.publishEvent(ctx, events.Event{Type: events.RequestPending, EntityID: shown, GroupID: &ownGroup.ID})
+
+		require.Eventually(t, func() bool {
+			return strings.Contains(w.String(), shown.String())
+		}, 5*time.Second, 20*time.Millisecond)
+		cancel()
+		require.NoError(t, <-done)
+
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.String(), "event: request.pending\ndata: {")
+		assert.NotContains(t, w.String(), hidden.String())
+	})
+
+	t.Run("unknown event type", func(t *testing.T) {
+		ctx := testutil.ContextWithUser(context.Background(), groupAdmin, testDB.Queries())
+		types := "request.pending,booking.deleted"
+		response, err := server.StreamEvents(ctx, api.StreamEventsRequestObject{
+			Params: api.StreamEventsParams{Types: &types},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.StreamEvents400JSONResponse{}, response)
+	})
+
+	t.Run("group filter needs access to the group", func(t *testing.T) {
+		ctx := testutil.ContextWithUser(context.Background(), groupAdmin, testDB.Queries())
+		response, err := server.StreamEvents(ctx, api.StreamEventsRequestObject{
+			Params: api.StreamEventsParams{GroupId: &otherGroup.ID},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.StreamEvents403JSONResponse{}, response)
+	})
+
+	t.Run("stopped broker", func(t *testing.T) {
+		stopped := events.NewBroker(sharedQueue.Redis)
+		require.NoError(t, stopped.Start(context.Background()))
+		require.NoError(t, stopped.Close())
+		require.Eventually(t, func() bool {
+			_, _, err := stopped.Subscribe()
+			return err != nil
+		}, 5*time.Second, 20*time.Millisecond)
+
+		server.events = stopped
+		defer func() { server.events = broker }()
+
+		ctx := testutil.ContextWithUser(context.Background(), groupAdmin, testDB.Queries())
+		response, err := server.StreamEvents(ctx, api.StreamEventsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.StreamEvents503JSONResponse{}, response)
+	})
+
+	t.Run("members are refused", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewAllData, nil, false, nil)
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewGroupData, nil, false, nil)
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		response, err := server.StreamEvents(ctx, api.StreamEventsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.StreamEvents403JSONResponse{}, response)
+	})
+}
+
+func TestServer_StreamMyEvents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, _ := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	broker := events.NewBroker(sharedQueue.Redis)
+	require.NoError(t, broker.Start(context.Background()))
+	defer broker.Close()
+	server.events = broker
+
+	ownGroup := testDB.NewGroup(t).WithName("Own Club").Create()
+	otherGroup := testDB.NewGroup(t).WithName("Other Club").Create()
+	member := testDB.NewUser(t).WithEmail("member@myevents.test").AsMemberOf(ownGroup).Create()
+	other := testDB.NewUser(t).WithEmail("other@myevents.test").AsMemberOf(otherGroup).Create()
+	open := testDB.NewItem(t).WithName("Tripod").WithType("medium").WithStock(2).Create()
+	hidden := testDB.NewItem(t).WithName("Telescope").WithType("medium").WithStock(1).Create()
+	require.NoError(t, testDB.Queries().SetItemOwner(context.Background(), db.SetItemOwnerParams{ID: hidden.ID, OwnerGroupID: &otherGroup.ID}))
+
+	t.Run("own events and visible stock changes", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(testutil.ContextWithUser(context.Background(), member, testDB.Queries()))
+		defer cancel()
+
+		response, err := server.StreamMyEvents(ctx, api.StreamMyEventsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, eventStreamResponse{}, response)
+
+		w := &streamRecorder{header: make(http.Header)}
+		done := make(chan error, 1)
+		go func() {
+			done <- response.VisitStreamMyEventsResponse(w)
+		}()
+
+		theirs := uuid.New()
+		mine := uuid.New()
+		server.publishEvent(ctx, events.Event{Type: events.RequestApproved, EntityID: theirs, GroupID: &otherGroup.ID, UserID: &other.ID})
+		server.publishEvent(ctx, events.Event{Type: events.RequestDenied, EntityID: mine, GroupID: &ownGroup.ID, UserID: &member.ID})
+		server.publishStockChanged(ctx, hidden.ID)
+		server.publishStockChanged(ctx, open.ID)
+
+		require.Eventually(t, func() bool {
+			return strings.Contains(w.String(), open.ID.String())
+		}, 5*time.Second, 20*time.Millisecond)
+		cancel()
+		require.NoError(t, <-done)
+
+		assert.Contains(t, w.String(), "event: request.denied\ndata: {")
+		assert.Contains(t, w.String(), mine.String())
+		assert.Contains(t, w.String(), "event: item.stock_changed\ndata: {")
+		assert.NotContains(t, w.String(), theirs.String())
+		assert.NotContains(t, w.String(), hidden.ID.String())
+	})
+
+	t.Run("unknown event type", func(t *testing.T) {
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		types := "request.denied,booking.deleted"
+		response, err := server.StreamMyEvents(ctx, api.StreamMyEventsRequestObject{
+			Params: api.StreamMyEventsParams{Types: &types},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.StreamMyEvents400JSONResponse{}, response)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		response, err := server.StreamMyEvents(context.Background(), api.StreamMyEventsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.StreamMyEvents401JSONResponse{}, response)
+	})
+}
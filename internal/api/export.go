@@ -0,0 +1,223 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// rows read per query while an export streams
+const exportPageSize = 500
+
+// whether the caller asked for CSV. Accept: text/csv arrives here as
+// format=csv; see middleware.NegotiateExport.
+func wantsCSV(format *api.ExportFormat) bool {
+	return format != nil && *format == api.ExportFormatCsv
+}
+
+// reads one page of an export as CSV records
+type exportPage func(ctx context.Context, limit, offset int64) ([][]string, error)
+
+// streams header and then every page in turn, read as the client takes them,
+// so large exports never sit in memory. The status is sent by the time a
+// later page fails, so the error cuts the body short and is logged.
+func streamCSV(ctx context.Context, header []string, page exportPage) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		writer := csv.NewWriter(pw)
+		err := func() error {
+			if err := writer.Write(header); err != nil {
+				return err
+			}
+			for offset := int64(0); ; offset += exportPageSize {
+				records, err := page(ctx, exportPageSize, offset)
+				if err != nil {
+					return err
+				}
+				// flushes, so each page reaches the client as it is read
+				if err := writer.WriteAll(records); err != nil {
+					return err
+				}
+				if len(records) < exportPageSize {
+					return nil
+				}
+			}
+		}()
+		// a client that went away closes the pipe; nobody is left to tell
+		if err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			middleware.GetLoggerFromContext(ctx).Error("Export stopped part way through", "error", err)
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// a whole report as CSV; reports are small enough to be written in one go
+func writeCSV(header []string, records [][]string) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// CSV layouts use the JSON field names as column headers, so an export reads
+// the same as the list it came from
+
+var requestColumns = []string{
+	"id", "user_id", "group_id", "item_id", "quantity", "status",
+	"reviewed_by", "reviewed_at", "override_justification",
+}
+
+func requestRecord(r api.RequestItemResponse) []string {
+	return []string{
+		r.Id.String(), r.UserId.String(), r.GroupId.String(), r.ItemId.String(),
+		strconv.Itoa(r.Quantity), string(r.Status),
+		csvUUID(r.ReviewedBy), csvTime(r.ReviewedAt), csvString(r.OverrideJustification),
+	}
+}
+
+var bookingColumns = []string{
+	"id", "status", "requester_id", "requester_email", "item_id", "item_name", "item_type", "group_name",
+	"availability_id", "availability_date", "start_time", "end_time",
+	"pick_up_date", "pick_up_location", "return_date", "return_location",
+	"manager_id", "manager_email", "confirm_by", "confirmed_by", "confirmed_at", "is_test", "created_at",
+}
+
+func bookingRecord(b api.BookingResponse) []string {
+	var itemType, availabilityDate string
+	if b.ItemType != nil {
+		itemType = string(*b.ItemType)
+	}
+	if b.AvailabilityDate != nil {
+		availabilityDate = b.AvailabilityDate.String()
+	}
+	return []string{
+		b.Id.String(), string(b.Status), b.RequesterId.String(), csvString(b.RequesterEmail),
+		b.ItemId.String(), csvString(b.ItemName), itemType, csvString(b.GroupName),
+		b.AvailabilityId.String(), availabilityDate, csvString(b.StartTime), csvString(b.EndTime),
+		b.PickUpDate.Format(time.RFC3339), b.PickUpLocation, b.ReturnDate.Format(time.RFC3339), b.ReturnLocation,
+		csvUUID(b.ManagerId), csvString(b.ManagerEmail), csvTime(b.ConfirmBy), csvUUID(b.ConfirmedBy), csvTime(b.ConfirmedAt),
+		strconv.FormatBool(b.IsTest), b.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+var borrowingColumns = []string{
+	"id", "user_id", "group_id", "item_id", "quantity", "borrowed_at", "due_date", "returned_at",
+	"before_condition", "after_condition", "damage_report_id",
+}
+
+func borrowingRecord(b api.BorrowingResponse) []string {
+	return []string{
+		b.Id.String(), b.UserId.String(), csvUUID(b.GroupId), b.ItemId.String(), strconv.Itoa(b.Quantity),
+		b.BorrowedAt.Format(time.RFC3339), b.DueDate.Format(time.RFC3339), csvTime(b.ReturnedAt),
+		b.BeforeCondition, csvString(b.AfterCondition), csvUUID(b.DamageReportId),
+	}
+}
+
+var utilizationColumns = []string{
+	"item_id", "item_name", "period_start", "period_end", "borrowings", "unit_days_borrowed", "utilization",
+}
+
+func utilizationRecord(u api.ItemUtilization) []string {
+	return []string{
+		u.ItemId.String(), u.ItemName, u.PeriodStart.String(), u.PeriodEnd.String(),
+		strconv.Itoa(u.Borrowings), csvFloat(u.UnitDaysBorrowed), csvFloat(u.Utilization),
+	}
+}
+
+var topItemColumns = []string{"item_id", "item_name", "item_type", "borrowings", "takings", "units"}
+
+func topItemRecord(i api.TopItem) []string {
+	return []string{
+		i.ItemId.String(), i.ItemName, string(i.ItemType),
+		strconv.Itoa(i.Borrowings), strconv.Itoa(i.Takings), strconv.Itoa(i.Units),
+	}
+}
+
+var groupActivityColumns = []string{
+	"group_id", "group_name", "requests", "bookings", "borrowings", "takings", "active_users",
+}
+
+func groupActivityRecord(g api.GroupActivity) []string {
+	return []string{
+		g.GroupId.String(), g.GroupName, strconv.Itoa(g.Requests), strconv.Itoa(g.Bookings),
+		strconv.Itoa(g.Borrowings), strconv.Itoa(g.Takings), strconv.Itoa(g.ActiveUsers),
+	}
+}
+
+// one row per group, then the totals under an empty group_id
+var overdueColumns = []string{
+	"group_id", "group_name", "due", "returned_on_time", "returned_late", "still_overdue", "average_days_late",
+}
+
+func overdueRecords(summary api.OverdueSummary) [][]string {
+	records := make([][]string, 0, len(summary.Groups)+1)
+	for _, g := range summary.Groups {
+		records = append(records, []string{
+			g.GroupId.String(), g.GroupName, strconv.Itoa(g.Due), strconv.Itoa(g.ReturnedOnTime),
+			strconv.Itoa(g.ReturnedLate), strconv.Itoa(g.StillOverdue), csvFloat(g.AverageDaysLate),
+		})
+	}
+	return append(records, []string{
+		"", "Total", strconv.Itoa(summary.Due), strconv.Itoa(summary.ReturnedOnTime),
+		strconv.Itoa(summary.ReturnedLate), strconv.Itoa(summary.StillOverdue), csvFloat(summary.AverageDaysLate),
+	})
+}
+
+// one row per count; missing units are separated by spaces
+var stocktakeCountColumns = []string{
+	"item_id", "item_name", "expected", "counted", "discrepancy", "missing_unit_ids",
+	"notes", "counted_by", "counted_at", "movement_id",
+}
+
+func stocktakeCountRecord(c api.StocktakeCount) []string {
+	missing := make([]string, 0, len(c.MissingUnitIds))
+	for _, id := range c.MissingUnitIds {
+		missing = append(missing, id.String())
+	}
+	return []string{
+		c.ItemId.String(), c.ItemName, strconv.Itoa(c.Expected), strconv.Itoa(c.Counted), strconv.Itoa(c.Discrepancy),
+		strings.Join(missing, " "), csvString(c.Notes), csvUUID(c.CountedBy), csvTime(&c.CountedAt), csvUUID(c.MovementId),
+	}
+}
+
+// absent values are empty cells
+
+func csvString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func csvUUID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func csvFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamCSV(t *testing.T) {
+	t.Run("reads pages until one comes back short", func(t *testing.T) {
+		rows := exportPageSize + 3
+		var offsets []int64
+		body := streamCSV(context.Background(), []string{"n"}, func(ctx context.Context, limit, offset int64) ([][]string, error) {
+			offsets = append(offsets, offset)
+			var records [][]string
+			for i := offset; i < offset+limit && i < int64(rows); i++ {
+				records = append(records, []string{strconv.FormatInt(i, 10)})
+			}
+			return records, nil
+		})
+		defer body.Close()
+
+		records, err := csv.NewReader(body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, rows+1)
+		assert.Equal(t, []string{"n"}, records[0])
+		assert.Equal(t, []string{strconv.Itoa(rows - 1)}, records[rows])
+		assert.Equal(t, []int64{0, exportPageSize}, offsets)
+	})
+
+	t.Run("a failing page cuts the body short", func(t *testing.T) {
+		failed := errors.New("connection reset")
+		body := streamCSV(context.Background(), []string{"n"}, func(ctx context.Context, limit, offset int64) ([][]string, error) {
+			if offset > 0 {
+				return nil, failed
+			}
+			return make([][]string, limit), nil
+		})
+		defer body.Close()
+
+		_, err := io.ReadAll(body)
+		assert.ErrorIs(t, err, failed)
+	})
+}
+
+func TestServer_GetBorrowedItemHistoryByUserId_CSV(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	member := testDB.NewUser(t).WithEmail("history-export@export.test").AsMember().Create()
+	first := createBorrowing(t, testDB, member.ID)
+	second := createBorrowing(t, testDB, member.ID)
+
+	mockAuth.ExpectCheckPermission(member.ID, rbac.ViewOwnData, nil, true, nil)
+	format := api.ExportFormatCsv
+	limit := 1
+	response, err := server.GetBorrowedItemHistoryByUserId(testutil.ContextWithUser(context.Background(), member, testDB.Queries()), api.GetBorrowedItemHistoryByUserIdRequestObject{
+		UserId: member.ID,
+		Params: api.GetBorrowedItemHistoryByUserIdParams{Limit: &limit, Format: &format},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.GetBorrowedItemHistoryByUserId200TextcsvResponse{}, response)
+
+	export := response.(api.GetBorrowedItemHistoryByUserId200TextcsvResponse)
+	assert.Equal(t, 2, export.Headers.XTotalCount)
+	records, err := csv.NewReader(export.Body).ReadAll()
+	require.NoError(t, err)
+
+	// the limit is ignored; every row is exported
+	require.Len(t, records, 3)
+	assert.Equal(t, borrowingColumns, records[0])
+	ids := []string{records[1][0], records[2][0]}
+	assert.ElementsMatch(t, []string{first.ID.String(), second.ID.String()}, ids)
+}
+
+func TestServer_GetTopItemsReport_CSV(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	admin := testDB.NewUser(t).WithEmail("top-items-export@export.test").AsGlobalAdmin().Create()
+	borrowing := createBorrowing(t, testDB, admin.ID)
+
+	mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewAllData, nil, true, nil)
+	format := api.ExportFormatCsv
+	response, err := server.GetTopItemsReport(testutil.ContextWithUser(context.Background(), admin, testDB.Queries()), api.GetTopItemsReportRequestObject{
+		Params: api.GetTopItemsReportParams{Format: &format},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.GetTopItemsReport200TextcsvResponse{}, response)
+
+	export := response.(api.GetTopItemsReport200TextcsvResponse)
+	records, err := csv.NewReader(export.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, topItemColumns, records[0])
+	assert.Equal(t, borrowing.ItemID.String(), records[1][0])
+	assert.Equal(t, "1", records[1][3])
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/fairness"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s Server) SetItemFairnessPolicy(ctx context.Context, request api.SetItemFairnessPolicyRequestObject) (api.SetItemFairnessPolicyResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.SetItemFairnessPolicy401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.SetItemFairnessPolicy500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.SetItemFairnessPolicy403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.SetItemFairnessPolicy400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	windowDays := fairness.DefaultWindowDays
+	if request.Body.WindowDays != nil {
+		windowDays = *request.Body.WindowDays
+	}
+	if windowDays < 1 {
+		return api.SetItemFairnessPolicy400JSONResponse(ValidationErr("window_days must be at least 1", nil).Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetItemByID(ctx, request.ItemId); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.SetItemFairnessPolicy404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.SetItemFairnessPolicy500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	policy, err := s.db.Queries().UpsertFairnessPolicy(ctx, db.UpsertFairnessPolicyParams{
+		ItemID:     request.ItemId,
+		WindowDays: int32(windowDays),
+		CreatedBy:  &user.ID,
+	})
+	if err != nil {
+		logger.Error("Failed to set fairness policy", "item_id", request.ItemId, "error", err)
+		return api.SetItemFairnessPolicy500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Fairness policy set", "item_id", policy.ItemID, "window_days", policy.WindowDays, "user_id", user.ID)
+	return api.SetItemFairnessPolicy200JSONResponse{
+		ItemId:     policy.ItemID,
+		WindowDays: int(policy.WindowDays),
+		CreatedBy:  policy.CreatedBy,
+		CreatedAt:  policy.CreatedAt.Time,
+	}, nil
+}
+
+func (s Server) RemoveItemFairnessPolicy(ctx context.Context, request api.RemoveItemFairnessPolicyRequestObject) (api.RemoveItemFairnessPolicyResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RemoveItemFairnessPolicy401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.RemoveItemFairnessPolicy500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.RemoveItemFairnessPolicy403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	deleted, err := s.db.Queries().DeleteFairnessPolicy(ctx, request.ItemId)
+	if err != nil {
+		logger.Error("Failed to remove fairness policy", "item_id", request.ItemId, "error", err)
+		return api.RemoveItemFairnessPolicy500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if deleted == 0 {
+		return api.RemoveItemFairnessPolicy404JSONResponse(NotFound("Fairness policy").Create()), nil
+	}
+
+	logger.Info("Fairness policy removed", "item_id", request.ItemId, "user_id", user.ID)
+	return api.RemoveItemFairnessPolicy204Response{}, nil
+}
+
+// pending requests for an item in fairness order along with the policy
+// window. Items without a policy return no candidates.
+func rankPendingRequests(ctx context.Context, q *db.Queries, itemID uuid.UUID) ([]fairness.Candidate, int, error) {
+	policy, err := q.GetFairnessPolicy(ctx, itemID)
+	if err == pgx.ErrNoRows {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := q.ListPendingRequestCandidates(ctx, &itemID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	candidates := make([]fairness.Candidate, 0, len(rows))
+	for _, row := range rows {
+		c := fairness.Candidate{RequestID: row.ID, RequestedAt: row.RequestedAt.Time}
+		if row.GroupLastHadAt.Valid {
+			lastHad := row.GroupLastHadAt.Time
+			c.LastHadAt = &lastHad
+		}
+		candidates = append(candidates, c)
+	}
+	return fairness.Rank(candidates, time.Now(), int(policy.WindowDays)), int(policy.WindowDays), nil
+}
+
+// fairness details for each request on a policy item, keyed by request ID.
+func (s Server) requestFairness(ctx context.Context, requests []db.Request) (map[uuid.UUID]api.RequestFairness, error) {
+	result := make(map[uuid.UUID]api.RequestFairness)
+	seen := make(map[uuid.UUID]bool)
+	now := time.Now()
+
+	for _, req := range requests {
+		if req.ItemID == nil || seen[*req.ItemID] {
+			continue
+		}
+		seen[*req.ItemID] = true
+
+		ranked, windowDays, err := rankPendingRequests(ctx, s.db.Queries(), *req.ItemID)
+		if err != nil {
+			return nil, err
+		}
+		for i, c := range ranked {
+			result[c.RequestID] = api.RequestFairness{
+				Priority:       fairness.Priority(c.LastHadAt, now, windowDays),
+				Rank:           i + 1,
+				GroupLastHadAt: c.LastHadAt,
+			}
+		}
+	}
+	return result, nil
+}
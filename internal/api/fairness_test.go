@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_SetItemFairnessPolicy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@fairness.test").AsGlobalAdmin().Create()
+	item := testDB.NewItem(t).WithName("Projector").WithType("high").Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	t.Run("defaults the window", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.SetItemFairnessPolicy(ctx, api.SetItemFairnessPolicyRequestObject{
+			ItemId: item.ID,
+			Body:   &api.SetFairnessPolicyRequest{},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetItemFairnessPolicy200JSONResponse{}, response)
+		assert.Equal(t, 90, response.(api.SetItemFairnessPolicy200JSONResponse).WindowDays)
+	})
+
+	t.Run("unknown item", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.SetItemFairnessPolicy(ctx, api.SetItemFairnessPolicyRequestObject{
+			ItemId: uuid.New(),
+			Body:   &api.SetFairnessPolicyRequest{},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetItemFairnessPolicy404JSONResponse{}, response)
+	})
+
+	t.Run("remove policy", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.RemoveItemFairnessPolicy(ctx, api.RemoveItemFairnessPolicyRequestObject{ItemId: item.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.RemoveItemFairnessPolicy204Response{}, response)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err = server.RemoveItemFairnessPolicy(ctx, api.RemoveItemFairnessPolicyRequestObject{ItemId: item.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.RemoveItemFairnessPolicy404JSONResponse{}, response)
+	})
+}
+
+func TestServer_FairnessRanking(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	ctx := context.Background()
+	testDB.CleanupDatabase(t)
+
+	approver := testDB.NewUser(t).WithEmail("approver@fairness.test").AsApprover().Create()
+	frequentUser := testDB.NewUser(t).WithEmail("frequent@fairness.test").AsMember().Create()
+	newUser := testDB.NewUser(t).WithEmail("new@fairness.test").AsMember().Create()
+	frequentGroup := testDB.NewGroup(t).WithName("Frequent Club").Create()
+	newGroup := testDB.NewGroup(t).WithName("New Club").Create()
+	item := testDB.NewItem(t).WithName("Drone").WithType("high").WithStock(3).Create()
+
+	_, err := testDB.Queries().UpsertFairnessPolicy(ctx, db.UpsertFairnessPolicyParams{
+		ItemID:     item.ID,
+		WindowDays: 90,
+		CreatedBy:  &approver.ID,
+	})
+	require.NoError(t, err)
+
+	// the frequent club had the drone last week
+	_, err = testDB.Queries().BorrowItem(ctx, db.BorrowItemParams{
+		UserID:          &frequentUser.ID,
+		GroupID:         &frequentGroup.ID,
+		ID:              item.ID,
+		Quantity:        1,
+		DueDate:         pgtype.Timestamp{Time: time.Now().Add(24 * time.Hour), Valid: true},
+		BeforeCondition: "good",
+	})
+	require.NoError(t, err)
+
+	frequentReq, err := testDB.Queries().RequestItem(ctx, db.RequestItemParams{
+		UserID: &frequentUser.ID, GroupID: &frequentGroup.ID, ID: item.ID, Quantity: 1,
+	})
+	require.NoError(t, err)
+	newReq, err := testDB.Queries().RequestItem(ctx, db.RequestItemParams{
+		UserID: &newUser.ID, GroupID: &newGroup.ID, ID: item.ID, Quantity: 1,
+	})
+	require.NoError(t, err)
+
+	approverCtx := testutil.ContextWithUser(ctx, approver, testDB.Queries())
+
+	// the group that never had the item is first in line despite asking later
+	mockAuth.ExpectCheckPermission(approver.ID, rbac.ApproveAllRequests, nil, true, nil)
+	listResp, err := server.GetPendingRequests(approverCtx, api.GetPendingRequestsRequestObject{})
+	require.NoError(t, err)
+	list := listResp.(api.GetPendingRequests200JSONResponse).Body
+	require.Len(t, list.Data, 2)
+	for _, r := range list.Data {
+		require.NotNil(t, r.Fairness)
+		switch r.Id {
+		case newReq.ID:
+			assert.Equal(t, 1, r.Fairness.Rank)
+			assert.Equal(t, 90, r.Fairness.Priority)
+			assert.Nil(t, r.Fairness.GroupLastHadAt)
+		case frequentReq.ID:
+			assert.Equal(t, 2, r.Fairness.Rank)
+			assert.Equal(t, 0, r.Fairness.Priority)
+			assert.NotNil(t, r.Fairness.GroupLastHadAt)
+		}
+	}
+
+	timeSlots, err := testDB.Queries().ListTimeSlots(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, timeSlots)
+	availability, err := testDB.Queries().CreateAvailability(ctx, db.CreateAvailabilityParams{
+		ID:         uuid.New(),
+		UserID:     &approver.ID,
+		TimeSlotID: &timeSlots[0].ID,
+		Date:       pgtype.Date{Time: time.Now().Add(24 * time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+	pickup, dropoff := "Office", "Office"
+
+	// skipping the new club needs a justification
+	mockAuth.ExpectCheckPermission(approver.ID, rbac.ApproveAllRequests, nil, true, nil)
+	response, err := server.ReviewRequest(approverCtx, api.ReviewRequestRequestObject{
+		RequestId: frequentReq.ID,
+		Body: &api.ReviewRequestJSONRequestBody{
+			Status:         api.RequestStatusApproved,
+			AvailabilityId: &availability.ID,
+			PickupLocation: &pickup,
+			ReturnLocation: &dropoff,
+		},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.ReviewRequest409JSONResponse{}, response)
+
+	justification := "New Club's event was cancelled"
+	mockAuth.ExpectCheckPermission(approver.ID, rbac.ApproveAllRequests, nil, true, nil)
+	response, err = server.ReviewRequest(approverCtx, api.ReviewRequestRequestObject{
+		RequestId: frequentReq.ID,
+		Body: &api.ReviewRequestJSONRequestBody{
+			Status:                api.RequestStatusApproved,
+			AvailabilityId:        &availability.ID,
+			PickupLocation:        &pickup,
+			ReturnLocation:        &dropoff,
+			OverrideJustification: &justification,
+		},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.ReviewRequest200JSONResponse{}, response)
+	approved := response.(api.ReviewRequest200JSONResponse)
+	require.NotNil(t, approved.OverrideJustification)
+	assert.Equal(t, justification, *approved.OverrideJustification)
+}
@@ -0,0 +1,407 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/fines"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func toFineResponse(fine db.Fine) api.Fine {
+	resp := api.Fine{
+		Id:             fine.ID,
+		UserId:         fine.UserID,
+		BorrowingId:    fine.BorrowingID,
+		ItemId:         fine.ItemID,
+		DamageReportId: fine.DamageReportID,
+		Reason:         api.FineReason(fine.Reason),
+		AmountCents:    int64(fine.AmountCents),
+		Status:         api.FineStatus(fine.Status),
+		ResolvedBy:     fine.ResolvedBy,
+		CreatedAt:      fine.CreatedAt.Time,
+	}
+	if fine.DaysLate.Valid {
+		days := int(fine.DaysLate.Int32)
+		resp.DaysLate = &days
+	}
+	if fine.ResolutionNotes.Valid {
+		resp.ResolutionNotes = &fine.ResolutionNotes.String
+	}
+	if fine.ResolvedAt.Valid {
+		resp.ResolvedAt = &fine.ResolvedAt.Time
+	}
+	return resp
+}
+
+// charges the borrower of a just-returned borrowing the item's late fee if it
+// came back after its due date, and its damage fee if a damage report was
+// opened for it. Items without fees set are never fined.
+func assessFines(ctx context.Context, qtx *db.Queries, borrowing db.Borrowing, report *db.DamageReport) ([]db.Fine, error) {
+	if borrowing.UserID == nil || borrowing.ItemID == nil {
+		return nil, nil
+	}
+
+	fees, err := qtx.GetItemFees(ctx, *borrowing.ItemID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var charged []db.Fine
+	if days := fines.DaysLate(borrowing.DueDate.Time, borrowing.ReturnedAt.Time); days > 0 && fees.LateFeeCents > 0 {
+		fine, err := qtx.CreateFine(ctx, db.CreateFineParams{
+			UserID:      *borrowing.UserID,
+			BorrowingID: borrowing.ID,
+			ItemID:      *borrowing.ItemID,
+			Reason:      db.FineReasonLate,
+			AmountCents: fines.LateFee(fees.LateFeeCents, borrowing.Quantity, days),
+			DaysLate:    pgtype.Int4{Int32: int32(days), Valid: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+		charged = append(charged, fine)
+	}
+
+	if report != nil && fees.DamageFeeCents > 0 {
+		fine, err := qtx.CreateFine(ctx, db.CreateFineParams{
+			UserID:         *borrowing.UserID,
+			BorrowingID:    borrowing.ID,
+			ItemID:         *borrowing.ItemID,
+			DamageReportID: &report.ID,
+			Reason:         db.FineReasonDamage,
+			AmountCents:    fees.DamageFeeCents,
+		})
+		if err != nil {
+			return nil, err
+		}
+		charged = append(charged, fine)
+	}
+	return charged, nil
+}
+
+// whether the user owes enough in unpaid fines that they may not borrow or
+// request anything more, and how much that is.
+func (s Server) blockedByFines(ctx context.Context, userID uuid.UUID) (bool, int64, error) {
+	if s.finePolicy.BlockThresholdCents <= 0 {
+		return false, 0, nil
+	}
+	unpaid, err := s.db.Queries().GetUnpaidFineTotal(ctx, userID)
+	if err != nil {
+		return false, 0, err
+	}
+	return s.finePolicy.Blocks(unpaid), unpaid, nil
+}
+
+func finesBlockedErr(unpaid int64) *ErrorBuilder {
+	return PermissionDenied("Unpaid fines must be settled before borrowing more items").
+		WithContext(ErrorContext{"unpaid_total_cents": unpaid})
+}
+
+func (s Server) ListMyFines(ctx context.Context, request api.ListMyFinesRequestObject) (api.ListMyFinesResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListMyFines401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewOwnData, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ViewOwnData permission", "error", err)
+		return api.ListMyFines500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListMyFines403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	var status db.NullFineStatus
+	if request.Params.Status != nil {
+		status = db.NullFineStatus{FineStatus: db.FineStatus(*request.Params.Status), Valid: true}
+	}
+
+	// a borrower's fines are few enough to return in one page
+	list, err := s.db.Queries().ListFines(ctx, db.ListFinesParams{
+		Status: status,
+		UserID: &user.ID,
+		Limit:  1000,
+		Offset: 0,
+	})
+	if err != nil {
+		logger.Error("Failed to list fines", "user_id", user.ID, "error", err)
+		return api.ListMyFines500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	unpaid, err := s.db.Queries().GetUnpaidFineTotal(ctx, user.ID)
+	if err != nil {
+		logger.Error("Failed to total unpaid fines", "user_id", user.ID, "error", err)
+		return api.ListMyFines500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	data := make([]api.Fine, 0, len(list))
+	for _, fine := range list {
+		data = append(data, toFineResponse(fine))
+	}
+
+	return api.ListMyFines200JSONResponse{
+		Fines:            data,
+		UnpaidTotalCents: unpaid,
+	}, nil
+}
+
+func (s Server) ListFines(ctx context.Context, request api.ListFinesRequestObject) (api.ListFinesResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListFines401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewAllData, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ViewAllData permission", "error", err)
+		return api.ListFines500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListFines403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	var status db.NullFineStatus
+	if request.Params.Status != nil {
+		status = db.NullFineStatus{FineStatus: db.FineStatus(*request.Params.Status), Valid: true}
+	}
+
+	list, err := s.db.Queries().ListFines(ctx, db.ListFinesParams{
+		Status: status,
+		UserID: request.Params.UserId,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		logger.Error("Failed to list fines", "error", err)
+		return api.ListFines500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountFines(ctx, db.CountFinesParams{
+		Status: status,
+		UserID: request.Params.UserId,
+	})
+	if err != nil {
+		logger.Error("Failed to count fines", "error", err)
+		return api.ListFines500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	data := make([]api.Fine, 0, len(list))
+	for _, fine := range list {
+		data = append(data, toFineResponse(fine))
+	}
+
+	return api.ListFines200JSONResponse{
+		Data: data,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
+
+func (s Server) PayFine(ctx context.Context, request api.PayFineRequestObject) (api.PayFineResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.PayFine401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageAllBookings, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageAllBookings permission", "error", err)
+		return api.PayFine500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.PayFine403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	fine, err := s.resolveFine(ctx, request.FineId, db.FineStatusPaid, user.ID, request.Body)
+	if err != nil {
+		switch apiErr := errorFor(err); apiErr.Code {
+		case CodeResourceNotFound:
+			return api.PayFine404JSONResponse(apiErr.Create()), nil
+		case CodeConflict:
+			return api.PayFine409JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to mark fine paid", "fine_id", request.FineId, "error", err)
+		return api.PayFine500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Fine paid", "fine_id", fine.ID, "amount_cents", fine.AmountCents, "user_id", user.ID)
+	return api.PayFine200JSONResponse(toFineResponse(fine)), nil
+}
+
+func (s Server) WaiveFine(ctx context.Context, request api.WaiveFineRequestObject) (api.WaiveFineResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.WaiveFine401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageAllBookings, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageAllBookings permission", "error", err)
+		return api.WaiveFine500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.WaiveFine403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	fine, err := s.resolveFine(ctx, request.FineId, db.FineStatusWaived, user.ID, request.Body)
+	if err != nil {
+		switch apiErr := errorFor(err); apiErr.Code {
+		case CodeResourceNotFound:
+			return api.WaiveFine404JSONResponse(apiErr.Create()), nil
+		case CodeConflict:
+			return api.WaiveFine409JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to waive fine", "fine_id", request.FineId, "error", err)
+		return api.WaiveFine500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Fine waived", "fine_id", fine.ID, "amount_cents", fine.AmountCents, "user_id", user.ID)
+	return api.WaiveFine200JSONResponse(toFineResponse(fine)), nil
+}
+
+// returned as client-facing errors, so handlers pass them through errorFor
+var (
+	errFineNotFound = NotFound("Fine")
+	errFineResolved = ConflictErr("Fine has already been paid or waived")
+)
+
+// settles an unpaid fine as paid or waived.
+func (s Server) resolveFine(ctx context.Context, fineID uuid.UUID, status db.FineStatus, resolvedBy uuid.UUID, body *api.ResolveFineRequest) (db.Fine, error) {
+	if _, err := s.db.Queries().GetFineByID(ctx, fineID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.Fine{}, errFineNotFound
+		}
+		return db.Fine{}, err
+	}
+
+	var notes pgtype.Text
+	if body != nil && body.Notes != nil && *body.Notes != "" {
+		notes = pgtype.Text{String: *body.Notes, Valid: true}
+	}
+
+	fine, err := s.db.Queries().ResolveFine(ctx, db.ResolveFineParams{
+		ID:              fineID,
+		Status:          status,
+		ResolutionNotes: notes,
+		ResolvedBy:      &resolvedBy,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Fine{}, errFineResolved
+	}
+	return fine, err
+}
+
+func (s Server) GetItemFees(ctx context.Context, request api.GetItemFeesRequestObject) (api.GetItemFeesResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetItemFees401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ViewItems permission", "error", err)
+		return api.GetItemFees500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetItemFees403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetItemByID(ctx, request.ItemId); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.GetItemFees404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.GetItemFees500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	// items nobody has set fees for are free of them
+	fees, err := s.db.Queries().GetItemFees(ctx, request.ItemId)
+	if err != nil && err != pgx.ErrNoRows {
+		logger.Error("Failed to get item fees", "item_id", request.ItemId, "error", err)
+		return api.GetItemFees500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	return api.GetItemFees200JSONResponse{
+		ItemId:         request.ItemId,
+		LateFeeCents:   int(fees.LateFeeCents),
+		DamageFeeCents: int(fees.DamageFeeCents),
+	}, nil
+}
+
+func (s Server) SetItemFees(ctx context.Context, request api.SetItemFeesRequestObject) (api.SetItemFeesResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.SetItemFees401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.SetItemFees500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.SetItemFees403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.SetItemFees400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	if request.Body.LateFeeCents < 0 || request.Body.DamageFeeCents < 0 {
+		return api.SetItemFees400JSONResponse(ValidationErr("Fees cannot be negative", nil).Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetItemByID(ctx, request.ItemId); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.SetItemFees404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.SetItemFees500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	fees, err := s.db.Queries().UpsertItemFees(ctx, db.UpsertItemFeesParams{
+		ItemID:         request.ItemId,
+		LateFeeCents:   int32(request.Body.LateFeeCents),
+		DamageFeeCents: int32(request.Body.DamageFeeCents),
+		UpdatedBy:      &user.ID,
+	})
+	if err != nil {
+		logger.Error("Failed to set item fees", "item_id", request.ItemId, "error", err)
+		return api.SetItemFees500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Item fees set",
+		"item_id", fees.ItemID,
+		"late_fee_cents", fees.LateFeeCents,
+		"damage_fee_cents", fees.DamageFeeCents,
+		"user_id", user.ID)
+	return api.SetItemFees200JSONResponse{
+		ItemId:         fees.ItemID,
+		LateFeeCents:   int(fees.LateFeeCents),
+		DamageFeeCents: int(fees.DamageFeeCents),
+	}, nil
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// a borrowing of an item with fees set that fell due the given time ago.
+func createFinedBorrowing(t *testing.T, testDB *testutil.TestDatabase, userID uuid.UUID, overdue time.Duration, lateFee, damageFee int32) db.Borrowing {
+	t.Helper()
+	ctx := context.Background()
+
+	borrowing := createBorrowing(t, testDB, userID)
+	_, err := testDB.Queries().UpsertItemFees(ctx, db.UpsertItemFeesParams{
+		ItemID:         *borrowing.ItemID,
+		LateFeeCents:   lateFee,
+		DamageFeeCents: damageFee,
+	})
+	require.NoError(t, err)
+
+	// set against the database clock, which stamps the return
+	_, err = testDB.Pool().Exec(ctx, `UPDATE borrowings SET due_date = NOW() - make_interval(secs => $2) WHERE id = $1`,
+		borrowing.ID, overdue.Seconds())
+	require.NoError(t, err)
+	return borrowing
+}
+
+func createUnpaidFine(t *testing.T, testDB *testutil.TestDatabase, userID uuid.UUID, amount int32) db.Fine {
+	t.Helper()
+	borrowing := createBorrowing(t, testDB, userID)
+	fine, err := testDB.Queries().CreateFine(context.Background(), db.CreateFineParams{
+		UserID:      userID,
+		BorrowingID: borrowing.ID,
+		ItemID:      *borrowing.ItemID,
+		Reason:      db.FineReasonLate,
+		AmountCents: amount,
+	})
+	require.NoError(t, err)
+	return fine
+}
+
+func TestServer_ReturnItem_Fines(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("late return is charged per part day", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@fines.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		borrowing := createFinedBorrowing(t, testDB, member.ID, 50*time.Hour, 100, 2500)
+
+		returnBorrowing(t, server, mockAuth, ctx, member.ID, borrowing, api.ReturnBorrowingRequest{AfterCondition: "good"})
+
+		charged, err := testDB.Queries().ListFinesByBorrowing(ctx, borrowing.ID)
+		require.NoError(t, err)
+		require.Len(t, charged, 1)
+		assert.Equal(t, db.FineReasonLate, charged[0].Reason)
+		assert.Equal(t, int32(3), charged[0].DaysLate.Int32)
+		assert.Equal(t, int32(300), charged[0].AmountCents)
+		assert.Equal(t, db.FineStatusUnpaid, charged[0].Status)
+	})
+
+	t.Run("damaged return is charged the damage fee", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@fines.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		borrowing := createFinedBorrowing(t, testDB, member.ID, -24*time.Hour, 100, 2500)
+
+		returned := returnBorrowing(t, server, mockAuth, ctx, member.ID, borrowing, api.ReturnBorrowingRequest{AfterCondition: "damaged"})
+		require.NotNil(t, returned.DamageReportId)
+
+		charged, err := testDB.Queries().ListFinesByBorrowing(ctx, borrowing.ID)
+		require.NoError(t, err)
+		require.Len(t, charged, 1, "returned before the due date, so no late fine")
+		assert.Equal(t, db.FineReasonDamage, charged[0].Reason)
+		assert.Equal(t, int32(2500), charged[0].AmountCents)
+		assert.Equal(t, returned.DamageReportId, charged[0].DamageReportID)
+	})
+
+	t.Run("items without fees are never fined", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@fines.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		borrowing := createBorrowing(t, testDB, member.ID)
+		_, err := testDB.Pool().Exec(ctx, `UPDATE borrowings SET due_date = NOW() - INTERVAL '3 days' WHERE id = $1`, borrowing.ID)
+		require.NoError(t, err)
+
+		returnBorrowing(t, server, mockAuth, ctx, member.ID, borrowing, api.ReturnBorrowingRequest{AfterCondition: "damaged"})
+
+		charged, err := testDB.Queries().ListFinesByBorrowing(ctx, borrowing.ID)
+		require.NoError(t, err)
+		assert.Empty(t, charged)
+	})
+}
+
+func TestServer_ListMyFines(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	member := testDB.NewUser(t).WithEmail("member@fines.test").AsMember().Create()
+	other := testDB.NewUser(t).WithEmail("other@fines.test").AsMember().Create()
+	createUnpaidFine(t, testDB, member.ID, 300)
+	createUnpaidFine(t, testDB, member.ID, 450)
+	createUnpaidFine(t, testDB, other.ID, 999)
+	ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+	mockAuth.ExpectCheckPermission(member.ID, rbac.ViewOwnData, nil, true, nil)
+	response, err := server.ListMyFines(ctx, api.ListMyFinesRequestObject{})
+	require.NoError(t, err)
+	require.IsType(t, api.ListMyFines200JSONResponse{}, response)
+
+	mine := response.(api.ListMyFines200JSONResponse)
+	assert.Len(t, mine.Fines, 2)
+	assert.Equal(t, int64(750), mine.UnpaidTotalCents)
+}
+
+func TestServer_ResolveFine(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("waive then pay conflicts", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@fines.test").AsGlobalAdmin().Create()
+		member := testDB.NewUser(t).WithEmail("member@fines.test").AsMember().Create()
+		fine := createUnpaidFine(t, testDB, member.ID, 300)
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		notes := "first offence"
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAllBookings, nil, true, nil)
+		response, err := server.WaiveFine(ctx, api.WaiveFineRequestObject{
+			FineId: fine.ID,
+			Body:   &api.ResolveFineRequest{Notes: &notes},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.WaiveFine200JSONResponse{}, response)
+
+		waived := response.(api.WaiveFine200JSONResponse)
+		assert.Equal(t, api.Waived, waived.Status)
+		assert.Equal(t, notes, *waived.ResolutionNotes)
+		assert.Equal(t, admin.ID, *waived.ResolvedBy)
+		assert.NotNil(t, waived.ResolvedAt)
+
+		payResponse, err := server.PayFine(ctx, api.PayFineRequestObject{FineId: fine.ID, Body: &api.ResolveFineRequest{}})
+		require.NoError(t, err)
+		require.IsType(t, api.PayFine409JSONResponse{}, payResponse)
+	})
+
+	t.Run("pay clears the block", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@fines.test").AsGlobalAdmin().Create()
+		member := testDB.NewUser(t).WithEmail("member@fines.test").AsMember().Create()
+		fine := createUnpaidFine(t, testDB, member.ID, 1500)
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		blocked, _, err := server.blockedByFines(ctx, member.ID)
+		require.NoError(t, err)
+		assert.True(t, blocked)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAllBookings, nil, true, nil)
+		response, err := server.PayFine(ctx, api.PayFineRequestObject{FineId: fine.ID, Body: &api.ResolveFineRequest{}})
+		require.NoError(t, err)
+		require.IsType(t, api.PayFine200JSONResponse{}, response)
+		assert.Equal(t, api.Paid, response.(api.PayFine200JSONResponse).Status)
+
+		blocked, _, err = server.blockedByFines(ctx, member.ID)
+		require.NoError(t, err)
+		assert.False(t, blocked)
+	})
+
+	t.Run("unknown fine", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@fines.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAllBookings, nil, true, nil)
+		response, err := server.PayFine(ctx, api.PayFineRequestObject{FineId: uuid.New(), Body: &api.ResolveFineRequest{}})
+		require.NoError(t, err)
+		require.IsType(t, api.PayFine404JSONResponse{}, response)
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@fines.test").AsMember().Create()
+		fine := createUnpaidFine(t, testDB, member.ID, 300)
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageAllBookings, nil, false, nil)
+		response, err := server.WaiveFine(ctx, api.WaiveFineRequestObject{FineId: fine.ID, Body: &api.ResolveFineRequest{}})
+		require.NoError(t, err)
+		require.IsType(t, api.WaiveFine403JSONResponse{}, response)
+	})
+}
+
+func TestServer_BorrowItem_BlockedByFines(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	member := testDB.NewUser(t).WithEmail("member@fines.test").AsMember().Create()
+	group := testDB.NewGroup(t).WithName("Fined Group").Create()
+	item := testDB.NewItem(t).WithName("Projector").WithType("medium").WithStock(5).Create()
+	createUnpaidFine(t, testDB, member.ID, 1500)
+	ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+	mockAuth.ExpectCheckPermission(member.ID, rbac.RequestItems, &group.ID, true, nil)
+	response, err := server.BorrowItem(ctx, api.BorrowItemRequestObject{
+		Body: &api.BorrowItemJSONRequestBody{
+			UserId:             member.ID,
+			GroupId:            group.ID,
+			ItemId:             item.ID,
+			Quantity:           1,
+			DueDate:            time.Now().Add(7 * 24 * time.Hour),
+			BeforeCondition:    "good",
+			BeforeConditionUrl: "http://example.com/before.jpg",
+		},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.BorrowItem403JSONResponse{}, response)
+
+	denied := response.(api.BorrowItem403JSONResponse)
+	assert.Equal(t, int64(1500), (*denied.Error.Context)["unpaid_total_cents"])
+}
+
+func TestServer_SetItemFees(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@fines.test").AsGlobalAdmin().Create()
+	item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewItems, nil, true, nil)
+	response, err := server.GetItemFees(ctx, api.GetItemFeesRequestObject{ItemId: item.ID})
+	require.NoError(t, err)
+	require.IsType(t, api.GetItemFees200JSONResponse{}, response)
+	assert.Zero(t, response.(api.GetItemFees200JSONResponse).LateFeeCents, "unset fees are zero")
+
+	mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+	setResponse, err := server.SetItemFees(ctx, api.SetItemFeesRequestObject{
+		ItemId: item.ID,
+		Body:   &api.SetItemFeesRequest{LateFeeCents: 200, DamageFeeCents: 5000},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.SetItemFees200JSONResponse{}, setResponse)
+	assert.Equal(t, 5000, setResponse.(api.SetItemFees200JSONResponse).DamageFeeCents)
+
+	setResponse, err = server.SetItemFees(ctx, api.SetItemFeesRequestObject{
+		ItemId: item.ID,
+		Body:   &api.SetItemFeesRequest{LateFeeCents: -1},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.SetItemFees400JSONResponse{}, setResponse)
+
+	setResponse, err = server.SetItemFees(ctx, api.SetItemFeesRequestObject{
+		ItemId: uuid.New(),
+		Body:   &api.SetItemFeesRequest{LateFeeCents: 100},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.SetItemFees404JSONResponse{}, setResponse)
+}
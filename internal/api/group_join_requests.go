@@ -0,0 +1,364 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+func toGroupJoinRequestResponse(req db.GroupJoinRequest, email string) api.GroupJoinRequest {
+	resp := api.GroupJoinRequest{
+		Id:        req.ID,
+		GroupId:   req.GroupID,
+		UserId:    req.UserID,
+		UserEmail: openapi_types.Email(email),
+		Status:    api.GroupJoinRequestStatus(req.Status),
+		DecidedBy: req.DecidedBy,
+		CreatedAt: req.CreatedAt.Time,
+	}
+	if req.Message.Valid {
+		resp.Message = &req.Message.String
+	}
+	if req.DecisionNotes.Valid {
+		resp.DecisionNotes = &req.DecisionNotes.String
+	}
+	if req.DecidedAt.Valid {
+		resp.DecidedAt = &req.DecidedAt.Time
+	}
+	return resp
+}
+
+func (s Server) RequestGroupJoin(ctx context.Context, request api.RequestGroupJoinRequestObject) (api.RequestGroupJoinResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RequestGroupJoin401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	group, err := s.db.Queries().GetGroupByID(ctx, request.Id)
+	if err == pgx.ErrNoRows {
+		return api.RequestGroupJoin404JSONResponse(NotFound("Group").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get group", "group_id", request.Id, "error", err)
+		return api.RequestGroupJoin500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	member, err := s.db.Queries().IsUserMemberOfGroup(ctx, db.IsUserMemberOfGroupParams{
+		UserID:  &user.ID,
+		ScopeID: &group.ID,
+	})
+	if err != nil {
+		logger.Error("Failed to check group membership", "group_id", group.ID, "error", err)
+		return api.RequestGroupJoin500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if member {
+		return api.RequestGroupJoin409JSONResponse(ConflictErr("You already have a role in this group").Create()), nil
+	}
+
+	params := db.CreateGroupJoinRequestParams{
+		GroupID: group.ID,
+		UserID:  user.ID,
+	}
+	if request.Body != nil && request.Body.Message != nil && *request.Body.Message != "" {
+		params.Message = pgtype.Text{String: *request.Body.Message, Valid: true}
+	}
+
+	joinReq, err := s.db.Queries().CreateGroupJoinRequest(ctx, params)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return api.RequestGroupJoin409JSONResponse(ConflictErr("You already have a request to join this group awaiting a decision").Create()), nil
+		}
+		logger.Error("Failed to create join request", "group_id", group.ID, "error", err)
+		return api.RequestGroupJoin500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	s.notifyGroupJoinRequested(ctx, user, group, joinReq)
+
+	logger.Info("Group join requested",
+		"join_request_id", joinReq.ID,
+		"group_id", group.ID,
+		"user_id", user.ID)
+	return api.RequestGroupJoin201JSONResponse(toGroupJoinRequestResponse(joinReq, user.Email)), nil
+}
+
+func (s Server) ListGroupJoinRequests(ctx context.Context, request api.ListGroupJoinRequestsRequestObject) (api.ListGroupJoinRequestsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListGroupJoinRequests401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageGroupUsers, &request.Id)
+	if err != nil {
+		logger.Error("Error checking manage_group_users permission", "error", err)
+		return api.ListGroupJoinRequests500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListGroupJoinRequests403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetGroupByID(ctx, request.Id); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.ListGroupJoinRequests404JSONResponse(NotFound("Group").Create()), nil
+		}
+		logger.Error("Failed to get group", "group_id", request.Id, "error", err)
+		return api.ListGroupJoinRequests500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	// admins come here for the queue, so that is what they get unasked
+	status := db.JoinRequestStatusPending
+	if request.Params.Status != nil {
+		status = db.JoinRequestStatus(*request.Params.Status)
+	}
+
+	list, err := s.db.Queries().ListGroupJoinRequests(ctx, db.ListGroupJoinRequestsParams{
+		GroupID: request.Id,
+		Status:  status,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		logger.Error("Failed to list join requests", "group_id", request.Id, "error", err)
+		return api.ListGroupJoinRequests500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountGroupJoinRequests(ctx, db.CountGroupJoinRequestsParams{
+		GroupID: request.Id,
+		Status:  status,
+	})
+	if err != nil {
+		logger.Error("Failed to count join requests", "group_id", request.Id, "error", err)
+		return api.ListGroupJoinRequests500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(list))
+	for _, joinReq := range list {
+		userIDs = append(userIDs, joinReq.UserID)
+	}
+	users, err := s.db.Queries().GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		logger.Error("Failed to get requesting users", "group_id", request.Id, "error", err)
+		return api.ListGroupJoinRequests500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	emails := make(map[uuid.UUID]string, len(users))
+	for _, u := range users {
+		emails[u.ID] = u.Email
+	}
+
+	data := make([]api.GroupJoinRequest, 0, len(list))
+	for _, joinReq := range list {
+		data = append(data, toGroupJoinRequestResponse(joinReq, emails[joinReq.UserID]))
+	}
+
+	return api.ListGroupJoinRequests200JSONResponse{
+		Data: data,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
+
+func (s Server) DecideGroupJoinRequest(ctx context.Context, request api.DecideGroupJoinRequestRequestObject) (api.DecideGroupJoinRequestResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.DecideGroupJoinRequest401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageGroupUsers, &request.Id)
+	if err != nil {
+		logger.Error("Error checking manage_group_users permission", "error", err)
+		return api.DecideGroupJoinRequest500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.DecideGroupJoinRequest403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.DecideGroupJoinRequest400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	approve := request.Body.Status == api.GroupJoinRequestStatusApproved
+	if !approve && request.Body.Status != api.GroupJoinRequestStatusDenied {
+		return api.DecideGroupJoinRequest400JSONResponse(ValidationErr("status must be 'approved' or 'denied'", nil).Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to start transaction", "error", err)
+		return api.DecideGroupJoinRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.db.Queries().WithTx(tx)
+
+	// a request for another group is as good as missing to this group's admins
+	existing, err := qtx.GetGroupJoinRequestByID(ctx, request.RequestId)
+	if err == pgx.ErrNoRows || (err == nil && existing.GroupID != request.Id) {
+		return api.DecideGroupJoinRequest404JSONResponse(NotFound("Join request").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get join request", "join_request_id", request.RequestId, "error", err)
+		return api.DecideGroupJoinRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	var notes pgtype.Text
+	if request.Body.Notes != nil && *request.Body.Notes != "" {
+		notes = pgtype.Text{String: *request.Body.Notes, Valid: true}
+	}
+
+	joinReq, err := qtx.DecideGroupJoinRequest(ctx, db.DecideGroupJoinRequestParams{
+		ID:            existing.ID,
+		Status:        db.JoinRequestStatus(request.Body.Status),
+		DecisionNotes: notes,
+		DecidedBy:     &user.ID,
+	})
+	if err == pgx.ErrNoRows {
+		return api.DecideGroupJoinRequest409JSONResponse(ConflictErr("Join request has already been decided").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to decide join request", "join_request_id", existing.ID, "error", err)
+		return api.DecideGroupJoinRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	requester, err := qtx.GetUserByID(ctx, joinReq.UserID)
+	if err != nil {
+		logger.Error("Failed to get requesting user", "user_id", joinReq.UserID, "error", err)
+		return api.DecideGroupJoinRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if approve {
+		// an admin may have added the user by hand while the request waited
+		member, err := qtx.IsUserMemberOfGroup(ctx, db.IsUserMemberOfGroupParams{
+			UserID:  &joinReq.UserID,
+			ScopeID: &joinReq.GroupID,
+		})
+		if err != nil {
+			logger.Error("Failed to check group membership", "group_id", joinReq.GroupID, "error", err)
+			return api.DecideGroupJoinRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		if !member {
+			if err := qtx.CreateUserRole(ctx, db.CreateUserRoleParams{
+				UserID:   &joinReq.UserID,
+				RoleName: pgtype.Text{String: rbac.RoleMember, Valid: true},
+				Scope:    db.ScopeTypeGroup,
+				ScopeID:  &joinReq.GroupID,
+			}); err != nil {
+				logger.Error("Failed to assign member role", "user_id", joinReq.UserID, "group_id", joinReq.GroupID, "error", err)
+				return api.DecideGroupJoinRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+			}
+			if err := qtx.CreateRoleChange(ctx, db.CreateRoleChangeParams{
+				Action:    db.RoleChangeActionUserRoleAssigned,
+				RoleName:  rbac.RoleMember,
+				UserID:    &joinReq.UserID,
+				Scope:     db.NullScopeType{ScopeType: db.ScopeTypeGroup, Valid: true},
+				ScopeID:   &joinReq.GroupID,
+				ChangedBy: &user.ID,
+			}); err != nil {
+				logger.Error("Failed to record role change", "user_id", joinReq.UserID, "error", err)
+				return api.DecideGroupJoinRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit join request decision", "join_request_id", joinReq.ID, "error", err)
+		return api.DecideGroupJoinRequest500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if approve {
+		s.authenticator.InvalidateUserPermissions(ctx, joinReq.UserID)
+	}
+
+	s.notifyGroupJoinDecided(ctx, user.ID, joinReq)
+
+	logger.Info("Group join request decided",
+		"join_request_id", joinReq.ID,
+		"group_id", joinReq.GroupID,
+		"status", joinReq.Status,
+		"user_id", user.ID)
+	return api.DecideGroupJoinRequest200JSONResponse(toGroupJoinRequestResponse(joinReq, requester.Email)), nil
+}
+
+// emails the group's admins that someone is waiting to join
+func (s Server) notifyGroupJoinRequested(ctx context.Context, requester *auth.AuthenticatedUser, group db.Group, joinReq db.GroupJoinRequest) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	ctx = s.sandboxContext(ctx, &group.ID)
+
+	admins, err := s.db.Queries().GetGroupAdminIDs(ctx, &group.ID)
+	if err != nil {
+		logger.Error("Failed to get group admins for join request", "join_request_id", joinReq.ID, "error", err)
+		return
+	}
+	var adminIDs []uuid.UUID
+	for _, id := range admins {
+		if id != nil {
+			adminIDs = append(adminIDs, *id)
+		}
+	}
+	if len(adminIDs) == 0 {
+		return
+	}
+
+	if err := s.dispatcher.Notify(ctx, requester.ID, "group_join_request", joinReq.ID, []notifications.NotifierGroup{
+		{
+			IDs:      adminIDs,
+			Template: "group_join_requested_group_admin",
+			TemplateData: map[string]interface{}{
+				"GroupName":      group.Name,
+				"RequesterEmail": requester.Email,
+				"Message":        joinReq.Message.String,
+			},
+			Facts: notifications.RoutingFacts{
+				GroupID: &group.ID,
+			},
+		},
+	}); err != nil {
+		logger.Error("Failed to send join request notifications", "join_request_id", joinReq.ID, "error", err)
+	}
+}
+
+// emails the user whether they are now in the group
+func (s Server) notifyGroupJoinDecided(ctx context.Context, actorID uuid.UUID, joinReq db.GroupJoinRequest) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	ctx = s.sandboxContext(ctx, &joinReq.GroupID)
+
+	group, err := s.db.Queries().GetGroupByID(ctx, joinReq.GroupID)
+	if err != nil {
+		logger.Error("Failed to get group for join request decision", "join_request_id", joinReq.ID, "error", err)
+		return
+	}
+
+	template := "group_join_denied"
+	if joinReq.Status == db.JoinRequestStatusApproved {
+		template = "group_join_approved"
+	}
+
+	if err := s.dispatcher.Notify(ctx, actorID, "group_join_request", joinReq.ID, []notifications.NotifierGroup{
+		{
+			IDs:      []uuid.UUID{joinReq.UserID},
+			Template: template,
+			TemplateData: map[string]interface{}{
+				"GroupName": group.Name,
+				"Notes":     joinReq.DecisionNotes.String,
+			},
+		},
+	}); err != nil {
+		logger.Error("Failed to send join request decision notification", "join_request_id", joinReq.ID, "error", err)
+	}
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_GroupJoinRequests(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	setup := func(t *testing.T) (*testutil.TestGroup, *testutil.TestUser, *testutil.TestUser) {
+		testDB.CleanupDatabase(t)
+		group := testDB.NewGroup(t).WithName("Outreach").Create()
+		admin := testDB.NewUser(t).WithEmail("admin@join.test").AsGroupAdminOf(group).Create()
+		student := testDB.NewUser(t).WithEmail("student@join.test").AsMember().Create()
+		return group, admin, student
+	}
+
+	request := func(t *testing.T, student *testutil.TestUser, groupID uuid.UUID) api.RequestGroupJoinResponseObject {
+		t.Helper()
+		ctx := testutil.ContextWithUser(context.Background(), student, testDB.Queries())
+		message := "Joining the outreach team"
+		resp, err := server.RequestGroupJoin(ctx, api.RequestGroupJoinRequestObject{
+			Id:   groupID,
+			Body: &api.GroupJoinRequestCreate{Message: &message},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	decide := func(t *testing.T, admin *testutil.TestUser, groupID, requestID uuid.UUID, status api.GroupJoinRequestStatus) api.DecideGroupJoinRequestResponseObject {
+		t.Helper()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroupUsers, &groupID, true, nil)
+		resp, err := server.DecideGroupJoinRequest(ctx, api.DecideGroupJoinRequestRequestObject{
+			Id:        groupID,
+			RequestId: requestID,
+			Body:      &api.GroupJoinRequestDecision{Status: status},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	isMember := func(t *testing.T, userID, groupID uuid.UUID) bool {
+		t.Helper()
+		member, err := testDB.Queries().IsUserMemberOfGroup(context.Background(), db.IsUserMemberOfGroupParams{
+			UserID:  &userID,
+			ScopeID: &groupID,
+		})
+		require.NoError(t, err)
+		return member
+	}
+
+	t.Run("approving makes the user a member", func(t *testing.T) {
+		group, admin, student := setup(t)
+
+		resp := request(t, student, group.ID)
+		require.IsType(t, api.RequestGroupJoin201JSONResponse{}, resp)
+		joinReq := resp.(api.RequestGroupJoin201JSONResponse)
+		assert.Equal(t, api.GroupJoinRequestStatusPending, joinReq.Status)
+		assert.Equal(t, "student@join.test", string(joinReq.UserEmail))
+
+		adminCtx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroupUsers, &group.ID, true, nil)
+		listed, err := server.ListGroupJoinRequests(adminCtx, api.ListGroupJoinRequestsRequestObject{Id: group.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.ListGroupJoinRequests200JSONResponse{}, listed)
+		pending := listed.(api.ListGroupJoinRequests200JSONResponse)
+		require.Len(t, pending.Data, 1)
+		assert.Equal(t, joinReq.Id, pending.Data[0].Id)
+		assert.Equal(t, "student@join.test", string(pending.Data[0].UserEmail))
+
+		decided := decide(t, admin, group.ID, joinReq.Id, api.GroupJoinRequestStatusApproved)
+		require.IsType(t, api.DecideGroupJoinRequest200JSONResponse{}, decided)
+		assert.Equal(t, api.GroupJoinRequestStatusApproved, decided.(api.DecideGroupJoinRequest200JSONResponse).Status)
+		assert.True(t, isMember(t, student.ID, group.ID))
+
+		again := decide(t, admin, group.ID, joinReq.Id, api.GroupJoinRequestStatusDenied)
+		assert.IsType(t, api.DecideGroupJoinRequest409JSONResponse{}, again, "a request is decided once")
+
+		assert.IsType(t, api.RequestGroupJoin409JSONResponse{}, request(t, student, group.ID), "members cannot ask again")
+	})
+
+	t.Run("denying leaves the user out", func(t *testing.T) {
+		group, admin, student := setup(t)
+
+		resp := request(t, student, group.ID)
+		require.IsType(t, api.RequestGroupJoin201JSONResponse{}, resp)
+		joinReq := resp.(api.RequestGroupJoin201JSONResponse)
+
+		decided := decide(t, admin, group.ID, joinReq.Id, api.GroupJoinRequestStatusDenied)
+		require.IsType(t, api.DecideGroupJoinRequest200JSONResponse{}, decided)
+		assert.False(t, isMember(t, student.ID, group.ID))
+
+		assert.IsType(t, api.RequestGroupJoin201JSONResponse{}, request(t, student, group.ID), "a denied user may ask again")
+	})
+
+	t.Run("one pending request per group", func(t *testing.T) {
+		group, _, student := setup(t)
+
+		require.IsType(t, api.RequestGroupJoin201JSONResponse{}, request(t, student, group.ID))
+		assert.IsType(t, api.RequestGroupJoin409JSONResponse{}, request(t, student, group.ID))
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		_, _, student := setup(t)
+
+		assert.IsType(t, api.RequestGroupJoin404JSONResponse{}, request(t, student, uuid.New()))
+	})
+
+	t.Run("another group's request is not found", func(t *testing.T) {
+		group, _, student := setup(t)
+		other := testDB.NewGroup(t).WithName("Robotics").Create()
+		otherAdmin := testDB.NewUser(t).WithEmail("robotics@join.test").AsGroupAdminOf(other).Create()
+
+		resp := request(t, student, group.ID)
+		require.IsType(t, api.RequestGroupJoin201JSONResponse{}, resp)
+
+		decided := decide(t, otherAdmin, other.ID, resp.(api.RequestGroupJoin201JSONResponse).Id, api.GroupJoinRequestStatusApproved)
+		assert.IsType(t, api.DecideGroupJoinRequest404JSONResponse{}, decided)
+		assert.False(t, isMember(t, student.ID, group.ID))
+	})
+}
@@ -73,11 +73,11 @@ func (s Server) UploadGroupLogo(ctx context.Context, request genapi.UploadGroupL
 
 	logger := middleware.GetLoggerFromContext(ctx)
 
-	if err := s.s3Service.PutObject(ctx, originalKey, bytes.NewReader(processed.Original), processed.ContentType); err != nil {
+	if err := s.storage.PutObject(ctx, originalKey, bytes.NewReader(processed.Original), processed.ContentType); err != nil {
 		return genapi.UploadGroupLogo500JSONResponse(InternalError("Failed to upload logo").Create()), nil
 	}
-	if err := s.s3Service.PutObject(ctx, thumbnailKey, bytes.NewReader(processed.Thumbnail), processed.ContentType); err != nil {
-		if err := s.s3Service.DeleteObject(ctx, originalKey); err != nil {
+	if err := s.storage.PutObject(ctx, thumbnailKey, bytes.NewReader(processed.Thumbnail), processed.ContentType); err != nil {
+		if err := s.storage.DeleteObject(ctx, originalKey); err != nil {
 			logger.Warn("failed to delete S3 object", "key", originalKey, "error", err)
 		}
 		return genapi.UploadGroupLogo500JSONResponse(InternalError("Failed to upload logo thumbnail").Create()), nil
@@ -89,22 +89,22 @@ func (s Server) UploadGroupLogo(ctx context.Context, request genapi.UploadGroupL
 		LogoThumbnailS3Key: pgtype.Text{String: thumbnailKey, Valid: true},
 	})
 	if err != nil {
-		if err := s.s3Service.DeleteObject(ctx, originalKey); err != nil {
+		if err := s.storage.DeleteObject(ctx, originalKey); err != nil {
 			logger.Warn("failed to delete S3 object", "key", originalKey, "error", err)
 		}
-		if err := s.s3Service.DeleteObject(ctx, thumbnailKey); err != nil {
+		if err := s.storage.DeleteObject(ctx, thumbnailKey); err != nil {
 			logger.Warn("failed to delete S3 object", "key", thumbnailKey, "error", err)
 		}
 		return genapi.UploadGroupLogo500JSONResponse(InternalError("Failed to save logo record").Create()), nil
 	}
 
 	if oldLogoKey.Valid {
-		if err := s.s3Service.DeleteObject(ctx, oldLogoKey.String); err != nil {
+		if err := s.storage.DeleteObject(ctx, oldLogoKey.String); err != nil {
 			logger.Warn("failed to delete S3 object", "key", oldLogoKey.String, "error", err)
 		}
 	}
 	if oldThumbKey.Valid {
-		if err := s.s3Service.DeleteObject(ctx, oldThumbKey.String); err != nil {
+		if err := s.storage.DeleteObject(ctx, oldThumbKey.String); err != nil {
 			logger.Warn("failed to delete S3 object", "key", oldThumbKey.String, "error", err)
 		}
 	}
@@ -120,5 +120,6 @@ func (s Server) UploadGroupLogo(ctx context.Context, request genapi.UploadGroupL
 		Description:      desc,
 		LogoUrl:          logoURL,
 		LogoThumbnailUrl: thumbURL,
+		Sandbox:          updated.Sandbox,
 	}), nil
 }
@@ -50,6 +50,7 @@ func (s Server) GetAllGroups(ctx context.Context, request api.GetAllGroupsReques
 			Description:      description,
 			LogoUrl:          logoURL,
 			LogoThumbnailUrl: thumbURL,
+			Sandbox:          group.Sandbox,
 		})
 	}
 
@@ -92,6 +93,7 @@ func (s Server) GetGroupByID(ctx context.Context, request api.GetGroupByIDReques
 		Description:      description,
 		LogoUrl:          logoURL,
 		LogoThumbnailUrl: thumbURL,
+		Sandbox:          group.Sandbox,
 	}
 
 	return response, nil
@@ -121,6 +123,9 @@ func (s Server) CreateGroup(ctx context.Context, request api.CreateGroupRequestO
 		Name:        request.Body.Name,
 		Description: pgtype.Text{String: *request.Body.Description, Valid: request.Body.Description != nil},
 	}
+	if request.Body.Sandbox != nil {
+		groupParams.Sandbox = *request.Body.Sandbox
+	}
 
 	group, err := s.db.Queries().CreateGroup(ctx, groupParams)
 	if err != nil {
@@ -141,6 +146,7 @@ func (s Server) CreateGroup(ctx context.Context, request api.CreateGroupRequestO
 		Description:      description,
 		LogoUrl:          logoURL,
 		LogoThumbnailUrl: thumbURL,
+		Sandbox:          group.Sandbox,
 	}
 
 	return response, nil
@@ -191,6 +197,7 @@ func (s Server) UpdateGroup(ctx context.Context, request api.UpdateGroupRequestO
 		Description:      description,
 		LogoUrl:          logoURL,
 		LogoThumbnailUrl: thumbURL,
+		Sandbox:          group.Sandbox,
 	}
 
 	return response, nil
@@ -200,13 +207,13 @@ func (s Server) resolveGroupLogoURLs(ctx context.Context, g db.Group) (logoURL,
 	if !g.LogoS3Key.Valid {
 		return nil, nil
 	}
-	url, err := s.s3Service.GeneratePresignedURL(ctx, "GET", g.LogoS3Key.String, time.Hour)
+	url, err := s.storage.GeneratePresignedURL(ctx, "GET", g.LogoS3Key.String, time.Hour)
 	if err != nil {
 		return nil, nil
 	}
 	var thumbURL *string
 	if g.LogoThumbnailS3Key.Valid {
-		t, err := s.s3Service.GeneratePresignedURL(ctx, "GET", g.LogoThumbnailS3Key.String, time.Hour)
+		t, err := s.storage.GeneratePresignedURL(ctx, "GET", g.LogoThumbnailS3Key.String, time.Hour)
 		if err == nil {
 			thumbURL = &t
 		}
@@ -239,23 +246,21 @@ func (s Server) DeleteGroup(ctx context.Context, request api.DeleteGroupRequestO
 		return api.DeleteGroup404JSONResponse(NotFound("Group").Create()), nil
 	}
 
-	oldLogoKey := group.LogoS3Key
-	oldThumbKey := group.LogoThumbnailS3Key
-
-	err = s.db.Queries().DeleteGroup(ctx, request.Id)
+	deletion, ok, err := s.requestDeletion(ctx, db.DeletionEntityGroup, group.ID, user.ID, group.Name, request.Params.Reason)
 	if err != nil {
-		logger.Error("Failed to delete group",
+		logger.Error("Failed to request group deletion",
 			"group_id", request.Id,
 			"error", err)
 		return api.DeleteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
 	}
-
-	if oldLogoKey.Valid {
-		_ = s.s3Service.DeleteObject(ctx, oldLogoKey.String)
-	}
-	if oldThumbKey.Valid {
-		_ = s.s3Service.DeleteObject(ctx, oldThumbKey.String)
+	if !ok {
+		return api.DeleteGroup409JSONResponse(ConflictErr("A deletion request for this group is already open").Create()), nil
 	}
 
-	return api.DeleteGroup204Response{}, nil
+	logger.Info("Group deletion requested",
+		"group_id", group.ID,
+		"deletion_id", deletion.ID,
+		"requested_by", user.ID)
+
+	return api.DeleteGroup202JSONResponse(convertToDeletionRequestResponse(deletion)), nil
 }
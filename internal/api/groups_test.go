@@ -253,7 +253,7 @@ func TestServer_DeleteGroup(t *testing.T) {
 
 	server, testDB, mockAuth := newTestServer(t)
 
-	t.Run("successful delete group", func(t *testing.T) {
+	t.Run("delete opens a pending deletion request", func(t *testing.T) {
 		testUser := testDB.NewUser(t).
 			WithEmail("delete@groups.ca").
 			AsGlobalAdmin().
@@ -271,11 +271,21 @@ func TestServer_DeleteGroup(t *testing.T) {
 		})
 
 		require.NoError(t, err)
-		require.IsType(t, api.DeleteGroup204Response{}, response)
+		require.IsType(t, api.DeleteGroup202JSONResponse{}, response)
 
+		deletion := response.(api.DeleteGroup202JSONResponse)
+		assert.Equal(t, api.DeletionRequestStatus("pending"), deletion.Status)
+		assert.Equal(t, group.ID, deletion.EntityId)
+
+		// nothing is removed until a second admin approves
 		mockAuth.ExpectCheckPermission(testUser.ID, rbac.ViewGroupData, nil, true, nil)
 		getResp, err := server.GetGroupByID(ctx, api.GetGroupByIDRequestObject{Id: group.ID})
 		require.NoError(t, err)
-		require.IsType(t, api.GetGroupByID404JSONResponse{}, getResp)
+		require.IsType(t, api.GetGroupByID200JSONResponse{}, getResp)
+
+		mockAuth.ExpectCheckPermission(testUser.ID, rbac.ManageGroups, nil, true, nil)
+		again, err := server.DeleteGroup(ctx, api.DeleteGroupRequestObject{Id: group.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.DeleteGroup409JSONResponse{}, again)
 	})
 }
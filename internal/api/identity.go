@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// canonical form of a student ID as typed or swiped: separators dropped,
+// letters upper-cased. Returns "" if anything else is left over.
+func normalizeStudentID(raw string) string {
+	var b strings.Builder
+	for _, r := range raw {
+		switch {
+		case r == ' ' || r == '-':
+			continue
+		case unicode.IsDigit(r) || unicode.IsLetter(r):
+			b.WriteRune(unicode.ToUpper(r))
+		default:
+			return ""
+		}
+	}
+	normalized := b.String()
+	if len(normalized) < 5 || len(normalized) > 20 {
+		return ""
+	}
+	return normalized
+}
+
+func (s Server) SetMyStudentId(ctx context.Context, request api.SetMyStudentIdRequestObject) (api.SetMyStudentIdResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.SetMyStudentId401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.SetMyStudentId400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	normalized := normalizeStudentID(request.Body.StudentId)
+	if normalized == "" {
+		return api.SetMyStudentId400JSONResponse(ValidationErr("student_id must be 5 to 20 letters or digits", nil).Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.SetMyStudentId500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	// set-once: a student could otherwise swap in someone else's ID before pickup
+	set, err := qtx.SetUserStudentIDHashIfUnset(ctx, db.SetUserStudentIDHashIfUnsetParams{
+		ID:            user.ID,
+		StudentIDHash: pgtype.Text{String: s.studentIDs.Hash(normalized), Valid: true},
+	})
+	if err != nil {
+		logger.Error("Failed to save student ID", "user_id", user.ID, "error", err)
+		return api.SetMyStudentId500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if set == 0 {
+		return api.SetMyStudentId409JSONResponse(ConflictErr("A student ID is already on file; ask an administrator to change it").Create()), nil
+	}
+
+	if err := qtx.RecordStudentIDChange(ctx, db.RecordStudentIDChangeParams{
+		UserID:    user.ID,
+		ChangedBy: &user.ID,
+		Replaced:  false,
+	}); err != nil {
+		logger.Error("Failed to record student ID change", "user_id", user.ID, "error", err)
+		return api.SetMyStudentId500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit student ID", "user_id", user.ID, "error", err)
+		return api.SetMyStudentId500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Student ID saved", "user_id", user.ID)
+	return api.SetMyStudentId204Response{}, nil
+}
+
+func (s Server) SetUserStudentId(ctx context.Context, request api.SetUserStudentIdRequestObject) (api.SetUserStudentIdResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.SetUserStudentId401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageUsers, nil)
+	if err != nil {
+		logger.Error("Error checking manage_users permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageUsers,
+			"error", err)
+		return api.SetUserStudentId500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.SetUserStudentId403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.SetUserStudentId400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	normalized := normalizeStudentID(request.Body.StudentId)
+	if normalized == "" {
+		return api.SetUserStudentId400JSONResponse(ValidationErr("student_id must be 5 to 20 letters or digits", nil).Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.SetUserStudentId500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	previous, err := qtx.GetUserStudentIDHash(ctx, request.UserId)
+	if err == pgx.ErrNoRows {
+		return api.SetUserStudentId404JSONResponse(NotFound("User").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get student ID", "user_id", request.UserId, "error", err)
+		return api.SetUserStudentId500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := qtx.SetUserStudentIDHash(ctx, db.SetUserStudentIDHashParams{
+		ID:            request.UserId,
+		StudentIDHash: pgtype.Text{String: s.studentIDs.Hash(normalized), Valid: true},
+	}); err != nil {
+		logger.Error("Failed to save student ID", "user_id", request.UserId, "error", err)
+		return api.SetUserStudentId500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := qtx.RecordStudentIDChange(ctx, db.RecordStudentIDChangeParams{
+		UserID:    request.UserId,
+		ChangedBy: &user.ID,
+		Replaced:  previous.Valid,
+	}); err != nil {
+		logger.Error("Failed to record student ID change", "user_id", request.UserId, "error", err)
+		return api.SetUserStudentId500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit student ID", "user_id", request.UserId, "error", err)
+		return api.SetUserStudentId500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Student ID set by administrator",
+		"user_id", request.UserId,
+		"changed_by", user.ID,
+		"replaced", previous.Valid)
+	return api.SetUserStudentId204Response{}, nil
+}
+
+func (s Server) VerifyBookingIdentity(ctx context.Context, request api.VerifyBookingIdentityRequestObject) (api.VerifyBookingIdentityResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.VerifyBookingIdentity401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageAllBookings, nil)
+	if err != nil {
+		logger.Error("Error checking manage_all_bookings permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageAllBookings,
+			"error", err)
+		return api.VerifyBookingIdentity500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.VerifyBookingIdentity403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.VerifyBookingIdentity400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	normalized := normalizeStudentID(request.Body.StudentId)
+	if normalized == "" {
+		return api.VerifyBookingIdentity400JSONResponse(ValidationErr("student_id must be 5 to 20 letters or digits", nil).Create()), nil
+	}
+
+	booking, err := s.db.Queries().GetBookingByID(ctx, request.BookingId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return api.VerifyBookingIdentity404JSONResponse(NotFound("Booking").Create()), nil
+		}
+		logger.Error("Failed to get booking", "booking_id", request.BookingId, "error", err)
+		return api.VerifyBookingIdentity500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if booking.RequesterID == nil {
+		return api.VerifyBookingIdentity409JSONResponse(ConflictErr("Booking has no requester to verify").Create()), nil
+	}
+
+	storedHash, err := s.db.Queries().GetUserStudentIDHash(ctx, *booking.RequesterID)
+	if err != nil {
+		logger.Error("Failed to get requester student ID", "booking_id", booking.ID, "error", err)
+		return api.VerifyBookingIdentity500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if !storedHash.Valid {
+		return api.VerifyBookingIdentity409JSONResponse(ConflictErr("Requester has no student ID on file").Create()), nil
+	}
+
+	matched, stale := s.studentIDs.Match(normalized, storedHash.String)
+	if stale {
+		// hashed under a rotated-out key; re-key while the plain ID is at hand
+		if err := s.db.Queries().SetUserStudentIDHash(ctx, db.SetUserStudentIDHashParams{
+			ID:            *booking.RequesterID,
+			StudentIDHash: pgtype.Text{String: s.studentIDs.Hash(normalized), Valid: true},
+		}); err != nil {
+			logger.Warn("Failed to re-key student ID", "user_id", *booking.RequesterID, "error", err)
+		}
+	}
+
+	verification, err := s.db.Queries().RecordBookingVerification(ctx, db.RecordBookingVerificationParams{
+		BookingID:  booking.ID,
+		VerifiedBy: &user.ID,
+		Matched:    matched,
+	})
+	if err != nil {
+		logger.Error("Failed to record booking verification", "booking_id", booking.ID, "error", err)
+		return api.VerifyBookingIdentity500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if matched {
+		logger.Info("Booking identity verified", "booking_id", booking.ID, "verified_by", user.ID)
+	} else {
+		logger.Warn("Booking identity mismatch", "booking_id", booking.ID, "verified_by", user.ID)
+	}
+
+	return api.VerifyBookingIdentity200JSONResponse{
+		Id:         verification.ID,
+		BookingId:  verification.BookingID,
+		Matched:    verification.Matched,
+		VerifiedBy: verification.VerifiedBy,
+		VerifiedAt: verification.CreatedAt.Time,
+	}, nil
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_SetMyStudentId(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, _ := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	user := testDB.NewUser(t).WithEmail("student@identity.test").AsMember().Create()
+	ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+
+	t.Run("stores only the hash", func(t *testing.T) {
+		response, err := server.SetMyStudentId(ctx, api.SetMyStudentIdRequestObject{
+			Body: &api.StudentIdRequest{StudentId: "4001-234 567"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetMyStudentId204Response{}, response)
+
+		stored, err := testDB.Queries().GetUserStudentIDHash(ctx, user.ID)
+		require.NoError(t, err)
+		require.True(t, stored.Valid)
+		assert.NotContains(t, stored.String, "4001234567")
+		assert.Equal(t, server.studentIDs.Hash("4001234567"), stored.String)
+	})
+
+	t.Run("cannot be changed once set", func(t *testing.T) {
+		response, err := server.SetMyStudentId(ctx, api.SetMyStudentIdRequestObject{
+			Body: &api.StudentIdRequest{StudentId: "400999999"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetMyStudentId409JSONResponse{}, response)
+
+		stored, err := testDB.Queries().GetUserStudentIDHash(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, server.studentIDs.Hash("4001234567"), stored.String)
+	})
+
+	t.Run("rejects malformed IDs", func(t *testing.T) {
+		response, err := server.SetMyStudentId(ctx, api.SetMyStudentIdRequestObject{
+			Body: &api.StudentIdRequest{StudentId: "12;--"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetMyStudentId400JSONResponse{}, response)
+	})
+}
+
+func TestServer_SetUserStudentId(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@identity.test").AsGlobalAdmin().Create()
+	student := testDB.NewUser(t).WithEmail("student@identity.test").AsMember().Create()
+	adminCtx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+	studentCtx := testutil.ContextWithUser(context.Background(), student, testDB.Queries())
+
+	_, err := server.SetMyStudentId(studentCtx, api.SetMyStudentIdRequestObject{
+		Body: &api.StudentIdRequest{StudentId: "400123456"},
+	})
+	require.NoError(t, err)
+
+	t.Run("admin replaces the student ID and the change is audited", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageUsers, nil, true, nil)
+		response, err := server.SetUserStudentId(adminCtx, api.SetUserStudentIdRequestObject{
+			UserId: student.ID,
+			Body:   &api.StudentIdRequest{StudentId: "400654321"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetUserStudentId204Response{}, response)
+
+		stored, err := testDB.Queries().GetUserStudentIDHash(adminCtx, student.ID)
+		require.NoError(t, err)
+		assert.Equal(t, server.studentIDs.Hash("400654321"), stored.String)
+
+		rows, err := testDB.Pool().Query(adminCtx,
+			`SELECT changed_by, replaced FROM student_id_changes WHERE user_id = $1 ORDER BY created_at`, student.ID)
+		require.NoError(t, err)
+		defer rows.Close()
+		var changes []db.StudentIDChange
+		for rows.Next() {
+			var c db.StudentIDChange
+			require.NoError(t, rows.Scan(&c.ChangedBy, &c.Replaced))
+			changes = append(changes, c)
+		}
+		require.NoError(t, rows.Err())
+		require.Len(t, changes, 2)
+		assert.Equal(t, student.ID, *changes[0].ChangedBy)
+		assert.False(t, changes[0].Replaced)
+		assert.Equal(t, admin.ID, *changes[1].ChangedBy)
+		assert.True(t, changes[1].Replaced)
+	})
+
+	t.Run("requires manage_users", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(student.ID, rbac.ManageUsers, nil, false, nil)
+		response, err := server.SetUserStudentId(studentCtx, api.SetUserStudentIdRequestObject{
+			UserId: student.ID,
+			Body:   &api.StudentIdRequest{StudentId: "400999999"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetUserStudentId403JSONResponse{}, response)
+	})
+
+	t.Run("unknown user", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageUsers, nil, true, nil)
+		response, err := server.SetUserStudentId(adminCtx, api.SetUserStudentIdRequestObject{
+			UserId: uuid.New(),
+			Body:   &api.StudentIdRequest{StudentId: "400999999"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetUserStudentId404JSONResponse{}, response)
+	})
+}
+
+func TestServer_VerifyBookingIdentity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	user := testDB.NewUser(t).WithEmail("user@identity.test").AsMember().Create()
+	approver := testDB.NewUser(t).WithEmail("approver@identity.test").AsApprover().Create()
+	desk := testDB.NewUser(t).WithEmail("desk@identity.test").AsGlobalAdmin().Create()
+	group := testDB.NewGroup(t).WithName("Identity Group").Create()
+	item := testDB.NewItem(t).WithName("Cinema Camera").WithType("high").WithStock(1).Create()
+	testDB.AssignUserToGroup(t, user.ID, group.ID, "member")
+
+	userCtx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+	approverCtx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+	deskCtx := testutil.ContextWithUser(context.Background(), desk, testDB.Queries())
+
+	_, err := server.SetMyStudentId(userCtx, api.SetMyStudentIdRequestObject{
+		Body: &api.StudentIdRequest{StudentId: "400123456"},
+	})
+	require.NoError(t, err)
+
+	timeSlots, err := testDB.Queries().ListTimeSlots(userCtx)
+	require.NoError(t, err)
+	require.NotEmpty(t, timeSlots)
+	availability, err := testDB.Queries().CreateAvailability(approverCtx, db.CreateAvailabilityParams{
+		ID:         uuid.New(),
+		UserID:     &approver.ID,
+		TimeSlotID: &timeSlots[0].ID,
+		Date:       pgtype.Date{Time: time.Now().AddDate(0, 0, 1), Valid: true},
+	})
+	require.NoError(t, err)
+
+	created, err := testDB.Queries().RequestItem(userCtx, db.RequestItemParams{
+		UserID: &user.ID, GroupID: &group.ID, ID: item.ID, Quantity: 1,
+	})
+	require.NoError(t, err)
+
+	pickup, dropoff := "Front Desk", "Front Desk"
+	mockAuth.ExpectCheckPermission(approver.ID, rbac.ApproveAllRequests, nil, true, nil)
+	_, err = server.ReviewRequest(approverCtx, api.ReviewRequestRequestObject{
+		RequestId: created.ID,
+		Body: &api.ReviewRequestJSONRequestBody{
+			Status:         api.RequestStatusApproved,
+			AvailabilityId: &availability.ID,
+			PickupLocation: &pickup,
+			ReturnLocation: &dropoff,
+		},
+	})
+	require.NoError(t, err)
+
+	approved, err := testDB.Queries().GetRequestById(userCtx, created.ID)
+	require.NoError(t, err)
+	require.NotNil(t, approved.BookingID)
+
+	borrow := func() api.BorrowItemResponseObject {
+		mockAuth.ExpectCheckPermission(user.ID, rbac.RequestItems, &group.ID, true, nil)
+		response, err := server.BorrowItem(userCtx, api.BorrowItemRequestObject{
+			Body: &api.BorrowItemJSONRequestBody{
+				UserId:          user.ID,
+				GroupId:         group.ID,
+				ItemId:          item.ID,
+				Quantity:        1,
+				DueDate:         time.Now().AddDate(0, 0, 7),
+				BeforeCondition: "good",
+			},
+		})
+		require.NoError(t, err)
+		return response
+	}
+
+	// pickup is blocked until the desk verifies the requester
+	require.IsType(t, api.BorrowItem403JSONResponse{}, borrow())
+
+	mockAuth.ExpectCheckPermission(desk.ID, rbac.ManageAllBookings, nil, true, nil)
+	response, err := server.VerifyBookingIdentity(deskCtx, api.VerifyBookingIdentityRequestObject{
+		BookingId: *approved.BookingID,
+		Body:      &api.StudentIdRequest{StudentId: "400999999"},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.VerifyBookingIdentity200JSONResponse{}, response)
+	assert.False(t, response.(api.VerifyBookingIdentity200JSONResponse).Matched)
+
+	require.IsType(t, api.BorrowItem403JSONResponse{}, borrow())
+
+	mockAuth.ExpectCheckPermission(desk.ID, rbac.ManageAllBookings, nil, true, nil)
+	response, err = server.VerifyBookingIdentity(deskCtx, api.VerifyBookingIdentityRequestObject{
+		BookingId: *approved.BookingID,
+		Body:      &api.StudentIdRequest{StudentId: "400-123-456"},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.VerifyBookingIdentity200JSONResponse{}, response)
+	assert.True(t, response.(api.VerifyBookingIdentity200JSONResponse).Matched)
+
+	require.IsType(t, api.BorrowItem201JSONResponse{}, borrow())
+}
@@ -6,6 +6,11 @@ import (
 	"time"
 
 	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/bookingpolicy"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/events"
+	"github.com/USSTM/cv-backend/internal/middleware"
 	"github.com/USSTM/cv-backend/internal/notifications"
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
@@ -25,26 +30,83 @@ type AuthService interface {
 	VerifyOTP(ctx context.Context, email, code string) (string, string, error)
 	Refresh(ctx context.Context, refreshToken string) (string, string, error)
 	Logout(ctx context.Context, refreshToken string) error
+	SignIn(ctx context.Context, userID uuid.UUID) (string, string, error)
+	EnrollMFA(ctx context.Context, userID uuid.UUID, email string) (*auth.MFAEnrollment, error)
+	ConfirmMFA(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	VerifyMFA(ctx context.Context, userID uuid.UUID, code string) (string, string, error)
 	OTPExpiry() time.Duration
 }
 
+// OIDCService defines the interface for campus single sign-on
+type OIDCService interface {
+	Issuer() string
+	PostLoginURL() string
+	CanProvision(email string) bool
+	AuthorizationURL(ctx context.Context) (string, error)
+	Exchange(ctx context.Context, code, state string) (*auth.OIDCIdentity, error)
+}
+
 // AuthenticatorService defines the interface for authentication operations
 type AuthenticatorService interface {
 	CheckPermission(ctx context.Context, userID uuid.UUID, permission string, scopeID *uuid.UUID) (bool, error)
+	InvalidateUserPermissions(ctx context.Context, userID uuid.UUID)
+	InvalidateAllPermissions(ctx context.Context)
 }
 
 // RedisQueueService defines the interface for Redis (asynq) queue operations
 type RedisQueueService interface {
-	Enqueue(taskType string, data interface{}) (*asynq.TaskInfo, error)
+	Enqueue(ctx context.Context, taskType string, data interface{}) (*asynq.TaskInfo, error)
+	EnqueueAt(ctx context.Context, taskType string, data interface{}, processAt time.Time) (*asynq.TaskInfo, error)
+	ListQueues() ([]*asynq.QueueInfo, error)
+	GetQueueInfo(name string) (*asynq.QueueInfo, error)
+	PauseQueue(name string) error
+	ResumeQueue(name string) error
+	DrainQueue(name, state string) (int, error)
+	CancelTask(taskID string) error
+}
+
+// LoadShedderService reports load-shedding counters for the API server
+type LoadShedderService interface {
+	Stats() middleware.SheddingStats
+}
+
+// BookingPolicyService resolves the confirmation rules for a group's bookings
+type BookingPolicyService interface {
+	ForGroup(ctx context.Context, q *db.Queries, groupID *uuid.UUID) (bookingpolicy.Policy, error)
+	All(ctx context.Context, q *db.Queries) (bookingpolicy.Policies, error)
+	Defaults() bookingpolicy.Policy
+}
+
+// StudentIDHasher hashes and checks student IDs under the server's secret key
+type StudentIDHasher interface {
+	Hash(normalized string) string
+	Match(normalized, stored string) (matched, stale bool)
+}
+
+// EventBroker relays dashboard events between API replicas
+type EventBroker interface {
+	Publish(ctx context.Context, e events.Event) error
+	Subscribe() (<-chan events.Event, func(), error)
+}
+
+// CatalogCache keeps item listings between requests
+type CatalogCache interface {
+	Load(ctx context.Context, key string, dest any, load func() error) error
+	Invalidate(ctx context.Context) error
+}
+
+// ConfigSource gives the configuration in effect, reloads included
+type ConfigSource interface {
+	Current() *config.Config
 }
 
 // EmailService defines the interface for email operations
 type EmailService interface {
-	SendEmail(ctx context.Context, to string, subject string, body string) error
+	SendEmail(ctx context.Context, to string, subject string, textBody string, htmlBody string) error
 }
 
-// S3Service defines the interface for S3 operations
-type S3Service interface {
+// StorageService defines the interface for object storage operations
+type StorageService interface {
 	PutObject(ctx context.Context, key string, body io.Reader, contentType string) error
 	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
 	GeneratePresignedURL(ctx context.Context, method string, key string, duration time.Duration) (string, error)
@@ -54,6 +116,7 @@ type S3Service interface {
 // NotificationService defines the interface for notifications operations
 type NotificationService interface {
 	Publish(ctx context.Context, actorID uuid.UUID, entityTypeName string, entityID uuid.UUID, notifierIDs []uuid.UUID) error
+	GetNotification(ctx context.Context, userID, notificationID uuid.UUID) (db.GetUserNotificationRow, error)
 	GetUserNotifications(ctx context.Context, userID uuid.UUID, limit, offset int64) ([]db.GetUserNotificationsRow, error)
 	MarkAsRead(ctx context.Context, userID, notificationID uuid.UUID) (db.Notification, error)
 	MarkAllAsRead(ctx context.Context, userID uuid.UUID) error
@@ -65,4 +128,6 @@ type NotificationService interface {
 type NotificationDispatcherService interface {
 	NotificationService
 	Notify(ctx context.Context, actorID uuid.UUID, entityType string, entityID uuid.UUID, groups []notifications.NotifierGroup) error
+	Announce(ctx context.Context, e notifications.ChatEvent, groupID *uuid.UUID, data map[string]interface{})
+	HasTemplate(name string) bool
 }
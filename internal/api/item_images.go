@@ -3,9 +3,12 @@ package api
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	genapi "github.com/USSTM/cv-backend/generated/api"
@@ -15,15 +18,16 @@ import (
 	"github.com/USSTM/cv-backend/internal/middleware"
 	"github.com/USSTM/cv-backend/internal/rbac"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 func (s Server) buildItemImageResponse(ctx context.Context, img db.ItemImage) genapi.ItemImage {
 	logger := middleware.GetLoggerFromContext(ctx)
-	url, err := s.s3Service.GeneratePresignedURL(ctx, "GET", img.OriginalS3Key, time.Hour)
+	url, err := s.storage.GeneratePresignedURL(ctx, "GET", img.OriginalS3Key, time.Hour)
 	if err != nil {
 		logger.Warn("failed to generate presigned URL", "key", img.OriginalS3Key, "error", err)
 	}
-	thumbURL, err := s.s3Service.GeneratePresignedURL(ctx, "GET", img.ThumbnailS3Key, time.Hour)
+	thumbURL, err := s.storage.GeneratePresignedURL(ctx, "GET", img.ThumbnailS3Key, time.Hour)
 	if err != nil {
 		logger.Warn("failed to generate presigned URL", "key", img.ThumbnailS3Key, "error", err)
 	}
@@ -38,6 +42,15 @@ func (s Server) buildItemImageResponse(ctx context.Context, img db.ItemImage) ge
 	}
 }
 
+// how long a presigned item image upload URL stays valid
+const itemImageUploadExpiry = 15 * time.Minute
+
+// presigned uploads go under this prefix, so a confirmation can only claim
+// keys issued for its item
+func itemImageUploadPrefix(itemID uuid.UUID) string {
+	return fmt.Sprintf("items/%s/uploads/", itemID)
+}
+
 func (s Server) UploadItemImage(ctx context.Context, request genapi.UploadItemImageRequestObject) (genapi.UploadItemImageResponseObject, error) {
 	user, ok := auth.GetAuthenticatedUser(ctx)
 	if !ok {
@@ -57,7 +70,14 @@ func (s Server) UploadItemImage(ctx context.Context, request genapi.UploadItemIm
 		return genapi.UploadItemImage404JSONResponse(NotFound("Item").Create()), nil
 	}
 
-	form, err := request.Body.ReadForm(32 << 20) // 32MB max memory
+	if request.JSONBody != nil {
+		return s.confirmItemImageUpload(ctx, user.ID, request.ItemId, *request.JSONBody), nil
+	}
+	if request.MultipartBody == nil {
+		return genapi.UploadItemImage400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+
+	form, err := request.MultipartBody.ReadForm(32 << 20) // 32MB max memory
 	if err != nil {
 		return genapi.UploadItemImage400JSONResponse(ValidationErr("Failed to parse multipart form", nil).Create()), nil
 	}
@@ -97,83 +117,211 @@ func (s Server) UploadItemImage(ctx context.Context, request genapi.UploadItemIm
 		}
 	}
 
-	ext := "jpg"
-	if processed.ContentType == "image/png" {
-		ext = "png"
-	}
+	ext := imageExtension(processed.ContentType)
 	id := uuid.New()
 	originalKey := fmt.Sprintf("items/%s/%s-original.%s", request.ItemId, id.String(), ext)
 	thumbnailKey := fmt.Sprintf("items/%s/%s-thumb.%s", request.ItemId, id.String(), ext)
 
 	logger := middleware.GetLoggerFromContext(ctx)
 
-	if err := s.s3Service.PutObject(ctx, originalKey, bytes.NewReader(processed.Original), processed.ContentType); err != nil {
+	if err := s.storage.PutObject(ctx, originalKey, bytes.NewReader(processed.Original), processed.ContentType); err != nil {
 		return genapi.UploadItemImage500JSONResponse(InternalError("Failed to upload image").Create()), nil
 	}
-	if err := s.s3Service.PutObject(ctx, thumbnailKey, bytes.NewReader(processed.Thumbnail), processed.ContentType); err != nil {
-		if err := s.s3Service.DeleteObject(ctx, originalKey); err != nil {
+	if err := s.storage.PutObject(ctx, thumbnailKey, bytes.NewReader(processed.Thumbnail), processed.ContentType); err != nil {
+		if err := s.storage.DeleteObject(ctx, originalKey); err != nil {
 			logger.Warn("failed to delete S3 object", "key", originalKey, "error", err)
 		}
 		return genapi.UploadItemImage500JSONResponse(InternalError("Failed to upload thumbnail").Create()), nil
 	}
 
-	tx, err := s.db.Pool().Begin(ctx)
+	img, err := s.saveItemImage(ctx, db.CreateItemImageParams{
+		ID:             id,
+		ItemID:         request.ItemId,
+		OriginalS3Key:  originalKey,
+		ThumbnailS3Key: thumbnailKey,
+		DisplayOrder:   displayOrder,
+		IsPrimary:      isPrimary,
+		Width:          int32(processed.Width),
+		Height:         int32(processed.Height),
+		UploadedBy:     &user.ID,
+	})
 	if err != nil {
-		if err := s.s3Service.DeleteObject(ctx, originalKey); err != nil {
+		logger.Error("Failed to save item image", "item_id", request.ItemId, "error", err)
+		if err := s.storage.DeleteObject(ctx, originalKey); err != nil {
 			logger.Warn("failed to delete S3 object", "key", originalKey, "error", err)
 		}
-		if err := s.s3Service.DeleteObject(ctx, thumbnailKey); err != nil {
+		if err := s.storage.DeleteObject(ctx, thumbnailKey); err != nil {
 			logger.Warn("failed to delete S3 object", "key", thumbnailKey, "error", err)
 		}
-		return genapi.UploadItemImage500JSONResponse(InternalError("Failed to start transaction").Create()), nil
+		return genapi.UploadItemImage500JSONResponse(InternalError("Failed to save image record").Create()), nil
 	}
-	defer tx.Rollback(ctx)
-	qtx := s.db.Queries().WithTx(tx)
 
-	if isPrimary {
-		if err := qtx.UnsetPrimaryItemImages(ctx, request.ItemId); err != nil {
-			if err := s.s3Service.DeleteObject(ctx, originalKey); err != nil {
-				logger.Warn("failed to delete S3 object", "key", originalKey, "error", err)
-			}
-			if err := s.s3Service.DeleteObject(ctx, thumbnailKey); err != nil {
-				logger.Warn("failed to delete S3 object", "key", thumbnailKey, "error", err)
-			}
-			return genapi.UploadItemImage500JSONResponse(InternalError("Failed to update primary image").Create()), nil
+	return genapi.UploadItemImage201JSONResponse(s.buildItemImageResponse(ctx, img)), nil
+}
+
+// adds an image the client uploaded straight to S3 with a presigned URL. The
+// upload is validated and thumbnailed here, as a multipart upload would be.
+func (s Server) confirmItemImageUpload(ctx context.Context, userID, itemID uuid.UUID, body genapi.ConfirmItemImageUploadRequest) genapi.UploadItemImageResponseObject {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	name, ok := strings.CutPrefix(body.Key, itemImageUploadPrefix(itemID))
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return genapi.UploadItemImage400JSONResponse(ValidationErr("key was not issued for this item", nil).Create())
+	}
+
+	// confirming twice returns the image the first confirmation created
+	existing, err := s.db.Queries().GetItemImageByOriginalKey(ctx, body.Key)
+	if err == nil {
+		return genapi.UploadItemImage201JSONResponse(s.buildItemImageResponse(ctx, existing))
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		logger.Error("Failed to look up item image", "key", body.Key, "error", err)
+		return genapi.UploadItemImage500JSONResponse(InternalError("An unexpected error occurred.").Create())
+	}
+
+	displayOrder := int32(0)
+	if body.DisplayOrder != nil {
+		if *body.DisplayOrder < 0 || *body.DisplayOrder > math.MaxInt32 {
+			return genapi.UploadItemImage400JSONResponse(ValidationErr("display_order must be between 0 and 2147483647", nil).Create())
 		}
+		displayOrder = int32(*body.DisplayOrder)
 	}
+	isPrimary := body.IsPrimary != nil && *body.IsPrimary
 
-	img, err := qtx.CreateItemImage(ctx, db.CreateItemImageParams{
+	object, err := s.storage.GetObject(ctx, body.Key)
+	if err != nil {
+		logger.Warn("Failed to get uploaded item image", "key", body.Key, "error", err)
+		return genapi.UploadItemImage400JSONResponse(ValidationErr("No upload found for this key", nil).Create())
+	}
+	data, err := io.ReadAll(io.LimitReader(object, cvimage.MaxFileSize+1))
+	object.Close()
+	if err != nil {
+		logger.Error("Failed to read uploaded item image", "key", body.Key, "error", err)
+		return genapi.UploadItemImage500JSONResponse(InternalError("Failed to read uploaded image").Create())
+	}
+
+	processed, err := cvimage.Process(data)
+	if err != nil {
+		// nothing can use an invalid upload, so don't keep it
+		if err := s.storage.DeleteObject(ctx, body.Key); err != nil {
+			logger.Warn("failed to delete S3 object", "key", body.Key, "error", err)
+		}
+		return genapi.UploadItemImage400JSONResponse(ValidationErr(err.Error(), nil).Create())
+	}
+	if isPrimary && !cvimage.IsSquare(processed.Width, processed.Height) {
+		return genapi.UploadItemImage400JSONResponse(ValidationErr("Primary image must be square", nil).Create())
+	}
+
+	id := uuid.New()
+	thumbnailKey := fmt.Sprintf("items/%s/%s-thumb.%s", itemID, id.String(), imageExtension(processed.ContentType))
+	if err := s.storage.PutObject(ctx, thumbnailKey, bytes.NewReader(processed.Thumbnail), processed.ContentType); err != nil {
+		return genapi.UploadItemImage500JSONResponse(InternalError("Failed to upload thumbnail").Create())
+	}
+
+	img, err := s.saveItemImage(ctx, db.CreateItemImageParams{
 		ID:             id,
-		ItemID:         request.ItemId,
-		OriginalS3Key:  originalKey,
+		ItemID:         itemID,
+		OriginalS3Key:  body.Key,
 		ThumbnailS3Key: thumbnailKey,
 		DisplayOrder:   displayOrder,
 		IsPrimary:      isPrimary,
 		Width:          int32(processed.Width),
 		Height:         int32(processed.Height),
-		UploadedBy:     &user.ID,
+		UploadedBy:     &userID,
 	})
 	if err != nil {
-		if err := s.s3Service.DeleteObject(ctx, originalKey); err != nil {
-			logger.Warn("failed to delete S3 object", "key", originalKey, "error", err)
-		}
-		if err := s.s3Service.DeleteObject(ctx, thumbnailKey); err != nil {
+		logger.Error("Failed to save item image", "item_id", itemID, "key", body.Key, "error", err)
+		if err := s.storage.DeleteObject(ctx, thumbnailKey); err != nil {
 			logger.Warn("failed to delete S3 object", "key", thumbnailKey, "error", err)
 		}
-		return genapi.UploadItemImage500JSONResponse(InternalError("Failed to save image record").Create()), nil
+		return genapi.UploadItemImage500JSONResponse(InternalError("Failed to save image record").Create())
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		if err := s.s3Service.DeleteObject(ctx, originalKey); err != nil {
-			logger.Warn("failed to delete S3 object", "key", originalKey, "error", err)
-		}
-		if err := s.s3Service.DeleteObject(ctx, thumbnailKey); err != nil {
-			logger.Warn("failed to delete S3 object", "key", thumbnailKey, "error", err)
+	return genapi.UploadItemImage201JSONResponse(s.buildItemImageResponse(ctx, img))
+}
+
+// records an uploaded image, taking over as the item's primary image if it is one.
+func (s Server) saveItemImage(ctx context.Context, params db.CreateItemImageParams) (db.ItemImage, error) {
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		return db.ItemImage{}, err
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.db.Queries().WithTx(tx)
+
+	if params.IsPrimary {
+		if err := qtx.UnsetPrimaryItemImages(ctx, params.ItemID); err != nil {
+			return db.ItemImage{}, err
 		}
-		return genapi.UploadItemImage500JSONResponse(InternalError("Failed to commit transaction").Create()), nil
 	}
 
-	return genapi.UploadItemImage201JSONResponse(s.buildItemImageResponse(ctx, img)), nil
+	img, err := qtx.CreateItemImage(ctx, params)
+	if err != nil {
+		return db.ItemImage{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return db.ItemImage{}, err
+	}
+	// listings carry each item's primary image
+	if img.IsPrimary {
+		s.invalidateCatalog(ctx)
+	}
+	return img, nil
+}
+
+func imageExtension(contentType string) string {
+	if contentType == "image/png" {
+		return "png"
+	}
+	return "jpg"
+}
+
+func (s Server) PresignItemImageUpload(ctx context.Context, request genapi.PresignItemImageUploadRequestObject) (genapi.PresignItemImageUploadResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return genapi.PresignItemImageUpload401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		return genapi.PresignItemImageUpload500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return genapi.PresignItemImageUpload403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	_, err = s.db.Queries().GetItemByID(ctx, request.ItemId)
+	if err != nil {
+		return genapi.PresignItemImageUpload404JSONResponse(NotFound("Item").Create()), nil
+	}
+
+	if request.Body == nil {
+		return genapi.PresignItemImageUpload400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	var ext string
+	switch request.Body.ContentType {
+	case genapi.ItemImagePresignRequestContentTypeImagejpeg:
+		ext = "jpg"
+	case genapi.ItemImagePresignRequestContentTypeImagepng:
+		ext = "png"
+	default:
+		return genapi.PresignItemImageUpload400JSONResponse(ValidationErr("content_type must be image/jpeg or image/png", nil).Create()), nil
+	}
+
+	key := itemImageUploadPrefix(request.ItemId) + uuid.New().String() + "." + ext
+	url, err := s.storage.GeneratePresignedURL(ctx, "PUT", key, itemImageUploadExpiry)
+	if err != nil {
+		logger.Error("Failed to presign item image upload", "item_id", request.ItemId, "error", err)
+		return genapi.PresignItemImageUpload500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	return genapi.PresignItemImageUpload201JSONResponse{
+		Key:       key,
+		UploadUrl: url,
+		ExpiresAt: time.Now().Add(itemImageUploadExpiry),
+	}, nil
 }
 
 func (s Server) ListItemImages(ctx context.Context, request genapi.ListItemImagesRequestObject) (genapi.ListItemImagesResponseObject, error) {
@@ -232,11 +380,14 @@ func (s Server) DeleteItemImage(ctx context.Context, request genapi.DeleteItemIm
 	if err := s.db.Queries().DeleteItemImage(ctx, img.ID); err != nil {
 		return genapi.DeleteItemImage500JSONResponse(InternalError("Failed to delete image record").Create()), nil
 	}
+	if img.IsPrimary {
+		s.invalidateCatalog(ctx)
+	}
 	logger := middleware.GetLoggerFromContext(ctx)
-	if err := s.s3Service.DeleteObject(ctx, img.OriginalS3Key); err != nil {
+	if err := s.storage.DeleteObject(ctx, img.OriginalS3Key); err != nil {
 		logger.Warn("failed to delete S3 object", "key", img.OriginalS3Key, "error", err)
 	}
-	if err := s.s3Service.DeleteObject(ctx, img.ThumbnailS3Key); err != nil {
+	if err := s.storage.DeleteObject(ctx, img.ThumbnailS3Key); err != nil {
 		logger.Warn("failed to delete S3 object", "key", img.ThumbnailS3Key, "error", err)
 	}
 
@@ -287,7 +438,44 @@ func (s Server) SetItemPrimaryImage(ctx context.Context, request genapi.SetItemP
 	if err := tx.Commit(ctx); err != nil {
 		return genapi.SetItemPrimaryImage500JSONResponse(InternalError("Failed to commit transaction").Create()), nil
 	}
+	s.invalidateCatalog(ctx)
 
 	img.IsPrimary = true
 	return genapi.SetItemPrimaryImage200JSONResponse(s.buildItemImageResponse(ctx, img)), nil
 }
+
+// fills in each item's primary image with one query.
+func (s Server) attachPrimaryImages(ctx context.Context, items []genapi.ItemResponse) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.Id)
+	}
+
+	images, err := s.db.Queries().ListPrimaryItemImagesForItems(ctx, ids)
+	if err != nil {
+		return err
+	}
+	imageByItem := make(map[uuid.UUID]db.ItemImage, len(images))
+	for _, img := range images {
+		imageByItem[img.ItemID] = img
+	}
+
+	for i := range items {
+		if img, ok := imageByItem[items[i].Id]; ok {
+			primary := s.buildItemImageResponse(ctx, img)
+			items[i].PrimaryImage = &primary
+		}
+	}
+	return nil
+}
+
+// fills in everything an item response carries beyond the item row itself.
+func (s Server) attachItemDetails(ctx context.Context, items []genapi.ItemResponse) error {
+	if err := s.attachItemLabels(ctx, items); err != nil {
+		return err
+	}
+	return s.attachPrimaryImages(ctx, items)
+}
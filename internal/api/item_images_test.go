@@ -8,6 +8,7 @@ import (
 	imgdraw "image/draw"
 	"image/jpeg"
 	"mime/multipart"
+	"strings"
 	"testing"
 
 	genapi "github.com/USSTM/cv-backend/generated/api"
@@ -62,8 +63,8 @@ func TestUploadItemImage(t *testing.T) {
 		})
 
 		resp, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
-			ItemId: item.ID,
-			Body:   reader,
+			ItemId:        item.ID,
+			MultipartBody: reader,
 		})
 		require.NoError(t, err)
 		require.IsType(t, genapi.UploadItemImage201JSONResponse{}, resp)
@@ -88,8 +89,8 @@ func TestUploadItemImage(t *testing.T) {
 
 		reader := createJPEGMultipartReader(t, 200, 150, nil)
 		resp, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
-			ItemId: item.ID,
-			Body:   reader,
+			ItemId:        item.ID,
+			MultipartBody: reader,
 		})
 		require.NoError(t, err)
 		require.IsType(t, genapi.UploadItemImage403JSONResponse{}, resp)
@@ -104,8 +105,8 @@ func TestUploadItemImage(t *testing.T) {
 
 		reader := createJPEGMultipartReader(t, 200, 150, nil)
 		resp, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
-			ItemId: uuid.New(),
-			Body:   reader,
+			ItemId:        uuid.New(),
+			MultipartBody: reader,
 		})
 		require.NoError(t, err)
 		require.IsType(t, genapi.UploadItemImage404JSONResponse{}, resp)
@@ -126,8 +127,8 @@ func TestUploadItemImage(t *testing.T) {
 		})
 
 		resp, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
-			ItemId: item.ID,
-			Body:   reader,
+			ItemId:        item.ID,
+			MultipartBody: reader,
 		})
 		require.NoError(t, err)
 		require.IsType(t, genapi.UploadItemImage201JSONResponse{}, resp)
@@ -149,8 +150,8 @@ func TestUploadItemImage(t *testing.T) {
 			"display_order": "-1",
 		})
 		resp, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
-			ItemId: item.ID,
-			Body:   reader,
+			ItemId:        item.ID,
+			MultipartBody: reader,
 		})
 		require.NoError(t, err)
 		require.IsType(t, genapi.UploadItemImage400JSONResponse{}, resp)
@@ -169,8 +170,8 @@ func TestUploadItemImage(t *testing.T) {
 			"display_order": "2147483648", // math.MaxInt32 + 1
 		})
 		resp, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
-			ItemId: item.ID,
-			Body:   reader,
+			ItemId:        item.ID,
+			MultipartBody: reader,
 		})
 		require.NoError(t, err)
 		require.IsType(t, genapi.UploadItemImage400JSONResponse{}, resp)
@@ -190,12 +191,123 @@ func TestUploadItemImage(t *testing.T) {
 		})
 
 		resp, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
+			ItemId:        item.ID,
+			MultipartBody: reader,
+		})
+		require.NoError(t, err)
+		require.IsType(t, genapi.UploadItemImage400JSONResponse{}, resp)
+	})
+}
+
+func encodeTestJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
+
+func TestPresignedItemImageUpload(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	t.Run("confirmed upload becomes the primary image", func(t *testing.T) {
+		server, testDB, mockAuth := newTestServer(t)
+
+		adminUser := testDB.NewUser(t).WithEmail("presign@item.ca").AsGlobalAdmin().Create()
+		item := testDB.NewItem(t).WithName("PresignCam").WithType("medium").WithStock(5).Create()
+		ctx := testutil.ContextWithUser(context.Background(), adminUser, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(adminUser.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.PresignItemImageUpload(ctx, genapi.PresignItemImageUploadRequestObject{
 			ItemId: item.ID,
-			Body:   reader,
+			Body:   &genapi.ItemImagePresignRequest{ContentType: genapi.ItemImagePresignRequestContentTypeImagejpeg},
+		})
+		require.NoError(t, err)
+		require.IsType(t, genapi.PresignItemImageUpload201JSONResponse{}, resp)
+		upload := resp.(genapi.PresignItemImageUpload201JSONResponse)
+		assert.True(t, strings.HasPrefix(upload.Key, "items/"+item.ID.String()+"/uploads/"))
+		assert.NotEmpty(t, upload.UploadUrl)
+
+		// stands in for the client's PUT to the presigned URL
+		require.NoError(t, sharedLocalStack.PutObject(ctx, upload.Key, bytes.NewReader(encodeTestJPEG(t, 300, 300)), "image/jpeg"))
+
+		isPrimary := true
+		confirm := genapi.ConfirmItemImageUploadRequest{Key: upload.Key, IsPrimary: &isPrimary}
+		mockAuth.ExpectCheckPermission(adminUser.ID, rbac.ManageItems, nil, true, nil)
+		resp2, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{ItemId: item.ID, JSONBody: &confirm})
+		require.NoError(t, err)
+		require.IsType(t, genapi.UploadItemImage201JSONResponse{}, resp2)
+		created := resp2.(genapi.UploadItemImage201JSONResponse)
+		assert.True(t, created.IsPrimary)
+
+		// confirming again returns the same image
+		mockAuth.ExpectCheckPermission(adminUser.ID, rbac.ManageItems, nil, true, nil)
+		resp3, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{ItemId: item.ID, JSONBody: &confirm})
+		require.NoError(t, err)
+		require.IsType(t, genapi.UploadItemImage201JSONResponse{}, resp3)
+		assert.Equal(t, created.Id, resp3.(genapi.UploadItemImage201JSONResponse).Id)
+
+		mockAuth.ExpectCheckPermission(adminUser.ID, rbac.ViewItems, nil, true, nil)
+		itemResp, err := server.GetItemById(ctx, genapi.GetItemByIdRequestObject{Id: item.ID})
+		require.NoError(t, err)
+		require.IsType(t, genapi.GetItemById200JSONResponse{}, itemResp)
+		primary := itemResp.(genapi.GetItemById200JSONResponse).Body.PrimaryImage
+		require.NotNil(t, primary)
+		assert.Equal(t, created.Id, primary.Id)
+	})
+
+	t.Run("rejects a key issued for another item", func(t *testing.T) {
+		server, testDB, mockAuth := newTestServer(t)
+
+		adminUser := testDB.NewUser(t).WithEmail("presign2@item.ca").AsGlobalAdmin().Create()
+		item := testDB.NewItem(t).WithName("PresignCam2").WithType("medium").WithStock(5).Create()
+		ctx := testutil.ContextWithUser(context.Background(), adminUser, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(adminUser.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
+			ItemId:   item.ID,
+			JSONBody: &genapi.ConfirmItemImageUploadRequest{Key: "items/" + uuid.NewString() + "/uploads/" + uuid.NewString() + ".jpg"},
 		})
 		require.NoError(t, err)
 		require.IsType(t, genapi.UploadItemImage400JSONResponse{}, resp)
 	})
+
+	t.Run("rejects an upload that is not an image", func(t *testing.T) {
+		server, testDB, mockAuth := newTestServer(t)
+
+		adminUser := testDB.NewUser(t).WithEmail("presign3@item.ca").AsGlobalAdmin().Create()
+		item := testDB.NewItem(t).WithName("PresignCam3").WithType("medium").WithStock(5).Create()
+		ctx := testutil.ContextWithUser(context.Background(), adminUser, testDB.Queries())
+
+		key := "items/" + item.ID.String() + "/uploads/" + uuid.NewString() + ".jpg"
+		require.NoError(t, sharedLocalStack.PutObject(ctx, key, strings.NewReader("not an image"), "image/jpeg"))
+
+		mockAuth.ExpectCheckPermission(adminUser.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
+			ItemId:   item.ID,
+			JSONBody: &genapi.ConfirmItemImageUploadRequest{Key: key},
+		})
+		require.NoError(t, err)
+		require.IsType(t, genapi.UploadItemImage400JSONResponse{}, resp)
+	})
+
+	t.Run("presign requires manage_items permission", func(t *testing.T) {
+		server, testDB, mockAuth := newTestServer(t)
+
+		member := testDB.NewUser(t).WithEmail("presign4@item.ca").AsMember().Create()
+		item := testDB.NewItem(t).WithName("PresignCam4").WithType("medium").WithStock(5).Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageItems, nil, false, nil)
+		resp, err := server.PresignItemImageUpload(ctx, genapi.PresignItemImageUploadRequestObject{
+			ItemId: item.ID,
+			Body:   &genapi.ItemImagePresignRequest{ContentType: genapi.ItemImagePresignRequestContentTypeImagepng},
+		})
+		require.NoError(t, err)
+		require.IsType(t, genapi.PresignItemImageUpload403JSONResponse{}, resp)
+	})
 }
 
 func TestListItemImages(t *testing.T) {
@@ -215,8 +327,8 @@ func TestListItemImages(t *testing.T) {
 			ctx := testutil.ContextWithUser(context.Background(), adminUser, testDB.Queries())
 			reader := createJPEGMultipartReader(t, 200, 150, map[string]string{"display_order": string(rune('0' + i))})
 			_, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
-				ItemId: item.ID,
-				Body:   reader,
+				ItemId:        item.ID,
+				MultipartBody: reader,
 			})
 			require.NoError(t, err)
 		}
@@ -274,8 +386,8 @@ func TestDeleteItemImage(t *testing.T) {
 		ctx := testutil.ContextWithUser(context.Background(), adminUser, testDB.Queries())
 		reader := createJPEGMultipartReader(t, 200, 150, nil)
 		uploadResp, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
-			ItemId: item.ID,
-			Body:   reader,
+			ItemId:        item.ID,
+			MultipartBody: reader,
 		})
 		require.NoError(t, err)
 		require.IsType(t, genapi.UploadItemImage201JSONResponse{}, uploadResp)
@@ -321,8 +433,8 @@ func TestDeleteItemImage(t *testing.T) {
 		ctx := testutil.ContextWithUser(context.Background(), adminUser, testDB.Queries())
 		reader := createJPEGMultipartReader(t, 200, 150, nil)
 		uploadResp, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
-			ItemId: itemA.ID,
-			Body:   reader,
+			ItemId:        itemA.ID,
+			MultipartBody: reader,
 		})
 		require.NoError(t, err)
 		require.IsType(t, genapi.UploadItemImage201JSONResponse{}, uploadResp)
@@ -355,8 +467,8 @@ func TestSetItemPrimaryImage(t *testing.T) {
 		mockAuth.ExpectCheckPermission(adminUser.ID, rbac.ManageItems, nil, true, nil)
 		reader1 := createJPEGMultipartReader(t, 300, 300, nil)
 		uploadResp1, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
-			ItemId: item.ID,
-			Body:   reader1,
+			ItemId:        item.ID,
+			MultipartBody: reader1,
 		})
 		require.NoError(t, err)
 		imageID1 := uploadResp1.(genapi.UploadItemImage201JSONResponse).Id
@@ -365,8 +477,8 @@ func TestSetItemPrimaryImage(t *testing.T) {
 		mockAuth.ExpectCheckPermission(adminUser.ID, rbac.ManageItems, nil, true, nil)
 		reader2 := createJPEGMultipartReader(t, 300, 300, nil)
 		uploadResp2, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
-			ItemId: item.ID,
-			Body:   reader2,
+			ItemId:        item.ID,
+			MultipartBody: reader2,
 		})
 		require.NoError(t, err)
 		imageID2 := uploadResp2.(genapi.UploadItemImage201JSONResponse).Id
@@ -413,8 +525,8 @@ func TestSetItemPrimaryImage(t *testing.T) {
 		mockAuth.ExpectCheckPermission(adminUser.ID, rbac.ManageItems, nil, true, nil)
 		reader := createJPEGMultipartReader(t, 200, 150, nil)
 		uploadResp, err := server.UploadItemImage(ctx, genapi.UploadItemImageRequestObject{
-			ItemId: item.ID,
-			Body:   reader,
+			ItemId:        item.ID,
+			MultipartBody: reader,
 		})
 		require.NoError(t, err)
 		imageID := uploadResp.(genapi.UploadItemImage201JSONResponse).Id
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/itemcsv"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s Server) ImportItems(ctx context.Context, request api.ImportItemsRequestObject) (api.ImportItemsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ImportItems401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.ImportItems500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ImportItems403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.ImportItems400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	form, err := request.Body.ReadForm(32 << 20)
+	if err != nil {
+		return api.ImportItems400JSONResponse(ValidationErr("Failed to parse multipart form", nil).Create()), nil
+	}
+	defer form.RemoveAll()
+
+	files := form.File["file"]
+	if len(files) == 0 {
+		return api.ImportItems400JSONResponse(ValidationErr("Missing file field", nil).Create()), nil
+	}
+	file, err := files[0].Open()
+	if err != nil {
+		return api.ImportItems400JSONResponse(ValidationErr("Failed to open file", nil).Create()), nil
+	}
+	defer file.Close()
+
+	rows, err := itemcsv.Parse(file)
+	if err != nil {
+		return api.ImportItems400JSONResponse(ValidationErr(err.Error(), nil).Create()), nil
+	}
+
+	run := itemImportRun{
+		queries:    s.db.Queries(),
+		begin:      s.db.Pool().Begin,
+		dryRun:     isDryRun(request.Params.DryRun),
+		importedBy: user.ID,
+	}
+	// as with user imports, a dry run imports every row inside one outer
+	// transaction and then rolls it all back
+	if run.dryRun {
+		outer, err := s.db.Pool().Begin(ctx)
+		if err != nil {
+			logger.Error("Failed to begin dry run transaction", "error", err)
+			return api.ImportItems500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		defer outer.Rollback(ctx)
+		run.queries = s.db.Queries().WithTx(outer)
+		run.begin = outer.Begin
+	}
+
+	report := api.ItemImportReport{DryRun: run.dryRun, Rows: make([]api.ItemImportRow, 0, len(rows))}
+	for _, row := range rows {
+		result := s.importItemRow(ctx, run, row)
+		switch result.Status {
+		case api.ItemImportRowStatusCreated:
+			report.Created++
+		case api.ItemImportRowStatusUpdated:
+			report.Updated++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+	if !run.dryRun && report.Created+report.Updated > 0 {
+		s.invalidateCatalog(ctx)
+	}
+
+	logger.Info("Items imported",
+		"dry_run", run.dryRun,
+		"created", report.Created,
+		"updated", report.Updated,
+		"failed", report.Failed,
+		"user_id", user.ID)
+	return api.ImportItems200JSONResponse(report), nil
+}
+
+type itemImportRun struct {
+	queries    *db.Queries
+	begin      func(context.Context) (pgx.Tx, error)
+	dryRun     bool
+	importedBy uuid.UUID
+}
+
+// creates the row's item, or updates it when the row has the id of an
+// existing one, together with its category and tags.
+func (s Server) importItemRow(ctx context.Context, run itemImportRun, row itemcsv.Row) api.ItemImportRow {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	result := api.ItemImportRow{Line: row.Line, Name: row.Name}
+	fail := func(message string) api.ItemImportRow {
+		result.Status = api.ItemImportRowStatusFailed
+		result.Message = &message
+		return result
+	}
+	if row.Err != "" {
+		return fail(row.Err)
+	}
+
+	labels, problem, err := s.resolveItemLabels(ctx, row.Category, row.Tags, false)
+	if err != nil {
+		logger.Error("Failed to resolve item labels", "line", row.Line, "error", err)
+		return fail("An unexpected error occurred.")
+	}
+	if problem != "" {
+		return fail(problem)
+	}
+
+	tx, err := run.begin(ctx)
+	if err != nil {
+		logger.Error("Failed to start transaction", "error", err)
+		return fail("An unexpected error occurred.")
+	}
+	defer tx.Rollback(ctx)
+	qtx := run.queries.WithTx(tx)
+
+	var item db.Item
+	if row.ID != nil {
+		existing, err := qtx.GetItemByIDForUpdate(ctx, *row.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fail("no item with this id")
+		}
+		if err != nil {
+			logger.Error("Failed to get item", "item_id", *row.ID, "error", err)
+			return fail("An unexpected error occurred.")
+		}
+
+		params := db.UpdateItemParams{
+			ID:          existing.ID,
+			Name:        row.Name,
+			Description: existing.Description,
+			Type:        db.ItemType(row.Type),
+			Stock:       int32(row.Stock),
+			Urls:        existing.Urls,
+		}
+		if row.Description != nil {
+			params.Description = pgtype.Text{String: *row.Description, Valid: *row.Description != ""}
+		}
+		if row.URLs != nil {
+			params.Urls = *row.URLs
+		}
+		if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindAdjust, Reason: "item import", ActorID: &run.importedBy}); err != nil {
+			logger.Error("Failed to tag stock movement", "item_id", existing.ID, "error", err)
+			return fail("An unexpected error occurred.")
+		}
+		if item, err = qtx.UpdateItem(ctx, params); err != nil {
+			logger.Error("Failed to update item", "item_id", existing.ID, "error", err)
+			return fail("An unexpected error occurred.")
+		}
+		result.Status = api.ItemImportRowStatusUpdated
+	} else {
+		params := db.CreateItemParams{
+			Name:  row.Name,
+			Type:  db.ItemType(row.Type),
+			Stock: int32(row.Stock),
+			Urls:  []string{},
+		}
+		if row.Description != nil && *row.Description != "" {
+			params.Description = pgtype.Text{String: *row.Description, Valid: true}
+		}
+		if row.URLs != nil {
+			params.Urls = *row.URLs
+		}
+		if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindPurchase, Reason: "item import", ActorID: &run.importedBy}); err != nil {
+			logger.Error("Failed to tag stock movement", "line", row.Line, "error", err)
+			return fail("An unexpected error occurred.")
+		}
+		if item, err = qtx.CreateItem(ctx, params); err != nil {
+			logger.Error("Failed to create item", "line", row.Line, "error", err)
+			return fail("An unexpected error occurred.")
+		}
+		result.Status = api.ItemImportRowStatusCreated
+	}
+
+	if err := applyItemLabels(ctx, qtx, item.ID, labels); err != nil {
+		logger.Error("Failed to set item labels", "item_id", item.ID, "error", err)
+		return fail("An unexpected error occurred.")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit imported item", "item_id", item.ID, "error", err)
+		return fail("An unexpected error occurred.")
+	}
+
+	result.ItemId = &item.ID
+	return result
+}
+
+func (s Server) ExportItems(ctx context.Context, request api.ExportItemsRequestObject) (api.ExportItemsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ExportItems401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.ExportItems500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ExportItems403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	items, err := s.db.Queries().ListAllItems(ctx)
+	if err != nil {
+		logger.Error("Failed to list items", "error", err)
+		return api.ExportItems500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	labelled := make([]api.ItemResponse, 0, len(items))
+	for _, item := range items {
+		labelled = append(labelled, api.ItemResponse{Id: item.ID})
+	}
+	if err := s.attachItemLabels(ctx, labelled); err != nil {
+		logger.Error("Failed to get item labels", "error", err)
+		return api.ExportItems500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	rows := make([]itemcsv.Item, 0, len(items))
+	for i, item := range items {
+		row := itemcsv.Item{
+			ID:          item.ID,
+			Name:        item.Name,
+			Description: item.Description.String,
+			Type:        string(item.Type),
+			Stock:       int(item.Stock),
+			Tags:        *labelled[i].Tags,
+			URLs:        item.Urls,
+		}
+		if labelled[i].Category != nil {
+			row.Category = *labelled[i].Category
+		}
+		rows = append(rows, row)
+	}
+
+	var buf bytes.Buffer
+	if err := itemcsv.Write(&buf, rows); err != nil {
+		logger.Error("Failed to write item export", "error", err)
+		return api.ExportItems500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Items exported", "count", len(rows), "user_id", user.ID)
+	return api.ExportItems200TextcsvResponse{
+		Body:          &buf,
+		ContentLength: int64(buf.Len()),
+	}, nil
+}
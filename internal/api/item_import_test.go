@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ImportItems(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("creates and updates rows independently", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@items.test").AsGlobalAdmin().Create()
+		existing := testDB.NewItem(t).WithName("Tripod").WithDescription("Manfrotto").WithType("low").WithStock(1).Create()
+		_, err := testDB.Queries().CreateCategory(context.Background(), db.CreateCategoryParams{Slug: "av", Name: "Audio & Visual"})
+		require.NoError(t, err)
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.ImportItems(ctx, api.ImportItemsRequestObject{
+			Body: createCSVMultipartReader(t,
+				"id,name,type,stock,category,tags\n"+
+					",Camera,high,2,av,video;camera\n"+
+					existing.ID.String()+",Tripod (tall),low,4,,\n"+
+					",Projector,medium,1,no-such-category,\n"+
+					",Cable,low,lots,,\n",
+				nil),
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ImportItems200JSONResponse{}, response)
+
+		report := response.(api.ImportItems200JSONResponse)
+		assert.False(t, report.DryRun)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Updated)
+		assert.Equal(t, 2, report.Failed)
+		require.Len(t, report.Rows, 4)
+		assert.Equal(t, "unknown category: no-such-category", *report.Rows[2].Message)
+		assert.Equal(t, 5, report.Rows[3].Line)
+
+		camera, err := testDB.Queries().GetItemByID(ctx, *report.Rows[0].ItemId)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), camera.Stock)
+		tags, err := testDB.Queries().ListTagsForItems(ctx, []api.UUID{camera.ID})
+		require.NoError(t, err)
+		assert.Len(t, tags, 2)
+
+		tripod, err := testDB.Queries().GetItemByID(ctx, existing.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Tripod (tall)", tripod.Name)
+		assert.Equal(t, int32(4), tripod.Stock)
+		assert.Equal(t, "Manfrotto", tripod.Description.String, "no description column leaves it alone")
+	})
+
+	t.Run("dry run rolls back", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@items.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		dryRun := true
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.ImportItems(ctx, api.ImportItemsRequestObject{
+			Params: api.ImportItemsParams{DryRun: &dryRun},
+			Body:   createCSVMultipartReader(t, "name,type,stock\nCamera,high,2\n", nil),
+		})
+		require.NoError(t, err)
+		report := response.(api.ImportItems200JSONResponse)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 1, report.Created)
+
+		_, err = testDB.Queries().GetItemByName(ctx, "Camera")
+		assert.Error(t, err, "nothing is written on a dry run")
+	})
+
+	t.Run("missing required column", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@items.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		response, err := server.ImportItems(ctx, api.ImportItemsRequestObject{
+			Body: createCSVMultipartReader(t, "name,type\nCamera,high\n", nil),
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ImportItems400JSONResponse{}, response)
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@items.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageItems, nil, false, nil)
+		response, err := server.ImportItems(ctx, api.ImportItemsRequestObject{
+			Body: createCSVMultipartReader(t, "name,type,stock\nCamera,high,2\n", nil),
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ImportItems403JSONResponse{}, response)
+	})
+}
+
+func TestServer_ExportItems(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@items.test").AsGlobalAdmin().Create()
+	camera := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(2).
+		WithUrls([]string{"https://example.com/manual.pdf"}).Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+	response, err := server.ExportItems(ctx, api.ExportItemsRequestObject{})
+	require.NoError(t, err)
+	require.IsType(t, api.ExportItems200TextcsvResponse{}, response)
+
+	body, err := io.ReadAll(response.(api.ExportItems200TextcsvResponse).Body)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "id,name,description,type,stock,category,tags,urls", lines[0])
+	assert.Equal(t, camera.ID.String()+",Camera,Test item description,high,2,,,https://example.com/manual.pdf", lines[1])
+}
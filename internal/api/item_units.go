@@ -0,0 +1,347 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/damage"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func toItemUnitResponse(u db.ItemUnit) api.ItemUnit {
+	resp := api.ItemUnit{
+		Id:        u.ID,
+		ItemId:    u.ItemID,
+		Condition: string(u.Condition),
+		Status:    api.UnitStatus(u.Status),
+		CreatedAt: u.CreatedAt.Time,
+		UpdatedAt: u.UpdatedAt.Time,
+	}
+	if u.SerialNumber.Valid {
+		resp.SerialNumber = &u.SerialNumber.String
+	}
+	if u.AssetTag.Valid {
+		resp.AssetTag = &u.AssetTag.String
+	}
+	if u.Notes.Valid {
+		resp.Notes = &u.Notes.String
+	}
+	return resp
+}
+
+// returned as client-facing errors, so handlers pass them through errorFor
+var (
+	errUnitNotTracked  = ValidationErr("unit_id was given for an item that is not tracked by unit", nil)
+	errUnitQuantity    = ValidationErr("Items tracked by unit are handed out one unit at a time", nil)
+	errUnitNotOfItem   = ValidationErr("unit_id is not a unit of this item", nil)
+	errUnitUnavailable = ValidationErr("Unit is not available", nil)
+	errNoUnitAvailable = ValidationErr("No unit of this item is available", nil)
+	errUnitNotFound    = NotFound("Unit")
+	errUnitBorrowed    = ConflictErr("Unit is borrowed; it must be returned first")
+	errUnitTaken       = ConflictErr("A unit with this serial number or asset tag already exists")
+)
+
+// the unit a hand-out of quantity of itemID takes: for items tracked by unit
+// the requested one, or the first available, locked until the transaction
+// ends. Items without units take none. The unit's status is left for
+// holdItemUnit, once the borrowing or maintenance record exists.
+func pickItemUnit(ctx context.Context, qtx *db.Queries, itemID uuid.UUID, requested *uuid.UUID, quantity int32) (*uuid.UUID, error) {
+	tracked, err := qtx.ItemHasUnits(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if !tracked {
+		if requested != nil {
+			return nil, errUnitNotTracked
+		}
+		return nil, nil
+	}
+	if quantity != 1 {
+		return nil, errUnitQuantity
+	}
+
+	var unit db.ItemUnit
+	if requested != nil {
+		unit, err = qtx.GetItemUnitForUpdate(ctx, *requested)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && unit.ItemID != itemID) {
+			return nil, errUnitNotOfItem
+		}
+		if err != nil {
+			return nil, err
+		}
+		if unit.Status != db.UnitStatusAvailable {
+			return nil, errUnitUnavailable
+		}
+	} else {
+		unit, err = qtx.GetAvailableItemUnit(ctx, itemID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errNoUnitAvailable
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &unit.ID, nil
+}
+
+// moves a unit picked by pickItemUnit off the shelf; the item's stock follows.
+func holdItemUnit(ctx context.Context, qtx *db.Queries, unitID *uuid.UUID, status db.UnitStatus) error {
+	if unitID == nil {
+		return nil
+	}
+	return qtx.SetItemUnitStatus(ctx, db.SetItemUnitStatusParams{ID: *unitID, Status: status})
+}
+
+// puts a unit back on the shelf, in the condition it came back in when known.
+func releaseItemUnit(ctx context.Context, qtx *db.Queries, unitID *uuid.UUID, condition db.NullCondition) error {
+	if unitID == nil {
+		return nil
+	}
+	return qtx.ReturnItemUnit(ctx, db.ReturnItemUnitParams{Condition: condition, ID: *unitID})
+}
+
+// trimmed, with blank meaning unset
+func unitText(s *string) pgtype.Text {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: strings.TrimSpace(*s), Valid: true}
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (s Server) ListItemUnits(ctx context.Context, request api.ListItemUnitsRequestObject) (api.ListItemUnitsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListItemUnits401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.ListItemUnits500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListItemUnits403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	// archived items keep their units
+	if _, err := s.db.Queries().GetItemByIDIncludingBinned(ctx, request.ItemId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.ListItemUnits404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.ListItemUnits500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	units, err := s.db.Queries().ListItemUnits(ctx, request.ItemId)
+	if err != nil {
+		logger.Error("Failed to list item units", "item_id", request.ItemId, "error", err)
+		return api.ListItemUnits500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := make(api.ListItemUnits200JSONResponse, 0, len(units))
+	for _, u := range units {
+		response = append(response, toItemUnitResponse(u))
+	}
+	return response, nil
+}
+
+func (s Server) CreateItemUnit(ctx context.Context, request api.CreateItemUnitRequestObject) (api.CreateItemUnitResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateItemUnit401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.CreateItemUnit500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CreateItemUnit403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.CreateItemUnit400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	condition := db.ConditionGood
+	if request.Body.Condition != nil {
+		condition = db.Condition(*request.Body.Condition)
+		if !damage.ValidCondition(condition) {
+			return api.CreateItemUnit400JSONResponse(ValidationErr("condition must be one of pristine, good, decent, damaged or unusable", nil).Create()), nil
+		}
+	}
+
+	item, err := s.db.Queries().GetItemByID(ctx, request.ItemId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.CreateItemUnit404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.CreateItemUnit500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	// low items are taken, never returned, so there is no unit to follow
+	if item.Type == db.ItemTypeLow {
+		return api.CreateItemUnit400JSONResponse(ValidationErr("Low-value items cannot be tracked by unit", nil).Create()), nil
+	}
+
+	unit, err := s.db.Queries().CreateItemUnit(ctx, db.CreateItemUnitParams{
+		ItemID:       item.ID,
+		SerialNumber: unitText(request.Body.SerialNumber),
+		AssetTag:     unitText(request.Body.AssetTag),
+		Condition:    condition,
+		Notes:        unitText(request.Body.Notes),
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return api.CreateItemUnit409JSONResponse(errUnitTaken.Create()), nil
+		}
+		logger.Error("Failed to create item unit", "item_id", item.ID, "error", err)
+		return api.CreateItemUnit500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Item unit created",
+		"item_id", item.ID,
+		"unit_id", unit.ID,
+		"user_id", user.ID)
+
+	return api.CreateItemUnit201JSONResponse(toItemUnitResponse(unit)), nil
+}
+
+func (s Server) UpdateItemUnit(ctx context.Context, request api.UpdateItemUnitRequestObject) (api.UpdateItemUnitResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.UpdateItemUnit401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.UpdateItemUnit500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.UpdateItemUnit403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.UpdateItemUnit400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	if request.Body.Condition != nil && !damage.ValidCondition(db.Condition(*request.Body.Condition)) {
+		return api.UpdateItemUnit400JSONResponse(ValidationErr("condition must be one of pristine, good, decent, damaged or unusable", nil).Create()), nil
+	}
+	if status := request.Body.Status; status != nil {
+		switch *status {
+		case api.UnitStatusAvailable, api.UnitStatusMaintenance, api.UnitStatusRetired:
+		default:
+			return api.UpdateItemUnit400JSONResponse(ValidationErr("status must be one of available, maintenance or retired", nil).Create()), nil
+		}
+	}
+
+	unit, restocked, err := s.updateItemUnit(ctx, request.ItemId, request.UnitId, request.Body)
+	if err != nil {
+		switch apiErr := errorFor(err); apiErr.Code {
+		case CodeResourceNotFound:
+			return api.UpdateItemUnit404JSONResponse(apiErr.Create()), nil
+		case CodeConflict:
+			return api.UpdateItemUnit409JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to update item unit", "unit_id", request.UnitId, "error", err)
+		return api.UpdateItemUnit500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	// a unit back on the shelf is one more the waitlist can have
+	if restocked {
+		if _, err := s.queue.Enqueue(ctx, queue.TypeWaitlistNotify, queue.WaitlistNotifyPayload{ItemID: unit.ItemID}); err != nil {
+			logger.Warn("Failed to enqueue waitlist notification", "item_id", unit.ItemID, "error", err)
+		}
+	}
+
+	logger.Info("Item unit updated",
+		"item_id", unit.ItemID,
+		"unit_id", unit.ID,
+		"status", unit.Status,
+		"user_id", user.ID)
+
+	return api.UpdateItemUnit200JSONResponse(toItemUnitResponse(unit)), nil
+}
+
+// applies body to the unit, reporting whether it went back on the shelf.
+func (s Server) updateItemUnit(ctx context.Context, itemID, unitID uuid.UUID, body *api.UpdateItemUnitRequest) (db.ItemUnit, bool, error) {
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		return db.ItemUnit{}, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	existing, err := qtx.GetItemUnitForUpdate(ctx, unitID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && existing.ItemID != itemID) {
+		return db.ItemUnit{}, false, errUnitNotFound
+	}
+	if err != nil {
+		return db.ItemUnit{}, false, err
+	}
+
+	params := db.UpdateItemUnitParams{
+		ID:           existing.ID,
+		SerialNumber: existing.SerialNumber,
+		AssetTag:     existing.AssetTag,
+		Condition:    existing.Condition,
+		Status:       existing.Status,
+		Notes:        existing.Notes,
+	}
+	if body.SerialNumber != nil {
+		params.SerialNumber = unitText(body.SerialNumber)
+	}
+	if body.AssetTag != nil {
+		params.AssetTag = unitText(body.AssetTag)
+	}
+	if body.Condition != nil {
+		params.Condition = db.Condition(*body.Condition)
+	}
+	if body.Notes != nil {
+		params.Notes = unitText(body.Notes)
+	}
+	if body.Status != nil {
+		// the borrowing holding it is closed by its return, not here
+		if existing.Status == db.UnitStatusBorrowed && db.UnitStatus(*body.Status) != db.UnitStatusBorrowed {
+			return db.ItemUnit{}, false, errUnitBorrowed
+		}
+		params.Status = db.UnitStatus(*body.Status)
+	}
+
+	unit, err := qtx.UpdateItemUnit(ctx, params)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return db.ItemUnit{}, false, errUnitTaken
+		}
+		return db.ItemUnit{}, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return db.ItemUnit{}, false, err
+	}
+	restocked := existing.Status != db.UnitStatusAvailable && unit.Status == db.UnitStatusAvailable
+	return unit, restocked, nil
+}
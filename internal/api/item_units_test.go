@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ItemUnits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@units.test").AsGlobalAdmin().Create()
+	adminCtx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	member := testDB.NewUser(t).WithEmail("member@units.test").AsMember().Create()
+	group := testDB.NewGroup(t).WithName("Units Group").Create()
+	testDB.AssignUserToGroup(t, member.ID, group.ID, "member")
+	memberCtx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+	createUnit := func(t *testing.T, itemID uuid.UUID, serial string) api.CreateItemUnitResponseObject {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.CreateItemUnit(adminCtx, api.CreateItemUnitRequestObject{
+			ItemId: itemID,
+			Body:   &api.CreateItemUnitRequest{SerialNumber: &serial},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+	updateUnit := func(t *testing.T, itemID, unitID uuid.UUID, body api.UpdateItemUnitRequest) api.UpdateItemUnitResponseObject {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.UpdateItemUnit(adminCtx, api.UpdateItemUnitRequestObject{ItemId: itemID, UnitId: unitID, Body: &body})
+		require.NoError(t, err)
+		return resp
+	}
+	borrow := func(t *testing.T, itemID uuid.UUID, unitID *uuid.UUID) api.BorrowItemResponseObject {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(member.ID, rbac.RequestItems, &group.ID, true, nil)
+		resp, err := server.BorrowItem(memberCtx, api.BorrowItemRequestObject{
+			Body: &api.BorrowItemJSONRequestBody{
+				UserId:             member.ID,
+				GroupId:            group.ID,
+				ItemId:             itemID,
+				Quantity:           1,
+				DueDate:            time.Now().Add(7 * 24 * time.Hour),
+				BeforeCondition:    "good",
+				BeforeConditionUrl: "http://example.com/before.jpg",
+				UnitId:             unitID,
+			},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+	stockOf := func(t *testing.T, itemID uuid.UUID) int32 {
+		t.Helper()
+		item, err := testDB.Queries().GetItemByID(context.Background(), itemID)
+		require.NoError(t, err)
+		return item.Stock
+	}
+	unitStatus := func(t *testing.T, unitID uuid.UUID) string {
+		t.Helper()
+		unit, err := testDB.Queries().GetItemUnitByID(context.Background(), unitID)
+		require.NoError(t, err)
+		return string(unit.Status)
+	}
+
+	t.Run("stock follows the available units", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Camera").WithType("medium").WithStock(10).Create()
+
+		require.IsType(t, api.CreateItemUnit201JSONResponse{}, createUnit(t, item.ID, "CAM-001"))
+		require.IsType(t, api.CreateItemUnit201JSONResponse{}, createUnit(t, item.ID, "CAM-002"))
+		assert.Equal(t, int32(2), stockOf(t, item.ID))
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.ListItemUnits(adminCtx, api.ListItemUnitsRequestObject{ItemId: item.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.ListItemUnits200JSONResponse{}, resp)
+		assert.Len(t, resp.(api.ListItemUnits200JSONResponse), 2)
+	})
+
+	t.Run("borrowing takes a unit and returning puts it back", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Tripod").WithType("medium").WithStock(1).Create()
+		created := createUnit(t, item.ID, "TRI-001")
+		require.IsType(t, api.CreateItemUnit201JSONResponse{}, created)
+		unitID := created.(api.CreateItemUnit201JSONResponse).Id
+
+		resp := borrow(t, item.ID, nil)
+		require.IsType(t, api.BorrowItem201JSONResponse{}, resp)
+		require.NotNil(t, resp.(api.BorrowItem201JSONResponse).UnitId)
+		assert.Equal(t, unitID, *resp.(api.BorrowItem201JSONResponse).UnitId)
+		assert.Equal(t, "borrowed", unitStatus(t, unitID))
+		assert.Equal(t, int32(0), stockOf(t, item.ID))
+
+		// a borrowed unit is closed by its return, not by staff
+		retired := api.UnitStatusRetired
+		assert.IsType(t, api.UpdateItemUnit409JSONResponse{}, updateUnit(t, item.ID, unitID, api.UpdateItemUnitRequest{Status: &retired}))
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewOwnData, nil, true, nil)
+		afterConditionURL := "http://example.com/after.jpg"
+		returned, err := server.ReturnItem(memberCtx, api.ReturnItemRequestObject{
+			ItemId: item.ID,
+			Body:   &api.ReturnItemJSONRequestBody{AfterCondition: "decent", AfterConditionUrl: &afterConditionURL},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ReturnItem200JSONResponse{}, returned)
+		assert.Equal(t, "available", unitStatus(t, unitID))
+		assert.Equal(t, int32(1), stockOf(t, item.ID))
+	})
+
+	t.Run("a unit that is not available cannot be borrowed", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Drone").WithType("medium").WithStock(1).Create()
+		created := createUnit(t, item.ID, "DRN-001")
+		require.IsType(t, api.CreateItemUnit201JSONResponse{}, created)
+		unitID := created.(api.CreateItemUnit201JSONResponse).Id
+
+		retired := api.UnitStatusRetired
+		require.IsType(t, api.UpdateItemUnit200JSONResponse{}, updateUnit(t, item.ID, unitID, api.UpdateItemUnitRequest{Status: &retired}))
+		assert.Equal(t, int32(0), stockOf(t, item.ID))
+
+		assert.IsType(t, api.BorrowItem400JSONResponse{}, borrow(t, item.ID, &unitID))
+	})
+
+	t.Run("duplicate serial numbers conflict", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Laptop").WithType("medium").Create()
+
+		require.IsType(t, api.CreateItemUnit201JSONResponse{}, createUnit(t, item.ID, "LAP-001"))
+		assert.IsType(t, api.CreateItemUnit409JSONResponse{}, createUnit(t, item.ID, "LAP-001"))
+	})
+
+	t.Run("low-value items cannot be tracked by unit", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Batteries").WithType("low").Create()
+
+		assert.IsType(t, api.CreateItemUnit400JSONResponse{}, createUnit(t, item.ID, "BAT-001"))
+	})
+}
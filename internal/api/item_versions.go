@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/google/uuid"
+)
+
+// the ETag for an item at a version. The version goes up with every write to
+// the item row, stock arithmetic included, so the tag changes whenever the
+// item does.
+func itemETag(version int32) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// whether an If-Match header names the item's version, or is the "*" that
+// matches any.
+func ifMatchAllows(ifMatch string, version int32) bool {
+	current := itemETag(version)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+// checks an update's If-Match against the item, locking the item until tx
+// ends so nobody can change it between the check and the update. Returns the
+// item as it now stands when the check fails, and nil when it passes or the
+// client sent no If-Match. pgx.ErrNoRows means there is no such item.
+func staleItem(ctx context.Context, qtx *db.Queries, itemID uuid.UUID, ifMatch *string) (*db.Item, error) {
+	if ifMatch == nil {
+		return nil, nil
+	}
+	version, err := qtx.LockItemVersion(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if ifMatchAllows(*ifMatch, version) {
+		return nil, nil
+	}
+	current, err := qtx.GetItemByIDIncludingBinned(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return &current, nil
+}
@@ -0,0 +1,15 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatchAllows(t *testing.T) {
+	assert.True(t, ifMatchAllows(`"3"`, 3))
+	assert.True(t, ifMatchAllows(`"2", "3"`, 3))
+	assert.True(t, ifMatchAllows("*", 3))
+	assert.False(t, ifMatchAllows(`"2"`, 3))
+	assert.False(t, ifMatchAllows(`W/"3"`, 3), "If-Match compares strong tags only")
+}
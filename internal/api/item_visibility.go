@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"slices"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// for a group borrowing, requesting or carting an item owned by another
+// group that has not shared it with them
+func itemNotForGroupErr() *ErrorBuilder {
+	return PermissionDenied("This item belongs to another group")
+}
+
+// the user whose group memberships limit which owned items a listing shows,
+// or nil for those who see the whole inventory
+func itemViewer(user *auth.AuthenticatedUser) *uuid.UUID {
+	if user.HoldsGlobally(rbac.ManageItems) || user.HoldsGlobally(rbac.ViewAllData) {
+		return nil
+	}
+	return &user.ID
+}
+
+// whether the user may see an item. Owned items are hidden from anyone
+// outside the owner and the groups it is shared with, as if they did not
+// exist, so callers answer 404.
+func (s Server) canSeeItem(ctx context.Context, user *auth.AuthenticatedUser, item db.Item) (bool, error) {
+	viewer := itemViewer(user)
+	if item.OwnerGroupID == nil || viewer == nil {
+		return true, nil
+	}
+	return s.db.Queries().IsItemVisibleToUser(ctx, db.IsItemVisibleToUserParams{
+		ItemID: item.ID,
+		UserID: *viewer,
+	})
+}
+
+// whether a group may borrow, request or cart an item: unowned items are open
+// to all, owned ones to the owner and the groups it is shared with
+func itemAvailableToGroup(ctx context.Context, q *db.Queries, item db.Item, groupID uuid.UUID) (bool, error) {
+	if item.OwnerGroupID == nil || *item.OwnerGroupID == groupID {
+		return true, nil
+	}
+	return q.IsItemSharedWithGroup(ctx, db.IsItemSharedWithGroupParams{
+		ItemID:  item.ID,
+		GroupID: groupID,
+	})
+}
+
+func (s Server) GetItemVisibility(ctx context.Context, request api.GetItemVisibilityRequestObject) (api.GetItemVisibilityResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetItemVisibility401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.GetItemVisibility500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetItemVisibility403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	item, err := s.db.Queries().GetItemByID(ctx, request.ItemId)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return api.GetItemVisibility404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.GetItemVisibility500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	shares, err := s.db.Queries().ListItemShares(ctx, item.ID)
+	if err != nil {
+		logger.Error("Failed to list item shares", "item_id", item.ID, "error", err)
+		return api.GetItemVisibility500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	return api.GetItemVisibility200JSONResponse{
+		OwnerGroupId:   item.OwnerGroupID,
+		SharedGroupIds: shares,
+	}, nil
+}
+
+func (s Server) SetItemVisibility(ctx context.Context, request api.SetItemVisibilityRequestObject) (api.SetItemVisibilityResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.SetItemVisibility401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.SetItemVisibility500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.SetItemVisibility403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.SetItemVisibility400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	owner := request.Body.OwnerGroupId
+
+	// the owner always sees its own item, so sharing with it is a no-op
+	var shares []uuid.UUID
+	for _, groupID := range request.Body.SharedGroupIds {
+		if (owner == nil || groupID != *owner) && !slices.Contains(shares, groupID) {
+			shares = append(shares, groupID)
+		}
+	}
+	if owner == nil && len(shares) > 0 {
+		return api.SetItemVisibility400JSONResponse(ValidationErr("Only an item with an owner_group_id can be shared", nil).Create()), nil
+	}
+
+	groups := shares
+	if owner != nil {
+		groups = append([]uuid.UUID{*owner}, shares...)
+	}
+	for _, groupID := range groups {
+		if _, err := s.db.Queries().GetGroupByID(ctx, groupID); err != nil {
+			if err == pgx.ErrNoRows {
+				return api.SetItemVisibility400JSONResponse(ValidationErr("Group "+groupID.String()+" does not exist", nil).Create()), nil
+			}
+			logger.Error("Failed to get group", "group_id", groupID, "error", err)
+			return api.SetItemVisibility500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.SetItemVisibility500JSONResponse(InternalError("Failed to start transaction").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	if _, err := qtx.GetItemByIDForUpdate(ctx, request.ItemId); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.SetItemVisibility404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.SetItemVisibility500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := qtx.SetItemOwner(ctx, db.SetItemOwnerParams{ID: request.ItemId, OwnerGroupID: owner}); err != nil {
+		logger.Error("Failed to set item owner", "item_id", request.ItemId, "error", err)
+		return api.SetItemVisibility500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if err := qtx.ClearItemShares(ctx, request.ItemId); err != nil {
+		logger.Error("Failed to clear item shares", "item_id", request.ItemId, "error", err)
+		return api.SetItemVisibility500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if len(shares) > 0 {
+		if err := qtx.AddItemShares(ctx, db.AddItemSharesParams{ItemID: request.ItemId, GroupIds: shares}); err != nil {
+			logger.Error("Failed to share item", "item_id", request.ItemId, "error", err)
+			return api.SetItemVisibility500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit item visibility", "item_id", request.ItemId, "error", err)
+		return api.SetItemVisibility500JSONResponse(InternalError("Failed to commit transaction").Create()), nil
+	}
+	s.invalidateCatalog(ctx)
+
+	if shares == nil {
+		shares = []uuid.UUID{}
+	}
+	logger.Info("Item visibility set",
+		"item_id", request.ItemId,
+		"owner_group_id", owner,
+		"shared_groups", len(shares),
+		"user_id", user.ID)
+	return api.SetItemVisibility200JSONResponse{
+		OwnerGroupId:   owner,
+		SharedGroupIds: shares,
+	}, nil
+}
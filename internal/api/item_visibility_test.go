@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ItemVisibility(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	type fixture struct {
+		owner, other             *testutil.TestGroup
+		admin, insider, outsider *testutil.TestUser
+		item                     *testutil.TestItem
+	}
+
+	setup := func(t *testing.T) fixture {
+		testDB.CleanupDatabase(t)
+		f := fixture{
+			owner: testDB.NewGroup(t).WithName("Film Society").Create(),
+			other: testDB.NewGroup(t).WithName("Chess Club").Create(),
+		}
+		f.admin = testDB.NewUser(t).WithEmail("admin@visibility.test").AsGlobalAdmin().Create()
+		f.insider = testDB.NewUser(t).WithEmail("film@visibility.test").AsMemberOf(f.owner).Create()
+		f.outsider = testDB.NewUser(t).WithEmail("chess@visibility.test").AsMemberOf(f.other).Create()
+		f.item = testDB.NewItem(t).WithName("Cinema Camera").WithType("medium").WithStock(2).Create()
+		return f
+	}
+
+	setVisibility := func(t *testing.T, f fixture, owner *uuid.UUID, shared ...uuid.UUID) api.SetItemVisibilityResponseObject {
+		t.Helper()
+		ctx := testutil.ContextWithUser(context.Background(), f.admin, testDB.Queries())
+		mockAuth.ExpectCheckPermission(f.admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.SetItemVisibility(ctx, api.SetItemVisibilityRequestObject{
+			ItemId: f.item.ID,
+			Body:   &api.ItemVisibility{OwnerGroupId: owner, SharedGroupIds: shared},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	listed := func(t *testing.T, user *testutil.TestUser) []uuid.UUID {
+		t.Helper()
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ViewItems, nil, true, nil)
+		resp, err := server.GetItems(ctx, api.GetItemsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItems200JSONResponse{}, resp)
+		var ids []uuid.UUID
+		for _, item := range resp.(api.GetItems200JSONResponse).Body.Data {
+			ids = append(ids, item.Id)
+		}
+		return ids
+	}
+
+	getItem := func(t *testing.T, user *testutil.TestUser, itemID uuid.UUID) api.GetItemByIdResponseObject {
+		t.Helper()
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ViewItems, nil, true, nil)
+		resp, err := server.GetItemById(ctx, api.GetItemByIdRequestObject{Id: itemID})
+		require.NoError(t, err)
+		return resp
+	}
+
+	addToCart := func(t *testing.T, user *testutil.TestUser, groupID, itemID uuid.UUID) api.AddToCartResponseObject {
+		t.Helper()
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+		mockAuth.ExpectCheckPermission(user.ID, rbac.ManageCart, &groupID, true, nil)
+		resp, err := server.AddToCart(ctx, api.AddToCartRequestObject{
+			GroupId: groupID,
+			Body:    &api.AddToCartJSONRequestBody{GroupId: groupID, ItemId: itemID, Quantity: 1},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("owned items are hidden from other groups", func(t *testing.T) {
+		f := setup(t)
+		require.IsType(t, api.SetItemVisibility200JSONResponse{}, setVisibility(t, f, &f.owner.ID))
+
+		assert.Contains(t, listed(t, f.insider), f.item.ID)
+		assert.NotContains(t, listed(t, f.outsider), f.item.ID)
+		assert.Contains(t, listed(t, f.admin), f.item.ID, "item managers see the whole inventory")
+
+		resp := getItem(t, f.insider, f.item.ID)
+		require.IsType(t, api.GetItemById200JSONResponse{}, resp)
+		assert.Equal(t, &f.owner.ID, resp.(api.GetItemById200JSONResponse).Body.OwnerGroupId)
+		assert.IsType(t, api.GetItemById404JSONResponse{}, getItem(t, f.outsider, f.item.ID))
+	})
+
+	t.Run("only the owner can borrow", func(t *testing.T) {
+		f := setup(t)
+		require.IsType(t, api.SetItemVisibility200JSONResponse{}, setVisibility(t, f, &f.owner.ID))
+
+		assert.IsType(t, api.AddToCart200JSONResponse{}, addToCart(t, f.insider, f.owner.ID, f.item.ID))
+		assert.IsType(t, api.AddToCart403JSONResponse{}, addToCart(t, f.outsider, f.other.ID, f.item.ID))
+
+		ctx := testutil.ContextWithUser(context.Background(), f.outsider, testDB.Queries())
+		mockAuth.ExpectCheckPermission(f.outsider.ID, rbac.RequestItems, &f.other.ID, true, nil)
+		resp, err := server.BorrowItem(ctx, api.BorrowItemRequestObject{
+			Body: &api.BorrowItemJSONRequestBody{
+				UserId:             f.outsider.ID,
+				GroupId:            f.other.ID,
+				ItemId:             f.item.ID,
+				Quantity:           1,
+				BeforeCondition:    "good",
+				BeforeConditionUrl: "http://example.com/before.jpg",
+			},
+		})
+		require.NoError(t, err)
+		assert.IsType(t, api.BorrowItem403JSONResponse{}, resp)
+	})
+
+	t.Run("sharing opens the item to another group", func(t *testing.T) {
+		f := setup(t)
+		resp := setVisibility(t, f, &f.owner.ID, f.other.ID, f.owner.ID)
+		require.IsType(t, api.SetItemVisibility200JSONResponse{}, resp)
+		assert.Equal(t, []uuid.UUID{f.other.ID}, resp.(api.SetItemVisibility200JSONResponse).SharedGroupIds, "the owner is not shared with itself")
+
+		assert.Contains(t, listed(t, f.outsider), f.item.ID)
+		assert.IsType(t, api.AddToCart200JSONResponse{}, addToCart(t, f.outsider, f.other.ID, f.item.ID))
+
+		ctx := testutil.ContextWithUser(context.Background(), f.admin, testDB.Queries())
+		mockAuth.ExpectCheckPermission(f.admin.ID, rbac.ManageItems, nil, true, nil)
+		got, err := server.GetItemVisibility(ctx, api.GetItemVisibilityRequestObject{ItemId: f.item.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItemVisibility200JSONResponse{}, got)
+		assert.Equal(t, &f.owner.ID, got.(api.GetItemVisibility200JSONResponse).OwnerGroupId)
+		assert.Equal(t, []uuid.UUID{f.other.ID}, got.(api.GetItemVisibility200JSONResponse).SharedGroupIds)
+	})
+
+	t.Run("clearing the owner opens the item to everyone", func(t *testing.T) {
+		f := setup(t)
+		require.IsType(t, api.SetItemVisibility200JSONResponse{}, setVisibility(t, f, &f.owner.ID))
+		require.IsType(t, api.SetItemVisibility200JSONResponse{}, setVisibility(t, f, nil))
+
+		assert.Contains(t, listed(t, f.outsider), f.item.ID)
+		assert.IsType(t, api.GetItemById200JSONResponse{}, getItem(t, f.outsider, f.item.ID))
+	})
+
+	t.Run("invalid visibility is rejected", func(t *testing.T) {
+		f := setup(t)
+		assert.IsType(t, api.SetItemVisibility400JSONResponse{}, setVisibility(t, f, nil, f.other.ID), "shares need an owner")
+
+		missing := uuid.New()
+		assert.IsType(t, api.SetItemVisibility400JSONResponse{}, setVisibility(t, f, &missing))
+	})
+}
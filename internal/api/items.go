@@ -2,12 +2,17 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/USSTM/cv-backend/generated/api"
 	"github.com/USSTM/cv-backend/generated/db"
 	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/catalog"
 	"github.com/USSTM/cv-backend/internal/middleware"
 	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -28,18 +33,45 @@ func (s Server) GetItems(ctx context.Context, request api.GetItemsRequestObject)
 		return api.GetItems403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
 	}
 
+	includeArchived := request.Params.IncludeArchived != nil && *request.Params.IncludeArchived
+	if includeArchived {
+		canManage, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+		if err != nil {
+			logger.Error("Error checking rbac.ManageItems permission", "error", err)
+			return api.GetItems500JSONResponse(InternalError("Internal server error").Create()), nil
+		}
+		if !canManage {
+			return api.GetItems403JSONResponse(PermissionDenied("Only item managers can list archived items").Create()), nil
+		}
+	}
+
 	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+	viewer := itemViewer(user)
 
 	// check if filter
-	hasFilters := request.Params.Q != nil || request.Params.Type != nil || request.Params.InStock != nil
-
-	var response []api.ItemResponse
+	hasFilters := request.Params.Q != nil || request.Params.Type != nil || request.Params.InStock != nil ||
+		request.Params.Category != nil || request.Params.Tags != nil ||
+		request.Params.SortBy != nil || request.Params.Order != nil || includeArchived
 
 	if hasFilters {
+		// a text query keeps relevance order unless a sort is asked for
+		sortDefault := listSort{By: "name"}
+		if request.Params.Q != nil {
+			sortDefault = listSort{}
+		}
+		sort, err := parseSort(request.Params.SortBy, request.Params.Order, sortDefault, "name", "stock")
+		if err != nil {
+			return api.GetItems400JSONResponse(ValidationErr(err.Error(), nil).Create()), nil
+		}
+
 		// query with offset/limit
 		searchParams := db.SearchItemsParams{
-			Offset: offset,
-			Limit:  limit,
+			IncludeArchived: includeArchived,
+			ViewerID:        viewer,
+			SortBy:          sort.By,
+			SortDesc:        sort.Desc,
+			Offset:          offset,
+			Limit:           limit,
 		}
 
 		// query with filter
@@ -60,64 +92,64 @@ func (s Server) GetItems(ctx context.Context, request api.GetItemsRequestObject)
 			searchParams.InStock = pgtype.Bool{Bool: *request.Params.InStock, Valid: true}
 		}
 
-		items, err := s.db.Queries().SearchItems(ctx, searchParams)
-		if err != nil {
-			logger.Error("Failed to search items", "error", err)
-			return api.GetItems500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		if request.Params.Category != nil {
+			searchParams.Category = pgtype.Text{String: *request.Params.Category, Valid: true}
 		}
 
-		for _, item := range items {
-			id := item.ID
-			name := item.Name
-			description := item.Description.String
-			itemType := api.ItemType(item.Type)
-			stock := int(item.Stock)
-			urls := item.Urls
-
-			itemResponse := api.ItemResponse{
-				Id:          id,
-				Name:        name,
-				Description: &description,
-				Type:        itemType,
-				Stock:       stock,
-				Urls:        &urls,
+		if request.Params.Tags != nil {
+			tags, err := catalog.ParseTags(*request.Params.Tags)
+			if err != nil {
+				return api.GetItems400JSONResponse(ValidationErr(err.Error(), nil).Create()), nil
+			}
+			if len(tags) > 0 {
+				searchParams.Tags = tags
 			}
-			response = append(response, itemResponse)
 		}
 
-		total, err := s.db.Queries().CountSearchItems(ctx, db.CountSearchItemsParams{
-			Query:    searchParams.Query,
-			ItemType: searchParams.ItemType,
-			InStock:  searchParams.InStock,
+		var page api.PaginatedItemResponse
+		err = s.cachedListing(ctx, "items:search", searchParams, &page, func() error {
+			var err error
+			page, err = s.searchItemsPage(ctx, searchParams)
+			return err
 		})
 		if err != nil {
-			logger.Error("Failed to count search items", "error", err)
+			logger.Error("Failed to search items", "error", err)
 			return api.GetItems500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
 		}
 
-		if response == nil {
-			response = []api.ItemResponse{}
-		}
-
 		return api.GetItems200JSONResponse{
-			Data: response,
-			Meta: buildPaginationMeta(total, limit, offset),
+			Body:    page,
+			Headers: api.GetItems200ResponseHeaders{XTotalCount: page.Meta.Total},
 		}, nil
 	}
 
 	// no filter or query shenanigans
-	items, err := s.db.Queries().GetAllItems(ctx, db.GetAllItemsParams{Limit: limit, Offset: offset})
+	allParams := db.GetAllItemsParams{ViewerID: viewer, Limit: limit, Offset: offset}
+	var page api.PaginatedItemResponse
+	err = s.cachedListing(ctx, "items:all", allParams, &page, func() error {
+		var err error
+		page, err = s.allItemsPage(ctx, allParams)
+		return err
+	})
 	if err != nil {
 		logger.Error("Failed to get items", "error", err)
 		return api.GetItems500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
 	}
 
-	total, err := s.db.Queries().CountAllItems(ctx)
+	return api.GetItems200JSONResponse{
+		Body:    page,
+		Headers: api.GetItems200ResponseHeaders{XTotalCount: page.Meta.Total},
+	}, nil
+}
+
+// a page of SearchItems, the filtered GET /items
+func (s Server) searchItemsPage(ctx context.Context, searchParams db.SearchItemsParams) (api.PaginatedItemResponse, error) {
+	items, err := s.db.Queries().SearchItems(ctx, searchParams)
 	if err != nil {
-		logger.Error("Failed to count items", "error", err)
-		return api.GetItems500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		return api.PaginatedItemResponse{}, fmt.Errorf("search items: %w", err)
 	}
 
+	response := make([]api.ItemResponse, 0, len(items))
 	for _, item := range items {
 		id := item.ID
 		name := item.Name
@@ -127,23 +159,188 @@ func (s Server) GetItems(ctx context.Context, request api.GetItemsRequestObject)
 		urls := item.Urls
 
 		itemResponse := api.ItemResponse{
-			Id:          id,
-			Name:        name,
-			Description: &description,
-			Type:        itemType,
-			Stock:       stock,
-			Urls:        &urls,
+			Id:           id,
+			Name:         name,
+			Description:  &description,
+			Type:         itemType,
+			Stock:        stock,
+			Urls:         &urls,
+			OwnerGroupId: item.OwnerGroupID,
+		}
+		if item.ArchivedAt.Valid {
+			itemResponse.ArchivedAt = &item.ArchivedAt.Time
 		}
 		response = append(response, itemResponse)
 	}
 
-	if response == nil {
-		response = []api.ItemResponse{}
+	total, err := s.db.Queries().CountSearchItems(ctx, db.CountSearchItemsParams{
+		Query:           searchParams.Query,
+		ItemType:        searchParams.ItemType,
+		InStock:         searchParams.InStock,
+		Category:        searchParams.Category,
+		Tags:            searchParams.Tags,
+		IncludeArchived: searchParams.IncludeArchived,
+		ViewerID:        searchParams.ViewerID,
+	})
+	if err != nil {
+		return api.PaginatedItemResponse{}, fmt.Errorf("count search items: %w", err)
 	}
 
-	return api.GetItems200JSONResponse{
+	if err := s.attachItemDetails(ctx, response); err != nil {
+		return api.PaginatedItemResponse{}, fmt.Errorf("item details: %w", err)
+	}
+
+	return api.PaginatedItemResponse{
 		Data: response,
-		Meta: buildPaginationMeta(total, limit, offset),
+		Meta: buildPaginationMeta(total, searchParams.Limit, searchParams.Offset),
+	}, nil
+}
+
+// a page of every item the viewer can see, the unfiltered GET /items
+func (s Server) allItemsPage(ctx context.Context, params db.GetAllItemsParams) (api.PaginatedItemResponse, error) {
+	items, err := s.db.Queries().GetAllItems(ctx, params)
+	if err != nil {
+		return api.PaginatedItemResponse{}, fmt.Errorf("get items: %w", err)
+	}
+
+	total, err := s.db.Queries().CountAllItems(ctx, params.ViewerID)
+	if err != nil {
+		return api.PaginatedItemResponse{}, fmt.Errorf("count items: %w", err)
+	}
+
+	response := make([]api.ItemResponse, 0, len(items))
+	for _, item := range items {
+		id := item.ID
+		name := item.Name
+		description := item.Description.String
+		itemType := api.ItemType(item.Type)
+		stock := int(item.Stock)
+		urls := item.Urls
+
+		itemResponse := api.ItemResponse{
+			Id:           id,
+			Name:         name,
+			Description:  &description,
+			Type:         itemType,
+			Stock:        stock,
+			Urls:         &urls,
+			OwnerGroupId: item.OwnerGroupID,
+		}
+		response = append(response, itemResponse)
+	}
+
+	if err := s.attachItemDetails(ctx, response); err != nil {
+		return api.PaginatedItemResponse{}, fmt.Errorf("item details: %w", err)
+	}
+
+	return api.PaginatedItemResponse{
+		Data: response,
+		Meta: buildPaginationMeta(total, params.Limit, params.Offset),
+	}, nil
+}
+
+func (s Server) SearchItems(ctx context.Context, request api.SearchItemsRequestObject) (api.SearchItemsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.SearchItems401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ViewItems permission", "error", err)
+		return api.SearchItems500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.SearchItems403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	includeArchived := request.Params.IncludeArchived != nil && *request.Params.IncludeArchived
+	if includeArchived {
+		canManage, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+		if err != nil {
+			logger.Error("Error checking rbac.ManageItems permission", "error", err)
+			return api.SearchItems500JSONResponse(InternalError("Internal server error").Create()), nil
+		}
+		if !canManage {
+			return api.SearchItems403JSONResponse(PermissionDenied("Only item managers can list archived items").Create()), nil
+		}
+	}
+
+	query := strings.TrimSpace(request.Params.Q)
+	if query == "" {
+		return api.SearchItems400JSONResponse(ValidationErr("q is required", nil).Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+	viewer := itemViewer(user)
+
+	searchParams := db.FullTextSearchItemsParams{
+		Query:           query,
+		IncludeArchived: includeArchived,
+		ViewerID:        viewer,
+		Limit:           limit,
+		Offset:          offset,
+	}
+	var page api.PaginatedItemResponse
+	err = s.cachedListing(ctx, "items:fulltext", searchParams, &page, func() error {
+		var err error
+		page, err = s.fullTextSearchPage(ctx, searchParams)
+		return err
+	})
+	if err != nil {
+		logger.Error("Failed to search items", "error", err)
+		return api.SearchItems500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	return api.SearchItems200JSONResponse{
+		Body:    page,
+		Headers: api.SearchItems200ResponseHeaders{XTotalCount: page.Meta.Total},
+	}, nil
+}
+
+// a page of GET /items/search
+func (s Server) fullTextSearchPage(ctx context.Context, params db.FullTextSearchItemsParams) (api.PaginatedItemResponse, error) {
+	items, err := s.db.Queries().FullTextSearchItems(ctx, params)
+	if err != nil {
+		return api.PaginatedItemResponse{}, fmt.Errorf("search items: %w", err)
+	}
+
+	total, err := s.db.Queries().CountFullTextSearchItems(ctx, db.CountFullTextSearchItemsParams{
+		Query:           params.Query,
+		IncludeArchived: params.IncludeArchived,
+		ViewerID:        params.ViewerID,
+	})
+	if err != nil {
+		return api.PaginatedItemResponse{}, fmt.Errorf("count search items: %w", err)
+	}
+
+	response := make([]api.ItemResponse, 0, len(items))
+	for _, item := range items {
+		description := item.Description.String
+		urls := item.Urls
+		itemResponse := api.ItemResponse{
+			Id:           item.ID,
+			Name:         item.Name,
+			Description:  &description,
+			Type:         api.ItemType(item.Type),
+			Stock:        int(item.Stock),
+			Urls:         &urls,
+			OwnerGroupId: item.OwnerGroupID,
+		}
+		if item.ArchivedAt.Valid {
+			itemResponse.ArchivedAt = &item.ArchivedAt.Time
+		}
+		response = append(response, itemResponse)
+	}
+	if err := s.attachItemDetails(ctx, response); err != nil {
+		return api.PaginatedItemResponse{}, fmt.Errorf("item details: %w", err)
+	}
+
+	return api.PaginatedItemResponse{
+		Data: response,
+		Meta: buildPaginationMeta(total, params.Limit, params.Offset),
 	}, nil
 }
 
@@ -165,25 +362,48 @@ func (s Server) GetItemsByType(ctx context.Context, request api.GetItemsByTypeRe
 	}
 
 	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
-
-	items, err := s.db.Queries().GetItemsByType(ctx, db.GetItemsByTypeParams{
-		Type:   db.ItemType(request.Type),
-		Limit:  limit,
-		Offset: offset,
+	viewer := itemViewer(user)
+
+	typeParams := db.GetItemsByTypeParams{
+		Type:     db.ItemType(request.Type),
+		ViewerID: viewer,
+		Limit:    limit,
+		Offset:   offset,
+	}
+	var page api.PaginatedItemResponse
+	err = s.cachedListing(ctx, "items:type", typeParams, &page, func() error {
+		var err error
+		page, err = s.itemsByTypePage(ctx, typeParams)
+		return err
 	})
 	if err != nil {
 		logger.Error("Failed to get items by type", "error", err)
 		return api.GetItemsByType500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
 	}
 
-	total, err := s.db.Queries().CountItemsByType(ctx, db.ItemType(request.Type))
+	return api.GetItemsByType200JSONResponse{
+		Body:    page,
+		Headers: api.GetItemsByType200ResponseHeaders{XTotalCount: page.Meta.Total},
+	}, nil
+}
+
+// a page of GET /items/type/{type}
+func (s Server) itemsByTypePage(ctx context.Context, params db.GetItemsByTypeParams) (api.PaginatedItemResponse, error) {
+	items, err := s.db.Queries().GetItemsByType(ctx, params)
 	if err != nil {
-		logger.Error("Failed to count items by type", "error", err)
-		return api.GetItemsByType500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		return api.PaginatedItemResponse{}, fmt.Errorf("get items by type: %w", err)
+	}
+
+	total, err := s.db.Queries().CountItemsByType(ctx, db.CountItemsByTypeParams{
+		Type:     params.Type,
+		ViewerID: params.ViewerID,
+	})
+	if err != nil {
+		return api.PaginatedItemResponse{}, fmt.Errorf("count items by type: %w", err)
 	}
 
 	// Convert database items to API response format
-	var response []api.ItemResponse
+	response := make([]api.ItemResponse, 0, len(items))
 	for _, item := range items {
 		id := item.ID
 		name := item.Name
@@ -193,23 +413,24 @@ func (s Server) GetItemsByType(ctx context.Context, request api.GetItemsByTypeRe
 		urls := item.Urls
 
 		itemResponse := api.ItemResponse{
-			Id:          id,
-			Name:        name,
-			Description: &description,
-			Type:        itemType,
-			Stock:       stock,
-			Urls:        &urls,
+			Id:           id,
+			Name:         name,
+			Description:  &description,
+			Type:         itemType,
+			Stock:        stock,
+			Urls:         &urls,
+			OwnerGroupId: item.OwnerGroupID,
 		}
 		response = append(response, itemResponse)
 	}
 
-	if response == nil {
-		response = []api.ItemResponse{}
+	if err := s.attachItemDetails(ctx, response); err != nil {
+		return api.PaginatedItemResponse{}, fmt.Errorf("item details: %w", err)
 	}
 
-	return api.GetItemsByType200JSONResponse{
+	return api.PaginatedItemResponse{
 		Data: response,
-		Meta: buildPaginationMeta(total, limit, offset),
+		Meta: buildPaginationMeta(total, params.Limit, params.Offset),
 	}, nil
 }
 
@@ -234,24 +455,47 @@ func (s Server) GetItemById(ctx context.Context, request api.GetItemByIdRequestO
 	if err != nil {
 		return api.GetItemById404JSONResponse(NotFound("Item").Create()), nil
 	}
+	visible, err := s.canSeeItem(ctx, user, item)
+	if err != nil {
+		logger.Error("Failed to check item visibility", "item_id", item.ID, "error", err)
+		return api.GetItemById500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if !visible {
+		return api.GetItemById404JSONResponse(NotFound("Item").Create()), nil
+	}
 
-	id := item.ID
-	name := item.Name
-	description := item.Description.String
-	itemType := api.ItemType(item.Type)
-	stock := int(item.Stock)
-	urls := item.Urls
+	response, err := s.itemResponse(ctx, item)
+	if err != nil {
+		logger.Error("Failed to get item details", "item_id", item.ID, "error", err)
+		return api.GetItemById500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
 
 	return api.GetItemById200JSONResponse{
-		Id:          id,
-		Name:        name,
-		Description: &description,
-		Type:        itemType,
-		Stock:       stock,
-		Urls:        &urls,
+		Body:    response,
+		Headers: api.GetItemById200ResponseHeaders{ETag: itemETag(item.Version)},
 	}, nil
 }
 
+// an item as the single-item endpoints return it, labels and primary image
+// included
+func (s Server) itemResponse(ctx context.Context, item db.Item) (api.ItemResponse, error) {
+	description := item.Description.String
+	urls := item.Urls
+	response := []api.ItemResponse{{
+		Id:           item.ID,
+		Name:         item.Name,
+		Description:  &description,
+		Type:         api.ItemType(item.Type),
+		Stock:        int(item.Stock),
+		Urls:         &urls,
+		OwnerGroupId: item.OwnerGroupID,
+	}}
+	if err := s.attachItemDetails(ctx, response); err != nil {
+		return api.ItemResponse{}, err
+	}
+	return response[0], nil
+}
+
 func (s Server) CreateItem(ctx context.Context, request api.CreateItemRequestObject) (api.CreateItemResponseObject, error) {
 	logger := middleware.GetLoggerFromContext(ctx)
 
@@ -294,12 +538,55 @@ func (s Server) CreateItem(ctx context.Context, request api.CreateItemRequestObj
 		params.Description = pgtype.Text{String: *req.Description, Valid: true}
 	}
 
-	item, err := s.db.Queries().CreateItem(ctx, params)
+	labels, problem, err := s.resolveItemLabels(ctx, req.Category, req.Tags, false)
+	if err != nil {
+		logger.Error("Failed to resolve item labels", "error", err)
+		return api.CreateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if problem != "" {
+		return api.CreateItem400JSONResponse(ValidationErr(problem, nil).Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.CreateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindPurchase, ActorID: &user.ID}); err != nil {
+		logger.Error("Failed to tag stock movement", "error", err)
+		return api.CreateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	item, err := qtx.CreateItem(ctx, params)
 	if err != nil {
 		logger.Error("Failed to create item", "error", err)
 		return api.CreateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
 	}
 
+	if err := applyItemLabels(ctx, qtx, item.ID, labels); err != nil {
+		logger.Error("Failed to set item labels", "item_id", item.ID, "error", err)
+		return api.CreateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		return api.CreateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	s.invalidateCatalog(ctx)
+
+	var category *string
+	if labels.category != nil {
+		category = &labels.category.Slug
+	}
+	tags := []string{}
+	if labels.tags != nil {
+		tags = *labels.tags
+	}
+
 	id := item.ID
 	name := item.Name
 	description := item.Description.String
@@ -313,6 +600,8 @@ func (s Server) CreateItem(ctx context.Context, request api.CreateItemRequestObj
 		Type:        itemType,
 		Stock:       stock,
 		Urls:        &urls,
+		Category:    category,
+		Tags:        &tags,
 	}, nil
 }
 
@@ -359,25 +648,85 @@ func (s Server) UpdateItem(ctx context.Context, request api.UpdateItemRequestObj
 		params.Description = pgtype.Text{String: *req.Description, Valid: true}
 	}
 
-	item, err := s.db.Queries().UpdateItem(ctx, params)
+	// a full update replaces the labels too, so leaving them out clears them
+	labels, problem, err := s.resolveItemLabels(ctx, req.Category, req.Tags, true)
+	if err != nil {
+		logger.Error("Failed to resolve item labels", "error", err)
+		return api.UpdateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if problem != "" {
+		return api.UpdateItem400JSONResponse(ValidationErr(problem, nil).Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.UpdateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	stale, err := staleItem(ctx, qtx, request.Id, request.Params.IfMatch)
+	if err == pgx.ErrNoRows {
+		return api.UpdateItem404JSONResponse(NotFound("Item").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to check item version", "item_id", request.Id, "error", err)
+		return api.UpdateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if stale != nil {
+		current, err := s.itemResponse(ctx, *stale)
+		if err != nil {
+			logger.Error("Failed to get item details", "item_id", stale.ID, "error", err)
+			return api.UpdateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		return api.UpdateItem409JSONResponse{
+			Body:    current,
+			Headers: api.UpdateItem409ResponseHeaders{ETag: itemETag(stale.Version)},
+		}, nil
+	}
+
+	if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindAdjust, Reason: "item edited", ActorID: &user.ID}); err != nil {
+		logger.Error("Failed to tag stock movement", "item_id", request.Id, "error", err)
+		return api.UpdateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	item, err := qtx.UpdateItem(ctx, params)
 	if err != nil {
 		logger.Error("Failed to update item", "error", err)
 		return api.UpdateItem404JSONResponse(NotFound("Item").Create()), nil
 	}
 
-	id := item.ID
-	name := item.Name
-	description := item.Description.String
-	itemType := api.ItemType(item.Type)
-	stock := int(item.Stock)
+	if err := applyItemLabels(ctx, qtx, item.ID, labels); err != nil {
+		logger.Error("Failed to set item labels", "item_id", item.ID, "error", err)
+		return api.UpdateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		return api.UpdateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	s.publishStockChanged(ctx, item.ID)
+
+	updated, err := s.itemResponse(ctx, item)
+	if err != nil {
+		logger.Error("Failed to get item details", "item_id", item.ID, "error", err)
+		return api.UpdateItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
 
 	return api.UpdateItem200JSONResponse{
-		Id:          id,
-		Name:        name,
-		Description: &description,
-		Type:        itemType,
-		Stock:       stock,
-		Urls:        &urls,
+		Body: api.ItemPostRequest{
+			Id:          updated.Id,
+			Name:        updated.Name,
+			Description: updated.Description,
+			Type:        updated.Type,
+			Stock:       updated.Stock,
+			Category:    updated.Category,
+			Tags:        updated.Tags,
+			Urls:        updated.Urls,
+		},
+		Headers: api.UpdateItem200ResponseHeaders{ETag: itemETag(item.Version)},
 	}, nil
 }
 
@@ -428,26 +777,79 @@ func (s Server) PatchItem(ctx context.Context, request api.PatchItemRequestObjec
 		params.Urls = *req.Urls
 	}
 
-	item, err := s.db.Queries().PatchItem(ctx, params)
+	labels, problem, err := s.resolveItemLabels(ctx, req.Category, req.Tags, false)
+	if err != nil {
+		logger.Error("Failed to resolve item labels", "error", err)
+		return api.PatchItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if problem != "" {
+		return api.PatchItem400JSONResponse(ValidationErr(problem, nil).Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.PatchItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	stale, err := staleItem(ctx, qtx, request.Id, request.Params.IfMatch)
+	if err == pgx.ErrNoRows {
+		return api.PatchItem404JSONResponse(NotFound("Item").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to check item version", "item_id", request.Id, "error", err)
+		return api.PatchItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if stale != nil {
+		current, err := s.itemResponse(ctx, *stale)
+		if err != nil {
+			logger.Error("Failed to get item details", "item_id", stale.ID, "error", err)
+			return api.PatchItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		return api.PatchItem409JSONResponse{
+			Body:    current,
+			Headers: api.PatchItem409ResponseHeaders{ETag: itemETag(stale.Version)},
+		}, nil
+	}
+
+	if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindAdjust, Reason: "item edited", ActorID: &user.ID}); err != nil {
+		logger.Error("Failed to tag stock movement", "item_id", request.Id, "error", err)
+		return api.PatchItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	item, err := qtx.PatchItem(ctx, params)
 
 	if err != nil {
 		return api.PatchItem404JSONResponse(NotFound("Item").Create()), nil
 	}
 
-	id := item.ID
-	name := item.Name
-	description := item.Description.String
-	itemType := api.ItemType(item.Type)
-	stock := int(item.Stock)
-	urls := item.Urls
+	if err := applyItemLabels(ctx, qtx, item.ID, labels); err != nil {
+		logger.Error("Failed to set item labels", "item_id", item.ID, "error", err)
+		return api.PatchItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit transaction", "error", err)
+		return api.PatchItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if params.Stock.Valid {
+		s.publishStockChanged(ctx, item.ID)
+	} else {
+		s.invalidateCatalog(ctx)
+	}
+
+	response, err := s.itemResponse(ctx, item)
+	if err != nil {
+		logger.Error("Failed to get item details", "item_id", item.ID, "error", err)
+		return api.PatchItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
 
 	return api.PatchItem200JSONResponse{
-		Id:          id,
-		Name:        name,
-		Description: &description,
-		Type:        itemType,
-		Stock:       stock,
-		Urls:        &urls,
+		Body:    response,
+		Headers: api.PatchItem200ResponseHeaders{ETag: itemETag(item.Version)},
 	}, nil
 }
 
@@ -468,11 +870,78 @@ func (s Server) DeleteItem(ctx context.Context, request api.DeleteItemRequestObj
 		return api.DeleteItem403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
 	}
 
-	err = s.db.Queries().DeleteItem(ctx, request.Id)
+	item, err := s.db.Queries().GetItemByID(ctx, request.Id)
 	if err != nil {
-		logger.Error("Failed to delete item", "error", err)
 		return api.DeleteItem404JSONResponse(NotFound("Item").Create()), nil
 	}
 
-	return api.DeleteItem204Response{}, nil
+	deletion, ok, err := s.requestDeletion(ctx, db.DeletionEntityItem, item.ID, user.ID, item.Name, request.Params.Reason)
+	if err != nil {
+		logger.Error("Failed to request item deletion", "item_id", request.Id, "error", err)
+		return api.DeleteItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if !ok {
+		return api.DeleteItem409JSONResponse(ConflictErr("A deletion request for this item is already open").Create()), nil
+	}
+
+	logger.Info("Item deletion requested", "item_id", item.ID, "deletion_id", deletion.ID, "requested_by", user.ID)
+
+	return api.DeleteItem202JSONResponse(convertToDeletionRequestResponse(deletion)), nil
+}
+
+func (s Server) RestoreItem(ctx context.Context, request api.RestoreItemRequestObject) (api.RestoreItemResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RestoreItem401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.RestoreItem500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.RestoreItem403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetItemByIDIncludingBinned(ctx, request.Id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.RestoreItem404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.Id, "error", err)
+		return api.RestoreItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	// no row back means the item exists but was never archived
+	item, err := s.db.Queries().RestoreItem(ctx, request.Id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return api.RestoreItem409JSONResponse(ConflictErr("Item is not archived").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to restore item", "item_id", request.Id, "error", err)
+		return api.RestoreItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	s.invalidateCatalog(ctx)
+
+	description := item.Description.String
+	urls := item.Urls
+	response := []api.ItemResponse{{
+		Id:           item.ID,
+		Name:         item.Name,
+		Description:  &description,
+		Type:         api.ItemType(item.Type),
+		Stock:        int(item.Stock),
+		Urls:         &urls,
+		OwnerGroupId: item.OwnerGroupID,
+	}}
+	if err := s.attachItemDetails(ctx, response); err != nil {
+		logger.Error("Failed to get item details", "item_id", item.ID, "error", err)
+		return api.RestoreItem500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Item restored", "item_id", item.ID, "restored_by", user.ID)
+
+	return api.RestoreItem200JSONResponse(response[0]), nil
 }
@@ -69,7 +69,7 @@ func TestServer_GetItems(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItems200JSONResponse{}, response)
 
-		itemsResp := response.(api.GetItems200JSONResponse)
+		itemsResp := response.(api.GetItems200JSONResponse).Body
 		assert.NotNil(t, itemsResp)
 		assert.Len(t, itemsResp.Data, 5)
 	})
@@ -98,7 +98,7 @@ func TestServer_GetItems(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItemById200JSONResponse{}, response)
 
-		itemResp := response.(api.GetItemById200JSONResponse)
+		itemResp := response.(api.GetItemById200JSONResponse).Body
 		assert.Equal(t, item.ID, itemResp.Id)
 		assert.Equal(t, item.Name, itemResp.Name)
 		assert.Equal(t, item.Description, *itemResp.Description)
@@ -131,7 +131,7 @@ func TestServer_GetItems(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItemsByType200JSONResponse{}, response)
 
-		itemsResp := response.(api.GetItemsByType200JSONResponse)
+		itemsResp := response.(api.GetItemsByType200JSONResponse).Body
 		assert.NotNil(t, itemsResp)
 	})
 }
@@ -249,7 +249,7 @@ func TestServer_UpdateItem(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.UpdateItem200JSONResponse{}, response)
 
-		itemResp := response.(api.UpdateItem200JSONResponse)
+		itemResp := response.(api.UpdateItem200JSONResponse).Body
 		assert.NotNil(t, itemResp.Id)
 		assert.Equal(t, "Updated Item", itemResp.Name)
 		assert.Equal(t, "Updated item description", *itemResp.Description)
@@ -293,7 +293,7 @@ func TestServer_PatchItem(t *testing.T) {
 
 		require.NoError(t, err)
 		require.IsType(t, api.PatchItem200JSONResponse{}, response)
-		itemResp := response.(api.PatchItem200JSONResponse)
+		itemResp := response.(api.PatchItem200JSONResponse).Body
 		assert.NotNil(t, itemResp.Id)
 		assert.Equal(t, item.ID, itemResp.Id)
 		assert.Equal(t, "An Item", itemResp.Name)
@@ -302,6 +302,61 @@ func TestServer_PatchItem(t *testing.T) {
 		assert.Equal(t, 15, itemResp.Stock)
 		assert.Equal(t, []string{"http://example.com/patchitem"}, *itemResp.Urls)
 	})
+
+	t.Run("stale If-Match is refused with the current item", func(t *testing.T) {
+		testUser := testDB.NewUser(t).
+			WithEmail("ifmatch@items.ca").
+			AsGlobalAdmin().
+			Create()
+		ctx := testutil.ContextWithUser(context.Background(), testUser, testDB.Queries())
+
+		item := testDB.NewItem(t).
+			WithName("Contested Item").
+			WithType("medium").
+			WithStock(5).
+			Create()
+
+		mockAuth.ExpectCheckPermission(testUser.ID, rbac.ViewItems, nil, true, nil)
+		got, err := server.GetItemById(ctx, api.GetItemByIdRequestObject{Id: item.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItemById200JSONResponse{}, got)
+		etag := got.(api.GetItemById200JSONResponse).Headers.ETag
+
+		// the first admin's edit goes through and moves the ETag on
+		mockAuth.ExpectCheckPermission(testUser.ID, rbac.ManageItems, nil, true, nil)
+		first, err := server.PatchItem(ctx, api.PatchItemRequestObject{
+			Id:     item.ID,
+			Params: api.PatchItemParams{IfMatch: &etag},
+			Body:   &api.PatchItemJSONRequestBody{Stock: 8},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.PatchItem200JSONResponse{}, first)
+		newETag := first.(api.PatchItem200JSONResponse).Headers.ETag
+		assert.NotEqual(t, etag, newETag)
+
+		// the second, still holding the old ETag, is told what changed
+		mockAuth.ExpectCheckPermission(testUser.ID, rbac.ManageItems, nil, true, nil)
+		second, err := server.UpdateItem(ctx, api.UpdateItemRequestObject{
+			Id:     item.ID,
+			Params: api.UpdateItemParams{IfMatch: &etag},
+			Body:   &api.UpdateItemJSONRequestBody{Name: "Contested Item", Type: "medium", Stock: 2},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.UpdateItem409JSONResponse{}, second)
+		conflict := second.(api.UpdateItem409JSONResponse)
+		assert.Equal(t, 8, conflict.Body.Stock)
+		assert.Equal(t, newETag, conflict.Headers.ETag)
+
+		mockAuth.ExpectCheckPermission(testUser.ID, rbac.ManageItems, nil, true, nil)
+		retried, err := server.UpdateItem(ctx, api.UpdateItemRequestObject{
+			Id:     item.ID,
+			Params: api.UpdateItemParams{IfMatch: &newETag},
+			Body:   &api.UpdateItemJSONRequestBody{Name: "Contested Item", Type: "medium", Stock: 2},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.UpdateItem200JSONResponse{}, retried)
+		assert.Equal(t, 2, retried.(api.UpdateItem200JSONResponse).Body.Stock)
+	})
 }
 
 func TestServer_DeleteItem(t *testing.T) {
@@ -311,7 +366,7 @@ func TestServer_DeleteItem(t *testing.T) {
 
 	server, testDB, mockAuth := newTestServer(t)
 
-	t.Run("successful delete item", func(t *testing.T) {
+	t.Run("delete opens a pending deletion request", func(t *testing.T) {
 		testUser := testDB.NewUser(t).
 			WithEmail("delete@items.ca").
 			AsGlobalAdmin().
@@ -333,7 +388,125 @@ func TestServer_DeleteItem(t *testing.T) {
 		})
 
 		require.NoError(t, err)
-		require.IsType(t, api.DeleteItem204Response{}, response)
+		require.IsType(t, api.DeleteItem202JSONResponse{}, response)
+
+		deletion := response.(api.DeleteItem202JSONResponse)
+		assert.Equal(t, api.DeletionRequestStatus("pending"), deletion.Status)
+		assert.Equal(t, item.ID, deletion.EntityId)
+		assert.Equal(t, "Item to Delete", deletion.EntityName)
+	})
+}
+
+func TestServer_ArchivedItems(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	admin := testDB.NewUser(t).
+		WithEmail("archive-admin@items.ca").
+		AsGlobalAdmin().
+		Create()
+	member := testDB.NewUser(t).
+		WithEmail("archive-member@items.ca").
+		AsMember().
+		Create()
+
+	archived := testDB.NewItem(t).WithName("Archived Projector").Create()
+	require.NoError(t, testDB.Queries().ArchiveItem(context.Background(), archived.ID))
+
+	t.Run("archived items are left out of the catalogue", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewItems, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		response, err := server.GetItems(ctx, api.GetItemsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItems200JSONResponse{}, response)
+
+		for _, item := range response.(api.GetItems200JSONResponse).Body.Data {
+			assert.NotEqual(t, archived.ID, item.Id)
+		}
+	})
+
+	t.Run("archived items are not found by ID", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewItems, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		response, err := server.GetItemById(ctx, api.GetItemByIdRequestObject{Id: archived.ID})
+		require.NoError(t, err)
+		assert.IsType(t, api.GetItemById404JSONResponse{}, response)
+	})
+
+	t.Run("admins can include archived items", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewItems, nil, true, nil)
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		includeArchived := true
+		response, err := server.GetItems(ctx, api.GetItemsRequestObject{
+			Params: api.GetItemsParams{IncludeArchived: &includeArchived},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItems200JSONResponse{}, response)
+
+		var found *api.ItemResponse
+		for _, item := range response.(api.GetItems200JSONResponse).Body.Data {
+			if item.Id == archived.ID {
+				found = &item
+			}
+		}
+		require.NotNil(t, found, "archived item should be listed")
+		assert.NotNil(t, found.ArchivedAt)
+	})
+
+	t.Run("members cannot include archived items", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewItems, nil, true, nil)
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageItems, nil, false, nil)
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		includeArchived := true
+		response, err := server.SearchItems(ctx, api.SearchItemsRequestObject{
+			Params: api.SearchItemsParams{Q: "projector", IncludeArchived: &includeArchived},
+		})
+		require.NoError(t, err)
+		assert.IsType(t, api.SearchItems403JSONResponse{}, response)
+	})
+
+	t.Run("restore brings an archived item back", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		response, err := server.RestoreItem(ctx, api.RestoreItemRequestObject{Id: archived.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.RestoreItem200JSONResponse{}, response)
+
+		restored := response.(api.RestoreItem200JSONResponse)
+		assert.Equal(t, archived.ID, restored.Id)
+		assert.Nil(t, restored.ArchivedAt)
+
+		_, err = testDB.Queries().GetItemByID(context.Background(), archived.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("restoring an item that is not archived conflicts", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Active Projector").Create()
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		response, err := server.RestoreItem(ctx, api.RestoreItemRequestObject{Id: item.ID})
+		require.NoError(t, err)
+		assert.IsType(t, api.RestoreItem409JSONResponse{}, response)
+	})
+
+	t.Run("restoring an unknown item is not found", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		response, err := server.RestoreItem(ctx, api.RestoreItemRequestObject{Id: uuid.New()})
+		require.NoError(t, err)
+		assert.IsType(t, api.RestoreItem404JSONResponse{}, response)
 	})
 }
 
@@ -469,7 +642,7 @@ func TestServer_GetItemsWithSearchAndFilters(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItems200JSONResponse{}, response)
 
-		itemsResp := response.(api.GetItems200JSONResponse)
+		itemsResp := response.(api.GetItems200JSONResponse).Body
 		assert.NotNil(t, itemsResp.Data)
 		assert.Len(t, itemsResp.Data, 1)
 		assert.Equal(t, "Laptop", itemsResp.Data[0].Name)
@@ -486,7 +659,7 @@ func TestServer_GetItemsWithSearchAndFilters(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItems200JSONResponse{}, response)
 
-		itemsResp := response.(api.GetItems200JSONResponse)
+		itemsResp := response.(api.GetItems200JSONResponse).Body
 		assert.NotNil(t, itemsResp.Data)
 		assert.Len(t, itemsResp.Data, 1)
 		assert.Equal(t, "HDMI Cable", itemsResp.Data[0].Name)
@@ -503,7 +676,7 @@ func TestServer_GetItemsWithSearchAndFilters(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItems200JSONResponse{}, response)
 
-		itemsResp := response.(api.GetItems200JSONResponse)
+		itemsResp := response.(api.GetItems200JSONResponse).Body
 		assert.NotNil(t, itemsResp.Data)
 		assert.Len(t, itemsResp.Data, 2)
 
@@ -512,6 +685,38 @@ func TestServer_GetItemsWithSearchAndFilters(t *testing.T) {
 		assert.Contains(t, names, "Whiteboard")
 	})
 
+	t.Run("sort by stock descending", func(t *testing.T) {
+		typeParam := api.ItemTypeMedium
+		sortBy := "stock"
+		order := api.Desc
+		response, err := server.GetItems(ctx, api.GetItemsRequestObject{
+			Params: api.GetItemsParams{
+				Type:   &typeParam,
+				SortBy: &sortBy,
+				Order:  &order,
+			},
+		})
+
+		require.NoError(t, err)
+		require.IsType(t, api.GetItems200JSONResponse{}, response)
+
+		itemsResp := response.(api.GetItems200JSONResponse)
+		require.Len(t, itemsResp.Body.Data, 2)
+		assert.Equal(t, "Projector", itemsResp.Body.Data[0].Name)
+		assert.Equal(t, "Whiteboard", itemsResp.Body.Data[1].Name)
+		assert.Equal(t, 2, itemsResp.Headers.XTotalCount)
+	})
+
+	t.Run("sort by unknown field is rejected", func(t *testing.T) {
+		sortBy := "price"
+		response, err := server.GetItems(ctx, api.GetItemsRequestObject{
+			Params: api.GetItemsParams{SortBy: &sortBy},
+		})
+
+		require.NoError(t, err)
+		require.IsType(t, api.GetItems400JSONResponse{}, response)
+	})
+
 	t.Run("search filter by stock success", func(t *testing.T) {
 		inStockTrue := true
 		response, err := server.GetItems(ctx, api.GetItemsRequestObject{
@@ -523,7 +728,7 @@ func TestServer_GetItemsWithSearchAndFilters(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItems200JSONResponse{}, response)
 
-		itemsResp := response.(api.GetItems200JSONResponse)
+		itemsResp := response.(api.GetItems200JSONResponse).Body
 		assert.NotNil(t, itemsResp.Data)
 		assert.Len(t, itemsResp.Data, 3)
 
@@ -545,7 +750,7 @@ func TestServer_GetItemsWithSearchAndFilters(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItems200JSONResponse{}, response)
 
-		itemsResp := response.(api.GetItems200JSONResponse)
+		itemsResp := response.(api.GetItems200JSONResponse).Body
 		assert.NotNil(t, itemsResp.Data)
 		assert.Len(t, itemsResp.Data, 1)
 		assert.Equal(t, "HDMI Cable", itemsResp.Data[0].Name)
@@ -564,7 +769,7 @@ func TestServer_GetItemsWithSearchAndFilters(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItems200JSONResponse{}, response)
 
-		itemsResp := response.(api.GetItems200JSONResponse)
+		itemsResp := response.(api.GetItems200JSONResponse).Body
 		assert.NotNil(t, itemsResp.Data)
 		assert.Len(t, itemsResp.Data, 1)
 		assert.Equal(t, "Projector", itemsResp.Data[0].Name)
@@ -583,7 +788,7 @@ func TestServer_GetItemsWithSearchAndFilters(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItems200JSONResponse{}, response)
 
-		itemsResp := response.(api.GetItems200JSONResponse)
+		itemsResp := response.(api.GetItems200JSONResponse).Body
 		assert.NotNil(t, itemsResp.Data)
 		assert.Len(t, itemsResp.Data, 1)
 		assert.Equal(t, "Whiteboard", itemsResp.Data[0].Name)
@@ -604,7 +809,7 @@ func TestServer_GetItemsWithSearchAndFilters(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItems200JSONResponse{}, response)
 
-		itemsResp := response.(api.GetItems200JSONResponse)
+		itemsResp := response.(api.GetItems200JSONResponse).Body
 		assert.NotNil(t, itemsResp.Data)
 		assert.Len(t, itemsResp.Data, 1)
 		assert.Equal(t, "Whiteboard", itemsResp.Data[0].Name)
@@ -621,7 +826,7 @@ func TestServer_GetItemsWithSearchAndFilters(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItems200JSONResponse{}, response)
 
-		itemsResp := response.(api.GetItems200JSONResponse)
+		itemsResp := response.(api.GetItems200JSONResponse).Body
 		assert.NotNil(t, itemsResp.Data)
 		assert.Len(t, itemsResp.Data, 0)
 	})
@@ -637,7 +842,7 @@ func TestServer_GetItemsWithSearchAndFilters(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItems200JSONResponse{}, response)
 
-		itemsResp := response.(api.GetItems200JSONResponse)
+		itemsResp := response.(api.GetItems200JSONResponse).Body
 		assert.NotNil(t, itemsResp.Data)
 		assert.Len(t, itemsResp.Data, 1)
 		assert.Equal(t, "Laptop", itemsResp.Data[0].Name)
@@ -651,8 +856,120 @@ func TestServer_GetItemsWithSearchAndFilters(t *testing.T) {
 		require.NoError(t, err)
 		require.IsType(t, api.GetItems200JSONResponse{}, response)
 
-		itemsResp := response.(api.GetItems200JSONResponse)
+		itemsResp := response.(api.GetItems200JSONResponse).Body
 		assert.NotNil(t, itemsResp.Data)
 		assert.Len(t, itemsResp.Data, 4)
 	})
 }
+
+func TestServer_SearchItems(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	testUser := testDB.NewUser(t).
+		WithEmail("fulltext@items.ca").
+		AsMember().
+		Create()
+
+	testDB.NewItem(t).
+		WithName("Projector").
+		WithDescription("Epson HD").
+		WithType("medium").
+		WithStock(3).
+		Create()
+
+	testDB.NewItem(t).
+		WithName("Adapter").
+		WithDescription("HDMI adapter for the projector").
+		WithType("low").
+		WithStock(10).
+		Create()
+
+	testDB.NewItem(t).
+		WithName("Whiteboard").
+		WithDescription("Magnetic board").
+		WithType("medium").
+		WithStock(2).
+		Create()
+
+	ctx := testutil.ContextWithUser(context.Background(), testUser, testDB.Queries())
+
+	search := func(t *testing.T, q string) api.SearchItemsResponseObject {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(testUser.ID, rbac.ViewItems, nil, true, nil)
+		response, err := server.SearchItems(ctx, api.SearchItemsRequestObject{
+			Params: api.SearchItemsParams{Q: q},
+		})
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("name matches rank above description matches", func(t *testing.T) {
+		response := search(t, "projector")
+		require.IsType(t, api.SearchItems200JSONResponse{}, response)
+
+		itemsResp := response.(api.SearchItems200JSONResponse).Body
+		require.Len(t, itemsResp.Data, 2)
+		assert.Equal(t, "Projector", itemsResp.Data[0].Name)
+		assert.Equal(t, "Adapter", itemsResp.Data[1].Name)
+		assert.Equal(t, 2, itemsResp.Meta.Total)
+	})
+
+	t.Run("matches on description", func(t *testing.T) {
+		response := search(t, "epson")
+		require.IsType(t, api.SearchItems200JSONResponse{}, response)
+
+		itemsResp := response.(api.SearchItems200JSONResponse).Body
+		require.Len(t, itemsResp.Data, 1)
+		assert.Equal(t, "Projector", itemsResp.Data[0].Name)
+	})
+
+	t.Run("typo still finds the item", func(t *testing.T) {
+		response := search(t, "projecter")
+		require.IsType(t, api.SearchItems200JSONResponse{}, response)
+
+		itemsResp := response.(api.SearchItems200JSONResponse).Body
+		require.NotEmpty(t, itemsResp.Data)
+		assert.Equal(t, "Projector", itemsResp.Data[0].Name)
+	})
+
+	t.Run("description matches rank above near misses", func(t *testing.T) {
+		testDB.NewItem(t).
+			WithName("Cameron Stand").
+			WithDescription("Music stand").
+			WithType("low").
+			WithStock(4).
+			Create()
+		testDB.NewItem(t).
+			WithName("Tripod").
+			WithDescription("Fits any camera").
+			WithType("medium").
+			WithStock(1).
+			Create()
+
+		response := search(t, "camera")
+		require.IsType(t, api.SearchItems200JSONResponse{}, response)
+
+		itemsResp := response.(api.SearchItems200JSONResponse).Body
+		require.Len(t, itemsResp.Data, 2)
+		assert.Equal(t, "Tripod", itemsResp.Data[0].Name)
+		assert.Equal(t, "Cameron Stand", itemsResp.Data[1].Name)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		response := search(t, "trombone")
+		require.IsType(t, api.SearchItems200JSONResponse{}, response)
+
+		itemsResp := response.(api.SearchItems200JSONResponse).Body
+		assert.Empty(t, itemsResp.Data)
+		assert.Equal(t, 0, itemsResp.Meta.Total)
+	})
+
+	t.Run("blank query", func(t *testing.T) {
+		response := search(t, "   ")
+		require.IsType(t, api.SearchItems400JSONResponse{}, response)
+	})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+)
+
+func (s Server) GetLoadSheddingStats(ctx context.Context, request api.GetLoadSheddingStatsRequestObject) (api.GetLoadSheddingStatsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetLoadSheddingStats401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageWorkers, nil)
+	if err != nil {
+		logger.Error("Error checking manage_workers permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageWorkers,
+			"error", err)
+		return api.GetLoadSheddingStats500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetLoadSheddingStats403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	stats := s.loadShedder.Stats()
+	classes := make([]api.PriorityClassStats, 0, len(stats.Classes))
+	for _, c := range stats.Classes {
+		classes = append(classes, api.PriorityClassStats{
+			Class:    api.PriorityClassStatsClass(c.Class.String()),
+			Admitted: c.Admitted,
+			Shed:     c.Shed,
+		})
+	}
+
+	return api.GetLoadSheddingStats200JSONResponse{
+		InFlight:           stats.InFlight,
+		MaxInFlight:        stats.MaxInFlight,
+		BestEffortInFlight: stats.BestEffortInFlight,
+		Classes:            classes,
+	}, nil
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_GetLoadSheddingStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("returns counters per class", func(t *testing.T) {
+		admin := testDB.NewUser(t).WithEmail("admin@shedding.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageWorkers, nil, true, nil)
+		response, err := server.GetLoadSheddingStats(ctx, api.GetLoadSheddingStatsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetLoadSheddingStats200JSONResponse{}, response)
+		assert.Len(t, response.(api.GetLoadSheddingStats200JSONResponse).Classes, 3)
+	})
+
+	t.Run("insufficient permissions", func(t *testing.T) {
+		member := testDB.NewUser(t).WithEmail("member@shedding.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageWorkers, nil, false, nil)
+		response, err := server.GetLoadSheddingStats(ctx, api.GetLoadSheddingStatsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetLoadSheddingStats403JSONResponse{}, response)
+	})
+}
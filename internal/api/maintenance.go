@@ -0,0 +1,321 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+func toItemMaintenanceResponse(m db.ItemMaintenance) api.ItemMaintenance {
+	resp := api.ItemMaintenance{
+		Id:          m.ID,
+		ItemId:      m.ItemID,
+		Quantity:    int(m.Quantity),
+		Reason:      m.Reason,
+		StartedBy:   m.StartedBy,
+		StartedAt:   m.StartedAt.Time,
+		CompletedBy: m.CompletedBy,
+		UnitId:      m.UnitID,
+	}
+	if m.ExpectedReturnDate.Valid {
+		resp.ExpectedReturnDate = &openapi_types.Date{Time: m.ExpectedReturnDate.Time}
+	}
+	if m.CompletionNotes.Valid {
+		resp.CompletionNotes = &m.CompletionNotes.String
+	}
+	if m.CompletedAt.Valid {
+		resp.CompletedAt = &m.CompletedAt.Time
+	}
+	return resp
+}
+
+// returned as client-facing errors, so handlers pass them through errorFor
+var (
+	errMaintenanceNotFound  = NotFound("Maintenance record")
+	errMaintenanceCompleted = ConflictErr("Maintenance has already been completed")
+)
+
+func (s Server) StartItemMaintenance(ctx context.Context, request api.StartItemMaintenanceRequestObject) (api.StartItemMaintenanceResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.StartItemMaintenance401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.StartItemMaintenance500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.StartItemMaintenance403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.StartItemMaintenance400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	reason := strings.TrimSpace(request.Body.Reason)
+	if reason == "" {
+		return api.StartItemMaintenance400JSONResponse(ValidationErr("reason is required", nil).Create()), nil
+	}
+	if request.Body.Quantity != nil && *request.Body.Quantity < 1 {
+		return api.StartItemMaintenance400JSONResponse(ValidationErr("quantity must be at least 1", nil).Create()), nil
+	}
+	var expectedReturn pgtype.Date
+	if request.Body.ExpectedReturnDate != nil {
+		if request.Body.ExpectedReturnDate.Time.Before(time.Now().Truncate(24 * time.Hour)) {
+			return api.StartItemMaintenance400JSONResponse(ValidationErr("expected_return_date must not be in the past", nil).Create()), nil
+		}
+		expectedReturn = pgtype.Date{Time: request.Body.ExpectedReturnDate.Time, Valid: true}
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.StartItemMaintenance500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	item, err := qtx.GetItemByIDForUpdate(ctx, request.ItemId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.StartItemMaintenance404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.StartItemMaintenance500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	tracked, err := qtx.ItemHasUnits(ctx, item.ID)
+	if err != nil {
+		logger.Error("Failed to check item units", "item_id", item.ID, "error", err)
+		return api.StartItemMaintenance500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	// only units on the shelf can go; borrowed ones come back first. Items
+	// tracked by unit send one unit at a time
+	quantity := item.Stock
+	if request.Body.Quantity != nil {
+		quantity = int32(*request.Body.Quantity)
+	} else if tracked {
+		quantity = 1
+	}
+	if quantity == 0 || quantity > item.Stock {
+		return api.StartItemMaintenance400JSONResponse(InsufficientStockErr(item.Name, int(quantity), int(item.Stock)).Create()), nil
+	}
+
+	unitID, err := pickItemUnit(ctx, qtx, item.ID, request.Body.UnitId, quantity)
+	if err != nil {
+		if apiErr := errorFor(err); apiErr.Code == CodeValidationError {
+			return api.StartItemMaintenance400JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to pick unit", "item_id", item.ID, "error", err)
+		return api.StartItemMaintenance500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	maintenance, err := qtx.CreateItemMaintenance(ctx, db.CreateItemMaintenanceParams{
+		ItemID:             item.ID,
+		Quantity:           quantity,
+		Reason:             reason,
+		ExpectedReturnDate: expectedReturn,
+		StartedBy:          &user.ID,
+		UnitID:             unitID,
+	})
+	if err != nil {
+		logger.Error("Failed to create maintenance record", "item_id", item.ID, "error", err)
+		return api.StartItemMaintenance500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindMaintenance, Reason: reason, ActorID: &user.ID, ReferenceID: &maintenance.ID}); err != nil {
+		logger.Error("Failed to tag stock movement", "item_id", item.ID, "error", err)
+		return api.StartItemMaintenance500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if err := qtx.DecrementItemStock(ctx, db.DecrementItemStockParams{ID: item.ID, Stock: quantity}); err != nil {
+		logger.Error("Failed to take units out of stock", "item_id", item.ID, "error", err)
+		return api.StartItemMaintenance500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if err := holdItemUnit(ctx, qtx, unitID, db.UnitStatusMaintenance); err != nil {
+		logger.Error("Failed to hold unit", "unit_id", unitID, "error", err)
+		return api.StartItemMaintenance500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit maintenance", "item_id", item.ID, "error", err)
+		return api.StartItemMaintenance500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	s.publishStockChanged(ctx, item.ID)
+
+	logger.Info("Item maintenance started",
+		"item_id", item.ID,
+		"maintenance_id", maintenance.ID,
+		"quantity", quantity,
+		"user_id", user.ID)
+
+	return api.StartItemMaintenance201JSONResponse(toItemMaintenanceResponse(maintenance)), nil
+}
+
+func (s Server) CompleteItemMaintenance(ctx context.Context, request api.CompleteItemMaintenanceRequestObject) (api.CompleteItemMaintenanceResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CompleteItemMaintenance401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.CompleteItemMaintenance500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CompleteItemMaintenance403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	maintenance, err := s.completeMaintenance(ctx, request.ItemId, request.MaintenanceId, user.ID, request.Body)
+	if err != nil {
+		switch apiErr := errorFor(err); apiErr.Code {
+		case CodeResourceNotFound:
+			return api.CompleteItemMaintenance404JSONResponse(apiErr.Create()), nil
+		case CodeConflict:
+			return api.CompleteItemMaintenance409JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to complete maintenance", "maintenance_id", request.MaintenanceId, "error", err)
+		return api.CompleteItemMaintenance500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	s.publishStockChanged(ctx, maintenance.ItemID)
+
+	// the units are borrowable again, so the waitlist hears about it
+	if _, err := s.queue.Enqueue(ctx, queue.TypeWaitlistNotify, queue.WaitlistNotifyPayload{ItemID: maintenance.ItemID}); err != nil {
+		logger.Warn("Failed to enqueue waitlist notification", "item_id", maintenance.ItemID, "error", err)
+	}
+
+	logger.Info("Item maintenance completed",
+		"item_id", maintenance.ItemID,
+		"maintenance_id", maintenance.ID,
+		"quantity", maintenance.Quantity,
+		"user_id", user.ID)
+
+	return api.CompleteItemMaintenance200JSONResponse(toItemMaintenanceResponse(maintenance)), nil
+}
+
+// closes an open maintenance record and puts its units back into stock.
+func (s Server) completeMaintenance(ctx context.Context, itemID, maintenanceID, completedBy uuid.UUID, body *api.CompleteItemMaintenanceRequest) (db.ItemMaintenance, error) {
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		return db.ItemMaintenance{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	existing, err := qtx.GetItemMaintenanceByID(ctx, maintenanceID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && existing.ItemID != itemID) {
+		return db.ItemMaintenance{}, errMaintenanceNotFound
+	}
+	if err != nil {
+		return db.ItemMaintenance{}, err
+	}
+
+	var notes pgtype.Text
+	if body != nil && body.Notes != nil && *body.Notes != "" {
+		notes = pgtype.Text{String: *body.Notes, Valid: true}
+	}
+
+	maintenance, err := qtx.CompleteItemMaintenance(ctx, db.CompleteItemMaintenanceParams{
+		ID:              maintenanceID,
+		CompletionNotes: notes,
+		CompletedBy:     &completedBy,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.ItemMaintenance{}, errMaintenanceCompleted
+	}
+	if err != nil {
+		return db.ItemMaintenance{}, err
+	}
+
+	if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindMaintenance, Reason: "maintenance completed", ActorID: &completedBy, ReferenceID: &maintenance.ID}); err != nil {
+		return db.ItemMaintenance{}, err
+	}
+	if err := qtx.IncrementItemStock(ctx, db.IncrementItemStockParams{ID: maintenance.ItemID, Stock: maintenance.Quantity}); err != nil {
+		return db.ItemMaintenance{}, err
+	}
+	if err := releaseItemUnit(ctx, qtx, maintenance.UnitID, db.NullCondition{}); err != nil {
+		return db.ItemMaintenance{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return db.ItemMaintenance{}, err
+	}
+	return maintenance, nil
+}
+
+func (s Server) GetItemMaintenanceHistory(ctx context.Context, request api.GetItemMaintenanceHistoryRequestObject) (api.GetItemMaintenanceHistoryResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetItemMaintenanceHistory401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.GetItemMaintenanceHistory500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetItemMaintenanceHistory403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	// archived items keep their history
+	if _, err := s.db.Queries().GetItemByIDIncludingBinned(ctx, request.ItemId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.GetItemMaintenanceHistory404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.GetItemMaintenanceHistory500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	list, err := s.db.Queries().ListItemMaintenance(ctx, db.ListItemMaintenanceParams{
+		ItemID: request.ItemId,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		logger.Error("Failed to list item maintenance", "item_id", request.ItemId, "error", err)
+		return api.GetItemMaintenanceHistory500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountItemMaintenance(ctx, request.ItemId)
+	if err != nil {
+		logger.Error("Failed to count item maintenance", "item_id", request.ItemId, "error", err)
+		return api.GetItemMaintenanceHistory500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	data := make([]api.ItemMaintenance, 0, len(list))
+	for _, m := range list {
+		data = append(data, toItemMaintenanceResponse(m))
+	}
+
+	return api.GetItemMaintenanceHistory200JSONResponse{
+		Data: data,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ItemMaintenance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@maintenance.test").AsGlobalAdmin().Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	start := func(t *testing.T, itemID uuid.UUID, body api.StartItemMaintenanceRequest) api.StartItemMaintenanceResponseObject {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.StartItemMaintenance(ctx, api.StartItemMaintenanceRequestObject{ItemId: itemID, Body: &body})
+		require.NoError(t, err)
+		return resp
+	}
+	complete := func(t *testing.T, itemID, maintenanceID uuid.UUID) api.CompleteItemMaintenanceResponseObject {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		notes := "Lamp replaced"
+		resp, err := server.CompleteItemMaintenance(ctx, api.CompleteItemMaintenanceRequestObject{
+			ItemId:        itemID,
+			MaintenanceId: maintenanceID,
+			Body:          &api.CompleteItemMaintenanceRequest{Notes: &notes},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+	stockOf := func(t *testing.T, itemID uuid.UUID) int32 {
+		t.Helper()
+		item, err := testDB.Queries().GetItemByID(context.Background(), itemID)
+		require.NoError(t, err)
+		return item.Stock
+	}
+
+	t.Run("units under maintenance leave the stock until completed", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Projector").WithType("medium").WithStock(5).Create()
+
+		quantity := 2
+		resp := start(t, item.ID, api.StartItemMaintenanceRequest{Quantity: &quantity, Reason: "Lamp replacement"})
+		require.IsType(t, api.StartItemMaintenance201JSONResponse{}, resp)
+		started := resp.(api.StartItemMaintenance201JSONResponse)
+		assert.Equal(t, 2, started.Quantity)
+		assert.Nil(t, started.CompletedAt)
+		assert.Equal(t, int32(3), stockOf(t, item.ID))
+
+		done := complete(t, item.ID, started.Id)
+		require.IsType(t, api.CompleteItemMaintenance200JSONResponse{}, done)
+		assert.NotNil(t, done.(api.CompleteItemMaintenance200JSONResponse).CompletedAt)
+		assert.Equal(t, int32(5), stockOf(t, item.ID))
+	})
+
+	t.Run("without a quantity every unit on the shelf goes", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+
+		resp := start(t, item.ID, api.StartItemMaintenanceRequest{Reason: "Sensor cleaning"})
+		require.IsType(t, api.StartItemMaintenance201JSONResponse{}, resp)
+		assert.Equal(t, 1, resp.(api.StartItemMaintenance201JSONResponse).Quantity)
+		assert.Equal(t, int32(0), stockOf(t, item.ID))
+	})
+
+	t.Run("more units than are on the shelf is rejected", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Tripod").WithType("medium").WithStock(1).Create()
+
+		quantity := 2
+		resp := start(t, item.ID, api.StartItemMaintenanceRequest{Quantity: &quantity, Reason: "Broken leg"})
+		require.IsType(t, api.StartItemMaintenance400JSONResponse{}, resp)
+		assert.Equal(t, api.INSUFFICIENTSTOCK, resp.(api.StartItemMaintenance400JSONResponse).Error.Code)
+		assert.Equal(t, int32(1), stockOf(t, item.ID))
+	})
+
+	t.Run("reason is required", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Microphone").Create()
+
+		resp := start(t, item.ID, api.StartItemMaintenanceRequest{Reason: "  "})
+		assert.IsType(t, api.StartItemMaintenance400JSONResponse{}, resp)
+	})
+
+	t.Run("completing twice conflicts", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Speaker").WithType("medium").WithStock(2).Create()
+
+		resp := start(t, item.ID, api.StartItemMaintenanceRequest{Reason: "Crackling"})
+		require.IsType(t, api.StartItemMaintenance201JSONResponse{}, resp)
+		id := resp.(api.StartItemMaintenance201JSONResponse).Id
+
+		require.IsType(t, api.CompleteItemMaintenance200JSONResponse{}, complete(t, item.ID, id))
+		assert.IsType(t, api.CompleteItemMaintenance409JSONResponse{}, complete(t, item.ID, id))
+		assert.Equal(t, int32(2), stockOf(t, item.ID), "stock should only be restored once")
+	})
+
+	t.Run("maintenance of another item is not found", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Laptop").WithType("medium").WithStock(2).Create()
+		other := testDB.NewItem(t).WithName("Monitor").Create()
+
+		resp := start(t, item.ID, api.StartItemMaintenanceRequest{Reason: "Battery swap"})
+		require.IsType(t, api.StartItemMaintenance201JSONResponse{}, resp)
+
+		assert.IsType(t, api.CompleteItemMaintenance404JSONResponse{},
+			complete(t, other.ID, resp.(api.StartItemMaintenance201JSONResponse).Id))
+	})
+
+	t.Run("history lists the item's maintenance newest first", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Mixer").WithType("medium").WithStock(3).Create()
+
+		one := 1
+		require.IsType(t, api.StartItemMaintenance201JSONResponse{}, start(t, item.ID, api.StartItemMaintenanceRequest{Quantity: &one, Reason: "First"}))
+		require.IsType(t, api.StartItemMaintenance201JSONResponse{}, start(t, item.ID, api.StartItemMaintenanceRequest{Quantity: &one, Reason: "Second"}))
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.GetItemMaintenanceHistory(ctx, api.GetItemMaintenanceHistoryRequestObject{ItemId: item.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItemMaintenanceHistory200JSONResponse{}, resp)
+
+		history := resp.(api.GetItemMaintenanceHistory200JSONResponse)
+		require.Len(t, history.Data, 2)
+		assert.Equal(t, "Second", history.Data[0].Reason)
+		assert.Equal(t, "First", history.Data[1].Reason)
+		assert.Equal(t, 2, history.Meta.Total)
+	})
+
+	t.Run("history of an unknown item is not found", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.GetItemMaintenanceHistory(ctx, api.GetItemMaintenanceHistoryRequestObject{ItemId: uuid.New()})
+		require.NoError(t, err)
+		assert.IsType(t, api.GetItemMaintenanceHistory404JSONResponse{}, resp)
+	})
+}
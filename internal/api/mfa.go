@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+)
+
+func (s Server) EnrollMFA(ctx context.Context, request api.EnrollMFARequestObject) (api.EnrollMFAResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.EnrollMFA401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	enrollment, err := s.authService.EnrollMFA(ctx, user.ID, user.Email)
+	if err != nil {
+		if apiErr := errorFor(err); apiErr.Code == CodeConflict {
+			return api.EnrollMFA409JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to start two-factor enrollment", "user_id", user.ID, "error", err)
+		return api.EnrollMFA500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	return api.EnrollMFA200JSONResponse{
+		Secret:     enrollment.Secret,
+		OtpauthUrl: enrollment.URI,
+	}, nil
+}
+
+func (s Server) ConfirmMFA(ctx context.Context, request api.ConfirmMFARequestObject) (api.ConfirmMFAResponseObject, error) {
+	if request.Body == nil {
+		return api.ConfirmMFA400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ConfirmMFA401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	backupCodes, err := s.authService.ConfirmMFA(ctx, user.ID, request.Body.Code)
+	if err != nil {
+		switch apiErr := errorFor(err); apiErr.Code {
+		case CodeValidationError:
+			return api.ConfirmMFA400JSONResponse(apiErr.Create()), nil
+		case CodeConflict:
+			return api.ConfirmMFA409JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to confirm two-factor enrollment", "user_id", user.ID, "error", err)
+		return api.ConfirmMFA500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Two-factor authentication enabled", "user_id", user.ID)
+	return api.ConfirmMFA200JSONResponse{BackupCodes: backupCodes}, nil
+}
+
+func (s Server) VerifyMFA(ctx context.Context, request api.VerifyMFARequestObject) (api.VerifyMFAResponseObject, error) {
+	if request.Body == nil {
+		return api.VerifyMFA400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.VerifyMFA401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	accessToken, refreshToken, err := s.authService.VerifyMFA(ctx, user.ID, request.Body.Code)
+	if err != nil {
+		switch apiErr := errorFor(err); apiErr.Code {
+		case CodeValidationError:
+			logger.Warn("Two-factor verification failed: invalid code", "user_id", user.ID)
+			return api.VerifyMFA400JSONResponse(apiErr.Create()), nil
+		case CodeRateLimited:
+			logger.Warn("Two-factor verification failed: max attempts exceeded", "user_id", user.ID)
+			return api.VerifyMFA429JSONResponse(apiErr.Create()), nil
+		case CodeConflict:
+			return api.VerifyMFA409JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to verify two-factor code", "user_id", user.ID, "error", err)
+		return api.VerifyMFA500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("User passed two-factor authentication", "user_id", user.ID)
+	return api.VerifyMFA200JSONResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
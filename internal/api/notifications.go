@@ -4,11 +4,74 @@ import (
 	"context"
 
 	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
 	"github.com/USSTM/cv-backend/internal/auth"
 	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
+// the notification queries all select the same columns, so their rows
+// convert to GetUserNotificationsRow
+func toNotificationResponse(n db.GetUserNotificationsRow) api.NotificationResponse {
+	response := api.NotificationResponse{
+		NotificationId:        n.NotificationID,
+		IsRead:                n.IsRead,
+		NotificationCreatedAt: n.NotificationCreatedAt.Time,
+		NotificationObjectId:  n.NotificationObjectID,
+		EntityId:              n.EntityID,
+		EntityTypeName:        n.EntityTypeName,
+		ActorId:               n.ActorID,
+		ActorEmail:            openapi_types.Email(n.ActorEmail),
+	}
+	if n.Title.Valid {
+		response.Title = &n.Title.String
+	}
+	return response
+}
+
+// a page of userID's inbox, newest first
+func (s Server) notificationPage(ctx context.Context, userID uuid.UUID, limitParam, offsetParam *int) (api.PaginatedNotificationResponse, error) {
+	limit, offset := parsePagination(limitParam, offsetParam)
+
+	notifs, err := s.dispatcher.GetUserNotifications(ctx, userID, limit, offset)
+	if err != nil {
+		return api.PaginatedNotificationResponse{}, err
+	}
+
+	response := make([]api.NotificationResponse, 0, len(notifs))
+	for _, n := range notifs {
+		response = append(response, toNotificationResponse(n))
+	}
+
+	total, err := s.dispatcher.GetTotalCount(ctx, userID)
+	if err != nil {
+		return api.PaginatedNotificationResponse{}, err
+	}
+
+	return api.PaginatedNotificationResponse{
+		Data: response,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
+
+func (s Server) ListMyNotifications(ctx context.Context, request api.ListMyNotificationsRequestObject) (api.ListMyNotificationsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListMyNotifications401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	page, err := s.notificationPage(ctx, user.ID, request.Params.Limit, request.Params.Offset)
+	if err != nil {
+		logger.Error("Failed to get notifications", "error", err, "user_id", user.ID)
+		return api.ListMyNotifications500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	return api.ListMyNotifications200JSONResponse(page), nil
+}
+
 func (s Server) GetNotifications(ctx context.Context, request api.GetNotificationsRequestObject) (api.GetNotificationsResponseObject, error) {
 	logger := middleware.GetLoggerFromContext(ctx)
 
@@ -17,41 +80,30 @@ func (s Server) GetNotifications(ctx context.Context, request api.GetNotificatio
 		return api.GetNotifications401JSONResponse(Unauthorized("Authentication required").Create()), nil
 	}
 
-	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
-
-	notifs, err := s.dispatcher.GetUserNotifications(ctx, user.ID, limit, offset)
+	page, err := s.notificationPage(ctx, user.ID, request.Params.Limit, request.Params.Offset)
 	if err != nil {
 		logger.Error("Failed to get notifications", "error", err, "user_id", user.ID)
 		return api.GetNotifications500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
 	}
+	return api.GetNotifications200JSONResponse(page), nil
+}
 
-	var response []api.NotificationResponse
-	for _, n := range notifs {
-		response = append(response, api.NotificationResponse{
-			NotificationId:        n.NotificationID,
-			IsRead:                n.IsRead,
-			NotificationCreatedAt: n.NotificationCreatedAt.Time,
-			NotificationObjectId:  n.NotificationObjectID,
-			EntityId:              n.EntityID,
-			EntityTypeName:        n.EntityTypeName,
-			ActorId:               n.ActorID,
-			ActorEmail:            openapi_types.Email(n.ActorEmail),
-		})
-	}
+func (s Server) GetMyUnreadNotificationCount(ctx context.Context, request api.GetMyUnreadNotificationCountRequestObject) (api.GetMyUnreadNotificationCountResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
 
-	if response == nil {
-		response = []api.NotificationResponse{}
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetMyUnreadNotificationCount401JSONResponse(Unauthorized("Authentication required").Create()), nil
 	}
 
-	total, err := s.dispatcher.GetTotalCount(ctx, user.ID)
+	count, err := s.dispatcher.GetUnreadCount(ctx, user.ID)
 	if err != nil {
-		logger.Error("Failed to get total notification count", "error", err, "user_id", user.ID)
-		return api.GetNotifications500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		logger.Error("Failed to get unread notification count", "error", err, "user_id", user.ID)
+		return api.GetMyUnreadNotificationCount500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
 	}
 
-	return api.GetNotifications200JSONResponse{
-		Data: response,
-		Meta: buildPaginationMeta(total, limit, offset),
+	return api.GetMyUnreadNotificationCount200JSONResponse{
+		UnreadCount: int(count),
 	}, nil
 }
 
@@ -74,6 +126,31 @@ func (s Server) GetUnreadNotificationCount(ctx context.Context, request api.GetU
 	}, nil
 }
 
+func (s Server) MarkMyNotificationRead(ctx context.Context, request api.MarkMyNotificationReadRequestObject) (api.MarkMyNotificationReadResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.MarkMyNotificationRead401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	if _, err := s.dispatcher.MarkAsRead(ctx, user.ID, request.Id); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.MarkMyNotificationRead404JSONResponse(NotFound("Notification").Create()), nil
+		}
+		logger.Error("Failed to mark notification as read", "error", err, "user_id", user.ID, "notif_id", request.Id)
+		return api.MarkMyNotificationRead500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	marked, err := s.dispatcher.GetNotification(ctx, user.ID, request.Id)
+	if err != nil {
+		logger.Error("Failed to get notification", "error", err, "user_id", user.ID, "notif_id", request.Id)
+		return api.MarkMyNotificationRead500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	return api.MarkMyNotificationRead200JSONResponse(toNotificationResponse(db.GetUserNotificationsRow(marked))), nil
+}
+
 func (s Server) MarkNotificationAsRead(ctx context.Context, request api.MarkNotificationAsReadRequestObject) (api.MarkNotificationAsReadResponseObject, error) {
 	logger := middleware.GetLoggerFromContext(ctx)
 
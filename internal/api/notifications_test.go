@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_MyNotifications(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, _ := newTestServer(t)
+
+	testDB.CleanupDatabase(t)
+	actor := testDB.NewUser(t).WithEmail("approver@inbox.test").Create()
+	member := testDB.NewUser(t).WithEmail("member@inbox.test").AsMember().Create()
+	other := testDB.NewUser(t).WithEmail("other@inbox.test").AsMember().Create()
+	ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+	require.NoError(t, server.dispatcher.Notify(context.Background(), actor.ID, "general", uuid.New(), []notifications.NotifierGroup{
+		{IDs: []uuid.UUID{member.ID}},
+	}))
+	require.NoError(t, server.dispatcher.Notify(context.Background(), actor.ID, "general", uuid.New(), []notifications.NotifierGroup{
+		{
+			IDs:      []uuid.UUID{member.ID, other.ID},
+			Template: "request_approved_requester",
+			TemplateData: map[string]interface{}{
+				"UserName":  "Member",
+				"ItemName":  "Laptop",
+				"RequestID": uuid.New().String(),
+			},
+		},
+	}))
+
+	t.Run("inbox lists newest first with titles", func(t *testing.T) {
+		resp, err := server.ListMyNotifications(ctx, api.ListMyNotificationsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.ListMyNotifications200JSONResponse{}, resp)
+		inbox := resp.(api.ListMyNotifications200JSONResponse)
+
+		require.Len(t, inbox.Data, 2)
+		assert.Equal(t, 2, inbox.Meta.Total)
+		require.NotNil(t, inbox.Data[0].Title)
+		assert.Equal(t, "Your request for Laptop has been approved", *inbox.Data[0].Title)
+		assert.Nil(t, inbox.Data[1].Title)
+	})
+
+	t.Run("reading one lowers the unread count", func(t *testing.T) {
+		count, err := server.GetMyUnreadNotificationCount(ctx, api.GetMyUnreadNotificationCountRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetMyUnreadNotificationCount200JSONResponse{}, count)
+		assert.Equal(t, 2, count.(api.GetMyUnreadNotificationCount200JSONResponse).UnreadCount)
+
+		list, err := server.ListMyNotifications(ctx, api.ListMyNotificationsRequestObject{})
+		require.NoError(t, err)
+		latest := list.(api.ListMyNotifications200JSONResponse).Data[0]
+
+		resp, err := server.MarkMyNotificationRead(ctx, api.MarkMyNotificationReadRequestObject{Id: latest.NotificationId})
+		require.NoError(t, err)
+		require.IsType(t, api.MarkMyNotificationRead200JSONResponse{}, resp)
+		marked := resp.(api.MarkMyNotificationRead200JSONResponse)
+		assert.True(t, marked.IsRead)
+		assert.Equal(t, latest.EntityId, marked.EntityId)
+		assert.Equal(t, actor.ID, marked.ActorId)
+		assert.Equal(t, latest.Title, marked.Title)
+
+		count, err = server.GetMyUnreadNotificationCount(ctx, api.GetMyUnreadNotificationCountRequestObject{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, count.(api.GetMyUnreadNotificationCount200JSONResponse).UnreadCount)
+	})
+
+	t.Run("someone else's notification is not found", func(t *testing.T) {
+		otherCtx := testutil.ContextWithUser(context.Background(), other, testDB.Queries())
+		list, err := server.ListMyNotifications(otherCtx, api.ListMyNotificationsRequestObject{})
+		require.NoError(t, err)
+		theirs := list.(api.ListMyNotifications200JSONResponse).Data
+		require.Len(t, theirs, 1)
+
+		resp, err := server.MarkMyNotificationRead(ctx, api.MarkMyNotificationReadRequestObject{Id: theirs[0].NotificationId})
+		require.NoError(t, err)
+		assert.IsType(t, api.MarkMyNotificationRead404JSONResponse{}, resp)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		resp, err := server.ListMyNotifications(context.Background(), api.ListMyNotificationsRequestObject{})
+		require.NoError(t, err)
+		assert.IsType(t, api.ListMyNotifications401JSONResponse{}, resp)
+	})
+}
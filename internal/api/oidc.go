@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var (
+	errOIDCEmailUnverified = errors.New("provider did not vouch for the email address")
+	errOIDCNotInvited      = errors.New("no account for the address and its domain is not provisioned")
+)
+
+func (s Server) OidcLogin(ctx context.Context, request api.OidcLoginRequestObject) (api.OidcLoginResponseObject, error) {
+	if s.oidc == nil {
+		return api.OidcLogin404JSONResponse(NotFound("Single sign-on").Create()), nil
+	}
+
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	authURL, err := s.oidc.AuthorizationURL(ctx)
+	if err != nil {
+		logger.Error("Failed to start single sign-on", "error", err)
+		return api.OidcLogin500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	return api.OidcLogin302Response{Headers: api.OidcLogin302ResponseHeaders{Location: authURL}}, nil
+}
+
+// OidcCallback always answers with a redirect to the frontend: the browser
+// arrives here from the provider, so there is no client to hand a JSON error
+// to. The outcome goes in the URL fragment, which is not sent to servers.
+func (s Server) OidcCallback(ctx context.Context, request api.OidcCallbackRequestObject) (api.OidcCallbackResponseObject, error) {
+	if s.oidc == nil {
+		return api.OidcCallback404JSONResponse(NotFound("Single sign-on").Create()), nil
+	}
+
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	redirect := func(fragment url.Values) api.OidcCallbackResponseObject {
+		return api.OidcCallback302Response{Headers: api.OidcCallback302ResponseHeaders{
+			Location: s.oidc.PostLoginURL() + "#" + fragment.Encode(),
+		}}
+	}
+	fail := func(reason string) (api.OidcCallbackResponseObject, error) {
+		return redirect(url.Values{"error": {reason}}), nil
+	}
+
+	params := request.Params
+	if params.Error != nil {
+		logger.Info("Provider refused single sign-on", "error", *params.Error)
+		return fail("access_denied")
+	}
+	if params.Code == nil || params.State == nil {
+		return fail("invalid_request")
+	}
+
+	identity, err := s.oidc.Exchange(ctx, *params.Code, *params.State)
+	if err != nil {
+		if errors.Is(err, auth.ErrOIDCStateInvalid) {
+			logger.Warn("Single sign-on callback with unknown or expired state")
+			return fail("expired")
+		}
+		logger.Error("Failed to complete single sign-on", "error", err)
+		return fail("server_error")
+	}
+
+	userID, err := s.resolveOIDCUser(ctx, identity)
+	if err != nil {
+		switch {
+		case errors.Is(err, errOIDCEmailUnverified):
+			logger.Warn("Single sign-on refused: email not verified", "subject", identity.Subject, "email", identity.Email)
+			return fail("email_unverified")
+		case errors.Is(err, errOIDCNotInvited):
+			logger.Warn("Single sign-on refused: no account and domain not provisioned", "email", identity.Email)
+			return fail("not_invited")
+		}
+		logger.Error("Failed to resolve single sign-on user", "subject", identity.Subject, "error", err)
+		return fail("server_error")
+	}
+
+	accessToken, refreshToken, err := s.authService.SignIn(ctx, userID)
+	if err != nil {
+		logger.Error("Failed to issue tokens after single sign-on", "user_id", userID, "error", err)
+		return fail("server_error")
+	}
+
+	logger.Info("User authenticated via single sign-on", "user_id", userID)
+	return redirect(url.Values{
+		"access_token":  {accessToken},
+		"refresh_token": {refreshToken},
+	}), nil
+}
+
+// resolveOIDCUser finds the user a provider account signs in as: the user it
+// is already linked to; otherwise the user with its verified address, linking
+// the two; otherwise a new member when the address's domain is provisioned.
+func (s Server) resolveOIDCUser(ctx context.Context, identity *auth.OIDCIdentity) (uuid.UUID, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+	queries := s.db.Queries()
+
+	link, err := queries.GetUserIdentity(ctx, db.GetUserIdentityParams{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+	})
+	if err == nil {
+		email := identity.Email
+		if email == "" {
+			email = link.Email
+		}
+		if err := queries.TouchUserIdentity(ctx, db.TouchUserIdentityParams{
+			Issuer:  identity.Issuer,
+			Subject: identity.Subject,
+			Email:   email,
+		}); err != nil {
+			return uuid.Nil, fmt.Errorf("recording sign-in: %w", err)
+		}
+		return link.UserID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("looking up linked account: %w", err)
+	}
+
+	// an address is only proof of who someone is if the provider checked it
+	if identity.Email == "" || !identity.EmailVerified {
+		return uuid.Nil, errOIDCEmailUnverified
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.db.Queries().WithTx(tx)
+
+	var userID uuid.UUID
+	existing, err := qtx.GetUserByEmail(ctx, identity.Email)
+	switch {
+	case err == nil:
+		userID = existing.ID
+		logger.Info("Linking single sign-on account to existing user", "user_id", userID)
+	case errors.Is(err, pgx.ErrNoRows):
+		if !s.oidc.CanProvision(identity.Email) {
+			return uuid.Nil, errOIDCNotInvited
+		}
+		created, err := qtx.CreateUser(ctx, identity.Email)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("creating user: %w", err)
+		}
+		if err := qtx.CreateUserRole(ctx, db.CreateUserRoleParams{
+			UserID:   &created.ID,
+			RoleName: pgtype.Text{String: rbac.RoleMember, Valid: true},
+			Scope:    db.ScopeTypeGlobal,
+		}); err != nil {
+			return uuid.Nil, fmt.Errorf("assigning member role: %w", err)
+		}
+		userID = created.ID
+		logger.Info("Provisioned user from single sign-on", "user_id", userID)
+	default:
+		return uuid.Nil, fmt.Errorf("looking up user by email: %w", err)
+	}
+
+	if err := qtx.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		UserID:  userID,
+		Email:   identity.Email,
+	}); err != nil {
+		return uuid.Nil, fmt.Errorf("linking account: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, fmt.Errorf("committing: %w", err)
+	}
+	return userID, nil
+}
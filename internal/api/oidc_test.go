@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOIDC signs everyone in as identity and provisions usstm.ca.
+type fakeOIDC struct {
+	identity *auth.OIDCIdentity
+}
+
+func (f *fakeOIDC) Issuer() string       { return "https://idp.test" }
+func (f *fakeOIDC) PostLoginURL() string { return "https://vault.test/auth/callback" }
+
+func (f *fakeOIDC) CanProvision(email string) bool {
+	return strings.HasSuffix(email, "@usstm.ca")
+}
+
+func (f *fakeOIDC) AuthorizationURL(ctx context.Context) (string, error) {
+	return "https://idp.test/authorize?state=abc", nil
+}
+
+func (f *fakeOIDC) Exchange(ctx context.Context, code, state string) (*auth.OIDCIdentity, error) {
+	if state != "good-state" {
+		return nil, auth.ErrOIDCStateInvalid
+	}
+	return f.identity, nil
+}
+
+// runs the callback and returns the fragment it redirected with
+func oidcCallback(t *testing.T, server *Server, state string) url.Values {
+	t.Helper()
+	code := "code"
+	response, err := server.OidcCallback(context.Background(), api.OidcCallbackRequestObject{
+		Params: api.OidcCallbackParams{Code: &code, State: &state},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.OidcCallback302Response{}, response)
+
+	location := response.(api.OidcCallback302Response).Headers.Location
+	prefix := "https://vault.test/auth/callback#"
+	require.True(t, strings.HasPrefix(location, prefix), location)
+	fragment, err := url.ParseQuery(strings.TrimPrefix(location, prefix))
+	require.NoError(t, err)
+	return fragment
+}
+
+func TestServer_OidcCallback(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, _ := newTestServer(t)
+	ctx := context.Background()
+	identity := func(email string, verified bool) *auth.OIDCIdentity {
+		return &auth.OIDCIdentity{Issuer: "https://idp.test", Subject: "subject-1", Email: email, EmailVerified: verified}
+	}
+
+	t.Run("not configured", func(t *testing.T) {
+		server.oidc = nil
+		response, err := server.OidcLogin(ctx, api.OidcLoginRequestObject{})
+		require.NoError(t, err)
+		assert.IsType(t, api.OidcLogin404JSONResponse{}, response)
+	})
+
+	t.Run("links an existing user by verified email", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		existing := testDB.NewUser(t).WithEmail("student@mcgill.ca").AsMember().Create()
+		server.oidc = &fakeOIDC{identity: identity("student@mcgill.ca", true)}
+
+		fragment := oidcCallback(t, server, "good-state")
+		assert.NotEmpty(t, fragment.Get("access_token"))
+		assert.NotEmpty(t, fragment.Get("refresh_token"))
+
+		link, err := testDB.Queries().GetUserIdentity(ctx, db.GetUserIdentityParams{Issuer: "https://idp.test", Subject: "subject-1"})
+		require.NoError(t, err)
+		assert.Equal(t, existing.ID, link.UserID)
+
+		t.Run("and signs in through the link once the address changes", func(t *testing.T) {
+			server.oidc = &fakeOIDC{identity: identity("renamed@elsewhere.test", false)}
+			fragment := oidcCallback(t, server, "good-state")
+			assert.NotEmpty(t, fragment.Get("access_token"))
+
+			link, err := testDB.Queries().GetUserIdentity(ctx, db.GetUserIdentityParams{Issuer: "https://idp.test", Subject: "subject-1"})
+			require.NoError(t, err)
+			assert.Equal(t, existing.ID, link.UserID)
+			assert.Equal(t, "renamed@elsewhere.test", link.Email)
+		})
+	})
+
+	t.Run("provisions a member from an allowed domain", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		server.oidc = &fakeOIDC{identity: identity("newcomer@usstm.ca", true)}
+
+		fragment := oidcCallback(t, server, "good-state")
+		assert.NotEmpty(t, fragment.Get("access_token"))
+
+		user, err := testDB.Queries().GetUserByEmail(ctx, "newcomer@usstm.ca")
+		require.NoError(t, err)
+		roles, err := testDB.Queries().GetUserRoles(ctx, &user.ID)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, rbac.RoleMember, roles[0].RoleName.String)
+		assert.Equal(t, db.ScopeTypeGlobal, roles[0].Scope)
+	})
+
+	t.Run("refuses an uninvited address from another domain", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		server.oidc = &fakeOIDC{identity: identity("visitor@gmail.com", true)}
+
+		fragment := oidcCallback(t, server, "good-state")
+		assert.Equal(t, "not_invited", fragment.Get("error"))
+		assert.Empty(t, fragment.Get("access_token"))
+
+		_, err := testDB.Queries().GetUserByEmail(ctx, "visitor@gmail.com")
+		assert.Error(t, err)
+	})
+
+	t.Run("refuses an unverified address", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		testDB.NewUser(t).WithEmail("student@mcgill.ca").AsMember().Create()
+		server.oidc = &fakeOIDC{identity: identity("student@mcgill.ca", false)}
+
+		fragment := oidcCallback(t, server, "good-state")
+		assert.Equal(t, "email_unverified", fragment.Get("error"))
+	})
+
+	t.Run("expired state", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		server.oidc = &fakeOIDC{identity: identity("newcomer@usstm.ca", true)}
+
+		fragment := oidcCallback(t, server, "stale-state")
+		assert.Equal(t, "expired", fragment.Get("error"))
+	})
+
+	t.Run("cancelled at the provider", func(t *testing.T) {
+		server.oidc = &fakeOIDC{}
+		providerError := "access_denied"
+		response, err := server.OidcCallback(ctx, api.OidcCallbackRequestObject{
+			Params: api.OidcCallbackParams{Error: &providerError},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.OidcCallback302Response{}, response)
+		assert.Equal(t, "https://vault.test/auth/callback#error=access_denied", response.(api.OidcCallback302Response).Headers.Location)
+	})
+}
@@ -1,6 +1,14 @@
 package api
 
-import "github.com/USSTM/cv-backend/generated/api"
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/jackc/pgx/v5/pgtype"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
 
 // parsePagination normalizes limit/offset query params.
 // limit=50, offset=0. limit capped at 100, minimum 1.
@@ -35,3 +43,47 @@ func buildPaginationMeta(total, limit, offset int64) api.PaginationMeta {
 		HasMore: int(offset)+int(limit) < int(total),
 	}
 }
+
+// listSort is the field a list is ordered by and its direction. An empty By
+// leaves the query's own default ordering in place.
+type listSort struct {
+	By   string
+	Desc bool
+}
+
+// parseSort validates sort_by/order query params against the fields a list can
+// be sorted on, falling back to the list's default. Naming a field without an
+// order sorts it ascending.
+func parseSort(sortBy *string, order *api.SortOrder, defaults listSort, allowed ...string) (listSort, error) {
+	sort := defaults
+	if sortBy != nil {
+		if !slices.Contains(allowed, *sortBy) {
+			return sort, fmt.Errorf("sort_by must be one of: %s", strings.Join(allowed, ", "))
+		}
+		sort = listSort{By: *sortBy}
+	}
+	if order != nil {
+		switch *order {
+		case api.Asc:
+			sort.Desc = false
+		case api.Desc:
+			sort.Desc = true
+		default:
+			return sort, fmt.Errorf("order must be asc or desc")
+		}
+	}
+	return sort, nil
+}
+
+// parseDateRange converts optional from_date/to_date query params into the
+// nullable dates the list queries filter on.
+func parseDateRange(from, to *openapi_types.Date) (pgtype.Date, pgtype.Date) {
+	var fromDate, toDate pgtype.Date
+	if from != nil {
+		fromDate = pgtype.Date{Time: from.Time, Valid: true}
+	}
+	if to != nil {
+		toDate = pgtype.Date{Time: to.Time, Valid: true}
+	}
+	return fromDate, toDate
+}
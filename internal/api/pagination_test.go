@@ -3,6 +3,7 @@ package api
 import (
 	"testing"
 
+	"github.com/USSTM/cv-backend/generated/api"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -76,3 +77,39 @@ func TestBuildPaginationMeta(t *testing.T) {
 		assert.False(t, meta.HasMore)
 	})
 }
+
+func TestParseSort(t *testing.T) {
+	defaults := listSort{By: "requested_at", Desc: true}
+
+	t.Run("nil params keep default", func(t *testing.T) {
+		sort, err := parseSort(nil, nil, defaults, "requested_at", "quantity")
+		assert.NoError(t, err)
+		assert.Equal(t, defaults, sort)
+	})
+
+	t.Run("field without order sorts ascending", func(t *testing.T) {
+		by := "quantity"
+		sort, err := parseSort(&by, nil, defaults, "requested_at", "quantity")
+		assert.NoError(t, err)
+		assert.Equal(t, listSort{By: "quantity"}, sort)
+	})
+
+	t.Run("order alone flips default direction", func(t *testing.T) {
+		order := api.Asc
+		sort, err := parseSort(nil, &order, defaults, "requested_at", "quantity")
+		assert.NoError(t, err)
+		assert.Equal(t, listSort{By: "requested_at"}, sort)
+	})
+
+	t.Run("unknown field rejected", func(t *testing.T) {
+		by := "password_hash"
+		_, err := parseSort(&by, nil, defaults, "requested_at", "quantity")
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown order rejected", func(t *testing.T) {
+		order := api.SortOrder("sideways")
+		_, err := parseSort(nil, &order, defaults, "requested_at", "quantity")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+)
+
+// policies is keyed by operation ID. Handlers may still check further
+// (ownership, the group an item belongs to) once the policy has passed.
+var policies = map[string]Policy{
+	"AddToCart": requirePermissionIn(rbac.ManageCart, func(r api.AddToCartRequestObject) *uuid.UUID {
+		if r.Body == nil {
+			return &uuid.Nil // no group a role can be scoped to
+		}
+		return &r.Body.GroupId
+	}),
+	"AdjustItemStock":        requirePermission(rbac.ManageItems),
+	"ApproveDeletionRequest": authenticated(),
+	"AssignUserRole":         requirePermission(rbac.ManageUsers),
+	"BorrowItem": requirePermissionIn(rbac.RequestItems, func(r api.BorrowItemRequestObject) *uuid.UUID {
+		if r.Body == nil {
+			return &uuid.Nil // no group a role can be scoped to
+		}
+		return &r.Body.GroupId
+	}),
+	"CancelBooking":            authenticated(),
+	"CancelQueueTask":          requirePermission(rbac.ManageWorkers),
+	"CancelRequest":            authenticated(),
+	"CancelReturnCampaign":     requirePermission(rbac.ManageAllBookings),
+	"CheckBorrowingItemStatus": requirePermission(rbac.RequestItems),
+	"CheckoutCart": requirePermissionIn(rbac.RequestItems, func(r api.CheckoutCartRequestObject) *uuid.UUID {
+		if r.Body == nil {
+			return &uuid.Nil // no group a role can be scoped to
+		}
+		return &r.Body.GroupId
+	}),
+	"CheckoutGroupCart":                        requirePermissionIn(rbac.RequestItems, func(r api.CheckoutGroupCartRequestObject) *uuid.UUID { return &r.GroupId }),
+	"ClearCart":                                requirePermissionIn(rbac.ManageCart, func(r api.ClearCartRequestObject) *uuid.UUID { return &r.GroupId }),
+	"CloseStocktake":                           requirePermission(rbac.ManageItems),
+	"CompleteItemMaintenance":                  requirePermission(rbac.ManageItems),
+	"ConfirmBooking":                           authenticated(),
+	"ConfirmMFA":                               authenticated(),
+	"CreateApiKey":                             requirePermission(rbac.ManageAPIKeys),
+	"CreateApprovalDelegation":                 authenticated(),
+	"CreateAvailability":                       requirePermission(rbac.ManageTimeSlots),
+	"CreateBorrowingBlackout":                  requirePermission(rbac.ManageBorrowingPolicies),
+	"CreateBorrowingPolicy":                    requirePermission(rbac.ManageBorrowingPolicies),
+	"CreateCategory":                           requirePermission(rbac.ManageItems),
+	"CreateGroup":                              requirePermission(rbac.ManageGroups),
+	"CreateItem":                               requirePermission(rbac.ManageItems),
+	"CreateItemUnit":                           requirePermission(rbac.ManageItems),
+	"CreateMyCalendarFeedToken":                requirePermission(rbac.ViewOwnData),
+	"CreateReport":                             authenticated(),
+	"CreateRequestComment":                     authenticated(),
+	"CreateReturnCampaign":                     requirePermission(rbac.ManageAllBookings),
+	"CreateRole":                               requirePermission(rbac.ManageUsers),
+	"CreateRoutingRule":                        requirePermission(rbac.ManageNotifications),
+	"CreateWebhook":                            requirePermission(rbac.ManageWebhooks),
+	"CreateWeeklyAvailability":                 requirePermission(rbac.ManageTimeSlots),
+	"DecideBorrowingExtension":                 requirePermission(rbac.ApproveAllRequests),
+	"DecideGroupJoinRequest":                   requirePermissionIn(rbac.ManageGroupUsers, func(r api.DecideGroupJoinRequestRequestObject) *uuid.UUID { return &r.Id }),
+	"DeleteApprovalDelegation":                 authenticated(),
+	"DeleteAvailability":                       requirePermission(rbac.ManageTimeSlots),
+	"DeleteBorrowingBlackout":                  requirePermission(rbac.ManageBorrowingPolicies),
+	"DeleteBorrowingImage":                     authenticated(),
+	"DeleteBorrowingPolicy":                    requirePermission(rbac.ManageBorrowingPolicies),
+	"DeleteGroup":                              requirePermission(rbac.ManageGroups),
+	"DeleteItem":                               requirePermission(rbac.ManageItems),
+	"DeleteItemImage":                          requirePermission(rbac.ManageItems),
+	"DeleteMyCalendarLink":                     requirePermission(rbac.ManageTimeSlots),
+	"DeleteRoutingRule":                        requirePermission(rbac.ManageNotifications),
+	"DeleteWebhook":                            requirePermission(rbac.ManageWebhooks),
+	"DrainQueue":                               requirePermission(rbac.ManageWorkers),
+	"EnrollMFA":                                authenticated(),
+	"ExportItems":                              requirePermission(rbac.ManageItems),
+	"GetActiveBorrowedItemsByUserId":           requirePermission(rbac.ViewOwnData),
+	"GetActiveBorrowedItemsToBeReturnedByDate": requirePermission(rbac.ViewAllData),
+	"GetActiveConfig":                          requirePermission(rbac.ManageWorkers),
+	"GetAllActiveBorrowedItems":                requirePermission(rbac.ViewAllData),
+	"GetAllGroups":                             requirePermission(rbac.ViewGroupData),
+	"GetAllRequests":                           requirePermission(rbac.ViewAllData),
+	"GetAllReturnedItems":                      requirePermission(rbac.ViewAllData),
+	"GetAuditLog":                              requirePermission(rbac.ViewAuditLog),
+	"GetAvailabilityByDate":                    authenticated(),
+	"GetAvailabilityByID":                      authenticated(),
+	"GetBookingByID":                           authenticated(),
+	"GetBookingEvents":                         authenticated(),
+	"GetBorrowedItemHistoryByUserId":           requirePermission(rbac.ViewOwnData),
+	"GetCart":                                  requirePermissionIn(rbac.ManageCart, func(r api.GetCartRequestObject) *uuid.UUID { return &r.GroupId }),
+	"GetGroupActivityReport":                   requirePermission(rbac.ViewAllData),
+	"GetGroupBookingPolicy":                    requirePermission(rbac.ViewGroupData),
+	"GetGroupByID":                             requirePermission(rbac.ViewGroupData),
+	"GetGroupRequestSLA":                       requirePermission(rbac.ViewGroupData),
+	"GetItemAvailableSlots":                    requirePermission(rbac.ViewItems),
+	"GetItemById":                              requirePermission(rbac.ViewItems),
+	"GetItemFees":                              requirePermission(rbac.ViewItems),
+	"GetItemMaintenanceHistory":                requirePermission(rbac.ManageItems),
+	"GetItemQRCode":                            requirePermission(rbac.ManageItems),
+	"GetItemStockMovements":                    requirePermission(rbac.ManageItems),
+	"GetItemTakingHistory":                     requirePermission(rbac.ViewAllData),
+	"GetItemTakingStats":                       requirePermission(rbac.ViewAllData),
+	"GetItemVisibility":                        requirePermission(rbac.ManageItems),
+	"GetItemWaitlist":                          requirePermission(rbac.ViewOwnData),
+	"GetItems":                                 requirePermission(rbac.ViewItems),
+	"GetItemsByType":                           requirePermission(rbac.ViewItems),
+	"GetLoadSheddingStats":                     requirePermission(rbac.ManageWorkers),
+	"GetMyApprovalQueue":                       authenticated(),
+	"GetMyBookings":                            authenticated(),
+	"GetMyBookingsCalendar":                    public(),
+	"GetMyCalendarLink":                        requirePermission(rbac.ManageTimeSlots),
+	"GetMyDashboard":                           requirePermission(rbac.ViewOwnData),
+	"GetMyNotificationPreferences":             authenticated(),
+	"GetMyPreferences":                         authenticated(),
+	"GetMyUnreadNotificationCount":             authenticated(),
+	"GetNotifications":                         authenticated(),
+	"GetOverdueBorrowings":                     requirePermission(rbac.ViewAllData),
+	"GetOverdueSummaryReport":                  requirePermission(rbac.ViewAllData),
+	"GetPendingRequests":                       requirePermission(rbac.ApproveAllRequests),
+	"GetRequestById":                           requirePermission(rbac.ViewOwnData),
+	"GetRequestSLACompliance":                  requirePermission(rbac.ViewAllData),
+	"GetRequestsByUserId":                      requirePermission(rbac.ViewOwnData),
+	"GetReturnedItemsByUserId":                 requirePermission(rbac.ViewOwnData),
+	"GetStocktakeReport":                       requirePermission(rbac.ManageItems),
+	"GetTopItemsReport":                        requirePermission(rbac.ViewAllData),
+	"GetUnreadNotificationCount":               authenticated(),
+	"GetUserAvailability":                      authenticated(),
+	"GetUserByEmail":                           requirePermission(rbac.ManageUsers),
+	"GetUserById":                              authenticated(),
+	"GetUserTakingHistory":                     authenticated(),
+	"GetUsers":                                 requirePermission(rbac.ManageUsers),
+	"GetUsersByGroup":                          requirePermissionIn(rbac.ManageGroupUsers, func(r api.GetUsersByGroupRequestObject) *uuid.UUID { return &r.GroupId }),
+	"GetUtilizationReport":                     requirePermission(rbac.ViewAllData),
+	"HealthCheck":                              public(),
+	"ImportItems":                              requirePermission(rbac.ManageItems),
+	"ImportUsers":                              requirePermission(rbac.ManageUsers),
+	"InviteUser":                               authenticated(),
+	"JoinItemWaitlist": requirePermissionIn(rbac.RequestItems, func(r api.JoinItemWaitlistRequestObject) *uuid.UUID {
+		if r.Body == nil {
+			return &uuid.Nil // no group a role can be scoped to
+		}
+		return &r.Body.GroupId
+	}),
+	"LeaveItemWaitlist":          requirePermission(rbac.ViewOwnData),
+	"LiftEmailSuppression":       requirePermission(rbac.ManageUsers),
+	"ListApiKeys":                requirePermission(rbac.ManageAPIKeys),
+	"ListApprovalDelegations":    authenticated(),
+	"ListAvailability":           authenticated(),
+	"ListBookings":               authenticated(),
+	"ListBorrowingBlackouts":     requirePermission(rbac.ManageBorrowingPolicies),
+	"ListBorrowingExtensions":    requirePermission(rbac.ApproveAllRequests),
+	"ListGroupJoinRequests":      requirePermissionIn(rbac.ManageGroupUsers, func(r api.ListGroupJoinRequestsRequestObject) *uuid.UUID { return &r.Id }),
+	"ListBorrowingImages":        authenticated(),
+	"ListBorrowingPolicies":      requirePermission(rbac.ManageBorrowingPolicies),
+	"ListCategories":             requirePermission(rbac.ViewItems),
+	"ListDamageReports":          authenticated(),
+	"ListDeletionRequests":       requirePermission(rbac.ViewAllData),
+	"ListFines":                  requirePermission(rbac.ViewAllData),
+	"ListItemImages":             requirePermission(rbac.ViewItems),
+	"ListItemUnits":              requirePermission(rbac.ManageItems),
+	"ListMyFines":                requirePermission(rbac.ViewOwnData),
+	"ListMyNotifications":        authenticated(),
+	"ListPendingConfirmation":    authenticated(),
+	"ListPermissions":            requirePermission(rbac.ManageUsers),
+	"ListQueues":                 requirePermission(rbac.ManageWorkers),
+	"ListReports":                authenticated(),
+	"ListRequestComments":        authenticated(),
+	"ListReturnCampaigns":        requirePermission(rbac.ManageAllBookings),
+	"ListRoles":                  requirePermission(rbac.ManageUsers),
+	"ListRoutingRules":           requirePermission(rbac.ManageNotifications),
+	"ListStocktakes":             requirePermission(rbac.ManageItems),
+	"ListTimeSlots":              authenticated(),
+	"ListTrash":                  requirePermission(rbac.ViewAllData),
+	"ListWebhookDeliveries":      requirePermission(rbac.ManageWebhooks),
+	"ListWebhooks":               requirePermission(rbac.ManageWebhooks),
+	"Logout":                     public(),
+	"MarkAllNotificationsAsRead": authenticated(),
+	"MarkMyNotificationRead":     authenticated(),
+	"MarkNotificationAsRead":     authenticated(),
+	"OidcCallback":               public(),
+	"OidcLogin":                  public(),
+	"OpenStocktake":              requirePermission(rbac.ManageItems),
+	"PatchItem":                  requirePermission(rbac.ManageItems),
+	"PauseQueue":                 requirePermission(rbac.ManageWorkers),
+	"PayFine":                    requirePermission(rbac.ManageAllBookings),
+	"PingProtected":              authenticated(),
+	"PresignItemImageUpload":     requirePermission(rbac.ManageItems),
+	"PromoteGroup":               requirePermission(rbac.ManageGroups),
+	"ReadinessCheck":             public(),
+	"RecordBookingPickup":        authenticated(),
+	"RecordBookingReturn":        authenticated(),
+	"RecordStocktakeCount":       requirePermission(rbac.ManageItems),
+	"RefreshToken":               public(),
+	"RejectDeletionRequest":      authenticated(),
+	"RemoveFromCart":             requirePermissionIn(rbac.ManageCart, func(r api.RemoveFromCartRequestObject) *uuid.UUID { return &r.GroupId }),
+	"RemoveGroupBookingPolicy":   requirePermission(rbac.ManageGroups),
+	"RemoveGroupRequestSLA":      requirePermission(rbac.ManageGroups),
+	"RemoveItemFairnessPolicy":   requirePermission(rbac.ManageItems),
+	"RequestBorrowingExtension":  requirePermission(rbac.RequestItems),
+	"RequestGroupJoin":           authenticated(),
+	"RequestItem": requirePermissionIn(rbac.RequestItems, func(r api.RequestItemRequestObject) *uuid.UUID {
+		if r.Body == nil {
+			return &uuid.Nil // no group a role can be scoped to
+		}
+		return &r.Body.GroupId
+	}),
+	"RequestOTP":                      public(),
+	"RescheduleBooking":               authenticated(),
+	"ResolveScanCode":                 requirePermission(rbac.ViewItems),
+	"RestoreDeletionRequest":          authenticated(),
+	"RestoreItem":                     requirePermission(rbac.ManageItems),
+	"RestoreTrashEntry":               authenticated(),
+	"ResumeQueue":                     requirePermission(rbac.ManageWorkers),
+	"ReturnItem":                      requirePermission(rbac.ViewOwnData),
+	"ReviewRequest":                   authenticated(),
+	"RevokeApiKey":                    requirePermission(rbac.ManageAPIKeys),
+	"RevokeMyCalendarFeedToken":       requirePermission(rbac.ViewOwnData),
+	"RevokeUserRole":                  requirePermission(rbac.ManageUsers),
+	"RunReturnCampaign":               requirePermission(rbac.ManageAllBookings),
+	"SearchItems":                     requirePermission(rbac.ViewItems),
+	"SetGroupBookingPolicy":           requirePermission(rbac.ManageGroups),
+	"SetGroupRequestSLA":              requirePermission(rbac.ManageGroups),
+	"SetItemFairnessPolicy":           requirePermission(rbac.ManageItems),
+	"SetItemFees":                     requirePermission(rbac.ManageItems),
+	"SetItemPrimaryImage":             requirePermission(rbac.ManageItems),
+	"SetItemVisibility":               requirePermission(rbac.ManageItems),
+	"SetMyCalendarLink":               requirePermission(rbac.ManageTimeSlots),
+	"SetMyStudentId":                  authenticated(),
+	"SetRolePermissions":              requirePermission(rbac.ManageUsers),
+	"SetUserStudentId":                requirePermission(rbac.ManageUsers),
+	"StartItemMaintenance":            requirePermission(rbac.ManageItems),
+	"StreamEvents":                    authenticated(),
+	"StreamMyEvents":                  authenticated(),
+	"UpdateBorrowingPolicy":           requirePermission(rbac.ManageBorrowingPolicies),
+	"UpdateCartItemQuantity":          requirePermissionIn(rbac.ManageCart, func(r api.UpdateCartItemQuantityRequestObject) *uuid.UUID { return &r.GroupId }),
+	"UpdateDamageReport":              requirePermission(rbac.ManageItems),
+	"UpdateGroup":                     requirePermission(rbac.ManageGroups),
+	"UpdateItem":                      requirePermission(rbac.ManageItems),
+	"UpdateItemUnit":                  requirePermission(rbac.ManageItems),
+	"UpdateMyNotificationPreferences": authenticated(),
+	"UpdateMyPreferences":             authenticated(),
+	"UploadBorrowingImage":            authenticated(),
+	"UploadGroupLogo":                 requirePermissionIn(rbac.ManageGroupUsers, func(r api.UploadGroupLogoRequestObject) *uuid.UUID { return &r.GroupId }),
+	"UploadItemImage":                 requirePermission(rbac.ManageItems),
+	"VerifyBookingIdentity":           requirePermission(rbac.ManageAllBookings),
+	"VerifyMFA":                       authenticated(),
+	"VerifyOTP":                       public(),
+	"WaiveFine":                       requirePermission(rbac.ManageAllBookings),
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/USSTM/cv-backend/generated/db"
 	"github.com/USSTM/cv-backend/internal/auth"
 	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/notifications"
 	"github.com/USSTM/cv-backend/internal/preferences"
 )
 
@@ -87,3 +88,86 @@ func (s Server) UpdateMyPreferences(ctx context.Context, request genapi.UpdateMy
 		EmailNotifications: result.EmailNotifications,
 	}, nil
 }
+
+// every notification type with its email switch; types with no stored row
+// are on unless they are opt-in
+func toNotificationPreferences(stored []db.NotificationPreference) []genapi.NotificationPreference {
+	enabled := make(map[string]bool, len(stored))
+	for _, pref := range stored {
+		enabled[pref.NotificationType] = pref.EmailEnabled
+	}
+	result := make([]genapi.NotificationPreference, 0, len(notifications.Types))
+	for _, t := range notifications.Types {
+		email, ok := enabled[string(t)]
+		result = append(result, genapi.NotificationPreference{
+			Type:  genapi.NotificationType(t),
+			Email: email || !ok && notifications.EmailByDefault(t),
+		})
+	}
+	return result
+}
+
+func (s Server) GetMyNotificationPreferences(ctx context.Context, _ genapi.GetMyNotificationPreferencesRequestObject) (genapi.GetMyNotificationPreferencesResponseObject, error) {
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return genapi.GetMyNotificationPreferences401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	stored, err := s.db.Queries().ListNotificationPreferences(ctx, user.ID)
+	if err != nil {
+		middleware.GetLoggerFromContext(ctx).Error("failed to list notification preferences", "user_id", user.ID, "error", err)
+		return genapi.GetMyNotificationPreferences500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	return genapi.GetMyNotificationPreferences200JSONResponse(toNotificationPreferences(stored)), nil
+}
+
+func (s Server) UpdateMyNotificationPreferences(ctx context.Context, request genapi.UpdateMyNotificationPreferencesRequestObject) (genapi.UpdateMyNotificationPreferencesResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return genapi.UpdateMyNotificationPreferences401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	if request.Body == nil {
+		return genapi.UpdateMyNotificationPreferences400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	for _, pref := range request.Body.Preferences {
+		if !notifications.ValidType(notifications.Type(pref.Type)) {
+			return genapi.UpdateMyNotificationPreferences400JSONResponse(ValidationErr("Unknown notification type: "+string(pref.Type), nil).Create()), nil
+		}
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return genapi.UpdateMyNotificationPreferences500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+	for _, pref := range request.Body.Preferences {
+		if err := qtx.UpsertNotificationPreference(ctx, db.UpsertNotificationPreferenceParams{
+			UserID:           user.ID,
+			NotificationType: string(pref.Type),
+			EmailEnabled:     pref.Email,
+		}); err != nil {
+			logger.Error("failed to update notification preference", "user_id", user.ID, "type", pref.Type, "error", err)
+			return genapi.UpdateMyNotificationPreferences500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+	}
+
+	stored, err := qtx.ListNotificationPreferences(ctx, user.ID)
+	if err != nil {
+		logger.Error("failed to list notification preferences", "user_id", user.ID, "error", err)
+		return genapi.UpdateMyNotificationPreferences500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit notification preferences", "user_id", user.ID, "error", err)
+		return genapi.UpdateMyNotificationPreferences500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	return genapi.UpdateMyNotificationPreferences200JSONResponse(toNotificationPreferences(stored)), nil
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/notifications"
 	"github.com/USSTM/cv-backend/internal/preferences"
 	"github.com/USSTM/cv-backend/internal/testutil"
 	"github.com/stretchr/testify/assert"
@@ -101,3 +102,88 @@ func TestServer_UpdateMyPreferences(t *testing.T) {
 		require.IsType(t, api.UpdateMyPreferences401JSONResponse{}, resp)
 	})
 }
+
+func TestServer_MyNotificationPreferences(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, _ := newTestServer(t)
+
+	emailFor := func(prefs []api.NotificationPreference, kind api.NotificationType) bool {
+		for _, pref := range prefs {
+			if pref.Type == kind {
+				return pref.Email
+			}
+		}
+		t.Fatalf("notification type %s missing", kind)
+		return false
+	}
+
+	t.Run("every type but the approver digest defaults to on", func(t *testing.T) {
+		user := testDB.NewUser(t).WithEmail("notifprefs@default.ca").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+
+		resp, err := server.GetMyNotificationPreferences(ctx, api.GetMyNotificationPreferencesRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetMyNotificationPreferences200JSONResponse{}, resp)
+
+		prefs := resp.(api.GetMyNotificationPreferences200JSONResponse)
+		assert.Len(t, prefs, len(notifications.Types))
+		for _, pref := range prefs {
+			if pref.Type == api.NotificationTypeApproverDigest {
+				assert.False(t, pref.Email, "the approver digest is opt-in")
+				continue
+			}
+			assert.True(t, pref.Email, "%s should default to on", pref.Type)
+		}
+	})
+
+	t.Run("turning a type off leaves the others alone", func(t *testing.T) {
+		user := testDB.NewUser(t).WithEmail("notifprefs@update.ca").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+
+		resp, err := server.UpdateMyNotificationPreferences(ctx, api.UpdateMyNotificationPreferencesRequestObject{
+			Body: &api.NotificationPreferencesUpdate{Preferences: []api.NotificationPreference{
+				{Type: api.NotificationTypeDueSoon, Email: false},
+			}},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.UpdateMyNotificationPreferences200JSONResponse{}, resp)
+		assert.False(t, emailFor(resp.(api.UpdateMyNotificationPreferences200JSONResponse), api.NotificationTypeDueSoon))
+
+		got, err := server.GetMyNotificationPreferences(ctx, api.GetMyNotificationPreferencesRequestObject{})
+		require.NoError(t, err)
+		prefs := got.(api.GetMyNotificationPreferences200JSONResponse)
+		assert.False(t, emailFor(prefs, api.NotificationTypeDueSoon))
+		assert.True(t, emailFor(prefs, api.NotificationTypeOverdue))
+
+		// and back on
+		resp, err = server.UpdateMyNotificationPreferences(ctx, api.UpdateMyNotificationPreferencesRequestObject{
+			Body: &api.NotificationPreferencesUpdate{Preferences: []api.NotificationPreference{
+				{Type: api.NotificationTypeDueSoon, Email: true},
+			}},
+		})
+		require.NoError(t, err)
+		assert.True(t, emailFor(resp.(api.UpdateMyNotificationPreferences200JSONResponse), api.NotificationTypeDueSoon))
+	})
+
+	t.Run("unknown type returns 400", func(t *testing.T) {
+		user := testDB.NewUser(t).WithEmail("notifprefs@unknown.ca").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), user, testDB.Queries())
+
+		resp, err := server.UpdateMyNotificationPreferences(ctx, api.UpdateMyNotificationPreferencesRequestObject{
+			Body: &api.NotificationPreferencesUpdate{Preferences: []api.NotificationPreference{
+				{Type: "newsletter", Email: false},
+			}},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.UpdateMyNotificationPreferences400JSONResponse{}, resp)
+	})
+
+	t.Run("unauthenticated returns 401", func(t *testing.T) {
+		resp, err := server.GetMyNotificationPreferences(context.Background(), api.GetMyNotificationPreferencesRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetMyNotificationPreferences401JSONResponse{}, resp)
+	})
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/hibiken/asynq"
+)
+
+func toQueueInfo(info *asynq.QueueInfo) api.QueueInfo {
+	return api.QueueInfo{
+		Name:      info.Queue,
+		Paused:    info.Paused,
+		Size:      info.Size,
+		Pending:   info.Pending,
+		Active:    info.Active,
+		Scheduled: info.Scheduled,
+		Retry:     info.Retry,
+		Archived:  info.Archived,
+	}
+}
+
+func (s Server) ListQueues(ctx context.Context, request api.ListQueuesRequestObject) (api.ListQueuesResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListQueues401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageWorkers, nil)
+	if err != nil {
+		logger.Error("Error checking manage_workers permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageWorkers,
+			"error", err)
+		return api.ListQueues500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListQueues403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	infos, err := s.queue.ListQueues()
+	if err != nil {
+		logger.Error("Failed to list queues", "error", err)
+		return api.ListQueues500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := make(api.ListQueues200JSONResponse, 0, len(infos))
+	for _, info := range infos {
+		response = append(response, toQueueInfo(info))
+	}
+	return response, nil
+}
+
+func (s Server) PauseQueue(ctx context.Context, request api.PauseQueueRequestObject) (api.PauseQueueResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.PauseQueue401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageWorkers, nil)
+	if err != nil {
+		logger.Error("Error checking manage_workers permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageWorkers,
+			"error", err)
+		return api.PauseQueue500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.PauseQueue403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if err := s.queue.PauseQueue(request.Queue); err != nil {
+		if apiErr := errorFor(err); apiErr.Code == CodeResourceNotFound {
+			return api.PauseQueue404JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to pause queue", "queue", request.Queue, "error", err)
+		return api.PauseQueue500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	info, err := s.queue.GetQueueInfo(request.Queue)
+	if err != nil {
+		logger.Error("Failed to get queue info", "queue", request.Queue, "error", err)
+		return api.PauseQueue500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Queue paused", "queue", request.Queue, "user_id", user.ID)
+	return api.PauseQueue200JSONResponse(toQueueInfo(info)), nil
+}
+
+func (s Server) ResumeQueue(ctx context.Context, request api.ResumeQueueRequestObject) (api.ResumeQueueResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ResumeQueue401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageWorkers, nil)
+	if err != nil {
+		logger.Error("Error checking manage_workers permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageWorkers,
+			"error", err)
+		return api.ResumeQueue500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ResumeQueue403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if err := s.queue.ResumeQueue(request.Queue); err != nil {
+		if apiErr := errorFor(err); apiErr.Code == CodeResourceNotFound {
+			return api.ResumeQueue404JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to resume queue", "queue", request.Queue, "error", err)
+		return api.ResumeQueue500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	info, err := s.queue.GetQueueInfo(request.Queue)
+	if err != nil {
+		logger.Error("Failed to get queue info", "queue", request.Queue, "error", err)
+		return api.ResumeQueue500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Queue resumed", "queue", request.Queue, "user_id", user.ID)
+	return api.ResumeQueue200JSONResponse(toQueueInfo(info)), nil
+}
+
+func (s Server) DrainQueue(ctx context.Context, request api.DrainQueueRequestObject) (api.DrainQueueResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.DrainQueue401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageWorkers, nil)
+	if err != nil {
+		logger.Error("Error checking manage_workers permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageWorkers,
+			"error", err)
+		return api.DrainQueue500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.DrainQueue403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	state := queue.StateScheduled
+	if request.Params.State != nil {
+		state = string(*request.Params.State)
+	}
+
+	deleted, err := s.queue.DrainQueue(request.Queue, state)
+	if err != nil {
+		if apiErr := errorFor(err); apiErr.Code == CodeResourceNotFound {
+			return api.DrainQueue404JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to drain queue", "queue", request.Queue, "state", state, "error", err)
+		return api.DrainQueue500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Queue drained", "queue", request.Queue, "state", state, "deleted", deleted, "user_id", user.ID)
+	return api.DrainQueue200JSONResponse{
+		Queue:   request.Queue,
+		State:   state,
+		Deleted: deleted,
+	}, nil
+}
+
+func (s Server) CancelQueueTask(ctx context.Context, request api.CancelQueueTaskRequestObject) (api.CancelQueueTaskResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CancelQueueTask401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageWorkers, nil)
+	if err != nil {
+		logger.Error("Error checking manage_workers permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageWorkers,
+			"error", err)
+		return api.CancelQueueTask500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CancelQueueTask403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if err := s.queue.CancelTask(request.TaskId); err != nil {
+		logger.Error("Failed to cancel task", "task_id", request.TaskId, "error", err)
+		return api.CancelQueueTask500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Task cancellation requested", "task_id", request.TaskId, "user_id", user.ID)
+	return api.CancelQueueTask200JSONResponse{Message: "Cancellation signal sent"}, nil
+}
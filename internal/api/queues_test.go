@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_PauseResumeQueue(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("pause and resume default queue", func(t *testing.T) {
+		admin := testDB.NewUser(t).WithEmail("admin@queues.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageWorkers, nil, true, nil)
+		response, err := server.PauseQueue(ctx, api.PauseQueueRequestObject{Queue: "default"})
+		require.NoError(t, err)
+		require.IsType(t, api.PauseQueue200JSONResponse{}, response)
+		assert.True(t, response.(api.PauseQueue200JSONResponse).Paused)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageWorkers, nil, true, nil)
+		resumed, err := server.ResumeQueue(ctx, api.ResumeQueueRequestObject{Queue: "default"})
+		require.NoError(t, err)
+		require.IsType(t, api.ResumeQueue200JSONResponse{}, resumed)
+		assert.False(t, resumed.(api.ResumeQueue200JSONResponse).Paused)
+	})
+
+	t.Run("unknown queue", func(t *testing.T) {
+		admin := testDB.NewUser(t).WithEmail("admin2@queues.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageWorkers, nil, true, nil)
+		response, err := server.PauseQueue(ctx, api.PauseQueueRequestObject{Queue: "does-not-exist"})
+		require.NoError(t, err)
+		require.IsType(t, api.PauseQueue404JSONResponse{}, response)
+	})
+
+	t.Run("insufficient permissions", func(t *testing.T) {
+		member := testDB.NewUser(t).WithEmail("member@queues.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageWorkers, nil, false, nil)
+		response, err := server.PauseQueue(ctx, api.PauseQueueRequestObject{Queue: "default"})
+		require.NoError(t, err)
+		require.IsType(t, api.PauseQueue403JSONResponse{}, response)
+	})
+}
+
+func TestServer_DrainQueue(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("drains scheduled tasks by default", func(t *testing.T) {
+		admin := testDB.NewUser(t).WithEmail("admin@drain.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		for i := 0; i < 2; i++ {
+			task := asynq.NewTask(queue.TypeEmailDelivery, []byte(`{}`))
+			_, err := sharedQueue.Client.Enqueue(task, asynq.ProcessIn(time.Hour))
+			require.NoError(t, err)
+		}
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageWorkers, nil, true, nil)
+		response, err := server.DrainQueue(ctx, api.DrainQueueRequestObject{Queue: "default"})
+		require.NoError(t, err)
+		require.IsType(t, api.DrainQueue200JSONResponse{}, response)
+
+		resp := response.(api.DrainQueue200JSONResponse)
+		assert.Equal(t, queue.StateScheduled, resp.State)
+		assert.Equal(t, 2, resp.Deleted)
+
+		scheduled, err := sharedQueue.Inspector.ListScheduledTasks("default")
+		require.NoError(t, err)
+		assert.Empty(t, scheduled)
+	})
+}
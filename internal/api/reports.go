@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/jackc/pgx/v5/pgtype"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+func (s Server) convertToReportResponse(ctx context.Context, report db.Report) api.Report {
+	response := api.Report{
+		Id:        report.ID,
+		Type:      api.ReportType(report.ReportType),
+		Status:    api.ReportStatus(report.Status),
+		GroupId:   report.GroupID,
+		CreatedAt: report.CreatedAt.Time,
+	}
+	if report.FromDate.Valid {
+		response.From = &openapi_types.Date{Time: report.FromDate.Time}
+	}
+	if report.ToDate.Valid {
+		response.To = &openapi_types.Date{Time: report.ToDate.Time}
+	}
+	if report.RowCount.Valid {
+		rowCount := int(report.RowCount.Int32)
+		response.RowCount = &rowCount
+	}
+	if report.Error.Valid {
+		response.Error = &report.Error.String
+	}
+	if report.CompletedAt.Valid {
+		response.CompletedAt = &report.CompletedAt.Time
+	}
+	if report.ExpiresAt.Valid {
+		response.ExpiresAt = &report.ExpiresAt.Time
+	}
+
+	// links are re-signed on every read and never outlive the report
+	if report.Status == db.ReportStatusReady && report.S3Key.Valid && report.ExpiresAt.Valid {
+		if remaining := time.Until(report.ExpiresAt.Time); remaining > 0 {
+			url, err := s.storage.GeneratePresignedURL(ctx, "GET", report.S3Key.String, remaining)
+			if err != nil {
+				middleware.GetLoggerFromContext(ctx).Error("Failed to presign report", "report_id", report.ID, "error", err)
+			} else {
+				response.DownloadUrl = &url
+			}
+		}
+	}
+	return response
+}
+
+func (s Server) CreateReport(ctx context.Context, request api.CreateReportRequestObject) (api.CreateReportResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateReport401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.CreateReport400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	body := request.Body
+
+	switch db.ReportType(body.Type) {
+	case db.ReportTypeBorrowings, db.ReportTypeBookings, db.ReportTypeTakings, db.ReportTypeOutstanding:
+	default:
+		return api.CreateReport400JSONResponse(ValidationErr("type must be one of borrowings, bookings, takings, outstanding", nil).Create()), nil
+	}
+	if body.From != nil && body.To != nil && body.To.Time.Before(body.From.Time) {
+		return api.CreateReport400JSONResponse(ValidationErr("to must not be before from", nil).Create()), nil
+	}
+
+	// group-scoped exports need group data access; everything else is system-wide
+	permission := rbac.ViewAllData
+	if body.GroupId != nil {
+		permission = rbac.ViewGroupData
+	}
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, permission, body.GroupId)
+	if err != nil {
+		logger.Error("Error checking report permission",
+			"user_id", user.ID,
+			"permission", permission,
+			"error", err)
+		return api.CreateReport500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CreateReport403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	params := db.CreateReportParams{
+		ReportType:  db.ReportType(body.Type),
+		RequestedBy: user.ID,
+		GroupID:     body.GroupId,
+	}
+	if body.From != nil {
+		params.FromDate = pgtype.Date{Time: body.From.Time, Valid: true}
+	}
+	if body.To != nil {
+		params.ToDate = pgtype.Date{Time: body.To.Time, Valid: true}
+	}
+
+	report, err := s.db.Queries().CreateReport(ctx, params)
+	if err != nil {
+		logger.Error("Failed to create report", "user_id", user.ID, "error", err)
+		return api.CreateReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if _, err := s.queue.Enqueue(ctx, queue.TypeReportGenerate, queue.ReportGeneratePayload{ReportID: report.ID}); err != nil {
+		logger.Error("Failed to enqueue report generation", "report_id", report.ID, "error", err)
+		if markErr := s.db.Queries().MarkReportFailed(ctx, db.MarkReportFailedParams{
+			ID:    report.ID,
+			Error: pgtype.Text{String: "failed to queue report generation", Valid: true},
+		}); markErr != nil {
+			logger.Error("Failed to mark report failed", "report_id", report.ID, "error", markErr)
+		}
+		return api.CreateReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Report requested", "report_id", report.ID, "type", report.ReportType, "user_id", user.ID)
+	return api.CreateReport202JSONResponse(s.convertToReportResponse(ctx, report)), nil
+}
+
+func (s Server) ListReports(ctx context.Context, request api.ListReportsRequestObject) (api.ListReportsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListReports401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	rows, err := s.db.Queries().ListReportsByUser(ctx, db.ListReportsByUserParams{
+		RequestedBy: user.ID,
+		Limit:       limit,
+		Offset:      offset,
+	})
+	if err != nil {
+		logger.Error("Failed to list reports", "user_id", user.ID, "error", err)
+		return api.ListReports500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountReportsByUser(ctx, user.ID)
+	if err != nil {
+		logger.Error("Failed to count reports", "user_id", user.ID, "error", err)
+		return api.ListReports500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	data := make([]api.Report, 0, len(rows))
+	for _, row := range rows {
+		data = append(data, s.convertToReportResponse(ctx, row))
+	}
+
+	return api.ListReports200JSONResponse{
+		Data: data,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
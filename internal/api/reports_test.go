@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_CreateReport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("admin report is queued", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@reports.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewAllData, nil, true, nil)
+		response, err := server.CreateReport(ctx, api.CreateReportRequestObject{
+			Body: &api.CreateReportRequest{Type: "borrowings"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateReport202JSONResponse{}, response)
+
+		report := response.(api.CreateReport202JSONResponse)
+		assert.Equal(t, api.ReportStatus("pending"), report.Status)
+		assert.Nil(t, report.DownloadUrl)
+
+		pending, err := sharedQueue.Inspector.ListPendingTasks("default")
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, queue.TypeReportGenerate, pending[0].Type)
+
+		var payload queue.ReportGeneratePayload
+		require.NoError(t, json.Unmarshal(pending[0].Payload, &payload))
+		assert.Equal(t, report.Id, payload.ReportID)
+	})
+
+	t.Run("group report requires group access", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@reports.test").AsMember().Create()
+		group := testDB.NewGroup(t).WithName("Reports Group").Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewGroupData, &group.ID, false, nil)
+		response, err := server.CreateReport(ctx, api.CreateReportRequestObject{
+			Body: &api.CreateReportRequest{Type: "takings", GroupId: &group.ID},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateReport403JSONResponse{}, response)
+	})
+
+	t.Run("invalid report type", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@reports.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		response, err := server.CreateReport(ctx, api.CreateReportRequestObject{
+			Body: &api.CreateReportRequest{Type: "salaries"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateReport400JSONResponse{}, response)
+	})
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+func toRequestCommentResponse(c db.ListRequestCommentsRow) api.RequestComment {
+	resp := api.RequestComment{
+		Id:        c.ID,
+		RequestId: c.RequestID,
+		ParentId:  c.ParentID,
+		AuthorId:  c.AuthorID,
+		Body:      c.Body,
+		CreatedAt: c.CreatedAt.Time,
+	}
+	if c.AuthorEmail.Valid {
+		email := openapi_types.Email(c.AuthorEmail.String)
+		resp.AuthorEmail = &email
+	}
+	return resp
+}
+
+// whether the user may read and join the discussion on a request: they made
+// it, approve requests globally or for the group owning its item, were routed
+// it, or administer the group it was made for.
+func (s Server) mayDiscussRequest(ctx context.Context, userID uuid.UUID, req db.Request) (bool, error) {
+	if req.UserID != nil && *req.UserID == userID {
+		return true, nil
+	}
+
+	globalApprover, err := s.authenticator.CheckPermission(ctx, userID, rbac.ApproveAllRequests, nil)
+	if err != nil || globalApprover {
+		return globalApprover, err
+	}
+
+	if req.GroupID != nil {
+		groupAdmin, err := s.authenticator.CheckPermission(ctx, userID, rbac.ViewGroupData, req.GroupID)
+		if err != nil || groupAdmin {
+			return groupAdmin, err
+		}
+	}
+
+	if req.ItemID == nil {
+		return s.db.Queries().IsRequestRoutedTo(ctx, db.IsRequestRoutedToParams{RequestID: req.ID, ApproverID: userID})
+	}
+	item, err := s.db.Queries().GetItemByIDIncludingBinned(ctx, *req.ItemID)
+	if err != nil {
+		return false, err
+	}
+	return s.mayReviewRequest(ctx, s.db.Queries(), userID, req.ID, item)
+}
+
+func (s Server) ListRequestComments(ctx context.Context, request api.ListRequestCommentsRequestObject) (api.ListRequestCommentsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListRequestComments401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	req, err := s.db.Queries().GetRequestById(ctx, request.RequestId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return api.ListRequestComments404JSONResponse(NotFound("Request").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get request", "request_id", request.RequestId, "error", err)
+		return api.ListRequestComments500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	allowed, err := s.mayDiscussRequest(ctx, user.ID, req)
+	if err != nil {
+		logger.Error("Failed to check access to request comments", "request_id", req.ID, "user_id", user.ID, "error", err)
+		return api.ListRequestComments500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if !allowed {
+		return api.ListRequestComments403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	comments, err := s.db.Queries().ListRequestComments(ctx, req.ID)
+	if err != nil {
+		logger.Error("Failed to list request comments", "request_id", req.ID, "error", err)
+		return api.ListRequestComments500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	resp := make(api.ListRequestComments200JSONResponse, 0, len(comments))
+	for _, c := range comments {
+		resp = append(resp, toRequestCommentResponse(c))
+	}
+	return resp, nil
+}
+
+func (s Server) CreateRequestComment(ctx context.Context, request api.CreateRequestCommentRequestObject) (api.CreateRequestCommentResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateRequestComment401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.CreateRequestComment400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	body := strings.TrimSpace(request.Body.Body)
+	if body == "" {
+		return api.CreateRequestComment400JSONResponse(ValidationErr("Comment body is required", nil).Create()), nil
+	}
+
+	req, err := s.db.Queries().GetRequestById(ctx, request.RequestId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return api.CreateRequestComment404JSONResponse(NotFound("Request").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get request", "request_id", request.RequestId, "error", err)
+		return api.CreateRequestComment500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	allowed, err := s.mayDiscussRequest(ctx, user.ID, req)
+	if err != nil {
+		logger.Error("Failed to check access to request comments", "request_id", req.ID, "user_id", user.ID, "error", err)
+		return api.CreateRequestComment500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if !allowed {
+		return api.CreateRequestComment403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body.ParentId != nil {
+		parent, err := s.db.Queries().GetRequestComment(ctx, *request.Body.ParentId)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && parent.RequestID != req.ID) {
+			return api.CreateRequestComment400JSONResponse(ValidationErr("parent_id must be a comment on this request", nil).Create()), nil
+		}
+		if err != nil {
+			logger.Error("Failed to get parent comment", "comment_id", *request.Body.ParentId, "error", err)
+			return api.CreateRequestComment500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+	}
+
+	comment, err := s.db.Queries().CreateRequestComment(ctx, db.CreateRequestCommentParams{
+		RequestID: req.ID,
+		ParentID:  request.Body.ParentId,
+		AuthorID:  &user.ID,
+		Body:      body,
+	})
+	if err != nil {
+		logger.Error("Failed to create request comment", "request_id", req.ID, "user_id", user.ID, "error", err)
+		return api.CreateRequestComment500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	s.notifyRequestComment(ctx, user, req, comment)
+
+	logger.Info("Request comment added", "comment_id", comment.ID, "request_id", req.ID, "user_id", user.ID)
+
+	email := openapi_types.Email(user.Email)
+	return api.CreateRequestComment201JSONResponse{
+		Id:          comment.ID,
+		RequestId:   comment.RequestID,
+		ParentId:    comment.ParentID,
+		AuthorId:    comment.AuthorID,
+		AuthorEmail: &email,
+		Body:        comment.Body,
+		CreatedAt:   comment.CreatedAt.Time,
+	}, nil
+}
+
+// emails everyone in the discussion but its author about a new comment: the
+// requester, the approvers the request was routed to, its reviewer and earlier
+// commenters.
+func (s Server) notifyRequestComment(ctx context.Context, author *auth.AuthenticatedUser, req db.Request, comment db.RequestComment) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	seen := map[uuid.UUID]bool{author.ID: true}
+	var recipients []uuid.UUID
+	add := func(id *uuid.UUID) {
+		if id != nil && !seen[*id] {
+			seen[*id] = true
+			recipients = append(recipients, *id)
+		}
+	}
+
+	add(req.UserID)
+	routes, err := s.db.Queries().ListRequestRoutes(ctx, req.ID)
+	if err != nil {
+		logger.Error("Failed to list request routes for comment", "request_id", req.ID, "error", err)
+		return
+	}
+	for _, r := range routes {
+		add(&r.ApproverID)
+	}
+	add(req.ReviewedBy)
+	commenters, err := s.db.Queries().ListRequestCommenterIDs(ctx, req.ID)
+	if err != nil {
+		logger.Error("Failed to list request commenters", "request_id", req.ID, "error", err)
+		return
+	}
+	for _, id := range commenters {
+		add(id)
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	itemName := "an item"
+	facts := notifications.RoutingFacts{GroupID: req.GroupID}
+	if req.ItemID != nil {
+		item, err := s.db.Queries().GetItemByIDIncludingBinned(ctx, *req.ItemID)
+		if err != nil {
+			logger.Error("Failed to get item for request comment", "request_id", req.ID, "error", err)
+			return
+		}
+		itemName = item.Name
+		facts.ItemID = &item.ID
+		facts.ItemType = item.Type
+	}
+
+	ctx = s.sandboxContext(ctx, req.GroupID)
+	if err := s.dispatcher.Notify(ctx, author.ID, "request", req.ID, []notifications.NotifierGroup{
+		{
+			IDs:      recipients,
+			Template: "request_comment_added",
+			TemplateData: map[string]interface{}{
+				"AuthorEmail": author.Email,
+				"ItemName":    itemName,
+				"Body":        comment.Body,
+				"RequestID":   req.ID,
+			},
+			Facts: facts,
+		},
+	}); err != nil {
+		logger.Error("Failed to send request comment notifications", "request_id", req.ID, "comment_id", comment.ID, "error", err)
+	}
+}
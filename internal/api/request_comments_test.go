@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RequestComments(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	ctx := context.Background()
+
+	group := testDB.NewGroup(t).WithName("Film Society").Create()
+	requester := testDB.NewUser(t).WithEmail("requester@comments.test").AsMember().Create()
+	testDB.AssignUserToGroup(t, requester.ID, group.ID, "member")
+	groupApprover := testDB.NewUser(t).WithEmail("treasurer@comments.test").AsMember().Create()
+	testDB.AssignUserToGroup(t, groupApprover.ID, group.ID, "group_approver")
+	outsider := testDB.NewUser(t).WithEmail("outsider@comments.test").AsMember().Create()
+
+	item := testDB.NewItem(t).WithName("Society Camera").WithType("high").WithStock(2).Create()
+	require.NoError(t, testDB.Queries().SetItemOwner(ctx, db.SetItemOwnerParams{ID: item.ID, OwnerGroupID: &group.ID}))
+
+	requesterCtx := testutil.ContextWithUser(ctx, requester, testDB.Queries())
+	groupApproverCtx := testutil.ContextWithUser(ctx, groupApprover, testDB.Queries())
+	outsiderCtx := testutil.ContextWithUser(ctx, outsider, testDB.Queries())
+
+	mockAuth.ExpectCheckPermission(requester.ID, rbac.RequestItems, &group.ID, true, nil)
+	created, err := server.RequestItem(requesterCtx, api.RequestItemRequestObject{
+		Body: &api.RequestItemJSONRequestBody{UserId: requester.ID, GroupId: group.ID, ItemId: item.ID, Quantity: 1},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.RequestItem201JSONResponse{}, created)
+	requestID := created.(api.RequestItem201JSONResponse).Id
+
+	notificationsOf := func(t *testing.T, userID uuid.UUID) int {
+		t.Helper()
+		notifs, err := testDB.Queries().GetUserNotifications(ctx, db.GetUserNotificationsParams{NotifierID: userID, Limit: 50})
+		require.NoError(t, err)
+		return len(notifs)
+	}
+
+	var question api.RequestComment
+
+	t.Run("an approver asks the requester a question", func(t *testing.T) {
+		before := notificationsOf(t, requester.ID)
+
+		mockAuth.ExpectCheckPermission(groupApprover.ID, rbac.ApproveAllRequests, nil, false, nil)
+		mockAuth.ExpectCheckPermission(groupApprover.ID, rbac.ViewGroupData, &group.ID, false, nil)
+		resp, err := server.CreateRequestComment(groupApproverCtx, api.CreateRequestCommentRequestObject{
+			RequestId: requestID,
+			Body:      &api.CreateRequestCommentJSONRequestBody{Body: "  What is this for?  "},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateRequestComment201JSONResponse{}, resp)
+		question = api.RequestComment(resp.(api.CreateRequestComment201JSONResponse))
+		assert.Equal(t, "What is this for?", question.Body)
+		assert.Nil(t, question.ParentId)
+
+		assert.Equal(t, before+1, notificationsOf(t, requester.ID), "the requester hears about it")
+	})
+
+	t.Run("the requester replies", func(t *testing.T) {
+		before := notificationsOf(t, groupApprover.ID)
+
+		resp, err := server.CreateRequestComment(requesterCtx, api.CreateRequestCommentRequestObject{
+			RequestId: requestID,
+			Body:      &api.CreateRequestCommentJSONRequestBody{Body: "Filming the spring showcase", ParentId: &question.Id},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateRequestComment201JSONResponse{}, resp)
+		reply := resp.(api.CreateRequestComment201JSONResponse)
+		require.NotNil(t, reply.ParentId)
+		assert.Equal(t, question.Id, *reply.ParentId)
+
+		assert.Equal(t, before+1, notificationsOf(t, groupApprover.ID), "the approver hears about it")
+
+		listed, err := server.ListRequestComments(requesterCtx, api.ListRequestCommentsRequestObject{RequestId: requestID})
+		require.NoError(t, err)
+		require.IsType(t, api.ListRequestComments200JSONResponse{}, listed)
+		comments := listed.(api.ListRequestComments200JSONResponse)
+		require.Len(t, comments, 2)
+		assert.Equal(t, question.Id, comments[0].Id)
+		require.NotNil(t, comments[0].AuthorEmail)
+		assert.Equal(t, "treasurer@comments.test", string(*comments[0].AuthorEmail))
+		assert.Equal(t, reply.Id, comments[1].Id)
+	})
+
+	t.Run("comments are validated", func(t *testing.T) {
+		create := func(body api.CreateRequestCommentRequest) api.CreateRequestCommentResponseObject {
+			resp, err := server.CreateRequestComment(requesterCtx, api.CreateRequestCommentRequestObject{RequestId: requestID, Body: &body})
+			require.NoError(t, err)
+			return resp
+		}
+		missing := uuid.New()
+
+		assert.IsType(t, api.CreateRequestComment400JSONResponse{}, create(api.CreateRequestCommentRequest{Body: "   "}))
+		assert.IsType(t, api.CreateRequestComment400JSONResponse{}, create(api.CreateRequestCommentRequest{Body: "Hello", ParentId: &missing}))
+
+		resp, err := server.CreateRequestComment(requesterCtx, api.CreateRequestCommentRequestObject{
+			RequestId: uuid.New(),
+			Body:      &api.CreateRequestCommentJSONRequestBody{Body: "Hello"},
+		})
+		require.NoError(t, err)
+		assert.IsType(t, api.CreateRequestComment404JSONResponse{}, resp)
+	})
+
+	t.Run("others cannot read or join the discussion", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(outsider.ID, rbac.ApproveAllRequests, nil, false, nil)
+		mockAuth.ExpectCheckPermission(outsider.ID, rbac.ViewGroupData, &group.ID, false, nil)
+		mockAuth.ExpectCheckPermission(outsider.ID, rbac.ApproveGroupRequests, &group.ID, false, nil)
+		listed, err := server.ListRequestComments(outsiderCtx, api.ListRequestCommentsRequestObject{RequestId: requestID})
+		require.NoError(t, err)
+		assert.IsType(t, api.ListRequestComments403JSONResponse{}, listed)
+	})
+
+	t.Run("group admins can read the discussion", func(t *testing.T) {
+		admin := testDB.NewUser(t).WithEmail("admin@comments.test").AsMember().Create()
+		testDB.AssignUserToGroup(t, admin.ID, group.ID, "group_admin")
+		adminCtx := testutil.ContextWithUser(ctx, admin, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ApproveAllRequests, nil, false, nil)
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewGroupData, &group.ID, true, nil)
+		listed, err := server.ListRequestComments(adminCtx, api.ListRequestCommentsRequestObject{RequestId: requestID})
+		require.NoError(t, err)
+		require.IsType(t, api.ListRequestComments200JSONResponse{}, listed)
+		assert.Len(t, listed.(api.ListRequestComments200JSONResponse), 2)
+	})
+}
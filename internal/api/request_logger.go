@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// ScopeLogger is strict middleware that adds the route, the operation, the
+// authenticated user and the group the request is scoped to to the
+// request's logger, so every line logged while handling it, and the
+// request's completion line, can be tied to them. It must come last in the
+// list, so it runs before Authorize and the others log anything.
+func ScopeLogger(next api.StrictHandlerFunc, operationID string) api.StrictHandlerFunc {
+	policy := policies[operationID]
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+		attrs := []any{"operation", operationID}
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			attrs = append(attrs, "route", rctx.RoutePattern())
+		}
+		if user, ok := auth.GetAuthenticatedUser(ctx); ok {
+			attrs = append(attrs, "user_id", user.ID)
+		}
+		if groupID := requestGroup(r, policy, request); groupID != nil {
+			attrs = append(attrs, "group_id", *groupID)
+		}
+		logging.AddAttrs(ctx, attrs...)
+		return next(ctx, w, r, request)
+	}
+}
+
+// the group a policy checks the permission in, or else the one in the path
+func requestGroup(r *http.Request, policy Policy, request any) *uuid.UUID {
+	if policy.Scope != nil {
+		if groupID := policy.Scope(request); groupID != nil && *groupID != uuid.Nil {
+			return groupID
+		}
+	}
+	if groupID, err := uuid.Parse(chi.URLParam(r, "groupId")); err == nil {
+		return &groupID
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeLogger(t *testing.T) {
+	userID := uuid.New()
+	groupID := uuid.New()
+
+	// serves operationID on pattern through the middleware, returning what
+	// the request's logger wrote after the handler ran
+	run := func(t *testing.T, pattern, path, operationID string, request func(r *http.Request) any) string {
+		var buf bytes.Buffer
+		router := chi.NewRouter()
+		router.Get(pattern, func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), auth.UserClaimsKey, &auth.AuthenticatedUser{ID: userID})
+			ctx = logging.NewContext(ctx, slog.New(slog.NewTextHandler(&buf, nil)))
+			next := func(ctx context.Context, w http.ResponseWriter, r *http.Request, request interface{}) (interface{}, error) {
+				return nil, nil
+			}
+			_, err := ScopeLogger(next, operationID)(ctx, w, r, request(r))
+			require.NoError(t, err)
+			logging.FromContext(ctx).Info("done")
+		})
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		return buf.String()
+	}
+
+	t.Run("the policy's group", func(t *testing.T) {
+		line := run(t, "/cart/{groupId}", "/cart/"+groupID.String(), "ClearCart", func(r *http.Request) any {
+			return api.ClearCartRequestObject{GroupId: groupID}
+		})
+		assert.Contains(t, line, "operation=ClearCart route=/cart/{groupId}")
+		assert.Contains(t, line, "user_id="+userID.String())
+		assert.Contains(t, line, "group_id="+groupID.String())
+	})
+
+	t.Run("no group", func(t *testing.T) {
+		line := run(t, "/health", "/health", "HealthCheck", func(r *http.Request) any {
+			return api.HealthCheckRequestObject{}
+		})
+		assert.Contains(t, line, "route=/health")
+		assert.NotContains(t, line, "group_id")
+	})
+}
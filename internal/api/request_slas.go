@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/sla"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s Server) GetGroupRequestSLA(ctx context.Context, request api.GetGroupRequestSLARequestObject) (api.GetGroupRequestSLAResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetGroupRequestSLA401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewGroupData, nil)
+	if err != nil {
+		logger.Error("Error checking view_group_data permission",
+			"user_id", user.ID,
+			"permission", rbac.ViewGroupData,
+			"error", err)
+		return api.GetGroupRequestSLA500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetGroupRequestSLA403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	row, err := s.db.Queries().GetGroupRequestSLA(ctx, request.Id)
+	if err == pgx.ErrNoRows {
+		return api.GetGroupRequestSLA404JSONResponse(NotFound("Request SLA").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get request SLA", "group_id", request.Id, "error", err)
+		return api.GetGroupRequestSLA500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	return api.GetGroupRequestSLA200JSONResponse(toRequestSLAResponse(row)), nil
+}
+
+func (s Server) SetGroupRequestSLA(ctx context.Context, request api.SetGroupRequestSLARequestObject) (api.SetGroupRequestSLAResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.SetGroupRequestSLA401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageGroups, nil)
+	if err != nil {
+		logger.Error("Error checking manage_groups permission", "error", err)
+		return api.SetGroupRequestSLA500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.SetGroupRequestSLA403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.SetGroupRequestSLA400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	if request.Body.ReviewHours < 1 {
+		return api.SetGroupRequestSLA400JSONResponse(ValidationErr("review_hours must be at least 1", nil).Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetGroupByID(ctx, request.Id); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.SetGroupRequestSLA404JSONResponse(NotFound("Group").Create()), nil
+		}
+		logger.Error("Failed to get group", "group_id", request.Id, "error", err)
+		return api.SetGroupRequestSLA500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	row, err := s.db.Queries().UpsertGroupRequestSLA(ctx, db.UpsertGroupRequestSLAParams{
+		GroupID:     request.Id,
+		ReviewHours: int32(request.Body.ReviewHours),
+		UpdatedBy:   &user.ID,
+	})
+	if err != nil {
+		logger.Error("Failed to set request SLA", "group_id", request.Id, "error", err)
+		return api.SetGroupRequestSLA500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Request SLA set",
+		"group_id", row.GroupID,
+		"review_hours", row.ReviewHours,
+		"user_id", user.ID)
+	return api.SetGroupRequestSLA200JSONResponse(toRequestSLAResponse(row)), nil
+}
+
+func (s Server) RemoveGroupRequestSLA(ctx context.Context, request api.RemoveGroupRequestSLARequestObject) (api.RemoveGroupRequestSLAResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RemoveGroupRequestSLA401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageGroups, nil)
+	if err != nil {
+		logger.Error("Error checking manage_groups permission", "error", err)
+		return api.RemoveGroupRequestSLA500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.RemoveGroupRequestSLA403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	deleted, err := s.db.Queries().DeleteGroupRequestSLA(ctx, request.Id)
+	if err != nil {
+		logger.Error("Failed to remove request SLA", "group_id", request.Id, "error", err)
+		return api.RemoveGroupRequestSLA500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if deleted == 0 {
+		return api.RemoveGroupRequestSLA404JSONResponse(NotFound("Request SLA").Create()), nil
+	}
+
+	logger.Info("Request SLA removed", "group_id", request.Id, "user_id", user.ID)
+	return api.RemoveGroupRequestSLA204Response{}, nil
+}
+
+func (s Server) GetRequestSLACompliance(ctx context.Context, request api.GetRequestSLAComplianceRequestObject) (api.GetRequestSLAComplianceResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetRequestSLACompliance401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewAllData, nil)
+	if err != nil {
+		logger.Error("Error checking view_all_data permission", "error", err)
+		return api.GetRequestSLACompliance500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetRequestSLACompliance403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	var fromDate, toDate pgtype.Date
+	if request.Params.FromDate != nil {
+		fromDate = pgtype.Date{Time: request.Params.FromDate.Time, Valid: true}
+	}
+	if request.Params.ToDate != nil {
+		toDate = pgtype.Date{Time: request.Params.ToDate.Time, Valid: true}
+	}
+	if fromDate.Valid && toDate.Valid && toDate.Time.Before(fromDate.Time) {
+		return api.GetRequestSLACompliance400JSONResponse(ValidationErr("to_date cannot be before from_date", nil).Create()), nil
+	}
+
+	rows, err := s.db.Queries().GetRequestSLACompliance(ctx, db.GetRequestSLAComplianceParams{
+		FromDate: fromDate,
+		ToDate:   toDate,
+		GroupID:  request.Params.GroupId,
+	})
+	if err != nil {
+		logger.Error("Failed to get request SLA compliance", "error", err)
+		return api.GetRequestSLACompliance500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := make(api.GetRequestSLACompliance200JSONResponse, 0, len(rows))
+	for _, row := range rows {
+		// only requests whose deadline has been settled count towards the rate
+		rate := 1.0
+		if settled := row.WithinSla + row.Breached; settled > 0 {
+			rate = float64(row.WithinSla) / float64(settled)
+		}
+		response = append(response, api.RequestSLACompliance{
+			GroupId:        row.GroupID,
+			GroupName:      row.GroupName,
+			ReviewHours:    int(row.ReviewHours),
+			Total:          int(row.Total),
+			WithinSla:      int(row.WithinSla),
+			Breached:       int(row.Breached),
+			Pending:        int(row.Pending),
+			ComplianceRate: rate,
+			AvgReviewHours: row.AvgReviewHours,
+		})
+	}
+	return response, nil
+}
+
+// review deadline of each request in a group with an SLA, keyed by request ID.
+func (s Server) requestSLAStatus(ctx context.Context, requests []db.Request) (map[uuid.UUID]api.RequestSLAStatus, error) {
+	slas, err := s.db.Queries().ListGroupRequestSLAs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reviewHours := make(map[uuid.UUID]int, len(slas))
+	for _, row := range slas {
+		reviewHours[row.GroupID] = int(row.ReviewHours)
+	}
+
+	result := make(map[uuid.UUID]api.RequestSLAStatus)
+	now := time.Now()
+	for _, req := range requests {
+		if req.GroupID == nil {
+			continue
+		}
+		hours, ok := reviewHours[*req.GroupID]
+		if !ok {
+			continue
+		}
+		var reviewedAt *time.Time
+		if req.ReviewedAt.Valid {
+			reviewedAt = &req.ReviewedAt.Time
+		}
+		deadline := sla.Deadline(req.RequestedAt.Time, hours)
+		result[req.ID] = api.RequestSLAStatus{
+			ReviewHours: hours,
+			DueAt:       deadline,
+			Breached:    sla.Breached(deadline, reviewedAt, now),
+		}
+	}
+	return result, nil
+}
+
+func toRequestSLAResponse(row db.GroupRequestSla) api.RequestSLA {
+	return api.RequestSLA{
+		GroupId:     row.GroupID,
+		ReviewHours: int(row.ReviewHours),
+		UpdatedBy:   row.UpdatedBy,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}
+}
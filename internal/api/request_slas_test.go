@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_GroupRequestSLA(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@sla.test").AsGlobalAdmin().Create()
+	group := testDB.NewGroup(t).WithName("Robotics Club").Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	t.Run("no SLA", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewGroupData, nil, true, nil)
+		response, err := server.GetGroupRequestSLA(ctx, api.GetGroupRequestSLARequestObject{Id: group.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetGroupRequestSLA404JSONResponse{}, response)
+	})
+
+	t.Run("set and replace", func(t *testing.T) {
+		for _, hours := range []int{48, 24} {
+			mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+			response, err := server.SetGroupRequestSLA(ctx, api.SetGroupRequestSLARequestObject{
+				Id:   group.ID,
+				Body: &api.SetRequestSLARequest{ReviewHours: hours},
+			})
+			require.NoError(t, err)
+			require.IsType(t, api.SetGroupRequestSLA200JSONResponse{}, response)
+		}
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewGroupData, nil, true, nil)
+		response, err := server.GetGroupRequestSLA(ctx, api.GetGroupRequestSLARequestObject{Id: group.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetGroupRequestSLA200JSONResponse{}, response)
+		got := response.(api.GetGroupRequestSLA200JSONResponse)
+		assert.Equal(t, 24, got.ReviewHours)
+		assert.Equal(t, &admin.ID, got.UpdatedBy)
+	})
+
+	t.Run("rejects zero hours", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+		response, err := server.SetGroupRequestSLA(ctx, api.SetGroupRequestSLARequestObject{
+			Id:   group.ID,
+			Body: &api.SetRequestSLARequest{ReviewHours: 0},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetGroupRequestSLA400JSONResponse{}, response)
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+		response, err := server.SetGroupRequestSLA(ctx, api.SetGroupRequestSLARequestObject{
+			Id:   uuid.New(),
+			Body: &api.SetRequestSLARequest{ReviewHours: 24},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetGroupRequestSLA404JSONResponse{}, response)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+		response, err := server.RemoveGroupRequestSLA(ctx, api.RemoveGroupRequestSLARequestObject{Id: group.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.RemoveGroupRequestSLA204Response{}, response)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+		response, err = server.RemoveGroupRequestSLA(ctx, api.RemoveGroupRequestSLARequestObject{Id: group.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.RemoveGroupRequestSLA404JSONResponse{}, response)
+	})
+}
+
+func TestServer_RequestSLATracking(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	ctx := context.Background()
+	testDB.CleanupDatabase(t)
+
+	approver := testDB.NewUser(t).WithEmail("approver@sla.test").AsApprover().Create()
+	member := testDB.NewUser(t).WithEmail("member@sla.test").AsMember().Create()
+	tracked := testDB.NewGroup(t).WithName("Tracked Club").Create()
+	untracked := testDB.NewGroup(t).WithName("Untracked Club").Create()
+	item := testDB.NewItem(t).WithName("Oscilloscope").WithType("high").WithStock(5).Create()
+
+	_, err := testDB.Queries().UpsertGroupRequestSLA(ctx, db.UpsertGroupRequestSLAParams{
+		GroupID: tracked.ID, ReviewHours: 24, UpdatedBy: &approver.ID,
+	})
+	require.NoError(t, err)
+
+	lateReq, err := testDB.Queries().RequestItem(ctx, db.RequestItemParams{
+		UserID: &member.ID, GroupID: &tracked.ID, ID: item.ID, Quantity: 1,
+	})
+	require.NoError(t, err)
+	freshReq, err := testDB.Queries().RequestItem(ctx, db.RequestItemParams{
+		UserID: &member.ID, GroupID: &tracked.ID, ID: item.ID, Quantity: 1,
+	})
+	require.NoError(t, err)
+	untrackedReq, err := testDB.Queries().RequestItem(ctx, db.RequestItemParams{
+		UserID: &member.ID, GroupID: &untracked.ID, ID: item.ID, Quantity: 1,
+	})
+	require.NoError(t, err)
+
+	_, err = testDB.Pool().Exec(ctx, `UPDATE requests SET requested_at = NOW() - INTERVAL '30 hours' WHERE id = $1`, lateReq.ID)
+	require.NoError(t, err)
+
+	approverCtx := testutil.ContextWithUser(ctx, approver, testDB.Queries())
+
+	t.Run("pending requests carry their deadline", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ApproveAllRequests, nil, true, nil)
+		response, err := server.GetPendingRequests(approverCtx, api.GetPendingRequestsRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetPendingRequests200JSONResponse{}, response)
+
+		byID := make(map[uuid.UUID]api.RequestItemResponse)
+		for _, req := range response.(api.GetPendingRequests200JSONResponse).Body.Data {
+			byID[req.Id] = req
+		}
+		require.NotNil(t, byID[lateReq.ID].Sla)
+		assert.True(t, byID[lateReq.ID].Sla.Breached)
+		require.NotNil(t, byID[freshReq.ID].Sla)
+		assert.False(t, byID[freshReq.ID].Sla.Breached)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), byID[freshReq.ID].Sla.DueAt, time.Minute)
+		assert.Nil(t, byID[untrackedReq.ID].Sla)
+	})
+
+	t.Run("compliance", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ViewAllData, nil, true, nil)
+		response, err := server.GetRequestSLACompliance(approverCtx, api.GetRequestSLAComplianceRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.GetRequestSLACompliance200JSONResponse{}, response)
+
+		rows := response.(api.GetRequestSLACompliance200JSONResponse)
+		require.Len(t, rows, 1, "groups without an SLA are not reported")
+		assert.Equal(t, tracked.ID, rows[0].GroupId)
+		assert.Equal(t, 2, rows[0].Total)
+		assert.Equal(t, 2, rows[0].Pending)
+		assert.Equal(t, 1, rows[0].Breached)
+		assert.Equal(t, 0, rows[0].WithinSla)
+		assert.Equal(t, 0.0, rows[0].ComplianceRate)
+	})
+
+	t.Run("compliance rejects an inverted window", func(t *testing.T) {
+		from := time.Now()
+		to := from.AddDate(0, 0, -1)
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ViewAllData, nil, true, nil)
+		response, err := server.GetRequestSLACompliance(approverCtx, api.GetRequestSLAComplianceRequestObject{
+			Params: api.GetRequestSLAComplianceParams{
+				FromDate: &openapi_types.Date{Time: from},
+				ToDate:   &openapi_types.Date{Time: to},
+			},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetRequestSLACompliance400JSONResponse{}, response)
+	})
+}
@@ -0,0 +1,496 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func toRoleResponse(role db.Role, permissions []string) api.Role {
+	resp := api.Role{Name: role.Name, Permissions: permissions}
+	if resp.Permissions == nil {
+		resp.Permissions = []string{}
+	}
+	if role.Description.Valid {
+		resp.Description = &role.Description.String
+	}
+	return resp
+}
+
+// sorted and without repeats
+func uniquePermissions(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// whether every one of the (unique) names is a permission that exists.
+func (s Server) knownPermissions(ctx context.Context, names []string) (bool, error) {
+	if len(names) == 0 {
+		return true, nil
+	}
+	count, err := s.db.Queries().CountPermissionsByName(ctx, names)
+	if err != nil {
+		return false, err
+	}
+	return count == int64(len(names)), nil
+}
+
+func (s Server) userRoleAssignments(ctx context.Context, userID uuid.UUID) ([]api.UserRoleAssignment, error) {
+	roles, err := s.db.Queries().GetUserRoles(ctx, &userID)
+	if err != nil {
+		return nil, err
+	}
+	assignments := make([]api.UserRoleAssignment, 0, len(roles))
+	for _, role := range roles {
+		assignments = append(assignments, api.UserRoleAssignment{
+			RoleName: role.RoleName.String,
+			Scope:    api.RoleScope(role.Scope),
+			ScopeId:  role.ScopeID,
+		})
+	}
+	return assignments, nil
+}
+
+func (s Server) ListPermissions(ctx context.Context, request api.ListPermissionsRequestObject) (api.ListPermissionsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	permissions, err := s.db.Queries().ListPermissions(ctx)
+	if err != nil {
+		logger.Error("Failed to list permissions", "error", err)
+		return api.ListPermissions500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := make(api.ListPermissions200JSONResponse, 0, len(permissions))
+	for _, permission := range permissions {
+		p := api.Permission{Name: permission.Name}
+		if permission.Description.Valid {
+			p.Description = &permission.Description.String
+		}
+		response = append(response, p)
+	}
+	return response, nil
+}
+
+func (s Server) ListRoles(ctx context.Context, request api.ListRolesRequestObject) (api.ListRolesResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	roles, err := s.db.Queries().ListRoles(ctx)
+	if err != nil {
+		logger.Error("Failed to list roles", "error", err)
+		return api.ListRoles500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	grants, err := s.db.Queries().ListRolePermissions(ctx)
+	if err != nil {
+		logger.Error("Failed to list role permissions", "error", err)
+		return api.ListRoles500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	byRole := make(map[string][]string)
+	for _, grant := range grants {
+		byRole[grant.RoleName] = append(byRole[grant.RoleName], grant.PermissionName)
+	}
+
+	response := make(api.ListRoles200JSONResponse, 0, len(roles))
+	for _, role := range roles {
+		response = append(response, toRoleResponse(role, byRole[role.Name]))
+	}
+	return response, nil
+}
+
+func (s Server) CreateRole(ctx context.Context, request api.CreateRoleRequestObject) (api.CreateRoleResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateRole401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.CreateRole400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	if len(request.Body.Name) > 255 || !roleNamePattern.MatchString(request.Body.Name) {
+		return api.CreateRole400JSONResponse(ValidationErr("Role names are lowercase letters, digits and underscores, starting with a letter", nil).Create()), nil
+	}
+
+	var permissions []string
+	if request.Body.Permissions != nil {
+		permissions = uniquePermissions(*request.Body.Permissions)
+	}
+	known, err := s.knownPermissions(ctx, permissions)
+	if err != nil {
+		logger.Error("Failed to check permissions", "error", err)
+		return api.CreateRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if !known {
+		return api.CreateRole400JSONResponse(ValidationErr("Unknown permission", nil).Create()), nil
+	}
+
+	role := db.Role{Name: request.Body.Name}
+	if request.Body.Description != nil && *request.Body.Description != "" {
+		role.Description = pgtype.Text{String: *request.Body.Description, Valid: true}
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to start transaction", "error", err)
+		return api.CreateRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.db.Queries().WithTx(tx)
+
+	if err := qtx.CreateRole(ctx, db.CreateRoleParams{Name: role.Name, Description: role.Description}); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return api.CreateRole409JSONResponse(ConflictErr("A role with this name already exists").Create()), nil
+		}
+		logger.Error("Failed to create role", "role", role.Name, "error", err)
+		return api.CreateRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if err := qtx.AddRolePermissions(ctx, db.AddRolePermissionsParams{RoleName: role.Name, PermissionNames: permissions}); err != nil {
+		logger.Error("Failed to add role permissions", "role", role.Name, "error", err)
+		return api.CreateRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if err := qtx.CreateRoleChange(ctx, db.CreateRoleChangeParams{
+		Action:      db.RoleChangeActionRoleCreated,
+		RoleName:    role.Name,
+		Permissions: permissions,
+		ChangedBy:   &user.ID,
+	}); err != nil {
+		logger.Error("Failed to record role change", "role", role.Name, "error", err)
+		return api.CreateRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit role", "role", role.Name, "error", err)
+		return api.CreateRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Role created", "role", role.Name, "permissions", permissions, "user_id", user.ID)
+	return api.CreateRole201JSONResponse(toRoleResponse(role, permissions)), nil
+}
+
+func (s Server) SetRolePermissions(ctx context.Context, request api.SetRolePermissionsRequestObject) (api.SetRolePermissionsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.SetRolePermissions401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.SetRolePermissions400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	// taking anything from global_admin could leave nobody able to give it back
+	if request.RoleName == rbac.RoleGlobalAdmin {
+		return api.SetRolePermissions400JSONResponse(ValidationErr("The global_admin role always holds every permission", nil).Create()), nil
+	}
+
+	role, err := s.db.Queries().GetRole(ctx, request.RoleName)
+	if err == pgx.ErrNoRows {
+		return api.SetRolePermissions404JSONResponse(NotFound("Role").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get role", "role", request.RoleName, "error", err)
+		return api.SetRolePermissions500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	permissions := uniquePermissions(request.Body.Permissions)
+	known, err := s.knownPermissions(ctx, permissions)
+	if err != nil {
+		logger.Error("Failed to check permissions", "error", err)
+		return api.SetRolePermissions500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if !known {
+		return api.SetRolePermissions400JSONResponse(ValidationErr("Unknown permission", nil).Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to start transaction", "error", err)
+		return api.SetRolePermissions500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.db.Queries().WithTx(tx)
+
+	// as in AssignUserRole, group-scoped roles can't hold global-only permissions
+	if globalOnly := rbac.GlobalOnly(permissions); len(globalOnly) > 0 {
+		held, err := qtx.CountGroupRoleHolders(ctx, pgtype.Text{String: role.Name, Valid: true})
+		if err != nil {
+			logger.Error("Failed to count group role holders", "role", role.Name, "error", err)
+			return api.SetRolePermissions500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		if held > 0 {
+			return api.SetRolePermissions400JSONResponse(ValidationErr("The role is held within groups, so it cannot have "+strings.Join(globalOnly, ", "), nil).Create()), nil
+		}
+	}
+
+	if err := qtx.ClearRolePermissions(ctx, role.Name); err != nil {
+		logger.Error("Failed to clear role permissions", "role", role.Name, "error", err)
+		return api.SetRolePermissions500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if err := qtx.AddRolePermissions(ctx, db.AddRolePermissionsParams{RoleName: role.Name, PermissionNames: permissions}); err != nil {
+		logger.Error("Failed to add role permissions", "role", role.Name, "error", err)
+		return api.SetRolePermissions500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if err := qtx.CreateRoleChange(ctx, db.CreateRoleChangeParams{
+		Action:      db.RoleChangeActionPermissionsSet,
+		RoleName:    role.Name,
+		Permissions: permissions,
+		ChangedBy:   &user.ID,
+	}); err != nil {
+		logger.Error("Failed to record role change", "role", role.Name, "error", err)
+		return api.SetRolePermissions500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit role permissions", "role", role.Name, "error", err)
+		return api.SetRolePermissions500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	s.authenticator.InvalidateAllPermissions(ctx)
+
+	logger.Info("Role permissions set", "role", role.Name, "permissions", permissions, "user_id", user.ID)
+	return api.SetRolePermissions200JSONResponse(toRoleResponse(role, permissions)), nil
+}
+
+func (s Server) AssignUserRole(ctx context.Context, request api.AssignUserRoleRequestObject) (api.AssignUserRoleResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.AssignUserRole401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.AssignUserRole400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	body := request.Body
+	switch body.Scope {
+	case api.RoleScopeGlobal:
+		if body.ScopeId != nil {
+			return api.AssignUserRole400JSONResponse(ValidationErr("Scope ID must be empty for global scope", nil).Create()), nil
+		}
+	case api.RoleScopeGroup:
+		if body.ScopeId == nil {
+			return api.AssignUserRole400JSONResponse(ValidationErr("Scope ID must be provided for group scope", nil).Create()), nil
+		}
+		if body.RoleName == rbac.RoleGlobalAdmin {
+			return api.AssignUserRole400JSONResponse(ValidationErr("global_admin can only be granted globally", nil).Create()), nil
+		}
+	default:
+		return api.AssignUserRole400JSONResponse(ValidationErr("scope must be 'global' or 'group'", nil).Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetUserByID(ctx, request.UserId); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.AssignUserRole404JSONResponse(NotFound("User").Create()), nil
+		}
+		logger.Error("Failed to get user", "user_id", request.UserId, "error", err)
+		return api.AssignUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if _, err := s.db.Queries().GetRole(ctx, body.RoleName); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.AssignUserRole404JSONResponse(NotFound("Role").Create()), nil
+		}
+		logger.Error("Failed to get role", "role", body.RoleName, "error", err)
+		return api.AssignUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	// a permission check made without a group also counts roles held within
+	// one, so a group-scoped role holding these would grant them everywhere,
+	// and a group-scoped global_admin would escape the last-admin guard in
+	// RevokeUserRole
+	if body.Scope == api.RoleScopeGroup {
+		permissions, err := s.db.Queries().ListPermissionsForRole(ctx, body.RoleName)
+		if err != nil {
+			logger.Error("Failed to get role permissions", "role", body.RoleName, "error", err)
+			return api.AssignUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		if globalOnly := rbac.GlobalOnly(permissions); len(globalOnly) > 0 {
+			return api.AssignUserRole400JSONResponse(ValidationErr("Roles holding "+strings.Join(globalOnly, ", ")+" can only be granted globally", nil).Create()), nil
+		}
+	}
+	if body.ScopeId != nil {
+		if _, err := s.db.Queries().GetGroupByID(ctx, *body.ScopeId); err != nil {
+			if err == pgx.ErrNoRows {
+				return api.AssignUserRole404JSONResponse(NotFound("Group").Create()), nil
+			}
+			logger.Error("Failed to get group", "group_id", *body.ScopeId, "error", err)
+			return api.AssignUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+	}
+
+	// the unique constraint treats every NULL scope_id as distinct, so it
+	// would let a global role be granted twice
+	current, err := s.userRoleAssignments(ctx, request.UserId)
+	if err != nil {
+		logger.Error("Failed to get user roles", "user_id", request.UserId, "error", err)
+		return api.AssignUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	for _, held := range current {
+		if held.RoleName == body.RoleName && held.Scope == body.Scope && sameScopeID(held.ScopeId, body.ScopeId) {
+			return api.AssignUserRole409JSONResponse(ConflictErr("User already has this role").Create()), nil
+		}
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to start transaction", "error", err)
+		return api.AssignUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.db.Queries().WithTx(tx)
+
+	scope := db.ScopeType(body.Scope)
+	if err := qtx.CreateUserRole(ctx, db.CreateUserRoleParams{
+		UserID:   &request.UserId,
+		RoleName: pgtype.Text{String: body.RoleName, Valid: true},
+		Scope:    scope,
+		ScopeID:  body.ScopeId,
+	}); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return api.AssignUserRole409JSONResponse(ConflictErr("User already has this role").Create()), nil
+		}
+		logger.Error("Failed to assign role", "user_id", request.UserId, "role", body.RoleName, "error", err)
+		return api.AssignUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if err := qtx.CreateRoleChange(ctx, db.CreateRoleChangeParams{
+		Action:    db.RoleChangeActionUserRoleAssigned,
+		RoleName:  body.RoleName,
+		UserID:    &request.UserId,
+		Scope:     db.NullScopeType{ScopeType: scope, Valid: true},
+		ScopeID:   body.ScopeId,
+		ChangedBy: &user.ID,
+	}); err != nil {
+		logger.Error("Failed to record role change", "user_id", request.UserId, "error", err)
+		return api.AssignUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit role assignment", "user_id", request.UserId, "error", err)
+		return api.AssignUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	s.authenticator.InvalidateUserPermissions(ctx, request.UserId)
+
+	assignments, err := s.userRoleAssignments(ctx, request.UserId)
+	if err != nil {
+		logger.Error("Failed to get user roles", "user_id", request.UserId, "error", err)
+		return api.AssignUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Role assigned",
+		"target_user_id", request.UserId,
+		"role", body.RoleName,
+		"scope", body.Scope,
+		"scope_id", body.ScopeId,
+		"user_id", user.ID)
+	return api.AssignUserRole200JSONResponse(assignments), nil
+}
+
+func (s Server) RevokeUserRole(ctx context.Context, request api.RevokeUserRoleRequestObject) (api.RevokeUserRoleResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RevokeUserRole401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	scope := db.ScopeTypeGlobal
+	if request.Params.ScopeId != nil {
+		scope = db.ScopeTypeGroup
+	}
+	roleName := pgtype.Text{String: request.RoleName, Valid: true}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to start transaction", "error", err)
+		return api.RevokeUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+	qtx := s.db.Queries().WithTx(tx)
+
+	removed, err := qtx.DeleteUserRole(ctx, db.DeleteUserRoleParams{
+		UserID:   &request.UserId,
+		RoleName: roleName,
+		Scope:    scope,
+		ScopeID:  request.Params.ScopeId,
+	})
+	if err != nil {
+		logger.Error("Failed to revoke role", "user_id", request.UserId, "role", request.RoleName, "error", err)
+		return api.RevokeUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if removed == 0 {
+		return api.RevokeUserRole404JSONResponse(NotFound("Role assignment").Create()), nil
+	}
+
+	if request.RoleName == rbac.RoleGlobalAdmin && scope == db.ScopeTypeGlobal {
+		left, err := qtx.CountGlobalRoleHolders(ctx, roleName)
+		if err != nil {
+			logger.Error("Failed to count global admins", "error", err)
+			return api.RevokeUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		if left == 0 {
+			return api.RevokeUserRole409JSONResponse(ConflictErr("The last global_admin cannot be removed").Create()), nil
+		}
+	}
+
+	if err := qtx.CreateRoleChange(ctx, db.CreateRoleChangeParams{
+		Action:    db.RoleChangeActionUserRoleRevoked,
+		RoleName:  request.RoleName,
+		UserID:    &request.UserId,
+		Scope:     db.NullScopeType{ScopeType: scope, Valid: true},
+		ScopeID:   request.Params.ScopeId,
+		ChangedBy: &user.ID,
+	}); err != nil {
+		logger.Error("Failed to record role change", "user_id", request.UserId, "error", err)
+		return api.RevokeUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit role revocation", "user_id", request.UserId, "error", err)
+		return api.RevokeUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	s.authenticator.InvalidateUserPermissions(ctx, request.UserId)
+
+	assignments, err := s.userRoleAssignments(ctx, request.UserId)
+	if err != nil {
+		logger.Error("Failed to get user roles", "user_id", request.UserId, "error", err)
+		return api.RevokeUserRole500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Role revoked",
+		"target_user_id", request.UserId,
+		"role", request.RoleName,
+		"scope", scope,
+		"scope_id", request.Params.ScopeId,
+		"user_id", user.ID)
+	return api.RevokeUserRole200JSONResponse(assignments), nil
+}
+
+func sameScopeID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roles survive CleanupDatabase, so every test role needs a fresh name
+func uniqueRoleName() string {
+	return "test_role_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+}
+
+func TestServer_CreateRole(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, _ := newTestServer(t)
+
+	t.Run("creates a role and lists it", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@roles.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		name := uniqueRoleName()
+		permissions := []string{rbac.RequestItems, rbac.RequestItems}
+		response, err := server.CreateRole(ctx, api.CreateRoleRequestObject{
+			Body: &api.CreateRoleRequest{Name: name, Permissions: &permissions},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateRole201JSONResponse{}, response)
+		assert.Equal(t, []string{rbac.RequestItems}, response.(api.CreateRole201JSONResponse).Permissions)
+
+		listResponse, err := server.ListRoles(ctx, api.ListRolesRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.ListRoles200JSONResponse{}, listResponse)
+
+		var found bool
+		for _, role := range listResponse.(api.ListRoles200JSONResponse) {
+			if role.Name == name {
+				found = true
+				assert.Equal(t, []string{rbac.RequestItems}, role.Permissions)
+			}
+		}
+		assert.True(t, found)
+
+		response, err = server.CreateRole(ctx, api.CreateRoleRequestObject{
+			Body: &api.CreateRoleRequest{Name: name},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateRole409JSONResponse{}, response)
+	})
+
+	t.Run("unknown permission", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@roles.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		permissions := []string{"launch_rockets"}
+		response, err := server.CreateRole(ctx, api.CreateRoleRequestObject{
+			Body: &api.CreateRoleRequest{Name: uniqueRoleName(), Permissions: &permissions},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateRole400JSONResponse{}, response)
+	})
+}
+
+func TestServer_SetRolePermissions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, _ := newTestServer(t)
+
+	t.Run("replaces the permission set", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@roles.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		name := uniqueRoleName()
+		permissions := []string{rbac.RequestItems}
+		_, err := server.CreateRole(ctx, api.CreateRoleRequestObject{
+			Body: &api.CreateRoleRequest{Name: name, Permissions: &permissions},
+		})
+		require.NoError(t, err)
+
+		response, err := server.SetRolePermissions(ctx, api.SetRolePermissionsRequestObject{
+			RoleName: name,
+			Body:     &api.SetRolePermissionsRequest{Permissions: []string{rbac.ApproveAllRequests}},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetRolePermissions200JSONResponse{}, response)
+
+		stored, err := testDB.Queries().ListPermissionsForRole(ctx, name)
+		require.NoError(t, err)
+		assert.Equal(t, []string{rbac.ApproveAllRequests}, stored)
+	})
+
+	t.Run("global_admin is off limits", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@roles.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		response, err := server.SetRolePermissions(ctx, api.SetRolePermissionsRequestObject{
+			RoleName: rbac.RoleGlobalAdmin,
+			Body:     &api.SetRolePermissionsRequest{Permissions: []string{}},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetRolePermissions400JSONResponse{}, response)
+	})
+
+	t.Run("a role held within a group stays off global-only permissions", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@roles.test").AsGlobalAdmin().Create()
+		member := testDB.NewUser(t).WithEmail("member@roles.test").AsMember().Create()
+		group := testDB.NewGroup(t).WithName("Role Testers").Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		name := uniqueRoleName()
+		permissions := []string{rbac.RequestItems}
+		_, err := server.CreateRole(ctx, api.CreateRoleRequestObject{
+			Body: &api.CreateRoleRequest{Name: name, Permissions: &permissions},
+		})
+		require.NoError(t, err)
+		assignResponse, err := server.AssignUserRole(ctx, api.AssignUserRoleRequestObject{
+			UserId: member.ID,
+			Body:   &api.UserRoleAssignment{RoleName: name, Scope: api.RoleScopeGroup, ScopeId: &group.ID},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.AssignUserRole200JSONResponse{}, assignResponse)
+
+		response, err := server.SetRolePermissions(ctx, api.SetRolePermissionsRequestObject{
+			RoleName: name,
+			Body:     &api.SetRolePermissionsRequest{Permissions: []string{rbac.RequestItems, rbac.ManageUsers}},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetRolePermissions400JSONResponse{}, response)
+
+		stored, err := testDB.Queries().ListPermissionsForRole(ctx, name)
+		require.NoError(t, err)
+		assert.Equal(t, []string{rbac.RequestItems}, stored)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@roles.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		response, err := server.SetRolePermissions(ctx, api.SetRolePermissionsRequestObject{
+			RoleName: uniqueRoleName(),
+			Body:     &api.SetRolePermissionsRequest{Permissions: []string{}},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.SetRolePermissions404JSONResponse{}, response)
+	})
+}
+
+func TestServer_UserRoles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, _ := newTestServer(t)
+
+	t.Run("assign then revoke a group role", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@roles.test").AsGlobalAdmin().Create()
+		member := testDB.NewUser(t).WithEmail("member@roles.test").AsMember().Create()
+		group := testDB.NewGroup(t).WithName("Role Testers").Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		assignment := api.UserRoleAssignment{RoleName: rbac.RoleGroupApprover, Scope: api.RoleScopeGroup, ScopeId: &group.ID}
+		response, err := server.AssignUserRole(ctx, api.AssignUserRoleRequestObject{UserId: member.ID, Body: &assignment})
+		require.NoError(t, err)
+		require.IsType(t, api.AssignUserRole200JSONResponse{}, response)
+		assert.Contains(t, response.(api.AssignUserRole200JSONResponse), assignment)
+
+		response, err = server.AssignUserRole(ctx, api.AssignUserRoleRequestObject{UserId: member.ID, Body: &assignment})
+		require.NoError(t, err)
+		require.IsType(t, api.AssignUserRole409JSONResponse{}, response)
+
+		revokeResponse, err := server.RevokeUserRole(ctx, api.RevokeUserRoleRequestObject{
+			UserId:   member.ID,
+			RoleName: rbac.RoleGroupApprover,
+			Params:   api.RevokeUserRoleParams{ScopeId: &group.ID},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RevokeUserRole200JSONResponse{}, revokeResponse)
+		assert.NotContains(t, revokeResponse.(api.RevokeUserRole200JSONResponse), assignment)
+	})
+
+	t.Run("group scope needs a group", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@roles.test").AsGlobalAdmin().Create()
+		member := testDB.NewUser(t).WithEmail("member@roles.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		response, err := server.AssignUserRole(ctx, api.AssignUserRoleRequestObject{
+			UserId: member.ID,
+			Body:   &api.UserRoleAssignment{RoleName: rbac.RoleApprover, Scope: api.RoleScopeGroup},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.AssignUserRole400JSONResponse{}, response)
+	})
+
+	t.Run("global-only roles can't be granted within a group", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@roles.test").AsGlobalAdmin().Create()
+		member := testDB.NewUser(t).WithEmail("member@roles.test").AsMember().Create()
+		group := testDB.NewGroup(t).WithName("Role Testers").Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		name := uniqueRoleName()
+		permissions := []string{rbac.ManageUsers}
+		_, err := server.CreateRole(ctx, api.CreateRoleRequestObject{
+			Body: &api.CreateRoleRequest{Name: name, Permissions: &permissions},
+		})
+		require.NoError(t, err)
+
+		for _, role := range []string{name, rbac.RoleGlobalAdmin, rbac.RoleApprover} {
+			response, err := server.AssignUserRole(ctx, api.AssignUserRoleRequestObject{
+				UserId: member.ID,
+				Body:   &api.UserRoleAssignment{RoleName: role, Scope: api.RoleScopeGroup, ScopeId: &group.ID},
+			})
+			require.NoError(t, err)
+			require.IsType(t, api.AssignUserRole400JSONResponse{}, response, role)
+		}
+
+		// a check made without a group counts group-scoped roles, so one
+		// getting through would have granted manage_users everywhere
+		allowed, err := testDB.Queries().CheckUserPermission(ctx, db.CheckUserPermissionParams{
+			UserID: &member.ID,
+			Name:   rbac.ManageUsers,
+		})
+		require.NoError(t, err)
+		assert.False(t, allowed)
+	})
+
+	t.Run("the last global_admin stays", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@roles.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		response, err := server.RevokeUserRole(ctx, api.RevokeUserRoleRequestObject{
+			UserId:   admin.ID,
+			RoleName: rbac.RoleGlobalAdmin,
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RevokeUserRole409JSONResponse{}, response)
+
+		roles, err := testDB.Queries().GetUserRoles(ctx, &admin.ID)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+		assert.Equal(t, rbac.RoleGlobalAdmin, roles[0].RoleName.String)
+	})
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/jackc/pgx/v5/pgtype"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+func (s Server) ListRoutingRules(ctx context.Context, request api.ListRoutingRulesRequestObject) (api.ListRoutingRulesResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListRoutingRules401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageNotifications, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageNotifications permission", "error", err)
+		return api.ListRoutingRules500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListRoutingRules403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	rules, err := s.db.Queries().ListRoutingRules(ctx)
+	if err != nil {
+		logger.Error("Failed to list routing rules", "error", err)
+		return api.ListRoutingRules500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := make(api.ListRoutingRules200JSONResponse, 0, len(rules))
+	for _, rule := range rules {
+		response = append(response, toRoutingRuleResponse(rule))
+	}
+	return response, nil
+}
+
+func (s Server) CreateRoutingRule(ctx context.Context, request api.CreateRoutingRuleRequestObject) (api.CreateRoutingRuleResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateRoutingRule401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageNotifications, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageNotifications permission", "error", err)
+		return api.CreateRoutingRule500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CreateRoutingRule403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.CreateRoutingRule400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	body := request.Body
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		return api.CreateRoutingRule400JSONResponse(ValidationErr("name is required", nil).Create()), nil
+	}
+	if !s.dispatcher.HasTemplate(body.Template) {
+		return api.CreateRoutingRule400JSONResponse(ValidationErr("Unknown notification template: "+body.Template, nil).Create()), nil
+	}
+	if (body.RecipientUserId == nil) == (body.RecipientEmail == nil) {
+		return api.CreateRoutingRule400JSONResponse(ValidationErr("Exactly one of recipient_user_id and recipient_email is required", nil).Create()), nil
+	}
+
+	params := db.CreateRoutingRuleParams{
+		Name:            name,
+		Template:        body.Template,
+		ItemID:          body.ItemId,
+		GroupID:         body.GroupId,
+		RecipientUserID: body.RecipientUserId,
+		ReplaceDefault:  body.ReplaceDefault != nil && *body.ReplaceDefault,
+		Enabled:         body.Enabled == nil || *body.Enabled,
+		CreatedBy:       &user.ID,
+	}
+	if body.ItemType != nil {
+		params.ItemType = db.NullItemType{ItemType: db.ItemType(*body.ItemType), Valid: true}
+	}
+	if body.MinDaysOverdue != nil {
+		if *body.MinDaysOverdue < 0 {
+			return api.CreateRoutingRule400JSONResponse(ValidationErr("min_days_overdue cannot be negative", nil).Create()), nil
+		}
+		params.MinDaysOverdue = pgtype.Int4{Int32: int32(*body.MinDaysOverdue), Valid: true}
+	}
+	if body.RecipientEmail != nil {
+		params.RecipientEmail = pgtype.Text{String: string(*body.RecipientEmail), Valid: true}
+	}
+
+	rule, err := s.db.Queries().CreateRoutingRule(ctx, params)
+	if err != nil {
+		logger.Error("Failed to create routing rule", "template", body.Template, "error", err)
+		return api.CreateRoutingRule500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Routing rule created", "rule_id", rule.ID, "template", rule.Template, "user_id", user.ID)
+	return api.CreateRoutingRule201JSONResponse(toRoutingRuleResponse(rule)), nil
+}
+
+func (s Server) DeleteRoutingRule(ctx context.Context, request api.DeleteRoutingRuleRequestObject) (api.DeleteRoutingRuleResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.DeleteRoutingRule401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageNotifications, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageNotifications permission", "error", err)
+		return api.DeleteRoutingRule500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.DeleteRoutingRule403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	deleted, err := s.db.Queries().DeleteRoutingRule(ctx, request.Id)
+	if err != nil {
+		logger.Error("Failed to delete routing rule", "rule_id", request.Id, "error", err)
+		return api.DeleteRoutingRule500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if deleted == 0 {
+		return api.DeleteRoutingRule404JSONResponse(NotFound("Routing rule").Create()), nil
+	}
+
+	logger.Info("Routing rule deleted", "rule_id", request.Id, "user_id", user.ID)
+	return api.DeleteRoutingRule204Response{}, nil
+}
+
+func toRoutingRuleResponse(rule db.NotificationRoutingRule) api.RoutingRule {
+	response := api.RoutingRule{
+		Id:              rule.ID,
+		Name:            rule.Name,
+		Template:        rule.Template,
+		ItemId:          rule.ItemID,
+		GroupId:         rule.GroupID,
+		RecipientUserId: rule.RecipientUserID,
+		ReplaceDefault:  rule.ReplaceDefault,
+		Enabled:         rule.Enabled,
+		CreatedBy:       rule.CreatedBy,
+		CreatedAt:       rule.CreatedAt.Time,
+	}
+	if rule.ItemType.Valid {
+		itemType := api.ItemType(rule.ItemType.ItemType)
+		response.ItemType = &itemType
+	}
+	if rule.MinDaysOverdue.Valid {
+		days := int(rule.MinDaysOverdue.Int32)
+		response.MinDaysOverdue = &days
+	}
+	if rule.RecipientEmail.Valid {
+		email := openapi_types.Email(rule.RecipientEmail.String)
+		response.RecipientEmail = &email
+	}
+	return response
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	openapi_types "github.com/oapi-codegen/runtime/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RoutingRules(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@routing.test").AsGlobalAdmin().Create()
+	group := testDB.NewGroup(t).WithName("AV Club").Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	create := func(body api.CreateRoutingRuleRequest) api.CreateRoutingRuleResponseObject {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageNotifications, nil, true, nil)
+		response, err := server.CreateRoutingRule(ctx, api.CreateRoutingRuleRequestObject{Body: &body})
+		require.NoError(t, err)
+		return response
+	}
+
+	email := openapi_types.Email("av-desk@routing.test")
+	var ruleID uuid.UUID
+
+	t.Run("create", func(t *testing.T) {
+		response := create(api.CreateRoutingRuleRequest{
+			Name:           "AV desk",
+			Template:       "return_overdue_group_admin",
+			GroupId:        &group.ID,
+			RecipientEmail: &email,
+		})
+		require.IsType(t, api.CreateRoutingRule201JSONResponse{}, response)
+		rule := response.(api.CreateRoutingRule201JSONResponse)
+		assert.True(t, rule.Enabled)
+		assert.False(t, rule.ReplaceDefault)
+		assert.Equal(t, &group.ID, rule.GroupId)
+		ruleID = rule.Id
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		response := create(api.CreateRoutingRuleRequest{Name: "Bad", Template: "no_such_template", RecipientEmail: &email})
+		require.IsType(t, api.CreateRoutingRule400JSONResponse{}, response)
+	})
+
+	t.Run("requires exactly one recipient", func(t *testing.T) {
+		response := create(api.CreateRoutingRuleRequest{Name: "None", Template: "return_overdue_group_admin"})
+		require.IsType(t, api.CreateRoutingRule400JSONResponse{}, response)
+
+		response = create(api.CreateRoutingRuleRequest{
+			Name: "Both", Template: "return_overdue_group_admin", RecipientEmail: &email, RecipientUserId: &admin.ID,
+		})
+		require.IsType(t, api.CreateRoutingRule400JSONResponse{}, response)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageNotifications, nil, true, nil)
+		response, err := server.ListRoutingRules(ctx, api.ListRoutingRulesRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.ListRoutingRules200JSONResponse{}, response)
+		rules := response.(api.ListRoutingRules200JSONResponse)
+		require.Len(t, rules, 1)
+		assert.Equal(t, ruleID, rules[0].Id)
+		assert.Equal(t, &email, rules[0].RecipientEmail)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageNotifications, nil, true, nil)
+		response, err := server.DeleteRoutingRule(ctx, api.DeleteRoutingRuleRequestObject{Id: ruleID})
+		require.NoError(t, err)
+		require.IsType(t, api.DeleteRoutingRule204Response{}, response)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageNotifications, nil, true, nil)
+		response, err = server.DeleteRoutingRule(ctx, api.DeleteRoutingRuleRequestObject{Id: ruleID})
+		require.NoError(t, err)
+		require.IsType(t, api.DeleteRoutingRule404JSONResponse{}, response)
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageNotifications, nil, false, nil)
+		response, err := server.ListRoutingRules(ctx, api.ListRoutingRulesRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.ListRoutingRules403JSONResponse{}, response)
+	})
+}
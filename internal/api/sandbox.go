@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// flags ctx so notifications for a sandbox group are logged instead of emailed.
+func (s Server) sandboxContext(ctx context.Context, groupID *uuid.UUID) context.Context {
+	if groupID == nil {
+		return ctx
+	}
+	sandbox, err := s.db.Queries().IsGroupSandbox(ctx, *groupID)
+	if err != nil {
+		middleware.GetLoggerFromContext(ctx).Warn("Failed to check group sandbox flag",
+			"group_id", *groupID,
+			"error", err)
+		return ctx
+	}
+	return notifications.WithSandbox(ctx, sandbox)
+}
+
+// PromoteGroup takes a sandbox group live: stock consumed during training is
+// restored, all of the group's transactional data is purged and the flag is cleared.
+func (s Server) PromoteGroup(ctx context.Context, request api.PromoteGroupRequestObject) (api.PromoteGroupResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.PromoteGroup401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageGroups, nil)
+	if err != nil {
+		logger.Error("Error checking manage_groups permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageGroups,
+			"error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.PromoteGroup403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	group, err := s.db.Queries().GetGroupByID(ctx, request.Id)
+	if err == pgx.ErrNoRows {
+		return api.PromoteGroup404JSONResponse(NotFound("Group").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get group", "group_id", request.Id, "error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if !group.Sandbox {
+		return api.PromoteGroup400JSONResponse(ValidationErr("Group is not in sandbox mode", nil).Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	// the stock the sandbox used goes back on the shelf
+	if err := tagStockMovement(ctx, qtx, stockMovement{Kind: db.StockMovementKindReturn, Reason: "sandbox promoted", ActorID: &user.ID, ReferenceID: &group.ID}); err != nil {
+		logger.Error("Failed to tag stock movement", "group_id", group.ID, "error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if err := qtx.RestoreSandboxTakenStock(ctx, group.ID); err != nil {
+		logger.Error("Failed to restore taken stock", "group_id", group.ID, "error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if err := qtx.RestoreSandboxBorrowedStock(ctx, &group.ID); err != nil {
+		logger.Error("Failed to restore borrowed stock", "group_id", group.ID, "error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if err := qtx.RestoreSandboxBorrowedUnits(ctx, &group.ID); err != nil {
+		logger.Error("Failed to restore borrowed units", "group_id", group.ID, "error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	takings, err := qtx.PurgeGroupItemTakings(ctx, group.ID)
+	if err != nil {
+		logger.Error("Failed to purge item takings", "group_id", group.ID, "error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	borrowings, err := qtx.PurgeGroupBorrowings(ctx, &group.ID)
+	if err != nil {
+		logger.Error("Failed to purge borrowings", "group_id", group.ID, "error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	requests, err := qtx.PurgeGroupRequests(ctx, &group.ID)
+	if err != nil {
+		logger.Error("Failed to purge requests", "group_id", group.ID, "error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	bookings, err := qtx.PurgeGroupBookings(ctx, &group.ID)
+	if err != nil {
+		logger.Error("Failed to purge bookings", "group_id", group.ID, "error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	carts, err := qtx.PurgeGroupCarts(ctx, group.ID)
+	if err != nil {
+		logger.Error("Failed to purge carts", "group_id", group.ID, "error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	promoted, err := qtx.SetGroupSandbox(ctx, db.SetGroupSandboxParams{ID: group.ID, Sandbox: false})
+	if err != nil {
+		logger.Error("Failed to clear sandbox flag", "group_id", group.ID, "error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	dryRun := isDryRun(request.Params.DryRun)
+	if err := finishTx(ctx, tx, dryRun); err != nil {
+		logger.Error("Failed to finish promote transaction", "group_id", group.ID, "dry_run", dryRun, "error", err)
+		return api.PromoteGroup500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	message := "Promoted sandbox group to live"
+	if dryRun {
+		message = "Dry run of sandbox group promotion"
+	}
+	logger.Info(message,
+		"group_id", group.ID,
+		"promoted_by", user.ID,
+		"item_takings", takings,
+		"borrowings", borrowings,
+		"requests", requests,
+		"bookings", bookings,
+		"carts", carts)
+
+	var description *string
+	if promoted.Description.Valid {
+		description = &promoted.Description.String
+	}
+	logoURL, thumbURL := s.resolveGroupLogoURLs(ctx, promoted)
+	response := api.PromoteGroup200JSONResponse{
+		Id:               promoted.ID,
+		Name:             promoted.Name,
+		Description:      description,
+		LogoUrl:          logoURL,
+		LogoThumbnailUrl: thumbURL,
+		Sandbox:          promoted.Sandbox,
+		DryRun:           dryRun,
+	}
+	response.Purged.ItemTakings = int(takings)
+	response.Purged.Borrowings = int(borrowings)
+	response.Purged.Requests = int(requests)
+	response.Purged.Bookings = int(bookings)
+	response.Purged.Carts = int(carts)
+	return response, nil
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_PromoteGroup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("bookings in sandbox group are marked test and purged on promote", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		admin := testDB.NewUser(t).WithEmail("admin@sandbox.test").AsGlobalAdmin().Create()
+		user := testDB.NewUser(t).WithEmail("user@sandbox.test").AsMember().Create()
+		approver := testDB.NewUser(t).WithEmail("approver@sandbox.test").AsApprover().Create()
+		item := testDB.NewItem(t).WithName("Laptop").WithType("high").WithStock(5).Create()
+		group := testDB.NewGroup(t).WithName("Training Group").AsSandbox().Create()
+		require.True(t, group.Sandbox)
+
+		availability := createTestAvailability(t, testDB, approver.ID)
+		booking := createTestBooking(t, testDB,
+			availability.ID, user.ID, approver.ID, item.ID, group.ID,
+			db.RequestStatusPendingConfirmation, 0)
+
+		created, err := testDB.Queries().GetBookingByID(context.Background(), booking.ID)
+		require.NoError(t, err)
+		assert.True(t, created.IsTest)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		response, err := server.PromoteGroup(ctx, api.PromoteGroupRequestObject{Id: group.ID})
+
+		require.NoError(t, err)
+		require.IsType(t, api.PromoteGroup200JSONResponse{}, response)
+
+		resp := response.(api.PromoteGroup200JSONResponse)
+		assert.Equal(t, group.ID, resp.Id)
+		assert.False(t, resp.Sandbox)
+
+		_, err = testDB.Queries().GetBookingByID(context.Background(), booking.ID)
+		assert.Error(t, err, "sandbox booking should be purged")
+	})
+
+	t.Run("dry run reports the purge and changes nothing", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		admin := testDB.NewUser(t).WithEmail("admin@sandbox.test").AsGlobalAdmin().Create()
+		user := testDB.NewUser(t).WithEmail("user@sandbox.test").AsMember().Create()
+		approver := testDB.NewUser(t).WithEmail("approver@sandbox.test").AsApprover().Create()
+		item := testDB.NewItem(t).WithName("Laptop").WithType("high").WithStock(5).Create()
+		group := testDB.NewGroup(t).WithName("Training Group").AsSandbox().Create()
+
+		availability := createTestAvailability(t, testDB, approver.ID)
+		booking := createTestBooking(t, testDB,
+			availability.ID, user.ID, approver.ID, item.ID, group.ID,
+			db.RequestStatusPendingConfirmation, 0)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		dryRun := true
+		response, err := server.PromoteGroup(ctx, api.PromoteGroupRequestObject{
+			Id:     group.ID,
+			Params: api.PromoteGroupParams{DryRun: &dryRun},
+		})
+
+		require.NoError(t, err)
+		require.IsType(t, api.PromoteGroup200JSONResponse{}, response)
+
+		resp := response.(api.PromoteGroup200JSONResponse)
+		assert.True(t, resp.DryRun)
+		assert.False(t, resp.Sandbox)
+		assert.Equal(t, 1, resp.Purged.Bookings)
+
+		_, err = testDB.Queries().GetBookingByID(context.Background(), booking.ID)
+		assert.NoError(t, err, "dry run must not purge the booking")
+		sandbox, err := testDB.Queries().IsGroupSandbox(context.Background(), group.ID)
+		require.NoError(t, err)
+		assert.True(t, sandbox, "dry run must not clear the sandbox flag")
+	})
+
+	t.Run("group not in sandbox mode", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		admin := testDB.NewUser(t).WithEmail("admin@sandbox.test").AsGlobalAdmin().Create()
+		group := testDB.NewGroup(t).WithName("Live Group").Create()
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		response, err := server.PromoteGroup(ctx, api.PromoteGroupRequestObject{Id: group.ID})
+
+		require.NoError(t, err)
+		require.IsType(t, api.PromoteGroup400JSONResponse{}, response)
+	})
+
+	t.Run("group not found", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		admin := testDB.NewUser(t).WithEmail("admin@sandbox.test").AsGlobalAdmin().Create()
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageGroups, nil, true, nil)
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		response, err := server.PromoteGroup(ctx, api.PromoteGroupRequestObject{Id: uuid.New()})
+
+		require.NoError(t, err)
+		require.IsType(t, api.PromoteGroup404JSONResponse{}, response)
+	})
+
+	t.Run("insufficient permissions", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		member := testDB.NewUser(t).WithEmail("member@sandbox.test").AsMember().Create()
+		group := testDB.NewGroup(t).AsSandbox().Create()
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageGroups, nil, false, nil)
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		response, err := server.PromoteGroup(ctx, api.PromoteGroupRequestObject{Id: group.ID})
+
+		require.NoError(t, err)
+		require.IsType(t, api.PromoteGroup403JSONResponse{}, response)
+	})
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/qrcode"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// codes printed on labels; anything else scanned is tried as a bare ID, then
+// as an asset tag or serial number
+const (
+	itemCodePrefix = "item:"
+	unitCodePrefix = "unit:"
+)
+
+const defaultLabelScale = 8
+
+// returned as client-facing errors, so handlers pass them through errorFor
+var errScanNotFound = NotFound("Item or unit with this code")
+
+func (s Server) GetItemQRCode(ctx context.Context, request api.GetItemQRCodeRequestObject) (api.GetItemQRCodeResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetItemQRCode401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.GetItemQRCode500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetItemQRCode403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	item, err := s.db.Queries().GetItemByID(ctx, request.ItemId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.GetItemQRCode404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.GetItemQRCode500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	code := itemCodePrefix + item.ID.String()
+	if request.Params.UnitId != nil {
+		unit, err := s.db.Queries().GetItemUnitByID(ctx, *request.Params.UnitId)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && unit.ItemID != item.ID) {
+			return api.GetItemQRCode400JSONResponse(errUnitNotOfItem.Create()), nil
+		}
+		if err != nil {
+			logger.Error("Failed to get unit", "unit_id", *request.Params.UnitId, "error", err)
+			return api.GetItemQRCode500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		code = unitCodePrefix + unit.ID.String()
+	}
+
+	qr, err := qrcode.Encode(s.scanURL + code)
+	if err != nil {
+		// only a scan URL too long to fit gets here
+		logger.Error("Failed to encode label", "item_id", item.ID, "error", err)
+		return api.GetItemQRCode500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	var buf bytes.Buffer
+	if request.Params.Format != nil && *request.Params.Format == api.Svg {
+		if err := qr.WriteSVG(&buf); err != nil {
+			logger.Error("Failed to write label", "item_id", item.ID, "error", err)
+			return api.GetItemQRCode500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		return api.GetItemQRCode200ImagesvgXmlResponse{Body: &buf, ContentLength: int64(buf.Len())}, nil
+	}
+
+	scale := defaultLabelScale
+	if request.Params.Scale != nil {
+		scale = *request.Params.Scale
+	}
+	if err := qr.WritePNG(&buf, scale); err != nil {
+		logger.Error("Failed to write label", "item_id", item.ID, "error", err)
+		return api.GetItemQRCode500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	return api.GetItemQRCode200ImagepngResponse{Body: &buf, ContentLength: int64(buf.Len())}, nil
+}
+
+func (s Server) ResolveScanCode(ctx context.Context, request api.ResolveScanCodeRequestObject) (api.ResolveScanCodeResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ResolveScanCode401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ViewItems permission", "error", err)
+		return api.ResolveScanCode500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ResolveScanCode403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	item, unit, err := s.resolveScanCode(ctx, request.Code)
+	if err != nil {
+		if apiErr := errorFor(err); apiErr.Code == CodeResourceNotFound {
+			return api.ResolveScanCode404JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to resolve scan code", "code", request.Code, "error", err)
+		return api.ResolveScanCode500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	visible, err := s.canSeeItem(ctx, user, item)
+	if err != nil {
+		logger.Error("Failed to check item visibility", "item_id", item.ID, "error", err)
+		return api.ResolveScanCode500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if !visible {
+		return api.ResolveScanCode404JSONResponse(errScanNotFound.Create()), nil
+	}
+
+	description := item.Description.String
+	urls := item.Urls
+	items := []api.ItemResponse{{
+		Id:           item.ID,
+		Name:         item.Name,
+		Description:  &description,
+		Type:         api.ItemType(item.Type),
+		Stock:        int(item.Stock),
+		Urls:         &urls,
+		OwnerGroupId: item.OwnerGroupID,
+	}}
+	if err := s.attachItemDetails(ctx, items); err != nil {
+		logger.Error("Failed to get item details", "item_id", item.ID, "error", err)
+		return api.ResolveScanCode500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := api.ResolveScanCode200JSONResponse{Item: items[0]}
+	if unit != nil {
+		u := toItemUnitResponse(*unit)
+		response.Unit = &u
+	}
+	return response, nil
+}
+
+// the item, and unit when there is one, that a scanned code names. A deep
+// link is reduced to the code at its end.
+func (s Server) resolveScanCode(ctx context.Context, code string) (db.Item, *db.ItemUnit, error) {
+	code = strings.TrimSpace(code)
+	if i := strings.LastIndex(code, "/"); i >= 0 {
+		code = code[i+1:]
+	}
+
+	var unit *db.ItemUnit
+	switch {
+	case strings.HasPrefix(code, itemCodePrefix):
+		id, err := uuid.Parse(strings.TrimPrefix(code, itemCodePrefix))
+		if err != nil {
+			return db.Item{}, nil, errScanNotFound
+		}
+		return s.scannedItem(ctx, id, nil)
+	case strings.HasPrefix(code, unitCodePrefix):
+		id, err := uuid.Parse(strings.TrimPrefix(code, unitCodePrefix))
+		if err != nil {
+			return db.Item{}, nil, errScanNotFound
+		}
+		u, err := s.db.Queries().GetItemUnitByID(ctx, id)
+		if err != nil {
+			return db.Item{}, nil, scanLookupErr(err)
+		}
+		unit = &u
+	default:
+		if id, err := uuid.Parse(code); err == nil {
+			item, _, err := s.scannedItem(ctx, id, nil)
+			if !errors.Is(err, errScanNotFound) {
+				return item, nil, err
+			}
+			u, err := s.db.Queries().GetItemUnitByID(ctx, id)
+			if err != nil {
+				return db.Item{}, nil, scanLookupErr(err)
+			}
+			unit = &u
+			break
+		}
+		// a barcode already on the gear
+		u, err := s.db.Queries().GetItemUnitByTag(ctx, code)
+		if err != nil {
+			return db.Item{}, nil, scanLookupErr(err)
+		}
+		unit = &u
+	}
+	return s.scannedItem(ctx, unit.ItemID, unit)
+}
+
+// archived items scan as not found, as they are gone from the catalogue
+func (s Server) scannedItem(ctx context.Context, itemID uuid.UUID, unit *db.ItemUnit) (db.Item, *db.ItemUnit, error) {
+	item, err := s.db.Queries().GetItemByID(ctx, itemID)
+	if err != nil {
+		return db.Item{}, nil, scanLookupErr(err)
+	}
+	return item, unit, nil
+}
+
+func scanLookupErr(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errScanNotFound
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ScanLabels(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@scan.test").AsGlobalAdmin().Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	item := testDB.NewItem(t).WithName("Camera").WithType("medium").Create()
+
+	mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+	serial, tag := "SN-4417", "CV-0001"
+	created, err := server.CreateItemUnit(ctx, api.CreateItemUnitRequestObject{
+		ItemId: item.ID,
+		Body:   &api.CreateItemUnitRequest{SerialNumber: &serial, AssetTag: &tag},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.CreateItemUnit201JSONResponse{}, created)
+	unitID := created.(api.CreateItemUnit201JSONResponse).Id
+
+	scan := func(t *testing.T, code string) api.ResolveScanCodeResponseObject {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewItems, nil, true, nil)
+		resp, err := server.ResolveScanCode(ctx, api.ResolveScanCodeRequestObject{Code: code})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("png label", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.GetItemQRCode(ctx, api.GetItemQRCodeRequestObject{ItemId: item.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItemQRCode200ImagepngResponse{}, resp)
+
+		img, err := png.Decode(resp.(api.GetItemQRCode200ImagepngResponse).Body)
+		require.NoError(t, err)
+		assert.Positive(t, img.Bounds().Dx())
+	})
+
+	t.Run("svg label for a unit", func(t *testing.T) {
+		format := api.Svg
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.GetItemQRCode(ctx, api.GetItemQRCodeRequestObject{
+			ItemId: item.ID,
+			Params: api.GetItemQRCodeParams{UnitId: &unitID, Format: &format},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItemQRCode200ImagesvgXmlResponse{}, resp)
+
+		body, err := io.ReadAll(resp.(api.GetItemQRCode200ImagesvgXmlResponse).Body)
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(body, []byte("<?xml")))
+	})
+
+	t.Run("label for another item's unit is rejected", func(t *testing.T) {
+		other := testDB.NewItem(t).WithName("Tripod").Create()
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.GetItemQRCode(ctx, api.GetItemQRCodeRequestObject{
+			ItemId: other.ID,
+			Params: api.GetItemQRCodeParams{UnitId: &unitID},
+		})
+		require.NoError(t, err)
+		assert.IsType(t, api.GetItemQRCode400JSONResponse{}, resp)
+	})
+
+	t.Run("codes resolve to the item and unit", func(t *testing.T) {
+		for _, code := range []string{
+			"unit:" + unitID.String(),
+			"https://vault.example.edu/scan/unit:" + unitID.String(),
+			unitID.String(),
+			tag,
+			serial,
+		} {
+			resp := scan(t, code)
+			require.IsType(t, api.ResolveScanCode200JSONResponse{}, resp, code)
+			result := resp.(api.ResolveScanCode200JSONResponse)
+			assert.Equal(t, item.ID, result.Item.Id, code)
+			require.NotNil(t, result.Unit, code)
+			assert.Equal(t, unitID, result.Unit.Id, code)
+		}
+	})
+
+	t.Run("item codes resolve without a unit", func(t *testing.T) {
+		for _, code := range []string{"item:" + item.ID.String(), item.ID.String()} {
+			resp := scan(t, code)
+			require.IsType(t, api.ResolveScanCode200JSONResponse{}, resp, code)
+			assert.Equal(t, item.ID, resp.(api.ResolveScanCode200JSONResponse).Item.Id, code)
+			assert.Nil(t, resp.(api.ResolveScanCode200JSONResponse).Unit, code)
+		}
+	})
+
+	t.Run("unknown codes are not found", func(t *testing.T) {
+		for _, code := range []string{"item:" + uuid.NewString(), uuid.NewString(), "item:not-an-id", "NO-SUCH-TAG"} {
+			assert.IsType(t, api.ResolveScanCode404JSONResponse{}, scan(t, code), code)
+		}
+	})
+}
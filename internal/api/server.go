@@ -1,23 +1,54 @@
 package api
 
+import (
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/fines"
+)
+
 type Server struct {
 	db            DatabaseService
 	queue         RedisQueueService
 	authService   AuthService
 	authenticator AuthenticatorService
 	emailService  EmailService
-	s3Service     S3Service
+	storage       StorageService
 	dispatcher    NotificationDispatcherService
+	loadShedder   LoadShedderService
+	policies      BookingPolicyService
+	studentIDs    StudentIDHasher
+	events        EventBroker
+	finePolicy    fines.Policy
+	// campus single sign-on; nil when it is not configured
+	oidc OIDCService
+	// zone booking and availability times are in
+	location *time.Location
+	// frontend page printed labels link to; empty prints bare codes
+	scanURL string
+	// item listings cache; nil skips caching altogether
+	catalogCache CatalogCache
+	// shown to administrators by GetActiveConfig
+	activeConfig ConfigSource
 }
 
-func NewServer(db DatabaseService, queue RedisQueueService, authService AuthService, authenticator AuthenticatorService, emailService EmailService, s3Service S3Service, dispatcher NotificationDispatcherService) *Server {
+func NewServer(db DatabaseService, queue RedisQueueService, authService AuthService, authenticator AuthenticatorService, emailService EmailService, storage StorageService, dispatcher NotificationDispatcherService, loadShedder LoadShedderService, policies BookingPolicyService, studentIDs StudentIDHasher, events EventBroker, finePolicy fines.Policy, oidc OIDCService, location *time.Location, scanURL string, catalogCache CatalogCache, activeConfig ConfigSource) *Server {
 	return &Server{
 		db:            db,
 		queue:         queue,
 		authService:   authService,
 		authenticator: authenticator,
 		emailService:  emailService,
-		s3Service:     s3Service,
+		storage:       storage,
 		dispatcher:    dispatcher,
+		loadShedder:   loadShedder,
+		policies:      policies,
+		studentIDs:    studentIDs,
+		events:        events,
+		finePolicy:    finePolicy,
+		oidc:          oidc,
+		location:      location,
+		scanURL:       scanURL,
+		catalogCache:  catalogCache,
+		activeConfig:  activeConfig,
 	}
 }
@@ -9,9 +9,15 @@ import (
 
 	"github.com/USSTM/cv-backend/generated/db"
 	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/bookingpolicy"
 	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/events"
+	"github.com/USSTM/cv-backend/internal/fines"
+	"github.com/USSTM/cv-backend/internal/identity"
+	"github.com/USSTM/cv-backend/internal/middleware"
 	"github.com/USSTM/cv-backend/internal/notifications"
 	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/USSTM/cv-backend/templates"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	openapi_types "github.com/oapi-codegen/runtime/types"
@@ -82,12 +88,24 @@ func newAuthTestServer(t *testing.T) (*Server, *testutil.TestDatabase, *testutil
 
 	notiService := notifications.NewNotificationService(testDB.Pool(), testDB.Queries())
 
-	emailTemplates, err := notifications.LoadTemplates("../../templates/email")
+	emailTemplates, err := notifications.LoadTemplates(templates.Email())
 	require.NoError(t, err)
 
-	dispatcher := notifications.NewNotificationDispatcher(notiService, sharedQueue, emailTemplates, notifications.NewEmailLookupFunc(testDB.Queries()))
+	dispatcher := notifications.NewNotificationDispatcher(notiService, sharedQueue, emailTemplates, notifications.NewEmailLookupFunc(testDB.Queries()), notifications.NewRuleLookupFunc(testDB.Queries()), notifications.NewSandboxLookupFunc(testDB.Queries()), nil)
 
-	server := NewServer(testDB, sharedQueue, authSvc, mockAuth, sharedLocalStack, sharedLocalStack, dispatcher)
+	loadShedder := middleware.NewLoadShedder(&config.ServerConfig{})
+
+	policies := bookingpolicy.NewResolver(config.BookingConfig{ConfirmationWindow: 48 * time.Hour})
+
+	studentIDs := identity.NewHasher(config.IdentityConfig{StudentIDKey: "test-student-id-key"})
+
+	// emails only ever go out through the queue, so nothing needs sending here
+	emailSender, err := notifications.NewEmailSender(config.EmailConfig{Provider: "log"}, config.AWSConfig{})
+	require.NoError(t, err)
+
+	server := NewServer(testDB, sharedQueue, authSvc, mockAuth, emailSender, sharedLocalStack, dispatcher, loadShedder, policies,
+		studentIDs, events.NewBroker(sharedQueue.Redis), fines.Policy{BlockThresholdCents: 1000}, nil, time.UTC, "", nil,
+		config.NewLive(&config.Config{JWT: config.JWTConfig{SigningKey: "test-signing-key"}}))
 	return server, testDB, mockAuth, authSvc
 }
 
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func toStockMovementResponse(m db.StockMovement) api.StockMovement {
+	resp := api.StockMovement{
+		Id:          m.ID,
+		ItemId:      m.ItemID,
+		Delta:       int(m.Delta),
+		Balance:     int(m.Balance),
+		Kind:        api.StockMovementKind(m.Kind),
+		ActorId:     m.ActorID,
+		ReferenceId: m.ReferenceID,
+		CreatedAt:   m.CreatedAt.Time,
+	}
+	if m.Reason.Valid {
+		resp.Reason = &m.Reason.String
+	}
+	return resp
+}
+
+// what a transaction's stock changes are, for the items_stock_ledger trigger
+// to record against them.
+type stockMovement struct {
+	Kind        db.StockMovementKind `json:"kind"`
+	Reason      string               `json:"reason,omitempty"`
+	ActorID     *uuid.UUID           `json:"actor_id,omitempty"`
+	ReferenceID *uuid.UUID           `json:"reference_id,omitempty"`
+}
+
+// tags the stock changes made by the rest of the transaction. A tag lasts
+// until the transaction ends, so a transaction moving stock for more than one
+// reason tags before each change.
+func tagStockMovement(ctx context.Context, qtx *db.Queries, movement stockMovement) error {
+	encoded, err := json.Marshal(movement)
+	if err != nil {
+		return fmt.Errorf("failed to encode stock movement: %w", err)
+	}
+	if err := qtx.TagStockMovement(ctx, string(encoded)); err != nil {
+		return fmt.Errorf("failed to tag stock movement: %w", err)
+	}
+	return nil
+}
+
+// returned as client-facing errors, so handlers pass them through errorFor
+var (
+	errStockTrackedByUnit = ConflictErr("Item is tracked by unit; its stock follows its units")
+	errStockBelowZero     = ConflictErr("Adjustment would take the item's stock below zero")
+)
+
+func (s Server) AdjustItemStock(ctx context.Context, request api.AdjustItemStockRequestObject) (api.AdjustItemStockResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.AdjustItemStock401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.AdjustItemStock500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.AdjustItemStock403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.AdjustItemStock400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	if request.Body.Delta == 0 {
+		return api.AdjustItemStock400JSONResponse(ValidationErr("delta must not be zero", nil).Create()), nil
+	}
+	reason := strings.TrimSpace(request.Body.Reason)
+	if reason == "" {
+		return api.AdjustItemStock400JSONResponse(ValidationErr("reason is required", nil).Create()), nil
+	}
+	kind := db.StockMovementKindAdjust
+	if request.Body.Kind != nil {
+		switch *request.Body.Kind {
+		case api.StockAdjustmentRequestKindAdjust, api.StockAdjustmentRequestKindPurchase, api.StockAdjustmentRequestKindWriteOff:
+			kind = db.StockMovementKind(*request.Body.Kind)
+		default:
+			return api.AdjustItemStock400JSONResponse(ValidationErr("kind must be adjust, purchase or write_off", nil).Create()), nil
+		}
+	}
+
+	movement, err := s.adjustItemStock(ctx, request.ItemId, int32(request.Body.Delta), stockMovement{
+		Kind:    kind,
+		Reason:  reason,
+		ActorID: &user.ID,
+	})
+	if err != nil {
+		switch apiErr := errorFor(err); apiErr.Code {
+		case CodeResourceNotFound:
+			return api.AdjustItemStock404JSONResponse(apiErr.Create()), nil
+		case CodeConflict:
+			return api.AdjustItemStock409JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to adjust stock", "item_id", request.ItemId, "error", err)
+		return api.AdjustItemStock500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	s.publishStockChanged(ctx, movement.ItemID)
+
+	logger.Info("Item stock adjusted",
+		"item_id", movement.ItemID,
+		"delta", movement.Delta,
+		"kind", movement.Kind,
+		"user_id", user.ID)
+
+	return api.AdjustItemStock201JSONResponse(toStockMovementResponse(movement)), nil
+}
+
+// moves the item's stock by delta and returns the movement the ledger
+// recorded for it.
+func (s Server) adjustItemStock(ctx context.Context, itemID uuid.UUID, delta int32, movement stockMovement) (db.StockMovement, error) {
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		return db.StockMovement{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	item, err := qtx.GetItemByIDForUpdate(ctx, itemID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.StockMovement{}, NotFound("Item")
+	}
+	if err != nil {
+		return db.StockMovement{}, err
+	}
+
+	// the units trigger would put the stock straight back
+	tracked, err := qtx.ItemHasUnits(ctx, item.ID)
+	if err != nil {
+		return db.StockMovement{}, err
+	}
+	if tracked {
+		return db.StockMovement{}, errStockTrackedByUnit
+	}
+
+	if err := tagStockMovement(ctx, qtx, movement); err != nil {
+		return db.StockMovement{}, err
+	}
+	if _, err := qtx.AdjustItemStock(ctx, db.AdjustItemStockParams{Delta: delta, ID: item.ID}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.StockMovement{}, errStockBelowZero
+		}
+		return db.StockMovement{}, err
+	}
+
+	recorded, err := qtx.GetLatestStockMovement(ctx, item.ID)
+	if err != nil {
+		return db.StockMovement{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return db.StockMovement{}, err
+	}
+	return recorded, nil
+}
+
+func (s Server) GetItemStockMovements(ctx context.Context, request api.GetItemStockMovementsRequestObject) (api.GetItemStockMovementsResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetItemStockMovements401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.GetItemStockMovements500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetItemStockMovements403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	// archived items keep their ledger
+	if _, err := s.db.Queries().GetItemByIDIncludingBinned(ctx, request.ItemId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.GetItemStockMovements404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.GetItemStockMovements500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	list, err := s.db.Queries().ListStockMovements(ctx, db.ListStockMovementsParams{
+		ItemID: request.ItemId,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		logger.Error("Failed to list stock movements", "item_id", request.ItemId, "error", err)
+		return api.GetItemStockMovements500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountStockMovements(ctx, request.ItemId)
+	if err != nil {
+		logger.Error("Failed to count stock movements", "item_id", request.ItemId, "error", err)
+		return api.GetItemStockMovements500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	data := make([]api.StockMovement, 0, len(list))
+	for _, m := range list {
+		data = append(data, toStockMovementResponse(m))
+	}
+
+	return api.GetItemStockMovements200JSONResponse{
+		Data: data,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_StockMovements(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@ledger.test").AsGlobalAdmin().Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	adjust := func(t *testing.T, itemID uuid.UUID, body api.StockAdjustmentRequest) api.AdjustItemStockResponseObject {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.AdjustItemStock(ctx, api.AdjustItemStockRequestObject{ItemId: itemID, Body: &body})
+		require.NoError(t, err)
+		return resp
+	}
+	ledger := func(t *testing.T, itemID uuid.UUID) []api.StockMovement {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.GetItemStockMovements(ctx, api.GetItemStockMovementsRequestObject{ItemId: itemID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItemStockMovements200JSONResponse{}, resp)
+		listed := resp.(api.GetItemStockMovements200JSONResponse)
+		assert.Equal(t, len(listed.Data), listed.Meta.Total)
+		return listed.Data
+	}
+	stockOf := func(t *testing.T, itemID uuid.UUID) int32 {
+		t.Helper()
+		item, err := testDB.Queries().GetItemByID(context.Background(), itemID)
+		require.NoError(t, err)
+		return item.Stock
+	}
+
+	t.Run("an adjustment moves the stock and lands in the ledger", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Microphone").WithType("medium").WithStock(5).Create()
+
+		writeOff := api.StockAdjustmentRequestKindWriteOff
+		resp := adjust(t, item.ID, api.StockAdjustmentRequest{Delta: -2, Kind: &writeOff, Reason: "Two found broken at the stocktake"})
+		require.IsType(t, api.AdjustItemStock201JSONResponse{}, resp)
+		movement := resp.(api.AdjustItemStock201JSONResponse)
+		assert.Equal(t, -2, movement.Delta)
+		assert.Equal(t, 3, movement.Balance)
+		assert.Equal(t, api.StockMovementKindWriteOff, movement.Kind)
+		require.NotNil(t, movement.ActorId)
+		assert.Equal(t, admin.ID, *movement.ActorId)
+		assert.Equal(t, int32(3), stockOf(t, item.ID))
+
+		movements := ledger(t, item.ID)
+		require.Len(t, movements, 2)
+		assert.Equal(t, movement.Id, movements[0].Id)
+		assert.Equal(t, api.StockMovementKindPurchase, movements[1].Kind, "the item's opening stock")
+		assert.Equal(t, 5, movements[1].Delta)
+	})
+
+	t.Run("stock changes from elsewhere are recorded with their kind", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Projector").WithType("medium").WithStock(4).Create()
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		quantity := 1
+		resp, err := server.StartItemMaintenance(ctx, api.StartItemMaintenanceRequestObject{
+			ItemId: item.ID,
+			Body:   &api.StartItemMaintenanceRequest{Quantity: &quantity, Reason: "Lamp replacement"},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.StartItemMaintenance201JSONResponse{}, resp)
+		started := resp.(api.StartItemMaintenance201JSONResponse)
+
+		movements := ledger(t, item.ID)
+		require.Len(t, movements, 2)
+		assert.Equal(t, api.StockMovementKindMaintenance, movements[0].Kind)
+		assert.Equal(t, -1, movements[0].Delta)
+		assert.Equal(t, 3, movements[0].Balance)
+		require.NotNil(t, movements[0].ReferenceId)
+		assert.Equal(t, started.Id, *movements[0].ReferenceId)
+		require.NotNil(t, movements[0].Reason)
+		assert.Equal(t, "Lamp replacement", *movements[0].Reason)
+	})
+
+	t.Run("stock cannot go below zero", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Tripod").WithType("medium").WithStock(1).Create()
+
+		resp := adjust(t, item.ID, api.StockAdjustmentRequest{Delta: -2, Reason: "Miscounted"})
+		require.IsType(t, api.AdjustItemStock409JSONResponse{}, resp)
+		assert.Equal(t, int32(1), stockOf(t, item.ID))
+		assert.Len(t, ledger(t, item.ID), 1)
+	})
+
+	t.Run("a zero delta or a missing reason is rejected", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Light Stand").WithType("medium").WithStock(1).Create()
+
+		resp := adjust(t, item.ID, api.StockAdjustmentRequest{Delta: 0, Reason: "Nothing"})
+		require.IsType(t, api.AdjustItemStock400JSONResponse{}, resp)
+
+		resp = adjust(t, item.ID, api.StockAdjustmentRequest{Delta: 3, Reason: "  "})
+		require.IsType(t, api.AdjustItemStock400JSONResponse{}, resp)
+	})
+
+	t.Run("unknown items are not found", func(t *testing.T) {
+		resp := adjust(t, uuid.New(), api.StockAdjustmentRequest{Delta: 1, Reason: "Found one"})
+		require.IsType(t, api.AdjustItemStock404JSONResponse{}, resp)
+	})
+}
@@ -0,0 +1,501 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func toStocktakeResponse(st db.Stocktake) api.Stocktake {
+	resp := api.Stocktake{
+		Id:       st.ID,
+		Status:   api.StocktakeStatus(st.Status),
+		OpenedBy: st.OpenedBy,
+		OpenedAt: st.OpenedAt.Time,
+		ClosedBy: st.ClosedBy,
+	}
+	if st.Notes.Valid {
+		resp.Notes = &st.Notes.String
+	}
+	if st.ClosedAt.Valid {
+		resp.ClosedAt = &st.ClosedAt.Time
+	}
+	return resp
+}
+
+func toStocktakeCountResponse(c db.ListStocktakeCountsRow) api.StocktakeCount {
+	resp := api.StocktakeCount{
+		ItemId:         c.ItemID,
+		ItemName:       c.ItemName,
+		Expected:       int(c.Expected),
+		Counted:        int(c.Counted),
+		Discrepancy:    int(c.Counted - c.Expected),
+		MissingUnitIds: c.MissingUnitIds,
+		CountedBy:      c.CountedBy,
+		CountedAt:      c.CountedAt.Time,
+		MovementId:     c.MovementID,
+	}
+	if resp.MissingUnitIds == nil {
+		resp.MissingUnitIds = []uuid.UUID{}
+	}
+	if c.Notes.Valid {
+		resp.Notes = &c.Notes.String
+	}
+	return resp
+}
+
+// returned as client-facing errors, so handlers pass them through errorFor
+var (
+	errStocktakeClosed   = ConflictErr("Stocktake is closed")
+	errStocktakeCounting = ConflictErr("A stocktake is already being counted")
+)
+
+// the stocktake with its counts, read through q so a closing transaction
+// reports the corrections it made
+func stocktakeReport(ctx context.Context, q *db.Queries, st db.Stocktake) (api.StocktakeReport, error) {
+	counts, err := q.ListStocktakeCounts(ctx, st.ID)
+	if err != nil {
+		return api.StocktakeReport{}, err
+	}
+	uncounted, err := q.CountUncountedItems(ctx, st.ID)
+	if err != nil {
+		return api.StocktakeReport{}, err
+	}
+
+	report := api.StocktakeReport{
+		Stocktake:      toStocktakeResponse(st),
+		Counts:         make([]api.StocktakeCount, 0, len(counts)),
+		UncountedItems: int(uncounted),
+	}
+	for _, c := range counts {
+		count := toStocktakeCountResponse(c)
+		if count.Discrepancy != 0 || len(count.MissingUnitIds) > 0 {
+			report.Discrepancies++
+		}
+		report.Counts = append(report.Counts, count)
+	}
+	return report, nil
+}
+
+func (s Server) ListStocktakes(ctx context.Context, request api.ListStocktakesRequestObject) (api.ListStocktakesResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListStocktakes401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.ListStocktakes500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListStocktakes403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	list, err := s.db.Queries().ListStocktakes(ctx, db.ListStocktakesParams{Limit: limit, Offset: offset})
+	if err != nil {
+		logger.Error("Failed to list stocktakes", "error", err)
+		return api.ListStocktakes500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountStocktakes(ctx)
+	if err != nil {
+		logger.Error("Failed to count stocktakes", "error", err)
+		return api.ListStocktakes500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	data := make([]api.Stocktake, 0, len(list))
+	for _, st := range list {
+		data = append(data, toStocktakeResponse(st))
+	}
+
+	return api.ListStocktakes200JSONResponse{
+		Data: data,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
+
+func (s Server) OpenStocktake(ctx context.Context, request api.OpenStocktakeRequestObject) (api.OpenStocktakeResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.OpenStocktake401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.OpenStocktake500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.OpenStocktake403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	params := db.OpenStocktakeParams{OpenedBy: &user.ID}
+	if request.Body != nil && request.Body.Notes != nil {
+		if notes := strings.TrimSpace(*request.Body.Notes); notes != "" {
+			params.Notes = pgtype.Text{String: notes, Valid: true}
+		}
+	}
+
+	st, err := s.db.Queries().OpenStocktake(ctx, params)
+	if err != nil {
+		// idx_stocktakes_counting allows one stocktake at a time
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return api.OpenStocktake409JSONResponse(errStocktakeCounting.Create()), nil
+		}
+		logger.Error("Failed to open stocktake", "error", err)
+		return api.OpenStocktake500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Stocktake opened", "stocktake_id", st.ID, "user_id", user.ID)
+
+	return api.OpenStocktake201JSONResponse(toStocktakeResponse(st)), nil
+}
+
+func (s Server) GetStocktakeReport(ctx context.Context, request api.GetStocktakeReportRequestObject) (api.GetStocktakeReportResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetStocktakeReport401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.GetStocktakeReport500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetStocktakeReport403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	st, err := s.db.Queries().GetStocktakeByID(ctx, request.StocktakeId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.GetStocktakeReport404JSONResponse(NotFound("Stocktake").Create()), nil
+		}
+		logger.Error("Failed to get stocktake", "stocktake_id", request.StocktakeId, "error", err)
+		return api.GetStocktakeReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	report, err := stocktakeReport(ctx, s.db.Queries(), st)
+	if err != nil {
+		logger.Error("Failed to build stocktake report", "stocktake_id", st.ID, "error", err)
+		return api.GetStocktakeReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if wantsCSV(request.Params.Format) {
+		records := make([][]string, 0, len(report.Counts))
+		for _, c := range report.Counts {
+			records = append(records, stocktakeCountRecord(c))
+		}
+		body, err := writeCSV(stocktakeCountColumns, records)
+		if err != nil {
+			logger.Error("Failed to write stocktake report as CSV", "stocktake_id", st.ID, "error", err)
+			return api.GetStocktakeReport500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		return api.GetStocktakeReport200TextcsvResponse{Body: body, ContentLength: int64(body.Len())}, nil
+	}
+	return api.GetStocktakeReport200JSONResponse(report), nil
+}
+
+func (s Server) RecordStocktakeCount(ctx context.Context, request api.RecordStocktakeCountRequestObject) (api.RecordStocktakeCountResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RecordStocktakeCount401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.RecordStocktakeCount500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.RecordStocktakeCount403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.RecordStocktakeCount400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	if request.Body.Counted != nil && *request.Body.Counted < 0 {
+		return api.RecordStocktakeCount400JSONResponse(ValidationErr("counted cannot be negative", nil).Create()), nil
+	}
+
+	count, err := s.recordStocktakeCount(ctx, request.StocktakeId, request.ItemId, user.ID, *request.Body)
+	if err != nil {
+		switch apiErr := errorFor(err); apiErr.Code {
+		case CodeValidationError:
+			return api.RecordStocktakeCount400JSONResponse(apiErr.Create()), nil
+		case CodeResourceNotFound:
+			return api.RecordStocktakeCount404JSONResponse(apiErr.Create()), nil
+		case CodeConflict:
+			return api.RecordStocktakeCount409JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to record stocktake count",
+			"stocktake_id", request.StocktakeId,
+			"item_id", request.ItemId,
+			"error", err)
+		return api.RecordStocktakeCount500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Stocktake count recorded",
+		"stocktake_id", request.StocktakeId,
+		"item_id", count.ItemId,
+		"discrepancy", count.Discrepancy,
+		"user_id", user.ID)
+
+	return api.RecordStocktakeCount200JSONResponse(count), nil
+}
+
+// records the item's count against its stock as it is now. Items tracked by
+// unit are counted by the units found, and the available units that were not
+// are kept as missing.
+func (s Server) recordStocktakeCount(ctx context.Context, stocktakeID, itemID, countedBy uuid.UUID, body api.RecordStocktakeCountRequest) (api.StocktakeCount, error) {
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		return api.StocktakeCount{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	// locked so the stocktake cannot close under the count
+	st, err := qtx.GetStocktakeForUpdate(ctx, stocktakeID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return api.StocktakeCount{}, NotFound("Stocktake")
+	}
+	if err != nil {
+		return api.StocktakeCount{}, err
+	}
+	if st.Status != db.StocktakeStatusCounting {
+		return api.StocktakeCount{}, errStocktakeClosed
+	}
+
+	item, err := qtx.GetItemByIDForUpdate(ctx, itemID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return api.StocktakeCount{}, NotFound("Item")
+	}
+	if err != nil {
+		return api.StocktakeCount{}, err
+	}
+
+	params := db.RecordStocktakeCountParams{
+		StocktakeID:    st.ID,
+		ItemID:         item.ID,
+		Expected:       item.Stock,
+		UnitIds:        []uuid.UUID{},
+		MissingUnitIds: []uuid.UUID{},
+		CountedBy:      &countedBy,
+	}
+	if body.Notes != nil {
+		if notes := strings.TrimSpace(*body.Notes); notes != "" {
+			params.Notes = pgtype.Text{String: notes, Valid: true}
+		}
+	}
+
+	units, err := qtx.ListItemUnits(ctx, item.ID)
+	if err != nil {
+		return api.StocktakeCount{}, err
+	}
+	if len(units) == 0 {
+		if body.UnitIds != nil && len(*body.UnitIds) > 0 {
+			return api.StocktakeCount{}, ValidationErr("Item is not tracked by unit; send counted instead of unit_ids", nil)
+		}
+		if body.Counted == nil {
+			return api.StocktakeCount{}, ValidationErr("counted is required", nil)
+		}
+		params.Counted = int32(*body.Counted)
+	} else {
+		if body.UnitIds == nil {
+			return api.StocktakeCount{}, ValidationErr("Item is tracked by unit; send the unit_ids found", nil)
+		}
+		found := make(map[uuid.UUID]bool, len(*body.UnitIds))
+		for _, id := range *body.UnitIds {
+			found[id] = true
+		}
+		if body.Counted != nil && *body.Counted != len(found) {
+			return api.StocktakeCount{}, ValidationErr("counted does not match the number of unit_ids", nil)
+		}
+		for _, unit := range units {
+			if found[unit.ID] {
+				params.UnitIds = append(params.UnitIds, unit.ID)
+				delete(found, unit.ID)
+			} else if unit.Status == db.UnitStatusAvailable {
+				params.MissingUnitIds = append(params.MissingUnitIds, unit.ID)
+			}
+		}
+		if len(found) > 0 {
+			return api.StocktakeCount{}, ValidationErr("unit_ids includes units that do not belong to the item", nil)
+		}
+		params.Counted = int32(len(params.UnitIds))
+	}
+
+	count, err := qtx.RecordStocktakeCount(ctx, params)
+	if err != nil {
+		return api.StocktakeCount{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return api.StocktakeCount{}, err
+	}
+
+	return toStocktakeCountResponse(db.ListStocktakeCountsRow{
+		StocktakeID:    count.StocktakeID,
+		ItemID:         count.ItemID,
+		ItemName:       item.Name,
+		Expected:       count.Expected,
+		Counted:        count.Counted,
+		UnitIds:        count.UnitIds,
+		MissingUnitIds: count.MissingUnitIds,
+		Notes:          count.Notes,
+		CountedBy:      count.CountedBy,
+		CountedAt:      count.CountedAt,
+		MovementID:     count.MovementID,
+	}), nil
+}
+
+func (s Server) CloseStocktake(ctx context.Context, request api.CloseStocktakeRequestObject) (api.CloseStocktakeResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CloseStocktake401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.CloseStocktake500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CloseStocktake403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	report, adjusted, err := s.closeStocktake(ctx, request.StocktakeId, user.ID)
+	if err != nil {
+		switch apiErr := errorFor(err); apiErr.Code {
+		case CodeResourceNotFound:
+			return api.CloseStocktake404JSONResponse(apiErr.Create()), nil
+		case CodeConflict:
+			return api.CloseStocktake409JSONResponse(apiErr.Create()), nil
+		}
+		logger.Error("Failed to close stocktake", "stocktake_id", request.StocktakeId, "error", err)
+		return api.CloseStocktake500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	for _, itemID := range adjusted {
+		s.publishStockChanged(ctx, itemID)
+	}
+
+	logger.Info("Stocktake closed",
+		"stocktake_id", request.StocktakeId,
+		"discrepancies", report.Discrepancies,
+		"adjusted", len(adjusted),
+		"user_id", user.ID)
+
+	return api.CloseStocktake200JSONResponse(report), nil
+}
+
+// closes the stocktake and moves each miscounted item's stock by what the
+// count found against what it expected, so changes made since the count are
+// kept. The stock of items tracked by unit follows their units, so their
+// missing units are only reported. Returns the items whose stock moved.
+func (s Server) closeStocktake(ctx context.Context, stocktakeID, closedBy uuid.UUID) (api.StocktakeReport, []uuid.UUID, error) {
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		return api.StocktakeReport{}, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	if _, err := qtx.GetStocktakeForUpdate(ctx, stocktakeID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.StocktakeReport{}, nil, NotFound("Stocktake")
+		}
+		return api.StocktakeReport{}, nil, err
+	}
+	st, err := qtx.CloseStocktake(ctx, db.CloseStocktakeParams{ID: stocktakeID, ClosedBy: &closedBy})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return api.StocktakeReport{}, nil, errStocktakeClosed
+	}
+	if err != nil {
+		return api.StocktakeReport{}, nil, err
+	}
+
+	counts, err := qtx.ListStocktakeCounts(ctx, st.ID)
+	if err != nil {
+		return api.StocktakeReport{}, nil, err
+	}
+
+	var adjusted []uuid.UUID
+	for _, c := range counts {
+		delta := c.Counted - c.Expected
+		if delta == 0 {
+			continue
+		}
+		tracked, err := qtx.ItemHasUnits(ctx, c.ItemID)
+		if err != nil {
+			return api.StocktakeReport{}, nil, err
+		}
+		if tracked {
+			continue
+		}
+
+		if err := tagStockMovement(ctx, qtx, stockMovement{
+			Kind:        db.StockMovementKindAdjust,
+			Reason:      "stocktake",
+			ActorID:     &closedBy,
+			ReferenceID: &st.ID,
+		}); err != nil {
+			return api.StocktakeReport{}, nil, err
+		}
+		if _, err := qtx.AdjustItemStock(ctx, db.AdjustItemStockParams{Delta: delta, ID: c.ItemID}); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return api.StocktakeReport{}, nil, ConflictErr("Correcting " + c.ItemName + " would take its stock below zero; recount it")
+			}
+			return api.StocktakeReport{}, nil, err
+		}
+		movement, err := qtx.GetLatestStockMovement(ctx, c.ItemID)
+		if err != nil {
+			return api.StocktakeReport{}, nil, err
+		}
+		if err := qtx.SetStocktakeCountMovement(ctx, db.SetStocktakeCountMovementParams{
+			StocktakeID: st.ID,
+			ItemID:      c.ItemID,
+			MovementID:  &movement.ID,
+		}); err != nil {
+			return api.StocktakeReport{}, nil, err
+		}
+		adjusted = append(adjusted, c.ItemID)
+	}
+
+	report, err := stocktakeReport(ctx, qtx, st)
+	if err != nil {
+		return api.StocktakeReport{}, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return api.StocktakeReport{}, nil, err
+	}
+	return report, adjusted, nil
+}
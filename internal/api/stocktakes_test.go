@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Stocktakes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@stocktake.test").AsGlobalAdmin().Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	open := func(t *testing.T) api.OpenStocktakeResponseObject {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		notes := "Fall semester count"
+		resp, err := server.OpenStocktake(ctx, api.OpenStocktakeRequestObject{Body: &api.OpenStocktakeRequest{Notes: &notes}})
+		require.NoError(t, err)
+		return resp
+	}
+	count := func(t *testing.T, stocktakeID, itemID uuid.UUID, counted int) api.RecordStocktakeCountResponseObject {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.RecordStocktakeCount(ctx, api.RecordStocktakeCountRequestObject{
+			StocktakeId: stocktakeID,
+			ItemId:      itemID,
+			Body:        &api.RecordStocktakeCountRequest{Counted: &counted},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+	closeStocktake := func(t *testing.T, stocktakeID uuid.UUID) api.CloseStocktakeResponseObject {
+		t.Helper()
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		resp, err := server.CloseStocktake(ctx, api.CloseStocktakeRequestObject{StocktakeId: stocktakeID})
+		require.NoError(t, err)
+		return resp
+	}
+	stockOf := func(t *testing.T, itemID uuid.UUID) int32 {
+		t.Helper()
+		item, err := testDB.Queries().GetItemByID(context.Background(), itemID)
+		require.NoError(t, err)
+		return item.Stock
+	}
+
+	t.Run("closing a stocktake corrects the stock of miscounted items", func(t *testing.T) {
+		short := testDB.NewItem(t).WithName("Microphone").WithType("medium").WithStock(5).Create()
+		right := testDB.NewItem(t).WithName("Tripod").WithType("medium").WithStock(2).Create()
+
+		resp := open(t)
+		require.IsType(t, api.OpenStocktake201JSONResponse{}, resp)
+		stocktake := resp.(api.OpenStocktake201JSONResponse)
+		assert.Equal(t, api.Counting, stocktake.Status)
+
+		again := open(t)
+		require.IsType(t, api.OpenStocktake409JSONResponse{}, again, "one stocktake at a time")
+
+		counted := count(t, stocktake.Id, short.ID, 3)
+		require.IsType(t, api.RecordStocktakeCount200JSONResponse{}, counted)
+		assert.Equal(t, 5, counted.(api.RecordStocktakeCount200JSONResponse).Expected)
+		assert.Equal(t, -2, counted.(api.RecordStocktakeCount200JSONResponse).Discrepancy)
+		require.IsType(t, api.RecordStocktakeCount200JSONResponse{}, count(t, stocktake.Id, right.ID, 2))
+
+		closed := closeStocktake(t, stocktake.Id)
+		require.IsType(t, api.CloseStocktake200JSONResponse{}, closed)
+		report := closed.(api.CloseStocktake200JSONResponse)
+		assert.Equal(t, api.Closed, report.Stocktake.Status)
+		assert.Equal(t, 1, report.Discrepancies)
+		require.Len(t, report.Counts, 2)
+		assert.Equal(t, short.ID, report.Counts[0].ItemId)
+		require.NotNil(t, report.Counts[0].MovementId)
+		assert.Nil(t, report.Counts[1].MovementId)
+
+		assert.Equal(t, int32(3), stockOf(t, short.ID))
+		assert.Equal(t, int32(2), stockOf(t, right.ID))
+
+		movement, err := testDB.Queries().GetLatestStockMovement(context.Background(), short.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *report.Counts[0].MovementId, movement.ID)
+		require.NotNil(t, movement.ReferenceID)
+		assert.Equal(t, stocktake.Id, *movement.ReferenceID)
+
+		require.IsType(t, api.RecordStocktakeCount409JSONResponse{}, count(t, stocktake.Id, right.ID, 1))
+		require.IsType(t, api.CloseStocktake409JSONResponse{}, closeStocktake(t, stocktake.Id))
+	})
+
+	t.Run("the report exports as CSV", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Light Stand").WithType("medium").WithStock(1).Create()
+
+		resp := open(t)
+		require.IsType(t, api.OpenStocktake201JSONResponse{}, resp)
+		stocktake := resp.(api.OpenStocktake201JSONResponse)
+		require.IsType(t, api.RecordStocktakeCount200JSONResponse{}, count(t, stocktake.Id, item.ID, 1))
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+		format := api.ExportFormatCsv
+		report, err := server.GetStocktakeReport(ctx, api.GetStocktakeReportRequestObject{
+			StocktakeId: stocktake.Id,
+			Params:      api.GetStocktakeReportParams{Format: &format},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.GetStocktakeReport200TextcsvResponse{}, report)
+
+		require.IsType(t, api.CloseStocktake200JSONResponse{}, closeStocktake(t, stocktake.Id))
+	})
+
+	t.Run("counts are validated", func(t *testing.T) {
+		item := testDB.NewItem(t).WithName("Projector").WithType("medium").WithStock(1).Create()
+
+		resp := open(t)
+		require.IsType(t, api.OpenStocktake201JSONResponse{}, resp)
+		stocktake := resp.(api.OpenStocktake201JSONResponse)
+
+		require.IsType(t, api.RecordStocktakeCount400JSONResponse{}, count(t, stocktake.Id, item.ID, -1))
+		require.IsType(t, api.RecordStocktakeCount404JSONResponse{}, count(t, stocktake.Id, uuid.New(), 1))
+		require.IsType(t, api.RecordStocktakeCount404JSONResponse{}, count(t, uuid.New(), item.ID, 1))
+
+		require.IsType(t, api.CloseStocktake200JSONResponse{}, closeStocktake(t, stocktake.Id))
+	})
+}
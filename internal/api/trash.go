@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/bookingevents"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// database trash row to API response
+func convertToTrashEntryResponse(row db.ListTrashRow) api.TrashEntry {
+	entry := api.TrashEntry{
+		EntityType: api.TrashEntityType(row.EntityType),
+		EntityId:   row.EntityID,
+		EntityName: row.EntityName,
+		RemovedBy:  row.RemovedBy,
+		Restorable: row.Restorable,
+	}
+	if row.RemovedAt.Valid {
+		entry.RemovedAt = &row.RemovedAt.Time
+	}
+	if row.Reason.Valid {
+		entry.Reason = &row.Reason.String
+	}
+	if row.PurgeAfter.Valid {
+		entry.PurgeAfter = &row.PurgeAfter.Time
+	}
+	return entry
+}
+
+func (s Server) ListTrash(ctx context.Context, request api.ListTrashRequestObject) (api.ListTrashResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListTrash401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewAllData, nil)
+	if err != nil {
+		logger.Error("Error checking view_all_data permission",
+			"user_id", user.ID,
+			"permission", rbac.ViewAllData,
+			"error", err)
+		return api.ListTrash500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListTrash403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	var entityType pgtype.Text
+	if request.Params.Type != nil {
+		entityType = pgtype.Text{String: string(*request.Params.Type), Valid: true}
+	}
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	rows, err := s.db.Queries().ListTrash(ctx, db.ListTrashParams{
+		EntityType: entityType,
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		logger.Error("Failed to list trash", "error", err)
+		return api.ListTrash500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	total, err := s.db.Queries().CountTrash(ctx, entityType)
+	if err != nil {
+		logger.Error("Failed to count trash", "error", err)
+		return api.ListTrash500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	data := make([]api.TrashEntry, 0, len(rows))
+	for _, row := range rows {
+		data = append(data, convertToTrashEntryResponse(row))
+	}
+
+	return api.ListTrash200JSONResponse{
+		Data: data,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
+
+// groups and items go back through their deletion request; cancelled bookings
+// are reinstated as confirmed if that is still safe.
+func (s Server) RestoreTrashEntry(ctx context.Context, request api.RestoreTrashEntryRequestObject) (api.RestoreTrashEntryResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.RestoreTrashEntry401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	var permission string
+	switch request.EntityType {
+	case api.TrashEntityTypeGroup:
+		permission = deletionPermission(db.DeletionEntityGroup)
+	case api.TrashEntityTypeItem:
+		permission = deletionPermission(db.DeletionEntityItem)
+	case api.TrashEntityTypeBooking:
+		permission = rbac.ManageAllBookings
+	default:
+		return api.RestoreTrashEntry404JSONResponse(NotFound("Trash entry").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, permission, nil)
+	if err != nil {
+		logger.Error("Error checking restore permission",
+			"user_id", user.ID,
+			"permission", permission,
+			"error", err)
+		return api.RestoreTrashEntry500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.RestoreTrashEntry403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	tx, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		logger.Error("Failed to begin transaction", "error", err)
+		return api.RestoreTrashEntry500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := s.db.Queries().WithTx(tx)
+
+	if request.EntityType == api.TrashEntityTypeBooking {
+		booking, err := qtx.GetBookingByIDForUpdate(ctx, request.EntityId)
+		if err != nil && err != pgx.ErrNoRows {
+			logger.Error("Failed to get booking", "booking_id", request.EntityId, "error", err)
+			return api.RestoreTrashEntry500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		if err == pgx.ErrNoRows || booking.Status != db.RequestStatusCancelled {
+			return api.RestoreTrashEntry404JSONResponse(NotFound("Trash entry").Create()), nil
+		}
+		// an unconfirmed booking would be expired again straight away
+		if !booking.ConfirmedAt.Valid {
+			return api.RestoreTrashEntry409JSONResponse(ConflictErr("Only bookings that were confirmed can be restored").Create()), nil
+		}
+		if !booking.PickUpDate.Time.After(time.Now()) {
+			return api.RestoreTrashEntry409JSONResponse(ConflictErr("Booking pickup has already passed").Create()), nil
+		}
+
+		restored, err := qtx.RestoreCancelledBooking(ctx, booking.ID)
+		if err != nil {
+			logger.Error("Failed to restore booking", "booking_id", booking.ID, "error", err)
+			return api.RestoreTrashEntry500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		if err := bookingevents.Record(ctx, qtx, restored.ID, &user.ID,
+			db.NullRequestStatus{RequestStatus: booking.Status, Valid: true}, restored.Status,
+			map[string]any{"restored_from_trash": true}); err != nil {
+			logger.Error("Failed to record booking restore", "booking_id", restored.ID, "error", err)
+			return api.RestoreTrashEntry500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+	} else {
+		deletion, err := qtx.GetBinnedDeletionRequestForUpdate(ctx, db.GetBinnedDeletionRequestForUpdateParams{
+			EntityType: db.DeletionEntity(request.EntityType),
+			EntityID:   request.EntityId,
+		})
+		if err == pgx.ErrNoRows {
+			return api.RestoreTrashEntry404JSONResponse(NotFound("Trash entry").Create()), nil
+		}
+		if err != nil {
+			logger.Error("Failed to get deletion request", "entity_id", request.EntityId, "error", err)
+			return api.RestoreTrashEntry500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+
+		if _, err := qtx.ResolveDeletionRequest(ctx, db.ResolveDeletionRequestParams{
+			ID:         deletion.ID,
+			Status:     db.DeletionStatusRestored,
+			ResolvedBy: &user.ID,
+		}); err != nil {
+			logger.Error("Failed to restore deletion request", "deletion_id", deletion.ID, "error", err)
+			return api.RestoreTrashEntry500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit restore", "entity_id", request.EntityId, "error", err)
+		return api.RestoreTrashEntry500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	if request.EntityType == api.TrashEntityTypeItem {
+		s.invalidateCatalog(ctx)
+	}
+
+	logger.Info("Entity restored from trash",
+		"entity_type", request.EntityType,
+		"entity_id", request.EntityId,
+		"restored_by", user.ID)
+
+	return api.RestoreTrashEntry204Response{}, nil
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ListTrash(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+	sharedQueue.Cleanup(t)
+
+	requester := testDB.NewUser(t).WithEmail("requester@trash.test").AsGlobalAdmin().Create()
+	approver := testDB.NewUser(t).WithEmail("approver@trash.test").AsGlobalAdmin().Create()
+	member := testDB.NewUser(t).WithEmail("member@trash.test").AsMember().Create()
+	group := testDB.NewGroup(t).WithName("Trashed Club").Create()
+	item := testDB.NewItem(t).WithName("Tripod").WithType("high").WithStock(1).Create()
+
+	requesterCtx := testutil.ContextWithUser(context.Background(), requester, testDB.Queries())
+	approverCtx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+
+	reason := "Club dissolved"
+	mockAuth.ExpectCheckPermission(requester.ID, rbac.ManageGroups, nil, true, nil)
+	response, err := server.DeleteGroup(requesterCtx, api.DeleteGroupRequestObject{
+		Id:     group.ID,
+		Params: api.DeleteGroupParams{Reason: &reason},
+	})
+	require.NoError(t, err)
+	deletion := response.(api.DeleteGroup202JSONResponse)
+
+	mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageGroups, nil, true, nil)
+	_, err = server.ApproveDeletionRequest(approverCtx, api.ApproveDeletionRequestRequestObject{Id: deletion.Id})
+	require.NoError(t, err)
+
+	availability := createTestAvailability(t, testDB, approver.ID)
+	booking := createTestBooking(t, testDB, availability.ID, member.ID, approver.ID, item.ID, group.ID,
+		db.RequestStatusPendingConfirmation, 0)
+	cancelReason := "No longer needed"
+	mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageAllBookings, nil, true, nil)
+	_, err = server.CancelBooking(approverCtx, api.CancelBookingRequestObject{
+		BookingId: booking.ID,
+		Body:      &api.CancelBookingJSONRequestBody{Reason: &cancelReason},
+	})
+	require.NoError(t, err)
+
+	t.Run("lists binned groups and cancelled bookings", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ViewAllData, nil, true, nil)
+		response, err := server.ListTrash(approverCtx, api.ListTrashRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.ListTrash200JSONResponse{}, response)
+
+		list := response.(api.ListTrash200JSONResponse)
+		assert.Equal(t, 2, list.Meta.Total)
+		for _, entry := range list.Data {
+			require.NotNil(t, entry.RemovedAt)
+			require.NotNil(t, entry.Reason)
+			switch entry.EntityType {
+			case api.TrashEntityTypeGroup:
+				assert.Equal(t, group.ID, entry.EntityId)
+				assert.Equal(t, requester.ID, *entry.RemovedBy)
+				assert.Equal(t, reason, *entry.Reason)
+				assert.NotNil(t, entry.PurgeAfter)
+				assert.True(t, entry.Restorable)
+			case api.TrashEntityTypeBooking:
+				assert.Equal(t, booking.ID, entry.EntityId)
+				assert.Equal(t, "Tripod", entry.EntityName)
+				assert.Equal(t, approver.ID, *entry.RemovedBy)
+				assert.Equal(t, cancelReason, *entry.Reason)
+				// never confirmed, so it cannot come back
+				assert.False(t, entry.Restorable)
+			}
+		}
+	})
+
+	t.Run("filters by type", func(t *testing.T) {
+		bookingType := api.TrashEntityTypeBooking
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ViewAllData, nil, true, nil)
+		response, err := server.ListTrash(approverCtx, api.ListTrashRequestObject{
+			Params: api.ListTrashParams{Type: &bookingType},
+		})
+		require.NoError(t, err)
+		list := response.(api.ListTrash200JSONResponse)
+		require.Len(t, list.Data, 1)
+		assert.Equal(t, booking.ID, list.Data[0].EntityId)
+	})
+
+	t.Run("requires view_all_data", func(t *testing.T) {
+		memberCtx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ViewAllData, nil, false, nil)
+		response, err := server.ListTrash(memberCtx, api.ListTrashRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.ListTrash403JSONResponse{}, response)
+	})
+}
+
+func TestServer_RestoreTrashEntry(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("restores a binned group", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		sharedQueue.Cleanup(t)
+
+		requester := testDB.NewUser(t).WithEmail("requester@trash.test").AsGlobalAdmin().Create()
+		approver := testDB.NewUser(t).WithEmail("approver@trash.test").AsGlobalAdmin().Create()
+		group := testDB.NewGroup(t).WithName("Restorable").Create()
+
+		requesterCtx := testutil.ContextWithUser(context.Background(), requester, testDB.Queries())
+		approverCtx := testutil.ContextWithUser(context.Background(), approver, testDB.Queries())
+
+		deletion := requestGroupDeletion(t, server, mockAuth, requesterCtx, requester.ID, group.ID)
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageGroups, nil, true, nil)
+		_, err := server.ApproveDeletionRequest(approverCtx, api.ApproveDeletionRequestRequestObject{Id: deletion.Id})
+		require.NoError(t, err)
+
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageGroups, nil, true, nil)
+		response, err := server.RestoreTrashEntry(approverCtx, api.RestoreTrashEntryRequestObject{
+			EntityType: api.TrashEntityTypeGroup,
+			EntityId:   group.ID,
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RestoreTrashEntry204Response{}, response)
+
+		restored, err := testDB.Queries().GetDeletionRequestByID(approverCtx, deletion.Id)
+		require.NoError(t, err)
+		assert.Equal(t, db.DeletionStatusRestored, restored.Status)
+
+		// nothing left to restore
+		mockAuth.ExpectCheckPermission(approver.ID, rbac.ManageGroups, nil, true, nil)
+		response, err = server.RestoreTrashEntry(approverCtx, api.RestoreTrashEntryRequestObject{
+			EntityType: api.TrashEntityTypeGroup,
+			EntityId:   group.ID,
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RestoreTrashEntry404JSONResponse{}, response)
+	})
+
+	t.Run("reinstates a confirmed booking", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		admin := testDB.NewUser(t).WithEmail("admin@trash.test").AsGlobalAdmin().Create()
+		member := testDB.NewUser(t).WithEmail("member@trash.test").AsMember().Create()
+		group := testDB.NewGroup(t).WithName("Booking Club").Create()
+		item := testDB.NewItem(t).WithName("Lens").WithType("high").WithStock(1).Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		availability := createTestAvailability(t, testDB, admin.ID)
+		booking := createTestBooking(t, testDB, availability.ID, member.ID, admin.ID, item.ID, group.ID,
+			db.RequestStatusPendingConfirmation, 0)
+		_, err := testDB.Queries().ConfirmBooking(ctx, db.ConfirmBookingParams{ID: booking.ID, ConfirmedBy: &member.ID})
+		require.NoError(t, err)
+		_, err = testDB.Queries().CancelBooking(ctx, db.CancelBookingParams{ID: booking.ID, CancelledBy: &admin.ID})
+		require.NoError(t, err)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAllBookings, nil, true, nil)
+		response, err := server.RestoreTrashEntry(ctx, api.RestoreTrashEntryRequestObject{
+			EntityType: api.TrashEntityTypeBooking,
+			EntityId:   booking.ID,
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RestoreTrashEntry204Response{}, response)
+
+		restored, err := testDB.Queries().GetBookingByID(ctx, booking.ID)
+		require.NoError(t, err)
+		assert.Equal(t, db.RequestStatusConfirmed, restored.Status)
+		assert.Nil(t, restored.CancelledBy)
+	})
+
+	t.Run("refuses unconfirmed bookings", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		admin := testDB.NewUser(t).WithEmail("admin@trash.test").AsGlobalAdmin().Create()
+		member := testDB.NewUser(t).WithEmail("member@trash.test").AsMember().Create()
+		group := testDB.NewGroup(t).WithName("Booking Club").Create()
+		item := testDB.NewItem(t).WithName("Lens").WithType("high").WithStock(1).Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		availability := createTestAvailability(t, testDB, admin.ID)
+		booking := createTestBooking(t, testDB, availability.ID, member.ID, admin.ID, item.ID, group.ID,
+			db.RequestStatusCancelled, 0)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAllBookings, nil, true, nil)
+		response, err := server.RestoreTrashEntry(ctx, api.RestoreTrashEntryRequestObject{
+			EntityType: api.TrashEntityTypeBooking,
+			EntityId:   booking.ID,
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RestoreTrashEntry409JSONResponse{}, response)
+	})
+
+	t.Run("unknown booking", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+
+		admin := testDB.NewUser(t).WithEmail("admin@trash.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageAllBookings, nil, true, nil)
+		response, err := server.RestoreTrashEntry(ctx, api.RestoreTrashEntryRequestObject{
+			EntityType: api.TrashEntityTypeBooking,
+			EntityId:   uuid.New(),
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.RestoreTrashEntry404JSONResponse{}, response)
+	})
+}
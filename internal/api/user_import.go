@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/userimport"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func (s Server) ImportUsers(ctx context.Context, request api.ImportUsersRequestObject) (api.ImportUsersResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ImportUsers401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageUsers, nil)
+	if err != nil {
+		logger.Error("Error checking manage_users permission",
+			"user_id", user.ID,
+			"permission", rbac.ManageUsers,
+			"error", err)
+		return api.ImportUsers500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ImportUsers403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.ImportUsers400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	form, err := request.Body.ReadForm(32 << 20)
+	if err != nil {
+		return api.ImportUsers400JSONResponse(ValidationErr("Failed to parse multipart form", nil).Create()), nil
+	}
+	defer form.RemoveAll()
+
+	role := "member"
+	if values := form.Value["role"]; len(values) > 0 && values[0] != "" {
+		role = values[0]
+	}
+	if role != "member" && role != "group_admin" {
+		return api.ImportUsers400JSONResponse(ValidationErr("role must be 'member' or 'group_admin'", nil).Create()), nil
+	}
+
+	var defaultGroupID *uuid.UUID
+	if values := form.Value["default_group_id"]; len(values) > 0 && values[0] != "" {
+		id, err := uuid.Parse(values[0])
+		if err != nil {
+			return api.ImportUsers400JSONResponse(ValidationErr("default_group_id must be a UUID", nil).Create()), nil
+		}
+		if _, err := s.db.Queries().GetGroupByID(ctx, id); err != nil {
+			if err == pgx.ErrNoRows {
+				return api.ImportUsers404JSONResponse(NotFound("Group").Create()), nil
+			}
+			logger.Error("Failed to get group", "group_id", id, "error", err)
+			return api.ImportUsers500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		defaultGroupID = &id
+	}
+
+	files := form.File["file"]
+	if len(files) == 0 {
+		return api.ImportUsers400JSONResponse(ValidationErr("Missing file field", nil).Create()), nil
+	}
+	file, err := files[0].Open()
+	if err != nil {
+		return api.ImportUsers400JSONResponse(ValidationErr("Failed to open file", nil).Create()), nil
+	}
+	defer file.Close()
+
+	rows, err := userimport.Parse(file)
+	if err != nil {
+		return api.ImportUsers400JSONResponse(ValidationErr(err.Error(), nil).Create()), nil
+	}
+
+	groups, err := s.db.Queries().GetAllGroups(ctx)
+	if err != nil {
+		logger.Error("Failed to get groups", "error", err)
+		return api.ImportUsers500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	matcher := userimport.NewGroupMatcher()
+	for _, group := range groups {
+		matcher.Add(group.Name, group.ID)
+	}
+
+	run := userImportRun{
+		actorID:        user.ID,
+		queries:        s.db.Queries(),
+		begin:          s.db.Pool().Begin,
+		role:           role,
+		matcher:        matcher,
+		defaultGroupID: defaultGroupID,
+		dryRun:         isDryRun(request.Params.DryRun),
+	}
+	// a dry run imports every row inside one outer transaction, so repeated
+	// emails are reported the same way a real import would, and then rolls
+	// it all back
+	if run.dryRun {
+		outer, err := s.db.Pool().Begin(ctx)
+		if err != nil {
+			logger.Error("Failed to begin dry run transaction", "error", err)
+			return api.ImportUsers500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		defer outer.Rollback(ctx)
+		run.queries = s.db.Queries().WithTx(outer)
+		run.begin = outer.Begin
+	}
+
+	report := api.UserImportReport{DryRun: run.dryRun, Rows: make([]api.UserImportRow, 0, len(rows))}
+	for _, row := range rows {
+		result := s.importUserRow(ctx, run, row)
+		switch result.Status {
+		case api.UserImportRowStatusCreated:
+			report.Created++
+		case api.UserImportRowStatusExists:
+			report.Exists++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	logger.Info("Users imported",
+		"dry_run", run.dryRun,
+		"created", report.Created,
+		"exists", report.Exists,
+		"failed", report.Failed,
+		"user_id", user.ID)
+	return api.ImportUsers200JSONResponse(report), nil
+}
+
+type userImportRun struct {
+	actorID        uuid.UUID
+	queries        *db.Queries
+	begin          func(context.Context) (pgx.Tx, error)
+	role           string
+	matcher        *userimport.GroupMatcher
+	defaultGroupID *uuid.UUID
+	dryRun         bool
+}
+
+// creates the user for one row with its group roles, then emails the invite.
+// A failed invite doesn't undo the user; the row reports it instead.
+func (s Server) importUserRow(ctx context.Context, run userImportRun, row userimport.Row) api.UserImportRow {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	result := api.UserImportRow{Line: row.Line, Email: row.Email, GroupIds: []uuid.UUID{}}
+	fail := func(message string) api.UserImportRow {
+		result.Status = api.UserImportRowStatusFailed
+		result.Message = &message
+		return result
+	}
+	if row.Err != "" {
+		return fail(row.Err)
+	}
+
+	existing, err := run.queries.GetUserByEmail(ctx, row.Email)
+	if err == nil {
+		result.Status = api.UserImportRowStatusExists
+		result.UserId = &existing.ID
+		return result
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		logger.Error("Failed to look up user", "email", row.Email, "error", err)
+		return fail("An unexpected error occurred.")
+	}
+
+	groupIDs, unmatched := run.matcher.Match(row.GroupHints)
+	if len(groupIDs) == 0 && run.defaultGroupID != nil {
+		groupIDs = []uuid.UUID{*run.defaultGroupID}
+	}
+
+	tx, err := run.begin(ctx)
+	if err != nil {
+		logger.Error("Failed to start transaction", "error", err)
+		return fail("An unexpected error occurred.")
+	}
+	defer tx.Rollback(ctx)
+	qtx := run.queries.WithTx(tx)
+
+	created, err := qtx.CreateImportedUser(ctx, db.CreateImportedUserParams{
+		Email:   row.Email,
+		Name:    pgtype.Text{String: row.Name, Valid: row.Name != ""},
+		Program: pgtype.Text{String: row.Program, Valid: row.Program != ""},
+	})
+	if err != nil {
+		logger.Error("Failed to create imported user", "email", row.Email, "error", err)
+		return fail("An unexpected error occurred.")
+	}
+	for _, groupID := range groupIDs {
+		if err := qtx.CreateUserRole(ctx, db.CreateUserRoleParams{
+			UserID:   &created.ID,
+			RoleName: pgtype.Text{String: run.role, Valid: true},
+			Scope:    db.ScopeTypeGroup,
+			ScopeID:  &groupID,
+		}); err != nil {
+			logger.Error("Failed to assign imported user role", "email", row.Email, "group_id", groupID, "error", err)
+			return fail("An unexpected error occurred.")
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		logger.Error("Failed to commit imported user", "email", row.Email, "error", err)
+		return fail("An unexpected error occurred.")
+	}
+
+	result.Status = api.UserImportRowStatusCreated
+	result.UserId = &created.ID
+	result.GroupIds = append(result.GroupIds, groupIDs...)
+
+	var warnings []string
+	if len(unmatched) > 0 {
+		warnings = append(warnings, "no group named "+strings.Join(unmatched, ", "))
+	}
+	if len(groupIDs) == 0 {
+		warnings = append(warnings, "user was not added to any group")
+	}
+	if !run.dryRun {
+		if err := s.dispatcher.Notify(ctx, run.actorID, "system", created.ID, []notifications.NotifierGroup{
+			{
+				IDs:          []uuid.UUID{created.ID},
+				Template:     "user_invited",
+				TemplateData: map[string]interface{}{"Name": row.Name},
+			},
+		}); err != nil {
+			logger.Error("Failed to send invite", "email", row.Email, "error", err)
+			warnings = append(warnings, "invite could not be sent")
+		}
+	}
+	if len(warnings) > 0 {
+		message := strings.Join(warnings, "; ")
+		result.Message = &message
+	}
+	return result
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createCSVMultipartReader(t *testing.T, csv string, fields map[string]string) *multipart.Reader {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	fw, err := mw.CreateFormFile("file", "registrar.csv")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(csv))
+	require.NoError(t, err)
+
+	for k, v := range fields {
+		require.NoError(t, mw.WriteField(k, v))
+	}
+	require.NoError(t, mw.Close())
+
+	return multipart.NewReader(&body, mw.Boundary())
+}
+
+func TestServer_ImportUsers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+
+	admin := testDB.NewUser(t).WithEmail("admin@import.test").AsGlobalAdmin().Create()
+	existing := testDB.NewUser(t).WithEmail("existing@import.test").AsMember().Create()
+	avClub := testDB.NewGroup(t).WithName("AV Club").Create()
+	fallback := testDB.NewGroup(t).WithName("First Years").Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	t.Run("imports rows independently", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageUsers, nil, true, nil)
+		response, err := server.ImportUsers(ctx, api.ImportUsersRequestObject{
+			Body: createCSVMultipartReader(t,
+				"email,name,program,groups\n"+
+					"ada@import.test,Ada Lovelace,Engineering,av club;Chess\n"+
+					"grace@import.test,Grace Hopper,Science,\n"+
+					"existing@import.test,,,\n"+
+					"not-an-email,,,\n",
+				map[string]string{"default_group_id": fallback.ID.String()}),
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ImportUsers200JSONResponse{}, response)
+
+		report := response.(api.ImportUsers200JSONResponse)
+		assert.Equal(t, 2, report.Created)
+		assert.Equal(t, 1, report.Exists)
+		assert.Equal(t, 1, report.Failed)
+		require.Len(t, report.Rows, 4)
+
+		ada := report.Rows[0]
+		assert.Equal(t, api.UserImportRowStatusCreated, ada.Status)
+		assert.Equal(t, []api.UUID{avClub.ID}, ada.GroupIds)
+		require.NotNil(t, ada.Message)
+		assert.Contains(t, *ada.Message, "Chess")
+
+		member, err := testDB.Queries().IsUserMemberOfGroup(ctx, db.IsUserMemberOfGroupParams{UserID: ada.UserId, ScopeID: &avClub.ID})
+		require.NoError(t, err)
+		assert.True(t, member)
+
+		assert.Equal(t, []api.UUID{fallback.ID}, report.Rows[1].GroupIds)
+		assert.Equal(t, &existing.ID, report.Rows[2].UserId)
+		assert.Equal(t, api.UserImportRowStatusFailed, report.Rows[3].Status)
+		assert.Equal(t, 5, report.Rows[3].Line)
+
+		tasks, err := sharedQueue.Inspector.ListPendingTasks("default")
+		require.NoError(t, err)
+		require.Len(t, tasks, 2)
+		for _, task := range tasks {
+			var payload queue.EmailDeliveryPayload
+			require.NoError(t, json.Unmarshal(task.Payload, &payload))
+			assert.Equal(t, "You've been invited to Campus Vault", payload.Subject)
+		}
+
+		// the invite also lands in the new user's in-app notifications
+		count, err := server.dispatcher.GetTotalCount(ctx, *ada.UserId)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("dry run reports without creating or inviting", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+
+		dryRun := true
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageUsers, nil, true, nil)
+		response, err := server.ImportUsers(ctx, api.ImportUsersRequestObject{
+			Params: api.ImportUsersParams{DryRun: &dryRun},
+			Body: createCSVMultipartReader(t,
+				"email,groups\nalan@import.test,AV Club\nalan@import.test,AV Club\n", nil),
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ImportUsers200JSONResponse{}, response)
+
+		report := response.(api.ImportUsers200JSONResponse)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Exists, "repeated email is reported as a real import would")
+
+		_, err = testDB.Queries().GetUserByEmail(ctx, "alan@import.test")
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+		tasks, _ := sharedQueue.Inspector.ListPendingTasks("default")
+		assert.Empty(t, tasks)
+	})
+
+	t.Run("rejects an unknown role", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageUsers, nil, true, nil)
+		response, err := server.ImportUsers(ctx, api.ImportUsersRequestObject{
+			Body: createCSVMultipartReader(t, "email\nx@import.test\n", map[string]string{"role": "global_admin"}),
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ImportUsers400JSONResponse{}, response)
+	})
+
+	t.Run("rejects a file without an email column", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageUsers, nil, true, nil)
+		response, err := server.ImportUsers(ctx, api.ImportUsersRequestObject{
+			Body: createCSVMultipartReader(t, "name\nAda\n", nil),
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ImportUsers400JSONResponse{}, response)
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageUsers, nil, false, nil)
+		response, err := server.ImportUsers(ctx, api.ImportUsersRequestObject{
+			Body: createCSVMultipartReader(t, "email\n", nil),
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ImportUsers403JSONResponse{}, response)
+	})
+}
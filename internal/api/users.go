@@ -41,6 +41,12 @@ func (s Server) GetUsers(ctx context.Context, request api.GetUsersRequestObject)
 		return api.GetUsers500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
 	}
 
+	suppressions, err := s.emailSuppressions(ctx)
+	if err != nil {
+		logger.Error("Failed to get email suppressions", "error", err)
+		return api.GetUsers500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
 	// Convert database users to API response format
 	var response api.GetUsers200JSONResponse
 	for _, user := range users {
@@ -72,6 +78,7 @@ func (s Server) GetUsers(ctx context.Context, request api.GetUsersRequestObject)
 			Email: types.Email(user.Email),
 			Role:  role,
 		}
+		userResponse.EmailSuppression = suppressionFor(suppressions, user.Email)
 		response = append(response, userResponse)
 	}
 
@@ -239,6 +246,11 @@ func (s Server) GetUserById(ctx context.Context, request api.GetUserByIdRequestO
 		Email: types.Email(user.Email),
 		Role:  GetUserRole(roles),
 	}
+	userResponse.EmailSuppression, err = s.emailSuppression(ctx, user.Email)
+	if err != nil {
+		logger.Error("Failed to get email suppression", "user_id", user.ID, "error", err)
+		return api.GetUserById500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
 
 	return api.GetUserById200JSONResponse(userResponse), nil
 }
@@ -275,6 +287,11 @@ func (s Server) GetUserByEmail(ctx context.Context, request api.GetUserByEmailRe
 		Email: types.Email(foundUser.Email),
 		Role:  GetUserRole(roles),
 	}
+	userResponse.EmailSuppression, err = s.emailSuppression(ctx, foundUser.Email)
+	if err != nil {
+		logger.Error("Failed to get email suppression", "user_id", foundUser.ID, "error", err)
+		return api.GetUserByEmail500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
 
 	return api.GetUserByEmail200JSONResponse(userResponse), nil
 }
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (s Server) JoinItemWaitlist(ctx context.Context, request api.JoinItemWaitlistRequestObject) (api.JoinItemWaitlistResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.JoinItemWaitlist401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.JoinItemWaitlist400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	quantity := 1
+	if request.Body.Quantity != nil {
+		quantity = *request.Body.Quantity
+	}
+	if quantity < 1 {
+		return api.JoinItemWaitlist400JSONResponse(ValidationErr("quantity must be at least 1", nil).Create()), nil
+	}
+
+	// same check as BorrowItem: the permission and membership of the group
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.RequestItems, &request.Body.GroupId)
+	if err != nil {
+		logger.Error("Error checking rbac.RequestItems permission", "error", err)
+		return api.JoinItemWaitlist500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.JoinItemWaitlist403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	item, err := s.db.Queries().GetItemByID(ctx, request.ItemId)
+	if err == pgx.ErrNoRows {
+		return api.JoinItemWaitlist404JSONResponse(NotFound("Item").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.JoinItemWaitlist500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	// low items are restocked rather than returned, so nobody would be notified
+	if item.Type == db.ItemTypeLow {
+		return api.JoinItemWaitlist400JSONResponse(ValidationErr("Low-value items do not have a waitlist", nil).Create()), nil
+	}
+	if item.Stock >= int32(quantity) {
+		return api.JoinItemWaitlist400JSONResponse(ValidationErr("Item is in stock and can be borrowed now", nil).Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetWaitingEntry(ctx, db.GetWaitingEntryParams{ItemID: item.ID, UserID: user.ID}); err == nil {
+		return api.JoinItemWaitlist409JSONResponse(ConflictErr("You are already on the waitlist for this item").Create()), nil
+	} else if err != pgx.ErrNoRows {
+		logger.Error("Failed to get waitlist entry", "item_id", item.ID, "user_id", user.ID, "error", err)
+		return api.JoinItemWaitlist500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	entry, err := s.db.Queries().JoinItemWaitlist(ctx, db.JoinItemWaitlistParams{
+		ItemID:   item.ID,
+		UserID:   user.ID,
+		GroupID:  request.Body.GroupId,
+		Quantity: int32(quantity),
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		// a concurrent join got in between the check above and the insert
+		return api.JoinItemWaitlist409JSONResponse(ConflictErr("You are already on the waitlist for this item").Create()), nil
+	}
+	if err != nil {
+		logger.Error("Failed to join waitlist", "item_id", item.ID, "user_id", user.ID, "error", err)
+		return api.JoinItemWaitlist500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	waiting, err := s.db.Queries().ListItemWaitlist(ctx, item.ID)
+	if err != nil {
+		logger.Error("Failed to list waitlist", "item_id", item.ID, "error", err)
+		return api.JoinItemWaitlist500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	for _, e := range toWaitlistEntries(waiting) {
+		if e.Id == entry.ID {
+			logger.Info("Joined waitlist", "item_id", item.ID, "user_id", user.ID, "position", e.Position)
+			return api.JoinItemWaitlist201JSONResponse(e), nil
+		}
+	}
+
+	// notified between joining and listing; report it as first in line
+	response := toWaitlistEntries([]db.ItemWaitlist{entry})[0]
+	return api.JoinItemWaitlist201JSONResponse(response), nil
+}
+
+func (s Server) GetItemWaitlist(ctx context.Context, request api.GetItemWaitlistRequestObject) (api.GetItemWaitlistResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.GetItemWaitlist401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewOwnData, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ViewOwnData permission", "error", err)
+		return api.GetItemWaitlist500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.GetItemWaitlist403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	canManage, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageItems, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageItems permission", "error", err)
+		return api.GetItemWaitlist500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetItemByID(ctx, request.ItemId); err != nil {
+		if err == pgx.ErrNoRows {
+			return api.GetItemWaitlist404JSONResponse(NotFound("Item").Create()), nil
+		}
+		logger.Error("Failed to get item", "item_id", request.ItemId, "error", err)
+		return api.GetItemWaitlist500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	waiting, err := s.db.Queries().ListItemWaitlist(ctx, request.ItemId)
+	if err != nil {
+		logger.Error("Failed to list waitlist", "item_id", request.ItemId, "error", err)
+		return api.GetItemWaitlist500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	// members only see where they stand, not who else is waiting
+	entries := make([]api.WaitlistEntry, 0)
+	for _, e := range toWaitlistEntries(waiting) {
+		if canManage || e.UserId == user.ID {
+			entries = append(entries, e)
+		}
+	}
+
+	return api.GetItemWaitlist200JSONResponse{
+		ItemId:  request.ItemId,
+		Waiting: len(waiting),
+		Entries: entries,
+	}, nil
+}
+
+func (s Server) LeaveItemWaitlist(ctx context.Context, request api.LeaveItemWaitlistRequestObject) (api.LeaveItemWaitlistResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.LeaveItemWaitlist401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ViewOwnData, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ViewOwnData permission", "error", err)
+		return api.LeaveItemWaitlist500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.LeaveItemWaitlist403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	deleted, err := s.db.Queries().LeaveItemWaitlist(ctx, db.LeaveItemWaitlistParams{
+		ItemID: request.ItemId,
+		UserID: user.ID,
+	})
+	if err != nil {
+		logger.Error("Failed to leave waitlist", "item_id", request.ItemId, "user_id", user.ID, "error", err)
+		return api.LeaveItemWaitlist500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if deleted == 0 {
+		return api.LeaveItemWaitlist404JSONResponse(NotFound("Waitlist entry").Create()), nil
+	}
+
+	logger.Info("Left waitlist", "item_id", request.ItemId, "user_id", user.ID)
+	return api.LeaveItemWaitlist204Response{}, nil
+}
+
+// numbers entries by their place in line; waiting must be in queue order.
+func toWaitlistEntries(waiting []db.ItemWaitlist) []api.WaitlistEntry {
+	entries := make([]api.WaitlistEntry, 0, len(waiting))
+	for i, e := range waiting {
+		entries = append(entries, api.WaitlistEntry{
+			Id:        e.ID,
+			ItemId:    e.ItemID,
+			UserId:    e.UserID,
+			GroupId:   e.GroupID,
+			Quantity:  int(e.Quantity),
+			Position:  i + 1,
+			CreatedAt: e.CreatedAt.Time,
+		})
+	}
+	return entries
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_JoinItemWaitlist(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("members queue in the order they join", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		group := testDB.NewGroup(t).WithName("Waitlist Group").Create()
+		first := testDB.NewUser(t).WithEmail("first@waitlist.test").AsMemberOf(group).Create()
+		second := testDB.NewUser(t).WithEmail("second@waitlist.test").AsMemberOf(group).Create()
+		item := testDB.NewItem(t).WithName("Projector").WithType("medium").WithStock(0).Create()
+
+		for i, member := range []*testutil.TestUser{first, second} {
+			ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+			mockAuth.ExpectCheckPermission(member.ID, rbac.RequestItems, &group.ID, true, nil)
+			response, err := server.JoinItemWaitlist(ctx, api.JoinItemWaitlistRequestObject{
+				ItemId: item.ID,
+				Body:   &api.JoinWaitlistRequest{GroupId: group.ID},
+			})
+			require.NoError(t, err)
+			require.IsType(t, api.JoinItemWaitlist201JSONResponse{}, response)
+			entry := response.(api.JoinItemWaitlist201JSONResponse)
+			assert.Equal(t, i+1, entry.Position)
+			assert.Equal(t, 1, entry.Quantity)
+			assert.Equal(t, member.ID, entry.UserId)
+		}
+	})
+
+	t.Run("joining twice conflicts", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		group := testDB.NewGroup(t).WithName("Waitlist Group").Create()
+		member := testDB.NewUser(t).WithEmail("member@waitlist.test").AsMemberOf(group).Create()
+		item := testDB.NewItem(t).WithName("Tripod").WithType("medium").WithStock(0).Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		request := api.JoinItemWaitlistRequestObject{ItemId: item.ID, Body: &api.JoinWaitlistRequest{GroupId: group.ID}}
+		mockAuth.ExpectCheckPermission(member.ID, rbac.RequestItems, &group.ID, true, nil)
+		response, err := server.JoinItemWaitlist(ctx, request)
+		require.NoError(t, err)
+		require.IsType(t, api.JoinItemWaitlist201JSONResponse{}, response)
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.RequestItems, &group.ID, true, nil)
+		response, err = server.JoinItemWaitlist(ctx, request)
+		require.NoError(t, err)
+		require.IsType(t, api.JoinItemWaitlist409JSONResponse{}, response)
+	})
+
+	t.Run("item in stock", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		group := testDB.NewGroup(t).WithName("Waitlist Group").Create()
+		member := testDB.NewUser(t).WithEmail("member@waitlist.test").AsMemberOf(group).Create()
+		item := testDB.NewItem(t).WithName("Speaker").WithType("medium").WithStock(2).Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.RequestItems, &group.ID, true, nil)
+		quantity := 2
+		response, err := server.JoinItemWaitlist(ctx, api.JoinItemWaitlistRequestObject{
+			ItemId: item.ID,
+			Body:   &api.JoinWaitlistRequest{GroupId: group.ID, Quantity: &quantity},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.JoinItemWaitlist400JSONResponse{}, response)
+	})
+
+	t.Run("low items have no waitlist", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		group := testDB.NewGroup(t).WithName("Waitlist Group").Create()
+		member := testDB.NewUser(t).WithEmail("member@waitlist.test").AsMemberOf(group).Create()
+		item := testDB.NewItem(t).WithName("Batteries").WithType("low").WithStock(0).Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.RequestItems, &group.ID, true, nil)
+		response, err := server.JoinItemWaitlist(ctx, api.JoinItemWaitlistRequestObject{
+			ItemId: item.ID,
+			Body:   &api.JoinWaitlistRequest{GroupId: group.ID},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.JoinItemWaitlist400JSONResponse{}, response)
+	})
+
+	t.Run("not a member of the group", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		group := testDB.NewGroup(t).WithName("Waitlist Group").Create()
+		outsider := testDB.NewUser(t).WithEmail("outsider@waitlist.test").AsMember().Create()
+		item := testDB.NewItem(t).WithName("Projector").WithType("medium").WithStock(0).Create()
+		ctx := testutil.ContextWithUser(context.Background(), outsider, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(outsider.ID, rbac.RequestItems, &group.ID, false, nil)
+		response, err := server.JoinItemWaitlist(ctx, api.JoinItemWaitlistRequestObject{
+			ItemId: item.ID,
+			Body:   &api.JoinWaitlistRequest{GroupId: group.ID},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.JoinItemWaitlist403JSONResponse{}, response)
+	})
+}
+
+func TestServer_GetItemWaitlist(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	testDB.CleanupDatabase(t)
+	group := testDB.NewGroup(t).WithName("Waitlist Group").Create()
+	first := testDB.NewUser(t).WithEmail("first@waitlist.test").AsMemberOf(group).Create()
+	second := testDB.NewUser(t).WithEmail("second@waitlist.test").AsMemberOf(group).Create()
+	admin := testDB.NewUser(t).WithEmail("admin@waitlist.test").AsGlobalAdmin().Create()
+	item := testDB.NewItem(t).WithName("Projector").WithType("medium").WithStock(0).Create()
+
+	for _, member := range []*testutil.TestUser{first, second} {
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+		mockAuth.ExpectCheckPermission(member.ID, rbac.RequestItems, &group.ID, true, nil)
+		response, err := server.JoinItemWaitlist(ctx, api.JoinItemWaitlistRequestObject{
+			ItemId: item.ID,
+			Body:   &api.JoinWaitlistRequest{GroupId: group.ID},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.JoinItemWaitlist201JSONResponse{}, response)
+	}
+
+	t.Run("member sees only their own place", func(t *testing.T) {
+		ctx := testutil.ContextWithUser(context.Background(), second, testDB.Queries())
+		mockAuth.ExpectCheckPermission(second.ID, rbac.ViewOwnData, nil, true, nil)
+		mockAuth.ExpectCheckPermission(second.ID, rbac.ManageItems, nil, false, nil)
+
+		response, err := server.GetItemWaitlist(ctx, api.GetItemWaitlistRequestObject{ItemId: item.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItemWaitlist200JSONResponse{}, response)
+		waitlist := response.(api.GetItemWaitlist200JSONResponse)
+		assert.Equal(t, 2, waitlist.Waiting)
+		require.Len(t, waitlist.Entries, 1)
+		assert.Equal(t, second.ID, waitlist.Entries[0].UserId)
+		assert.Equal(t, 2, waitlist.Entries[0].Position)
+	})
+
+	t.Run("item manager sees everyone", func(t *testing.T) {
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ViewOwnData, nil, true, nil)
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageItems, nil, true, nil)
+
+		response, err := server.GetItemWaitlist(ctx, api.GetItemWaitlistRequestObject{ItemId: item.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItemWaitlist200JSONResponse{}, response)
+		waitlist := response.(api.GetItemWaitlist200JSONResponse)
+		require.Len(t, waitlist.Entries, 2)
+		assert.Equal(t, first.ID, waitlist.Entries[0].UserId)
+		assert.Equal(t, second.ID, waitlist.Entries[1].UserId)
+	})
+
+	t.Run("leaving moves everyone behind up", func(t *testing.T) {
+		ctx := testutil.ContextWithUser(context.Background(), first, testDB.Queries())
+		mockAuth.ExpectCheckPermission(first.ID, rbac.ViewOwnData, nil, true, nil)
+		response, err := server.LeaveItemWaitlist(ctx, api.LeaveItemWaitlistRequestObject{ItemId: item.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.LeaveItemWaitlist204Response{}, response)
+
+		mockAuth.ExpectCheckPermission(first.ID, rbac.ViewOwnData, nil, true, nil)
+		response, err = server.LeaveItemWaitlist(ctx, api.LeaveItemWaitlistRequestObject{ItemId: item.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.LeaveItemWaitlist404JSONResponse{}, response)
+
+		ctx = testutil.ContextWithUser(context.Background(), second, testDB.Queries())
+		mockAuth.ExpectCheckPermission(second.ID, rbac.ViewOwnData, nil, true, nil)
+		mockAuth.ExpectCheckPermission(second.ID, rbac.ManageItems, nil, false, nil)
+		getResp, err := server.GetItemWaitlist(ctx, api.GetItemWaitlistRequestObject{ItemId: item.ID})
+		require.NoError(t, err)
+		require.IsType(t, api.GetItemWaitlist200JSONResponse{}, getResp)
+		waitlist := getResp.(api.GetItemWaitlist200JSONResponse)
+		assert.Equal(t, 1, waitlist.Waiting)
+		require.Len(t, waitlist.Entries, 1)
+		assert.Equal(t, 1, waitlist.Entries[0].Position)
+	})
+}
+
+func TestServer_ReturnItem_NotifiesWaitlist(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	group := testDB.NewGroup(t).WithName("Waitlist Group").Create()
+	borrower := testDB.NewUser(t).WithEmail("borrower@waitlist.test").AsMemberOf(group).Create()
+	waiter := testDB.NewUser(t).WithEmail("waiter@waitlist.test").AsMemberOf(group).Create()
+	item := testDB.NewItem(t).WithName("Projector").WithType("medium").WithStock(1).Create()
+
+	borrowerCtx := testutil.ContextWithUser(context.Background(), borrower, testDB.Queries())
+	mockAuth.ExpectCheckPermission(borrower.ID, rbac.RequestItems, &group.ID, true, nil)
+	borrowResp, err := server.BorrowItem(borrowerCtx, api.BorrowItemRequestObject{
+		Body: &api.BorrowItemJSONRequestBody{
+			UserId:             borrower.ID,
+			GroupId:            group.ID,
+			ItemId:             item.ID,
+			Quantity:           1,
+			DueDate:            time.Now().Add(7 * 24 * time.Hour),
+			BeforeCondition:    "good",
+			BeforeConditionUrl: "http://example.com/before.jpg",
+		},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.BorrowItem201JSONResponse{}, borrowResp)
+
+	waiterCtx := testutil.ContextWithUser(context.Background(), waiter, testDB.Queries())
+	mockAuth.ExpectCheckPermission(waiter.ID, rbac.RequestItems, &group.ID, true, nil)
+	joinResp, err := server.JoinItemWaitlist(waiterCtx, api.JoinItemWaitlistRequestObject{
+		ItemId: item.ID,
+		Body:   &api.JoinWaitlistRequest{GroupId: group.ID},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.JoinItemWaitlist201JSONResponse{}, joinResp)
+
+	sharedQueue.Cleanup(t)
+	afterConditionURL := "http://example.com/after.jpg"
+	mockAuth.ExpectCheckPermission(borrower.ID, rbac.ViewOwnData, nil, true, nil)
+	returnResp, err := server.ReturnItem(borrowerCtx, api.ReturnItemRequestObject{
+		ItemId: item.ID,
+		Body: &api.ReturnItemJSONRequestBody{
+			AfterCondition:    "good",
+			AfterConditionUrl: &afterConditionURL,
+		},
+	})
+	require.NoError(t, err)
+	require.IsType(t, api.ReturnItem200JSONResponse{}, returnResp)
+
+	pending, err := sharedQueue.Inspector.ListPendingTasks("default")
+	require.NoError(t, err)
+	found := false
+	for _, task := range pending {
+		if task.Type == queue.TypeWaitlistNotify {
+			found = true
+		}
+	}
+	assert.True(t, found, "expected a waitlist notification task to be queued")
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/webhooks"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// shortest secret a webhook can be registered with; generated ones are longer
+const minWebhookSecretLength = 16
+
+func (s Server) ListWebhooks(ctx context.Context, request api.ListWebhooksRequestObject) (api.ListWebhooksResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListWebhooks401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageWebhooks, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageWebhooks permission", "error", err)
+		return api.ListWebhooks500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListWebhooks403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	hooks, err := s.db.Queries().ListWebhooks(ctx)
+	if err != nil {
+		logger.Error("Failed to list webhooks", "error", err)
+		return api.ListWebhooks500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	response := make(api.ListWebhooks200JSONResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		response = append(response, toWebhookResponse(hook))
+	}
+	return response, nil
+}
+
+func (s Server) CreateWebhook(ctx context.Context, request api.CreateWebhookRequestObject) (api.CreateWebhookResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.CreateWebhook401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageWebhooks, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageWebhooks permission", "error", err)
+		return api.CreateWebhook500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.CreateWebhook403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if request.Body == nil {
+		return api.CreateWebhook400JSONResponse(ValidationErr("Request body is required", nil).Create()), nil
+	}
+	body := request.Body
+	endpoint, err := url.Parse(strings.TrimSpace(body.Url))
+	if err != nil || (endpoint.Scheme != "https" && endpoint.Scheme != "http") || endpoint.Host == "" {
+		return api.CreateWebhook400JSONResponse(ValidationErr("url must be an http or https URL", nil).Create()), nil
+	}
+
+	var eventTypes []string
+	for _, e := range body.Events {
+		if !webhooks.Valid(string(e)) {
+			return api.CreateWebhook400JSONResponse(ValidationErr("Unknown event type: "+string(e), nil).Create()), nil
+		}
+		if !slices.Contains(eventTypes, string(e)) {
+			eventTypes = append(eventTypes, string(e))
+		}
+	}
+	if len(eventTypes) == 0 {
+		return api.CreateWebhook400JSONResponse(ValidationErr("At least one event is required", nil).Create()), nil
+	}
+
+	var secret string
+	if body.Secret != nil {
+		secret = *body.Secret
+		if len(secret) < minWebhookSecretLength {
+			return api.CreateWebhook400JSONResponse(ValidationErr("secret must be at least 16 characters", nil).Create()), nil
+		}
+	} else {
+		secret, err = webhooks.GenerateSecret()
+		if err != nil {
+			logger.Error("Failed to generate webhook secret", "error", err)
+			return api.CreateWebhook500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+	}
+
+	params := db.CreateWebhookParams{
+		Url:       endpoint.String(),
+		Secret:    secret,
+		Events:    eventTypes,
+		CreatedBy: &user.ID,
+	}
+	if body.Description != nil && strings.TrimSpace(*body.Description) != "" {
+		params.Description = pgtype.Text{String: strings.TrimSpace(*body.Description), Valid: true}
+	}
+
+	hook, err := s.db.Queries().CreateWebhook(ctx, params)
+	if err != nil {
+		logger.Error("Failed to create webhook", "error", err)
+		return api.CreateWebhook500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	logger.Info("Webhook registered", "webhook_id", hook.ID, "events", hook.Events, "user_id", user.ID)
+	return api.CreateWebhook201JSONResponse{
+		Webhook: toWebhookResponse(hook),
+		Secret:  secret,
+	}, nil
+}
+
+func (s Server) DeleteWebhook(ctx context.Context, request api.DeleteWebhookRequestObject) (api.DeleteWebhookResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.DeleteWebhook401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageWebhooks, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageWebhooks permission", "error", err)
+		return api.DeleteWebhook500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.DeleteWebhook403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	deleted, err := s.db.Queries().DeleteWebhook(ctx, request.Id)
+	if err != nil {
+		logger.Error("Failed to delete webhook", "webhook_id", request.Id, "error", err)
+		return api.DeleteWebhook500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+	if deleted == 0 {
+		return api.DeleteWebhook404JSONResponse(NotFound("Webhook").Create()), nil
+	}
+
+	// queued retries find their delivery gone and are dropped by the worker
+	logger.Info("Webhook deleted", "webhook_id", request.Id, "user_id", user.ID)
+	return api.DeleteWebhook204Response{}, nil
+}
+
+func (s Server) ListWebhookDeliveries(ctx context.Context, request api.ListWebhookDeliveriesRequestObject) (api.ListWebhookDeliveriesResponseObject, error) {
+	logger := middleware.GetLoggerFromContext(ctx)
+
+	user, ok := auth.GetAuthenticatedUser(ctx)
+	if !ok {
+		return api.ListWebhookDeliveries401JSONResponse(Unauthorized("Authentication required").Create()), nil
+	}
+
+	hasPermission, err := s.authenticator.CheckPermission(ctx, user.ID, rbac.ManageWebhooks, nil)
+	if err != nil {
+		logger.Error("Error checking rbac.ManageWebhooks permission", "error", err)
+		return api.ListWebhookDeliveries500JSONResponse(InternalError("Internal server error").Create()), nil
+	}
+	if !hasPermission {
+		return api.ListWebhookDeliveries403JSONResponse(PermissionDenied("Insufficient permissions").Create()), nil
+	}
+
+	if _, err := s.db.Queries().GetWebhookByID(ctx, request.Id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return api.ListWebhookDeliveries404JSONResponse(NotFound("Webhook").Create()), nil
+		}
+		logger.Error("Failed to get webhook", "webhook_id", request.Id, "error", err)
+		return api.ListWebhookDeliveries500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	limit, offset := parsePagination(request.Params.Limit, request.Params.Offset)
+
+	total, err := s.db.Queries().CountWebhookDeliveries(ctx, request.Id)
+	if err != nil {
+		logger.Error("Failed to count webhook deliveries", "webhook_id", request.Id, "error", err)
+		return api.ListWebhookDeliveries500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	deliveries, err := s.db.Queries().ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{
+		WebhookID: request.Id,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		logger.Error("Failed to list webhook deliveries", "webhook_id", request.Id, "error", err)
+		return api.ListWebhookDeliveries500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+	}
+
+	data := make([]api.WebhookDelivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		delivery, err := toWebhookDeliveryResponse(d)
+		if err != nil {
+			logger.Error("Failed to decode webhook delivery payload", "delivery_id", d.ID, "error", err)
+			return api.ListWebhookDeliveries500JSONResponse(InternalError("An unexpected error occurred.").Create()), nil
+		}
+		data = append(data, delivery)
+	}
+
+	return api.ListWebhookDeliveries200JSONResponse{
+		Data: data,
+		Meta: buildPaginationMeta(total, limit, offset),
+	}, nil
+}
+
+func toWebhookResponse(hook db.Webhook) api.Webhook {
+	response := api.Webhook{
+		Id:        hook.ID,
+		Url:       hook.Url,
+		Events:    make([]api.WebhookEventType, 0, len(hook.Events)),
+		CreatedBy: hook.CreatedBy,
+		CreatedAt: hook.CreatedAt.Time,
+	}
+	for _, e := range hook.Events {
+		response.Events = append(response.Events, api.WebhookEventType(e))
+	}
+	if hook.Description.Valid {
+		response.Description = &hook.Description.String
+	}
+	return response
+}
+
+func toWebhookDeliveryResponse(d db.WebhookDelivery) (api.WebhookDelivery, error) {
+	response := api.WebhookDelivery{
+		Id:        d.ID,
+		WebhookId: d.WebhookID,
+		EventType: api.WebhookEventType(d.EventType),
+		Status:    api.WebhookDeliveryStatus(d.Status),
+		Attempts:  int(d.Attempts),
+		CreatedAt: d.CreatedAt.Time,
+	}
+	if err := json.Unmarshal(d.Payload, &response.Payload); err != nil {
+		return response, err
+	}
+	if d.ResponseStatus.Valid {
+		status := int(d.ResponseStatus.Int32)
+		response.ResponseStatus = &status
+	}
+	if d.LastError.Valid {
+		response.LastError = &d.LastError.String
+	}
+	if d.LastAttemptAt.Valid {
+		response.LastAttemptAt = &d.LastAttemptAt.Time
+	}
+	if d.DeliveredAt.Valid {
+		response.DeliveredAt = &d.DeliveredAt.Time
+	}
+	return response, nil
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_CreateWebhook(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+
+	t.Run("secret is generated and returned once", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@webhooks.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		description := "  Discord #equipment  "
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageWebhooks, nil, true, nil)
+		response, err := server.CreateWebhook(ctx, api.CreateWebhookRequestObject{
+			Body: &api.CreateWebhookRequest{
+				Url:         "https://discord.example.com/hooks/123",
+				Description: &description,
+				Events:      []api.WebhookEventType{api.WebhookEventTypeItemOverdue, api.WebhookEventTypeItemOverdue, api.WebhookEventTypeItemReturned},
+			},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.CreateWebhook201JSONResponse{}, response)
+
+		created := response.(api.CreateWebhook201JSONResponse)
+		assert.True(t, strings.HasPrefix(created.Secret, "whsec_"))
+		assert.Equal(t, []api.WebhookEventType{api.WebhookEventTypeItemOverdue, api.WebhookEventTypeItemReturned}, created.Webhook.Events)
+		require.NotNil(t, created.Webhook.Description)
+		assert.Equal(t, "Discord #equipment", *created.Webhook.Description)
+
+		// listing never shows the secret
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageWebhooks, nil, true, nil)
+		listed, err := server.ListWebhooks(ctx, api.ListWebhooksRequestObject{})
+		require.NoError(t, err)
+		require.IsType(t, api.ListWebhooks200JSONResponse{}, listed)
+		hooks := listed.(api.ListWebhooks200JSONResponse)
+		require.Len(t, hooks, 1)
+		assert.Equal(t, created.Webhook.Id, hooks[0].Id)
+	})
+
+	t.Run("invalid registrations are rejected", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		admin := testDB.NewUser(t).WithEmail("admin@webhooks.test").AsGlobalAdmin().Create()
+		ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+		short := "too-short"
+		for name, body := range map[string]api.CreateWebhookRequest{
+			"not a url":     {Url: "discord", Events: []api.WebhookEventType{api.WebhookEventTypeItemReturned}},
+			"ftp url":       {Url: "ftp://files.example.com", Events: []api.WebhookEventType{api.WebhookEventTypeItemReturned}},
+			"unknown event": {Url: "https://example.com/hook", Events: []api.WebhookEventType{"request.pending"}},
+			"no events":     {Url: "https://example.com/hook", Events: []api.WebhookEventType{}},
+			"short secret":  {Url: "https://example.com/hook", Events: []api.WebhookEventType{api.WebhookEventTypeItemReturned}, Secret: &short},
+		} {
+			t.Run(name, func(t *testing.T) {
+				mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageWebhooks, nil, true, nil)
+				response, err := server.CreateWebhook(ctx, api.CreateWebhookRequestObject{Body: &body})
+				require.NoError(t, err)
+				assert.IsType(t, api.CreateWebhook400JSONResponse{}, response)
+			})
+		}
+	})
+
+	t.Run("member cannot register webhooks", func(t *testing.T) {
+		testDB.CleanupDatabase(t)
+		member := testDB.NewUser(t).WithEmail("member@webhooks.test").AsMember().Create()
+		ctx := testutil.ContextWithUser(context.Background(), member, testDB.Queries())
+
+		mockAuth.ExpectCheckPermission(member.ID, rbac.ManageWebhooks, nil, false, nil)
+		response, err := server.CreateWebhook(ctx, api.CreateWebhookRequestObject{
+			Body: &api.CreateWebhookRequest{Url: "https://example.com/hook", Events: []api.WebhookEventType{api.WebhookEventTypeItemReturned}},
+		})
+		require.NoError(t, err)
+		assert.IsType(t, api.CreateWebhook403JSONResponse{}, response)
+	})
+}
+
+func TestServer_ListWebhookDeliveries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	server, testDB, mockAuth := newTestServer(t)
+	testDB.CleanupDatabase(t)
+	admin := testDB.NewUser(t).WithEmail("admin@webhooks.test").AsGlobalAdmin().Create()
+	ctx := testutil.ContextWithUser(context.Background(), admin, testDB.Queries())
+
+	hook, err := testDB.Queries().CreateWebhook(ctx, db.CreateWebhookParams{
+		Url:    "https://example.com/hook",
+		Secret: "whsec_test_secret",
+		Events: []string{"item.returned"},
+	})
+	require.NoError(t, err)
+	for range 3 {
+		_, err := testDB.Queries().CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+			WebhookID: hook.ID,
+			EventType: "item.returned",
+			Payload:   []byte(`{"type":"item.returned"}`),
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("deliveries are paginated", func(t *testing.T) {
+		limit := 2
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageWebhooks, nil, true, nil)
+		response, err := server.ListWebhookDeliveries(ctx, api.ListWebhookDeliveriesRequestObject{
+			Id:     hook.ID,
+			Params: api.ListWebhookDeliveriesParams{Limit: &limit},
+		})
+		require.NoError(t, err)
+		require.IsType(t, api.ListWebhookDeliveries200JSONResponse{}, response)
+
+		page := response.(api.ListWebhookDeliveries200JSONResponse)
+		assert.Len(t, page.Data, 2)
+		assert.Equal(t, 3, page.Meta.Total)
+		assert.Equal(t, api.WebhookDeliveryStatusPending, page.Data[0].Status)
+		assert.Equal(t, "item.returned", page.Data[0].Payload["type"])
+	})
+
+	t.Run("unknown webhook", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageWebhooks, nil, true, nil)
+		response, err := server.ListWebhookDeliveries(ctx, api.ListWebhookDeliveriesRequestObject{Id: uuid.New()})
+		require.NoError(t, err)
+		assert.IsType(t, api.ListWebhookDeliveries404JSONResponse{}, response)
+	})
+
+	t.Run("deleting removes the webhook and its deliveries", func(t *testing.T) {
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageWebhooks, nil, true, nil)
+		response, err := server.DeleteWebhook(ctx, api.DeleteWebhookRequestObject{Id: hook.ID})
+		require.NoError(t, err)
+		assert.IsType(t, api.DeleteWebhook204Response{}, response)
+
+		count, err := testDB.Queries().CountWebhookDeliveries(ctx, hook.ID)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+
+		mockAuth.ExpectCheckPermission(admin.ID, rbac.ManageWebhooks, nil, true, nil)
+		response, err = server.DeleteWebhook(ctx, api.DeleteWebhookRequestObject{Id: hook.ID})
+		require.NoError(t, err)
+		assert.IsType(t, api.DeleteWebhook404JSONResponse{}, response)
+	})
+}
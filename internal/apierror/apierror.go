@@ -0,0 +1,165 @@
+// Package apierror is the API's error catalogue: the machine-readable codes
+// clients can rely on, the HTTP status each is served with, and the single
+// envelope, {"error": {"code", "message", ..., "request_id"}}, every error
+// response uses whichever layer writes it.
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+
+	genapi "github.com/USSTM/cv-backend/generated/api"
+)
+
+type Code string
+
+const (
+	CodeValidation        Code = "VALIDATION_ERROR"
+	CodeAuthRequired      Code = "AUTHENTICATION_REQUIRED"
+	CodePermissionDenied  Code = "PERMISSION_DENIED"
+	CodeNotFound          Code = "RESOURCE_NOT_FOUND"
+	CodeInsufficientStock Code = "INSUFFICIENT_STOCK"
+	CodePolicyViolation   Code = "POLICY_VIOLATION"
+	CodeConflict          Code = "CONFLICT"
+	CodeRateLimited       Code = "RATE_LIMITED"
+	CodeInternal          Code = "INTERNAL_ERROR"
+	CodeTimeout           Code = "REQUEST_TIMEOUT"
+	CodeUnavailable       Code = "SERVICE_UNAVAILABLE"
+)
+
+var statuses = map[Code]int{
+	CodeValidation:        http.StatusBadRequest,
+	CodeAuthRequired:      http.StatusUnauthorized,
+	CodePermissionDenied:  http.StatusForbidden,
+	CodeNotFound:          http.StatusNotFound,
+	CodeInsufficientStock: http.StatusBadRequest,
+	CodePolicyViolation:   http.StatusBadRequest,
+	CodeConflict:          http.StatusConflict,
+	CodeRateLimited:       http.StatusTooManyRequests,
+	CodeInternal:          http.StatusInternalServerError,
+	CodeTimeout:           http.StatusGatewayTimeout,
+	CodeUnavailable:       http.StatusServiceUnavailable,
+}
+
+// the status c is served with when nothing more specific is known; codes
+// outside the catalogue are internal errors.
+func (c Code) Status() int {
+	if status, ok := statuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// the code a bare status, such as one from the request validator, is
+// reported under.
+func CodeFor(status int) Code {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusRequestEntityTooLarge:
+		return CodeValidation
+	case http.StatusUnauthorized:
+		return CodeAuthRequired
+	case http.StatusForbidden:
+		return CodePermissionDenied
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	return CodeInternal
+}
+
+type Detail struct {
+	Field   string
+	Message string
+}
+
+// Error is a failure as a client sees it. It is also an error, so domain
+// code can return one and have it reach the client unchanged.
+type Error struct {
+	Code    Code
+	Message string
+	Details []Detail
+	Context map[string]interface{}
+	// what went wrong underneath; logged, never sent
+	Cause error
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+func (e *Error) WithDetails(details []Detail) *Error {
+	e.Details = details
+	return e
+}
+
+func (e *Error) WithContext(context map[string]interface{}) *Error {
+	e.Context = context
+	return e
+}
+
+func (e *Error) WithCause(err error) *Error {
+	e.Cause = err
+	return e
+}
+
+// the response body for e. The request ID is left for Stamp, which knows
+// the request.
+func (e *Error) Create() genapi.Error {
+	var body genapi.Error
+	body.Error.Code = genapi.ErrorErrorCode(e.Code)
+	body.Error.Message = e.Message
+	if len(e.Details) > 0 {
+		details := make([]struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		}, len(e.Details))
+		for i, d := range e.Details {
+			details[i].Field = d.Field
+			details[i].Message = d.Message
+		}
+		body.Error.Details = &details
+	}
+	if len(e.Context) > 0 {
+		context := e.Context
+		body.Error.Context = &context
+	}
+	return body
+}
+
+// body with requestID filled in, unless it has one already.
+func Stamp(body genapi.Error, requestID string) genapi.Error {
+	if body.Error.RequestId == nil && requestID != "" {
+		body.Error.RequestId = &requestID
+	}
+	return body
+}
+
+// writes e as the whole response, with its code's status.
+func Write(w http.ResponseWriter, e *Error, requestID string) {
+	WriteStatus(w, e.Code.Status(), e, requestID)
+}
+
+// Write for when the status is already decided, as by the request validator.
+func WriteStatus(w http.ResponseWriter, status int, e *Error, requestID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Stamp(e.Create(), requestID))
+}
@@ -0,0 +1,78 @@
+package apierror_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	genapi "github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/apierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCode_Status(t *testing.T) {
+	assert.Equal(t, http.StatusBadRequest, apierror.CodeValidation.Status())
+	assert.Equal(t, http.StatusBadRequest, apierror.CodePolicyViolation.Status())
+	assert.Equal(t, http.StatusNotFound, apierror.CodeNotFound.Status())
+	assert.Equal(t, http.StatusTooManyRequests, apierror.CodeRateLimited.Status())
+	assert.Equal(t, http.StatusInternalServerError, apierror.Code("MADE_UP").Status())
+}
+
+func TestCodeFor(t *testing.T) {
+	assert.Equal(t, apierror.CodeValidation, apierror.CodeFor(http.StatusBadRequest))
+	assert.Equal(t, apierror.CodeAuthRequired, apierror.CodeFor(http.StatusUnauthorized))
+	assert.Equal(t, apierror.CodeNotFound, apierror.CodeFor(http.StatusNotFound))
+	assert.Equal(t, apierror.CodeInternal, apierror.CodeFor(http.StatusTeapot))
+}
+
+func TestError_Unwrap(t *testing.T) {
+	cause := errors.New("connection reset")
+	err := fmt.Errorf("resolving fine: %w", apierror.New(apierror.CodeConflict, "Fine already paid").WithCause(cause))
+
+	var apiErr *apierror.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, apierror.CodeConflict, apiErr.Code)
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "resolving fine: Fine already paid: connection reset", err.Error())
+}
+
+func TestWrite(t *testing.T) {
+	w := httptest.NewRecorder()
+	apierror.Write(w, apierror.New(apierror.CodeValidation, "Invalid request").
+		WithDetails([]apierror.Detail{{Field: "name", Message: "is required"}}).
+		WithContext(map[string]interface{}{"limit": 5}), "req-1")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var body genapi.Error
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, genapi.VALIDATIONERROR, body.Error.Code)
+	assert.Equal(t, "Invalid request", body.Error.Message)
+	require.NotNil(t, body.Error.RequestId)
+	assert.Equal(t, "req-1", *body.Error.RequestId)
+	require.NotNil(t, body.Error.Details)
+	assert.Equal(t, "name", (*body.Error.Details)[0].Field)
+	require.NotNil(t, body.Error.Context)
+	assert.EqualValues(t, 5, (*body.Error.Context)["limit"])
+}
+
+func TestWrite_CauseIsNotSent(t *testing.T) {
+	w := httptest.NewRecorder()
+	apierror.Write(w, apierror.New(apierror.CodeInternal, "An unexpected error occurred.").
+		WithCause(errors.New("password authentication failed for user postgres")), "")
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "postgres")
+	assert.NotContains(t, w.Body.String(), "request_id")
+}
+
+func TestStamp_KeepsExistingID(t *testing.T) {
+	body := apierror.Stamp(apierror.New(apierror.CodeNotFound, "Item not found").Create(), "first")
+	body = apierror.Stamp(body, "second")
+	assert.Equal(t, "first", *body.Error.RequestId)
+}
@@ -0,0 +1,124 @@
+package approvals
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/google/uuid"
+)
+
+// an approver a request is routed to, and why.
+type Route struct {
+	ApproverID uuid.UUID
+	Reason     db.RequestRouteReason
+	// the approver this one stands in for while they are away; nil if none
+	DelegatedFrom *uuid.UUID
+}
+
+// routes a request to each of approvers, or to whoever stands in for them
+// while they are away. away maps an approver to their delegate; a delegate who
+// is away too hands on to their own. An approver whose delegates lead back to
+// them keeps the request. Each approver is routed to once.
+func Delegate(approvers []uuid.UUID, reason db.RequestRouteReason, away map[uuid.UUID]uuid.UUID) []Route {
+	routes := make([]Route, 0, len(approvers))
+	seen := make(map[uuid.UUID]bool, len(approvers))
+	for _, approver := range approvers {
+		route := Route{ApproverID: approver, Reason: reason}
+		visited := map[uuid.UUID]bool{approver: true}
+		for delegate, ok := away[route.ApproverID]; ok; delegate, ok = away[delegate] {
+			if visited[delegate] {
+				route.ApproverID = approver
+				break
+			}
+			visited[delegate] = true
+			route.ApproverID = delegate
+		}
+		if route.ApproverID != approver {
+			from := approver
+			route.DelegatedFrom = &from
+		}
+		if !seen[route.ApproverID] {
+			seen[route.ApproverID] = true
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// the approvers away now, mapped to their delegates. Where an approver's
+// delegations overlap, the latest made wins.
+func Away(ctx context.Context, q *db.Queries) (map[uuid.UUID]uuid.UUID, error) {
+	delegations, err := q.ListActiveApprovalDelegations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approval delegations: %w", err)
+	}
+	away := make(map[uuid.UUID]uuid.UUID, len(delegations))
+	for _, d := range delegations {
+		away[d.DelegatorID] = d.DelegateID
+	}
+	return away, nil
+}
+
+// routes a new request to the approvers of the group owning its item, or to
+// the global approvers when no group owns it or the group has none of its
+// own. Returns the routes recorded.
+func RouteRequest(ctx context.Context, q *db.Queries, requestID uuid.UUID, ownerGroupID *uuid.UUID) ([]Route, error) {
+	if ownerGroupID != nil {
+		approvers, err := q.GetGroupApproverIDs(ctx, ownerGroupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get group approvers: %w", err)
+		}
+		if len(approvers) > 0 {
+			return route(ctx, q, requestID, approvers, db.RequestRouteReasonOwnerGroup)
+		}
+	}
+
+	approvers, err := q.GetRequestApproverIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approvers: %w", err)
+	}
+	return route(ctx, q, requestID, approvers, db.RequestRouteReasonGlobal)
+}
+
+// routes a request left with its owning group to the global approvers as well.
+// Returns the routes added; approvers the request was already routed to are
+// left as they were.
+func Escalate(ctx context.Context, q *db.Queries, requestID uuid.UUID) ([]Route, error) {
+	approvers, err := q.GetRequestApproverIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approvers: %w", err)
+	}
+	return route(ctx, q, requestID, approvers, db.RequestRouteReasonEscalated)
+}
+
+func route(ctx context.Context, q *db.Queries, requestID uuid.UUID, approvers []*uuid.UUID, reason db.RequestRouteReason) ([]Route, error) {
+	away, err := Away(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(approvers))
+	for _, id := range approvers {
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+
+	var added []Route
+	for _, r := range Delegate(ids, reason, away) {
+		n, err := q.AddRequestRoute(ctx, db.AddRequestRouteParams{
+			RequestID:     requestID,
+			ApproverID:    r.ApproverID,
+			Reason:        r.Reason,
+			DelegatedFrom: r.DelegatedFrom,
+		})
+		if err != nil {
+			return added, fmt.Errorf("failed to route request %s to %s: %w", requestID, r.ApproverID, err)
+		}
+		if n > 0 {
+			added = append(added, r)
+		}
+	}
+	return added, nil
+}
@@ -0,0 +1,52 @@
+package approvals_test
+
+import (
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/approvals"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelegate(t *testing.T) {
+	alice, bob, carol, dave := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	t.Run("approvers who are in keep their requests", func(t *testing.T) {
+		routes := approvals.Delegate([]uuid.UUID{alice, bob}, db.RequestRouteReasonOwnerGroup, nil)
+		require.Len(t, routes, 2)
+		assert.Equal(t, approvals.Route{ApproverID: alice, Reason: db.RequestRouteReasonOwnerGroup}, routes[0])
+		assert.Equal(t, approvals.Route{ApproverID: bob, Reason: db.RequestRouteReasonOwnerGroup}, routes[1])
+	})
+
+	t.Run("an approver who is away hands on to their delegate", func(t *testing.T) {
+		routes := approvals.Delegate([]uuid.UUID{alice}, db.RequestRouteReasonGlobal, map[uuid.UUID]uuid.UUID{alice: bob})
+		require.Len(t, routes, 1)
+		assert.Equal(t, bob, routes[0].ApproverID)
+		assert.Equal(t, db.RequestRouteReasonGlobal, routes[0].Reason)
+		require.NotNil(t, routes[0].DelegatedFrom)
+		assert.Equal(t, alice, *routes[0].DelegatedFrom)
+	})
+
+	t.Run("a delegate who is away too hands on again", func(t *testing.T) {
+		routes := approvals.Delegate([]uuid.UUID{alice}, db.RequestRouteReasonGlobal, map[uuid.UUID]uuid.UUID{alice: bob, bob: carol})
+		require.Len(t, routes, 1)
+		assert.Equal(t, carol, routes[0].ApproverID)
+		assert.Equal(t, alice, *routes[0].DelegatedFrom)
+	})
+
+	t.Run("delegations leading back round leave the approver with the request", func(t *testing.T) {
+		routes := approvals.Delegate([]uuid.UUID{alice}, db.RequestRouteReasonGlobal, map[uuid.UUID]uuid.UUID{alice: bob, bob: alice})
+		require.Len(t, routes, 1)
+		assert.Equal(t, alice, routes[0].ApproverID)
+		assert.Nil(t, routes[0].DelegatedFrom)
+	})
+
+	t.Run("a delegate standing in for two approvers is routed to once", func(t *testing.T) {
+		routes := approvals.Delegate([]uuid.UUID{alice, bob, dave}, db.RequestRouteReasonGlobal, map[uuid.UUID]uuid.UUID{alice: carol, bob: carol})
+		require.Len(t, routes, 2)
+		assert.Equal(t, carol, routes[0].ApproverID)
+		assert.Equal(t, dave, routes[1].ApproverID)
+	})
+}
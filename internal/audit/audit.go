@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Entry is one change to record. Before and After are snapshots of the
+// entity, anything that encodes to a JSON object; nil when there was nothing
+// before (a create) or is nothing after (a delete).
+type Entry struct {
+	ActorID    *uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     any
+	After      any
+	RequestID  string
+}
+
+// appends the entry to the audit log, keeping only the fields the change
+// touched
+func Record(ctx context.Context, q *db.Queries, entry Entry) error {
+	before, after, err := Diff(entry.Before, entry.After)
+	if err != nil {
+		return err
+	}
+
+	params := db.CreateAuditLogEntryParams{
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		Before:     before,
+		After:      after,
+	}
+	if entry.EntityID != "" {
+		params.EntityID = pgtype.Text{String: entry.EntityID, Valid: true}
+	}
+	if entry.RequestID != "" {
+		params.RequestID = pgtype.Text{String: entry.RequestID, Valid: true}
+	}
+	if err := q.CreateAuditLogEntry(ctx, params); err != nil {
+		return fmt.Errorf("failed to append audit log entry: %w", err)
+	}
+	return nil
+}
+
+// the fields that differ between two snapshots, as they were before and
+// after. A missing snapshot keeps the other whole; two identical ones give
+// two empty objects.
+func Diff(before, after any) (json.RawMessage, json.RawMessage, error) {
+	b, err := toObject(before)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode before snapshot: %w", err)
+	}
+	a, err := toObject(after)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode after snapshot: %w", err)
+	}
+
+	if b != nil && a != nil {
+		for field, value := range b {
+			if reflect.DeepEqual(value, a[field]) {
+				delete(b, field)
+				delete(a, field)
+			}
+		}
+	}
+	return encode(b), encode(a), nil
+}
+
+func toObject(snapshot any) (map[string]any, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]any
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func encode(object map[string]any) json.RawMessage {
+	if object == nil {
+		return nil
+	}
+	// decoded from JSON, so it encodes again
+	data, _ := json.Marshal(object)
+	return data
+}
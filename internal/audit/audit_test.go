@@ -0,0 +1,51 @@
+package audit_test
+
+import (
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/audit"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	item := db.Item{ID: uuid.New(), Name: "Projector", Stock: 4}
+
+	t.Run("keeps only the changed fields", func(t *testing.T) {
+		changed := item
+		changed.Stock = 3
+
+		before, after, err := audit.Diff(item, changed)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"stock": 4}`, string(before))
+		assert.JSONEq(t, `{"stock": 3}`, string(after))
+	})
+
+	t.Run("a create keeps the whole new state", func(t *testing.T) {
+		before, after, err := audit.Diff(nil, item)
+		require.NoError(t, err)
+		assert.Nil(t, before)
+		assert.Contains(t, string(after), `"name":"Projector"`)
+	})
+
+	t.Run("a delete keeps the whole old state", func(t *testing.T) {
+		before, after, err := audit.Diff(item, nil)
+		require.NoError(t, err)
+		assert.Contains(t, string(before), `"name":"Projector"`)
+		assert.Nil(t, after)
+	})
+
+	t.Run("no change", func(t *testing.T) {
+		before, after, err := audit.Diff(item, item)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{}`, string(before))
+		assert.JSONEq(t, `{}`, string(after))
+	})
+
+	t.Run("snapshots must be objects", func(t *testing.T) {
+		_, _, err := audit.Diff([]string{"a"}, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrAPIKeyInvalid = errors.New("invalid or revoked API key")
+
+const (
+	// tells API keys apart from JWTs in the Authorization header
+	apiKeyPrefix = "cvk_"
+	// characters of the key kept in the clear, to tell keys apart in listings
+	apiKeyVisibleLength = len(apiKeyPrefix) + 8
+	apiKeyRateWindow    = time.Minute
+)
+
+// NewAPIKey is a freshly generated API key. Key is shown to its creator once;
+// only Hash is stored.
+type NewAPIKey struct {
+	Key    string
+	Prefix string
+	Hash   string
+}
+
+// GenerateAPIKey returns a new random API key.
+func GenerateAPIKey() (*NewAPIKey, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	key := apiKeyPrefix + hex.EncodeToString(b)
+	return &NewAPIKey{Key: key, Prefix: key[:apiKeyVisibleLength], Hash: hashString(key)}, nil
+}
+
+func isAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// looks up an unrevoked key, counts the request against its rate limit and
+// records that it was used. limited reports the key is over its limit.
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, token string) (key db.ApiKey, limited bool, err error) {
+	key, err = a.queries.GetActiveAPIKeyByHash(ctx, hashString(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return key, false, ErrAPIKeyInvalid
+		}
+		return key, false, fmt.Errorf("looking up API key: %w", err)
+	}
+
+	limit := a.apiKeyRateLimit.Load()
+	if key.RateLimit.Valid {
+		limit = int64(key.RateLimit.Int32)
+	}
+	requests, err := a.store.incrAPIKeyRequests(ctx, key.ID.String(), time.Now(), apiKeyRateWindow)
+	if err != nil {
+		return key, false, fmt.Errorf("counting API key requests: %w", err)
+	}
+	if limit > 0 && requests > limit {
+		return key, true, nil
+	}
+
+	if err := a.queries.TouchAPIKey(ctx, key.ID); err != nil {
+		logging.FromContext(ctx).Error("failed to record API key use", "api_key_id", key.ID, "error", err)
+	}
+	return key, false, nil
+}
@@ -0,0 +1,113 @@
+package auth_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticator_APIKey(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+	jwtSvc, err := auth.NewJWTService([]byte("test-signing-key"), "test-issuer", 15*time.Minute)
+	require.NoError(t, err)
+	authenticator := auth.NewAuthenticator(sharedQueue.Redis, jwtSvc, sharedDB.Queries(), config.AuthConfig{APIKeyRateLimit: 100})
+
+	// runs the bearer token through Authenticate and returns who it made the
+	// request's user
+	authenticate := func(t *testing.T, token string) (*auth.AuthenticatedUser, error) {
+		t.Helper()
+		r := httptest.NewRequest("GET", "/items", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		err := authenticator.Authenticate(ctx, &openapi3filter.AuthenticationInput{
+			RequestValidationInput: &openapi3filter.RequestValidationInput{Request: r},
+			SecuritySchemeName:     "BearerAuth",
+		})
+		if err != nil {
+			return nil, err
+		}
+		user, ok := auth.GetAuthenticatedUser(r.Context())
+		require.True(t, ok)
+		return user, nil
+	}
+
+	// stores a new key and returns it in the clear
+	createKey := func(t *testing.T, params db.CreateAPIKeyParams) (string, db.ApiKey) {
+		t.Helper()
+		generated, err := auth.GenerateAPIKey()
+		require.NoError(t, err)
+		params.KeyHash, params.KeyPrefix = generated.Hash, generated.Prefix
+		key, err := sharedDB.Queries().CreateAPIKey(ctx, params)
+		require.NoError(t, err)
+		return generated.Key, key
+	}
+
+	t.Run("acts as its service account with the key's permissions", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		sharedDB.CleanupDatabase(t)
+		kiosk := sharedDB.NewUser(t).WithEmail("kiosk@keys.test").Create()
+		token, key := createKey(t, db.CreateAPIKeyParams{Name: "Kiosk", UserID: kiosk.ID, Permissions: []string{"view_items"}})
+
+		user, err := authenticate(t, token)
+		require.NoError(t, err)
+		assert.Equal(t, kiosk.ID, user.ID)
+		require.NotNil(t, user.APIKey)
+		assert.Equal(t, key.ID, user.APIKey.ID)
+		assert.False(t, user.RateLimited)
+		assert.False(t, user.MFAPending)
+
+		stored, err := sharedDB.Queries().GetActiveAPIKeyByHash(ctx, key.KeyHash)
+		require.NoError(t, err)
+		assert.True(t, stored.LastUsedAt.Valid, "use is recorded")
+	})
+
+	t.Run("revoked keys stop working", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		sharedDB.CleanupDatabase(t)
+		kiosk := sharedDB.NewUser(t).WithEmail("kiosk@keys.test").Create()
+		token, key := createKey(t, db.CreateAPIKeyParams{Name: "Kiosk", UserID: kiosk.ID, Permissions: []string{"view_items"}})
+
+		_, err := sharedDB.Queries().RevokeAPIKey(ctx, key.ID)
+		require.NoError(t, err)
+		_, err = authenticate(t, token)
+		assert.ErrorIs(t, err, auth.ErrAPIKeyInvalid)
+	})
+
+	t.Run("unknown keys are refused", func(t *testing.T) {
+		_, err := authenticate(t, "cvk_0000000000000000")
+		assert.ErrorIs(t, err, auth.ErrAPIKeyInvalid)
+	})
+
+	t.Run("requests beyond the key's limit are marked", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		sharedDB.CleanupDatabase(t)
+		kiosk := sharedDB.NewUser(t).WithEmail("kiosk@keys.test").Create()
+		token, _ := createKey(t, db.CreateAPIKeyParams{
+			Name:        "Script",
+			UserID:      kiosk.ID,
+			Permissions: []string{"view_items"},
+			RateLimit:   pgtype.Int4{Int32: 2, Valid: true},
+		})
+
+		for range 2 {
+			user, err := authenticate(t, token)
+			require.NoError(t, err)
+			assert.False(t, user.RateLimited)
+		}
+		user, err := authenticate(t, token)
+		require.NoError(t, err)
+		assert.True(t, user.RateLimited)
+	})
+}
@@ -19,6 +19,8 @@ type JWTService struct {
 
 type TokenClaims struct {
 	UserID uuid.UUID `json:"user_id"`
+	// the session passed two-factor authentication
+	MFAPassed bool `json:"mfa_passed"`
 }
 
 func NewJWTService(signingKey []byte, issuer string, expiry time.Duration) (*JWTService, error) {
@@ -39,6 +41,16 @@ func NewJWTService(signingKey []byte, issuer string, expiry time.Duration) (*JWT
 }
 
 func (s *JWTService) GenerateToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	return s.generateToken(ctx, userID, false)
+}
+
+// GenerateMFAToken is GenerateToken for a session that has passed two-factor
+// authentication.
+func (s *JWTService) GenerateMFAToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	return s.generateToken(ctx, userID, true)
+}
+
+func (s *JWTService) generateToken(ctx context.Context, userID uuid.UUID, mfaPassed bool) (string, error) {
 	now := time.Now()
 	
 	token, err := jwt.NewBuilder().
@@ -47,6 +59,7 @@ func (s *JWTService) GenerateToken(ctx context.Context, userID uuid.UUID) (strin
 		IssuedAt(now).
 		Expiration(now.Add(s.expiry)).
 		Claim("user_id", userID.String()).
+		Claim("mfa_passed", mfaPassed).
 		Build()
 	if err != nil {
 		return "", fmt.Errorf("failed to build token: %w", err)
@@ -80,7 +93,12 @@ func (s *JWTService) ValidateToken(ctx context.Context, tokenString string) (*To
 		return nil, fmt.Errorf("invalid user_id format: %w", err)
 	}
 
+	// tokens issued before two-factor authentication existed have no claim
+	mfaPassed, _ := parsedToken.Get("mfa_passed")
+	passed, _ := mfaPassed.(bool)
+
 	return &TokenClaims{
-		UserID: userID,
+		UserID:    userID,
+		MFAPassed: passed,
 	}, nil
 }
\ No newline at end of file
@@ -83,3 +83,23 @@ func TestJWTService_ValidateToken_ExpiredToken(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to parse token")
 }
+
+func TestJWTService_MFAClaim(t *testing.T) {
+	service, err := NewJWTService([]byte("test-secret-key"), "test-issuer", time.Hour)
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	ctx := context.Background()
+
+	token, err := service.GenerateToken(ctx, userID)
+	require.NoError(t, err)
+	claims, err := service.ValidateToken(ctx, token)
+	require.NoError(t, err)
+	assert.False(t, claims.MFAPassed)
+
+	token, err = service.GenerateMFAToken(ctx, userID)
+	require.NoError(t, err)
+	claims, err = service.ValidateToken(ctx, token)
+	require.NoError(t, err)
+	assert.True(t, claims.MFAPassed)
+}
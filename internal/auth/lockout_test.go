@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthService_LockoutDuration(t *testing.T) {
+	s := &AuthService{lockoutBase: time.Minute, lockoutMax: 10 * time.Minute}
+
+	assert.Equal(t, time.Minute, s.lockoutDuration(1))
+	assert.Equal(t, 2*time.Minute, s.lockoutDuration(2))
+	assert.Equal(t, 8*time.Minute, s.lockoutDuration(4))
+	assert.Equal(t, 10*time.Minute, s.lockoutDuration(5), "capped")
+	assert.Equal(t, 10*time.Minute, s.lockoutDuration(500))
+}
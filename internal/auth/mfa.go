@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrMFAAlreadyEnrolled = errors.New("two-factor authentication is already set up")
+	ErrMFANotPending      = errors.New("no two-factor enrollment to confirm")
+	ErrMFANotEnrolled     = errors.New("two-factor authentication is not set up")
+	ErrMFACodeInvalid     = errors.New("invalid two-factor code")
+	ErrMFAMaxAttempts     = errors.New("too many two-factor attempts")
+)
+
+const (
+	mfaBackupCodeCount  = 10
+	mfaBackupCodeLength = 10
+	// easily confused characters left out
+	mfaBackupCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	mfaMaxAttempts        = 5
+	mfaAttemptWindow      = 15 * time.Minute
+)
+
+// MFAEnrollment is a TOTP secret waiting for its first code.
+type MFAEnrollment struct {
+	Secret string
+	// otpauth:// URI to show as a QR code
+	URI string
+}
+
+// EnrollMFA starts setting up two-factor authentication, replacing any
+// enrollment that was never confirmed.
+func (s *AuthService) EnrollMFA(ctx context.Context, userID uuid.UUID, email string) (*MFAEnrollment, error) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("generating TOTP secret: %w", err)
+	}
+
+	n, err := s.db.UpsertPendingUserMFA(ctx, db.UpsertPendingUserMFAParams{UserID: userID, Secret: secret})
+	if err != nil {
+		return nil, fmt.Errorf("storing TOTP secret: %w", err)
+	}
+	if n == 0 {
+		return nil, ErrMFAAlreadyEnrolled
+	}
+
+	return &MFAEnrollment{Secret: secret, URI: totpURI(secret, email)}, nil
+}
+
+// ConfirmMFA finishes enrollment with a first code from the authenticator
+// app and returns the backup codes, which are not shown again.
+func (s *AuthService) ConfirmMFA(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	mfa, err := s.db.GetUserMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMFANotPending
+		}
+		return nil, fmt.Errorf("retrieving TOTP secret: %w", err)
+	}
+	if mfa.ConfirmedAt.Valid {
+		return nil, ErrMFAAlreadyEnrolled
+	}
+
+	step, ok, err := matchTOTP(mfa.Secret, strings.TrimSpace(code), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrMFACodeInvalid
+	}
+
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		return nil, fmt.Errorf("generating backup codes: %w", err)
+	}
+	if err := s.db.ReplaceMFABackupCodes(ctx, db.ReplaceMFABackupCodesParams{UserID: userID, CodeHashes: hashes}); err != nil {
+		return nil, fmt.Errorf("storing backup codes: %w", err)
+	}
+
+	// the confirming code counts as used
+	n, err := s.db.ConfirmUserMFA(ctx, db.ConfirmUserMFAParams{UserID: userID, LastUsedStep: step})
+	if err != nil {
+		return nil, fmt.Errorf("confirming two-factor authentication: %w", err)
+	}
+	if n == 0 {
+		return nil, ErrMFANotPending
+	}
+
+	logging.FromContext(ctx).Info("two-factor authentication enabled", "user_id", userID)
+	return codes, nil
+}
+
+// VerifyMFA checks a code from the authenticator app, or an unused backup
+// code, and returns a token pair for a session that passed two-factor
+// authentication.
+func (s *AuthService) VerifyMFA(ctx context.Context, userID uuid.UUID, code string) (accessToken, refreshToken string, err error) {
+	attempts, err := s.store.incrMFAAttempts(ctx, userID.String(), mfaAttemptWindow)
+	if err != nil {
+		return "", "", fmt.Errorf("incrementing two-factor attempts: %w", err)
+	}
+	if attempts > mfaMaxAttempts {
+		return "", "", ErrMFAMaxAttempts
+	}
+
+	mfa, err := s.db.GetUserMFA(ctx, userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", "", fmt.Errorf("retrieving TOTP secret: %w", err)
+	}
+	if err != nil || !mfa.ConfirmedAt.Valid {
+		return "", "", ErrMFANotEnrolled
+	}
+
+	ok, err := s.useMFACode(ctx, mfa, strings.TrimSpace(code))
+	if err != nil {
+		return "", "", err
+	}
+	if !ok {
+		return "", "", ErrMFACodeInvalid
+	}
+
+	if err := s.store.deleteMFAAttempts(ctx, userID.String()); err != nil {
+		logging.FromContext(ctx).Error("failed to reset two-factor attempts", "user_id", userID, "error", err)
+	}
+	return s.issueTokenPair(ctx, userID, true)
+}
+
+// reports whether code is a TOTP code not used before or an unused backup
+// code, marking it used
+func (s *AuthService) useMFACode(ctx context.Context, mfa db.UserMfa, code string) (bool, error) {
+	if len(code) == totpDigits {
+		step, ok, err := matchTOTP(mfa.Secret, code, time.Now())
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+		n, err := s.db.UseUserMFAStep(ctx, db.UseUserMFAStepParams{UserID: mfa.UserID, LastUsedStep: step})
+		if err != nil {
+			return false, fmt.Errorf("recording TOTP step: %w", err)
+		}
+		return n > 0, nil
+	}
+
+	n, err := s.db.UseMFABackupCode(ctx, db.UseMFABackupCodeParams{
+		UserID:   mfa.UserID,
+		CodeHash: hashString(normalizeBackupCode(code)),
+	})
+	if err != nil {
+		return false, fmt.Errorf("using backup code: %w", err)
+	}
+	if n > 0 {
+		logging.FromContext(ctx).Info("two-factor backup code used", "user_id", mfa.UserID)
+	}
+	return n > 0, nil
+}
+
+// returns the codes as shown to the user, xxxxx-xxxxx, and their hashes
+func generateBackupCodes() (codes, hashes []string, err error) {
+	max := big.NewInt(int64(len(mfaBackupCodeAlphabet)))
+	for range mfaBackupCodeCount {
+		var b strings.Builder
+		for i := range mfaBackupCodeLength {
+			if i == mfaBackupCodeLength/2 {
+				b.WriteByte('-')
+			}
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, nil, err
+			}
+			b.WriteByte(mfaBackupCodeAlphabet[n.Int64()])
+		}
+		code := b.String()
+		codes = append(codes, code)
+		hashes = append(hashes, hashString(normalizeBackupCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+// backup codes are accepted in any case, with or without the dash
+func normalizeBackupCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
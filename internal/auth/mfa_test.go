@@ -0,0 +1,146 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the code an authenticator app would show for secret right now
+func currentTOTP(t *testing.T, secret string) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	require.NoError(t, err)
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:])&0x7fffffff)%1_000_000)
+}
+
+func validateAccessToken(t *testing.T, token string) *auth.TokenClaims {
+	t.Helper()
+	jwtSvc, err := auth.NewJWTService([]byte("test-signing-key"), "test-issuer", 15*time.Minute)
+	require.NoError(t, err)
+	claims, err := jwtSvc.ValidateToken(context.Background(), token)
+	require.NoError(t, err)
+	return claims
+}
+
+func TestAuthService_MFA(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	// enrolls user and returns the secret and backup codes
+	enroll := func(t *testing.T, svc *auth.AuthService, email string) (string, []string) {
+		t.Helper()
+		user := sharedDB.NewUser(t).WithEmail(email).Create()
+		enrollment, err := svc.EnrollMFA(ctx, user.ID, user.Email)
+		require.NoError(t, err)
+		assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+		backupCodes, err := svc.ConfirmMFA(ctx, user.ID, currentTOTP(t, enrollment.Secret))
+		require.NoError(t, err)
+		assert.Len(t, backupCodes, 10)
+		return enrollment.Secret, backupCodes
+	}
+
+	t.Run("enrollment needs a correct first code", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		sharedDB.CleanupDatabase(t)
+		svc := newTestAuthService(t)
+
+		user := sharedDB.NewUser(t).WithEmail("mfa-confirm@example.com").Create()
+		_, err := svc.ConfirmMFA(ctx, user.ID, "123456")
+		assert.ErrorIs(t, err, auth.ErrMFANotPending)
+
+		enrollment, err := svc.EnrollMFA(ctx, user.ID, user.Email)
+		require.NoError(t, err)
+		wrong := "000000"
+		if currentTOTP(t, enrollment.Secret) == wrong {
+			wrong = "111111"
+		}
+		_, err = svc.ConfirmMFA(ctx, user.ID, wrong)
+		assert.ErrorIs(t, err, auth.ErrMFACodeInvalid)
+
+		_, err = svc.ConfirmMFA(ctx, user.ID, currentTOTP(t, enrollment.Secret))
+		require.NoError(t, err)
+
+		_, err = svc.EnrollMFA(ctx, user.ID, user.Email)
+		assert.ErrorIs(t, err, auth.ErrMFAAlreadyEnrolled)
+	})
+
+	t.Run("backup codes work once", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		sharedDB.CleanupDatabase(t)
+		svc := newTestAuthService(t)
+		_, backupCodes := enroll(t, svc, "mfa-backup@example.com")
+		user, err := sharedDB.Queries().GetUserByEmail(ctx, "mfa-backup@example.com")
+		require.NoError(t, err)
+
+		access, refresh, err := svc.VerifyMFA(ctx, user.ID, backupCodes[0])
+		require.NoError(t, err)
+		assert.True(t, validateAccessToken(t, access).MFAPassed)
+
+		_, _, err = svc.VerifyMFA(ctx, user.ID, backupCodes[0])
+		assert.ErrorIs(t, err, auth.ErrMFACodeInvalid)
+
+		t.Run("and the session stays verified across refreshes", func(t *testing.T) {
+			access, _, err := svc.Refresh(ctx, refresh)
+			require.NoError(t, err)
+			assert.True(t, validateAccessToken(t, access).MFAPassed)
+		})
+	})
+
+	t.Run("the confirming code cannot be replayed", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		sharedDB.CleanupDatabase(t)
+		svc := newTestAuthService(t)
+		secret, _ := enroll(t, svc, "mfa-replay@example.com")
+		user, err := sharedDB.Queries().GetUserByEmail(ctx, "mfa-replay@example.com")
+		require.NoError(t, err)
+
+		_, _, err = svc.VerifyMFA(ctx, user.ID, currentTOTP(t, secret))
+		assert.ErrorIs(t, err, auth.ErrMFACodeInvalid)
+	})
+
+	t.Run("attempts are limited", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		sharedDB.CleanupDatabase(t)
+		svc := newTestAuthService(t)
+		_, backupCodes := enroll(t, svc, "mfa-limit@example.com")
+		user, err := sharedDB.Queries().GetUserByEmail(ctx, "mfa-limit@example.com")
+		require.NoError(t, err)
+
+		for range 5 {
+			_, _, err := svc.VerifyMFA(ctx, user.ID, "nope-nope")
+			assert.ErrorIs(t, err, auth.ErrMFACodeInvalid)
+		}
+		_, _, err = svc.VerifyMFA(ctx, user.ID, backupCodes[0])
+		assert.ErrorIs(t, err, auth.ErrMFAMaxAttempts)
+	})
+
+	t.Run("not enrolled", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		sharedDB.CleanupDatabase(t)
+		svc := newTestAuthService(t)
+		user := sharedDB.NewUser(t).WithEmail("mfa-none@example.com").Create()
+
+		_, _, err := svc.VerifyMFA(ctx, user.ID, "123456")
+		assert.ErrorIs(t, err, auth.ErrMFANotEnrolled)
+	})
+}
@@ -3,11 +3,15 @@ package auth
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"sync/atomic"
 
 	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/config"
 	"github.com/getkin/kin-openapi/openapi3filter"
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 )
 
 type contextKey string
@@ -15,6 +19,7 @@ type contextKey string
 const (
 	UserIDKey     contextKey = "user_id"
 	UserClaimsKey contextKey = "user_claims"
+	ClientIPKey   contextKey = "client_ip"
 )
 
 type AuthenticatedUser struct {
@@ -22,18 +27,63 @@ type AuthenticatedUser struct {
 	Email       string
 	Permissions []db.GetUserPermissionsRow
 	Roles       []db.GetUserRolesRow
+	// the session passed two-factor authentication
+	MFAPassed bool
+	// the user has set up two-factor authentication, or holds a role that
+	// requires it, and this session has not passed it: until it does, the
+	// user holds no permissions
+	MFAPending bool
+	// the API key the request authenticated with, nil for a session: the
+	// key's permissions narrow its service account's
+	APIKey *db.ApiKey
+	// the API key has used up its requests for this minute
+	RateLimited bool
+}
+
+// HoldsGlobally reports whether the user holds a permission through a global
+// role, going by the permissions loaded with the request. Unlike
+// CheckPermission with a nil scope, a group-scoped grant does not count.
+func (u *AuthenticatedUser) HoldsGlobally(permission string) bool {
+	if u.MFAPending {
+		return false
+	}
+	if u.APIKey != nil && !slices.Contains(u.APIKey.Permissions, permission) {
+		return false
+	}
+	return slices.ContainsFunc(u.Permissions, func(p db.GetUserPermissionsRow) bool {
+		return p.Name == permission && p.Scope == db.ScopeTypeGlobal
+	})
 }
 
 type Authenticator struct {
-	jwtService *JWTService
-	queries    *db.Queries
+	store            *redisStore
+	jwtService       *JWTService
+	queries          *db.Queries
+	permissions      *permissionCache
+	mfaRequiredRoles []string
+	// requests per minute for keys without their own limit; see
+	// SetAPIKeyRateLimit
+	apiKeyRateLimit atomic.Int64
 }
 
-func NewAuthenticator(jwtService *JWTService, queries *db.Queries) *Authenticator {
-	return &Authenticator{
-		jwtService: jwtService,
-		queries:    queries,
+// cfg.PermissionCacheTTL bounds how long a permission answer is reused across
+// requests; zero disables the shared cache but keeps the per-request memo.
+func NewAuthenticator(redisClient *redis.Client, jwtService *JWTService, queries *db.Queries, cfg config.AuthConfig) *Authenticator {
+	a := &Authenticator{
+		store:            newRedisStore(redisClient),
+		jwtService:       jwtService,
+		queries:          queries,
+		permissions:      newPermissionCache(cfg.PermissionCacheTTL),
+		mfaRequiredRoles: cfg.MFARequiredRoles,
 	}
+	a.SetAPIKeyRateLimit(cfg.APIKeyRateLimit)
+	return a
+}
+
+// replaces the default API key rate limit, for a configuration reload. Keys
+// with their own limit keep it.
+func (a *Authenticator) SetAPIKeyRateLimit(limit int) {
+	a.apiKeyRateLimit.Store(int64(limit))
 }
 
 func (a *Authenticator) Authenticate(ctx context.Context, input *openapi3filter.AuthenticationInput) error {
@@ -52,35 +102,65 @@ func (a *Authenticator) Authenticate(ctx context.Context, input *openapi3filter.
 	}
 
 	token := strings.TrimPrefix(authHeader, bearerPrefix)
-	claims, err := a.jwtService.ValidateToken(ctx, token)
-	if err != nil {
-		return fmt.Errorf("invalid token: %w", err)
+
+	var (
+		userID      uuid.UUID
+		mfaPassed   bool
+		apiKey      *db.ApiKey
+		rateLimited bool
+	)
+	if isAPIKey(token) {
+		key, limited, err := a.authenticateAPIKey(ctx, token)
+		if err != nil {
+			return fmt.Errorf("invalid API key: %w", err)
+		}
+		userID, apiKey, rateLimited = key.UserID, &key, limited
+	} else {
+		claims, err := a.jwtService.ValidateToken(ctx, token)
+		if err != nil {
+			return fmt.Errorf("invalid token: %w", err)
+		}
+		userID, mfaPassed = claims.UserID, claims.MFAPassed
 	}
 
-	user, err := a.queries.GetUserByID(ctx, claims.UserID)
+	user, err := a.queries.GetUserByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("user not found: %w", err)
 	}
 
-	permissions, err := a.queries.GetUserPermissions(ctx, &claims.UserID)
+	permissions, err := a.queries.GetUserPermissions(ctx, &userID)
 	if err != nil {
 		return fmt.Errorf("failed to get user permissions: %w", err)
 	}
 
-	roles, err := a.queries.GetUserRoles(ctx, &claims.UserID)
+	roles, err := a.queries.GetUserRoles(ctx, &userID)
 	if err != nil {
 		return fmt.Errorf("failed to get user roles: %w", err)
 	}
 
+	// a key is a credential of its own, issued by an administrator, so
+	// two-factor authentication is for sessions only
+	var mfaPending bool
+	if apiKey == nil && !mfaPassed {
+		mfaPending, err = a.requiresMFA(ctx, userID, roles)
+		if err != nil {
+			return fmt.Errorf("failed to check two-factor enrollment: %w", err)
+		}
+	}
+
 	authenticatedUser := &AuthenticatedUser{
-		ID:          claims.UserID,
+		ID:          userID,
 		Email:       user.Email,
 		Permissions: permissions,
 		Roles:       roles,
+		MFAPassed:   mfaPassed,
+		MFAPending:  mfaPending,
+		APIKey:      apiKey,
+		RateLimited: rateLimited,
 	}
 
 	*input.RequestValidationInput.Request = *input.RequestValidationInput.Request.WithContext(
-		context.WithValue(ctx, UserIDKey, claims.UserID),
+		context.WithValue(WithPermissionMemo(ctx), UserIDKey, userID),
 	)
 	*input.RequestValidationInput.Request = *input.RequestValidationInput.Request.WithContext(
 		context.WithValue(input.RequestValidationInput.Request.Context(), UserClaimsKey, authenticatedUser),
@@ -89,7 +169,48 @@ func (a *Authenticator) Authenticate(ctx context.Context, input *openapi3filter.
 	return nil
 }
 
+// reports whether a user must pass two-factor authentication before their
+// permissions apply
+func (a *Authenticator) requiresMFA(ctx context.Context, userID uuid.UUID, roles []db.GetUserRolesRow) (bool, error) {
+	for _, role := range roles {
+		if slices.Contains(a.mfaRequiredRoles, role.RoleName.String) {
+			return true, nil
+		}
+	}
+	return a.queries.IsMFAEnrolled(ctx, userID)
+}
+
 func (a *Authenticator) CheckPermission(ctx context.Context, userID uuid.UUID, permission string, scopeID *uuid.UUID) (bool, error) {
+	if user, ok := GetAuthenticatedUser(ctx); ok && user.ID == userID {
+		if user.MFAPending {
+			return false, nil
+		}
+		if user.APIKey != nil && !slices.Contains(user.APIKey.Permissions, permission) {
+			return false, nil
+		}
+	}
+
+	key := newPermissionKey(userID, permission, scopeID)
+
+	memo := permissionMemoFromContext(ctx)
+	if memo != nil {
+		if allowed, ok := memo.get(key); ok {
+			return allowed, nil
+		}
+	}
+
+	var generation uint64
+	if a.permissions.enabled() {
+		allowed, ok, gen := a.permissions.get(key)
+		if ok {
+			if memo != nil {
+				memo.set(key, allowed)
+			}
+			return allowed, nil
+		}
+		generation = gen
+	}
+
 	hasPermission, err := a.queries.CheckUserPermission(ctx, db.CheckUserPermissionParams{
 		UserID:  &userID,
 		Name:    permission,
@@ -98,14 +219,50 @@ func (a *Authenticator) CheckPermission(ctx context.Context, userID uuid.UUID, p
 	if err != nil {
 		return false, err
 	}
+
+	if a.permissions.enabled() {
+		a.permissions.set(key, hasPermission, generation)
+	}
+	if memo != nil {
+		memo.set(key, hasPermission)
+	}
 	return hasPermission, nil
 }
 
+// InvalidateUserPermissions forgets cached permission answers for one user.
+// Call it once a change to their roles has been committed.
+func (a *Authenticator) InvalidateUserPermissions(ctx context.Context, userID uuid.UUID) {
+	if a.permissions.enabled() {
+		a.permissions.invalidateUser(userID)
+	}
+	if memo := permissionMemoFromContext(ctx); memo != nil {
+		memo.forgetUser(userID)
+	}
+}
+
+// InvalidateAllPermissions forgets every cached permission answer, for
+// changes such as a role's permission set that affect many users at once.
+func (a *Authenticator) InvalidateAllPermissions(ctx context.Context) {
+	if a.permissions.enabled() {
+		a.permissions.invalidateAll()
+	}
+	if memo := permissionMemoFromContext(ctx); memo != nil {
+		memo.forgetAll()
+	}
+}
+
 func GetUserID(ctx context.Context) (uuid.UUID, bool) {
 	userID, ok := ctx.Value(UserIDKey).(uuid.UUID)
 	return userID, ok
 }
 
+// GetClientIP returns the address the request came from, or "" outside a
+// request.
+func GetClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(ClientIPKey).(string)
+	return ip
+}
+
 func GetAuthenticatedUser(ctx context.Context) (*AuthenticatedUser, bool) {
 	user, ok := ctx.Value(UserClaimsKey).(*AuthenticatedUser)
 	return user, ok
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticator_CheckPermissionMFAPending(t *testing.T) {
+	a, fake := newCountingAuthenticator(0)
+	userID := uuid.New()
+
+	pending := context.WithValue(context.Background(), UserClaimsKey, &AuthenticatedUser{ID: userID, MFAPending: true})
+	allowed, err := a.CheckPermission(pending, userID, "manage_items", nil)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.EqualValues(t, 0, fake.queries.Load())
+
+	// only the pending user's own checks are refused
+	allowed, err = a.CheckPermission(pending, uuid.New(), "manage_items", nil)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	passed := context.WithValue(context.Background(), UserClaimsKey, &AuthenticatedUser{ID: userID, MFAPassed: true})
+	allowed, err = a.CheckPermission(passed, userID, "manage_items", nil)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+func TestAuthenticator_RequiresMFA(t *testing.T) {
+	fake := &countingDB{}
+	a := NewAuthenticator(nil, nil, db.New(fake), config.AuthConfig{MFARequiredRoles: []string{"global_admin", "approver"}})
+	ctx := context.Background()
+	role := func(name string) db.GetUserRolesRow {
+		return db.GetUserRolesRow{RoleName: pgtype.Text{String: name, Valid: true}}
+	}
+
+	required, err := a.requiresMFA(ctx, uuid.New(), []db.GetUserRolesRow{role("member"), role("approver")})
+	require.NoError(t, err)
+	assert.True(t, required)
+	assert.EqualValues(t, 0, fake.queries.Load(), "a required role settles it")
+
+	required, err = a.requiresMFA(ctx, uuid.New(), []db.GetUserRolesRow{role("member")})
+	require.NoError(t, err)
+	assert.False(t, required)
+
+	fake.allowed.Store(true)
+	required, err = a.requiresMFA(ctx, uuid.New(), []db.GetUserRolesRow{role("member")})
+	require.NoError(t, err)
+	assert.True(t, required, "members who enrolled have to use it too")
+}
+
+func TestAuthenticator_CheckPermissionAPIKey(t *testing.T) {
+	a, fake := newCountingAuthenticator(0)
+	userID := uuid.New()
+	ctx := context.WithValue(context.Background(), UserClaimsKey, &AuthenticatedUser{
+		ID:     userID,
+		APIKey: &db.ApiKey{UserID: userID, Permissions: []string{"view_items"}},
+	})
+
+	allowed, err := a.CheckPermission(ctx, userID, "manage_items", nil)
+	require.NoError(t, err)
+	assert.False(t, allowed, "the key does not carry the permission")
+	assert.EqualValues(t, 0, fake.queries.Load())
+
+	allowed, err = a.CheckPermission(ctx, userID, "view_items", nil)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	fake.allowed.Store(false)
+	groupID := uuid.New()
+	allowed, err = a.CheckPermission(ctx, userID, "view_items", &groupID)
+	require.NoError(t, err)
+	assert.False(t, allowed, "nor can it use what its service account lacks")
+}
+
+func TestAuthenticatedUser_HoldsGlobally(t *testing.T) {
+	groupID := uuid.New()
+	user := &AuthenticatedUser{
+		ID: uuid.New(),
+		Permissions: []db.GetUserPermissionsRow{
+			{Name: "view_all_data", Scope: db.ScopeTypeGlobal},
+			{Name: "manage_items", Scope: db.ScopeTypeGroup, ScopeID: &groupID},
+		},
+	}
+
+	assert.True(t, user.HoldsGlobally("view_all_data"))
+	assert.False(t, user.HoldsGlobally("manage_items"), "a group grant is not global")
+	assert.False(t, user.HoldsGlobally("manage_users"))
+
+	user.APIKey = &db.ApiKey{UserID: user.ID, Permissions: []string{"view_items"}}
+	assert.False(t, user.HoldsGlobally("view_all_data"), "the key does not carry the permission")
+
+	user.APIKey = nil
+	user.MFAPending = true
+	assert.False(t, user.HoldsGlobally("view_all_data"))
+}
@@ -0,0 +1,350 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrOIDCStateInvalid = errors.New("invalid or expired sign-in state")
+	ErrOIDCTokenInvalid = errors.New("invalid ID token")
+)
+
+const (
+	// how long the browser has to come back from the provider
+	oidcStateTTL = 10 * time.Minute
+	// signing keys are refetched this often, and no sooner than
+	// oidcMinKeyRefresh when a token names a key we don't have
+	oidcKeyTTL        = time.Hour
+	oidcMinKeyRefresh = time.Minute
+)
+
+// OIDCIdentity is the provider account a sign-in proved control of.
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// OIDCProvider runs the authorization code flow, with PKCE, against a single
+// OpenID Connect issuer. The discovery document and signing keys are fetched
+// on first use, so an unreachable provider does not stop the server starting.
+type OIDCProvider struct {
+	cfg    config.OIDCConfig
+	store  *redisStore
+	client *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          jwk.Set
+	keysFetchedAt time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// what is kept between sending the browser to the provider and its return
+type oidcState struct {
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+func NewOIDCProvider(redisClient *redis.Client, cfg config.OIDCConfig) *OIDCProvider {
+	return &OIDCProvider{
+		cfg:    cfg,
+		store:  newRedisStore(redisClient),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Issuer returns the configured issuer, the namespace of OIDCIdentity.Subject.
+func (p *OIDCProvider) Issuer() string {
+	return p.cfg.Issuer
+}
+
+// PostLoginURL returns the frontend page sign-in results are sent to.
+func (p *OIDCProvider) PostLoginURL() string {
+	return p.cfg.PostLoginURL
+}
+
+// CanProvision reports whether a first-time user with this address may be
+// given an account without an invitation.
+func (p *OIDCProvider) CanProvision(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, allowed := range p.cfg.ProvisionDomains {
+		if strings.EqualFold(domain, strings.TrimPrefix(allowed, "@")) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizationURL starts a sign-in and returns the provider page to send
+// the browser to.
+func (p *OIDCProvider) AuthorizationURL(ctx context.Context) (string, error) {
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomURLString(32)
+	if err != nil {
+		return "", fmt.Errorf("generating state: %w", err)
+	}
+	nonce, err := randomURLString(32)
+	if err != nil {
+		return "", fmt.Errorf("generating nonce: %w", err)
+	}
+	verifier, err := randomURLString(32)
+	if err != nil {
+		return "", fmt.Errorf("generating code verifier: %w", err)
+	}
+
+	saved, err := json.Marshal(oidcState{Nonce: nonce, Verifier: verifier})
+	if err != nil {
+		return "", err
+	}
+	if err := p.store.storeOIDCState(ctx, hashString(state), string(saved), oidcStateTTL); err != nil {
+		return "", fmt.Errorf("storing sign-in state: %w", err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	authURL, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("parsing authorization endpoint: %w", err)
+	}
+	if authURL.RawQuery != "" {
+		authURL.RawQuery += "&"
+	}
+	authURL.RawQuery += query.Encode()
+	return authURL.String(), nil
+}
+
+// Exchange finishes a sign-in: it redeems the code the provider returned
+// with state, and verifies the ID token it is given for it. The state is
+// single use whatever the outcome.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, state string) (*OIDCIdentity, error) {
+	saved, err := p.store.takeOIDCState(ctx, hashString(state))
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrOIDCStateInvalid
+		}
+		return nil, fmt.Errorf("retrieving sign-in state: %w", err)
+	}
+	var pending oidcState
+	if err := json.Unmarshal([]byte(saved), &pending); err != nil {
+		return nil, fmt.Errorf("decoding sign-in state: %w", err)
+	}
+
+	discovery, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, err := p.redeemCode(ctx, discovery, code, pending.Verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.verifyIDToken(ctx, discovery, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCTokenInvalid, err)
+	}
+	if nonce, _ := token.Get("nonce"); nonce != pending.Nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCTokenInvalid)
+	}
+	if token.Subject() == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrOIDCTokenInvalid)
+	}
+
+	identity := &OIDCIdentity{
+		Issuer:        p.cfg.Issuer,
+		Subject:       token.Subject(),
+		EmailVerified: p.cfg.AssumeEmailVerified,
+	}
+	if email, ok := token.Get("email"); ok {
+		identity.Email, _ = email.(string)
+		identity.Email = strings.ToLower(identity.Email)
+	}
+	// some providers send the flag as a string
+	if verified, ok := token.Get("email_verified"); ok {
+		switch v := verified.(type) {
+		case bool:
+			identity.EmailVerified = identity.EmailVerified || v
+		case string:
+			identity.EmailVerified = identity.EmailVerified || v == "true"
+		}
+	}
+	return identity, nil
+}
+
+// posts the code to the token endpoint and returns the ID token
+func (p *OIDCProvider) redeemCode(ctx context.Context, discovery *oidcDiscovery, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"client_secret": {p.cfg.ClientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("building token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("redeeming authorization code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%w: token response has no id_token", ErrOIDCTokenInvalid)
+	}
+	return body.IDToken, nil
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, discovery *oidcDiscovery, rawIDToken string) (jwt.Token, error) {
+	keys, err := p.getKeys(ctx, discovery, false)
+	if err != nil {
+		return nil, err
+	}
+	token, err := p.parseIDToken(rawIDToken, keys)
+	if err == nil {
+		return token, nil
+	}
+
+	// the provider may have rotated its keys since we fetched them
+	fresh, refreshErr := p.getKeys(ctx, discovery, true)
+	if refreshErr != nil || fresh == keys {
+		return nil, err
+	}
+	return p.parseIDToken(rawIDToken, fresh)
+}
+
+func (p *OIDCProvider) parseIDToken(rawIDToken string, keys jwk.Set) (jwt.Token, error) {
+	// Azure AD publishes keys without an alg
+	return jwt.Parse([]byte(rawIDToken),
+		jwt.WithKeySet(keys, jws.WithInferAlgorithmFromKey(true)),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithAcceptableSkew(time.Minute),
+	)
+}
+
+func (p *OIDCProvider) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	discoveryURL := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	var discovery oidcDiscovery
+	if err := p.getJSON(ctx, discoveryURL, &discovery); err != nil {
+		return nil, fmt.Errorf("fetching OIDC discovery document: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != strings.TrimSuffix(p.cfg.Issuer, "/") {
+		return nil, fmt.Errorf("OIDC discovery document is for issuer %q, not %q", discovery.Issuer, p.cfg.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery document is missing an endpoint")
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// returns the provider's signing keys, fetching them when they are stale or,
+// with refresh, when the cached set is older than oidcMinKeyRefresh
+func (p *OIDCProvider) getKeys(ctx context.Context, discovery *oidcDiscovery, refresh bool) (jwk.Set, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	age := time.Since(p.keysFetchedAt)
+	if p.keys != nil && age < oidcKeyTTL && (!refresh || age < oidcMinKeyRefresh) {
+		return p.keys, nil
+	}
+
+	keys, err := jwk.Fetch(ctx, discovery.JWKSURI, jwk.WithHTTPClient(p.client))
+	if err != nil {
+		return nil, fmt.Errorf("fetching OIDC signing keys: %w", err)
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+	return keys, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, dest any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+// returns n random bytes, base64url encoded without padding
+func randomURLString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,209 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIdP is an OpenID Connect provider that hands out an ID token for the
+// code "good-code", provided the PKCE verifier matches challenge.
+type fakeIdP struct {
+	server    *httptest.Server
+	key       jwk.Key
+	challenge string
+	// claims of the next ID token, on top of iss, aud, iat and exp
+	claims map[string]any
+	// aud of the next ID token
+	audience string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	raw, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err := jwk.FromRaw(raw)
+	require.NoError(t, err)
+	require.NoError(t, key.Set(jwk.KeyIDKey, "test-key"))
+	public, err := jwk.PublicKeyOf(key)
+	require.NoError(t, err)
+	keys := jwk.NewSet()
+	require.NoError(t, keys.AddKey(public))
+
+	idp := &fakeIdP{key: key, audience: "campus-vault"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(keys)
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		builder := jwt.NewBuilder().
+			Issuer(idp.server.URL).
+			Audience([]string{idp.audience}).
+			IssuedAt(time.Now()).
+			Expiration(time.Now().Add(5 * time.Minute))
+		for name, value := range idp.claims {
+			builder = builder.Claim(name, value)
+		}
+		token, err := builder.Build()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256, idp.key))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": string(signed)})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *fakeIdP) provider() *auth.OIDCProvider {
+	return auth.NewOIDCProvider(sharedQueue.Redis, config.OIDCConfig{
+		Issuer:           idp.server.URL,
+		ClientID:         "campus-vault",
+		ClientSecret:     "secret",
+		RedirectURL:      "http://localhost:8080/auth/oidc/callback",
+		Scopes:           []string{"openid", "email"},
+		ProvisionDomains: []string{"usstm.ca"},
+	})
+}
+
+// starts a sign-in, remembering its challenge, and returns its state and nonce
+func (idp *fakeIdP) start(t *testing.T, provider *auth.OIDCProvider) (string, string) {
+	t.Helper()
+	authURL, err := provider.AuthorizationURL(context.Background())
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, idp.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	assert.Equal(t, "openid email", query.Get("scope"))
+	idp.challenge = query.Get("code_challenge")
+	return query.Get("state"), query.Get("nonce")
+}
+
+func TestOIDCProvider_Exchange(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	t.Run("returns the verified identity", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		idp := newFakeIdP(t)
+		provider := idp.provider()
+		state, nonce := idp.start(t, provider)
+		idp.claims = map[string]any{"sub": "subject-1", "nonce": nonce, "email": "Student@USSTM.ca", "email_verified": true}
+
+		identity, err := provider.Exchange(ctx, "good-code", state)
+		require.NoError(t, err)
+		assert.Equal(t, &auth.OIDCIdentity{
+			Issuer:        idp.server.URL,
+			Subject:       "subject-1",
+			Email:         "student@usstm.ca",
+			EmailVerified: true,
+		}, identity)
+	})
+
+	t.Run("state is single use", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		idp := newFakeIdP(t)
+		provider := idp.provider()
+		state, nonce := idp.start(t, provider)
+		idp.claims = map[string]any{"sub": "subject-1", "nonce": nonce}
+
+		_, err := provider.Exchange(ctx, "good-code", state)
+		require.NoError(t, err)
+		_, err = provider.Exchange(ctx, "good-code", state)
+		assert.ErrorIs(t, err, auth.ErrOIDCStateInvalid)
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		idp := newFakeIdP(t)
+		_, err := idp.provider().Exchange(ctx, "good-code", "made-up")
+		assert.ErrorIs(t, err, auth.ErrOIDCStateInvalid)
+	})
+
+	t.Run("token for another client", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		idp := newFakeIdP(t)
+		provider := idp.provider()
+		state, nonce := idp.start(t, provider)
+		idp.claims = map[string]any{"sub": "subject-1", "nonce": nonce}
+		idp.audience = "someone-else"
+
+		_, err := provider.Exchange(ctx, "good-code", state)
+		assert.ErrorIs(t, err, auth.ErrOIDCTokenInvalid)
+	})
+
+	t.Run("token from another sign-in", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		idp := newFakeIdP(t)
+		provider := idp.provider()
+		state, _ := idp.start(t, provider)
+		idp.claims = map[string]any{"sub": "subject-1", "nonce": "replayed"}
+
+		_, err := provider.Exchange(ctx, "good-code", state)
+		assert.ErrorIs(t, err, auth.ErrOIDCTokenInvalid)
+	})
+
+	t.Run("unverified address", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		idp := newFakeIdP(t)
+		provider := idp.provider()
+		state, nonce := idp.start(t, provider)
+		idp.claims = map[string]any{"sub": "subject-1", "nonce": nonce, "email": "student@usstm.ca", "email_verified": "false"}
+
+		identity, err := provider.Exchange(ctx, "good-code", state)
+		require.NoError(t, err)
+		assert.False(t, identity.EmailVerified)
+	})
+}
+
+func TestOIDCProvider_CanProvision(t *testing.T) {
+	provider := auth.NewOIDCProvider(nil, config.OIDCConfig{ProvisionDomains: []string{"usstm.ca", "@mail.mcgill.ca"}})
+
+	assert.True(t, provider.CanProvision("student@usstm.ca"))
+	assert.True(t, provider.CanProvision("Student@USSTM.CA"))
+	assert.True(t, provider.CanProvision("student@mail.mcgill.ca"))
+	assert.False(t, provider.CanProvision("student@mcgill.ca"))
+	assert.False(t, provider.CanProvision("student@usstm.ca.evil.com"))
+	assert.False(t, provider.CanProvision("usstm.ca"))
+}
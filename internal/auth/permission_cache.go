@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// permission checks are cached at two levels: a memo that lives for one
+// request, so a handler asking the same question twice only queries once,
+// and a short-TTL cache shared by every request on this replica. Role
+// changes made here invalidate both straight away; other replicas keep
+// serving their answers until the TTL runs out.
+
+type permissionKey struct {
+	userID     uuid.UUID
+	permission string
+	scopeID    uuid.UUID // uuid.Nil for an unscoped check
+}
+
+func newPermissionKey(userID uuid.UUID, permission string, scopeID *uuid.UUID) permissionKey {
+	key := permissionKey{userID: userID, permission: permission}
+	if scopeID != nil {
+		key.scopeID = *scopeID
+	}
+	return key
+}
+
+type permissionEntry struct {
+	allowed bool
+	expires time.Time
+}
+
+type permissionCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	now        func() time.Time
+	entries    map[uuid.UUID]map[permissionKey]permissionEntry
+	generation uint64
+	lastSweep  time.Time
+}
+
+// a ttl of zero or less turns the shared cache off
+func newPermissionCache(ttl time.Duration) *permissionCache {
+	return &permissionCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[uuid.UUID]map[permissionKey]permissionEntry),
+	}
+}
+
+func (c *permissionCache) enabled() bool {
+	return c != nil && c.ttl > 0
+}
+
+// get also returns the generation to hand back to set, so an answer read
+// from the database before an invalidation is never stored after it.
+func (c *permissionCache) get(key permissionKey) (allowed, ok bool, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.entries[key.userID][key]
+	if !found || !c.now().Before(entry.expires) {
+		return false, false, c.generation
+	}
+	return entry.allowed, true, c.generation
+}
+
+func (c *permissionCache) set(key permissionKey, allowed bool, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	now := c.now()
+	if now.Sub(c.lastSweep) > c.ttl {
+		c.sweep(now)
+	}
+
+	userEntries, found := c.entries[key.userID]
+	if !found {
+		userEntries = make(map[permissionKey]permissionEntry)
+		c.entries[key.userID] = userEntries
+	}
+	userEntries[key] = permissionEntry{allowed: allowed, expires: now.Add(c.ttl)}
+}
+
+// drops expired entries; callers hold mu
+func (c *permissionCache) sweep(now time.Time) {
+	for userID, userEntries := range c.entries {
+		for key, entry := range userEntries {
+			if !now.Before(entry.expires) {
+				delete(userEntries, key)
+			}
+		}
+		if len(userEntries) == 0 {
+			delete(c.entries, userID)
+		}
+	}
+	c.lastSweep = now
+}
+
+func (c *permissionCache) invalidateUser(userID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, userID)
+	c.generation++
+}
+
+func (c *permissionCache) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[uuid.UUID]map[permissionKey]permissionEntry)
+	c.generation++
+}
+
+// permissionMemo holds the answers already given during one request.
+// Handlers may fan out, so it is safe for concurrent use.
+type permissionMemo struct {
+	mu      sync.Mutex
+	answers map[permissionKey]bool
+}
+
+type permissionMemoKey struct{}
+
+// WithPermissionMemo returns a context in which repeated CheckPermission
+// calls are answered from memory. Authenticate adds one to every request.
+func WithPermissionMemo(ctx context.Context) context.Context {
+	return context.WithValue(ctx, permissionMemoKey{}, &permissionMemo{answers: make(map[permissionKey]bool)})
+}
+
+func permissionMemoFromContext(ctx context.Context) *permissionMemo {
+	memo, _ := ctx.Value(permissionMemoKey{}).(*permissionMemo)
+	return memo
+}
+
+func (m *permissionMemo) get(key permissionKey) (allowed, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	allowed, ok = m.answers[key]
+	return allowed, ok
+}
+
+func (m *permissionMemo) set(key permissionKey, allowed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.answers[key] = allowed
+}
+
+func (m *permissionMemo) forgetUser(userID uuid.UUID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.answers {
+		if key.userID == userID {
+			delete(m.answers, key)
+		}
+	}
+}
+
+func (m *permissionMemo) forgetAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.answers = make(map[permissionKey]bool)
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingDB answers every CheckUserPermission with allowed and counts how
+// often it was asked.
+type countingDB struct {
+	queries atomic.Int64
+	allowed atomic.Bool
+}
+
+func (d *countingDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errors.New("countingDB only answers permission checks")
+}
+
+func (d *countingDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("countingDB only answers permission checks")
+}
+
+func (d *countingDB) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	d.queries.Add(1)
+	return boolRow(d.allowed.Load())
+}
+
+func (d *countingDB) CopyFrom(context.Context, pgx.Identifier, []string, pgx.CopyFromSource) (int64, error) {
+	return 0, errors.New("countingDB only answers permission checks")
+}
+
+type boolRow bool
+
+func (r boolRow) Scan(dest ...any) error {
+	*dest[0].(*bool) = bool(r)
+	return nil
+}
+
+func newCountingAuthenticator(ttl time.Duration) (*Authenticator, *countingDB) {
+	fake := &countingDB{}
+	fake.allowed.Store(true)
+	return NewAuthenticator(nil, nil, db.New(fake), config.AuthConfig{PermissionCacheTTL: ttl}), fake
+}
+
+func TestAuthenticator_CheckPermissionMemo(t *testing.T) {
+	a, fake := newCountingAuthenticator(0)
+	userID := uuid.New()
+	groupID := uuid.New()
+
+	ctx := WithPermissionMemo(context.Background())
+	for range 3 {
+		allowed, err := a.CheckPermission(ctx, userID, "manage_users", nil)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	assert.EqualValues(t, 1, fake.queries.Load(), "repeats within a request come from the memo")
+
+	_, err := a.CheckPermission(ctx, userID, "manage_users", &groupID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, fake.queries.Load(), "a scoped check is a different question")
+
+	_, err = a.CheckPermission(WithPermissionMemo(context.Background()), userID, "manage_users", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, fake.queries.Load(), "the memo does not outlive its request")
+
+	_, err = a.CheckPermission(context.Background(), userID, "manage_users", nil)
+	require.NoError(t, err)
+	_, err = a.CheckPermission(context.Background(), userID, "manage_users", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, fake.queries.Load(), "no memo and no shared cache means every call queries")
+}
+
+func TestAuthenticator_CheckPermissionSharedCache(t *testing.T) {
+	a, fake := newCountingAuthenticator(time.Minute)
+	now := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+	a.permissions.now = func() time.Time { return now }
+	userID := uuid.New()
+	ctx := context.Background()
+
+	allowed, err := a.CheckPermission(ctx, userID, "request_items", nil)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	_, err = a.CheckPermission(ctx, userID, "request_items", nil)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, fake.queries.Load())
+
+	t.Run("expires after the ttl", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		_, err := a.CheckPermission(ctx, userID, "request_items", nil)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, fake.queries.Load())
+	})
+
+	t.Run("invalidating a user drops their answers only", func(t *testing.T) {
+		otherID := uuid.New()
+		_, err := a.CheckPermission(ctx, otherID, "request_items", nil)
+		require.NoError(t, err)
+		before := fake.queries.Load()
+
+		fake.allowed.Store(false)
+		memoCtx := WithPermissionMemo(ctx)
+		_, err = a.CheckPermission(memoCtx, userID, "request_items", nil)
+		require.NoError(t, err)
+		a.InvalidateUserPermissions(memoCtx, userID)
+
+		allowed, err := a.CheckPermission(memoCtx, userID, "request_items", nil)
+		require.NoError(t, err)
+		assert.False(t, allowed, "neither the cache nor the memo may keep the old answer")
+
+		allowed, err = a.CheckPermission(ctx, otherID, "request_items", nil)
+		require.NoError(t, err)
+		assert.True(t, allowed, "other users keep their cached answer")
+		assert.Equal(t, before+1, fake.queries.Load())
+		fake.allowed.Store(true)
+	})
+
+	t.Run("invalidating everything", func(t *testing.T) {
+		a.InvalidateAllPermissions(ctx)
+		before := fake.queries.Load()
+		_, err := a.CheckPermission(ctx, userID, "request_items", nil)
+		require.NoError(t, err)
+		assert.Equal(t, before+1, fake.queries.Load())
+	})
+
+	t.Run("an answer read before an invalidation is not stored", func(t *testing.T) {
+		key := newPermissionKey(userID, "approve_all_requests", nil)
+		_, _, generation := a.permissions.get(key)
+		a.permissions.invalidateAll()
+		a.permissions.set(key, true, generation)
+
+		_, ok, _ := a.permissions.get(key)
+		assert.False(t, ok)
+	})
+}
+
+// BenchmarkCheckPermission models a request whose handler checks the same
+// permission twice, spread over a few hundred users, and reports how many
+// queries reach the database per request.
+func BenchmarkCheckPermission(b *testing.B) {
+	users := make([]uuid.UUID, 200)
+	for i := range users {
+		users[i] = uuid.New()
+	}
+
+	run := func(b *testing.B, ttl time.Duration, memo bool) {
+		a, fake := newCountingAuthenticator(ttl)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ctx := context.Background()
+			if memo {
+				ctx = WithPermissionMemo(ctx)
+			}
+			userID := users[i%len(users)]
+			for range 2 {
+				if _, err := a.CheckPermission(ctx, userID, "request_items", nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.ReportMetric(float64(fake.queries.Load())/float64(b.N), "queries/op")
+	}
+
+	b.Run("uncached", func(b *testing.B) { run(b, 0, false) })
+	b.Run("request memo", func(b *testing.B) { run(b, 0, true) })
+	b.Run("memo and shared cache", func(b *testing.B) { run(b, 30*time.Second, true) })
+}
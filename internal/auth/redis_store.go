@@ -3,8 +3,10 @@ package auth
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -78,6 +80,96 @@ func (r *redisStore) deleteRefreshToken(ctx context.Context, hash string) error
 	return r.client.Del(ctx, refreshTokenKey(hash)).Err()
 }
 
+// two-factor authentication operations
+
+func (r *redisStore) incrMFAAttempts(ctx context.Context, userID string, ttl time.Duration) (int64, error) {
+	pipe := r.client.Pipeline()
+	incrCmd := pipe.Incr(ctx, mfaAttemptsKey(userID))
+	pipe.ExpireNX(ctx, mfaAttemptsKey(userID), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incrCmd.Val(), nil
+}
+
+func (r *redisStore) deleteMFAAttempts(ctx context.Context, userID string) error {
+	return r.client.Del(ctx, mfaAttemptsKey(userID)).Err()
+}
+
+// API key rate limit operations
+
+// counts a request in the fixed window containing now and returns the count
+// so far
+func (r *redisStore) incrAPIKeyRequests(ctx context.Context, keyID string, now time.Time, window time.Duration) (int64, error) {
+	key := apiKeyRequestsKey(keyID, now.Truncate(window).Unix())
+	pipe := r.client.Pipeline()
+	incrCmd := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incrCmd.Val(), nil
+}
+
+// sign-in throttling operations
+
+// records an attempt in the sliding window ending at now and returns how
+// many attempts fall inside it
+func (r *redisStore) addToWindow(ctx context.Context, key string, now time.Time, window time.Duration) (int64, error) {
+	pipe := r.client.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Add(-window).UnixMilli(), 10))
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.UnixMilli()), Member: uuid.NewString()})
+	countCmd := pipe.ZCard(ctx, key)
+	pipe.Expire(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return countCmd.Val(), nil
+}
+
+func (r *redisStore) incrLockouts(ctx context.Context, email string, ttl time.Duration) (int64, error) {
+	pipe := r.client.Pipeline()
+	incrCmd := pipe.Incr(ctx, loginLockoutsKey(email))
+	pipe.Expire(ctx, loginLockoutsKey(email), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incrCmd.Val(), nil
+}
+
+// locks the account for ttl and starts its failure count over for when the
+// lockout ends
+func (r *redisStore) lockOut(ctx context.Context, email string, ttl time.Duration) error {
+	pipe := r.client.Pipeline()
+	pipe.Set(ctx, loginLockoutKey(email), "", ttl)
+	pipe.Del(ctx, loginFailuresKey(email))
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *redisStore) isLockedOut(ctx context.Context, email string) (bool, error) {
+	n, err := r.client.Exists(ctx, loginLockoutKey(email)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *redisStore) clearLoginFailures(ctx context.Context, email string) error {
+	return r.client.Del(ctx, loginFailuresKey(email), loginLockoutsKey(email)).Err()
+}
+
+// OIDC sign-in state operations
+
+func (r *redisStore) storeOIDCState(ctx context.Context, hash, state string, ttl time.Duration) error {
+	return r.client.Set(ctx, oidcStateKey(hash), state, ttl).Err()
+}
+
+// returns the state and deletes it, so each can be used once
+func (r *redisStore) takeOIDCState(ctx context.Context, hash string) (string, error) {
+	return r.client.GetDel(ctx, oidcStateKey(hash)).Result()
+}
+
 func otpCodeKey(email string) string {
 	return fmt.Sprintf("otp:code:%s", email)
 }
@@ -93,3 +185,31 @@ func otpCooldownKey(email string) string {
 func refreshTokenKey(hash string) string {
 	return fmt.Sprintf("refresh:token:%s", hash)
 }
+
+func mfaAttemptsKey(userID string) string {
+	return fmt.Sprintf("mfa:attempts:%s", userID)
+}
+
+func apiKeyRequestsKey(keyID string, window int64) string {
+	return fmt.Sprintf("apikey:requests:%s:%d", keyID, window)
+}
+
+func loginIPAttemptsKey(ip string) string {
+	return fmt.Sprintf("login:ip:%s", ip)
+}
+
+func loginFailuresKey(email string) string {
+	return fmt.Sprintf("login:failures:%s", email)
+}
+
+func loginLockoutKey(email string) string {
+	return fmt.Sprintf("login:lockout:%s", email)
+}
+
+func loginLockoutsKey(email string) string {
+	return fmt.Sprintf("login:lockouts:%s", email)
+}
+
+func oidcStateKey(hash string) string {
+	return fmt.Sprintf("oidc:state:%s", hash)
+}
@@ -9,12 +9,14 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/USSTM/cv-backend/generated/db"
 	"github.com/USSTM/cv-backend/internal/config"
 	"github.com/USSTM/cv-backend/internal/logging"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -26,6 +28,10 @@ var (
 	ErrUserNotFound   = errors.New("user not found")
 )
 
+// marks a stored refresh token as belonging to a session that passed
+// two-factor authentication
+const mfaRefreshSuffix = ":mfa"
+
 // AuthService handles passwordless OTP authentication and rotating refresh tokens.
 type AuthService struct {
 	store          *redisStore
@@ -35,10 +41,17 @@ type AuthService struct {
 	otpCooldown    time.Duration
 	otpMaxAttempts int
 	refreshExpiry  time.Duration
+	// brute-force protection; a zero limit turns its check off. The limits
+	// can change under traffic, see SetLoginLimits
+	loginIPLimit      atomic.Int64
+	loginFailureLimit atomic.Int64
+	loginWindow       time.Duration
+	lockoutBase       time.Duration
+	lockoutMax        time.Duration
 }
 
 func NewAuthService(redisClient *redis.Client, jwtSvc *JWTService, queries *db.Queries, cfg config.AuthConfig) *AuthService {
-	return &AuthService{
+	s := &AuthService{
 		store:          newRedisStore(redisClient),
 		jwt:            jwtSvc,
 		db:             queries,
@@ -46,7 +59,20 @@ func NewAuthService(redisClient *redis.Client, jwtSvc *JWTService, queries *db.Q
 		otpCooldown:    cfg.OTPCooldown,
 		otpMaxAttempts: cfg.OTPMaxAttempts,
 		refreshExpiry:  cfg.RefreshExpiry,
+
+		loginWindow: cfg.LoginWindow,
+		lockoutBase: cfg.LockoutBase,
+		lockoutMax:  cfg.LockoutMax,
 	}
+	s.SetLoginLimits(cfg.LoginIPLimit, cfg.LoginFailureLimit)
+	return s
+}
+
+// replaces the sign-in attempt and failure limits, for a configuration
+// reload. Attempts already counted in the window still count.
+func (s *AuthService) SetLoginLimits(ipLimit, failureLimit int) {
+	s.loginIPLimit.Store(int64(ipLimit))
+	s.loginFailureLimit.Store(int64(failureLimit))
 }
 
 // OTPExpiry returns the configured OTP expiry duration.
@@ -58,10 +84,13 @@ func (s *AuthService) OTPExpiry() time.Duration {
 func (s *AuthService) RequestOTP(ctx context.Context, email string) (string, error) {
 	email = strings.ToLower(email)
 
-	if _, err := s.db.GetUserByEmail(ctx, email); err != nil {
-		return "", ErrUserNotFound
+	if err := s.checkLoginAllowed(ctx, email); err != nil {
+		return "", err
 	}
 
+	// the cooldown applies whether or not the address is registered:
+	// otherwise a second request answering "wait" would give away which
+	// addresses have accounts
 	on, err := s.store.isOnCooldown(ctx, email)
 	if err != nil {
 		return "", fmt.Errorf("checking OTP cooldown: %w", err)
@@ -70,6 +99,13 @@ func (s *AuthService) RequestOTP(ctx context.Context, email string) (string, err
 		return "", ErrOTPCooldown
 	}
 
+	if _, err := s.db.GetUserByEmail(ctx, email); err != nil {
+		if err := s.store.setCooldown(ctx, email, s.otpCooldown); err != nil {
+			return "", fmt.Errorf("setting OTP cooldown: %w", err)
+		}
+		return "", ErrUserNotFound
+	}
+
 	code, err := generateOTPCode()
 	if err != nil {
 		return "", fmt.Errorf("generating OTP: %w", err)
@@ -90,12 +126,18 @@ func (s *AuthService) RequestOTP(ctx context.Context, email string) (string, err
 
 // checks the OTP code and returns a new access + refresh token pair.
 func (s *AuthService) VerifyOTP(ctx context.Context, email, code string) (accessToken, refreshToken string, err error) {
+	logger := logging.FromContext(ctx)
+
 	email = strings.ToLower(email)
 
+	if err := s.checkLoginAllowed(ctx, email); err != nil {
+		return "", "", err
+	}
+
 	storedHash, err := s.store.getOTPHash(ctx, email)
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return "", "", ErrOTPInvalid
+			return "", "", s.loginFailed(ctx, email, ErrOTPInvalid)
 		}
 		return "", "", fmt.Errorf("retrieving OTP hash: %w", err)
 	}
@@ -107,28 +149,33 @@ func (s *AuthService) VerifyOTP(ctx context.Context, email, code string) (access
 		}
 		if attempts > int64(s.otpMaxAttempts) {
 			if err := s.store.deleteOTP(ctx, email); err != nil {
-				logging.Error("failed to delete OTP on max attempts", "email", email, "error", err)
+				logger.Error("failed to delete OTP on max attempts", "email", email, "error", err)
 			}
-			return "", "", ErrOTPMaxAttempts
+			return "", "", s.loginFailed(ctx, email, ErrOTPMaxAttempts)
 		}
-		return "", "", ErrOTPInvalid
+		return "", "", s.loginFailed(ctx, email, ErrOTPInvalid)
 	}
 
 	// remove otp after verifying
 	if err := s.store.deleteOTP(ctx, email); err != nil {
 		return "", "", fmt.Errorf("deleting OTP: %w", err)
 	}
+	if err := s.recordLoginSuccess(ctx, email); err != nil {
+		logger.Error("failed to clear sign-in failures", "email", email, "error", err)
+	}
 
 	user, err := s.db.GetUserByEmail(ctx, email)
 	if err != nil {
 		return "", "", ErrUserNotFound
 	}
 
-	return s.issueTokenPair(ctx, user.ID)
+	return s.issueTokenPair(ctx, user.ID, false)
 }
 
 // rotates refresh token and returns new pair
 func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (newAccess, newRefresh string, err error) {
+	logger := logging.FromContext(ctx)
+
 	hash := hashString(refreshToken)
 
 	userIDStr, err := s.store.getRefreshToken(ctx, hash)
@@ -139,12 +186,25 @@ func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (newAcce
 		return "", "", fmt.Errorf("retrieving refresh token: %w", err)
 	}
 
+	userIDStr, mfaPassed := strings.CutSuffix(userIDStr, mfaRefreshSuffix)
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
 		return "", "", fmt.Errorf("invalid user ID in refresh token: %w", err)
 	}
 
-	newAccess, newRefresh, err = s.issueTokenPair(ctx, userID)
+	// deleting an account revokes its sessions: without this the token
+	// would keep minting access tokens for a user that no longer exists
+	if _, err := s.db.GetUserByID(ctx, userID); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return "", "", fmt.Errorf("looking up refresh token user: %w", err)
+		}
+		if err := s.store.deleteRefreshToken(ctx, hash); err != nil {
+			logger.Error("failed to delete refresh token of deleted user", "user_id", userID, "error", err)
+		}
+		return "", "", ErrRefreshInvalid
+	}
+
+	newAccess, newRefresh, err = s.issueTokenPair(ctx, userID, mfaPassed)
 	if err != nil {
 		return "", "", err
 	}
@@ -153,7 +213,7 @@ func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (newAcce
 		return "", "", fmt.Errorf("deleting refresh token: %w", err)
 	}
 
-	logging.Info("refresh token rotated", "user_id", userID)
+	logger.Info("refresh token rotated", "user_id", userID)
 	return newAccess, newRefresh, nil
 }
 
@@ -171,14 +231,29 @@ func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
 	}
 
 	if userIDStr != "" {
-		logging.Info("user logged out", "user_id", userIDStr)
+		userIDStr, _ = strings.CutSuffix(userIDStr, mfaRefreshSuffix)
+		logging.FromContext(ctx).Info("user logged out", "user_id", userIDStr)
 	}
 	return nil
 }
 
-// generates a JWT access token and a random refresh token
-func (s *AuthService) issueTokenPair(ctx context.Context, userID uuid.UUID) (accessToken, refreshToken string, err error) {
-	accessToken, err = s.jwt.GenerateToken(ctx, userID)
+// returns a new token pair for a user who proved who they are some way
+// other than an OTP, such as single sign-on
+func (s *AuthService) SignIn(ctx context.Context, userID uuid.UUID) (accessToken, refreshToken string, err error) {
+	return s.issueTokenPair(ctx, userID, false)
+}
+
+// generates a JWT access token and a random refresh token. mfaPassed is kept
+// with the refresh token so the session stays two-factor authenticated
+// across refreshes.
+func (s *AuthService) issueTokenPair(ctx context.Context, userID uuid.UUID, mfaPassed bool) (accessToken, refreshToken string, err error) {
+	value := userID.String()
+	if mfaPassed {
+		accessToken, err = s.jwt.GenerateMFAToken(ctx, userID)
+		value += mfaRefreshSuffix
+	} else {
+		accessToken, err = s.jwt.GenerateToken(ctx, userID)
+	}
 	if err != nil {
 		return "", "", fmt.Errorf("generating access token: %w", err)
 	}
@@ -189,7 +264,7 @@ func (s *AuthService) issueTokenPair(ctx context.Context, userID uuid.UUID) (acc
 	}
 
 	hash := hashString(rawRefresh)
-	if err := s.store.storeRefreshToken(ctx, hash, userID.String(), s.refreshExpiry); err != nil {
+	if err := s.store.storeRefreshToken(ctx, hash, value, s.refreshExpiry); err != nil {
 		return "", "", fmt.Errorf("storing refresh token: %w", err)
 	}
 
@@ -94,6 +94,18 @@ func TestAuthService_RequestOTP(t *testing.T) {
 		assert.ErrorIs(t, err, auth.ErrOTPCooldown)
 	})
 
+	t.Run("cooldown applies to unknown emails too", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		sharedDB.CleanupDatabase(t)
+		svc := newTestAuthService(t)
+
+		_, err := svc.RequestOTP(ctx, "ghost@example.com")
+		require.ErrorIs(t, err, auth.ErrUserNotFound)
+
+		_, err = svc.RequestOTP(ctx, "ghost@example.com")
+		assert.ErrorIs(t, err, auth.ErrOTPCooldown)
+	})
+
 	t.Run("different emails are independent", func(t *testing.T) {
 		sharedQueue.Cleanup(t)
 		sharedDB.CleanupDatabase(t)
@@ -272,6 +284,24 @@ func TestAuthService_Refresh(t *testing.T) {
 		_, _, err := svc.Refresh(ctx, "not-a-real-token")
 		assert.ErrorIs(t, err, auth.ErrRefreshInvalid)
 	})
+
+	t.Run("deleted user's token is revoked", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		sharedDB.CleanupDatabase(t)
+		svc := newTestAuthService(t)
+
+		user := sharedDB.NewUser(t).WithEmail("gone@example.com").Create()
+		code, err := svc.RequestOTP(ctx, user.Email)
+		require.NoError(t, err)
+		_, refresh, err := svc.VerifyOTP(ctx, user.Email, code)
+		require.NoError(t, err)
+
+		_, err = sharedDB.Pool().Exec(ctx, `DELETE FROM users WHERE id = $1`, user.ID)
+		require.NoError(t, err)
+
+		_, _, err = svc.Refresh(ctx, refresh)
+		assert.ErrorIs(t, err, auth.ErrRefreshInvalid)
+	})
 }
 
 func TestAuthService_Logout(t *testing.T) {
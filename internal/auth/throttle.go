@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/logging"
+)
+
+var (
+	ErrLoginThrottled = errors.New("too many sign-in attempts from this address")
+	ErrAccountLocked  = errors.New("account temporarily locked after repeated failures")
+)
+
+// how long an account's lockouts are remembered when deciding the next one's
+// length; an account that stays out of trouble this long starts over at the
+// base lockout
+const loginLockoutMemory = 24 * time.Hour
+
+// refuses a sign-in attempt from an address that has made too many, or for
+// an account that is locked out. Every attempt, failed or not, counts
+// against the client's address.
+func (s *AuthService) checkLoginAllowed(ctx context.Context, email string) error {
+	ip := GetClientIP(ctx)
+	if limit := s.loginIPLimit.Load(); ip != "" && limit > 0 {
+		attempts, err := s.store.addToWindow(ctx, loginIPAttemptsKey(ip), time.Now(), s.loginWindow)
+		if err != nil {
+			return fmt.Errorf("counting sign-in attempts: %w", err)
+		}
+		if attempts > limit {
+			// logged once as the limit is crossed, not for every attempt
+			// after it
+			if attempts == limit+1 {
+				logSecurityEvent(ctx, "login_ip_throttled", "client_ip", ip, "email", email, "attempts", attempts, "window", s.loginWindow.String())
+			}
+			return ErrLoginThrottled
+		}
+	}
+
+	locked, err := s.store.isLockedOut(ctx, email)
+	if err != nil {
+		return fmt.Errorf("checking account lockout: %w", err)
+	}
+	if locked {
+		return ErrAccountLocked
+	}
+	return nil
+}
+
+// counts a failed sign-in against the account, locking it once it has failed
+// loginFailureLimit times within the window. Each lockout within
+// loginLockoutMemory of the last lasts twice as long, up to lockoutMax.
+func (s *AuthService) recordLoginFailure(ctx context.Context, email string) error {
+	limit := s.loginFailureLimit.Load()
+	if limit <= 0 {
+		return nil
+	}
+
+	failures, err := s.store.addToWindow(ctx, loginFailuresKey(email), time.Now(), s.loginWindow)
+	if err != nil {
+		return fmt.Errorf("counting sign-in failures: %w", err)
+	}
+	if failures < limit {
+		if failures > 1 {
+			logSecurityEvent(ctx, "login_failed_repeatedly", "client_ip", GetClientIP(ctx), "email", email, "failures", failures)
+		}
+		return nil
+	}
+
+	lockouts, err := s.store.incrLockouts(ctx, email, loginLockoutMemory)
+	if err != nil {
+		return fmt.Errorf("counting account lockouts: %w", err)
+	}
+	duration := s.lockoutDuration(lockouts)
+	if err := s.store.lockOut(ctx, email, duration); err != nil {
+		return fmt.Errorf("locking account: %w", err)
+	}
+	logSecurityEvent(ctx, "account_locked", "client_ip", GetClientIP(ctx), "email", email, "failures", failures,
+		"lockouts", lockouts, "duration", duration.String())
+	return nil
+}
+
+// records the failure and returns err, the reason for it
+func (s *AuthService) loginFailed(ctx context.Context, email string, err error) error {
+	if recordErr := s.recordLoginFailure(ctx, email); recordErr != nil {
+		logging.FromContext(ctx).Error("failed to record sign-in failure", "email", email, "error", recordErr)
+	}
+	return err
+}
+
+// forgets the account's failures after a successful sign-in
+func (s *AuthService) recordLoginSuccess(ctx context.Context, email string) error {
+	if s.loginFailureLimit.Load() <= 0 {
+		return nil
+	}
+	return s.store.clearLoginFailures(ctx, email)
+}
+
+// length of the nth lockout: lockoutBase doubled for each earlier one,
+// capped at lockoutMax
+func (s *AuthService) lockoutDuration(n int64) time.Duration {
+	d := s.lockoutBase
+	for i := int64(1); i < n && d < s.lockoutMax; i++ {
+		d *= 2
+	}
+	return min(d, s.lockoutMax)
+}
+
+// security events share one message so they can be filtered for alerting
+func logSecurityEvent(ctx context.Context, event string, args ...any) {
+	logging.FromContext(ctx).Warn("security event", append([]any{"event", event}, args...)...)
+}
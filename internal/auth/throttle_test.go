@@ -0,0 +1,104 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/auth"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthService_LoginThrottling(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	newService := func(t *testing.T) *auth.AuthService {
+		t.Helper()
+		jwtSvc, err := auth.NewJWTService([]byte("test-signing-key"), "test-issuer", 15*time.Minute)
+		require.NoError(t, err)
+		return auth.NewAuthService(sharedQueue.Redis, jwtSvc, sharedDB.Queries(), config.AuthConfig{
+			OTPExpiry:         5 * time.Minute,
+			OTPMaxAttempts:    10,
+			RefreshExpiry:     7 * 24 * time.Hour,
+			LoginIPLimit:      5,
+			LoginFailureLimit: 3,
+			LoginWindow:       15 * time.Minute,
+			LockoutBase:       time.Minute,
+			LockoutMax:        time.Hour,
+		})
+	}
+	fromIP := func(ip string) context.Context {
+		return context.WithValue(context.Background(), auth.ClientIPKey, ip)
+	}
+
+	t.Run("repeated failures lock the account", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		sharedDB.CleanupDatabase(t)
+		svc := newService(t)
+		user := sharedDB.NewUser(t).WithEmail("locked@example.com").Create()
+
+		code, err := svc.RequestOTP(context.Background(), user.Email)
+		require.NoError(t, err)
+		for range 3 {
+			_, _, err = svc.VerifyOTP(context.Background(), user.Email, "000000")
+			assert.ErrorIs(t, err, auth.ErrOTPInvalid)
+		}
+
+		// even the right code is refused until the lockout ends
+		_, _, err = svc.VerifyOTP(context.Background(), user.Email, code)
+		assert.ErrorIs(t, err, auth.ErrAccountLocked)
+		_, err = svc.RequestOTP(context.Background(), user.Email)
+		assert.ErrorIs(t, err, auth.ErrAccountLocked)
+	})
+
+	t.Run("unregistered addresses lock the same way", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		svc := newService(t)
+
+		for range 3 {
+			_, _, err := svc.VerifyOTP(context.Background(), "ghost@example.com", "000000")
+			assert.ErrorIs(t, err, auth.ErrOTPInvalid)
+		}
+		_, _, err := svc.VerifyOTP(context.Background(), "ghost@example.com", "000000")
+		assert.ErrorIs(t, err, auth.ErrAccountLocked)
+	})
+
+	t.Run("success clears the failures", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		sharedDB.CleanupDatabase(t)
+		svc := newService(t)
+		user := sharedDB.NewUser(t).WithEmail("recovered@example.com").Create()
+
+		code, err := svc.RequestOTP(context.Background(), user.Email)
+		require.NoError(t, err)
+		for range 2 {
+			_, _, err = svc.VerifyOTP(context.Background(), user.Email, "000000")
+			assert.ErrorIs(t, err, auth.ErrOTPInvalid)
+		}
+		_, _, err = svc.VerifyOTP(context.Background(), user.Email, code)
+		require.NoError(t, err)
+
+		_, _, err = svc.VerifyOTP(context.Background(), user.Email, "000000")
+		assert.ErrorIs(t, err, auth.ErrOTPInvalid, "the count started over")
+	})
+
+	t.Run("one address is limited across accounts", func(t *testing.T) {
+		sharedQueue.Cleanup(t)
+		svc := newService(t)
+		ctx := fromIP("203.0.113.7")
+
+		for _, email := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"} {
+			_, _, err := svc.VerifyOTP(ctx, email, "000000")
+			assert.ErrorIs(t, err, auth.ErrOTPInvalid)
+		}
+		_, _, err := svc.VerifyOTP(ctx, "f@example.com", "000000")
+		assert.ErrorIs(t, err, auth.ErrLoginThrottled)
+
+		_, _, err = svc.VerifyOTP(fromIP("198.51.100.1"), "f@example.com", "000000")
+		assert.ErrorIs(t, err, auth.ErrOTPInvalid, "other addresses are unaffected")
+	})
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the SHA-1 vectors from RFC 6238, appendix B, truncated to six digits
+func TestTOTPCode(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	for unix, want := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		code, err := totpCode(secret, totpStep(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, want, code, "at %d", unix)
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	require.NoError(t, err)
+	now := time.Unix(1_800_000_000, 0)
+
+	code, err := totpCode(secret, totpStep(now)-1)
+	require.NoError(t, err)
+	step, ok, err := matchTOTP(secret, code, now)
+	require.NoError(t, err)
+	assert.True(t, ok, "the previous step is allowed for clock drift")
+	assert.Equal(t, totpStep(now)-1, step)
+
+	_, ok, err = matchTOTP(secret, code, now.Add(2*totpPeriod*time.Second))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := totpURI("JBSWY3DPEHPK3PXP", "admin@usstm.ca")
+	assert.Equal(t, "otpauth://totp/Campus%20Vault:admin@usstm.ca?algorithm=SHA1&digits=6&issuer=Campus+Vault&period=30&secret=JBSWY3DPEHPK3PXP", uri)
+}
+
+func TestGenerateBackupCodes(t *testing.T) {
+	codes, hashes, err := generateBackupCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, mfaBackupCodeCount)
+
+	for i, code := range codes {
+		assert.Regexp(t, `^[a-z2-9]{5}-[a-z2-9]{5}$`, code)
+		assert.Equal(t, hashes[i], hashString(normalizeBackupCode(" "+strings.ToUpper(code))))
+	}
+}
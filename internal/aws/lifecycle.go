@@ -0,0 +1,68 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// what S3 removes from the bucket on its own. Zero days leaves a rule out.
+type LifecycleRules struct {
+	// unfinished multipart uploads are aborted this many days after they start
+	AbortUploadsAfterDays int
+	// objects under each key prefix are deleted this many days after upload
+	ExpireAfterDays map[string]int
+}
+
+// replaces the bucket's lifecycle configuration with rules, so rules added to
+// the bucket by hand are dropped. Does nothing when rules has none to apply.
+func (s *S3Service) ApplyLifecycle(ctx context.Context, rules LifecycleRules) error {
+	lifecycle := rules.build()
+	if len(lifecycle) == 0 {
+		return nil
+	}
+	_, err := s.client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(s.bucket),
+		LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: lifecycle},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put S3 bucket lifecycle: %w", err)
+	}
+	return nil
+}
+
+func (r LifecycleRules) build() []types.LifecycleRule {
+	var rules []types.LifecycleRule
+	if r.AbortUploadsAfterDays > 0 {
+		rules = append(rules, types.LifecycleRule{
+			ID:     aws.String("abort-incomplete-uploads"),
+			Status: types.ExpirationStatusEnabled,
+			Filter: &types.LifecycleRuleFilterMemberPrefix{Value: ""},
+			AbortIncompleteMultipartUpload: &types.AbortIncompleteMultipartUpload{
+				DaysAfterInitiation: aws.Int32(int32(r.AbortUploadsAfterDays)),
+			},
+		})
+	}
+
+	prefixes := make([]string, 0, len(r.ExpireAfterDays))
+	for prefix, days := range r.ExpireAfterDays {
+		if days > 0 {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		rules = append(rules, types.LifecycleRule{
+			ID:         aws.String("expire-" + strings.TrimSuffix(prefix, "/")),
+			Status:     types.ExpirationStatusEnabled,
+			Filter:     &types.LifecycleRuleFilterMemberPrefix{Value: prefix},
+			Expiration: &types.LifecycleExpiration{Days: aws.Int32(int32(r.ExpireAfterDays[prefix]))},
+		})
+	}
+	return rules
+}
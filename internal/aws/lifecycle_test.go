@@ -0,0 +1,28 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycleRules_Build(t *testing.T) {
+	rules := LifecycleRules{
+		AbortUploadsAfterDays: 2,
+		ExpireAfterDays:       map[string]int{"reports/": 30, "exports/": 0},
+	}.build()
+
+	require.Len(t, rules, 2, "a rule of zero days is left out")
+	assert.Equal(t, "abort-incomplete-uploads", aws.ToString(rules[0].ID))
+	assert.Equal(t, &types.LifecycleRuleFilterMemberPrefix{Value: ""}, rules[0].Filter)
+	assert.Equal(t, int32(2), aws.ToInt32(rules[0].AbortIncompleteMultipartUpload.DaysAfterInitiation))
+
+	assert.Equal(t, "expire-reports", aws.ToString(rules[1].ID))
+	assert.Equal(t, &types.LifecycleRuleFilterMemberPrefix{Value: "reports/"}, rules[1].Filter)
+	assert.Equal(t, int32(30), aws.ToInt32(rules[1].Expiration.Days))
+
+	assert.Empty(t, LifecycleRules{}.build())
+}
@@ -0,0 +1,264 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// S3 rejects parts smaller than this, except the last
+	MinPartSize = 5 << 20
+	// S3 allows at most this many parts in one upload
+	MaxParts = 10000
+)
+
+// how UploadStream splits a file into parts and sends them.
+type MultipartOptions struct {
+	// bytes per part; files no bigger than one part are sent in a single
+	// PutObject. Raised to MinPartSize when smaller.
+	PartSize int64
+	// parts uploaded at once; each holds a PartSize buffer in memory
+	Concurrency int
+	// further attempts at a part after the first fails
+	MaxRetries int
+	// the wait before the first retry, doubling for each one after
+	RetryDelay time.Duration
+}
+
+var DefaultMultipartOptions = MultipartOptions{
+	PartSize:    8 << 20,
+	Concurrency: 4,
+	MaxRetries:  3,
+	RetryDelay:  500 * time.Millisecond,
+}
+
+// called after each part is stored with the bytes stored so far. Parts finish
+// out of order, so calls may come from several goroutines at once.
+type ProgressFunc func(uploaded int64)
+
+// the calls a multipart upload makes; *s3.Client implements it.
+type multipartClient interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+}
+
+// streams body to key without holding more than Concurrency parts in memory.
+// Bodies that fit in one part are sent with a plain PutObject; bigger ones as
+// a multipart upload, retrying each failed part and aborting the upload if a
+// part still fails. progress may be nil.
+func (s *S3Service) UploadStream(ctx context.Context, key string, body io.Reader, contentType string, progress ProgressFunc) error {
+	u := uploader{client: s.client, bucket: s.bucket, opts: s.multipart}
+	return u.upload(ctx, key, body, contentType, progress)
+}
+
+type uploader struct {
+	client multipartClient
+	bucket string
+	opts   MultipartOptions
+}
+
+type part struct {
+	number int32
+	data   []byte
+}
+
+func (u uploader) upload(ctx context.Context, key string, body io.Reader, contentType string, progress ProgressFunc) error {
+	partSize := max(u.opts.PartSize, MinPartSize)
+	concurrency := max(u.opts.Concurrency, 1)
+	if progress == nil {
+		progress = func(int64) {}
+	}
+
+	first := make([]byte, partSize)
+	n, err := io.ReadFull(body, first)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return fmt.Errorf("failed to read file for S3 upload: %w", err)
+	}
+	if n < len(first) {
+		// no bigger than one part
+		if _, err := u.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(u.bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(first[:n]),
+			ContentType: aws.String(contentType),
+		}); err != nil {
+			return fmt.Errorf("failed to upload file to S3: %w", err)
+		}
+		progress(int64(n))
+		return nil
+	}
+
+	created, err := u.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(u.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload to S3: %w", err)
+	}
+	uploadID := created.UploadId
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		completed []types.CompletedPart
+		firstErr  error
+		uploaded  atomic.Int64
+		wg        sync.WaitGroup
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	// buffers are handed back once their part is stored, so at most
+	// concurrency parts are held at once
+	buffers := make(chan []byte, concurrency)
+	for range concurrency - 1 {
+		buffers <- make([]byte, partSize)
+	}
+	parts := make(chan part)
+
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range parts {
+				etag, err := u.uploadPart(ctx, key, uploadID, p)
+				if err != nil {
+					fail(err)
+				} else {
+					mu.Lock()
+					completed = append(completed, types.CompletedPart{ETag: etag, PartNumber: aws.Int32(p.number)})
+					mu.Unlock()
+					progress(uploaded.Add(int64(len(p.data))))
+				}
+				buffers <- p.data[:cap(p.data)]
+			}
+		}()
+	}
+
+	send := func(p part) bool {
+		select {
+		case parts <- p:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for p := (part{number: 1, data: first}); send(p); {
+		if p.number == MaxParts {
+			// anything left would need one part too many
+			if _, err := io.ReadFull(body, make([]byte, 1)); err != io.EOF {
+				fail(fmt.Errorf("file needs more than %d parts of %d bytes", MaxParts, partSize))
+			}
+			break
+		}
+
+		var buf []byte
+		select {
+		case buf = <-buffers:
+		case <-ctx.Done():
+		}
+		if buf == nil {
+			break
+		}
+		n, err := io.ReadFull(body, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			fail(fmt.Errorf("failed to read file for S3 upload: %w", err))
+			break
+		}
+		p = part{number: p.number + 1, data: buf[:n]}
+		if n < len(buf) {
+			// the last part
+			send(p)
+			break
+		}
+	}
+	close(parts)
+	wg.Wait()
+
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = ctx.Err()
+	}
+	if firstErr != nil {
+		u.abort(key, uploadID)
+		return firstErr
+	}
+
+	sort.Slice(completed, func(i, j int) bool { return *completed[i].PartNumber < *completed[j].PartNumber })
+	if _, err := u.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(u.bucket),
+		Key:             aws.String(key),
+		UploadId:        uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	}); err != nil {
+		u.abort(key, uploadID)
+		return fmt.Errorf("failed to complete multipart upload to S3: %w", err)
+	}
+	return nil
+}
+
+// uploads one part, retrying with exponential backoff
+func (u uploader) uploadPart(ctx context.Context, key string, uploadID *string, p part) (*string, error) {
+	var err error
+	for attempt := 0; attempt <= u.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(u.opts.RetryDelay << (attempt - 1)):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		var out *s3.UploadPartOutput
+		out, err = u.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(u.bucket),
+			Key:        aws.String(key),
+			UploadId:   uploadID,
+			PartNumber: aws.Int32(p.number),
+			Body:       bytes.NewReader(p.data),
+		})
+		if err == nil {
+			return out.ETag, nil
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			break
+		}
+	}
+	return nil, fmt.Errorf("failed to upload part %d to S3: %w", p.number, err)
+}
+
+// drops the parts stored so far; S3 keeps charging for them otherwise. Runs
+// even when the upload was cancelled.
+func (u uploader) abort(key string, uploadID *string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, _ = u.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(u.bucket),
+		Key:      aws.String(key),
+		UploadId: uploadID,
+	})
+}
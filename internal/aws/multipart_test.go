@@ -0,0 +1,123 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keeps parts in memory, failing each part number failures[n] times first
+type fakeMultipartClient struct {
+	mu        sync.Mutex
+	failures  map[int32]int
+	put       []byte
+	parts     map[int32][]byte
+	completed []int32
+	aborted   bool
+}
+
+func (f *fakeMultipartClient) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.put = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeMultipartClient) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	f.parts = map[int32][]byte{}
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil
+}
+
+func (f *fakeMultipartClient) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := *params.PartNumber
+	if f.failures[n] > 0 {
+		f.failures[n]--
+		return nil, errors.New("connection reset")
+	}
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.parts[n] = data
+	return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf("etag-%d", n))}, nil
+}
+
+func (f *fakeMultipartClient) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	for _, p := range params.MultipartUpload.Parts {
+		f.completed = append(f.completed, *p.PartNumber)
+	}
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (f *fakeMultipartClient) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	f.aborted = true
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+// the parts joined back together in order
+func (f *fakeMultipartClient) assembled() []byte {
+	var out []byte
+	for _, n := range f.completed {
+		out = append(out, f.parts[n]...)
+	}
+	return out
+}
+
+func TestUploader_Upload(t *testing.T) {
+	opts := MultipartOptions{PartSize: MinPartSize, Concurrency: 3, MaxRetries: 2}
+	file := make([]byte, 3*MinPartSize+1234)
+	for i := range file {
+		file[i] = byte(i % 251)
+	}
+
+	t.Run("a small file goes in one request", func(t *testing.T) {
+		client := &fakeMultipartClient{}
+		var progress int64
+		u := uploader{client: client, bucket: "bucket", opts: opts}
+		require.NoError(t, u.upload(context.Background(), "small.txt", bytes.NewReader(file[:100]), "text/plain", func(n int64) { progress = n }))
+		assert.Equal(t, file[:100], client.put)
+		assert.Nil(t, client.parts)
+		assert.Equal(t, int64(100), progress)
+	})
+
+	t.Run("a large file goes in parts, in order", func(t *testing.T) {
+		client := &fakeMultipartClient{failures: map[int32]int{2: 2}}
+		var mu sync.Mutex
+		var progress []int64
+		u := uploader{client: client, bucket: "bucket", opts: opts}
+		err := u.upload(context.Background(), "video.mp4", bytes.NewReader(file), "video/mp4", func(n int64) {
+			mu.Lock()
+			defer mu.Unlock()
+			progress = append(progress, n)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int32{1, 2, 3, 4}, client.completed, "part 2 succeeds on its last retry")
+		assert.Equal(t, file, client.assembled())
+		assert.False(t, client.aborted)
+		assert.Len(t, progress, 4)
+		assert.Contains(t, progress, int64(len(file)))
+	})
+
+	t.Run("a part that keeps failing aborts the upload", func(t *testing.T) {
+		client := &fakeMultipartClient{failures: map[int32]int{3: 3}}
+		u := uploader{client: client, bucket: "bucket", opts: opts}
+		err := u.upload(context.Background(), "video.mp4", bytes.NewReader(file), "video/mp4", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "part 3")
+		assert.True(t, client.aborted)
+		assert.Empty(t, client.completed)
+	})
+}
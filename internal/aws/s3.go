@@ -15,8 +15,9 @@ import (
 )
 
 type S3Service struct {
-	client *s3.Client
-	bucket string
+	client    *s3.Client
+	bucket    string
+	multipart MultipartOptions
 }
 
 func NewS3Service(cfg config.AWSConfig) (*S3Service, error) {
@@ -32,12 +33,25 @@ func NewS3Service(cfg config.AWSConfig) (*S3Service, error) {
 		}
 	})
 
+	multipart := DefaultMultipartOptions
+	if cfg.PartSizeMB > 0 {
+		multipart.PartSize = int64(cfg.PartSizeMB) << 20
+	}
+	if cfg.PartConcurrency > 0 {
+		multipart.Concurrency = cfg.PartConcurrency
+	}
+	if cfg.PartRetries >= 0 {
+		multipart.MaxRetries = cfg.PartRetries
+	}
+
 	return &S3Service{
-		client: client,
-		bucket: cfg.Bucket,
+		client:    client,
+		bucket:    cfg.Bucket,
+		multipart: multipart,
 	}, nil
 }
 
+// sends body in a single request; UploadStream suits files that may be large.
 func (s *S3Service) PutObject(ctx context.Context, key string, body io.Reader, contentType string) error {
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(s.bucket),
@@ -111,14 +125,29 @@ func (s *S3Service) ListBuckets(ctx context.Context) ([]types.Bucket, error) {
 }
 
 func (s *S3Service) ListObjects(ctx context.Context) ([]types.Object, error) {
-	output, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+	return s.ListObjectsWithPrefix(ctx, "")
+}
+
+// pages through every object whose key starts with prefix.
+func (s *S3Service) ListObjectsWithPrefix(ctx context.Context, prefix string) ([]types.Object, error) {
+	input := &s3.ListObjectsV2Input{
 		Bucket: aws.String(s.bucket),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+
+	var objects []types.Object
+	paginator := s3.NewListObjectsV2Paginator(s.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		objects = append(objects, page.Contents...)
 	}
 
-	return output.Contents, nil
+	return objects, nil
 }
 
 func (s *S3Service) DeleteObject(ctx context.Context, key string) error {
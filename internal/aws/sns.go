@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// sends text messages with SNS Publish. SNS is called over its query API,
+// signed with SigV4, so the worker doesn't need the full SNS client for one
+// action.
+type SMSService struct {
+	httpClient  *http.Client
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	region      string
+	endpoint    string
+}
+
+func NewSMSService(cfg config.AWSConfig) (*SMSService, error) {
+	awsCfg, err := LoadAWSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// override endpoint if provided (for LocalStack)
+	endpoint := fmt.Sprintf("https://sns.%s.amazonaws.com/", awsCfg.Region)
+	if cfg.EndpointURL != "" {
+		endpoint = strings.TrimSuffix(cfg.EndpointURL, "/") + "/"
+	}
+
+	return &SMSService{
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		credentials: awsCfg.Credentials,
+		signer:      v4.NewSigner(),
+		region:      awsCfg.Region,
+		endpoint:    endpoint,
+	}, nil
+}
+
+type snsError struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+func (s *SMSService) SendSMS(ctx context.Context, to, body string) error {
+	form := url.Values{
+		"Action":      {"Publish"},
+		"Version":     {"2010-03-31"},
+		"PhoneNumber": {to},
+		"Message":     {body},
+		// transactional texts skip the promotional-rate queue and quiet hours
+		"MessageAttributes.entry.1.Name":              {"AWS.SNS.SMS.SMSType"},
+		"MessageAttributes.entry.1.Value.DataType":    {"String"},
+		"MessageAttributes.entry.1.Value.StringValue": {"Transactional"},
+	}
+	payload := form.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, strings.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build SNS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	creds, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256([]byte(payload))
+	if err := s.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "sns", s.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign SNS request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var snsErr snsError
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = xml.Unmarshal(raw, &snsErr)
+	// InvalidParameter is how SNS rejects a malformed or opted-out number
+	if snsErr.Code == "InvalidParameter" || snsErr.Code == "OptedOut" {
+		return fmt.Errorf("failed to send SMS: %s: %s: %w", snsErr.Code, snsErr.Message, queue.ErrUndeliverable)
+	}
+	return fmt.Errorf("failed to send SMS: status %d: %s %s", resp.StatusCode, snsErr.Code, snsErr.Message)
+}
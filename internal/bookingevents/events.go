@@ -0,0 +1,100 @@
+package bookingevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/google/uuid"
+)
+
+var ErrNoEvents = errors.New("booking has no events")
+
+// appends a status transition to the booking's log. q must be bound to the
+// transaction that made the change so the two commit or roll back together.
+func Record(ctx context.Context, q *db.Queries, bookingID uuid.UUID, actorID *uuid.UUID, from db.NullRequestStatus, to db.RequestStatus, payload map[string]any) error {
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode booking event payload: %w", err)
+	}
+
+	if _, err := q.AppendBookingEvent(ctx, db.AppendBookingEventParams{
+		BookingID:  bookingID,
+		ActorID:    actorID,
+		FromStatus: from,
+		ToStatus:   to,
+		Payload:    data,
+	}); err != nil {
+		return fmt.Errorf("failed to append booking event: %w", err)
+	}
+	return nil
+}
+
+// status a booking's events lead to. events must be in log order; each one
+// has to start from where the previous one ended.
+func Replay(events []db.BookingEvent) (db.RequestStatus, error) {
+	if len(events) == 0 {
+		return "", ErrNoEvents
+	}
+	if events[0].FromStatus.Valid {
+		return "", fmt.Errorf("event %s: log starts from %s instead of creation", events[0].ID, events[0].FromStatus.RequestStatus)
+	}
+
+	status := events[0].ToStatus
+	for _, e := range events[1:] {
+		if !e.FromStatus.Valid || e.FromStatus.RequestStatus != status {
+			return "", fmt.Errorf("event %s: transition from %q does not follow %s", e.ID, e.FromStatus.RequestStatus, status)
+		}
+		status = e.ToStatus
+	}
+	return status, nil
+}
+
+type Inconsistency struct {
+	BookingID uuid.UUID
+	Status    db.RequestStatus
+	// status derived from the events; empty when they could not be replayed
+	Derived db.RequestStatus
+	Reason  string
+}
+
+// replays every booking's events and reports those whose stored status does
+// not match.
+func Check(ctx context.Context, q *db.Queries) ([]Inconsistency, error) {
+	bookings, err := q.ListBookingStatuses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bookings: %w", err)
+	}
+	events, err := q.ListAllBookingEvents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list booking events: %w", err)
+	}
+
+	byBooking := make(map[uuid.UUID][]db.BookingEvent)
+	for _, e := range events {
+		byBooking[e.BookingID] = append(byBooking[e.BookingID], e)
+	}
+
+	var found []Inconsistency
+	for _, b := range bookings {
+		derived, err := Replay(byBooking[b.ID])
+		if err != nil {
+			found = append(found, Inconsistency{BookingID: b.ID, Status: b.Status, Reason: err.Error()})
+			continue
+		}
+		if derived != b.Status {
+			found = append(found, Inconsistency{
+				BookingID: b.ID,
+				Status:    b.Status,
+				Derived:   derived,
+				Reason:    fmt.Sprintf("events lead to %s but booking is %s", derived, b.Status),
+			})
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,52 @@
+package bookingevents_test
+
+import (
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/bookingevents"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func event(from *db.RequestStatus, to db.RequestStatus) db.BookingEvent {
+	e := db.BookingEvent{ID: uuid.New(), ToStatus: to}
+	if from != nil {
+		e.FromStatus = db.NullRequestStatus{RequestStatus: *from, Valid: true}
+	}
+	return e
+}
+
+func TestReplay(t *testing.T) {
+	pending := db.RequestStatusPendingConfirmation
+	confirmed := db.RequestStatusConfirmed
+
+	t.Run("follows the transitions", func(t *testing.T) {
+		status, err := bookingevents.Replay([]db.BookingEvent{
+			event(nil, pending),
+			event(&pending, confirmed),
+			event(&confirmed, db.RequestStatusCancelled),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, db.RequestStatusCancelled, status)
+	})
+
+	t.Run("no events", func(t *testing.T) {
+		_, err := bookingevents.Replay(nil)
+		assert.ErrorIs(t, err, bookingevents.ErrNoEvents)
+	})
+
+	t.Run("log must start at creation", func(t *testing.T) {
+		_, err := bookingevents.Replay([]db.BookingEvent{event(&pending, confirmed)})
+		assert.Error(t, err)
+	})
+
+	t.Run("gap in the log", func(t *testing.T) {
+		_, err := bookingevents.Replay([]db.BookingEvent{
+			event(nil, pending),
+			event(&confirmed, db.RequestStatusCancelled),
+		})
+		assert.Error(t, err)
+	})
+}
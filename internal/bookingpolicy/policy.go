@@ -0,0 +1,103 @@
+package bookingpolicy
+
+import (
+	"context"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// confirmation rules for a group's bookings.
+type Policy struct {
+	// how long after a booking is created the requester has to confirm it
+	ConfirmationWindow time.Duration
+	// confirmation closes this long before pickup
+	PickupCutoff time.Duration
+	// false when the group has its own policy
+	IsDefault bool
+}
+
+// last moment a booking created at createdAt for pickup at pickUp can be
+// confirmed: the end of the window or the pickup cutoff, whichever is first.
+func (p Policy) ConfirmationDeadline(createdAt, pickUp time.Time) time.Time {
+	deadline := createdAt.Add(p.ConfirmationWindow)
+	if cutoff := pickUp.Add(-p.PickupCutoff); cutoff.Before(deadline) {
+		return cutoff
+	}
+	return deadline
+}
+
+// applies a group's overrides on top of the defaults.
+func (p Policy) With(row db.GroupBookingPolicy) Policy {
+	if row.ConfirmationWindowHours.Valid {
+		p.ConfirmationWindow = time.Duration(row.ConfirmationWindowHours.Int32) * time.Hour
+	}
+	if row.PickupCutoffHours.Valid {
+		p.PickupCutoff = time.Duration(row.PickupCutoffHours.Int32) * time.Hour
+	}
+	p.IsDefault = false
+	return p
+}
+
+// resolves the policy that applies to a group's bookings.
+type Resolver struct {
+	defaults Policy
+}
+
+func NewResolver(cfg config.BookingConfig) *Resolver {
+	return &Resolver{defaults: Policy{
+		ConfirmationWindow: cfg.ConfirmationWindow,
+		PickupCutoff:       cfg.PickupCutoff,
+		IsDefault:          true,
+	}}
+}
+
+func (r *Resolver) Defaults() Policy {
+	return r.defaults
+}
+
+// policy for one group; the defaults when groupID is nil or the group has no
+// policy of its own.
+func (r *Resolver) ForGroup(ctx context.Context, q *db.Queries, groupID *uuid.UUID) (Policy, error) {
+	if groupID == nil {
+		return r.defaults, nil
+	}
+	row, err := q.GetGroupBookingPolicy(ctx, *groupID)
+	if err == pgx.ErrNoRows {
+		return r.defaults, nil
+	}
+	if err != nil {
+		return Policy{}, err
+	}
+	return r.defaults.With(row), nil
+}
+
+// every group's policy, for resolving many bookings with one query.
+func (r *Resolver) All(ctx context.Context, q *db.Queries) (Policies, error) {
+	rows, err := q.ListGroupBookingPolicies(ctx)
+	if err != nil {
+		return Policies{}, err
+	}
+	byGroup := make(map[uuid.UUID]Policy, len(rows))
+	for _, row := range rows {
+		byGroup[row.GroupID] = r.defaults.With(row)
+	}
+	return Policies{defaults: r.defaults, byGroup: byGroup}, nil
+}
+
+type Policies struct {
+	defaults Policy
+	byGroup  map[uuid.UUID]Policy
+}
+
+func (p Policies) ForGroup(groupID *uuid.UUID) Policy {
+	if groupID != nil {
+		if policy, ok := p.byGroup[*groupID]; ok {
+			return policy
+		}
+	}
+	return p.defaults
+}
@@ -0,0 +1,51 @@
+package bookingpolicy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/bookingpolicy"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmationDeadline(t *testing.T) {
+	created := time.Date(2026, 9, 1, 9, 0, 0, 0, time.UTC)
+	policy := bookingpolicy.Policy{ConfirmationWindow: 48 * time.Hour, PickupCutoff: 12 * time.Hour}
+
+	t.Run("window ends first", func(t *testing.T) {
+		pickUp := created.AddDate(0, 0, 7)
+		assert.Equal(t, created.Add(48*time.Hour), policy.ConfirmationDeadline(created, pickUp))
+	})
+
+	t.Run("pickup cutoff ends first", func(t *testing.T) {
+		pickUp := created.Add(24 * time.Hour)
+		assert.Equal(t, created.Add(12*time.Hour), policy.ConfirmationDeadline(created, pickUp))
+	})
+}
+
+func TestPolicyWith(t *testing.T) {
+	defaults := bookingpolicy.NewResolver(config.BookingConfig{
+		ConfirmationWindow: 48 * time.Hour,
+	}).Defaults()
+	assert.True(t, defaults.IsDefault)
+
+	t.Run("overrides only what the group set", func(t *testing.T) {
+		policy := defaults.With(db.GroupBookingPolicy{
+			PickupCutoffHours: pgtype.Int4{Int32: 24, Valid: true},
+		})
+		assert.Equal(t, 48*time.Hour, policy.ConfirmationWindow)
+		assert.Equal(t, 24*time.Hour, policy.PickupCutoff)
+		assert.False(t, policy.IsDefault)
+	})
+
+	t.Run("window override", func(t *testing.T) {
+		policy := defaults.With(db.GroupBookingPolicy{
+			ConfirmationWindowHours: pgtype.Int4{Int32: 72, Valid: true},
+		})
+		assert.Equal(t, 72*time.Hour, policy.ConfirmationWindow)
+		assert.Equal(t, time.Duration(0), policy.PickupCutoff)
+	})
+}
@@ -0,0 +1,207 @@
+package borrowingpolicy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/google/uuid"
+)
+
+// loan length given to approved bookings when no policy sets one.
+const DefaultLoanDays = 7
+
+// which limit a loan broke; sent to clients with the violation.
+type Rule string
+
+const (
+	RuleMaxActiveBorrowings Rule = "max_active_borrowings"
+	RuleMaxQuantity         Rule = "max_quantity"
+	RuleLoanDuration        Rule = "loan_duration"
+	RuleBlackout            Rule = "blackout"
+	RuleApprovedQuantity    Rule = "approved_quantity"
+)
+
+// a loan the policies do not allow.
+type Violation struct {
+	Rule    Rule
+	Message string
+	// the limit broken; zero for blackouts
+	Limit int
+	// the policy or blackout that set it; nil for the approved quantity
+	SourceID *uuid.UUID
+}
+
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// a loan being asked for, by pickup or by approval.
+type Loan struct {
+	Quantity int
+	// zero when approving a request that has no booking, which only the
+	// quantity and active borrowing limits apply to
+	Start, Due time.Time
+	// units approved for a high-value pickup; zero outside one
+	Approved int
+}
+
+// the limits on one user borrowing one type of item.
+type Limits struct {
+	Policies  []db.BorrowingPolicy
+	Blackouts []db.BorrowingBlackout
+	// borrowings the user has out now, by item type
+	Active map[db.ItemType]int
+}
+
+// the limits on userID borrowing items of itemType. A nil userID, as for a
+// request whose requester was deleted, matches only policies without a role.
+func Load(ctx context.Context, q *db.Queries, userID *uuid.UUID, itemType db.ItemType) (Limits, error) {
+	policies, err := q.ListBorrowingPoliciesForUser(ctx, db.ListBorrowingPoliciesForUserParams{
+		ItemType: itemType,
+		UserID:   userID,
+	})
+	if err != nil {
+		return Limits{}, err
+	}
+	blackouts, err := q.ListBorrowingBlackoutsForItemType(ctx, itemType)
+	if err != nil {
+		return Limits{}, err
+	}
+	active := map[db.ItemType]int{}
+	if userID != nil && len(policies) > 0 {
+		counts, err := q.CountActiveBorrowingsByItemType(ctx, userID)
+		if err != nil {
+			return Limits{}, err
+		}
+		for _, c := range counts {
+			active[c.Type] = int(c.Count)
+		}
+	}
+	return Limits{Policies: policies, Blackouts: blackouts, Active: active}, nil
+}
+
+// days an approved booking is lent for: the shortest any policy allows.
+func (l Limits) LoanDays() int {
+	days := 0
+	for _, p := range l.Policies {
+		if p.LoanDays.Valid && (days == 0 || int(p.LoanDays.Int32) < days) {
+			days = int(p.LoanDays.Int32)
+		}
+	}
+	if days == 0 {
+		return DefaultLoanDays
+	}
+	return days
+}
+
+// whether a high-value pickup may take fewer units than were approved: only
+// when some policy allows it and none forbids it.
+func (l Limits) AllowsPartialPickup() bool {
+	allowed := false
+	for _, p := range l.Policies {
+		if p.AllowPartialPickup.Valid {
+			if !p.AllowPartialPickup.Bool {
+				return false
+			}
+			allowed = true
+		}
+	}
+	return allowed
+}
+
+// the first rule loan breaks, or nil when it is allowed. Every policy that
+// applies is checked, so the strictest limit of each kind is the one that
+// counts.
+func (l Limits) Check(loan Loan) *Violation {
+	if !loan.Start.IsZero() {
+		for _, b := range l.Blackouts {
+			for _, at := range []time.Time{loan.Start, loan.Due} {
+				if !at.Before(b.StartsAt.Time) && at.Before(b.EndsAt.Time) {
+					return &Violation{
+						Rule:     RuleBlackout,
+						Message:  fmt.Sprintf("Items cannot be picked up or returned between %s and %s: %s", b.StartsAt.Time.Format("2006-01-02 15:04"), b.EndsAt.Time.Format("2006-01-02 15:04"), b.Reason),
+						SourceID: &b.ID,
+					}
+				}
+			}
+		}
+	}
+
+	if p, limit := l.strictest(func(p db.BorrowingPolicy) (int, bool) {
+		return int(p.MaxQuantity.Int32), p.MaxQuantity.Valid
+	}); p != nil && loan.Quantity > limit {
+		return &Violation{
+			Rule:     RuleMaxQuantity,
+			Message:  fmt.Sprintf("At most %d of this item can be borrowed at once", limit),
+			Limit:    limit,
+			SourceID: &p.ID,
+		}
+	}
+
+	if loan.Approved > 0 {
+		if loan.Quantity > loan.Approved || (loan.Quantity < loan.Approved && !l.AllowsPartialPickup()) {
+			message := "Borrow quantity must match approved request quantity"
+			if loan.Quantity > loan.Approved {
+				message = "Borrow quantity cannot exceed approved request quantity"
+			}
+			return &Violation{Rule: RuleApprovedQuantity, Message: message, Limit: loan.Approved}
+		}
+	}
+
+	if !loan.Start.IsZero() && !loan.Due.IsZero() {
+		if p, limit := l.strictest(func(p db.BorrowingPolicy) (int, bool) {
+			return int(p.LoanDays.Int32), p.LoanDays.Valid
+		}); p != nil && loan.Due.After(loan.Start.AddDate(0, 0, limit)) {
+			return &Violation{
+				Rule:     RuleLoanDuration,
+				Message:  fmt.Sprintf("This item can be borrowed for at most %d days", limit),
+				Limit:    limit,
+				SourceID: &p.ID,
+			}
+		}
+	}
+
+	for _, p := range l.Policies {
+		if !p.MaxActiveBorrowings.Valid {
+			continue
+		}
+		limit := int(p.MaxActiveBorrowings.Int32)
+		if active := l.activeFor(p); active+1 > limit {
+			return &Violation{
+				Rule:     RuleMaxActiveBorrowings,
+				Message:  fmt.Sprintf("You already have %d items out; at most %d are allowed at once", active, limit),
+				Limit:    limit,
+				SourceID: &p.ID,
+			}
+		}
+	}
+	return nil
+}
+
+// the policy setting the lowest value of a limit, and that value; nil when
+// no policy sets it.
+func (l Limits) strictest(limit func(db.BorrowingPolicy) (int, bool)) (*db.BorrowingPolicy, int) {
+	var strictest *db.BorrowingPolicy
+	lowest := 0
+	for i, p := range l.Policies {
+		if value, ok := limit(p); ok && (strictest == nil || value < lowest) {
+			strictest, lowest = &l.Policies[i], value
+		}
+	}
+	return strictest, lowest
+}
+
+// borrowings the user has out that count towards p: those of p's item
+// type, or all of them when p covers every type.
+func (l Limits) activeFor(p db.BorrowingPolicy) int {
+	if p.ItemType.Valid {
+		return l.Active[p.ItemType.ItemType]
+	}
+	total := 0
+	for _, n := range l.Active {
+		total += n
+	}
+	return total
+}
@@ -0,0 +1,122 @@
+package borrowingpolicy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/borrowingpolicy"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func limit(n int32) pgtype.Int4 {
+	return pgtype.Int4{Int32: n, Valid: true}
+}
+
+func TestLoanDays(t *testing.T) {
+	assert.Equal(t, borrowingpolicy.DefaultLoanDays, borrowingpolicy.Limits{}.LoanDays())
+
+	limits := borrowingpolicy.Limits{Policies: []db.BorrowingPolicy{
+		{ID: uuid.New(), LoanDays: limit(14)},
+		{ID: uuid.New(), MaxQuantity: limit(2)},
+		{ID: uuid.New(), LoanDays: limit(3)},
+	}}
+	assert.Equal(t, 3, limits.LoanDays(), "the shortest loan wins")
+}
+
+func TestAllowsPartialPickup(t *testing.T) {
+	allow := db.BorrowingPolicy{AllowPartialPickup: pgtype.Bool{Bool: true, Valid: true}}
+	forbid := db.BorrowingPolicy{AllowPartialPickup: pgtype.Bool{Bool: false, Valid: true}}
+
+	assert.False(t, borrowingpolicy.Limits{}.AllowsPartialPickup())
+	assert.True(t, borrowingpolicy.Limits{Policies: []db.BorrowingPolicy{allow, {}}}.AllowsPartialPickup())
+	assert.False(t, borrowingpolicy.Limits{Policies: []db.BorrowingPolicy{allow, forbid}}.AllowsPartialPickup())
+}
+
+func TestCheck(t *testing.T) {
+	start := time.Date(2026, 11, 2, 10, 0, 0, 0, time.UTC)
+
+	t.Run("no policies allow anything but an over-approved pickup", func(t *testing.T) {
+		var limits borrowingpolicy.Limits
+		assert.Nil(t, limits.Check(borrowingpolicy.Loan{Quantity: 50, Start: start, Due: start.AddDate(1, 0, 0)}))
+
+		v := limits.Check(borrowingpolicy.Loan{Quantity: 2, Approved: 1})
+		require.NotNil(t, v)
+		assert.Equal(t, borrowingpolicy.RuleApprovedQuantity, v.Rule)
+
+		v = limits.Check(borrowingpolicy.Loan{Quantity: 1, Approved: 2})
+		require.NotNil(t, v, "partial pickups need a policy allowing them")
+		assert.Equal(t, borrowingpolicy.RuleApprovedQuantity, v.Rule)
+	})
+
+	t.Run("the strictest quantity limit is reported", func(t *testing.T) {
+		strict := db.BorrowingPolicy{ID: uuid.New(), MaxQuantity: limit(2)}
+		limits := borrowingpolicy.Limits{Policies: []db.BorrowingPolicy{
+			{ID: uuid.New(), MaxQuantity: limit(5)},
+			strict,
+		}}
+		assert.Nil(t, limits.Check(borrowingpolicy.Loan{Quantity: 2}))
+
+		v := limits.Check(borrowingpolicy.Loan{Quantity: 3})
+		require.NotNil(t, v)
+		assert.Equal(t, borrowingpolicy.RuleMaxQuantity, v.Rule)
+		assert.Equal(t, 2, v.Limit)
+		assert.Equal(t, &strict.ID, v.SourceID)
+	})
+
+	t.Run("loans longer than allowed", func(t *testing.T) {
+		limits := borrowingpolicy.Limits{Policies: []db.BorrowingPolicy{{ID: uuid.New(), LoanDays: limit(7)}}}
+		assert.Nil(t, limits.Check(borrowingpolicy.Loan{Quantity: 1, Start: start, Due: start.AddDate(0, 0, 7)}))
+
+		v := limits.Check(borrowingpolicy.Loan{Quantity: 1, Start: start, Due: start.AddDate(0, 0, 8)})
+		require.NotNil(t, v)
+		assert.Equal(t, borrowingpolicy.RuleLoanDuration, v.Rule)
+		assert.Equal(t, 7, v.Limit)
+	})
+
+	t.Run("active borrowings count by the policy's item type", func(t *testing.T) {
+		limits := borrowingpolicy.Limits{
+			Policies: []db.BorrowingPolicy{
+				{ID: uuid.New(), ItemType: db.NullItemType{ItemType: db.ItemTypeHigh, Valid: true}, MaxActiveBorrowings: limit(1)},
+				{ID: uuid.New(), MaxActiveBorrowings: limit(3)},
+			},
+			Active: map[db.ItemType]int{db.ItemTypeMedium: 2},
+		}
+		assert.Nil(t, limits.Check(borrowingpolicy.Loan{Quantity: 1}))
+
+		limits.Active[db.ItemTypeHigh] = 1
+		v := limits.Check(borrowingpolicy.Loan{Quantity: 1})
+		require.NotNil(t, v)
+		assert.Equal(t, borrowingpolicy.RuleMaxActiveBorrowings, v.Rule)
+		assert.Equal(t, 1, v.Limit)
+
+		limits.Active[db.ItemTypeHigh] = 0
+		limits.Active[db.ItemTypeMedium] = 3
+		v = limits.Check(borrowingpolicy.Loan{Quantity: 1})
+		require.NotNil(t, v)
+		assert.Equal(t, 3, v.Limit)
+	})
+
+	t.Run("no pickup or return during a blackout", func(t *testing.T) {
+		blackout := db.BorrowingBlackout{
+			ID:       uuid.New(),
+			Reason:   "Exam period",
+			StartsAt: pgtype.Timestamp{Time: start.AddDate(0, 0, 5), Valid: true},
+			EndsAt:   pgtype.Timestamp{Time: start.AddDate(0, 0, 10), Valid: true},
+		}
+		limits := borrowingpolicy.Limits{Blackouts: []db.BorrowingBlackout{blackout}}
+
+		assert.Nil(t, limits.Check(borrowingpolicy.Loan{Quantity: 1, Start: start, Due: start.AddDate(0, 0, 3)}))
+		assert.Nil(t, limits.Check(borrowingpolicy.Loan{Quantity: 1, Start: start, Due: start.AddDate(0, 0, 12)}), "a loan may span a blackout")
+		assert.Nil(t, limits.Check(borrowingpolicy.Loan{Quantity: 1}), "approvals without dates are not affected")
+
+		v := limits.Check(borrowingpolicy.Loan{Quantity: 1, Start: start, Due: start.AddDate(0, 0, 6)})
+		require.NotNil(t, v)
+		assert.Equal(t, borrowingpolicy.RuleBlackout, v.Rule)
+		assert.Equal(t, &blackout.ID, v.SourceID)
+		assert.Contains(t, v.Message, "Exam period")
+	})
+}
@@ -0,0 +1,102 @@
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const prodID = "-//USSTM//CV Equipment Booking//EN"
+
+// a VEVENT to publish. Start and End may be in any zone; they are written
+// in UTC.
+type Event struct {
+	// stable across feeds and emails, so calendar apps update rather than
+	// duplicate the event
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	// "TENTATIVE", "CONFIRMED" or "CANCELLED"; "" leaves STATUS out
+	Status string
+}
+
+// writes events as an iCalendar (RFC 5545) object named name, the way
+// subscribed feeds and email attachments both publish them.
+func WriteICS(w io.Writer, name string, events []Event) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+
+	stamp := formatUTC(time.Now())
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", prodID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if name != "" {
+		line("X-WR-CALNAME", escapeText(name))
+	}
+	for _, e := range events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", stamp)
+		line("DTSTART", formatUTC(e.Start))
+		line("DTEND", formatUTC(e.End))
+		line("SUMMARY", escapeText(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escapeText(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION", escapeText(e.Location))
+		}
+		if e.Status != "" {
+			line("STATUS", e.Status)
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+
+	return bw.Flush()
+}
+
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapes a TEXT value (RFC 5545 3.3.11).
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writes a content line, folded at 75 octets without splitting a UTF-8
+// sequence, and terminated by CRLF.
+func writeFolded(w *bufio.Writer, line string) {
+	const limit = 75
+	width := limit
+	for len(line) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space of a continuation counts towards its length
+		width = limit - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
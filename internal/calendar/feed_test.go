@@ -0,0 +1,57 @@
+package calendar_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/calendar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteICS(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+
+	events := []calendar.Event{
+		{
+			UID:         "pickup-1@cv",
+			Summary:     "Pick up Camera, tripod; lens",
+			Description: "Bring your student card\nand the booking ID",
+			Location:    "Room 101",
+			Start:       time.Date(2026, 10, 20, 9, 0, 0, 0, toronto),
+			End:         time.Date(2026, 10, 20, 9, 15, 0, 0, toronto),
+			Status:      "CONFIRMED",
+		},
+		{
+			UID:     "return-1@cv",
+			Summary: "Return " + strings.Repeat("é", 60),
+			Start:   time.Date(2026, 10, 27, 13, 0, 0, 0, time.UTC),
+			End:     time.Date(2026, 10, 27, 13, 15, 0, 0, time.UTC),
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, calendar.WriteICS(&buf, "My bookings", events))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "DTSTART:20261020T130000Z\r\n", "local times are written in UTC")
+	assert.Contains(t, out, `SUMMARY:Pick up Camera\, tripod\; lens`)
+	assert.Contains(t, out, `DESCRIPTION:Bring your student card\nand the booking ID`)
+	assert.Contains(t, out, "STATUS:CONFIRMED\r\n")
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, "line %q is not folded", line)
+	}
+
+	// what we write, we can read back
+	blocks, err := calendar.ParseICS(strings.NewReader(out))
+	require.NoError(t, err)
+	require.Len(t, blocks, 2)
+	assert.True(t, blocks[0].Start.Equal(events[0].Start))
+	assert.True(t, blocks[1].End.Equal(events[1].End))
+}
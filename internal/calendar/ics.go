@@ -0,0 +1,145 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// a span of time during which the calendar owner is unavailable.
+type BusyBlock struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (b BusyBlock) Overlaps(start, end time.Time) bool {
+	return b.Start.Before(end) && start.Before(b.End)
+}
+
+// extracts busy blocks from the VEVENTs of an iCalendar feed. Transparent
+// (free) and cancelled events are skipped. Recurrence rules are not expanded,
+// so only the first occurrence of a recurring event is returned; most
+// calendar providers already publish expanded instances in their ICS exports.
+func ParseICS(r io.Reader) ([]BusyBlock, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		blocks  []BusyBlock
+		inEvent bool
+		start   time.Time
+		end     time.Time
+		allDay  bool
+		skip    bool
+	)
+
+	for _, line := range lines {
+		name, params, value := splitProperty(line)
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent = true
+			start, end, allDay, skip = time.Time{}, time.Time{}, false, false
+		case name == "END" && value == "VEVENT":
+			inEvent = false
+			if skip || start.IsZero() {
+				continue
+			}
+			if end.IsZero() {
+				// RFC 5545: a DATE start without an end lasts one day,
+				// a DATE-TIME start without an end is instantaneous
+				if allDay {
+					end = start.AddDate(0, 0, 1)
+				} else {
+					continue
+				}
+			}
+			if !end.After(start) {
+				continue
+			}
+			blocks = append(blocks, BusyBlock{Start: start, End: end})
+		case !inEvent:
+			continue
+		case name == "DTSTART":
+			t, dateOnly, err := parseTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("invalid DTSTART %q: %w", value, err)
+			}
+			start, allDay = t, dateOnly
+		case name == "DTEND":
+			t, _, err := parseTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("invalid DTEND %q: %w", value, err)
+			}
+			end = t
+		case name == "TRANSP" && value == "TRANSPARENT":
+			skip = true
+		case name == "STATUS" && value == "CANCELLED":
+			skip = true
+		}
+	}
+
+	return blocks, nil
+}
+
+// joins folded content lines (continuations start with a space or tab).
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// splits "NAME;PARAM=x;PARAM=y:value" into its parts. Parameter names are
+// upper-cased; quoted parameter values are unquoted.
+func splitProperty(line string) (string, map[string]string, string) {
+	head, value, _ := strings.Cut(line, ":")
+	parts := strings.Split(head, ";")
+
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, strings.TrimSpace(value)
+}
+
+// parses DATE and DATE-TIME values. UTC ("Z") times are honoured, TZID
+// parameters are resolved through the tz database, and floating times fall
+// back to the server's local zone. The bool result reports a DATE value.
+func parseTime(value string, params map[string]string) (time.Time, bool, error) {
+	loc := time.Local
+	if tzid, ok := params["TZID"]; ok {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
@@ -0,0 +1,74 @@
+package calendar_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/calendar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:MATH 1ZA3 Lecture\r\n" +
+	"DTSTART:20261019T140000Z\r\n" +
+	"DTEND:20261019T153000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Tutorial\r\n" +
+	"DTSTART;TZID=America/Toronto:20261020T090000\r\n" +
+	"DTEND;TZID=America/Toronto:20261020T\r\n" +
+	" 100000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Reading week\r\n" +
+	"DTSTART;VALUE=DATE:20261026\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Free lunch\r\n" +
+	"TRANSP:TRANSPARENT\r\n" +
+	"DTSTART:20261019T160000Z\r\n" +
+	"DTEND:20261019T170000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Cancelled lab\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"DTSTART:20261021T160000Z\r\n" +
+	"DTEND:20261021T170000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICS(t *testing.T) {
+	blocks, err := calendar.ParseICS(strings.NewReader(sampleICS))
+	require.NoError(t, err)
+	require.Len(t, blocks, 3)
+
+	assert.True(t, blocks[0].Start.Equal(time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)))
+	assert.True(t, blocks[0].End.Equal(time.Date(2026, 10, 19, 15, 30, 0, 0, time.UTC)))
+
+	toronto, err := time.LoadLocation("America/Toronto")
+	require.NoError(t, err)
+	assert.True(t, blocks[1].Start.Equal(time.Date(2026, 10, 20, 9, 0, 0, 0, toronto)))
+	assert.True(t, blocks[1].End.Equal(time.Date(2026, 10, 20, 10, 0, 0, 0, toronto)), "folded DTEND should be unfolded")
+
+	// all-day event without DTEND lasts one day
+	assert.Equal(t, 24*time.Hour, blocks[2].End.Sub(blocks[2].Start))
+}
+
+func TestParseICS_InvalidDate(t *testing.T) {
+	ics := "BEGIN:VEVENT\r\nDTSTART:not-a-date\r\nEND:VEVENT\r\n"
+	_, err := calendar.ParseICS(strings.NewReader(ics))
+	assert.Error(t, err)
+}
+
+func TestBusyBlock_Overlaps(t *testing.T) {
+	base := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	block := calendar.BusyBlock{Start: base, End: base.Add(time.Hour)}
+
+	assert.True(t, block.Overlaps(base.Add(30*time.Minute), base.Add(90*time.Minute)))
+	assert.False(t, block.Overlaps(base.Add(time.Hour), base.Add(2*time.Hour)), "touching slots do not overlap")
+	assert.False(t, block.Overlaps(base.Add(-time.Hour), base))
+}
@@ -0,0 +1,175 @@
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// upper bound on a downloaded feed; larger feeds fail the sync.
+const maxFeedSize = 5 << 20
+
+type Syncer struct {
+	db       *db.Queries
+	client   *http.Client
+	location *time.Location
+}
+
+// location is the zone availability slot times are expressed in.
+func NewSyncer(queries *db.Queries, timeout time.Duration, location *time.Location) *Syncer {
+	return &Syncer{
+		db:       queries,
+		client:   &http.Client{Timeout: timeout},
+		location: location,
+	}
+}
+
+// syncs every linked calendar. A failing feed is recorded on its link and
+// does not stop the remaining links from syncing.
+func (s *Syncer) SyncAll(ctx context.Context) (int, error) {
+	links, err := s.db.ListCalendarLinks(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list calendar links: %w", err)
+	}
+
+	removed := 0
+	for _, link := range links {
+		n, err := s.sync(ctx, link)
+		if err != nil {
+			logging.FromContext(ctx).Warn("calendar sync failed", "user_id", link.UserID, "error", err)
+			continue
+		}
+		removed += n
+	}
+	return removed, nil
+}
+
+// syncs a single user's linked calendar and returns how many availability
+// slots were removed.
+func (s *Syncer) SyncUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	link, err := s.db.GetCalendarLink(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get calendar link for %s: %w", userID, err)
+	}
+	return s.sync(ctx, link)
+}
+
+func (s *Syncer) sync(ctx context.Context, link db.CalendarLink) (int, error) {
+	removed, syncErr := s.prune(ctx, link)
+
+	var lastError pgtype.Text
+	if syncErr != nil {
+		lastError = pgtype.Text{String: syncErr.Error(), Valid: true}
+	}
+	if err := s.db.RecordCalendarSync(ctx, db.RecordCalendarSyncParams{
+		UserID:    link.UserID,
+		LastError: lastError,
+		Removed:   int32(removed),
+	}); err != nil {
+		return removed, fmt.Errorf("failed to record calendar sync: %w", err)
+	}
+
+	if syncErr == nil {
+		logging.FromContext(ctx).Info("calendar synced", "user_id", link.UserID, "removed_slots", removed)
+	}
+	return removed, syncErr
+}
+
+// deletes the user's upcoming availability that overlaps a busy block.
+// Slots already holding an active booking are kept.
+func (s *Syncer) prune(ctx context.Context, link db.CalendarLink) (int, error) {
+	blocks, err := s.fetch(ctx, link.IcsUrl)
+	if err != nil {
+		return 0, err
+	}
+	if len(blocks) == 0 {
+		return 0, nil
+	}
+
+	today := time.Now().In(s.location)
+	slots, err := s.db.GetUserAvailability(ctx, db.GetUserAvailabilityParams{
+		UserID:   &link.UserID,
+		FromDate: pgtype.Date{Time: today, Valid: true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to load availability: %w", err)
+	}
+
+	removed := 0
+	for _, slot := range slots {
+		start, end := s.slotBounds(slot)
+		if !overlapsAny(blocks, start, end) {
+			continue
+		}
+
+		inUse, err := s.db.CheckAvailabilityInUse(ctx, &slot.ID)
+		if err != nil {
+			return removed, fmt.Errorf("failed to check availability %s: %w", slot.ID, err)
+		}
+		if inUse {
+			logging.FromContext(ctx).Warn("calendar conflict with booked availability",
+				"user_id", link.UserID,
+				"availability_id", slot.ID)
+			continue
+		}
+
+		if err := s.db.DeleteAvailability(ctx, slot.ID); err != nil {
+			return removed, fmt.Errorf("failed to delete availability %s: %w", slot.ID, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (s *Syncer) fetch(ctx context.Context, url string) ([]BusyBlock, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, FeedURL(url), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar URL: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch calendar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("calendar feed returned %s", resp.Status)
+	}
+
+	return ParseICS(http.MaxBytesReader(nil, resp.Body, maxFeedSize))
+}
+
+// slot date and times are wall-clock values in the syncer's location.
+func (s *Syncer) slotBounds(slot db.GetUserAvailabilityRow) (time.Time, time.Time) {
+	y, m, d := slot.Date.Time.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, s.location)
+	start := midnight.Add(time.Duration(slot.StartTime.Microseconds) * time.Microsecond)
+	end := midnight.Add(time.Duration(slot.EndTime.Microseconds) * time.Microsecond)
+	return start, end
+}
+
+func overlapsAny(blocks []BusyBlock, start, end time.Time) bool {
+	for _, b := range blocks {
+		if b.Overlaps(start, end) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewrites webcal:// subscription links to https, which is what calendar
+// providers actually serve them over.
+func FeedURL(url string) string {
+	if rest, ok := strings.CutPrefix(url, "webcal://"); ok {
+		return "https://" + rest
+	}
+	return url
+}
@@ -0,0 +1,101 @@
+package calendar_test
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/calendar"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sharedDB *testutil.TestDatabase
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		// the ICS parser tests need no containers
+		os.Exit(m.Run())
+	}
+
+	t := &testing.T{}
+	sharedDB = testutil.NewTestDatabase(t, "cv-backend-test-db-calendar")
+	sharedDB.RunMigrations(t)
+
+	code := m.Run()
+
+	if sharedDB.Pool() != nil {
+		sharedDB.Pool().Close()
+	}
+
+	os.Exit(code)
+}
+
+func TestSyncer_SyncUser(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+	sharedDB.CleanupDatabase(t)
+
+	approver := sharedDB.NewUser(t).WithEmail("approver@sync.test").AsApprover().Create()
+
+	timeSlots, err := sharedDB.Queries().ListTimeSlots(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, timeSlots)
+
+	availability, err := sharedDB.Queries().CreateAvailability(ctx, db.CreateAvailabilityParams{
+		ID:         uuid.New(),
+		UserID:     &approver.ID,
+		TimeSlotID: &timeSlots[0].ID,
+		Date:       pgtype.Date{Time: time.Now().AddDate(0, 0, 7), Valid: true},
+	})
+	require.NoError(t, err)
+
+	slot, err := sharedDB.Queries().GetAvailabilityByID(ctx, availability.ID)
+	require.NoError(t, err)
+
+	// a class covering the whole availability slot
+	y, m, d := availability.Date.Time.Date()
+	classStart := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Add(time.Duration(slot.StartTime.Microseconds) * time.Microsecond)
+	feed := fmt.Sprintf("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART:%s\r\nDTEND:%s\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+		classStart.Format("20060102T150405Z"),
+		classStart.Add(time.Hour).Format("20060102T150405Z"))
+
+	feedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/calendar")
+		_, _ = w.Write([]byte(feed))
+	}))
+	defer feedServer.Close()
+
+	_, err = sharedDB.Queries().UpsertCalendarLink(ctx, db.UpsertCalendarLinkParams{
+		UserID: approver.ID,
+		IcsUrl: feedServer.URL,
+	})
+	require.NoError(t, err)
+
+	syncer := calendar.NewSyncer(sharedDB.Queries(), 5*time.Second, time.UTC)
+	removed, err := syncer.SyncUser(ctx, approver.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	_, err = sharedDB.Queries().GetAvailabilityByID(ctx, availability.ID)
+	assert.Error(t, err, "conflicting availability should be removed")
+
+	link, err := sharedDB.Queries().GetCalendarLink(ctx, approver.ID)
+	require.NoError(t, err)
+	assert.True(t, link.LastSyncedAt.Valid)
+	assert.False(t, link.LastError.Valid)
+	assert.Equal(t, int32(1), link.RemovedSlots)
+}
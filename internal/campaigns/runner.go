@@ -0,0 +1,255 @@
+package campaigns
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/google/uuid"
+)
+
+type notifier interface {
+	Notify(ctx context.Context, actorID uuid.UUID, entityType string, entityID uuid.UUID, groups []notifications.NotifierGroup) error
+}
+
+type reportGenerator interface {
+	Generate(ctx context.Context, reportID uuid.UUID) error
+}
+
+type Runner struct {
+	db       *db.Queries
+	notifier notifier
+	reports  reportGenerator
+}
+
+func NewRunner(queries *db.Queries, notifier notifier, reports reportGenerator) *Runner {
+	return &Runner{
+		db:       queries,
+		notifier: notifier,
+		reports:  reports,
+	}
+}
+
+// the reminder stage a borrowing is in, given whole days until it is due.
+// Borrowings more than two weeks out are not contacted yet.
+func StageFor(daysUntilDue int) (db.CampaignStage, bool) {
+	switch {
+	case daysUntilDue < 0:
+		return db.CampaignStageEscalated, true
+	case daysUntilDue <= 1:
+		return db.CampaignStageT1, true
+	case daysUntilDue <= 7:
+		return db.CampaignStageT7, true
+	case daysUntilDue <= 14:
+		return db.CampaignStageT14, true
+	default:
+		return "", false
+	}
+}
+
+// runs every active campaign and returns the number of notices sent. A
+// failing campaign is logged and does not stop the others.
+func (r *Runner) RunAll(ctx context.Context) (int, error) {
+	active, err := r.db.ListActiveReturnCampaigns(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list active campaigns: %w", err)
+	}
+
+	sent := 0
+	for _, campaign := range active {
+		n, err := r.run(ctx, campaign)
+		if err != nil {
+			logging.FromContext(ctx).Error("return campaign run failed", "campaign_id", campaign.ID, "error", err)
+			continue
+		}
+		sent += n
+	}
+	return sent, nil
+}
+
+// runs a single campaign; cancelled or completed campaigns are a no-op.
+func (r *Runner) Run(ctx context.Context, campaignID uuid.UUID) (int, error) {
+	campaign, err := r.db.GetReturnCampaignByID(ctx, campaignID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get campaign %s: %w", campaignID, err)
+	}
+	if campaign.Status != db.CampaignStatusActive {
+		return 0, nil
+	}
+	return r.run(ctx, campaign)
+}
+
+func (r *Runner) run(ctx context.Context, campaign db.ReturnCampaign) (int, error) {
+	logger := logging.FromContext(ctx)
+
+	borrowings, err := r.db.ListCampaignBorrowings(ctx, campaign.TermEnd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list borrowings: %w", err)
+	}
+
+	today := day(time.Now())
+	sent := 0
+	for _, b := range borrowings {
+		daysUntilDue := int(day(b.DueDate.Time).Sub(today).Hours() / 24)
+		stage, ok := StageFor(daysUntilDue)
+		if !ok {
+			continue
+		}
+
+		recorded, err := r.db.RecordCampaignNotice(ctx, db.RecordCampaignNoticeParams{
+			CampaignID:  campaign.ID,
+			BorrowingID: b.ID,
+			Stage:       stage,
+		})
+		if err != nil {
+			return sent, fmt.Errorf("failed to record notice for borrowing %s: %w", b.ID, err)
+		}
+		if recorded == 0 {
+			continue
+		}
+
+		if err := r.notify(ctx, campaign, b, stage, daysUntilDue); err != nil {
+			logger.Error("failed to send return campaign notice",
+				"campaign_id", campaign.ID,
+				"borrowing_id", b.ID,
+				"stage", stage,
+				"error", err)
+			continue
+		}
+		sent++
+	}
+
+	if err := r.db.RecordReturnCampaignRun(ctx, campaign.ID); err != nil {
+		return sent, fmt.Errorf("failed to record campaign run: %w", err)
+	}
+
+	// the day after term end every borrowing has been reminded or escalated
+	if today.After(day(campaign.TermEnd.Time)) {
+		if err := r.complete(ctx, campaign); err != nil {
+			return sent, err
+		}
+	}
+
+	logger.Info("return campaign run", "campaign_id", campaign.ID, "notices_sent", sent)
+	return sent, nil
+}
+
+func (r *Runner) notify(ctx context.Context, campaign db.ReturnCampaign, b db.ListCampaignBorrowingsRow, stage db.CampaignStage, daysUntilDue int) error {
+	dueDate := b.DueDate.Time.Format("2006-01-02")
+
+	if stage == db.CampaignStageEscalated {
+		// group admins by default; routing rules can add to or replace them,
+		// e.g. sending long-overdue items to operations instead
+		var ids []uuid.UUID
+		if b.GroupID != nil {
+			adminIDs, err := r.db.GetGroupAdminIDs(ctx, b.GroupID)
+			if err != nil {
+				return fmt.Errorf("failed to get group admins: %w", err)
+			}
+			for _, id := range adminIDs {
+				if id != nil {
+					ids = append(ids, *id)
+				}
+			}
+		}
+		var actorID uuid.UUID
+		switch {
+		case campaign.CreatedBy != nil:
+			actorID = *campaign.CreatedBy
+		case len(ids) > 0:
+			actorID = ids[0]
+		default:
+			return fmt.Errorf("borrowing has no group admins to escalate to")
+		}
+		return r.notifier.Notify(ctx, actorID, "return_campaign", campaign.ID, []notifications.NotifierGroup{
+			{
+				IDs:      ids,
+				Template: "return_overdue_group_admin",
+				TemplateData: map[string]interface{}{
+					"CampaignName":  campaign.Name,
+					"ItemName":      b.ItemName,
+					"GroupName":     b.GroupName.String,
+					"BorrowerEmail": b.UserEmail.String,
+					"DueDate":       dueDate,
+				},
+				Facts: notifications.RoutingFacts{
+					ItemID:      b.ItemID,
+					ItemType:    b.ItemType,
+					GroupID:     b.GroupID,
+					DaysOverdue: -daysUntilDue,
+				},
+			},
+		})
+	}
+
+	if b.UserID == nil {
+		return fmt.Errorf("borrowing has no borrower")
+	}
+	return r.notifier.Notify(ctx, actorFor(campaign, *b.UserID), "return_campaign", campaign.ID, []notifications.NotifierGroup{
+		{
+			IDs:      []uuid.UUID{*b.UserID},
+			Template: "return_reminder_requester",
+			TemplateData: map[string]interface{}{
+				"CampaignName": campaign.Name,
+				"ItemName":     b.ItemName,
+				"DueDate":      dueDate,
+				"DaysLeft":     daysUntilDue,
+			},
+			Facts: notifications.RoutingFacts{
+				ItemID:   b.ItemID,
+				ItemType: b.ItemType,
+				GroupID:  b.GroupID,
+			},
+		},
+	})
+}
+
+// generates the final outstanding-items report and closes the campaign.
+func (r *Runner) complete(ctx context.Context, campaign db.ReturnCampaign) error {
+	logger := logging.FromContext(ctx)
+
+	var reportID *uuid.UUID
+	if campaign.CreatedBy != nil {
+		report, err := r.db.CreateReport(ctx, db.CreateReportParams{
+			ReportType:  db.ReportTypeOutstanding,
+			RequestedBy: *campaign.CreatedBy,
+			ToDate:      campaign.TermEnd,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create outstanding report: %w", err)
+		}
+		if err := r.reports.Generate(ctx, report.ID); err != nil {
+			// the report records its own failure; the campaign still closes
+			logger.Error("failed to generate outstanding report", "campaign_id", campaign.ID, "report_id", report.ID, "error", err)
+		}
+		reportID = &report.ID
+	}
+
+	if _, err := r.db.CompleteReturnCampaign(ctx, db.CompleteReturnCampaignParams{
+		ID:       campaign.ID,
+		ReportID: reportID,
+	}); err != nil {
+		return fmt.Errorf("failed to complete campaign: %w", err)
+	}
+
+	logger.Info("return campaign completed", "campaign_id", campaign.ID, "report_id", reportID)
+	return nil
+}
+
+// notifications are attributed to the admin who started the campaign, or to
+// the recipient if that admin no longer exists.
+func actorFor(campaign db.ReturnCampaign, fallback uuid.UUID) uuid.UUID {
+	if campaign.CreatedBy != nil {
+		return *campaign.CreatedBy
+	}
+	return fallback
+}
+
+// midnight UTC of t's calendar date, so day differences ignore time of day.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
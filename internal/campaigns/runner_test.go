@@ -0,0 +1,133 @@
+package campaigns_test
+
+import (
+	"context"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/campaigns"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sharedDB *testutil.TestDatabase
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		// unit tests still run; integration tests skip themselves
+		os.Exit(m.Run())
+	}
+
+	t := &testing.T{}
+	sharedDB = testutil.NewTestDatabase(t, "cv-backend-test-db-campaigns")
+	sharedDB.RunMigrations(t)
+
+	code := m.Run()
+
+	if sharedDB.Pool() != nil {
+		sharedDB.Pool().Close()
+	}
+
+	os.Exit(code)
+}
+
+type fakeNotifier struct {
+	groups []notifications.NotifierGroup
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, actorID uuid.UUID, entityType string, entityID uuid.UUID, groups []notifications.NotifierGroup) error {
+	f.groups = append(f.groups, groups...)
+	return nil
+}
+
+type fakeReports struct {
+	generated []uuid.UUID
+}
+
+func (f *fakeReports) Generate(ctx context.Context, reportID uuid.UUID) error {
+	f.generated = append(f.generated, reportID)
+	return nil
+}
+
+func TestStageFor(t *testing.T) {
+	tests := []struct {
+		days  int
+		stage db.CampaignStage
+		ok    bool
+	}{
+		{days: 30, ok: false},
+		{days: 15, ok: false},
+		{days: 14, stage: db.CampaignStageT14, ok: true},
+		{days: 8, stage: db.CampaignStageT14, ok: true},
+		{days: 7, stage: db.CampaignStageT7, ok: true},
+		{days: 2, stage: db.CampaignStageT7, ok: true},
+		{days: 1, stage: db.CampaignStageT1, ok: true},
+		{days: 0, stage: db.CampaignStageT1, ok: true},
+		{days: -1, stage: db.CampaignStageEscalated, ok: true},
+	}
+
+	for _, tt := range tests {
+		stage, ok := campaigns.StageFor(tt.days)
+		assert.Equal(t, tt.ok, ok, "days=%d", tt.days)
+		assert.Equal(t, tt.stage, stage, "days=%d", tt.days)
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	ctx := context.Background()
+
+	admin := sharedDB.NewUser(t).WithEmail("admin@runner.test").AsGlobalAdmin().Create()
+	borrower := sharedDB.NewUser(t).WithEmail("borrower@runner.test").AsMember().Create()
+	group := sharedDB.NewGroup(t).WithName("Runner Group").Create()
+	item := sharedDB.NewItem(t).WithName("Runner Item").WithType("medium").WithStock(5).Create()
+
+	_, err := sharedDB.Queries().BorrowItem(ctx, db.BorrowItemParams{
+		UserID:             &borrower.ID,
+		GroupID:            &group.ID,
+		ID:                 item.ID,
+		Quantity:           1,
+		DueDate:            pgtype.Timestamp{Time: time.Now().Add(7 * 24 * time.Hour), Valid: true},
+		BeforeCondition:    "good",
+		BeforeConditionUrl: "",
+	})
+	require.NoError(t, err)
+
+	campaign, err := sharedDB.Queries().CreateReturnCampaign(ctx, db.CreateReturnCampaignParams{
+		Name:      "Spring term",
+		TermEnd:   pgtype.Date{Time: time.Now().AddDate(0, 0, 10), Valid: true},
+		CreatedBy: &admin.ID,
+	})
+	require.NoError(t, err)
+
+	notifier := &fakeNotifier{}
+	runner := campaigns.NewRunner(sharedDB.Queries(), notifier, &fakeReports{})
+
+	sent, err := runner.Run(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "borrowing due in a week gets the t7 reminder")
+	require.NotEmpty(t, notifier.groups)
+	assert.Equal(t, []uuid.UUID{borrower.ID}, notifier.groups[0].IDs)
+
+	// reruns on the same stage do not remind again
+	sent, err = runner.Run(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	updated, err := sharedDB.Queries().GetReturnCampaignByID(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.CampaignStatusActive, updated.Status)
+	assert.True(t, updated.LastRunAt.Valid)
+}
@@ -0,0 +1,114 @@
+package catalog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis key holding the current generation of cached listings.
+const generationKey = "cv:catalog:generation"
+
+// keeps item listings in Redis. Entries are stored under the generation they
+// were read in, and Invalidate moves to a new generation, so one write drops
+// every cached listing at once without hunting down their keys; the old
+// entries expire on their own.
+type Cache struct {
+	client *redis.Client
+	ttl    time.Duration
+	// off sends every Load straight to the database; see SetEnabled
+	disabled atomic.Bool
+}
+
+// ttl bounds how stale a listing can get from changes that do not
+// invalidate, such as another process editing items or a viewer's group
+// memberships changing.
+func NewCache(client *redis.Client, ttl time.Duration) *Cache {
+	return &Cache{client: client, ttl: ttl}
+}
+
+// turns the cache on or off, for a configuration reload. Invalidate keeps
+// working while it is off, so turning it back on can't serve listings from
+// before the writes made in between.
+func (c *Cache) SetEnabled(enabled bool) {
+	c.disabled.Store(!enabled)
+}
+
+// a cache key for a listing: its name and a hash of whatever picks the
+// results, such as the query parameters and the viewer.
+func Key(name string, params any) (string, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cache key: %w", err)
+	}
+	sum := sha256.Sum256(encoded)
+	return name + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// fills dest with the listing cached under key, or calls load to fill it
+// and caches the result. The cache failing never fails the listing: Redis
+// errors are logged and the listing is loaded from the database instead.
+func (c *Cache) Load(ctx context.Context, key string, dest any, load func() error) error {
+	logger := logging.FromContext(ctx)
+
+	if c.disabled.Load() {
+		return load()
+	}
+	generation, err := c.client.Get(ctx, generationKey).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		metrics.CatalogCache.Inc("error")
+		logger.Warn("Catalogue cache unavailable", "error", err)
+		return load()
+	}
+	entryKey := fmt.Sprintf("cv:catalog:%d:%s", generation, key)
+
+	cached, err := c.client.Get(ctx, entryKey).Bytes()
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(cached, dest); err == nil {
+			metrics.CatalogCache.Inc("hit")
+			return nil
+		}
+		metrics.CatalogCache.Inc("error")
+		logger.Warn("Dropping malformed catalogue cache entry", "key", entryKey)
+	case errors.Is(err, redis.Nil):
+		metrics.CatalogCache.Inc("miss")
+	default:
+		metrics.CatalogCache.Inc("error")
+		logger.Warn("Catalogue cache unavailable", "error", err)
+	}
+
+	if err := load(); err != nil {
+		return err
+	}
+
+	// stored under the generation read before loading, so a listing loaded
+	// while a write invalidated the cache lands in the dropped generation
+	// rather than outliving the write
+	encoded, err := json.Marshal(dest)
+	if err != nil {
+		logger.Warn("Failed to encode catalogue cache entry", "key", entryKey, "error", err)
+		return nil
+	}
+	if err := c.client.Set(ctx, entryKey, encoded, c.ttl).Err(); err != nil {
+		logger.Warn("Failed to store catalogue cache entry", "key", entryKey, "error", err)
+	}
+	return nil
+}
+
+// drops every cached listing.
+func (c *Cache) Invalidate(ctx context.Context) error {
+	if err := c.client.Incr(ctx, generationKey).Err(); err != nil {
+		return fmt.Errorf("failed to invalidate catalogue cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/catalog"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	type params struct {
+		Query  string
+		Offset int
+	}
+	first, err := catalog.Key("items:search", params{Query: "camera"})
+	require.NoError(t, err)
+	again, err := catalog.Key("items:search", params{Query: "camera"})
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	nextPage, err := catalog.Key("items:search", params{Query: "camera", Offset: 20})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, nextPage)
+
+	otherListing, err := catalog.Key("items:type", params{Query: "camera"})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, otherListing)
+}
+
+func TestCache_Load(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	queue := testutil.NewTestQueue(t, "cv-backend-test-redis-catalog")
+	t.Cleanup(queue.Close)
+	queue.Cleanup(t)
+
+	ctx := context.Background()
+	cache := catalog.NewCache(queue.Redis, time.Minute)
+
+	loads := 0
+	load := func(dest *[]string, names ...string) func() error {
+		return func() error {
+			loads++
+			*dest = names
+			return nil
+		}
+	}
+
+	var listed []string
+	require.NoError(t, cache.Load(ctx, "items", &listed, load(&listed, "Tripod")))
+	assert.Equal(t, []string{"Tripod"}, listed)
+	assert.Equal(t, 1, loads)
+
+	var cached []string
+	require.NoError(t, cache.Load(ctx, "items", &cached, load(&cached, "Light Stand")))
+	assert.Equal(t, []string{"Tripod"}, cached, "the second load is a hit")
+	assert.Equal(t, 1, loads)
+
+	require.NoError(t, cache.Invalidate(ctx))
+
+	var reloaded []string
+	require.NoError(t, cache.Load(ctx, "items", &reloaded, load(&reloaded, "Tripod", "Light Stand")))
+	assert.Equal(t, []string{"Tripod", "Light Stand"}, reloaded)
+	assert.Equal(t, 2, loads)
+	cache.SetEnabled(false)
+	var bypassed []string
+	require.NoError(t, cache.Load(ctx, "items", &bypassed, load(&bypassed, "Tripod")))
+	assert.Equal(t, []string{"Tripod"}, bypassed, "a disabled cache always loads")
+	assert.Equal(t, 3, loads)
+
+	cache.SetEnabled(true)
+	var resumed []string
+	require.NoError(t, cache.Load(ctx, "items", &resumed, load(&resumed, "Tripod")))
+	assert.Equal(t, []string{"Tripod", "Light Stand"}, resumed, "entries cached before it was turned off are served again")
+	assert.Equal(t, 3, loads)
+}
@@ -0,0 +1,42 @@
+package catalog
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const MaxTagLength = 64
+
+var ErrTagTooLong = errors.New("tags must be at most 64 characters")
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// category slugs are lowercase words joined by hyphens, e.g. "av" or "lab-equipment".
+func ValidSlug(slug string) bool {
+	return len(slug) <= 64 && slugPattern.MatchString(slug)
+}
+
+// trims and lowercases tags, dropping blanks and duplicates but keeping the
+// order they were given in.
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > MaxTagLength {
+			return nil, ErrTagTooLong
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+// parses a comma-separated filter such as "camera,tripod".
+func ParseTags(list string) ([]string, error) {
+	return NormalizeTags(strings.Split(list, ","))
+}
@@ -0,0 +1,45 @@
+package catalog_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/internal/catalog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidSlug(t *testing.T) {
+	assert.True(t, catalog.ValidSlug("av"))
+	assert.True(t, catalog.ValidSlug("lab-equipment"))
+	assert.True(t, catalog.ValidSlug("3d-printers"))
+	assert.False(t, catalog.ValidSlug(""))
+	assert.False(t, catalog.ValidSlug("AV"))
+	assert.False(t, catalog.ValidSlug("lab equipment"))
+	assert.False(t, catalog.ValidSlug("-av"))
+	assert.False(t, catalog.ValidSlug("av--kit"))
+	assert.False(t, catalog.ValidSlug(strings.Repeat("a", 65)))
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := catalog.NormalizeTags([]string{" Camera", "tripod", "", "camera", "TRIPOD "})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"camera", "tripod"}, tags)
+
+	tags, err = catalog.NormalizeTags(nil)
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	_, err = catalog.NormalizeTags([]string{strings.Repeat("x", catalog.MaxTagLength+1)})
+	assert.ErrorIs(t, err, catalog.ErrTagTooLong)
+}
+
+func TestParseTags(t *testing.T) {
+	tags, err := catalog.ParseTags("camera, tripod,,Camera")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"camera", "tripod"}, tags)
+
+	tags, err = catalog.ParseTags("")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+}
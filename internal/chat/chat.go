@@ -0,0 +1,73 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/queue"
+)
+
+// the ChatSender for cfg.Provider. Without a webhook URL messages are only
+// logged.
+func New(cfg config.ChatConfig) (queue.ChatSender, error) {
+	if cfg.WebhookURL == "" {
+		return logSender{}, nil
+	}
+	switch cfg.Provider {
+	case "slack":
+		return NewSlack(cfg.WebhookURL), nil
+	case "discord":
+		return NewDiscord(cfg.WebhookURL), nil
+	default:
+		return nil, fmt.Errorf("unknown chat provider %q", cfg.Provider)
+	}
+}
+
+// for development: messages are logged instead of posted.
+type logSender struct{}
+
+func (logSender) SendChat(ctx context.Context, text string) error {
+	logging.FromContext(ctx).Info("Chat message not sent, no webhook configured", "text", text)
+	return nil
+}
+
+// posts body as JSON to an incoming webhook. A webhook that was deleted or
+// revoked, or that rejects the message, answers the same way every time.
+func post(ctx context.Context, client *http.Client, url string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode chat message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build chat request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post chat message: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("failed to post chat message: status %d: %s", resp.StatusCode, raw)
+	default:
+		return fmt.Errorf("failed to post chat message: status %d: %s: %w", resp.StatusCode, raw, queue.ErrUndeliverable)
+	}
+}
+
+func newClient() *http.Client {
+	return &http.Client{Timeout: 10 * time.Second}
+}
@@ -0,0 +1,78 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlack_SendChat(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	require.NoError(t, NewSlack(server.URL).SendChat(context.Background(), "Overdue: *Tripod*"))
+	assert.Equal(t, map[string]any{"text": "Overdue: *Tripod*"}, got)
+}
+
+func TestDiscord_SendChat(t *testing.T) {
+	var got discordMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	discord := NewDiscord(server.URL)
+	require.NoError(t, discord.SendChat(context.Background(), "Overdue: **Tripod**"))
+	assert.Equal(t, "Overdue: **Tripod**", got.Content)
+	assert.Empty(t, got.AllowedMentions.Parse, "mentions never ping")
+
+	require.NoError(t, discord.SendChat(context.Background(), strings.Repeat("a", 2500)))
+	assert.Len(t, []rune(got.Content), discordMaxContent)
+}
+
+func TestSendChat_Errors(t *testing.T) {
+	status := http.StatusNotFound
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("no_service"))
+	}))
+	defer server.Close()
+
+	slack := NewSlack(server.URL)
+	err := slack.SendChat(context.Background(), "hello")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, queue.ErrUndeliverable, "a revoked webhook stays revoked")
+
+	for _, status = range []int{http.StatusTooManyRequests, http.StatusBadGateway} {
+		err = slack.SendChat(context.Background(), "hello")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, queue.ErrUndeliverable, "rate limits and outages are retried")
+	}
+}
+
+func TestNew(t *testing.T) {
+	sender, err := New(config.ChatConfig{Provider: "slack"})
+	require.NoError(t, err)
+	assert.IsType(t, logSender{}, sender)
+
+	sender, err = New(config.ChatConfig{Provider: "discord", WebhookURL: "https://discord.com/api/webhooks/1/abc"})
+	require.NoError(t, err)
+	assert.IsType(t, &Discord{}, sender)
+
+	_, err = New(config.ChatConfig{Provider: "teams", WebhookURL: "https://example.com"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package chat
+
+import (
+	"context"
+	"net/http"
+)
+
+// Discord rejects longer message content
+const discordMaxContent = 2000
+
+// posts to a Discord channel through a channel webhook.
+type Discord struct {
+	httpClient *http.Client
+	webhookURL string
+}
+
+func NewDiscord(webhookURL string) *Discord {
+	return &Discord{httpClient: newClient(), webhookURL: webhookURL}
+}
+
+type discordMessage struct {
+	Content         string                 `json:"content"`
+	AllowedMentions discordAllowedMentions `json:"allowed_mentions"`
+}
+
+type discordAllowedMentions struct {
+	Parse []string `json:"parse"`
+}
+
+// text is Discord markdown. Mentions in it are shown but don't ping anyone,
+// so an item named "@everyone" can't page the server.
+func (d *Discord) SendChat(ctx context.Context, text string) error {
+	if runes := []rune(text); len(runes) > discordMaxContent {
+		text = string(runes[:discordMaxContent-1]) + "…"
+	}
+	return post(ctx, d.httpClient, d.webhookURL, discordMessage{
+		Content:         text,
+		AllowedMentions: discordAllowedMentions{Parse: []string{}},
+	})
+}
@@ -0,0 +1,21 @@
+package chat
+
+import (
+	"context"
+	"net/http"
+)
+
+// posts to a Slack channel through an incoming webhook.
+type Slack struct {
+	httpClient *http.Client
+	webhookURL string
+}
+
+func NewSlack(webhookURL string) *Slack {
+	return &Slack{httpClient: newClient(), webhookURL: webhookURL}
+}
+
+// text is Slack mrkdwn.
+func (s *Slack) SendChat(ctx context.Context, text string) error {
+	return post(ctx, s.httpClient, s.webhookURL, map[string]string{"text": text})
+}
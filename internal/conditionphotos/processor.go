@@ -0,0 +1,95 @@
+package conditionphotos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	cvimage "github.com/USSTM/cv-backend/internal/image"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// subset of storage.Storage needed to read photos and store their variants.
+type objectStore interface {
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+	PutObject(ctx context.Context, key string, body io.Reader, contentType string) error
+}
+
+// turns the before/after photos taken at pickup and return into something
+// the UI can load: a web-sized copy and a thumbnail, with location data gone.
+type Processor struct {
+	db      *db.Queries
+	objects objectStore
+}
+
+func NewProcessor(queries *db.Queries, objects objectStore) *Processor {
+	return &Processor{db: queries, objects: objects}
+}
+
+// S3 keys of a photo's web-sized and thumbnail variants, next to the original.
+func VariantKeys(originalKey string) (web, thumbnail string) {
+	ext := path.Ext(originalKey)
+	base := strings.TrimSuffix(originalKey, ext)
+	return base + "-web" + ext, base + "-thumb" + ext
+}
+
+// overwrites a borrowing image with a copy stripped of its EXIF data and
+// stores its variants. Images already processed, or deleted since they were
+// uploaded, are skipped, as are ones that can't be decoded: the upload
+// checked them, so retrying won't help.
+func (p *Processor) Process(ctx context.Context, imageID uuid.UUID) error {
+	img, err := p.db.GetBorrowingImageByID(ctx, imageID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get borrowing image %s: %w", imageID, err)
+	}
+	if img.ProcessedAt.Valid {
+		return nil
+	}
+
+	object, err := p.objects.GetObject(ctx, img.S3Key)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", img.S3Key, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(object, cvimage.MaxFileSize+1))
+	object.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", img.S3Key, err)
+	}
+
+	variants, err := cvimage.MakeVariants(data)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Skipping condition photo that can't be processed", "image_id", img.ID, "key", img.S3Key, "error", err)
+		return nil
+	}
+
+	webKey, thumbnailKey := VariantKeys(img.S3Key)
+	for key, body := range map[string][]byte{
+		img.S3Key:    variants.Clean,
+		webKey:       variants.Web,
+		thumbnailKey: variants.Thumbnail,
+	} {
+		if err := p.objects.PutObject(ctx, key, bytes.NewReader(body), variants.ContentType); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", key, err)
+		}
+	}
+
+	if err := p.db.SetBorrowingImageVariants(ctx, db.SetBorrowingImageVariantsParams{
+		ID:             img.ID,
+		WebS3Key:       pgtype.Text{String: webKey, Valid: true},
+		ThumbnailS3Key: pgtype.Text{String: thumbnailKey, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("failed to record variants of %s: %w", img.ID, err)
+	}
+	return nil
+}
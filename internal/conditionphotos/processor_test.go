@@ -0,0 +1,116 @@
+package conditionphotos_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/conditionphotos"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sharedDB *testutil.TestDatabase
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		os.Exit(0)
+	}
+
+	t := &testing.T{}
+	sharedDB = testutil.NewTestDatabase(t, "cv-backend-test-db-conditionphotos")
+	sharedDB.RunMigrations(t)
+
+	code := m.Run()
+
+	if sharedDB.Pool() != nil {
+		sharedDB.Pool().Close()
+	}
+
+	os.Exit(code)
+}
+
+// keeps objects in memory instead of S3.
+type fakeStore struct {
+	objects map[string][]byte
+}
+
+func (f *fakeStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := f.objects[key]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (f *fakeStore) PutObject(ctx context.Context, key string, body io.Reader, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	f.objects[key] = data
+	return nil
+}
+
+func TestProcessor_Process(t *testing.T) {
+	sharedDB.CleanupDatabase(t)
+	ctx := context.Background()
+
+	borrower := sharedDB.NewUser(t).WithEmail("borrower@photos.test").AsMember().Create()
+	item := sharedDB.NewItem(t).WithName("Photo Item").WithType("medium").WithStock(5).Create()
+	borrowing, err := sharedDB.Queries().BorrowItem(ctx, db.BorrowItemParams{
+		UserID:          &borrower.ID,
+		ID:              item.ID,
+		Quantity:        1,
+		DueDate:         pgtype.Timestamp{Time: time.Now().Add(7 * 24 * time.Hour), Valid: true},
+		BeforeCondition: "good",
+	})
+	require.NoError(t, err)
+
+	var photo bytes.Buffer
+	require.NoError(t, jpeg.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 2400, 1800)), nil))
+	key := "borrowings/" + borrowing.ID.String() + "/photo-before.jpg"
+	store := &fakeStore{objects: map[string][]byte{key: photo.Bytes()}}
+
+	img, err := sharedDB.Queries().CreateBorrowingImage(ctx, db.CreateBorrowingImageParams{
+		ID:          uuid.New(),
+		BorrowingID: borrowing.ID,
+		S3Key:       key,
+		ImageType:   "before",
+		UploadedBy:  &borrower.ID,
+	})
+	require.NoError(t, err)
+
+	processor := conditionphotos.NewProcessor(sharedDB.Queries(), store)
+	require.NoError(t, processor.Process(ctx, img.ID))
+
+	updated, err := sharedDB.Queries().GetBorrowingImageByID(ctx, img.ID)
+	require.NoError(t, err)
+	assert.True(t, updated.ProcessedAt.Valid)
+	webKey, thumbnailKey := conditionphotos.VariantKeys(key)
+	assert.Equal(t, "borrowings/"+borrowing.ID.String()+"/photo-before-web.jpg", webKey)
+	assert.Equal(t, webKey, updated.WebS3Key.String)
+	assert.Equal(t, thumbnailKey, updated.ThumbnailS3Key.String)
+
+	web, _, err := image.DecodeConfig(bytes.NewReader(store.objects[webKey]))
+	require.NoError(t, err)
+	assert.Equal(t, 1600, web.Width)
+	assert.Contains(t, store.objects, thumbnailKey)
+
+	// processed images and deleted ones are left alone
+	delete(store.objects, webKey)
+	require.NoError(t, processor.Process(ctx, img.ID))
+	assert.NotContains(t, store.objects, webKey)
+	require.NoError(t, processor.Process(ctx, uuid.New()))
+}
@@ -5,50 +5,309 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type Config struct {
+	// deployment the process runs in, from APP_ENV: "development",
+	// "staging" or "production"
+	Env      string
 	Database DatabaseConfig
 	Redis    RedisConfig
 	Server   ServerConfig
 	JWT      JWTConfig
 	Auth     AuthConfig
+	OIDC     OIDCConfig
+	Identity IdentityConfig
 	Logging  LoggingConfig
 	CORS     CORSConfig
 	AWS      AWSConfig
+	Email    EmailConfig
+	Calendar CalendarConfig
+	Campaign CampaignConfig
+	Booking  BookingConfig
+	SLA      SLAConfig
+	Overdue  OverdueConfig
+	Worker   WorkerConfig
+	// weekly inventory summary for catalogue managers
+	Digest       DigestConfig
+	Availability AvailabilityConfig
+	Fines        FinesConfig
+	SMS          SMSConfig
+	Push         PushConfig
+	Chat         ChatConfig
+	Metrics      MetricsConfig
+	Labels       LabelConfig
+	Catalog      CatalogConfig
+	Storage      StorageConfig
+	Secrets      SecretsConfig
+
+	// env file of KEY=value lines re-read on SIGHUP, for the settings that
+	// can change without a restart (see Reload); empty turns reloading off
+	ReloadFile string
+
+	// variables that were set but couldn't be parsed, and so fell back to
+	// their defaults; Validate reports them
+	unparsed []string
 }
 
 type AWSConfig struct {
 	Region          string
-	AccessKeyID     string
-	SecretAccessKey string
+	AccessKeyID     string `secret:"true"`
+	SecretAccessKey string `secret:"true"`
 	EndpointURL     string
-	Sender          string
 	Bucket          string
+	// multipart uploads to the bucket: MiB per part, parts sent at once and
+	// retries of a failed part
+	PartSizeMB      int
+	PartConcurrency int
+	PartRetries     int
+}
+
+// how the worker sends email
+type EmailConfig struct {
+	// "ses", "smtp" or "log", which only logs the message
+	Provider string
+	// address emails are sent from, optionally as "Name <address>"
+	From string
+	// SMTP server; a username turns on login. ImplicitTLS is for servers
+	// that expect TLS from the start (usually port 465), otherwise STARTTLS
+	// is used when the server offers it
+	SMTPHost        string
+	SMTPPort        string
+	SMTPUsername    string
+	SMTPPassword    string `secret:"true"`
+	SMTPImplicitTLS bool
+	// SES bounce and complaint notifications reach /webhooks/ses through an
+	// SNS subscription carrying ?token=NotificationToken; without a token the
+	// endpoint isn't served. A topic ARN turns away any other topic
+	NotificationToken    string `secret:"true"`
+	NotificationTopicARN string
 }
 
+// MigrateOnStart has the API apply pending migrations before it serves.
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host           string
+	Port           string
+	User           string
+	Password       string `secret:"true"`
+	DBName         string
+	SSLMode        string
+	MigrateOnStart bool
 }
 
 type RedisConfig struct {
 	Addr     string
-	Password string
+	Password string `secret:"true"`
 	DB       int
 }
 
+type CalendarConfig struct {
+	SyncInterval time.Duration
+	FetchTimeout time.Duration
+	Timezone     string
+}
+
+type CampaignConfig struct {
+	Schedule string
+}
+
+// defaults for groups without their own booking policy
+type BookingConfig struct {
+	// how long after a booking is created the requester has to confirm it
+	ConfirmationWindow time.Duration
+	// confirmation closes this long before pickup
+	PickupCutoff time.Duration
+	// cron spec for expiring bookings nobody confirmed in time
+	ExpirySchedule string
+}
+
+type SLAConfig struct {
+	// how often the worker alerts on requests past their group's review SLA
+	CheckInterval time.Duration
+	// how long a request for a group-owned item waits on the group's
+	// approvers before going to the global approvers, for requesting groups
+	// without an SLA (0 never escalates them)
+	EscalateAfter time.Duration
+}
+
+type OverdueConfig struct {
+	// cron spec for marking overdue borrowings and sending reminders
+	CheckSchedule string
+	// borrowers are reminded this many days before the due date (0 disables)
+	ReminderDaysBefore int
+	// once overdue, reminders repeat every this many days (0 disables)
+	ReminderRepeatDays int
+}
+
+// how the worker retries failed tasks. A task whose retries run out is
+// archived by asynq, which is what the worker's dead-letter queue is.
+type WorkerConfig struct {
+	// retries per task type, e.g. "email:delivery"; other types get MaxRetry
+	MaxRetries map[string]int
+	MaxRetry   int
+	// the nth retry waits RetryBaseDelay * 2^n, at most RetryMaxDelay
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+type DigestConfig struct {
+	// cron spec for sending the digest
+	Schedule string
+	// items with this much stock or less are listed as running low
+	LowStockThreshold int
+	// cron spec for sending the approver digest, daily or weekly
+	ApproverSchedule string
+}
+
+type AvailabilityConfig struct {
+	// cron spec for deleting past availability slots nobody booked
+	CleanupSchedule string
+	// how many days past slots are kept before cleanup removes them
+	RetentionDays int
+}
+
+type FinesConfig struct {
+	// borrowers owing more than this many cents in unpaid fines can't borrow
+	// or request items (0 disables)
+	BlockThresholdCents int
+}
+
+type SMSConfig struct {
+	// "twilio", "sns" or "log", which only logs the message
+	Provider string
+	// E.164 number Twilio sends from
+	From             string
+	TwilioAccountSID string
+	TwilioAuthToken  string `secret:"true"`
+}
+
+type PushConfig struct {
+	// "fcm", "webpush" or "log", which only logs the notification
+	Provider string
+	// FCM HTTP v1 project and the service account key file used to call it
+	FCMProjectID       string
+	FCMCredentialsFile string
+	// VAPID key pair, base64url encoded, and the contact push services are
+	// given for this server ("mailto:..." or an https URL)
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string `secret:"true"`
+	VAPIDSubject    string
+}
+
+// a Slack or Discord channel the team follows. Off while WebhookURL is empty.
+type ChatConfig struct {
+	// "slack" or "discord", which decides how messages are formatted and sent
+	Provider string
+	// the channel's incoming webhook, which is all it takes to post there
+	WebhookURL string `secret:"true"`
+	// the messages posted: request_submitted, request_approved and
+	// item_overdue
+	Events []string
+}
+
+// the Prometheus endpoint, /metrics
+type MetricsConfig struct {
+	// bearer token a scrape must send; empty leaves /metrics open, for when
+	// only the monitoring network can reach it
+	Token string `secret:"true"`
+	// port the standalone worker serves /metrics on; empty serves nothing.
+	// The API server serves it on its own port.
+	WorkerPort string
+}
+
+// the Redis cache in front of item listings
+type CatalogConfig struct {
+	// false sends every listing straight to the database
+	CacheEnabled bool
+	// how long a cached listing lives. Item writes through the API drop the
+	// cache at once, so this only bounds staleness from other changes, such
+	// as a viewer joining a group. Keep it well under the hour the image URLs
+	// in a listing stay valid for.
+	CacheTTL time.Duration
+}
+
+// where uploads are kept, and housekeeping of the S3 bucket
+type StorageConfig struct {
+	// "s3", the bucket in AWSConfig, or "filesystem", which keeps objects
+	// under FilesystemRoot and has the API serve presigned URLs at
+	// FilesystemURL, for development without LocalStack
+	Backend        string
+	FilesystemRoot string
+	FilesystemURL  string
+	// signs the filesystem backend's presigned URLs; the API and worker must
+	// share it
+	URLSecret string `secret:"true"`
+	// cron spec for sweeping objects no row references any more
+	OrphanSchedule string
+	// objects newer than this many days are never swept, so an upload isn't
+	// taken before its row is saved
+	OrphanMinAgeDays int
+	// only log the orphans a sweep finds instead of deleting them
+	OrphanDryRun bool
+	// lifecycle rules put on the bucket at startup: days before an unfinished
+	// multipart upload is aborted and before a generated report is expired
+	// (0 leaves the rule out)
+	AbortUploadsAfterDays int
+	ReportExpiryDays      int
+}
+
+// where secrets are read from besides the environment. Values from the
+// backend win over the environment, which wins over the defaults; see
+// LoadSecrets.
+type SecretsConfig struct {
+	// "aws" for AWS Secrets Manager (using the AWS settings) or "vault" for
+	// HashiCorp Vault; empty reads secrets from the environment
+	Backend string
+	// secret holding variables by name as a JSON object, e.g.
+	// {"JWT_SIGNING_KEY": "..."}: a Secrets Manager secret ID, or a Vault
+	// path such as secret/data/cv-backend
+	Name string
+	// secret holding the database "username" and "password", re-read as it's
+	// rotated: an RDS-managed secret, or a Vault path such as
+	// database/creds/cv-backend. Empty leaves them to POSTGRES_USER and
+	// POSTGRES_PASSWORD
+	DatabaseName string
+	// how long a fetched secret is reused before it's read again
+	CacheTTL   time.Duration
+	VaultAddr  string
+	VaultToken string `secret:"true"`
+}
+
+// QR labels printed for items and their units
+type LabelConfig struct {
+	// frontend page a scanned label opens, with the label's code appended,
+	// e.g. https://vault.example.edu/scan/; empty prints the bare code
+	ScanURL string
+}
+
 type ServerConfig struct {
-	Port string
+	Port           string
+	RequestTimeout time.Duration
+	// path patterns ("*" matches one segment) that need longer or shorter
+	// than RequestTimeout, e.g. image uploads
+	RouteTimeouts map[string]time.Duration
+	// bounds CSV exports, which stream every matching row and so outlast
+	// RequestTimeout; 0 disables it
+	ExportTimeout time.Duration
+	// concurrent requests admitted before normal traffic is shed with a 503;
+	// best-effort traffic is shed at the lower BestEffortInFlight. Critical
+	// routes are never shed. 0 disables the limit.
+	MaxInFlight        int
+	BestEffortInFlight int
+	ShedRetryAfter     time.Duration
+	// whole-path patterns ("*" matches one segment, no prefix matching);
+	// "?name" additionally requires the query parameter, e.g. "/items?q"
+	CriticalRoutes   []string
+	BestEffortRoutes []string
+	// long-lived routes such as event streams; these are never timed out
+	// and don't count towards MaxInFlight
+	StreamRoutes []string
 }
 
 type JWTConfig struct {
-	SigningKey string
+	SigningKey string `secret:"true"`
 	Issuer     string
 	Expiry     time.Duration
 }
@@ -58,16 +317,82 @@ type AuthConfig struct {
 	OTPCooldown    time.Duration
 	OTPMaxAttempts int
 	RefreshExpiry  time.Duration
+	// how long a permission check answer is reused; 0 disables the cache
+	PermissionCacheTTL time.Duration
+	// holders of these roles, in any scope, get no permissions until their
+	// session has passed two-factor authentication
+	MFARequiredRoles []string
+	// requests per minute for API keys that don't set their own limit; 0 is
+	// unlimited
+	APIKeyRateLimit int
+	// sign-in attempts one address may make per LoginWindow; 0 is unlimited
+	LoginIPLimit int
+	// failed sign-ins per LoginWindow that lock an account; 0 never locks
+	LoginFailureLimit int
+	LoginWindow       time.Duration
+	// the first lockout's length, doubled for each further one within a day
+	// up to LockoutMax
+	LockoutBase time.Duration
+	LockoutMax  time.Duration
+}
+
+// campus single sign-on through an OpenID Connect provider (Azure AD, Google
+// Workspace). Off while Issuer is empty. Azure AD must be given its
+// single-tenant issuer, https://login.microsoftonline.com/{tenant}/v2.0.
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string `secret:"true"`
+	// this server's /auth/oidc/callback, as registered with the provider
+	RedirectURL string
+	// frontend page the browser lands on after sign-in, with the tokens or
+	// the error in its URL fragment
+	PostLoginURL string
+	Scopes       []string
+	// users signing in for the first time get an account if their verified
+	// address is in one of these domains; everyone else must be invited
+	ProvisionDomains []string
+	// treat every address the provider sends as verified. Azure AD sends no
+	// email_verified claim; only set this when the tenant can only issue
+	// addresses in domains the campus owns
+	AssumeEmailVerified bool
+}
+
+// student IDs are stored as HMAC-SHA256 keyed with StudentIDKey. To rotate,
+// set the new key and move the old one into PreviousStudentIDKeys: hashes
+// made with an old key still verify and are rewritten under the new key the
+// next time the student is verified at the desk. An old key can be dropped
+// once no stored hash depends on it; students still on it must re-enter
+// their ID through an administrator.
+type IdentityConfig struct {
+	StudentIDKey          string   `secret:"true"`
+	PreviousStudentIDKeys []string `secret:"true"`
 }
 
+// Output is "file", "stdout" or "both". With Format "json" every line
+// written, including those of the standard library's log package and the
+// task queue, is a JSON object, for shipping to Loki or CloudWatch.
+//
+// Redact masks email addresses and phone numbers and hides tokens and
+// message bodies in the attributes logging.Init knows of, plus RedactFields.
+// It's off in development, where the email, SMS and push log providers print
+// messages in full. SampleRates keeps
+// one in n lines of each message at a level, keyed by level name, e.g.
+// debug=100.
 type LoggingConfig struct {
-	Level      string
-	Format     string
-	Filename   string
-	MaxSize    int
-	MaxBackups int
-	MaxAge     int
-	Compress   bool
+	Level        string
+	Format       string
+	Output       string
+	Filename     string
+	MaxSize      int
+	MaxBackups   int
+	MaxAge       int
+	Compress     bool
+	LocalTime    bool
+	RotateEvery  time.Duration
+	Redact       bool
+	RedactFields []string
+	SampleRates  map[string]int
 }
 
 type CORSConfig struct {
@@ -79,8 +404,34 @@ type CORSConfig struct {
 	MaxAge           int
 }
 
+var (
+	// Load is serialized so each Config gets only its own parse failures
+	loadMu   sync.Mutex
+	unparsed []string
+	// variables LoadFile read, which win over the environment
+	overrides map[string]string
+	// variables LoadSecrets fetched, which win over the environment but not
+	// over overrides
+	secretValues map[string]string
+)
+
+// reads the configuration from the environment, defaulting whatever is
+// unset. Nothing is checked here; call Validate before relying on it.
 func Load() *Config {
-	return &Config{
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	return load()
+}
+
+// Load, with loadMu held
+func load() *Config {
+	unparsed = nil
+	env := getEnv("APP_ENV", "development")
+	logFormat := getEnv("LOG_FORMAT", "json")
+
+	cfg := &Config{
+		Env:        env,
+		ReloadFile: getEnv("CONFIG_RELOAD_FILE", ""),
 		Database: DatabaseConfig{
 			Host:     getEnv("POSTGRES_HOST", "localhost"),
 			Port:     getEnv("POSTGRES_PORT", "5432"),
@@ -88,6 +439,8 @@ func Load() *Config {
 			Password: getEnv("POSTGRES_PASSWORD", ""),
 			DBName:   getEnv("POSTGRES_DB", "postgres"),
 			SSLMode:  getEnv("POSTGRES_SSL_MODE", "disable"),
+
+			MigrateOnStart: getEnvAs("MIGRATE_ON_START", false, strconv.ParseBool),
 		},
 		Redis: RedisConfig{
 			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
@@ -95,27 +448,75 @@ func Load() *Config {
 			DB:       getEnvAs("REDIS_DB", 0, strconv.Atoi),
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
+			Port:           getEnv("SERVER_PORT", "8080"),
+			RequestTimeout: getEnvDuration("REQUEST_TIMEOUT", 30*time.Second),
+			RouteTimeouts: getEnvDurationMap("ROUTE_TIMEOUTS", map[string]time.Duration{
+				"/items/*/images":      2 * time.Minute,
+				"/borrowings/*/images": 2 * time.Minute,
+				"/groups/*/logo":       2 * time.Minute,
+				// up to userimport.MaxRows users, each created and invited in turn
+				"/admin/users/import": 5 * time.Minute,
+			}),
+			ExportTimeout:      getEnvDuration("EXPORT_TIMEOUT", 5*time.Minute),
+			MaxInFlight:        getEnvAs("MAX_IN_FLIGHT", 64, strconv.Atoi),
+			BestEffortInFlight: getEnvAs("BEST_EFFORT_IN_FLIGHT", 16, strconv.Atoi),
+			ShedRetryAfter:     getEnvDuration("SHED_RETRY_AFTER", 5*time.Second),
+			CriticalRoutes: getEnvSlice("CRITICAL_ROUTES", []string{
+				"/health", "/ready", "/auth/*", "/borrowings/item", "/borrowings/item/return/*", "/checkout",
+				"/groups/*/cart/checkout", "/bookings/*/pickup", "/bookings/*/return",
+			}),
+			BestEffortRoutes: getEnvSlice("BEST_EFFORT_ROUTES", []string{
+				"/reports", "/items?q", "/items/search",
+			}),
+			StreamRoutes: getEnvSlice("STREAM_ROUTES", []string{"/events/stream"}),
 		},
 		JWT: JWTConfig{
-			SigningKey: getEnv("JWT_SIGNING_KEY", "default-signing-key-change-in-production"),
+			SigningKey: getEnv("JWT_SIGNING_KEY", defaultJWTSigningKey),
 			Issuer:     getEnv("JWT_ISSUER", "campus-vault"),
 			Expiry:     getEnvDuration("JWT_EXPIRY", 15*time.Minute),
 		},
 		Auth: AuthConfig{
-			OTPExpiry:      getEnvDuration("OTP_EXPIRY", 5*time.Minute),
-			OTPCooldown:    getEnvDuration("OTP_COOLDOWN", 60*time.Second),
-			OTPMaxAttempts: getEnvAs("OTP_MAX_ATTEMPTS", 3, strconv.Atoi),
-			RefreshExpiry:  getEnvDuration("REFRESH_TOKEN_EXPIRY", 168*time.Hour),
+			OTPExpiry:          getEnvDuration("OTP_EXPIRY", 5*time.Minute),
+			OTPCooldown:        getEnvDuration("OTP_COOLDOWN", 60*time.Second),
+			OTPMaxAttempts:     getEnvAs("OTP_MAX_ATTEMPTS", 3, strconv.Atoi),
+			RefreshExpiry:      getEnvDuration("REFRESH_TOKEN_EXPIRY", 168*time.Hour),
+			PermissionCacheTTL: getEnvDuration("PERMISSION_CACHE_TTL", 30*time.Second),
+			MFARequiredRoles:   getEnvSlice("MFA_REQUIRED_ROLES", nil),
+			APIKeyRateLimit:    getEnvAs("API_KEY_RATE_LIMIT", 120, strconv.Atoi),
+			LoginIPLimit:       getEnvAs("LOGIN_IP_LIMIT", 30, strconv.Atoi),
+			LoginFailureLimit:  getEnvAs("LOGIN_FAILURE_LIMIT", 5, strconv.Atoi),
+			LoginWindow:        getEnvDuration("LOGIN_WINDOW", 15*time.Minute),
+			LockoutBase:        getEnvDuration("LOCKOUT_BASE", time.Minute),
+			LockoutMax:         getEnvDuration("LOCKOUT_MAX", time.Hour),
+		},
+		OIDC: OIDCConfig{
+			Issuer:              getEnv("OIDC_ISSUER", ""),
+			ClientID:            getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret:        getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:         getEnv("OIDC_REDIRECT_URL", "http://localhost:8080/auth/oidc/callback"),
+			PostLoginURL:        getEnv("OIDC_POST_LOGIN_URL", "http://localhost:5173/auth/callback"),
+			Scopes:              getEnvSlice("OIDC_SCOPES", []string{"openid", "email", "profile"}),
+			ProvisionDomains:    getEnvSlice("OIDC_PROVISION_DOMAINS", nil),
+			AssumeEmailVerified: getEnvAs("OIDC_ASSUME_EMAIL_VERIFIED", false, strconv.ParseBool),
+		},
+		Identity: IdentityConfig{
+			StudentIDKey:          getEnv("STUDENT_ID_HASH_KEY", defaultStudentIDKey),
+			PreviousStudentIDKeys: getEnvSlice("STUDENT_ID_HASH_PREVIOUS_KEYS", nil),
 		},
 		Logging: LoggingConfig{
-			Level:      getEnv("LOG_LEVEL", "info"),
-			Format:     getEnv("LOG_FORMAT", "json"),
-			Filename:   getEnv("LOG_FILENAME", "logs/app.log"),
-			MaxSize:    getEnvAs("LOG_MAX_SIZE", 100, strconv.Atoi),
-			MaxBackups: getEnvAs("LOG_MAX_BACKUPS", 3, strconv.Atoi),
-			MaxAge:     getEnvAs("LOG_MAX_AGE", 28, strconv.Atoi),
-			Compress:   getEnvAs("LOG_COMPRESS", true, strconv.ParseBool),
+			Level:        getEnv("LOG_LEVEL", "info"),
+			Format:       logFormat,
+			Output:       getEnv("LOG_OUTPUT", defaultLogOutput(logFormat)),
+			Filename:     getEnv("LOG_FILENAME", "logs/app.log"),
+			MaxSize:      getEnvMiB("LOG_MAX_SIZE", 100),
+			MaxBackups:   getEnvAs("LOG_MAX_BACKUPS", 3, strconv.Atoi),
+			MaxAge:       getEnvAs("LOG_MAX_AGE", 28, strconv.Atoi),
+			Compress:     getEnvAs("LOG_COMPRESS", true, strconv.ParseBool),
+			LocalTime:    getEnvAs("LOG_LOCAL_TIME", false, strconv.ParseBool),
+			RotateEvery:  getEnvDuration("LOG_ROTATE_EVERY", 0),
+			Redact:       getEnvAs("LOG_REDACT", env != "development", strconv.ParseBool),
+			RedactFields: getEnvSlice("LOG_REDACT_FIELDS", nil),
+			SampleRates:  getEnvIntMap("LOG_SAMPLE_RATES", nil),
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: getEnvSlice("CORS_ALLOWED_ORIGINS", []string{
@@ -124,7 +525,7 @@ func Load() *Config {
 			}),
 			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-			ExposedHeaders:   []string{"Link"},
+			ExposedHeaders:   []string{"Link", "X-Total-Count", "X-Request-ID"},
 			AllowCredentials: true,
 			MaxAge:           300,
 		},
@@ -133,10 +534,132 @@ func Load() *Config {
 			AccessKeyID:     getEnv("AWS_ACCESS_KEY_ID", ""),
 			SecretAccessKey: getEnv("AWS_SECRET_ACCESS_KEY", ""),
 			EndpointURL:     getEnv("AWS_ENDPOINT_URL", ""),
-			Sender:          getEnv("AWS_EMAIL_SENDER", "test@example.com"),
 			Bucket:          getEnv("AWS_BUCKET", "cv-backend-test-bucket"),
+			PartSizeMB:      getEnvMiB("AWS_S3_PART_SIZE_MB", 8),
+			PartConcurrency: getEnvAs("AWS_S3_PART_CONCURRENCY", 4, strconv.Atoi),
+			PartRetries:     getEnvAs("AWS_S3_PART_RETRIES", 3, strconv.Atoi),
+		},
+		Email: EmailConfig{
+			// AWS_EMAIL_SENDER predates other providers and is still honoured
+			From:            getEnv("EMAIL_FROM", getEnv("AWS_EMAIL_SENDER", "test@example.com")),
+			Provider:        getEnv("EMAIL_PROVIDER", "ses"),
+			SMTPHost:        getEnv("SMTP_HOST", ""),
+			SMTPPort:        getEnv("SMTP_PORT", "587"),
+			SMTPUsername:    getEnv("SMTP_USERNAME", ""),
+			SMTPPassword:    getEnv("SMTP_PASSWORD", ""),
+			SMTPImplicitTLS: getEnvAs("SMTP_IMPLICIT_TLS", false, strconv.ParseBool),
+			// off unless set
+			NotificationToken:    getEnv("SES_NOTIFICATION_TOKEN", ""),
+			NotificationTopicARN: getEnv("SES_NOTIFICATION_TOPIC_ARN", ""),
+		},
+		Calendar: CalendarConfig{
+			SyncInterval: getEnvDuration("CALENDAR_SYNC_INTERVAL", time.Hour),
+			FetchTimeout: getEnvDuration("CALENDAR_FETCH_TIMEOUT", 30*time.Second),
+			Timezone:     getEnv("CALENDAR_TIMEZONE", "America/Toronto"),
+		},
+		Campaign: CampaignConfig{
+			Schedule: getEnv("RETURN_CAMPAIGN_SCHEDULE", "0 9 * * *"),
+		},
+		Booking: BookingConfig{
+			ConfirmationWindow: getEnvDuration("BOOKING_CONFIRMATION_WINDOW", 48*time.Hour),
+			PickupCutoff:       getEnvDuration("BOOKING_PICKUP_CUTOFF", 0),
+			ExpirySchedule:     getEnv("BOOKING_EXPIRY_SCHEDULE", "*/15 * * * *"),
+		},
+		SLA: SLAConfig{
+			CheckInterval: getEnvDuration("REQUEST_SLA_CHECK_INTERVAL", 15*time.Minute),
+			EscalateAfter: getEnvDuration("REQUEST_ESCALATE_AFTER", 48*time.Hour),
+		},
+		Overdue: OverdueConfig{
+			// OVERDUE_CHECK_INTERVAL predates cron schedules and is still honoured
+			CheckSchedule:      getEnv("OVERDUE_CHECK_SCHEDULE", everySpec(getEnvDuration("OVERDUE_CHECK_INTERVAL", time.Hour))),
+			ReminderDaysBefore: getEnvAs("OVERDUE_REMINDER_DAYS_BEFORE", 1, strconv.Atoi),
+			ReminderRepeatDays: getEnvAs("OVERDUE_REMINDER_REPEAT_DAYS", 3, strconv.Atoi),
+		},
+		Digest: DigestConfig{
+			Schedule:          getEnv("INVENTORY_DIGEST_SCHEDULE", "0 8 * * 1"),
+			LowStockThreshold: getEnvAs("INVENTORY_DIGEST_LOW_STOCK", 2, strconv.Atoi),
+			ApproverSchedule:  getEnv("APPROVER_DIGEST_SCHEDULE", "0 7 * * *"),
+		},
+		Availability: AvailabilityConfig{
+			CleanupSchedule: getEnv("AVAILABILITY_CLEANUP_SCHEDULE", "0 3 * * *"),
+			RetentionDays:   getEnvAs("AVAILABILITY_RETENTION_DAYS", 30, strconv.Atoi),
+		},
+		Fines: FinesConfig{
+			BlockThresholdCents: getEnvAs("FINES_BLOCK_THRESHOLD_CENTS", 0, strconv.Atoi),
+		},
+		Worker: WorkerConfig{
+			MaxRetries: getEnvIntMap("TASK_MAX_RETRIES", map[string]int{
+				// SES throttling and outages can last a while; keep trying for about a day
+				"email:delivery": 12,
+				// a text about a pickup an hour away is useless by tomorrow
+				"sms:delivery":  4,
+				"push:delivery": 4,
+				"chat:delivery": 4,
+				// the next scheduled run covers anything a failed one missed
+				"calendar:sync": 2,
+				"sla:check":     2,
+				"overdue:check": 2,
+			}),
+			MaxRetry:       getEnvAs("TASK_MAX_RETRY", 8, strconv.Atoi),
+			RetryBaseDelay: getEnvDuration("TASK_RETRY_BASE_DELAY", 30*time.Second),
+			RetryMaxDelay:  getEnvDuration("TASK_RETRY_MAX_DELAY", 6*time.Hour),
+		},
+		SMS: SMSConfig{
+			Provider:         getEnv("SMS_PROVIDER", "log"),
+			From:             getEnv("SMS_FROM", ""),
+			TwilioAccountSID: getEnv("TWILIO_ACCOUNT_SID", ""),
+			TwilioAuthToken:  getEnv("TWILIO_AUTH_TOKEN", ""),
+		},
+		Push: PushConfig{
+			Provider:           getEnv("PUSH_PROVIDER", "log"),
+			FCMProjectID:       getEnv("FCM_PROJECT_ID", ""),
+			FCMCredentialsFile: getEnv("FCM_CREDENTIALS_FILE", ""),
+			VAPIDPublicKey:     getEnv("VAPID_PUBLIC_KEY", ""),
+			VAPIDPrivateKey:    getEnv("VAPID_PRIVATE_KEY", ""),
+			VAPIDSubject:       getEnv("VAPID_SUBJECT", ""),
+		},
+		Chat: ChatConfig{
+			Provider:   getEnv("CHAT_PROVIDER", "slack"),
+			WebhookURL: getEnv("CHAT_WEBHOOK_URL", ""),
+			Events:     getEnvSlice("CHAT_EVENTS", []string{"request_submitted", "request_approved", "item_overdue"}),
+		},
+		Metrics: MetricsConfig{
+			Token:      getEnv("METRICS_TOKEN", ""),
+			WorkerPort: getEnv("WORKER_METRICS_PORT", ""),
+		},
+		Labels: LabelConfig{
+			ScanURL: getEnv("LABEL_SCAN_URL", ""),
+		},
+		Catalog: CatalogConfig{
+			CacheEnabled: getEnvAs("CATALOG_CACHE_ENABLED", true, strconv.ParseBool),
+			CacheTTL:     getEnvDuration("CATALOG_CACHE_TTL", time.Minute),
+		},
+		Storage: StorageConfig{
+			Backend:               getEnv("STORAGE_BACKEND", "s3"),
+			FilesystemRoot:        getEnv("STORAGE_FILESYSTEM_ROOT", "data/storage"),
+			FilesystemURL:         getEnv("STORAGE_FILESYSTEM_URL", "http://localhost:8080/files/"),
+			URLSecret:             getEnv("STORAGE_URL_SECRET", defaultStorageSecret),
+			OrphanSchedule:        getEnv("S3_ORPHAN_SWEEP_SCHEDULE", "30 4 * * 0"),
+			OrphanMinAgeDays:      getEnvAs("S3_ORPHAN_MIN_AGE_DAYS", 7, strconv.Atoi),
+			OrphanDryRun:          getEnvAs("S3_ORPHAN_DRY_RUN", true, strconv.ParseBool),
+			AbortUploadsAfterDays: getEnvAs("S3_ABORT_UPLOADS_AFTER_DAYS", 2, strconv.Atoi),
+			ReportExpiryDays:      getEnvAs("S3_REPORT_EXPIRY_DAYS", 30, strconv.Atoi),
+		},
+		Secrets: SecretsConfig{
+			Backend:      getEnv("SECRETS_BACKEND", ""),
+			Name:         getEnv("SECRETS_NAME", ""),
+			DatabaseName: getEnv("SECRETS_DATABASE_NAME", ""),
+			CacheTTL:     getEnvDuration("SECRETS_CACHE_TTL", 5*time.Minute),
+			VaultAddr:    getEnv("VAULT_ADDR", ""),
+			VaultToken:   getEnv("VAULT_TOKEN", ""),
 		},
 	}
+	cfg.unparsed = unparsed
+	return cfg
+}
+
+func (c *Config) IsProduction() bool {
+	return strings.EqualFold(c.Env, "production")
 }
 
 func (c *DatabaseConfig) ConnectionString() string {
@@ -146,33 +669,121 @@ func (c *DatabaseConfig) ConnectionString() string {
 	)
 }
 
+// key's value from LoadFile's overrides, the secret backend or else the
+// environment
+func lookupEnv(key string) string {
+	if value, ok := overrides[key]; ok {
+		return value
+	}
+	if value, ok := secretValues[key]; ok {
+		return value
+	}
+	return os.Getenv(key)
+}
+
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
+// a cron spec running every d; "" (disabled) when d is 0
+func everySpec(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return "@every " + d.String()
+}
+
+// notes a variable that is set but couldn't be parsed
+func invalid(key, value, want string) {
+	unparsed = append(unparsed, fmt.Sprintf("%s: %q is not %s", key, value, want))
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		if duration, err := time.ParseDuration(value); err == nil {
-			return duration
+	if value := lookupEnv(key); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			invalid(key, value, "a duration, e.g. 30s or 15m")
+			return defaultValue
 		}
+		return duration
 	}
 	return defaultValue
 }
 
 func getEnvAs[T any](key string, defaultValue T, parser func(string) (T, error)) T {
-	if value := os.Getenv(key); value != "" {
-		if parsed, err := parser(value); err == nil {
-			return parsed
+	if value := lookupEnv(key); value != "" {
+		parsed, err := parser(value)
+		if err != nil {
+			invalid(key, value, fmt.Sprintf("a valid %T", defaultValue))
+			return defaultValue
 		}
+		return parsed
 	}
 	return defaultValue
 }
 
+// a size in MiB. A bare number is MiB; with a unit it must come to whole
+// MiB, e.g. 16MiB or 1GiB
+func getEnvMiB(key string, defaultValue int) int {
+	value := lookupEnv(key)
+	if value == "" {
+		return defaultValue
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		return n
+	}
+	size, err := ParseSize(value)
+	if err != nil || size%(1<<20) != 0 {
+		invalid(key, value, "a whole number of MiB, e.g. 8, 8MiB or 1GiB")
+		return defaultValue
+	}
+	return int(size >> 20)
+}
+
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"kib": 1 << 10,
+	"mb":  1000 * 1000,
+	"mib": 1 << 20,
+	"gb":  1000 * 1000 * 1000,
+	"gib": 1 << 30,
+}
+
+// parses a size in bytes such as "512", "64KB" or "8MiB". KB, MB and GB are
+// decimal; KiB, MiB and GiB binary.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i == -1 {
+		i = len(s)
+	}
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit", s)
+	}
+	return n * unit, nil
+}
+
+// JSON logs go to the file only, as before LOG_OUTPUT; text logs are also
+// printed for whoever is watching the terminal
+func defaultLogOutput(format string) string {
+	if format == "json" {
+		return "file"
+	}
+	return "both"
+}
+
 func getEnvSlice(key string, defaultValue []string) []string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		parts := strings.Split(value, ",")
 		result := make([]string, 0, len(parts))
 		for _, part := range parts {
@@ -184,3 +795,51 @@ func getEnvSlice(key string, defaultValue []string) []string {
 	}
 	return defaultValue
 }
+
+// parses "key=duration" pairs separated by commas. Malformed pairs are
+// skipped, and reported by Validate.
+func getEnvDurationMap(key string, defaultValue map[string]time.Duration) map[string]time.Duration {
+	value := lookupEnv(key)
+	if value == "" {
+		return defaultValue
+	}
+	result := make(map[string]time.Duration)
+	for _, part := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			invalid(key, part, "a key=duration pair")
+			continue
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			invalid(key, part, "a key=duration pair")
+			continue
+		}
+		result[strings.TrimSpace(k)] = duration
+	}
+	return result
+}
+
+// parses "key=n" pairs separated by commas. Malformed pairs are skipped, and
+// reported by Validate.
+func getEnvIntMap(key string, defaultValue map[string]int) map[string]int {
+	value := lookupEnv(key)
+	if value == "" {
+		return defaultValue
+	}
+	result := make(map[string]int)
+	for _, part := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			invalid(key, part, "a key=number pair")
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			invalid(key, part, "a key=number pair")
+			continue
+		}
+		result[strings.TrimSpace(k)] = n
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// shown in place of a secret that is set. An unset one stays empty, so it's
+// still clear whether it was configured.
+const redacted = "[redacted]"
+
+// the configuration as nested maps keyed by snake_case field name, for
+// showing to administrators. Fields tagged secret:"true" are redacted, and
+// durations are written out as "30s" rather than nanoseconds.
+func (cfg *Config) Redacted() map[string]any {
+	return redactStruct(reflect.ValueOf(cfg).Elem())
+}
+
+func redactStruct(v reflect.Value) map[string]any {
+	out := make(map[string]any, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+		if field.Tag.Get("secret") == "true" {
+			out[snakeCase(field.Name)] = redactSecret(value)
+			continue
+		}
+		out[snakeCase(field.Name)] = redactValue(value)
+	}
+	return out
+}
+
+func redactSecret(v reflect.Value) any {
+	if v.Kind() == reflect.Slice {
+		secrets := make([]any, v.Len())
+		for i := range secrets {
+			secrets[i] = redactSecret(v.Index(i))
+		}
+		return secrets
+	}
+	if v.IsZero() {
+		return ""
+	}
+	return redacted
+}
+
+func redactValue(v reflect.Value) any {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return redactStruct(v)
+	case reflect.Map:
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = redactValue(iter.Value())
+		}
+		return out
+	case reflect.Slice:
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = redactValue(v.Index(i))
+		}
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+// "SMTPHost" to "smtp_host": a word starts at an upper-case letter after a
+// lower-case one or a digit, or at the last capital of an acronym followed
+// by a lower-case letter
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
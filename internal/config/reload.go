@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// reads the configuration as Load does, except that the variables in path,
+// an env file like the one the Makefile exports, win over the environment.
+// A running process can't have its environment changed from outside, so
+// reloads read from here instead.
+func LoadFile(path string) (*Config, error) {
+	vars, err := readEnvFile(path)
+	if err != nil {
+		return nil, err
+	}
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	overrides = vars
+	defer func() { overrides = nil }()
+	return load(), nil
+}
+
+// KEY=value lines; blank lines, # comments, a leading "export" and quotes
+// around the value are allowed
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: not a KEY=value line", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return vars, nil
+}
+
+// a copy of cfg with the settings a running API can change taken from next:
+// the log level, the API key and sign-in rate limits, the load-shedding
+// limits, the catalogue cache switch and the chat events. Everything else
+// stays as cfg has it, since it only takes effect on a restart.
+func (cfg *Config) Reload(next *Config) *Config {
+	reloaded := *cfg
+	reloaded.Logging.Level = next.Logging.Level
+	reloaded.Auth.APIKeyRateLimit = next.Auth.APIKeyRateLimit
+	reloaded.Auth.LoginIPLimit = next.Auth.LoginIPLimit
+	reloaded.Auth.LoginFailureLimit = next.Auth.LoginFailureLimit
+	reloaded.Server.MaxInFlight = next.Server.MaxInFlight
+	reloaded.Server.BestEffortInFlight = next.Server.BestEffortInFlight
+	reloaded.Catalog.CacheEnabled = next.Catalog.CacheEnabled
+	reloaded.Chat.Events = next.Chat.Events
+	reloaded.unparsed = nil
+	return &reloaded
+}
+
+// the configuration a running process is using, replaced whole as reloads
+// are applied. A Config is never changed once stored, so readers can keep
+// the one Current gave them.
+type Live struct {
+	current atomic.Pointer[Config]
+}
+
+func NewLive(cfg *Config) *Live {
+	l := &Live{}
+	l.current.Store(cfg)
+	return l
+}
+
+func (l *Live) Current() *Config {
+	return l.current.Load()
+}
+
+func (l *Live) Store(cfg *Config) {
+	l.current.Store(cfg)
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Reload(t *testing.T) {
+	running := Load()
+
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("API_KEY_RATE_LIMIT", "30")
+	t.Setenv("MAX_IN_FLIGHT", "400")
+	t.Setenv("CATALOG_CACHE_ENABLED", "false")
+	t.Setenv("CHAT_EVENTS", "item_overdue")
+	t.Setenv("SERVER_PORT", "9090")
+	t.Setenv("JWT_SIGNING_KEY", "rotated")
+	next := Load()
+
+	reloaded := running.Reload(next)
+	assert.Equal(t, "debug", reloaded.Logging.Level)
+	assert.Equal(t, 30, reloaded.Auth.APIKeyRateLimit)
+	assert.Equal(t, 400, reloaded.Server.MaxInFlight)
+	assert.False(t, reloaded.Catalog.CacheEnabled)
+	assert.Equal(t, []string{"item_overdue"}, reloaded.Chat.Events)
+
+	assert.Equal(t, running.Server.Port, reloaded.Server.Port, "needs a restart")
+	assert.Equal(t, running.JWT.SigningKey, reloaded.JWT.SigningKey, "needs a restart")
+	assert.NotEqual(t, "debug", running.Logging.Level, "the running config is left alone")
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("MAX_IN_FLIGHT", "100")
+
+	path := filepath.Join(t.TempDir(), "reload.env")
+	require.NoError(t, os.WriteFile(path, []byte(`# tuned for exam week
+LOG_LEVEL=debug
+export CHAT_EVENTS="item_overdue,request_submitted"
+
+API_KEY_RATE_LIMIT=soon
+`), 0o600))
+
+	cfg, err := LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "debug", cfg.Logging.Level, "the file wins over the environment")
+	assert.Equal(t, 100, cfg.Server.MaxInFlight, "the environment fills in the rest")
+	assert.Equal(t, []string{"item_overdue", "request_submitted"}, cfg.Chat.Events)
+	assert.Error(t, cfg.Validate(), "bad values in the file are caught")
+
+	assert.Equal(t, "warn", Load().Logging.Level, "later loads don't see the file")
+
+	require.NoError(t, os.WriteFile(path, []byte("LOG_LEVEL\n"), 0o600))
+	_, err = LoadFile(path)
+	assert.Error(t, err)
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	t.Setenv("POSTGRES_PASSWORD", "hunter2")
+	t.Setenv("STUDENT_ID_HASH_PREVIOUS_KEYS", "old-key")
+	t.Setenv("SMTP_HOST", "mail.example.com")
+	cfg := Load()
+
+	shown := cfg.Redacted()
+	database := shown["database"].(map[string]any)
+	assert.Equal(t, "[redacted]", database["password"])
+	assert.Equal(t, "localhost", database["host"])
+	assert.Equal(t, "", shown["redis"].(map[string]any)["password"], "unset secrets stay empty")
+
+	identity := shown["identity"].(map[string]any)
+	assert.Equal(t, []any{"[redacted]"}, identity["previous_student_id_keys"])
+
+	email := shown["email"].(map[string]any)
+	assert.Equal(t, "mail.example.com", email["smtp_host"])
+
+	server := shown["server"].(map[string]any)
+	assert.Equal(t, "30s", server["request_timeout"])
+	assert.Equal(t, (2 * time.Minute).String(), server["route_timeouts"].(map[string]any)["/items/*/images"])
+	assert.NotContains(t, shown, "unparsed")
+}
+
+// a new password or token field must be tagged, or Redacted shows it
+func TestConfig_SecretsTagged(t *testing.T) {
+	looksSecret := regexp.MustCompile(`Password|Secret|Token|PrivateKey|SigningKey|StudentIDKey`)
+	var check func(typ reflect.Type)
+	check = func(typ reflect.Type) {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if field.Type.Kind() == reflect.Struct {
+				check(field.Type)
+				continue
+			}
+			if looksSecret.MatchString(field.Name) {
+				require.Equal(t, "true", field.Tag.Get("secret"), "%s.%s", typ.Name(), field.Name)
+			}
+		}
+	}
+	check(reflect.TypeOf(Config{}))
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"Host":             "host",
+		"SMTPHost":         "smtp_host",
+		"AccessKeyID":      "access_key_id",
+		"PartSizeMB":       "part_size_mb",
+		"VAPIDPublicKey":   "vapid_public_key",
+		"TwilioAccountSID": "twilio_account_sid",
+		"CORS":             "cors",
+	} {
+		assert.Equal(t, want, snakeCase(name))
+	}
+}
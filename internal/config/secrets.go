@@ -0,0 +1,221 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// a place secrets are kept: AWS Secrets Manager or Vault
+type secretBackend interface {
+	fetch(ctx context.Context, name string) (secret, error)
+}
+
+type secret struct {
+	values map[string]string
+	// changes whenever the secret does, e.g. when it's rotated
+	version string
+	// how long the backend says the values stay valid; 0 when it doesn't say
+	lease time.Duration
+}
+
+// a secret is read again at most this often when Expire asks for it early
+const minSecretRefetch = 30 * time.Second
+
+// caches secrets read from a backend for their TTL
+type secretStore struct {
+	backend secretBackend
+	ttl     time.Duration
+	// SecretsConfig.DatabaseName; empty when the database has no secret
+	databaseName string
+	now          func() time.Time
+
+	mu     sync.Mutex
+	cached map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	secret
+	fetched time.Time
+	expires time.Time
+}
+
+// set by LoadSecrets; nil while secrets come from the environment
+var activeSecrets atomic.Pointer[secretStore]
+
+func newSecretStore(cfg *Config) (*secretStore, error) {
+	var backend secretBackend
+	var err error
+	switch cfg.Secrets.Backend {
+	case "aws":
+		backend, err = newAWSSecretsManager(cfg.AWS)
+	case "vault":
+		backend, err = newVaultBackend(cfg.Secrets)
+	default:
+		return nil, fmt.Errorf("unknown secrets backend %q", cfg.Secrets.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &secretStore{
+		backend:      backend,
+		ttl:          cfg.Secrets.CacheTTL,
+		databaseName: cfg.Secrets.DatabaseName,
+		now:          time.Now,
+		cached:       make(map[string]cachedSecret),
+	}, nil
+}
+
+// the named secret, from the cache while it's fresh. Once it has been read,
+// failing to read it again returns the cached values, so a backend outage
+// doesn't stop new database connections.
+func (s *secretStore) get(ctx context.Context, name string) (secret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	cached, ok := s.cached[name]
+	if ok && now.Before(cached.expires) {
+		return cached.secret, nil
+	}
+
+	fresh, err := s.backend.fetch(ctx, name)
+	if err != nil {
+		if ok {
+			slog.Warn("Failed to refresh secret, using the cached one", "secret", name, "error", err)
+			return cached.secret, nil
+		}
+		return secret{}, fmt.Errorf("failed to read secret %s: %w", name, err)
+	}
+	if ok && fresh.version != cached.version {
+		slog.Info("Secret rotated", "secret", name, "version", fresh.version)
+	}
+
+	ttl := s.ttl
+	// renewed well before a lease, such as on Vault's dynamic database
+	// credentials, runs out
+	if fresh.lease > 0 && fresh.lease/2 < ttl {
+		ttl = fresh.lease / 2
+	}
+	s.cached[name] = cachedSecret{secret: fresh, fetched: now, expires: now.Add(ttl)}
+	return fresh, nil
+}
+
+// makes the next get read the named secret again, unless it was read within
+// minSecretRefetch
+func (s *secretStore) expire(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cached, ok := s.cached[name]
+	if !ok || s.now().Sub(cached.fetched) < minSecretRefetch {
+		return
+	}
+	cached.expires = cached.fetched
+	s.cached[name] = cached
+}
+
+func (s *secretStore) databaseCredentials(ctx context.Context) (user, password string, err error) {
+	sec, err := s.get(ctx, s.databaseName)
+	if err != nil {
+		return "", "", err
+	}
+	user, password = sec.values["username"], sec.values["password"]
+	if user == "" || password == "" {
+		return "", "", fmt.Errorf("secret %s has no username and password", s.databaseName)
+	}
+	return user, password, nil
+}
+
+// reads SECRETS_NAME and SECRETS_DATABASE_NAME from SECRETS_BACKEND, both
+// configured in the environment, so that Load sees their values. Call it
+// once, before Load. The precedence is then:
+//
+//  1. the variables in SECRETS_NAME, and POSTGRES_USER and POSTGRES_PASSWORD
+//     from SECRETS_DATABASE_NAME
+//  2. the environment
+//  3. the defaults
+//
+// Does nothing when SECRETS_BACKEND is unset.
+func LoadSecrets(ctx context.Context) error {
+	cfg := Load()
+	if cfg.Secrets.Backend == "" {
+		return nil
+	}
+	store, err := newSecretStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]string)
+	if cfg.Secrets.Name != "" {
+		sec, err := store.get(ctx, cfg.Secrets.Name)
+		if err != nil {
+			return err
+		}
+		maps.Copy(values, sec.values)
+	}
+	if cfg.Secrets.DatabaseName != "" {
+		user, password, err := store.databaseCredentials(ctx)
+		if err != nil {
+			return err
+		}
+		values["POSTGRES_USER"] = user
+		values["POSTGRES_PASSWORD"] = password
+	}
+
+	loadMu.Lock()
+	secretValues = values
+	loadMu.Unlock()
+	activeSecrets.Store(store)
+	return nil
+}
+
+// the username and password to connect with. With SECRETS_DATABASE_NAME set
+// they come from that secret, read again once SECRETS_CACHE_TTL has passed so
+// rotations are picked up; otherwise they are User and Password.
+func (c *DatabaseConfig) Credentials(ctx context.Context) (user, password string, err error) {
+	store := activeSecrets.Load()
+	if store == nil || store.databaseName == "" {
+		return c.User, c.Password, nil
+	}
+	return store.databaseCredentials(ctx)
+}
+
+// makes the next Credentials read the database secret again rather than
+// wait out the cache, for when the credentials were refused because the
+// secret has just been rotated.
+func ExpireDatabaseCredentials() {
+	if store := activeSecrets.Load(); store != nil && store.databaseName != "" {
+		store.expire(store.databaseName)
+	}
+}
+
+// a secret's JSON object as strings; numbers and booleans, such as an RDS
+// secret's port, are written out as JSON has them
+func secretStrings(object map[string]json.RawMessage) (map[string]string, error) {
+	values := make(map[string]string, len(object))
+	for key, raw := range object {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			values[key] = s
+			continue
+		}
+		var scalar any
+		if err := json.Unmarshal(raw, &scalar); err != nil {
+			return nil, err
+		}
+		switch scalar.(type) {
+		case float64, bool:
+			values[key] = string(raw)
+		case nil:
+		default:
+			return nil, fmt.Errorf("%s is not a string", key)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+)
+
+// reads secrets with Secrets Manager's GetSecretValue, called over its JSON
+// API and signed with SigV4 as internal/aws does for SNS. That package
+// imports this one, so its LoadAWSConfig can't be used here.
+type awsSecretsManager struct {
+	httpClient  *http.Client
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	region      string
+	endpoint    string
+}
+
+func newAWSSecretsManager(cfg AWSConfig) (*awsSecretsManager, error) {
+	opts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(cfg.Region),
+	}
+	if cfg.AccessKeyID != "" && cfg.SecretAccessKey != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{
+				AccessKeyID:     cfg.AccessKeyID,
+				SecretAccessKey: cfg.SecretAccessKey,
+			}, nil
+		})))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	// override endpoint if provided (for LocalStack)
+	endpoint := fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", awsCfg.Region)
+	if cfg.EndpointURL != "" {
+		endpoint = strings.TrimSuffix(cfg.EndpointURL, "/") + "/"
+	}
+
+	return &awsSecretsManager{
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		credentials: awsCfg.Credentials,
+		signer:      v4.NewSigner(),
+		region:      awsCfg.Region,
+		endpoint:    endpoint,
+	}, nil
+}
+
+type secretsManagerError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// the secret's SecretString, which must be a JSON object. Binary secrets
+// aren't supported.
+func (m *awsSecretsManager) fetch(ctx context.Context, name string) (secret, error) {
+	payload, err := json.Marshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return secret{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return secret{}, fmt.Errorf("failed to build Secrets Manager request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	creds, err := m.credentials.Retrieve(ctx)
+	if err != nil {
+		return secret{}, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256(payload)
+	if err := m.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "secretsmanager", m.region, time.Now()); err != nil {
+		return secret{}, fmt.Errorf("failed to sign Secrets Manager request: %w", err)
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return secret{}, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return secret{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var smErr secretsManagerError
+		_ = json.Unmarshal(raw, &smErr)
+		return secret{}, fmt.Errorf("status %d: %s %s", resp.StatusCode, smErr.Type, smErr.Message)
+	}
+
+	var out struct {
+		SecretString *string
+		VersionId    string
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return secret{}, fmt.Errorf("failed to decode GetSecretValue response: %w", err)
+	}
+	if out.SecretString == nil {
+		return secret{}, errors.New("secret has no SecretString; binary secrets aren't supported")
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(*out.SecretString), &object); err != nil {
+		return secret{}, fmt.Errorf("secret is not a JSON object: %w", err)
+	}
+	values, err := secretStrings(object)
+	if err != nil {
+		return secret{}, err
+	}
+	return secret{values: values, version: out.VersionId}, nil
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// answers Vault reads from paths, as JSON bodies
+func fakeVault(t *testing.T, paths map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		body, ok := paths[strings.TrimPrefix(r.URL.Path, "/v1/")]
+		if !ok {
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVaultBackend(t *testing.T) {
+	srv := fakeVault(t, map[string]string{
+		"secret/data/cv-backend": `{"data": {"data": {"JWT_SIGNING_KEY": "from-vault", "REDIS_DB": 2}, "metadata": {"version": 3}}}`,
+		"database/creds/cv":      `{"lease_id": "database/creds/cv/abc", "lease_duration": 3600, "data": {"username": "v-cv-abc", "password": "p4ss"}}`,
+	})
+	backend, err := newVaultBackend(SecretsConfig{VaultAddr: srv.URL, VaultToken: "vault-token"})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	kv, err := backend.fetch(ctx, "secret/data/cv-backend")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"JWT_SIGNING_KEY": "from-vault", "REDIS_DB": "2"}, kv.values)
+	assert.Equal(t, "3", kv.version)
+
+	creds, err := backend.fetch(ctx, "database/creds/cv")
+	require.NoError(t, err)
+	assert.Equal(t, "v-cv-abc", creds.values["username"])
+	assert.Equal(t, "database/creds/cv/abc", creds.version)
+	assert.Equal(t, time.Hour, creds.lease)
+
+	_, err = backend.fetch(ctx, "secret/data/missing")
+	assert.ErrorContains(t, err, "status 404")
+}
+
+func TestAWSSecretsManager(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-east-1/secretsmanager/aws4_request")
+
+		var in struct{ SecretId string }
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		if in.SecretId != "prod/cv-backend/db" {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"__type": "ResourceNotFoundException", "message": "Secrets Manager can't find the specified secret."}`)
+			return
+		}
+		io.WriteString(w, `{"VersionId": "v2", "SecretString": "{\"username\": \"cv\", \"password\": \"rotated\", \"port\": 5432}"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	sm, err := newAWSSecretsManager(AWSConfig{Region: "us-east-1", AccessKeyID: "test", SecretAccessKey: "test", EndpointURL: srv.URL})
+	require.NoError(t, err)
+
+	sec, err := sm.fetch(context.Background(), "prod/cv-backend/db")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"username": "cv", "password": "rotated", "port": "5432"}, sec.values)
+	assert.Equal(t, "v2", sec.version)
+
+	_, err = sm.fetch(context.Background(), "prod/cv-backend/other")
+	assert.ErrorContains(t, err, "ResourceNotFoundException")
+}
+
+// hands out a new version on every fetch, or fails while broken
+type countingBackend struct {
+	fetches int
+	lease   time.Duration
+	broken  bool
+}
+
+func (b *countingBackend) fetch(ctx context.Context, name string) (secret, error) {
+	if b.broken {
+		return secret{}, errors.New("backend down")
+	}
+	b.fetches++
+	password := strings.Repeat("x", b.fetches)
+	return secret{values: map[string]string{"username": "cv", "password": password}, version: password, lease: b.lease}, nil
+}
+
+func TestSecretStore(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	newStore := func(backend *countingBackend) *secretStore {
+		return &secretStore{
+			backend:      backend,
+			ttl:          5 * time.Minute,
+			databaseName: "db",
+			now:          func() time.Time { return now },
+			cached:       make(map[string]cachedSecret),
+		}
+	}
+	ctx := context.Background()
+
+	t.Run("cached for the TTL", func(t *testing.T) {
+		backend := &countingBackend{}
+		store := newStore(backend)
+
+		_, password, err := store.databaseCredentials(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "x", password)
+
+		now = now.Add(4 * time.Minute)
+		_, password, _ = store.databaseCredentials(ctx)
+		assert.Equal(t, "x", password)
+
+		now = now.Add(2 * time.Minute)
+		_, password, _ = store.databaseCredentials(ctx)
+		assert.Equal(t, "xx", password, "read again once the TTL has passed")
+		assert.Equal(t, 2, backend.fetches)
+	})
+
+	t.Run("leases shorten the TTL", func(t *testing.T) {
+		backend := &countingBackend{lease: 2 * time.Minute}
+		store := newStore(backend)
+
+		store.databaseCredentials(ctx)
+		now = now.Add(61 * time.Second)
+		store.databaseCredentials(ctx)
+		assert.Equal(t, 2, backend.fetches, "renewed at half the lease")
+	})
+
+	t.Run("expire reads again, but not too often", func(t *testing.T) {
+		backend := &countingBackend{}
+		store := newStore(backend)
+
+		store.databaseCredentials(ctx)
+		store.expire("db")
+		store.databaseCredentials(ctx)
+		assert.Equal(t, 1, backend.fetches, "just read")
+
+		now = now.Add(minSecretRefetch)
+		store.expire("db")
+		_, password, _ := store.databaseCredentials(ctx)
+		assert.Equal(t, "xx", password)
+	})
+
+	t.Run("the cached secret outlives an outage", func(t *testing.T) {
+		backend := &countingBackend{}
+		store := newStore(backend)
+
+		store.databaseCredentials(ctx)
+		backend.broken = true
+		now = now.Add(time.Hour)
+		_, password, err := store.databaseCredentials(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "x", password)
+
+		_, err = store.get(ctx, "never-read")
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadSecrets(t *testing.T) {
+	srv := fakeVault(t, map[string]string{
+		"secret/data/cv-backend": `{"data": {"data": {"JWT_SIGNING_KEY": "from-vault"}, "metadata": {"version": 1}}}`,
+		"database/creds/cv":      `{"lease_duration": 3600, "data": {"username": "v-cv-abc", "password": "p4ss"}}`,
+	})
+	t.Cleanup(func() {
+		secretValues = nil
+		activeSecrets.Store(nil)
+	})
+
+	t.Setenv("SECRETS_BACKEND", "vault")
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN", "vault-token")
+	t.Setenv("SECRETS_NAME", "secret/data/cv-backend")
+	t.Setenv("SECRETS_DATABASE_NAME", "database/creds/cv")
+	t.Setenv("JWT_SIGNING_KEY", "from-env")
+	t.Setenv("POSTGRES_PASSWORD", "from-env")
+	t.Setenv("LOG_LEVEL", "warn")
+
+	require.NoError(t, LoadSecrets(context.Background()))
+	cfg := Load()
+	assert.Equal(t, "from-vault", cfg.JWT.SigningKey, "the backend wins over the environment")
+	assert.Equal(t, "warn", cfg.Logging.Level, "the environment fills in the rest")
+	assert.Equal(t, "p4ss", cfg.Database.Password)
+
+	user, password, err := cfg.Database.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "v-cv-abc", user)
+	assert.Equal(t, "p4ss", password)
+
+	t.Setenv("VAULT_TOKEN", "wrong")
+	assert.ErrorContains(t, LoadSecrets(context.Background()), "permission denied")
+}
+
+func TestDatabaseConfig_Credentials(t *testing.T) {
+	cfg := DatabaseConfig{User: "postgres", Password: "secret"}
+	user, password, err := cfg.Credentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "postgres", user, "without a secret backend")
+	assert.Equal(t, "secret", password)
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reads secrets from HashiCorp Vault's HTTP API with a token. Paths are as
+// the API has them: a KV version 2 secret is read at <mount>/data/<path>.
+type vaultBackend struct {
+	httpClient *http.Client
+	addr       string
+	token      string
+}
+
+func newVaultBackend(cfg SecretsConfig) (*vaultBackend, error) {
+	if cfg.VaultAddr == "" || cfg.VaultToken == "" {
+		return nil, errors.New("vault needs VAULT_ADDR and VAULT_TOKEN")
+	}
+	return &vaultBackend{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		addr:       strings.TrimSuffix(cfg.VaultAddr, "/"),
+		token:      cfg.VaultToken,
+	}, nil
+}
+
+type vaultResponse struct {
+	LeaseID       string                     `json:"lease_id"`
+	LeaseDuration int                        `json:"lease_duration"`
+	Data          map[string]json.RawMessage `json:"data"`
+	Errors        []string                   `json:"errors"`
+}
+
+// KV version 2 nests the values under data.data with the version alongside
+// in data.metadata; everything else (KV version 1, dynamic database
+// credentials) has them in data, and a lease instead of a version.
+func (v *vaultBackend) fetch(ctx context.Context, name string) (secret, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.addr+"/v1/"+strings.TrimPrefix(name, "/"), nil)
+	if err != nil {
+		return secret{}, fmt.Errorf("failed to build Vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return secret{}, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return secret{}, err
+	}
+
+	var out vaultResponse
+	_ = json.Unmarshal(raw, &out)
+	if resp.StatusCode != http.StatusOK {
+		return secret{}, fmt.Errorf("status %d: %s", resp.StatusCode, strings.Join(out.Errors, "; "))
+	}
+	if out.Data == nil {
+		return secret{}, errors.New("no data in the Vault response")
+	}
+
+	object := out.Data
+	version := out.LeaseID
+	var kv2 struct {
+		Data     map[string]json.RawMessage `json:"data"`
+		Metadata *struct {
+			Version int `json:"version"`
+		} `json:"metadata"`
+	}
+	if data, ok := out.Data["data"]; ok && json.Unmarshal(data, &kv2.Data) == nil {
+		if metadata, ok := out.Data["metadata"]; ok && json.Unmarshal(metadata, &kv2.Metadata) == nil && kv2.Metadata != nil {
+			object = kv2.Data
+			version = strconv.Itoa(kv2.Metadata.Version)
+		}
+	}
+
+	values, err := secretStrings(object)
+	if err != nil {
+		return secret{}, err
+	}
+	return secret{values: values, version: version, lease: time.Duration(out.LeaseDuration) * time.Second}, nil
+}
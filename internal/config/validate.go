@@ -0,0 +1,269 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the defaults of settings that must be changed before going to production
+const (
+	defaultJWTSigningKey = "default-signing-key-change-in-production"
+	defaultStudentIDKey  = "default-student-id-key-change-in-production"
+	defaultStorageSecret = "default-storage-secret-change-in-production"
+)
+
+// every problem Validate found, each naming the variable to fix.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// collects problems as Validate goes
+type checker struct {
+	problems []string
+}
+
+func (c *checker) check(ok bool, key, format string, args ...any) {
+	if !ok {
+		c.problems = append(c.problems, key+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+func (c *checker) oneOf(key, value string, allowed ...string) {
+	c.check(slices.Contains(allowed, value), key, "%q is not one of %s", value, strings.Join(allowed, ", "))
+}
+
+func (c *checker) positive(key string, d time.Duration) {
+	c.check(d > 0, key, "must be positive, got %s", d)
+}
+
+func (c *checker) notNegative(key string, n int) {
+	c.check(n >= 0, key, "must not be negative, got %d", n)
+}
+
+func (c *checker) port(key, value string) {
+	n, err := strconv.Atoi(value)
+	c.check(err == nil && n > 0 && n < 1<<16, key, "%q is not a port number", value)
+}
+
+// a cron spec as the worker's scheduler takes it, or "off"/"" to disable.
+// Only the shape is checked; the scheduler rejects bad fields at startup.
+func (c *checker) schedule(key, spec string) {
+	if spec == "" || spec == "off" {
+		return
+	}
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(interval)
+		c.check(err == nil && d > 0, key, "%q is not a positive @every interval", spec)
+		return
+	}
+	if strings.HasPrefix(spec, "@") {
+		c.oneOf(key, spec, "@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight", "@hourly")
+		return
+	}
+	c.check(len(strings.Fields(spec)) == 5, key, "%q is not a five-field cron spec", spec)
+}
+
+func (c *checker) url(key, value string) {
+	u, err := url.Parse(value)
+	c.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", key, "%q is not an http(s) URL", value)
+}
+
+// checks the configuration as a whole: values that didn't parse, values out
+// of range, settings that contradict each other, and, in production,
+// secrets left at their development defaults. Returns a *ValidationError
+// listing every problem, so they can all be fixed in one go.
+func (cfg *Config) Validate() error {
+	c := &checker{problems: slices.Clone(cfg.unparsed)}
+
+	c.oneOf("APP_ENV", strings.ToLower(cfg.Env), "development", "staging", "production")
+	if cfg.ReloadFile != "" {
+		_, err := os.Stat(cfg.ReloadFile)
+		c.check(err == nil, "CONFIG_RELOAD_FILE", "%q can't be read", cfg.ReloadFile)
+	}
+
+	c.check(cfg.Database.Host != "", "POSTGRES_HOST", "is required")
+	c.port("POSTGRES_PORT", cfg.Database.Port)
+	c.check(cfg.Database.User != "", "POSTGRES_USER", "is required")
+	c.check(cfg.Database.DBName != "", "POSTGRES_DB", "is required")
+	c.oneOf("POSTGRES_SSL_MODE", cfg.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+
+	_, _, err := net.SplitHostPort(cfg.Redis.Addr)
+	c.check(err == nil, "REDIS_ADDR", "%q is not host:port", cfg.Redis.Addr)
+	c.notNegative("REDIS_DB", cfg.Redis.DB)
+
+	c.port("SERVER_PORT", cfg.Server.Port)
+	c.positive("REQUEST_TIMEOUT", cfg.Server.RequestTimeout)
+	for pattern, d := range cfg.Server.RouteTimeouts {
+		c.check(d > 0, "ROUTE_TIMEOUTS", "%s must be positive, got %s", pattern, d)
+	}
+	c.check(cfg.Server.ExportTimeout >= 0, "EXPORT_TIMEOUT", "must not be negative")
+	c.notNegative("MAX_IN_FLIGHT", cfg.Server.MaxInFlight)
+	c.notNegative("BEST_EFFORT_IN_FLIGHT", cfg.Server.BestEffortInFlight)
+	c.check(cfg.Server.MaxInFlight == 0 || cfg.Server.BestEffortInFlight <= cfg.Server.MaxInFlight,
+		"BEST_EFFORT_IN_FLIGHT", "must not exceed MAX_IN_FLIGHT (%d)", cfg.Server.MaxInFlight)
+
+	c.check(cfg.JWT.SigningKey != "", "JWT_SIGNING_KEY", "is required")
+	c.positive("JWT_EXPIRY", cfg.JWT.Expiry)
+	c.positive("OTP_EXPIRY", cfg.Auth.OTPExpiry)
+	c.check(cfg.Auth.OTPCooldown >= 0, "OTP_COOLDOWN", "must not be negative")
+	c.check(cfg.Auth.OTPMaxAttempts > 0, "OTP_MAX_ATTEMPTS", "must be at least 1")
+	c.check(cfg.Auth.RefreshExpiry > cfg.JWT.Expiry, "REFRESH_TOKEN_EXPIRY", "must be longer than JWT_EXPIRY (%s)", cfg.JWT.Expiry)
+	c.check(cfg.Auth.PermissionCacheTTL >= 0, "PERMISSION_CACHE_TTL", "must not be negative")
+	c.notNegative("API_KEY_RATE_LIMIT", cfg.Auth.APIKeyRateLimit)
+	c.notNegative("LOGIN_IP_LIMIT", cfg.Auth.LoginIPLimit)
+	c.notNegative("LOGIN_FAILURE_LIMIT", cfg.Auth.LoginFailureLimit)
+	if cfg.Auth.LoginIPLimit > 0 || cfg.Auth.LoginFailureLimit > 0 {
+		c.positive("LOGIN_WINDOW", cfg.Auth.LoginWindow)
+	}
+	if cfg.Auth.LoginFailureLimit > 0 {
+		c.positive("LOCKOUT_BASE", cfg.Auth.LockoutBase)
+		c.check(cfg.Auth.LockoutMax >= cfg.Auth.LockoutBase, "LOCKOUT_MAX", "must not be shorter than LOCKOUT_BASE (%s)", cfg.Auth.LockoutBase)
+	}
+
+	if cfg.OIDC.Issuer != "" {
+		c.url("OIDC_ISSUER", cfg.OIDC.Issuer)
+		c.check(cfg.OIDC.ClientID != "", "OIDC_CLIENT_ID", "is required with OIDC_ISSUER")
+		c.check(cfg.OIDC.ClientSecret != "", "OIDC_CLIENT_SECRET", "is required with OIDC_ISSUER")
+		c.url("OIDC_REDIRECT_URL", cfg.OIDC.RedirectURL)
+		c.url("OIDC_POST_LOGIN_URL", cfg.OIDC.PostLoginURL)
+	}
+
+	c.check(cfg.Identity.StudentIDKey != "", "STUDENT_ID_HASH_KEY", "is required")
+	c.check(!slices.Contains(cfg.Identity.PreviousStudentIDKeys, cfg.Identity.StudentIDKey),
+		"STUDENT_ID_HASH_PREVIOUS_KEYS", "must not include the current STUDENT_ID_HASH_KEY")
+
+	c.oneOf("LOG_LEVEL", strings.ToLower(cfg.Logging.Level), "debug", "info", "warn", "error")
+	c.oneOf("LOG_FORMAT", cfg.Logging.Format, "json", "text")
+	c.oneOf("LOG_OUTPUT", cfg.Logging.Output, "file", "stdout", "both")
+	if cfg.Logging.Output != "stdout" {
+		c.check(cfg.Logging.Filename != "", "LOG_FILENAME", "is required")
+		c.check(cfg.Logging.MaxSize > 0, "LOG_MAX_SIZE", "must be positive")
+		c.notNegative("LOG_MAX_BACKUPS", cfg.Logging.MaxBackups)
+		c.notNegative("LOG_MAX_AGE", cfg.Logging.MaxAge)
+		c.check(cfg.Logging.RotateEvery == 0 || cfg.Logging.RotateEvery >= time.Minute, "LOG_ROTATE_EVERY", "must be 0 or at least 1m, got %s", cfg.Logging.RotateEvery)
+	}
+	for level, n := range cfg.Logging.SampleRates {
+		c.oneOf("LOG_SAMPLE_RATES", strings.ToLower(level), "debug", "info", "warn", "error")
+		c.check(n >= 1, "LOG_SAMPLE_RATES", "%s must be at least 1, got %d", level, n)
+	}
+
+	// S3 refuses parts under 5 MiB, other than the last
+	c.check(cfg.AWS.PartSizeMB >= 5, "AWS_S3_PART_SIZE_MB", "must be at least 5, got %d", cfg.AWS.PartSizeMB)
+	c.check(cfg.AWS.PartConcurrency > 0, "AWS_S3_PART_CONCURRENCY", "must be at least 1")
+	c.notNegative("AWS_S3_PART_RETRIES", cfg.AWS.PartRetries)
+	if cfg.AWS.EndpointURL != "" {
+		c.url("AWS_ENDPOINT_URL", cfg.AWS.EndpointURL)
+	}
+
+	c.oneOf("EMAIL_PROVIDER", cfg.Email.Provider, "ses", "smtp", "log")
+	c.check(cfg.Email.From != "", "EMAIL_FROM", "is required")
+	if cfg.Email.Provider == "smtp" {
+		c.check(cfg.Email.SMTPHost != "", "SMTP_HOST", "is required with EMAIL_PROVIDER=smtp")
+		c.port("SMTP_PORT", cfg.Email.SMTPPort)
+	}
+	c.check(cfg.Email.NotificationTopicARN == "" || cfg.Email.NotificationToken != "",
+		"SES_NOTIFICATION_TOPIC_ARN", "has no effect without SES_NOTIFICATION_TOKEN")
+
+	c.check(cfg.Calendar.SyncInterval >= 0, "CALENDAR_SYNC_INTERVAL", "must not be negative")
+	c.positive("CALENDAR_FETCH_TIMEOUT", cfg.Calendar.FetchTimeout)
+	_, err = time.LoadLocation(cfg.Calendar.Timezone)
+	c.check(err == nil, "CALENDAR_TIMEZONE", "%q is not a known time zone", cfg.Calendar.Timezone)
+
+	c.schedule("RETURN_CAMPAIGN_SCHEDULE", cfg.Campaign.Schedule)
+	c.positive("BOOKING_CONFIRMATION_WINDOW", cfg.Booking.ConfirmationWindow)
+	c.check(cfg.Booking.PickupCutoff >= 0, "BOOKING_PICKUP_CUTOFF", "must not be negative")
+	c.schedule("BOOKING_EXPIRY_SCHEDULE", cfg.Booking.ExpirySchedule)
+	c.check(cfg.SLA.CheckInterval >= 0, "REQUEST_SLA_CHECK_INTERVAL", "must not be negative")
+	c.check(cfg.SLA.EscalateAfter >= 0, "REQUEST_ESCALATE_AFTER", "must not be negative")
+	c.schedule("OVERDUE_CHECK_SCHEDULE", cfg.Overdue.CheckSchedule)
+	c.notNegative("OVERDUE_REMINDER_DAYS_BEFORE", cfg.Overdue.ReminderDaysBefore)
+	c.notNegative("OVERDUE_REMINDER_REPEAT_DAYS", cfg.Overdue.ReminderRepeatDays)
+	c.schedule("INVENTORY_DIGEST_SCHEDULE", cfg.Digest.Schedule)
+	c.notNegative("INVENTORY_DIGEST_LOW_STOCK", cfg.Digest.LowStockThreshold)
+	c.schedule("APPROVER_DIGEST_SCHEDULE", cfg.Digest.ApproverSchedule)
+	c.schedule("AVAILABILITY_CLEANUP_SCHEDULE", cfg.Availability.CleanupSchedule)
+	c.notNegative("AVAILABILITY_RETENTION_DAYS", cfg.Availability.RetentionDays)
+	c.notNegative("FINES_BLOCK_THRESHOLD_CENTS", cfg.Fines.BlockThresholdCents)
+
+	c.notNegative("TASK_MAX_RETRY", cfg.Worker.MaxRetry)
+	for taskType, n := range cfg.Worker.MaxRetries {
+		c.check(n >= 0, "TASK_MAX_RETRIES", "%s must not be negative, got %d", taskType, n)
+	}
+	c.positive("TASK_RETRY_BASE_DELAY", cfg.Worker.RetryBaseDelay)
+	c.check(cfg.Worker.RetryMaxDelay >= cfg.Worker.RetryBaseDelay, "TASK_RETRY_MAX_DELAY", "must not be shorter than TASK_RETRY_BASE_DELAY (%s)", cfg.Worker.RetryBaseDelay)
+
+	c.oneOf("SMS_PROVIDER", cfg.SMS.Provider, "twilio", "sns", "log")
+	if cfg.SMS.Provider == "twilio" {
+		c.check(cfg.SMS.TwilioAccountSID != "" && cfg.SMS.TwilioAuthToken != "" && cfg.SMS.From != "",
+			"SMS_PROVIDER", "twilio needs TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and SMS_FROM")
+	}
+	c.oneOf("PUSH_PROVIDER", cfg.Push.Provider, "fcm", "webpush", "log")
+	switch cfg.Push.Provider {
+	case "fcm":
+		c.check(cfg.Push.FCMProjectID != "" && cfg.Push.FCMCredentialsFile != "",
+			"PUSH_PROVIDER", "fcm needs FCM_PROJECT_ID and FCM_CREDENTIALS_FILE")
+	case "webpush":
+		c.check(cfg.Push.VAPIDPublicKey != "" && cfg.Push.VAPIDPrivateKey != "" && cfg.Push.VAPIDSubject != "",
+			"PUSH_PROVIDER", "webpush needs VAPID_PUBLIC_KEY, VAPID_PRIVATE_KEY and VAPID_SUBJECT")
+	}
+	if cfg.Chat.WebhookURL != "" {
+		c.oneOf("CHAT_PROVIDER", cfg.Chat.Provider, "slack", "discord")
+		c.url("CHAT_WEBHOOK_URL", cfg.Chat.WebhookURL)
+	}
+
+	if cfg.Metrics.WorkerPort != "" {
+		c.port("WORKER_METRICS_PORT", cfg.Metrics.WorkerPort)
+	}
+	if cfg.Labels.ScanURL != "" {
+		c.url("LABEL_SCAN_URL", cfg.Labels.ScanURL)
+	}
+	if cfg.Catalog.CacheEnabled {
+		c.positive("CATALOG_CACHE_TTL", cfg.Catalog.CacheTTL)
+	}
+
+	c.oneOf("STORAGE_BACKEND", cfg.Storage.Backend, "s3", "filesystem")
+	if cfg.Storage.Backend == "filesystem" {
+		c.check(cfg.Storage.FilesystemRoot != "", "STORAGE_FILESYSTEM_ROOT", "is required with STORAGE_BACKEND=filesystem")
+		c.url("STORAGE_FILESYSTEM_URL", cfg.Storage.FilesystemURL)
+		c.check(cfg.Storage.URLSecret != "", "STORAGE_URL_SECRET", "is required with STORAGE_BACKEND=filesystem")
+	}
+	c.schedule("S3_ORPHAN_SWEEP_SCHEDULE", cfg.Storage.OrphanSchedule)
+	c.notNegative("S3_ORPHAN_MIN_AGE_DAYS", cfg.Storage.OrphanMinAgeDays)
+	c.notNegative("S3_ABORT_UPLOADS_AFTER_DAYS", cfg.Storage.AbortUploadsAfterDays)
+	c.notNegative("S3_REPORT_EXPIRY_DAYS", cfg.Storage.ReportExpiryDays)
+
+	if cfg.Secrets.Backend != "" {
+		c.oneOf("SECRETS_BACKEND", cfg.Secrets.Backend, "aws", "vault")
+		c.check(cfg.Secrets.Name != "" || cfg.Secrets.DatabaseName != "",
+			"SECRETS_NAME", "is required with SECRETS_BACKEND unless SECRETS_DATABASE_NAME is set")
+		c.positive("SECRETS_CACHE_TTL", cfg.Secrets.CacheTTL)
+		if cfg.Secrets.Backend == "vault" {
+			c.url("VAULT_ADDR", cfg.Secrets.VaultAddr)
+			c.check(cfg.Secrets.VaultToken != "", "VAULT_TOKEN", "is required with SECRETS_BACKEND=vault")
+		}
+	}
+
+	if cfg.IsProduction() {
+		c.check(cfg.JWT.SigningKey != defaultJWTSigningKey, "JWT_SIGNING_KEY", "must be changed from the default in production")
+		c.check(cfg.Identity.StudentIDKey != defaultStudentIDKey, "STUDENT_ID_HASH_KEY", "must be changed from the default in production")
+		c.check(cfg.Storage.Backend != "filesystem" || cfg.Storage.URLSecret != defaultStorageSecret,
+			"STORAGE_URL_SECRET", "must be changed from the default in production")
+		c.check(cfg.Database.Password != "", "POSTGRES_PASSWORD", "is required in production")
+		c.check(cfg.Email.Provider != "log", "EMAIL_PROVIDER", "log sends nothing; not allowed in production")
+	}
+
+	if len(c.problems) > 0 {
+		return &ValidationError{Problems: c.problems}
+	}
+	return nil
+}
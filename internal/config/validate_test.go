@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("the defaults are valid", func(t *testing.T) {
+		assert.NoError(t, Load().Validate())
+	})
+
+	t.Run("every problem is reported", func(t *testing.T) {
+		t.Setenv("REQUEST_TIMEOUT", "soon")
+		t.Setenv("REDIS_DB", "first")
+		t.Setenv("TASK_MAX_RETRIES", "email:delivery=12,sms:delivery")
+		t.Setenv("EMAIL_PROVIDER", "smtp")
+		t.Setenv("MAX_IN_FLIGHT", "8")
+		t.Setenv("BEST_EFFORT_IN_FLIGHT", "16")
+		t.Setenv("LOCKOUT_MAX", "30s")
+		t.Setenv("INVENTORY_DIGEST_SCHEDULE", "every monday")
+
+		cfg := Load()
+		assert.Equal(t, 30*time.Second, cfg.Server.RequestTimeout, "falls back to the default")
+		assert.Equal(t, 12, cfg.Worker.MaxRetries["email:delivery"])
+
+		var verr *ValidationError
+		require.True(t, errors.As(cfg.Validate(), &verr))
+		assert.Equal(t, []string{
+			`REDIS_DB: "first" is not a valid int`,
+			`REQUEST_TIMEOUT: "soon" is not a duration, e.g. 30s or 15m`,
+			`TASK_MAX_RETRIES: "sms:delivery" is not a key=number pair`,
+			"BEST_EFFORT_IN_FLIGHT: must not exceed MAX_IN_FLIGHT (8)",
+			"LOCKOUT_MAX: must not be shorter than LOCKOUT_BASE (1m0s)",
+			"SMTP_HOST: is required with EMAIL_PROVIDER=smtp",
+			`INVENTORY_DIGEST_SCHEDULE: "every monday" is not a five-field cron spec`,
+		}, verr.Problems)
+	})
+
+	t.Run("production refuses default secrets", func(t *testing.T) {
+		t.Setenv("APP_ENV", "production")
+		t.Setenv("POSTGRES_PASSWORD", "hunter2")
+
+		var verr *ValidationError
+		require.True(t, errors.As(Load().Validate(), &verr))
+		assert.Equal(t, []string{
+			"JWT_SIGNING_KEY: must be changed from the default in production",
+			"STUDENT_ID_HASH_KEY: must be changed from the default in production",
+		}, verr.Problems)
+	})
+
+	t.Run("log shipping settings", func(t *testing.T) {
+		t.Setenv("LOG_OUTPUT", "syslog")
+		t.Setenv("LOG_SAMPLE_RATES", "debug=0,trace=10")
+
+		var verr *ValidationError
+		require.True(t, errors.As(Load().Validate(), &verr))
+		assert.ElementsMatch(t, []string{
+			`LOG_OUTPUT: "syslog" is not one of file, stdout, both`,
+			"LOG_SAMPLE_RATES: debug must be at least 1, got 0",
+			`LOG_SAMPLE_RATES: "trace" is not one of debug, info, warn, error`,
+		}, verr.Problems)
+
+		t.Setenv("LOG_OUTPUT", "stdout")
+		t.Setenv("LOG_SAMPLE_RATES", "debug=100")
+		t.Setenv("LOG_FILENAME", "")
+		assert.NoError(t, Load().Validate(), "no file to write")
+	})
+
+	t.Run("a secret backend needs somewhere to read", func(t *testing.T) {
+		t.Setenv("SECRETS_BACKEND", "vault")
+
+		var verr *ValidationError
+		require.True(t, errors.As(Load().Validate(), &verr))
+		assert.Equal(t, []string{
+			"SECRETS_NAME: is required with SECRETS_BACKEND unless SECRETS_DATABASE_NAME is set",
+			`VAULT_ADDR: "" is not an http(s) URL`,
+			"VAULT_TOKEN: is required with SECRETS_BACKEND=vault",
+		}, verr.Problems)
+	})
+}
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{"512": 512, "64KB": 64000, "8MiB": 8 << 20, "1 GiB": 1 << 30} {
+		got, err := ParseSize(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"", "MiB", "8 parsecs", "-1MB"} {
+		_, err := ParseSize(in)
+		assert.Error(t, err, in)
+	}
+
+	t.Setenv("AWS_S3_PART_SIZE_MB", "16MiB")
+	assert.Equal(t, 16, Load().AWS.PartSizeMB)
+	t.Setenv("AWS_S3_PART_SIZE_MB", "10MB")
+	cfg := Load()
+	assert.Equal(t, 8, cfg.AWS.PartSizeMB, "not whole MiB, so the default")
+	assert.Error(t, cfg.Validate())
+}
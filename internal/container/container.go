@@ -2,31 +2,66 @@ package container
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/USSTM/cv-backend/internal/api"
 	"github.com/USSTM/cv-backend/internal/auth"
 	"github.com/USSTM/cv-backend/internal/aws"
+	"github.com/USSTM/cv-backend/internal/bookingpolicy"
+	"github.com/USSTM/cv-backend/internal/calendar"
+	"github.com/USSTM/cv-backend/internal/campaigns"
+	"github.com/USSTM/cv-backend/internal/catalog"
+	"github.com/USSTM/cv-backend/internal/chat"
+	"github.com/USSTM/cv-backend/internal/conditionphotos"
 	"github.com/USSTM/cv-backend/internal/config"
 	"github.com/USSTM/cv-backend/internal/database"
+	"github.com/USSTM/cv-backend/internal/digest"
+	"github.com/USSTM/cv-backend/internal/events"
+	"github.com/USSTM/cv-backend/internal/fines"
+	"github.com/USSTM/cv-backend/internal/housekeeping"
+	"github.com/USSTM/cv-backend/internal/identity"
 	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/metrics"
+	"github.com/USSTM/cv-backend/internal/middleware"
 	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/orphans"
+	"github.com/USSTM/cv-backend/internal/overdue"
+	"github.com/USSTM/cv-backend/internal/push"
 	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/recyclebin"
+	"github.com/USSTM/cv-backend/internal/reports"
+	"github.com/USSTM/cv-backend/internal/sla"
+	"github.com/USSTM/cv-backend/internal/sms"
+	"github.com/USSTM/cv-backend/internal/storage"
+	"github.com/USSTM/cv-backend/internal/waitlist"
+	"github.com/USSTM/cv-backend/internal/webhooks"
+	"github.com/USSTM/cv-backend/templates"
 	"github.com/redis/go-redis/v9"
 )
 
 type Container struct {
+	// the configuration the container was built from; Live has it with
+	// whatever reloads have changed since
 	Config        *config.Config
+	Live          *config.Live
 	Database      *database.Database
 	Queue         *queue.TaskQueue
 	RedisClient   *redis.Client
 	AuthService   *auth.AuthService
-	EmailService  *aws.EmailService
-	S3Service     *aws.S3Service
+	EmailSender   notifications.EmailSender
+	Storage       storage.Storage
 	Authenticator *auth.Authenticator
 	Dispatcher    *notifications.NotificationDispatcher
+	Events        *events.Broker
+	LoadShedder   *middleware.LoadShedder
 	Server        *api.Server
 	Worker        *queue.Worker
+
+	// changed by Reload along with the services above
+	catalogCache *catalog.Cache
+	chat         *notifications.Chat
 }
 
 func New(cfg config.Config) (*Container, error) {
@@ -34,15 +69,26 @@ func New(cfg config.Config) (*Container, error) {
 	if err != nil {
 		return nil, err
 	}
+	if cfg.Database.MigrateOnStart {
+		applied, err := db.Migrate(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		logging.Info("Database migrated", "applied", applied)
+	}
 
-	taskQueue, err := queue.NewQueue(&cfg.Redis)
+	taskQueue, err := queue.NewQueue(&cfg.Redis, queue.NewRetryPolicy(&cfg.Worker))
 	if err != nil {
 		return nil, err
 	}
 
+	metrics.RegisterPool(db.Pool())
+	metrics.RegisterQueues(taskQueue)
+
 	// Two separate Redis connection pools are used: the asynq task
 	// queue manages its own connection, and this client is used
-	// for auth state (OTP hashes, refresh tokens).
+	// for auth state (OTP hashes, refresh tokens), dashboard events and the
+	// catalogue cache.
 	redisClient := redis.NewClient(&redis.Options{
 		Addr:     cfg.Redis.Addr,
 		Password: cfg.Redis.Password,
@@ -56,44 +102,118 @@ func New(cfg config.Config) (*Container, error) {
 
 	authService := auth.NewAuthService(redisClient, jwtService, db.Queries(), cfg.Auth)
 
-	authenticator := auth.NewAuthenticator(jwtService, db.Queries())
+	authenticator := auth.NewAuthenticator(redisClient, jwtService, db.Queries(), cfg.Auth)
+
+	// left a nil interface rather than a nil *OIDCProvider so the handlers
+	// can tell single sign-on is off
+	var oidcService api.OIDCService
+	if cfg.OIDC.Issuer != "" {
+		oidcService = auth.NewOIDCProvider(redisClient, cfg.OIDC)
+	}
 
-	sesService, err := aws.NewEmailService(cfg.AWS)
+	emailSender, err := notifications.NewEmailSender(cfg.Email, cfg.AWS)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to set up email provider: %w", err)
 	}
 
 	// localstack-specific config (email identity not managed by app in prod)
-	if cfg.AWS.EndpointURL != "" {
-		if _, err := sesService.VerifyEmailIdentity(context.Background()); err != nil {
+	if ses, ok := emailSender.(*notifications.SESSender); ok && cfg.AWS.EndpointURL != "" {
+		if _, err := ses.VerifyEmailIdentity(context.Background()); err != nil {
 			logging.Error("Failed to verify email identity", "error", err)
 		}
 	}
 
-	s3Service, err := aws.NewS3Service(cfg.AWS)
+	objectStore, err := storage.New(cfg.Storage, cfg.AWS)
 	if err != nil {
 		return nil, err
 	}
 
-	// localstack-specific config (buckets are not managed by app in prod)
-	if cfg.AWS.EndpointURL != "" {
-		if err := s3Service.CreateBucket(context.Background()); err != nil {
-			logging.Info("S3 bucket creation attempted", "bucket", cfg.AWS.Bucket, "result", err)
+	if s3Store, ok := objectStore.(*storage.S3); ok {
+		// localstack-specific config (buckets are not managed by app in prod)
+		if cfg.AWS.EndpointURL != "" {
+			if err := s3Store.CreateBucket(context.Background()); err != nil {
+				logging.Info("S3 bucket creation attempted", "bucket", cfg.AWS.Bucket, "result", err)
+			}
+		}
+
+		// the rules replace the bucket's whole lifecycle, so every instance
+		// putting them on start is harmless
+		if err := s3Store.ApplyLifecycle(context.Background(), aws.LifecycleRules{
+			AbortUploadsAfterDays: cfg.Storage.AbortUploadsAfterDays,
+			ExpireAfterDays:       map[string]int{reports.ObjectPrefix: cfg.Storage.ReportExpiryDays},
+		}); err != nil {
+			logging.Error("Failed to apply S3 bucket lifecycle", "bucket", cfg.AWS.Bucket, "error", err)
 		}
 	}
 
-	worker := queue.NewWorker(&cfg.Redis, sesService)
+	calendarLocation, err := time.LoadLocation(cfg.Calendar.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar timezone %q: %w", cfg.Calendar.Timezone, err)
+	}
 
 	notiService := notifications.NewNotificationService(db.Pool(), db.Queries())
 
-	emailTemplates, err := notifications.LoadTemplates("templates/email")
+	emailTemplates, err := notifications.LoadTemplates(templates.Email())
 	if err != nil {
 		return nil, fmt.Errorf("failed to load email templates: %w", err)
 	}
 
-	dispatcher := notifications.NewNotificationDispatcher(notiService, taskQueue, emailTemplates, notifications.NewEmailLookupFunc(db.Queries()))
+	chatMessages, err := notifications.NewChat(cfg.Chat, templates.Chat())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up chat messages: %w", err)
+	}
+
+	dispatcher := notifications.NewNotificationDispatcher(notiService, taskQueue, emailTemplates, notifications.NewEmailLookupFunc(db.Queries()), notifications.NewRuleLookupFunc(db.Queries()), notifications.NewSandboxLookupFunc(db.Queries()), chatMessages)
+
+	reportGenerator := reports.NewGenerator(db.Queries(), objectStore, dispatcher)
 
-	server := api.NewServer(db, taskQueue, authService, authenticator, sesService, s3Service, dispatcher)
+	smsSender, err := sms.New(cfg.SMS, cfg.AWS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up SMS provider: %w", err)
+	}
+
+	pushSender, err := push.New(cfg.Push)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up push provider: %w", err)
+	}
+
+	chatSender, err := chat.New(cfg.Chat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up chat provider: %w", err)
+	}
+
+	// addresses that bounced or complained are dropped rather than sent to
+	worker := queue.NewWorker(&cfg.Redis, notifications.NewSuppressingSender(emailSender, db.Queries()), smsSender, pushSender, chatSender,
+		recyclebin.NewPurger(db.Pool(), db.Queries(), objectStore),
+		calendar.NewSyncer(db.Queries(), cfg.Calendar.FetchTimeout, calendarLocation),
+		reportGenerator,
+		campaigns.NewRunner(db.Queries(), dispatcher, reportGenerator),
+		sla.NewChecker(db.Queries(), dispatcher, cfg.SLA.EscalateAfter),
+		waitlist.NewNotifier(db.Queries(), dispatcher),
+		overdue.NewChecker(db.Queries(), dispatcher, webhooks.NewPublisher(db.Queries(), taskQueue), overdue.Schedule{
+			DaysBefore: cfg.Overdue.ReminderDaysBefore,
+			RepeatDays: cfg.Overdue.ReminderRepeatDays,
+		}),
+		housekeeping.NewHousekeeper(db.Pool(), db.Queries(), dispatcher, cfg.Booking.ConfirmationWindow, cfg.Availability.RetentionDays),
+		digest.NewSender(db.Queries(), dispatcher, cfg.Digest.LowStockThreshold),
+		conditionphotos.NewProcessor(db.Queries(), objectStore),
+		webhooks.NewDeliverer(db.Queries()),
+		orphans.NewSweeper(db.Queries(), objectStore, cfg.Storage.OrphanMinAgeDays, cfg.Storage.OrphanDryRun),
+		queue.NewSchedule(&cfg, calendarLocation),
+		queue.NewRetryPolicy(&cfg.Worker))
+
+	loadShedder := middleware.NewLoadShedder(&cfg.Server)
+
+	broker := events.NewBroker(redisClient)
+
+	// always built, since a reload can turn it on
+	catalogCache := catalog.NewCache(redisClient, cfg.Catalog.CacheTTL)
+	catalogCache.SetEnabled(cfg.Catalog.CacheEnabled)
+
+	live := config.NewLive(&cfg)
+
+	server := api.NewServer(db, taskQueue, authService, authenticator, emailSender, objectStore, dispatcher, loadShedder,
+		bookingpolicy.NewResolver(cfg.Booking), identity.NewHasher(cfg.Identity), broker, fines.NewPolicy(cfg.Fines), oidcService, calendarLocation, cfg.Labels.ScanURL, catalogCache, live)
 
 	logging.Info("Connected to database",
 		"host", cfg.Database.Host,
@@ -101,19 +221,65 @@ func New(cfg config.Config) (*Container, error) {
 
 	return &Container{
 		Config:        &cfg,
+		Live:          live,
 		Database:      db,
 		Queue:         taskQueue,
 		RedisClient:   redisClient,
 		AuthService:   authService,
-		EmailService:  sesService,
-		S3Service:     s3Service,
+		EmailSender:   emailSender,
+		Storage:       objectStore,
 		Authenticator: authenticator,
 		Dispatcher:    dispatcher,
+		Events:        broker,
+		LoadShedder:   loadShedder,
 		Server:        server,
 		Worker:        worker,
+		catalogCache:  catalogCache,
+		chat:          chatMessages,
 	}, nil
 }
 
+// re-reads CONFIG_RELOAD_FILE and applies the settings that can change
+// without a restart (see config.Config.Reload). An invalid file changes
+// nothing; the error says why.
+func (c *Container) Reload() error {
+	active := c.Live.Current()
+	if active.ReloadFile == "" {
+		return errors.New("CONFIG_RELOAD_FILE is not set")
+	}
+	next, err := config.LoadFile(active.ReloadFile)
+	if err != nil {
+		return err
+	}
+	if err := next.Validate(); err != nil {
+		return err
+	}
+	reloaded := active.Reload(next)
+
+	// first, as the only one that can fail
+	if err := c.chat.SetEvents(reloaded.Chat.Events); err != nil {
+		return fmt.Errorf("CHAT_EVENTS: %w", err)
+	}
+	logging.SetLevel(reloaded.Logging.Level)
+	c.Authenticator.SetAPIKeyRateLimit(reloaded.Auth.APIKeyRateLimit)
+	c.AuthService.SetLoginLimits(reloaded.Auth.LoginIPLimit, reloaded.Auth.LoginFailureLimit)
+	c.LoadShedder.SetLimits(reloaded.Server.MaxInFlight, reloaded.Server.BestEffortInFlight)
+	c.catalogCache.SetEnabled(reloaded.Catalog.CacheEnabled)
+	c.Live.Store(reloaded)
+
+	logging.Info("Configuration reloaded",
+		"file", active.ReloadFile,
+		"log_level", reloaded.Logging.Level,
+		"api_key_rate_limit", reloaded.Auth.APIKeyRateLimit,
+		"login_ip_limit", reloaded.Auth.LoginIPLimit,
+		"login_failure_limit", reloaded.Auth.LoginFailureLimit,
+		"max_in_flight", reloaded.Server.MaxInFlight,
+		"best_effort_in_flight", reloaded.Server.BestEffortInFlight,
+		"catalog_cache_enabled", reloaded.Catalog.CacheEnabled,
+		"chat_events", reloaded.Chat.Events)
+	return nil
+}
+
 func (c *Container) Cleanup() {
 	if c.Queue != nil {
 		c.Queue.Close()
@@ -123,6 +289,10 @@ func (c *Container) Cleanup() {
 		c.Worker.Close()
 		logging.Info("Worker closed")
 	}
+	if c.Events != nil {
+		c.Events.Close()
+		logging.Info("Event broker closed")
+	}
 	if c.RedisClient != nil {
 		c.RedisClient.Close()
 		logging.Info("Redis client closed")
@@ -0,0 +1,36 @@
+package damage
+
+import "github.com/USSTM/cv-backend/generated/db"
+
+// conditions from best to worst.
+var conditionRank = map[db.Condition]int{
+	db.ConditionPristine: 0,
+	db.ConditionGood:     1,
+	db.ConditionDecent:   2,
+	db.ConditionDamaged:  3,
+	db.ConditionUnusable: 4,
+}
+
+func ValidCondition(c db.Condition) bool {
+	_, ok := conditionRank[c]
+	return ok
+}
+
+// whether an item came back in worse condition than it went out in.
+func Worse(before, after db.Condition) bool {
+	return conditionRank[after] > conditionRank[before]
+}
+
+// the severity a damage report gets when the returner doesn't give one: an
+// unusable item is severe, a drop of two or more conditions moderate, and
+// anything else minor.
+func SeverityFor(before, after db.Condition) db.DamageSeverity {
+	switch {
+	case after == db.ConditionUnusable:
+		return db.DamageSeveritySevere
+	case conditionRank[after]-conditionRank[before] >= 2:
+		return db.DamageSeverityModerate
+	default:
+		return db.DamageSeverityMinor
+	}
+}
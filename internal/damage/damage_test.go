@@ -0,0 +1,35 @@
+package damage_test
+
+import (
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/damage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorse(t *testing.T) {
+	assert.True(t, damage.Worse(db.ConditionGood, db.ConditionDecent))
+	assert.True(t, damage.Worse(db.ConditionPristine, db.ConditionUnusable))
+	assert.False(t, damage.Worse(db.ConditionGood, db.ConditionGood))
+	assert.False(t, damage.Worse(db.ConditionDamaged, db.ConditionGood))
+}
+
+func TestSeverityFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		before db.Condition
+		after  db.Condition
+		want   db.DamageSeverity
+	}{
+		{"one step worse", db.ConditionGood, db.ConditionDecent, db.DamageSeverityMinor},
+		{"two steps worse", db.ConditionGood, db.ConditionDamaged, db.DamageSeverityModerate},
+		{"unusable", db.ConditionDamaged, db.ConditionUnusable, db.DamageSeveritySevere},
+		{"pristine to unusable", db.ConditionPristine, db.ConditionUnusable, db.DamageSeveritySevere},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, damage.SeverityFor(tt.before, tt.after))
+		})
+	}
+}
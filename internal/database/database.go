@@ -3,9 +3,11 @@ package database
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/USSTM/cv-backend/generated/db"
 	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -15,7 +17,34 @@ type Database struct {
 }
 
 func New(cfg *config.DatabaseConfig) (*Database, error) {
-	pool, err := pgxpool.New(context.Background(), cfg.ConnectionString())
+	poolCfg, err := pgxpool.ParseConfig(cfg.ConnectionString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database config: %w", err)
+	}
+
+	// credentials are looked up for each new connection so a rotated
+	// database secret is picked up without a restart. An attempt that never
+	// reached AfterConnect was most likely refused its credentials, so the
+	// one after it reads the secret again.
+	var connecting atomic.Bool
+	poolCfg.BeforeConnect = func(ctx context.Context, conn *pgx.ConnConfig) error {
+		if connecting.Swap(true) {
+			config.ExpireDatabaseCredentials()
+		}
+		user, password, err := cfg.Credentials(ctx)
+		if err != nil {
+			return err
+		}
+		conn.User = user
+		conn.Password = password
+		return nil
+	}
+	poolCfg.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		connecting.Store(false)
+		return nil
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
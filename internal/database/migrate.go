@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/USSTM/cv-backend/db/migrations"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// a goose provider over the migrations embedded in the binary. It holds a
+// Postgres advisory lock while it works, so replicas started together take
+// turns rather than race to apply the same migration.
+func NewMigrator(sqlDB *sql.DB) (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration lock: %w", err)
+	}
+	provider, err := goose.NewProvider(goose.DialectPostgres, sqlDB, migrations.FS(), goose.WithSessionLocker(locker))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return provider, nil
+}
+
+// applies every pending migration, returning how many there were
+func Migrate(ctx context.Context, sqlDB *sql.DB) (int, error) {
+	provider, err := NewMigrator(sqlDB)
+	if err != nil {
+		return 0, err
+	}
+	results, err := provider.Up(ctx)
+	if err != nil {
+		return len(results), fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return len(results), nil
+}
+
+// rolls back every migration, then applies them all again
+func ResetMigrations(ctx context.Context, sqlDB *sql.DB) error {
+	provider, err := NewMigrator(sqlDB)
+	if err != nil {
+		return err
+	}
+	if _, err := provider.DownTo(ctx, 0); err != nil {
+		return fmt.Errorf("failed to reset migrations: %w", err)
+	}
+	if _, err := provider.Up(ctx); err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return nil
+}
+
+// Migrate over the pool, so the connection uses the same, possibly rotated,
+// credentials as everything else
+func (d *Database) Migrate(ctx context.Context) (int, error) {
+	sqlDB := stdlib.OpenDBFromPool(d.pool)
+	defer sqlDB.Close()
+	return Migrate(ctx, sqlDB)
+}
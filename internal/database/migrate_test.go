@@ -0,0 +1,35 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMigrator_EmbedsEveryMigration(t *testing.T) {
+	// not connected until used
+	sqlDB, err := sql.Open("pgx", "postgres://localhost:1/none")
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	provider, err := NewMigrator(sqlDB)
+	require.NoError(t, err)
+
+	onDisk, err := filepath.Glob("../../db/migrations/*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, onDisk)
+
+	var embedded []string
+	for _, source := range provider.ListSources() {
+		embedded = append(embedded, filepath.Base(source.Path))
+	}
+	var names []string
+	for _, path := range onDisk {
+		names = append(names, filepath.Base(path))
+	}
+	assert.Equal(t, names, embedded)
+}
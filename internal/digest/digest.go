@@ -0,0 +1,191 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/rbac"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// how far back "this week" reaches in the digest
+const Period = 7 * 24 * time.Hour
+
+// how many entries of each kind the approver digest lists; the rest are
+// only counted
+const MaxListed = 10
+
+type notifier interface {
+	Notify(ctx context.Context, actorID uuid.UUID, entityType string, entityID uuid.UUID, groups []notifications.NotifierGroup) error
+}
+
+// sends the weekly inventory summary to everyone who manages the catalogue.
+type Sender struct {
+	db       *db.Queries
+	notifier notifier
+	lowStock int
+}
+
+func NewSender(queries *db.Queries, notifier notifier, lowStock int) *Sender {
+	return &Sender{db: queries, notifier: notifier, lowStock: lowStock}
+}
+
+// returns how many users the digest went to; 0 when nobody holds
+// manage_items globally.
+func (s *Sender) SendInventoryDigest(ctx context.Context) (int, error) {
+	holders, err := s.db.GetGlobalPermissionHolderIDs(ctx, rbac.ManageItems)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get catalogue managers: %w", err)
+	}
+	var ids []uuid.UUID
+	for _, id := range holders {
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	since := time.Now().Add(-Period)
+	counts, err := s.db.GetInventoryDigest(ctx, pgtype.Timestamp{Time: since, Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get inventory counts: %w", err)
+	}
+	low, err := s.db.ListLowStockItems(ctx, int32(s.lowStock))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list low stock items: %w", err)
+	}
+
+	lowStock := make([]map[string]interface{}, 0, len(low))
+	for _, item := range low {
+		lowStock = append(lowStock, map[string]interface{}{
+			"Name":  item.Name,
+			"Stock": item.Stock,
+		})
+	}
+
+	if err := s.notifier.Notify(ctx, ids[0], "system", uuid.Nil, []notifications.NotifierGroup{
+		{
+			IDs:      ids,
+			Template: "inventory_digest",
+			TemplateData: map[string]interface{}{
+				"WeekOf":            since.Format("2006-01-02"),
+				"Items":             counts.Items,
+				"OutOfStock":        counts.OutOfStock,
+				"ActiveBorrowings":  counts.ActiveBorrowings,
+				"OverdueBorrowings": counts.OverdueBorrowings,
+				"BorrowedThisWeek":  counts.BorrowedSince,
+				"ReturnedThisWeek":  counts.ReturnedSince,
+				"PendingRequests":   counts.PendingRequests,
+				"OpenDamageReports": counts.OpenDamageReports,
+				"LowStock":          lowStock,
+				"LowStockThreshold": s.lowStock,
+			},
+		},
+	}); err != nil {
+		return 0, fmt.Errorf("failed to send inventory digest: %w", err)
+	}
+	return len(ids), nil
+}
+
+// sends each user who opted in to the approver digest one email listing the
+// pending requests routed to them, the bookings awaiting confirmation in groups
+// whose bookings they manage and the overdue borrowings in groups whose data
+// they can see. Users with nothing waiting get no email. Returns how many
+// digests were sent; a digest that fails to send is skipped.
+func (s *Sender) SendApproverDigest(ctx context.Context) (int, error) {
+	recipients, err := s.db.ListApproverDigestRecipients(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list approver digest recipients: %w", err)
+	}
+
+	sent := 0
+	for _, userID := range recipients {
+		data, err := s.approverDigest(ctx, userID)
+		if err != nil {
+			return sent, err
+		}
+		if data == nil {
+			continue
+		}
+		if err := s.notifier.Notify(ctx, userID, "system", uuid.Nil, []notifications.NotifierGroup{
+			{
+				IDs:          []uuid.UUID{userID},
+				Template:     "approver_digest",
+				TemplateData: data,
+			},
+		}); err != nil {
+			logging.FromContext(ctx).Error("failed to send approver digest", "user_id", userID, "error", err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// the template data for userID's digest; nil when nothing is waiting on them
+func (s *Sender) approverDigest(ctx context.Context, userID uuid.UUID) (map[string]interface{}, error) {
+	requests, err := s.db.ListDigestPendingRequests(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending requests for %s: %w", userID, err)
+	}
+	bookings, err := s.db.ListDigestPendingConfirmations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bookings awaiting confirmation for %s: %w", userID, err)
+	}
+	overdue, err := s.db.ListDigestOverdueBorrowings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdue borrowings for %s: %w", userID, err)
+	}
+	if len(requests) == 0 && len(bookings) == 0 && len(overdue) == 0 {
+		return nil, nil
+	}
+
+	listedRequests := make([]map[string]interface{}, 0, min(len(requests), MaxListed))
+	for _, r := range requests[:min(len(requests), MaxListed)] {
+		listedRequests = append(listedRequests, map[string]interface{}{
+			"ItemName":    r.ItemName,
+			"Quantity":    r.Quantity,
+			"Requester":   r.RequesterEmail.String,
+			"GroupName":   r.GroupName.String,
+			"RequestedAt": r.RequestedAt.Time.Format("2006-01-02"),
+		})
+	}
+	listedBookings := make([]map[string]interface{}, 0, min(len(bookings), MaxListed))
+	for _, b := range bookings[:min(len(bookings), MaxListed)] {
+		listedBookings = append(listedBookings, map[string]interface{}{
+			"ItemName":   b.ItemName,
+			"Requester":  b.RequesterEmail.String,
+			"GroupName":  b.GroupName.String,
+			"PickUpDate": b.PickUpDate.Time.Format("2006-01-02 15:04"),
+		})
+	}
+	listedOverdue := make([]map[string]interface{}, 0, min(len(overdue), MaxListed))
+	for _, b := range overdue[:min(len(overdue), MaxListed)] {
+		listedOverdue = append(listedOverdue, map[string]interface{}{
+			"ItemName":  b.ItemName,
+			"Quantity":  b.Quantity,
+			"Borrower":  b.UserEmail.String,
+			"GroupName": b.GroupName.String,
+			"DueDate":   b.DueDate.Time.Format("2006-01-02"),
+		})
+	}
+
+	return map[string]interface{}{
+		"PendingRequests":      len(requests),
+		"PendingConfirmations": len(bookings),
+		"OverdueBorrowings":    len(overdue),
+		"Requests":             listedRequests,
+		"Bookings":             listedBookings,
+		"Overdue":              listedOverdue,
+		"MoreRequests":         len(requests) - len(listedRequests),
+		"MoreBookings":         len(bookings) - len(listedBookings),
+		"MoreOverdue":          len(overdue) - len(listedOverdue),
+	}, nil
+}
@@ -0,0 +1,176 @@
+package digest_test
+
+import (
+	"context"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/digest"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sharedDB *testutil.TestDatabase
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	t := &testing.T{}
+	sharedDB = testutil.NewTestDatabase(t, "cv-backend-test-db-digest")
+	sharedDB.RunMigrations(t)
+
+	code := m.Run()
+
+	if sharedDB.Pool() != nil {
+		sharedDB.Pool().Close()
+	}
+
+	os.Exit(code)
+}
+
+type fakeNotifier struct {
+	groups []notifications.NotifierGroup
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, actorID uuid.UUID, entityType string, entityID uuid.UUID, groups []notifications.NotifierGroup) error {
+	f.groups = append(f.groups, groups...)
+	return nil
+}
+
+func TestSender_SendApproverDigest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	ctx := context.Background()
+	q := sharedDB.Queries()
+
+	group := sharedDB.NewGroup(t).WithName("Film Society").Create()
+	member := sharedDB.NewUser(t).WithEmail("member@digest.test").AsMember().Create()
+	approver := sharedDB.NewUser(t).WithEmail("approver@digest.test").AsApprover().Create()
+	optedOut := sharedDB.NewUser(t).WithEmail("optedout@digest.test").AsApprover().Create()
+	idle := sharedDB.NewUser(t).WithEmail("idle@digest.test").AsMember().Create()
+	item := sharedDB.NewItem(t).WithName("Camera").WithType("high").WithStock(3).Create()
+
+	for _, id := range []uuid.UUID{approver.ID, idle.ID} {
+		require.NoError(t, q.UpsertNotificationPreference(ctx, db.UpsertNotificationPreferenceParams{
+			UserID: id, NotificationType: string(notifications.TypeApproverDigest), EmailEnabled: true,
+		}))
+	}
+
+	// a request routed to both approvers
+	request, err := q.RequestItem(ctx, db.RequestItemParams{UserID: &member.ID, GroupID: &group.ID, ID: item.ID, Quantity: 1})
+	require.NoError(t, err)
+	for _, id := range []uuid.UUID{approver.ID, optedOut.ID} {
+		_, err := q.AddRequestRoute(ctx, db.AddRequestRouteParams{RequestID: request.ID, ApproverID: id, Reason: db.RequestRouteReasonGlobal})
+		require.NoError(t, err)
+	}
+
+	// and a booking the member has yet to confirm
+	slots, err := q.ListTimeSlots(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, slots)
+	pickup := time.Now().AddDate(0, 0, 7)
+	availability, err := q.CreateAvailability(ctx, db.CreateAvailabilityParams{
+		ID:         uuid.New(),
+		UserID:     &approver.ID,
+		TimeSlotID: &slots[0].ID,
+		Date:       pgtype.Date{Time: pickup, Valid: true},
+	})
+	require.NoError(t, err)
+	_, err = q.CreateBooking(ctx, db.CreateBookingParams{
+		ID:             uuid.New(),
+		RequesterID:    &member.ID,
+		ManagerID:      &approver.ID,
+		ItemID:         &item.ID,
+		GroupID:        &group.ID,
+		AvailabilityID: &availability.ID,
+		PickUpDate:     pgtype.Timestamp{Time: pickup, Valid: true},
+		PickUpLocation: "Main Office",
+		ReturnDate:     pgtype.Timestamp{Time: pickup.Add(24 * time.Hour), Valid: true},
+		ReturnLocation: "Main Office",
+		Status:         db.RequestStatusPendingConfirmation,
+	})
+	require.NoError(t, err)
+
+	notifier := &fakeNotifier{}
+	sent, err := digest.NewSender(q, notifier, 2).SendApproverDigest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent, "only users who opted in and have something waiting get a digest")
+
+	require.Len(t, notifier.groups, 1)
+	group0 := notifier.groups[0]
+	assert.Equal(t, "approver_digest", group0.Template)
+	assert.Equal(t, []uuid.UUID{approver.ID}, group0.IDs)
+	assert.Equal(t, 1, group0.TemplateData["PendingRequests"])
+	assert.Equal(t, 1, group0.TemplateData["PendingConfirmations"])
+	assert.Equal(t, 0, group0.TemplateData["OverdueBorrowings"])
+
+	requests := group0.TemplateData["Requests"].([]map[string]interface{})
+	require.Len(t, requests, 1)
+	assert.Equal(t, "Camera", requests[0]["ItemName"])
+	assert.Equal(t, "member@digest.test", requests[0]["Requester"])
+	assert.Equal(t, "Film Society", requests[0]["GroupName"])
+}
+
+func TestSender_SendInventoryDigest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	ctx := context.Background()
+	q := sharedDB.Queries()
+
+	group := sharedDB.NewGroup(t).WithName("Film Society").Create()
+	admin := sharedDB.NewUser(t).WithEmail("admin@digest.test").AsGlobalAdmin().Create()
+	member := sharedDB.NewUser(t).WithEmail("member@digest.test").AsMember().Create()
+	camera := sharedDB.NewItem(t).WithName("Camera").WithType("high").WithStock(3).Create()
+	sharedDB.NewItem(t).WithName("Tripod").WithType("medium").WithStock(0).Create()
+
+	// an overdue borrowing and a request still waiting for review
+	_, err := q.BorrowItem(ctx, db.BorrowItemParams{
+		UserID:          &member.ID,
+		GroupID:         &group.ID,
+		ID:              camera.ID,
+		Quantity:        1,
+		DueDate:         pgtype.Timestamp{Time: time.Now().Add(-24 * time.Hour), Valid: true},
+		BeforeCondition: db.ConditionGood,
+	})
+	require.NoError(t, err)
+	_, err = q.RequestItem(ctx, db.RequestItemParams{UserID: &member.ID, GroupID: &group.ID, ID: camera.ID, Quantity: 1})
+	require.NoError(t, err)
+
+	notifier := &fakeNotifier{}
+	sent, err := digest.NewSender(q, notifier, 2).SendInventoryDigest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	require.Len(t, notifier.groups, 1)
+	group0 := notifier.groups[0]
+	assert.Equal(t, "inventory_digest", group0.Template)
+	assert.Equal(t, []uuid.UUID{admin.ID}, group0.IDs)
+	assert.Equal(t, int64(2), group0.TemplateData["Items"])
+	assert.Equal(t, int64(1), group0.TemplateData["OutOfStock"])
+	assert.Equal(t, int64(1), group0.TemplateData["ActiveBorrowings"])
+	assert.Equal(t, int64(1), group0.TemplateData["OverdueBorrowings"])
+	assert.Equal(t, int64(1), group0.TemplateData["BorrowedThisWeek"])
+	assert.Equal(t, int64(0), group0.TemplateData["ReturnedThisWeek"])
+	assert.Equal(t, int64(1), group0.TemplateData["PendingRequests"])
+	assert.Equal(t, int64(0), group0.TemplateData["OpenDamageReports"])
+
+	lowStock := group0.TemplateData["LowStock"].([]map[string]interface{})
+	require.Len(t, lowStock, 1)
+	assert.Equal(t, "Tripod", lowStock[0]["Name"])
+}
@@ -0,0 +1,177 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis pub/sub channel every API replica publishes to and listens on.
+const Channel = "cv:events"
+
+type Type string
+
+const (
+	RequestPending   Type = "request.pending"
+	RequestApproved  Type = "request.approved"
+	RequestDenied    Type = "request.denied"
+	RequestCancelled Type = "request.cancelled"
+	BookingConfirmed Type = "booking.confirmed"
+	BookingCancelled Type = "booking.cancelled"
+	ItemReturned     Type = "item.returned"
+	// an item's stock went up or down; EntityID and ItemID are the item
+	StockChanged Type = "item.stock_changed"
+	// raised by the worker's overdue check, so only sent to webhooks
+	ItemOverdue Type = "item.overdue"
+)
+
+// the types streamed to dashboards and /me/events
+var Types = []Type{RequestPending, RequestApproved, RequestDenied, RequestCancelled, BookingConfirmed, BookingCancelled, ItemReturned, StockChanged}
+
+// a domain change worth refreshing a dashboard for. It carries IDs only;
+// dashboards fetch the details through the normal endpoints.
+type Event struct {
+	Type     Type       `json:"type"`
+	EntityID uuid.UUID  `json:"entity_id"`
+	GroupID  *uuid.UUID `json:"group_id,omitempty"`
+	ItemID   *uuid.UUID `json:"item_id,omitempty"`
+	// the user the change concerns, such as a request's requester; set for
+	// the events sent to that user's /me/events stream
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+	OccurredAt time.Time  `json:"occurred_at"`
+}
+
+// returned by Subscribe once the broker's Redis subscription has ended.
+var ErrStopped = errors.New("event broker has stopped")
+
+// events buffered per subscriber; a subscriber further behind than this
+// misses events rather than holding up the others.
+const subscriberBuffer = 64
+
+// relays events between replicas over Redis pub/sub. Start holds one Redis
+// subscription per replica and fans its messages out to local subscribers.
+type Broker struct {
+	client *redis.Client
+
+	mu      sync.Mutex
+	subs    map[chan Event]struct{}
+	pubsub  *redis.PubSub
+	stopped bool
+}
+
+func NewBroker(client *redis.Client) *Broker {
+	return &Broker{client: client, subs: make(map[chan Event]struct{})}
+}
+
+// sends e to every replica, this one included.
+func (b *Broker) Publish(ctx context.Context, e Event) error {
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if err := b.client.Publish(ctx, Channel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
+
+// subscribes to Channel and starts relaying to local subscribers. go-redis
+// reconnects a dropped subscription on its own.
+func (b *Broker) Start(ctx context.Context) error {
+	pubsub := b.client.Subscribe(ctx, Channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("failed to subscribe to %s: %w", Channel, err)
+	}
+
+	b.mu.Lock()
+	b.pubsub = pubsub
+	b.mu.Unlock()
+
+	go b.relay(pubsub.Channel())
+	return nil
+}
+
+func (b *Broker) relay(messages <-chan *redis.Message) {
+	for msg := range messages {
+		var e Event
+		if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+			logging.Warn("Dropping malformed event", "error", err)
+			continue
+		}
+
+		b.mu.Lock()
+		for sub := range b.subs {
+			select {
+			case sub <- e:
+			default:
+				logging.Warn("Event subscriber is behind, dropping event", "type", e.Type, "entity_id", e.EntityID)
+			}
+		}
+		b.mu.Unlock()
+	}
+
+	// the subscription is gone; end every stream and refuse new ones, since
+	// nothing would ever be delivered to them
+	b.mu.Lock()
+	b.stopped = true
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		close(sub)
+	}
+	b.mu.Unlock()
+}
+
+// returns a channel of every event published from now on and a function
+// that ends the subscription. The channel is closed when either is called or
+// the broker shuts down. Returns ErrStopped once the relay has ended.
+func (b *Broker) Subscribe() (<-chan Event, func(), error) {
+	sub := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.stopped {
+		b.mu.Unlock()
+		return nil, nil, ErrStopped
+	}
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[sub]; ok {
+			delete(b.subs, sub)
+			close(sub)
+		}
+	}, nil
+}
+
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	pubsub := b.pubsub
+	b.mu.Unlock()
+	if pubsub == nil {
+		return nil
+	}
+	return pubsub.Close()
+}
+
+// whether name is one of Types.
+func Valid(name string) bool {
+	for _, t := range Types {
+		if string(t) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package events_test
+
+import (
+	"context"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/events"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sharedQueue *testutil.TestQueue
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		os.Exit(0)
+	}
+
+	t := &testing.T{}
+	sharedQueue = testutil.NewTestQueue(t, "cv-backend-test-redis-events")
+
+	code := m.Run()
+
+	sharedQueue.Close()
+
+	os.Exit(code)
+}
+
+func receive(t *testing.T, ch <-chan events.Event) events.Event {
+	t.Helper()
+	select {
+	case e, ok := <-ch:
+		require.True(t, ok, "subscription closed")
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return events.Event{}
+	}
+}
+
+func TestBroker_RelaysAcrossReplicas(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	// two brokers on one Redis stand in for two API replicas
+	publisher := events.NewBroker(sharedQueue.Redis)
+	require.NoError(t, publisher.Start(ctx))
+	defer publisher.Close()
+	listener := events.NewBroker(sharedQueue.Redis)
+	require.NoError(t, listener.Start(ctx))
+	defer listener.Close()
+
+	local, stopLocal, err := publisher.Subscribe()
+	require.NoError(t, err)
+	defer stopLocal()
+	remote, stopRemote, err := listener.Subscribe()
+	require.NoError(t, err)
+	defer stopRemote()
+
+	groupID := uuid.New()
+	sent := events.Event{Type: events.RequestPending, EntityID: uuid.New(), GroupID: &groupID}
+	require.NoError(t, publisher.Publish(ctx, sent))
+
+	for _, ch := range []<-chan events.Event{local, remote} {
+		got := receive(t, ch)
+		assert.Equal(t, sent.Type, got.Type)
+		assert.Equal(t, sent.EntityID, got.EntityID)
+		assert.Equal(t, &groupID, got.GroupID)
+		assert.False(t, got.OccurredAt.IsZero())
+	}
+}
+
+func TestBroker_Unsubscribe(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	broker := events.NewBroker(sharedQueue.Redis)
+	require.NoError(t, broker.Start(context.Background()))
+	defer broker.Close()
+
+	ch, stop, err := broker.Subscribe()
+	require.NoError(t, err)
+	stop()
+	stop()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestBroker_CloseEndsSubscriptions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	broker := events.NewBroker(sharedQueue.Redis)
+	require.NoError(t, broker.Start(context.Background()))
+
+	ch, stop, err := broker.Subscribe()
+	require.NoError(t, err)
+	defer stop()
+	require.NoError(t, broker.Close())
+
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription still open after Close")
+	}
+
+	// a stopped broker refuses new subscribers instead of handing out a
+	// stream that never delivers
+	_, _, err = broker.Subscribe()
+	assert.ErrorIs(t, err, events.ErrStopped)
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, events.Valid("booking.confirmed"))
+	assert.False(t, events.Valid("booking.deleted"))
+}
@@ -0,0 +1,51 @@
+package fairness
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// window used when an item is put under the policy without one.
+const DefaultWindowDays = 90
+
+// a pending request competing for a policy item.
+type Candidate struct {
+	RequestID   uuid.UUID
+	RequestedAt time.Time
+	// when the requesting group last borrowed the item; nil if it never has
+	LastHadAt *time.Time
+}
+
+// days since the group last had the item, capped at windowDays. Groups that
+// never had it, or not within the window, get the full window.
+func Priority(lastHadAt *time.Time, now time.Time, windowDays int) int {
+	if lastHadAt == nil {
+		return windowDays
+	}
+	days := int(now.Sub(*lastHadAt).Hours() / 24)
+	if days < 0 {
+		return 0
+	}
+	if days > windowDays {
+		return windowDays
+	}
+	return days
+}
+
+// orders candidates by priority, highest first, falling back to first come,
+// first served among equal priorities.
+func Rank(candidates []Candidate, now time.Time, windowDays int) []Candidate {
+	ranked := make([]Candidate, len(candidates))
+	copy(ranked, candidates)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		pi := Priority(ranked[i].LastHadAt, now, windowDays)
+		pj := Priority(ranked[j].LastHadAt, now, windowDays)
+		if pi != pj {
+			return pi > pj
+		}
+		return ranked[i].RequestedAt.Before(ranked[j].RequestedAt)
+	})
+	return ranked
+}
@@ -0,0 +1,42 @@
+package fairness_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/fairness"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriority(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) *time.Time {
+		ts := now.AddDate(0, 0, -d)
+		return &ts
+	}
+
+	assert.Equal(t, 90, fairness.Priority(nil, now, 90))
+	assert.Equal(t, 0, fairness.Priority(daysAgo(0), now, 90))
+	assert.Equal(t, 12, fairness.Priority(daysAgo(12), now, 90))
+	assert.Equal(t, 90, fairness.Priority(daysAgo(400), now, 90))
+}
+
+func TestRank(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, 0, -3)
+	older := now.AddDate(0, 0, -30)
+
+	frequent := fairness.Candidate{RequestID: uuid.New(), RequestedAt: now.Add(-3 * time.Hour), LastHadAt: &recent}
+	occasional := fairness.Candidate{RequestID: uuid.New(), RequestedAt: now.Add(-2 * time.Hour), LastHadAt: &older}
+	newcomer := fairness.Candidate{RequestID: uuid.New(), RequestedAt: now.Add(-1 * time.Hour)}
+	alsoNew := fairness.Candidate{RequestID: uuid.New(), RequestedAt: now}
+
+	ranked := fairness.Rank([]fairness.Candidate{frequent, alsoNew, occasional, newcomer}, now, 90)
+
+	ids := make([]uuid.UUID, len(ranked))
+	for i, c := range ranked {
+		ids[i] = c.RequestID
+	}
+	assert.Equal(t, []uuid.UUID{newcomer.RequestID, alsoNew.RequestID, occasional.RequestID, frequent.RequestID}, ids)
+}
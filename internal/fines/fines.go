@@ -0,0 +1,43 @@
+package fines
+
+import (
+	"math"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/config"
+)
+
+const day = 24 * time.Hour
+
+// when unpaid fines stop a borrower from taking out more items.
+type Policy struct {
+	// unpaid cents above which borrowing is blocked (0 never blocks)
+	BlockThresholdCents int64
+}
+
+func NewPolicy(cfg config.FinesConfig) Policy {
+	return Policy{BlockThresholdCents: int64(cfg.BlockThresholdCents)}
+}
+
+// whether a borrower owing unpaid cents may not borrow or request items.
+func (p Policy) Blocks(unpaid int64) bool {
+	return p.BlockThresholdCents > 0 && unpaid > p.BlockThresholdCents
+}
+
+// whole or part days an item came back after its due date; 0 when on time.
+func DaysLate(due, returned time.Time) int {
+	if !returned.After(due) {
+		return 0
+	}
+	return int((returned.Sub(due) + day - 1) / day)
+}
+
+// the late fee for quantity units returned days late at dailyCents per unit
+// per day, capped to fit the fines table.
+func LateFee(dailyCents, quantity int32, days int) int32 {
+	fee := int64(dailyCents) * int64(quantity) * int64(days)
+	if fee > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int32(fee)
+}
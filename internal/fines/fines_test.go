@@ -0,0 +1,45 @@
+package fines_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/fines"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDaysLate(t *testing.T) {
+	due := time.Date(2026, 3, 2, 17, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		returned time.Time
+		want     int
+	}{
+		{"early", due.Add(-time.Hour), 0},
+		{"on the dot", due, 0},
+		{"a minute late", due.Add(time.Minute), 1},
+		{"exactly a day late", due.Add(24 * time.Hour), 1},
+		{"a day and a bit", due.Add(25 * time.Hour), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, fines.DaysLate(due, tt.returned))
+		})
+	}
+}
+
+func TestLateFee(t *testing.T) {
+	assert.Equal(t, int32(1500), fines.LateFee(250, 2, 3))
+	assert.Equal(t, int32(0), fines.LateFee(250, 2, 0))
+	assert.Equal(t, int32(math.MaxInt32), fines.LateFee(math.MaxInt32, 10, 30))
+}
+
+func TestPolicy_Blocks(t *testing.T) {
+	policy := fines.Policy{BlockThresholdCents: 1000}
+	assert.False(t, policy.Blocks(0))
+	assert.False(t, policy.Blocks(1000), "owing exactly the threshold is allowed")
+	assert.True(t, policy.Blocks(1001))
+
+	assert.False(t, fines.Policy{}.Blocks(1_000_000), "no threshold never blocks")
+}
@@ -0,0 +1,174 @@
+package housekeeping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/bookingevents"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type notifier interface {
+	Notify(ctx context.Context, actorID uuid.UUID, entityType string, entityID uuid.UUID, groups []notifications.NotifierGroup) error
+}
+
+// periodic cleanup the API leaves to the worker: bookings nobody confirmed in
+// time and availability slots that are long past.
+type Housekeeper struct {
+	pool     *pgxpool.Pool
+	db       *db.Queries
+	notifier notifier
+	// confirmation window for groups without their own booking policy
+	defaultWindow time.Duration
+	retentionDays int
+}
+
+func NewHousekeeper(pool *pgxpool.Pool, queries *db.Queries, notifier notifier, defaultWindow time.Duration, retentionDays int) *Housekeeper {
+	return &Housekeeper{
+		pool:          pool,
+		db:            queries,
+		notifier:      notifier,
+		defaultWindow: defaultWindow,
+		retentionDays: retentionDays,
+	}
+}
+
+// moves bookings still pending confirmation past their group's window to
+// expired and returns how many were. An expired booking no longer holds its
+// availability slot, and the request it was approved from goes back to
+// pending so a reviewer can book it again. A booking that fails is logged and
+// skipped; the failures are joined into the returned error.
+func (h *Housekeeper) ExpireBookings(ctx context.Context) (int, error) {
+	ids, err := h.db.GetExpiredBookings(ctx, int32(h.defaultWindow/time.Hour))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired bookings: %w", err)
+	}
+
+	expired := 0
+	var errs []error
+	for _, id := range ids {
+		ok, err := h.expire(ctx, id)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to expire booking", "booking_id", id, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+
+	if len(errs) > 0 {
+		return expired, fmt.Errorf("failed to expire %d of %d bookings: %w", len(errs), len(ids), errors.Join(errs...))
+	}
+	return expired, nil
+}
+
+// false when the booking was confirmed or cancelled since it was listed
+func (h *Housekeeper) expire(ctx context.Context, id uuid.UUID) (bool, error) {
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := h.db.WithTx(tx)
+
+	rows, err := qtx.ExpireBooking(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to expire booking %s: %w", id, err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	payload := map[string]any{"reason": "confirmation_window"}
+	reopened := true
+	request, err := qtx.ReopenRequestForBooking(ctx, &id)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// not booked through a request, e.g. seeded
+		reopened = false
+	case err != nil:
+		return false, fmt.Errorf("failed to reopen request for booking %s: %w", id, err)
+	default:
+		payload["request_id"] = request.ID
+	}
+
+	if err := bookingevents.Record(ctx, qtx, id, nil,
+		db.NullRequestStatus{RequestStatus: db.RequestStatusPendingConfirmation, Valid: true}, db.RequestStatusExpired,
+		payload); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit booking expiry: %w", err)
+	}
+
+	h.notifyExpired(ctx, id, reopened)
+	return true, nil
+}
+
+// tells the requester their booking lapsed and the manager that the slot is
+// free again. Failures are logged; the booking stays expired either way.
+func (h *Housekeeper) notifyExpired(ctx context.Context, id uuid.UUID, reopened bool) {
+	logger := logging.FromContext(ctx)
+
+	booking, err := h.db.GetBookingByID(ctx, id)
+	if err != nil {
+		logger.Error("failed to load expired booking for notifications", "booking_id", id, "error", err)
+		return
+	}
+
+	pickupDate := booking.PickUpDate.Time.Format("2006-01-02")
+	var groups []notifications.NotifierGroup
+	if booking.RequesterID != nil {
+		groups = append(groups, notifications.NotifierGroup{
+			IDs:      []uuid.UUID{*booking.RequesterID},
+			Template: "booking_expired_requester",
+			TemplateData: map[string]interface{}{
+				"ItemName":   booking.ItemName,
+				"PickupDate": pickupDate,
+				"Reopened":   reopened,
+			},
+		})
+	}
+	if booking.ManagerID != nil {
+		groups = append(groups, notifications.NotifierGroup{
+			IDs:      []uuid.UUID{*booking.ManagerID},
+			Template: "booking_expired_approver",
+			TemplateData: map[string]interface{}{
+				"RequesterEmail": booking.RequesterEmail,
+				"ItemName":       booking.ItemName,
+				"PickupDate":     pickupDate,
+				"Reopened":       reopened,
+			},
+		})
+	}
+	if len(groups) == 0 {
+		return
+	}
+
+	// test bookings from sandbox groups still notify in-app, but never email
+	ctx = notifications.WithSandbox(ctx, booking.IsTest)
+	if err := h.notifier.Notify(ctx, uuid.Nil, "booking", id, groups); err != nil {
+		logger.Error("failed to notify about expired booking", "booking_id", id, "error", err)
+	}
+}
+
+// deletes availability slots more than retentionDays past that were never
+// booked. Booked slots stay: bookings cascade with their slot.
+func (h *Housekeeper) CleanupAvailability(ctx context.Context) (int, error) {
+	deleted, err := h.db.DeletePastUnusedAvailability(ctx, int32(h.retentionDays))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete past availability: %w", err)
+	}
+	return int(deleted), nil
+}
@@ -0,0 +1,201 @@
+package housekeeping_test
+
+import (
+	"context"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/housekeeping"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sharedDB *testutil.TestDatabase
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	t := &testing.T{}
+	sharedDB = testutil.NewTestDatabase(t, "cv-backend-test-db-housekeeping")
+	sharedDB.RunMigrations(t)
+
+	code := m.Run()
+
+	if sharedDB.Pool() != nil {
+		sharedDB.Pool().Close()
+	}
+
+	os.Exit(code)
+}
+
+type fakeNotifier struct {
+	groups []notifications.NotifierGroup
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, actorID uuid.UUID, entityType string, entityID uuid.UUID, groups []notifications.NotifierGroup) error {
+	f.groups = append(f.groups, groups...)
+	return nil
+}
+
+func createAvailability(t *testing.T, userID uuid.UUID, date time.Time) db.UserAvailability {
+	t.Helper()
+	ctx := context.Background()
+	slots, err := sharedDB.Queries().ListTimeSlots(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, slots)
+
+	availability, err := sharedDB.Queries().CreateAvailability(ctx, db.CreateAvailabilityParams{
+		ID:         uuid.New(),
+		UserID:     &userID,
+		TimeSlotID: &slots[0].ID,
+		Date:       pgtype.Date{Time: date, Valid: true},
+	})
+	require.NoError(t, err)
+	return availability
+}
+
+func createBooking(t *testing.T, availabilityID, requesterID, managerID, itemID, groupID uuid.UUID, status db.RequestStatus, createdAgo time.Duration) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	pickup := time.Now().AddDate(0, 0, 7)
+
+	booking, err := sharedDB.Queries().CreateBooking(ctx, db.CreateBookingParams{
+		ID:             uuid.New(),
+		RequesterID:    &requesterID,
+		ManagerID:      &managerID,
+		ItemID:         &itemID,
+		GroupID:        &groupID,
+		AvailabilityID: &availabilityID,
+		PickUpDate:     pgtype.Timestamp{Time: pickup, Valid: true},
+		PickUpLocation: "Main Office",
+		ReturnDate:     pgtype.Timestamp{Time: pickup.Add(24 * time.Hour), Valid: true},
+		ReturnLocation: "Main Office",
+		Status:         status,
+	})
+	require.NoError(t, err)
+
+	_, err = sharedDB.Pool().Exec(ctx, `UPDATE booking SET created_at = NOW() - make_interval(secs => $2) WHERE id = $1`,
+		booking.ID, createdAgo.Seconds())
+	require.NoError(t, err)
+	return booking.ID
+}
+
+func TestHousekeeper_ExpireBookings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	ctx := context.Background()
+
+	user := sharedDB.NewUser(t).WithEmail("member@housekeeping.test").AsMember().Create()
+	manager := sharedDB.NewUser(t).WithEmail("manager@housekeeping.test").AsApprover().Create()
+	item := sharedDB.NewItem(t).WithName("Camera").WithType("high").WithStock(3).Create()
+	group := sharedDB.NewGroup(t).WithName("Housekeeping").Create()
+	availability := createAvailability(t, manager.ID, time.Now().AddDate(0, 0, 7))
+
+	staleSlot := createAvailability(t, manager.ID, time.Now().AddDate(0, 0, 8))
+
+	stale := createBooking(t, staleSlot.ID, user.ID, manager.ID, item.ID, group.ID, db.RequestStatusPendingConfirmation, 72*time.Hour)
+	fresh := createBooking(t, availability.ID, user.ID, manager.ID, item.ID, group.ID, db.RequestStatusPendingConfirmation, time.Hour)
+	confirmed := createBooking(t, availability.ID, user.ID, manager.ID, item.ID, group.ID, db.RequestStatusConfirmed, 72*time.Hour)
+
+	// the stale booking was made by approving this request
+	request, err := sharedDB.Queries().RequestItem(ctx, db.RequestItemParams{
+		UserID: &user.ID, GroupID: &group.ID, ID: item.ID, Quantity: 1,
+	})
+	require.NoError(t, err)
+	_, err = sharedDB.Queries().UpdateRequestWithBooking(ctx, db.UpdateRequestWithBookingParams{ID: request.ID, BookingID: &stale})
+	require.NoError(t, err)
+	_, err = sharedDB.Queries().ReviewRequest(ctx, db.ReviewRequestParams{
+		ID:         request.ID,
+		Status:     db.NullRequestStatus{RequestStatus: db.RequestStatusApproved, Valid: true},
+		ReviewedBy: &manager.ID,
+	})
+	require.NoError(t, err)
+
+	notifier := &fakeNotifier{}
+	housekeeper := housekeeping.NewHousekeeper(sharedDB.Pool(), sharedDB.Queries(), notifier, 48*time.Hour, 30)
+	expired, err := housekeeper.ExpireBookings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	for id, want := range map[uuid.UUID]db.RequestStatus{
+		stale:     db.RequestStatusExpired,
+		fresh:     db.RequestStatusPendingConfirmation,
+		confirmed: db.RequestStatusConfirmed,
+	} {
+		booking, err := sharedDB.Queries().GetBookingByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, want, booking.Status)
+	}
+
+	events, err := sharedDB.Queries().ListBookingEvents(ctx, stale)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, db.RequestStatusExpired, events[0].ToStatus)
+	assert.Nil(t, events[0].ActorID)
+
+	reopened, err := sharedDB.Queries().GetRequestById(ctx, request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.RequestStatusPending, reopened.Status, "the request can be reviewed again")
+	assert.Nil(t, reopened.BookingID)
+	assert.Nil(t, reopened.ReviewedBy)
+
+	inUse, err := sharedDB.Queries().CheckAvailabilityInUse(ctx, &staleSlot.ID)
+	require.NoError(t, err)
+	assert.False(t, inUse, "the expired booking released its slot")
+
+	require.Len(t, notifier.groups, 2)
+	assert.Equal(t, []uuid.UUID{user.ID}, notifier.groups[0].IDs)
+	assert.Equal(t, "booking_expired_requester", notifier.groups[0].Template)
+	assert.Equal(t, []uuid.UUID{manager.ID}, notifier.groups[1].IDs)
+	assert.Equal(t, "booking_expired_approver", notifier.groups[1].Template)
+	assert.Equal(t, true, notifier.groups[1].TemplateData["Reopened"])
+
+	// a second run finds nothing left to expire
+	expired, err = housekeeper.ExpireBookings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired)
+}
+
+func TestHousekeeper_CleanupAvailability(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	ctx := context.Background()
+
+	user := sharedDB.NewUser(t).WithEmail("member@housekeeping.test").AsMember().Create()
+	manager := sharedDB.NewUser(t).WithEmail("manager@housekeeping.test").AsApprover().Create()
+	item := sharedDB.NewItem(t).WithName("Camera").WithType("high").WithStock(3).Create()
+	group := sharedDB.NewGroup(t).WithName("Housekeeping").Create()
+
+	old := createAvailability(t, manager.ID, time.Now().AddDate(0, 0, -60))
+	oldBooked := createAvailability(t, user.ID, time.Now().AddDate(0, 0, -60))
+	recent := createAvailability(t, manager.ID, time.Now().AddDate(0, 0, -5))
+	createBooking(t, oldBooked.ID, user.ID, manager.ID, item.ID, group.ID, db.RequestStatusFulfilled, 0)
+
+	housekeeper := housekeeping.NewHousekeeper(sharedDB.Pool(), sharedDB.Queries(), &fakeNotifier{}, 48*time.Hour, 30)
+	deleted, err := housekeeper.CleanupAvailability(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	_, err = sharedDB.Queries().GetAvailabilityByID(ctx, old.ID)
+	assert.Error(t, err, "an old unbooked slot is removed")
+	_, err = sharedDB.Queries().GetAvailabilityByID(ctx, oldBooked.ID)
+	assert.NoError(t, err, "a booked slot keeps its booking's history")
+	_, err = sharedDB.Queries().GetAvailabilityByID(ctx, recent.ID)
+	assert.NoError(t, err)
+}
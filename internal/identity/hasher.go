@@ -0,0 +1,50 @@
+package identity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/USSTM/cv-backend/internal/config"
+)
+
+// keyed hashing for student IDs. A plain digest of a short numeric ID can be
+// reversed by enumeration, so the hash is an HMAC under a server secret.
+type Hasher struct {
+	key      []byte
+	previous [][]byte
+}
+
+func NewHasher(cfg config.IdentityConfig) *Hasher {
+	h := &Hasher{key: []byte(cfg.StudentIDKey)}
+	for _, k := range cfg.PreviousStudentIDKeys {
+		h.previous = append(h.previous, []byte(k))
+	}
+	return h
+}
+
+// hex HMAC-SHA256 of a normalized student ID under the current key.
+func (h *Hasher) Hash(normalized string) string {
+	return sign(h.key, normalized)
+}
+
+// reports whether normalized matches the stored hash under the current key or
+// any previous one. stale is true when only a previous key matched, meaning
+// the caller should store Hash(normalized) in its place.
+func (h *Hasher) Match(normalized, stored string) (matched, stale bool) {
+	if hmac.Equal([]byte(sign(h.key, normalized)), []byte(stored)) {
+		return true, false
+	}
+	for _, k := range h.previous {
+		if hmac.Equal([]byte(sign(k, normalized)), []byte(stored)) {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+func sign(key []byte, normalized string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(normalized))
+	return hex.EncodeToString(mac.Sum(nil))
+}
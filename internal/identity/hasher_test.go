@@ -0,0 +1,57 @@
+package identity_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasher_Hash(t *testing.T) {
+	h := identity.NewHasher(config.IdentityConfig{StudentIDKey: "key-a"})
+
+	hash := h.Hash("400123456")
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, h.Hash("400123456"), "hashing is deterministic")
+	assert.NotEqual(t, hash, h.Hash("400123457"))
+
+	plain := sha256.Sum256([]byte("400123456"))
+	assert.NotEqual(t, hex.EncodeToString(plain[:]), hash, "hash must be keyed")
+
+	other := identity.NewHasher(config.IdentityConfig{StudentIDKey: "key-b"})
+	assert.NotEqual(t, hash, other.Hash("400123456"))
+}
+
+func TestHasher_Match(t *testing.T) {
+	old := identity.NewHasher(config.IdentityConfig{StudentIDKey: "key-a"})
+	rotated := identity.NewHasher(config.IdentityConfig{
+		StudentIDKey:          "key-b",
+		PreviousStudentIDKeys: []string{"key-a"},
+	})
+	dropped := identity.NewHasher(config.IdentityConfig{StudentIDKey: "key-b"})
+
+	tests := []struct {
+		name        string
+		hasher      *identity.Hasher
+		stored      string
+		input       string
+		wantMatched bool
+		wantStale   bool
+	}{
+		{"current key", rotated, rotated.Hash("400123456"), "400123456", true, false},
+		{"previous key", rotated, old.Hash("400123456"), "400123456", true, true},
+		{"wrong ID", rotated, old.Hash("400123456"), "400999999", false, false},
+		{"old key no longer configured", dropped, old.Hash("400123456"), "400123456", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, stale := tt.hasher.Match(tt.input, tt.stored)
+			assert.Equal(t, tt.wantMatched, matched)
+			assert.Equal(t, tt.wantStale, stale)
+		})
+	}
+}
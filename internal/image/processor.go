@@ -39,6 +39,16 @@ func ValidateAndProcess(file io.Reader, header *multipart.FileHeader) (*Processe
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
+	return Process(data)
+}
+
+// validates and thumbnails an image that is already in memory, such as one
+// uploaded straight to S3.
+func Process(data []byte) (*ProcessedImage, error) {
+	if len(data) > MaxFileSize {
+		return nil, fmt.Errorf("file size %d exceeds maximum %d bytes", len(data), MaxFileSize)
+	}
+
 	contentType := http.DetectContentType(data)
 	if contentType != "image/jpeg" && contentType != "image/png" {
 		return nil, fmt.Errorf("invalid file type %q: only jpeg and png are allowed", contentType)
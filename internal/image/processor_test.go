@@ -59,6 +59,19 @@ func TestValidateAndProcess_RejectsTooLargeDimensions(t *testing.T) {
 	assert.Contains(t, err.Error(), "dimensions")
 }
 
+func TestProcess_RejectsOversizedData(t *testing.T) {
+	data := make([]byte, cvimage.MaxFileSize+1)
+	_, err := cvimage.Process(data)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file size")
+}
+
+func TestProcess_RejectsNonImage(t *testing.T) {
+	_, err := cvimage.Process([]byte("%PDF-1.7 not an image"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid file type")
+}
+
 func TestIsSquare(t *testing.T) {
 	assert.True(t, cvimage.IsSquare(500, 500))
 	assert.True(t, cvimage.IsSquare(100, 101)) // within 1%
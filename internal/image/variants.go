@@ -0,0 +1,84 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"net/http"
+
+	"github.com/disintegration/imaging"
+)
+
+// longest edge of the web-sized variant of a photo
+const WebSize = 1600
+
+// the sizes a photo is served at. Each is re-encoded from the decoded pixels,
+// so none carries the original's EXIF data, GPS position included.
+type Variants struct {
+	// full size, turned upright
+	Clean []byte
+	// fits within WebSize x WebSize; never larger than Clean
+	Web []byte
+	// ThumbnailSize x ThumbnailSize, center-cropped
+	Thumbnail   []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// decodes a jpeg/png photo, turns it upright by its EXIF orientation and
+// encodes its variants. Width and Height are of the upright image.
+func MakeVariants(data []byte) (*Variants, error) {
+	contentType := http.DetectContentType(data)
+	var format imaging.Format
+	var options []imaging.EncodeOption
+	switch contentType {
+	case "image/jpeg":
+		format = imaging.JPEG
+		options = []imaging.EncodeOption{imaging.JPEGQuality(85)}
+	case "image/png":
+		format = imaging.PNG
+	default:
+		return nil, fmt.Errorf("invalid file type %q: only jpeg and png are allowed", contentType)
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	encode := func(img image.Image) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, img, format, options...); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	clean, err := encode(img)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	web := clean
+	bounds := img.Bounds()
+	if bounds.Dx() > WebSize || bounds.Dy() > WebSize {
+		if web, err = encode(imaging.Fit(img, WebSize, WebSize, imaging.Lanczos)); err != nil {
+			return nil, fmt.Errorf("failed to encode web variant: %w", err)
+		}
+	}
+
+	thumbnail, err := encode(imaging.Fill(img, ThumbnailSize, ThumbnailSize, imaging.Center, imaging.Lanczos))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	return &Variants{
+		Clean:       clean,
+		Web:         web,
+		Thumbnail:   thumbnail,
+		ContentType: contentType,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+	}, nil
+}
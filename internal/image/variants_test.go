@@ -0,0 +1,84 @@
+package image_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	stdimage "image"
+	"testing"
+
+	cvimage "github.com/USSTM/cv-backend/internal/image"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inserts an EXIF segment with the given orientation and a GPS IFD pointer
+// after the JPEG start-of-image marker
+func withEXIF(t *testing.T, jpegData []byte, orientation uint16) []byte {
+	t.Helper()
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(2))
+	// orientation, SHORT
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	// GPS IFD pointer, LONG; the target is left empty
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x8825, 4})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, uint32(38))
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	binary.Write(&tiff, binary.BigEndian, uint16(0))
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(jpegData[:2])
+	out.Write([]byte{0xff, 0xe1})
+	require.NoError(t, binary.Write(&out, binary.BigEndian, uint16(len(segment)+2)))
+	out.Write(segment)
+	out.Write(jpegData[2:])
+	return out.Bytes()
+}
+
+func decodeSize(t *testing.T, data []byte) (int, int) {
+	t.Helper()
+	cfg, _, err := stdimage.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	return cfg.Width, cfg.Height
+}
+
+func TestMakeVariants_StripsEXIFAndTurnsUpright(t *testing.T) {
+	data, _ := createTestJPEG(t, 400, 200)
+	data = withEXIF(t, data, 6) // rotated 90° clockwise
+	require.Contains(t, string(data), "Exif\x00\x00")
+
+	variants, err := cvimage.MakeVariants(data)
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", variants.ContentType)
+	assert.Equal(t, 200, variants.Width)
+	assert.Equal(t, 400, variants.Height)
+
+	for name, variant := range map[string][]byte{"clean": variants.Clean, "web": variants.Web, "thumbnail": variants.Thumbnail} {
+		assert.NotContains(t, string(variant), "Exif\x00\x00", name)
+	}
+	w, h := decodeSize(t, variants.Clean)
+	assert.Equal(t, [2]int{200, 400}, [2]int{w, h})
+	w, h = decodeSize(t, variants.Thumbnail)
+	assert.Equal(t, [2]int{cvimage.ThumbnailSize, cvimage.ThumbnailSize}, [2]int{w, h})
+}
+
+func TestMakeVariants_ScalesDownLargePhotos(t *testing.T) {
+	data, _ := createTestJPEG(t, 3200, 2400)
+
+	variants, err := cvimage.MakeVariants(data)
+	require.NoError(t, err)
+	w, h := decodeSize(t, variants.Web)
+	assert.Equal(t, [2]int{cvimage.WebSize, 1200}, [2]int{w, h})
+	w, h = decodeSize(t, variants.Clean)
+	assert.Equal(t, [2]int{3200, 2400}, [2]int{w, h})
+}
+
+func TestMakeVariants_RejectsNonImage(t *testing.T) {
+	_, err := cvimage.MakeVariants([]byte("not an image"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package itemcsv
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// an inventory item as exported
+type Item struct {
+	ID          uuid.UUID
+	Name        string
+	Description string
+	Type        string
+	Stock       int
+	Category    string
+	Tags        []string
+	URLs        []string
+}
+
+// writes items under the Columns header, in the layout Parse reads back, so
+// an export can be edited and re-imported.
+func Write(w io.Writer, items []Item) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(Columns); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := writer.Write([]string{
+			item.ID.String(),
+			item.Name,
+			item.Description,
+			item.Type,
+			strconv.Itoa(item.Stock),
+			item.Category,
+			strings.Join(item.Tags, ";"),
+			strings.Join(item.URLs, ";"),
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
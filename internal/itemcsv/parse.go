@@ -0,0 +1,176 @@
+package itemcsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/USSTM/cv-backend/internal/catalog"
+	"github.com/google/uuid"
+)
+
+// largest file accepted in one import; the whole inventory is a few hundred items.
+const MaxRows = 5000
+
+// the columns Export writes and Parse reads, in export order
+var Columns = []string{"id", "name", "description", "type", "stock", "category", "tags", "urls"}
+
+var ErrMissingColumns = errors.New("CSV header must include name, type and stock columns")
+
+// one data row of an inventory sheet. Err is set when the row can't be
+// imported; the other fields hold whatever could be read. Optional fields are
+// nil when the file has no column for them, so an import leaves them as they are.
+type Row struct {
+	// line in the file where the row starts, counting the header as line 1
+	Line int
+	// the item to update; nil creates a new item
+	ID          *uuid.UUID
+	Name        string
+	Description *string
+	Type        string
+	Stock       int
+	// a category slug; "" clears the item's category
+	Category *string
+	Tags     *[]string
+	URLs     *[]string
+	Err      string
+}
+
+// reads an inventory sheet. The header names the columns, in any order and
+// case: name, type and stock are required; id, description, category, tags
+// and urls are optional. Tags and URLs are separated by semicolons. Errors
+// are returned only when the file as a whole is unreadable; bad rows come
+// back with Err set.
+func Parse(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"name", "type", "stock"} {
+		if _, ok := columns[required]; !ok {
+			return nil, ErrMissingColumns
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	optional := func(record []string, name string) *string {
+		if _, ok := columns[name]; !ok {
+			return nil
+		}
+		value := field(record, name)
+		return &value
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, Row{Line: parseErr.StartLine, Err: parseErr.Err.Error()})
+				continue
+			}
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("CSV has more than %d rows", MaxRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := Row{
+			Line:        line,
+			Name:        field(record, "name"),
+			Description: optional(record, "description"),
+			Type:        strings.ToLower(field(record, "type")),
+			Category:    optional(record, "category"),
+		}
+		row.Err = readRow(&row, record, field, optional)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// fills in the fields that need parsing and returns the first problem found.
+func readRow(row *Row, record []string, field func([]string, string) string, optional func([]string, string) *string) string {
+	if id := field(record, "id"); id != "" {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return "id must be a UUID"
+		}
+		row.ID = &parsed
+	}
+
+	if row.Name == "" {
+		return "name is required"
+	}
+
+	switch row.Type {
+	case "low", "medium", "high":
+	case "":
+		return "type is required"
+	default:
+		return "type must be low, medium or high"
+	}
+
+	stock, err := strconv.Atoi(field(record, "stock"))
+	if err != nil || stock < 0 {
+		return "stock must be a whole number of at least 0"
+	}
+	row.Stock = stock
+
+	if row.Category != nil && *row.Category != "" {
+		*row.Category = strings.ToLower(*row.Category)
+		if !catalog.ValidSlug(*row.Category) {
+			return "invalid category slug: " + *row.Category
+		}
+	}
+
+	if tags := optional(record, "tags"); tags != nil {
+		normalized, err := catalog.NormalizeTags(strings.Split(*tags, ";"))
+		if err != nil {
+			return err.Error()
+		}
+		row.Tags = &normalized
+	}
+
+	if list := optional(record, "urls"); list != nil {
+		urls := []string{}
+		for _, raw := range strings.Split(*list, ";") {
+			if raw = strings.TrimSpace(raw); raw == "" {
+				continue
+			}
+			parsed, err := url.ParseRequestURI(raw)
+			if err != nil || parsed.Host == "" {
+				return "invalid URL: " + raw
+			}
+			urls = append(urls, raw)
+		}
+		row.URLs = &urls
+	}
+
+	return ""
+}
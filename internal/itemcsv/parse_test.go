@@ -0,0 +1,102 @@
+package itemcsv_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/internal/itemcsv"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("reads columns by header in any order", func(t *testing.T) {
+		rows, err := itemcsv.Parse(strings.NewReader(
+			"Stock,NAME,Type,Tags,Category\n" +
+				"3, Camera ,HIGH,Video; Tripod;video,AV\n"))
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+
+		row := rows[0]
+		assert.Empty(t, row.Err)
+		assert.Equal(t, 2, row.Line)
+		assert.Nil(t, row.ID)
+		assert.Equal(t, "Camera", row.Name)
+		assert.Equal(t, "high", row.Type)
+		assert.Equal(t, 3, row.Stock)
+		require.NotNil(t, row.Category)
+		assert.Equal(t, "av", *row.Category)
+		require.NotNil(t, row.Tags)
+		assert.Equal(t, []string{"video", "tripod"}, *row.Tags)
+		assert.Nil(t, row.Description, "no column leaves the description alone")
+		assert.Nil(t, row.URLs)
+	})
+
+	t.Run("bad rows are reported, not fatal", func(t *testing.T) {
+		rows, err := itemcsv.Parse(strings.NewReader("id,name,type,stock,urls\n" +
+			"not-a-uuid,Camera,high,1,\n" +
+			",,high,1,\n" +
+			",Camera,huge,1,\n" +
+			",Camera,high,-1,\n" +
+			",Camera,high,1,not a url\n" +
+			",Camera,high,1,https://example.com/manual.pdf\n"))
+		require.NoError(t, err)
+		require.Len(t, rows, 6)
+		assert.Equal(t, "id must be a UUID", rows[0].Err)
+		assert.Equal(t, "name is required", rows[1].Err)
+		assert.Equal(t, "type must be low, medium or high", rows[2].Err)
+		assert.Equal(t, "stock must be a whole number of at least 0", rows[3].Err)
+		assert.Equal(t, "invalid URL: not a url", rows[4].Err)
+		assert.Empty(t, rows[5].Err)
+	})
+
+	t.Run("missing required column", func(t *testing.T) {
+		_, err := itemcsv.Parse(strings.NewReader("name,type\nCamera,high\n"))
+		assert.ErrorIs(t, err, itemcsv.ErrMissingColumns)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		_, err := itemcsv.Parse(strings.NewReader(""))
+		assert.Error(t, err)
+	})
+}
+
+func TestWrite_RoundTrip(t *testing.T) {
+	items := []itemcsv.Item{
+		{
+			ID:          uuid.New(),
+			Name:        "Camera, large",
+			Description: "Canon \"R5\"\nwith charger",
+			Type:        "high",
+			Stock:       2,
+			Category:    "av",
+			Tags:        []string{"camera", "video"},
+			URLs:        []string{"https://example.com/a", "https://example.com/b"},
+		},
+		{ID: uuid.New(), Name: "Cable", Type: "low"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, itemcsv.Write(&buf, items))
+
+	rows, err := itemcsv.Parse(&buf)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	first := rows[0]
+	assert.Empty(t, first.Err)
+	assert.Equal(t, items[0].ID, *first.ID)
+	assert.Equal(t, items[0].Name, first.Name)
+	assert.Equal(t, items[0].Description, *first.Description)
+	assert.Equal(t, items[0].Stock, first.Stock)
+	assert.Equal(t, items[0].Tags, *first.Tags)
+	assert.Equal(t, items[0].URLs, *first.URLs)
+
+	second := rows[1]
+	assert.Empty(t, second.Err)
+	assert.Equal(t, "", *second.Category)
+	assert.Empty(t, *second.Tags)
+	assert.Empty(t, *second.URLs)
+}
@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type contextKey struct{}
+
+// the logger for a request or a task. It's shared by everything below the
+// context it was put on, so attributes learnt part way through, such as the
+// user once authenticated, also reach the lines logged by the code that
+// started the scope.
+type scope struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+// a context carrying l, which FromContext returns and AddAttrs extends
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, &scope{logger: l})
+}
+
+// the logger NewContext put on ctx, with any attributes added since; the
+// global logger when there is none
+func FromContext(ctx context.Context) *slog.Logger {
+	if s, ok := ctx.Value(contextKey{}).(*scope); ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.logger
+	}
+	if logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// adds key-value pairs, as With takes them, to the logger on ctx for the
+// rest of its scope. Does nothing when ctx has no logger.
+func AddAttrs(ctx context.Context, args ...any) {
+	if s, ok := ctx.Value(contextKey{}).(*scope); ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.logger = s.logger.With(args...)
+	}
+}
@@ -0,0 +1,31 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(slog.NewTextHandler(&buf, nil))
+
+	t.Run("attributes reach the whole scope", func(t *testing.T) {
+		buf.Reset()
+		ctx := NewContext(context.Background(), base.With("request_id", "r1"))
+		inner := context.WithValue(ctx, struct{}{}, "deeper")
+		AddAttrs(inner, "user_id", "u1")
+
+		FromContext(ctx).Info("done")
+		assert.Contains(t, buf.String(), "request_id=r1 user_id=u1")
+	})
+
+	t.Run("without a scope", func(t *testing.T) {
+		ctx := context.Background()
+		AddAttrs(ctx, "user_id", "u1")
+		assert.NotNil(t, FromContext(ctx))
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/USSTM/cv-backend/internal/config"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -13,39 +14,74 @@ import (
 
 var logger *slog.Logger
 
+// the handlers' minimum level, kept apart so SetLevel can change it while
+// the logger is in use
+var level = new(slog.LevelVar)
+
 func Init(cfg *config.LoggingConfig) error {
-	if err := os.MkdirAll(filepath.Dir(cfg.Filename), 0755); err != nil {
-		return err
+	var writers []io.Writer
+	if cfg.Output != "file" {
+		writers = append(writers, os.Stdout)
 	}
-
-	roller := &lumberjack.Logger{
-		Filename:   cfg.Filename,
-		MaxSize:    cfg.MaxSize,
-		MaxBackups: cfg.MaxBackups,
-		MaxAge:     cfg.MaxAge,
-		Compress:   cfg.Compress,
+	if cfg.Output != "stdout" {
+		if err := os.MkdirAll(filepath.Dir(cfg.Filename), 0755); err != nil {
+			return err
+		}
+		roller := &lumberjack.Logger{
+			Filename:   cfg.Filename,
+			MaxSize:    cfg.MaxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+			Compress:   cfg.Compress,
+			LocalTime:  cfg.LocalTime,
+		}
+		if cfg.RotateEvery > 0 {
+			go rotateEvery(roller, cfg.RotateEvery)
+		}
+		writers = append(writers, roller)
 	}
+	writer := io.MultiWriter(writers...)
 
-	var writer io.Writer = roller
-	if cfg.Format != "json" {
-		writer = io.MultiWriter(os.Stdout, roller)
-	}
+	level.Set(parseLevel(cfg.Level))
 
-	level := parseLevel(cfg.Level)
-	
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.Redact {
+		opts.ReplaceAttr = redactor(cfg.RedactFields)
+	}
 	var handler slog.Handler
 	if cfg.Format == "json" {
-		handler = slog.NewJSONHandler(writer, &slog.HandlerOptions{Level: level})
+		handler = slog.NewJSONHandler(writer, opts)
 	} else {
-		handler = slog.NewTextHandler(writer, &slog.HandlerOptions{Level: level})
+		handler = slog.NewTextHandler(writer, opts)
+	}
+	if len(cfg.SampleRates) > 0 {
+		handler = newSamplingHandler(handler, cfg.SampleRates)
 	}
 
+	// SetDefault also sends the standard library's log package through the
+	// handler, so its lines come out as JSON too
 	logger = slog.New(handler)
 	slog.SetDefault(logger)
 
 	return nil
 }
 
+// rotates on a schedule as well as by size, e.g. daily for shippers that
+// pick up whole files
+func rotateEvery(roller *lumberjack.Logger, interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := roller.Rotate(); err != nil {
+			Error("Failed to rotate log file", "error", err)
+		}
+	}
+}
+
+// changes the minimum level logged, for a configuration reload. Unknown
+// levels mean info, as in Init.
+func SetLevel(name string) {
+	level.Set(parseLevel(name))
+}
+
 func parseLevel(level string) slog.Level {
 	switch strings.ToLower(level) {
 	case "debug":
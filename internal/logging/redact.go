@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// attributes holding an email address or phone number, logged masked so
+// lines can still be told apart: jane@example.com becomes j***@example.com
+var contactFields = map[string]bool{
+	"email":     true,
+	"to":        true,
+	"address":   true,
+	"recipient": true,
+	"phone":     true,
+}
+
+// attributes never logged at all. Task payloads and message bodies are
+// among them since they carry addresses, names and sign-in codes.
+var secretFields = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"authorization": true,
+	"api_key":       true,
+	"otp":           true,
+	"payload":       true,
+	"body":          true,
+}
+
+const redacted = "[redacted]"
+
+// a HandlerOptions.ReplaceAttr hiding the fields above, and extra ones, in
+// any group. Keys are matched case-insensitively.
+func redactor(extra []string) func(groups []string, a slog.Attr) slog.Attr {
+	hidden := make(map[string]bool, len(secretFields)+len(extra))
+	for field := range secretFields {
+		hidden[field] = true
+	}
+	for _, field := range extra {
+		hidden[strings.ToLower(field)] = true
+	}
+	return func(groups []string, a slog.Attr) slog.Attr {
+		key := strings.ToLower(a.Key)
+		switch {
+		case hidden[key]:
+			return slog.String(a.Key, redacted)
+		case contactFields[key]:
+			value := a.Value.Resolve()
+			if value.Kind() == slog.KindGroup {
+				return a
+			}
+			return slog.String(a.Key, maskContact(fmt.Sprint(value.Any())))
+		}
+		return a
+	}
+}
+
+// keeps the first letter and domain of an email address, or the last four
+// digits of a phone number
+func maskContact(s string) string {
+	if s == "" {
+		return s
+	}
+	if local, domain, ok := strings.Cut(s, "@"); ok {
+		if local == "" {
+			return "***@" + domain
+		}
+		return local[:1] + "***@" + domain
+	}
+	if len(s) > 4 {
+		return "***" + s[len(s)-4:]
+	}
+	return "***"
+}
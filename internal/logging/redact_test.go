@@ -0,0 +1,38 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: redactor([]string{"Student_ID"})}))
+
+	logger.With("email", "jane.doe@example.com").Info("Sending",
+		"to", "+15551234567",
+		"token", "eyJhbGciOi",
+		slog.Group("task", "payload", `{"To": "jane.doe@example.com"}`),
+		"student_id", "1234567",
+		"item_id", "tripod")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "j***@example.com", line["email"])
+	assert.Equal(t, "***4567", line["to"])
+	assert.Equal(t, redacted, line["token"])
+	assert.Equal(t, map[string]any{"payload": redacted}, line["task"])
+	assert.Equal(t, redacted, line["student_id"], "extra fields")
+	assert.Equal(t, "tripod", line["item_id"])
+}
+
+func TestMaskContact(t *testing.T) {
+	assert.Equal(t, "", maskContact(""))
+	assert.Equal(t, "***@example.com", maskContact("@example.com"))
+	assert.Equal(t, "***", maskContact("1234"))
+}
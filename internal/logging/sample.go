@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// keeps one in every n lines of each message at a level, starting with the
+// first, so a debug line logged in a hot loop doesn't drown the rest while
+// rare messages still come through
+type samplingHandler struct {
+	slog.Handler
+	every map[slog.Level]uint64
+	// *atomic.Uint64 by sampleKey, shared with the handlers WithAttrs and
+	// WithGroup return
+	seen *sync.Map
+}
+
+type sampleKey struct {
+	level   slog.Level
+	message string
+}
+
+// rates is keyed by level name, as LOG_SAMPLE_RATES has it
+func newSamplingHandler(next slog.Handler, rates map[string]int) *samplingHandler {
+	every := make(map[slog.Level]uint64, len(rates))
+	for name, n := range rates {
+		if n > 1 {
+			every[parseLevel(name)] = uint64(n)
+		}
+	}
+	return &samplingHandler{Handler: next, every: every, seen: new(sync.Map)}
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if n := h.every[r.Level]; n > 1 {
+		counter, _ := h.seen.LoadOrStore(sampleKey{r.Level, r.Message}, new(atomic.Uint64))
+		if (counter.(*atomic.Uint64).Add(1)-1)%n != 0 {
+			return nil
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), every: h.every, seen: h.seen}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), every: h.every, seen: h.seen}
+}
@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	logger := slog.New(newSamplingHandler(handler, map[string]int{"debug": 10}))
+
+	for i := 0; i < 25; i++ {
+		logger.Debug("Cache hit", "i", i)
+		logger.With("request_id", "r1").Debug("Cache hit", "i", i)
+	}
+	logger.Debug("Cache miss")
+	logger.Info("Request completed")
+
+	out := buf.String()
+	assert.Equal(t, 5, strings.Count(out, "Cache hit"), "one in ten, counted across loggers")
+	assert.Contains(t, out, "msg=\"Cache hit\" i=0")
+	assert.Contains(t, out, "Cache miss", "each message is counted apart")
+	assert.Contains(t, out, "Request completed", "other levels aren't sampled")
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// exposes pool's connection stats.
+func RegisterPool(pool *pgxpool.Pool) {
+	NewGaugeFunc("cv_db_pool_connections", "Database connections in the pool, by state.", []string{"state"}, func() []Sample {
+		stat := pool.Stat()
+		return []Sample{
+			{Labels: []string{"acquired"}, Value: float64(stat.AcquiredConns())},
+			{Labels: []string{"idle"}, Value: float64(stat.IdleConns())},
+			{Labels: []string{"constructing"}, Value: float64(stat.ConstructingConns())},
+		}
+	})
+	NewGaugeFunc("cv_db_pool_max_connections", "The most connections the pool will open.", nil, func() []Sample {
+		return []Sample{{Value: float64(pool.Stat().MaxConns())}}
+	})
+	NewCounterFunc("cv_db_pool_acquires_total", "Connections acquired from the pool.", nil, func() []Sample {
+		return []Sample{{Value: float64(pool.Stat().AcquireCount())}}
+	})
+	NewCounterFunc("cv_db_pool_empty_acquires_total", "Acquires that had to wait because the pool had no idle connection.", nil, func() []Sample {
+		return []Sample{{Value: float64(pool.Stat().EmptyAcquireCount())}}
+	})
+	NewCounterFunc("cv_db_pool_acquire_seconds_total", "Time spent acquiring connections from the pool.", nil, func() []Sample {
+		return []Sample{{Value: pool.Stat().AcquireDuration().Seconds()}}
+	})
+}
+
+type queueLister interface {
+	ListQueues() ([]*asynq.QueueInfo, error)
+}
+
+// exposes how many tasks each queue holds, by state. A failed lookup leaves
+// the metric out of that scrape.
+func RegisterQueues(queues queueLister) {
+	NewGaugeFunc("cv_queue_tasks", "Tasks in each queue, by state.", []string{"queue", "state"}, func() []Sample {
+		infos, err := queues.ListQueues()
+		if err != nil {
+			logging.Error("Failed to read queue depth for metrics", "error", err)
+			return nil
+		}
+		var samples []Sample
+		for _, info := range infos {
+			for _, state := range []struct {
+				name  string
+				count int
+			}{
+				{"pending", info.Pending},
+				{"active", info.Active},
+				{"scheduled", info.Scheduled},
+				{"retry", info.Retry},
+				{"archived", info.Archived},
+			} {
+				samples = append(samples, Sample{Labels: []string{info.Queue, state.name}, Value: float64(state.count)})
+			}
+		}
+		return samples
+	})
+}
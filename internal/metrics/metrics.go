@@ -0,0 +1,300 @@
+// Package metrics keeps the process's Prometheus metrics and serves them in
+// the text exposition format at /metrics.
+package metrics
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// request latencies, in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// task run times, in seconds; reports and campaigns take minutes
+var TaskBuckets = []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300}
+
+var (
+	HTTPRequests = NewCounterVec("cv_http_requests_total",
+		"HTTP requests served, by route pattern, method and status code.", "route", "method", "status")
+	HTTPDuration = NewHistogramVec("cv_http_request_duration_seconds",
+		"Time taken to serve HTTP requests, by route pattern, method and status code.", DefaultBuckets, "route", "method", "status")
+	TaskDuration = NewHistogramVec("cv_task_duration_seconds",
+		"Time taken to process queued tasks, by task type and result (success or failure).", TaskBuckets, "type", "result")
+	EmailSends = NewCounterVec("cv_email_sends_total",
+		"Emails handed to the email provider, by result (success, failure or suppressed).", "result")
+	CatalogCache = NewCounterVec("cv_catalog_cache_lookups_total",
+		"Item listing lookups in the catalogue cache, by result (hit, miss or error).", "result")
+)
+
+// something Registry can write out
+type collector interface {
+	metricName() string
+	write(w *bufio.Writer)
+}
+
+// the metrics a /metrics handler serves, in the order they were registered.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// where NewCounterVec, NewHistogramVec and the Register functions put their
+// metrics.
+var Default = &Registry{}
+
+// adds c, replacing a metric of the same name so collectors can be set up
+// again, e.g. by tests.
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.collectors {
+		if existing.metricName() == c.metricName() {
+			r.collectors[i] = c
+			return
+		}
+	}
+	r.collectors = append(r.collectors, c)
+}
+
+func (r *Registry) write(w *bufio.Writer) {
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// serves the Default registry. When token is set the request must carry it
+// as a bearer token.
+func Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			given, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		out := bufio.NewWriter(w)
+		Default.write(out)
+		out.Flush()
+	})
+}
+
+// the series of one metric, keyed by their label values
+type family[T any] struct {
+	name, help, kind string
+	labels           []string
+	mu               sync.Mutex
+	series           map[string]*T
+	values           map[string][]string
+}
+
+func newFamily[T any](name, help, kind string, labels []string) *family[T] {
+	return &family[T]{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		series: map[string]*T{},
+		values: map[string][]string{},
+	}
+}
+
+func (f *family[T]) metricName() string { return f.name }
+
+// the series for values, created by init the first time. Callers hold f.mu.
+func (f *family[T]) get(values []string, init func() *T) *T {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = init()
+		f.series[key] = s
+		f.values[key] = slices.Clone(values)
+	}
+	return s
+}
+
+// calls fn for each series, ordered by label values so output is stable
+func (f *family[T]) each(w *bufio.Writer, fn func(labels string, s *T)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	writeHeader(w, f.name, f.help, f.kind)
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fn(formatLabels(f.labels, f.values[key]), f.series[key])
+	}
+}
+
+// a counter split by labels.
+type CounterVec struct {
+	*family[float64]
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newFamily[float64](name, help, "counter", labels)}
+	Default.register(c)
+	return c
+}
+
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *CounterVec) Add(v float64, values ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.get(values, func() *float64 { return new(float64) }) += v
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.each(w, func(labels string, v *float64) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, labels, formatFloat(*v))
+	})
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// a histogram split by labels.
+type HistogramVec struct {
+	*family[histogram]
+	buckets []float64
+}
+
+// buckets are upper bounds in increasing order; +Inf is added.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{newFamily[histogram](name, help, "histogram", labels), buckets}
+	Default.register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(values, func() *histogram { return &histogram{counts: make([]uint64, len(h.buckets))} })
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.each(w, func(labels string, s *histogram) {
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLabel(labels, "le", formatFloat(bound)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLabel(labels, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labels, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labels, s.count)
+	})
+}
+
+// one series of a metric read at scrape time
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// a gauge or counter whose series are read from somewhere else, such as
+// the database pool, each time metrics are scraped.
+type funcCollector struct {
+	name, help, kind string
+	labels           []string
+	collect          func() []Sample
+}
+
+func (f *funcCollector) metricName() string { return f.name }
+
+func (f *funcCollector) write(w *bufio.Writer) {
+	samples := f.collect()
+	if len(samples) == 0 {
+		return
+	}
+	writeHeader(w, f.name, f.help, f.kind)
+	for _, s := range samples {
+		fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(f.labels, s.Labels), formatFloat(s.Value))
+	}
+}
+
+// registers a gauge read by collect at every scrape.
+func NewGaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	Default.register(&funcCollector{name: name, help: help, kind: "gauge", labels: labels, collect: collect})
+}
+
+// registers a counter read by collect at every scrape; the source must only
+// ever count up.
+func NewCounterFunc(name, help string, labels []string, collect func() []Sample) {
+	Default.register(&funcCollector{name: name, help: help, kind: "counter", labels: labels, collect: collect})
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, helpEscaper.Replace(help), name, kind)
+}
+
+// {a="x",b="y"}, or "" without labels
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// labels with name="value" appended
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + value + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,78 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/USSTM/cv-backend/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, token, bearer string) (int, string) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if bearer != "" {
+		r.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	w := httptest.NewRecorder()
+	metrics.Handler(token).ServeHTTP(w, r)
+	body, err := io.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+	return w.Code, string(body)
+}
+
+func TestHandler_Exposition(t *testing.T) {
+	counter := metrics.NewCounterVec("test_widgets_total", "Widgets made.", "colour")
+	counter.Inc("red")
+	counter.Add(2, `say "hi"`)
+
+	histogram := metrics.NewHistogramVec("test_wait_seconds", "Time spent waiting.", []float64{0.1, 1}, "queue")
+	histogram.Observe(0.05, "default")
+	histogram.Observe(0.5, "default")
+	histogram.Observe(3, "default")
+
+	metrics.NewGaugeFunc("test_open_things", "Things open right now.", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: 7}}
+	})
+	metrics.NewGaugeFunc("test_unavailable", "Never has samples.", nil, func() []metrics.Sample {
+		return nil
+	})
+
+	code, body := scrape(t, "", "")
+	require.Equal(t, http.StatusOK, code)
+
+	assert.Contains(t, body, "# HELP test_widgets_total Widgets made.\n# TYPE test_widgets_total counter\n"+
+		"test_widgets_total{colour=\"red\"} 1\n"+
+		"test_widgets_total{colour=\"say \\\"hi\\\"\"} 2\n")
+
+	assert.Contains(t, body, "# TYPE test_wait_seconds histogram\n"+
+		"test_wait_seconds_bucket{queue=\"default\",le=\"0.1\"} 1\n"+
+		"test_wait_seconds_bucket{queue=\"default\",le=\"1\"} 2\n"+
+		"test_wait_seconds_bucket{queue=\"default\",le=\"+Inf\"} 3\n"+
+		"test_wait_seconds_sum{queue=\"default\"} 3.55\n"+
+		"test_wait_seconds_count{queue=\"default\"} 3\n")
+
+	assert.Contains(t, body, "# TYPE test_open_things gauge\ntest_open_things 7\n")
+	// a collector with nothing to report leaves the metric out entirely
+	assert.NotContains(t, body, "test_unavailable")
+}
+
+func TestHandler_Token(t *testing.T) {
+	code, _ := scrape(t, "secret", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = scrape(t, "secret", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, body := scrape(t, "secret", "secret")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "# TYPE cv_http_requests_total counter")
+}
+
+func TestCounterVec_WrongLabelCount(t *testing.T) {
+	counter := metrics.NewCounterVec("test_labelled_total", "Needs two labels.", "a", "b")
+	assert.Panics(t, func() { counter.Inc("only-one") })
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// lets clients ask for CSV with Accept: text/csv as well as ?format=csv, by
+// rewriting the one into the other before the request is validated. An
+// explicit format parameter wins over the header.
+func NegotiateExport(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.Method == http.MethodGet && !query.Has("format") && prefersCSV(r.Header.Get("Accept")) {
+			r = r.Clone(r.Context())
+			query.Set("format", "csv")
+			r.URL.RawQuery = query.Encode()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// whether text/csv comes before JSON or a wildcard in the Accept header.
+// Quality values are not weighed; clients list what they want first.
+func prefersCSV(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/csv":
+			return true
+		case "application/json", "*/*", "text/*":
+			return false
+		}
+	}
+	return false
+}
+
+// whether r asks for a CSV export; see NegotiateExport.
+func IsExport(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "csv"
+}
+
+// exports stream rows as they are read, so unlike other requests they can't
+// be buffered and replaced by a 504. They run under the longer ExportTimeout
+// instead, and a deadline passing cuts the body short.
+func serveExport(w http.ResponseWriter, r *http.Request, next http.Handler, timeout time.Duration) {
+	if timeout <= 0 {
+		next.ServeHTTP(w, r)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
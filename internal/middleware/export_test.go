@@ -0,0 +1,68 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateExport(t *testing.T) {
+	format := func(method, target, accept string) string {
+		var got string
+		handler := middleware.NegotiateExport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.URL.Query().Get("format")
+		}))
+		r := httptest.NewRequest(method, target, nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		return got
+	}
+
+	assert.Equal(t, "csv", format(http.MethodGet, "/requests", "text/csv"))
+	assert.Equal(t, "csv", format(http.MethodGet, "/requests?limit=5", "text/csv, application/json;q=0.5"))
+	assert.Equal(t, "", format(http.MethodGet, "/requests", "application/json, text/csv"))
+	assert.Equal(t, "", format(http.MethodGet, "/requests", "*/*"))
+	assert.Equal(t, "", format(http.MethodGet, "/requests", ""))
+	// an explicit parameter wins over the header
+	assert.Equal(t, "json", format(http.MethodGet, "/requests?format=json", "text/csv"))
+	assert.Equal(t, "", format(http.MethodPost, "/requests", "text/csv"))
+}
+
+func TestTimeoutHandler_Export(t *testing.T) {
+	cfg := &config.ServerConfig{RequestTimeout: 20 * time.Millisecond, ExportTimeout: time.Minute}
+
+	t.Run("exports stream past the request timeout", func(t *testing.T) {
+		handler := middleware.NewTimeoutHandler(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, ok := r.Context().Deadline()
+			assert.True(t, ok)
+			assert.Greater(t, time.Until(deadline), cfg.RequestTimeout)
+
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("id\n"))
+			time.Sleep(2 * cfg.RequestTimeout)
+			w.Write([]byte("1\n"))
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/requests?format=csv", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "id\n1\n", rec.Body.String())
+	})
+
+	t.Run("other formats keep the request timeout", func(t *testing.T) {
+		handler := middleware.NewTimeoutHandler(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/requests?format=json", nil))
+		assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	})
+}
@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/apierror"
+	"github.com/USSTM/cv-backend/internal/config"
+)
+
+type Priority int
+
+const (
+	PriorityCritical Priority = iota
+	PriorityNormal
+	PriorityBestEffort
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityCritical:
+		return "critical"
+	case PriorityBestEffort:
+		return "best_effort"
+	default:
+		return "normal"
+	}
+}
+
+var priorities = []Priority{PriorityCritical, PriorityNormal, PriorityBestEffort}
+
+type ClassStats struct {
+	Class    Priority
+	Admitted int64
+	Shed     int64
+}
+
+type SheddingStats struct {
+	InFlight           int64
+	MaxInFlight        int
+	BestEffortInFlight int
+	Classes            []ClassStats
+}
+
+// refuses lower-priority requests with a 503 once too many are in flight, so
+// sign-in, borrowing and returns keep their share of the DB pool during spikes.
+type LoadShedder struct {
+	cfg      *config.ServerConfig
+	inFlight atomic.Int64
+	admitted [3]atomic.Int64
+	shed     [3]atomic.Int64
+	// copied out of cfg so SetLimits can change them under traffic
+	maxInFlight        atomic.Int64
+	bestEffortInFlight atomic.Int64
+}
+
+func NewLoadShedder(cfg *config.ServerConfig) *LoadShedder {
+	l := &LoadShedder{cfg: cfg}
+	l.SetLimits(cfg.MaxInFlight, cfg.BestEffortInFlight)
+	return l
+}
+
+// replaces MaxInFlight and BestEffortInFlight, for a configuration reload.
+// Requests already admitted are unaffected.
+func (l *LoadShedder) SetLimits(maxInFlight, bestEffortInFlight int) {
+	l.maxInFlight.Store(int64(maxInFlight))
+	l.bestEffortInFlight.Store(int64(bestEffortInFlight))
+}
+
+func (l *LoadShedder) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a stream holds its slot for as long as the client stays connected
+		if IsStreamRoute(r.URL.Path, l.cfg) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		priority := PriorityFor(r, l.cfg)
+
+		limit := l.maxInFlight.Load()
+		switch priority {
+		case PriorityCritical:
+			limit = 0
+		case PriorityBestEffort:
+			limit = l.bestEffortInFlight.Load()
+		}
+
+		n := l.inFlight.Add(1)
+		defer l.inFlight.Add(-1)
+
+		if limit > 0 && n > limit {
+			l.shed[priority].Add(1)
+			l.writeShed(w, r, priority, n)
+			return
+		}
+
+		l.admitted[priority].Add(1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// counters since startup, one entry per priority class.
+func (l *LoadShedder) Stats() SheddingStats {
+	stats := SheddingStats{
+		InFlight:           l.inFlight.Load(),
+		MaxInFlight:        int(l.maxInFlight.Load()),
+		BestEffortInFlight: int(l.bestEffortInFlight.Load()),
+	}
+	for _, p := range priorities {
+		stats.Classes = append(stats.Classes, ClassStats{
+			Class:    p,
+			Admitted: l.admitted[p].Load(),
+			Shed:     l.shed[p].Load(),
+		})
+	}
+	return stats
+}
+
+func (l *LoadShedder) writeShed(w http.ResponseWriter, r *http.Request, priority Priority, inFlight int64) {
+	GetLoggerFromContext(r.Context()).Warn("Request shed under load",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"priority", priority.String(),
+		"in_flight", inFlight)
+
+	retryAfter := int(l.cfg.ShedRetryAfter.Round(time.Second) / time.Second)
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	apierror.Write(w, apierror.New(apierror.CodeUnavailable, "Server is busy, please retry shortly"), GetRequestID(r.Context()))
+}
+
+// priority class for r: the most specific critical or best-effort pattern
+// matching the whole path, otherwise normal. Unlike timeouts these are not
+// prefixes, so "/borrowings/item" does not also cover /borrowings/item/active.
+// Critical wins a tie.
+func PriorityFor(r *http.Request, cfg *config.ServerConfig) Priority {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	priority := PriorityNormal
+	best := -1
+	classes := []struct {
+		priority Priority
+		patterns []string
+	}{
+		{PriorityCritical, cfg.CriticalRoutes},
+		{PriorityBestEffort, cfg.BestEffortRoutes},
+	}
+	for _, class := range classes {
+		for _, pattern := range class.patterns {
+			path, param, hasParam := strings.Cut(pattern, "?")
+			parts := strings.Split(strings.Trim(path, "/"), "/")
+			if len(parts) != len(segments) || !matchSegments(parts, segments) {
+				continue
+			}
+			// literal segments beat wildcards
+			specificity := 0
+			for _, part := range parts {
+				if part != "*" {
+					specificity++
+				}
+			}
+			if hasParam {
+				if !r.URL.Query().Has(param) {
+					continue
+				}
+				specificity++
+			}
+			if specificity > best {
+				priority = class.priority
+				best = specificity
+			}
+		}
+	}
+	return priority
+}
@@ -0,0 +1,158 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	genapi "github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityFor(t *testing.T) {
+	cfg := &config.ServerConfig{
+		CriticalRoutes:   []string{"/auth/*", "/borrowings/item", "/borrowings/item/return/*"},
+		BestEffortRoutes: []string{"/reports", "/items?q", "/borrowings/user/*"},
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		want   middleware.Priority
+	}{
+		{"auth endpoint", "/auth/verify-otp", middleware.PriorityCritical},
+		{"borrow", "/borrowings/item", middleware.PriorityCritical},
+		{"return", "/borrowings/item/return/abc", middleware.PriorityCritical},
+		{"active borrowings are not swept in by /borrowings/item", "/borrowings/item/active", middleware.PriorityNormal},
+		{"returned borrowings are not swept in by /borrowings/item", "/borrowings/item/returned", middleware.PriorityNormal},
+		{"wildcard needs a segment", "/borrowings/item/return", middleware.PriorityNormal},
+		{"wildcard covers exactly one segment", "/borrowings/item/return/abc/extra", middleware.PriorityNormal},
+		{"bare prefix of a wildcard pattern", "/auth", middleware.PriorityNormal},
+		{"best effort", "/borrowings/user/abc", middleware.PriorityBestEffort},
+		{"reports", "/reports", middleware.PriorityBestEffort},
+		{"query parameter required", "/items?q=camera", middleware.PriorityBestEffort},
+		{"query parameter missing", "/items", middleware.PriorityNormal},
+		{"item by ID", "/items/abc", middleware.PriorityNormal},
+		{"unlisted", "/groups", middleware.PriorityNormal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := middleware.PriorityFor(httptest.NewRequest(http.MethodGet, tt.target, nil), cfg)
+			assert.Equal(t, tt.want, got, tt.target)
+		})
+	}
+}
+
+func TestLoadShedder(t *testing.T) {
+	cfg := &config.ServerConfig{
+		MaxInFlight:        2,
+		BestEffortInFlight: 1,
+		ShedRetryAfter:     3 * time.Second,
+		CriticalRoutes:     []string{"/auth/*"},
+		BestEffortRoutes:   []string{"/reports"},
+		StreamRoutes:       []string{"/events/stream"},
+	}
+	shedder := middleware.NewLoadShedder(cfg)
+
+	// holds every admitted request until released
+	release := make(chan struct{})
+	var started sync.WaitGroup
+	handler := middleware.RequestContext(shedder.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started.Done()
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	// two normal requests fill the pool
+	var done sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			serve("/groups")
+		}()
+	}
+	started.Wait()
+
+	t.Run("best effort is shed with Retry-After", func(t *testing.T) {
+		rec := serve("/reports")
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "3", rec.Header().Get("Retry-After"))
+
+		var body genapi.Error
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, genapi.SERVICEUNAVAILABLE, body.Error.Code)
+	})
+
+	t.Run("normal is shed at the limit", func(t *testing.T) {
+		assert.Equal(t, http.StatusServiceUnavailable, serve("/items").Code)
+	})
+
+	t.Run("critical is always admitted", func(t *testing.T) {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			assert.Equal(t, http.StatusOK, serve("/auth/refresh").Code)
+		}()
+		started.Wait()
+	})
+
+	t.Run("streams are neither shed nor counted", func(t *testing.T) {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			assert.Equal(t, http.StatusOK, serve("/events/stream").Code)
+		}()
+		started.Wait()
+		assert.Equal(t, int64(3), shedder.Stats().InFlight)
+	})
+
+	t.Run("new limits apply to the next request", func(t *testing.T) {
+		shedder.SetLimits(0, 0)
+		assert.Equal(t, 0, shedder.Stats().BestEffortInFlight)
+
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			assert.Equal(t, http.StatusOK, serve("/reports").Code)
+		}()
+		started.Wait()
+	})
+
+	close(release)
+	done.Wait()
+
+	stats := shedder.Stats()
+	assert.Equal(t, int64(0), stats.InFlight)
+	require.Len(t, stats.Classes, 3)
+	for _, c := range stats.Classes {
+		switch c.Class {
+		case middleware.PriorityCritical:
+			assert.Equal(t, int64(1), c.Admitted)
+			assert.Equal(t, int64(0), c.Shed)
+		case middleware.PriorityNormal:
+			assert.Equal(t, int64(2), c.Admitted)
+			assert.Equal(t, int64(1), c.Shed)
+		case middleware.PriorityBestEffort:
+			assert.Equal(t, int64(1), c.Admitted)
+			assert.Equal(t, int64(1), c.Shed)
+		}
+	}
+}
@@ -27,6 +27,12 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return rw.ResponseWriter.Write(b)
 }
 
+// lets http.ResponseController reach the underlying writer, e.g. to flush
+// event streams
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // logs HTTP requests and responses with logger module
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -39,11 +45,8 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 			written:        false,
 		}
 
-		// Get logger
-		logger := GetLoggerFromContext(r.Context())
-
 		// incoming request
-		logger.Info("Request received",
+		GetLoggerFromContext(r.Context()).Info("Request received",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"query", r.URL.RawQuery)
@@ -54,6 +57,9 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		// Calculate duration
 		duration := time.Since(start)
 
+		// fetched again to pick up the route and user added while handling
+		logger := GetLoggerFromContext(r.Context())
+
 		// Determine log level based on status code
 		statusCode := wrapped.statusCode
 		logAttrs := []any{
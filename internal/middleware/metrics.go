@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/metrics"
+	"github.com/go-chi/chi/v5"
+)
+
+// counts requests and their latency by route pattern (/items/{id}, not the
+// item's ID), method and status. Requests that match no route are counted
+// under "unmatched" so scanners can't grow the series without bound.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(wrapped, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := strconv.Itoa(wrapped.statusCode)
+		metrics.HTTPRequests.Inc(route, r.Method, status)
+		metrics.HTTPDuration.Observe(time.Since(start).Seconds(), route, r.Method, status)
+	})
+}
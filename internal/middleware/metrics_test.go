@@ -0,0 +1,36 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/USSTM/cv-backend/internal/metrics"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(middleware.Metrics)
+	r.Get("/widgets/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	for _, target := range []string{"/widgets/1", "/widgets/2", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	w := httptest.NewRecorder()
+	metrics.Handler("").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+
+	// both widgets share the route pattern's series
+	assert.Contains(t, string(body), `cv_http_requests_total{route="/widgets/{id}",method="GET",status="418"} 2`)
+	assert.Contains(t, string(body), `cv_http_requests_total{route="unmatched",method="GET",status="404"} 1`)
+	assert.Contains(t, string(body), `cv_http_request_duration_seconds_count{route="/widgets/{id}",method="GET",status="418"} 2`)
+}
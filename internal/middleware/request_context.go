@@ -13,13 +13,17 @@ import (
 
 type contextKey string
 
+// response header carrying the request ID, which error bodies repeat
+const RequestIDHeader = "X-Request-ID"
+
 const (
 	requestIDKey contextKey = "requestID"
-	userIDKey    contextKey = "userID"
-	loggerKey    contextKey = "logger"
 )
 
-// middleware adds request ID, user ID, and IP address to context
+// middleware adds request ID and IP address to context, along with a
+// request-scoped logger carrying both, and sends the request ID back in the
+// X-Request-ID header. The user isn't known yet; api.ScopeLogger adds them
+// to the logger once authenticated.
 func RequestContext(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -27,37 +31,26 @@ func RequestContext(next http.Handler) http.Handler {
 		// request ID
 		requestID := uuid.New().String()
 		ctx = context.WithValue(ctx, requestIDKey, requestID)
-
-		// user ID from JWT
-		userID := ""
-		if user, ok := auth.GetAuthenticatedUser(ctx); ok {
-			userID = user.Email
-		}
-		if userID != "" {
-			ctx = context.WithValue(ctx, userIDKey, userID)
-		}
+		w.Header().Set(RequestIDHeader, requestID)
 
 		// client IP
 		clientIP := getClientIP(r)
+		ctx = context.WithValue(ctx, auth.ClientIPKey, clientIP)
 
 		// Create logger with request context
 		logger := logging.With(
 			"request_id", requestID,
-			"user_id", userID,
 			"client_ip", clientIP,
 		)
-		ctx = context.WithValue(ctx, loggerKey, logger)
+		ctx = logging.NewContext(ctx, logger)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// the request's logger; see logging.FromContext
 func GetLoggerFromContext(ctx context.Context) *slog.Logger {
-	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
-		return logger
-	}
-	// Fallback to default logger if not found
-	return slog.Default()
+	return logging.FromContext(ctx)
 }
 
 func GetRequestID(ctx context.Context) string {
@@ -67,7 +60,7 @@ func GetRequestID(ctx context.Context) string {
 	return ""
 }
 
-// attempt to get client IP, later can be used for rate limiting
+// attempt to get client IP, used to rate limit sign-in attempts
 func getClientIP(r *http.Request) string {
 	// Check X-Forwarded-For header for proxied requests
 	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/apierror"
+	"github.com/USSTM/cv-backend/internal/config"
+)
+
+// bounds every request by the timeout configured for its route. The deadline
+// is set on the request context, so DB, queue and S3 calls made with it are
+// cancelled as well. When it passes, the client gets a 504 with the request ID.
+// CSV exports are the exception; see serveExport.
+func NewTimeoutHandler(cfg *config.ServerConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if IsExport(r) {
+				serveExport(w, r, next, cfg.ExportTimeout)
+				return
+			}
+
+			timeout := TimeoutFor(r.URL.Path, cfg)
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			// seeded with the headers set so far, such as X-Request-ID, so the
+			// handler sees them too
+			tw := &timeoutWriter{header: w.Header().Clone(), statusCode: http.StatusOK}
+			done := make(chan struct{})
+			panicChan := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicChan <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicChan:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				// handlers report cancelled calls as 5xx; surface those as the timeout they are
+				if tw.statusCode >= 500 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					writeTimeout(w, r, timeout)
+					return
+				}
+				for k, v := range tw.header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(tw.statusCode)
+				w.Write(tw.body.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				// a client that went away has nobody left to answer
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					writeTimeout(w, r, timeout)
+				}
+			}
+		})
+	}
+}
+
+// timeout for path: the most specific matching route override, or the
+// server-wide RequestTimeout. Stream routes get none.
+func TimeoutFor(path string, cfg *config.ServerConfig) time.Duration {
+	if IsStreamRoute(path, cfg) {
+		return 0
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	timeout := cfg.RequestTimeout
+	best := -1
+	for pattern, d := range cfg.RouteTimeouts {
+		parts := strings.Split(strings.Trim(pattern, "/"), "/")
+		if len(parts) > len(segments) || len(parts) <= best {
+			continue
+		}
+		if matchSegments(parts, segments) {
+			timeout = d
+			best = len(parts)
+		}
+	}
+	return timeout
+}
+
+// whether path is one of the configured long-lived StreamRoutes.
+func IsStreamRoute(path string, cfg *config.ServerConfig) bool {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, pattern := range cfg.StreamRoutes {
+		parts := strings.Split(strings.Trim(pattern, "/"), "/")
+		if len(parts) <= len(segments) && matchSegments(parts, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// patterns match as a prefix, segment by segment.
+func matchSegments(pattern, segments []string) bool {
+	for i, p := range pattern {
+		if p != "*" && p != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeTimeout(w http.ResponseWriter, r *http.Request, timeout time.Duration) {
+	GetLoggerFromContext(r.Context()).Warn("Request timed out",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"timeout", timeout.String())
+
+	apierror.Write(w, apierror.New(apierror.CodeTimeout, "Request timed out"), GetRequestID(r.Context()))
+}
+
+// buffers the handler's response so it can be discarded if the deadline wins.
+type timeoutWriter struct {
+	mu         sync.Mutex
+	header     http.Header
+	body       bytes.Buffer
+	statusCode int
+	written    bool
+	timedOut   bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.written = true
+	return tw.body.Write(b)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.written {
+		return
+	}
+	tw.statusCode = code
+	tw.written = true
+}
@@ -0,0 +1,85 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	genapi "github.com/USSTM/cv-backend/generated/api"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutFor(t *testing.T) {
+	cfg := &config.ServerConfig{
+		RequestTimeout: 30 * time.Second,
+		RouteTimeouts: map[string]time.Duration{
+			"/items/*/images": 2 * time.Minute,
+			"/items":          10 * time.Second,
+		},
+		StreamRoutes: []string{"/events/stream"},
+	}
+
+	assert.Equal(t, 30*time.Second, middleware.TimeoutFor("/groups", cfg))
+	assert.Equal(t, 10*time.Second, middleware.TimeoutFor("/items/abc", cfg))
+	assert.Equal(t, 2*time.Minute, middleware.TimeoutFor("/items/abc/images", cfg))
+	assert.Equal(t, 2*time.Minute, middleware.TimeoutFor("/items/abc/images/def/primary", cfg))
+	assert.Equal(t, time.Duration(0), middleware.TimeoutFor("/events/stream", cfg))
+	assert.Equal(t, 30*time.Second, middleware.TimeoutFor("/events", cfg))
+}
+
+func TestTimeoutHandler(t *testing.T) {
+	cfg := &config.ServerConfig{RequestTimeout: 50 * time.Millisecond}
+
+	t.Run("fast handler passes through", func(t *testing.T) {
+		handler := middleware.RequestContext(middleware.NewTimeoutHandler(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline := r.Context().Deadline()
+			assert.True(t, hasDeadline)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("ok"))
+		})))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "ok", rec.Body.String())
+	})
+
+	t.Run("slow handler gets 504 with request ID", func(t *testing.T) {
+		handler := middleware.RequestContext(middleware.NewTimeoutHandler(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			w.WriteHeader(http.StatusOK)
+		})))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+		require.Equal(t, http.StatusGatewayTimeout, rec.Code)
+
+		var body genapi.Error
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, genapi.REQUESTTIMEOUT, body.Error.Code)
+		require.NotNil(t, body.Error.RequestId)
+		assert.Equal(t, rec.Header().Get(middleware.RequestIDHeader), *body.Error.RequestId)
+	})
+
+	t.Run("handler failing on a cancelled call reports the timeout", func(t *testing.T) {
+		handler := middleware.NewTimeoutHandler(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			select {
+			case <-r.Context().Done():
+			case <-ctx.Done():
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+		assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	})
+}
@@ -0,0 +1,112 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"text/template"
+
+	"github.com/USSTM/cv-backend/internal/config"
+)
+
+// a message the team's chat channel can be sent
+type ChatEvent string
+
+const (
+	ChatRequestSubmitted ChatEvent = "request_submitted"
+	ChatRequestApproved  ChatEvent = "request_approved"
+	ChatItemOverdue      ChatEvent = "item_overdue"
+)
+
+var ChatEvents = []ChatEvent{ChatRequestSubmitted, ChatRequestApproved, ChatItemOverdue}
+
+// renders chat messages in the configured provider's markup, for the events
+// turned on in CHAT_EVENTS.
+type Chat struct {
+	templates *template.Template
+	escape    *strings.Replacer
+	// swapped whole by SetEvents
+	enabled atomic.Pointer[[]ChatEvent]
+}
+
+var chatMarkup = map[string]struct {
+	bold   string
+	escape *strings.Replacer
+}{
+	// https://api.slack.com/reference/surfaces/formatting#escaping
+	"slack":   {"*", strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")},
+	"discord": {"**", strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, ">", `\>`)},
+}
+
+// the chat messages for cfg, or nil when chat is turned off. fsys holds one
+// file per ChatEvent, each with a {{define "event"}} block; {{bold .X}}
+// renders in the provider's bold.
+func NewChat(cfg config.ChatConfig, fsys fs.FS) (*Chat, error) {
+	if cfg.WebhookURL == "" {
+		return nil, nil
+	}
+	markup, ok := chatMarkup[cfg.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown chat provider %q", cfg.Provider)
+	}
+
+	tmpl, err := template.New("chat").Funcs(template.FuncMap{
+		"bold": func(s any) string { return markup.bold + fmt.Sprint(s) + markup.bold },
+	}).ParseFS(fsys, "*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chat templates: %w", err)
+	}
+
+	c := &Chat{templates: tmpl, escape: markup.escape}
+	if err := c.SetEvents(cfg.Events); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// replaces the events posted, for a configuration reload; on an error the
+// old ones stay. Does nothing for a nil Chat: turning chat on or off takes a
+// restart.
+func (c *Chat) SetEvents(names []string) error {
+	if c == nil {
+		return nil
+	}
+	var enabled []ChatEvent
+	for _, name := range names {
+		if !slices.Contains(ChatEvents, ChatEvent(name)) {
+			return fmt.Errorf("unknown chat event %q", name)
+		}
+		if c.templates.Lookup(name) == nil {
+			return fmt.Errorf("no chat template for %q", name)
+		}
+		enabled = append(enabled, ChatEvent(name))
+	}
+	c.enabled.Store(&enabled)
+	return nil
+}
+
+// whether e is posted. False for a nil Chat.
+func (c *Chat) Enabled(e ChatEvent) bool {
+	return c != nil && slices.Contains(*c.enabled.Load(), e)
+}
+
+// e's message. Strings in data are escaped first, so a name can't inject
+// links or formatting.
+func (c *Chat) Render(e ChatEvent, data map[string]interface{}) (string, error) {
+	escaped := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		if s, ok := value.(string); ok {
+			value = c.escape.Replace(s)
+		}
+		escaped[key] = value
+	}
+
+	var buf bytes.Buffer
+	if err := c.templates.ExecuteTemplate(&buf, string(e), escaped); err != nil {
+		return "", fmt.Errorf("render chat message %q: %w", e, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,130 @@
+package notifications_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var chatSamples = map[notifications.ChatEvent]map[string]interface{}{
+	notifications.ChatRequestSubmitted: {"RequesterName": "sam@usstm.ca", "ItemName": "Sony A7 III", "Quantity": 1, "RequestID": "3f6c", "GroupName": "Film Club"},
+	notifications.ChatRequestApproved:  {"ApproverName": "vp@usstm.ca", "RequesterName": "sam@usstm.ca", "ItemName": "Sony A7 III", "Quantity": 1, "GroupName": "Film Club"},
+	notifications.ChatItemOverdue:      {"ItemName": "Sony A7 III", "Quantity": 1, "BorrowerEmail": "sam@usstm.ca", "GroupName": "Film Club", "DueDate": "2026-10-01"},
+}
+
+func TestNewChat(t *testing.T) {
+	chat, err := notifications.NewChat(config.ChatConfig{Provider: "slack"}, templates.Chat())
+	require.NoError(t, err)
+	assert.Nil(t, chat, "no webhook turns chat off")
+	assert.False(t, chat.Enabled(notifications.ChatItemOverdue))
+
+	_, err = notifications.NewChat(config.ChatConfig{Provider: "teams", WebhookURL: "https://example.com"}, templates.Chat())
+	assert.Error(t, err)
+
+	_, err = notifications.NewChat(config.ChatConfig{Provider: "slack", WebhookURL: "https://example.com", Events: []string{"item_returned"}}, templates.Chat())
+	assert.Error(t, err)
+
+	chat, err = notifications.NewChat(config.ChatConfig{Provider: "slack", WebhookURL: "https://example.com", Events: []string{"item_overdue"}}, templates.Chat())
+	require.NoError(t, err)
+	assert.True(t, chat.Enabled(notifications.ChatItemOverdue))
+	assert.False(t, chat.Enabled(notifications.ChatRequestSubmitted))
+}
+
+func TestChat_SetEvents(t *testing.T) {
+	chat, err := notifications.NewChat(config.ChatConfig{Provider: "slack", WebhookURL: "https://example.com", Events: []string{"item_overdue"}}, templates.Chat())
+	require.NoError(t, err)
+
+	require.NoError(t, chat.SetEvents([]string{"request_submitted"}))
+	assert.True(t, chat.Enabled(notifications.ChatRequestSubmitted))
+	assert.False(t, chat.Enabled(notifications.ChatItemOverdue))
+
+	assert.Error(t, chat.SetEvents([]string{"item_returned"}))
+	assert.True(t, chat.Enabled(notifications.ChatRequestSubmitted), "a bad list keeps the old events")
+
+	var off *notifications.Chat
+	assert.NoError(t, off.SetEvents([]string{"item_overdue"}))
+	assert.False(t, off.Enabled(notifications.ChatItemOverdue))
+}
+
+func TestChat_Render(t *testing.T) {
+	for _, provider := range []string{"slack", "discord"} {
+		chat, err := notifications.NewChat(config.ChatConfig{Provider: provider, WebhookURL: "https://example.com"}, templates.Chat())
+		require.NoError(t, err)
+
+		for _, e := range notifications.ChatEvents {
+			t.Run(provider+"/"+string(e), func(t *testing.T) {
+				text, err := chat.Render(e, chatSamples[e])
+				require.NoError(t, err)
+				assert.Contains(t, text, "Sony A7 III")
+				assert.NotContains(t, text, "<no value>")
+			})
+		}
+	}
+
+	t.Run("slack", func(t *testing.T) {
+		chat, err := notifications.NewChat(config.ChatConfig{Provider: "slack", WebhookURL: "https://example.com"}, templates.Chat())
+		require.NoError(t, err)
+		text, err := chat.Render(notifications.ChatItemOverdue, map[string]interface{}{
+			"ItemName": "Mic <!channel>", "Quantity": 2, "BorrowerEmail": "sam@usstm.ca", "GroupName": "Radio & TV", "DueDate": "2026-10-01",
+		})
+		require.NoError(t, err)
+		assert.Contains(t, text, "*Mic &lt;!channel&gt;*")
+		assert.Contains(t, text, "Radio &amp; TV")
+	})
+
+	t.Run("discord", func(t *testing.T) {
+		chat, err := notifications.NewChat(config.ChatConfig{Provider: "discord", WebhookURL: "https://example.com"}, templates.Chat())
+		require.NoError(t, err)
+		text, err := chat.Render(notifications.ChatItemOverdue, map[string]interface{}{
+			"ItemName": "**Tripod**", "Quantity": 2, "BorrowerEmail": "first_last@usstm.ca", "GroupName": "Film Club", "DueDate": "2026-10-01",
+		})
+		require.NoError(t, err)
+		assert.Contains(t, text, `**\*\*Tripod\*\***`)
+		assert.Contains(t, text, `first\_last@usstm.ca`)
+	})
+}
+
+func TestNotificationDispatcher_Announce(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	sharedQueue.Cleanup(t)
+	ctx := context.Background()
+
+	chat, err := notifications.NewChat(config.ChatConfig{
+		Provider:   "slack",
+		WebhookURL: "https://hooks.slack.example/T000/B000",
+		Events:     []string{"item_overdue"},
+	}, templates.Chat())
+	require.NoError(t, err)
+	svc := notifications.NewNotificationService(sharedDB.Pool(), sharedDB.Queries())
+	emailTemplates, err := notifications.LoadTemplates(templates.Email())
+	require.NoError(t, err)
+	d := notifications.NewNotificationDispatcher(svc, sharedQueue, emailTemplates, notifications.NewEmailLookupFunc(sharedDB.Queries()), notifications.NewRuleLookupFunc(sharedDB.Queries()), notifications.NewSandboxLookupFunc(sharedDB.Queries()), chat)
+
+	live := sharedDB.NewGroup(t).WithName("Film Club").Create()
+	sandbox := sharedDB.NewGroup(t).WithName("Training").AsSandbox().Create()
+
+	d.Announce(ctx, notifications.ChatItemOverdue, &live.ID, chatSamples[notifications.ChatItemOverdue])
+	// turned off in CHAT_EVENTS
+	d.Announce(ctx, notifications.ChatRequestSubmitted, &live.ID, chatSamples[notifications.ChatRequestSubmitted])
+	d.Announce(ctx, notifications.ChatItemOverdue, &sandbox.ID, chatSamples[notifications.ChatItemOverdue])
+
+	tasks, err := sharedQueue.Inspector.ListPendingTasks("default")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, queue.TypeChatDelivery, tasks[0].Type)
+
+	var payload queue.ChatDeliveryPayload
+	require.NoError(t, json.Unmarshal(tasks[0].Payload, &payload))
+	assert.Contains(t, payload.Text, "Overdue: 1 × *Sony A7 III*")
+}
@@ -1,7 +1,6 @@
 package notifications
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"html/template"
@@ -19,14 +18,34 @@ type NotifierGroup struct {
 	IDs          []uuid.UUID
 	Template     string
 	TemplateData map[string]interface{}
+	// email-only recipients with no user account, e.g. a team mailbox
+	Addresses []string
+	// matched against the routing rules for Template
+	Facts RoutingFacts
+	// the preference that lets users turn these emails off; "" uses the
+	// Template's Type
+	Type Type
+	// files sent with every email of the group, e.g. a calendar invite
+	Attachments []queue.EmailAttachment
 }
 
-// resolves UUIDs to email address.
-type EmailLookupFunc func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error)
+// the Type whose email preference applies to g's recipients
+func (g NotifierGroup) emailType() Type {
+	if g.Type != "" {
+		return g.Type
+	}
+	return TypeForTemplate(g.Template)
+}
+
+// resolves UUIDs to email address, leaving out users who turned off email,
+// or email of kind when kind is not "".
+type EmailLookupFunc func(ctx context.Context, ids []uuid.UUID, kind Type) (map[uuid.UUID]string, error)
 
 // full NotificationService interface needed by the dispatcher.
 type notificationSvc interface {
 	Publish(ctx context.Context, actorID uuid.UUID, entityTypeName string, entityID uuid.UUID, notifierIDs []uuid.UUID) error
+	PublishTo(ctx context.Context, actorID uuid.UUID, entityTypeName string, entityID uuid.UUID, recipients []Recipient) error
+	GetNotification(ctx context.Context, userID, notificationID uuid.UUID) (db.GetUserNotificationRow, error)
 	GetUserNotifications(ctx context.Context, userID uuid.UUID, limit, offset int64) ([]db.GetUserNotificationsRow, error)
 	MarkAsRead(ctx context.Context, userID, notificationID uuid.UUID) (db.Notification, error)
 	MarkAllAsRead(ctx context.Context, userID uuid.UUID) error
@@ -36,80 +55,209 @@ type notificationSvc interface {
 
 // subset of TaskQueue.
 type queueService interface {
-	Enqueue(taskType string, data interface{}) (*asynq.TaskInfo, error)
+	Enqueue(ctx context.Context, taskType string, data interface{}) (*asynq.TaskInfo, error)
+}
+
+type sandboxKey struct{}
+
+// marks ctx as belonging to a sandbox group; emails are logged instead of sent.
+func WithSandbox(ctx context.Context, sandbox bool) context.Context {
+	return context.WithValue(ctx, sandboxKey{}, sandbox)
+}
+
+func IsSandbox(ctx context.Context) bool {
+	sandbox, _ := ctx.Value(sandboxKey{}).(bool)
+	return sandbox
+}
+
+// reports whether a group is in sandbox mode.
+type SandboxLookupFunc func(ctx context.Context, groupID uuid.UUID) (bool, error)
+
+func NewSandboxLookupFunc(queries *db.Queries) SandboxLookupFunc {
+	return func(ctx context.Context, groupID uuid.UUID) (bool, error) {
+		return queries.IsGroupSandbox(ctx, groupID)
+	}
 }
 
 type NotificationDispatcher struct {
-	svc         notificationSvc
-	queue       queueService
-	templates   *template.Template
-	emailLookup EmailLookupFunc
+	svc           notificationSvc
+	queue         queueService
+	templates     *template.Template
+	emailLookup   EmailLookupFunc
+	ruleLookup    RuleLookupFunc
+	sandboxLookup SandboxLookupFunc
+	chat          *Chat
 }
 
-func NewNotificationDispatcher(svc notificationSvc, q queueService, tmpl *template.Template, lookup EmailLookupFunc) *NotificationDispatcher {
+// rules may be nil, in which case notifications go only to the recipients
+// their callers name. sandbox may be nil, in which case only a ctx marked
+// with WithSandbox suppresses emails. chat may be nil, in which case nothing
+// is posted to the team's chat channel.
+func NewNotificationDispatcher(svc notificationSvc, q queueService, tmpl *template.Template, lookup EmailLookupFunc, rules RuleLookupFunc, sandbox SandboxLookupFunc, chat *Chat) *NotificationDispatcher {
 	return &NotificationDispatcher{
-		svc:         svc,
-		queue:       q,
-		templates:   tmpl,
-		emailLookup: lookup,
+		svc:           svc,
+		queue:         q,
+		templates:     tmpl,
+		emailLookup:   lookup,
+		ruleLookup:    rules,
+		sandboxLookup: sandbox,
+		chat:          chat,
 	}
 }
 
-// writes in-app notifications for all groups, then enqueues emails for
-// groups that specify a template. Email failures are logged, not returned.
+// applies the routing rules, writes in-app notifications for all groups,
+// then enqueues emails for groups that specify a template. A group's email
+// subject doubles as the title of its in-app notifications. Email failures
+// are logged, not returned.
 func (d *NotificationDispatcher) Notify(ctx context.Context, actorID uuid.UUID, entityType string, entityID uuid.UUID, groups []NotifierGroup) error {
-	var allIDs []uuid.UUID
-	for _, g := range groups {
-		allIDs = append(allIDs, g.IDs...)
+	groups = d.route(ctx, groups)
+
+	var recipients []Recipient
+	hasAddresses := false
+	messages := make([]*Message, len(groups))
+	for i, g := range groups {
+		hasAddresses = hasAddresses || len(g.Addresses) > 0
+		if g.Template != "" && (len(g.IDs) > 0 || len(g.Addresses) > 0) {
+			msg, err := d.renderTemplate(g.Template, g.TemplateData)
+			if err != nil {
+				logging.FromContext(ctx).Error("failed to render notification template", "template", g.Template, "error", err)
+			} else {
+				messages[i] = &msg
+			}
+		}
+		for _, id := range g.IDs {
+			r := Recipient{ID: id}
+			if messages[i] != nil {
+				r.Title = messages[i].Subject
+			}
+			recipients = append(recipients, r)
+		}
 	}
 
-	if len(allIDs) == 0 {
+	if len(recipients) == 0 && !hasAddresses {
 		return nil
 	}
 
-	if err := d.svc.Publish(ctx, actorID, entityType, entityID, allIDs); err != nil {
-		return fmt.Errorf("failed to publish in-app notification: %w", err)
+	if len(recipients) > 0 {
+		if err := d.svc.PublishTo(ctx, actorID, entityType, entityID, recipients); err != nil {
+			return fmt.Errorf("failed to publish in-app notification: %w", err)
+		}
 	}
 
-	for _, g := range groups {
-		if g.Template == "" {
+	for i, g := range groups {
+		if messages[i] == nil {
 			continue
 		}
-		d.sendGroupEmails(ctx, g)
+		d.sendGroupEmails(ctx, g, *messages[i])
 	}
 
 	return nil
 }
 
-func (d *NotificationDispatcher) sendGroupEmails(ctx context.Context, g NotifierGroup) {
-	if len(g.IDs) == 0 {
+// groups with a template after their routing rules; a failed lookup leaves
+// the groups as they were.
+func (d *NotificationDispatcher) route(ctx context.Context, groups []NotifierGroup) []NotifierGroup {
+	if d.ruleLookup == nil {
+		return groups
+	}
+	routed := make([]NotifierGroup, 0, len(groups))
+	for _, g := range groups {
+		if g.Template == "" {
+			routed = append(routed, g)
+			continue
+		}
+		rules, err := d.ruleLookup(ctx, g.Template)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to load notification routing rules", "template", g.Template, "error", err)
+			routed = append(routed, g)
+			continue
+		}
+		routed = append(routed, Route(g, rules))
+	}
+	return routed
+}
+
+func (d *NotificationDispatcher) sendGroupEmails(ctx context.Context, g NotifierGroup, msg Message) {
+	logger := logging.FromContext(ctx)
+
+	emails := map[uuid.UUID]string{}
+	if len(g.IDs) > 0 {
+		if d.emailLookup == nil {
+			logger.Error("email lookup func is nil, skipping email dispatch", "template", g.Template)
+			return
+		}
+		var err error
+		emails, err = d.emailLookup(ctx, g.IDs, g.emailType())
+		if err != nil {
+			logger.Error("failed to look up emails for notification", "template", g.Template, "error", err)
+			return
+		}
+	}
+	recipients := make([]string, 0, len(emails)+len(g.Addresses))
+	for _, email := range emails {
+		recipients = append(recipients, email)
+	}
+	recipients = append(recipients, g.Addresses...)
+
+	if d.sandboxed(ctx, g) {
+		for _, email := range recipients {
+			logger.Info("sandbox group, suppressed notification email", "to", email, "subject", msg.Subject, "template", g.Template)
+		}
 		return
 	}
-	if d.emailLookup == nil {
-		logging.Error("email lookup func is nil, skipping email dispatch", "template", g.Template)
+
+	for _, email := range recipients {
+		if _, err := d.queue.Enqueue(ctx, queue.TypeEmailDelivery, queue.EmailDeliveryPayload{
+			To:          email,
+			Subject:     msg.Subject,
+			Body:        msg.Text,
+			HTMLBody:    msg.HTML,
+			Attachments: g.Attachments,
+		}); err != nil {
+			logger.Error("failed to enqueue notification email", "to", email, "template", g.Template, "error", err)
+		}
+	}
+}
+
+// posts e to the team's chat channel when chat is configured and e is turned
+// on. Like emails, nothing is posted about sandbox groups; failures are
+// logged, not returned.
+func (d *NotificationDispatcher) Announce(ctx context.Context, e ChatEvent, groupID *uuid.UUID, data map[string]interface{}) {
+	logger := logging.FromContext(ctx)
+
+	if !d.chat.Enabled(e) {
 		return
 	}
-	emails, err := d.emailLookup(ctx, g.IDs)
+	text, err := d.chat.Render(e, data)
 	if err != nil {
-		logging.Error("failed to look up emails for notification", "template", g.Template, "error", err)
+		logger.Error("failed to render chat message", "event", e, "error", err)
 		return
 	}
-
-	subject, body, err := d.renderTemplate(g.Template, g.TemplateData)
-	if err != nil {
-		logging.Error("failed to render notification template", "template", g.Template, "error", err)
+	if d.sandboxed(ctx, NotifierGroup{Template: string(e), Facts: RoutingFacts{GroupID: groupID}}) {
+		logger.Info("sandbox group, suppressed chat message", "event", e, "text", text)
 		return
 	}
+	if _, err := d.queue.Enqueue(ctx, queue.TypeChatDelivery, queue.ChatDeliveryPayload{Text: text}); err != nil {
+		logger.Error("failed to enqueue chat message", "event", e, "error", err)
+	}
+}
 
-	for _, email := range emails {
-		if _, err := d.queue.Enqueue(queue.TypeEmailDelivery, queue.EmailDeliveryPayload{
-			To:      email,
-			Subject: subject,
-			Body:    body,
-		}); err != nil {
-			logging.Error("failed to enqueue notification email", "to", email, "template", g.Template, "error", err)
-		}
+// whether g's emails must be suppressed: ctx was marked by an API handler,
+// or the group the notification is about is a sandbox group. Workers rely on
+// the latter since nothing marks their ctx.
+func (d *NotificationDispatcher) sandboxed(ctx context.Context, g NotifierGroup) bool {
+	if IsSandbox(ctx) {
+		return true
 	}
+	if d.sandboxLookup == nil || g.Facts.GroupID == nil {
+		return false
+	}
+	sandbox, err := d.sandboxLookup(ctx, *g.Facts.GroupID)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to check group sandbox flag", "group_id", *g.Facts.GroupID, "template", g.Template, "error", err)
+		return false
+	}
+	return sandbox
 }
 
 // only expose dispatcher, notiService should be wrapped under disptacher
@@ -118,6 +266,14 @@ func (d *NotificationDispatcher) Publish(ctx context.Context, actorID uuid.UUID,
 	return d.svc.Publish(ctx, actorID, entityTypeName, entityID, notifierIDs)
 }
 
+func (d *NotificationDispatcher) PublishTo(ctx context.Context, actorID uuid.UUID, entityTypeName string, entityID uuid.UUID, recipients []Recipient) error {
+	return d.svc.PublishTo(ctx, actorID, entityTypeName, entityID, recipients)
+}
+
+func (d *NotificationDispatcher) GetNotification(ctx context.Context, userID, notificationID uuid.UUID) (db.GetUserNotificationRow, error) {
+	return d.svc.GetNotification(ctx, userID, notificationID)
+}
+
 func (d *NotificationDispatcher) GetUserNotifications(ctx context.Context, userID uuid.UUID, limit, offset int64) ([]db.GetUserNotificationsRow, error) {
 	return d.svc.GetUserNotifications(ctx, userID, limit, offset)
 }
@@ -138,17 +294,12 @@ func (d *NotificationDispatcher) GetTotalCount(ctx context.Context, userID uuid.
 	return d.svc.GetTotalCount(ctx, userID)
 }
 
-// {{define "name:subject"}} and {{define "name:body"}}
-func (d *NotificationDispatcher) renderTemplate(name string, data map[string]interface{}) (subject, body string, err error) {
-	var subjectBuf bytes.Buffer
-	if err = d.templates.ExecuteTemplate(&subjectBuf, name+":subject", data); err != nil {
-		return "", "", fmt.Errorf("render subject for %q: %w", name, err)
-	}
-
-	var bodyBuf bytes.Buffer
-	if err = d.templates.ExecuteTemplate(&bodyBuf, name+":body", data); err != nil {
-		return "", "", fmt.Errorf("render body for %q: %w", name, err)
-	}
+// whether name is a loaded email template, so routing rules can be checked
+// before they are saved.
+func (d *NotificationDispatcher) HasTemplate(name string) bool {
+	return d.templates != nil && d.templates.Lookup(name+":subject") != nil
+}
 
-	return subjectBuf.String(), bodyBuf.String(), nil
+func (d *NotificationDispatcher) renderTemplate(name string, data map[string]interface{}) (Message, error) {
+	return RenderTemplate(d.templates, name, data)
 }
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/USSTM/cv-backend/generated/db"
 	"github.com/USSTM/cv-backend/internal/notifications"
 	"github.com/USSTM/cv-backend/internal/preferences"
 	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/templates"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,9 +18,9 @@ import (
 func newTestDispatcher(t *testing.T) *notifications.NotificationDispatcher {
 	t.Helper()
 	svc := notifications.NewNotificationService(sharedDB.Pool(), sharedDB.Queries())
-	emailTemplates, err := notifications.LoadTemplates("../../templates/email")
+	emailTemplates, err := notifications.LoadTemplates(templates.Email())
 	require.NoError(t, err)
-	return notifications.NewNotificationDispatcher(svc, sharedQueue, emailTemplates, notifications.NewEmailLookupFunc(sharedDB.Queries()))
+	return notifications.NewNotificationDispatcher(svc, sharedQueue, emailTemplates, notifications.NewEmailLookupFunc(sharedDB.Queries()), notifications.NewRuleLookupFunc(sharedDB.Queries()), notifications.NewSandboxLookupFunc(sharedDB.Queries()), nil)
 }
 
 func TestNotificationDispatcher_Notify_InAppOnly(t *testing.T) {
@@ -43,8 +45,9 @@ func TestNotificationDispatcher_Notify_InAppOnly(t *testing.T) {
 
 	notifs, err := d.GetUserNotifications(ctx, notifier.ID, 10, 0)
 	require.NoError(t, err)
-	assert.Len(t, notifs, 1)
+	require.Len(t, notifs, 1)
 	assert.Equal(t, entityID, notifs[0].EntityID)
+	assert.False(t, notifs[0].Title.Valid, "no email, so no title")
 
 	// queue not found means no tasks were ever enqueued — treat as empty
 	tasks, _ := sharedQueue.Inspector.ListPendingTasks("default")
@@ -81,7 +84,7 @@ func TestNotificationDispatcher_Notify_WithEmail(t *testing.T) {
 
 	notifs, err := d.GetUserNotifications(ctx, notifier.ID, 10, 0)
 	require.NoError(t, err)
-	assert.Len(t, notifs, 1)
+	require.Len(t, notifs, 1)
 
 	tasks, err := sharedQueue.Inspector.ListPendingTasks("default")
 	require.NoError(t, err)
@@ -92,6 +95,84 @@ func TestNotificationDispatcher_Notify_WithEmail(t *testing.T) {
 	require.NoError(t, json.Unmarshal(tasks[0].Payload, &payload))
 	assert.Equal(t, "notifier2@example.com", payload.To)
 	assert.Contains(t, payload.Subject, "Test Item")
+	assert.Equal(t, payload.Subject, notifs[0].Title.String, "the in-app notification is titled with the email's subject")
+}
+
+func TestNotificationDispatcher_Notify_Attachments(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	sharedQueue.Cleanup(t)
+
+	ctx := context.Background()
+	actor := sharedDB.NewUser(t).WithEmail("actor-ics@example.com").Create()
+	notifier := sharedDB.NewUser(t).WithEmail("notifier-ics@example.com").Create()
+
+	d := newTestDispatcher(t)
+	ics := queue.EmailAttachment{Filename: "booking.ics", ContentType: "text/calendar", Data: []byte("BEGIN:VCALENDAR\r\n")}
+
+	err := d.Notify(ctx, actor.ID, "booking", uuid.New(), []notifications.NotifierGroup{
+		{
+			IDs:      []uuid.UUID{notifier.ID},
+			Template: "booking_confirmed_requester",
+			TemplateData: map[string]interface{}{
+				"ItemName":       "Camera",
+				"PickupDate":     "2026-10-20 09:00",
+				"PickupLocation": "Room 101",
+				"ReturnDate":     "2026-10-27 09:00",
+				"ReturnLocation": "Room 101",
+			},
+			Attachments: []queue.EmailAttachment{ics},
+		},
+	})
+	require.NoError(t, err)
+
+	tasks, err := sharedQueue.Inspector.ListPendingTasks("default")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+
+	var payload queue.EmailDeliveryPayload
+	require.NoError(t, json.Unmarshal(tasks[0].Payload, &payload))
+	assert.Equal(t, []queue.EmailAttachment{ics}, payload.Attachments)
+}
+
+func TestNotificationDispatcher_Notify_SandboxSuppressesEmail(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	sharedQueue.Cleanup(t)
+
+	ctx := notifications.WithSandbox(context.Background(), true)
+	actor := sharedDB.NewUser(t).WithEmail("actor5@example.com").Create()
+	notifier := sharedDB.NewUser(t).WithEmail("notifier5@example.com").Create()
+
+	d := newTestDispatcher(t)
+	entityID := uuid.New()
+
+	err := d.Notify(ctx, actor.ID, "general", entityID, []notifications.NotifierGroup{
+		{
+			IDs:      []uuid.UUID{notifier.ID},
+			Template: "request_approved_requester",
+			TemplateData: map[string]interface{}{
+				"UserName":  "Test User",
+				"ItemName":  "Test Item",
+				"RequestID": entityID.String(),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	// in-app notification is still written
+	notifs, err := d.GetUserNotifications(ctx, notifier.ID, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, notifs, 1)
+
+	tasks, _ := sharedQueue.Inspector.ListPendingTasks("default")
+	assert.Empty(t, tasks, "sandbox emails should be logged, not enqueued")
 }
 
 func TestNotificationDispatcher_Notify_MultiGroup(t *testing.T) {
@@ -221,7 +302,8 @@ func TestNotificationDispatcher_Notify_EmailOptOut(t *testing.T) {
 
 	inAppOptedOut, err := d.GetUserNotifications(ctx, optedOut.ID, 10, 0)
 	require.NoError(t, err)
-	assert.Len(t, inAppOptedOut, 1)
+	require.Len(t, inAppOptedOut, 1)
+	assert.Equal(t, "Your request for Laptop has been approved", inAppOptedOut[0].Title.String, "the inbox doesn't depend on the email being sent")
 
 	tasks, err := sharedQueue.Inspector.ListPendingTasks("default")
 	require.NoError(t, err)
@@ -231,3 +313,61 @@ func TestNotificationDispatcher_Notify_EmailOptOut(t *testing.T) {
 	require.NoError(t, json.Unmarshal(tasks[0].Payload, &payload))
 	assert.Equal(t, "optedin@example.com", payload.To)
 }
+
+func TestNotificationDispatcher_Notify_TypeOptOut(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	sharedQueue.Cleanup(t)
+
+	ctx := context.Background()
+	actor := sharedDB.NewUser(t).WithEmail("actor6@example.com").Create()
+	user := sharedDB.NewUser(t).WithEmail("typeoptout@example.com").Create()
+
+	// due-soon reminders off, overdue notices left on
+	require.NoError(t, sharedDB.Queries().UpsertNotificationPreference(ctx, db.UpsertNotificationPreferenceParams{
+		UserID:           user.ID,
+		NotificationType: string(notifications.TypeDueSoon),
+		EmailEnabled:     false,
+	}))
+
+	d := newTestDispatcher(t)
+	reminder := func(kind notifications.Type, daysOverdue int) notifications.NotifierGroup {
+		return notifications.NotifierGroup{
+			IDs:      []uuid.UUID{user.ID},
+			Template: "borrowing_return_reminder",
+			Type:     kind,
+			TemplateData: map[string]interface{}{
+				"ItemName":    "Laptop",
+				"Quantity":    1,
+				"DueDate":     "2026-01-01",
+				"DaysLeft":    0,
+				"DaysOverdue": daysOverdue,
+			},
+		}
+	}
+
+	require.NoError(t, d.Notify(ctx, actor.ID, "borrowing", uuid.New(), []notifications.NotifierGroup{
+		reminder(notifications.TypeDueSoon, 0),
+	}))
+	tasks, _ := sharedQueue.Inspector.ListPendingTasks("default")
+	assert.Empty(t, tasks, "due-soon email should be suppressed")
+
+	inApp, err := d.GetUserNotifications(ctx, user.ID, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, inApp, 1, "in-app notifications ignore email preferences")
+
+	require.NoError(t, d.Notify(ctx, actor.ID, "borrowing", uuid.New(), []notifications.NotifierGroup{
+		reminder(notifications.TypeOverdue, 3),
+	}))
+	tasks, err = sharedQueue.Inspector.ListPendingTasks("default")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+
+	var payload queue.EmailDeliveryPayload
+	require.NoError(t, json.Unmarshal(tasks[0].Payload, &payload))
+	assert.Equal(t, "typeoptout@example.com", payload.To)
+	assert.Contains(t, payload.Subject, "Overdue")
+}
@@ -0,0 +1,137 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/queue"
+)
+
+// sends the emails the worker delivers; every provider can attach files.
+type EmailSender interface {
+	// htmlBody may be empty, in which case only the text part is sent
+	SendEmail(ctx context.Context, to, subject, textBody, htmlBody string) error
+	SendEmailWithAttachments(ctx context.Context, to, subject, textBody, htmlBody string, attachments []queue.EmailAttachment) error
+}
+
+// the EmailSender for cfg.Provider. SES uses the AWS credentials and region
+// the rest of the app does.
+func NewEmailSender(cfg config.EmailConfig, awsCfg config.AWSConfig) (EmailSender, error) {
+	switch cfg.Provider {
+	case "ses":
+		return NewSESSender(awsCfg, cfg.From)
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("smtp needs SMTP_HOST")
+		}
+		return NewSMTPSender(cfg), nil
+	case "", "log":
+		return logEmailSender{}, nil
+	default:
+		return nil, fmt.Errorf("unknown email provider %q", cfg.Provider)
+	}
+}
+
+// for development and tests: emails are logged instead of sent.
+type logEmailSender struct{}
+
+func (logEmailSender) SendEmail(ctx context.Context, to, subject, textBody, htmlBody string) error {
+	logging.FromContext(ctx).Info("Email not sent, no provider configured", "to", to, "subject", subject, "body", textBody)
+	return nil
+}
+
+func (logEmailSender) SendEmailWithAttachments(ctx context.Context, to, subject, textBody, htmlBody string, attachments []queue.EmailAttachment) error {
+	filenames := make([]string, 0, len(attachments))
+	for _, a := range attachments {
+		filenames = append(filenames, a.Filename)
+	}
+	logging.FromContext(ctx).Info("Email not sent, no provider configured", "to", to, "subject", subject, "body", textBody, "attachments", filenames)
+	return nil
+}
+
+// a multipart/mixed message: the text and HTML bodies as alternatives,
+// followed by the attachments.
+func rawEmail(from, to, subject, textBody, htmlBody string, attachments []queue.EmailAttachment) ([]byte, error) {
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mixed.Boundary())
+
+	var body bytes.Buffer
+	alternative := multipart.NewWriter(&body)
+	if err := writeQuotedPrintable(alternative, "text/plain; charset=UTF-8", textBody); err != nil {
+		return nil, err
+	}
+	if htmlBody != "" {
+		if err := writeQuotedPrintable(alternative, "text/html; charset=UTF-8", htmlBody); err != nil {
+			return nil, err
+		}
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + fmt.Sprintf("%q", alternative.Boundary())},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(body.Bytes()); err != nil {
+		return nil, err
+	}
+
+	for _, a := range attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		// RFC 2045 caps encoded lines at 76 characters
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			fmt.Fprintf(part, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		fmt.Fprintf(part, "%s\r\n", encoded)
+	}
+
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w *multipart.Writer, contentType, body string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
@@ -0,0 +1,61 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRawEmail(t *testing.T) {
+	ics := []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")
+	raw, err := rawEmail("noreply@example.com", "member@example.com", "Booking confirmé", "See you at pickup", "<p>See you at pickup</p>",
+		[]queue.EmailAttachment{{Filename: "booking.ics", ContentType: "text/calendar; charset=UTF-8; method=PUBLISH", Data: ics}})
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Booking confirmé", subject)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+
+	body, err := parts.NextPart()
+	require.NoError(t, err)
+	mediaType, params, err = mime.ParseMediaType(body.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+	alternatives := multipart.NewReader(body, params["boundary"])
+	for _, want := range []string{"See you at pickup", "<p>See you at pickup</p>"} {
+		part, err := alternatives.NextPart()
+		require.NoError(t, err)
+		got, err := io.ReadAll(part)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got))
+	}
+
+	attachment, err := parts.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "booking.ics", attachment.FileName())
+	assert.Equal(t, "text/calendar; charset=UTF-8; method=PUBLISH", attachment.Header.Get("Content-Type"))
+	encoded, err := io.ReadAll(attachment)
+	require.NoError(t, err)
+	decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(encoded)))
+	require.NoError(t, err)
+	assert.Equal(t, ics, decoded)
+
+	_, err = parts.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,83 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/google/uuid"
+)
+
+// what routing rules can match a notification on. Zero values only match
+// rules that leave the condition unset.
+type RoutingFacts struct {
+	ItemID      *uuid.UUID
+	ItemType    db.ItemType
+	GroupID     *uuid.UUID
+	DaysOverdue int
+}
+
+// loads the enabled routing rules for an email template.
+type RuleLookupFunc func(ctx context.Context, template string) ([]db.NotificationRoutingRule, error)
+
+func NewRuleLookupFunc(queries *db.Queries) RuleLookupFunc {
+	return func(ctx context.Context, template string) ([]db.NotificationRoutingRule, error) {
+		return queries.ListEnabledRoutingRules(ctx, template)
+	}
+}
+
+// whether rule applies to a notification with the given facts.
+func RuleMatches(rule db.NotificationRoutingRule, facts RoutingFacts) bool {
+	if rule.ItemID != nil && (facts.ItemID == nil || *rule.ItemID != *facts.ItemID) {
+		return false
+	}
+	if rule.ItemType.Valid && rule.ItemType.ItemType != facts.ItemType {
+		return false
+	}
+	if rule.GroupID != nil && (facts.GroupID == nil || *rule.GroupID != *facts.GroupID) {
+		return false
+	}
+	if rule.MinDaysOverdue.Valid && facts.DaysOverdue < int(rule.MinDaysOverdue.Int32) {
+		return false
+	}
+	return true
+}
+
+// adds the recipients of every matching rule to g. If any matching rule
+// replaces the default, g's own recipients are dropped.
+func Route(g NotifierGroup, rules []db.NotificationRoutingRule) NotifierGroup {
+	var ids []uuid.UUID
+	var addresses []string
+	replace := false
+	for _, rule := range rules {
+		if rule.Template != g.Template || !RuleMatches(rule, g.Facts) {
+			continue
+		}
+		if rule.RecipientUserID != nil {
+			ids = append(ids, *rule.RecipientUserID)
+		}
+		if rule.RecipientEmail.Valid {
+			addresses = append(addresses, rule.RecipientEmail.String)
+		}
+		replace = replace || rule.ReplaceDefault
+	}
+
+	if !replace {
+		ids = append(append([]uuid.UUID{}, g.IDs...), ids...)
+		addresses = append(append([]string{}, g.Addresses...), addresses...)
+	}
+	g.IDs = dedupe(ids)
+	g.Addresses = dedupe(addresses)
+	return g
+}
+
+func dedupe[T comparable](values []T) []T {
+	seen := make(map[T]bool, len(values))
+	out := make([]T, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
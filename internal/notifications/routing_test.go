@@ -0,0 +1,124 @@
+package notifications_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleMatches(t *testing.T) {
+	itemID := uuid.New()
+	groupID := uuid.New()
+	facts := notifications.RoutingFacts{ItemID: &itemID, ItemType: db.ItemTypeHigh, GroupID: &groupID, DaysOverdue: 5}
+
+	otherID := uuid.New()
+	tests := []struct {
+		name string
+		rule db.NotificationRoutingRule
+		want bool
+	}{
+		{"no conditions", db.NotificationRoutingRule{}, true},
+		{"same item", db.NotificationRoutingRule{ItemID: &itemID}, true},
+		{"other item", db.NotificationRoutingRule{ItemID: &otherID}, false},
+		{"same type", db.NotificationRoutingRule{ItemType: db.NullItemType{ItemType: db.ItemTypeHigh, Valid: true}}, true},
+		{"other type", db.NotificationRoutingRule{ItemType: db.NullItemType{ItemType: db.ItemTypeLow, Valid: true}}, false},
+		{"other group", db.NotificationRoutingRule{GroupID: &otherID}, false},
+		{"overdue long enough", db.NotificationRoutingRule{MinDaysOverdue: pgtype.Int4{Int32: 5, Valid: true}}, true},
+		{"not overdue long enough", db.NotificationRoutingRule{MinDaysOverdue: pgtype.Int4{Int32: 6, Valid: true}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, notifications.RuleMatches(tt.rule, facts))
+		})
+	}
+
+	t.Run("unset facts never match a set condition", func(t *testing.T) {
+		assert.False(t, notifications.RuleMatches(db.NotificationRoutingRule{GroupID: &groupID}, notifications.RoutingFacts{}))
+	})
+}
+
+func TestRoute(t *testing.T) {
+	defaultID := uuid.New()
+	routedID := uuid.New()
+	group := notifications.NotifierGroup{IDs: []uuid.UUID{defaultID}, Template: "return_overdue_group_admin"}
+
+	t.Run("adds recipients to the defaults", func(t *testing.T) {
+		routed := notifications.Route(group, []db.NotificationRoutingRule{
+			{Template: "return_overdue_group_admin", RecipientUserID: &routedID},
+			{Template: "return_overdue_group_admin", RecipientEmail: pgtype.Text{String: "av@example.com", Valid: true}},
+			{Template: "return_overdue_group_admin", RecipientUserID: &defaultID},
+		})
+		assert.Equal(t, []uuid.UUID{defaultID, routedID}, routed.IDs)
+		assert.Equal(t, []string{"av@example.com"}, routed.Addresses)
+	})
+
+	t.Run("replace drops the defaults", func(t *testing.T) {
+		routed := notifications.Route(group, []db.NotificationRoutingRule{
+			{Template: "return_overdue_group_admin", RecipientUserID: &routedID, ReplaceDefault: true},
+		})
+		assert.Equal(t, []uuid.UUID{routedID}, routed.IDs)
+	})
+
+	t.Run("ignores rules for other templates", func(t *testing.T) {
+		routed := notifications.Route(group, []db.NotificationRoutingRule{
+			{Template: "request_approved_requester", RecipientUserID: &routedID, ReplaceDefault: true},
+		})
+		assert.Equal(t, []uuid.UUID{defaultID}, routed.IDs)
+	})
+}
+
+func TestNotificationDispatcher_Notify_RoutingRuleEmail(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	sharedQueue.Cleanup(t)
+
+	ctx := context.Background()
+	actor := sharedDB.NewUser(t).WithEmail("actor-routing@example.com").Create()
+	group := sharedDB.NewGroup(t).WithName("AV Club").Create()
+
+	_, err := sharedDB.Queries().CreateRoutingRule(ctx, db.CreateRoutingRuleParams{
+		Name:           "AV desk",
+		Template:       "request_submitted_approver",
+		GroupID:        &group.ID,
+		RecipientEmail: pgtype.Text{String: "av-desk@example.com", Valid: true},
+		Enabled:        true,
+	})
+	require.NoError(t, err)
+
+	d := newTestDispatcher(t)
+	requestID := uuid.New()
+	err = d.Notify(ctx, actor.ID, "request", requestID, []notifications.NotifierGroup{
+		{
+			Template: "request_submitted_approver",
+			TemplateData: map[string]interface{}{
+				"RequesterName": "Test User",
+				"ItemName":      "Projector",
+				"Quantity":      1,
+				"RequestID":     requestID.String(),
+			},
+			Facts: notifications.RoutingFacts{GroupID: &group.ID},
+		},
+	})
+	require.NoError(t, err)
+
+	tasks, err := sharedQueue.Inspector.ListPendingTasks("default")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, queue.TypeEmailDelivery, tasks[0].Type)
+
+	var payload queue.EmailDeliveryPayload
+	require.NoError(t, json.Unmarshal(tasks[0].Payload, &payload))
+	assert.Equal(t, "av-desk@example.com", payload.To)
+	assert.Contains(t, payload.Subject, "Projector")
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/USSTM/cv-backend/generated/db"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -21,7 +22,23 @@ func NewNotificationService(pool *pgxpool.Pool, queries *db.Queries) *Notificati
 	}
 }
 
+// someone to notify, and what their inbox says about it. An empty Title
+// leaves the inbox to describe the notification by its entity type.
+type Recipient struct {
+	ID    uuid.UUID
+	Title string
+}
+
 func (s *NotificationService) Publish(ctx context.Context, actorID uuid.UUID, entityTypeName string, entityID uuid.UUID, notifierIDs []uuid.UUID) error {
+	recipients := make([]Recipient, 0, len(notifierIDs))
+	for _, id := range notifierIDs {
+		recipients = append(recipients, Recipient{ID: id})
+	}
+	return s.PublishTo(ctx, actorID, entityTypeName, entityID, recipients)
+}
+
+// like Publish, with a title for each recipient's notification
+func (s *NotificationService) PublishTo(ctx context.Context, actorID uuid.UUID, entityTypeName string, entityID uuid.UUID, recipients []Recipient) error {
 	entityType, err := s.db.GetNotificationEntityTypeByName(ctx, entityTypeName)
 	if err != nil {
 		return fmt.Errorf("failed to get entity type %s: %w", entityTypeName, err)
@@ -52,17 +69,18 @@ func (s *NotificationService) Publish(ctx context.Context, actorID uuid.UUID, en
 		return fmt.Errorf("failed to create notification change: %w", err)
 	}
 
-	for _, notifierID := range notifierIDs {
-		if notifierID == actorID {
+	for _, r := range recipients {
+		if r.ID == actorID {
 			continue
 		}
 
 		_, err = qtx.CreateNotification(ctx, db.CreateNotificationParams{
 			NotificationObjectID: obj.ID,
-			NotifierID:           notifierID,
+			NotifierID:           r.ID,
+			Title:                pgtype.Text{String: r.Title, Valid: r.Title != ""},
 		})
 		if err != nil {
-			return fmt.Errorf("failed to create notification for %s: %w", notifierID, err)
+			return fmt.Errorf("failed to create notification for %s: %w", r.ID, err)
 		}
 	}
 
@@ -77,6 +95,14 @@ func (s *NotificationService) GetUserNotifications(ctx context.Context, userID u
 	})
 }
 
+// one of userID's notifications; pgx.ErrNoRows when it is someone else's
+func (s *NotificationService) GetNotification(ctx context.Context, userID, notificationID uuid.UUID) (db.GetUserNotificationRow, error) {
+	return s.db.GetUserNotification(ctx, db.GetUserNotificationParams{
+		ID:         notificationID,
+		NotifierID: userID,
+	})
+}
+
 func (s *NotificationService) MarkAsRead(ctx context.Context, userID, notificationID uuid.UUID) (db.Notification, error) {
 	return s.db.MarkNotificationAsRead(ctx, db.MarkNotificationAsReadParams{
 		ID:         notificationID,
@@ -0,0 +1,104 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+
+	appaws "github.com/USSTM/cv-backend/internal/aws"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+)
+
+// sends email through Amazon SES, or LocalStack when cfg has an endpoint.
+type SESSender struct {
+	client *ses.Client
+	sender string
+}
+
+func NewSESSender(cfg config.AWSConfig, from string) (*SESSender, error) {
+	awsCfg, err := appaws.LoadAWSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// create SES client, overriding endpoint if provided (for LocalStack)
+	client := ses.NewFromConfig(awsCfg, func(o *ses.Options) {
+		if cfg.EndpointURL != "" {
+			o.BaseEndpoint = aws.String(cfg.EndpointURL)
+		}
+	})
+
+	return &SESSender{
+		client: client,
+		sender: from,
+	}, nil
+}
+
+// htmlBody is optional; clients that can't show it fall back to textBody
+func (s *SESSender) SendEmail(ctx context.Context, to string, subject string, textBody string, htmlBody string) error {
+	body := &types.Body{
+		Text: &types.Content{
+			Data:    aws.String(textBody),
+			Charset: aws.String("UTF-8"),
+		},
+	}
+	if htmlBody != "" {
+		body.Html = &types.Content{
+			Data:    aws.String(htmlBody),
+			Charset: aws.String("UTF-8"),
+		}
+	}
+
+	input := &ses.SendEmailInput{
+		Destination: &types.Destination{
+			ToAddresses: []string{to},
+		},
+		Message: &types.Message{
+			Body: body,
+			Subject: &types.Content{
+				Data: aws.String(subject),
+			},
+		},
+		Source: aws.String(s.sender),
+	}
+
+	_, err := s.client.SendEmail(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// SES's simple send has no attachments, so these go out as a raw MIME message
+func (s *SESSender) SendEmailWithAttachments(ctx context.Context, to, subject, textBody, htmlBody string, attachments []queue.EmailAttachment) error {
+	raw, err := rawEmail(s.sender, to, subject, textBody, htmlBody, attachments)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	_, err = s.client.SendRawEmail(ctx, &ses.SendRawEmailInput{
+		Destinations: []string{to},
+		RawMessage:   &types.RawMessage{Data: raw},
+		Source:       aws.String(s.sender),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+func (s *SESSender) VerifyEmailIdentity(ctx context.Context) (*ses.VerifyEmailIdentityOutput, error) {
+	output, err := s.client.VerifyEmailIdentity(ctx, &ses.VerifyEmailIdentityInput{
+		EmailAddress: aws.String(s.sender),
+	})
+
+	return output, err
+}
+
+// for printing/debugging
+func (s *SESSender) Sender() string {
+	return s.sender
+}
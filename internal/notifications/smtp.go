@@ -0,0 +1,117 @@
+package notifications
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/queue"
+)
+
+// sends email through an SMTP server, for deployments without SES.
+type SMTPSender struct {
+	addr     string
+	host     string
+	username string
+	password string
+	// TLS from the first byte rather than STARTTLS
+	implicitTLS bool
+	from        string
+}
+
+func NewSMTPSender(cfg config.EmailConfig) *SMTPSender {
+	return &SMTPSender{
+		addr:        net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort),
+		host:        cfg.SMTPHost,
+		username:    cfg.SMTPUsername,
+		password:    cfg.SMTPPassword,
+		implicitTLS: cfg.SMTPImplicitTLS,
+		from:        cfg.From,
+	}
+}
+
+func (s *SMTPSender) SendEmail(ctx context.Context, to, subject, textBody, htmlBody string) error {
+	return s.SendEmailWithAttachments(ctx, to, subject, textBody, htmlBody, nil)
+}
+
+// upgrades the connection with STARTTLS when the server offers it, and logs in
+// when a username is configured.
+func (s *SMTPSender) SendEmailWithAttachments(ctx context.Context, to, subject, textBody, htmlBody string, attachments []queue.EmailAttachment) error {
+	raw, err := rawEmail(s.from, to, subject, textBody, htmlBody, attachments)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+	raw = append([]byte("Date: "+time.Now().Format(time.RFC1123Z)+"\r\n"), raw...)
+
+	if err := s.send(ctx, to, raw); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+func (s *SMTPSender) send(ctx context.Context, to string, raw []byte) error {
+	var conn net.Conn
+	var err error
+	if s.implicitTLS {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: s.host}}
+		conn, err = dialer.DialContext(ctx, "tcp", s.addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", s.addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if !s.implicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+				return err
+			}
+		}
+	}
+	if s.username != "" {
+		// PlainAuth refuses to send the password unencrypted, except to localhost
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+
+	// the envelope wants the bare address of a "Name <address>" sender
+	envelopeFrom := s.from
+	if addr, err := mail.ParseAddress(s.from); err == nil {
+		envelopeFrom = addr.Address
+	}
+	if err := client.Mail(envelopeFrom); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
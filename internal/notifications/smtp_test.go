@@ -0,0 +1,126 @@
+package notifications
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// what a fake SMTP server was sent in one session
+type smtpSession struct {
+	auth string
+	from string
+	to   string
+	data string
+}
+
+// accepts one session on a local port, answering every command with success
+func fakeSMTPServer(t *testing.T) (host, port string, session <-chan smtpSession) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	done := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		var s smtpSession
+
+		text.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			verb, arg, _ := strings.Cut(line, " ")
+			switch strings.ToUpper(verb) {
+			case "EHLO":
+				text.PrintfLine("250-localhost")
+				text.PrintfLine("250 AUTH PLAIN")
+			case "AUTH":
+				s.auth = arg
+				text.PrintfLine("235 authenticated")
+			case "MAIL":
+				s.from = arg
+				text.PrintfLine("250 ok")
+			case "RCPT":
+				s.to = arg
+				text.PrintfLine("250 ok")
+			case "DATA":
+				text.PrintfLine("354 go ahead")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				s.data = string(data)
+				text.PrintfLine("250 queued")
+			case "QUIT":
+				text.PrintfLine("221 bye")
+				done <- s
+				return
+			default:
+				text.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+
+	host, port, err = net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return host, port, done
+}
+
+func TestSMTPSender_SendEmailWithAttachments(t *testing.T) {
+	host, port, session := fakeSMTPServer(t)
+	sender := NewSMTPSender(config.EmailConfig{
+		From:         "Campus Vault <noreply@example.com>",
+		SMTPHost:     host,
+		SMTPPort:     port,
+		SMTPUsername: "vault",
+		SMTPPassword: "secret",
+	})
+
+	err := sender.SendEmailWithAttachments(context.Background(), "member@example.com", "Your booking", "See you at pickup", "",
+		[]queue.EmailAttachment{{Filename: "booking.ics", ContentType: "text/calendar", Data: []byte("BEGIN:VCALENDAR")}})
+	require.NoError(t, err)
+
+	s := <-session
+	credentials, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s.auth, "PLAIN "))
+	require.NoError(t, err)
+	assert.Equal(t, "\x00vault\x00secret", string(credentials))
+	assert.Equal(t, "FROM:<noreply@example.com>", s.from, "the envelope gets the bare address")
+	assert.Equal(t, "TO:<member@example.com>", s.to)
+
+	msg := textproto.NewReader(bufio.NewReader(strings.NewReader(s.data)))
+	header, err := msg.ReadMIMEHeader()
+	require.NoError(t, err)
+	assert.NotEmpty(t, header.Get("Date"))
+	assert.Equal(t, "Campus Vault <noreply@example.com>", header.Get("From"))
+	assert.Equal(t, "member@example.com", header.Get("To"))
+	assert.Contains(t, s.data, `filename=booking.ics`)
+}
+
+func TestNewEmailSender(t *testing.T) {
+	_, err := NewEmailSender(config.EmailConfig{Provider: "smtp"}, config.AWSConfig{})
+	assert.Error(t, err, "smtp needs a host")
+
+	_, err = NewEmailSender(config.EmailConfig{Provider: "carrier-pigeon"}, config.AWSConfig{})
+	assert.Error(t, err)
+
+	sender, err := NewEmailSender(config.EmailConfig{Provider: "log"}, config.AWSConfig{})
+	require.NoError(t, err)
+	assert.NoError(t, sender.SendEmail(context.Background(), "member@example.com", "Hello", "Hi", ""))
+}
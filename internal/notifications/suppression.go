@@ -0,0 +1,246 @@
+package notifications
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// the subset of db.Queries suppression needs
+type suppressionStore interface {
+	GetEmailSuppression(ctx context.Context, email string) (db.EmailSuppression, error)
+	SuppressEmail(ctx context.Context, arg db.SuppressEmailParams) error
+}
+
+// wraps an EmailSender so nothing more goes to addresses that bounced or
+// complained; sends to them fail with queue.ErrSuppressed.
+type SuppressingSender struct {
+	sender  EmailSender
+	queries suppressionStore
+}
+
+func NewSuppressingSender(sender EmailSender, queries suppressionStore) *SuppressingSender {
+	return &SuppressingSender{sender: sender, queries: queries}
+}
+
+func (s *SuppressingSender) SendEmail(ctx context.Context, to, subject, textBody, htmlBody string) error {
+	if err := s.check(ctx, to); err != nil {
+		return err
+	}
+	return s.sender.SendEmail(ctx, to, subject, textBody, htmlBody)
+}
+
+func (s *SuppressingSender) SendEmailWithAttachments(ctx context.Context, to, subject, textBody, htmlBody string, attachments []queue.EmailAttachment) error {
+	if err := s.check(ctx, to); err != nil {
+		return err
+	}
+	return s.sender.SendEmailWithAttachments(ctx, to, subject, textBody, htmlBody, attachments)
+}
+
+func (s *SuppressingSender) check(ctx context.Context, to string) error {
+	suppression, err := s.queries.GetEmailSuppression(ctx, to)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check email suppression: %w", err)
+	}
+	return fmt.Errorf("%s after a %s: %w", to, suppression.Reason, queue.ErrSuppressed)
+}
+
+// SNS bodies are small; anything much bigger isn't from SNS
+const maxNotificationSize = 256 << 10
+
+// receives SES bounce and complaint notifications through an SNS HTTPS
+// subscription and suppresses the addresses concerned. SNS can't sign in,
+// so each request must carry the configured token in its query string.
+type BounceHandler struct {
+	queries  suppressionStore
+	token    string
+	topicARN string
+	client   *http.Client
+	// subscriptions are only confirmed at https URLs on hosts ending in this
+	confirmHost string
+}
+
+func NewBounceHandler(queries suppressionStore, cfg config.EmailConfig) *BounceHandler {
+	return &BounceHandler{
+		queries:     queries,
+		token:       cfg.NotificationToken,
+		topicARN:    cfg.NotificationTopicARN,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		confirmHost: ".amazonaws.com",
+	}
+}
+
+// the envelope SNS posts, as text/plain
+type snsMessage struct {
+	Type         string
+	TopicArn     string
+	Message      string
+	SubscribeURL string
+}
+
+// the SES notification inside an SNS message. Notifications set by an
+// identity have notificationType, those from a configuration set's event
+// destination eventType.
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	EventType        string `json:"eventType"`
+	Bounce           struct {
+		BounceType        string `json:"bounceType"`
+		BounceSubType     string `json:"bounceSubType"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint struct {
+		ComplaintFeedbackType string `json:"complaintFeedbackType"`
+		ComplainedRecipients  []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+}
+
+func (h *BounceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	if h.token == "" || subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(h.token)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxNotificationSize))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	var msg snsMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		http.Error(w, "not an SNS message", http.StatusBadRequest)
+		return
+	}
+	if h.topicARN != "" && msg.TopicArn != h.topicARN {
+		logger.Warn("SES notification from an unexpected topic", "topic", msg.TopicArn)
+		http.Error(w, "unexpected topic", http.StatusForbidden)
+		return
+	}
+
+	switch msg.Type {
+	case "SubscriptionConfirmation":
+		if err := h.confirm(r.Context(), msg.SubscribeURL); err != nil {
+			logger.Error("Failed to confirm SNS subscription", "topic", msg.TopicArn, "error", err)
+			http.Error(w, "failed to confirm subscription", http.StatusBadGateway)
+			return
+		}
+		logger.Info("Confirmed SNS subscription for SES notifications", "topic", msg.TopicArn)
+	case "UnsubscribeConfirmation":
+		logger.Warn("SNS subscription for SES notifications removed", "topic", msg.TopicArn)
+	case "Notification":
+		var n sesNotification
+		if err := json.Unmarshal([]byte(msg.Message), &n); err != nil {
+			http.Error(w, "not an SES notification", http.StatusBadRequest)
+			return
+		}
+		// failing lets SNS retry
+		if err := h.handle(r.Context(), n); err != nil {
+			logger.Error("Failed to record SES notification", "error", err)
+			http.Error(w, "failed to record notification", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, "unknown message type", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// permanent bounces and complaints suppress their recipients. Transient
+// bounces (a full mailbox, say) may well clear up, so they're only logged.
+func (h *BounceHandler) handle(ctx context.Context, n sesNotification) error {
+	kind := n.NotificationType
+	if kind == "" {
+		kind = n.EventType
+	}
+
+	switch kind {
+	case "Bounce":
+		if n.Bounce.BounceType != "Permanent" {
+			for _, r := range n.Bounce.BouncedRecipients {
+				logging.FromContext(ctx).Info("Email bounced, not suppressing", "email", r.EmailAddress, "type", n.Bounce.BounceType, "subtype", n.Bounce.BounceSubType)
+			}
+			return nil
+		}
+		for _, r := range n.Bounce.BouncedRecipients {
+			detail := n.Bounce.BounceSubType
+			if r.DiagnosticCode != "" {
+				detail += ": " + r.DiagnosticCode
+			}
+			if err := h.suppress(ctx, r.EmailAddress, "bounce", detail); err != nil {
+				return err
+			}
+		}
+	case "Complaint":
+		for _, r := range n.Complaint.ComplainedRecipients {
+			if err := h.suppress(ctx, r.EmailAddress, "complaint", n.Complaint.ComplaintFeedbackType); err != nil {
+				return err
+			}
+		}
+	default:
+		// deliveries and the like, when the topic carries them too
+	}
+	return nil
+}
+
+func (h *BounceHandler) suppress(ctx context.Context, email, reason, detail string) error {
+	if email == "" {
+		return nil
+	}
+	err := h.queries.SuppressEmail(ctx, db.SuppressEmailParams{
+		Email:  email,
+		Reason: reason,
+		Detail: pgtype.Text{String: detail, Valid: detail != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to suppress %s: %w", email, err)
+	}
+	logging.FromContext(ctx).Warn("Email address suppressed", "email", email, "reason", reason, "detail", detail)
+	return nil
+}
+
+// SNS waits for the SubscribeURL it sends to be visited. Only AWS's own
+// URLs are, so a forged confirmation can't make us fetch anything else.
+func (h *BounceHandler) confirm(ctx context.Context, subscribeURL string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil || u.Scheme != "https" || !strings.HasSuffix(u.Hostname(), h.confirmHost) {
+		return fmt.Errorf("refusing to confirm a subscription at %q", subscribeURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("subscribe URL returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// suppressions by lowercased address
+type fakeSuppressionStore struct {
+	suppressed map[string]db.SuppressEmailParams
+}
+
+func (f *fakeSuppressionStore) GetEmailSuppression(ctx context.Context, email string) (db.EmailSuppression, error) {
+	p, ok := f.suppressed[strings.ToLower(email)]
+	if !ok {
+		return db.EmailSuppression{}, pgx.ErrNoRows
+	}
+	return db.EmailSuppression{Email: strings.ToLower(email), Reason: p.Reason, Detail: p.Detail}, nil
+}
+
+func (f *fakeSuppressionStore) SuppressEmail(ctx context.Context, arg db.SuppressEmailParams) error {
+	f.suppressed[strings.ToLower(arg.Email)] = arg
+	return nil
+}
+
+// counts the emails that get through
+type countingSender struct {
+	logEmailSender
+	sent int
+}
+
+func (c *countingSender) SendEmail(ctx context.Context, to, subject, textBody, htmlBody string) error {
+	c.sent++
+	return nil
+}
+
+func TestSuppressingSender(t *testing.T) {
+	store := &fakeSuppressionStore{suppressed: map[string]db.SuppressEmailParams{
+		"gone@example.com": {Reason: "bounce"},
+	}}
+	sender := &countingSender{}
+	suppressing := NewSuppressingSender(sender, store)
+
+	require.NoError(t, suppressing.SendEmail(context.Background(), "member@example.com", "Hello", "Hi", ""))
+	err := suppressing.SendEmail(context.Background(), "Gone@example.com", "Hello", "Hi", "")
+	assert.True(t, errors.Is(err, queue.ErrSuppressed), "got %v", err)
+	assert.Equal(t, 1, sender.sent)
+}
+
+func TestBounceHandler(t *testing.T) {
+	store := &fakeSuppressionStore{suppressed: map[string]db.SuppressEmailParams{}}
+	handler := NewBounceHandler(store, config.EmailConfig{
+		NotificationToken:    "sns-secret",
+		NotificationTopicARN: "arn:aws:sns:ca-central-1:123456789012:ses-feedback",
+	})
+
+	post := func(t *testing.T, token string, msg map[string]string) int {
+		t.Helper()
+		body, err := json.Marshal(msg)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/ses?token="+token, strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "text/plain; charset=UTF-8")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	notification := func(ses string) map[string]string {
+		return map[string]string{
+			"Type":     "Notification",
+			"TopicArn": "arn:aws:sns:ca-central-1:123456789012:ses-feedback",
+			"Message":  ses,
+		}
+	}
+
+	t.Run("the token is required", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, post(t, "wrong", notification(`{}`)))
+	})
+
+	t.Run("other topics are refused", func(t *testing.T) {
+		msg := notification(`{}`)
+		msg["TopicArn"] = "arn:aws:sns:ca-central-1:123456789012:someone-else"
+		assert.Equal(t, http.StatusForbidden, post(t, "sns-secret", msg))
+	})
+
+	t.Run("permanent bounces are suppressed", func(t *testing.T) {
+		code := post(t, "sns-secret", notification(`{"notificationType":"Bounce","bounce":{"bounceType":"Permanent","bounceSubType":"General",
+			"bouncedRecipients":[{"emailAddress":"Gone@example.com","diagnosticCode":"smtp; 550 5.1.1 user unknown"}]}}`))
+		assert.Equal(t, http.StatusOK, code)
+		require.Contains(t, store.suppressed, "gone@example.com")
+		assert.Equal(t, "bounce", store.suppressed["gone@example.com"].Reason)
+		assert.Equal(t, "General: smtp; 550 5.1.1 user unknown", store.suppressed["gone@example.com"].Detail.String)
+	})
+
+	t.Run("transient bounces are not", func(t *testing.T) {
+		code := post(t, "sns-secret", notification(`{"notificationType":"Bounce","bounce":{"bounceType":"Transient","bounceSubType":"MailboxFull",
+			"bouncedRecipients":[{"emailAddress":"full@example.com"}]}}`))
+		assert.Equal(t, http.StatusOK, code)
+		assert.NotContains(t, store.suppressed, "full@example.com")
+	})
+
+	t.Run("complaints are suppressed", func(t *testing.T) {
+		code := post(t, "sns-secret", notification(`{"eventType":"Complaint","complaint":{"complaintFeedbackType":"abuse",
+			"complainedRecipients":[{"emailAddress":"annoyed@example.com"}]}}`))
+		assert.Equal(t, http.StatusOK, code)
+		require.Contains(t, store.suppressed, "annoyed@example.com")
+		assert.Equal(t, "complaint", store.suppressed["annoyed@example.com"].Reason)
+	})
+
+	t.Run("subscriptions are confirmed only at AWS", func(t *testing.T) {
+		confirmed := false
+		aws := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			confirmed = r.URL.Query().Get("Action") == "ConfirmSubscription"
+		}))
+		defer aws.Close()
+
+		msg := notification("")
+		msg["Type"] = "SubscriptionConfirmation"
+		msg["SubscribeURL"] = aws.URL + "/?Action=ConfirmSubscription&Token=abc"
+		assert.Equal(t, http.StatusBadGateway, post(t, "sns-secret", msg), "not an amazonaws.com host")
+		assert.False(t, confirmed)
+
+		handler.client = aws.Client()
+		handler.confirmHost = "127.0.0.1"
+		assert.Equal(t, http.StatusOK, post(t, "sns-secret", msg))
+		assert.True(t, confirmed)
+	})
+}
@@ -1,18 +1,27 @@
 package notifications
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"html"
 	"html/template"
-	"path/filepath"
+	"io/fs"
+	"math"
+	"strings"
 
 	"github.com/USSTM/cv-backend/generated/db"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func NewEmailLookupFunc(queries *db.Queries) EmailLookupFunc {
-	return func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]string, error) {
-		rows, err := queries.GetUsersByIDsEmailOptIn(ctx, ids)
+	return func(ctx context.Context, ids []uuid.UUID, kind Type) (map[uuid.UUID]string, error) {
+		rows, err := queries.GetUsersByIDsEmailOptIn(ctx, db.GetUsersByIDsEmailOptInParams{
+			Ids:              ids,
+			NotificationType: pgtype.Text{String: string(kind), Valid: kind != ""},
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -25,12 +34,88 @@ func NewEmailLookupFunc(queries *db.Queries) EmailLookupFunc {
 }
 
 // each .html file must define {{define "name:subject"}} and {{define "name:body"}} blocks,
-// where name matches the filename without extension.
-func LoadTemplates(dir string) (*template.Template, error) {
-	pattern := filepath.Join(dir, "*.html")
-	tmpl, err := template.ParseGlob(pattern)
+// where name matches the filename without extension. layouts/*.html wrap every body.
+func LoadTemplates(fsys fs.FS) (*template.Template, error) {
+	tmpl, err := template.ParseFS(fsys, "layouts/*.html", "*.html")
 	if err != nil {
-		return nil, fmt.Errorf("failed to load email templates from %s: %w", dir, err)
+		return nil, fmt.Errorf("failed to load email templates: %w", err)
 	}
 	return tmpl, nil
 }
+
+// a rendered email: the HTML part wrapped in the layout, and a plain-text
+// part for clients that won't show HTML
+type Message struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+const textFooter = "You are receiving this email because of your Campus Vault account. " +
+	"You can choose which emails you get under your notification preferences."
+
+// {{define "name:subject"}} and {{define "name:body"}}, with the body placed
+// inside {{define "layout:html"}}
+func RenderTemplate(tmpl *template.Template, name string, data map[string]interface{}) (Message, error) {
+	var subjectBuf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subjectBuf, name+":subject", data); err != nil {
+		return Message{}, fmt.Errorf("render subject for %q: %w", name, err)
+	}
+	// subjects are headers, not markup: undo html/template's escaping
+	subject := strings.TrimSpace(html.UnescapeString(subjectBuf.String()))
+
+	var bodyBuf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&bodyBuf, name+":body", data); err != nil {
+		return Message{}, fmt.Errorf("render body for %q: %w", name, err)
+	}
+
+	var htmlBuf bytes.Buffer
+	err := tmpl.ExecuteTemplate(&htmlBuf, "layout:html", map[string]interface{}{
+		"Subject": subject,
+		"Content": template.HTML(bodyBuf.String()),
+	})
+	if err != nil {
+		return Message{}, fmt.Errorf("render layout for %q: %w", name, err)
+	}
+
+	return Message{
+		Subject: subject,
+		HTML:    htmlBuf.String(),
+		Text:    htmlToText(bodyBuf.String()) + "\n\n--\n" + textFooter + "\n",
+	}, nil
+}
+
+// preview.json maps each template name to the sample data emailer --preview
+// renders it with
+func LoadPreviewData(fsys fs.FS) (map[string]map[string]interface{}, error) {
+	raw, err := fs.ReadFile(fsys, "preview.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read preview data: %w", err)
+	}
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse preview data: %w", err)
+	}
+	data := make(map[string]map[string]interface{}, len(entries))
+	for name, entry := range entries {
+		if data[name], err = DecodeTemplateData(entry); err != nil {
+			return nil, fmt.Errorf("failed to parse preview data for %q: %w", name, err)
+		}
+	}
+	return data, nil
+}
+
+// template data from JSON. Whole numbers become ints so templates can compare
+// them with literals ({{if gt .DaysOverdue 0}}), which float64 would fail.
+func DecodeTemplateData(raw []byte) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	for key, value := range data {
+		if f, ok := value.(float64); ok && f == math.Trunc(f) {
+			data[key] = int(f)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,75 @@
+package notifications_test
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplate_PreviewData(t *testing.T) {
+	fsys := templates.Email()
+	tmpl, err := notifications.LoadTemplates(fsys)
+	require.NoError(t, err)
+	samples, err := notifications.LoadPreviewData(fsys)
+	require.NoError(t, err)
+
+	files, err := fs.Glob(fsys, "*.html")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		name := strings.TrimSuffix(file, ".html")
+		t.Run(name, func(t *testing.T) {
+			data, ok := samples[name]
+			require.True(t, ok, "preview.json has no sample data for %s", name)
+
+			msg, err := notifications.RenderTemplate(tmpl, name, data)
+			require.NoError(t, err)
+			assert.NotEmpty(t, msg.Subject)
+			assert.NotContains(t, msg.Subject, "&")
+			assert.True(t, strings.HasPrefix(msg.HTML, "<!DOCTYPE html>"), "body is wrapped in the base layout")
+			assert.NotContains(t, msg.Text, "<")
+			assert.NotContains(t, msg.Text, "<no value>")
+			assert.NotContains(t, msg.HTML, "<no value>")
+		})
+	}
+}
+
+func TestRenderTemplate_LayoutAndText(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": {Data: []byte(`{{define "layout:html"}}<html><title>{{.Subject}}</title><body>{{.Content}}</body></html>{{end}}`)},
+		"sample.html": {Data: []byte(`{{define "sample:subject"}}Tom & Jerry's {{.ItemName}}{{end}}
+{{define "sample:body"}}<p>Hi {{.Name}},</p>
+<ul><li>first</li><li>second</li></ul>
+<p><a href="https://example.com/x">Open it</a><br>Thanks</p>{{end}}`)},
+	}
+	tmpl, err := notifications.LoadTemplates(fsys)
+	require.NoError(t, err)
+
+	data, err := notifications.DecodeTemplateData([]byte(`{"ItemName": "Camera", "Name": "<b>Jane</b>"}`))
+	require.NoError(t, err)
+
+	msg, err := notifications.RenderTemplate(tmpl, "sample", data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Tom & Jerry's Camera", msg.Subject)
+	assert.True(t, strings.HasPrefix(msg.HTML, "<html><title>"), "body is wrapped in the layout")
+	assert.Contains(t, msg.HTML, "<p>Hi &lt;b&gt;Jane&lt;/b&gt;,</p>", "data is still escaped in the HTML part")
+
+	assert.True(t, strings.HasPrefix(msg.Text, "Hi <b>Jane</b>,\n\n- first\n- second\n\nOpen it (https://example.com/x)\nThanks"), msg.Text)
+	assert.Contains(t, msg.Text, "notification preferences")
+}
+
+func TestDecodeTemplateData_WholeNumbers(t *testing.T) {
+	data, err := notifications.DecodeTemplateData([]byte(`{"DaysLeft": 3, "Ratio": 0.5, "Name": "x"}`))
+	require.NoError(t, err)
+	assert.Equal(t, 3, data["DaysLeft"])
+	assert.Equal(t, 0.5, data["Ratio"])
+	assert.Equal(t, "x", data["Name"])
+}
@@ -0,0 +1,63 @@
+package notifications
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	linkTag      = regexp.MustCompile(`(?is)<a\s[^>]*href="([^"]*)"[^>]*>(.*?)</a>`)
+	breakTag     = regexp.MustCompile(`(?i)<br\s*/?>`)
+	listItemTag  = regexp.MustCompile(`(?i)<li[^>]*>`)
+	listItemEnd  = regexp.MustCompile(`(?i)</li>`)
+	blockEndTag  = regexp.MustCompile(`(?i)</(p|div|ul|ol|h[1-6]|tr|table)>`)
+	anyTag       = regexp.MustCompile(`(?s)<[^>]*>`)
+	inlineSpaces = regexp.MustCompile(`[ \t]+`)
+	blankLines   = regexp.MustCompile(`\n{3,}`)
+)
+
+// the plain-text part of an email, derived from its rendered HTML body so
+// templates only have to be written once
+func htmlToText(body string) string {
+	text := linkTag.ReplaceAllStringFunc(body, func(link string) string {
+		m := linkTag.FindStringSubmatch(link)
+		href, label := m[1], strings.TrimSpace(anyTag.ReplaceAllString(m[2], ""))
+		if label == "" || label == href {
+			return href
+		}
+		return label + " (" + href + ")"
+	})
+	text = breakTag.ReplaceAllString(text, "\n")
+	text = listItemTag.ReplaceAllString(text, "- ")
+	text = listItemEnd.ReplaceAllString(text, "\n")
+	text = blockEndTag.ReplaceAllString(text, "\n\n")
+	text = anyTag.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(inlineSpaces.ReplaceAllString(line, " "))
+	}
+	// keep list items together however the template spaced them
+	kept := lines[:0]
+	for i, line := range lines {
+		if line == "" && len(kept) > 0 && strings.HasPrefix(kept[len(kept)-1], "- ") && nextIsListItem(lines[i+1:]) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	text = strings.Join(kept, "\n")
+	text = blankLines.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// whether the first non-blank line is a list item
+func nextIsListItem(lines []string) bool {
+	for _, line := range lines {
+		if line != "" {
+			return strings.HasPrefix(line, "- ")
+		}
+	}
+	return false
+}
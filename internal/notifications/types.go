@@ -0,0 +1,65 @@
+package notifications
+
+// a kind of notification users can turn email off for
+type Type string
+
+const (
+	TypeRequestApproved   Type = "request_approved"
+	TypeRequestDenied     Type = "request_denied"
+	TypeBookingConfirmed  Type = "booking_confirmed"
+	TypeDueSoon           Type = "due_soon"
+	TypeOverdue           Type = "overdue"
+	TypeWaitlistAvailable Type = "waitlist_available"
+	TypeInventoryDigest   Type = "inventory_digest"
+	TypeApproverDigest    Type = "approver_digest"
+)
+
+// every Type, in the order preferences are listed
+var Types = []Type{
+	TypeRequestApproved,
+	TypeRequestDenied,
+	TypeBookingConfirmed,
+	TypeDueSoon,
+	TypeOverdue,
+	TypeWaitlistAvailable,
+	TypeInventoryDigest,
+	TypeApproverDigest,
+}
+
+// Types whose emails are off until the user turns them on
+var optInTypes = map[Type]bool{
+	TypeApproverDigest: true,
+}
+
+// the Type each email template belongs to. Templates not listed, such as
+// invitations or report links, are sent whenever the user takes email at all.
+var templateTypes = map[string]Type{
+	"request_approved_requester":  TypeRequestApproved,
+	"request_denied_requester":    TypeRequestDenied,
+	"booking_confirmed_requester": TypeBookingConfirmed,
+	"borrowing_return_reminder":   TypeDueSoon,
+	"return_reminder_requester":   TypeDueSoon,
+	"return_overdue_group_admin":  TypeOverdue,
+	"waitlist_available":          TypeWaitlistAvailable,
+	"inventory_digest":            TypeInventoryDigest,
+	"approver_digest":             TypeApproverDigest,
+}
+
+// "" when the template has no Type
+func TypeForTemplate(template string) Type {
+	return templateTypes[template]
+}
+
+// whether emails of type t are sent to users who have not chosen either way
+func EmailByDefault(t Type) bool {
+	return !optInTypes[t]
+}
+
+func ValidType(t Type) bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
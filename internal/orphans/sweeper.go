@@ -0,0 +1,105 @@
+package orphans
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/storage"
+)
+
+// key prefixes the sweep looks under: item images, borrowing condition photos
+// and group logos. Generated reports are left to the bucket's lifecycle rules.
+var Prefixes = []string{"items/", "borrowings/", "groups/"}
+
+// subset of storage.Storage needed to find and delete orphaned objects.
+type objectStore interface {
+	ListObjectsWithPrefix(ctx context.Context, prefix string) ([]storage.Object, error)
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// deletes uploads nothing refers to any more: images whose rows were deleted,
+// uploads whose row was never saved and variants of replaced photos.
+type Sweeper struct {
+	db      *db.Queries
+	objects objectStore
+	// objects newer than this are never swept, so an upload isn't taken
+	// before the row pointing at it is saved
+	minAge time.Duration
+	// log orphans without deleting them
+	dryRun bool
+}
+
+func NewSweeper(queries *db.Queries, objects objectStore, minAgeDays int, dryRun bool) *Sweeper {
+	return &Sweeper{
+		db:      queries,
+		objects: objects,
+		minAge:  time.Duration(minAgeDays) * 24 * time.Hour,
+		dryRun:  dryRun,
+	}
+}
+
+// deletes every object under Prefixes older than the minimum age that no
+// item image, borrowing image, damage report or group references, and
+// returns how many were (in dry-run mode, how many would have been). An
+// object that fails to delete is logged and skipped; the failures are joined
+// into the returned error.
+func (s *Sweeper) Sweep(ctx context.Context) (int, error) {
+	logger := logging.FromContext(ctx)
+
+	cutoff := time.Now().Add(-s.minAge)
+
+	// list the objects before the references, so a row saved in between
+	// still protects its object
+	var candidates []storage.Object
+	for _, prefix := range Prefixes {
+		objects, err := s.objects.ListObjectsWithPrefix(ctx, prefix)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list objects under %s: %w", prefix, err)
+		}
+		for _, obj := range objects {
+			if obj.LastModified.Before(cutoff) {
+				candidates = append(candidates, obj)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+
+	keys, err := s.db.ListReferencedObjectKeys(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list referenced object keys: %w", err)
+	}
+	referenced := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		referenced[key] = true
+	}
+
+	swept := 0
+	var errs []error
+	for _, obj := range candidates {
+		if referenced[obj.Key] {
+			continue
+		}
+		if s.dryRun {
+			logger.Info("Orphaned object found (dry run)", "key", obj.Key, "last_modified", obj.LastModified, "size", obj.Size)
+			swept++
+			continue
+		}
+		if err := s.objects.DeleteObject(ctx, obj.Key); err != nil {
+			logger.Error("failed to delete orphaned object", "key", obj.Key, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		swept++
+	}
+
+	if len(errs) > 0 {
+		return swept, fmt.Errorf("failed to delete %d orphaned objects: %w", len(errs), errors.Join(errs...))
+	}
+	return swept, nil
+}
@@ -0,0 +1,123 @@
+package orphans_test
+
+import (
+	"context"
+	"flag"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/orphans"
+	"github.com/USSTM/cv-backend/internal/storage"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sharedDB *testutil.TestDatabase
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	t := &testing.T{}
+	sharedDB = testutil.NewTestDatabase(t, "cv-backend-test-db-orphans")
+	sharedDB.RunMigrations(t)
+
+	code := m.Run()
+
+	if sharedDB.Pool() != nil {
+		sharedDB.Pool().Close()
+	}
+
+	os.Exit(code)
+}
+
+// objects by key, each with its last-modified time
+type fakeObjectStore struct {
+	objects map[string]time.Time
+}
+
+func (f *fakeObjectStore) ListObjectsWithPrefix(ctx context.Context, prefix string) ([]storage.Object, error) {
+	var out []storage.Object
+	for key, modified := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			out = append(out, storage.Object{Key: key, LastModified: modified})
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeObjectStore) DeleteObject(ctx context.Context, key string) error {
+	delete(f.objects, key)
+	return nil
+}
+
+func (f *fakeObjectStore) keys() []string {
+	var keys []string
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestSweeper_Sweep(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	ctx := context.Background()
+	q := sharedDB.Queries()
+
+	item := sharedDB.NewItem(t).WithName("Camera").WithType("high").WithStock(1).Create()
+	_, err := q.CreateItemImage(ctx, db.CreateItemImageParams{
+		ID:             uuid.New(),
+		ItemID:         item.ID,
+		OriginalS3Key:  "items/kept-original.jpg",
+		ThumbnailS3Key: "items/kept-thumb.jpg",
+		Width:          100,
+		Height:         100,
+	})
+	require.NoError(t, err)
+
+	old := time.Now().AddDate(0, 0, -30)
+	store := func() *fakeObjectStore {
+		return &fakeObjectStore{objects: map[string]time.Time{
+			"items/kept-original.jpg":  old,
+			"items/kept-thumb.jpg":     old,
+			"items/deleted-thumb.jpg":  old,
+			"borrowings/abandoned.jpg": old,
+			"groups/just-uploaded.png": time.Now(),
+			"reports/old.csv":          old,
+		}}
+	}
+
+	t.Run("a dry run only counts orphans", func(t *testing.T) {
+		objects := store()
+		swept, err := orphans.NewSweeper(q, objects, 7, true).Sweep(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, swept)
+		assert.Len(t, objects.objects, 6)
+	})
+
+	t.Run("old unreferenced objects are deleted", func(t *testing.T) {
+		objects := store()
+		swept, err := orphans.NewSweeper(q, objects, 7, false).Sweep(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, swept)
+		assert.Equal(t, []string{
+			"groups/just-uploaded.png",
+			"items/kept-original.jpg",
+			"items/kept-thumb.jpg",
+			"reports/old.csv",
+		}, objects.keys(), "referenced, recent and unswept objects stay")
+	})
+}
@@ -0,0 +1,171 @@
+package overdue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/events"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// when borrowers are reminded to return an item.
+type Schedule struct {
+	// days before the due date for the advance reminder; 0 sends none
+	DaysBefore int
+	// days between reminders once overdue; 0 stops after the due date
+	RepeatDays int
+}
+
+// which reminder a borrowing due in daysUntilDue whole days is owed, keyed by
+// its day relative to the due date: -DaysBefore ahead of it, 0 on the day
+// and every RepeatDays after. A missed reminder is caught up by the next
+// check rather than skipped.
+func ReminderFor(daysUntilDue int, schedule Schedule) (int, bool) {
+	switch {
+	case daysUntilDue > 0:
+		if daysUntilDue > schedule.DaysBefore {
+			return 0, false
+		}
+		return -schedule.DaysBefore, true
+	case daysUntilDue == 0:
+		return 0, true
+	}
+
+	daysOverdue := -daysUntilDue
+	if schedule.RepeatDays <= 0 || daysOverdue < schedule.RepeatDays {
+		return 0, true
+	}
+	return daysOverdue - daysOverdue%schedule.RepeatDays, true
+}
+
+type notifier interface {
+	Notify(ctx context.Context, actorID uuid.UUID, entityType string, entityID uuid.UUID, groups []notifications.NotifierGroup) error
+	Announce(ctx context.Context, e notifications.ChatEvent, groupID *uuid.UUID, data map[string]interface{})
+}
+
+type publisher interface {
+	Publish(ctx context.Context, e events.Event) error
+}
+
+// marks borrowings overdue and reminds borrowers to return them.
+type Checker struct {
+	db        *db.Queries
+	notifier  notifier
+	publisher publisher
+	schedule  Schedule
+}
+
+func NewChecker(queries *db.Queries, notifier notifier, publisher publisher, schedule Schedule) *Checker {
+	return &Checker{db: queries, notifier: notifier, publisher: publisher, schedule: schedule}
+}
+
+// marks unreturned borrowings past their due date as overdue, publishing an
+// item.overdue event and posting to the team's chat for each, then sends every reminder that has come due
+// and returns how many were sent. A failed event or reminder is logged and
+// not retried.
+func (c *Checker) CheckOverdue(ctx context.Context) (int, error) {
+	logger := logging.FromContext(ctx)
+
+	marked, err := c.db.MarkOverdueBorrowings(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark overdue borrowings: %w", err)
+	}
+	for _, b := range marked {
+		err := c.publisher.Publish(ctx, events.Event{Type: events.ItemOverdue, EntityID: b.ID, GroupID: b.GroupID, ItemID: b.ItemID})
+		if err != nil {
+			logger.Error("failed to publish overdue event", "borrowing_id", b.ID, "error", err)
+		}
+		c.notifier.Announce(ctx, notifications.ChatItemOverdue, b.GroupID, map[string]interface{}{
+			"ItemName":      b.ItemName,
+			"Quantity":      b.Quantity,
+			"BorrowerEmail": textOr(b.UserEmail, "a deleted user"),
+			"GroupName":     textOr(b.GroupName, "no group"),
+			"DueDate":       b.DueDate.Time.Format("2006-01-02"),
+		})
+	}
+
+	today := day(time.Now())
+	cutoff := today.AddDate(0, 0, max(c.schedule.DaysBefore, 0)+1)
+	borrowings, err := c.db.ListBorrowingsDueBy(ctx, pgtype.Timestamp{Time: cutoff, Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list borrowings: %w", err)
+	}
+
+	sent := 0
+	for _, b := range borrowings {
+		daysUntilDue := int(day(b.DueDate.Time).Sub(today).Hours() / 24)
+		daysFromDue, ok := ReminderFor(daysUntilDue, c.schedule)
+		if !ok {
+			continue
+		}
+
+		recorded, err := c.db.RecordBorrowingReminder(ctx, db.RecordBorrowingReminderParams{
+			BorrowingID: b.ID,
+			DaysFromDue: int32(daysFromDue),
+		})
+		if err != nil {
+			return sent, fmt.Errorf("failed to record reminder for borrowing %s: %w", b.ID, err)
+		}
+		if recorded == 0 {
+			continue
+		}
+
+		if err := c.remind(ctx, b, daysUntilDue); err != nil {
+			logger.Error("failed to send return reminder", "borrowing_id", b.ID, "days_from_due", daysFromDue, "error", err)
+			continue
+		}
+		sent++
+	}
+
+	logger.Info("overdue borrowing check", "newly_overdue", len(marked), "reminders_sent", sent)
+	return sent, nil
+}
+
+func (c *Checker) remind(ctx context.Context, b db.ListBorrowingsDueByRow, daysUntilDue int) error {
+	if b.UserID == nil {
+		return fmt.Errorf("borrowing has no borrower")
+	}
+	kind := notifications.TypeDueSoon
+	if daysUntilDue < 0 {
+		kind = notifications.TypeOverdue
+	}
+
+	return c.notifier.Notify(ctx, *b.UserID, "borrowing", b.ID, []notifications.NotifierGroup{
+		{
+			IDs:      []uuid.UUID{*b.UserID},
+			Template: "borrowing_return_reminder",
+			Type:     kind,
+			TemplateData: map[string]interface{}{
+				"ItemName":    b.ItemName,
+				"Quantity":    b.Quantity,
+				"DueDate":     b.DueDate.Time.Format("2006-01-02"),
+				"DaysLeft":    max(daysUntilDue, 0),
+				"DaysOverdue": max(-daysUntilDue, 0),
+			},
+			Facts: notifications.RoutingFacts{
+				ItemID:      b.ItemID,
+				ItemType:    b.ItemType,
+				GroupID:     b.GroupID,
+				DaysOverdue: max(-daysUntilDue, 0),
+			},
+		},
+	})
+}
+
+func textOr(t pgtype.Text, fallback string) string {
+	if t.Valid {
+		return t.String
+	}
+	return fallback
+}
+
+// midnight UTC of t's calendar date, so day differences ignore time of day.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
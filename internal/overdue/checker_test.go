@@ -0,0 +1,150 @@
+package overdue_test
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/events"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/overdue"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/USSTM/cv-backend/templates"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	sharedQueue *testutil.TestQueue
+	sharedDB    *testutil.TestDatabase
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		// unit tests still run; integration tests skip themselves
+		os.Exit(m.Run())
+	}
+
+	t := &testing.T{}
+	sharedDB = testutil.NewTestDatabase(t, "cv-backend-test-db-overdue")
+	sharedDB.RunMigrations(t)
+	sharedQueue = testutil.NewTestQueue(t, "cv-backend-test-redis-overdue")
+
+	code := m.Run()
+
+	if sharedDB.Pool() != nil {
+		sharedDB.Pool().Close()
+	}
+	sharedQueue.Close()
+
+	os.Exit(code)
+}
+
+func TestReminderFor(t *testing.T) {
+	schedule := overdue.Schedule{DaysBefore: 1, RepeatDays: 3}
+
+	tests := []struct {
+		name         string
+		daysUntilDue int
+		schedule     overdue.Schedule
+		wantDay      int
+		wantOK       bool
+	}{
+		{"too early", 2, schedule, 0, false},
+		{"day before", 1, schedule, -1, true},
+		{"due today", 0, schedule, 0, true},
+		{"overdue before the first repeat", -2, schedule, 0, true},
+		{"first repeat", -3, schedule, 3, true},
+		{"between repeats", -5, schedule, 3, true},
+		{"second repeat", -6, schedule, 6, true},
+		{"no advance reminder", 1, overdue.Schedule{RepeatDays: 3}, 0, false},
+		{"no repeats", -10, overdue.Schedule{DaysBefore: 1}, 0, true},
+		{"a week ahead", 5, overdue.Schedule{DaysBefore: 7}, -7, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotDay, gotOK := overdue.ReminderFor(tt.daysUntilDue, tt.schedule)
+			assert.Equal(t, tt.wantOK, gotOK)
+			assert.Equal(t, tt.wantDay, gotDay)
+		})
+	}
+}
+
+type recordingPublisher struct{ events []events.Event }
+
+func (p *recordingPublisher) Publish(ctx context.Context, e events.Event) error {
+	p.events = append(p.events, e)
+	return nil
+}
+
+func TestChecker_CheckOverdue_SandboxGroup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	sharedQueue.Cleanup(t)
+	ctx := context.Background()
+
+	emailTemplates, err := notifications.LoadTemplates(templates.Email())
+	require.NoError(t, err)
+	dispatcher := notifications.NewNotificationDispatcher(
+		notifications.NewNotificationService(sharedDB.Pool(), sharedDB.Queries()),
+		sharedQueue, emailTemplates, notifications.NewEmailLookupFunc(sharedDB.Queries()),
+		notifications.NewRuleLookupFunc(sharedDB.Queries()),
+		notifications.NewSandboxLookupFunc(sharedDB.Queries()), nil)
+
+	item := sharedDB.NewItem(t).WithName("Training Camera").WithType("medium").WithStock(5).Create()
+	borrow := func(email string, sandbox bool) {
+		builder := sharedDB.NewGroup(t).WithName(email)
+		if sandbox {
+			builder = builder.AsSandbox()
+		}
+		group := builder.Create()
+		user := sharedDB.NewUser(t).WithEmail(email).AsMember().Create()
+		_, err := sharedDB.Queries().BorrowItem(ctx, db.BorrowItemParams{
+			UserID:             &user.ID,
+			GroupID:            &group.ID,
+			ID:                 item.ID,
+			Quantity:           1,
+			DueDate:            pgtype.Timestamp{Time: time.Now().Add(-2 * 24 * time.Hour), Valid: true},
+			BeforeCondition:    "good",
+			BeforeConditionUrl: "http://example.com/before.jpg",
+		})
+		require.NoError(t, err)
+	}
+	borrow("trainee@sandbox.test", true)
+	borrow("member@live.test", false)
+
+	published := &recordingPublisher{}
+	checker := overdue.NewChecker(sharedDB.Queries(), dispatcher, published, overdue.Schedule{DaysBefore: 1, RepeatDays: 3})
+	_, err = checker.CheckOverdue(ctx)
+	require.NoError(t, err)
+
+	// both are announced; webhooks leave out sandbox groups themselves
+	require.Len(t, published.events, 2)
+	assert.Equal(t, events.ItemOverdue, published.events[0].Type)
+	assert.Equal(t, item.ID, *published.events[0].ItemID)
+
+	// a second check finds nothing newly overdue
+	_, err = checker.CheckOverdue(ctx)
+	require.NoError(t, err)
+	assert.Len(t, published.events, 2)
+
+	// only the live group's borrower is emailed
+	tasks, err := sharedQueue.Inspector.ListPendingTasks("default")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, queue.TypeEmailDelivery, tasks[0].Type)
+
+	var payload queue.EmailDeliveryPayload
+	require.NoError(t, json.Unmarshal(tasks[0].Payload, &payload))
+	assert.Equal(t, "member@live.test", payload.To)
+}
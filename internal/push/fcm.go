@@ -0,0 +1,226 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const (
+	fcmBaseURL = "https://fcm.googleapis.com/v1"
+	fcmScope   = "https://www.googleapis.com/auth/firebase.messaging"
+)
+
+// sends notifications through the FCM HTTP v1 API, authenticating as a
+// Google service account.
+type FCM struct {
+	httpClient  *http.Client
+	baseURL     string
+	projectID   string
+	clientEmail string
+	tokenURI    string
+	key         jwk.Key
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// the fields of a service account key file FCM needs
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// projectID may be empty to use the one in the key file.
+func NewFCM(projectID, credentialsFile string) (*FCM, error) {
+	raw, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FCM credentials: %w", err)
+	}
+
+	var account serviceAccount
+	if err := json.Unmarshal(raw, &account); err != nil {
+		return nil, fmt.Errorf("failed to parse FCM credentials: %w", err)
+	}
+
+	key, err := jwk.ParseKey([]byte(account.PrivateKey), jwk.WithPEM(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FCM service account key: %w", err)
+	}
+
+	if projectID == "" {
+		projectID = account.ProjectID
+	}
+	if projectID == "" || account.ClientEmail == "" || account.TokenURI == "" {
+		return nil, fmt.Errorf("FCM credentials need a project_id, client_email and token_uri")
+	}
+
+	return &FCM{
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		baseURL:     fcmBaseURL,
+		projectID:   projectID,
+		clientEmail: account.ClientEmail,
+		tokenURI:    account.TokenURI,
+		key:         key,
+	}, nil
+}
+
+type fcmMessage struct {
+	Message struct {
+		Token        string            `json:"token"`
+		Notification fcmNotification   `json:"notification"`
+		Data         map[string]string `json:"data,omitempty"`
+		Webpush      *fcmWebpush       `json:"webpush,omitempty"`
+	} `json:"message"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmWebpush struct {
+	FCMOptions struct {
+		Link string `json:"link"`
+	} `json:"fcm_options"`
+}
+
+type fcmError struct {
+	Error struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+func (f *FCM) SendPush(ctx context.Context, p queue.PushDeliveryPayload) error {
+	accessToken, err := f.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	var msg fcmMessage
+	msg.Message.Token = p.Token
+	msg.Message.Notification = fcmNotification{Title: p.Title, Body: p.Body}
+	if p.URL != "" {
+		// apps read the link from data; browsers open fcm_options.link
+		msg.Message.Data = map[string]string{"url": p.URL}
+		msg.Message.Webpush = &fcmWebpush{}
+		msg.Message.Webpush.FCMOptions.Link = p.URL
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal FCM message: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/projects/%s/messages:send", f.baseURL, url.PathEscape(f.projectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build FCM request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var fErr fcmError
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = json.Unmarshal(raw, &fErr)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		// fetch a fresh access token on the retry
+		f.mu.Lock()
+		f.accessToken = ""
+		f.mu.Unlock()
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusBadRequest:
+		// UNREGISTERED, or a token that was never valid
+		return fmt.Errorf("failed to send push notification: %s: %s: %w", fErr.Error.Status, fErr.Error.Message, queue.ErrUndeliverable)
+	}
+	return fmt.Errorf("failed to send push notification: status %d: %s: %s", resp.StatusCode, fErr.Error.Status, fErr.Error.Message)
+}
+
+// an OAuth access token for the service account, reused until shortly before
+// it expires
+func (f *FCM) token(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.accessToken != "" && time.Now().Before(f.expiresAt) {
+		return f.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewBuilder().
+		Issuer(f.clientEmail).
+		Audience([]string{f.tokenURI}).
+		IssuedAt(now).
+		Expiration(now.Add(time.Hour)).
+		Claim("scope", fcmScope).
+		Build()
+	if err != nil {
+		return "", fmt.Errorf("failed to build FCM assertion: %w", err)
+	}
+	signed, err := jwt.Sign(assertion, jwt.WithKey(jwa.RS256, f.key))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign FCM assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {string(signed)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build FCM token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch FCM access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch FCM access token: status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode FCM access token: %w", err)
+	}
+
+	f.accessToken = token.AccessToken
+	f.expiresAt = now.Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return f.accessToken, nil
+}
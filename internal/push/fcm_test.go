@@ -0,0 +1,87 @@
+package push
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFCM(t *testing.T, serverURL string) *FCM {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	credentials, err := json.Marshal(serviceAccount{
+		ProjectID:   "cv-test",
+		ClientEmail: "push@cv-test.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		TokenURI:    serverURL + "/token",
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "fcm.json")
+	require.NoError(t, os.WriteFile(path, credentials, 0o600))
+
+	fcm, err := NewFCM("", path)
+	require.NoError(t, err)
+	fcm.baseURL = serverURL
+	return fcm
+}
+
+func TestFCM_SendPush(t *testing.T) {
+	var tokenRequests int
+	var sent []fcmMessage
+	status := http.StatusOK
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.FormValue("grant_type"))
+		assert.NotEmpty(t, r.FormValue("assertion"))
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "ya29.test", "expires_in": 3600})
+	})
+	mux.HandleFunc("/projects/cv-test/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer ya29.test", r.Header.Get("Authorization"))
+		var msg fcmMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		sent = append(sent, msg)
+		w.WriteHeader(status)
+		if status == http.StatusNotFound {
+			_, _ = w.Write([]byte(`{"error": {"status": "NOT_FOUND", "message": "Requested entity was not found."}}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fcm := newTestFCM(t, server.URL)
+	payload := queue.PushDeliveryPayload{
+		Token: "device-token",
+		Title: "Pickup in 1 hour",
+		Body:  "Your camera is ready at the front desk",
+		URL:   "https://equipment.example.com/bookings/1",
+	}
+
+	require.NoError(t, fcm.SendPush(context.Background(), payload))
+	require.NoError(t, fcm.SendPush(context.Background(), payload))
+	assert.Equal(t, 1, tokenRequests, "the access token is reused")
+
+	require.Len(t, sent, 2)
+	assert.Equal(t, "device-token", sent[0].Message.Token)
+	assert.Equal(t, "Pickup in 1 hour", sent[0].Message.Notification.Title)
+	assert.Equal(t, "https://equipment.example.com/bookings/1", sent[0].Message.Data["url"])
+
+	status = http.StatusNotFound
+	err := fcm.SendPush(context.Background(), payload)
+	assert.ErrorIs(t, err, queue.ErrUndeliverable, "unregistered tokens are not retried")
+}
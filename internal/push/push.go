@@ -0,0 +1,33 @@
+package push
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/queue"
+)
+
+// the PushSender for cfg.Provider. The provider decides what a payload's
+// Token is: an FCM registration token, or a web push subscription.
+func New(cfg config.PushConfig) (queue.PushSender, error) {
+	switch cfg.Provider {
+	case "fcm":
+		return NewFCM(cfg.FCMProjectID, cfg.FCMCredentialsFile)
+	case "webpush":
+		return NewWebPush(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey, cfg.VAPIDSubject)
+	case "", "log":
+		return logSender{}, nil
+	default:
+		return nil, fmt.Errorf("unknown push provider %q", cfg.Provider)
+	}
+}
+
+// for development: notifications are logged instead of sent.
+type logSender struct{}
+
+func (logSender) SendPush(ctx context.Context, p queue.PushDeliveryPayload) error {
+	logging.FromContext(ctx).Info("Push notification not sent, no provider configured", "title", p.Title, "body", p.Body, "url", p.URL)
+	return nil
+}
@@ -0,0 +1,244 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// sends notifications straight to browsers' push services (RFC 8030), with
+// VAPID (RFC 8292) identifying this server and the payload encrypted for the
+// subscription (RFC 8291).
+type WebPush struct {
+	httpClient *http.Client
+	publicKey  string
+	privateKey *ecdsa.PrivateKey
+	subject    string
+}
+
+// the keys are the base64url VAPID pair web-push tools generate: the
+// uncompressed P-256 public point and the raw private scalar.
+func NewWebPush(publicKey, privateKey, subject string) (*WebPush, error) {
+	if subject == "" {
+		return nil, fmt.Errorf("web push needs VAPID_SUBJECT, a mailto: or https: contact")
+	}
+
+	rawPrivate, err := decodeBase64(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	ecdhKey, err := ecdh.P256().NewPrivateKey(rawPrivate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	rawPublic, err := decodeBase64(publicKey)
+	if err != nil || !bytes.Equal(rawPublic, ecdhKey.PublicKey().Bytes()) {
+		return nil, fmt.Errorf("VAPID public key does not match the private key")
+	}
+
+	// JWT signing wants an ecdsa key; PKCS#8 is the stdlib's way between the two
+	der, err := x509.MarshalPKCS8PrivateKey(ecdhKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	return &WebPush{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		publicKey:  base64.RawURLEncoding.EncodeToString(rawPublic),
+		privateKey: parsed.(*ecdsa.PrivateKey),
+		subject:    subject,
+	}, nil
+}
+
+// what a browser's PushSubscription.toJSON() returns
+type subscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// what the service worker receives as event.data.json()
+type webPushMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url,omitempty"`
+}
+
+func (w *WebPush) SendPush(ctx context.Context, p queue.PushDeliveryPayload) error {
+	var sub subscription
+	if err := json.Unmarshal([]byte(p.Token), &sub); err != nil {
+		return fmt.Errorf("invalid push subscription: %v: %w", err, queue.ErrUndeliverable)
+	}
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil || endpoint.Scheme != "https" {
+		return fmt.Errorf("invalid push subscription endpoint %q: %w", sub.Endpoint, queue.ErrUndeliverable)
+	}
+
+	message, err := json.Marshal(webPushMessage{Title: p.Title, Body: p.Body, URL: p.URL})
+	if err != nil {
+		return fmt.Errorf("failed to marshal push message: %w", err)
+	}
+	body, err := encrypt(message, sub.Keys.P256dh, sub.Keys.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt push message: %v: %w", err, queue.ErrUndeliverable)
+	}
+
+	authorization, err := w.vapid(endpoint.Scheme + "://" + endpoint.Host)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build push request: %w", err)
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	// a notice nobody saw within a day is no longer worth showing
+	req.Header.Set("TTL", "86400")
+	req.Header.Set("Urgency", "high")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	// the browser unsubscribed or the subscription expired
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return fmt.Errorf("failed to send push notification: status %d: %w", resp.StatusCode, queue.ErrUndeliverable)
+	}
+	return fmt.Errorf("failed to send push notification: status %d: %s", resp.StatusCode, raw)
+}
+
+// the Authorization header for a push service at audience
+func (w *WebPush) vapid(audience string) (string, error) {
+	token, err := jwt.NewBuilder().
+		Audience([]string{audience}).
+		Subject(w.subject).
+		Expiration(time.Now().Add(12 * time.Hour)).
+		Build()
+	if err != nil {
+		return "", fmt.Errorf("failed to build VAPID token: %w", err)
+	}
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.ES256, w.privateKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", signed, w.publicKey), nil
+}
+
+// encrypts plaintext for a subscription as a single aes128gcm record
+// (RFC 8188), keyed as RFC 8291 describes.
+func encrypt(plaintext []byte, p256dh, auth string) ([]byte, error) {
+	uaPublicRaw, err := decodeBase64(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicRaw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64(auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublicRaw := asPrivate.PublicKey().Bytes()
+	shared, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	keyInfo := "WebPush: info\x00" + string(uaPublicRaw) + string(asPublicRaw)
+	prkKey, err := hkdf.Extract(sha256.New, shared, authSecret)
+	if err != nil {
+		return nil, err
+	}
+	ikm, err := hkdf.Expand(sha256.New, prkKey, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// salt, record size, key id length, key id (the sender's public key)
+	header := make([]byte, 0, 16+4+1+len(asPublicRaw))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, 4096)
+	header = append(header, byte(len(asPublicRaw)))
+	header = append(header, asPublicRaw...)
+
+	// 0x02 marks the last (and only) record
+	record := append(append([]byte{}, plaintext...), 0x02)
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+// browsers hand out base64url keys, sometimes padded
+func decodeBase64(s string) ([]byte, error) {
+	for _, enc := range []*base64.Encoding{base64.RawURLEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.StdEncoding} {
+		if b, err := enc.DecodeString(s); err == nil {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("not base64")
+}
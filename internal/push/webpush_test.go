@@ -0,0 +1,179 @@
+package push
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// a browser's side of a subscription
+type testBrowser struct {
+	private *ecdh.PrivateKey
+	auth    []byte
+}
+
+func newTestBrowser(t *testing.T) *testBrowser {
+	private, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	auth := make([]byte, 16)
+	_, err = rand.Read(auth)
+	require.NoError(t, err)
+	return &testBrowser{private: private, auth: auth}
+}
+
+func (b *testBrowser) subscription(endpoint string) string {
+	raw, _ := json.Marshal(map[string]any{
+		"endpoint": endpoint,
+		"keys": map[string]string{
+			"p256dh": base64.RawURLEncoding.EncodeToString(b.private.PublicKey().Bytes()),
+			"auth":   base64.RawURLEncoding.EncodeToString(b.auth),
+		},
+	})
+	return string(raw)
+}
+
+// the receiving half of RFC 8291
+func (b *testBrowser) decrypt(t *testing.T, body []byte) []byte {
+	require.Greater(t, len(body), 21)
+	salt := body[:16]
+	require.Equal(t, uint32(4096), binary.BigEndian.Uint32(body[16:20]))
+	idLen := int(body[20])
+	asPublic, err := ecdh.P256().NewPublicKey(body[21 : 21+idLen])
+	require.NoError(t, err)
+	ciphertext := body[21+idLen:]
+
+	shared, err := b.private.ECDH(asPublic)
+	require.NoError(t, err)
+	keyInfo := "WebPush: info\x00" + string(b.private.PublicKey().Bytes()) + string(asPublic.Bytes())
+	prkKey, err := hkdf.Extract(sha256.New, shared, b.auth)
+	require.NoError(t, err)
+	ikm, err := hkdf.Expand(sha256.New, prkKey, keyInfo, 32)
+	require.NoError(t, err)
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	require.NoError(t, err)
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	require.NoError(t, err)
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher(cek)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	record, err := gcm.Open(nil, nonce, ciphertext, nil)
+	require.NoError(t, err)
+	require.Equal(t, byte(0x02), record[len(record)-1], "single record is marked last")
+	return record[:len(record)-1]
+}
+
+func newTestWebPush(t *testing.T) *WebPush {
+	vapid, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	w, err := NewWebPush(
+		base64.RawURLEncoding.EncodeToString(vapid.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(vapid.Bytes()),
+		"mailto:equipment@example.com")
+	require.NoError(t, err)
+	return w
+}
+
+func TestWebPush_SendPush(t *testing.T) {
+	browser := newTestBrowser(t)
+	sender := newTestWebPush(t)
+
+	var header http.Header
+	var body []byte
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	sender.httpClient = server.Client()
+
+	err := sender.SendPush(context.Background(), queue.PushDeliveryPayload{
+		Token: browser.subscription(server.URL + "/push/abc"),
+		Title: "Pickup in 1 hour",
+		Body:  "Your camera is ready at the front desk",
+		URL:   "https://equipment.example.com/bookings/1",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "aes128gcm", header.Get("Content-Encoding"))
+	assert.NotEmpty(t, header.Get("TTL"))
+
+	var message webPushMessage
+	require.NoError(t, json.Unmarshal(browser.decrypt(t, body), &message))
+	assert.Equal(t, webPushMessage{
+		Title: "Pickup in 1 hour",
+		Body:  "Your camera is ready at the front desk",
+		URL:   "https://equipment.example.com/bookings/1",
+	}, message)
+
+	// the push service checks the VAPID token against the k= key
+	authorization, ok := strings.CutPrefix(header.Get("Authorization"), "vapid t=")
+	require.True(t, ok)
+	token, key, ok := strings.Cut(authorization, ", k=")
+	require.True(t, ok)
+	assert.Equal(t, sender.publicKey, key)
+	parsed, err := jwt.Parse([]byte(token), jwt.WithKey(jwa.ES256, &sender.privateKey.PublicKey))
+	require.NoError(t, err)
+	assert.Equal(t, []string{server.URL}, parsed.Audience())
+	assert.Equal(t, "mailto:equipment@example.com", parsed.Subject())
+}
+
+func TestWebPush_SendPush_Errors(t *testing.T) {
+	browser := newTestBrowser(t)
+	sender := newTestWebPush(t)
+
+	status := http.StatusGone
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	sender.httpClient = server.Client()
+
+	payload := queue.PushDeliveryPayload{Token: browser.subscription(server.URL), Title: "t", Body: "b"}
+
+	err := sender.SendPush(context.Background(), payload)
+	assert.ErrorIs(t, err, queue.ErrUndeliverable, "expired subscriptions are not retried")
+
+	status = http.StatusTooManyRequests
+	err = sender.SendPush(context.Background(), payload)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, queue.ErrUndeliverable)
+
+	err = sender.SendPush(context.Background(), queue.PushDeliveryPayload{Token: "not a subscription"})
+	assert.ErrorIs(t, err, queue.ErrUndeliverable)
+}
+
+func TestNewWebPush_MismatchedKeys(t *testing.T) {
+	a, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	b, err := ecdh.P256().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	_, err = NewWebPush(
+		base64.RawURLEncoding.EncodeToString(b.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(a.Bytes()),
+		"mailto:equipment@example.com")
+	assert.Error(t, err)
+}
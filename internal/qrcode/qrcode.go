@@ -0,0 +1,230 @@
+// Package qrcode encodes short text, such as a label's deep link, as a QR
+// code (ISO/IEC 18004) in byte mode at error correction level M, which
+// survives a scuffed or partly covered label. Versions 1 to 10 are
+// supported, up to 213 bytes of text; labels need far less.
+package qrcode
+
+import (
+	"errors"
+	"math"
+)
+
+// text longer than version 10 holds
+var ErrTooLong = errors.New("qrcode: text too long")
+
+// a QR code as a square of modules, dark or light.
+type Code struct {
+	size    int
+	modules [][]bool
+}
+
+// width and height in modules, without the quiet zone.
+func (c *Code) Size() int {
+	return c.size
+}
+
+// reports whether the module at column x, row y is dark.
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// error correction blocks of a version at level M: every block carries
+// ecPerBlock codewords, the first blocks1 hold data1 data codewords and the
+// remaining blocks2 one more.
+type blockLayout struct {
+	ecPerBlock int
+	blocks1    int
+	data1      int
+	blocks2    int
+}
+
+var layouts = [...]blockLayout{
+	1:  {10, 1, 16, 0},
+	2:  {16, 1, 28, 0},
+	3:  {26, 1, 44, 0},
+	4:  {18, 2, 32, 0},
+	5:  {24, 2, 43, 0},
+	6:  {16, 4, 27, 0},
+	7:  {18, 4, 31, 0},
+	8:  {22, 2, 38, 2},
+	9:  {22, 3, 36, 2},
+	10: {26, 4, 43, 1},
+}
+
+func (l blockLayout) dataCodewords() int {
+	return l.blocks1*l.data1 + l.blocks2*(l.data1+1)
+}
+
+// centres of the alignment patterns on each axis
+var alignment = [...][]int{
+	2:  {6, 18},
+	3:  {6, 22},
+	4:  {6, 26},
+	5:  {6, 30},
+	6:  {6, 34},
+	7:  {6, 22, 38},
+	8:  {6, 24, 42},
+	9:  {6, 26, 46},
+	10: {6, 28, 50},
+}
+
+const maxVersion = 10
+
+// encodes text in the smallest version it fits.
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+	version := 0
+	for v := 1; v <= maxVersion; v++ {
+		if 4+countBits(v)+8*len(data) <= 8*layouts[v].dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	q := newMatrix(version)
+	q.drawFunctionPatterns()
+	q.drawCodewords(interleave(version, dataCodewords(version, data)))
+
+	// the mask giving the fewest scanner-confusing features wins
+	best, bestPenalty := 0, math.MaxInt
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if p := q.penalty(); p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		q.applyMask(mask) // masking twice undoes it
+	}
+	q.applyMask(best)
+	q.drawFormatBits(best)
+
+	return &Code{size: q.size, modules: q.modules}, nil
+}
+
+// width of the byte mode character count
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// the mode indicator, count and text, terminated and padded to the
+// version's data capacity
+func dataCodewords(version int, data []byte) []byte {
+	capacity := 8 * layouts[version].dataCodewords()
+
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	return bits.bytes()
+}
+
+// splits data into the version's blocks, adds each block's error correction
+// and interleaves the lot in the order it is placed
+func interleave(version int, data []byte) []byte {
+	l := layouts[version]
+	gen := generator(l.ecPerBlock)
+
+	var blocks, ecs [][]byte
+	for i := 0; i < l.blocks1+l.blocks2; i++ {
+		n := l.data1
+		if i >= l.blocks1 {
+			n++
+		}
+		blocks = append(blocks, data[:n])
+		ecs = append(ecs, remainder(data[:n], gen))
+		data = data[n:]
+	}
+
+	var out []byte
+	for i := 0; i <= l.data1; i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < l.ecPerBlock; i++ {
+		for _, ec := range ecs {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// Reed-Solomon over GF(256) with the QR polynomial x^8+x^4+x^3+x^2+1
+
+func gfMul(x, y byte) byte {
+	var z byte
+	for ; y != 0; y >>= 1 {
+		if y&1 != 0 {
+			z ^= x
+		}
+		carry := x & 0x80
+		x <<= 1
+		if carry != 0 {
+			x ^= 0x1D
+		}
+	}
+	return z
+}
+
+// the generator polynomial of the given degree, highest coefficient first
+func generator(degree int) []byte {
+	g := []byte{1}
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		next := make([]byte, len(g)+1)
+		for j, c := range g {
+			next[j] ^= c
+			next[j+1] ^= gfMul(c, root)
+		}
+		g = next
+		root = gfMul(root, 2)
+	}
+	return g
+}
+
+// the error correction codewords of data: its remainder modulo gen
+func remainder(data, gen []byte) []byte {
+	rem := make([]byte, len(gen)-1)
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[len(rem)-1] = 0
+		for j := range rem {
+			rem[j] ^= gfMul(gen[j+1], factor)
+		}
+	}
+	return rem
+}
@@ -0,0 +1,110 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemainder(t *testing.T) {
+	// the 1-M "HELLO WORLD" example from the specification's annex
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	assert.Equal(t, want, remainder(data, generator(10)))
+}
+
+func TestDataCodewords(t *testing.T) {
+	got := dataCodewords(1, []byte("AB"))
+
+	require.Len(t, got, 16)
+	// 0100, count 00000010, 'A' 01000001, 'B' 01000010, terminator 0000
+	assert.Equal(t, []byte{0x40, 0x24, 0x14, 0x20}, got[:4])
+	assert.Equal(t, []byte{0xEC, 0x11, 0xEC}, got[4:7])
+}
+
+func TestEncode(t *testing.T) {
+	t.Run("picks the smallest version the text fits", func(t *testing.T) {
+		for _, tc := range []struct {
+			length int
+			size   int
+		}{
+			{1, 21},
+			{14, 21},
+			{15, 25},
+			{84, 37},
+			{85, 41},
+			{213, 57},
+		} {
+			code, err := Encode(strings.Repeat("x", tc.length))
+			require.NoError(t, err)
+			assert.Equal(t, tc.size, code.Size(), "%d bytes", tc.length)
+		}
+	})
+
+	t.Run("too long for version 10", func(t *testing.T) {
+		_, err := Encode(strings.Repeat("x", 214))
+		assert.ErrorIs(t, err, ErrTooLong)
+	})
+
+	t.Run("finders sit in three corners", func(t *testing.T) {
+		code, err := Encode("https://vault.example.edu/scan/item:4f1c")
+		require.NoError(t, err)
+
+		last := code.Size() - 1
+		for _, corner := range [][2]int{{0, 0}, {last - 6, 0}, {0, last - 6}} {
+			x, y := corner[0], corner[1]
+			assert.True(t, code.Dark(x, y))
+			assert.False(t, code.Dark(x+1, y+1))
+			assert.True(t, code.Dark(x+3, y+3))
+		}
+	})
+
+	t.Run("format bits carry level M", func(t *testing.T) {
+		code, err := Encode("unit")
+		require.NoError(t, err)
+
+		// the level's two bits, at the foot of the lower left copy, are
+		// M's 00 under the format mask's 10
+		levelBits := 0
+		if code.Dark(8, code.Size()-1) {
+			levelBits |= 0b10
+		}
+		if code.Dark(8, code.Size()-2) {
+			levelBits |= 0b01
+		}
+		assert.Equal(t, 0b10, levelBits)
+	})
+}
+
+func TestWritePNG(t *testing.T) {
+	code, err := Encode("item")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, code.WritePNG(&buf, 4))
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	width := (code.Size() + 2*QuietZone) * 4
+	assert.Equal(t, width, img.Bounds().Dx())
+
+	r, _, _, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, uint32(0xffff), r, "quiet zone is light")
+	r, _, _, _ = img.At(QuietZone*4, QuietZone*4).RGBA()
+	assert.Equal(t, uint32(0), r, "finder corner is dark")
+}
+
+func TestWriteSVG(t *testing.T) {
+	code, err := Encode("item")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, code.WriteSVG(&buf))
+	assert.Contains(t, buf.String(), `viewBox="0 0 29 29"`)
+	assert.Contains(t, buf.String(), "M4,4h1v1h-1z")
+}
@@ -0,0 +1,56 @@
+package qrcode
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// light modules around the symbol scanners need to find its edge
+const QuietZone = 4
+
+// writes the code as a black on white PNG, scale pixels to a module.
+func (c *Code) WritePNG(w io.Writer, scale int) error {
+	if scale < 1 {
+		scale = 1
+	}
+	width := (c.size + 2*QuietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, width, width), color.Palette{color.White, color.Black})
+	for y := range c.size {
+		for x := range c.size {
+			if !c.modules[y][x] {
+				continue
+			}
+			for dy := range scale {
+				for dx := range scale {
+					img.SetColorIndex((x+QuietZone)*scale+dx, (y+QuietZone)*scale+dy, 1)
+				}
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// writes the code as an SVG of one path, a unit to a module, which prints
+// sharp at any size.
+func (c *Code) WriteSVG(w io.Writer) error {
+	var path strings.Builder
+	for y := range c.size {
+		for x := range c.size {
+			if c.modules[y][x] {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+	width := c.size + 2*QuietZone
+	_, err := fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">
+<rect width="100%%" height="100%%" fill="#fff"/>
+<path d="%s" fill="#000"/>
+</svg>
+`, width, width, path.String())
+	return err
+}
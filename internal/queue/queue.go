@@ -3,27 +3,189 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/USSTM/cv-backend/internal/config"
 	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/metrics"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 )
 
 type EmailSender interface {
-	SendEmail(ctx context.Context, to, subject, body string) error
+	// htmlBody may be empty, in which case only the text part is sent
+	SendEmail(ctx context.Context, to, subject, textBody, htmlBody string) error
+}
+
+// an EmailSender that can also attach files. Emails with attachments sent
+// through a sender without it go out without them.
+type AttachmentSender interface {
+	SendEmailWithAttachments(ctx context.Context, to, subject, textBody, htmlBody string, attachments []EmailAttachment) error
+}
+
+type SMSSender interface {
+	// to is an E.164 phone number
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+type PushSender interface {
+	SendPush(ctx context.Context, p PushDeliveryPayload) error
+}
+
+// posts a message to the team's Slack or Discord channel.
+type ChatSender interface {
+	SendChat(ctx context.Context, text string) error
+}
+
+// returned, wrapped, by an SMSSender, PushSender or ChatSender when retrying
+// can't help, e.g. a number the provider rejects, a push subscription that
+// has expired or a chat webhook that was revoked
+var ErrUndeliverable = errors.New("undeliverable")
+
+// returned, wrapped, by an EmailSender for an address that bounced or
+// complained before. The email is dropped: retrying won't change that.
+var ErrSuppressed = errors.New("address suppressed")
+
+// permanently removes recycle-bin entries whose retention window has passed.
+type DeletionPurger interface {
+	PurgeExpired(ctx context.Context) (int, error)
+}
+
+// imports managers' linked calendars and removes conflicting availability.
+type CalendarSyncer interface {
+	SyncAll(ctx context.Context) (int, error)
+	SyncUser(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// builds a requested CSV report and notifies its requester.
+type ReportGenerator interface {
+	Generate(ctx context.Context, reportID uuid.UUID) error
+}
+
+// sends end-of-term return reminders and escalations.
+type CampaignRunner interface {
+	RunAll(ctx context.Context) (int, error)
+	Run(ctx context.Context, campaignID uuid.UUID) (int, error)
+}
+
+// alerts reviewers about requests past their group's review SLA and escalates
+// requests left with an item's owning group.
+type SLAChecker interface {
+	CheckBreaches(ctx context.Context) (int, error)
+	Escalate(ctx context.Context) (int, error)
+}
+
+// marks overdue borrowings and reminds borrowers to return them.
+type OverdueChecker interface {
+	CheckOverdue(ctx context.Context) (int, error)
+}
+
+// expires unconfirmed bookings and deletes stale availability slots.
+type Housekeeper interface {
+	ExpireBookings(ctx context.Context) (int, error)
+	CleanupAvailability(ctx context.Context) (int, error)
+}
+
+// emails the weekly inventory summary and the approver digests.
+type DigestSender interface {
+	SendInventoryDigest(ctx context.Context) (int, error)
+	SendApproverDigest(ctx context.Context) (int, error)
+}
+
+// deletes stored uploads nothing refers to any more.
+type OrphanSweeper interface {
+	Sweep(ctx context.Context) (int, error)
+}
+
+// tells members waiting on an item that it is back in stock.
+type WaitlistNotifier interface {
+	NotifyNext(ctx context.Context, itemID uuid.UUID) (int, error)
+}
+
+// strips EXIF data from an uploaded condition photo and stores its variants.
+type PhotoProcessor interface {
+	Process(ctx context.Context, imageID uuid.UUID) error
+}
+
+// posts an event to a webhook and records the attempt. final is set on the
+// last attempt asynq will make, so a failure marks the delivery failed rather
+// than waiting for a retry.
+type WebhookDeliverer interface {
+	Deliver(ctx context.Context, deliveryID uuid.UUID, final bool) error
+}
+
+// how failed tasks are retried. asynq archives a task once its retries run out
+// or it fails with asynq.SkipRetry; the archived tasks are the dead-letter
+// queue, see ListDeadTasks.
+type RetryPolicy struct {
+	// retries per task type; other types get MaxRetry, or asynq's default
+	// when that is 0 too
+	MaxRetries map[string]int
+	MaxRetry   int
+	// the nth retry waits BaseDelay * 2^n, at most MaxDelay (a day if 0), plus
+	// up to a tenth of that again as jitter. A zero BaseDelay keeps asynq's
+	// backoff.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+func NewRetryPolicy(cfg *config.WorkerConfig) RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: cfg.MaxRetries,
+		MaxRetry:   cfg.MaxRetry,
+		BaseDelay:  cfg.RetryBaseDelay,
+		MaxDelay:   cfg.RetryMaxDelay,
+	}
+}
+
+// the enqueue options that give taskType its retry count
+func (p RetryPolicy) options(taskType string) []asynq.Option {
+	if n, ok := p.MaxRetries[taskType]; ok {
+		return []asynq.Option{asynq.MaxRetry(n)}
+	}
+	if p.MaxRetry > 0 {
+		return []asynq.Option{asynq.MaxRetry(p.MaxRetry)}
+	}
+	return nil
+}
+
+func (p RetryPolicy) delay(n int, err error, task *asynq.Task) time.Duration {
+	if p.BaseDelay <= 0 {
+		return asynq.DefaultRetryDelayFunc(n, err, task)
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 24 * time.Hour
+	}
+	d := p.BaseDelay
+	for i := 0; i < n && d < maxDelay; i++ {
+		d *= 2
+	}
+	d = min(d, maxDelay)
+	// spread out tasks that failed together, e.g. during an SES outage
+	return d + time.Duration(rand.Int63n(int64(d)/10+1))
 }
 
 type TaskQueue struct {
-	client *asynq.Client
+	client    *asynq.Client
+	inspector *asynq.Inspector
+	retry     RetryPolicy
 }
 
-func NewQueue(cfg *config.RedisConfig) (*TaskQueue, error) {
-	client := asynq.NewClient(asynq.RedisClientOpt{
+func NewQueue(cfg *config.RedisConfig, retry RetryPolicy) (*TaskQueue, error) {
+	opt := asynq.RedisClientOpt{
 		Addr:     cfg.Addr,
 		Password: cfg.Password,
 		DB:       cfg.DB,
-	})
+	}
+	client := asynq.NewClient(opt)
 
 	// Activate and test the connection
 	if err := client.Ping(); err != nil {
@@ -32,48 +194,373 @@ func NewQueue(cfg *config.RedisConfig) (*TaskQueue, error) {
 
 	logging.Info("Connected to Redis task queue")
 
-	return &TaskQueue{client: client}, nil
+	return &TaskQueue{client: client, inspector: asynq.NewInspector(opt), retry: retry}, nil
 }
 
-func (q *TaskQueue) Enqueue(taskType string, data interface{}) (*asynq.TaskInfo, error) {
-	payload, err := json.Marshal(data)
+func (q *TaskQueue) Enqueue(ctx context.Context, taskType string, data interface{}) (*asynq.TaskInfo, error) {
+	payload, err := marshalPayload(ctx, data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+		return nil, err
 	}
 
 	task := asynq.NewTask(taskType, payload)
 
-	t, err := q.client.Enqueue(task)
+	t, err := q.client.EnqueueContext(ctx, task, q.retry.options(taskType)...)
 
 	return t, err
 }
 
+// like Enqueue, but the task is held as scheduled until processAt.
+func (q *TaskQueue) EnqueueAt(ctx context.Context, taskType string, data interface{}, processAt time.Time) (*asynq.TaskInfo, error) {
+	payload, err := marshalPayload(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := append(q.retry.options(taskType), asynq.ProcessAt(processAt))
+	return q.client.EnqueueContext(ctx, asynq.NewTask(taskType, payload), opts...)
+}
+
+// the payload key carrying the ID of the request that enqueued a task.
+// Tasks have no headers, and handlers decoding the payload into their own
+// struct ignore it.
+const payloadRequestID = "RequestID"
+
+// data as JSON, with the request ID from ctx added when data is an object
+func marshalPayload(ctx context.Context, data interface{}) ([]byte, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	requestID := middleware.GetRequestID(ctx)
+	if requestID == "" {
+		return payload, nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &object); err != nil || object == nil {
+		return payload, nil
+	}
+	object[payloadRequestID], _ = json.Marshal(requestID)
+	return json.Marshal(object)
+}
+
 func (q *TaskQueue) Close() error {
+	if err := q.inspector.Close(); err != nil {
+		logging.Error("failed to close queue inspector", "error", err)
+	}
 	return q.client.Close()
 }
 
+// ErrQueueNotFound is returned by the admin operations for unknown queue names.
+var ErrQueueNotFound = errors.New("queue not found")
+
+// Task states that DrainQueue can delete.
 const (
-	TypeEmailDelivery = "email:delivery"
+	StateScheduled = "scheduled"
+	StatePending   = "pending"
+	StateRetry     = "retry"
+	StateArchived  = "archived"
 )
 
+func (q *TaskQueue) ListQueues() ([]*asynq.QueueInfo, error) {
+	names, err := q.inspector.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+
+	infos := make([]*asynq.QueueInfo, 0, len(names))
+	for _, name := range names {
+		info, err := q.inspector.GetQueueInfo(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get queue info for %s: %w", name, err)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (q *TaskQueue) GetQueueInfo(name string) (*asynq.QueueInfo, error) {
+	if err := q.requireQueue(name); err != nil {
+		return nil, err
+	}
+	return q.inspector.GetQueueInfo(name)
+}
+
+// workers stop picking up new tasks from a paused queue; active tasks keep running.
+func (q *TaskQueue) PauseQueue(name string) error {
+	if err := q.requireQueue(name); err != nil {
+		return err
+	}
+	return q.inspector.PauseQueue(name)
+}
+
+func (q *TaskQueue) ResumeQueue(name string) error {
+	if err := q.requireQueue(name); err != nil {
+		return err
+	}
+	return q.inspector.UnpauseQueue(name)
+}
+
+// deletes every task in the given state and returns how many were removed.
+func (q *TaskQueue) DrainQueue(name, state string) (int, error) {
+	if err := q.requireQueue(name); err != nil {
+		return 0, err
+	}
+
+	switch state {
+	case StateScheduled:
+		return q.inspector.DeleteAllScheduledTasks(name)
+	case StatePending:
+		return q.inspector.DeleteAllPendingTasks(name)
+	case StateRetry:
+		return q.inspector.DeleteAllRetryTasks(name)
+	case StateArchived:
+		return q.inspector.DeleteAllArchivedTasks(name)
+	default:
+		return 0, fmt.Errorf("unsupported task state: %s", state)
+	}
+}
+
+// signals the worker processing taskID to cancel it. Best effort: a task that
+// already finished is not an error.
+func (q *TaskQueue) CancelTask(taskID string) error {
+	return q.inspector.CancelProcessing(taskID)
+}
+
+// tasks that failed for good in the named queue, newest first
+func (q *TaskQueue) ListDeadTasks(name string) ([]*asynq.TaskInfo, error) {
+	if err := q.requireQueue(name); err != nil {
+		return nil, err
+	}
+
+	const pageSize = 100
+	var tasks []*asynq.TaskInfo
+	for page := 1; ; page++ {
+		batch, err := q.inspector.ListArchivedTasks(name, asynq.PageSize(pageSize), asynq.Page(page))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list dead tasks in %s: %w", name, err)
+		}
+		tasks = append(tasks, batch...)
+		if len(batch) < pageSize {
+			return tasks, nil
+		}
+	}
+}
+
+// moves a dead task back to pending with its retry count reset.
+func (q *TaskQueue) RetryDeadTask(name, taskID string) error {
+	if err := q.requireQueue(name); err != nil {
+		return err
+	}
+	return q.inspector.RunTask(name, taskID)
+}
+
+func (q *TaskQueue) RetryAllDeadTasks(name string) (int, error) {
+	if err := q.requireQueue(name); err != nil {
+		return 0, err
+	}
+	return q.inspector.RunAllArchivedTasks(name)
+}
+
+func (q *TaskQueue) DiscardDeadTask(name, taskID string) error {
+	if err := q.requireQueue(name); err != nil {
+		return err
+	}
+	return q.inspector.DeleteTask(name, taskID)
+}
+
+func (q *TaskQueue) requireQueue(name string) error {
+	names, err := q.inspector.Queues()
+	if err != nil {
+		return fmt.Errorf("failed to list queues: %w", err)
+	}
+	for _, n := range names {
+		if n == name {
+			return nil
+		}
+	}
+	return ErrQueueNotFound
+}
+
+const (
+	TypeEmailDelivery   = "email:delivery"
+	TypeSMSDelivery     = "sms:delivery"
+	TypePushDelivery    = "push:delivery"
+	TypeChatDelivery    = "chat:delivery"
+	TypeDeletionPurge   = "deletion:purge"
+	TypeCalendarSync    = "calendar:sync"
+	TypeReportGenerate  = "report:generate"
+	TypeCampaignRun     = "campaign:run"
+	TypeSLACheck        = "sla:check"
+	TypeWaitlistNotify  = "waitlist:notify"
+	TypeOverdueCheck    = "overdue:check"
+	TypePhotoProcess    = "photo:process"
+	TypeWebhookDelivery = "webhook:delivery"
+
+	TypeBookingExpire       = "booking:expire"
+	TypeAvailabilityCleanup = "availability:cleanup"
+	TypeInventoryDigest     = "inventory:digest"
+	TypeApproverDigest      = "approver:digest"
+	TypeOrphanSweep         = "orphan:sweep"
+)
+
+// Body is the plain-text part; HTMLBody is optional and sent alongside it.
 type EmailDeliveryPayload struct {
-	To      string
-	Subject string
-	Body    string
+	To          string
+	Subject     string
+	Body        string
+	HTMLBody    string
+	Attachments []EmailAttachment `json:",omitempty"`
+}
+
+// ContentType may carry parameters, e.g. "text/calendar; method=PUBLISH".
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// To is an E.164 phone number. Keep Body short; long texts are split and
+// billed per segment.
+type SMSDeliveryPayload struct {
+	To   string
+	Body string
+}
+
+// Token is an FCM registration token, or a web push subscription as the JSON
+// the browser's PushSubscription.toJSON() returns, depending on the worker's
+// push provider. URL is opened when the notification is tapped.
+type PushDeliveryPayload struct {
+	Token string
+	Title string
+	Body  string
+	URL   string
+}
+
+// Text is already formatted for the configured chat provider.
+type ChatDeliveryPayload struct {
+	Text string
+}
+
+// a nil UserID syncs every linked calendar.
+type CalendarSyncPayload struct {
+	UserID *uuid.UUID
+}
+
+type ReportGeneratePayload struct {
+	ReportID uuid.UUID
+}
+
+// a nil CampaignID runs every active campaign.
+type CampaignRunPayload struct {
+	CampaignID *uuid.UUID
+}
+
+type WaitlistNotifyPayload struct {
+	ItemID uuid.UUID
+}
+
+// ImageID is a borrowing image, i.e. a condition photo.
+type PhotoProcessPayload struct {
+	ImageID uuid.UUID
+}
+
+type WebhookDeliveryPayload struct {
+	DeliveryID uuid.UUID
+}
+
+// a task the scheduler enqueues whenever Spec fires. Spec is a cron
+// expression ("0 9 * * *") or "@every <duration>"; "" or "off" disables it.
+type PeriodicTask struct {
+	Type string
+	Spec string
+}
+
+// periodic tasks registered with the scheduler. Cron expressions are read in
+// Location, or UTC when it is nil.
+type Schedule struct {
+	Location *time.Location
+	Tasks    []PeriodicTask
+}
+
+// every periodic job the worker knows about, on the schedules from cfg
+func NewSchedule(cfg *config.Config, location *time.Location) Schedule {
+	return Schedule{
+		Location: location,
+		Tasks: []PeriodicTask{
+			{TypeCalendarSync, Every(cfg.Calendar.SyncInterval)},
+			{TypeCampaignRun, cfg.Campaign.Schedule},
+			{TypeSLACheck, Every(cfg.SLA.CheckInterval)},
+			{TypeOverdueCheck, cfg.Overdue.CheckSchedule},
+			{TypeBookingExpire, cfg.Booking.ExpirySchedule},
+			{TypeAvailabilityCleanup, cfg.Availability.CleanupSchedule},
+			{TypeInventoryDigest, cfg.Digest.Schedule},
+			{TypeApproverDigest, cfg.Digest.ApproverSchedule},
+			{TypeOrphanSweep, cfg.Storage.OrphanSchedule},
+		},
+	}
+}
+
+// the spec for a task run every interval; "" when interval is 0
+func Every(interval time.Duration) string {
+	if interval <= 0 {
+		return ""
+	}
+	return "@every " + interval.String()
+}
+
+func (s Schedule) enabled() []PeriodicTask {
+	var tasks []PeriodicTask
+	for _, task := range s.Tasks {
+		if task.Spec != "" && task.Spec != "off" {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// several workers may share a schedule, so each run is unique until it
+// finishes or, at most, until the next one is due
+func uniqueFor(spec string) time.Duration {
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		if d, err := time.ParseDuration(interval); err == nil && d > 0 {
+			return d
+		}
+	}
+	return time.Hour
 }
 
 type Worker struct {
 	server       *asynq.Server
+	scheduler    *asynq.Scheduler
+	schedule     Schedule
+	retry        RetryPolicy
 	emailService EmailSender
+	sms          SMSSender
+	push         PushSender
+	chat         ChatSender
+	purger       DeletionPurger
+	calendar     CalendarSyncer
+	reports      ReportGenerator
+	campaigns    CampaignRunner
+	slas         SLAChecker
+	waitlist     WaitlistNotifier
+	overdue      OverdueChecker
+	housekeeper  Housekeeper
+	digest       DigestSender
+	photos       PhotoProcessor
+	webhooks     WebhookDeliverer
+	orphans      OrphanSweeper
 }
 
-func NewWorker(cfg *config.RedisConfig, emailService EmailSender) *Worker {
+func NewWorker(cfg *config.RedisConfig, emailService EmailSender, sms SMSSender, push PushSender, chat ChatSender, purger DeletionPurger, calendar CalendarSyncer, reports ReportGenerator, campaigns CampaignRunner, slas SLAChecker, waitlist WaitlistNotifier, overdue OverdueChecker, housekeeper Housekeeper, digest DigestSender, photos PhotoProcessor, webhooks WebhookDeliverer, orphans OrphanSweeper, schedule Schedule, retry RetryPolicy) *Worker {
+	opt := asynq.RedisClientOpt{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	}
 	server := asynq.NewServer(
-		asynq.RedisClientOpt{
-			Addr:     cfg.Addr,
-			Password: cfg.Password,
-			DB:       cfg.DB,
-		},
+		opt,
 		asynq.Config{
 			Concurrency: 10,
 			Queues: map[string]int{
@@ -81,41 +568,466 @@ func NewWorker(cfg *config.RedisConfig, emailService EmailSender) *Worker {
 				"default":  3,
 				"low":      1,
 			},
+			RetryDelayFunc: retry.delay,
+			Logger:         asynqLogger{},
 			ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
-				logging.Error("process task failed", "type", task.Type(), "payload", string(task.Payload()), "error", err)
+				// asynq hands the error handler the task's own context rather
+				// than the one scopeTask built, so the logger is made again
+				logger := taskLogger(ctx, task)
+				retried, _ := asynq.GetRetryCount(ctx)
+				maxRetry, _ := asynq.GetMaxRetry(ctx)
+				if retried >= maxRetry || errors.Is(err, asynq.SkipRetry) {
+					logger.Error("task failed for good, moved to dead-letter queue", "payload", string(task.Payload()), "retried", retried, "error", err)
+					return
+				}
+				logger.Error("process task failed, will retry", "payload", string(task.Payload()), "retried", retried, "max_retry", maxRetry, "error", err)
 			}),
 		},
 	)
 
+	var scheduler *asynq.Scheduler
+	if len(schedule.enabled()) > 0 {
+		scheduler = asynq.NewScheduler(opt, &asynq.SchedulerOpts{Location: schedule.Location, Logger: asynqLogger{}})
+	}
+
 	return &Worker{
 		server:       server,
+		scheduler:    scheduler,
+		schedule:     schedule,
+		retry:        retry,
 		emailService: emailService,
+		sms:          sms,
+		push:         push,
+		chat:         chat,
+		purger:       purger,
+		calendar:     calendar,
+		reports:      reports,
+		campaigns:    campaigns,
+		slas:         slas,
+		waitlist:     waitlist,
+		overdue:      overdue,
+		housekeeper:  housekeeper,
+		digest:       digest,
+		photos:       photos,
+		webhooks:     webhooks,
+		orphans:      orphans,
 	}
 }
 
 func (w *Worker) Start() error {
 	mux := asynq.NewServeMux()
+	mux.Use(scopeTask, observeTask)
 	mux.HandleFunc(TypeEmailDelivery, w.HandleEmailDelivery)
+	mux.HandleFunc(TypeSMSDelivery, w.HandleSMSDelivery)
+	mux.HandleFunc(TypePushDelivery, w.HandlePushDelivery)
+	mux.HandleFunc(TypeChatDelivery, w.HandleChatDelivery)
+	mux.HandleFunc(TypeDeletionPurge, w.HandleDeletionPurge)
+	mux.HandleFunc(TypeCalendarSync, w.HandleCalendarSync)
+	mux.HandleFunc(TypeReportGenerate, w.HandleReportGenerate)
+	mux.HandleFunc(TypeCampaignRun, w.HandleCampaignRun)
+	mux.HandleFunc(TypeSLACheck, w.HandleSLACheck)
+	mux.HandleFunc(TypeWaitlistNotify, w.HandleWaitlistNotify)
+	mux.HandleFunc(TypeOverdueCheck, w.HandleOverdueCheck)
+	mux.HandleFunc(TypeBookingExpire, w.HandleBookingExpire)
+	mux.HandleFunc(TypeAvailabilityCleanup, w.HandleAvailabilityCleanup)
+	mux.HandleFunc(TypeInventoryDigest, w.HandleInventoryDigest)
+	mux.HandleFunc(TypeApproverDigest, w.HandleApproverDigest)
+	mux.HandleFunc(TypeOrphanSweep, w.HandleOrphanSweep)
+	mux.HandleFunc(TypePhotoProcess, w.HandlePhotoProcess)
+	mux.HandleFunc(TypeWebhookDelivery, w.HandleWebhookDelivery)
 
-	return w.server.Start(mux)
+	if err := w.server.Start(mux); err != nil {
+		return err
+	}
+
+	if w.scheduler != nil {
+		for _, task := range w.schedule.enabled() {
+			opts := w.periodicOptions(task.Type, uniqueFor(task.Spec))
+			if _, err := w.scheduler.Register(task.Spec, asynq.NewTask(task.Type, []byte(`{}`)), opts...); err != nil {
+				return fmt.Errorf("failed to register %s on %q: %w", task.Type, task.Spec, err)
+			}
+			logging.Info("Registered periodic task", "type", task.Type, "spec", task.Spec)
+		}
+		if err := w.scheduler.Start(); err != nil {
+			return fmt.Errorf("failed to start scheduler: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// gives each task a logger carrying its type, ID and attempt, and the ID of
+// the request that enqueued it, for handlers to get with logging.FromContext
+func scopeTask(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		return next.ProcessTask(logging.NewContext(ctx, taskLogger(ctx, t)), t)
+	})
+}
+
+func taskLogger(ctx context.Context, t *asynq.Task) *slog.Logger {
+	attrs := []any{"task_type", t.Type()}
+	if id, ok := asynq.GetTaskID(ctx); ok {
+		attrs = append(attrs, "task_id", id)
+	}
+	if retried, ok := asynq.GetRetryCount(ctx); ok && retried > 0 {
+		attrs = append(attrs, "retry", retried)
+	}
+	var envelope map[string]json.RawMessage
+	if json.Unmarshal(t.Payload(), &envelope) == nil {
+		var requestID string
+		if json.Unmarshal(envelope[payloadRequestID], &requestID) == nil && requestID != "" {
+			attrs = append(attrs, "request_id", requestID)
+		}
+	}
+	return logging.With(attrs...)
+}
+
+// sends the task queue's own lines through the logging package, so they
+// come out in the same format, JSON included, as everything else
+type asynqLogger struct{}
+
+func (asynqLogger) Debug(args ...interface{}) { logging.Debug(fmt.Sprint(args...)) }
+func (asynqLogger) Info(args ...interface{})  { logging.Info(fmt.Sprint(args...)) }
+func (asynqLogger) Warn(args ...interface{})  { logging.Warn(fmt.Sprint(args...)) }
+func (asynqLogger) Error(args ...interface{}) { logging.Error(fmt.Sprint(args...)) }
+
+func (asynqLogger) Fatal(args ...interface{}) {
+	logging.Error(fmt.Sprint(args...))
+	os.Exit(1)
+}
+
+// records how long each task took and whether it failed
+func observeTask(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		start := time.Now()
+		err := next.ProcessTask(ctx, t)
+		result := "success"
+		if err != nil {
+			result = "failure"
+			logging.FromContext(ctx).Warn("Task failed", "error", err)
+		}
+		metrics.TaskDuration.Observe(time.Since(start).Seconds(), t.Type(), result)
+		return err
+	})
+}
+
+// scheduled tasks are unique for ttl and get their type's retry count
+func (w *Worker) periodicOptions(taskType string, ttl time.Duration) []asynq.Option {
+	return append(w.retry.options(taskType), asynq.Unique(ttl))
 }
 
 func (w *Worker) Close() {
+	if w.scheduler != nil {
+		w.scheduler.Shutdown()
+	}
 	if w.server != nil {
 		w.server.Shutdown()
 	}
 }
 
 func (w *Worker) HandleEmailDelivery(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
 	var p EmailDeliveryPayload
 	if err := json.Unmarshal(t.Payload(), &p); err != nil {
 		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
 	}
 
-	logging.Info("Sending email", "to", p.To, "subject", p.Subject)
-	if err := w.emailService.SendEmail(ctx, p.To, p.Subject, p.Body); err != nil {
+	logger.Info("Sending email", "to", p.To, "subject", p.Subject)
+	if err := w.sendEmail(ctx, p); err != nil {
+		if errors.Is(err, ErrSuppressed) {
+			logger.Info("Email not sent, address is suppressed", "to", p.To, "subject", p.Subject)
+			metrics.EmailSends.Inc("suppressed")
+			return nil
+		}
+		metrics.EmailSends.Inc("failure")
+		return err
+	}
+	metrics.EmailSends.Inc("success")
+
+	return nil
+}
+
+func (w *Worker) sendEmail(ctx context.Context, p EmailDeliveryPayload) error {
+	logger := logging.FromContext(ctx)
+	if len(p.Attachments) > 0 {
+		if sender, ok := w.emailService.(AttachmentSender); ok {
+			if err := sender.SendEmailWithAttachments(ctx, p.To, p.Subject, p.Body, p.HTMLBody, p.Attachments); err != nil {
+				return fmt.Errorf("emailService.SendEmailWithAttachments failed: %w", err)
+			}
+			return nil
+		}
+		logger.Warn("Email sender cannot attach files, sending without them", "to", p.To, "attachments", len(p.Attachments))
+	}
+	if err := w.emailService.SendEmail(ctx, p.To, p.Subject, p.Body, p.HTMLBody); err != nil {
 		return fmt.Errorf("emailService.SendEmail failed: %w", err)
 	}
+	return nil
+}
+
+func (w *Worker) HandleSMSDelivery(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	var p SMSDeliveryPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	logger.Info("Sending SMS", "to", p.To)
+	if err := w.sms.SendSMS(ctx, p.To, p.Body); err != nil {
+		if errors.Is(err, ErrUndeliverable) {
+			return fmt.Errorf("sms.SendSMS failed: %v: %w", err, asynq.SkipRetry)
+		}
+		return fmt.Errorf("sms.SendSMS failed: %w", err)
+	}
+
+	return nil
+}
+
+func (w *Worker) HandlePushDelivery(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	var p PushDeliveryPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	logger.Info("Sending push notification", "title", p.Title)
+	if err := w.push.SendPush(ctx, p); err != nil {
+		if errors.Is(err, ErrUndeliverable) {
+			return fmt.Errorf("push.SendPush failed: %v: %w", err, asynq.SkipRetry)
+		}
+		return fmt.Errorf("push.SendPush failed: %w", err)
+	}
+
+	return nil
+}
+
+func (w *Worker) HandleChatDelivery(ctx context.Context, t *asynq.Task) error {
+	var p ChatDeliveryPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	if err := w.chat.SendChat(ctx, p.Text); err != nil {
+		if errors.Is(err, ErrUndeliverable) {
+			return fmt.Errorf("chat.SendChat failed: %v: %w", err, asynq.SkipRetry)
+		}
+		return fmt.Errorf("chat.SendChat failed: %w", err)
+	}
+
+	return nil
+}
+
+// sweeps the recycle bin; the payload is ignored so any number of scheduled
+// purge tasks collapse into the same idempotent sweep.
+func (w *Worker) HandleDeletionPurge(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	purged, err := w.purger.PurgeExpired(ctx)
+	if err != nil {
+		return fmt.Errorf("purger.PurgeExpired failed: %w", err)
+	}
+
+	logger.Info("Recycle bin purge complete", "purged", purged)
+	return nil
+}
+
+func (w *Worker) HandleCalendarSync(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	var p CalendarSyncPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	if p.UserID != nil {
+		removed, err := w.calendar.SyncUser(ctx, *p.UserID)
+		if err != nil {
+			// the failure is recorded on the link; the next scheduled sync retries it
+			return fmt.Errorf("calendar.SyncUser failed: %v: %w", err, asynq.SkipRetry)
+		}
+		logger.Info("Calendar sync complete", "user_id", *p.UserID, "removed_slots", removed)
+		return nil
+	}
+
+	removed, err := w.calendar.SyncAll(ctx)
+	if err != nil {
+		return fmt.Errorf("calendar.SyncAll failed: %w", err)
+	}
+
+	logger.Info("Calendar sync complete", "removed_slots", removed)
+	return nil
+}
+
+func (w *Worker) HandleReportGenerate(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	var p ReportGeneratePayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	logger.Info("Generating report", "report_id", p.ReportID)
+	if err := w.reports.Generate(ctx, p.ReportID); err != nil {
+		// the failure is recorded on the report; the requester can ask again
+		return fmt.Errorf("reports.Generate failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	return nil
+}
+
+func (w *Worker) HandleCampaignRun(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	var p CampaignRunPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	if p.CampaignID != nil {
+		sent, err := w.campaigns.Run(ctx, *p.CampaignID)
+		if err != nil {
+			return fmt.Errorf("campaigns.Run failed: %w", err)
+		}
+		logger.Info("Return campaign run complete", "campaign_id", *p.CampaignID, "notices_sent", sent)
+		return nil
+	}
+
+	sent, err := w.campaigns.RunAll(ctx)
+	if err != nil {
+		return fmt.Errorf("campaigns.RunAll failed: %w", err)
+	}
+
+	logger.Info("Return campaigns run complete", "notices_sent", sent)
+	return nil
+}
+
+// the payload is ignored; every check looks at all groups with an SLA.
+func (w *Worker) HandleSLACheck(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	alerted, err := w.slas.CheckBreaches(ctx)
+	if err != nil {
+		return fmt.Errorf("slas.CheckBreaches failed: %w", err)
+	}
+
+	escalated, err := w.slas.Escalate(ctx)
+	if err != nil {
+		return fmt.Errorf("slas.Escalate failed: %w", err)
+	}
+
+	logger.Info("Request SLA check complete", "alerted", alerted, "escalated", escalated)
+	return nil
+}
+
+func (w *Worker) HandleWaitlistNotify(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	var p WaitlistNotifyPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	notified, err := w.waitlist.NotifyNext(ctx, p.ItemID)
+	if err != nil {
+		return fmt.Errorf("waitlist.NotifyNext failed: %w", err)
+	}
+
+	logger.Info("Waitlist notification complete", "item_id", p.ItemID, "notified", notified)
+	return nil
+}
+
+// the payload is ignored; every check looks at all unreturned borrowings.
+func (w *Worker) HandleOverdueCheck(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	sent, err := w.overdue.CheckOverdue(ctx)
+	if err != nil {
+		return fmt.Errorf("overdue.CheckOverdue failed: %w", err)
+	}
+
+	logger.Info("Overdue borrowing check complete", "reminders_sent", sent)
+	return nil
+}
+
+// the payload is ignored; every run looks at all unconfirmed bookings.
+func (w *Worker) HandleBookingExpire(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	expired, err := w.housekeeper.ExpireBookings(ctx)
+	if err != nil {
+		return fmt.Errorf("housekeeper.ExpireBookings failed: %w", err)
+	}
+
+	logger.Info("Booking expiry complete", "expired", expired)
+	return nil
+}
+
+func (w *Worker) HandleAvailabilityCleanup(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	deleted, err := w.housekeeper.CleanupAvailability(ctx)
+	if err != nil {
+		return fmt.Errorf("housekeeper.CleanupAvailability failed: %w", err)
+	}
+
+	logger.Info("Availability cleanup complete", "deleted", deleted)
+	return nil
+}
+
+func (w *Worker) HandleInventoryDigest(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	sent, err := w.digest.SendInventoryDigest(ctx)
+	if err != nil {
+		// a digest sent twice is worse than one skipped week
+		return fmt.Errorf("digest.SendInventoryDigest failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	logger.Info("Inventory digest sent", "recipients", sent)
+	return nil
+}
+
+func (w *Worker) HandleApproverDigest(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	sent, err := w.digest.SendApproverDigest(ctx)
+	if err != nil {
+		// as with the inventory digest, better a day skipped than some sent twice
+		return fmt.Errorf("digest.SendApproverDigest failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	logger.Info("Approver digests sent", "recipients", sent)
+	return nil
+}
+
+func (w *Worker) HandleOrphanSweep(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	swept, err := w.orphans.Sweep(ctx)
+	if err != nil {
+		return fmt.Errorf("orphans.Sweep failed: %w", err)
+	}
+
+	logger.Info("Orphaned object sweep complete", "swept", swept)
+	return nil
+}
+
+func (w *Worker) HandlePhotoProcess(ctx context.Context, t *asynq.Task) error {
+	logger := logging.FromContext(ctx)
+	var p PhotoProcessPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	if err := w.photos.Process(ctx, p.ImageID); err != nil {
+		return fmt.Errorf("photos.Process failed: %w", err)
+	}
+
+	logger.Info("Condition photo processed", "image_id", p.ImageID)
+	return nil
+}
+
+func (w *Worker) HandleWebhookDelivery(ctx context.Context, t *asynq.Task) error {
+	var p WebhookDeliveryPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	if err := w.webhooks.Deliver(ctx, p.DeliveryID, retried >= maxRetry); err != nil {
+		if errors.Is(err, ErrUndeliverable) {
+			return fmt.Errorf("webhooks.Deliver failed: %v: %w", err, asynq.SkipRetry)
+		}
+		return fmt.Errorf("webhooks.Deliver failed: %w", err)
+	}
 
 	return nil
 }
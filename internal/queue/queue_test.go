@@ -0,0 +1,286 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/redis"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 30 * time.Second, MaxDelay: 10 * time.Minute}
+	task := asynq.NewTask(TypeEmailDelivery, nil)
+	err := errors.New("throttled")
+
+	tests := []struct {
+		retried int
+		want    time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, time.Minute},
+		{3, 4 * time.Minute},
+		{5, 10 * time.Minute},
+		{40, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		got := policy.delay(tt.retried, err, task)
+		assert.GreaterOrEqual(t, got, tt.want, "retry %d", tt.retried)
+		assert.LessOrEqual(t, got, tt.want+tt.want/10, "retry %d", tt.retried)
+	}
+}
+
+func TestRetryPolicy_Options(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: map[string]int{TypeEmailDelivery: 12, TypeReportGenerate: 0}, MaxRetry: 5}
+
+	assert.Equal(t, []asynq.Option{asynq.MaxRetry(12)}, policy.options(TypeEmailDelivery))
+	assert.Equal(t, []asynq.Option{asynq.MaxRetry(0)}, policy.options(TypeReportGenerate), "an explicit 0 disables retries")
+	assert.Equal(t, []asynq.Option{asynq.MaxRetry(5)}, policy.options(TypeSLACheck))
+	assert.Empty(t, RetryPolicy{}.options(TypeSLACheck), "no policy keeps asynq's default")
+}
+
+func TestSchedule_Enabled(t *testing.T) {
+	schedule := Schedule{Tasks: []PeriodicTask{
+		{TypeCalendarSync, Every(0)},
+		{TypeSLACheck, Every(15 * time.Minute)},
+		{TypeInventoryDigest, "off"},
+		{TypeBookingExpire, "*/15 * * * *"},
+	}}
+
+	assert.Equal(t, []PeriodicTask{
+		{TypeSLACheck, "@every 15m0s"},
+		{TypeBookingExpire, "*/15 * * * *"},
+	}, schedule.enabled())
+}
+
+func TestTaskLogger(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	// the request ID of the request enqueueing the task rides in its payload
+	var ctx context.Context
+	middleware.RequestContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	requestID := middleware.GetRequestID(ctx)
+
+	itemID := uuid.New()
+	payload, err := marshalPayload(ctx, WaitlistNotifyPayload{ItemID: itemID})
+	require.NoError(t, err)
+	var p WaitlistNotifyPayload
+	require.NoError(t, json.Unmarshal(payload, &p))
+	assert.Equal(t, itemID, p.ItemID, "handlers still decode the payload")
+
+	var logged context.Context
+	handler := scopeTask(asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+		logged = ctx
+		return nil
+	}))
+	require.NoError(t, handler.ProcessTask(context.Background(), asynq.NewTask(TypeWaitlistNotify, payload)))
+	logging.FromContext(logged).Info("done")
+	assert.Contains(t, buf.String(), "task_type="+TypeWaitlistNotify)
+	assert.Contains(t, buf.String(), "request_id="+requestID)
+
+	plain, err := marshalPayload(context.Background(), WaitlistNotifyPayload{ItemID: itemID})
+	require.NoError(t, err)
+	assert.NotContains(t, string(plain), payloadRequestID, "nothing added outside a request")
+}
+
+func TestUniqueFor(t *testing.T) {
+	assert.Equal(t, 15*time.Minute, uniqueFor("@every 15m"))
+	assert.Equal(t, time.Hour, uniqueFor("0 8 * * 1"))
+	assert.Equal(t, time.Hour, uniqueFor("@every nonsense"))
+}
+
+type fakeSMSSender struct{ err error }
+
+func (f fakeSMSSender) SendSMS(ctx context.Context, to, body string) error { return f.err }
+
+func TestWorker_HandleSMSDelivery(t *testing.T) {
+	task := asynq.NewTask(TypeSMSDelivery, []byte(`{"To": "+15551234567", "Body": "Pickup in 1 hour"}`))
+
+	w := &Worker{sms: fakeSMSSender{}}
+	assert.NoError(t, w.HandleSMSDelivery(context.Background(), task))
+
+	w = &Worker{sms: fakeSMSSender{err: fmt.Errorf("rejected: %w", ErrUndeliverable)}}
+	assert.ErrorIs(t, w.HandleSMSDelivery(context.Background(), task), asynq.SkipRetry)
+
+	w = &Worker{sms: fakeSMSSender{err: errors.New("timeout")}}
+	err := w.HandleSMSDelivery(context.Background(), task)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, asynq.SkipRetry, "transient failures are retried")
+}
+
+type fakeChatSender struct{ err error }
+
+func (f fakeChatSender) SendChat(ctx context.Context, text string) error { return f.err }
+
+func TestWorker_HandleChatDelivery(t *testing.T) {
+	task := asynq.NewTask(TypeChatDelivery, []byte(`{"Text": "Overdue: *Tripod*"}`))
+
+	w := &Worker{chat: fakeChatSender{}}
+	assert.NoError(t, w.HandleChatDelivery(context.Background(), task))
+
+	w = &Worker{chat: fakeChatSender{err: fmt.Errorf("no_service: %w", ErrUndeliverable)}}
+	assert.ErrorIs(t, w.HandleChatDelivery(context.Background(), task), asynq.SkipRetry)
+
+	w = &Worker{chat: fakeChatSender{err: errors.New("rate limited")}}
+	err := w.HandleChatDelivery(context.Background(), task)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, asynq.SkipRetry)
+}
+
+type fakeEmailSender struct{ plain, attached int }
+
+func (f *fakeEmailSender) SendEmail(ctx context.Context, to, subject, textBody, htmlBody string) error {
+	f.plain++
+	return nil
+}
+
+type fakeAttachmentSender struct {
+	fakeEmailSender
+	attachments []EmailAttachment
+}
+
+func (f *fakeAttachmentSender) SendEmailWithAttachments(ctx context.Context, to, subject, textBody, htmlBody string, attachments []EmailAttachment) error {
+	f.attached++
+	f.attachments = attachments
+	return nil
+}
+
+func TestWorker_HandleEmailDelivery_Attachments(t *testing.T) {
+	task := asynq.NewTask(TypeEmailDelivery, []byte(`{"To": "a@example.com", "Subject": "Booking confirmed", "Body": "See you",
+		"Attachments": [{"Filename": "booking.ics", "ContentType": "text/calendar", "Data": "QkVHSU46VkNBTEVOREFS"}]}`))
+
+	sender := &fakeAttachmentSender{}
+	w := &Worker{emailService: sender}
+	assert.NoError(t, w.HandleEmailDelivery(context.Background(), task))
+	assert.Equal(t, 1, sender.attached)
+	assert.Equal(t, 0, sender.plain)
+	if assert.Len(t, sender.attachments, 1) {
+		assert.Equal(t, "BEGIN:VCALENDAR", string(sender.attachments[0].Data))
+	}
+
+	plain := &fakeEmailSender{}
+	w = &Worker{emailService: plain}
+	assert.NoError(t, w.HandleEmailDelivery(context.Background(), task))
+	assert.Equal(t, 1, plain.plain, "senders without attachment support still deliver the email")
+}
+
+type fakeWebhookDeliverer struct {
+	err   error
+	final []bool
+}
+
+func (f *fakeWebhookDeliverer) Deliver(ctx context.Context, deliveryID uuid.UUID, final bool) error {
+	f.final = append(f.final, final)
+	return f.err
+}
+
+func TestWorker_HandleWebhookDelivery(t *testing.T) {
+	task := asynq.NewTask(TypeWebhookDelivery, []byte(`{"DeliveryID": "6f1c2f0e-3c5b-4a8e-9d57-0b8f1f0d2a11"}`))
+
+	deliverer := &fakeWebhookDeliverer{}
+	w := &Worker{webhooks: deliverer}
+	assert.NoError(t, w.HandleWebhookDelivery(context.Background(), task))
+	// outside a worker there's no retry count, so the first run is the last
+	assert.Equal(t, []bool{true}, deliverer.final)
+
+	w = &Worker{webhooks: &fakeWebhookDeliverer{err: fmt.Errorf("deleted: %w", ErrUndeliverable)}}
+	assert.ErrorIs(t, w.HandleWebhookDelivery(context.Background(), task), asynq.SkipRetry)
+
+	w = &Worker{webhooks: &fakeWebhookDeliverer{err: errors.New("webhook responded 502 Bad Gateway")}}
+	err := w.HandleWebhookDelivery(context.Background(), task)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, asynq.SkipRetry, "failed deliveries are retried")
+}
+
+// a log destination the test can read while the worker is still writing
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+type fakeWaitlistNotifier struct{}
+
+func (fakeWaitlistNotifier) NotifyNext(ctx context.Context, itemID uuid.UUID) (int, error) {
+	return 1, nil
+}
+
+func TestWorker_TaskLogsCarryTaskID(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	var logs logBuffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	ctx := context.Background()
+	redisContainer, err := redis.Run(ctx,
+		"redis:7-alpine",
+		testcontainers.WithReuseByName("cv-backend-test-redis-queue"),
+		testcontainers.WithWaitStrategy(wait.ForListeningPort("6379/tcp").WithStartupTimeout(30*time.Second)),
+	)
+	require.NoError(t, err)
+	endpoint, err := redisContainer.Endpoint(ctx, "")
+	require.NoError(t, err)
+	cfg := config.RedisConfig{Addr: endpoint}
+
+	worker := NewWorker(&cfg, nil, nil, nil, nil, nil, nil, nil, nil, nil, fakeWaitlistNotifier{}, nil, nil, nil, nil, nil, nil, Schedule{}, RetryPolicy{})
+	require.NoError(t, worker.Start())
+	t.Cleanup(worker.Close)
+
+	q, err := NewQueue(&cfg, RetryPolicy{})
+	require.NoError(t, err)
+	t.Cleanup(func() { q.Close() })
+	info, err := q.Enqueue(ctx, TypeWaitlistNotify, WaitlistNotifyPayload{ItemID: uuid.New()})
+	require.NoError(t, err)
+
+	// the line the handler logs once the waitlist has been notified
+	var line string
+	require.Eventually(t, func() bool {
+		for _, l := range strings.Split(logs.String(), "\n") {
+			if strings.Contains(l, "Waitlist notification complete") {
+				line = l
+				return true
+			}
+		}
+		return false
+	}, 10*time.Second, 50*time.Millisecond)
+	assert.Contains(t, line, "task_id="+info.ID)
+	assert.Contains(t, line, "task_type="+TypeWaitlistNotify)
+}
@@ -8,20 +8,48 @@ const (
 	ViewOwnData   = "view_own_data"   // View user's own data
 	ViewGroupData = "view_group_data" // View group-scoped data
 	ViewItems     = "view_items"      // View item catalog
+	ViewAuditLog  = "view_audit_log"  // View the audit log of changes made through the API
 
-	ManageCart          = "manage_cart"           // Manage shopping cart
-	ManageItems         = "manage_items"          // CRUD operations on items
-	ManageGroups        = "manage_groups"         // CRUD operations on groups
-	ManageUsers         = "manage_users"          // CRUD operations on users
-	ManageGroupUsers    = "manage_group_users"    // Manage users within group
-	ManageTimeSlots     = "manage_time_slots"     // Manage availability/time slots
-	ManageAllBookings   = "manage_all_bookings"   // Manage all bookings system-wide
-	ManageGroupBookings = "manage_group_bookings" // Manage group-scoped bookings
+	ManageCart              = "manage_cart"               // Manage shopping cart
+	ManageItems             = "manage_items"              // CRUD operations on items
+	ManageGroups            = "manage_groups"             // CRUD operations on groups
+	ManageUsers             = "manage_users"              // CRUD operations on users
+	ManageGroupUsers        = "manage_group_users"        // Manage users within group
+	ManageTimeSlots         = "manage_time_slots"         // Manage availability/time slots
+	ManageAllBookings       = "manage_all_bookings"       // Manage all bookings system-wide
+	ManageGroupBookings     = "manage_group_bookings"     // Manage group-scoped bookings
+	ManageWorkers           = "manage_workers"            // Pause, drain, and cancel background task queues
+	ManageNotifications     = "manage_notifications"      // Configure notification routing rules
+	ManageAPIKeys           = "manage_api_keys"           // Create and revoke API keys for service accounts
+	ManageWebhooks          = "manage_webhooks"           // Register webhooks and view their deliveries
+	ManageBorrowingPolicies = "manage_borrowing_policies" // Configure borrowing limits and blackout periods
 
-	RequestItems       = "request_items"        // Request/borrow items
-	ApproveAllRequests = "approve_all_requests" // Approve high-value item requests
+	RequestItems         = "request_items"          // Request/borrow items
+	ApproveAllRequests   = "approve_all_requests"   // Approve high-value item requests
+	ApproveGroupRequests = "approve_group_requests" // Approve requests for items the group owns
 )
 
+// permissions that only make sense system-wide. A check made without a group
+// also counts roles held within a group, so a role holding any of these is
+// only ever granted globally.
+var globalOnly = map[string]bool{
+	ManageUsers:        true,
+	ManageItems:        true,
+	ManageGroups:       true,
+	ApproveAllRequests: true,
+}
+
+// the permissions among names that a group-scoped role may not hold
+func GlobalOnly(names []string) []string {
+	var found []string
+	for _, name := range names {
+		if globalOnly[name] {
+			found = append(found, name)
+		}
+	}
+	return found
+}
+
 // Checkout statuses
 const (
 	CheckoutStatusCompleted       = "completed"        // LOW item successfully taken
@@ -31,8 +59,9 @@ const (
 
 // Role names
 const (
-	RoleGlobalAdmin = "global_admin" // System administrator
-	RoleApprover    = "approver"     // approves requests
-	RoleGroupAdmin  = "group_admin"  // Group-level administrator
-	RoleMember      = "member"       // Regular user
+	RoleGlobalAdmin   = "global_admin"   // System administrator
+	RoleApprover      = "approver"       // approves requests
+	RoleGroupApprover = "group_approver" // approves requests for items the group owns
+	RoleGroupAdmin    = "group_admin"    // Group-level administrator
+	RoleMember        = "member"         // Regular user
 )
@@ -0,0 +1,136 @@
+package recyclebin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// how long an approved deletion stays restorable before the purge job removes it.
+const Retention = 30 * 24 * time.Hour
+
+// subset of storage.Storage needed to clean up group logos.
+type objectDeleter interface {
+	DeleteObject(ctx context.Context, key string) error
+}
+
+type Purger struct {
+	pool    *pgxpool.Pool
+	db      *db.Queries
+	objects objectDeleter
+}
+
+func NewPurger(pool *pgxpool.Pool, queries *db.Queries, objects objectDeleter) *Purger {
+	return &Purger{
+		pool:    pool,
+		db:      queries,
+		objects: objects,
+	}
+}
+
+// permanently deletes (for items, archives) every binned entity whose
+// retention window has passed.
+// A failing entity is logged and skipped so one bad row cannot stall the sweep;
+// the failures are joined into the returned error. Returns the number purged.
+func (p *Purger) PurgeExpired(ctx context.Context) (int, error) {
+	expired, err := p.db.ListExpiredDeletionRequests(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired deletion requests: %w", err)
+	}
+
+	purged := 0
+	var errs []error
+	for _, req := range expired {
+		if err := p.purge(ctx, req.ID); err != nil {
+			logging.FromContext(ctx).Error("failed to purge deleted entity",
+				"deletion_id", req.ID,
+				"entity_type", req.EntityType,
+				"entity_id", req.EntityID,
+				"error", err)
+			errs = append(errs, err)
+			continue
+		}
+		purged++
+	}
+
+	if len(errs) > 0 {
+		return purged, fmt.Errorf("failed to purge %d of %d expired entities: %w", len(errs), len(expired), errors.Join(errs...))
+	}
+	return purged, nil
+}
+
+func (p *Purger) purge(ctx context.Context, id uuid.UUID) error {
+	logger := logging.FromContext(ctx)
+
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := p.db.WithTx(tx)
+
+	// re-check under lock: the request may have been restored since it was listed
+	req, err := qtx.GetDeletionRequestForUpdate(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to lock deletion request %s: %w", id, err)
+	}
+	if req.Status != db.DeletionStatusBinned {
+		return nil
+	}
+
+	var objectKeys []string
+	switch req.EntityType {
+	case db.DeletionEntityGroup:
+		group, err := qtx.GetGroupByIDIncludingBinned(ctx, req.EntityID)
+		if err == nil {
+			if group.LogoS3Key.Valid {
+				objectKeys = append(objectKeys, group.LogoS3Key.String)
+			}
+			if group.LogoThumbnailS3Key.Valid {
+				objectKeys = append(objectKeys, group.LogoThumbnailS3Key.String)
+			}
+		}
+		if err := qtx.DeleteGroup(ctx, req.EntityID); err != nil {
+			return fmt.Errorf("failed to delete group %s: %w", req.EntityID, err)
+		}
+	case db.DeletionEntityItem:
+		// items are archived, not deleted: their bookings, borrowings and
+		// takings reference them and would cascade away with the row
+		if err := qtx.ArchiveItem(ctx, req.EntityID); err != nil {
+			return fmt.Errorf("failed to archive item %s: %w", req.EntityID, err)
+		}
+	default:
+		return fmt.Errorf("unsupported deletion entity type: %s", req.EntityType)
+	}
+
+	if _, err := qtx.ResolveDeletionRequest(ctx, db.ResolveDeletionRequestParams{
+		ID:     req.ID,
+		Status: db.DeletionStatusPurged,
+	}); err != nil {
+		return fmt.Errorf("failed to mark deletion request %s purged: %w", req.ID, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit purge: %w", err)
+	}
+
+	for _, key := range objectKeys {
+		if err := p.objects.DeleteObject(ctx, key); err != nil {
+			logger.Warn("failed to delete S3 object", "key", key, "error", err)
+		}
+	}
+
+	logger.Info("purged deleted entity",
+		"deletion_id", req.ID,
+		"entity_type", req.EntityType,
+		"entity_id", req.EntityID,
+		"entity_name", req.EntityName)
+	return nil
+}
@@ -0,0 +1,125 @@
+package recyclebin_test
+
+import (
+	"context"
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/recyclebin"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sharedDB *testutil.TestDatabase
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		os.Exit(0)
+	}
+
+	t := &testing.T{}
+	sharedDB = testutil.NewTestDatabase(t, "cv-backend-test-db-recyclebin")
+	sharedDB.RunMigrations(t)
+
+	code := m.Run()
+
+	if sharedDB.Pool() != nil {
+		sharedDB.Pool().Close()
+	}
+
+	os.Exit(code)
+}
+
+// records deleted keys instead of talking to S3.
+type fakeObjects struct {
+	deleted []string
+}
+
+func (f *fakeObjects) DeleteObject(ctx context.Context, key string) error {
+	f.deleted = append(f.deleted, key)
+	return nil
+}
+
+func TestPurger_PurgeExpired(t *testing.T) {
+	sharedDB.CleanupDatabase(t)
+	ctx := context.Background()
+
+	requester := sharedDB.NewUser(t).WithEmail("requester@purge.test").AsGlobalAdmin().Create()
+	approver := sharedDB.NewUser(t).WithEmail("approver@purge.test").AsGlobalAdmin().Create()
+	expiredGroup := sharedDB.NewGroup(t).WithName("Expired").Create()
+	freshGroup := sharedDB.NewGroup(t).WithName("Fresh").Create()
+
+	binGroup := func(groupID uuid.UUID, purgeAfter time.Time) {
+		deletion, err := sharedDB.Queries().CreateDeletionRequest(ctx, db.CreateDeletionRequestParams{
+			EntityType:  db.DeletionEntityGroup,
+			EntityID:    groupID,
+			EntityName:  "group",
+			RequestedBy: &requester.ID,
+		})
+		require.NoError(t, err)
+		_, err = sharedDB.Queries().ApproveDeletionRequest(ctx, db.ApproveDeletionRequestParams{
+			ID:         deletion.ID,
+			ApprovedBy: &approver.ID,
+			PurgeAfter: pgtype.Timestamp{Time: purgeAfter, Valid: true},
+		})
+		require.NoError(t, err)
+	}
+	binGroup(expiredGroup.ID, time.Now().Add(-time.Hour))
+	binGroup(freshGroup.ID, time.Now().Add(recyclebin.Retention))
+
+	purger := recyclebin.NewPurger(sharedDB.Pool(), sharedDB.Queries(), &fakeObjects{})
+	purged, err := purger.PurgeExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = sharedDB.Queries().GetGroupByIDIncludingBinned(ctx, expiredGroup.ID)
+	assert.Error(t, err, "expired group should be purged")
+
+	_, err = sharedDB.Queries().GetGroupByIDIncludingBinned(ctx, freshGroup.ID)
+	assert.NoError(t, err, "group inside retention window should be kept")
+
+	_, err = sharedDB.Queries().GetGroupByID(ctx, freshGroup.ID)
+	assert.Error(t, err, "binned group should be hidden from by-ID lookups")
+}
+
+func TestPurger_ArchivesItems(t *testing.T) {
+	sharedDB.CleanupDatabase(t)
+	ctx := context.Background()
+
+	requester := sharedDB.NewUser(t).WithEmail("requester@purge.test").AsGlobalAdmin().Create()
+	approver := sharedDB.NewUser(t).WithEmail("approver@purge.test").AsGlobalAdmin().Create()
+	item := sharedDB.NewItem(t).WithName("Old Projector").Create()
+
+	deletion, err := sharedDB.Queries().CreateDeletionRequest(ctx, db.CreateDeletionRequestParams{
+		EntityType:  db.DeletionEntityItem,
+		EntityID:    item.ID,
+		EntityName:  item.Name,
+		RequestedBy: &requester.ID,
+	})
+	require.NoError(t, err)
+	_, err = sharedDB.Queries().ApproveDeletionRequest(ctx, db.ApproveDeletionRequestParams{
+		ID:         deletion.ID,
+		ApprovedBy: &approver.ID,
+		PurgeAfter: pgtype.Timestamp{Time: time.Now().Add(-time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+
+	purger := recyclebin.NewPurger(sharedDB.Pool(), sharedDB.Queries(), &fakeObjects{})
+	purged, err := purger.PurgeExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	archived, err := sharedDB.Queries().GetItemByIDIncludingBinned(ctx, item.ID)
+	require.NoError(t, err, "purged item should be kept for its history")
+	assert.True(t, archived.ArchivedAt.Valid)
+
+	_, err = sharedDB.Queries().GetItemByID(ctx, item.ID)
+	assert.Error(t, err, "archived item should be hidden from by-ID lookups")
+}
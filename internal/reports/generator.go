@@ -0,0 +1,223 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// how long a generated report can be downloaded. S3 caps presigned URLs at
+// seven days, so this is also the lifetime of the emailed link.
+const LinkExpiry = 7 * 24 * time.Hour
+
+// subset of storage.Storage needed to store and share reports.
+type objectStore interface {
+	PutObject(ctx context.Context, key string, body io.Reader, contentType string) error
+	GeneratePresignedURL(ctx context.Context, method string, key string, duration time.Duration) (string, error)
+}
+
+type notifier interface {
+	Notify(ctx context.Context, actorID uuid.UUID, entityType string, entityID uuid.UUID, groups []notifications.NotifierGroup) error
+}
+
+type Generator struct {
+	db       *db.Queries
+	objects  objectStore
+	notifier notifier
+}
+
+func NewGenerator(queries *db.Queries, objects objectStore, notifier notifier) *Generator {
+	return &Generator{
+		db:       queries,
+		objects:  objects,
+		notifier: notifier,
+	}
+}
+
+// every report's CSV is stored under this S3 key prefix.
+const ObjectPrefix = "reports/"
+
+// S3 key a report's CSV is stored under.
+func ObjectKey(reportID uuid.UUID) string {
+	return fmt.Sprintf("%s%s.csv", ObjectPrefix, reportID)
+}
+
+// builds the CSV for a pending report, uploads it and notifies the requester
+// with a download link. Failures are recorded on the report before returning.
+func (g *Generator) Generate(ctx context.Context, reportID uuid.UUID) error {
+	report, err := g.db.GetReportByID(ctx, reportID)
+	if err != nil {
+		return fmt.Errorf("failed to get report %s: %w", reportID, err)
+	}
+	if report.Status != db.ReportStatusPending {
+		return nil
+	}
+
+	if err := g.generate(ctx, report); err != nil {
+		if markErr := g.db.MarkReportFailed(ctx, db.MarkReportFailedParams{
+			ID:    report.ID,
+			Error: pgtype.Text{String: err.Error(), Valid: true},
+		}); markErr != nil {
+			logging.FromContext(ctx).Error("failed to mark report failed", "report_id", report.ID, "error", markErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (g *Generator) generate(ctx context.Context, report db.Report) error {
+	logger := logging.FromContext(ctx)
+
+	records, err := g.records(ctx, report)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(records); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	key := ObjectKey(report.ID)
+	if err := g.objects.PutObject(ctx, key, &buf, "text/csv"); err != nil {
+		return fmt.Errorf("failed to upload report: %w", err)
+	}
+
+	url, err := g.objects.GeneratePresignedURL(ctx, http.MethodGet, key, LinkExpiry)
+	if err != nil {
+		return fmt.Errorf("failed to presign report: %w", err)
+	}
+
+	rowCount := len(records) - 1
+	expiresAt := time.Now().Add(LinkExpiry)
+	if _, err := g.db.MarkReportReady(ctx, db.MarkReportReadyParams{
+		ID:        report.ID,
+		S3Key:     pgtype.Text{String: key, Valid: true},
+		RowCount:  pgtype.Int4{Int32: int32(rowCount), Valid: true},
+		ExpiresAt: pgtype.Timestamp{Time: expiresAt, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("failed to mark report ready: %w", err)
+	}
+
+	if err := g.notifier.Notify(ctx, report.RequestedBy, "report", report.ID, []notifications.NotifierGroup{
+		{
+			IDs:      []uuid.UUID{report.RequestedBy},
+			Template: "report_ready",
+			TemplateData: map[string]interface{}{
+				"ReportType":  string(report.ReportType),
+				"RowCount":    rowCount,
+				"DownloadURL": url,
+				"ExpiresAt":   expiresAt.Format("2006-01-02 15:04"),
+			},
+			Facts: notifications.RoutingFacts{GroupID: report.GroupID},
+		},
+	}); err != nil {
+		logger.Error("failed to notify report requester", "report_id", report.ID, "error", err)
+	}
+
+	logger.Info("report generated", "report_id", report.ID, "type", report.ReportType, "rows", rowCount)
+	return nil
+}
+
+// header row followed by one record per exported row.
+func (g *Generator) records(ctx context.Context, report db.Report) ([][]string, error) {
+	switch report.ReportType {
+	case db.ReportTypeBorrowings:
+		rows, err := g.db.ExportBorrowings(ctx, db.ExportBorrowingsParams{
+			GroupID:  report.GroupID,
+			FromDate: report.FromDate,
+			ToDate:   report.ToDate,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to export borrowings: %w", err)
+		}
+		records := [][]string{{"id", "user_email", "group", "item", "quantity", "borrowed_at", "due_date", "returned_at", "before_condition", "after_condition"}}
+		for _, r := range rows {
+			afterCondition := ""
+			if r.AfterCondition.Valid {
+				afterCondition = string(r.AfterCondition.Condition)
+			}
+			records = append(records, []string{
+				r.ID.String(), r.UserEmail.String, r.GroupName.String, r.ItemName.String,
+				fmt.Sprint(r.Quantity), formatTimestamp(r.BorrowedAt), formatTimestamp(r.DueDate),
+				formatTimestamp(r.ReturnedAt), string(r.BeforeCondition), afterCondition,
+			})
+		}
+		return records, nil
+
+	case db.ReportTypeBookings:
+		rows, err := g.db.ExportBookings(ctx, db.ExportBookingsParams{
+			GroupID:  report.GroupID,
+			FromDate: report.FromDate,
+			ToDate:   report.ToDate,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to export bookings: %w", err)
+		}
+		records := [][]string{{"id", "requester_email", "manager_email", "group", "item", "status", "pick_up_date", "pick_up_location", "return_date", "return_location", "created_at"}}
+		for _, r := range rows {
+			records = append(records, []string{
+				r.ID.String(), r.RequesterEmail.String, r.ManagerEmail.String, r.GroupName.String,
+				r.ItemName.String, string(r.Status), formatTimestamp(r.PickUpDate), r.PickUpLocation,
+				formatTimestamp(r.ReturnDate), r.ReturnLocation, formatTimestamp(r.CreatedAt),
+			})
+		}
+		return records, nil
+
+	case db.ReportTypeTakings:
+		rows, err := g.db.ExportTakings(ctx, db.ExportTakingsParams{
+			GroupID:  report.GroupID,
+			FromDate: report.FromDate,
+			ToDate:   report.ToDate,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to export takings: %w", err)
+		}
+		records := [][]string{{"id", "user_email", "group", "item", "quantity", "taken_at"}}
+		for _, r := range rows {
+			records = append(records, []string{
+				r.ID.String(), r.UserEmail, r.GroupName, r.ItemName,
+				fmt.Sprint(r.Quantity), formatTimestamp(r.TakenAt),
+			})
+		}
+		return records, nil
+
+	case db.ReportTypeOutstanding:
+		rows, err := g.db.ExportOutstanding(ctx, db.ExportOutstandingParams{
+			GroupID: report.GroupID,
+			ToDate:  report.ToDate,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to export outstanding borrowings: %w", err)
+		}
+		records := [][]string{{"id", "user_email", "group", "item", "quantity", "borrowed_at", "due_date"}}
+		for _, r := range rows {
+			records = append(records, []string{
+				r.ID.String(), r.UserEmail.String, r.GroupName.String, r.ItemName.String,
+				fmt.Sprint(r.Quantity), formatTimestamp(r.BorrowedAt), formatTimestamp(r.DueDate),
+			})
+		}
+		return records, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported report type: %s", report.ReportType)
+	}
+}
+
+func formatTimestamp(ts pgtype.Timestamp) string {
+	if !ts.Valid {
+		return ""
+	}
+	return ts.Time.Format(time.RFC3339)
+}
@@ -0,0 +1,108 @@
+package reports_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"flag"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/reports"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sharedDB *testutil.TestDatabase
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		os.Exit(0)
+	}
+
+	t := &testing.T{}
+	sharedDB = testutil.NewTestDatabase(t, "cv-backend-test-db-reports")
+	sharedDB.RunMigrations(t)
+
+	code := m.Run()
+
+	if sharedDB.Pool() != nil {
+		sharedDB.Pool().Close()
+	}
+
+	os.Exit(code)
+}
+
+// keeps uploaded objects in memory instead of S3.
+type fakeStore struct {
+	objects map[string][]byte
+}
+
+func (f *fakeStore) PutObject(ctx context.Context, key string, body io.Reader, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	f.objects[key] = data
+	return nil
+}
+
+func (f *fakeStore) GeneratePresignedURL(ctx context.Context, method string, key string, duration time.Duration) (string, error) {
+	return "https://s3.test/" + key, nil
+}
+
+type fakeNotifier struct {
+	groups []notifications.NotifierGroup
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, actorID uuid.UUID, entityType string, entityID uuid.UUID, groups []notifications.NotifierGroup) error {
+	f.groups = append(f.groups, groups...)
+	return nil
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	sharedDB.CleanupDatabase(t)
+	ctx := context.Background()
+
+	admin := sharedDB.NewUser(t).WithEmail("admin@generate.test").AsGlobalAdmin().Create()
+	report, err := sharedDB.Queries().CreateReport(ctx, db.CreateReportParams{
+		ReportType:  db.ReportTypeBookings,
+		RequestedBy: admin.ID,
+	})
+	require.NoError(t, err)
+
+	store := &fakeStore{objects: map[string][]byte{}}
+	notifier := &fakeNotifier{}
+	generator := reports.NewGenerator(sharedDB.Queries(), store, notifier)
+	require.NoError(t, generator.Generate(ctx, report.ID))
+
+	updated, err := sharedDB.Queries().GetReportByID(ctx, report.ID)
+	require.NoError(t, err)
+	assert.Equal(t, db.ReportStatusReady, updated.Status)
+	assert.Equal(t, int32(0), updated.RowCount.Int32)
+	assert.True(t, updated.ExpiresAt.Valid)
+
+	object, ok := store.objects[reports.ObjectKey(report.ID)]
+	require.True(t, ok, "report CSV should be uploaded")
+	records, err := csv.NewReader(bytes.NewReader(object)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 1, "empty report should contain only the header")
+	assert.Equal(t, "requester_email", records[0][1])
+
+	// the requester gets a ready report with a download link
+	require.Len(t, notifier.groups, 1)
+	assert.Equal(t, "report_ready", notifier.groups[0].Template)
+	assert.Equal(t, []uuid.UUID{admin.ID}, notifier.groups[0].IDs)
+	assert.Equal(t, "https://s3.test/"+reports.ObjectKey(report.ID), notifier.groups[0].TemplateData["DownloadURL"])
+
+	// a report that is no longer pending is left alone
+	require.NoError(t, generator.Generate(ctx, report.ID))
+	assert.Len(t, notifier.groups, 1)
+}
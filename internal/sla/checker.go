@@ -0,0 +1,185 @@
+package sla
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/approvals"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/google/uuid"
+)
+
+// when a request made at requestedAt must be reviewed by under an SLA of
+// reviewHours.
+func Deadline(requestedAt time.Time, reviewHours int) time.Time {
+	return requestedAt.Add(time.Duration(reviewHours) * time.Hour)
+}
+
+// whether a request is past its deadline: reviewed after it, or still
+// waiting at now.
+func Breached(deadline time.Time, reviewedAt *time.Time, now time.Time) bool {
+	if reviewedAt != nil {
+		return reviewedAt.After(deadline)
+	}
+	return now.After(deadline)
+}
+
+type notifier interface {
+	Notify(ctx context.Context, actorID uuid.UUID, entityType string, entityID uuid.UUID, groups []notifications.NotifierGroup) error
+}
+
+// alerts reviewers about pending requests that have outlived their group's
+// review SLA, and escalates requests left with an item's owning group.
+type Checker struct {
+	db       *db.Queries
+	notifier notifier
+	// how long requests from groups without an SLA stay with the owning
+	// group; 0 leaves them there
+	escalateAfter time.Duration
+}
+
+func NewChecker(queries *db.Queries, notifier notifier, escalateAfter time.Duration) *Checker {
+	return &Checker{db: queries, notifier: notifier, escalateAfter: escalateAfter}
+}
+
+// alerts approvers and the group's admins once for every request newly past
+// its SLA and returns how many were alerted. A failed alert is logged and
+// not retried.
+func (c *Checker) CheckBreaches(ctx context.Context) (int, error) {
+	logger := logging.FromContext(ctx)
+
+	breaches, err := c.db.ListUnalertedSLABreaches(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list SLA breaches: %w", err)
+	}
+	if len(breaches) == 0 {
+		return 0, nil
+	}
+
+	approvers, err := c.db.GetRequestApproverIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get approvers: %w", err)
+	}
+
+	alerted := 0
+	for _, b := range breaches {
+		recorded, err := c.db.RecordSLAAlert(ctx, b.ID)
+		if err != nil {
+			return alerted, fmt.Errorf("failed to record SLA alert for request %s: %w", b.ID, err)
+		}
+		if recorded == 0 {
+			continue
+		}
+
+		if err := c.alert(ctx, b, approvers); err != nil {
+			logger.Error("failed to send SLA breach alert", "request_id", b.ID, "error", err)
+			continue
+		}
+		alerted++
+	}
+
+	logger.Info("request SLA check", "breaches", len(breaches), "alerted", alerted)
+	return alerted, nil
+}
+
+func (c *Checker) alert(ctx context.Context, b db.ListUnalertedSLABreachesRow, approvers []*uuid.UUID) error {
+	admins, err := c.db.GetGroupAdminIDs(ctx, b.GroupID)
+	if err != nil {
+		return fmt.Errorf("failed to get group admins: %w", err)
+	}
+
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, list := range [][]*uuid.UUID{approvers, admins} {
+		for _, id := range list {
+			if id != nil && !seen[*id] {
+				seen[*id] = true
+				ids = append(ids, *id)
+			}
+		}
+	}
+
+	var actorID uuid.UUID
+	switch {
+	case b.UserID != nil:
+		actorID = *b.UserID
+	case len(ids) > 0:
+		actorID = ids[0]
+	default:
+		return fmt.Errorf("request has no reviewers to alert")
+	}
+
+	deadline := Deadline(b.RequestedAt.Time, int(b.ReviewHours))
+	return c.notifier.Notify(ctx, actorID, "request", b.ID, []notifications.NotifierGroup{
+		{
+			IDs:      ids,
+			Template: "request_sla_breached",
+			TemplateData: map[string]interface{}{
+				"ItemName":       b.ItemName,
+				"GroupName":      b.GroupName,
+				"RequesterEmail": b.RequesterEmail.String,
+				"Quantity":       b.Quantity,
+				"ReviewHours":    b.ReviewHours,
+				"RequestedAt":    b.RequestedAt.Time.Format("2006-01-02 15:04"),
+				"DueAt":          deadline.Format("2006-01-02 15:04"),
+				"RequestID":      b.ID.String(),
+			},
+			Facts: notifications.RoutingFacts{GroupID: b.GroupID},
+		},
+	})
+}
+
+// routes requests that have waited on their item's owning group past their
+// SLA on to the global approvers, telling those newly routed. Returns how many
+// requests were escalated. A failed notice is logged and not retried.
+func (c *Checker) Escalate(ctx context.Context) (int, error) {
+	logger := logging.FromContext(ctx)
+
+	due, err := c.db.ListRequestsDueForEscalation(ctx, int32(c.escalateAfter.Hours()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list requests due for escalation: %w", err)
+	}
+
+	escalated := 0
+	for _, r := range due {
+		routes, err := approvals.Escalate(ctx, c.db, r.ID)
+		if err != nil {
+			return escalated, fmt.Errorf("failed to escalate request %s: %w", r.ID, err)
+		}
+		if len(routes) == 0 {
+			continue
+		}
+		escalated++
+
+		ids := make([]uuid.UUID, 0, len(routes))
+		for _, route := range routes {
+			ids = append(ids, route.ApproverID)
+		}
+		actorID := ids[0]
+		if r.UserID != nil {
+			actorID = *r.UserID
+		}
+		if err := c.notifier.Notify(ctx, actorID, "request", r.ID, []notifications.NotifierGroup{
+			{
+				IDs:      ids,
+				Template: "request_escalated_approver",
+				TemplateData: map[string]interface{}{
+					"ItemName":       r.ItemName,
+					"RequesterEmail": r.RequesterEmail.String,
+					"Quantity":       r.Quantity,
+					"RequestedAt":    r.RequestedAt.Time.Format("2006-01-02 15:04"),
+					"RequestID":      r.ID.String(),
+				},
+				Facts: notifications.RoutingFacts{GroupID: r.GroupID},
+			},
+		}); err != nil {
+			logger.Error("failed to send escalation notice", "request_id", r.ID, "error", err)
+		}
+	}
+
+	logger.Info("request escalation check", "due", len(due), "escalated", escalated)
+	return escalated, nil
+}
@@ -0,0 +1,38 @@
+package sla_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/sla"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadline(t *testing.T) {
+	requestedAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC), sla.Deadline(requestedAt, 48))
+}
+
+func TestBreached(t *testing.T) {
+	deadline := time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)
+	before := deadline.Add(-time.Hour)
+	after := deadline.Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		reviewedAt *time.Time
+		now        time.Time
+		want       bool
+	}{
+		{"pending before deadline", nil, before, false},
+		{"pending past deadline", nil, after, true},
+		{"reviewed in time", &before, after, false},
+		{"reviewed late", &after, after, true},
+		{"reviewed on the deadline", &deadline, after, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sla.Breached(deadline, tt.reviewedAt, tt.now))
+		})
+	}
+}
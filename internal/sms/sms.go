@@ -0,0 +1,37 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/USSTM/cv-backend/internal/aws"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/queue"
+)
+
+// the SMSSender for cfg.Provider. SNS uses the AWS credentials and region the
+// rest of the app does.
+func New(cfg config.SMSConfig, awsCfg config.AWSConfig) (queue.SMSSender, error) {
+	switch cfg.Provider {
+	case "twilio":
+		if cfg.TwilioAccountSID == "" || cfg.TwilioAuthToken == "" || cfg.From == "" {
+			return nil, fmt.Errorf("twilio needs TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and SMS_FROM")
+		}
+		return NewTwilio(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.From), nil
+	case "sns":
+		return aws.NewSMSService(awsCfg)
+	case "", "log":
+		return logSender{}, nil
+	default:
+		return nil, fmt.Errorf("unknown SMS provider %q", cfg.Provider)
+	}
+}
+
+// for development: texts are logged instead of sent.
+type logSender struct{}
+
+func (logSender) SendSMS(ctx context.Context, to, body string) error {
+	logging.FromContext(ctx).Info("SMS not sent, no provider configured", "to", to, "body", body)
+	return nil
+}
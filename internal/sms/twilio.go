@@ -0,0 +1,76 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/queue"
+)
+
+const twilioBaseURL = "https://api.twilio.com/2010-04-01"
+
+// sends texts through Twilio's Messages API.
+type Twilio struct {
+	httpClient *http.Client
+	baseURL    string
+	accountSID string
+	authToken  string
+	from       string
+}
+
+func NewTwilio(accountSID, authToken, from string) *Twilio {
+	return &Twilio{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    twilioBaseURL,
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+	}
+}
+
+type twilioError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (t *Twilio) SendSMS(ctx context.Context, to, body string) error {
+	form := url.Values{
+		"To":   {to},
+		"From": {t.from},
+		"Body": {body},
+	}
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", t.baseURL, url.PathEscape(t.accountSID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build Twilio request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.accountSID, t.authToken)
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var twErr twilioError
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = json.Unmarshal(raw, &twErr)
+	// a 400 is a bad or unreachable number (21211, 21610 for opted out, ...);
+	// sending it again gets the same answer
+	if resp.StatusCode == http.StatusBadRequest {
+		return fmt.Errorf("failed to send SMS: twilio error %d: %s: %w", twErr.Code, twErr.Message, queue.ErrUndeliverable)
+	}
+	return fmt.Errorf("failed to send SMS: status %d: twilio error %d: %s", resp.StatusCode, twErr.Code, twErr.Message)
+}
@@ -0,0 +1,70 @@
+package sms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwilio_SendSMS(t *testing.T) {
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		got = r
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	twilio := NewTwilio("AC123", "secret", "+15550000000")
+	twilio.baseURL = server.URL
+
+	require.NoError(t, twilio.SendSMS(context.Background(), "+15551234567", "Your camera is ready for pickup in 1 hour"))
+
+	assert.Equal(t, "/Accounts/AC123/Messages.json", got.URL.Path)
+	user, pass, ok := got.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "AC123", user)
+	assert.Equal(t, "secret", pass)
+	assert.Equal(t, "+15551234567", got.PostForm.Get("To"))
+	assert.Equal(t, "+15550000000", got.PostForm.Get("From"))
+	assert.Equal(t, "Your camera is ready for pickup in 1 hour", got.PostForm.Get("Body"))
+}
+
+func TestTwilio_SendSMS_Errors(t *testing.T) {
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"code": 21211, "message": "Invalid 'To' Phone Number"}`))
+	}))
+	defer server.Close()
+
+	twilio := NewTwilio("AC123", "secret", "+15550000000")
+	twilio.baseURL = server.URL
+
+	err := twilio.SendSMS(context.Background(), "12345", "hello")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, queue.ErrUndeliverable)
+
+	status = http.StatusServiceUnavailable
+	err = twilio.SendSMS(context.Background(), "+15551234567", "hello")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, queue.ErrUndeliverable, "outages are retried")
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(config.SMSConfig{Provider: "twilio"}, config.AWSConfig{})
+	assert.Error(t, err, "twilio without credentials")
+
+	_, err = New(config.SMSConfig{Provider: "pigeon"}, config.AWSConfig{})
+	assert.Error(t, err)
+
+	sender, err := New(config.SMSConfig{Provider: "log"}, config.AWSConfig{})
+	require.NoError(t, err)
+	assert.NoError(t, sender.SendSMS(context.Background(), "+15551234567", "hello"))
+}
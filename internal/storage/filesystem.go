@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// files being written are named with this prefix until they're complete
+const partialPrefix = ".partial-"
+
+// keeps objects as files under a directory, for development and tests without
+// LocalStack. Presigned URLs point at Handler, which must be served at
+// baseURL.
+type Filesystem struct {
+	root    string
+	baseURL string
+	// baseURL's path, which Handler is mounted at
+	basePath string
+	// signs presigned URLs; every process sharing root must use the same one
+	secret []byte
+}
+
+func NewFilesystem(root, baseURL string, secret []byte) (*Filesystem, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("filesystem storage needs a secret to sign URLs with")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage URL %q: %w", baseURL, err)
+	}
+	return &Filesystem{root: root, baseURL: baseURL, basePath: u.Path, secret: secret}, nil
+}
+
+// the path Handler expects to be mounted at, e.g. "/files/".
+func (f *Filesystem) BasePath() string {
+	return f.basePath
+}
+
+// the file key is kept in. Keys that would escape root, or name a directory,
+// are refused.
+func (f *Filesystem) path(key string) (string, error) {
+	if key == "" || strings.HasSuffix(key, "/") || path.Clean("/"+key) != "/"+key || strings.HasPrefix(path.Base(key), partialPrefix) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(f.root, filepath.FromSlash(key)), nil
+}
+
+// writes to a temporary file first so readers never see half an object.
+// contentType isn't kept; Handler serves files by their extension.
+func (f *Filesystem) PutObject(ctx context.Context, key string, body io.Reader, contentType string) error {
+	name, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), partialPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	return nil
+}
+
+func (f *Filesystem) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	name, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+	return file, nil
+}
+
+// a URL under baseURL carrying its expiry and a signature over the method,
+// key and expiry.
+func (f *Filesystem) GeneratePresignedURL(ctx context.Context, method string, key string, duration time.Duration) (string, error) {
+	if _, err := f.path(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(duration).Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {f.sign(method, key, expires)},
+	}
+	return f.baseURL + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+func (f *Filesystem) sign(method, key, expires string) string {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write([]byte(method + "\n" + key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (f *Filesystem) ListObjectsWithPrefix(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(f.root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), partialPrefix) {
+			return nil
+		}
+		rel, err := filepath.Rel(f.root, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Key: key, Size: info.Size(), LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	return objects, nil
+}
+
+func (f *Filesystem) DeleteObject(ctx context.Context, key string) error {
+	name, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// serves the URLs GeneratePresignedURL hands out, at BasePath: GET (and HEAD)
+// downloads the file, PUT stores the request body.
+func (f *Filesystem) Handler() http.Handler {
+	return http.StripPrefix(f.basePath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := r.Method
+		if method == http.MethodHead {
+			method = http.MethodGet
+		}
+		if method != http.MethodGet && method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		key := r.URL.Path
+		expires := r.URL.Query().Get("expires")
+		unix, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || time.Now().Unix() > unix ||
+			!hmac.Equal([]byte(r.URL.Query().Get("signature")), []byte(f.sign(method, key, expires))) {
+			http.Error(w, "invalid or expired signature", http.StatusForbidden)
+			return
+		}
+
+		if method == http.MethodPut {
+			if err := f.PutObject(r.Context(), key, r.Body, r.Header.Get("Content-Type")); err != nil {
+				http.Error(w, "failed to store file", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		name, err := f.path(key)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		file, err := os.Open(name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, path.Base(key), info.ModTime(), file)
+	}))
+}
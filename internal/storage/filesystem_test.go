@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilesystem(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(nil)
+	defer server.Close()
+
+	files, err := NewFilesystem(t.TempDir(), server.URL+"/files", []byte("secret"))
+	require.NoError(t, err)
+	assert.Equal(t, "/files/", files.BasePath())
+	mux := http.NewServeMux()
+	mux.Handle(files.BasePath(), files.Handler())
+	server.Config.Handler = mux
+
+	read := func(t *testing.T, key string) string {
+		t.Helper()
+		body, err := files.GetObject(ctx, key)
+		require.NoError(t, err)
+		defer body.Close()
+		data, err := io.ReadAll(body)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("objects are stored, listed and deleted", func(t *testing.T) {
+		require.NoError(t, files.PutObject(ctx, "items/1/a-thumb.jpg", strings.NewReader("thumb"), "image/jpeg"))
+		require.NoError(t, files.PutObject(ctx, "items/1/a-original.jpg", strings.NewReader("original"), "image/jpeg"))
+		require.NoError(t, files.PutObject(ctx, "groups/2/logo.png", strings.NewReader("logo"), "image/png"))
+		assert.Equal(t, "thumb", read(t, "items/1/a-thumb.jpg"))
+
+		objects, err := files.ListObjectsWithPrefix(ctx, "items/")
+		require.NoError(t, err)
+		require.Len(t, objects, 2)
+		assert.Equal(t, "items/1/a-original.jpg", objects[0].Key)
+		assert.Equal(t, int64(len("original")), objects[0].Size)
+		assert.Equal(t, "items/1/a-thumb.jpg", objects[1].Key)
+
+		require.NoError(t, files.DeleteObject(ctx, "items/1/a-thumb.jpg"))
+		require.NoError(t, files.DeleteObject(ctx, "items/1/a-thumb.jpg"), "deleting twice is fine")
+		_, err = files.GetObject(ctx, "items/1/a-thumb.jpg")
+		assert.Error(t, err)
+	})
+
+	t.Run("keys cannot leave the root", func(t *testing.T) {
+		for _, key := range []string{"", "../escape.txt", "items/../../escape.txt", "/abs.txt", "items/"} {
+			assert.Error(t, files.PutObject(ctx, key, strings.NewReader("x"), "text/plain"), key)
+		}
+	})
+
+	t.Run("presigned URLs upload and download", func(t *testing.T) {
+		putURL, err := files.GeneratePresignedURL(ctx, http.MethodPut, "items/3/uploads/photo one.jpg", time.Minute)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPut, putURL, strings.NewReader("uploaded"))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "uploaded", read(t, "items/3/uploads/photo one.jpg"))
+
+		// a PUT URL doesn't allow a GET
+		resp, err = http.Get(putURL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		getURL, err := files.GeneratePresignedURL(ctx, http.MethodGet, "items/3/uploads/photo one.jpg", time.Minute)
+		require.NoError(t, err)
+		resp, err = http.Get(getURL)
+		require.NoError(t, err)
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "uploaded", string(data))
+
+		expiredURL, err := files.GeneratePresignedURL(ctx, http.MethodGet, "items/3/uploads/photo one.jpg", -time.Minute)
+		require.NoError(t, err)
+		resp, err = http.Get(expiredURL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
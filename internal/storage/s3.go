@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"strings"
+
+	"github.com/USSTM/cv-backend/internal/aws"
+	"github.com/USSTM/cv-backend/internal/config"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// the S3 backend. The calls only S3 has, such as UploadStream and
+// ApplyLifecycle, are reached through the embedded service.
+type S3 struct {
+	*aws.S3Service
+}
+
+func NewS3(cfg config.AWSConfig) (*S3, error) {
+	service, err := aws.NewS3Service(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &S3{S3Service: service}, nil
+}
+
+func (s *S3) ListObjectsWithPrefix(ctx context.Context, prefix string) ([]Object, error) {
+	listed, err := s.S3Service.ListObjectsWithPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]Object, 0, len(listed))
+	for _, obj := range listed {
+		if obj.Key == nil {
+			continue
+		}
+		objects = append(objects, Object{
+			Key:          *obj.Key,
+			Size:         awssdk.ToInt64(obj.Size),
+			LastModified: awssdk.ToTime(obj.LastModified),
+			ETag:         strings.Trim(awssdk.ToString(obj.ETag), `"`),
+		})
+	}
+	return objects, nil
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/config"
+)
+
+// an object as listed by a backend.
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	// the backend's checksum of the contents, quotes stripped; empty when it
+	// keeps none. For S3 it's the MD5 unless the object was uploaded in parts.
+	ETag string
+}
+
+// where uploaded images, condition photos and generated reports are kept.
+// Keys are slash-separated paths such as "items/<id>/<image>-thumb.jpg".
+type Storage interface {
+	PutObject(ctx context.Context, key string, body io.Reader, contentType string) error
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+	// a URL that lets whoever holds it GET or PUT key until duration passes
+	GeneratePresignedURL(ctx context.Context, method string, key string, duration time.Duration) (string, error)
+	// every object whose key starts with prefix, in key order
+	ListObjectsWithPrefix(ctx context.Context, prefix string) ([]Object, error)
+	// deleting a key that doesn't exist is not an error
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// the backend cfg.Backend names: "s3", using the bucket in awsCfg, or
+// "filesystem".
+func New(cfg config.StorageConfig, awsCfg config.AWSConfig) (Storage, error) {
+	switch cfg.Backend {
+	case "s3":
+		return NewS3(awsCfg)
+	case "filesystem":
+		return NewFilesystem(cfg.FilesystemRoot, cfg.FilesystemURL, []byte(cfg.URLSecret))
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
@@ -45,6 +45,7 @@ type TestGroup struct {
 	ID          uuid.UUID
 	Name        string
 	Description string
+	Sandbox     bool
 }
 
 // TestUser represents a test user
@@ -65,6 +66,7 @@ type UserRole struct {
 type GroupBuilder struct {
 	name        string
 	description string
+	sandbox     bool
 	testDB      *TestDatabase
 	t           *testing.T
 }
@@ -91,6 +93,12 @@ func (gb *GroupBuilder) WithDescription(desc string) *GroupBuilder {
 	return gb
 }
 
+// AsSandbox creates the group in sandbox mode
+func (gb *GroupBuilder) AsSandbox() *GroupBuilder {
+	gb.sandbox = true
+	return gb
+}
+
 // Create creates the group in the database and returns the TestGroup
 func (gb *GroupBuilder) Create() *TestGroup {
 	ctx := context.Background()
@@ -98,6 +106,7 @@ func (gb *GroupBuilder) Create() *TestGroup {
 	group, err := gb.testDB.Queries().CreateGroup(ctx, db.CreateGroupParams{
 		Name:        gb.name,
 		Description: pgtype.Text{String: gb.description, Valid: gb.description != ""},
+		Sandbox:     gb.sandbox,
 	})
 	require.NoError(gb.t, err, "Failed to create group")
 
@@ -105,6 +114,7 @@ func (gb *GroupBuilder) Create() *TestGroup {
 		ID:          group.ID,
 		Name:        group.Name,
 		Description: group.Description.String,
+		Sandbox:     group.Sandbox,
 	}
 }
 
@@ -11,7 +11,6 @@ import (
 	"github.com/USSTM/cv-backend/internal/database"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
-	"github.com/pressly/goose/v3"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
@@ -29,7 +28,7 @@ type TestDatabase struct {
 // NewTestDatabase creates a new test database using testcontainers.
 // 'name' param used as the container reuse key, should pass a package-specific name so
 // parallel packages each get their own container.
-func NewTestDatabase(t *testing.T, name string) *TestDatabase {
+func NewTestDatabase(t testing.TB, name string) *TestDatabase {
 	ctx := context.Background()
 
 	// Disable Ryuk (cleanup container) via environment variable
@@ -85,17 +84,13 @@ func (tdb *TestDatabase) Pool() *pgxpool.Pool {
 	return tdb.pool
 }
 
-func (tdb *TestDatabase) RunMigrations(t *testing.T) {
+func (tdb *TestDatabase) RunMigrations(t testing.TB) {
 	// Convert pgxpool connection to database/sql for goose
 	sqlDB := stdlib.OpenDBFromPool(tdb.pool)
 	defer sqlDB.Close()
 
-	// Set the SQL dialect for goose
-	goose.SetDialect("postgres")
-
-	// Run migrations from db/migrations directory
-	// relative from project root
-	err := goose.Up(sqlDB, "../../db/migrations")
+	// the migrations embedded in the binary, as the API applies them
+	_, err := database.Migrate(context.Background(), sqlDB)
 	require.NoError(t, err, "Failed to run goose migrations")
 }
 
@@ -116,20 +111,62 @@ func (tdb *TestDatabase) CleanupDatabase(t *testing.T) {
 	// Seed data tables (roles, permissions, role_permissions, time_slots) are preserved
 	// Order matters: truncate child tables before parent tables to avoid FK violations
 	tables := []string{
-		"notifications",        // references users, notification_objects
-		"notification_changes", // references users, notification_objects
-		"notification_objects", // references notification_entity_types
-		"item_takings",         // references users, items
-		"cart_items",           // references users, items, groups
-		"booking",              // references users, items, user_availability
-		"borrowings",           // references users, items, requests
-		"requests",             // references users, items
-		"user_availability",    // references users, time_slots
-		"user_roles",           // references users, roles, groups
-		"signup_codes",         // references groups
-		"items",                // no FK dependencies
-		"users",                // no FK dependencies
-		"groups",               // no FK dependencies
+		"notifications",              // references users, notification_objects
+		"notification_changes",       // references users, notification_objects
+		"notification_preferences",   // references users
+		"notification_objects",       // references notification_entity_types
+		"item_takings",               // references users, items
+		"cart_items",                 // references users, items, groups
+		"booking_verifications",      // references booking, users
+		"student_id_changes",         // references users
+		"booking_handoffs",           // references booking, borrowings, users
+		"booking_events",             // references booking, users
+		"booking",                    // references users, items, user_availability
+		"return_campaign_notices",    // references return_campaigns, borrowings
+		"return_campaigns",           // references users, reports
+		"fines",                      // references users, borrowings, items, damage_reports
+		"damage_reports",             // references borrowings, items, groups, users
+		"borrowing_extensions",       // references borrowings, users
+		"borrowing_reminders",        // references borrowings
+		"overdue_borrowings",         // references borrowings
+		"borrowings",                 // references users, items, requests
+		"request_sla_alerts",         // references requests
+		"request_comments",           // references requests, users
+		"request_routes",             // references requests, users
+		"requests",                   // references users, items
+		"user_availability",          // references users, time_slots
+		"role_changes",               // references users
+		"user_identities",            // references users
+		"user_mfa_backup_codes",      // references users
+		"user_mfa",                   // references users
+		"api_keys",                   // references users
+		"user_roles",                 // references users, roles, groups
+		"signup_codes",               // references groups
+		"deletion_requests",          // references users
+		"approval_delegations",       // references users
+		"calendar_links",             // references users
+		"calendar_feed_tokens",       // references users
+		"reports",                    // references users, groups
+		"item_fairness_policies",     // references items, users
+		"item_fees",                  // references items, users
+		"item_waitlist",              // references items, users, groups
+		"group_booking_policies",     // references groups, users
+		"group_request_slas",         // references groups, users
+		"notification_routing_rules", // references items, groups, users
+		"borrowing_policies",         // references roles, users
+		"borrowing_blackouts",        // references users
+		"stocktake_counts",           // references stocktakes, items, users, stock_movements
+		"stocktakes",                 // references users
+		"stock_movements",            // references items, users
+		"item_tags",                  // references items, tags
+		"item_categories",            // references items, categories
+		"audit_log",                  // no FK dependencies
+		"email_suppressions",         // no FK dependencies
+		"tags",                       // no FK dependencies
+		"categories",                 // no FK dependencies
+		"items",                      // no FK dependencies
+		"users",                      // no FK dependencies
+		"groups",                     // no FK dependencies
 	}
 
 	for _, table := range tables {
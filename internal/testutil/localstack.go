@@ -123,17 +123,24 @@ func (ls *TestLocalStack) Cleanup(t *testing.T) {
 	}
 }
 
-func (ls *TestLocalStack) SendEmail(ctx context.Context, to, subject, body string) error {
+func (ls *TestLocalStack) SendEmail(ctx context.Context, to, subject, textBody, htmlBody string) error {
+	body := &types.Body{
+		Text: &types.Content{
+			Data: aws.String(textBody),
+		},
+	}
+	if htmlBody != "" {
+		body.Html = &types.Content{
+			Data: aws.String(htmlBody),
+		}
+	}
+
 	input := &ses.SendEmailInput{
 		Destination: &types.Destination{
 			ToAddresses: []string{to},
 		},
 		Message: &types.Message{
-			Body: &types.Body{
-				Text: &types.Content{
-					Data: aws.String(body),
-				},
-			},
+			Body: body,
 			Subject: &types.Content{
 				Data: aws.String(subject),
 			},
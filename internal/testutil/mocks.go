@@ -32,6 +32,12 @@ func (m *MockAuthenticator) ExpectCheckPermission(userID uuid.UUID, permission s
 	return m.On("CheckPermission", mock.Anything, userID, permission, scopeID).Return(hasPermission, err)
 }
 
+// InvalidateUserPermissions is a no-op: the mock answers from expectations, not a cache
+func (m *MockAuthenticator) InvalidateUserPermissions(ctx context.Context, userID uuid.UUID) {}
+
+// InvalidateAllPermissions is a no-op: the mock answers from expectations, not a cache
+func (m *MockAuthenticator) InvalidateAllPermissions(ctx context.Context) {}
+
 // MockJWTService is kept for middleware/authenticator tests that still need it
 type MockJWTService struct {
 	mock.Mock
@@ -20,6 +20,7 @@ type TestQueue struct {
 	container *redis.RedisContainer
 	Redis     *rdb.Client
 	Inspector *asynq.Inspector // (this is for inspecting the queue in tests)
+	Client    *asynq.Client    // (this is for enqueueing with custom options in tests)
 }
 
 func NewTestQueue(t *testing.T, name string) *TestQueue {
@@ -58,7 +59,7 @@ func NewTestQueue(t *testing.T, name string) *TestQueue {
 	}
 
 	// Create Asynq Task Queue
-	taskQueue, err := queue.NewQueue(&appConfig)
+	taskQueue, err := queue.NewQueue(&appConfig, queue.RetryPolicy{})
 	require.NoError(t, err, "Failed to create application queue wrapper")
 
 	// Create Asynq Inspector
@@ -74,13 +75,42 @@ func NewTestQueue(t *testing.T, name string) *TestQueue {
 		container: redisContainer,
 		Redis:     redisClient,
 		Inspector: inspector,
+		Client:    asynq.NewClient(clientOpts),
 	}
 
 	return testQueue
 }
 
-func (tQ *TestQueue) Enqueue(taskType string, data interface{}) (*asynq.TaskInfo, error) {
-	return tQ.Queue.Enqueue(taskType, data)
+func (tQ *TestQueue) Enqueue(ctx context.Context, taskType string, data interface{}) (*asynq.TaskInfo, error) {
+	return tQ.Queue.Enqueue(ctx, taskType, data)
+}
+
+func (tQ *TestQueue) EnqueueAt(ctx context.Context, taskType string, data interface{}, processAt time.Time) (*asynq.TaskInfo, error) {
+	return tQ.Queue.EnqueueAt(ctx, taskType, data, processAt)
+}
+
+func (tQ *TestQueue) ListQueues() ([]*asynq.QueueInfo, error) {
+	return tQ.Queue.ListQueues()
+}
+
+func (tQ *TestQueue) GetQueueInfo(name string) (*asynq.QueueInfo, error) {
+	return tQ.Queue.GetQueueInfo(name)
+}
+
+func (tQ *TestQueue) PauseQueue(name string) error {
+	return tQ.Queue.PauseQueue(name)
+}
+
+func (tQ *TestQueue) ResumeQueue(name string) error {
+	return tQ.Queue.ResumeQueue(name)
+}
+
+func (tQ *TestQueue) DrainQueue(name, state string) (int, error) {
+	return tQ.Queue.DrainQueue(name, state)
+}
+
+func (tQ *TestQueue) CancelTask(taskID string) error {
+	return tQ.Queue.CancelTask(taskID)
 }
 
 func (tQ *TestQueue) Cleanup(t *testing.T) {
@@ -105,6 +135,9 @@ func (tq *TestQueue) Close() {
 	if tq.Inspector != nil {
 		tq.Inspector.Close()
 	}
+	if tq.Client != nil {
+		tq.Client.Close()
+	}
 	if tq.Redis != nil {
 		tq.Redis.Close()
 	}
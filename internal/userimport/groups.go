@@ -0,0 +1,43 @@
+package userimport
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// resolves group hints from the registrar export to groups by name, ignoring
+// case and surrounding whitespace.
+type GroupMatcher struct {
+	byName map[string]uuid.UUID
+}
+
+func NewGroupMatcher() *GroupMatcher {
+	return &GroupMatcher{byName: make(map[string]uuid.UUID)}
+}
+
+func (m *GroupMatcher) Add(name string, id uuid.UUID) {
+	m.byName[normalize(name)] = id
+}
+
+// the groups the hints name, without duplicates, and the hints that named
+// no group.
+func (m *GroupMatcher) Match(hints []string) (matched []uuid.UUID, unmatched []string) {
+	seen := make(map[uuid.UUID]bool, len(hints))
+	for _, hint := range hints {
+		id, ok := m.byName[normalize(hint)]
+		if !ok {
+			unmatched = append(unmatched, hint)
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			matched = append(matched, id)
+		}
+	}
+	return matched, unmatched
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
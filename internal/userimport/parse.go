@@ -0,0 +1,106 @@
+package userimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+)
+
+// largest file accepted in one import; a September intake is about 3,000 rows.
+const MaxRows = 10000
+
+var ErrMissingEmailColumn = errors.New("CSV header must include an email column")
+
+// one data row of the registrar export. Err is set when the row can't be
+// imported; the other fields hold whatever could be read.
+type Row struct {
+	// line in the file where the row starts, counting the header as line 1
+	Line       int
+	Email      string
+	Name       string
+	Program    string
+	GroupHints []string
+	Err        string
+}
+
+// reads the registrar export. The header names the columns, in any order and
+// case: email (required), name, program and groups. Group hints are separated
+// by semicolons. Errors are returned only when the file as a whole is
+// unreadable; bad rows come back with Err set.
+func Parse(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, ErrMissingEmailColumn
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, Row{Line: parseErr.StartLine, Err: parseErr.Err.Error()})
+				continue
+			}
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("CSV has more than %d rows", MaxRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := Row{
+			Line:    line,
+			Email:   strings.ToLower(field(record, "email")),
+			Name:    field(record, "name"),
+			Program: field(record, "program"),
+		}
+		for _, hint := range strings.Split(field(record, "groups"), ";") {
+			if hint = strings.TrimSpace(hint); hint != "" {
+				row.GroupHints = append(row.GroupHints, hint)
+			}
+		}
+
+		switch {
+		case row.Email == "":
+			row.Err = "email is required"
+		case !validEmail(row.Email):
+			row.Err = "invalid email address"
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
@@ -0,0 +1,63 @@
+package userimport_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/USSTM/cv-backend/internal/userimport"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("reads columns by header in any order", func(t *testing.T) {
+		rows, err := userimport.Parse(strings.NewReader(
+			"Program,EMAIL,Name,Groups\n" +
+				"Engineering, Ada@McMaster.ca ,Ada Lovelace,AV Club; Robotics\n" +
+				"Science,grace@mcmaster.ca,Grace Hopper,\n"))
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+
+		assert.Equal(t, userimport.Row{
+			Line:       2,
+			Email:      "ada@mcmaster.ca",
+			Name:       "Ada Lovelace",
+			Program:    "Engineering",
+			GroupHints: []string{"AV Club", "Robotics"},
+		}, rows[0])
+		assert.Equal(t, 3, rows[1].Line)
+		assert.Empty(t, rows[1].GroupHints)
+	})
+
+	t.Run("bad rows are reported, not fatal", func(t *testing.T) {
+		rows, err := userimport.Parse(strings.NewReader("email,name\n,No Email\nnot-an-email,Bad\nok@mcmaster.ca,Ok\n"))
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+		assert.Equal(t, "email is required", rows[0].Err)
+		assert.Equal(t, "invalid email address", rows[1].Err)
+		assert.Empty(t, rows[2].Err)
+	})
+
+	t.Run("missing email column", func(t *testing.T) {
+		_, err := userimport.Parse(strings.NewReader("name,program\nAda,Engineering\n"))
+		assert.ErrorIs(t, err, userimport.ErrMissingEmailColumn)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		_, err := userimport.Parse(strings.NewReader(""))
+		assert.Error(t, err)
+	})
+}
+
+func TestGroupMatcher(t *testing.T) {
+	av := uuid.New()
+	robotics := uuid.New()
+	matcher := userimport.NewGroupMatcher()
+	matcher.Add("AV Club", av)
+	matcher.Add("Robotics", robotics)
+
+	matched, unmatched := matcher.Match([]string{"av club", "Chess", "ROBOTICS ", "AV Club"})
+	assert.Equal(t, []uuid.UUID{av, robotics}, matched)
+	assert.Equal(t, []string{"Chess"}, unmatched)
+}
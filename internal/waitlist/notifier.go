@@ -0,0 +1,89 @@
+package waitlist
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/google/uuid"
+)
+
+// the entries at the front of the line that stock can cover, in order. The
+// line is served strictly first come, first served: a member wanting more
+// than is left holds up everyone behind them.
+func NextInLine(waiting []db.ItemWaitlist, stock int32) []db.ItemWaitlist {
+	var next []db.ItemWaitlist
+	for _, entry := range waiting {
+		if entry.Quantity > stock {
+			break
+		}
+		stock -= entry.Quantity
+		next = append(next, entry)
+	}
+	return next
+}
+
+type notifier interface {
+	Notify(ctx context.Context, actorID uuid.UUID, entityType string, entityID uuid.UUID, groups []notifications.NotifierGroup) error
+}
+
+// emails members on an item's waitlist when it comes back into stock.
+type Notifier struct {
+	db       *db.Queries
+	notifier notifier
+}
+
+func NewNotifier(queries *db.Queries, notifier notifier) *Notifier {
+	return &Notifier{db: queries, notifier: notifier}
+}
+
+// notifies the members next in line that the item's current stock can
+// cover and returns how many were notified. Each entry leaves the waitlist
+// once notified; a failed email is logged and not retried.
+func (n *Notifier) NotifyNext(ctx context.Context, itemID uuid.UUID) (int, error) {
+	item, err := n.db.GetItemByID(ctx, itemID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get item %s: %w", itemID, err)
+	}
+
+	waiting, err := n.db.ListItemWaitlist(ctx, itemID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list waitlist for item %s: %w", itemID, err)
+	}
+
+	notified := 0
+	for _, entry := range NextInLine(waiting, item.Stock) {
+		marked, err := n.db.MarkWaitlistNotified(ctx, entry.ID)
+		if err != nil {
+			return notified, fmt.Errorf("failed to mark waitlist entry %s notified: %w", entry.ID, err)
+		}
+		if marked == 0 {
+			continue
+		}
+
+		if err := n.notify(ctx, item, entry); err != nil {
+			logging.FromContext(ctx).Error("failed to send waitlist notification", "waitlist_id", entry.ID, "error", err)
+			continue
+		}
+		notified++
+	}
+
+	return notified, nil
+}
+
+func (n *Notifier) notify(ctx context.Context, item db.Item, entry db.ItemWaitlist) error {
+	return n.notifier.Notify(ctx, entry.UserID, "item_waitlist", entry.ID, []notifications.NotifierGroup{
+		{
+			IDs:      []uuid.UUID{entry.UserID},
+			Template: "waitlist_available",
+			TemplateData: map[string]interface{}{
+				"ItemName": item.Name,
+				"Quantity": entry.Quantity,
+				"ItemID":   item.ID.String(),
+			},
+			Facts: notifications.RoutingFacts{ItemID: &item.ID, ItemType: item.Type, GroupID: &entry.GroupID},
+		},
+	})
+}
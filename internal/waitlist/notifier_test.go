@@ -0,0 +1,35 @@
+package waitlist_test
+
+import (
+	"testing"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/waitlist"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextInLine(t *testing.T) {
+	entry := func(quantity int32) db.ItemWaitlist {
+		return db.ItemWaitlist{ID: uuid.New(), Quantity: quantity}
+	}
+	first, second, third := entry(1), entry(2), entry(1)
+	waiting := []db.ItemWaitlist{first, second, third}
+
+	tests := []struct {
+		name  string
+		stock int32
+		want  []db.ItemWaitlist
+	}{
+		{"no stock", 0, nil},
+		{"enough for the first", 1, []db.ItemWaitlist{first}},
+		{"second waits for more and holds up the third", 2, []db.ItemWaitlist{first}},
+		{"enough for the first two", 3, []db.ItemWaitlist{first, second}},
+		{"enough for everyone", 10, waiting},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, waitlist.NextInLine(waiting, tt.stock))
+		})
+	}
+}
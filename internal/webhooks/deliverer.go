@@ -0,0 +1,95 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// how long an endpoint has to answer before the attempt counts as failed
+const deliveryTimeout = 10 * time.Second
+
+// posts queued deliveries to their webhooks.
+type Deliverer struct {
+	db     *db.Queries
+	client *http.Client
+}
+
+func NewDeliverer(queries *db.Queries) *Deliverer {
+	return &Deliverer{db: queries, client: &http.Client{Timeout: deliveryTimeout}}
+}
+
+// posts the delivery's event to its webhook, signed with the webhook's
+// secret, and records the attempt. Anything but a 2xx response is an error so
+// the worker retries it; on the final attempt the delivery is marked failed.
+func (d *Deliverer) Deliver(ctx context.Context, deliveryID uuid.UUID, final bool) error {
+	delivery, err := d.db.GetWebhookDeliveryForSend(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// the webhook was deleted, and its deliveries with it
+			return fmt.Errorf("delivery %s no longer exists: %w", deliveryID, queue.ErrUndeliverable)
+		}
+		return fmt.Errorf("failed to get delivery %s: %w", deliveryID, err)
+	}
+	if delivery.Status != db.WebhookDeliveryStatusPending {
+		// a task run twice; the first run already settled it
+		return nil
+	}
+
+	status, sendErr := d.send(ctx, delivery)
+
+	attempt := db.RecordWebhookAttemptParams{ID: delivery.ID, Status: db.WebhookDeliveryStatusSucceeded}
+	if status != 0 {
+		attempt.ResponseStatus = pgtype.Int4{Int32: int32(status), Valid: true}
+	}
+	if sendErr != nil {
+		attempt.Status = db.WebhookDeliveryStatusPending
+		if final {
+			attempt.Status = db.WebhookDeliveryStatusFailed
+		}
+		attempt.LastError = pgtype.Text{String: sendErr.Error(), Valid: true}
+	}
+	if err := d.db.RecordWebhookAttempt(ctx, attempt); err != nil {
+		return fmt.Errorf("failed to record attempt for delivery %s: %w", delivery.ID, err)
+	}
+	return sendErr
+}
+
+// the response status, or 0 when no response came
+func (d *Deliverer) send(ctx context.Context, delivery db.GetWebhookDeliveryForSendRow) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %w", err)
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cv-backend-webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID.String())
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("X-Webhook-Signature", Sign(delivery.Secret, now, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	// drained so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,113 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/events"
+	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/hibiken/asynq"
+)
+
+// the events a webhook can subscribe to
+var Types = []events.Type{events.RequestApproved, events.BookingConfirmed, events.ItemReturned, events.ItemOverdue}
+
+// whether name is one of Types.
+func Valid(name string) bool {
+	return slices.Contains(Types, events.Type(name))
+}
+
+const secretPrefix = "whsec_"
+
+// a random signing secret for a webhook registered without one
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + hex.EncodeToString(b), nil
+}
+
+// the X-Webhook-Signature value for body sent at timestamp. The timestamp is
+// signed too, so receivers can reject replays of old deliveries.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type enqueuer interface {
+	Enqueue(ctx context.Context, taskType string, data interface{}) (*asynq.TaskInfo, error)
+}
+
+// queues events for the webhooks subscribed to them.
+type Publisher struct {
+	db    *db.Queries
+	queue enqueuer
+}
+
+func NewPublisher(queries *db.Queries, queue enqueuer) *Publisher {
+	return &Publisher{db: queries, queue: queue}
+}
+
+// records a delivery of e for every webhook subscribed to its type and queues
+// it for the worker. Events from sandbox groups are not sent; training
+// shouldn't reach the union's channels.
+func (p *Publisher) Publish(ctx context.Context, e events.Event) error {
+	if !Valid(string(e.Type)) {
+		return nil
+	}
+	if e.GroupID != nil {
+		sandbox, err := p.db.IsGroupSandbox(ctx, *e.GroupID)
+		if err != nil {
+			return fmt.Errorf("failed to check group sandbox flag: %w", err)
+		}
+		if sandbox {
+			return nil
+		}
+	}
+
+	hooks, err := p.db.ListWebhooksForEvent(ctx, string(e.Type))
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	for _, hook := range hooks {
+		delivery, err := p.db.CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+			WebhookID: hook.ID,
+			EventType: string(e.Type),
+			Payload:   payload,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record delivery to webhook %s: %w", hook.ID, err)
+		}
+		if _, err := p.queue.Enqueue(ctx, queue.TypeWebhookDelivery, queue.WebhookDeliveryPayload{DeliveryID: delivery.ID}); err != nil {
+			// the delivery stays pending in the log, where an admin can see it
+			logging.FromContext(ctx).Error("Failed to queue webhook delivery", "webhook_id", hook.ID, "delivery_id", delivery.ID, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/events"
+	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/USSTM/cv-backend/internal/webhooks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	sharedQueue *testutil.TestQueue
+	sharedDB    *testutil.TestDatabase
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		// unit tests still run; integration tests skip themselves
+		os.Exit(m.Run())
+	}
+
+	t := &testing.T{}
+	sharedDB = testutil.NewTestDatabase(t, "cv-backend-test-db-webhooks")
+	sharedDB.RunMigrations(t)
+	sharedQueue = testutil.NewTestQueue(t, "cv-backend-test-redis-webhooks")
+
+	code := m.Run()
+
+	if sharedDB.Pool() != nil {
+		sharedDB.Pool().Close()
+	}
+	sharedQueue.Close()
+
+	os.Exit(code)
+}
+
+func TestSign(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	body := []byte(`{"type":"item.returned"}`)
+
+	signature := webhooks.Sign("whsec_test", at, body)
+	assert.Equal(t, "sha256=450630c7a579a37886069caf170599286e612efa7a92005f9910a469ee02ce7c", signature)
+	assert.NotEqual(t, signature, webhooks.Sign("whsec_other", at, body))
+	assert.NotEqual(t, signature, webhooks.Sign("whsec_test", at.Add(time.Second), body))
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, webhooks.Valid("item.overdue"))
+	assert.True(t, webhooks.Valid("request.approved"))
+	// dashboards only
+	assert.False(t, webhooks.Valid("request.pending"))
+}
+
+func createWebhook(t *testing.T, url string, eventTypes ...events.Type) db.Webhook {
+	names := make([]string, 0, len(eventTypes))
+	for _, e := range eventTypes {
+		names = append(names, string(e))
+	}
+	hook, err := sharedDB.Queries().CreateWebhook(context.Background(), db.CreateWebhookParams{
+		Url:    url,
+		Secret: "whsec_test_secret",
+		Events: names,
+	})
+	require.NoError(t, err)
+	return hook
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	sharedQueue.Cleanup(t)
+	ctx := context.Background()
+
+	returns := createWebhook(t, "https://bot.example.com/returns", events.ItemReturned)
+	createWebhook(t, "https://bot.example.com/approvals", events.RequestApproved)
+	live := sharedDB.NewGroup(t).WithName("Live Group").Create()
+	sandbox := sharedDB.NewGroup(t).WithName("Training Group").AsSandbox().Create()
+	publisher := webhooks.NewPublisher(sharedDB.Queries(), sharedQueue)
+
+	require.NoError(t, publisher.Publish(ctx, events.Event{Type: events.ItemReturned, EntityID: live.ID, GroupID: &live.ID}))
+	require.NoError(t, publisher.Publish(ctx, events.Event{Type: events.ItemReturned, EntityID: sandbox.ID, GroupID: &sandbox.ID}))
+	// not a webhook event
+	require.NoError(t, publisher.Publish(ctx, events.Event{Type: events.RequestPending, EntityID: live.ID, GroupID: &live.ID}))
+
+	// only the live group's return reaches the subscribed webhook
+	deliveries, err := sharedDB.Queries().ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{WebhookID: returns.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, db.WebhookDeliveryStatusPending, deliveries[0].Status)
+
+	var sent events.Event
+	require.NoError(t, json.Unmarshal(deliveries[0].Payload, &sent))
+	assert.Equal(t, events.ItemReturned, sent.Type)
+	assert.Equal(t, live.ID, sent.EntityID)
+	assert.False(t, sent.OccurredAt.IsZero())
+
+	tasks, err := sharedQueue.Inspector.ListPendingTasks("default")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, queue.TypeWebhookDelivery, tasks[0].Type)
+	var payload queue.WebhookDeliveryPayload
+	require.NoError(t, json.Unmarshal(tasks[0].Payload, &payload))
+	assert.Equal(t, deliveries[0].ID, payload.DeliveryID)
+}
+
+func TestDeliverer_Deliver(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	sharedDB.CleanupDatabase(t)
+	ctx := context.Background()
+
+	status := http.StatusOK
+	var received *http.Request
+	var receivedBody []byte
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer endpoint.Close()
+
+	hook := createWebhook(t, endpoint.URL, events.ItemOverdue)
+	deliverer := webhooks.NewDeliverer(sharedDB.Queries())
+	newDelivery := func() db.WebhookDelivery {
+		delivery, err := sharedDB.Queries().CreateWebhookDelivery(ctx, db.CreateWebhookDeliveryParams{
+			WebhookID: hook.ID,
+			EventType: string(events.ItemOverdue),
+			Payload:   []byte(`{"type":"item.overdue"}`),
+		})
+		require.NoError(t, err)
+		return delivery
+	}
+	latest := func(id uuid.UUID) db.WebhookDelivery {
+		deliveries, err := sharedDB.Queries().ListWebhookDeliveries(ctx, db.ListWebhookDeliveriesParams{WebhookID: hook.ID, Limit: 10})
+		require.NoError(t, err)
+		for _, d := range deliveries {
+			if d.ID == id {
+				return d
+			}
+		}
+		t.Fatalf("delivery %v not found", id)
+		return db.WebhookDelivery{}
+	}
+
+	t.Run("signed and recorded", func(t *testing.T) {
+		delivery := newDelivery()
+		require.NoError(t, deliverer.Deliver(ctx, delivery.ID, false))
+
+		assert.Equal(t, "item.overdue", received.Header.Get("X-Webhook-Event"))
+		assert.Equal(t, delivery.ID.String(), received.Header.Get("X-Webhook-Delivery"))
+		timestamp, err := strconv.ParseInt(received.Header.Get("X-Webhook-Timestamp"), 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, webhooks.Sign("whsec_test_secret", time.Unix(timestamp, 0), receivedBody), received.Header.Get("X-Webhook-Signature"))
+		assert.JSONEq(t, `{"type":"item.overdue"}`, string(receivedBody))
+
+		recorded := latest(delivery.ID)
+		assert.Equal(t, db.WebhookDeliveryStatusSucceeded, recorded.Status)
+		assert.Equal(t, int32(1), recorded.Attempts)
+		assert.Equal(t, int32(http.StatusOK), recorded.ResponseStatus.Int32)
+		assert.True(t, recorded.DeliveredAt.Valid)
+
+		// a task run twice doesn't post again
+		received = nil
+		require.NoError(t, deliverer.Deliver(ctx, delivery.ID, false))
+		assert.Nil(t, received)
+	})
+
+	t.Run("errors are retried until the final attempt", func(t *testing.T) {
+		status = http.StatusBadGateway
+		delivery := newDelivery()
+
+		assert.Error(t, deliverer.Deliver(ctx, delivery.ID, false))
+		recorded := latest(delivery.ID)
+		assert.Equal(t, db.WebhookDeliveryStatusPending, recorded.Status)
+		assert.Equal(t, int32(http.StatusBadGateway), recorded.ResponseStatus.Int32)
+		assert.True(t, recorded.LastError.Valid)
+
+		assert.Error(t, deliverer.Deliver(ctx, delivery.ID, true))
+		recorded = latest(delivery.ID)
+		assert.Equal(t, db.WebhookDeliveryStatusFailed, recorded.Status)
+		assert.Equal(t, int32(2), recorded.Attempts)
+		assert.False(t, recorded.DeliveredAt.Valid)
+	})
+
+	t.Run("deleted webhooks are not retried", func(t *testing.T) {
+		delivery := newDelivery()
+		_, err := sharedDB.Queries().DeleteWebhook(ctx, hook.ID)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, deliverer.Deliver(ctx, delivery.ID, false), queue.ErrUndeliverable)
+	})
+}
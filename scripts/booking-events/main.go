@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/USSTM/cv-backend/internal/bookingevents"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/database"
+	"github.com/google/uuid"
+)
+
+var (
+	bookingPtr = flag.String("booking", "", "Booking ID to print the event log for")
+	checkPtr   = flag.Bool("check", false, "Replay every booking's events and report bookings whose status does not match")
+)
+
+func main() {
+	flag.Parse()
+
+	if *bookingPtr == "" && !*checkPtr {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+
+	bookingDB, err := database.New(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer bookingDB.Close()
+
+	ctx := context.Background()
+	queries := bookingDB.Queries()
+
+	if *bookingPtr != "" {
+		bookingID, err := uuid.Parse(*bookingPtr)
+		if err != nil {
+			log.Fatalf("Invalid booking ID: %v", err)
+		}
+
+		events, err := queries.ListBookingEvents(ctx, bookingID)
+		if err != nil {
+			log.Fatalf("Failed to list booking events: %v", err)
+		}
+		for _, e := range events {
+			from := "-"
+			if e.FromStatus.Valid {
+				from = string(e.FromStatus.RequestStatus)
+			}
+			actor := "system"
+			if e.ActorID != nil {
+				actor = e.ActorID.String()
+			}
+			fmt.Printf("%s  %-20s -> %-20s  by %s  %s\n",
+				e.CreatedAt.Time.Format("2006-01-02 15:04:05.000"), from, e.ToStatus, actor, e.Payload)
+		}
+
+		status, err := bookingevents.Replay(events)
+		if err != nil {
+			fmt.Printf("replay failed: %v\n", err)
+		} else {
+			fmt.Printf("derived status: %s\n", status)
+		}
+	}
+
+	if *checkPtr {
+		found, err := bookingevents.Check(ctx, queries)
+		if err != nil {
+			log.Fatalf("Consistency check failed: %v", err)
+		}
+		for _, inc := range found {
+			fmt.Printf("%s  %s\n", inc.BookingID, inc.Reason)
+		}
+		fmt.Printf("%d inconsistent booking(s)\n", len(found))
+		if len(found) > 0 {
+			os.Exit(1)
+		}
+	}
+}
@@ -3,16 +3,23 @@ package main
 import (
 	"context"
 	"flag"
+	"io/fs"
 	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
 
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 
-	emailSvc "github.com/USSTM/cv-backend/internal/aws"
 	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/notifications"
 	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/templates"
 )
 
 type LocalStackEmail struct {
@@ -38,75 +45,216 @@ type LocalStackResponse struct {
 
 // flags for make go script
 var (
-	enqueuePtr = flag.Bool("enqueue", false, "Enqueue the email task instead of sending directly")
-	viewPtr    = flag.Bool("view", false, "View the emails")
-	testPtr    = flag.Bool("test", false, "Test sending an email")
+	queuePtr     = flag.Bool("queue", false, "Enqueue the email task for the worker to deliver")
+	directPtr    = flag.Bool("direct", false, "Send the email directly through SES, bypassing the queue")
+	viewPtr      = flag.Bool("view", false, "View the emails")
+	toPtr        = flag.String("to", "test@example.com", "Comma-separated recipient addresses")
+	templatePtr  = flag.String("template", "", "Email template name, e.g. request_approved_requester")
+	templatesDir = flag.String("templates", "", "Directory containing email templates (default: the set built into the binary)")
+	varsPtr      = flag.String("vars", "", "Path to a JSON file with template variables")
+	previewPtr   = flag.String("preview", "", "Render a template with its sample data to stdout, e.g. request_approved_requester")
+	browserPtr   = flag.Bool("browser", false, "With --preview, open the rendered HTML in a browser instead of printing it")
+	subjectPtr   = flag.String("subject", "Test Email from LocalStack", "Subject when no template is given")
+	bodyPtr      = flag.String("body", "Sup ladies and gentlemen", "Body when no template is given")
 )
 
 func main() {
 	flag.Parse()
 
+	// this is for make email flag=preview (designers checking a template, no services needed)
+	if *previewPtr != "" {
+		if err := preview(*previewPtr); err != nil {
+			log.Fatalf("Failed to preview template: %v", err)
+		}
+		return
+	}
+
 	cfg := config.Load()
 
-	to := "test@example.com"
-	subject := "Test Email from LocalStack"
-	body := "Sup ladies and gentlemen"
+	// this is for make email flag=view (viewing the emails)
+	if *viewPtr {
+		viewEmails()
+		return
+	}
+
+	if *queuePtr == *directPtr {
+		log.Fatal("Exactly one of --queue or --direct must be set")
+	}
+
+	recipients := parseRecipients(*toPtr)
+	if len(recipients) == 0 {
+		log.Fatal("--to must contain at least one address")
+	}
+
+	msg, err := buildMessage()
+	if err != nil {
+		log.Fatalf("Failed to build email: %v", err)
+	}
 
-	// this is for make email flag=enqueue (enqueueing the email to redis/asynq to then be processed by the worker)
-	if *enqueuePtr {
+	// this is for make email flag=queue (enqueueing the email to redis/asynq to then be processed by the worker)
+	if *queuePtr {
 		log.Println("Initializing Redis queue...")
-		q, err := queue.NewQueue(&cfg.Redis)
+		q, err := queue.NewQueue(&cfg.Redis, queue.NewRetryPolicy(&cfg.Worker))
 		if err != nil {
 			log.Fatalf("Failed to connect to queue: %v", err)
 		}
 		defer q.Close()
 
-		log.Printf("Enqueuing email to %s...", to)
-		payload := queue.EmailDeliveryPayload{
-			To:      to,
-			Subject: subject,
-			Body:    body,
+		for _, to := range recipients {
+			log.Printf("Enqueuing email to %s...", to)
+			info, err := q.Enqueue(context.Background(), queue.TypeEmailDelivery, queue.EmailDeliveryPayload{
+				To:       to,
+				Subject:  msg.Subject,
+				Body:     msg.Text,
+				HTMLBody: msg.HTML,
+			})
+			if err != nil {
+				log.Fatalf("Failed to enqueue task: %v", err)
+			}
+			log.Printf("Task enqueued successfully! ID: %s", info.ID)
 		}
-
-		// enqueue email
-		info, err := q.Enqueue(queue.TypeEmailDelivery, payload)
-		if err != nil {
-			log.Fatalf("Failed to enqueue task: %v", err)
-		}
-		log.Printf("Task enqueued successfully! ID: %s", info.ID)
 		return
 	}
 
-	// this is for make email flag=view (viewing the emails)
-	if *viewPtr {
-		viewEmails()
-		return
+	// this is for make email flag=direct (testing to send an email directly)
+	log.Printf("Initializing %s email provider...", cfg.Email.Provider)
+	svc, err := notifications.NewEmailSender(cfg.Email, cfg.AWS)
+	if err != nil {
+		log.Fatalf("Failed to create email service: %v", err)
 	}
 
-	// this is for make email flag=test (testing to send an email directly)
-	if *testPtr {
-		log.Println("Initializing email service...")
-		svc, err := emailSvc.NewEmailService(cfg.AWS)
-		if err != nil {
-			log.Fatalf("Failed to create email service: %v", err)
-		}
-
-		log.Printf("Verifying sender identity %s...", svc.Sender())
-		_, err = svc.VerifyEmailIdentity(context.Background())
-		if err != nil {
+	if ses, ok := svc.(*notifications.SESSender); ok {
+		log.Printf("Verifying sender identity %s...", ses.Sender())
+		if _, err := ses.VerifyEmailIdentity(context.Background()); err != nil {
 			log.Fatalf("Failed to verify email identity: %v", err)
 		}
+	}
 
+	for _, to := range recipients {
 		log.Printf("Sending email to %s...", to)
-		err = svc.SendEmail(context.Background(), to, subject, body)
-		if err != nil {
+		if err := svc.SendEmail(context.Background(), to, msg.Subject, msg.Text, msg.HTML); err != nil {
 			log.Fatalf("Failed to send email: %v", err)
 		}
+	}
 
-		log.Println("Email sent successfully!")
+	log.Println("Email sent successfully!")
 
-		viewEmails()
+	viewEmails()
+}
+
+func parseRecipients(raw string) []string {
+	var recipients []string
+	for _, addr := range strings.Split(raw, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			recipients = append(recipients, addr)
+		}
+	}
+	return recipients
+}
+
+// renders --template with --vars when given, otherwise falls back to --subject/--body.
+func buildMessage() (notifications.Message, error) {
+	if *templatePtr == "" {
+		return notifications.Message{Subject: *subjectPtr, Text: *bodyPtr}, nil
+	}
+
+	vars, err := readVars()
+	if err != nil {
+		return notifications.Message{}, err
+	}
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+
+	tmpl, err := notifications.LoadTemplates(templateFiles())
+	if err != nil {
+		return notifications.Message{}, err
+	}
+	return notifications.RenderTemplate(tmpl, *templatePtr, vars)
+}
+
+// --vars as a map, nil when the flag isn't set
+func readVars() (map[string]interface{}, error) {
+	if *varsPtr == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(*varsPtr)
+	if err != nil {
+		return nil, fmt.Errorf("read vars file: %w", err)
+	}
+	vars, err := notifications.DecodeTemplateData(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse vars file %s: %w", *varsPtr, err)
+	}
+	return vars, nil
+}
+
+// the embedded templates, or --templates read from disk so edits show up
+// without rebuilding
+func templateFiles() fs.FS {
+	if *templatesDir != "" {
+		return os.DirFS(*templatesDir)
 	}
+	return templates.Email()
+}
+
+// renders name with --vars, or its entry in preview.json, and prints the HTML
+// part to stdout (or opens it with --browser). The subject and text part go
+// to the log so the HTML can be piped to a file.
+func preview(name string) error {
+	fsys := templateFiles()
+	tmpl, err := notifications.LoadTemplates(fsys)
+	if err != nil {
+		return err
+	}
+
+	vars, err := readVars()
+	if err != nil {
+		return err
+	}
+	if vars == nil {
+		samples, err := notifications.LoadPreviewData(fsys)
+		if err != nil {
+			return err
+		}
+		var ok bool
+		if vars, ok = samples[name]; !ok {
+			return fmt.Errorf("no sample data for %q in preview.json, pass --vars", name)
+		}
+	}
+
+	msg, err := notifications.RenderTemplate(tmpl, name, vars)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Subject: %s", msg.Subject)
+	log.Printf("Text part:\n%s", msg.Text)
+
+	if !*browserPtr {
+		fmt.Println(msg.HTML)
+		return nil
+	}
+
+	path := filepath.Join(os.TempDir(), "cv-email-"+name+".html")
+	if err := os.WriteFile(path, []byte(msg.HTML), 0o644); err != nil {
+		return fmt.Errorf("write preview file: %w", err)
+	}
+	log.Printf("Opening %s...", path)
+	return openBrowser(path)
+}
+
+func openBrowser(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	return cmd.Start()
 }
 
 func viewEmails() {
@@ -137,6 +285,9 @@ func viewEmails() {
 		fmt.Printf("To: %v\n", msg.Destination.ToAddresses)
 		fmt.Printf("Subject: %s\n", msg.Subject)
 		fmt.Printf("Body: %s\n", msg.Body.Text)
+		if msg.Body.HTML != "" {
+			fmt.Printf("HTML: %d bytes\n", len(msg.Body.HTML))
+		}
 		fmt.Println("---------------------------------------------------")
 	}
 }
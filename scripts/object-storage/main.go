@@ -2,24 +2,38 @@ package main
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
+	"mime"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/USSTM/cv-backend/internal/aws"
 	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/storage"
 )
 
 var (
-	uploadPtr  = flag.String("upload", "", "Path to file to upload")
-	getPtr     = flag.String("get", "", "Key of file to retrieve")
-	linkPtr    = flag.String("link", "", "Key of file to generate presigned URL for")
-	listPtr    = flag.Bool("list", false, "List all objects in the bucket")
-	bucketsPtr = flag.Bool("buckets", false, "List all buckets")
+	uploadPtr     = flag.String("upload", "", "Path to file to upload")
+	getPtr        = flag.String("get", "", "Key of file to retrieve")
+	linkPtr       = flag.String("link", "", "Key of file to generate presigned URL for")
+	presignPutPtr = flag.String("presign-put", "", "Key to generate a presigned upload (PUT) URL for")
+	deletePtr     = flag.String("delete", "", "Key of file to delete")
+	pushPtr       = flag.String("push", "", "Local directory to sync up into the bucket under --prefix")
+	pullPtr       = flag.String("pull", "", "Local directory to sync the objects under --prefix down into")
+	listPtr       = flag.Bool("list", false, "List all objects in the bucket")
+	bucketsPtr    = flag.Bool("buckets", false, "List all buckets (S3 only)")
+	prefixPtr     = flag.String("prefix", "", "Key prefix to filter --list and to target --upload, --push and --pull")
+	expiresPtr    = flag.Duration("expires", 15*time.Minute, "Expiry for --link and --presign-put URLs")
 )
 
 func main() {
@@ -27,16 +41,23 @@ func main() {
 
 	cfg := config.Load()
 
-	s3Service, err := aws.NewS3Service(cfg.AWS)
+	store, err := storage.New(cfg.Storage, cfg.AWS)
 	if err != nil {
-		log.Fatalf("Failed to initialize S3 service: %v", err)
+		log.Fatalf("Failed to initialize object storage: %v", err)
 	}
 
 	ctx := context.Background()
 
-	// create bucket if it doesn't exist (for localstack)
-	if err := s3Service.CreateBucket(ctx); err != nil {
-		log.Fatalf("Warning: failed to ensure bucket exists: %v", err)
+	// the bucket, or the directory files are kept in
+	location := cfg.Storage.FilesystemRoot
+	s3Store, isS3 := store.(*storage.S3)
+	if isS3 {
+		location = cfg.AWS.Bucket
+
+		// create bucket if it doesn't exist (for localstack)
+		if err := s3Store.CreateBucket(ctx); err != nil {
+			log.Fatalf("Warning: failed to ensure bucket exists: %v", err)
+		}
 	}
 
 	if *uploadPtr != "" {
@@ -47,13 +68,35 @@ func main() {
 		}
 		defer file.Close()
 
-		key := filepath.Base(filePath)
-		contentType := "application/octet-stream"
+		key := *prefixPtr + filepath.Base(filePath)
+
+		info, err := file.Stat()
+		if err != nil {
+			log.Fatalf("Failed to stat file: %v", err)
+		}
 
-		fmt.Printf("Uploading %s to %s/%s...\n", filePath, cfg.AWS.Bucket, key)
-		if err := s3Service.PutObject(ctx, key, file, contentType); err != nil {
-			log.Fatalf("Failed to upload file: %v", err)
+		fmt.Printf("Uploading %s to %s/%s...\n", filePath, location, key)
+		// parts finish out of order; only ever show the count going up
+		var mu sync.Mutex
+		var shown int64
+		progress := func(uploaded int64) {
+			mu.Lock()
+			defer mu.Unlock()
+			if uploaded > shown {
+				shown = uploaded
+				fmt.Printf("\r%d/%d bytes", uploaded, info.Size())
+			}
 		}
+		if isS3 {
+			err = s3Store.UploadStream(ctx, key, file, contentTypeFor(filePath), progress)
+		} else {
+			err = store.PutObject(ctx, key, file, contentTypeFor(filePath))
+			progress(info.Size())
+		}
+		if err != nil {
+			log.Fatalf("\nFailed to upload file: %v", err)
+		}
+		fmt.Println()
 
 		fmt.Println("Upload successful!")
 		return
@@ -61,15 +104,15 @@ func main() {
 
 	if *getPtr != "" {
 		key := *getPtr
-		fmt.Printf("Retrieving %s from %s...\n", key, cfg.AWS.Bucket)
+		fmt.Printf("Retrieving %s from %s...\n", key, location)
 
-		body, err := s3Service.GetObject(ctx, key)
+		body, err := store.GetObject(ctx, key)
 		if err != nil {
 			log.Fatalf("Failed to get file: %v", err)
 		}
 		defer body.Close()
 
-		outFile, err := os.Create(key)
+		outFile, err := os.Create(path.Base(key))
 		if err != nil {
 			log.Fatalf("Failed to create output file: %v", err)
 		}
@@ -78,23 +121,58 @@ func main() {
 		if _, err := io.Copy(outFile, body); err != nil {
 			log.Fatalf("Failed to save file: %v", err)
 		}
-		fmt.Printf("File saved to %s", key)
+		fmt.Printf("File saved to %s", path.Base(key))
 		return
 	}
 
 	if *linkPtr != "" {
 		key := *linkPtr
-		url, err := s3Service.GeneratePresignedURL(ctx, "GET", key, 15*time.Minute)
+		url, err := store.GeneratePresignedURL(ctx, http.MethodGet, key, *expiresPtr)
 		if err != nil {
 			log.Fatalf("Failed to generate presigned URL: %v", err)
 		}
-		fmt.Printf("Presigned URL for %s (expires in 15m):\n%s\n", key, url)
+		fmt.Printf("Presigned URL for %s (expires in %s):\n%s\n", key, *expiresPtr, url)
+		return
+	}
+
+	if *presignPutPtr != "" {
+		key := *presignPutPtr
+		url, err := store.GeneratePresignedURL(ctx, http.MethodPut, key, *expiresPtr)
+		if err != nil {
+			log.Fatalf("Failed to generate presigned URL: %v", err)
+		}
+		fmt.Printf("Presigned upload URL for %s (expires in %s):\n%s\n", key, *expiresPtr, url)
+		fmt.Printf("\ncurl -X PUT --upload-file <file> '%s'\n", url)
+		return
+	}
+
+	if *deletePtr != "" {
+		key := *deletePtr
+		fmt.Printf("Deleting %s from %s...\n", key, location)
+		if err := store.DeleteObject(ctx, key); err != nil {
+			log.Fatalf("Failed to delete file: %v", err)
+		}
+		fmt.Println("Delete successful!")
+		return
+	}
+
+	if *pushPtr != "" {
+		if err := push(ctx, store, *pushPtr, *prefixPtr); err != nil {
+			log.Fatalf("Failed to sync %s: %v", *pushPtr, err)
+		}
+		return
+	}
+
+	if *pullPtr != "" {
+		if err := pull(ctx, store, *pullPtr, *prefixPtr); err != nil {
+			log.Fatalf("Failed to sync into %s: %v", *pullPtr, err)
+		}
 		return
 	}
 
 	if *listPtr {
-		fmt.Printf("Listing objects in bucket %s...", cfg.AWS.Bucket)
-		objects, err := s3Service.ListObjects(ctx)
+		fmt.Printf("Listing objects in %s...", location)
+		objects, err := store.ListObjectsWithPrefix(ctx, *prefixPtr)
 		if err != nil {
 			log.Fatalf("Failed to list objects: %v", err)
 		}
@@ -105,15 +183,18 @@ func main() {
 			fmt.Printf("%-30s %-10s %s\n", "Key", "Size", "LastModified")
 			fmt.Println("------------------------------------------------------------")
 			for _, obj := range objects {
-				fmt.Printf("%-30s %-10d %s\n", *obj.Key, obj.Size, obj.LastModified.Format(time.RFC3339))
+				fmt.Printf("%-30s %-10d %s\n", obj.Key, obj.Size, obj.LastModified.Format(time.RFC3339))
 			}
 		}
 		return
 	}
 
 	if *bucketsPtr {
+		if !isS3 {
+			log.Fatalf("--buckets needs the s3 storage backend")
+		}
 		fmt.Println("Listing all buckets...")
-		buckets, err := s3Service.ListBuckets(ctx)
+		buckets, err := s3Store.ListBuckets(ctx)
 		if err != nil {
 			log.Fatalf("Failed to list buckets: %v", err)
 		}
@@ -129,3 +210,143 @@ func main() {
 		return
 	}
 }
+
+// uploads every file under dir to prefix, skipping objects whose ETag already
+// matches the local MD5.
+func push(ctx context.Context, store storage.Storage, dir, prefix string) error {
+	remote, err := remoteChecksums(ctx, store, prefix)
+	if err != nil {
+		return err
+	}
+
+	var uploaded, skipped int
+	err = filepath.WalkDir(dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		key := prefix + filepath.ToSlash(rel)
+
+		sum, err := fileMD5(filePath)
+		if err != nil {
+			return err
+		}
+		if remote[key] == sum {
+			skipped++
+			return nil
+		}
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		fmt.Printf("upload: %s -> %s\n", filePath, key)
+		if err := store.PutObject(ctx, key, file, contentTypeFor(filePath)); err != nil {
+			return err
+		}
+		uploaded++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Sync complete: %d uploaded, %d unchanged\n", uploaded, skipped)
+	return nil
+}
+
+// downloads every object under prefix into dir, skipping local files whose
+// MD5 already matches the object's ETag.
+func pull(ctx context.Context, store storage.Storage, dir, prefix string) error {
+	remote, err := remoteChecksums(ctx, store, prefix)
+	if err != nil {
+		return err
+	}
+
+	var downloaded, skipped int
+	for key, etag := range remote {
+		rel := strings.TrimPrefix(key, prefix)
+		if rel == "" || strings.HasSuffix(rel, "/") {
+			continue
+		}
+		localPath := filepath.Join(dir, filepath.FromSlash(rel))
+
+		if sum, err := fileMD5(localPath); err == nil && sum == etag {
+			skipped++
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
+			return err
+		}
+
+		fmt.Printf("download: %s -> %s\n", key, localPath)
+		if err := download(ctx, store, key, localPath); err != nil {
+			return err
+		}
+		downloaded++
+	}
+
+	fmt.Printf("Sync complete: %d downloaded, %d unchanged\n", downloaded, skipped)
+	return nil
+}
+
+func download(ctx context.Context, store storage.Storage, key, localPath string) error {
+	body, err := store.GetObject(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	outFile, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	_, err = io.Copy(outFile, body)
+	return err
+}
+
+// maps key -> ETag for objects under prefix. Multipart ETags never match an
+// MD5, so those objects are always re-transferred, as is everything on a
+// backend that keeps no checksums.
+func remoteChecksums(ctx context.Context, store storage.Storage, prefix string) (map[string]string, error) {
+	objects, err := store.ListObjectsWithPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	checksums := make(map[string]string, len(objects))
+	for _, obj := range objects {
+		checksums[obj.Key] = obj.ETag
+	}
+	return checksums, nil
+}
+
+func fileMD5(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func contentTypeFor(filePath string) string {
+	if ct := mime.TypeByExtension(filepath.Ext(filePath)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/database"
+	"github.com/jackc/pgx/v5/pgtype"
+	"gopkg.in/yaml.v3"
+)
+
+func exportCommand(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	out := fs.String("out", "", "YAML file to write the snapshot to")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+	if *out == "" {
+		return errors.New("must specify --out")
+	}
+
+	cfg := config.Load()
+	exportDB, err := database.New(&cfg.Database)
+	if err != nil {
+		return fmt.Errorf("database connection failed: %w", err)
+	}
+	defer exportDB.Close()
+
+	data, err := exportSeedData(context.Background(), exportDB.Queries())
+	if err != nil {
+		return err
+	}
+
+	encoded, err := yaml.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := os.WriteFile(*out, encoded, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+
+	fmt.Printf("exported database to %s\n", *out)
+	return validateSeedData(data)
+}
+
+// reads the database back as seed data that applySeedData can replay. Rows
+// the seeder has no way to recreate are left out with a note.
+func exportSeedData(ctx context.Context, queries *db.Queries) (*SeedData, error) {
+	data := &SeedData{}
+
+	groups, err := queries.SnapshotGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export groups: %w", err)
+	}
+	for _, row := range groups {
+		data.Groups = append(data.Groups, Group{Name: row.Name, Description: row.Description.String})
+	}
+
+	items, err := queries.SnapshotItems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export items: %w", err)
+	}
+	for _, row := range items {
+		data.Items = append(data.Items, Item{
+			Name:        row.Name,
+			Type:        string(row.Type),
+			Stock:       int(row.Stock),
+			Description: row.Description.String,
+			URLs:        row.Urls,
+		})
+	}
+
+	users, err := queries.SnapshotUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export users: %w", err)
+	}
+	for _, email := range users {
+		data.Users = append(data.Users, User{Email: email})
+	}
+
+	roles, err := queries.SnapshotUserRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export user roles: %w", err)
+	}
+	for _, row := range roles {
+		data.UserRoles = append(data.UserRoles, UserRole{
+			UserEmail: row.UserEmail,
+			RoleName:  row.RoleName.String,
+			Scope:     string(row.Scope),
+			GroupName: textPtr(row.GroupName),
+		})
+	}
+
+	availability, err := queries.SnapshotAvailability(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export availability: %w", err)
+	}
+	for _, row := range availability {
+		data.Availability = append(data.Availability, Availability{
+			UserEmail:     row.UserEmail,
+			Date:          row.Date.Time.Format("2006-01-02"),
+			TimeSlotStart: formatSlotTime(row.StartTime),
+		})
+	}
+
+	borrowings, err := queries.SnapshotBorrowings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export borrowings: %w", err)
+	}
+	for _, row := range borrowings {
+		borrowing := Borrowing{
+			UserEmail:          row.UserEmail,
+			GroupName:          row.GroupName,
+			ItemName:           row.ItemName,
+			Quantity:           int(row.Quantity),
+			BorrowedAt:         timestampPtr(row.BorrowedAt),
+			DueDate:            formatSeedTime(row.DueDate.Time),
+			ReturnedAt:         timestampPtr(row.ReturnedAt),
+			BeforeCondition:    string(row.BeforeCondition),
+			BeforeConditionURL: row.BeforeConditionUrl,
+			AfterConditionURL:  textPtr(row.AfterConditionUrl),
+		}
+		if row.AfterCondition.Valid {
+			condition := string(row.AfterCondition.Condition)
+			borrowing.AfterCondition = &condition
+		}
+		data.Borrowings = append(data.Borrowings, borrowing)
+	}
+
+	requests, err := queries.SnapshotRequests(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export requests: %w", err)
+	}
+	for _, row := range requests {
+		status := string(db.RequestStatusPending)
+		if row.Status.Valid {
+			status = string(row.Status.RequestStatus)
+		}
+		// the seeder writes "fulfilled" as an approved request with fulfilled_at
+		if status == string(db.RequestStatusApproved) && row.FulfilledAt.Valid {
+			status = string(db.RequestStatusFulfilled)
+		}
+		switch status {
+		case "pending", "approved", "denied", "fulfilled":
+		default:
+			fmt.Printf("skipping %s request by %s: the seeder can't recreate it\n", status, row.UserEmail)
+			continue
+		}
+		data.Requests = append(data.Requests, Request{
+			UserEmail:       row.UserEmail,
+			GroupName:       row.GroupName,
+			ItemName:        row.ItemName,
+			Quantity:        int(row.Quantity),
+			Status:          status,
+			RequestedAt:     timestampPtr(row.RequestedAt),
+			ReviewedByEmail: textPtr(row.ReviewedByEmail),
+			ReviewedAt:      timestampPtr(row.ReviewedAt),
+			FulfilledAt:     timestampPtr(row.FulfilledAt),
+		})
+	}
+
+	bookings, err := queries.SnapshotBookings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export bookings: %w", err)
+	}
+	for _, row := range bookings {
+		switch row.Status {
+		case db.RequestStatusPendingConfirmation, db.RequestStatusConfirmed, db.RequestStatusCancelled:
+		default:
+			fmt.Printf("skipping %s booking by %s: the seeder can't recreate it\n", row.Status, row.RequesterEmail)
+			continue
+		}
+		data.Bookings = append(data.Bookings, Booking{
+			RequesterEmail:       row.RequesterEmail,
+			ManagerEmail:         row.ManagerEmail,
+			ItemName:             row.ItemName,
+			GroupName:            row.GroupName,
+			AvailabilityDate:     row.AvailabilityDate.Time.Format("2006-01-02"),
+			AvailabilityTimeSlot: formatSlotTime(row.AvailabilityTimeSlot),
+			PickupDate:           formatSeedTime(row.PickUpDate.Time),
+			PickupLocation:       row.PickUpLocation,
+			ReturnDate:           formatSeedTime(row.ReturnDate.Time),
+			ReturnLocation:       row.ReturnLocation,
+			Status:               string(row.Status),
+			ConfirmedAt:          timestampPtr(row.ConfirmedAt),
+			ConfirmedByEmail:     textPtr(row.ConfirmedByEmail),
+			CancelledAt:          timestampPtr(row.CancelledAt),
+			CancelledByEmail:     textPtr(row.CancelledByEmail),
+			CancellationReason:   textPtr(row.CancellationReason),
+		})
+	}
+
+	cart, err := queries.SnapshotCartItems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export cart items: %w", err)
+	}
+	for _, row := range cart {
+		data.CartItems = append(data.CartItems, CartItem{
+			UserEmail: row.UserEmail,
+			GroupName: row.GroupName,
+			ItemName:  row.ItemName,
+			Quantity:  int(row.Quantity),
+		})
+	}
+
+	takings, err := queries.SnapshotItemTakings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export item takings: %w", err)
+	}
+	for _, row := range takings {
+		data.ItemTakings = append(data.ItemTakings, ItemTaking{
+			UserEmail: row.UserEmail,
+			GroupName: row.GroupName,
+			ItemName:  row.ItemName,
+			Quantity:  int(row.Quantity),
+			TakenAt:   timestampPtr(row.TakenAt),
+		})
+	}
+
+	return data, nil
+}
+
+// the inverse of parseSeedTime.
+func formatSeedTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func timestampPtr(ts pgtype.Timestamp) *string {
+	if !ts.Valid {
+		return nil
+	}
+	formatted := formatSeedTime(ts.Time)
+	return &formatted
+}
+
+func textPtr(text pgtype.Text) *string {
+	if !text.Valid {
+		return nil
+	}
+	return &text.String
+}
+
+// formats a time slot start the way the seed YAML writes it, "09:00:00".
+func formatSlotTime(t pgtype.Time) string {
+	seconds := t.Microseconds / 1000000
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/database"
+	"gopkg.in/yaml.v3"
+)
+
+func generateCommand(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	opts := generateOptions{}
+	fs.IntVar(&opts.groups, "groups", 20, "Number of groups to generate")
+	fs.IntVar(&opts.users, "users", 500, "Number of users to generate")
+	fs.IntVar(&opts.items, "items", 200, "Number of items to generate")
+	fs.IntVar(&opts.borrowings, "borrowings", 2000, "Number of borrowings to generate")
+	fs.IntVar(&opts.requests, "requests", 500, "Number of requests to generate")
+	fs.Uint64Var(&opts.seed, "seed", 1, "Random seed; the same seed generates the same data")
+	out := fs.String("out", "", "Write the generated data to this YAML file instead of the database")
+	atomic := fs.Bool("atomic", true, "Seed in one transaction, rolling everything back on any error")
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+	if opts.groups < 1 || opts.users < 1 {
+		return errors.New("--groups and --users must be at least 1")
+	}
+	if opts.items < 0 || opts.borrowings < 0 || opts.requests < 0 {
+		return errors.New("counts cannot be negative")
+	}
+	if opts.items == 0 && (opts.borrowings > 0 || opts.requests > 0) {
+		return errors.New("--items must be at least 1 to generate borrowings or requests")
+	}
+
+	data := generateSeedData(opts, time.Now())
+	if err := validateSeedData(data); err != nil {
+		return fmt.Errorf("generated data is invalid: %w", err)
+	}
+
+	if *out != "" {
+		encoded, err := yaml.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to encode generated data: %w", err)
+		}
+		if err := os.WriteFile(*out, encoded, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", *out, err)
+		}
+		fmt.Printf("wrote generated data to %s\n", *out)
+		return nil
+	}
+
+	cfg := config.Load()
+	seedDB, err := database.New(&cfg.Database)
+	if err != nil {
+		return fmt.Errorf("seedDB connection failed: %w", err)
+	}
+	defer seedDB.Close()
+
+	ctx := context.Background()
+	if !*atomic {
+		return applySeedData(ctx, seedDB.Queries(), data, false)
+	}
+	return applySeedDataAtomically(ctx, seedDB, data, false)
+}
+
+type generateOptions struct {
+	groups     int
+	users      int
+	items      int
+	borrowings int
+	requests   int
+	seed       uint64
+}
+
+var (
+	firstNames = []string{
+		"Aiden", "Amara", "Ben", "Chloe", "Daniel", "Elena", "Farah", "Gabriel", "Hana", "Isaac",
+		"Jia", "Kofi", "Laila", "Mateo", "Nadia", "Omar", "Priya", "Quinn", "Rohan", "Sofia",
+		"Tariq", "Uma", "Victor", "Wen", "Ximena", "Yusuf", "Zara", "Liam", "Maya", "Noah",
+	}
+	lastNames = []string{
+		"Ahmed", "Brown", "Chen", "Dubois", "Evans", "Fernandes", "Gupta", "Hassan", "Ito", "Johnson",
+		"Kim", "Lopez", "Martin", "Nguyen", "Okafor", "Patel", "Rossi", "Singh", "Tremblay", "Wang",
+	}
+	groupSubjects = []string{
+		"Chemistry", "Physics", "Robotics", "Debate", "Photography", "Film", "Engineering", "Biology",
+		"Mathematics", "Computing", "Astronomy", "Music", "Theatre", "Chess", "Outdoors", "Design",
+	}
+	groupKinds = []string{"Club", "Society", "Association", "Collective", "Union"}
+	itemNouns  = map[string][]string{
+		"low":    {"Marker Pack", "Sticky Notes", "Batteries", "Zip Ties", "Tape Roll", "Poster Board", "Cable Ties", "Glue Sticks"},
+		"medium": {"Projector", "Extension Cord", "Folding Table", "Speaker", "Tripod", "Whiteboard", "Microphone", "Canopy Tent"},
+		"high":   {"Laptop", "DSLR Camera", "Drone", "VR Headset", "3D Printer", "Oscilloscope", "Tablet", "Mixing Desk"},
+	}
+	itemBrands = []string{"Acme", "Northwind", "Contoso", "Fabrikam", "Globex", "Initech", "Umbrella", "Vandelay"}
+)
+
+// fabricates seed data shaped like a real term's worth of activity. Dates
+// fall within the year before now, weighted towards recent weeks.
+func generateSeedData(opts generateOptions, now time.Time) *SeedData {
+	rng := rand.New(rand.NewPCG(opts.seed, opts.seed))
+	pick := func(values []string) string { return values[rng.IntN(len(values))] }
+	data := &SeedData{}
+
+	for i := range opts.groups {
+		name := fmt.Sprintf("%s %s %d", pick(groupSubjects), pick(groupKinds), i+1)
+		data.Groups = append(data.Groups, Group{
+			Name:        name,
+			Description: "Generated group for load testing",
+		})
+	}
+
+	// roughly one approver per 500 users, always at least one to review requests
+	approvers := max(1, opts.users/500)
+	var approverEmails []string
+	for i := range opts.users {
+		first, last := pick(firstNames), pick(lastNames)
+		email := fmt.Sprintf("%s.%s.%d@example.com", strings.ToLower(first), strings.ToLower(last), i+1)
+		data.Users = append(data.Users, User{Email: email})
+
+		if i < approvers {
+			approverEmails = append(approverEmails, email)
+			data.UserRoles = append(data.UserRoles, UserRole{UserEmail: email, RoleName: "approver", Scope: "global"})
+			continue
+		}
+		role := "member"
+		if rng.IntN(50) == 0 {
+			role = "group_admin"
+		}
+		group := data.Groups[rng.IntN(len(data.Groups))].Name
+		data.UserRoles = append(data.UserRoles, UserRole{UserEmail: email, RoleName: role, Scope: "group", GroupName: &group})
+	}
+
+	// half the catalogue is consumables; the rest are borrowed, a few of
+	// them valuable enough to need approval
+	var borrowable, requestable []Item
+	for i := range opts.items {
+		itemType := weighted(rng, []string{"low", "medium", "high"}, []int{50, 35, 15})
+		// guarantee something to borrow and request when those are asked for
+		if i == 0 && (opts.borrowings > 0 || opts.requests > 0) {
+			itemType = "high"
+		}
+		stock := 1 + rng.IntN(10)
+		switch itemType {
+		case "low":
+			stock = 20 + rng.IntN(180)
+		case "high":
+			stock = 1 + rng.IntN(3)
+		}
+		item := Item{
+			Name:        fmt.Sprintf("%s %s %d", pick(itemBrands), pick(itemNouns[itemType]), i+1),
+			Type:        itemType,
+			Stock:       stock,
+			Description: fmt.Sprintf("Generated %s item", itemType),
+			URLs:        []string{},
+		}
+		data.Items = append(data.Items, item)
+		if itemType != "low" {
+			borrowable = append(borrowable, item)
+		}
+		if itemType == "high" {
+			requestable = append(requestable, item)
+		}
+	}
+
+	conditions := []string{"pristine", "good", "decent", "damaged", "unusable"}
+	for range opts.borrowings {
+		role := data.UserRoles[rng.IntN(len(data.UserRoles))]
+		group := data.Groups[rng.IntN(len(data.Groups))].Name
+		if role.GroupName != nil {
+			group = *role.GroupName
+		}
+		borrowedAt := pastTime(rng, now)
+		dueDate := borrowedAt.Add(time.Duration(7+rng.IntN(15)) * 24 * time.Hour)
+		borrow := Borrowing{
+			UserEmail:          role.UserEmail,
+			GroupName:          group,
+			ItemName:           borrowable[rng.IntN(len(borrowable))].Name,
+			Quantity:           1,
+			BorrowedAt:         seedTimePtr(borrowedAt),
+			DueDate:            formatSeedTime(dueDate),
+			BeforeCondition:    weighted(rng, conditions[:3], []int{30, 55, 15}),
+			BeforeConditionURL: "https://example.com/generated/before.jpg",
+		}
+		// most past-due borrowings have come back, some late; a few current ones early
+		if (dueDate.Before(now) && rng.IntN(10) > 0) || rng.IntN(3) == 0 {
+			returnedAt := borrowedAt.Add(time.Duration(rng.Int64N(int64(dueDate.Sub(borrowedAt) + 72*time.Hour))))
+			if returnedAt.After(now) {
+				returnedAt = now
+			}
+			after := weighted(rng, conditions, []int{10, 50, 30, 8, 2})
+			afterURL := "https://example.com/generated/after.jpg"
+			borrow.ReturnedAt = seedTimePtr(returnedAt)
+			borrow.AfterCondition = &after
+			borrow.AfterConditionURL = &afterURL
+		}
+		data.Borrowings = append(data.Borrowings, borrow)
+	}
+
+	for range opts.requests {
+		role := data.UserRoles[rng.IntN(len(data.UserRoles))]
+		group := data.Groups[rng.IntN(len(data.Groups))].Name
+		if role.GroupName != nil {
+			group = *role.GroupName
+		}
+		requestedAt := pastTime(rng, now)
+		req := Request{
+			UserEmail:   role.UserEmail,
+			GroupName:   group,
+			ItemName:    requestable[rng.IntN(len(requestable))].Name,
+			Quantity:    1,
+			Status:      weighted(rng, []string{"pending", "approved", "denied", "fulfilled"}, []int{15, 25, 15, 45}),
+			RequestedAt: seedTimePtr(requestedAt),
+		}
+		if req.Status != "pending" {
+			reviewer := pick(approverEmails)
+			reviewedAt := requestedAt.Add(time.Duration(1+rng.IntN(72)) * time.Hour)
+			req.ReviewedByEmail = &reviewer
+			req.ReviewedAt = seedTimePtr(reviewedAt)
+			if req.Status == "fulfilled" {
+				req.FulfilledAt = seedTimePtr(reviewedAt.Add(time.Duration(1+rng.IntN(96)) * time.Hour))
+			}
+		}
+		data.Requests = append(data.Requests, req)
+	}
+
+	return data
+}
+
+// picks one of values with probability proportional to its weight.
+func weighted(rng *rand.Rand, values []string, weights []int) string {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	n := rng.IntN(total)
+	for i, w := range weights {
+		if n < w {
+			return values[i]
+		}
+		n -= w
+	}
+	return values[len(values)-1]
+}
+
+// a time within the last year, squared so recent days come up more often.
+func pastTime(rng *rand.Rand, now time.Time) time.Time {
+	r := rng.Float64()
+	ago := time.Duration(r * r * float64(365*24*time.Hour))
+	return now.Add(-ago).Truncate(time.Minute)
+}
+
+func seedTimePtr(t time.Time) *string {
+	formatted := formatSeedTime(t)
+	return &formatted
+}
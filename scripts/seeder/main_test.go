@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/testutil"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// a generated fixture with 10k items, the size seeding has to stay quick for
+const benchmarkItems = 10000
+
+func benchmarkSeedData(b *testing.B) *SeedData {
+	b.Helper()
+	data := generateSeedData(generateOptions{
+		groups:     20,
+		users:      500,
+		items:      benchmarkItems,
+		borrowings: 2000,
+		requests:   500,
+		seed:       1,
+	}, time.Now())
+	require.NoError(b, validateSeedData(data))
+	return data
+}
+
+// a migrated database, with the seeder's progress output silenced for the
+// length of the benchmark
+func benchmarkDatabase(b *testing.B) *testutil.TestDatabase {
+	b.Helper()
+	testDB := testutil.NewTestDatabase(b, "cv-backend-test-db-seeder")
+	testDB.RunMigrations(b)
+	b.Cleanup(testDB.Cleanup)
+
+	devNull, err := os.Open(os.DevNull)
+	require.NoError(b, err)
+	stdout := os.Stdout
+	os.Stdout = devNull
+	b.Cleanup(func() {
+		os.Stdout = stdout
+		devNull.Close()
+	})
+	return testDB
+}
+
+// runs seed in a transaction that is rolled back, so every iteration starts
+// from the same empty database
+func benchmarkInRollback(b *testing.B, testDB *testutil.TestDatabase, seed func(context.Context, *db.Queries) error) {
+	b.Helper()
+	ctx := context.Background()
+	b.ResetTimer()
+	for range b.N {
+		tx, err := testDB.Pool().Begin(ctx)
+		require.NoError(b, err)
+		require.NoError(b, seed(ctx, testDB.Queries().WithTx(tx)))
+		b.StopTimer()
+		require.NoError(b, tx.Rollback(ctx))
+		b.StartTimer()
+	}
+}
+
+func BenchmarkApplySeedData(b *testing.B) {
+	if testing.Short() {
+		b.Skip("Skipping integration benchmarks in short mode")
+	}
+
+	testDB := benchmarkDatabase(b)
+	data := benchmarkSeedData(b)
+
+	benchmarkInRollback(b, testDB, func(ctx context.Context, queries *db.Queries) error {
+		return applySeedData(ctx, queries, data, false)
+	})
+}
+
+// seeding the fixture's items and resolving the names the other sections use,
+// the way the seeder used to (an INSERT and a lookup per row) against the
+// COPY and the single batch lookup it uses now
+func BenchmarkSeedItems(b *testing.B) {
+	if testing.Short() {
+		b.Skip("Skipping integration benchmarks in short mode")
+	}
+
+	testDB := benchmarkDatabase(b)
+	data := benchmarkSeedData(b)
+
+	b.Run("row_by_row", func(b *testing.B) {
+		benchmarkInRollback(b, testDB, func(ctx context.Context, queries *db.Queries) error {
+			for _, item := range data.Items {
+				if _, err := queries.CreateItem(ctx, db.CreateItemParams{
+					Name:        item.Name,
+					Description: pgtype.Text{String: item.Description, Valid: true},
+					Type:        db.ItemType(item.Type),
+					Stock:       int32(item.Stock),
+					Urls:        item.URLs,
+				}); err != nil {
+					return err
+				}
+			}
+			for _, borrow := range data.Borrowings {
+				if _, err := queries.GetItemByName(ctx, borrow.ItemName); err != nil {
+					return err
+				}
+			}
+			for _, req := range data.Requests {
+				if _, err := queries.GetItemByName(ctx, req.ItemName); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+
+	b.Run("batched", func(b *testing.B) {
+		benchmarkInRollback(b, testDB, func(ctx context.Context, queries *db.Queries) error {
+			if err := seedItems(ctx, queries, data.Items, false); err != nil {
+				return err
+			}
+			_, err := seedItemsByName(ctx, queries, data)
+			return err
+		})
+	})
+}
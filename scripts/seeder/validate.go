@@ -0,0 +1,312 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// where a seed record was read from, for pointing validation problems at it.
+type source struct {
+	file string
+	line int
+}
+
+// notes the line of every entry under each top-level section of a file.
+// Entries are appended in the same order loadSeedData combines the sections,
+// so sources[section][i] belongs to the i-th combined record.
+func recordSources(file string, content []byte, sources map[string][]source) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if value.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, entry := range value.Content {
+			sources[key.Value] = append(sources[key.Value], source{file: file, line: entry.Line})
+		}
+	}
+	return nil
+}
+
+var (
+	validItemTypes       = []string{"low", "medium", "high"}
+	validConditions      = []string{"pristine", "good", "decent", "damaged", "unusable"}
+	validScopes          = []string{"global", "group"}
+	validRoles           = []string{"global_admin", "approver", "group_approver", "group_admin", "member"}
+	validRequestStatuses = []string{"pending", "approved", "denied", "fulfilled"}
+	validBookingStatuses = []string{"pending_confirmation", "confirmed", "cancelled"}
+)
+
+func validateSeedData(data *SeedData) error {
+	fmt.Printf("  Groups: %d\n", len(data.Groups))
+	fmt.Printf("  Items: %d\n", len(data.Items))
+	fmt.Printf("  Users: %d\n", len(data.Users))
+	fmt.Printf("  User Roles: %d\n", len(data.UserRoles))
+	fmt.Printf("  Availability: %d\n", len(data.Availability))
+	fmt.Printf("  Borrowings: %d\n", len(data.Borrowings))
+	fmt.Printf("  Requests: %d\n", len(data.Requests))
+	fmt.Printf("  Bookings: %d\n", len(data.Bookings))
+	fmt.Printf("  Cart Items: %d\n", len(data.CartItems))
+	fmt.Printf("  Item Takings: %d\n", len(data.ItemTakings))
+
+	problems := checkSeedData(data)
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		return fmt.Errorf("seed data has %d problem(s)", len(problems))
+	}
+	fmt.Println("data structure is valid")
+	return nil
+}
+
+// cross-checks every record against the rest of the seed, the same way
+// applySeedData resolves them, and returns each problem found.
+func checkSeedData(data *SeedData) []string {
+	v := &seedValidator{
+		sources: data.sources,
+		groups:  make(map[string]bool),
+		items:   make(map[string]string),
+		users:   make(map[string]bool),
+		slots:   make(map[string]bool),
+	}
+
+	for i, group := range data.Groups {
+		at := v.at("groups", i)
+		if group.Name == "" {
+			at("name is required")
+			continue
+		}
+		if v.groups[group.Name] {
+			at("group %s is defined more than once", group.Name)
+		}
+		v.groups[group.Name] = true
+	}
+
+	for i, item := range data.Items {
+		at := v.at("items", i)
+		if item.Name == "" {
+			at("name is required")
+			continue
+		}
+		if _, exists := v.items[item.Name]; exists {
+			at("item %s is defined more than once", item.Name)
+		}
+		v.items[item.Name] = item.Type
+		v.oneOf(at, "type", item.Type, validItemTypes)
+		if item.Stock < 0 {
+			at("stock cannot be negative")
+		}
+	}
+
+	for i, user := range data.Users {
+		at := v.at("users", i)
+		if user.Email == "" {
+			at("email is required")
+			continue
+		}
+		if v.users[user.Email] {
+			at("user %s is defined more than once", user.Email)
+		}
+		v.users[user.Email] = true
+	}
+
+	for i, role := range data.UserRoles {
+		at := v.at("user_roles", i)
+		v.user(at, role.UserEmail)
+		v.oneOf(at, "role_name", role.RoleName, validRoles)
+		v.oneOf(at, "scope", role.Scope, validScopes)
+		switch {
+		case role.Scope == "group" && role.GroupName == nil:
+			at("group scoped role needs group_name")
+		case role.Scope == "global" && role.GroupName != nil:
+			at("global role cannot have group_name")
+		case role.GroupName != nil:
+			v.group(at, *role.GroupName)
+		}
+	}
+
+	for i, avail := range data.Availability {
+		at := v.at("availability", i)
+		v.user(at, avail.UserEmail)
+		v.date(at, "date", avail.Date)
+		v.slot(at, "time_slot_start", avail.TimeSlotStart)
+		v.slots[fmt.Sprintf("%s_%s_%s", avail.UserEmail, avail.Date, avail.TimeSlotStart)] = true
+	}
+
+	for i, borrow := range data.Borrowings {
+		at := v.at("borrowings", i)
+		v.user(at, borrow.UserEmail)
+		v.group(at, borrow.GroupName)
+		if itemType, ok := v.item(at, borrow.ItemName); ok && itemType == "low" {
+			at("item %s is low and cannot be borrowed", borrow.ItemName)
+		}
+		v.quantity(at, borrow.Quantity)
+		v.timestamp(at, "borrowed_at", borrow.BorrowedAt)
+		v.timestamp(at, "due_date", &borrow.DueDate)
+		v.timestamp(at, "returned_at", borrow.ReturnedAt)
+		v.oneOf(at, "before_condition", borrow.BeforeCondition, validConditions)
+		if borrow.AfterCondition != nil {
+			v.oneOf(at, "after_condition", *borrow.AfterCondition, validConditions)
+		} else if borrow.ReturnedAt != nil {
+			at("returned borrowing needs after_condition")
+		}
+	}
+
+	for i, req := range data.Requests {
+		at := v.at("requests", i)
+		v.user(at, req.UserEmail)
+		v.group(at, req.GroupName)
+		if itemType, ok := v.item(at, req.ItemName); ok && itemType != "high" {
+			at("item %s is %s and does not need a request", req.ItemName, itemType)
+		}
+		v.quantity(at, req.Quantity)
+		v.oneOf(at, "status", req.Status, validRequestStatuses)
+		v.timestamp(at, "requested_at", req.RequestedAt)
+		v.timestamp(at, "reviewed_at", req.ReviewedAt)
+		v.timestamp(at, "fulfilled_at", req.FulfilledAt)
+		if req.ReviewedByEmail != nil {
+			v.user(at, *req.ReviewedByEmail)
+		} else if req.Status != "pending" && contains(validRequestStatuses, req.Status) {
+			at("%s request needs reviewed_by_email", req.Status)
+		}
+	}
+
+	for i, booking := range data.Bookings {
+		at := v.at("bookings", i)
+		v.user(at, booking.RequesterEmail)
+		v.user(at, booking.ManagerEmail)
+		v.group(at, booking.GroupName)
+		v.item(at, booking.ItemName)
+		key := fmt.Sprintf("%s_%s_%s", booking.ManagerEmail, booking.AvailabilityDate, booking.AvailabilityTimeSlot)
+		if !v.slots[key] {
+			at("%s has no availability on %s at %s", booking.ManagerEmail, booking.AvailabilityDate, booking.AvailabilityTimeSlot)
+		}
+		v.timestamp(at, "pickup_date", &booking.PickupDate)
+		v.timestamp(at, "return_date", &booking.ReturnDate)
+		v.oneOf(at, "status", booking.Status, validBookingStatuses)
+		v.timestamp(at, "confirmed_at", booking.ConfirmedAt)
+		v.timestamp(at, "cancelled_at", booking.CancelledAt)
+		if booking.ConfirmedByEmail != nil {
+			v.user(at, *booking.ConfirmedByEmail)
+		}
+		if booking.CancelledByEmail != nil {
+			v.user(at, *booking.CancelledByEmail)
+		}
+	}
+
+	for i, cart := range data.CartItems {
+		at := v.at("cart_items", i)
+		v.user(at, cart.UserEmail)
+		v.group(at, cart.GroupName)
+		v.item(at, cart.ItemName)
+		v.quantity(at, cart.Quantity)
+	}
+
+	for i, taking := range data.ItemTakings {
+		at := v.at("item_takings", i)
+		v.user(at, taking.UserEmail)
+		v.group(at, taking.GroupName)
+		v.item(at, taking.ItemName)
+		v.quantity(at, taking.Quantity)
+		v.timestamp(at, "taken_at", taking.TakenAt)
+	}
+
+	return v.problems
+}
+
+type seedValidator struct {
+	sources  map[string][]source
+	groups   map[string]bool
+	items    map[string]string // name to type
+	users    map[string]bool
+	slots    map[string]bool // key: "email_date_timeslot"
+	problems []string
+}
+
+type reportFunc func(format string, args ...any)
+
+// returns a reporter for problems with the i-th record of section, prefixed
+// with its file and line when loadSeedData recorded them.
+func (v *seedValidator) at(section string, i int) reportFunc {
+	location := fmt.Sprintf("%s[%d]", section, i)
+	if sources := v.sources[section]; i < len(sources) {
+		location = fmt.Sprintf("%s:%d (%s)", sources[i].file, sources[i].line, section)
+	}
+	return func(format string, args ...any) {
+		v.problems = append(v.problems, location+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+func (v *seedValidator) user(at reportFunc, email string) {
+	if !v.users[email] {
+		at("user %s is not defined", email)
+	}
+}
+
+func (v *seedValidator) group(at reportFunc, name string) {
+	if !v.groups[name] {
+		at("group %s is not defined", name)
+	}
+}
+
+func (v *seedValidator) item(at reportFunc, name string) (string, bool) {
+	itemType, ok := v.items[name]
+	if !ok {
+		at("item %s is not defined", name)
+	}
+	return itemType, ok
+}
+
+func (v *seedValidator) quantity(at reportFunc, quantity int) {
+	if quantity < 1 {
+		at("quantity must be at least 1")
+	}
+}
+
+func (v *seedValidator) oneOf(at reportFunc, field, value string, allowed []string) {
+	if !contains(allowed, value) {
+		at("%s %q must be one of %v", field, value, allowed)
+	}
+}
+
+// optional timestamps are passed as nil when absent.
+func (v *seedValidator) timestamp(at reportFunc, field string, value *string) {
+	if value == nil {
+		return
+	}
+	if _, err := time.Parse(time.RFC3339, *value); err != nil {
+		at("%s %q is not an ISO8601 timestamp", field, *value)
+	}
+}
+
+func (v *seedValidator) date(at reportFunc, field, value string) {
+	if _, err := time.Parse("2006-01-02", value); err != nil {
+		at("%s %q is not a YYYY-MM-DD date", field, value)
+	}
+}
+
+func (v *seedValidator) slot(at reportFunc, field, value string) {
+	if _, err := time.Parse("15:04:05", value); err != nil {
+		at("%s %q is not an HH:MM:SS time", field, value)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
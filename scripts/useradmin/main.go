@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/USSTM/cv-backend/generated/db"
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/database"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	if len(os.Args) < 2 {
+		printUsage()
+		return errors.New("command required")
+	}
+
+	var command func(context.Context, *db.Queries, []string) error
+	switch os.Args[1] {
+	case "create":
+		command = createCommand
+	case "assign-role":
+		command = assignRoleCommand
+	case "revoke-role":
+		command = revokeRoleCommand
+	case "list":
+		command = listCommand
+	case "help", "--help", "-h":
+		printUsage()
+		return nil
+	default:
+		printUsage()
+		return fmt.Errorf("unknown command: %s", os.Args[1])
+	}
+
+	if err := config.LoadSecrets(context.Background()); err != nil {
+		return err
+	}
+	cfg := config.Load()
+	adminDB, err := database.New(&cfg.Database)
+	if err != nil {
+		return fmt.Errorf("database connection failed: %w", err)
+	}
+	defer adminDB.Close()
+
+	return command(context.Background(), adminDB.Queries(), os.Args[2:])
+}
+
+func createCommand(ctx context.Context, queries *db.Queries, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	email := fs.String("email", "", "Email address of the new user")
+	name := fs.String("name", "", "Display name")
+	program := fs.String("program", "", "Program of study")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+	if *email == "" {
+		return errors.New("must specify --email")
+	}
+	normalized := strings.ToLower(strings.TrimSpace(*email))
+
+	if _, err := queries.GetUserByEmail(ctx, normalized); err == nil {
+		return fmt.Errorf("user %s already exists", normalized)
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to look up user %s: %w", normalized, err)
+	}
+
+	user, err := queries.CreateImportedUser(ctx, db.CreateImportedUserParams{
+		Email:   normalized,
+		Name:    pgtype.Text{String: *name, Valid: *name != ""},
+		Program: pgtype.Text{String: *program, Valid: *program != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create user %s: %w", normalized, err)
+	}
+	fmt.Printf("created user %s (%s)\n", user.Email, user.ID)
+	return nil
+}
+
+// flags shared by assign-role and revoke-role. A role is global unless
+// --group names the group it applies to.
+type roleFlags struct {
+	email string
+	role  string
+	group string
+}
+
+func parseRoleFlags(name string, args []string) (roleFlags, error) {
+	var f roleFlags
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&f.email, "email", "", "Email address of the user")
+	fs.StringVar(&f.role, "role", "", "Role name: global_admin, approver, group_approver, group_admin or member")
+	fs.StringVar(&f.group, "group", "", "Group name for a group-scoped role; omit for a global role")
+	if err := fs.Parse(args); err != nil {
+		return f, fmt.Errorf("failed to parse flags: %w", err)
+	}
+	if f.email == "" || f.role == "" {
+		return f, errors.New("must specify --email and --role")
+	}
+	return f, nil
+}
+
+// resolves the user and the role's scope from the flags.
+func (f roleFlags) resolve(ctx context.Context, queries *db.Queries) (db.GetUserByEmailRow, db.ScopeType, *uuid.UUID, error) {
+	user, err := queries.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(f.email)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.GetUserByEmailRow{}, "", nil, fmt.Errorf("user %s not found", f.email)
+	}
+	if err != nil {
+		return db.GetUserByEmailRow{}, "", nil, fmt.Errorf("failed to look up user %s: %w", f.email, err)
+	}
+	if f.group == "" {
+		return user, db.ScopeTypeGlobal, nil, nil
+	}
+
+	group, err := queries.GetGroupByName(ctx, f.group)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.GetUserByEmailRow{}, "", nil, fmt.Errorf("group %s not found", f.group)
+	}
+	if err != nil {
+		return db.GetUserByEmailRow{}, "", nil, fmt.Errorf("failed to look up group %s: %w", f.group, err)
+	}
+	return user, db.ScopeTypeGroup, &group.ID, nil
+}
+
+func assignRoleCommand(ctx context.Context, queries *db.Queries, args []string) error {
+	f, err := parseRoleFlags("assign-role", args)
+	if err != nil {
+		return err
+	}
+	user, scope, scopeID, err := f.resolve(ctx, queries)
+	if err != nil {
+		return err
+	}
+
+	roles, err := queries.GetUserRoles(ctx, &user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get roles for %s: %w", user.Email, err)
+	}
+	for _, role := range roles {
+		if role.RoleName.String == f.role && role.Scope == scope && sameScope(role.ScopeID, scopeID) {
+			fmt.Printf("%s already has %s\n", user.Email, describeRole(f.role, f.group))
+			return nil
+		}
+	}
+
+	if err := queries.CreateUserRole(ctx, db.CreateUserRoleParams{
+		UserID:   &user.ID,
+		RoleName: pgtype.Text{String: f.role, Valid: true},
+		Scope:    scope,
+		ScopeID:  scopeID,
+	}); err != nil {
+		return fmt.Errorf("failed to assign %s to %s: %w", f.role, user.Email, err)
+	}
+	fmt.Printf("assigned %s to %s\n", describeRole(f.role, f.group), user.Email)
+	return nil
+}
+
+func revokeRoleCommand(ctx context.Context, queries *db.Queries, args []string) error {
+	f, err := parseRoleFlags("revoke-role", args)
+	if err != nil {
+		return err
+	}
+	user, scope, scopeID, err := f.resolve(ctx, queries)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := queries.DeleteUserRole(ctx, db.DeleteUserRoleParams{
+		UserID:   &user.ID,
+		RoleName: pgtype.Text{String: f.role, Valid: true},
+		Scope:    scope,
+		ScopeID:  scopeID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke %s from %s: %w", f.role, user.Email, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%s does not have %s", user.Email, describeRole(f.role, f.group))
+	}
+	fmt.Printf("revoked %s from %s\n", describeRole(f.role, f.group), user.Email)
+	return nil
+}
+
+func listCommand(ctx context.Context, queries *db.Queries, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	filter := fs.String("email", "", "Only list users whose email contains this")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	rows, err := queries.ListUsersWithRoles(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+
+	users := 0
+	var last uuid.UUID
+	for _, row := range rows {
+		if *filter != "" && !strings.Contains(row.Email, strings.ToLower(*filter)) {
+			continue
+		}
+		if row.ID != last {
+			last = row.ID
+			users++
+			fmt.Printf("%s  %s", row.ID, row.Email)
+			if row.Name.Valid {
+				fmt.Printf("  (%s)", row.Name.String)
+			}
+			fmt.Println()
+		}
+		if row.RoleName.Valid {
+			fmt.Printf("    %s\n", describeRole(row.RoleName.String, row.GroupName.String))
+		}
+	}
+	fmt.Printf("%d user(s)\n", users)
+	return nil
+}
+
+func sameScope(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func describeRole(role, group string) string {
+	if group == "" {
+		return role + " (global)"
+	}
+	return role + " in " + group
+}
+
+func printUsage() {
+	fmt.Println("User Admin - Manage Campus Vault users and roles")
+	fmt.Println()
+	fmt.Println("USAGE:")
+	fmt.Println("  useradmin <command> [flags]")
+	fmt.Println()
+	fmt.Println("COMMANDS:")
+	fmt.Println("  create        Create a user")
+	fmt.Println("  assign-role   Give a user a global or group role")
+	fmt.Println("  revoke-role   Take a global or group role away from a user")
+	fmt.Println("  list          List users and their roles")
+	fmt.Println("  help          Show this help message")
+	fmt.Println()
+	fmt.Println("FLAGS:")
+	fmt.Println("  create        --email (required), --name, --program")
+	fmt.Println("  assign-role   --email, --role (required), --group for a group role")
+	fmt.Println("  revoke-role   --email, --role (required), --group for a group role")
+	fmt.Println("  list          --email to filter by a fragment of the address")
+	fmt.Println()
+	fmt.Println("The database comes from the usual POSTGRES_* environment variables.")
+	fmt.Println()
+	fmt.Println("EXAMPLES:")
+	fmt.Println("  useradmin create --email jane@torontomu.ca --name \"Jane Doe\"")
+	fmt.Println("  useradmin assign-role --email jane@torontomu.ca --role group_admin --group usstm")
+	fmt.Println("  useradmin assign-role --email jane@torontomu.ca --role approver")
+	fmt.Println("  useradmin revoke-role --email jane@torontomu.ca --role approver")
+	fmt.Println("  useradmin list --email torontomu")
+}
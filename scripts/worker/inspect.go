@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/queue"
+)
+
+// worker inspect <list|retry|discard>: tasks that ran out of retries wait in
+// the dead-letter queue until someone retries or discards them.
+func inspect(args []string) error {
+	if len(args) < 1 {
+		printInspectUsage()
+		return errors.New("inspect command required")
+	}
+
+	fs := flag.NewFlagSet("inspect "+args[0], flag.ExitOnError)
+	queueName := fs.String("queue", "", "Queue to inspect (list defaults to every queue)")
+	taskID := fs.String("id", "", "Task ID to retry or discard")
+	all := fs.Bool("all", false, "Retry or discard every dead task in --queue")
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	cfg := config.Load()
+	taskQueue, err := queue.NewQueue(&cfg.Redis, queue.NewRetryPolicy(&cfg.Worker))
+	if err != nil {
+		return fmt.Errorf("failed to connect to task queue: %w", err)
+	}
+	defer taskQueue.Close()
+
+	switch args[0] {
+	case "list":
+		return listDeadTasks(taskQueue, *queueName)
+	case "retry", "discard":
+		if *queueName == "" {
+			return errors.New("must specify --queue")
+		}
+		if (*taskID == "") == !*all {
+			return errors.New("must specify exactly one of --id or --all")
+		}
+		if args[0] == "retry" {
+			return retryDeadTasks(taskQueue, *queueName, *taskID)
+		}
+		return discardDeadTasks(taskQueue, *queueName, *taskID)
+	default:
+		printInspectUsage()
+		return fmt.Errorf("unknown inspect command: %s", args[0])
+	}
+}
+
+func listDeadTasks(taskQueue *queue.TaskQueue, name string) error {
+	names := []string{name}
+	if name == "" {
+		infos, err := taskQueue.ListQueues()
+		if err != nil {
+			return err
+		}
+		names = names[:0]
+		for _, info := range infos {
+			names = append(names, info.Queue)
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "QUEUE\tID\tTYPE\tRETRIED\tFAILED AT\tERROR")
+	var total int
+	for _, n := range names {
+		tasks, err := taskQueue.ListDeadTasks(n)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d/%d\t%s\t%s\n",
+				n, task.ID, task.Type, task.Retried, task.MaxRetry,
+				task.LastFailedAt.Format(time.RFC3339), task.LastErr)
+		}
+		total += len(tasks)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d dead task(s)\n", total)
+	return nil
+}
+
+// an empty taskID retries every dead task in the queue
+func retryDeadTasks(taskQueue *queue.TaskQueue, name, taskID string) error {
+	if taskID != "" {
+		if err := taskQueue.RetryDeadTask(name, taskID); err != nil {
+			return fmt.Errorf("failed to retry task %s: %w", taskID, err)
+		}
+		fmt.Printf("Task %s moved back to %s\n", taskID, name)
+		return nil
+	}
+
+	n, err := taskQueue.RetryAllDeadTasks(name)
+	if err != nil {
+		return fmt.Errorf("failed to retry dead tasks: %w", err)
+	}
+	fmt.Printf("%d task(s) moved back to %s\n", n, name)
+	return nil
+}
+
+// an empty taskID discards every dead task in the queue
+func discardDeadTasks(taskQueue *queue.TaskQueue, name, taskID string) error {
+	if taskID != "" {
+		if err := taskQueue.DiscardDeadTask(name, taskID); err != nil {
+			return fmt.Errorf("failed to discard task %s: %w", taskID, err)
+		}
+		fmt.Printf("Task %s discarded\n", taskID)
+		return nil
+	}
+
+	n, err := taskQueue.DrainQueue(name, queue.StateArchived)
+	if err != nil {
+		return fmt.Errorf("failed to discard dead tasks: %w", err)
+	}
+	fmt.Printf("%d task(s) discarded from %s\n", n, name)
+	return nil
+}
+
+func printInspectUsage() {
+	fmt.Fprintln(os.Stderr, `Usage: worker inspect <command> [flags]
+
+Commands:
+  list     [--queue <name>]                  List dead tasks
+  retry    --queue <name> (--id <id> | --all)  Move dead tasks back to pending
+  discard  --queue <name> (--id <id> | --all)  Delete dead tasks`)
+}
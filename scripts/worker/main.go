@@ -2,34 +2,173 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
-	"github.com/USSTM/cv-backend/internal/aws"
+	"github.com/USSTM/cv-backend/internal/calendar"
+	"github.com/USSTM/cv-backend/internal/campaigns"
+	"github.com/USSTM/cv-backend/internal/chat"
+	"github.com/USSTM/cv-backend/internal/conditionphotos"
 	"github.com/USSTM/cv-backend/internal/config"
+	"github.com/USSTM/cv-backend/internal/database"
+	"github.com/USSTM/cv-backend/internal/digest"
+	"github.com/USSTM/cv-backend/internal/housekeeping"
 	"github.com/USSTM/cv-backend/internal/logging"
+	"github.com/USSTM/cv-backend/internal/metrics"
+	"github.com/USSTM/cv-backend/internal/notifications"
+	"github.com/USSTM/cv-backend/internal/orphans"
+	"github.com/USSTM/cv-backend/internal/overdue"
+	"github.com/USSTM/cv-backend/internal/push"
 	"github.com/USSTM/cv-backend/internal/queue"
+	"github.com/USSTM/cv-backend/internal/recyclebin"
+	"github.com/USSTM/cv-backend/internal/reports"
+	"github.com/USSTM/cv-backend/internal/sla"
+	"github.com/USSTM/cv-backend/internal/sms"
+	"github.com/USSTM/cv-backend/internal/storage"
+	"github.com/USSTM/cv-backend/internal/waitlist"
+	"github.com/USSTM/cv-backend/internal/webhooks"
+	"github.com/USSTM/cv-backend/templates"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "inspect" {
+		if err := inspect(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := config.LoadSecrets(context.Background()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 
 	if err := logging.Init(&cfg.Logging); err != nil {
 		logging.Error("Failed to initialize logger: %v", err)
 	}
 
-	emailSvc, err := aws.NewEmailService(cfg.AWS)
+	emailSender, err := notifications.NewEmailSender(cfg.Email, cfg.AWS)
+	if err != nil {
+		logging.Error("Failed to set up email provider", "provider", cfg.Email.Provider, "error", err)
+		os.Exit(1)
+	}
+
+	if ses, ok := emailSender.(*notifications.SESSender); ok {
+		logging.Info("Verifying sender identity", "email", ses.Sender())
+		if _, err := ses.VerifyEmailIdentity(context.Background()); err != nil {
+			logging.Error("Failed to verify email identity", "error", err)
+		}
+	}
+
+	dbConn, err := database.New(&cfg.Database)
 	if err != nil {
-		logging.Error("Failed to initialize email service: %v", err)
+		logging.Error("Failed to connect to database: %v", err)
+		os.Exit(1)
 	}
+	defer dbConn.Close()
 
-	logging.Info("Verifying sender identity", "email", emailSvc.Sender())
-	if _, err := emailSvc.VerifyEmailIdentity(context.Background()); err != nil {
-		logging.Error("Failed to verify email identity: %v", err)
+	objectStore, err := storage.New(cfg.Storage, cfg.AWS)
+	if err != nil {
+		logging.Error("Failed to initialize object storage", "backend", cfg.Storage.Backend, "error", err)
+		os.Exit(1)
 	}
 
-	worker := queue.NewWorker(&cfg.Redis, emailSvc)
+	calendarLocation, err := time.LoadLocation(cfg.Calendar.Timezone)
+	if err != nil {
+		logging.Error("Invalid calendar timezone", "timezone", cfg.Calendar.Timezone, "error", err)
+		os.Exit(1)
+	}
+
+	// report notifications are dispatched through the task queue like the API's
+	taskQueue, err := queue.NewQueue(&cfg.Redis, queue.NewRetryPolicy(&cfg.Worker))
+	if err != nil {
+		logging.Error("Failed to connect to task queue", "error", err)
+		os.Exit(1)
+	}
+	defer taskQueue.Close()
+
+	emailTemplates, err := notifications.LoadTemplates(templates.Email())
+	if err != nil {
+		logging.Error("Failed to load email templates", "error", err)
+		os.Exit(1)
+	}
+
+	chatMessages, err := notifications.NewChat(cfg.Chat, templates.Chat())
+	if err != nil {
+		logging.Error("Failed to set up chat messages", "provider", cfg.Chat.Provider, "error", err)
+		os.Exit(1)
+	}
+
+	dispatcher := notifications.NewNotificationDispatcher(
+		notifications.NewNotificationService(dbConn.Pool(), dbConn.Queries()),
+		taskQueue, emailTemplates, notifications.NewEmailLookupFunc(dbConn.Queries()),
+		notifications.NewRuleLookupFunc(dbConn.Queries()),
+		notifications.NewSandboxLookupFunc(dbConn.Queries()),
+		chatMessages)
+
+	reportGenerator := reports.NewGenerator(dbConn.Queries(), objectStore, dispatcher)
+
+	smsSender, err := sms.New(cfg.SMS, cfg.AWS)
+	if err != nil {
+		logging.Error("Failed to set up SMS provider", "provider", cfg.SMS.Provider, "error", err)
+		os.Exit(1)
+	}
+
+	pushSender, err := push.New(cfg.Push)
+	if err != nil {
+		logging.Error("Failed to set up push provider", "provider", cfg.Push.Provider, "error", err)
+		os.Exit(1)
+	}
+
+	chatSender, err := chat.New(cfg.Chat)
+	if err != nil {
+		logging.Error("Failed to set up chat provider", "provider", cfg.Chat.Provider, "error", err)
+		os.Exit(1)
+	}
+
+	// addresses that bounced or complained are dropped rather than sent to
+	worker := queue.NewWorker(&cfg.Redis, notifications.NewSuppressingSender(emailSender, dbConn.Queries()), smsSender, pushSender, chatSender,
+		recyclebin.NewPurger(dbConn.Pool(), dbConn.Queries(), objectStore),
+		calendar.NewSyncer(dbConn.Queries(), cfg.Calendar.FetchTimeout, calendarLocation),
+		reportGenerator,
+		campaigns.NewRunner(dbConn.Queries(), dispatcher, reportGenerator),
+		sla.NewChecker(dbConn.Queries(), dispatcher, cfg.SLA.EscalateAfter),
+		waitlist.NewNotifier(dbConn.Queries(), dispatcher),
+		overdue.NewChecker(dbConn.Queries(), dispatcher, webhooks.NewPublisher(dbConn.Queries(), taskQueue), overdue.Schedule{
+			DaysBefore: cfg.Overdue.ReminderDaysBefore,
+			RepeatDays: cfg.Overdue.ReminderRepeatDays,
+		}),
+		housekeeping.NewHousekeeper(dbConn.Pool(), dbConn.Queries(), dispatcher, cfg.Booking.ConfirmationWindow, cfg.Availability.RetentionDays),
+		digest.NewSender(dbConn.Queries(), dispatcher, cfg.Digest.LowStockThreshold),
+		conditionphotos.NewProcessor(dbConn.Queries(), objectStore),
+		webhooks.NewDeliverer(dbConn.Queries()),
+		orphans.NewSweeper(dbConn.Queries(), objectStore, cfg.Storage.OrphanMinAgeDays, cfg.Storage.OrphanDryRun),
+		queue.NewSchedule(cfg, calendarLocation),
+		queue.NewRetryPolicy(&cfg.Worker))
+
+	metrics.RegisterPool(dbConn.Pool())
+	metrics.RegisterQueues(taskQueue)
+	if cfg.Metrics.WorkerPort != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics.Handler(cfg.Metrics.Token))
+		go func() {
+			addr := fmt.Sprintf("0.0.0.0:%s", cfg.Metrics.WorkerPort)
+			logging.Info("Serving worker metrics", "address", addr)
+			if err := http.ListenAndServe(addr, mux); err != nil {
+				logging.Error("Worker metrics server failed", "error", err)
+			}
+		}()
+	}
 
 	logging.Info("Starting queue worker...")
 	if err := worker.Start(); err != nil {
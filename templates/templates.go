@@ -0,0 +1,32 @@
+// Package templates embeds the email and chat templates so the binaries don't
+// depend on the working directory they are started from.
+package templates
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed email chat
+var files embed.FS
+
+// templates/email: one file per notification, the shared wrappers under
+// layouts/, and the sample data emailer --preview renders them with.
+func Email() fs.FS {
+	sub, err := fs.Sub(files, "email")
+	if err != nil {
+		// only possible if the embed directive above stops matching
+		panic(err)
+	}
+	return sub
+}
+
+// templates/chat: one file per chat message, each defining a template named
+// after the file.
+func Chat() fs.FS {
+	sub, err := fs.Sub(files, "chat")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}